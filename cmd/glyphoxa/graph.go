@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/MrWong99/glyphoxa/internal/config"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/memory/postgres"
+)
+
+// graphUsage is printed for an unknown or missing graph subcommand.
+const graphUsage = `usage: glyphoxa graph <command> [flags]
+
+commands:
+  export    write the knowledge graph as Graphviz DOT or GraphML`
+
+// runGraph implements the "glyphoxa graph" subcommands and returns the
+// process exit code.
+func runGraph(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, graphUsage)
+		return 2
+	}
+	switch args[0] {
+	case "export":
+		return runGraphExport(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "glyphoxa graph: unknown command %q\n%s\n", args[0], graphUsage)
+		return 2
+	}
+}
+
+// runGraphExport writes the knowledge graph of the configured memory store,
+// optionally scoped by entity type or campaign, to stdout or a file.
+func runGraphExport(args []string) int {
+	fs := flag.NewFlagSet("glyphoxa graph export", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "path to the YAML configuration file")
+	format := fs.String("format", memory.GraphFormatDOT, "output format: dot or graphml")
+	types := fs.String("types", "", "comma-separated entity types to export (default: all)")
+	campaign := fs.String("campaign", "", "only export entities tagged with this campaign")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: %v\n", err)
+		return 1
+	}
+	if cfg.Memory.PostgresDSN == "" {
+		fmt.Fprintln(os.Stderr, "glyphoxa: memory.postgres_dsn is required to export the knowledge graph")
+		return 1
+	}
+
+	var opts []memory.ExportOpt
+	if *types != "" {
+		for t := range strings.SplitSeq(*types, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				opts = append(opts, memory.ExportEntityTypes(t))
+			}
+		}
+	}
+	if *campaign != "" {
+		opts = append(opts, memory.ExportCampaign(*campaign))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dims := cfg.Memory.EmbeddingDimensions
+	if dims == 0 {
+		dims = 1536 // same default as the application
+	}
+	store, err := postgres.NewStore(ctx, cfg.Memory.PostgresDSN, dims,
+		postgres.WithDefaultLanguage(cfg.Memory.Language),
+		postgres.WithDistanceMetric(cfg.Memory.DistanceMetric),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	data, err := store.ExportGraph(ctx, *format, opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: %v\n", err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "glyphoxa: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if _, err := w.Write(data); err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: write graph: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"flag"
@@ -9,6 +10,7 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
@@ -18,22 +20,35 @@ import (
 	"github.com/MrWong99/glyphoxa/internal/config"
 	discordbot "github.com/MrWong99/glyphoxa/internal/discord"
 	"github.com/MrWong99/glyphoxa/internal/discord/commands"
+	"github.com/MrWong99/glyphoxa/internal/engine"
 	"github.com/MrWong99/glyphoxa/internal/entity"
 	"github.com/MrWong99/glyphoxa/internal/feedback"
+	"github.com/MrWong99/glyphoxa/internal/resilience"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
 	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
 	ollamaembed "github.com/MrWong99/glyphoxa/pkg/provider/embeddings/ollama"
 	oaembed "github.com/MrWong99/glyphoxa/pkg/provider/embeddings/openai"
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm/anthropic"
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm/anyllm"
 	"github.com/MrWong99/glyphoxa/pkg/provider/s2s"
 	geminilive "github.com/MrWong99/glyphoxa/pkg/provider/s2s/gemini"
 	oais2s "github.com/MrWong99/glyphoxa/pkg/provider/s2s/openai"
 	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	azurestt "github.com/MrWong99/glyphoxa/pkg/provider/stt/azure"
 	"github.com/MrWong99/glyphoxa/pkg/provider/stt/deepgram"
 	"github.com/MrWong99/glyphoxa/pkg/provider/stt/whisper"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	azuretts "github.com/MrWong99/glyphoxa/pkg/provider/tts/azure"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/cartesia"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts/coqui"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts/elevenlabs"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/onnx"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/piper"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/polly"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad/energy"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad/silero"
 )
 
 func main() {
@@ -41,6 +56,17 @@ func main() {
 }
 
 func run() int {
+	// ── Subcommands ───────────────────────────────────────────────────────────
+	if len(os.Args) > 1 && os.Args[1] == "graph" {
+		return runGraph(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "memory" {
+		return runMemory(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "transcribe" {
+		return runTranscribe(os.Args[2:])
+	}
+
 	// ── CLI flags ──────────────────────────────────────────────────────────────
 	configPath := flag.String("config", "config.yaml", "path to the YAML configuration file")
 	flag.Parse()
@@ -57,8 +83,10 @@ func run() int {
 	}
 
 	// ── Logger ────────────────────────────────────────────────────────────────
-	logger := newLogger(cfg.Server.LogLevel)
-	slog.SetDefault(logger)
+	// The level is a variable so config reloads can change it.
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(slogLevel(cfg.Server.LogLevel))
+	slog.SetDefault(newLogger(logLevel))
 
 	slog.Info("glyphoxa starting",
 		"config", *configPath,
@@ -73,7 +101,7 @@ func run() int {
 	// ── Instantiate providers ─────────────────────────────────────────────────
 	providers, err := buildProviders(cfg, reg)
 	if err != nil {
-		slog.Error("failed to build providers", "err", err)
+		logProviderErrors(err)
 		return 1
 	}
 
@@ -110,10 +138,11 @@ func run() int {
 	}
 
 	// ── Discord commands ─────────────────────────────────────────────────────
+	var sessionMgr *app.SessionManager
 	if bot != nil {
 		perms := bot.Permissions()
 
-		sessionMgr := app.NewSessionManager(app.SessionManagerConfig{
+		sessionMgr = app.NewSessionManager(app.SessionManagerConfig{
 			Platform:     bot.Platform(),
 			Config:       cfg,
 			Providers:    providers,
@@ -121,6 +150,12 @@ func run() int {
 			Graph:        application.KnowledgeGraph(),
 			MCPHost:      application.MCPHost(),
 			Entities:     application.EntityStore(),
+			NPCStates:    application.NPCStates(),
+			Transcripts:  application.TranscriptMerger(),
+			TurnBus:      application.TurnBus(),
+			Ready:        application.Ready(),
+			Engines:      application.Engines(),
+			IDs:          application.IDGenerator(),
 		})
 
 		// Session and recap register themselves in the constructor.
@@ -133,9 +168,13 @@ func run() int {
 		})
 
 		// Remaining commands need explicit Register() calls.
-		npcCmds := commands.NewNPCCommands(perms, sessionMgr.Orchestrator)
+		npcCmds := commands.NewNPCCommands(perms, sessionMgr.Orchestrator, application.KnowledgeGraph)
+		npcCmds.SetIDGenerator(application.IDGenerator())
 		npcCmds.Register(bot.Router())
 
+		graphCmds := commands.NewGraphCommands(perms, application.KnowledgeGraph)
+		graphCmds.Register(bot.Router())
+
 		entityCmds := commands.NewEntityCommands(perms, func() entity.Store { return application.EntityStore() })
 		entityCmds.Register(bot.Router())
 
@@ -155,6 +194,19 @@ func run() int {
 		feedbackCmds.Register(bot.Router())
 	}
 
+	// ── Config hot-reload ─────────────────────────────────────────────────────
+	watcher, err := config.NewWatcher(*configPath, nil)
+	if err != nil {
+		slog.Warn("config hot-reload disabled", "err", err)
+	} else {
+		defer watcher.Stop()
+		go watchConfig(ctx, watcher, cfg, &configReloader{
+			app:        application,
+			sessionMgr: sessionMgr,
+			logLevel:   logLevel,
+		})
+	}
+
 	// Start the Discord bot interaction loop in a separate goroutine.
 	if bot != nil {
 		go func() {
@@ -199,10 +251,10 @@ func run() int {
 // provider from the real implementation packages.
 func registerBuiltinProviders(reg *config.Registry) {
 	// ── LLM ───────────────────────────────────────────────────────────────────
-	// openai, anthropic, gemini, deepseek, mistral, groq, llamacpp, llamafile
-	// all share the same pattern: optional APIKey + optional BaseURL.
+	// openai, gemini, deepseek, mistral, groq, llamacpp, llamafile all share
+	// the same pattern: optional APIKey + optional BaseURL.
 	for _, providerName := range []string{
-		"openai", "anthropic", "gemini",
+		"openai", "gemini",
 		"deepseek", "mistral", "groq", "llamacpp", "llamafile",
 	} {
 		reg.RegisterLLM(providerName, func(entry config.ProviderEntry) (llm.Provider, error) {
@@ -221,6 +273,25 @@ func registerBuiltinProviders(reg *config.Registry) {
 		})
 	}
 
+	// anthropic uses the native Messages API so thinking blocks and tool
+	// results round-trip intact.
+	reg.RegisterLLM("anthropic", func(entry config.ProviderEntry) (llm.Provider, error) {
+		var opts []anthropic.Option
+		if entry.APIKey != "" {
+			opts = append(opts, anthropic.WithAPIKey(entry.APIKey))
+		}
+		if entry.BaseURL != "" {
+			opts = append(opts, anthropic.WithBaseURL(entry.BaseURL))
+		}
+		if v, ok := optInt(entry.Options, "thinking_budget"); ok {
+			opts = append(opts, anthropic.WithThinkingBudget(v))
+		}
+		if v, ok := optInt(entry.Options, "max_tokens"); ok {
+			opts = append(opts, anthropic.WithMaxTokens(v))
+		}
+		return anthropic.New(entry.Model, opts...)
+	})
+
 	// ollama is a local server; it uses BaseURL for the address, not an API key.
 	reg.RegisterLLM("ollama", func(entry config.ProviderEntry) (llm.Provider, error) {
 		var opts []anyllmlib.Option
@@ -244,6 +315,9 @@ func registerBuiltinProviders(reg *config.Registry) {
 		if lang := optString(entry.Options, "language"); lang != "" {
 			opts = append(opts, deepgram.WithLanguage(lang))
 		}
+		if n, ok := optInt(entry.Options, "max_reconnect_attempts"); ok {
+			opts = append(opts, deepgram.WithMaxReconnectAttempts(n))
+		}
 		return deepgram.New(entry.APIKey, opts...)
 	})
 
@@ -270,6 +344,23 @@ func registerBuiltinProviders(reg *config.Registry) {
 		return whisper.NewNative(modelPath, opts...)
 	})
 
+	reg.RegisterSTT("azure", func(entry config.ProviderEntry) (stt.Provider, error) {
+		var opts []azurestt.Option
+		if entry.BaseURL != "" {
+			opts = append(opts, azurestt.WithEndpoint(entry.BaseURL))
+		}
+		if region := optString(entry.Options, "region"); region != "" {
+			opts = append(opts, azurestt.WithRegion(region))
+		}
+		if lang := optString(entry.Options, "language"); lang != "" {
+			opts = append(opts, azurestt.WithLanguage(lang))
+		}
+		if entry.Model != "" {
+			opts = append(opts, azurestt.WithModel(entry.Model))
+		}
+		return azurestt.New(entry.APIKey, opts...)
+	})
+
 	// ── TTS ───────────────────────────────────────────────────────────────────
 
 	reg.RegisterTTS("elevenlabs", func(entry config.ProviderEntry) (tts.Provider, error) {
@@ -280,6 +371,22 @@ func registerBuiltinProviders(reg *config.Registry) {
 		if outputFmt := optString(entry.Options, "output_format"); outputFmt != "" {
 			opts = append(opts, elevenlabs.WithOutputFormat(outputFmt))
 		}
+		stability, hasStability := optFloat(entry.Options, "stability")
+		similarity, hasSimilarity := optFloat(entry.Options, "similarity_boost")
+		style, hasStyle := optFloat(entry.Options, "style")
+		speakerBoost, hasSpeakerBoost := optBool(entry.Options, "use_speaker_boost")
+		if hasStability || hasSimilarity || hasStyle || hasSpeakerBoost {
+			if !hasStability {
+				stability = 0.5
+			}
+			if !hasSimilarity {
+				similarity = 0.75
+			}
+			if !hasSpeakerBoost {
+				speakerBoost = true
+			}
+			opts = append(opts, elevenlabs.WithVoiceSettings(stability, similarity, style, speakerBoost))
+		}
 		return elevenlabs.New(entry.APIKey, opts...)
 	})
 
@@ -291,9 +398,185 @@ func registerBuiltinProviders(reg *config.Registry) {
 		if mode := optString(entry.Options, "api_mode"); mode != "" {
 			opts = append(opts, coqui.WithAPIMode(coqui.APIMode(mode)))
 		}
+		if n, ok := optInt(entry.Options, "concurrency"); ok {
+			opts = append(opts, coqui.WithConcurrency(n))
+		}
+		if n, ok := optInt(entry.Options, "max_text_length"); ok {
+			opts = append(opts, coqui.WithMaxTextLength(n))
+		}
+		if flush, ok := optBool(entry.Options, "flush_first_sentence"); ok {
+			opts = append(opts, coqui.WithFlushFirstSentence(flush))
+		}
+		if ms, ok := optInt(entry.Options, "target_latency_ms"); ok {
+			lo, _ := optInt(entry.Options, "min_concurrency")
+			hi, _ := optInt(entry.Options, "max_concurrency")
+			opts = append(opts, coqui.WithAdaptiveConcurrency(lo, hi, time.Duration(ms)*time.Millisecond))
+		}
+		if n, ok := optInt(entry.Options, "max_retries"); ok {
+			opts = append(opts, coqui.WithMaxRetries(n))
+		}
+		if ms, ok := optInt(entry.Options, "retry_backoff_ms"); ok {
+			opts = append(opts, coqui.WithRetryBackoff(time.Duration(ms)*time.Millisecond))
+		}
+		if rate, ok := optInt(entry.Options, "output_sample_rate"); ok {
+			opts = append(opts, coqui.WithOutputSampleRate(rate))
+		}
+		if n, ok := optInt(entry.Options, "output_channels"); ok {
+			opts = append(opts, coqui.WithOutputChannels(n))
+		}
+		opts = append(opts, coqui.WithErrorHandler(func(sentence string, err error) {
+			slog.Error("coqui: sentence synthesis failed", "sentence", sentence, "err", err)
+		}))
 		return coqui.New(entry.BaseURL, opts...)
 	})
 
+	// onnx runs a Kokoro, Piper (vits) or other registered ONNX TTS model
+	// in-process; model is the path of the .onnx file.
+	reg.RegisterTTS("onnx", func(entry config.ProviderEntry) (tts.Provider, error) {
+		var opts []onnx.Option
+		if arch := optString(entry.Options, "architecture"); arch != "" {
+			opts = append(opts, onnx.WithArchitecture(arch))
+		}
+		if path := optString(entry.Options, "config_path"); path != "" {
+			opts = append(opts, onnx.WithConfig(path))
+		}
+		if path := optString(entry.Options, "voices_path"); path != "" {
+			opts = append(opts, onnx.WithVoices(path))
+		}
+		if voice := optString(entry.Options, "voice"); voice != "" {
+			opts = append(opts, onnx.WithVoice(voice))
+		}
+		if id, ok := optInt(entry.Options, "speaker"); ok {
+			opts = append(opts, onnx.WithSpeaker(id))
+		}
+		if lang := optString(entry.Options, "language"); lang != "" {
+			opts = append(opts, onnx.WithLanguage(lang))
+		}
+		if bin := optString(entry.Options, "espeak_binary"); bin != "" {
+			opts = append(opts, onnx.WithPhonemizer(onnx.Espeak{Binary: bin}))
+		}
+		if path := optString(entry.Options, "library_path"); path != "" {
+			opts = append(opts, onnx.WithLibraryPath(path))
+		}
+		if rate, ok := optInt(entry.Options, "output_sample_rate"); ok {
+			opts = append(opts, onnx.WithOutputSampleRate(rate))
+		}
+		if n, ok := optInt(entry.Options, "concurrency"); ok {
+			opts = append(opts, onnx.WithConcurrency(n))
+		}
+		if n, ok := optInt(entry.Options, "max_text_length"); ok {
+			opts = append(opts, onnx.WithMaxTextLength(n))
+		}
+		return onnx.New(cmp.Or(entry.Model, optString(entry.Options, "model_path")), opts...)
+	})
+
+	// piper talks to a Piper HTTP server when base_url is set and otherwise
+	// runs the piper executable on a local model.
+	reg.RegisterTTS("piper", func(entry config.ProviderEntry) (tts.Provider, error) {
+		var opts []piper.Option
+		source := entry.BaseURL
+		if source == "" {
+			source = cmp.Or(optString(entry.Options, "model_path"), entry.Model)
+		} else if entry.Model != "" {
+			opts = append(opts, piper.WithModel(entry.Model))
+		}
+		if path := optString(entry.Options, "config_path"); path != "" {
+			opts = append(opts, piper.WithConfig(path))
+		}
+		if bin := optString(entry.Options, "binary"); bin != "" {
+			opts = append(opts, piper.WithBinary(bin))
+		}
+		if lang := optString(entry.Options, "language"); lang != "" {
+			opts = append(opts, piper.WithLanguage(lang))
+		}
+		if rate, ok := optInt(entry.Options, "output_sample_rate"); ok {
+			opts = append(opts, piper.WithOutputSampleRate(rate))
+		}
+		if n, ok := optInt(entry.Options, "concurrency"); ok {
+			opts = append(opts, piper.WithConcurrency(n))
+		}
+		if n, ok := optInt(entry.Options, "max_text_length"); ok {
+			opts = append(opts, piper.WithMaxTextLength(n))
+		}
+		return piper.New(source, opts...)
+	})
+
+	// polly authenticates through the default AWS credential chain, not api_key.
+	reg.RegisterTTS("polly", func(entry config.ProviderEntry) (tts.Provider, error) {
+		var opts []polly.Option
+		if entry.BaseURL != "" {
+			opts = append(opts, polly.WithEndpoint(entry.BaseURL))
+		}
+		if entry.Model != "" {
+			opts = append(opts, polly.WithVoiceEngine(entry.Model))
+		}
+		if region := optString(entry.Options, "region"); region != "" {
+			opts = append(opts, polly.WithRegion(region))
+		}
+		if rate, ok := optInt(entry.Options, "sample_rate"); ok {
+			opts = append(opts, polly.WithSampleRate(rate))
+		}
+		if lang := optString(entry.Options, "language_code"); lang != "" {
+			opts = append(opts, polly.WithLanguageCode(lang))
+		}
+		if n, ok := optInt(entry.Options, "concurrency"); ok {
+			opts = append(opts, polly.WithConcurrency(n))
+		}
+		if n, ok := optInt(entry.Options, "max_text_length"); ok {
+			opts = append(opts, polly.WithMaxTextLength(n))
+		}
+		return polly.New(opts...)
+	})
+
+	reg.RegisterTTS("azure", func(entry config.ProviderEntry) (tts.Provider, error) {
+		var opts []azuretts.Option
+		if entry.BaseURL != "" {
+			opts = append(opts, azuretts.WithEndpoint(entry.BaseURL))
+		}
+		if region := optString(entry.Options, "region"); region != "" {
+			opts = append(opts, azuretts.WithRegion(region))
+		}
+		if outputFmt := optString(entry.Options, "output_format"); outputFmt != "" {
+			opts = append(opts, azuretts.WithOutputFormat(outputFmt))
+		}
+		if lang := optString(entry.Options, "language"); lang != "" {
+			opts = append(opts, azuretts.WithLanguage(lang))
+		}
+		if n, ok := optInt(entry.Options, "concurrency"); ok {
+			opts = append(opts, azuretts.WithConcurrency(n))
+		}
+		if n, ok := optInt(entry.Options, "max_text_length"); ok {
+			opts = append(opts, azuretts.WithMaxTextLength(n))
+		}
+		return azuretts.New(entry.APIKey, opts...)
+	})
+
+	reg.RegisterTTS("cartesia", func(entry config.ProviderEntry) (tts.Provider, error) {
+		var opts []cartesia.Option
+		if entry.Model != "" {
+			opts = append(opts, cartesia.WithModel(entry.Model))
+		}
+		if entry.BaseURL != "" {
+			opts = append(opts, cartesia.WithBaseURL(entry.BaseURL))
+		}
+		if id := optString(entry.Options, "voice_id"); id != "" {
+			opts = append(opts, cartesia.WithVoiceID(id))
+		}
+		if rate, ok := optInt(entry.Options, "sample_rate"); ok {
+			opts = append(opts, cartesia.WithSampleRate(rate))
+		}
+		if lang := optString(entry.Options, "language"); lang != "" {
+			opts = append(opts, cartesia.WithLanguage(lang))
+		}
+		if n, ok := optInt(entry.Options, "concurrency"); ok {
+			opts = append(opts, cartesia.WithConcurrency(n))
+		}
+		if n, ok := optInt(entry.Options, "max_text_length"); ok {
+			opts = append(opts, cartesia.WithMaxTextLength(n))
+		}
+		return cartesia.New(entry.APIKey, opts...)
+	})
+
 	// ── Embeddings ────────────────────────────────────────────────────────────
 
 	reg.RegisterEmbeddings("openai", func(entry config.ProviderEntry) (embeddings.Provider, error) {
@@ -301,11 +584,12 @@ func registerBuiltinProviders(reg *config.Registry) {
 		if entry.BaseURL != "" {
 			opts = append(opts, oaembed.WithBaseURL(entry.BaseURL))
 		}
+		opts = append(opts, oaembed.WithBatching(embeddingBatchOptions(entry.Options)...))
 		return oaembed.New(entry.APIKey, entry.Model, opts...)
 	})
 
 	reg.RegisterEmbeddings("ollama", func(entry config.ProviderEntry) (embeddings.Provider, error) {
-		return ollamaembed.New(entry.BaseURL, entry.Model)
+		return ollamaembed.New(entry.BaseURL, entry.Model, ollamaembed.WithBatching(embeddingBatchOptions(entry.Options)...))
 	})
 
 	// ── S2S ───────────────────────────────────────────────────────────────────
@@ -318,6 +602,9 @@ func registerBuiltinProviders(reg *config.Registry) {
 		if entry.BaseURL != "" {
 			opts = append(opts, oais2s.WithBaseURL(entry.BaseURL))
 		}
+		if ms, ok := optInt(entry.Options, "audio_frame_ms"); ok {
+			opts = append(opts, oais2s.WithAudioFrameDuration(time.Duration(ms)*time.Millisecond))
+		}
 		return oais2s.New(entry.APIKey, opts...), nil
 	})
 
@@ -329,9 +616,65 @@ func registerBuiltinProviders(reg *config.Registry) {
 		if entry.BaseURL != "" {
 			opts = append(opts, geminilive.WithBaseURL(entry.BaseURL))
 		}
+		if modalities := optStringSlice(entry.Options, "response_modalities"); len(modalities) > 0 {
+			opts = append(opts, geminilive.WithResponseModalities(modalities))
+		}
+		if ms, ok := optInt(entry.Options, "audio_frame_ms"); ok {
+			opts = append(opts, geminilive.WithAudioFrameDuration(time.Duration(ms)*time.Millisecond))
+		}
+		if v, ok := optBool(entry.Options, "input_transcription"); ok {
+			opts = append(opts, geminilive.WithInputTranscription(v))
+		}
+		if v, ok := optBool(entry.Options, "output_transcription"); ok {
+			opts = append(opts, geminilive.WithOutputTranscription(v))
+		}
+		if v, ok := optBool(entry.Options, "affective_dialog"); ok {
+			opts = append(opts, geminilive.WithAffectiveDialog(v))
+		}
+		if v, ok := optBool(entry.Options, "proactive_audio"); ok {
+			opts = append(opts, geminilive.WithProactiveAudio(v))
+		}
 		return geminilive.New(entry.APIKey, opts...), nil
 	})
 
+	// ── VAD ───────────────────────────────────────────────────────────────────
+
+	// silero runs the Silero VAD ONNX model in-process; model is the path of
+	// silero_vad.onnx. Speech thresholds come from the top-level vad section,
+	// which every session is opened with.
+	reg.RegisterVAD("silero", func(entry config.ProviderEntry) (vad.Engine, error) {
+		var opts []silero.Option
+		if ms, ok := optInt(entry.Options, "min_silence_ms"); ok {
+			opts = append(opts, silero.WithMinSilence(time.Duration(ms)*time.Millisecond))
+		}
+		if rate, ok := optInt(entry.Options, "sample_rate"); ok {
+			opts = append(opts, silero.WithSampleRate(rate))
+		}
+		if path := optString(entry.Options, "library_path"); path != "" {
+			opts = append(opts, silero.WithLibraryPath(path))
+		}
+		return silero.New(cmp.Or(entry.Model, optString(entry.Options, "model_path")), opts...)
+	})
+
+	// energy compares RMS energy with an adaptive noise floor; it needs no
+	// model file.
+	reg.RegisterVAD("energy", func(entry config.ProviderEntry) (vad.Engine, error) {
+		var opts []energy.Option
+		if ms, ok := optInt(entry.Options, "frame_ms"); ok {
+			opts = append(opts, energy.WithFrameMs(ms))
+		}
+		if ms, ok := optInt(entry.Options, "hangover_ms"); ok {
+			opts = append(opts, energy.WithHangoverMs(ms))
+		}
+		if v, ok := optFloat(entry.Options, "sensitivity"); ok {
+			opts = append(opts, energy.WithSensitivity(v))
+		}
+		if n, ok := optInt(entry.Options, "onset_frames"); ok {
+			opts = append(opts, energy.WithOnsetFrames(n))
+		}
+		return energy.New(opts...)
+	})
+
 	// Debug log of all registered providers.
 	for kind, names := range config.ValidProviderNames {
 		for _, name := range names {
@@ -340,96 +683,243 @@ func registerBuiltinProviders(reg *config.Registry) {
 	}
 }
 
+// providerError reports that a configured provider could not be created.
+// [buildProviders] joins one per broken provider, so a config with several
+// mistakes reports all of them at once.
+type providerError struct {
+	kind  string // provider kind, e.g. "llm" or "tts"
+	name  string // provider name from the config
+	scope string // what the provider is for, e.g. `for NPC "Bart"`; empty for the global provider
+	err   error
+}
+
+func (e *providerError) Error() string {
+	if e.scope != "" {
+		return fmt.Sprintf("create %s provider %q %s: %v", e.kind, e.name, e.scope, e.err)
+	}
+	return fmt.Sprintf("create %s provider %q: %v", e.kind, e.name, e.err)
+}
+
+func (e *providerError) Unwrap() error { return e.err }
+
 // buildProviders instantiates all providers named in cfg using the registry
 // and returns them in an [app.Providers] struct for the application to consume.
+//
+// Providers that are not registered are skipped. All other construction
+// errors are collected and returned together, one [providerError] each,
+// combined with [errors.Join].
 func buildProviders(cfg *config.Config, reg *config.Registry) (*app.Providers, error) {
 	ps := &app.Providers{}
+	var errs []error
 
-	if name := cfg.Providers.LLM.Name; name != "" {
-		p, err := reg.CreateLLM(cfg.Providers.LLM)
-		if errors.Is(err, config.ErrProviderNotRegistered) {
-			slog.Debug("provider not yet implemented — skipping", "kind", "llm", "name", name)
-		} else if err != nil {
-			return nil, fmt.Errorf("create llm provider %q: %w", name, err)
-		} else {
-			ps.LLM = p
-			slog.Info("provider created", "kind", "llm", "name", name)
+	// create reports whether build created the provider of kind described by
+	// entry. It skips unregistered providers and records all other errors.
+	create := func(kind string, entry config.ProviderEntry, build func(config.ProviderEntry) error) bool {
+		err := build(entry)
+		switch {
+		case errors.Is(err, config.ErrProviderNotRegistered):
+			slog.Debug("provider not yet implemented — skipping", "kind", kind, "name", entry.Name)
+			return false
+		case err != nil:
+			errs = append(errs, &providerError{kind: kind, name: entry.Name, err: err})
+			return false
 		}
+		slog.Info("provider created", "kind", kind, "name", entry.Name)
+		return true
 	}
 
-	if name := cfg.Providers.STT.Name; name != "" {
-		p, err := reg.CreateSTT(cfg.Providers.STT)
-		if errors.Is(err, config.ErrProviderNotRegistered) {
-			slog.Debug("provider not yet implemented — skipping", "kind", "stt", "name", name)
-		} else if err != nil {
-			return nil, fmt.Errorf("create stt provider %q: %w", name, err)
-		} else {
-			ps.STT = p
-			slog.Info("provider created", "kind", "stt", "name", name)
+	if cfg.Providers.LLM.Name != "" {
+		create("llm", cfg.Providers.LLM, func(e config.ProviderEntry) (err error) {
+			ps.LLM, err = reg.CreateLLM(e)
+			return err
+		})
+	}
+
+	// LLM providers NPCs select by name with llm.provider: the global one
+	// and the providers.npc_llms entries, each with its own credentials.
+	ps.NPCLLMs = make(map[string]llm.Provider, len(cfg.Providers.NPCLLMs)+1)
+	if ps.LLM != nil {
+		ps.NPCLLMs[cfg.Providers.LLM.Name] = ps.LLM
+	}
+	for _, entry := range cfg.Providers.NPCLLMs {
+		p, err := reg.CreateLLM(entry)
+		switch {
+		case errors.Is(err, config.ErrProviderNotRegistered):
+			slog.Debug("provider not yet implemented — skipping", "kind", "llm", "name", entry.Name)
+		case err != nil:
+			errs = append(errs, &providerError{kind: "llm", name: entry.Name, scope: "for NPCs", err: err})
+		default:
+			ps.NPCLLMs[entry.Name] = p
+			slog.Info("provider created", "kind", "llm", "name", entry.Name, "scope", "npc")
 		}
 	}
 
-	if name := cfg.Providers.TTS.Name; name != "" {
-		p, err := reg.CreateTTS(cfg.Providers.TTS)
-		if errors.Is(err, config.ErrProviderNotRegistered) {
-			slog.Debug("provider not yet implemented — skipping", "kind", "tts", "name", name)
-		} else if err != nil {
-			return nil, fmt.Errorf("create tts provider %q: %w", name, err)
-		} else {
+	if cfg.Providers.STT.Name != "" {
+		create("stt", cfg.Providers.STT, func(e config.ProviderEntry) (err error) {
+			ps.STT, err = reg.CreateSTT(e)
+			return err
+		})
+	}
+
+	if cfg.Providers.TTS.Name != "" {
+		var primary tts.Provider
+		if create("tts", cfg.Providers.TTS, func(e config.ProviderEntry) (err error) {
+			primary, err = reg.CreateTTS(e)
+			return err
+		}) {
+			p, err := buildTTSFallback(cfg.Providers, reg, primary)
+			if err != nil {
+				errs = append(errs, err)
+			}
 			ps.TTS = p
-			slog.Info("provider created", "kind", "tts", "name", name)
 		}
 	}
 
-	if name := cfg.Providers.S2S.Name; name != "" {
-		p, err := reg.CreateS2S(cfg.Providers.S2S)
-		if errors.Is(err, config.ErrProviderNotRegistered) {
-			slog.Debug("provider not yet implemented — skipping", "kind", "s2s", "name", name)
-		} else if err != nil {
-			return nil, fmt.Errorf("create s2s provider %q: %w", name, err)
-		} else {
-			ps.S2S = p
-			slog.Info("provider created", "kind", "s2s", "name", name)
+	if spec := cfg.Providers.TTSErrorAudio; spec != "" {
+		pcm, err := loadTTSErrorAudio(spec, engine.TTSFormat(ps.TTS))
+		if err != nil {
+			errs = append(errs, err)
 		}
+		ps.TTSErrorAudio = pcm
 	}
 
-	if name := cfg.Providers.Embeddings.Name; name != "" {
-		p, err := reg.CreateEmbeddings(cfg.Providers.Embeddings)
-		if errors.Is(err, config.ErrProviderNotRegistered) {
-			slog.Debug("provider not yet implemented — skipping", "kind", "embeddings", "name", name)
-		} else if err != nil {
-			return nil, fmt.Errorf("create embeddings provider %q: %w", name, err)
-		} else {
-			ps.Embeddings = p
-			slog.Info("provider created", "kind", "embeddings", "name", name)
-		}
+	if cfg.Providers.S2S.Name != "" {
+		create("s2s", cfg.Providers.S2S, func(e config.ProviderEntry) (err error) {
+			ps.S2S, err = reg.CreateS2S(e)
+			return err
+		})
 	}
 
-	if name := cfg.Providers.VAD.Name; name != "" {
-		p, err := reg.CreateVAD(cfg.Providers.VAD)
-		if errors.Is(err, config.ErrProviderNotRegistered) {
-			slog.Debug("provider not yet implemented — skipping", "kind", "vad", "name", name)
-		} else if err != nil {
-			return nil, fmt.Errorf("create vad provider %q: %w", name, err)
-		} else {
-			ps.VAD = p
-			slog.Info("provider created", "kind", "vad", "name", name)
+	if cfg.Providers.Embeddings.Name != "" {
+		create("embeddings", cfg.Providers.Embeddings, func(e config.ProviderEntry) (err error) {
+			ps.Embeddings, err = reg.CreateEmbeddings(e)
+			return err
+		})
+	}
+
+	if cfg.Providers.VAD.Name != "" {
+		create("vad", cfg.Providers.VAD, func(e config.ProviderEntry) (err error) {
+			ps.VAD, err = reg.CreateVAD(e)
+			return err
+		})
+	}
+
+	if cfg.Providers.Audio.Name != "" {
+		create("audio", cfg.Providers.Audio, func(e config.ProviderEntry) (err error) {
+			ps.Audio, err = reg.CreateAudio(e)
+			return err
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	ps.Safety = buildSafetyFilter(cfg.Safety)
+	return ps, nil
+}
+
+// logProviderErrors logs each provider that [buildProviders] failed to
+// create as a separate record, so every broken provider is listed with its
+// kind and name.
+func logProviderErrors(err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			logProviderErrors(err)
 		}
+		return
+	}
+	var pe *providerError
+	if errors.As(err, &pe) {
+		slog.Error("failed to build provider", "kind", pe.kind, "name", pe.name, "err", err)
+		return
 	}
+	slog.Error("failed to build providers", "err", err)
+}
 
-	if name := cfg.Providers.Audio.Name; name != "" {
-		p, err := reg.CreateAudio(cfg.Providers.Audio)
-		if errors.Is(err, config.ErrProviderNotRegistered) {
-			slog.Debug("provider not yet implemented — skipping", "kind", "audio", "name", name)
-		} else if err != nil {
-			return nil, fmt.Errorf("create audio provider %q: %w", name, err)
-		} else {
-			ps.Audio = p
-			slog.Info("provider created", "kind", "audio", "name", name)
+// loadTTSErrorAudio decodes the clip configured as providers.tts_error_audio
+// into target, the TTS format of the cascaded engines (see [engine.TTSFormat]).
+// spec is a WAV or MP3 file path or [config.TTSErrorAudioEarcon].
+func loadTTSErrorAudio(spec string, target audio.Format) ([]byte, error) {
+	if spec == config.TTSErrorAudioEarcon {
+		return audio.Resample(audio.EarconError.PCM(target.SampleRate), target.SampleRate, target.SampleRate, 1, target.Channels), nil
+	}
+	data, err := os.ReadFile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("load tts error audio: %w", err)
+	}
+	pcm, err := audio.DecodeFile(data, audio.Format{}, target)
+	if err != nil {
+		return nil, fmt.Errorf("load tts error audio %q: %w", spec, err)
+	}
+	return pcm, nil
+}
+
+// buildSafetyFilter creates the content-safety filter selected by sc, or nil
+// when filtering is disabled.
+func buildSafetyFilter(sc config.SafetyConfig) engine.SafetyFilter {
+	action := engine.SafetyBlock
+	switch sc.Policy {
+	case config.SafetyPolicyRedact:
+		action = engine.SafetyRedact
+	case config.SafetyPolicyRegenerate:
+		action = engine.SafetyRegenerate
+	}
+	switch sc.Filter {
+	case config.SafetyFilterWordlist:
+		slog.Info("safety filter enabled", "filter", sc.Filter, "policy", action, "words", len(sc.Words))
+		return engine.NewWordlistFilter(sc.Words, action)
+	default:
+		return nil
+	}
+}
+
+// ttsLanguageDiscoveryTimeout bounds the startup queries for the languages
+// of TTS providers without a "languages" option.
+const ttsLanguageDiscoveryTimeout = 10 * time.Second
+
+// buildTTSFallback wraps primary and the providers in pc.TTSFallbacks in a
+// [resilience.TTSFallback] that handles unsupported languages according to
+// pc.TTSLanguageFallback. Each provider's supported languages come from its
+// "languages" option or are queried from the provider. primary is returned
+// unchanged when neither fallbacks nor language settings are configured.
+func buildTTSFallback(pc config.ProvidersConfig, reg *config.Registry, primary tts.Provider) (tts.Provider, error) {
+	entries := append([]config.ProviderEntry{pc.TTS}, pc.TTSFallbacks...)
+	configured := pc.TTSLanguageFallback != "" || slices.ContainsFunc(entries, func(e config.ProviderEntry) bool {
+		return len(optStringSlice(e.Options, "languages")) > 0
+	})
+	if len(pc.TTSFallbacks) == 0 && !configured {
+		return primary, nil
+	}
+
+	fb := resilience.NewTTSFallback(primary, pc.TTS.Name, resilience.FallbackConfig{})
+	var errs []error
+	for i, entry := range pc.TTSFallbacks {
+		p, err := reg.CreateTTS(entry)
+		if err != nil {
+			errs = append(errs, &providerError{kind: "tts", name: entry.Name, scope: fmt.Sprintf("as fallback %d", i), err: err})
+			continue
+		}
+		fb.AddFallback(entry.Name, p)
+		slog.Info("provider created", "kind", "tts", "name", entry.Name, "fallback", i)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if pc.TTSLanguageFallback == config.TTSLanguageFallbackProvider {
+		fb.SetLanguagePolicy(resilience.LanguagePolicySwitchProvider)
+	}
+	for _, entry := range entries {
+		if langs := optStringSlice(entry.Options, "languages"); len(langs) > 0 {
+			fb.SetLanguages(entry.Name, tts.NewLanguageSet(langs...))
 		}
 	}
 
-	return ps, nil
+	ctx, cancel := context.WithTimeout(context.Background(), ttsLanguageDiscoveryTimeout)
+	defer cancel()
+	if err := fb.DiscoverLanguages(ctx); err != nil {
+		slog.Warn("could not query TTS languages; assuming all languages are supported", "error", err)
+	}
+	return fb, nil
 }
 
 // ── Startup summary ───────────────────────────────────────────────────────────
@@ -473,23 +963,109 @@ func printProvider(kind, name, model string) {
 
 // ── Logger ─────────────────────────────────────────────────────────────────────
 
-func newLogger(level config.LogLevel) *slog.Logger {
-	var lvl slog.Level
+func newLogger(level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// slogLevel maps a configured log level to its slog level.
+func slogLevel(level config.LogLevel) slog.Level {
 	switch level {
 	case config.LogDebug:
-		lvl = slog.LevelDebug
+		return slog.LevelDebug
 	case config.LogWarn:
-		lvl = slog.LevelWarn
+		return slog.LevelWarn
 	case config.LogError:
-		lvl = slog.LevelError
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// ── Config hot-reload ──────────────────────────────────────────────────────────
+
+// configReloader applies the hot-reloadable parts of a changed config.
+type configReloader struct {
+	app        *app.App
+	sessionMgr *app.SessionManager // nil without a Discord bot
+	logLevel   *slog.LevelVar
+}
+
+// watchConfig applies each config the watcher reports until ctx is done or
+// the watcher stops. current is the config the process started with.
+func watchConfig(ctx context.Context, w *config.Watcher, current *config.Config, r *configReloader) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case next, ok := <-w.Changes():
+			if !ok {
+				return
+			}
+			current = r.apply(ctx, current, next)
+		}
+	}
+}
+
+// apply applies the NPC definitions, MCP servers and log level of next, which
+// the watcher has already validated, and logs the changed sections that need
+// a restart. It returns the config that is in effect afterwards: NPCs that
+// could not be applied keep their values from cur, so they are retried on
+// the next change.
+func (r *configReloader) apply(ctx context.Context, cur, next *config.Config) *config.Config {
+	d := config.Diff(cur, next)
+	applied := *cur
+
+	if d.LogLevelChanged {
+		r.logLevel.Set(slogLevel(d.NewLogLevel))
+		applied.Server.LogLevel = d.NewLogLevel
+		slog.Info("config reload: log level changed", "log_level", d.NewLogLevel)
+	}
+
+	// Without a bot the NPCs are created once at startup, so there is no
+	// later session to pick up new definitions.
+	switch {
+	case !d.NPCsChanged:
+	case r.sessionMgr == nil:
+		slog.Warn("config reload: NPC changes need a restart to take effect without a Discord bot")
 	default:
-		lvl = slog.LevelInfo
+		if err := r.sessionMgr.SetNPCs(next.NPCs); err != nil {
+			slog.Error("config reload: NPCs not applied", "err", err)
+			break
+		}
+		applied.NPCs = next.NPCs
+		slog.Info("config reload: NPCs updated; they take effect from the next session", "npcs", len(next.NPCs))
+	}
+
+	if d.MCPChanged {
+		// Servers that did register are in place even if others failed.
+		if err := r.app.ReloadMCP(ctx, cur.MCP.Servers, next.MCP.Servers); err != nil {
+			slog.Error("config reload: MCP servers partly applied", "err", err)
+		}
+		applied.MCP = next.MCP
+	}
+
+	if len(d.RestartRequired) > 0 {
+		slog.Warn("config reload: changes need a restart to take effect", "sections", d.RestartRequired)
 	}
-	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: lvl}))
+	return &applied
 }
 
 // ── Helpers ───────────────────────────────────────────────────────────────────
 
+// embeddingBatchOptions returns the batching options of an embeddings
+// provider entry: batch_size caps the inputs per request and
+// requests_per_minute paces the requests.
+func embeddingBatchOptions(opts map[string]any) []embeddings.BatchOption {
+	var batching []embeddings.BatchOption
+	if n, ok := optInt(opts, "batch_size"); ok {
+		batching = append(batching, embeddings.WithBatchSize(n))
+	}
+	if n, ok := optInt(opts, "requests_per_minute"); ok {
+		batching = append(batching, embeddings.WithRateLimit(n, time.Minute))
+	}
+	return batching
+}
+
 // optString extracts a string value from a provider Options map[string]any.
 // Returns "" if the map is nil, the key is absent, or the value is not a string.
 func optString(opts map[string]any, key string) string {
@@ -506,3 +1082,57 @@ func optString(opts map[string]any, key string) string {
 	}
 	return s
 }
+
+// optInt extracts an integer value from a provider Options map[string]any.
+// The boolean reports whether the key was present with an integer value.
+func optInt(opts map[string]any, key string) (int, bool) {
+	if opts == nil {
+		return 0, false
+	}
+	n, ok := opts[key].(int)
+	return n, ok
+}
+
+// optFloat extracts a numeric value from a provider Options map[string]any.
+// Integers are converted, so YAML values such as 1 and 1.0 are both accepted.
+// The second result reports whether the key was present with a numeric value.
+func optFloat(opts map[string]any, key string) (float64, bool) {
+	switch v := opts[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// optBool extracts a boolean value from a provider Options map[string]any.
+// The second result reports whether the key was present with a boolean value.
+func optBool(opts map[string]any, key string) (bool, bool) {
+	if opts == nil {
+		return false, false
+	}
+	b, ok := opts[key].(bool)
+	return b, ok
+}
+
+// optStringSlice extracts a list of strings from a provider Options
+// map[string]any. Non-string elements are skipped. Returns nil if the map is
+// nil, the key is absent, or the value is not a list.
+func optStringSlice(opts map[string]any, key string) []string {
+	if opts == nil {
+		return nil
+	}
+	list, ok := opts[key].([]any)
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
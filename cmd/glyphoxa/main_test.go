@@ -0,0 +1,317 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/internal/app"
+	"github.com/MrWong99/glyphoxa/internal/config"
+	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
+	mcpmock "github.com/MrWong99/glyphoxa/internal/mcp/mock"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	audiomock "github.com/MrWong99/glyphoxa/pkg/audio/mock"
+	memorymock "github.com/MrWong99/glyphoxa/pkg/memory/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	sttmock "github.com/MrWong99/glyphoxa/pkg/provider/stt/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	ttsmock "github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+)
+
+func TestBuildProviders_ReportsAllErrors(t *testing.T) {
+	t.Parallel()
+
+	errNoKey := errors.New("api_key is required")
+	errNoURL := errors.New("base_url is required")
+
+	reg := config.NewRegistry()
+	reg.RegisterLLM("openai", func(config.ProviderEntry) (llm.Provider, error) { return nil, errNoKey })
+	reg.RegisterLLM("anthropic", func(config.ProviderEntry) (llm.Provider, error) { return nil, errNoKey })
+	reg.RegisterSTT("deepgram", func(config.ProviderEntry) (stt.Provider, error) { return nil, errNoKey })
+	reg.RegisterTTS("coqui", func(config.ProviderEntry) (tts.Provider, error) { return &ttsmock.Provider{}, nil })
+	reg.RegisterTTS("azure", func(config.ProviderEntry) (tts.Provider, error) { return nil, errNoURL })
+
+	cfg := &config.Config{
+		Providers: config.ProvidersConfig{
+			LLM:          config.ProviderEntry{Name: "openai"},
+			STT:          config.ProviderEntry{Name: "deepgram"},
+			TTS:          config.ProviderEntry{Name: "coqui"},
+			TTSFallbacks: []config.ProviderEntry{{Name: "azure"}},
+			NPCLLMs:      []config.ProviderEntry{{Name: "anthropic"}},
+			// Not registered: skipped without an error.
+			S2S: config.ProviderEntry{Name: "gemini-live"},
+		},
+	}
+
+	ps, err := buildProviders(cfg, reg)
+	if err == nil {
+		t.Fatal("buildProviders: want error, got nil")
+	}
+	if ps != nil {
+		t.Errorf("providers = %+v, want nil on error", ps)
+	}
+	if !errors.Is(err, errNoKey) || !errors.Is(err, errNoURL) {
+		t.Errorf("error %v does not wrap the factory errors", err)
+	}
+
+	var got []string
+	var collect func(error)
+	collect = func(err error) {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range joined.Unwrap() {
+				collect(err)
+			}
+			return
+		}
+		var pe *providerError
+		if !errors.As(err, &pe) {
+			t.Errorf("error %v is not a providerError", err)
+			return
+		}
+		got = append(got, pe.kind+" "+pe.name)
+	}
+	collect(err)
+
+	want := []string{"llm openai", "llm anthropic", "stt deepgram", "tts azure"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("reported providers = %q, want %q", got, want)
+	}
+	for _, sub := range []string{
+		`create llm provider "openai": api_key is required`,
+		`create llm provider "anthropic" for NPCs: api_key is required`,
+		`create stt provider "deepgram": api_key is required`,
+		`create tts provider "azure" as fallback 0: base_url is required`,
+	} {
+		if !strings.Contains(err.Error(), sub) {
+			t.Errorf("error %q does not contain %q", err, sub)
+		}
+	}
+}
+
+func TestBuildProviders_SkipsUnregistered(t *testing.T) {
+	t.Parallel()
+
+	reg := config.NewRegistry()
+	reg.RegisterLLM("openai", func(config.ProviderEntry) (llm.Provider, error) { return &llmmock.Provider{}, nil })
+
+	cfg := &config.Config{
+		Providers: config.ProvidersConfig{
+			LLM: config.ProviderEntry{Name: "openai"},
+			STT: config.ProviderEntry{Name: "not-implemented"},
+		},
+	}
+	ps, err := buildProviders(cfg, reg)
+	if err != nil {
+		t.Fatalf("buildProviders: %v", err)
+	}
+	if ps.LLM == nil {
+		t.Error("LLM provider not created")
+	}
+	if ps.STT != nil {
+		t.Errorf("STT = %v, want nil for an unregistered provider", ps.STT)
+	}
+}
+
+func TestBuildProviders_NPCLLMs(t *testing.T) {
+	t.Parallel()
+
+	global := &llmmock.Provider{}
+	var entries []config.ProviderEntry
+	reg := config.NewRegistry()
+	reg.RegisterLLM("openai", func(config.ProviderEntry) (llm.Provider, error) { return global, nil })
+	reg.RegisterLLM("anthropic", func(e config.ProviderEntry) (llm.Provider, error) {
+		entries = append(entries, e)
+		return &llmmock.Provider{}, nil
+	})
+
+	cfg := &config.Config{
+		Providers: config.ProvidersConfig{
+			LLM: config.ProviderEntry{Name: "openai"},
+			NPCLLMs: []config.ProviderEntry{
+				{Name: "anthropic", APIKey: "sk-ant-test", BaseURL: "https://proxy.example", Model: "claude-sonnet-4"},
+			},
+		},
+	}
+	ps, err := buildProviders(cfg, reg)
+	if err != nil {
+		t.Fatalf("buildProviders: %v", err)
+	}
+	if len(entries) != 1 || entries[0].APIKey != "sk-ant-test" || entries[0].BaseURL != "https://proxy.example" || entries[0].Model != "claude-sonnet-4" {
+		t.Errorf("anthropic created with %+v, want the npc_llms entry and its credentials", entries)
+	}
+	if ps.NPCLLMs["openai"] != global {
+		t.Error("the global LLM is not selectable by name")
+	}
+	if ps.NPCLLMs["anthropic"] == nil {
+		t.Error("the npc_llms provider was not created")
+	}
+}
+
+func TestLoadTTSErrorAudio(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	wav := filepath.Join(dir, "error.wav")
+	// 100 ms of 44.1 kHz stereo, decoded to 22050 Hz mono.
+	if err := os.WriteFile(wav, audio.EncodeWAV(make([]byte, 4410*4), 44100, 2), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	garbage := filepath.Join(dir, "error.txt")
+	if err := os.WriteFile(garbage, []byte("not audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		spec    string
+		target  audio.Format // default: mono at cascade.DefaultTTSSampleRate
+		wantLen int
+		wantErr bool
+	}{
+		{name: "earcon", spec: config.TTSErrorAudioEarcon, wantLen: len(audio.EarconError.PCM(cascade.DefaultTTSSampleRate))},
+		{name: "wav file", spec: wav, wantLen: 2205 * 2},
+		{name: "wav file at the TTS format", spec: wav, target: audio.Format{SampleRate: 16000, Channels: 1}, wantLen: 1600 * 2},
+		{name: "stereo earcon", spec: config.TTSErrorAudioEarcon, target: audio.Format{SampleRate: 16000, Channels: 2}, wantLen: len(audio.EarconError.PCM(16000)) * 2},
+		{name: "missing file", spec: filepath.Join(dir, "missing.wav"), wantErr: true},
+		{name: "not audio", spec: garbage, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			pcm, err := loadTTSErrorAudio(tc.spec, cmp.Or(tc.target, audio.Format{SampleRate: cascade.DefaultTTSSampleRate, Channels: 1}))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("loadTTSErrorAudio(%q) error = %v, wantErr %v", tc.spec, err, tc.wantErr)
+			}
+			if len(pcm) != tc.wantLen {
+				t.Errorf("len(pcm) = %d, want %d", len(pcm), tc.wantLen)
+			}
+		})
+	}
+}
+
+func TestConfigReloader_Apply(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cfg := &config.Config{
+		Server: config.ServerConfig{LogLevel: config.LogInfo},
+		NPCs:   []config.NPCConfig{{Name: "Grimjaw", Engine: config.EngineCascaded}},
+	}
+	providers := &app.Providers{LLM: &llmmock.Provider{}, TTS: &ttsmock.Provider{}}
+	mcpHost := &mcpmock.Host{}
+	application, err := app.New(ctx, cfg, providers,
+		app.WithSessionStore(&memorymock.SessionStore{}),
+		app.WithKnowledgeGraph(&memorymock.KnowledgeGraph{}),
+		app.WithMCPHost(mcpHost),
+		app.WithMixer(&audiomock.Mixer{}),
+	)
+	if err != nil {
+		t.Fatalf("app.New: %v", err)
+	}
+	r := &configReloader{
+		app: application,
+		sessionMgr: app.NewSessionManager(app.SessionManagerConfig{
+			Platform:  &audiomock.Platform{ConnectResult: &audiomock.Connection{}},
+			Config:    cfg,
+			Providers: providers,
+		}),
+		logLevel: new(slog.LevelVar),
+	}
+
+	// NPCs that need a provider that is not running are rejected; the log
+	// level, MCP servers and the provider change are handled regardless.
+	next := *cfg
+	next.Server.LogLevel = config.LogDebug
+	next.NPCs = []config.NPCConfig{{Name: "Sage", Engine: config.EngineCascaded, LLM: &config.NPCLLMConfig{Provider: "anthropic"}}}
+	next.MCP.Servers = []config.MCPServerConfig{{Name: "dice", Transport: "stdio", Command: "dice-server"}}
+	next.Providers.LLM.Model = "gpt-4o-mini"
+
+	applied := r.apply(ctx, cfg, &next)
+	if got := r.logLevel.Level(); got != slog.LevelDebug {
+		t.Errorf("log level = %v, want %v", got, slog.LevelDebug)
+	}
+	if applied.Server.LogLevel != config.LogDebug {
+		t.Errorf("applied log_level = %q, want %q", applied.Server.LogLevel, config.LogDebug)
+	}
+	if len(applied.NPCs) != 1 || applied.NPCs[0].Name != "Grimjaw" {
+		t.Errorf("applied NPCs = %+v, want the rejected change to keep Grimjaw", applied.NPCs)
+	}
+	if got := mcpHost.CallCount("RegisterServer"); got != 1 {
+		t.Errorf("RegisterServer calls = %d, want 1", got)
+	}
+	if applied.Providers.LLM.Model != "" {
+		t.Errorf("applied llm model = %q, want the restart-only change left out", applied.Providers.LLM.Model)
+	}
+
+	// Once the NPCs are valid they are applied.
+	next.NPCs = []config.NPCConfig{{Name: "Sage", Engine: config.EngineCascaded}}
+	applied = r.apply(ctx, applied, &next)
+	if len(applied.NPCs) != 1 || applied.NPCs[0].Name != "Sage" {
+		t.Errorf("applied NPCs = %+v, want Sage", applied.NPCs)
+	}
+}
+
+func TestRegisterBuiltinProviders_CoquiOutputFormat(t *testing.T) {
+	t.Parallel()
+
+	reg := config.NewRegistry()
+	registerBuiltinProviders(reg)
+	p, err := reg.CreateTTS(config.ProviderEntry{
+		Name:    "coqui",
+		BaseURL: "http://localhost:5002",
+		Options: map[string]any{"output_sample_rate": 48000, "output_channels": 2},
+	})
+	if err != nil {
+		t.Fatalf("CreateTTS: %v", err)
+	}
+	f, ok := p.(interface {
+		SampleRate() int
+		Channels() int
+	})
+	if !ok {
+		t.Fatalf("coqui provider %T does not report its output format", p)
+	}
+	if f.SampleRate() != 48000 || f.Channels() != 2 {
+		t.Errorf("output format = %d Hz / %d ch, want 48000 Hz / 2 ch", f.SampleRate(), f.Channels())
+	}
+}
+
+func TestTranscribeFiles(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "session.pcm")
+	if err := os.WriteFile(path, make([]byte, 48000*2), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sess := &sttmock.Session{PartialsCh: make(chan stt.Transcript), FinalsCh: make(chan stt.Transcript, 2)}
+	close(sess.PartialsCh)
+	sess.FinalsCh <- stt.Transcript{Text: "Grimjaw, open the gate.", IsFinal: true}
+	sess.FinalsCh <- stt.Transcript{Text: "Now.", IsFinal: true}
+	close(sess.FinalsCh)
+	p := &sttmock.Provider{Session: sess}
+
+	var out strings.Builder
+	err := transcribeFiles(t.Context(), p, []string{path}, stt.FileConfig{
+		RawFormat: audio.Format{SampleRate: 48000, Channels: 1},
+	}, &out)
+	if err != nil {
+		t.Fatalf("transcribeFiles: %v", err)
+	}
+	if want := "Grimjaw, open the gate.\nNow.\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if cfg := p.StartStreamCalls[0].Cfg; cfg.SampleRate != 16000 || cfg.Channels != 1 {
+		t.Errorf("stream format = %d Hz × %d, want 16 kHz mono", cfg.SampleRate, cfg.Channels)
+	}
+
+	if err := transcribeFiles(t.Context(), p, []string{filepath.Join(t.TempDir(), "missing.wav")}, stt.FileConfig{}, &out); err == nil {
+		t.Error("transcribeFiles of a missing file: expected error, got nil")
+	}
+}
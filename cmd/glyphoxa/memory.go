@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/MrWong99/glyphoxa/internal/app"
+	"github.com/MrWong99/glyphoxa/internal/config"
+	"github.com/MrWong99/glyphoxa/pkg/memory/postgres"
+)
+
+// memoryUsage is printed for an unknown or missing memory subcommand.
+const memoryUsage = `usage: glyphoxa memory <command> [flags]
+
+commands:
+  reembed   re-embed all stored chunks with the configured embeddings model`
+
+// runMemory implements the "glyphoxa memory" subcommands and returns the
+// process exit code.
+func runMemory(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, memoryUsage)
+		return 2
+	}
+	switch args[0] {
+	case "reembed":
+		return runMemoryReembed(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "glyphoxa memory: unknown command %q\n%s\n", args[0], memoryUsage)
+		return 2
+	}
+}
+
+// runMemoryReembed migrates the configured memory store to the configured
+// embeddings model by re-embedding every stored chunk. Run it after changing
+// providers.embeddings, while the application is stopped.
+func runMemoryReembed(args []string) int {
+	fs := flag.NewFlagSet("glyphoxa memory reembed", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "path to the YAML configuration file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: %v\n", err)
+		return 1
+	}
+	if cfg.Memory.PostgresDSN == "" {
+		fmt.Fprintln(os.Stderr, "glyphoxa: memory.postgres_dsn is required to re-embed the memory store")
+		return 1
+	}
+	if cfg.Providers.Embeddings.Name == "" {
+		fmt.Fprintln(os.Stderr, "glyphoxa: providers.embeddings is required to re-embed the memory store")
+		return 1
+	}
+
+	reg := config.NewRegistry()
+	registerBuiltinProviders(reg)
+	provider, err := reg.CreateEmbeddings(cfg.Providers.Embeddings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: embeddings provider: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// The dimension only matters if the store is created here; ReembedAll
+	// resizes an existing embeddings column to the model's dimension.
+	dims, err := app.EmbeddingDimensions(cfg.Memory.EmbeddingDimensions, provider)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: %v\n", err)
+		return 1
+	}
+	store, err := postgres.NewStore(ctx, cfg.Memory.PostgresDSN, dims,
+		postgres.WithDefaultLanguage(cfg.Memory.Language),
+		postgres.WithDistanceMetric(cfg.Memory.DistanceMetric),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	n, err := store.ReembedAll(ctx, provider)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: %v\n", err)
+		return 1
+	}
+	model, dims, err := store.EmbeddingModel(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: %v\n", err)
+		return 1
+	}
+	fmt.Printf("re-embedded %d chunks with %q (%d dimensions)\n", n, model, dims)
+	return 0
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/MrWong99/glyphoxa/internal/config"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+)
+
+// transcribeUsage is printed when "glyphoxa transcribe" is run without files.
+const transcribeUsage = `usage: glyphoxa transcribe [flags] <file>...
+
+Transcribes WAV, MP3 or headerless 16-bit PCM recordings with the configured
+STT provider and prints the final transcripts.`
+
+// runTranscribe implements the "glyphoxa transcribe" subcommand and returns
+// the process exit code.
+func runTranscribe(args []string) int {
+	fs := flag.NewFlagSet("glyphoxa transcribe", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "path to the YAML configuration file")
+	language := fs.String("language", "", "BCP-47 language of the recordings; empty lets the provider detect it")
+	rawRate := fs.Int("raw-sample-rate", 0, "sample rate in Hz of headerless PCM input")
+	rawChannels := fs.Int("raw-channels", 1, "channel count of headerless PCM input")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, transcribeUsage)
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: %v\n", err)
+		return 1
+	}
+	if cfg.Providers.STT.Name == "" {
+		fmt.Fprintln(os.Stderr, "glyphoxa: providers.stt is required to transcribe recordings")
+		return 1
+	}
+
+	reg := config.NewRegistry()
+	registerBuiltinProviders(reg)
+	provider, err := reg.CreateSTT(cfg.Providers.STT)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: stt provider: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	fileCfg := stt.FileConfig{
+		Stream:    stt.StreamConfig{Language: *language},
+		RawFormat: audio.Format{SampleRate: *rawRate, Channels: *rawChannels},
+	}
+	if err := transcribeFiles(ctx, provider, fs.Args(), fileCfg, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "glyphoxa: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// transcribeFiles transcribes the recordings at paths with p and writes each
+// final transcript to w, one per line.
+func transcribeFiles(ctx context.Context, p stt.Provider, paths []string, cfg stt.FileConfig, w io.Writer) error {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		finals, err := stt.TranscribeFile(ctx, p, data, cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, t := range finals {
+			if len(paths) > 1 {
+				fmt.Fprintf(w, "%s: %s\n", path, t.Text)
+			} else {
+				fmt.Fprintln(w, t.Text)
+			}
+		}
+	}
+	return nil
+}
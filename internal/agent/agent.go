@@ -16,6 +16,7 @@ import (
 	"context"
 
 	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
 	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
 )
@@ -49,6 +50,39 @@ type NPCIdentity struct {
 	// "Never break character", "Always speak in archaic English").
 	// Appended to the system prompt as a numbered list of rules.
 	BehaviorRules []string
+
+	// ColdOpen makes the NPC answer the first utterance addressed to it in a
+	// session with a context-aware greeting instead of a regular reply.
+	ColdOpen bool
+
+	// Aliases are alternative names the NPC answers to (e.g., "the smith" or
+	// a nickname), matched like Name when detecting who a player addressed.
+	Aliases []string
+
+	// RespondOnlyWhenNamed restricts routing to utterances that mention the
+	// NPC's name or one of its Aliases. Such an NPC never receives a turn
+	// merely for being the last speaker or the only NPC present, which keeps
+	// it quiet in crowded scenes. A DM puppet override still applies.
+	RespondOnlyWhenNamed bool
+
+	// AwarenessRadius is how many knowledge-graph hops around the NPC are
+	// searched for passages relevant to each utterance. Passages about closer
+	// entities are weighted higher. Zero disables retrieval.
+	AwarenessRadius int
+
+	// StyleExemplars is how many of the NPC's own most recent lines, from
+	// this and earlier sessions, are shown to it as references for its
+	// speaking style, apart from the conversation history. They keep its
+	// voice consistent across long sessions. Zero disables them.
+	StyleExemplars int
+
+	// StockResponses maps intents to canned lines the NPC answers them with,
+	// bypassing the LLM. Utterances classified as one of these intents are
+	// answered with a line picked at random by weight; all others reach the
+	// engine as usual. The random source is seeded per session and NPC, so a
+	// replayed session picks the same lines. Useful for generic NPCs such as
+	// merchants and guards. Requires a TTS provider.
+	StockResponses map[Intent][]StockLine
 }
 
 // SceneContext describes the current in-game situation passed to an NPC
@@ -134,3 +168,60 @@ type NPCAgent interface {
 	// TTS provider configured.
 	SpeakText(ctx context.Context, text string) error
 }
+
+// PromptRenderer is an optional interface for [NPCAgent] implementations that
+// can show the prompt they would send to their engine for an utterance,
+// without running a turn. Use a type assertion to detect support. It helps
+// DMs tuning a persona to see exactly what the model receives.
+type PromptRenderer interface {
+	// RenderPrompt runs the full context assembly of
+	// [NPCAgent.HandleUtterance] for input — identity, retrieval, scene and
+	// rules — and returns the resulting prompt. No model is called and the
+	// conversation history is left unchanged. A non-nil scene replaces the
+	// scene description, as an [NPCAgent.UpdateScene] with it would for the
+	// next turn.
+	RenderPrompt(ctx context.Context, input string, scene *SceneContext) (engine.PromptContext, error)
+}
+
+// ContextInjector is an optional interface for [NPCAgent] implementations that
+// accept out-of-band context updates, such as a sudden event announced by the
+// DM. Use a type assertion to detect support.
+type ContextInjector interface {
+	// InjectContext pushes update into the NPC's engine via
+	// [engine.VoiceEngine.InjectContext]. Without [engine.ContextUpdate.Priority]
+	// it applies on the next turn. With it, a reply the NPC is still generating
+	// or speaking is cut off and generated again for the same utterance, so
+	// that the NPC reacts to the update at once.
+	InjectContext(ctx context.Context, update engine.ContextUpdate) error
+}
+
+// HistoryPolicy bounds the conversation history an [NPCAgent] keeps between
+// turns. After every recorded exchange the agent passes its full history to
+// Apply and replaces it with the returned slice.
+//
+// Implementations must not modify the input slice and must be safe for
+// concurrent use, as a single policy is typically shared by all agents in a
+// session.
+type HistoryPolicy interface {
+	// Apply returns the history to keep. Returning an error leaves the agent's
+	// history unchanged.
+	Apply(ctx context.Context, messages []llm.Message) ([]llm.Message, error)
+}
+
+// DefaultRecapEvery is the number of turns between two refreshes of an
+// [NPCAgent]'s scene recap when [AgentConfig.RecapEvery] is zero.
+const DefaultRecapEvery = 5
+
+// SceneRecapper maintains the rolling scene recap an [NPCAgent] injects into
+// every prompt alongside its verbatim recent turns. The agent refreshes the
+// recap every few turns by passing the previous recap and the turns since to
+// Recap, so that each refresh only costs the new turns.
+//
+// Implementations must be safe for concurrent use, as a single recapper is
+// typically shared by all agents in a session.
+type SceneRecapper interface {
+	// Recap returns recap updated with turns, which happened after it. recap
+	// is empty on the first refresh. Returning an error keeps the previous
+	// recap; the turns are passed again on the next refresh.
+	Recap(ctx context.Context, recap string, turns []llm.Message) (string, error)
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+)
+
+// GateReplyEarcon is the [TranscriptGate.Reply] value that plays
+// [audio.EarconUnclear] instead of speaking a line.
+const GateReplyEarcon = "earcon"
+
+// TranscriptGate decides which STT transcripts an NPC answers. Background
+// noise tends to come back from STT as an empty or low-confidence transcript;
+// answering it would prompt the LLM with noise, so the gate skips such turns.
+//
+// The zero value skips only transcripts without any letters or digits.
+type TranscriptGate struct {
+	// MinConfidence is the lowest STT confidence, between 0 and 1, a
+	// transcript needs to be answered. Zero disables the confidence check.
+	// Transcripts whose provider reports no confidence always pass it.
+	MinConfidence float64
+
+	// Reply is played when a turn is skipped: empty stays silent,
+	// [GateReplyEarcon] plays [audio.EarconUnclear], and any other text is
+	// spoken in the NPC's voice, such as "Sorry, I didn't catch that." Replies
+	// need the agent's mixer; spoken ones also its TTS provider.
+	Reply string
+}
+
+// Pass reports whether t should be answered.
+func (g TranscriptGate) Pass(t stt.Transcript) bool {
+	if !strings.ContainsFunc(t.Text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+		return false
+	}
+	if g.MinConfidence <= 0 {
+		return true
+	}
+	c := transcriptConfidence(t)
+	return c == 0 || c >= g.MinConfidence
+}
+
+// transcriptConfidence returns the confidence of t: [stt.Transcript.Confidence]
+// or, when the provider only scores words, their mean. Zero means unknown.
+func transcriptConfidence(t stt.Transcript) float64 {
+	if t.Confidence > 0 || len(t.Words) == 0 {
+		return t.Confidence
+	}
+	var sum float64
+	for _, w := range t.Words {
+		sum += w.Confidence
+	}
+	return sum / float64(len(t.Words))
+}
+
+// gateReply plays the gate's reply to a skipped turn through the mixer. The
+// caller must hold a.mu.
+func (a *liveAgent) gateReply(ctx context.Context) error {
+	if a.mixer == nil {
+		return nil
+	}
+	seg := &audio.AudioSegment{
+		NPCID:      a.id,
+		SampleRate: a.ttsFormat.SampleRate,
+		Channels:   a.ttsFormat.Channels,
+		Priority:   defaultAudioPriority,
+	}
+	switch a.gate.Reply {
+	case "":
+		return nil
+	case GateReplyEarcon:
+		ch := make(chan []byte, 1)
+		ch <- audio.EarconUnclear.PCM(seg.SampleRate)
+		close(ch)
+		seg.Audio = ch
+		seg.Channels = 1
+	default:
+		if a.ttsProvider == nil {
+			return nil
+		}
+		resp, err := a.stockResponse(ctx, a.gate.Reply)
+		if err != nil {
+			return err
+		}
+		seg.Audio = resp.Audio
+	}
+	a.mixer.Enqueue(seg, defaultAudioPriority)
+	return nil
+}
@@ -0,0 +1,198 @@
+package agent_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/internal/agent"
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	enginemock "github.com/MrWong99/glyphoxa/internal/engine/mock"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	audiomock "github.com/MrWong99/glyphoxa/pkg/audio/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	ttsmock "github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+)
+
+func TestTranscriptGate_Pass(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		gate agent.TranscriptGate
+		tr   stt.Transcript
+		want bool
+	}{
+		{name: "empty", tr: stt.Transcript{}, want: false},
+		{name: "whitespace", tr: stt.Transcript{Text: "  \n"}, want: false},
+		{name: "punctuation", tr: stt.Transcript{Text: "... ?"}, want: false},
+		{name: "text", tr: stt.Transcript{Text: "Hello"}, want: true},
+		{name: "digits", tr: stt.Transcript{Text: "42"}, want: true},
+		{name: "low confidence without threshold", tr: stt.Transcript{Text: "Hello", Confidence: 0.1}, want: true},
+		{
+			name: "low confidence",
+			gate: agent.TranscriptGate{MinConfidence: 0.6},
+			tr:   stt.Transcript{Text: "hmm brr", Confidence: 0.3},
+			want: false,
+		},
+		{
+			name: "confidence at threshold",
+			gate: agent.TranscriptGate{MinConfidence: 0.6},
+			tr:   stt.Transcript{Text: "Hello", Confidence: 0.6},
+			want: true,
+		},
+		{
+			name: "confidence not reported",
+			gate: agent.TranscriptGate{MinConfidence: 0.6},
+			tr:   stt.Transcript{Text: "Hello"},
+			want: true,
+		},
+		{
+			name: "low word confidence",
+			gate: agent.TranscriptGate{MinConfidence: 0.6},
+			tr: stt.Transcript{Text: "hmm brr", Words: []stt.WordDetail{
+				{Word: "hmm", Confidence: 0.2},
+				{Word: "brr", Confidence: 0.4},
+			}},
+			want: false,
+		},
+		{
+			name: "high word confidence",
+			gate: agent.TranscriptGate{MinConfidence: 0.6},
+			tr: stt.Transcript{Text: "Hello there", Words: []stt.WordDetail{
+				{Word: "Hello", Confidence: 0.9},
+				{Word: "there", Confidence: 0.7},
+			}},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.gate.Pass(tt.tr); got != tt.want {
+				t.Errorf("Pass(%+v) = %v, want %v", tt.tr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleUtterance_TranscriptGateSkipsTurn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		tr   stt.Transcript
+	}{
+		{name: "empty", tr: stt.Transcript{Text: "", IsFinal: true}},
+		{name: "low confidence", tr: stt.Transcript{Text: "shh krr", IsFinal: true, Confidence: 0.2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := validConfig()
+			cfg.TranscriptGate = &agent.TranscriptGate{MinConfidence: 0.5}
+			var turns int
+			cfg.OnTurn = func(agent.TurnInfo) { turns++ }
+			mixer := &audiomock.Mixer{}
+			cfg.Mixer = mixer
+			a, err := agent.NewAgent(cfg)
+			if err != nil {
+				t.Fatalf("NewAgent: %v", err)
+			}
+
+			if err := a.HandleUtterance(context.Background(), "player-1", tt.tr); err != nil {
+				t.Fatalf("HandleUtterance: %v", err)
+			}
+
+			if n := len(cfg.Engine.(*enginemock.VoiceEngine).ProcessCalls); n != 0 {
+				t.Errorf("Process calls = %d, want 0", n)
+			}
+			if msgs := a.(agent.Stateful).State().Messages; len(msgs) != 0 {
+				t.Errorf("history = %+v, want empty", msgs)
+			}
+			if turns != 0 {
+				t.Errorf("turn observer called %d times, want 0", turns)
+			}
+			if n := len(mixer.EnqueueCalls); n != 0 {
+				t.Errorf("Enqueue calls = %d, want 0 without a reply", n)
+			}
+		})
+	}
+}
+
+func TestHandleUtterance_TranscriptGatePasses(t *testing.T) {
+	t.Parallel()
+
+	cfg := validConfig()
+	cfg.TranscriptGate = &agent.TranscriptGate{MinConfidence: 0.5}
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+
+	tr := stt.Transcript{Text: "What news from the capital?", IsFinal: true, Confidence: 0.9}
+	if err := a.HandleUtterance(context.Background(), "player-1", tr); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+	if n := len(cfg.Engine.(*enginemock.VoiceEngine).ProcessCalls); n != 1 {
+		t.Errorf("Process calls = %d, want 1", n)
+	}
+}
+
+func TestHandleUtterance_TranscriptGateReply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		reply     string
+		wantAudio []byte
+		wantTTS   int
+	}{
+		{name: "earcon", reply: agent.GateReplyEarcon, wantAudio: audio.EarconUnclear.PCM(engine.DefaultTTSSampleRate)},
+		{name: "line", reply: "Sorry, I didn't catch that.", wantAudio: []byte("pcm"), wantTTS: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := validConfig()
+			cfg.TranscriptGate = &agent.TranscriptGate{Reply: tt.reply}
+			tp := &ttsmock.Provider{SynthesizeChunks: [][]byte{[]byte("pcm")}}
+			cfg.TTS = tp
+			mixer := &audiomock.Mixer{}
+			cfg.Mixer = mixer
+			a, err := agent.NewAgent(cfg)
+			if err != nil {
+				t.Fatalf("NewAgent: %v", err)
+			}
+
+			if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: " "}); err != nil {
+				t.Fatalf("HandleUtterance: %v", err)
+			}
+
+			if n := len(cfg.Engine.(*enginemock.VoiceEngine).ProcessCalls); n != 0 {
+				t.Errorf("Process calls = %d, want 0", n)
+			}
+			if n := len(mixer.EnqueueCalls); n != 1 {
+				t.Fatalf("Enqueue calls = %d, want 1", n)
+			}
+			var got []byte
+			for chunk := range mixer.EnqueueCalls[0].Segment.Audio {
+				got = append(got, chunk...)
+			}
+			if !bytes.Equal(got, tt.wantAudio) {
+				t.Errorf("reply audio = %d bytes, want %d", len(got), len(tt.wantAudio))
+			}
+			if n := len(tp.SynthesizeStreamCalls); n != tt.wantTTS {
+				t.Errorf("SynthesizeStream calls = %d, want %d", n, tt.wantTTS)
+			}
+			if msgs := a.(agent.Stateful).State().Messages; len(msgs) != 0 {
+				t.Errorf("history = %+v, want empty", msgs)
+			}
+		})
+	}
+}
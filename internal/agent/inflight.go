@@ -0,0 +1,275 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+)
+
+// turnLockKey is the context key of the turn lock set with [WithTurnLock].
+type turnLockKey struct{}
+
+// WithTurnLock returns a copy of ctx carrying lock, which acquires the lock
+// that serialises the turns of the session and returns its release function.
+// The orchestrator passes it to [NPCAgent.HandleUtterance], which holds the
+// lock for the turn. A reply that a priority context update restarts after
+// HandleUtterance returned takes it again, so the restart does not run
+// concurrently with the session's other turns.
+func WithTurnLock(ctx context.Context, lock func(context.Context) (unlock func(), err error)) context.Context {
+	return context.WithValue(ctx, turnLockKey{}, lock)
+}
+
+// lockTurn acquires the turn lock carried by ctx, if any, and returns its
+// release function.
+func lockTurn(ctx context.Context) (unlock func(), err error) {
+	lock, _ := ctx.Value(turnLockKey{}).(func(context.Context) (func(), error))
+	if lock == nil {
+		return func() {}, nil
+	}
+	return lock(ctx)
+}
+
+// flight is an engine turn of [liveAgent.HandleUtterance] whose reply is
+// still being generated or streamed. A priority context update (see
+// [liveAgent.InjectContext]) restarts it.
+type flight struct {
+	speaker    string
+	transcript stt.Transcript
+	history    []llm.Message // conversation history before the turn
+	tracker    *turnTracker  // may be nil
+
+	// restart is signalled by a priority update. It is buffered, so that a
+	// signal arriving while the agent is busy waits until it gets to it.
+	restart chan struct{}
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // cancels the engine call of the current attempt
+	resp   *engine.Response   // reply of the current attempt; nil until ready
+}
+
+// attempt returns the context for a new attempt at the reply, derived from
+// ctx, and cancels the previous attempt.
+func (f *flight) attempt(ctx context.Context) context.Context {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	f.mu.Lock()
+	prev := f.cancel
+	f.cancel = cancel
+	f.mu.Unlock()
+	if prev != nil {
+		prev()
+	}
+	return attemptCtx
+}
+
+// interrupt asks for the reply to be restarted and cancels the current
+// attempt, so that an engine call in progress returns early.
+func (f *flight) interrupt() {
+	select {
+	case f.restart <- struct{}{}:
+	default: // a restart is already pending
+	}
+	f.mu.Lock()
+	cancel := f.cancel
+	f.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// restarting reports whether a restart was asked for, and clears the request.
+func (f *flight) restarting() bool {
+	select {
+	case <-f.restart:
+		return true
+	default:
+		return false
+	}
+}
+
+// response returns the reply of the current attempt.
+func (f *flight) response() *engine.Response {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.resp
+}
+
+// startFlight registers a new turn in flight for transcript, taking the
+// place of any earlier one. The caller must hold a.mu.
+func (a *liveAgent) startFlight(speaker string, transcript stt.Transcript, tracker *turnTracker) *flight {
+	f := &flight{
+		speaker:    speaker,
+		transcript: transcript,
+		history:    slices.Clone(a.messages),
+		tracker:    tracker,
+		restart:    make(chan struct{}, 1),
+	}
+	a.flightMu.Lock()
+	a.inflight = f
+	a.flightMu.Unlock()
+	return f
+}
+
+// landFlight unregisters f, unless a later turn already took its place, so
+// that priority updates no longer restart it.
+func (a *liveAgent) landFlight(f *flight) {
+	a.flightMu.Lock()
+	defer a.flightMu.Unlock()
+	if a.inflight == f {
+		a.inflight = nil
+	}
+}
+
+// endFlight unregisters f and cancels its last attempt.
+func (a *liveAgent) endFlight(f *flight) {
+	a.landFlight(f)
+	f.mu.Lock()
+	cancel := f.cancel
+	f.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// respondFlight has the engine answer f's utterance like [liveAgent.respond],
+// starting over whenever a priority update interrupts the engine call. The
+// caller must hold a.mu.
+func (a *liveAgent) respondFlight(ctx context.Context, f *flight) (*engine.Response, llm.Message, error) {
+	for {
+		resp, userMsg, err := a.respond(f.attempt(ctx), f.history, f.speaker, f.transcript, f.tracker)
+		if err != nil && ctx.Err() == nil && f.restarting() {
+			slog.Debug("agent: priority context update restarts reply", "npc", a.id)
+			continue
+		}
+		if err == nil {
+			f.mu.Lock()
+			f.resp = resp
+			f.mu.Unlock()
+		}
+		return resp, userMsg, err
+	}
+}
+
+// relay streams the audio of f's reply, starting with resp, and returns the
+// channel to play in its place. When a priority update interrupts the reply,
+// the rest of its audio is dropped and the reply is generated again (see
+// [liveAgent.restartFlight]). The flight ends with the audio.
+func (a *liveAgent) relay(ctx context.Context, f *flight, resp *engine.Response) <-chan []byte {
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		defer a.endFlight(f)
+		in := resp.Audio
+		for in != nil {
+			select {
+			case chunk, ok := <-in:
+				if !ok {
+					// An interrupted attempt may end its audio before the
+					// restart request is seen.
+					if f.restarting() {
+						in = a.restartFlight(ctx, f, in)
+						continue
+					}
+					return
+				}
+				select {
+				case out <- chunk:
+				case <-f.restart:
+					in = a.restartFlight(ctx, f, in)
+				}
+			case <-f.restart:
+				in = a.restartFlight(ctx, f, in)
+			}
+		}
+	}()
+	return out
+}
+
+// restartFlight drops the rest of the audio on old and has the engine answer
+// f's utterance again, now that the priority update has reached it. The new
+// reply takes the place of the interrupted one in the conversation history.
+// It returns the new reply's audio, or nil if there is none.
+//
+// The restart is a turn of its own, so like [liveAgent.HandleUtterance] it
+// holds the session's turn lock (see [WithTurnLock]) and a.mu while the
+// engine generates the reply (LLM and TTS start): the history it answers
+// from and updates must not change meanwhile, and a player's next utterance
+// waits for it instead of racing it.
+func (a *liveAgent) restartFlight(ctx context.Context, f *flight, old <-chan []byte) <-chan []byte {
+	go audio.Drain(old)
+
+	unlock, err := lockTurn(ctx)
+	if err != nil {
+		slog.Warn("agent: restarting reply failed", "npc", a.id, "err", err)
+		return nil
+	}
+	defer unlock()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	prev := f.response()
+	resp, _, err := a.respondFlight(ctx, f)
+	if err != nil {
+		slog.Warn("agent: restarting reply failed", "npc", a.id, "err", err)
+		return nil
+	}
+	a.replaceReply(prev.Text, resp.Text)
+	a.saveState(ctx)
+	return resp.Audio
+}
+
+// replaceReply replaces the NPC's interrupted reply old with text in the
+// conversation history and in the messages pending for the scene recap. A
+// reply no longer in the history, e.g. because the history policy summarised
+// it, is added as a new message. The caller must hold a.mu.
+func (a *liveAgent) replaceReply(old, text string) {
+	if text == "" || text == old {
+		return
+	}
+	if i := a.lastReply(a.messages, old); i >= 0 {
+		a.messages[i].Content = text
+	} else {
+		a.messages = append(a.messages, llm.Message{Role: "assistant", Content: text, Name: a.identity.Name})
+	}
+	if i := a.lastReply(a.recapPending, old); i >= 0 {
+		a.recapPending[i].Content = text
+	}
+}
+
+// lastReply returns the index of the last reply of the NPC in msgs if its
+// text is old, or -1.
+func (a *liveAgent) lastReply(msgs []llm.Message, old string) int {
+	for i, msg := range slices.Backward(msgs) {
+		if msg.Role == "assistant" && msg.Name == a.identity.Name {
+			if old != "" && msg.Content == old {
+				return i
+			}
+			return -1
+		}
+	}
+	return -1
+}
+
+// InjectContext implements [ContextInjector]. A priority update interrupts
+// the turn in flight, if any, once it has reached the engine.
+func (a *liveAgent) InjectContext(ctx context.Context, update engine.ContextUpdate) error {
+	if err := a.eng.InjectContext(ctx, update); err != nil {
+		return fmt.Errorf("agent: inject context: %w", err)
+	}
+	if !update.Priority {
+		return nil
+	}
+	a.flightMu.Lock()
+	f := a.inflight
+	a.flightMu.Unlock()
+	if f != nil {
+		f.interrupt()
+	}
+	return nil
+}
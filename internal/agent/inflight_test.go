@@ -0,0 +1,333 @@
+package agent_test
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/agent"
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	enginemock "github.com/MrWong99/glyphoxa/internal/engine/mock"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	audiomock "github.com/MrWong99/glyphoxa/pkg/audio/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+)
+
+// restartEngine answers the n-th Process call with process(ctx, n) and
+// records the context and prompt of every call. All other methods are those
+// of the embedded mock.
+type restartEngine struct {
+	enginemock.VoiceEngine
+
+	process func(ctx context.Context, call int) (*engine.Response, error)
+
+	mu      sync.Mutex
+	ctxs    []context.Context
+	prompts []engine.PromptContext
+}
+
+func (e *restartEngine) Process(ctx context.Context, _ audio.AudioFrame, prompt engine.PromptContext) (*engine.Response, error) {
+	e.mu.Lock()
+	call := len(e.ctxs)
+	e.ctxs = append(e.ctxs, ctx)
+	e.prompts = append(e.prompts, prompt)
+	e.mu.Unlock()
+	return e.process(ctx, call)
+}
+
+// calls returns the contexts and prompts of the Process calls so far.
+func (e *restartEngine) calls() ([]context.Context, []engine.PromptContext) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.ctxs), slices.Clone(e.prompts)
+}
+
+// speakUntilCancelled returns an audio channel repeating chunk until ctx is
+// cancelled, like a reply that is still being spoken.
+func speakUntilCancelled(ctx context.Context, chunk string) <-chan []byte {
+	ch := make(chan []byte)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case ch <- []byte(chunk):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// spoken returns an audio channel holding chunk once.
+func spoken(chunk string) <-chan []byte {
+	ch := make(chan []byte, 1)
+	ch <- []byte(chunk)
+	close(ch)
+	return ch
+}
+
+// readChunk returns the next chunk on ch, or fails the test if none arrives.
+func readChunk(t *testing.T, ch <-chan []byte) (string, bool) {
+	t.Helper()
+	select {
+	case chunk, ok := <-ch:
+		return string(chunk), ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for audio")
+		return "", false
+	}
+}
+
+// readRest returns the chunks on ch until it is closed.
+func readRest(t *testing.T, ch <-chan []byte) []string {
+	t.Helper()
+	var chunks []string
+	for {
+		chunk, ok := readChunk(t, ch)
+		if !ok {
+			return chunks
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+// fireUpdate is the urgent event the DM announces in the tests.
+var fireUpdate = engine.ContextUpdate{Scene: "The tavern is on fire!", Priority: true}
+
+const (
+	oldReply = "Let me tell you of the ancient lore."
+	newReply = "Fire! Everyone out!"
+)
+
+// newRestartAgent returns an agent with eng and a mock mixer.
+func newRestartAgent(t *testing.T, eng engine.VoiceEngine) (agent.NPCAgent, *audiomock.Mixer) {
+	t.Helper()
+	mixer := &audiomock.Mixer{}
+	cfg := validConfig()
+	cfg.Engine = eng
+	cfg.Mixer = mixer
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	return a, mixer
+}
+
+// assertReply fails the test unless the last message in a's history is the
+// NPC's reply text and the turn was recorded once.
+func assertReply(t *testing.T, a agent.NPCAgent, text string) {
+	t.Helper()
+	msgs := a.(agent.Stateful).State().Messages
+	if len(msgs) != 2 {
+		t.Fatalf("history has %d messages, want 2: %+v", len(msgs), msgs)
+	}
+	if got := msgs[1]; got.Role != "assistant" || got.Content != text {
+		t.Errorf("recorded reply = %s %q, want assistant %q", got.Role, got.Content, text)
+	}
+}
+
+func TestInjectContext_PriorityRestartsSpokenReply(t *testing.T) {
+	t.Parallel()
+
+	eng := &restartEngine{process: func(ctx context.Context, call int) (*engine.Response, error) {
+		if call == 0 {
+			return &engine.Response{Text: oldReply, Audio: speakUntilCancelled(ctx, "old")}, nil
+		}
+		return &engine.Response{Text: newReply, Audio: spoken("new")}, nil
+	}}
+	a, mixer := newRestartAgent(t, eng)
+
+	if err := a.HandleUtterance(t.Context(), "player-1", stt.Transcript{Text: "Tell me of the lore.", IsFinal: true}); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+	if len(mixer.EnqueueCalls) != 1 {
+		t.Fatalf("expected 1 Enqueue call, got %d", len(mixer.EnqueueCalls))
+	}
+	seg := mixer.EnqueueCalls[0].Segment
+	if chunk, _ := readChunk(t, seg.Audio); chunk != "old" {
+		t.Fatalf("first chunk = %q, want %q", chunk, "old")
+	}
+
+	if err := a.(agent.ContextInjector).InjectContext(t.Context(), fireUpdate); err != nil {
+		t.Fatalf("InjectContext: %v", err)
+	}
+
+	// The same segment carries on with the restarted reply.
+	rest := readRest(t, seg.Audio)
+	if len(rest) == 0 || rest[len(rest)-1] != "new" {
+		t.Fatalf("audio after the update = %q, want it to end with the restarted reply", rest)
+	}
+	if i := slices.Index(rest, "new"); i != len(rest)-1 {
+		t.Errorf("audio after the update = %q, old reply continued after the restart", rest)
+	}
+
+	ctxs, prompts := eng.calls()
+	if len(ctxs) != 2 {
+		t.Fatalf("Process calls = %d, want 2", len(ctxs))
+	}
+	if ctxs[0].Err() == nil {
+		t.Error("interrupted Process call's context not cancelled")
+	}
+	// The restart answers the same utterance, without the interrupted reply.
+	if !reflect.DeepEqual(prompts[1].Messages, prompts[0].Messages) {
+		t.Errorf("restart messages = %+v, want %+v", prompts[1].Messages, prompts[0].Messages)
+	}
+	if len(eng.InjectContextCalls) != 1 || !eng.InjectContextCalls[0].Update.Priority {
+		t.Errorf("InjectContext calls = %+v, want the priority update", eng.InjectContextCalls)
+	}
+	assertReply(t, a, newReply)
+}
+
+func TestInjectContext_PriorityRestartTakesTurnLock(t *testing.T) {
+	t.Parallel()
+
+	eng := &restartEngine{process: func(ctx context.Context, call int) (*engine.Response, error) {
+		if call == 0 {
+			return &engine.Response{Text: oldReply, Audio: speakUntilCancelled(ctx, "old")}, nil
+		}
+		return &engine.Response{Text: newReply, Audio: spoken("new")}, nil
+	}}
+	a, mixer := newRestartAgent(t, eng)
+
+	turn := make(chan struct{}, 1) // holds a token while the turn lock is held
+	ctx := agent.WithTurnLock(t.Context(), func(ctx context.Context) (func(), error) {
+		select {
+		case turn <- struct{}{}:
+			return func() { <-turn }, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	if err := a.HandleUtterance(ctx, "player-1", stt.Transcript{Text: "Tell me of the lore.", IsFinal: true}); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+	seg := mixer.EnqueueCalls[0].Segment
+	if chunk, _ := readChunk(t, seg.Audio); chunk != "old" {
+		t.Fatalf("first chunk = %q, want %q", chunk, "old")
+	}
+
+	// Another turn of the session holds the lock while the update arrives.
+	turn <- struct{}{}
+	if err := a.(agent.ContextInjector).InjectContext(t.Context(), fireUpdate); err != nil {
+		t.Fatalf("InjectContext: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if ctxs, _ := eng.calls(); len(ctxs) != 1 {
+		t.Fatalf("Process calls while the turn lock is held = %d, want 1", len(ctxs))
+	}
+
+	<-turn
+	if rest := readRest(t, seg.Audio); len(rest) == 0 || rest[len(rest)-1] != "new" {
+		t.Fatalf("audio after the update = %q, want it to end with the restarted reply", rest)
+	}
+	select {
+	case turn <- struct{}{}:
+	default:
+		t.Error("the restart did not release the turn lock")
+	}
+	assertReply(t, a, newReply)
+}
+
+func TestInjectContext_PriorityRestartsPendingReply(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	eng := &restartEngine{process: func(ctx context.Context, call int) (*engine.Response, error) {
+		if call == 0 {
+			// Still generating when the update arrives.
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &engine.Response{Text: newReply, Audio: spoken("new")}, nil
+	}}
+	a, mixer := newRestartAgent(t, eng)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- a.HandleUtterance(t.Context(), "player-1", stt.Transcript{Text: "Tell me of the lore.", IsFinal: true})
+	}()
+	<-started
+	if err := a.(agent.ContextInjector).InjectContext(t.Context(), fireUpdate); err != nil {
+		t.Fatalf("InjectContext: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+
+	if ctxs, _ := eng.calls(); len(ctxs) != 2 {
+		t.Fatalf("Process calls = %d, want 2", len(ctxs))
+	}
+	if len(mixer.EnqueueCalls) != 1 {
+		t.Fatalf("expected 1 Enqueue call, got %d", len(mixer.EnqueueCalls))
+	}
+	if got := readRest(t, mixer.EnqueueCalls[0].Segment.Audio); !slices.Equal(got, []string{"new"}) {
+		t.Errorf("audio = %q, want the restarted reply only", got)
+	}
+	assertReply(t, a, newReply)
+}
+
+func TestInjectContext_RegularUpdateKeepsReply(t *testing.T) {
+	t.Parallel()
+
+	eng := &restartEngine{process: func(ctx context.Context, _ int) (*engine.Response, error) {
+		return &engine.Response{Text: oldReply, Audio: speakUntilCancelled(ctx, "old")}, nil
+	}}
+	a, mixer := newRestartAgent(t, eng)
+
+	if err := a.HandleUtterance(t.Context(), "player-1", stt.Transcript{Text: "Tell me of the lore.", IsFinal: true}); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+	seg := mixer.EnqueueCalls[0].Segment
+	readChunk(t, seg.Audio)
+
+	update := fireUpdate
+	update.Priority = false
+	if err := a.(agent.ContextInjector).InjectContext(t.Context(), update); err != nil {
+		t.Fatalf("InjectContext: %v", err)
+	}
+	for range 3 {
+		if chunk, _ := readChunk(t, seg.Audio); chunk != "old" {
+			t.Fatalf("chunk after a regular update = %q, want %q", chunk, "old")
+		}
+	}
+
+	ctxs, _ := eng.calls()
+	if len(ctxs) != 1 {
+		t.Errorf("Process calls = %d, want 1", len(ctxs))
+	}
+	if ctxs[0].Err() != nil {
+		t.Error("Process context cancelled by a regular update")
+	}
+	assertReply(t, a, oldReply)
+}
+
+func TestInjectContext_PriorityAfterTurn(t *testing.T) {
+	t.Parallel()
+
+	eng := &restartEngine{process: func(context.Context, int) (*engine.Response, error) {
+		return &engine.Response{Text: oldReply, Audio: spoken("old")}, nil
+	}}
+	a, mixer := newRestartAgent(t, eng)
+
+	if err := a.HandleUtterance(t.Context(), "player-1", stt.Transcript{Text: "Tell me of the lore.", IsFinal: true}); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+	readRest(t, mixer.EnqueueCalls[0].Segment.Audio)
+
+	if err := a.(agent.ContextInjector).InjectContext(t.Context(), fireUpdate); err != nil {
+		t.Fatalf("InjectContext: %v", err)
+	}
+	if ctxs, _ := eng.calls(); len(ctxs) != 1 {
+		t.Errorf("Process calls = %d, want 1: a finished turn is not restarted", len(ctxs))
+	}
+	if len(eng.InjectContextCalls) != 1 {
+		t.Errorf("InjectContext calls = %d, want 1", len(eng.InjectContextCalls))
+	}
+	assertReply(t, a, oldReply)
+}
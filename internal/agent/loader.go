@@ -1,7 +1,9 @@
 package agent
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 
 	"github.com/MrWong99/glyphoxa/internal/engine"
 	"github.com/MrWong99/glyphoxa/internal/hotctx"
@@ -23,6 +25,13 @@ type Loader struct {
 	mcpHost     mcp.Host
 	mixer       audio.Mixer
 	ttsProvider tts.Provider
+	history     HistoryPolicy
+	recapper    SceneRecapper
+	recapEvery  int
+	onTurn      TurnObserver
+	classifier  IntentClassifier
+	gate        *TranscriptGate
+	states      StateStore
 	sessionID   string
 }
 
@@ -47,6 +56,47 @@ func WithTTS(provider tts.Provider) LoaderOption {
 	return func(l *Loader) { l.ttsProvider = provider }
 }
 
+// WithHistoryPolicy configures the [Loader] to inject the given [HistoryPolicy]
+// into every agent it creates, bounding each agent's conversation history.
+func WithHistoryPolicy(policy HistoryPolicy) LoaderOption {
+	return func(l *Loader) { l.history = policy }
+}
+
+// WithSceneRecap configures the [Loader] to have every agent it creates keep
+// a recap of the scene so far with r, refreshed every every turns, and inject
+// it into its prompts. An every of zero uses [DefaultRecapEvery].
+func WithSceneRecap(r SceneRecapper, every int) LoaderOption {
+	return func(l *Loader) {
+		l.recapper = r
+		l.recapEvery = every
+	}
+}
+
+// WithTurnObserver configures the [Loader] to report every completed turn of
+// the agents it creates to fn. See [TurnInfo].
+func WithTurnObserver(fn TurnObserver) LoaderOption {
+	return func(l *Loader) { l.onTurn = fn }
+}
+
+// WithIntentClassifier configures the [Loader] to detect the intents of the
+// agents' [NPCIdentity.StockResponses] with c instead of [KeywordClassifier].
+func WithIntentClassifier(c IntentClassifier) LoaderOption {
+	return func(l *Loader) { l.classifier = c }
+}
+
+// WithTranscriptGate configures the [Loader] to skip the agents' turns for
+// transcripts g rejects (see [TranscriptGate]). The gate is copied.
+func WithTranscriptGate(g TranscriptGate) LoaderOption {
+	return func(l *Loader) { l.gate = &g }
+}
+
+// WithStateStore configures the [Loader] to persist the working memory of
+// every agent it creates in s, and to restore it when [Loader.Load] recreates
+// an agent for the same NPC ID and session, for example after a restart.
+func WithStateStore(s StateStore) LoaderOption {
+	return func(l *Loader) { l.states = s }
+}
+
 // NewLoader creates a [Loader] with the given shared dependencies.
 //
 // assembler is the hot-context assembler shared by all agents created by this
@@ -79,19 +129,41 @@ func NewLoader(assembler *hotctx.Assembler, sessionID string, opts ...LoaderOpti
 // are available. If budgetTier is zero-valued it defaults to [mcp.BudgetFast].
 //
 // The loader injects its shared assembler, session ID, MCP host, and mixer into
-// the new agent automatically.
+// the new agent automatically. With a [StateStore] (see [WithStateStore]) the
+// agent starts from the [State] last saved for id in the loader's session.
+// Failing to load it is logged and the agent starts afresh.
 //
 // Errors are prefixed with "agent: ".
-func (l *Loader) Load(id string, identity NPCIdentity, eng engine.VoiceEngine, budgetTier mcp.BudgetTier) (NPCAgent, error) {
-	return NewAgent(AgentConfig{
-		ID:         id,
-		Identity:   identity,
-		Engine:     eng,
-		Assembler:  l.assembler,
-		MCPHost:    l.mcpHost,
-		Mixer:      l.mixer,
-		TTS:        l.ttsProvider,
-		SessionID:  l.sessionID,
-		BudgetTier: budgetTier,
+func (l *Loader) Load(ctx context.Context, id string, identity NPCIdentity, eng engine.VoiceEngine, budgetTier mcp.BudgetTier) (NPCAgent, error) {
+	ag, err := NewAgent(AgentConfig{
+		ID:               id,
+		Identity:         identity,
+		Engine:           eng,
+		Assembler:        l.assembler,
+		MCPHost:          l.mcpHost,
+		Mixer:            l.mixer,
+		TTS:              l.ttsProvider,
+		SessionID:        l.sessionID,
+		BudgetTier:       budgetTier,
+		HistoryPolicy:    l.history,
+		Recapper:         l.recapper,
+		RecapEvery:       l.recapEvery,
+		OnTurn:           l.onTurn,
+		IntentClassifier: l.classifier,
+		TranscriptGate:   l.gate,
+		StateStore:       l.states,
 	})
+	if err != nil || l.states == nil {
+		return ag, err
+	}
+
+	state, err := l.states.LoadState(ctx, id, l.sessionID)
+	if err != nil {
+		slog.Warn("agent: load state failed; starting afresh", "npc", id, "err", err)
+		return ag, nil
+	}
+	if s, ok := ag.(Stateful); ok && state != nil {
+		s.RestoreState(*state)
+	}
+	return ag, nil
 }
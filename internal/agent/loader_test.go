@@ -1,6 +1,12 @@
 package agent_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/MrWong99/glyphoxa/internal/agent"
@@ -10,6 +16,7 @@ import (
 	mcpmock "github.com/MrWong99/glyphoxa/internal/mcp/mock"
 	audiomock "github.com/MrWong99/glyphoxa/pkg/audio/mock"
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
 )
 
 func TestNewLoader(t *testing.T) {
@@ -40,7 +47,7 @@ func TestLoader_Load_Valid(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewLoader returned unexpected error: %v", err)
 	}
-	a, err := loader.Load("npc-1", testIdentity(), eng, mcp.BudgetFast)
+	a, err := loader.Load(context.Background(), "npc-1", testIdentity(), eng, mcp.BudgetFast)
 	if err != nil {
 		t.Fatalf("Load returned error: %v", err)
 	}
@@ -87,7 +94,7 @@ func TestLoader_Load_EmptyNPCID(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewLoader returned unexpected error: %v", err)
 	}
-	_, err = loader.Load("", testIdentity(), eng, mcp.BudgetFast)
+	_, err = loader.Load(context.Background(), "", testIdentity(), eng, mcp.BudgetFast)
 	if err == nil {
 		t.Fatal("expected error for empty NPC ID, got nil")
 	}
@@ -101,7 +108,7 @@ func TestLoader_Load_NilEngine(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewLoader returned unexpected error: %v", err)
 	}
-	_, err = loader.Load("npc-1", testIdentity(), nil, mcp.BudgetFast)
+	_, err = loader.Load(context.Background(), "npc-1", testIdentity(), nil, mcp.BudgetFast)
 	if err == nil {
 		t.Fatal("expected error for nil engine, got nil")
 	}
@@ -127,7 +134,7 @@ func TestLoader_WithMCPHost(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewLoader returned unexpected error: %v", err)
 	}
-	a, err := loader.Load("npc-1", testIdentity(), eng, mcp.BudgetStandard)
+	a, err := loader.Load(context.Background(), "npc-1", testIdentity(), eng, mcp.BudgetStandard)
 	if err != nil {
 		t.Fatalf("Load with MCPHost returned error: %v", err)
 	}
@@ -167,7 +174,7 @@ func TestLoader_WithMixer(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewLoader returned unexpected error: %v", err)
 	}
-	a, err := loader.Load("npc-1", testIdentity(), eng, mcp.BudgetFast)
+	a, err := loader.Load(context.Background(), "npc-1", testIdentity(), eng, mcp.BudgetFast)
 	if err != nil {
 		t.Fatalf("Load with Mixer returned error: %v", err)
 	}
@@ -202,11 +209,11 @@ func TestLoader_MultipleAgents(t *testing.T) {
 	id2 := testIdentity()
 	id2.Name = "NPC Beta"
 
-	a1, err := loader.Load("npc-alpha", id1, eng1, mcp.BudgetFast)
+	a1, err := loader.Load(context.Background(), "npc-alpha", id1, eng1, mcp.BudgetFast)
 	if err != nil {
 		t.Fatalf("Load npc-alpha: %v", err)
 	}
-	a2, err := loader.Load("npc-beta", id2, eng2, mcp.BudgetDeep)
+	a2, err := loader.Load(context.Background(), "npc-beta", id2, eng2, mcp.BudgetDeep)
 	if err != nil {
 		t.Fatalf("Load npc-beta: %v", err)
 	}
@@ -221,3 +228,126 @@ func TestLoader_MultipleAgents(t *testing.T) {
 		t.Errorf("a2.Name() = %q, want %q", a2.Name(), "NPC Beta")
 	}
 }
+
+// memStateStore is an in-memory [agent.StateStore] that round-trips states
+// through JSON, as a database would.
+type memStateStore struct {
+	mu      sync.Mutex
+	states  map[string][]byte // keyed by npcID + "/" + sessionID
+	loadErr error
+}
+
+func (s *memStateStore) SaveState(_ context.Context, npcID, sessionID string, state agent.State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.states == nil {
+		s.states = map[string][]byte{}
+	}
+	s.states[npcID+"/"+sessionID] = data
+	return nil
+}
+
+func (s *memStateStore) LoadState(_ context.Context, npcID, sessionID string) (*agent.State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loadErr != nil {
+		return nil, s.loadErr
+	}
+	data, ok := s.states[npcID+"/"+sessionID]
+	if !ok {
+		return nil, nil
+	}
+	var state agent.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func TestLoader_Load_RestoresState(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := &memStateStore{}
+	loader, err := agent.NewLoader(testAssembler(), "session-001", agent.WithStateStore(store))
+	if err != nil {
+		t.Fatalf("NewLoader: %v", err)
+	}
+
+	eng1 := &enginemock.VoiceEngine{
+		ProcessResult: &engine.Response{Text: "The mine collapsed.", Audio: closedAudioCh()},
+	}
+	a1, err := loader.Load(ctx, "npc-1", testIdentity(), eng1, mcp.BudgetFast)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := a1.UpdateScene(ctx, agent.SceneContext{Location: "Thornwood Tavern"}); err != nil {
+		t.Fatalf("UpdateScene: %v", err)
+	}
+	a1.(agent.Stateful).SetMood("wary")
+	if err := a1.HandleUtterance(ctx, "player-1", stt.Transcript{Text: "Any news?", IsFinal: true}); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+	want := a1.(agent.Stateful).State()
+
+	// Recreate the agent with a fresh engine, as after a restart.
+	eng2 := &enginemock.VoiceEngine{
+		ProcessResult: &engine.Response{Text: "Nobody knows why.", Audio: closedAudioCh()},
+	}
+	a2, err := loader.Load(ctx, "npc-1", testIdentity(), eng2, mcp.BudgetFast)
+	if err != nil {
+		t.Fatalf("Load again: %v", err)
+	}
+	got := a2.(agent.Stateful).State()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("restored State() = %+v, want %+v", got, want)
+	}
+	if len(got.Messages) != 2 || got.Mood != "wary" || got.Scene.Location != "Thornwood Tavern" {
+		t.Errorf("restored State() = %+v, want the turn, mood and scene", got)
+	}
+
+	if err := a2.HandleUtterance(ctx, "player-1", stt.Transcript{Text: "And then?", IsFinal: true}); err != nil {
+		t.Fatalf("HandleUtterance after restore: %v", err)
+	}
+	prompt := eng2.ProcessCalls[0].Prompt
+	if len(prompt.Messages) != 3 || prompt.Messages[0].Content != "Any news?" || prompt.Messages[1].Content != "The mine collapsed." {
+		t.Errorf("prompt messages = %+v, want the restored history before the new utterance", prompt.Messages)
+	}
+	if !strings.Contains(prompt.SystemPrompt, "## Your Current Mood\nwary") {
+		t.Errorf("system prompt lacks the restored mood:\n%s", prompt.SystemPrompt)
+	}
+
+	// Another session starts afresh.
+	other, err := agent.NewLoader(testAssembler(), "session-002", agent.WithStateStore(store))
+	if err != nil {
+		t.Fatalf("NewLoader: %v", err)
+	}
+	a3, err := other.Load(ctx, "npc-1", testIdentity(), &enginemock.VoiceEngine{}, mcp.BudgetFast)
+	if err != nil {
+		t.Fatalf("Load in other session: %v", err)
+	}
+	if s := a3.(agent.Stateful).State(); len(s.Messages) != 0 || s.Mood != "" {
+		t.Errorf("State() in other session = %+v, want empty", s)
+	}
+}
+
+func TestLoader_Load_StateLoadError(t *testing.T) {
+	t.Parallel()
+
+	store := &memStateStore{loadErr: errors.New("connection refused")}
+	loader, err := agent.NewLoader(testAssembler(), "session-001", agent.WithStateStore(store))
+	if err != nil {
+		t.Fatalf("NewLoader: %v", err)
+	}
+	a, err := loader.Load(context.Background(), "npc-1", testIdentity(), &enginemock.VoiceEngine{}, mcp.BudgetFast)
+	if err != nil {
+		t.Fatalf("Load: want the agent to start afresh, got error %v", err)
+	}
+	if s := a.(agent.Stateful).State(); len(s.Messages) != 0 {
+		t.Errorf("State() = %+v, want empty", s)
+	}
+}
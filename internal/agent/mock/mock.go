@@ -27,8 +27,9 @@ import (
 
 // Compile-time interface assertions.
 var (
-	_ agent.NPCAgent = (*NPCAgent)(nil)
-	_ agent.Router   = (*Router)(nil)
+	_ agent.NPCAgent        = (*NPCAgent)(nil)
+	_ agent.ContextInjector = (*NPCAgent)(nil)
+	_ agent.Router          = (*Router)(nil)
 )
 
 // ─── NPCAgent ─────────────────────────────────────────────────────────────────
@@ -47,7 +48,14 @@ type UpdateSceneCall struct {
 	Scene agent.SceneContext
 }
 
-// NPCAgent is a mock implementation of [agent.NPCAgent].
+// InjectContextCall records the arguments of a single [NPCAgent.InjectContext] invocation.
+type InjectContextCall struct {
+	// Update is the context update passed to InjectContext.
+	Update engine.ContextUpdate
+}
+
+// NPCAgent is a mock implementation of [agent.NPCAgent] and
+// [agent.ContextInjector].
 type NPCAgent struct {
 	mu sync.Mutex
 
@@ -72,12 +80,18 @@ type NPCAgent struct {
 	// SpeakTextError is returned by [NPCAgent.SpeakText].
 	SpeakTextError error
 
+	// InjectContextError is returned by [NPCAgent.InjectContext].
+	InjectContextError error
+
 	// HandleUtteranceCalls records all HandleUtterance invocations.
 	HandleUtteranceCalls []HandleUtteranceCall
 
 	// UpdateSceneCalls records all UpdateScene invocations.
 	UpdateSceneCalls []UpdateSceneCall
 
+	// InjectContextCalls records all InjectContext invocations.
+	InjectContextCalls []InjectContextCall
+
 	// CallCountID records how many times ID was called.
 	CallCountID int
 
@@ -145,6 +159,20 @@ func (n *NPCAgent) UpdateScene(_ context.Context, scene agent.SceneContext) erro
 	return n.UpdateSceneError
 }
 
+// InjectContext implements [agent.ContextInjector]. Records the call and
+// returns InjectContextError. Like the live agent, it otherwise passes the
+// update on to EngineResult, if set.
+func (n *NPCAgent) InjectContext(ctx context.Context, update engine.ContextUpdate) error {
+	n.mu.Lock()
+	n.InjectContextCalls = append(n.InjectContextCalls, InjectContextCall{Update: update})
+	err, eng := n.InjectContextError, n.EngineResult
+	n.mu.Unlock()
+	if err != nil || eng == nil {
+		return err
+	}
+	return eng.InjectContext(ctx, update)
+}
+
 // SpeakText implements [agent.NPCAgent]. Records the text and returns SpeakTextError.
 func (n *NPCAgent) SpeakText(_ context.Context, text string) error {
 	n.mu.Lock()
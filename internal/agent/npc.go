@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -19,7 +23,12 @@ import (
 )
 
 // Compile-time interface check: liveAgent must satisfy NPCAgent.
-var _ NPCAgent = (*liveAgent)(nil)
+var (
+	_ NPCAgent        = (*liveAgent)(nil)
+	_ PromptRenderer  = (*liveAgent)(nil)
+	_ Stateful        = (*liveAgent)(nil)
+	_ ContextInjector = (*liveAgent)(nil)
+)
 
 // AgentConfig holds all dependencies needed to create a [liveAgent].
 //
@@ -61,6 +70,37 @@ type AgentConfig struct {
 	// TTS is an optional TTS provider used by [liveAgent.SpeakText] for
 	// direct text-to-speech synthesis. When nil, SpeakText returns an error.
 	TTS tts.Provider
+
+	// HistoryPolicy optionally bounds the conversation history kept between
+	// turns. When nil, the history grows for the lifetime of the agent.
+	HistoryPolicy HistoryPolicy
+
+	// Recapper optionally maintains a recap of the scene so far that is
+	// injected into every prompt. When nil, prompts carry no recap.
+	Recapper SceneRecapper
+
+	// RecapEvery is the number of turns between two refreshes of the scene
+	// recap. Zero uses [DefaultRecapEvery]. Unused without a Recapper.
+	RecapEvery int
+
+	// OnTurn is optionally called once for every completed turn of
+	// [liveAgent.HandleUtterance], after the reply audio finished streaming.
+	OnTurn TurnObserver
+
+	// IntentClassifier detects utterances answered with one of the NPC's
+	// [NPCIdentity.StockResponses]. Defaults to [KeywordClassifier] when nil.
+	// Unused for NPCs without stock responses.
+	IntentClassifier IntentClassifier
+
+	// StateStore optionally persists the agent's working memory (see [State])
+	// after every turn, puppet line and scene update. Restoring it is up to
+	// the caller, as [Loader.Load] does.
+	StateStore StateStore
+
+	// TranscriptGate optionally skips turns for empty or low-confidence
+	// transcripts instead of answering noise. When nil, every transcript is
+	// answered.
+	TranscriptGate *TranscriptGate
 }
 
 // defaultAudioPriority is the priority used when enqueuing NPC audio segments.
@@ -79,18 +119,44 @@ type liveAgent struct {
 	mcpHost     mcp.Host     // may be nil if no tools
 	mixer       audio.Mixer  // may be nil if not using mixer
 	ttsProvider tts.Provider // may be nil; required for SpeakText
+	ttsFormat   audio.Format // format of the PCM ttsProvider synthesises
 	sessionID   string
 	budgetTier  mcp.BudgetTier
-
-	mu       sync.Mutex
-	scene    SceneContext
-	messages []llm.Message // recent conversation history
+	history     HistoryPolicy // may be nil; history is then unbounded
+	onTurn      TurnObserver  // may be nil
+	classifier  IntentClassifier
+	recapper    SceneRecapper // may be nil; prompts then carry no recap
+	recapEvery  int
+	states      StateStore      // may be nil; working memory is then not persisted
+	gate        *TranscriptGate // may be nil; every transcript is then answered
+
+	mu        sync.Mutex
+	stockRand *rand.Rand // picks stock lines; seeded per session
+	scene     SceneContext
+	messages  []llm.Message // recent conversation history
+	greeted   bool          // whether the cold-open greeting has been given
+	mood      string        // current mood shown in the system prompt
+
+	recap        string        // scene recap injected into every prompt
+	recapPending []llm.Message // messages not yet folded into recap
+	recapTurns   int           // turns since the last refresh of recap
 
 	// toolCtxMu guards toolCtx independently from mu to avoid deadlock
 	// when tool calls are invoked from engine background goroutines while
 	// mu is held by HandleUtterance.
 	toolCtxMu sync.Mutex
 	toolCtx   context.Context
+	turn      *turnTracker // tool calls of the active turn; nil without onTurn
+
+	// flightMu guards inflight independently from mu, so that a priority
+	// context update can interrupt a turn while HandleUtterance holds mu.
+	flightMu sync.Mutex
+	inflight *flight // engine turn still generating or streaming; may be nil
+
+	// stockMu guards stockAudio, which caches the synthesised audio of stock
+	// lines by text. It is filled after a.mu has been released.
+	stockMu    sync.Mutex
+	stockAudio map[string][][]byte
 }
 
 // NewAgent creates a concrete [NPCAgent] from the given configuration.
@@ -121,8 +187,23 @@ func NewAgent(cfg AgentConfig) (NPCAgent, error) {
 		mcpHost:     cfg.MCPHost,
 		mixer:       cfg.Mixer,
 		ttsProvider: cfg.TTS,
+		ttsFormat:   engine.TTSFormat(cfg.TTS),
 		sessionID:   cfg.SessionID,
 		budgetTier:  cfg.BudgetTier,
+		history:     cfg.HistoryPolicy,
+		onTurn:      cfg.OnTurn,
+		classifier:  cfg.IntentClassifier,
+		recapper:    cfg.Recapper,
+		recapEvery:  cfg.RecapEvery,
+		states:      cfg.StateStore,
+		gate:        cfg.TranscriptGate,
+		stockRand:   newStockRand(cfg.SessionID, cfg.ID),
+	}
+	if a.classifier == nil {
+		a.classifier = KeywordClassifier{}
+	}
+	if a.recapEvery <= 0 {
+		a.recapEvery = DefaultRecapEvery
 	}
 
 	// Wire MCP tools into the engine when a host is provided.
@@ -135,11 +216,14 @@ func NewAgent(cfg AgentConfig) (NPCAgent, error) {
 			// Use the context from the active HandleUtterance call so that
 			// tool execution respects session cancellation.
 			a.toolCtxMu.Lock()
-			ctx := a.toolCtx
+			ctx, turn := a.toolCtx, a.turn
 			a.toolCtxMu.Unlock()
 			if ctx == nil {
 				ctx = context.Background()
 			}
+			if turn != nil {
+				turn.addTool(name)
+			}
 			result, err := cfg.MCPHost.ExecuteTool(ctx, name, args)
 			if err != nil {
 				return "", fmt.Errorf("agent: execute tool %q: %w", name, err)
@@ -170,9 +254,28 @@ func (a *liveAgent) Engine() engine.VoiceEngine { return a.eng }
 //  1. Assembles hot context via the [hotctx.Assembler].
 //  2. Formats a system prompt from the hot context and NPC personality.
 //  3. Builds a [engine.PromptContext] with the system prompt, messages, and budget tier.
-//  4. Calls [engine.VoiceEngine.Process] with a synthetic (empty) audio frame.
-//  5. Enqueues the response audio to the mixer (if set).
-//  6. Records the exchange in the conversation history.
+//  4. Calls [engine.VoiceEngine.Process] with a synthetic (empty) audio frame,
+//     or generates a greeting instead if this is the NPC's cold open (see
+//     [NPCIdentity.ColdOpen]).
+//  5. Enqueues the response audio to the mixer (if set). If playback is cut
+//     short and the engine implements [engine.Interruptible], the engine is
+//     told so it can let the NPC react on its next turn. Until the audio has
+//     finished streaming, a priority update passed to
+//     [liveAgent.InjectContext] restarts steps 1.–4. for the same utterance
+//     and replaces the reply.
+//  6. Records the exchange in the conversation history, refreshing the
+//     scene recap when it is due (see [SceneRecapper]), and saves the
+//     agent's [State] if it has a [StateStore].
+//
+// Utterances the [IntentClassifier] assigns an intent with
+// [NPCIdentity.StockResponses] skip steps 1.–4.: a stock line is picked and
+// synthesised with the agent's TTS provider instead.
+//
+// Transcripts rejected by the agent's [TranscriptGate] skip the turn
+// entirely: nothing is recorded and only the gate's reply, if any, is played.
+//
+// When a [TurnObserver] is configured, it is called with the turn's
+// [TurnInfo] once the reply audio has finished streaming.
 //
 // HandleUtterance respects context cancellation. Concurrent calls are serialised
 // via an internal mutex.
@@ -182,31 +285,208 @@ func (a *liveAgent) HandleUtterance(ctx context.Context, speaker string, transcr
 		return fmt.Errorf("agent: %w", err)
 	}
 
+	start := time.Now()
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	recorded := false
+	defer func() {
+		a.mu.Unlock()
+		// The history policy may call a model, so it runs without the lock.
+		if recorded {
+			a.applyHistoryPolicy(ctx)
+		}
+	}()
 
 	// Check context again after acquiring the lock (we may have waited).
 	if err := ctx.Err(); err != nil {
 		return fmt.Errorf("agent: %w", err)
 	}
 
-	// 1. Assemble hot context.
+	if a.gate != nil && !a.gate.Pass(transcript) {
+		slog.Debug("agent: skipped turn for unclear transcript",
+			"npc", a.id, "speaker", speaker, "text", transcript.Text, "confidence", transcriptConfidence(transcript))
+		if err := a.gateReply(ctx); err != nil {
+			return fmt.Errorf("agent: gate reply: %w", err)
+		}
+		return nil
+	}
+
+	var tracker *turnTracker
+	if a.onTurn != nil {
+		tracker = &turnTracker{}
+	}
+
+	var (
+		resp    *engine.Response
+		userMsg llm.Message
+		f       *flight // nil for stock lines, which cannot be restarted
+		err     error
+	)
+	line, stock := a.stockLine(transcript.Text)
+	if stock {
+		// Stock lines skip the engine and hot context assembly entirely.
+		resp, err = a.stockResponse(ctx, line)
+		if err != nil {
+			return fmt.Errorf("agent: stock response: %w", err)
+		}
+		userMsg = llm.Message{Role: "user", Content: transcript.Text, Name: speaker}
+	} else {
+		f = a.startFlight(speaker, transcript, tracker)
+		resp, userMsg, err = a.respondFlight(ctx, f)
+		if err != nil {
+			a.endFlight(f)
+			return err
+		}
+	}
+
+	replyAudio := resp.Audio
+	current := func() *engine.Response { return resp }
+	if f != nil {
+		current = f.response
+		if replyAudio != nil {
+			replyAudio = a.relay(ctx, f, resp)
+		} else {
+			a.endFlight(f)
+		}
+	}
+
+	if a.onTurn != nil {
+		replyAudio = a.observeTurn(replyAudio, current, TurnInfo{
+			NPCID:      a.id,
+			NPCName:    a.identity.Name,
+			Speaker:    speaker,
+			Input:      transcript.Text,
+			BudgetTier: a.budgetTier,
+			StockLine:  stock,
+		}, start, tracker)
+	}
+
+	// 5. Enqueue response audio to mixer (if set), otherwise drain.
+	if a.mixer != nil && replyAudio != nil {
+		seg := &audio.AudioSegment{
+			NPCID:      a.id,
+			Audio:      replyAudio,
+			SampleRate: resp.SampleRate,
+			Channels:   resp.Channels,
+			Priority:   defaultAudioPriority,
+		}
+		if f != nil {
+			// Stock lines bypass the engine, so it only hears about its own replies.
+			ir, _ := a.eng.(engine.Interruptible)
+			seg.OnInterrupt = func() {
+				// A reply nobody hears any more is not worth restarting.
+				a.landFlight(f)
+				if ir != nil {
+					ir.Interrupted()
+				}
+			}
+		}
+		a.mixer.Enqueue(seg, defaultAudioPriority)
+	} else if replyAudio != nil {
+		// Drain audio channel to avoid blocking the engine pipeline.
+		go func(ch <-chan []byte) {
+			for range ch {
+			}
+		}(replyAudio)
+	}
+
+	// 6. Record the exchange in conversation history.
+	n := len(a.messages)
+	a.messages = append(a.messages, userMsg)
+	if resp.Text != "" {
+		a.messages = append(a.messages, llm.Message{
+			Role:    "assistant",
+			Content: resp.Text,
+			Name:    a.identity.Name,
+		})
+	}
+	a.recordRecapTurn(ctx, a.messages[n:])
+	a.saveState(ctx)
+	recorded = true
+
+	return nil
+}
+
+// respond runs steps 1.–4. of [liveAgent.HandleUtterance]: it builds the
+// prompt for transcript following history and has the engine answer it, or
+// greet instead on the NPC's cold open. Tool calls of the turn are recorded
+// in tracker, which may be nil. It returns the engine's response and the user
+// message to record. The caller must hold a.mu.
+func (a *liveAgent) respond(ctx context.Context, history []llm.Message, speaker string, transcript stt.Transcript, tracker *turnTracker) (*engine.Response, llm.Message, error) {
+	// 1.–3. Assemble hot context, format the system prompt and build the
+	// prompt context with the history and the user's new utterance.
+	promptCtx, userMsg, err := a.buildPrompt(ctx, history, speaker, transcript)
+	if err != nil {
+		return nil, llm.Message{}, err
+	}
+
+	// 4. Create a synthetic audio frame (cascaded mode: STT already ran).
+	frame := audio.AudioFrame{
+		Data:       nil,
+		SampleRate: 16000,
+		Channels:   1,
+		Timestamp:  0,
+	}
+
+	// Store the context for tool call handlers that may run in engine
+	// background goroutines (e.g., cascade strong-model stage).
+	a.toolCtxMu.Lock()
+	a.toolCtx = ctx
+	a.turn = tracker
+	a.toolCtxMu.Unlock()
+
+	if a.identity.ColdOpen && !a.greeted {
+		resp, err := a.greet(ctx, frame, promptCtx)
+		if err != nil {
+			return nil, llm.Message{}, fmt.Errorf("agent: cold open: %w", err)
+		}
+		a.greeted = true
+		return resp, userMsg, nil
+	}
+	resp, err := a.eng.Process(ctx, frame, promptCtx)
+	if err != nil {
+		return nil, llm.Message{}, fmt.Errorf("agent: engine process: %w", err)
+	}
+	return resp, userMsg, nil
+}
+
+// buildPrompt assembles the hot context for transcript, formats the system
+// prompt from it and the NPC's identity, and builds the prompt context with
+// the conversation history followed by the user's new utterance, which is
+// also returned. The caller must hold a.mu.
+func (a *liveAgent) buildPrompt(ctx context.Context, history []llm.Message, speaker string, transcript stt.Transcript) (engine.PromptContext, llm.Message, error) {
 	hctx, err := a.assembler.Assemble(ctx, a.id, a.sessionID)
 	if err != nil {
-		return fmt.Errorf("agent: assemble hot context: %w", err)
+		return engine.PromptContext{}, llm.Message{}, fmt.Errorf("agent: assemble hot context: %w", err)
 	}
+	if r := a.identity.AwarenessRadius; r > 0 {
+		// Retrieval is best-effort: the NPC can still answer without it.
+		knowledge, err := a.assembler.Retrieve(ctx, a.id, transcript.Text, r)
+		if err != nil {
+			slog.Warn("agent: knowledge retrieval failed", "npc", a.id, "err", err)
+		}
+		hctx.Knowledge = knowledge
+	}
+	if k := a.identity.StyleExemplars; k > 0 {
+		// Best-effort as well: without exemplars the NPC merely risks drifting.
+		lines, err := a.assembler.StyleExemplars(ctx, a.id, k)
+		if err != nil {
+			slog.Warn("agent: style exemplars failed", "npc", a.id, "err", err)
+		}
+		hctx.StyleExemplars = lines
+	}
+	hctx.Rules = a.identity.BehaviorRules
+	hctx.SceneRecap = a.recap
+	hctx.Mood = a.mood
 
-	// 2. Format system prompt.
 	systemPrompt := hotctx.FormatSystemPrompt(hctx, a.identity.Personality)
 
-	// 3. Build prompt context with current messages + the user's new utterance.
 	userMsg := llm.Message{
 		Role:    "user",
 		Content: transcript.Text,
 		Name:    speaker,
 	}
-	msgs := make([]llm.Message, len(a.messages), len(a.messages)+1)
-	copy(msgs, a.messages)
+	msgs := make([]llm.Message, len(history), len(history)+1)
+	copy(msgs, history)
 	msgs = append(msgs, userMsg)
 
 	// Build the hot context string from the assembled hot context.
@@ -225,61 +505,45 @@ func (a *liveAgent) HandleUtterance(ctx context.Context, speaker string, transcr
 		hotContextStr = strings.Join(parts, "; ")
 	}
 
-	promptCtx := engine.PromptContext{
+	return engine.PromptContext{
 		SystemPrompt: systemPrompt,
 		HotContext:   hotContextStr,
 		Messages:     msgs,
 		BudgetTier:   a.budgetTier,
-	}
-
-	// 4. Create a synthetic audio frame (cascaded mode: STT already ran).
-	frame := audio.AudioFrame{
-		Data:       nil,
-		SampleRate: 16000,
-		Channels:   1,
-		Timestamp:  0,
-	}
+		Language:     transcript.Language,
+	}, userMsg, nil
+}
 
-	// Store the context for tool call handlers that may run in engine
-	// background goroutines (e.g., cascade strong-model stage).
-	a.toolCtxMu.Lock()
-	a.toolCtx = ctx
-	a.toolCtxMu.Unlock()
+// RenderPrompt implements [PromptRenderer]. It holds the agent's lock, so it
+// waits for a turn in progress to finish.
+func (a *liveAgent) RenderPrompt(ctx context.Context, input string, scene *SceneContext) (engine.PromptContext, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-	resp, err := a.eng.Process(ctx, frame, promptCtx)
+	prompt, _, err := a.buildPrompt(ctx, a.messages, "", stt.Transcript{Text: input})
 	if err != nil {
-		return fmt.Errorf("agent: engine process: %w", err)
+		return engine.PromptContext{}, err
 	}
-
-	// 5. Enqueue response audio to mixer (if set), otherwise drain.
-	if a.mixer != nil && resp.Audio != nil {
-		seg := &audio.AudioSegment{
-			NPCID:      a.id,
-			Audio:      resp.Audio,
-			SampleRate: resp.SampleRate,
-			Channels:   resp.Channels,
-			Priority:   defaultAudioPriority,
+	if scene != nil {
+		prompt.HotContext = formatScene(*scene)
+	}
+	if a.identity.ColdOpen && !a.greeted {
+		if _, ok := a.eng.(engine.Greeter); !ok {
+			prompt.SystemPrompt += "\n\n" + engine.DefaultGreetingInstruction
 		}
-		a.mixer.Enqueue(seg, defaultAudioPriority)
-	} else if resp.Audio != nil {
-		// Drain audio channel to avoid blocking the engine pipeline.
-		go func(ch <-chan []byte) {
-			for range ch {
-			}
-		}(resp.Audio)
 	}
+	return prompt, nil
+}
 
-	// 6. Record the exchange in conversation history.
-	a.messages = append(a.messages, userMsg)
-	if resp.Text != "" {
-		a.messages = append(a.messages, llm.Message{
-			Role:    "assistant",
-			Content: resp.Text,
-			Name:    a.identity.Name,
-		})
+// greet generates the NPC's cold-open greeting. Engines implementing
+// [engine.Greeter] produce it themselves; for all others the greeting
+// instruction is appended to the system prompt of a regular Process call.
+func (a *liveAgent) greet(ctx context.Context, frame audio.AudioFrame, prompt engine.PromptContext) (*engine.Response, error) {
+	if g, ok := a.eng.(engine.Greeter); ok {
+		return g.Greet(ctx, prompt)
 	}
-
-	return nil
+	prompt.SystemPrompt += "\n\n" + engine.DefaultGreetingInstruction
+	return a.eng.Process(ctx, frame, prompt)
 }
 
 // UpdateScene pushes a new scene context to the NPC. The scene is stored
@@ -291,6 +555,7 @@ func (a *liveAgent) UpdateScene(ctx context.Context, scene SceneContext) error {
 	// with each other and cannot be interleaved with a concurrent HandleUtterance.
 	a.mu.Lock()
 	a.scene = scene
+	a.saveState(ctx)
 	recentEntries := make([]memory.TranscriptEntry, 0, len(a.messages))
 	for _, msg := range a.messages {
 		recentEntries = append(recentEntries, memory.TranscriptEntry{
@@ -303,6 +568,21 @@ func (a *liveAgent) UpdateScene(ctx context.Context, scene SceneContext) error {
 	a.mu.Unlock()
 
 	// Build a scene description string outside the lock; scene is a value copy.
+	update := engine.ContextUpdate{
+		Scene:            formatScene(scene),
+		RecentUtterances: recentEntries,
+	}
+
+	if err := a.eng.InjectContext(ctx, update); err != nil {
+		return fmt.Errorf("agent: inject context: %w", err)
+	}
+
+	return nil
+}
+
+// formatScene renders scene as the short scene description engines receive
+// as hot context.
+func formatScene(scene SceneContext) string {
 	var parts []string
 	if scene.Location != "" {
 		parts = append(parts, "Location: "+scene.Location)
@@ -316,18 +596,7 @@ func (a *liveAgent) UpdateScene(ctx context.Context, scene SceneContext) error {
 	if len(scene.ActiveQuests) > 0 {
 		parts = append(parts, "Quests: "+strings.Join(scene.ActiveQuests, ", "))
 	}
-	sceneStr := strings.Join(parts, "; ")
-
-	update := engine.ContextUpdate{
-		Scene:            sceneStr,
-		RecentUtterances: recentEntries,
-	}
-
-	if err := a.eng.InjectContext(ctx, update); err != nil {
-		return fmt.Errorf("agent: inject context: %w", err)
-	}
-
-	return nil
+	return strings.Join(parts, "; ")
 }
 
 // SpeakText synthesises the given text using this NPC's TTS voice without
@@ -343,7 +612,7 @@ func (a *liveAgent) SpeakText(ctx context.Context, text string) error {
 	textCh <- text
 	close(textCh)
 
-	audioCh, err := a.ttsProvider.SynthesizeStream(ctx, textCh, a.identity.Voice)
+	stream, err := a.ttsProvider.SynthesizeStream(ctx, textCh, a.identity.Voice)
 	if err != nil {
 		return fmt.Errorf("agent: speak text: %w", err)
 	}
@@ -351,15 +620,17 @@ func (a *liveAgent) SpeakText(ctx context.Context, text string) error {
 	// Enqueue the audio in the mixer at default priority.
 	if a.mixer != nil {
 		seg := &audio.AudioSegment{
-			NPCID:    a.id,
-			Audio:    audioCh,
-			Priority: defaultAudioPriority,
+			NPCID:      a.id,
+			Audio:      stream.Audio(),
+			SampleRate: a.ttsFormat.SampleRate,
+			Channels:   a.ttsFormat.Channels,
+			Priority:   defaultAudioPriority,
 		}
 		a.mixer.Enqueue(seg, defaultAudioPriority)
 	} else {
 		// Drain to avoid blocking.
 		go func() {
-			for range audioCh {
+			for range stream.Audio() {
 			}
 		}()
 	}
@@ -371,7 +642,67 @@ func (a *liveAgent) SpeakText(ctx context.Context, text string) error {
 		Content: text,
 		Name:    a.identity.Name,
 	})
+	if a.recapper != nil {
+		// Puppet lines belong in the recap but do not count as turns.
+		a.recapPending = append(a.recapPending, a.messages[len(a.messages)-1])
+	}
+	a.saveState(ctx)
 	a.mu.Unlock()
 
+	a.applyHistoryPolicy(ctx)
 	return nil
 }
+
+// applyHistoryPolicy bounds a.messages using the configured [HistoryPolicy].
+// The policy may summarise with a model, so it is applied to a snapshot of
+// the history without holding a.mu; if the history changed in the meantime,
+// the result is dropped and the next turn tries again. Failures are logged
+// and leave the history untouched so that a flaky summariser never breaks
+// the conversation. The caller must not hold a.mu.
+func (a *liveAgent) applyHistoryPolicy(ctx context.Context) {
+	if a.history == nil {
+		return
+	}
+	a.mu.Lock()
+	snapshot := slices.Clone(a.messages)
+	a.mu.Unlock()
+
+	msgs, err := a.history.Apply(ctx, snapshot)
+	if err != nil {
+		slog.Warn("agent: history policy failed; keeping full history", "npc", a.id, "err", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !reflect.DeepEqual(a.messages, snapshot) {
+		slog.Debug("agent: history changed during history policy; dropping result", "npc", a.id)
+		return
+	}
+	a.messages = msgs
+	a.saveState(ctx)
+}
+
+// recordRecapTurn adds the messages of a completed turn to the ones pending
+// for the scene recap and refreshes the recap every a.recapEvery turns.
+// Failures are logged and keep the pending messages for the next refresh, so
+// that a flaky recapper never breaks the conversation. The caller must hold
+// a.mu.
+func (a *liveAgent) recordRecapTurn(ctx context.Context, msgs []llm.Message) {
+	if a.recapper == nil {
+		return
+	}
+	a.recapPending = append(a.recapPending, msgs...)
+	a.recapTurns++
+	if a.recapTurns < a.recapEvery {
+		return
+	}
+	recap, err := a.recapper.Recap(ctx, a.recap, a.recapPending)
+	if err != nil {
+		slog.Warn("agent: scene recap failed; keeping previous recap", "npc", a.id, "err", err)
+		return
+	}
+	a.recap = strings.TrimSpace(recap)
+	a.recapPending = nil
+	a.recapTurns = 0
+}
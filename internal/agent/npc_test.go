@@ -3,8 +3,11 @@ package agent_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/MrWong99/glyphoxa/internal/agent"
 	"github.com/MrWong99/glyphoxa/internal/engine"
@@ -12,9 +15,13 @@ import (
 	"github.com/MrWong99/glyphoxa/internal/hotctx"
 	"github.com/MrWong99/glyphoxa/internal/mcp"
 	mcpmock "github.com/MrWong99/glyphoxa/internal/mcp/mock"
+	"github.com/MrWong99/glyphoxa/internal/session"
 	audiomock "github.com/MrWong99/glyphoxa/pkg/audio/mock"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
 	memorymock "github.com/MrWong99/glyphoxa/pkg/memory/mock"
+	embeddingsmock "github.com/MrWong99/glyphoxa/pkg/provider/embeddings/mock"
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
 	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
 )
@@ -194,8 +201,9 @@ func TestHandleUtterance_Success(t *testing.T) {
 	}
 
 	transcript := stt.Transcript{
-		Text:    "Tell me about the ancient lore.",
-		IsFinal: true,
+		Text:     "Tell me about the ancient lore.",
+		IsFinal:  true,
+		Language: "en-GB",
 	}
 
 	err = a.HandleUtterance(context.Background(), "player-1", transcript)
@@ -208,6 +216,11 @@ func TestHandleUtterance_Success(t *testing.T) {
 		t.Errorf("expected 1 Process call, got %d", len(eng.ProcessCalls))
 	}
 
+	// Verify the detected language is passed on for synthesis.
+	if len(eng.ProcessCalls) > 0 && eng.ProcessCalls[0].Prompt.Language != "en-GB" {
+		t.Errorf("prompt language = %q, want %q", eng.ProcessCalls[0].Prompt.Language, "en-GB")
+	}
+
 	// Verify prompt contains the transcript text.
 	if len(eng.ProcessCalls) > 0 {
 		call := eng.ProcessCalls[0]
@@ -270,6 +283,44 @@ func TestHandleUtterance_NilMixer(t *testing.T) {
 	}
 }
 
+func TestHandleUtterance_InterruptNotifiesEngine(t *testing.T) {
+	t.Parallel()
+
+	audioCh := make(chan []byte)
+	close(audioCh)
+
+	eng := &enginemock.InterruptibleEngine{
+		VoiceEngine: enginemock.VoiceEngine{
+			ProcessResult: &engine.Response{Text: "As I was saying...", Audio: audioCh},
+		},
+	}
+	mixer := &audiomock.Mixer{}
+
+	cfg := validConfig()
+	cfg.Engine = eng
+	cfg.Mixer = mixer
+
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: "Hello.", IsFinal: true}); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+
+	if len(mixer.EnqueueCalls) != 1 {
+		t.Fatalf("expected 1 Enqueue call, got %d", len(mixer.EnqueueCalls))
+	}
+	seg := mixer.EnqueueCalls[0].Segment
+	if seg.OnInterrupt == nil {
+		t.Fatal("segment OnInterrupt is nil for an interruptible engine")
+	}
+	seg.OnInterrupt()
+	if got := eng.CallCountInterrupted.Load(); got != 1 {
+		t.Errorf("Interrupted calls = %d, want 1", got)
+	}
+}
+
 func TestHandleUtterance_AssemblerError(t *testing.T) {
 	t.Parallel()
 
@@ -611,3 +662,659 @@ func TestHandleUtterance_BuildsConversationHistory(t *testing.T) {
 		t.Errorf("third message content = %q, want %q", secondCallMsgs[2].Content, "Second question.")
 	}
 }
+
+func TestHandleUtterance_HistoryPolicyCompressesHistory(t *testing.T) {
+	t.Parallel()
+
+	summariserLLM := &llmmock.Provider{
+		CompleteResponse: &llm.CompletionResponse{Content: "The player asked about the ruins."},
+	}
+	policy, err := session.NewCompressPolicy(session.CompressPolicyConfig{
+		Threshold:  4,
+		Summariser: session.NewLLMSummariser(summariserLLM),
+	})
+	if err != nil {
+		t.Fatalf("NewCompressPolicy: %v", err)
+	}
+
+	eng := &enginemock.VoiceEngine{}
+	cfg := validConfig()
+	cfg.Engine = eng
+	cfg.HistoryPolicy = policy
+
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		eng.ProcessResult = &engine.Response{
+			Text:  fmt.Sprintf("Reply %d.", i),
+			Audio: closedAudioCh(),
+		}
+		err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{
+			Text: fmt.Sprintf("Question %d.", i), IsFinal: true,
+		})
+		if err != nil {
+			t.Fatalf("HandleUtterance %d: %v", i, err)
+		}
+	}
+
+	// The third and fourth exchanges each pushed the history to 6 messages,
+	// triggering one summarisation apiece.
+	if len(summariserLLM.CompleteCalls) != 2 {
+		t.Fatalf("expected 2 summarisation calls, got %d", len(summariserLLM.CompleteCalls))
+	}
+
+	// The fourth prompt sees the summary plus the recent turns verbatim.
+	got := eng.ProcessCalls[3].Prompt.Messages
+	want := []llm.Message{
+		{Role: "system", Content: "[Previous conversation summary]: The player asked about the ruins."},
+		{Role: "assistant", Content: "Reply 2."},
+		{Role: "user", Content: "Question 3."},
+		{Role: "assistant", Content: "Reply 3."},
+		{Role: "user", Content: "Question 4."},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("fourth prompt has %d messages, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Role != want[i].Role || got[i].Content != want[i].Content {
+			t.Errorf("message[%d] = {%s %q}, want {%s %q}", i, got[i].Role, got[i].Content, want[i].Role, want[i].Content)
+		}
+	}
+}
+
+func TestHandleUtterance_HistoryPolicyErrorKeepsHistory(t *testing.T) {
+	t.Parallel()
+
+	policy, err := session.NewCompressPolicy(session.CompressPolicyConfig{
+		Threshold:  2,
+		Summariser: session.NewLLMSummariser(&llmmock.Provider{CompleteErr: errors.New("llm down")}),
+	})
+	if err != nil {
+		t.Fatalf("NewCompressPolicy: %v", err)
+	}
+
+	eng := &enginemock.VoiceEngine{
+		ProcessResult: &engine.Response{Text: "Aye.", Audio: closedAudioCh()},
+	}
+	cfg := validConfig()
+	cfg.Engine = eng
+	cfg.HistoryPolicy = policy
+
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+
+	for i := range 3 {
+		if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{
+			Text: fmt.Sprintf("Question %d.", i), IsFinal: true,
+		}); err != nil {
+			t.Fatalf("HandleUtterance %d: %v", i, err)
+		}
+	}
+
+	// A failing summariser must not lose any turns.
+	if got := len(eng.ProcessCalls[2].Prompt.Messages); got != 5 {
+		t.Errorf("third prompt has %d messages, want 5", got)
+	}
+}
+
+// blockingPolicy is a hand-written [agent.HistoryPolicy] that signals entered
+// and then waits for release before keeping only the last message.
+type blockingPolicy struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingPolicy) Apply(_ context.Context, messages []llm.Message) ([]llm.Message, error) {
+	p.entered <- struct{}{}
+	<-p.release
+	return messages[len(messages)-1:], nil
+}
+
+func TestHandleUtterance_HistoryPolicyRunsWithoutLock(t *testing.T) {
+	t.Parallel()
+
+	policy := &blockingPolicy{entered: make(chan struct{}), release: make(chan struct{})}
+	cfg := validConfig()
+	cfg.HistoryPolicy = policy
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: "Hello.", IsFinal: true})
+	}()
+	<-policy.entered
+
+	// The agent stays usable while the policy works, and a change to the
+	// history in the meantime makes the agent drop the policy's result.
+	st := a.(agent.Stateful).State()
+	if len(st.Messages) != 2 {
+		t.Fatalf("history during policy has %d messages, want 2", len(st.Messages))
+	}
+	st.Messages = append(st.Messages, llm.Message{Role: "assistant", Content: "Also, hello."})
+	a.(agent.Stateful).RestoreState(st)
+
+	close(policy.release)
+	if err := <-errc; err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+	if got := len(a.(agent.Stateful).State().Messages); got != 3 {
+		t.Errorf("history has %d messages, want 3: stale policy result applied", got)
+	}
+}
+
+// fakeRecapper is a hand-written [agent.SceneRecapper] that records its calls
+// and returns "recap N" for the Nth successful call.
+type fakeRecapper struct {
+	mu    sync.Mutex
+	calls []recapCall
+	errs  []error // returned by the first calls, in order
+}
+
+type recapCall struct {
+	recap string
+	turns []llm.Message
+}
+
+func (r *fakeRecapper) Recap(_ context.Context, recap string, turns []llm.Message) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, recapCall{recap: recap, turns: turns})
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return "", err
+	}
+	return fmt.Sprintf("recap %d", len(r.calls)), nil
+}
+
+// utterN hands n numbered questions to a.
+func utterN(t *testing.T, a agent.NPCAgent, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{
+			Text: fmt.Sprintf("Question %d.", i), IsFinal: true,
+		}); err != nil {
+			t.Fatalf("HandleUtterance %d: %v", i, err)
+		}
+	}
+}
+
+func TestHandleUtterance_SceneRecap(t *testing.T) {
+	t.Parallel()
+
+	recapper := &fakeRecapper{}
+	cfg := validConfig()
+	cfg.Recapper = recapper
+	cfg.RecapEvery = 2
+	eng := cfg.Engine.(*enginemock.VoiceEngine)
+
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	utterN(t, a, 5)
+
+	// The recap is refreshed after turns 2 and 4, each time with the
+	// previous recap and the two exchanges since.
+	if len(recapper.calls) != 2 {
+		t.Fatalf("Recap calls = %d, want 2", len(recapper.calls))
+	}
+	for i, prev := range []string{"", "recap 1"} {
+		call := recapper.calls[i]
+		if call.recap != prev {
+			t.Errorf("call %d: previous recap = %q, want %q", i, call.recap, prev)
+		}
+		if len(call.turns) != 4 {
+			t.Fatalf("call %d: %d messages, want 4", i, len(call.turns))
+		}
+		if want := fmt.Sprintf("Question %d.", 2*i+1); call.turns[0].Content != want {
+			t.Errorf("call %d: first message = %q, want %q", i, call.turns[0].Content, want)
+		}
+	}
+
+	// Each prompt carries the latest recap besides the verbatim history.
+	for i, want := range []string{"", "", "recap 1", "recap 1", "recap 2"} {
+		prompt := eng.ProcessCalls[i].Prompt
+		has := strings.Contains(prompt.SystemPrompt, "## The Scene So Far")
+		if want == "" && has {
+			t.Errorf("prompt %d has a recap before the first refresh", i+1)
+		}
+		if want != "" && !strings.Contains(prompt.SystemPrompt, "## The Scene So Far\n"+want) {
+			t.Errorf("prompt %d should carry %q:\n%s", i+1, want, prompt.SystemPrompt)
+		}
+		if got := len(prompt.Messages); got != 2*i+1 {
+			t.Errorf("prompt %d has %d messages, want %d", i+1, got, 2*i+1)
+		}
+	}
+}
+
+func TestHandleUtterance_SceneRecapErrorRetries(t *testing.T) {
+	t.Parallel()
+
+	recapper := &fakeRecapper{errs: []error{errors.New("llm down")}}
+	cfg := validConfig()
+	cfg.Recapper = recapper
+	cfg.RecapEvery = 2
+	eng := cfg.Engine.(*enginemock.VoiceEngine)
+
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	utterN(t, a, 4)
+
+	// The failed refresh after turn 2 is retried after turn 3 with all turns
+	// since, then the count starts over.
+	if len(recapper.calls) != 2 {
+		t.Fatalf("Recap calls = %d, want 2", len(recapper.calls))
+	}
+	if got := len(recapper.calls[1].turns); got != 6 {
+		t.Errorf("retry got %d messages, want 6", got)
+	}
+	if !strings.Contains(eng.ProcessCalls[3].Prompt.SystemPrompt, "recap 2") {
+		t.Errorf("fourth prompt should carry the retried recap:\n%s", eng.ProcessCalls[3].Prompt.SystemPrompt)
+	}
+}
+
+func TestHandleUtterance_ColdOpen(t *testing.T) {
+	t.Parallel()
+
+	newEngine := func() *enginemock.GreeterEngine {
+		return &enginemock.GreeterEngine{
+			VoiceEngine: enginemock.VoiceEngine{
+				ProcessResult: &engine.Response{Text: "The lore is vast.", Audio: closedAudioCh()},
+			},
+			GreetResult: &engine.Response{Text: "Ah, a visitor! Welcome.", Audio: closedAudioCh()},
+		}
+	}
+	utter := func(t *testing.T, a agent.NPCAgent, text string) {
+		t.Helper()
+		if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: text, IsFinal: true}); err != nil {
+			t.Fatalf("HandleUtterance: %v", err)
+		}
+	}
+
+	// Each agent lives for one session, so a new agent is a new session.
+	for session := range 2 {
+		eng := newEngine()
+		cfg := validConfig()
+		cfg.Engine = eng
+		cfg.Identity.ColdOpen = true
+		cfg.SessionID = fmt.Sprintf("session-%d", session)
+		a, err := agent.NewAgent(cfg)
+		if err != nil {
+			t.Fatalf("NewAgent: %v", err)
+		}
+
+		utter(t, a, "Hello there.")
+		utter(t, a, "Tell me about the lore.")
+		utter(t, a, "Thank you.")
+
+		if got := len(eng.GreetCalls); got != 1 {
+			t.Errorf("session %d: Greet calls = %d, want 1", session, got)
+		}
+		if got := len(eng.ProcessCalls); got != 2 {
+			t.Errorf("session %d: Process calls = %d, want 2", session, got)
+		}
+		if len(eng.GreetCalls) > 0 {
+			msgs := eng.GreetCalls[0].Prompt.Messages
+			if len(msgs) == 0 || msgs[len(msgs)-1].Content != "Hello there." {
+				t.Errorf("session %d: greeting prompt lacks the opening utterance: %+v", session, msgs)
+			}
+		}
+	}
+}
+
+func TestHandleUtterance_ColdOpenDisabled(t *testing.T) {
+	t.Parallel()
+
+	eng := &enginemock.GreeterEngine{
+		VoiceEngine: enginemock.VoiceEngine{
+			ProcessResult: &engine.Response{Text: "Hm?", Audio: closedAudioCh()},
+		},
+	}
+	cfg := validConfig()
+	cfg.Engine = eng
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: "Hello."}); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+	if len(eng.GreetCalls) != 0 {
+		t.Errorf("Greet calls = %d, want 0 without cold_open", len(eng.GreetCalls))
+	}
+}
+
+func TestHandleUtterance_ColdOpenFallback(t *testing.T) {
+	t.Parallel()
+
+	// An engine without the Greeter capability greets through Process.
+	eng := &enginemock.VoiceEngine{
+		ProcessResult: &engine.Response{Text: "Welcome!", Audio: closedAudioCh()},
+	}
+	cfg := validConfig()
+	cfg.Engine = eng
+	cfg.Identity.ColdOpen = true
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	for _, text := range []string{"Hello.", "Goodbye."} {
+		if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: text}); err != nil {
+			t.Fatalf("HandleUtterance: %v", err)
+		}
+	}
+
+	if len(eng.ProcessCalls) != 2 {
+		t.Fatalf("Process calls = %d, want 2", len(eng.ProcessCalls))
+	}
+	if !strings.Contains(eng.ProcessCalls[0].Prompt.SystemPrompt, engine.DefaultGreetingInstruction) {
+		t.Error("first Process call should carry the greeting instruction")
+	}
+	if strings.Contains(eng.ProcessCalls[1].Prompt.SystemPrompt, engine.DefaultGreetingInstruction) {
+		t.Error("second Process call should not carry the greeting instruction")
+	}
+}
+
+func TestHandleUtterance_ColdOpenErrorRetries(t *testing.T) {
+	t.Parallel()
+
+	eng := &enginemock.GreeterEngine{
+		VoiceEngine: enginemock.VoiceEngine{
+			ProcessResult: &engine.Response{Text: "Hm?", Audio: closedAudioCh()},
+		},
+		GreetError: errors.New("llm unavailable"),
+	}
+	cfg := validConfig()
+	cfg.Engine = eng
+	cfg.Identity.ColdOpen = true
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+
+	if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: "Hello."}); err == nil {
+		t.Fatal("expected error from failed greeting")
+	}
+	eng.GreetError = nil
+	eng.GreetResult = &engine.Response{Text: "Welcome!", Audio: closedAudioCh()}
+	if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: "Hello?"}); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+
+	// A failed greeting is not counted; the next utterance greets again.
+	if len(eng.GreetCalls) != 2 {
+		t.Errorf("Greet calls = %d, want 2", len(eng.GreetCalls))
+	}
+	if len(eng.ProcessCalls) != 0 {
+		t.Errorf("Process calls = %d, want 0", len(eng.ProcessCalls))
+	}
+}
+
+func TestHandleUtterance_AwarenessRadius(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		radius        int
+		queryErr      error
+		embedErr      error
+		wantKnowledge bool
+	}{
+		{name: "retrieves within radius", radius: 1, wantKnowledge: true},
+		{name: "disabled", radius: 0},
+		{name: "retrieval error is not fatal", radius: 1, queryErr: errors.New("db down")},
+		{name: "embeddings down falls back to FTS", radius: 1, embedErr: errors.New("embeddings: 503"), wantKnowledge: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			kg := &memorymock.GraphRAGQuerier{
+				QueryWithContextResult: []memory.ContextResult{
+					{Entity: memory.Entity{ID: "tower", Name: "Old Tower"}, Content: "The tower is haunted.", Score: 0.9},
+				},
+				QueryWithContextErr: tc.queryErr,
+			}
+			kg.NeighborsResult = []memory.Entity{{ID: "tower", Name: "Old Tower"}}
+
+			eng := &enginemock.VoiceEngine{
+				ProcessResult: &engine.Response{Text: "Aye.", Audio: closedAudioCh()},
+			}
+			cfg := validConfig()
+			cfg.Engine = eng
+			cfg.Identity.AwarenessRadius = tc.radius
+			var opts []hotctx.Option
+			if tc.embedErr != nil {
+				opts = append(opts, hotctx.WithEmbeddings(&embeddingsmock.Provider{EmbedErr: tc.embedErr}))
+			}
+			cfg.Assembler = hotctx.NewAssembler(&memorymock.SessionStore{}, kg, opts...)
+			a, err := agent.NewAgent(cfg)
+			if err != nil {
+				t.Fatalf("NewAgent: %v", err)
+			}
+
+			if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: "Tell me about the tower."}); err != nil {
+				t.Fatalf("HandleUtterance: %v", err)
+			}
+			if tc.embedErr != nil && kg.CallCount("QueryWithContext") != 1 {
+				t.Errorf("QueryWithContext called %d times, want 1", kg.CallCount("QueryWithContext"))
+			}
+			prompt := eng.ProcessCalls[0].Prompt.SystemPrompt
+			if got := strings.Contains(prompt, "Old Tower: The tower is haunted."); got != tc.wantKnowledge {
+				t.Errorf("prompt contains knowledge = %v, want %v:\n%s", got, tc.wantKnowledge, prompt)
+			}
+		})
+	}
+}
+
+func TestHandleUtterance_StyleExemplars(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		k         int
+		searchErr error
+		wantStyle bool
+	}{
+		{name: "prior lines as style references", k: 2, wantStyle: true},
+		{name: "disabled", k: 0},
+		{name: "search error is not fatal", k: 2, searchErr: errors.New("db down")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &memorymock.SessionStore{
+				SearchResult: []memory.TranscriptEntry{
+					{SpeakerID: "greymantle", NPCID: "greymantle", Text: "Hmm, the stars whisper of such things."},
+					{SpeakerID: "player-1", Text: "Stop talking in riddles, old man."},
+				},
+				SearchErr: tc.searchErr,
+			}
+			eng := &enginemock.VoiceEngine{
+				ProcessResult: &engine.Response{Text: "Patience, traveller.", Audio: closedAudioCh()},
+			}
+			cfg := validConfig()
+			cfg.Engine = eng
+			cfg.Identity.StyleExemplars = tc.k
+			cfg.Assembler = hotctx.NewAssembler(store, &memorymock.KnowledgeGraph{})
+			a, err := agent.NewAgent(cfg)
+			if err != nil {
+				t.Fatalf("NewAgent: %v", err)
+			}
+
+			if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: "What do the stars say?"}); err != nil {
+				t.Fatalf("HandleUtterance: %v", err)
+			}
+			prompt := eng.ProcessCalls[0].Prompt.SystemPrompt
+			if got := strings.Contains(prompt, "## Your Speaking Style"); got != tc.wantStyle {
+				t.Fatalf("prompt has speaking style section = %v, want %v:\n%s", got, tc.wantStyle, prompt)
+			}
+			if tc.wantStyle && !strings.Contains(prompt, "- Hmm, the stars whisper of such things.") {
+				t.Errorf("prompt misses the NPC's prior line:\n%s", prompt)
+			}
+			if strings.Contains(prompt, "Stop talking in riddles") {
+				t.Errorf("prompt contains a player line as style reference:\n%s", prompt)
+			}
+		})
+	}
+}
+
+func TestHandleUtterance_TurnObserver(t *testing.T) {
+	t.Parallel()
+
+	audioCh := make(chan []byte)
+	resp := &engine.Response{Text: "Let me check the ledger.", Audio: audioCh}
+	resp.SetUsedStrongModel()
+	eng := &enginemock.VoiceEngine{ProcessResult: resp}
+
+	turns := make(chan agent.TurnInfo, 1)
+	cfg := validConfig()
+	cfg.Engine = eng
+	cfg.MCPHost = &mcpmock.Host{}
+	cfg.BudgetTier = mcp.BudgetStandard
+	cfg.OnTurn = func(info agent.TurnInfo) { turns <- info }
+
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: "Who owes you coin?"}); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+
+	// The turn is still streaming: tools called now belong to it.
+	if _, err := eng.InvokeToolCall("lookup_debt", `{}`); err != nil {
+		t.Fatalf("InvokeToolCall: %v", err)
+	}
+	select {
+	case info := <-turns:
+		t.Fatalf("turn reported before its audio finished: %+v", info)
+	default:
+	}
+	audioCh <- []byte("audio")
+	close(audioCh)
+
+	var info agent.TurnInfo
+	select {
+	case info = <-turns:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the turn observer")
+	}
+
+	if info.NPCID != "greymantle" || info.NPCName != "Greymantle the Sage" {
+		t.Errorf("NPC = (%q, %q)", info.NPCID, info.NPCName)
+	}
+	if info.Speaker != "player-1" || info.Input != "Who owes you coin?" {
+		t.Errorf("Speaker, Input = %q, %q", info.Speaker, info.Input)
+	}
+	if info.Text != "Let me check the ledger." {
+		t.Errorf("Text = %q", info.Text)
+	}
+	if len(info.ToolCalls) != 1 || info.ToolCalls[0] != "lookup_debt" {
+		t.Errorf("ToolCalls = %v, want [lookup_debt]", info.ToolCalls)
+	}
+	if !info.UsedStrongModel || info.BudgetTier != mcp.BudgetStandard {
+		t.Errorf("UsedStrongModel, BudgetTier = %v, %v", info.UsedStrongModel, info.BudgetTier)
+	}
+	if info.Duration <= 0 {
+		t.Errorf("Duration = %v, want > 0", info.Duration)
+	}
+}
+
+func TestRenderPrompt(t *testing.T) {
+	t.Parallel()
+
+	kg := &memorymock.GraphRAGQuerier{
+		QueryWithContextResult: []memory.ContextResult{
+			{Entity: memory.Entity{ID: "tower", Name: "Old Tower"}, Content: "The tower is haunted.", Score: 0.9},
+		},
+	}
+	kg.NeighborsResult = []memory.Entity{{ID: "tower", Name: "Old Tower"}}
+	kg.IdentitySnapshotResult = &memory.NPCIdentity{
+		Entity: memory.Entity{ID: "greymantle", Type: "npc", Name: "Greymantle the Sage", Attributes: map[string]any{
+			memory.AttrOccupation: "keeper of the archive",
+		}},
+	}
+
+	eng := &enginemock.VoiceEngine{}
+	cfg := validConfig()
+	cfg.Engine = eng
+	cfg.Identity.AwarenessRadius = 1
+	cfg.Identity.ColdOpen = true
+	cfg.Assembler = hotctx.NewAssembler(&memorymock.SessionStore{}, kg)
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	r, ok := a.(agent.PromptRenderer)
+	if !ok {
+		t.Fatal("agent does not implement PromptRenderer")
+	}
+
+	scene := &agent.SceneContext{Location: "The Archive", TimeOfDay: "midnight"}
+	prompt, err := r.RenderPrompt(context.Background(), "Tell me about the tower.", scene)
+	if err != nil {
+		t.Fatalf("RenderPrompt: %v", err)
+	}
+
+	for _, want := range []string{
+		"You are Greymantle the Sage. A wise and ancient sage who speaks in riddles.",
+		"occupation: keeper of the archive",
+		"Old Tower: The tower is haunted.",
+		"## Rules",
+		"1. Always speak in archaic English.",
+		engine.DefaultGreetingInstruction,
+	} {
+		if !strings.Contains(prompt.SystemPrompt, want) {
+			t.Errorf("system prompt missing %q:\n%s", want, prompt.SystemPrompt)
+		}
+	}
+	if want := "Location: The Archive; Time: midnight"; prompt.HotContext != want {
+		t.Errorf("HotContext = %q, want %q", prompt.HotContext, want)
+	}
+	if len(prompt.Messages) != 1 || prompt.Messages[0].Role != "user" || prompt.Messages[0].Content != "Tell me about the tower." {
+		t.Errorf("Messages = %+v, want only the input", prompt.Messages)
+	}
+
+	// No turn was run: the engine was not called and the history is unchanged.
+	if len(eng.ProcessCalls) != 0 {
+		t.Errorf("Process called %d times, want 0", len(eng.ProcessCalls))
+	}
+	prompt, err = r.RenderPrompt(context.Background(), "And the dragon?", nil)
+	if err != nil {
+		t.Fatalf("RenderPrompt: %v", err)
+	}
+	if len(prompt.Messages) != 1 || prompt.Messages[0].Content != "And the dragon?" {
+		t.Errorf("Messages after a second render = %+v, want only the new input", prompt.Messages)
+	}
+}
+
+func TestRenderPrompt_AssemblerError(t *testing.T) {
+	t.Parallel()
+
+	cfg := validConfig()
+	cfg.Assembler = hotctx.NewAssembler(&memorymock.SessionStore{}, &memorymock.KnowledgeGraph{
+		IdentitySnapshotErr: errors.New("graph down"),
+	})
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	if _, err := a.(agent.PromptRenderer).RenderPrompt(context.Background(), "Hello.", nil); err == nil {
+		t.Error("RenderPrompt: expected an error when context assembly fails")
+	}
+}
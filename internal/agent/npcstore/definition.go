@@ -7,6 +7,9 @@
 // The primary abstraction is the [Store] interface, which offers CRUD and list
 // operations. The reference implementation [PostgresStore] stores definitions in
 // a single npc_definitions table using JSONB columns for structured sub-fields.
+// It also implements [agent.StateStore], keeping each NPC's per-session
+// working memory ([agent.State]) as JSONB in the npc_states table so that it
+// survives restarts.
 //
 // Conversion helpers ([ToIdentity]) bridge between the storage representation
 // and the runtime [agent.NPCIdentity] / [tts.VoiceProfile] types used by the
@@ -16,9 +19,11 @@ package npcstore
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/MrWong99/glyphoxa/internal/agent"
+	"github.com/MrWong99/glyphoxa/internal/config"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
 )
 
@@ -38,8 +43,10 @@ type NPCDefinition struct {
 	// patterns, quirks, and motivations.
 	Personality string `yaml:"personality" json:"personality"`
 
-	// Engine selects the voice-processing pipeline: "cascaded" (STT→LLM→TTS)
-	// or "s2s" (speech-to-speech). An empty value defaults to "cascaded".
+	// Engine selects the voice-processing pipeline: "cascaded" (STT→LLM→TTS),
+	// "s2s" (speech-to-speech), or "sentence_cascade" (a single LLM whose
+	// reply is synthesised sentence by sentence). An empty value defaults to
+	// "cascaded".
 	Engine string `yaml:"engine" json:"engine"`
 
 	// Voice configures the TTS voice used for this NPC.
@@ -64,6 +71,10 @@ type NPCDefinition struct {
 	// Attributes holds arbitrary key-value metadata for the NPC.
 	Attributes map[string]any `yaml:"attributes" json:"attributes"`
 
+	// LLM optionally overrides the global LLM provider and/or model for this
+	// NPC. The zero value uses the global configuration unchanged.
+	LLM LLMConfig `yaml:"llm" json:"llm"`
+
 	// CreatedAt is the time the definition was first persisted.
 	CreatedAt time.Time `json:"created_at" yaml:"-"`
 
@@ -87,6 +98,17 @@ type VoiceConfig struct {
 	SpeedFactor float64 `yaml:"speed_factor" json:"speed_factor"`
 }
 
+// LLMConfig overrides the language model used by a single NPC.
+type LLMConfig struct {
+	// Provider selects an LLM provider by name (e.g., "openai", "anthropic").
+	// An empty value uses the globally configured LLM provider.
+	Provider string `yaml:"provider" json:"provider,omitempty"`
+
+	// Model selects the model to request (e.g., "gpt-4o-mini"). Required when
+	// Provider is set.
+	Model string `yaml:"model" json:"model,omitempty"`
+}
+
 // validEngines is the set of accepted Engine values.
 var validEngines = map[string]struct{}{
 	"":                 {}, // empty defaults to "cascaded"
@@ -129,6 +151,14 @@ func (d *NPCDefinition) Validate() error {
 		errs = append(errs, fmt.Errorf("npcstore: voice pitch_shift must be in [-10, 10], got %g", d.Voice.PitchShift))
 	}
 
+	if p := d.LLM.Provider; p != "" && !slices.Contains(config.ValidProviderNames["llm"], p) {
+		errs = append(errs, fmt.Errorf("npcstore: llm provider %q is not a known LLM provider", p))
+	}
+
+	if d.LLM.Provider != "" && d.LLM.Model == "" {
+		errs = append(errs, fmt.Errorf("npcstore: llm model must be set when llm provider is %q", d.LLM.Provider))
+	}
+
 	return errors.Join(errs...)
 }
 
@@ -10,8 +10,9 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
-// Schema is the SQL DDL for the npc_definitions table. Execute it via
-// [PostgresStore.Migrate] or apply it manually during deployment.
+// Schema is the SQL DDL for the npc_definitions and npc_states tables.
+// Execute it via [PostgresStore.Migrate] or apply it manually during
+// deployment.
 const Schema = `
 CREATE TABLE IF NOT EXISTS npc_definitions (
     id               TEXT PRIMARY KEY,
@@ -26,11 +27,20 @@ CREATE TABLE IF NOT EXISTS npc_definitions (
     tools            JSONB NOT NULL DEFAULT '[]',
     budget_tier      TEXT NOT NULL DEFAULT 'fast',
     attributes       JSONB NOT NULL DEFAULT '{}',
+    llm              JSONB NOT NULL DEFAULT '{}',
     created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
     updated_at       TIMESTAMPTZ NOT NULL DEFAULT now()
 );
+ALTER TABLE npc_definitions ADD COLUMN IF NOT EXISTS llm JSONB NOT NULL DEFAULT '{}';
 CREATE INDEX IF NOT EXISTS idx_npc_definitions_campaign ON npc_definitions(campaign_id);
 CREATE INDEX IF NOT EXISTS idx_npc_definitions_name ON npc_definitions(name);
+CREATE TABLE IF NOT EXISTS npc_states (
+    npc_id     TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    state      JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (npc_id, session_id)
+);
 `
 
 // DB is the database interface used by [PostgresStore]. Both *pgxpool.Pool
@@ -58,7 +68,8 @@ func NewPostgresStore(db DB) *PostgresStore {
 }
 
 // Migrate executes the [Schema] DDL against the database, creating the
-// npc_definitions table and indexes if they do not already exist.
+// npc_definitions and npc_states tables and indexes if they do not already
+// exist.
 func (s *PostgresStore) Migrate(ctx context.Context) error {
 	_, err := s.db.Exec(ctx, Schema)
 	if err != nil {
@@ -98,19 +109,23 @@ func (s *PostgresStore) Create(ctx context.Context, def *NPCDefinition) error {
 	if err != nil {
 		return fmt.Errorf("npcstore: marshal attributes: %w", err)
 	}
+	llmJSON, err := json.Marshal(def.LLM)
+	if err != nil {
+		return fmt.Errorf("npcstore: marshal llm: %w", err)
+	}
 
 	const query = `
 		INSERT INTO npc_definitions (
 			id, campaign_id, name, personality, engine,
 			voice, knowledge_scope, secret_knowledge, behavior_rules, tools,
-			budget_tier, attributes
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+			budget_tier, attributes, llm
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
 		RETURNING created_at, updated_at`
 
 	err = s.db.QueryRow(ctx, query,
 		def.ID, def.CampaignID, def.Name, def.Personality, defaultEngine(def.Engine),
 		voiceJSON, ksJSON, skJSON, brJSON, toolsJSON,
-		defaultBudgetTier(def.BudgetTier), attrJSON,
+		defaultBudgetTier(def.BudgetTier), attrJSON, llmJSON,
 	).Scan(&def.CreatedAt, &def.UpdatedAt)
 	if err != nil {
 		if isDuplicateKeyError(err) {
@@ -127,17 +142,17 @@ func (s *PostgresStore) Get(ctx context.Context, id string) (*NPCDefinition, err
 	const query = `
 		SELECT id, campaign_id, name, personality, engine,
 		       voice, knowledge_scope, secret_knowledge, behavior_rules, tools,
-		       budget_tier, attributes, created_at, updated_at
+		       budget_tier, attributes, llm, created_at, updated_at
 		FROM npc_definitions
 		WHERE id = $1`
 
 	var def NPCDefinition
-	var voiceJSON, ksJSON, skJSON, brJSON, toolsJSON, attrJSON []byte
+	var voiceJSON, ksJSON, skJSON, brJSON, toolsJSON, attrJSON, llmJSON []byte
 
 	err := s.db.QueryRow(ctx, query, id).Scan(
 		&def.ID, &def.CampaignID, &def.Name, &def.Personality, &def.Engine,
 		&voiceJSON, &ksJSON, &skJSON, &brJSON, &toolsJSON,
-		&def.BudgetTier, &attrJSON, &def.CreatedAt, &def.UpdatedAt,
+		&def.BudgetTier, &attrJSON, &llmJSON, &def.CreatedAt, &def.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -146,7 +161,7 @@ func (s *PostgresStore) Get(ctx context.Context, id string) (*NPCDefinition, err
 		return nil, fmt.Errorf("npcstore: get %q: %w", id, err)
 	}
 
-	if err := unmarshalFields(&def, voiceJSON, ksJSON, skJSON, brJSON, toolsJSON, attrJSON); err != nil {
+	if err := unmarshalFields(&def, voiceJSON, ksJSON, skJSON, brJSON, toolsJSON, attrJSON, llmJSON); err != nil {
 		return nil, err
 	}
 	return &def, nil
@@ -183,20 +198,24 @@ func (s *PostgresStore) Update(ctx context.Context, def *NPCDefinition) error {
 	if err != nil {
 		return fmt.Errorf("npcstore: marshal attributes: %w", err)
 	}
+	llmJSON, err := json.Marshal(def.LLM)
+	if err != nil {
+		return fmt.Errorf("npcstore: marshal llm: %w", err)
+	}
 
 	const query = `
 		UPDATE npc_definitions SET
 			campaign_id = $2, name = $3, personality = $4, engine = $5,
 			voice = $6, knowledge_scope = $7, secret_knowledge = $8,
 			behavior_rules = $9, tools = $10, budget_tier = $11,
-			attributes = $12, updated_at = now()
+			attributes = $12, llm = $13, updated_at = now()
 		WHERE id = $1
 		RETURNING updated_at`
 
 	err = s.db.QueryRow(ctx, query,
 		def.ID, def.CampaignID, def.Name, def.Personality, defaultEngine(def.Engine),
 		voiceJSON, ksJSON, skJSON, brJSON, toolsJSON,
-		defaultBudgetTier(def.BudgetTier), attrJSON,
+		defaultBudgetTier(def.BudgetTier), attrJSON, llmJSON,
 	).Scan(&def.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -229,7 +248,7 @@ func (s *PostgresStore) List(ctx context.Context, campaignID string) ([]NPCDefin
 		const query = `
 			SELECT id, campaign_id, name, personality, engine,
 			       voice, knowledge_scope, secret_knowledge, behavior_rules, tools,
-			       budget_tier, attributes, created_at, updated_at
+			       budget_tier, attributes, llm, created_at, updated_at
 			FROM npc_definitions
 			ORDER BY name`
 		rows, err = s.db.Query(ctx, query)
@@ -237,7 +256,7 @@ func (s *PostgresStore) List(ctx context.Context, campaignID string) ([]NPCDefin
 		const query = `
 			SELECT id, campaign_id, name, personality, engine,
 			       voice, knowledge_scope, secret_knowledge, behavior_rules, tools,
-			       budget_tier, attributes, created_at, updated_at
+			       budget_tier, attributes, llm, created_at, updated_at
 			FROM npc_definitions
 			WHERE campaign_id = $1
 			ORDER BY name`
@@ -251,17 +270,17 @@ func (s *PostgresStore) List(ctx context.Context, campaignID string) ([]NPCDefin
 	var defs []NPCDefinition
 	for rows.Next() {
 		var def NPCDefinition
-		var voiceJSON, ksJSON, skJSON, brJSON, toolsJSON, attrJSON []byte
+		var voiceJSON, ksJSON, skJSON, brJSON, toolsJSON, attrJSON, llmJSON []byte
 
 		if err := rows.Scan(
 			&def.ID, &def.CampaignID, &def.Name, &def.Personality, &def.Engine,
 			&voiceJSON, &ksJSON, &skJSON, &brJSON, &toolsJSON,
-			&def.BudgetTier, &attrJSON, &def.CreatedAt, &def.UpdatedAt,
+			&def.BudgetTier, &attrJSON, &llmJSON, &def.CreatedAt, &def.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("npcstore: list scan: %w", err)
 		}
 
-		if err := unmarshalFields(&def, voiceJSON, ksJSON, skJSON, brJSON, toolsJSON, attrJSON); err != nil {
+		if err := unmarshalFields(&def, voiceJSON, ksJSON, skJSON, brJSON, toolsJSON, attrJSON, llmJSON); err != nil {
 			return nil, err
 		}
 		defs = append(defs, def)
@@ -304,13 +323,17 @@ func (s *PostgresStore) Upsert(ctx context.Context, def *NPCDefinition) error {
 	if err != nil {
 		return fmt.Errorf("npcstore: marshal attributes: %w", err)
 	}
+	llmJSON, err := json.Marshal(def.LLM)
+	if err != nil {
+		return fmt.Errorf("npcstore: marshal llm: %w", err)
+	}
 
 	const query = `
 		INSERT INTO npc_definitions (
 			id, campaign_id, name, personality, engine,
 			voice, knowledge_scope, secret_knowledge, behavior_rules, tools,
-			budget_tier, attributes
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+			budget_tier, attributes, llm
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
 		ON CONFLICT (id) DO UPDATE SET
 			campaign_id = EXCLUDED.campaign_id,
 			name = EXCLUDED.name,
@@ -323,13 +346,14 @@ func (s *PostgresStore) Upsert(ctx context.Context, def *NPCDefinition) error {
 			tools = EXCLUDED.tools,
 			budget_tier = EXCLUDED.budget_tier,
 			attributes = EXCLUDED.attributes,
+			llm = EXCLUDED.llm,
 			updated_at = now()
 		RETURNING created_at, updated_at`
 
 	err = s.db.QueryRow(ctx, query,
 		def.ID, def.CampaignID, def.Name, def.Personality, defaultEngine(def.Engine),
 		voiceJSON, ksJSON, skJSON, brJSON, toolsJSON,
-		defaultBudgetTier(def.BudgetTier), attrJSON,
+		defaultBudgetTier(def.BudgetTier), attrJSON, llmJSON,
 	).Scan(&def.CreatedAt, &def.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("npcstore: upsert: %w", err)
@@ -339,7 +363,7 @@ func (s *PostgresStore) Upsert(ctx context.Context, def *NPCDefinition) error {
 
 // unmarshalFields deserialises the JSONB columns into the corresponding
 // [NPCDefinition] fields.
-func unmarshalFields(def *NPCDefinition, voice, ks, sk, br, tools, attrs []byte) error {
+func unmarshalFields(def *NPCDefinition, voice, ks, sk, br, tools, attrs, llmCfg []byte) error {
 	if err := json.Unmarshal(voice, &def.Voice); err != nil {
 		return fmt.Errorf("npcstore: unmarshal voice: %w", err)
 	}
@@ -358,6 +382,11 @@ func unmarshalFields(def *NPCDefinition, voice, ks, sk, br, tools, attrs []byte)
 	if err := json.Unmarshal(attrs, &def.Attributes); err != nil {
 		return fmt.Errorf("npcstore: unmarshal attributes: %w", err)
 	}
+	if len(llmCfg) > 0 {
+		if err := json.Unmarshal(llmCfg, &def.LLM); err != nil {
+			return fmt.Errorf("npcstore: unmarshal llm: %w", err)
+		}
+	}
 	return nil
 }
 
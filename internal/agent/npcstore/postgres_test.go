@@ -4,12 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/MrWong99/glyphoxa/internal/agent"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
 )
 
 // ---------------------------------------------------------------------------
@@ -165,6 +170,36 @@ func TestNPCDefinition_Validate(t *testing.T) {
 				Voice: VoiceConfig{SpeedFactor: 0},
 			},
 		},
+		{
+			name: "valid llm override",
+			def: NPCDefinition{
+				Name: "NPC",
+				LLM:  LLMConfig{Provider: "openai", Model: "gpt-4o-mini"},
+			},
+		},
+		{
+			name: "valid llm model only",
+			def: NPCDefinition{
+				Name: "NPC",
+				LLM:  LLMConfig{Model: "gpt-4o-mini"},
+			},
+		},
+		{
+			name: "unknown llm provider",
+			def: NPCDefinition{
+				Name: "NPC",
+				LLM:  LLMConfig{Provider: "skynet", Model: "t-800"},
+			},
+			wantErr: []string{`llm provider "skynet" is not a known LLM provider`},
+		},
+		{
+			name: "llm provider without model",
+			def: NPCDefinition{
+				Name: "NPC",
+				LLM:  LLMConfig{Provider: "anthropic"},
+			},
+			wantErr: []string{"llm model must be set"},
+		},
 		{
 			name:    "empty name",
 			def:     NPCDefinition{},
@@ -421,8 +456,8 @@ func TestPostgresStore_Create(t *testing.T) {
 		if !strings.Contains(capturedSQL, "INSERT INTO npc_definitions") {
 			t.Errorf("SQL should contain INSERT, got: %s", capturedSQL)
 		}
-		if len(capturedArgs) != 12 {
-			t.Errorf("expected 12 args, got %d", len(capturedArgs))
+		if len(capturedArgs) != 13 {
+			t.Errorf("expected 13 args, got %d", len(capturedArgs))
 		}
 		if capturedArgs[0] != "npc-1" {
 			t.Errorf("first arg = %v, want 'npc-1'", capturedArgs[0])
@@ -549,8 +584,9 @@ func TestPostgresStore_Get(t *testing.T) {
 						*(dest[9].(*[]byte)) = []byte(`["tool1"]`)
 						*(dest[10].(*string)) = "fast"
 						*(dest[11].(*[]byte)) = []byte(`{"race":"elf"}`)
-						*(dest[12].(*time.Time)) = fixedTime
+						*(dest[12].(*[]byte)) = []byte(`{"provider":"anthropic","model":"claude-sonnet-4"}`)
 						*(dest[13].(*time.Time)) = fixedTime
+						*(dest[14].(*time.Time)) = fixedTime
 						return nil
 					},
 				}
@@ -580,6 +616,9 @@ func TestPostgresStore_Get(t *testing.T) {
 		if def.Attributes["race"] != "elf" {
 			t.Errorf("Attributes[race] = %v, want 'elf'", def.Attributes["race"])
 		}
+		if def.LLM.Provider != "anthropic" || def.LLM.Model != "claude-sonnet-4" {
+			t.Errorf("LLM = %+v, want anthropic/claude-sonnet-4", def.LLM)
+		}
 	})
 
 	t.Run("not found", func(t *testing.T) {
@@ -751,6 +790,7 @@ func TestPostgresStore_List(t *testing.T) {
 			[]byte(`[]`), // tools
 			"fast",       // budget_tier
 			[]byte(`{}`), // attributes
+			[]byte(`{}`), // llm
 			fixedTime,    // created_at
 			fixedTime,    // updated_at
 		}
@@ -939,6 +979,161 @@ func TestPostgresStore_Upsert(t *testing.T) {
 	})
 }
 
+// ---------------------------------------------------------------------------
+// SaveState / LoadState tests
+// ---------------------------------------------------------------------------
+
+// stateDB returns a mock DB that keeps the state saved through it in rows,
+// keyed by NPC and session ID, so that it can be loaded back.
+func stateDB(rows map[[2]string][]byte) *mockDB {
+	var mu sync.Mutex
+	return &mockDB{
+		execFunc: func(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			if !strings.Contains(sql, "INSERT INTO npc_states") {
+				return pgconn.CommandTag{}, fmt.Errorf("unexpected exec: %s", sql)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			rows[[2]string{args[0].(string), args[1].(string)}] = args[2].([]byte)
+			return pgconn.CommandTag{}, nil
+		},
+		queryRowFunc: func(_ context.Context, _ string, args ...any) pgx.Row {
+			mu.Lock()
+			data, ok := rows[[2]string{args[0].(string), args[1].(string)}]
+			mu.Unlock()
+			return &mockRow{scanFunc: func(dest ...any) error {
+				if !ok {
+					return pgx.ErrNoRows
+				}
+				*(dest[0].(*[]byte)) = data
+				return nil
+			}}
+		},
+	}
+}
+
+func TestPostgresStore_SaveLoadState(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	rows := map[[2]string][]byte{}
+	store := NewPostgresStore(stateDB(rows))
+
+	want := agent.State{
+		Messages: []llm.Message{
+			{Role: "user", Content: "Any news?", Name: "player-1"},
+			{Role: "assistant", Content: "The mine collapsed.", Name: "Greymantle"},
+		},
+		Recap:        "The party asked about the mine.",
+		RecapPending: []llm.Message{{Role: "user", Content: "Who was hurt?", Name: "player-2"}},
+		RecapTurns:   1,
+		Scene: agent.SceneContext{
+			Location:        "Thornwood Tavern",
+			TimeOfDay:       "evening",
+			PresentEntities: []string{"player-1", "player-2"},
+		},
+		Greeted: true,
+		Mood:    "grieving",
+	}
+	if err := store.SaveState(ctx, "npc-1", "session-1", want); err != nil {
+		t.Fatalf("SaveState() unexpected error: %v", err)
+	}
+
+	got, err := store.LoadState(ctx, "npc-1", "session-1")
+	if err != nil {
+		t.Fatalf("LoadState() unexpected error: %v", err)
+	}
+	if got == nil {
+		t.Fatal("LoadState() returned nil, want state")
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("LoadState() = %+v, want %+v", *got, want)
+	}
+
+	// State is kept per NPC and per session.
+	for _, key := range [][2]string{{"npc-2", "session-1"}, {"npc-1", "session-2"}} {
+		got, err := store.LoadState(ctx, key[0], key[1])
+		if err != nil {
+			t.Fatalf("LoadState(%q, %q) unexpected error: %v", key[0], key[1], err)
+		}
+		if got != nil {
+			t.Errorf("LoadState(%q, %q) = %+v, want nil", key[0], key[1], got)
+		}
+	}
+
+	// Saving again replaces the state.
+	want.Mood = "hopeful"
+	if err := store.SaveState(ctx, "npc-1", "session-1", want); err != nil {
+		t.Fatalf("SaveState() unexpected error: %v", err)
+	}
+	got, err = store.LoadState(ctx, "npc-1", "session-1")
+	if err != nil {
+		t.Fatalf("LoadState() unexpected error: %v", err)
+	}
+	if got.Mood != "hopeful" {
+		t.Errorf("Mood after second save = %q, want %q", got.Mood, "hopeful")
+	}
+}
+
+func TestPostgresStore_StateErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	failing := &mockDB{
+		execFunc: func(_ context.Context, _ string, _ ...any) (pgconn.CommandTag, error) {
+			return pgconn.CommandTag{}, errors.New("connection refused")
+		},
+		queryRowFunc: func(_ context.Context, _ string, _ ...any) pgx.Row {
+			return &mockRow{scanFunc: func(_ ...any) error { return errors.New("timeout") }}
+		},
+	}
+	corrupt := &mockDB{
+		queryRowFunc: func(_ context.Context, _ string, _ ...any) pgx.Row {
+			return &mockRow{scanFunc: func(dest ...any) error {
+				*(dest[0].(*[]byte)) = []byte(`{"messages": 42}`)
+				return nil
+			}}
+		},
+	}
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr string
+	}{
+		{
+			name:    "save db error",
+			call:    func() error { return NewPostgresStore(failing).SaveState(ctx, "npc-1", "s", agent.State{}) },
+			wantErr: "npcstore: save state",
+		},
+		{
+			name: "load db error",
+			call: func() error {
+				_, err := NewPostgresStore(failing).LoadState(ctx, "npc-1", "s")
+				return err
+			},
+			wantErr: "npcstore: load state",
+		},
+		{
+			name: "load corrupt state",
+			call: func() error {
+				_, err := NewPostgresStore(corrupt).LoadState(ctx, "npc-1", "s")
+				return err
+			},
+			wantErr: "npcstore: unmarshal state",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.call()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Helpers
 // ---------------------------------------------------------------------------
@@ -0,0 +1,63 @@
+package npcstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/MrWong99/glyphoxa/internal/agent"
+)
+
+// Compile-time interface check.
+var _ agent.StateStore = (*PostgresStore)(nil)
+
+// SaveState implements [agent.StateStore]. It upserts the working memory of
+// an NPC for one session into the npc_states table, serialised as JSONB.
+//
+// NPC IDs are not checked against npc_definitions: agents created from
+// config.yaml have no stored definition.
+func (s *PostgresStore) SaveState(ctx context.Context, npcID, sessionID string, state agent.State) error {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("npcstore: marshal state: %w", err)
+	}
+
+	const query = `
+		INSERT INTO npc_states (npc_id, session_id, state)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (npc_id, session_id) DO UPDATE SET
+			state = EXCLUDED.state,
+			updated_at = now()`
+
+	if _, err := s.db.Exec(ctx, query, npcID, sessionID, stateJSON); err != nil {
+		return fmt.Errorf("npcstore: save state %q: %w", npcID, err)
+	}
+	return nil
+}
+
+// LoadState implements [agent.StateStore]. It returns the working memory last
+// saved for the NPC in the session, or (nil, nil) if there is none.
+func (s *PostgresStore) LoadState(ctx context.Context, npcID, sessionID string) (*agent.State, error) {
+	const query = `
+		SELECT state
+		FROM npc_states
+		WHERE npc_id = $1 AND session_id = $2`
+
+	var stateJSON []byte
+	err := s.db.QueryRow(ctx, query, npcID, sessionID).Scan(&stateJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("npcstore: load state %q: %w", npcID, err)
+	}
+
+	var state agent.State
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		return nil, fmt.Errorf("npcstore: unmarshal state: %w", err)
+	}
+	return &state, nil
+}
@@ -8,6 +8,9 @@ import (
 	"errors"
 	"slices"
 	"strings"
+	"unicode"
+
+	"github.com/antzucaro/matchr"
 
 	"github.com/MrWong99/glyphoxa/internal/agent"
 )
@@ -16,6 +19,19 @@ import (
 // was addressed by a player's utterance.
 var ErrNoTarget = errors.New("orchestrator: no target NPC identified")
 
+// Fuzzy name matching tolerates STT misspellings such as "Grimjah" for
+// "Grimjaw". Only single-word keys of at least fuzzyMinKeyLen characters take
+// part, and a transcript word must reach fuzzyThreshold Jaro-Winkler
+// similarity to count as a mention.
+const (
+	fuzzyMinKeyLen = 5
+	fuzzyThreshold = 0.9
+)
+
+// fragmentStopWords are name words never indexed on their own: as substrings
+// they would match nearly every utterance.
+var fragmentStopWords = map[string]bool{"the": true, "and": true}
+
 // candidate is a pre-sorted name-to-ID mapping entry.
 type candidate struct {
 	key string
@@ -25,26 +41,36 @@ type candidate struct {
 // AddressDetector determines which NPC was spoken to by scanning the
 // transcript text for NPC names, falling back through a priority chain of
 // heuristics (DM override → last-speaker continuation → single-NPC fallback).
+//
+// NPCs with [agent.NPCIdentity.RespondOnlyWhenNamed] set are skipped by the
+// last-speaker and single-NPC fallbacks: they are only routed to when their
+// name or one of their aliases is mentioned, or through a DM override.
 type AddressDetector struct {
-	// nameIndex maps lowercase NPC names (and name fragments) to agent IDs.
+	// nameIndex maps lowercase NPC names and aliases (and their fragments)
+	// to agent IDs.
 	nameIndex map[string]string
 
 	// sorted is the nameIndex entries pre-sorted by descending key length
 	// so that more specific (longer) names match before shorter fragments.
 	// Built once in buildIndex and reused on every matchName call.
 	sorted []candidate
+
+	// fuzzy is the subset of sorted whose keys are single words long enough
+	// for approximate matching.
+	fuzzy []candidate
+
+	// namedOnly holds the IDs of agents that must be addressed by name.
+	namedOnly map[string]bool
 }
 
 // NewAddressDetector builds a name index from the given agents.
 //
-// The index includes the full lowercase name of each NPC and every individual
-// word of length ≥ 3 from that name. For example, "Grimjaw the Blacksmith"
-// produces entries for "grimjaw the blacksmith", "grimjaw", and "blacksmith"
-// (the word "the" is too short).
+// The index includes the full lowercase name and every alias of each NPC,
+// plus every individual word of length ≥ 3 from those except "the" and "and".
+// For example, "Grimjaw the Blacksmith" produces entries for
+// "grimjaw the blacksmith", "grimjaw", and "blacksmith".
 func NewAddressDetector(agents []agent.NPCAgent) *AddressDetector {
-	d := &AddressDetector{
-		nameIndex: make(map[string]string),
-	}
+	d := &AddressDetector{}
 	d.buildIndex(agents)
 	return d
 }
@@ -52,10 +78,13 @@ func NewAddressDetector(agents []agent.NPCAgent) *AddressDetector {
 // Detect returns the agent ID of the NPC addressed in the transcript.
 //
 // The detection strategy is applied in order:
-//  1. Explicit name match — scan text for indexed NPC names/fragments.
+//  1. Explicit name match — scan text for indexed NPC names/fragments, then
+//     for words that closely resemble a name (e.g. STT misspellings).
 //  2. DM override — if speaker has an active puppet override in dmOverrides.
-//  3. Last-speaker continuation — route to lastSpeaker if set and active.
-//  4. Single-NPC fallback — if exactly one unmuted NPC exists, route there.
+//  3. Last-speaker continuation — route to lastSpeaker if set, active and
+//     not restricted to being named.
+//  4. Single-NPC fallback — if exactly one unmuted NPC that is not
+//     restricted to being named exists, route there.
 //  5. No match — return ("", [ErrNoTarget]).
 func (d *AddressDetector) Detect(
 	text string,
@@ -68,6 +97,9 @@ func (d *AddressDetector) Detect(
 	if id := d.matchName(text, activeAgents); id != "" {
 		return id, nil
 	}
+	if id := d.matchFuzzy(text, activeAgents); id != "" {
+		return id, nil
+	}
 
 	// 2. DM override / puppet mode.
 	if npcID, ok := dmOverrides[speaker]; ok {
@@ -78,7 +110,7 @@ func (d *AddressDetector) Detect(
 
 	// 3. Last-speaker continuation.
 	if lastSpeaker != "" {
-		if entry, ok := activeAgents[lastSpeaker]; ok && !entry.muted {
+		if entry, ok := activeAgents[lastSpeaker]; ok && !entry.muted && !d.namedOnly[lastSpeaker] {
 			return lastSpeaker, nil
 		}
 	}
@@ -87,7 +119,7 @@ func (d *AddressDetector) Detect(
 	var unmutedID string
 	unmutedCount := 0
 	for id, entry := range activeAgents {
-		if !entry.muted {
+		if !entry.muted && !d.namedOnly[id] {
 			unmutedID = id
 			unmutedCount++
 			if unmutedCount > 1 {
@@ -105,25 +137,35 @@ func (d *AddressDetector) Detect(
 // Rebuild rebuilds the name index from a fresh set of agents.
 // Call this after adding or removing agents.
 func (d *AddressDetector) Rebuild(agents []agent.NPCAgent) {
-	d.nameIndex = make(map[string]string)
 	d.buildIndex(agents)
 }
 
 // buildIndex populates nameIndex from the given agents and pre-sorts
 // candidates by descending key length for efficient matching.
 func (d *AddressDetector) buildIndex(agents []agent.NPCAgent) {
+	d.nameIndex = make(map[string]string)
+	d.namedOnly = make(map[string]bool)
 	for _, a := range agents {
-		name := a.Name()
 		id := a.ID()
-		lower := strings.ToLower(name)
+		identity := a.Identity()
+		if identity.RespondOnlyWhenNamed {
+			d.namedOnly[id] = true
+		}
+
+		for _, name := range append([]string{a.Name()}, identity.Aliases...) {
+			lower := strings.ToLower(strings.TrimSpace(name))
+			if lower == "" {
+				continue
+			}
 
-		// Index the full name.
-		d.nameIndex[lower] = id
+			// Index the full name.
+			d.nameIndex[lower] = id
 
-		// Index individual words ≥ 3 characters.
-		for word := range strings.FieldsSeq(lower) {
-			if len(word) >= 3 {
-				d.nameIndex[word] = id
+			// Index individual words ≥ 3 characters.
+			for word := range strings.FieldsSeq(lower) {
+				if len(word) >= 3 && !fragmentStopWords[word] {
+					d.nameIndex[word] = id
+				}
 			}
 		}
 	}
@@ -137,6 +179,13 @@ func (d *AddressDetector) buildIndex(agents []agent.NPCAgent) {
 	slices.SortFunc(d.sorted, func(a, b candidate) int {
 		return len(b.key) - len(a.key) // descending
 	})
+
+	d.fuzzy = nil
+	for _, c := range d.sorted {
+		if len(c.key) >= fuzzyMinKeyLen && !strings.Contains(c.key, " ") {
+			d.fuzzy = append(d.fuzzy, c)
+		}
+	}
 }
 
 // matchName scans the lowercase transcript text for indexed NPC names.
@@ -157,3 +206,36 @@ func (d *AddressDetector) matchName(text string, activeAgents map[string]*agentE
 	}
 	return ""
 }
+
+// matchFuzzy compares every word of the transcript with the single-word name
+// keys and returns the unmuted agent whose key is most similar, provided the
+// Jaro-Winkler similarity reaches fuzzyThreshold. It catches names the STT
+// engine spelled slightly differently.
+func (d *AddressDetector) matchFuzzy(text string, activeAgents map[string]*agentEntry) string {
+	if len(d.fuzzy) == 0 {
+		return ""
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var (
+		bestID    string
+		bestScore float64
+	)
+	for _, c := range d.fuzzy {
+		entry, ok := activeAgents[c.id]
+		if !ok || entry.muted {
+			continue
+		}
+		for _, w := range words {
+			if len(w) < fuzzyMinKeyLen {
+				continue
+			}
+			if score := matchr.JaroWinkler(w, c.key, false); score >= fuzzyThreshold && score > bestScore {
+				bestID, bestScore = c.id, score
+			}
+		}
+	}
+	return bestID
+}
@@ -36,6 +36,9 @@ type Orchestrator struct {
 	buffer   *UtteranceBuffer
 
 	dmOverrides map[string]string // speaker → forced NPC id (puppet mode)
+
+	turns      *TurnLocks // nil disables turn isolation
+	sessionKey string     // key of this session in turns
 }
 
 // agentEntry pairs an [agent.NPCAgent] with its muted state.
@@ -63,6 +66,20 @@ func WithBufferDuration(d time.Duration) Option {
 	}
 }
 
+// WithTurnIsolation configures how [Orchestrator.Dispatch] isolates concurrent
+// turns. Turns are serialised per sessionID using locks, which may be shared
+// with the orchestrators of other sessions; turns of different sessions run
+// concurrently. A nil locks disables isolation, so turns of the session run
+// concurrently as well.
+//
+// The default isolates the orchestrator's turns with locks of its own.
+func WithTurnIsolation(locks *TurnLocks, sessionID string) Option {
+	return func(o *Orchestrator) {
+		o.turns = locks
+		o.sessionKey = sessionID
+	}
+}
+
 // New creates an Orchestrator with the given NPC agents and functional options.
 //
 // Each agent must have a unique [agent.NPCAgent.ID]; duplicates are silently
@@ -78,6 +95,7 @@ func New(agents []agent.NPCAgent, opts ...Option) *Orchestrator {
 		agents:      entries,
 		buffer:      NewUtteranceBuffer(defaultBufferSize, defaultBufferDuration),
 		dmOverrides: make(map[string]string),
+		turns:       NewTurnLocks(),
 	}
 
 	for _, opt := range opts {
@@ -96,7 +114,7 @@ func New(agents []agent.NPCAgent, opts ...Option) *Orchestrator {
 
 // Route determines which [agent.NPCAgent] was addressed by speaker's utterance
 // and returns that agent. Before returning, Route injects recent cross-NPC
-// utterances into the target agent's engine via [engine.VoiceEngine.InjectContext].
+// utterances into the target agent (see [Orchestrator.InjectContext]).
 //
 // If no NPC can be identified or the identified NPC is muted, Route returns
 // [ErrNoTarget].
@@ -141,7 +159,7 @@ func (o *Orchestrator) Route(ctx context.Context, speaker string, transcript stt
 				Timestamp:   r.Timestamp,
 			}
 		}
-		if injectErr := targetAgent.Engine().InjectContext(ctx, engine.ContextUpdate{
+		if injectErr := injectContext(ctx, targetAgent, engine.ContextUpdate{
 			RecentUtterances: entries,
 		}); injectErr != nil {
 			return nil, fmt.Errorf("orchestrator: inject context: %w", injectErr)
@@ -151,6 +169,38 @@ func (o *Orchestrator) Route(ctx context.Context, speaker string, transcript stt
 	return targetAgent, nil
 }
 
+// Dispatch routes speaker's utterance like [Orchestrator.Route] and hands it
+// to the target agent's [agent.NPCAgent.HandleUtterance]. It returns the
+// target agent, or nil if routing failed.
+//
+// With turn isolation (see [WithTurnIsolation]), Dispatch holds the session's
+// turn lock from routing until HandleUtterance returns. Turns of the session
+// are then processed one at a time in arrival order, so the context injected
+// for one turn cannot be consumed or overwritten by a concurrent one. The
+// lock is passed on with [agent.WithTurnLock] for replies that are restarted
+// later.
+func (o *Orchestrator) Dispatch(ctx context.Context, speaker string, transcript stt.Transcript) (agent.NPCAgent, error) {
+	if o.turns != nil {
+		unlock, err := o.turns.Lock(ctx, o.sessionKey)
+		if err != nil {
+			return nil, fmt.Errorf("orchestrator: %w", err)
+		}
+		defer unlock()
+		ctx = agent.WithTurnLock(ctx, func(ctx context.Context) (func(), error) {
+			return o.turns.Lock(ctx, o.sessionKey)
+		})
+	}
+
+	target, err := o.Route(ctx, speaker, transcript)
+	if err != nil {
+		return nil, err
+	}
+	if err := target.HandleUtterance(ctx, speaker, transcript); err != nil {
+		return target, fmt.Errorf("orchestrator: handle utterance for %q: %w", target.ID(), err)
+	}
+	return target, nil
+}
+
 // ActiveAgents returns a snapshot of all NPC agents currently managed by
 // this orchestrator, including both muted and unmuted agents.
 func (o *Orchestrator) ActiveAgents() []agent.NPCAgent {
@@ -291,6 +341,56 @@ func (o *Orchestrator) BroadcastScene(ctx context.Context, scene agent.SceneCont
 	return errors.Join(errs...)
 }
 
+// InjectContext pushes update to the agent with the given ID. Agents
+// implementing [agent.ContextInjector] receive it directly, so that an update
+// with [engine.ContextUpdate.Priority] set restarts a reply the NPC is still
+// giving; for all others it goes straight into the agent's engine. Returns an
+// error if id does not correspond to a registered agent.
+func (o *Orchestrator) InjectContext(ctx context.Context, id string, update engine.ContextUpdate) error {
+	o.mu.RLock()
+	entry, ok := o.agents[id]
+	o.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("orchestrator: agent %q not found", id)
+	}
+	if err := injectContext(ctx, entry.agent, update); err != nil {
+		return fmt.Errorf("orchestrator: inject context for %q: %w", id, err)
+	}
+	return nil
+}
+
+// BroadcastContext pushes update to all active (unmuted) agents like
+// [Orchestrator.InjectContext], e.g. to announce an event the whole scene
+// notices. Errors are collected like those of [Orchestrator.BroadcastScene].
+func (o *Orchestrator) BroadcastContext(ctx context.Context, update engine.ContextUpdate) error {
+	o.mu.RLock()
+	snapshots := make([]agentSnapshot, 0, len(o.agents))
+	for _, e := range o.agents {
+		snapshots = append(snapshots, agentSnapshot{agent: e.agent, muted: e.muted})
+	}
+	o.mu.RUnlock()
+
+	var errs []error
+	for _, s := range snapshots {
+		if s.muted {
+			continue
+		}
+		if err := injectContext(ctx, s.agent, update); err != nil {
+			errs = append(errs, fmt.Errorf("orchestrator: broadcast context for %q: %w", s.agent.ID(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// injectContext pushes update to a through [agent.ContextInjector] when it
+// implements it, and into its engine otherwise.
+func injectContext(ctx context.Context, a agent.NPCAgent, update engine.ContextUpdate) error {
+	if inj, ok := a.(agent.ContextInjector); ok {
+		return inj.InjectContext(ctx, update)
+	}
+	return a.Engine().InjectContext(ctx, update)
+}
+
 // IsMuted reports whether the agent with the given ID is muted.
 // Returns an error if id does not correspond to a registered agent.
 func (o *Orchestrator) IsMuted(id string) (bool, error) {
@@ -9,7 +9,12 @@ import (
 
 	"github.com/MrWong99/glyphoxa/internal/agent"
 	agentmock "github.com/MrWong99/glyphoxa/internal/agent/mock"
+	"github.com/MrWong99/glyphoxa/internal/engine"
 	enginemock "github.com/MrWong99/glyphoxa/internal/engine/mock"
+	"github.com/MrWong99/glyphoxa/internal/hotctx"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	audiomock "github.com/MrWong99/glyphoxa/pkg/audio/mock"
+	memorymock "github.com/MrWong99/glyphoxa/pkg/memory/mock"
 	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
 )
 
@@ -137,6 +142,112 @@ func TestAddressDetector(t *testing.T) {
 	})
 }
 
+func TestAddressDetector_RespondOnlyWhenNamed(t *testing.T) {
+	t.Parallel()
+
+	guard, _ := newMockAgent("guard-1", "Captain Brannock")
+	guard.IdentityResult = agent.NPCIdentity{
+		Name:                 "Captain Brannock",
+		Aliases:              []string{"the captain", "Old Iron"},
+		RespondOnlyWhenNamed: true,
+	}
+	elara, _ := newMockAgent("elara-1", "Elara")
+
+	detector := NewAddressDetector([]agent.NPCAgent{guard, elara})
+	both := map[string]*agentEntry{
+		"guard-1": {agent: guard},
+		"elara-1": {agent: elara},
+	}
+	guardOnly := map[string]*agentEntry{
+		"guard-1": {agent: guard},
+	}
+
+	tests := []struct {
+		name        string
+		text        string
+		lastSpeaker string
+		active      map[string]*agentEntry
+		overrides   map[string]string
+		want        string // empty means ErrNoTarget
+	}{
+		{name: "full name", text: "Captain Brannock, open the gate!", active: both, want: "guard-1"},
+		{name: "name fragment", text: "Brannock, a word?", active: both, want: "guard-1"},
+		{name: "alias", text: "What says the captain?", active: both, want: "guard-1"},
+		{name: "multi-word alias", text: "Hey old iron, you awake?", active: both, want: "guard-1"},
+		{name: "misheard name", text: "Brannok, open up!", active: both, want: "guard-1"},
+		{name: "not named, last speaker", text: "And then what?", lastSpeaker: "guard-1", active: guardOnly},
+		{name: "not named, only NPC present", text: "Hello there.", active: guardOnly},
+		{name: "not named, falls back to the other NPC", text: "Hello there.", active: both, want: "elara-1"},
+		{name: "not named, last speaker skipped", text: "And then what?", lastSpeaker: "guard-1", active: both, want: "elara-1"},
+		{name: "DM override still applies", text: "Halt!", active: both, overrides: map[string]string{"dm": "guard-1"}, want: "guard-1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			overrides := tc.overrides
+			if overrides == nil {
+				overrides = map[string]string{}
+			}
+			id, err := detector.Detect(tc.text, tc.lastSpeaker, tc.active, overrides, "dm")
+			if tc.want == "" {
+				if !errors.Is(err, ErrNoTarget) {
+					t.Fatalf("want ErrNoTarget, got %q, %v", id, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != tc.want {
+				t.Fatalf("want %s, got %s", tc.want, id)
+			}
+		})
+	}
+}
+
+func TestAddressDetector_FuzzyMatch(t *testing.T) {
+	t.Parallel()
+
+	grimjaw, _ := newMockAgent("grimjaw-1", "Grimjaw the Blacksmith")
+	elara, _ := newMockAgent("elara-1", "Elara")
+	detector := NewAddressDetector([]agent.NPCAgent{grimjaw, elara})
+	active := map[string]*agentEntry{
+		"grimjaw-1": {agent: grimjaw},
+		"elara-1":   {agent: elara},
+	}
+
+	tests := []struct {
+		text string
+		want string // empty means ErrNoTarget
+	}{
+		{text: "Grimjah, fix my sword.", want: "grimjaw-1"},
+		{text: "Where is the blaksmith?", want: "grimjaw-1"},
+		{text: "Ellara, over here!", want: "elara-1"},
+		{text: "Grim times ahead."},
+		{text: "It is clear now."},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			t.Parallel()
+			id, err := detector.Detect(tc.text, "", active, map[string]string{}, "player-1")
+			if tc.want == "" {
+				if !errors.Is(err, ErrNoTarget) {
+					t.Fatalf("want ErrNoTarget, got %q, %v", id, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != tc.want {
+				t.Fatalf("want %s, got %s", tc.want, id)
+			}
+		})
+	}
+}
+
 // ── Orchestrator Routing ─────────────────────────────────────────────────────
 
 func TestOrchestratorRoute(t *testing.T) {
@@ -157,6 +268,27 @@ func TestOrchestratorRoute(t *testing.T) {
 		}
 	})
 
+	t.Run("NPC that must be named stays quiet otherwise", func(t *testing.T) {
+		t.Parallel()
+		guard, _ := newMockAgent("g1", "Brannock")
+		guard.IdentityResult = agent.NPCIdentity{Name: "Brannock", RespondOnlyWhenNamed: true}
+		o := New([]agent.NPCAgent{guard})
+
+		got, err := o.Route(context.Background(), "player-1", transcript("Brannock, open the gate."))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.ID() != "g1" {
+			t.Fatalf("want g1, got %s", got.ID())
+		}
+
+		// The follow-up neither names the guard nor falls back to them as
+		// the last speaker.
+		if _, err := o.Route(context.Background(), "player-1", transcript("Thanks, friend.")); !errors.Is(err, ErrNoTarget) {
+			t.Fatalf("want ErrNoTarget, got %v", err)
+		}
+	})
+
 	t.Run("route to muted NPC returns ErrNoTarget", func(t *testing.T) {
 		t.Parallel()
 		grimjaw, _ := newMockAgent("g1", "Grimjaw")
@@ -636,3 +768,470 @@ func TestOptions(t *testing.T) {
 		}
 	})
 }
+
+// ── Turn isolation ───────────────────────────────────────────────────────────
+
+// blockingAgent is a mock agent whose HandleUtterance reports the transcript
+// text on started and then blocks until a value is sent on release.
+type blockingAgent struct {
+	*agentmock.NPCAgent
+	started chan<- string
+	release <-chan struct{}
+}
+
+func (b *blockingAgent) HandleUtterance(ctx context.Context, speaker string, tr stt.Transcript) error {
+	b.started <- tr.Text
+	<-b.release
+	return b.NPCAgent.HandleUtterance(ctx, speaker, tr)
+}
+
+func newBlockingAgent(id, name string, started chan<- string, release <-chan struct{}) *blockingAgent {
+	npc, _ := newMockAgent(id, name)
+	return &blockingAgent{NPCAgent: npc, started: started, release: release}
+}
+
+// waitStarted returns the text of the next turn that started, failing the
+// test if none starts in time.
+func waitStarted(t *testing.T, started <-chan string) string {
+	t.Helper()
+	select {
+	case text := <-started:
+		return text
+	case <-time.After(5 * time.Second):
+		t.Fatal("turn did not start")
+		return ""
+	}
+}
+
+// assertNotStarted fails the test if a turn starts within a short grace
+// period.
+func assertNotStarted(t *testing.T, started <-chan string) {
+	t.Helper()
+	select {
+	case text := <-started:
+		t.Fatalf("turn %q started while another turn of the session was running", text)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDispatch_TurnIsolation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("same session serialises", func(t *testing.T) {
+		t.Parallel()
+		started := make(chan string, 2)
+		release := make(chan struct{})
+		grimjaw := newBlockingAgent("grimjaw-1", "Grimjaw", started, release)
+		elara := newBlockingAgent("elara-1", "Elara", started, release)
+		o := New([]agent.NPCAgent{grimjaw, elara})
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		wg.Go(func() { _, errs[0] = o.Dispatch(context.Background(), "p1", transcript("Grimjaw, a sword please")) })
+		if got := waitStarted(t, started); got != "Grimjaw, a sword please" {
+			t.Fatalf("first turn = %q", got)
+		}
+		wg.Go(func() { _, errs[1] = o.Dispatch(context.Background(), "p2", transcript("Elara, any news?")) })
+		assertNotStarted(t, started)
+
+		release <- struct{}{}
+		if got := waitStarted(t, started); got != "Elara, any news?" {
+			t.Fatalf("second turn = %q", got)
+		}
+		release <- struct{}{}
+		wg.Wait()
+
+		for i, err := range errs {
+			if err != nil {
+				t.Errorf("turn %d: Dispatch: %v", i, err)
+			}
+		}
+		if len(grimjaw.HandleUtteranceCalls) != 1 || len(elara.HandleUtteranceCalls) != 1 {
+			t.Errorf("HandleUtterance calls = %d, %d, want 1, 1",
+				len(grimjaw.HandleUtteranceCalls), len(elara.HandleUtteranceCalls))
+		}
+	})
+
+	t.Run("different sessions run concurrently", func(t *testing.T) {
+		t.Parallel()
+		started := make(chan string, 2)
+		release := make(chan struct{})
+		locks := NewTurnLocks()
+		o1 := New([]agent.NPCAgent{newBlockingAgent("grimjaw-1", "Grimjaw", started, release)}, WithTurnIsolation(locks, "session-1"))
+		o2 := New([]agent.NPCAgent{newBlockingAgent("grimjaw-2", "Grimjaw", started, release)}, WithTurnIsolation(locks, "session-2"))
+
+		var wg sync.WaitGroup
+		wg.Go(func() { _, _ = o1.Dispatch(context.Background(), "p1", transcript("Grimjaw, one")) })
+		wg.Go(func() { _, _ = o2.Dispatch(context.Background(), "p1", transcript("Grimjaw, two")) })
+		waitStarted(t, started)
+		waitStarted(t, started)
+
+		release <- struct{}{}
+		release <- struct{}{}
+		wg.Wait()
+		if n := len(locks.locks); n != 0 {
+			t.Errorf("%d session keys left after all turns finished, want 0", n)
+		}
+	})
+
+	t.Run("isolation disabled", func(t *testing.T) {
+		t.Parallel()
+		started := make(chan string, 2)
+		release := make(chan struct{})
+		o := New([]agent.NPCAgent{
+			newBlockingAgent("grimjaw-1", "Grimjaw", started, release),
+			newBlockingAgent("elara-1", "Elara", started, release),
+		}, WithTurnIsolation(nil, ""))
+
+		var wg sync.WaitGroup
+		wg.Go(func() { _, _ = o.Dispatch(context.Background(), "p1", transcript("Grimjaw, hello")) })
+		wg.Go(func() { _, _ = o.Dispatch(context.Background(), "p2", transcript("Elara, hello")) })
+		waitStarted(t, started)
+		waitStarted(t, started)
+
+		release <- struct{}{}
+		release <- struct{}{}
+		wg.Wait()
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		t.Parallel()
+		started := make(chan string, 1)
+		release := make(chan struct{})
+		npc := newBlockingAgent("grimjaw-1", "Grimjaw", started, release)
+		o := New([]agent.NPCAgent{npc})
+
+		var wg sync.WaitGroup
+		wg.Go(func() { _, _ = o.Dispatch(context.Background(), "p1", transcript("Grimjaw, first")) })
+		waitStarted(t, started)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := o.Dispatch(ctx, "p1", transcript("Grimjaw, second")); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Dispatch error = %v, want %v", err, context.DeadlineExceeded)
+		}
+
+		release <- struct{}{}
+		wg.Wait()
+		if len(npc.HandleUtteranceCalls) != 1 {
+			t.Errorf("HandleUtterance calls = %d, want 1", len(npc.HandleUtteranceCalls))
+		}
+	})
+}
+
+func TestDispatch_Errors(t *testing.T) {
+	t.Parallel()
+
+	errBusy := errors.New("busy")
+	npc, _ := newMockAgent("grimjaw-1", "Grimjaw")
+	npc.HandleUtteranceError = errBusy
+	o := New([]agent.NPCAgent{npc})
+
+	target, err := o.Dispatch(context.Background(), "p1", transcript("Grimjaw, hello"))
+	if !errors.Is(err, errBusy) || target != npc {
+		t.Errorf("Dispatch() = %v, %v, want the agent and %v", target, err, errBusy)
+	}
+
+	o = New(nil)
+	if target, err := o.Dispatch(context.Background(), "p1", transcript("hello")); !errors.Is(err, ErrNoTarget) || target != nil {
+		t.Errorf("Dispatch() without agents = %v, %v, want nil, %v", target, err, ErrNoTarget)
+	}
+}
+
+func TestTurnLocks(t *testing.T) {
+	t.Parallel()
+
+	var locks TurnLocks
+	unlock, err := locks.Lock(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	// Another key is independent.
+	unlock2, err := locks.Lock(context.Background(), "s2")
+	if err != nil {
+		t.Fatalf("Lock other key: %v", err)
+	}
+	unlock2()
+
+	// The held key blocks until ctx is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := locks.Lock(ctx, "s1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lock of held key = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Unlocking twice is safe, and the key can be locked again.
+	unlock()
+	unlock()
+	unlock, err = locks.Lock(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("Lock after unlock: %v", err)
+	}
+	unlock()
+
+	if n := len(locks.locks); n != 0 {
+		t.Errorf("%d keys left, want 0", n)
+	}
+}
+
+// ── context updates ──────────────────────────────────────────────────────────
+
+func TestInjectContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("goes through the agent", func(t *testing.T) {
+		t.Parallel()
+		grimjaw, gEng := newMockAgent("g1", "Grimjaw")
+		o := New([]agent.NPCAgent{grimjaw})
+
+		update := engine.ContextUpdate{Scene: "The bridge collapses!", Priority: true}
+		if err := o.InjectContext(context.Background(), "g1", update); err != nil {
+			t.Fatalf("InjectContext: %v", err)
+		}
+		if len(grimjaw.InjectContextCalls) != 1 || !grimjaw.InjectContextCalls[0].Update.Priority {
+			t.Fatalf("agent InjectContext calls = %+v, want the priority update", grimjaw.InjectContextCalls)
+		}
+		if len(gEng.InjectContextCalls) != 1 {
+			t.Fatalf("engine InjectContext calls = %d, want 1", len(gEng.InjectContextCalls))
+		}
+	})
+
+	t.Run("unknown agent", func(t *testing.T) {
+		t.Parallel()
+		o := New(nil)
+		if err := o.InjectContext(context.Background(), "nobody", engine.ContextUpdate{Scene: "x"}); err == nil {
+			t.Fatal("want error for unknown agent")
+		}
+	})
+
+	t.Run("agent error is returned", func(t *testing.T) {
+		t.Parallel()
+		grimjaw, _ := newMockAgent("g1", "Grimjaw")
+		grimjaw.InjectContextError = errors.New("boom")
+		o := New([]agent.NPCAgent{grimjaw})
+		if err := o.InjectContext(context.Background(), "g1", engine.ContextUpdate{Scene: "x"}); !errors.Is(err, grimjaw.InjectContextError) {
+			t.Fatalf("want wrapped agent error, got %v", err)
+		}
+	})
+
+	t.Run("Route passes recent utterances through the agent", func(t *testing.T) {
+		t.Parallel()
+		grimjaw, _ := newMockAgent("g1", "Grimjaw")
+		o := New([]agent.NPCAgent{grimjaw})
+		o.buffer.Add(BufferEntry{SpeakerID: "player-2", Text: "Did you hear that?", Timestamp: time.Now()})
+
+		if _, err := o.Route(context.Background(), "player-1", transcript("Hey Grimjaw")); err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		if len(grimjaw.InjectContextCalls) != 1 {
+			t.Fatalf("agent InjectContext calls = %d, want 1", len(grimjaw.InjectContextCalls))
+		}
+		if got := grimjaw.InjectContextCalls[0].Update.RecentUtterances; len(got) != 1 {
+			t.Fatalf("recent utterances = %+v, want 1", got)
+		}
+	})
+}
+
+func TestBroadcastContext_SkipsMuted(t *testing.T) {
+	t.Parallel()
+
+	alice, _ := newMockAgent("a1", "Alice")
+	muted, _ := newMockAgent("m1", "Muted")
+	o := New([]agent.NPCAgent{alice, muted})
+	if err := o.MuteAgent("m1"); err != nil {
+		t.Fatalf("mute: %v", err)
+	}
+
+	if err := o.BroadcastContext(context.Background(), engine.ContextUpdate{Scene: "Night falls."}); err != nil {
+		t.Fatalf("BroadcastContext: %v", err)
+	}
+	if len(alice.InjectContextCalls) != 1 {
+		t.Errorf("alice: InjectContext calls = %d, want 1", len(alice.InjectContextCalls))
+	}
+	if len(muted.InjectContextCalls) != 0 {
+		t.Errorf("muted: InjectContext calls = %d, want 0", len(muted.InjectContextCalls))
+	}
+}
+
+// scriptedEngine answers the n-th Process call with process(ctx, n).
+type scriptedEngine struct {
+	enginemock.VoiceEngine
+
+	process func(ctx context.Context, call int) (*engine.Response, error)
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (e *scriptedEngine) Process(ctx context.Context, _ audio.AudioFrame, _ engine.PromptContext) (*engine.Response, error) {
+	e.mu.Lock()
+	call := e.calls
+	e.calls++
+	e.mu.Unlock()
+	return e.process(ctx, call)
+}
+
+func TestBroadcastContext_PriorityRestartsLiveReply(t *testing.T) {
+	t.Parallel()
+
+	eng := &scriptedEngine{process: func(ctx context.Context, call int) (*engine.Response, error) {
+		if call > 0 {
+			ch := make(chan []byte, 1)
+			ch <- []byte("new")
+			close(ch)
+			return &engine.Response{Text: "Fire! Everyone out!", Audio: ch}, nil
+		}
+		// The first reply keeps talking until it is interrupted.
+		ch := make(chan []byte)
+		go func() {
+			defer close(ch)
+			for {
+				select {
+				case ch <- []byte("old"):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return &engine.Response{Text: "Let me tell you of the ancient lore.", Audio: ch}, nil
+	}}
+	mixer := &audiomock.Mixer{}
+	sage, err := agent.NewAgent(agent.AgentConfig{
+		ID:        "sage",
+		Identity:  agent.NPCIdentity{Name: "Greymantle"},
+		Engine:    eng,
+		Assembler: hotctx.NewAssembler(&memorymock.SessionStore{}, &memorymock.KnowledgeGraph{}),
+		Mixer:     mixer,
+		SessionID: "session-001",
+	})
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	o := New([]agent.NPCAgent{sage})
+
+	if err := sage.HandleUtterance(t.Context(), "player-1", transcript("Tell me of the lore.")); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+	if len(mixer.EnqueueCalls) != 1 {
+		t.Fatalf("Enqueue calls = %d, want 1", len(mixer.EnqueueCalls))
+	}
+	seg := mixer.EnqueueCalls[0].Segment
+	if chunk := <-seg.Audio; string(chunk) != "old" {
+		t.Fatalf("first chunk = %q, want %q", chunk, "old")
+	}
+
+	update := engine.ContextUpdate{Scene: "The tavern is on fire!", Priority: true}
+	if err := o.BroadcastContext(t.Context(), update); err != nil {
+		t.Fatalf("BroadcastContext: %v", err)
+	}
+
+	var last string
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case chunk, ok := <-seg.Audio:
+			if !ok {
+				done = true
+				break
+			}
+			last = string(chunk)
+		case <-timeout:
+			t.Fatal("timed out waiting for the restarted reply")
+		}
+	}
+	if last != "new" {
+		t.Errorf("last chunk = %q, want the restarted reply", last)
+	}
+	eng.mu.Lock()
+	calls := eng.calls
+	eng.mu.Unlock()
+	if calls != 2 {
+		t.Errorf("Process calls = %d, want 2", calls)
+	}
+	if len(eng.InjectContextCalls) != 1 || !eng.InjectContextCalls[0].Update.Priority {
+		t.Errorf("engine InjectContext calls = %+v, want the priority update", eng.InjectContextCalls)
+	}
+}
+
+func TestDispatch_RestartedReplyTakesTurnLock(t *testing.T) {
+	t.Parallel()
+
+	eng := &scriptedEngine{process: func(ctx context.Context, call int) (*engine.Response, error) {
+		if call > 0 {
+			ch := make(chan []byte, 1)
+			ch <- []byte("new")
+			close(ch)
+			return &engine.Response{Text: "Fire! Everyone out!", Audio: ch}, nil
+		}
+		ch := make(chan []byte)
+		go func() {
+			defer close(ch)
+			for {
+				select {
+				case ch <- []byte("old"):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return &engine.Response{Text: "Let me tell you of the ancient lore.", Audio: ch}, nil
+	}}
+	mixer := &audiomock.Mixer{}
+	sage, err := agent.NewAgent(agent.AgentConfig{
+		ID:        "sage",
+		Identity:  agent.NPCIdentity{Name: "Greymantle"},
+		Engine:    eng,
+		Assembler: hotctx.NewAssembler(&memorymock.SessionStore{}, &memorymock.KnowledgeGraph{}),
+		Mixer:     mixer,
+		SessionID: "session-001",
+	})
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	locks := NewTurnLocks()
+	o := New([]agent.NPCAgent{sage}, WithTurnIsolation(locks, "session-001"))
+
+	if _, err := o.Dispatch(t.Context(), "player-1", transcript("Greymantle, tell me of the lore.")); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	seg := mixer.EnqueueCalls[0].Segment
+	if chunk := <-seg.Audio; string(chunk) != "old" {
+		t.Fatalf("first chunk = %q, want %q", chunk, "old")
+	}
+
+	// Another turn of the session is running when the update arrives.
+	unlock, err := locks.Lock(t.Context(), "session-001")
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if err := o.BroadcastContext(t.Context(), engine.ContextUpdate{Scene: "The tavern is on fire!", Priority: true}); err != nil {
+		t.Fatalf("BroadcastContext: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	eng.mu.Lock()
+	calls := eng.calls
+	eng.mu.Unlock()
+	if calls != 1 {
+		t.Fatalf("Process calls while another turn holds the lock = %d, want 1", calls)
+	}
+	unlock()
+
+	var last string
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case chunk, ok := <-seg.Audio:
+			if !ok {
+				done = true
+				break
+			}
+			last = string(chunk)
+		case <-timeout:
+			t.Fatal("timed out waiting for the restarted reply")
+		}
+	}
+	if last != "new" {
+		t.Errorf("last chunk = %q, want the restarted reply", last)
+	}
+}
@@ -0,0 +1,70 @@
+package orchestrator
+
+import (
+	"context"
+	"sync"
+)
+
+// TurnLocks is a keyed mutex that serialises NPC turns per session. Turns
+// holding the lock of one session key run one at a time, in the order they
+// asked for it, while turns of different keys run concurrently.
+//
+// One TurnLocks may be shared by the orchestrators of several sessions (see
+// [WithTurnIsolation]). Keys are forgotten once no turn holds or waits for
+// them, so the set does not grow with the number of sessions.
+//
+// The zero value is ready to use. All methods are safe for concurrent use.
+type TurnLocks struct {
+	mu    sync.Mutex
+	locks map[string]*turnLock
+}
+
+// turnLock is the lock of one key. sem holds a token while the lock is held;
+// refs counts the turns holding or waiting for it.
+type turnLock struct {
+	sem  chan struct{}
+	refs int
+}
+
+// NewTurnLocks returns an empty [TurnLocks].
+func NewTurnLocks() *TurnLocks {
+	return &TurnLocks{}
+}
+
+// Lock blocks until the lock of key is acquired or ctx is done. On success
+// it returns the function that releases the lock; calling it more than once
+// is safe. If ctx is done first, Lock returns ctx's error.
+func (l *TurnLocks) Lock(ctx context.Context, key string) (unlock func(), err error) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*turnLock)
+	}
+	tl, ok := l.locks[key]
+	if !ok {
+		tl = &turnLock{sem: make(chan struct{}, 1)}
+		l.locks[key] = tl
+	}
+	tl.refs++
+	l.mu.Unlock()
+
+	select {
+	case tl.sem <- struct{}{}:
+		return sync.OnceFunc(func() {
+			<-tl.sem
+			l.release(key, tl)
+		}), nil
+	case <-ctx.Done():
+		l.release(key, tl)
+		return nil, ctx.Err()
+	}
+}
+
+// release drops one reference to tl and forgets key once it is unused.
+func (l *TurnLocks) release(key string, tl *turnLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tl.refs--
+	if tl.refs == 0 {
+		delete(l.locks, key)
+	}
+}
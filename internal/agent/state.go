@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+// State is the working memory an [NPCAgent] builds up over a session: its
+// conversation history, scene recap, current scene and mood. [Stateful]
+// agents expose it so that it can be persisted with a [StateStore] and
+// restored into an agent recreated for the same NPC and session, for example
+// after a restart.
+type State struct {
+	// Messages is the conversation history sent with every prompt.
+	Messages []llm.Message `json:"messages"`
+
+	// Recap is the scene recap injected into every prompt (see
+	// [SceneRecapper]).
+	Recap string `json:"recap,omitempty"`
+
+	// RecapPending holds the messages not yet folded into Recap.
+	RecapPending []llm.Message `json:"recap_pending,omitempty"`
+
+	// RecapTurns is the number of turns since Recap was last refreshed.
+	RecapTurns int `json:"recap_turns,omitempty"`
+
+	// Scene is the scene last pushed with [NPCAgent.UpdateScene].
+	Scene SceneContext `json:"scene"`
+
+	// Greeted reports whether the NPC already gave its cold-open greeting
+	// (see [NPCIdentity.ColdOpen]).
+	Greeted bool `json:"greeted,omitempty"`
+
+	// Mood is a short free-text description of how the NPC currently feels
+	// (e.g., "suspicious of the party"), shown to it in the system prompt.
+	// Empty means no particular mood.
+	Mood string `json:"mood,omitempty"`
+}
+
+// Stateful is an optional interface for [NPCAgent] implementations whose
+// working memory can be saved and restored. Use a type assertion to detect
+// support.
+type Stateful interface {
+	// State returns a snapshot of the agent's working memory. The snapshot
+	// does not share memory with the agent.
+	State() State
+
+	// RestoreState replaces the agent's working memory with s.
+	RestoreState(s State)
+
+	// SetMood sets the mood shown to the NPC from its next turn on. An empty
+	// mood clears it.
+	SetMood(mood string)
+}
+
+// StateStore persists the working memory of NPC agents per NPC and session.
+//
+// Implementations must be safe for concurrent use.
+type StateStore interface {
+	// SaveState stores state for the NPC npcID in session sessionID,
+	// replacing any state stored before.
+	SaveState(ctx context.Context, npcID, sessionID string, state State) error
+
+	// LoadState returns the state last saved for the NPC npcID in session
+	// sessionID. Returns (nil, nil) if none was saved.
+	LoadState(ctx context.Context, npcID, sessionID string) (*State, error)
+}
+
+// State implements [Stateful]. It holds the agent's lock, so it waits for a
+// turn in progress to finish.
+func (a *liveAgent) State() State {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.stateLocked()
+}
+
+// RestoreState implements [Stateful].
+func (a *liveAgent) RestoreState(s State) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.messages = slices.Clone(s.Messages)
+	a.recap = s.Recap
+	a.recapPending = slices.Clone(s.RecapPending)
+	a.recapTurns = s.RecapTurns
+	a.scene = cloneScene(s.Scene)
+	a.greeted = s.Greeted
+	a.mood = s.Mood
+}
+
+// SetMood implements [Stateful]. The mood is persisted with the next saved
+// state.
+func (a *liveAgent) SetMood(mood string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mood = mood
+}
+
+// stateLocked returns a snapshot of the agent's working memory. The caller
+// must hold a.mu.
+func (a *liveAgent) stateLocked() State {
+	return State{
+		Messages:     slices.Clone(a.messages),
+		Recap:        a.recap,
+		RecapPending: slices.Clone(a.recapPending),
+		RecapTurns:   a.recapTurns,
+		Scene:        cloneScene(a.scene),
+		Greeted:      a.greeted,
+		Mood:         a.mood,
+	}
+}
+
+// saveState persists the agent's working memory to its [StateStore], if it
+// has one. Failures are logged: the conversation goes on, and the next save
+// catches up. The caller must hold a.mu.
+func (a *liveAgent) saveState(ctx context.Context) {
+	if a.states == nil {
+		return
+	}
+	if err := a.states.SaveState(ctx, a.id, a.sessionID, a.stateLocked()); err != nil {
+		slog.Warn("agent: save state failed", "npc", a.id, "err", err)
+	}
+}
+
+// cloneScene returns a copy of scene that shares no slices with it.
+func cloneScene(scene SceneContext) SceneContext {
+	scene.PresentEntities = slices.Clone(scene.PresentEntities)
+	scene.ActiveQuests = slices.Clone(scene.ActiveQuests)
+	return scene
+}
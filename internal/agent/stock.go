@@ -0,0 +1,236 @@
+package agent
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+)
+
+// Intent is the conversational intent of a player utterance, as detected by
+// an [IntentClassifier]. NPCs answer utterances with an intent from their
+// [NPCIdentity.StockResponses] with a canned line instead of calling their
+// engine.
+type Intent string
+
+const (
+	// IntentNone means the utterance has no intent with stock responses and
+	// must be answered by the engine.
+	IntentNone Intent = ""
+
+	// IntentGreeting is a bare greeting, such as "Hello there!".
+	IntentGreeting Intent = "greeting"
+
+	// IntentDismissal ends the conversation, such as "Goodbye" or "Never
+	// mind".
+	IntentDismissal Intent = "dismissal"
+)
+
+// IntentClassifier detects the [Intent] of a player utterance. It runs before
+// every turn of an NPC with stock responses, so it must be cheap: no model
+// calls. Implementations must be safe for concurrent use.
+type IntentClassifier interface {
+	// Classify returns the intent of text, or [IntentNone] if it has none or
+	// the classifier is unsure. A false positive replaces a real answer with
+	// a canned line, so implementations should err towards IntentNone.
+	Classify(text string) Intent
+}
+
+// KeywordClassifier is the default [IntentClassifier]. It recognises short
+// utterances made up of a greeting or dismissal phrase and at most
+// [KeywordClassifier.MaxExtraWords] other words, such as the NPC's name.
+// Questions are never classified, so "Hello, what do you sell?" reaches the
+// engine.
+type KeywordClassifier struct {
+	// MaxExtraWords is the number of words besides the phrase an utterance
+	// may contain. Zero uses 2.
+	MaxExtraWords int
+}
+
+// Compile-time interface check.
+var _ IntentClassifier = KeywordClassifier{}
+
+// intentPhrases lists the phrases KeywordClassifier recognises, as
+// lower-case word sequences.
+var intentPhrases = []struct {
+	words  []string
+	intent Intent
+}{
+	{[]string{"good", "morning"}, IntentGreeting},
+	{[]string{"good", "afternoon"}, IntentGreeting},
+	{[]string{"good", "evening"}, IntentGreeting},
+	{[]string{"good", "day"}, IntentGreeting},
+	{[]string{"well", "met"}, IntentGreeting},
+	{[]string{"hello"}, IntentGreeting},
+	{[]string{"hi"}, IntentGreeting},
+	{[]string{"hey"}, IntentGreeting},
+	{[]string{"greetings"}, IntentGreeting},
+	{[]string{"hail"}, IntentGreeting},
+	{[]string{"howdy"}, IntentGreeting},
+	{[]string{"see", "you"}, IntentDismissal},
+	{[]string{"good", "night"}, IntentDismissal},
+	{[]string{"never", "mind"}, IntentDismissal},
+	{[]string{"that's", "all"}, IntentDismissal},
+	{[]string{"goodbye"}, IntentDismissal},
+	{[]string{"bye"}, IntentDismissal},
+	{[]string{"farewell"}, IntentDismissal},
+	{[]string{"nevermind"}, IntentDismissal},
+}
+
+// Classify implements [IntentClassifier].
+func (c KeywordClassifier) Classify(text string) Intent {
+	if strings.Contains(text, "?") {
+		return IntentNone
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	maxExtra := c.MaxExtraWords
+	if maxExtra <= 0 {
+		maxExtra = 2
+	}
+	for _, p := range intentPhrases {
+		if len(words) < len(p.words) || len(words)-len(p.words) > maxExtra {
+			continue
+		}
+		for i := 0; i+len(p.words) <= len(words); i++ {
+			if slices.Equal(words[i:i+len(p.words)], p.words) {
+				return p.intent
+			}
+		}
+	}
+	return IntentNone
+}
+
+// StockLine is a canned NPC response. See [NPCIdentity.StockResponses].
+type StockLine struct {
+	// Text is spoken as is, after replacing the placeholders {name} with the
+	// NPC's name, {location} with the current scene's location and {time}
+	// with its time of day.
+	Text string
+
+	// Weight is the line's chance of being picked relative to the other
+	// lines for the same intent. Zero or negative counts as 1.
+	Weight int
+}
+
+// newStockRand returns the random source that picks the stock lines of the
+// NPC id in session sessionID. It is seeded from both, so an NPC picks the
+// same sequence of lines when a session is replayed.
+func newStockRand(sessionID, id string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(sessionID))
+	h.Write([]byte{0})
+	h.Write([]byte(id))
+	seed := h.Sum64()
+	return rand.New(rand.NewPCG(seed, seed>>32|seed<<32))
+}
+
+// pickStockLine picks one of lines at random, proportionally to their
+// weights. lines must not be empty.
+func pickStockLine(r *rand.Rand, lines []StockLine) StockLine {
+	total := 0
+	for _, l := range lines {
+		total += max(l.Weight, 1)
+	}
+	n := r.IntN(total)
+	for _, l := range lines {
+		n -= max(l.Weight, 1)
+		if n < 0 {
+			return l
+		}
+	}
+	return lines[len(lines)-1]
+}
+
+// stockLine returns the text of the stock line the NPC answers text with, or
+// false if the utterance must go to the engine. Stock lines need a TTS
+// provider and never replace a pending cold-open greeting. The caller must
+// hold a.mu.
+func (a *liveAgent) stockLine(text string) (string, bool) {
+	if len(a.identity.StockResponses) == 0 || a.ttsProvider == nil {
+		return "", false
+	}
+	if a.identity.ColdOpen && !a.greeted {
+		return "", false
+	}
+	lines := a.identity.StockResponses[a.classifier.Classify(text)]
+	if len(lines) == 0 {
+		return "", false
+	}
+	line := pickStockLine(a.stockRand, lines)
+	return strings.NewReplacer(
+		"{name}", a.identity.Name,
+		"{location}", a.scene.Location,
+		"{time}", a.scene.TimeOfDay,
+	).Replace(line.Text), true
+}
+
+// stockResponse speaks text with the NPC's TTS voice, wrapped in an
+// [engine.Response] as if the engine had produced it, in the format the TTS
+// provider reports (see [engine.TTSFormat]). The audio of every line is cached
+// after its first complete synthesis, so repeated lines cost nothing.
+func (a *liveAgent) stockResponse(ctx context.Context, text string) (*engine.Response, error) {
+	out := make(chan []byte)
+	resp := &engine.Response{
+		Text:       text,
+		Audio:      out,
+		SampleRate: a.ttsFormat.SampleRate,
+		Channels:   a.ttsFormat.Channels,
+	}
+
+	a.stockMu.Lock()
+	cached, ok := a.stockAudio[text]
+	a.stockMu.Unlock()
+	if ok {
+		go func() {
+			defer close(out)
+			for _, chunk := range cached {
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return resp, nil
+	}
+
+	textCh := make(chan string, 1)
+	textCh <- text
+	close(textCh)
+	stream, err := a.ttsProvider.SynthesizeStream(ctx, textCh, a.identity.Voice)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer close(out)
+		var chunks [][]byte
+		for chunk := range stream.Audio() {
+			chunks = append(chunks, chunk)
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// Keep draining so the provider is not blocked.
+			}
+		}
+		if err := stream.Err(); err != nil {
+			resp.SetStreamErr(err)
+			return
+		}
+		if ctx.Err() != nil || len(chunks) == 0 {
+			return
+		}
+		a.stockMu.Lock()
+		if a.stockAudio == nil {
+			a.stockAudio = make(map[string][][]byte)
+		}
+		a.stockAudio[text] = chunks
+		a.stockMu.Unlock()
+	}()
+	return resp, nil
+}
@@ -0,0 +1,243 @@
+package agent_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/agent"
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	enginemock "github.com/MrWong99/glyphoxa/internal/engine/mock"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	audiomock "github.com/MrWong99/glyphoxa/pkg/audio/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	ttsmock "github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+)
+
+func TestKeywordClassifier(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text string
+		want agent.Intent
+	}{
+		{"Hello!", agent.IntentGreeting},
+		{"hey there Grimjaw", agent.IntentGreeting},
+		{"Good morning, merchant.", agent.IntentGreeting},
+		{"Well met.", agent.IntentGreeting},
+		{"Goodbye.", agent.IntentDismissal},
+		{"Never mind, thanks.", agent.IntentDismissal},
+		{"That's all for now", agent.IntentDismissal},
+		{"See you", agent.IntentDismissal},
+		{"Hello, what do you sell?", agent.IntentNone},
+		{"Hello there, I need a sword for the journey", agent.IntentNone},
+		{"Bye?", agent.IntentNone},
+		{"Tell me about the mine.", agent.IntentNone},
+		{"Which way to the highway?", agent.IntentNone},
+		{"", agent.IntentNone},
+	}
+
+	var c agent.KeywordClassifier
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			t.Parallel()
+			if got := c.Classify(tt.text); got != tt.want {
+				t.Errorf("Classify(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// stockConfig returns a config for an NPC with greeting stock responses that
+// reports the text of every turn on the returned channel.
+func stockConfig(sessionID string, lines ...agent.StockLine) (agent.AgentConfig, *enginemock.VoiceEngine, *ttsmock.Provider, <-chan agent.TurnInfo) {
+	turns := make(chan agent.TurnInfo, 1)
+	cfg := validConfig()
+	cfg.SessionID = sessionID
+	cfg.TTS = &ttsmock.Provider{SynthesizeChunks: [][]byte{[]byte("pcm")}}
+	cfg.Identity.StockResponses = map[agent.Intent][]agent.StockLine{agent.IntentGreeting: lines}
+	cfg.OnTurn = func(info agent.TurnInfo) { turns <- info }
+	return cfg, cfg.Engine.(*enginemock.VoiceEngine), cfg.TTS.(*ttsmock.Provider), turns
+}
+
+// stockTurn hands text to a and returns the completed turn.
+func stockTurn(t *testing.T, a agent.NPCAgent, turns <-chan agent.TurnInfo, text string) agent.TurnInfo {
+	t.Helper()
+	if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: text}); err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+	select {
+	case info := <-turns:
+		return info
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the turn observer")
+		return agent.TurnInfo{}
+	}
+}
+
+func TestHandleUtterance_StockResponsesByWeight(t *testing.T) {
+	t.Parallel()
+
+	cfg, eng, _, turns := stockConfig("session-001",
+		agent.StockLine{Text: "Welcome!", Weight: 3},
+		agent.StockLine{Text: "What do you want?"},
+	)
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+
+	const n = 400
+	counts := map[string]int{}
+	for range n {
+		info := stockTurn(t, a, turns, "Hello!")
+		if !info.StockLine {
+			t.Fatalf("turn %+v not answered with a stock line", info)
+		}
+		counts[info.Text]++
+	}
+
+	if got := len(eng.ProcessCalls); got != 0 {
+		t.Errorf("Process calls = %d, want 0", got)
+	}
+	// Weights 3:1 pick the first line about 300 times out of 400.
+	if got := counts["Welcome!"]; got < 260 || got > 340 {
+		t.Errorf("weight 3 line picked %d of %d times, want about 300", got, n)
+	}
+	if counts["Welcome!"]+counts["What do you want?"] != n {
+		t.Errorf("unexpected lines picked: %v", counts)
+	}
+}
+
+func TestHandleUtterance_StockResponsesSeededPerSession(t *testing.T) {
+	t.Parallel()
+
+	lines := []agent.StockLine{{Text: "A"}, {Text: "B"}, {Text: "C"}, {Text: "D"}}
+	sequence := func(sessionID string) []string {
+		cfg, _, _, turns := stockConfig(sessionID, lines...)
+		a, err := agent.NewAgent(cfg)
+		if err != nil {
+			t.Fatalf("NewAgent: %v", err)
+		}
+		var seq []string
+		for range 20 {
+			seq = append(seq, stockTurn(t, a, turns, "Hi").Text)
+		}
+		return seq
+	}
+
+	first, replay, other := sequence("session-a"), sequence("session-a"), sequence("session-b")
+	for i := range first {
+		if first[i] != replay[i] {
+			t.Fatalf("replayed session picked %v, want %v", replay, first)
+		}
+	}
+	same := true
+	for i := range first {
+		same = same && first[i] == other[i]
+	}
+	if same {
+		t.Errorf("sessions a and b picked the same lines %v", first)
+	}
+}
+
+func TestHandleUtterance_StockResponsesFallThrough(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		text   string
+		mutate func(*agent.AgentConfig)
+	}{
+		{name: "question", text: "Hello, what do you sell?"},
+		{name: "intent without lines", text: "Goodbye."},
+		{name: "no TTS provider", text: "Hello!", mutate: func(c *agent.AgentConfig) { c.TTS = nil }},
+		{name: "pending cold open", text: "Hello!", mutate: func(c *agent.AgentConfig) { c.Identity.ColdOpen = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, eng, _, turns := stockConfig("session-001", agent.StockLine{Text: "Welcome!"})
+			if tt.mutate != nil {
+				tt.mutate(&cfg)
+			}
+			a, err := agent.NewAgent(cfg)
+			if err != nil {
+				t.Fatalf("NewAgent: %v", err)
+			}
+			info := stockTurn(t, a, turns, tt.text)
+			if info.StockLine || info.Text != "Well met, traveller." {
+				t.Errorf("turn = %q (stock %v), want the engine's reply", info.Text, info.StockLine)
+			}
+			if got := len(eng.ProcessCalls); got != 1 {
+				t.Errorf("Process calls = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestHandleUtterance_StockResponseTemplateAndCache(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, tp, turns := stockConfig("session-001", agent.StockLine{Text: "{name} welcomes you to {location}."})
+	a, err := agent.NewAgent(cfg)
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	if err := a.UpdateScene(context.Background(), agent.SceneContext{Location: "the Gilded Flagon"}); err != nil {
+		t.Fatalf("UpdateScene: %v", err)
+	}
+
+	for range 2 {
+		info := stockTurn(t, a, turns, "Good evening")
+		if want := "Greymantle the Sage welcomes you to the Gilded Flagon."; info.Text != want {
+			t.Errorf("Text = %q, want %q", info.Text, want)
+		}
+	}
+	if got := len(tp.SynthesizeStreamCalls); got != 1 {
+		t.Errorf("SynthesizeStream calls = %d, want 1; the line's audio should be cached", got)
+	}
+}
+
+func TestHandleUtterance_StockResponseFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		sampleRate int // reported by the TTS provider
+		want       int
+	}{
+		{name: "unreported", want: engine.DefaultTTSSampleRate},
+		{name: "16 kHz provider", sampleRate: 16000, want: 16000},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, tp, turns := stockConfig("session-001", agent.StockLine{Text: "Welcome!"})
+			tp.SampleRateResult = tc.sampleRate
+			mixer := &audiomock.Mixer{}
+			cfg.Mixer = mixer
+			a, err := agent.NewAgent(cfg)
+			if err != nil {
+				t.Fatalf("NewAgent: %v", err)
+			}
+			if err := a.HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: "Hello!"}); err != nil {
+				t.Fatalf("HandleUtterance: %v", err)
+			}
+
+			if len(mixer.EnqueueCalls) != 1 {
+				t.Fatalf("Enqueue calls = %d, want 1", len(mixer.EnqueueCalls))
+			}
+			seg := mixer.EnqueueCalls[0].Segment
+			// The mixer rejects segments without a format.
+			if seg.SampleRate != tc.want || seg.Channels != 1 {
+				t.Errorf("segment format = %d Hz, %d channels; want %d Hz mono", seg.SampleRate, seg.Channels, tc.want)
+			}
+			audio.Drain(seg.Audio)
+			<-turns
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/mcp"
+)
+
+// TurnInfo describes a completed NPC turn: one player utterance handled by
+// [NPCAgent.HandleUtterance] and the reply it produced. It is passed to the
+// [TurnObserver] configured with [WithTurnObserver].
+type TurnInfo struct {
+	// NPCID and NPCName identify the NPC that replied.
+	NPCID   string
+	NPCName string
+
+	// Speaker is the player the NPC replied to, and Input what they said.
+	Speaker string
+	Input   string
+
+	// Text is the reply text returned by the engine.
+	Text string
+
+	// ToolCalls lists the names of the tools executed during the turn, in
+	// call order.
+	ToolCalls []string
+
+	// Duration is the time from the start of HandleUtterance until the reply
+	// audio finished streaming.
+	Duration time.Duration
+
+	// Latency is the engine's latency breakdown of the reply.
+	Latency engine.Latency
+
+	// UsedStrongModel reports whether the turn was escalated to a strong
+	// model. Together with BudgetTier it describes what the turn cost.
+	UsedStrongModel bool
+
+	// BudgetTier is the tool budget the NPC answered with.
+	BudgetTier mcp.BudgetTier
+
+	// StockLine reports whether the reply was one of the NPC's
+	// [NPCIdentity.StockResponses], spoken without calling the engine.
+	StockLine bool
+
+	// Err is the error that cut the reply audio short, or nil.
+	Err error
+}
+
+// TurnObserver is called once for every completed turn. It runs on a
+// goroutine owned by the agent and must return quickly; hand the turn off to
+// a queue for slow work.
+type TurnObserver func(TurnInfo)
+
+// turnTracker collects the tool calls of one turn. Tool calls may arrive from
+// engine goroutines, so it is safe for concurrent use.
+type turnTracker struct {
+	mu    sync.Mutex
+	tools []string
+}
+
+// addTool records a call of the tool name.
+func (t *turnTracker) addTool(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tools = append(t.tools, name)
+}
+
+// toolCalls returns a copy of the recorded tool names.
+func (t *turnTracker) toolCalls() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.tools)
+}
+
+// observeTurn reports the turn to a.onTurn once the reply audio has finished
+// streaming. The reply is the one resp returns at that point, which differs
+// from the first if a priority update restarted it. It returns the channel to
+// play in place of audio; like audio it must be drained.
+func (a *liveAgent) observeTurn(audio <-chan []byte, current func() *engine.Response, info TurnInfo, start time.Time, tracker *turnTracker) <-chan []byte {
+	finish := func() {
+		resp := current()
+		info.Text = resp.Text
+		info.ToolCalls = tracker.toolCalls()
+		info.Duration = time.Since(start)
+		info.Latency = resp.Latency()
+		info.UsedStrongModel = resp.UsedStrongModel()
+		info.Err = resp.Err()
+		a.onTurn(info)
+	}
+
+	if audio == nil {
+		go finish()
+		return nil
+	}
+	out := make(chan []byte)
+	go func(in <-chan []byte) {
+		for chunk := range in {
+			out <- chunk
+		}
+		close(out)
+		finish()
+	}(audio)
+	return out
+}
@@ -10,24 +10,41 @@
 package app
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/MrWong99/glyphoxa/internal/agent"
+	"github.com/MrWong99/glyphoxa/internal/agent/npcstore"
 	"github.com/MrWong99/glyphoxa/internal/agent/orchestrator"
 	"github.com/MrWong99/glyphoxa/internal/config"
 	"github.com/MrWong99/glyphoxa/internal/engine"
 	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
+	"github.com/MrWong99/glyphoxa/internal/engine/fallback"
+	"github.com/MrWong99/glyphoxa/internal/engine/idle"
+	"github.com/MrWong99/glyphoxa/internal/engine/limit"
+	"github.com/MrWong99/glyphoxa/internal/engine/pace"
 	s2sengine "github.com/MrWong99/glyphoxa/internal/engine/s2s"
+	sentencecascade "github.com/MrWong99/glyphoxa/internal/engine/sentence_cascade"
 	"github.com/MrWong99/glyphoxa/internal/entity"
 	"github.com/MrWong99/glyphoxa/internal/hotctx"
 	"github.com/MrWong99/glyphoxa/internal/mcp"
 	"github.com/MrWong99/glyphoxa/internal/mcp/mcphost"
+	"github.com/MrWong99/glyphoxa/internal/observe"
+	"github.com/MrWong99/glyphoxa/internal/session"
 	"github.com/MrWong99/glyphoxa/internal/transcript"
+	"github.com/MrWong99/glyphoxa/internal/vocab"
 	"github.com/MrWong99/glyphoxa/pkg/audio"
 	audiomixer "github.com/MrWong99/glyphoxa/pkg/audio/mixer"
+	"github.com/MrWong99/glyphoxa/pkg/idgen"
 	"github.com/MrWong99/glyphoxa/pkg/memory"
 	"github.com/MrWong99/glyphoxa/pkg/memory/postgres"
 	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
@@ -41,13 +58,24 @@ import (
 // Providers holds one interface value per provider slot. Nil means the
 // provider is not configured. Populated by main.go via the config registry.
 type Providers struct {
-	LLM        llm.Provider
+	LLM llm.Provider
+	// NPCLLMs holds the LLM providers NPCs select with their llm.provider
+	// override, keyed by provider name: the global LLM and the
+	// providers.npc_llms entries.
+	NPCLLMs    map[string]llm.Provider
 	STT        stt.Provider
 	TTS        tts.Provider
 	S2S        providers2s.Provider
 	Embeddings embeddings.Provider
 	VAD        vad.Engine
 	Audio      audio.Platform
+	// Safety checks the speech of cascaded NPC engines before synthesis.
+	// Nil disables content filtering.
+	Safety engine.SafetyFilter
+	// TTSErrorAudio is the PCM cascaded NPC engines play when synthesis of a
+	// reply fails entirely, in the format of the TTS provider (see
+	// [engine.TTSFormat]). Nil keeps such turns silent.
+	TTSErrorAudio []byte
 }
 
 // App owns all subsystem lifetimes and orchestrates the Glyphoxa voice pipeline.
@@ -60,16 +88,27 @@ type App struct {
 	entities  entity.Store
 	sessions  memory.SessionStore
 	graph     memory.KnowledgeGraph
+	npcStates agent.StateStore
 	assembler *hotctx.Assembler
 	mixer     audio.Mixer
 	conn      audio.Connection
 	agents    []agent.NPCAgent
 	router    agent.Router
 	pipeline  transcript.Pipeline
+	hub       *TranscriptHub
+	merger    *TranscriptMerger
+	pruner    *session.Pruner
+	turns     *TurnBus
+	engines   *limit.Limiter
+	vocab     *vocab.Vocabulary
+	ids       idgen.Generator
 
 	// closers are called in order during Shutdown.
 	closers []func() error
 
+	// ready is closed once all providers have warmed up. See [App.Ready].
+	ready chan struct{}
+
 	// stopOnce guards the Shutdown path.
 	stopOnce sync.Once
 }
@@ -87,6 +126,12 @@ func WithKnowledgeGraph(g memory.KnowledgeGraph) Option {
 	return func(a *App) { a.graph = g }
 }
 
+// WithNPCStateStore injects the store NPC working memory is persisted in
+// instead of keeping it in the memory database.
+func WithNPCStateStore(s agent.StateStore) Option {
+	return func(a *App) { a.npcStates = s }
+}
+
 // WithEntityStore injects an entity store instead of creating a MemStore.
 func WithEntityStore(s entity.Store) Option {
 	return func(a *App) { a.entities = s }
@@ -102,6 +147,33 @@ func WithMCPHost(h mcp.Host) Option {
 	return func(a *App) { a.mcpHost = h }
 }
 
+// WithTranscriptHub injects the hub that the merged table transcript is
+// broadcast to. When omitted, New creates a private hub.
+func WithTranscriptHub(h *TranscriptHub) Option {
+	return func(a *App) { a.hub = h }
+}
+
+// WithTurnBus injects the bus that completed NPC turns are published to.
+// When omitted, New creates a private bus; subscribe to it via [App.TurnBus].
+func WithTurnBus(b *TurnBus) Option {
+	return func(a *App) { a.turns = b }
+}
+
+// WithEngineLimiter sets the limiter that counts and caps the open NPC
+// engines. By default New creates one from server.max_concurrent_npcs that
+// reports to [observe.DefaultMetrics]. Share the limiter with the
+// [SessionManager] so both count against the same limit.
+func WithEngineLimiter(l *limit.Limiter) Option {
+	return func(a *App) { a.engines = l }
+}
+
+// WithIDGenerator sets the generator for the IDs of entities the app
+// creates. Share it with the [SessionManager] so session IDs come from the
+// same source. Defaults to [idgen.Default].
+func WithIDGenerator(g idgen.Generator) Option {
+	return func(a *App) { a.ids = g }
+}
+
 // sessionID returns the canonical session identifier derived from the campaign
 // name. It falls back to "session-default" when no campaign is configured.
 func (a *App) sessionID() string {
@@ -118,8 +190,10 @@ func (a *App) sessionID() string {
 // to inject test doubles for any subsystem.
 //
 // New performs all initialisation synchronously: entity loading, memory store
-// connection, MCP server registration + calibration, NPC engine construction,
-// agent loading, and orchestrator assembly.
+// connection, MCP server registration + calibration, NPC engine
+// construction, agent loading, and orchestrator assembly. Provider warm-up
+// (see [Warmer]) runs in the background with ctx after New returns;
+// [App.Ready] is closed once it completes.
 func New(ctx context.Context, cfg *config.Config, providers *Providers, opts ...Option) (*App, error) {
 	a := &App{
 		cfg:       cfg,
@@ -128,6 +202,12 @@ func New(ctx context.Context, cfg *config.Config, providers *Providers, opts ...
 	for _, o := range opts {
 		o(a)
 	}
+	if a.engines == nil {
+		a.engines = newEngineLimiter(cfg.Server.MaxConcurrentNPCs)
+	}
+	if a.ids == nil {
+		a.ids = idgen.Default()
+	}
 
 	// ── 1. Entity store ──────────────────────────────────────────────────
 	if err := a.initEntities(ctx); err != nil {
@@ -138,26 +218,67 @@ func New(ctx context.Context, cfg *config.Config, providers *Providers, opts ...
 	if err := a.initMemory(ctx); err != nil {
 		return nil, fmt.Errorf("app: init memory: %w", err)
 	}
+	a.initPruner()
+	a.sessions = filterTranscripts(a.sessions, a.cfg.Memory.TranscriptFilter)
 
 	// ── 3. MCP host ─────────────────────────────────────────────────────
 	if err := a.initMCP(ctx); err != nil {
 		return nil, fmt.Errorf("app: init mcp: %w", err)
 	}
 
-	// ── 4. Hot context assembler ─────────────────────────────────────────
-	a.assembler = hotctx.NewAssembler(a.sessions, a.graph)
+	// ── 4. Vocabulary + hot context assembler ────────────────────────────
+	a.vocab = newVocabulary(ctx, a.cfg, a.graph, a.entities)
+	a.assembler = newAssembler(a.sessions, a.graph, a.providers, a.vocab)
 
 	// ── 5. Mixer ─────────────────────────────────────────────────────────
 	a.initMixer()
 
-	// ── 6. Agents + orchestrator ─────────────────────────────────────────
+	// ── 6. Turn bus ──────────────────────────────────────────────────────
+	if a.turns == nil {
+		a.turns = NewTurnBus()
+		a.closers = append(a.closers, func() error {
+			a.turns.Close()
+			return nil
+		})
+	}
+
+	// ── 7. Agents + orchestrator ─────────────────────────────────────────
 	if err := a.initAgents(ctx); err != nil {
 		return nil, fmt.Errorf("app: init agents: %w", err)
 	}
 
-	// ── 7. Transcript pipeline ───────────────────────────────────────────
+	// ── 8. Transcript pipeline ───────────────────────────────────────────
 	a.pipeline = transcript.NewPipeline()
 
+	// ── 9. Transcript hub ────────────────────────────────────────────────
+	ownHub := a.hub == nil
+	if ownHub {
+		a.hub = NewTranscriptHub()
+	}
+
+	// ── 10. Merged transcript ────────────────────────────────────────────
+	// The merger puts the lines of every NPC and player in order; the hub
+	// broadcasts its output. Closing the merger flushes the held-back lines
+	// to the hub before the hub itself is closed.
+	a.merger = NewTranscriptMerger()
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		a.publishTranscripts()
+	}()
+	a.closers = append(a.closers, func() error {
+		a.merger.Close()
+		<-published
+		if ownHub {
+			a.hub.Close()
+		}
+		return nil
+	})
+
+	// ── 11. Provider warm-up ─────────────────────────────────────────────
+	a.ready = make(chan struct{})
+	go a.warmup(ctx)
+
 	return a, nil
 }
 
@@ -166,7 +287,7 @@ func New(ctx context.Context, cfg *config.Config, providers *Providers, opts ...
 // initEntities sets up the entity store and loads campaign data.
 func (a *App) initEntities(ctx context.Context) error {
 	if a.entities == nil {
-		a.entities = entity.NewMemStore()
+		a.entities = entity.NewMemStore(entity.WithIDGenerator(a.ids))
 	}
 
 	for _, path := range a.cfg.Campaign.EntityFiles {
@@ -195,22 +316,41 @@ func (a *App) initMemory(ctx context.Context) error {
 		return fmt.Errorf("memory.postgres_dsn is required when memory stores are not injected")
 	}
 
-	dims := a.cfg.Memory.EmbeddingDimensions
-	if dims == 0 {
-		dims = 1536 // sensible default for OpenAI text-embedding-3-small
+	dims, err := EmbeddingDimensions(a.cfg.Memory.EmbeddingDimensions, a.providers.Embeddings)
+	if err != nil {
+		return err
 	}
 
-	store, err := postgres.NewStore(ctx, dsn, dims)
+	store, err := postgres.NewStore(ctx, dsn, dims,
+		postgres.WithDefaultLanguage(a.cfg.Memory.Language),
+		postgres.WithDistanceMetric(a.cfg.Memory.DistanceMetric),
+	)
 	if err != nil {
 		return err
 	}
 
+	// Refuse to mix vectors from different embedding models in one store.
+	if p := a.providers.Embeddings; p != nil {
+		if err := store.BindEmbeddingModel(ctx, p.ModelID(), p.Dimensions()); err != nil {
+			store.Close()
+			return err
+		}
+	}
+
 	if a.sessions == nil {
 		a.sessions = store.L1()
 	}
 	if a.graph == nil {
 		a.graph = store
 	}
+	if a.npcStates == nil {
+		states := npcstore.NewPostgresStore(store.Pool())
+		if err := states.Migrate(ctx); err != nil {
+			store.Close()
+			return err
+		}
+		a.npcStates = states
+	}
 
 	a.closers = append(a.closers, func() error {
 		store.Close()
@@ -219,6 +359,88 @@ func (a *App) initMemory(ctx context.Context) error {
 	return nil
 }
 
+// newAssembler creates the hot context assembler. Knowledge retrieval uses
+// vector search when an embeddings provider is configured; voc, if non-nil,
+// is added to every prompt as a spelling reference.
+func newAssembler(sessions memory.SessionStore, graph memory.KnowledgeGraph, providers *Providers, voc *vocab.Vocabulary) *hotctx.Assembler {
+	var opts []hotctx.Option
+	if providers != nil && providers.Embeddings != nil {
+		opts = append(opts, hotctx.WithEmbeddings(providers.Embeddings))
+	}
+	if voc != nil {
+		opts = append(opts, hotctx.WithVocabulary(voc.Terms))
+	}
+	return hotctx.NewAssembler(sessions, graph, opts...)
+}
+
+// newVocabulary creates the campaign vocabulary from campaign.vocabulary,
+// the NPC names, the entity store and the knowledge graph. Failing to read a
+// store is logged and leaves its names out; the vocabulary is best-effort.
+func newVocabulary(ctx context.Context, cfg *config.Config, graph memory.KnowledgeGraph, entities entity.Store) *vocab.Vocabulary {
+	v := vocab.New(graph, cfg.Campaign.Vocabulary)
+	if err := v.Refresh(ctx); err != nil {
+		slog.Warn("vocabulary: knowledge graph names unavailable", "err", err)
+	}
+	var names []string
+	for _, npc := range cfg.NPCs {
+		names = append(names, npc.Name)
+	}
+	if entities != nil {
+		defs, err := entities.List(ctx, entity.ListOptions{})
+		if err != nil {
+			slog.Warn("vocabulary: entity names unavailable", "err", err)
+		}
+		for _, d := range defs {
+			names = append(names, d.Name)
+		}
+	}
+	v.Add(names...)
+	return v
+}
+
+// EmbeddingDimensions resolves the vector size of the L2 embeddings column.
+// An explicit memory.embedding_dimensions must agree with the size reported by
+// the embeddings provider, so a model switch is caught at startup instead of
+// on the first insert. When unset, the provider's size is used, falling back
+// to 1536 (OpenAI text-embedding-3-small) when neither is known.
+func EmbeddingDimensions(configured int, p embeddings.Provider) (int, error) {
+	reported := 0
+	if p != nil {
+		reported = p.Dimensions()
+	}
+	switch {
+	case configured > 0 && reported > 0 && configured != reported:
+		return 0, fmt.Errorf("memory.embedding_dimensions is %d but embeddings model %q produces %d-dimensional vectors", configured, p.ModelID(), reported)
+	case configured > 0:
+		return configured, nil
+	case reported > 0:
+		slog.Info("using embedding dimensions reported by provider", "model", p.ModelID(), "dimensions", reported)
+		return reported, nil
+	default:
+		return 1536, nil
+	}
+}
+
+// initPruner sets up the job that prunes L1 transcript entries older than
+// server.l1_retention. Without a retention, or when the session store cannot
+// prune, no job is created and all entries are kept.
+func (a *App) initPruner() {
+	retention := a.cfg.Server.L1Retention
+	if retention <= 0 {
+		return
+	}
+	store, ok := a.sessions.(memory.TranscriptPruner)
+	if !ok {
+		slog.Warn("server.l1_retention is set but the session store cannot prune transcripts; keeping all entries")
+		return
+	}
+	a.pruner = session.NewPruner(session.PrunerConfig{Store: store, Retention: retention})
+	a.closers = append(a.closers, func() error {
+		a.pruner.Stop()
+		return nil
+	})
+}
+
 // initMCP sets up the MCP host, registers servers, and calibrates.
 func (a *App) initMCP(ctx context.Context) error {
 	if a.mcpHost == nil {
@@ -227,14 +449,7 @@ func (a *App) initMCP(ctx context.Context) error {
 	a.closers = append(a.closers, a.mcpHost.Close)
 
 	for _, srv := range a.cfg.MCP.Servers {
-		serverCfg := mcp.ServerConfig{
-			Name:      srv.Name,
-			Transport: srv.Transport,
-			Command:   srv.Command,
-			URL:       srv.URL,
-			Env:       srv.Env,
-		}
-		if err := a.mcpHost.RegisterServer(ctx, serverCfg); err != nil {
+		if err := a.mcpHost.RegisterServer(ctx, mcpServerConfig(srv)); err != nil {
 			return fmt.Errorf("register mcp server %q: %w", srv.Name, err)
 		}
 		slog.Info("registered MCP server", "name", srv.Name)
@@ -247,6 +462,73 @@ func (a *App) initMCP(ctx context.Context) error {
 	return nil
 }
 
+// mcpServerConfig converts a configured MCP server into an [mcp.ServerConfig].
+func mcpServerConfig(srv config.MCPServerConfig) mcp.ServerConfig {
+	return mcp.ServerConfig{
+		Name:      srv.Name,
+		Transport: srv.Transport,
+		Command:   srv.Command,
+		URL:       srv.URL,
+		Env:       srv.Env,
+	}
+}
+
+// ReloadMCP brings the MCP host in line with a changed mcp.servers list. old
+// is the list currently registered. Servers that were removed or whose
+// settings changed are disconnected; new and changed servers are connected
+// and the host is recalibrated.
+//
+// Agents get their tools when they are created, so new tools reach the NPCs
+// of sessions started afterwards. A server that fails to connect is skipped
+// and reported in the returned error; the others are still applied.
+func (a *App) ReloadMCP(ctx context.Context, old, new []config.MCPServerConfig) error {
+	if a.mcpHost == nil {
+		return fmt.Errorf("reload mcp: no mcp host")
+	}
+
+	oldByName := make(map[string]config.MCPServerConfig, len(old))
+	for _, srv := range old {
+		oldByName[srv.Name] = srv
+	}
+	newByName := make(map[string]config.MCPServerConfig, len(new))
+	for _, srv := range new {
+		newByName[srv.Name] = srv
+	}
+
+	var errs []error
+	for _, srv := range old {
+		if n, ok := newByName[srv.Name]; ok && reflect.DeepEqual(n, srv) {
+			continue
+		}
+		if err := a.mcpHost.UnregisterServer(srv.Name); err != nil {
+			errs = append(errs, fmt.Errorf("unregister mcp server %q: %w", srv.Name, err))
+			continue
+		}
+		slog.Info("unregistered MCP server", "name", srv.Name)
+	}
+
+	var registered bool
+	for _, srv := range new {
+		if o, ok := oldByName[srv.Name]; ok && reflect.DeepEqual(o, srv) {
+			continue
+		}
+		if err := a.mcpHost.RegisterServer(ctx, mcpServerConfig(srv)); err != nil {
+			errs = append(errs, fmt.Errorf("register mcp server %q: %w", srv.Name, err))
+			continue
+		}
+		registered = true
+		slog.Info("registered MCP server", "name", srv.Name)
+	}
+
+	if registered {
+		if err := a.mcpHost.Calibrate(ctx); err != nil {
+			slog.Warn("MCP calibration failed, using declared latencies", "err", err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 // initMixer creates the priority mixer if one wasn't injected.
 func (a *App) initMixer() {
 	if a.mixer != nil {
@@ -254,11 +536,30 @@ func (a *App) initMixer() {
 	}
 	// Output callback is wired to the audio connection in Run.
 	// For now create with a no-op output; Run replaces it.
-	pm := audiomixer.New(func(audio.AudioFrame) {})
+	pm := audiomixer.New(func(audio.AudioFrame) {}, mixerOptions(a.cfg.BargeIn, a.cfg.Output)...)
 	a.mixer = pm
 	a.closers = append(a.closers, pm.Close)
 }
 
+// mixerOptions translates the interruption and output settings into mixer
+// options.
+func mixerOptions(bargeIn config.BargeInConfig, output config.OutputConfig) []audiomixer.Option {
+	var opts []audiomixer.Option
+	switch {
+	case bargeIn.FadeOutMs > 0:
+		opts = append(opts, audiomixer.WithFadeOut(time.Duration(bargeIn.FadeOutMs)*time.Millisecond))
+	case bargeIn.FadeOutMs < 0:
+		opts = append(opts, audiomixer.WithFadeOut(0))
+	}
+	if output.MaxQueue > 0 {
+		opts = append(opts, audiomixer.WithMaxQueue(output.MaxQueue))
+	}
+	if output.Preempt == config.OutputPreemptNewest {
+		opts = append(opts, audiomixer.WithPreemptNewest())
+	}
+	return opts
+}
+
 // initAgents creates per-NPC engines and agents, then builds the orchestrator.
 func (a *App) initAgents(ctx context.Context) error {
 	if len(a.cfg.NPCs) == 0 {
@@ -267,35 +568,55 @@ func (a *App) initAgents(ctx context.Context) error {
 		return nil
 	}
 
-	loader, err := agent.NewLoader(
-		a.assembler,
-		a.sessionID(),
+	historyOpts, err := historyLoaderOptions(a.cfg.Memory, a.providers.LLM)
+	if err != nil {
+		return fmt.Errorf("create history policy: %w", err)
+	}
+	loaderOpts := append([]agent.LoaderOption{
 		agent.WithMCPHost(a.mcpHost),
 		agent.WithMixer(a.mixer),
-	)
+		agent.WithTurnObserver(turnPublisher(a.turns, a.sessionID())),
+	}, historyOpts...)
+	if a.npcStates != nil {
+		loaderOpts = append(loaderOpts, agent.WithStateStore(a.npcStates))
+	}
+	loaderOpts = append(loaderOpts, recapLoaderOptions(a.cfg.Memory.SceneRecap, a.providers.LLM)...)
+	loaderOpts = append(loaderOpts, gateLoaderOptions(a.cfg.STTGate)...)
+	if a.providers.TTS != nil {
+		loaderOpts = append(loaderOpts, agent.WithTTS(a.providers.TTS))
+	}
+
+	loader, err := agent.NewLoader(a.assembler, a.sessionID(), loaderOpts...)
 	if err != nil {
 		return fmt.Errorf("create agent loader: %w", err)
 	}
 
 	var agents []agent.NPCAgent
-	for i, npc := range a.cfg.NPCs {
-		eng, err := buildEngine(a.providers, npc)
+	for i, npc := range assignVoices(ctx, a.providers.TTS, a.cfg.NPCs, a.cfg.VoiceAssignment) {
+		eng, err := buildEngine(a.providers, npc, a.engines)
 		if err != nil {
 			return fmt.Errorf("build engine for NPC %q (index %d): %w", npc.Name, i, err)
 		}
 		a.closers = append(a.closers, eng.Close)
 
 		identity := agent.NPCIdentity{
-			Name:           npc.Name,
-			Personality:    npc.Personality,
-			Voice:          configVoiceProfile(npc.Voice),
-			KnowledgeScope: npc.KnowledgeScope,
+			Name:                 npc.Name,
+			Personality:          npc.Personality,
+			Voice:                configVoiceProfile(npc.Voice),
+			KnowledgeScope:       npc.KnowledgeScope,
+			BehaviorRules:        npc.BehaviorRules,
+			ColdOpen:             npc.ColdOpen,
+			AwarenessRadius:      npc.AwarenessRadius,
+			StyleExemplars:       npc.StyleExemplars,
+			StockResponses:       configStockResponses(npc.StockResponses),
+			Aliases:              npcAliases(ctx, a.graph, npc),
+			RespondOnlyWhenNamed: npc.RespondOnlyWhenNamed,
 		}
 
 		npcID := fmt.Sprintf("npc-%d-%s", i, npc.Name)
 		tier := configBudgetTier(npc.BudgetTier)
 
-		ag, err := loader.Load(npcID, identity, eng, tier)
+		ag, err := loader.Load(ctx, npcID, identity, eng, tier)
 		if err != nil {
 			return fmt.Errorf("load agent %q: %w", npc.Name, err)
 		}
@@ -308,36 +629,79 @@ func (a *App) initAgents(ctx context.Context) error {
 	return nil
 }
 
-// buildEngine constructs the appropriate VoiceEngine for an NPC config.
+// buildEngine constructs the appropriate VoiceEngine for an NPC config. The
+// engine takes a slot from engines until it is closed. When the NPC has an
+// idle timeout, the engine is wrapped so it is closed while idle, freeing its
+// slot, and recreated on the next turn. A minimum response latency holds back
+// the audio of fast replies.
 // This is a package-level function so both App and SessionManager can use it.
-func buildEngine(providers *Providers, npc config.NPCConfig) (engine.VoiceEngine, error) {
+func buildEngine(providers *Providers, npc config.NPCConfig, engines *limit.Limiter) (engine.VoiceEngine, error) {
+	build := func() (engine.VoiceEngine, error) {
+		return engines.Build(string(npc.Engine), func() (engine.VoiceEngine, error) {
+			eng, err := buildNPCEngine(providers, npc)
+			if err != nil {
+				return nil, err
+			}
+			return pace.New(eng, time.Duration(npc.MinResponseLatencyMs)*time.Millisecond), nil
+		})
+	}
+	if npc.IdleTimeoutMinutes <= 0 {
+		return build()
+	}
+	return idle.New(build, time.Duration(npc.IdleTimeoutMinutes)*time.Minute)
+}
+
+// newEngineLimiter creates an engine limiter allowing maxEngines open engines
+// that reports them to the default metrics.
+func newEngineLimiter(maxEngines int) *limit.Limiter {
+	m := observe.DefaultMetrics()
+	return limit.New(maxEngines, limit.WithObserver(func(kind string, delta int) {
+		m.RecordActiveEngines(context.Background(), kind, delta)
+	}))
+}
+
+// buildNPCEngine constructs the VoiceEngine selected by npc.Engine.
+func buildNPCEngine(providers *Providers, npc config.NPCConfig) (engine.VoiceEngine, error) {
 	voice := configVoiceProfile(npc.Voice)
+	post, err := engine.LookupPostProcessors(npc.PostProcessors)
+	if err != nil {
+		return nil, err
+	}
 
 	switch npc.Engine {
-	case config.EngineCascaded, config.EngineSentenceCascade:
-		if providers.LLM == nil {
-			return nil, fmt.Errorf("cascaded engine requires an LLM provider")
-		}
-		if providers.TTS == nil {
-			return nil, fmt.Errorf("cascaded engine requires a TTS provider")
-		}
-		return cascade.New(
-			providers.LLM, // fast LLM
-			providers.LLM, // strong LLM (same for now; cascade config can override)
-			providers.TTS,
-			voice,
-		), nil
+	case config.EngineCascaded:
+		return buildCascade(providers, npc, voice, post)
+
+	case config.EngineSentenceCascade:
+		return buildSentenceCascade(providers, npc, voice, post)
 
 	case config.EngineS2S:
 		if providers.S2S == nil {
 			return nil, fmt.Errorf("s2s engine requires an S2S provider")
 		}
-		return s2sengine.New(
+		s2s := s2sengine.New(
 			providers.S2S,
 			providers2s.SessionConfig{
 				Voice:        voice,
 				Instructions: npc.Personality,
 			},
+			s2sengine.WithMaxToolRounds(npc.MaxToolRounds),
+			s2sengine.WithPostProcessors(post...),
+		)
+		fb := npc.S2SFallback
+		if fb == nil {
+			return s2s, nil
+		}
+		fbVoice := configVoiceProfile(fb.Voice)
+		fbVoice.Language = cmp.Or(fbVoice.Language, voice.Language)
+		cascadeEng, err := buildCascade(providers, npc, fbVoice, post)
+		if err != nil {
+			_ = s2s.Close()
+			return nil, fmt.Errorf("s2s fallback: %w", err)
+		}
+		return fallback.New(s2s, cascadeEng,
+			fallback.WithFailureThreshold(fb.FailureThreshold),
+			fallback.WithRetryAfter(time.Duration(fb.RetryAfterSeconds)*time.Second),
 		), nil
 
 	default:
@@ -345,6 +709,188 @@ func buildEngine(providers *Providers, npc config.NPCConfig) (engine.VoiceEngine
 	}
 }
 
+// buildCascade constructs the cascaded STT → LLM → TTS engine for npc.
+func buildCascade(providers *Providers, npc config.NPCConfig, voice tts.VoiceProfile, post engine.PostProcessors) (engine.VoiceEngine, error) {
+	llmProvider, err := npcLLM(providers, npc)
+	if err != nil {
+		return nil, err
+	}
+	if providers.TTS == nil {
+		return nil, fmt.Errorf("cascaded engine requires a TTS provider")
+	}
+	format := engine.TTSFormat(providers.TTS)
+	opts := append(cascadeOptions(npc),
+		cascade.WithTTSFormat(format.SampleRate, format.Channels),
+		cascade.WithPostProcessors(post...),
+		cascade.WithSpeaker(npc.Name),
+		cascade.WithSafetyFilter(providers.Safety),
+		cascade.WithErrorAudio(providers.TTSErrorAudio),
+	)
+	return cascade.New(
+		llmProvider, // fast LLM
+		llmProvider, // strong LLM (same provider; models may differ per cascade config)
+		providers.TTS,
+		voice,
+		opts...,
+	), nil
+}
+
+// buildSentenceCascade constructs the single-model sentence cascade engine
+// for npc. Its model is cascade.strong_model, falling back to llm.model.
+func buildSentenceCascade(providers *Providers, npc config.NPCConfig, voice tts.VoiceProfile, post engine.PostProcessors) (engine.VoiceEngine, error) {
+	llmProvider, err := npcLLM(providers, npc)
+	if err != nil {
+		return nil, err
+	}
+	if providers.TTS == nil {
+		return nil, fmt.Errorf("sentence_cascade engine requires a TTS provider")
+	}
+	var model, fallback string
+	if npc.LLM != nil {
+		model = npc.LLM.Model
+	}
+	if cfg := npc.CascadeConfig; cfg != nil {
+		model = cmp.Or(cfg.StrongModel, model)
+		fallback = cfg.FallbackLine
+	}
+	format := engine.TTSFormat(providers.TTS)
+	return sentencecascade.New(llmProvider, providers.TTS, voice,
+		sentencecascade.WithTTSFormat(format.SampleRate, format.Channels),
+		sentencecascade.WithModel(model),
+		sentencecascade.WithEmptyResponseFallback(fallback),
+		sentencecascade.WithMaxToolRounds(npc.MaxToolRounds),
+		sentencecascade.WithPostProcessors(post...),
+		sentencecascade.WithSpeaker(npc.Name),
+		sentencecascade.WithSafetyFilter(providers.Safety),
+		sentencecascade.WithErrorAudio(providers.TTSErrorAudio),
+	), nil
+}
+
+// npcLLM resolves the LLM provider for npc, honouring its llm.provider
+// override. It returns an error if the selected provider is unavailable.
+func npcLLM(providers *Providers, npc config.NPCConfig) (llm.Provider, error) {
+	if npc.LLM == nil || npc.LLM.Provider == "" {
+		if providers.LLM == nil {
+			return nil, fmt.Errorf("cascaded engine requires an LLM provider")
+		}
+		return providers.LLM, nil
+	}
+	if p, ok := providers.NPCLLMs[npc.LLM.Provider]; ok && p != nil {
+		return p, nil
+	}
+	return nil, fmt.Errorf("llm provider %q for NPC %q is not available", npc.LLM.Provider, npc.Name)
+}
+
+// cascadeOptions translates the optional per-NPC cascade, LLM and tool settings
+// into [cascade.Option] values. Cascade fast/strong models take precedence
+// over the NPC-level llm.model override.
+func cascadeOptions(npc config.NPCConfig) []cascade.Option {
+	var opts []cascade.Option
+	var model string
+	if npc.LLM != nil {
+		model = npc.LLM.Model
+	}
+	cfg := npc.CascadeConfig
+	if cfg == nil {
+		cfg = &config.CascadeConfig{}
+	}
+	if m := cmp.Or(cfg.FastModel, model); m != "" {
+		opts = append(opts, cascade.WithFastModel(m))
+	}
+	if m := cmp.Or(cfg.StrongModel, model); m != "" {
+		opts = append(opts, cascade.WithStrongModel(m))
+	}
+	if cfg.OpenerInstruction != "" {
+		opts = append(opts, cascade.WithOpenerPromptSuffix(cfg.OpenerInstruction))
+	}
+	if cfg.FallbackLine != "" {
+		opts = append(opts, cascade.WithEmptyResponseFallback(cfg.FallbackLine))
+	}
+	if npc.MaxToolRounds > 0 {
+		opts = append(opts, cascade.WithMaxToolRounds(npc.MaxToolRounds))
+	}
+	if cfg.SplitTranscript {
+		opts = append(opts, cascade.WithSplitTranscript(true))
+	}
+	if cfg.RepeatWindow > 0 {
+		opts = append(opts, cascade.WithRepeatWindow(cfg.RepeatWindow), cascade.WithRepeatThreshold(cfg.RepeatThreshold))
+	}
+	if len(cfg.ContinuationStop) > 0 {
+		opts = append(opts, cascade.WithContinuationStop(cfg.ContinuationStop...))
+	}
+	if cfg.MaxContinuationTokens > 0 {
+		opts = append(opts, cascade.WithMaxContinuationTokens(cfg.MaxContinuationTokens))
+	}
+	if cfg.ResumeAfterInterrupt {
+		opts = append(opts, cascade.WithInterruptionNote(cfg.InterruptInstruction))
+	}
+	return opts
+}
+
+// filterTranscripts wraps store so that it skips the utterances rejected by
+// the configured memory.transcript_filter. It returns store unchanged when
+// the filter is disabled.
+func filterTranscripts(store memory.SessionStore, cfg config.TranscriptFilterConfig) memory.SessionStore {
+	filter := session.NewPersistFilter(session.PersistFilterConfig{
+		MinWords:    cfg.MinWords,
+		MinChars:    cfg.MinChars,
+		SkipFillers: cfg.SkipFillers,
+		Fillers:     cfg.Fillers,
+	})
+	if store == nil || !filter.Enabled() {
+		return store
+	}
+	return session.NewFilteredStore(store, filter)
+}
+
+// historyLoaderOptions returns the [agent.WithHistoryPolicy] option for the
+// configured memory.history_summary_threshold, or nil when history
+// compression is disabled or no LLM is available to write the summaries.
+func historyLoaderOptions(cfg config.MemoryConfig, provider llm.Provider) ([]agent.LoaderOption, error) {
+	if cfg.HistorySummaryThreshold == 0 {
+		return nil, nil
+	}
+	if provider == nil {
+		slog.Warn("memory.history_summary_threshold is set but no LLM provider is configured; history compression disabled")
+		return nil, nil
+	}
+	policy, err := session.NewCompressPolicy(session.CompressPolicyConfig{
+		Threshold:  cfg.HistorySummaryThreshold,
+		Summariser: session.NewLLMSummariser(provider),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []agent.LoaderOption{agent.WithHistoryPolicy(policy)}, nil
+}
+
+// recapLoaderOptions returns the [agent.WithSceneRecap] option for the
+// configured memory.scene_recap, or nil when the recap is disabled or no LLM
+// is available to write it.
+func recapLoaderOptions(cfg config.SceneRecapConfig, provider llm.Provider) []agent.LoaderOption {
+	if cfg.Every == 0 {
+		return nil
+	}
+	if provider == nil {
+		slog.Warn("memory.scene_recap is set but no LLM provider is configured; scene recap disabled")
+		return nil
+	}
+	return []agent.LoaderOption{agent.WithSceneRecap(session.NewLLMRecapper(provider, cfg.MaxWords), cfg.Every)}
+}
+
+// gateLoaderOptions returns the [agent.WithTranscriptGate] option for the
+// configured stt_gate, or nil when the gate is disabled.
+func gateLoaderOptions(cfg config.STTGateConfig) []agent.LoaderOption {
+	if cfg.Disabled {
+		return nil
+	}
+	gate := agent.TranscriptGate{MinConfidence: cfg.MinConfidence, Reply: cfg.Reply}
+	if cfg.Reply == config.STTGateReplyEarcon {
+		gate.Reply = agent.GateReplyEarcon
+	}
+	return []agent.LoaderOption{agent.WithTranscriptGate(gate)}
+}
+
 // ─── Accessors ───────────────────────────────────────────────────────────────
 
 // SessionStore returns the session transcript store. May be nil if memory
@@ -355,12 +901,41 @@ func (a *App) SessionStore() memory.SessionStore { return a.sessions }
 // configured.
 func (a *App) KnowledgeGraph() memory.KnowledgeGraph { return a.graph }
 
+// NPCStates returns the store NPC working memory is persisted in. May be nil
+// if memory is not configured.
+func (a *App) NPCStates() agent.StateStore { return a.npcStates }
+
+// IDGenerator returns the generator the app creates IDs with.
+func (a *App) IDGenerator() idgen.Generator { return a.ids }
+
 // MCPHost returns the MCP host. May be nil if no MCP servers are configured.
 func (a *App) MCPHost() mcp.Host { return a.mcpHost }
 
 // EntityStore returns the entity store.
 func (a *App) EntityStore() entity.Store { return a.entities }
 
+// TranscriptHub returns the hub that broadcasts the merged table transcript
+// to external subscribers.
+func (a *App) TranscriptHub() *TranscriptHub { return a.hub }
+
+// TranscriptMerger returns the merger that orders the lines of every NPC and
+// player before they are broadcast on the [App.TranscriptHub]. Pass it to
+// [SessionManagerConfig].
+func (a *App) TranscriptMerger() *TranscriptMerger { return a.merger }
+
+// TurnBus returns the bus on which a [TurnCompleted] event is published after
+// every NPC turn. Integrations register callbacks with [TurnBus.Subscribe].
+func (a *App) TurnBus() *TurnBus { return a.turns }
+
+// Engines returns the limiter that counts the open NPC engines. Pass it to
+// [SessionManagerConfig] and report [limit.Limiter.Stats] on /healthz.
+func (a *App) Engines() *limit.Limiter { return a.engines }
+
+// Vocabulary returns the campaign vocabulary used as the spelling reference
+// in NPC prompts. Wrap an STT provider with [vocab.Vocabulary.WrapSTT] to
+// send it as keyword hints too.
+func (a *App) Vocabulary() *vocab.Vocabulary { return a.vocab }
+
 // ─── Run ─────────────────────────────────────────────────────────────────────
 
 // Run starts the main processing loop and blocks until ctx is cancelled.
@@ -376,6 +951,11 @@ func (a *App) Run(ctx context.Context) error {
 		})
 	}
 
+	// ── Prune old transcript entries ─────────────────────────────────────
+	if a.pruner != nil {
+		a.pruner.Start(ctx)
+	}
+
 	slog.Info("app running", "npcs", len(a.agents))
 	<-ctx.Done()
 
@@ -383,8 +963,8 @@ func (a *App) Run(ctx context.Context) error {
 	return ctx.Err()
 }
 
-// recordTranscripts drains the engine's transcript channel and writes entries
-// to the session store.
+// recordTranscripts drains the engine's transcript channel, writes entries
+// to the session store and adds them to the merged transcript.
 func (a *App) recordTranscripts(ctx context.Context, ag agent.NPCAgent) {
 	ch := ag.Engine().Transcripts()
 	sid := a.sessionID()
@@ -399,10 +979,19 @@ func (a *App) recordTranscripts(ctx context.Context, ag agent.NPCAgent) {
 			if err := a.sessions.WriteEntry(ctx, sid, entry); err != nil {
 				slog.Warn("failed to record transcript", "npc", ag.Name(), "err", err)
 			}
+			a.merger.Add(sid, SourceNPC, entry)
 		}
 	}
 }
 
+// publishTranscripts broadcasts the merged transcript on the transcript hub
+// until the merger is closed.
+func (a *App) publishTranscripts() {
+	for e := range a.merger.Entries() {
+		a.hub.Publish(TranscriptEvent{SessionID: e.SessionID, Source: e.Source, Entry: e.Entry})
+	}
+}
+
 // ─── Shutdown ────────────────────────────────────────────────────────────────
 
 // Shutdown tears down all subsystems in reverse-init order. It respects the
@@ -453,12 +1042,89 @@ func configBudgetTier(tier config.BudgetTier) mcp.BudgetTier {
 	}
 }
 
+// npcAliases returns the configured aliases of npc together with those stored
+// in the [memory.AttrAliases] attribute of the knowledge-graph entity named
+// like the NPC. Graph lookup failures are logged and leave only the
+// configured aliases.
+func npcAliases(ctx context.Context, graph memory.KnowledgeGraph, npc config.NPCConfig) []string {
+	aliases := slices.Clone(npc.Aliases)
+	if graph == nil {
+		return aliases
+	}
+	entities, err := graph.FindEntities(ctx, memory.EntityFilter{Name: npc.Name})
+	if err != nil {
+		slog.Warn("look up NPC aliases in knowledge graph", "npc", npc.Name, "err", err)
+		return aliases
+	}
+	for _, e := range entities {
+		if !strings.EqualFold(e.Name, npc.Name) {
+			continue
+		}
+		switch v := e.Attributes[memory.AttrAliases].(type) {
+		case string:
+			for alias := range strings.SplitSeq(v, ",") {
+				aliases = append(aliases, strings.TrimSpace(alias))
+			}
+		case []string:
+			aliases = append(aliases, v...)
+		case []any:
+			for _, alias := range v {
+				if s, ok := alias.(string); ok {
+					aliases = append(aliases, s)
+				}
+			}
+		}
+	}
+	return slices.DeleteFunc(aliases, func(s string) bool { return strings.TrimSpace(s) == "" })
+}
+
 // configVoiceProfile converts a config.VoiceConfig to tts.VoiceProfile.
+// Voice settings overrides are passed on as [tts.VoiceProfile.Metadata].
 func configVoiceProfile(vc config.VoiceConfig) tts.VoiceProfile {
-	return tts.VoiceProfile{
+	vp := tts.VoiceProfile{
 		ID:          vc.VoiceID,
 		Provider:    vc.Provider,
 		PitchShift:  vc.PitchShift,
 		SpeedFactor: vc.SpeedFactor,
+		Language:    vc.Language,
+	}
+	setMeta := func(key, value string) {
+		if vp.Metadata == nil {
+			vp.Metadata = make(map[string]string)
+		}
+		vp.Metadata[key] = value
+	}
+	for key, v := range map[string]*float64{
+		tts.MetaStability:       vc.Stability,
+		tts.MetaSimilarityBoost: vc.SimilarityBoost,
+		tts.MetaStyle:           vc.Style,
+	} {
+		if v != nil {
+			setMeta(key, strconv.FormatFloat(*v, 'f', -1, 64))
+		}
+	}
+	if vc.UseSpeakerBoost != nil {
+		setMeta(tts.MetaUseSpeakerBoost, strconv.FormatBool(*vc.UseSpeakerBoost))
+	}
+	if vc.SeedPhrase != "" {
+		setMeta(tts.MetaSeedPhrase, vc.SeedPhrase)
+	}
+	return vp
+}
+
+// configStockResponses converts the stock responses of an NPC's config to
+// the agent's form.
+func configStockResponses(cfg map[string][]config.StockLineConfig) map[agent.Intent][]agent.StockLine {
+	if len(cfg) == 0 {
+		return nil
+	}
+	out := make(map[agent.Intent][]agent.StockLine, len(cfg))
+	for intent, lines := range cfg {
+		stock := make([]agent.StockLine, len(lines))
+		for i, l := range lines {
+			stock[i] = agent.StockLine{Text: l.Text, Weight: l.Weight}
+		}
+		out[agent.Intent(intent)] = stock
 	}
+	return out
 }
@@ -0,0 +1,470 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/config"
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
+	"github.com/MrWong99/glyphoxa/internal/engine/idle"
+	"github.com/MrWong99/glyphoxa/internal/engine/limit"
+	"github.com/MrWong99/glyphoxa/internal/mcp"
+	mcpmock "github.com/MrWong99/glyphoxa/internal/mcp/mock"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	audiomock "github.com/MrWong99/glyphoxa/pkg/audio/mock"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	memorymock "github.com/MrWong99/glyphoxa/pkg/memory/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
+	embeddingsmock "github.com/MrWong99/glyphoxa/pkg/provider/embeddings/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+	s2smock "github.com/MrWong99/glyphoxa/pkg/provider/s2s/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	ttsmock "github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+)
+
+func TestEmbeddingDimensions(t *testing.T) {
+	t.Parallel()
+
+	model := func(dims int) embeddings.Provider {
+		return &embeddingsmock.Provider{DimensionsValue: dims, ModelIDValue: "test-embed"}
+	}
+
+	tests := []struct {
+		name       string
+		configured int
+		provider   embeddings.Provider
+		want       int
+		wantErr    bool
+	}{
+		{name: "default without provider", want: 1536},
+		{name: "configured without provider", configured: 768, want: 768},
+		{name: "reported by provider", provider: model(1024), want: 1024},
+		{name: "configured matches provider", configured: 1024, provider: model(1024), want: 1024},
+		{name: "configured differs from provider", configured: 1536, provider: model(768), wantErr: true},
+		{name: "provider cannot tell", configured: 512, provider: model(0), want: 512},
+		{name: "nobody knows", provider: model(0), want: 1536},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := EmbeddingDimensions(tc.configured, tc.provider)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("EmbeddingDimensions = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNPCAliases(t *testing.T) {
+	t.Parallel()
+
+	npc := config.NPCConfig{Name: "Brannock", Aliases: []string{"the captain"}}
+
+	tests := []struct {
+		name  string
+		graph memory.KnowledgeGraph
+		want  []string
+	}{
+		{name: "no graph", want: []string{"the captain"}},
+		{
+			name: "list attribute",
+			graph: &memorymock.KnowledgeGraph{FindEntitiesResult: []memory.Entity{
+				{Name: "Brannock", Attributes: map[string]any{memory.AttrAliases: []any{"Old Iron", ""}}},
+			}},
+			want: []string{"the captain", "Old Iron"},
+		},
+		{
+			name: "comma-separated attribute",
+			graph: &memorymock.KnowledgeGraph{FindEntitiesResult: []memory.Entity{
+				{Name: "brannock", Attributes: map[string]any{memory.AttrAliases: "Old Iron, Bran"}},
+			}},
+			want: []string{"the captain", "Old Iron", "Bran"},
+		},
+		{
+			name: "other entity ignored",
+			graph: &memorymock.KnowledgeGraph{FindEntitiesResult: []memory.Entity{
+				{Name: "Brannock's Gate", Attributes: map[string]any{memory.AttrAliases: "the gate"}},
+			}},
+			want: []string{"the captain"},
+		},
+		{
+			name:  "graph error",
+			graph: &memorymock.KnowledgeGraph{FindEntitiesErr: errors.New("db down")},
+			want:  []string{"the captain"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := npcAliases(context.Background(), tc.graph, npc)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("npcAliases = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConfigVoiceProfile_VoiceSettings(t *testing.T) {
+	t.Parallel()
+
+	stability, style := 0.3, 1.0
+	speakerBoost := false
+	vp := configVoiceProfile(config.VoiceConfig{
+		VoiceID:         "voice-1",
+		Stability:       &stability,
+		Style:           &style,
+		UseSpeakerBoost: &speakerBoost,
+		SeedPhrase:      "Well met.",
+	})
+	want := map[string]string{
+		tts.MetaStability:       "0.3",
+		tts.MetaStyle:           "1",
+		tts.MetaUseSpeakerBoost: "false",
+		tts.MetaSeedPhrase:      "Well met.",
+	}
+	if !maps.Equal(vp.Metadata, want) {
+		t.Errorf("Metadata = %v, want %v", vp.Metadata, want)
+	}
+
+	if vp := configVoiceProfile(config.VoiceConfig{VoiceID: "voice-1"}); vp.Metadata != nil {
+		t.Errorf("Metadata without overrides = %v, want nil", vp.Metadata)
+	}
+}
+
+func TestBuildEngine_IdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	providers := &Providers{LLM: &llmmock.Provider{}, TTS: &ttsmock.Provider{}}
+	tests := []struct {
+		minutes  int
+		wantIdle bool
+	}{
+		{minutes: 0},
+		{minutes: 10, wantIdle: true},
+	}
+	for _, tc := range tests {
+		npc := config.NPCConfig{Name: "Grimjaw", Engine: config.EngineCascaded, IdleTimeoutMinutes: tc.minutes}
+		eng, err := buildEngine(providers, npc, limit.New(0))
+		if err != nil {
+			t.Fatalf("idle_timeout_minutes %d: buildEngine: %v", tc.minutes, err)
+		}
+		if _, ok := eng.(*idle.Engine); ok != tc.wantIdle {
+			t.Errorf("idle_timeout_minutes %d: engine is %T, want idle wrapper %v", tc.minutes, eng, tc.wantIdle)
+		}
+		_ = eng.Close()
+	}
+}
+
+func TestBuildNPCEngine_TTSFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		engine     config.Engine
+		sampleRate int
+		want       int
+	}{
+		{name: "cascaded at 16 kHz", engine: config.EngineCascaded, sampleRate: 16000, want: 16000},
+		{name: "sentence cascade at 16 kHz", engine: config.EngineSentenceCascade, sampleRate: 16000, want: 16000},
+		{name: "unreported rate", engine: config.EngineCascaded, want: cascade.DefaultTTSSampleRate},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			providers := &Providers{
+				LLM: &llmmock.Provider{StreamChunks: []llm.Chunk{{Text: "Aye.", FinishReason: "stop"}}},
+				TTS: &ttsmock.Provider{SampleRateResult: tc.sampleRate, SynthesizeChunks: [][]byte{{0, 0}}},
+			}
+			eng, err := buildNPCEngine(providers, config.NPCConfig{Name: "Grimjaw", Engine: tc.engine})
+			if err != nil {
+				t.Fatalf("buildNPCEngine: %v", err)
+			}
+			t.Cleanup(func() { _ = eng.Close() })
+
+			resp, err := eng.Process(t.Context(), audio.AudioFrame{}, engine.PromptContext{
+				Messages: []llm.Message{{Role: "user", Content: "Well met."}},
+			})
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			audio.Drain(resp.Audio)
+			if resp.SampleRate != tc.want || resp.Channels != 1 {
+				t.Errorf("reply format = %d Hz × %d, want %d Hz mono", resp.SampleRate, resp.Channels, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildNPCEngine_Selection(t *testing.T) {
+	t.Parallel()
+
+	providers := &Providers{LLM: &llmmock.Provider{}, TTS: &ttsmock.Provider{}}
+	tests := []struct {
+		engine config.Engine
+		want   string
+	}{
+		{engine: config.EngineCascaded, want: "*cascade.Engine"},
+		{engine: config.EngineSentenceCascade, want: "*sentencecascade.Engine"},
+	}
+	for _, tc := range tests {
+		eng, err := buildNPCEngine(providers, config.NPCConfig{Name: "Grimjaw", Engine: tc.engine})
+		if err != nil {
+			t.Fatalf("engine %q: buildNPCEngine: %v", tc.engine, err)
+		}
+		if got := fmt.Sprintf("%T", eng); got != tc.want {
+			t.Errorf("engine %q built %s, want %s", tc.engine, got, tc.want)
+		}
+		_ = eng.Close()
+	}
+
+	if _, err := buildNPCEngine(&Providers{LLM: &llmmock.Provider{}}, config.NPCConfig{Name: "Grimjaw", Engine: config.EngineSentenceCascade}); err == nil {
+		t.Error("sentence_cascade without a TTS provider: want an error")
+	}
+}
+
+func TestBuildNPCEngine_S2SFallbackVoice(t *testing.T) {
+	t.Parallel()
+
+	ttsP := &ttsmock.Provider{SynthesizeChunks: [][]byte{{0, 0}}}
+	providers := &Providers{
+		LLM: &llmmock.Provider{StreamChunks: []llm.Chunk{{Text: "Aye.", FinishReason: "stop"}}},
+		TTS: ttsP,
+		S2S: &s2smock.Provider{ConnectErr: errors.New("realtime API unreachable")},
+	}
+	eng, err := buildNPCEngine(providers, config.NPCConfig{
+		Name:   "Grimjaw",
+		Engine: config.EngineS2S,
+		Voice:  config.VoiceConfig{VoiceID: "alloy", Language: "de-DE"},
+		S2SFallback: &config.S2SFallbackConfig{
+			Voice: config.VoiceConfig{VoiceID: "pNInz6obpgDQGcFmaJgB"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("buildNPCEngine: %v", err)
+	}
+	t.Cleanup(func() { _ = eng.Close() })
+
+	resp, err := eng.Process(t.Context(), audio.AudioFrame{}, engine.PromptContext{
+		Messages: []llm.Message{{Role: "user", Content: "Well met."}},
+	})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	audio.Drain(resp.Audio)
+
+	if len(ttsP.SynthesizeStreamCalls) == 0 {
+		t.Fatal("the fallback cascade did not synthesise the reply")
+	}
+	voice := ttsP.SynthesizeStreamCalls[0].Voice
+	if voice.ID != "pNInz6obpgDQGcFmaJgB" || voice.Language != "de-DE" {
+		t.Errorf("fallback voice = %q (%s), want the s2s_fallback voice in the NPC's language", voice.ID, voice.Language)
+	}
+}
+
+func TestBuildEngine_Limit(t *testing.T) {
+	t.Parallel()
+
+	providers := &Providers{LLM: &llmmock.Provider{}, TTS: &ttsmock.Provider{}}
+	engines := limit.New(1)
+	npc := config.NPCConfig{Name: "Grimjaw", Engine: config.EngineCascaded}
+
+	first, err := buildEngine(providers, npc, engines)
+	if err != nil {
+		t.Fatalf("buildEngine: %v", err)
+	}
+	if got := engines.Stats().ByKind[string(config.EngineCascaded)]; got != 1 {
+		t.Errorf("open cascaded engines = %d, want 1", got)
+	}
+	if _, err := buildEngine(providers, npc, engines); !errors.Is(err, limit.ErrLimitReached) {
+		t.Fatalf("second buildEngine error = %v, want %v", err, limit.ErrLimitReached)
+	}
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	second, err := buildEngine(providers, npc, engines)
+	if err != nil {
+		t.Fatalf("buildEngine after close: %v", err)
+	}
+	_ = second.Close()
+	if got := engines.Stats().Active; got != 0 {
+		t.Errorf("open engines after close = %d, want 0", got)
+	}
+}
+
+func TestApp_TurnCompleted(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		NPCs: []config.NPCConfig{{
+			Name:       "Grimjaw",
+			Engine:     config.EngineCascaded,
+			BudgetTier: config.BudgetTierFast,
+		}},
+		Campaign: config.CampaignConfig{Name: "test-campaign"},
+	}
+	providers := &Providers{
+		LLM: &llmmock.Provider{StreamChunks: []llm.Chunk{{Text: "Aye, what'll it be?", FinishReason: "stop"}}},
+		TTS: &ttsmock.Provider{SynthesizeChunks: [][]byte{make([]byte, 320)}},
+	}
+	mixer := &audiomock.Mixer{}
+
+	a, err := New(context.Background(), cfg, providers,
+		WithSessionStore(&memorymock.SessionStore{}),
+		WithKnowledgeGraph(&memorymock.KnowledgeGraph{}),
+		WithMCPHost(&mcpmock.Host{}),
+		WithMixer(mixer),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = a.Shutdown(context.Background()) })
+
+	events := make(chan TurnCompleted, 1)
+	a.TurnBus().Subscribe(func(ev TurnCompleted) { events <- ev })
+
+	err = a.agents[0].HandleUtterance(context.Background(), "player-1", stt.Transcript{Text: "An ale, please.", IsFinal: true})
+	if err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+
+	// The turn completes once the mixer has played the reply.
+	if len(mixer.EnqueueCalls) != 1 {
+		t.Fatalf("want 1 enqueued segment, got %d", len(mixer.EnqueueCalls))
+	}
+	audio.Drain(mixer.EnqueueCalls[0].Segment.Audio)
+
+	var ev TurnCompleted
+	select {
+	case ev = <-events:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for TurnCompleted")
+	}
+
+	if ev.SessionID != "session-test-campaign" {
+		t.Errorf("SessionID = %q, want %q", ev.SessionID, "session-test-campaign")
+	}
+	if ev.NPCName != "Grimjaw" || ev.NPCID != a.agents[0].ID() {
+		t.Errorf("NPC = (%q, %q), want (%q, %q)", ev.NPCID, ev.NPCName, a.agents[0].ID(), "Grimjaw")
+	}
+	if ev.Speaker != "player-1" || ev.Input != "An ale, please." {
+		t.Errorf("Speaker, Input = %q, %q", ev.Speaker, ev.Input)
+	}
+	if ev.Text != "Aye, what'll it be?" {
+		t.Errorf("Text = %q, want %q", ev.Text, "Aye, what'll it be?")
+	}
+	if ev.Duration <= 0 {
+		t.Errorf("Duration = %v, want > 0", ev.Duration)
+	}
+	if ev.Cost.BudgetTier != mcp.BudgetFast || ev.Cost.StrongModel {
+		t.Errorf("Cost = %+v, want fast tier without strong model", ev.Cost)
+	}
+	if len(ev.ToolCalls) != 0 || ev.Err != nil {
+		t.Errorf("ToolCalls, Err = %v, %v; want none", ev.ToolCalls, ev.Err)
+	}
+}
+
+func TestApp_TranscriptHub_MergesTable(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		NPCs: []config.NPCConfig{{
+			Name:       "Grimjaw",
+			Engine:     config.EngineCascaded,
+			BudgetTier: config.BudgetTierFast,
+		}},
+		Campaign: config.CampaignConfig{Name: "test-campaign"},
+	}
+	providers := &Providers{
+		LLM: &llmmock.Provider{StreamChunks: []llm.Chunk{{Text: "Aye, what'll it be?", FinishReason: "stop"}}},
+		TTS: &ttsmock.Provider{SynthesizeChunks: [][]byte{make([]byte, 320)}},
+	}
+	mixer := &audiomock.Mixer{}
+
+	a, err := New(context.Background(), cfg, providers,
+		WithSessionStore(&memorymock.SessionStore{}),
+		WithKnowledgeGraph(&memorymock.KnowledgeGraph{}),
+		WithMCPHost(&mcpmock.Host{}),
+		WithMixer(mixer),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	sub := a.TranscriptHub().Subscribe("")
+	defer sub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		_ = a.Run(ctx)
+	}()
+
+	// The player's line is reported after the NPC has already answered, as
+	// STT finalises late; the merger still puts it first.
+	spoken := time.Now()
+	err = a.agents[0].HandleUtterance(ctx, "player-1", stt.Transcript{Text: "An ale, please.", IsFinal: true})
+	if err != nil {
+		t.Fatalf("HandleUtterance: %v", err)
+	}
+	if len(mixer.EnqueueCalls) != 1 {
+		t.Fatalf("want 1 enqueued segment, got %d", len(mixer.EnqueueCalls))
+	}
+	audio.Drain(mixer.EnqueueCalls[0].Segment.Audio)
+	a.TranscriptMerger().Add("session-test-campaign", SourcePlayer, memory.TranscriptEntry{
+		SpeakerID: "player-1",
+		Text:      "An ale, please.",
+		Timestamp: spoken,
+	})
+
+	var got []TranscriptEvent
+	for len(got) < 2 {
+		select {
+		case ev := <-sub.Events():
+			got = append(got, ev)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("received %d transcript events, want 2", len(got))
+		}
+	}
+	cancel()
+	<-runDone
+	_ = a.Shutdown(context.Background())
+
+	want := []struct {
+		source TranscriptSource
+		text   string
+	}{
+		{SourcePlayer, "An ale, please."},
+		{SourceNPC, "Aye, what'll it be?"},
+	}
+	for i, w := range want {
+		if got[i].SessionID != "session-test-campaign" {
+			t.Errorf("event %d SessionID = %q, want %q", i, got[i].SessionID, "session-test-campaign")
+		}
+		if got[i].Source != w.source || got[i].Entry.Text != w.text {
+			t.Errorf("event %d = (%s, %q), want (%s, %q)", i, got[i].Source, got[i].Entry.Text, w.source, w.text)
+		}
+	}
+}
@@ -2,16 +2,23 @@ package app_test
 
 import (
 	"context"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/MrWong99/glyphoxa/internal/app"
 	"github.com/MrWong99/glyphoxa/internal/config"
+	"github.com/MrWong99/glyphoxa/internal/mcp"
 	mcpmock "github.com/MrWong99/glyphoxa/internal/mcp/mock"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
 	audiomock "github.com/MrWong99/glyphoxa/pkg/audio/mock"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
 	memorymock "github.com/MrWong99/glyphoxa/pkg/memory/mock"
 	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
 	ttsmock "github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+	vadmock "github.com/MrWong99/glyphoxa/pkg/provider/vad/mock"
 )
 
 // testConfig returns a minimal config with one cascaded NPC for tests.
@@ -79,6 +86,86 @@ func TestNew_WithMocks(t *testing.T) {
 	}
 }
 
+func TestNew_TranscriptFilter(t *testing.T) {
+	t.Parallel()
+
+	cfg := testConfig()
+	cfg.Memory.TranscriptFilter = config.TranscriptFilterConfig{MinWords: 2, SkipFillers: true}
+	sessions := &memorymock.SessionStore{}
+
+	application, err := app.New(
+		context.Background(),
+		cfg,
+		testProviders(),
+		app.WithSessionStore(sessions),
+		app.WithKnowledgeGraph(&memorymock.KnowledgeGraph{}),
+		app.WithMCPHost(&mcpmock.Host{}),
+		app.WithMixer(&audiomock.Mixer{}),
+	)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+
+	store := application.SessionStore()
+	for _, text := range []string{"Um, yeah.", "Tell me about the mine."} {
+		if err := store.WriteEntry(context.Background(), "s1", memory.TranscriptEntry{SpeakerID: "p1", Text: text}); err != nil {
+			t.Fatalf("WriteEntry(%q): %v", text, err)
+		}
+	}
+	if got := sessions.CallCount("WriteEntry"); got != 1 {
+		t.Errorf("WriteEntry calls = %d, want 1 (backchannel filtered)", got)
+	}
+}
+
+func TestRun_L1Retention(t *testing.T) {
+	t.Parallel()
+
+	cfg := testConfig()
+	cfg.Server.L1Retention = 24 * time.Hour
+	sessions := &memorymock.SessionStore{}
+
+	application, err := app.New(
+		context.Background(),
+		cfg,
+		testProviders(),
+		app.WithSessionStore(sessions),
+		app.WithKnowledgeGraph(&memorymock.KnowledgeGraph{}),
+		app.WithMCPHost(&mcpmock.Host{}),
+		app.WithMixer(&audiomock.Mixer{}),
+	)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	if got := sessions.CallCount("PruneTranscripts"); got != 0 {
+		t.Errorf("PruneTranscripts calls before Run = %d, want 0", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- application.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sessions.CallCount("PruneTranscripts") == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-errCh
+
+	calls := sessions.Calls()
+	i := slices.IndexFunc(calls, func(c memorymock.Call) bool { return c.Method == "PruneTranscripts" })
+	if i < 0 {
+		t.Fatal("Run did not prune transcripts")
+	}
+	if cutoff := calls[i].Args[0].(time.Time); time.Since(cutoff) < 24*time.Hour {
+		t.Errorf("cutoff = %v, want at least 24h ago", cutoff)
+	}
+	if err := application.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error: %v", err)
+	}
+}
+
 func TestNew_NoNPCs(t *testing.T) {
 	t.Parallel()
 
@@ -198,3 +285,265 @@ func TestApp_RunAndShutdown(t *testing.T) {
 		t.Fatalf("Shutdown() error: %v", err)
 	}
 }
+
+// warmingLLM is an LLM provider whose Warmup blocks until release is closed.
+type warmingLLM struct {
+	*llmmock.Provider
+	release chan struct{}
+}
+
+func (w *warmingLLM) Warmup(ctx context.Context) error {
+	select {
+	case <-w.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestApp_Ready(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	providers := testProviders()
+	providers.LLM = &warmingLLM{Provider: &llmmock.Provider{}, release: release}
+
+	application, err := app.New(
+		context.Background(),
+		testConfig(),
+		providers,
+		app.WithSessionStore(&memorymock.SessionStore{}),
+		app.WithKnowledgeGraph(&memorymock.KnowledgeGraph{}),
+		app.WithMCPHost(&mcpmock.Host{}),
+		app.WithMixer(&audiomock.Mixer{}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	select {
+	case <-application.Ready():
+		t.Fatal("Ready() closed before the provider finished warming up")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-application.Ready():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Ready() not closed after warm-up completed")
+	}
+}
+
+func TestApp_Ready_NoWarmers(t *testing.T) {
+	t.Parallel()
+
+	application, err := app.New(
+		context.Background(),
+		testConfig(),
+		testProviders(),
+		app.WithSessionStore(&memorymock.SessionStore{}),
+		app.WithKnowledgeGraph(&memorymock.KnowledgeGraph{}),
+		app.WithMCPHost(&mcpmock.Host{}),
+		app.WithMixer(&audiomock.Mixer{}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	select {
+	case <-application.Ready():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Ready() not closed without any warmers")
+	}
+}
+
+// notifyingVAD is a VAD engine that reports every session it creates on
+// sessions.
+type notifyingVAD struct{ sessions chan struct{} }
+
+func (e notifyingVAD) NewSession(vad.Config) (vad.SessionHandle, error) {
+	select {
+	case e.sessions <- struct{}{}:
+	default:
+	}
+	return &vadmock.Session{}, nil
+}
+
+func TestApp_Ready_HoldsInputUntilWarm(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	providers := testProviders()
+	providers.LLM = &warmingLLM{Provider: &llmmock.Provider{}, release: release}
+	sessions := make(chan struct{}, 1)
+	providers.VAD = notifyingVAD{sessions: sessions}
+
+	application, err := app.New(
+		context.Background(),
+		testConfig(),
+		providers,
+		app.WithSessionStore(&memorymock.SessionStore{}),
+		app.WithKnowledgeGraph(&memorymock.KnowledgeGraph{}),
+		app.WithMCPHost(&mcpmock.Host{}),
+		app.WithMixer(&audiomock.Mixer{}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	src := make(chan audio.AudioFrame)
+	sm := app.NewSessionManager(app.SessionManagerConfig{
+		Platform: &audiomock.Platform{ConnectResult: &audiomock.Connection{
+			InputStreamsResult: map[string]<-chan audio.AudioFrame{"player-1": src},
+		}},
+		Config:       &config.Config{},
+		Providers:    providers,
+		SessionStore: &memorymock.SessionStore{},
+		Ready:        application.Ready(),
+	})
+	ctx := context.Background()
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = sm.Stop(ctx) }()
+
+	// Speech while the LLM is still warming up never reaches the VAD.
+	frame := audio.AudioFrame{Data: make([]byte, 640), SampleRate: 16000, Channels: 1}
+	for range 3 {
+		src <- frame
+	}
+	select {
+	case <-sessions:
+		t.Fatal("input audio reached the VAD before warm-up finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-application.Ready()
+
+	// The gate drops frames the pipeline is not ready to take, so keep
+	// talking until one gets through.
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case src <- frame:
+		case <-sessions:
+			return
+		case <-timeout:
+			t.Fatal("input audio did not reach the VAD after warm-up finished")
+		}
+	}
+}
+
+func TestApp_RenderPrompt(t *testing.T) {
+	t.Parallel()
+
+	cfg := testConfig()
+	cfg.NPCs[0].AwarenessRadius = 1
+	cfg.NPCs[0].BehaviorRules = []string{"Never reveal the secret tunnel.", "Stay in character."}
+
+	graph := &memorymock.GraphRAGQuerier{
+		QueryWithContextResult: []memory.ContextResult{
+			{Entity: memory.Entity{ID: "mine", Name: "Deepdelve Mine"}, Content: "The mine collapsed last winter.", Score: 0.8},
+		},
+	}
+	graph.NeighborsResult = []memory.Entity{{ID: "mine", Name: "Deepdelve Mine"}}
+	graph.IdentitySnapshotResult = &memory.NPCIdentity{
+		Entity: memory.Entity{ID: "grimjaw", Type: "npc", Name: "Grimjaw", Attributes: map[string]any{
+			memory.AttrOccupation: "bartender",
+		}},
+	}
+
+	application, err := app.New(
+		context.Background(),
+		cfg,
+		testProviders(),
+		app.WithSessionStore(&memorymock.SessionStore{}),
+		app.WithKnowledgeGraph(graph),
+		app.WithMCPHost(&mcpmock.Host{}),
+		app.WithMixer(&audiomock.Mixer{}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	prompt, dump, err := application.RenderPrompt(context.Background(), "grimjaw", "What happened to the mine?", nil)
+	if err != nil {
+		t.Fatalf("RenderPrompt() error: %v", err)
+	}
+	for _, want := range []string{
+		"You are Grimjaw. A gruff dwarven bartender.",
+		"occupation: bartender",
+		"Deepdelve Mine: The mine collapsed last winter.",
+		"## Rules",
+		"1. Never reveal the secret tunnel.",
+		"2. Stay in character.",
+	} {
+		if !strings.Contains(prompt.SystemPrompt, want) {
+			t.Errorf("system prompt missing %q:\n%s", want, prompt.SystemPrompt)
+		}
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump missing %q:\n%s", want, dump)
+		}
+	}
+	if !strings.Contains(dump, "[user] What happened to the mine?") {
+		t.Errorf("dump missing the input message:\n%s", dump)
+	}
+
+	if _, _, err := application.RenderPrompt(context.Background(), "Nobody", "Hello?", nil); err == nil {
+		t.Error("RenderPrompt() for unknown NPC returned nil error")
+	}
+}
+
+func TestApp_ReloadMCP(t *testing.T) {
+	t.Parallel()
+
+	keep := config.MCPServerConfig{Name: "dice", Transport: "stdio", Command: "dice-server"}
+	change := config.MCPServerConfig{Name: "lore", Transport: "stdio", Command: "lore-server"}
+	remove := config.MCPServerConfig{Name: "weather", Transport: "stdio", Command: "weather-server"}
+	add := config.MCPServerConfig{Name: "maps", Transport: "streamable-http", URL: "https://maps.example.com/mcp"}
+
+	cfg := testConfig()
+	cfg.MCP.Servers = []config.MCPServerConfig{keep, change, remove}
+	mcpHost := &mcpmock.Host{}
+
+	application, err := app.New(
+		context.Background(),
+		cfg,
+		testProviders(),
+		app.WithSessionStore(&memorymock.SessionStore{}),
+		app.WithKnowledgeGraph(&memorymock.KnowledgeGraph{}),
+		app.WithMCPHost(mcpHost),
+		app.WithMixer(&audiomock.Mixer{}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	mcpHost.Reset()
+
+	changed := change
+	changed.Command = "lore-server --v2"
+	if err := application.ReloadMCP(context.Background(), cfg.MCP.Servers, []config.MCPServerConfig{keep, changed, add}); err != nil {
+		t.Fatalf("ReloadMCP() error: %v", err)
+	}
+
+	var unregistered, registered []string
+	for _, c := range mcpHost.Calls() {
+		switch c.Method {
+		case "UnregisterServer":
+			unregistered = append(unregistered, c.Args[0].(string))
+		case "RegisterServer":
+			registered = append(registered, c.Args[0].(mcp.ServerConfig).Name)
+		}
+	}
+	if want := []string{"lore", "weather"}; !slices.Equal(unregistered, want) {
+		t.Errorf("unregistered = %v, want %v", unregistered, want)
+	}
+	if want := []string{"lore", "maps"}; !slices.Equal(registered, want) {
+		t.Errorf("registered = %v, want %v", registered, want)
+	}
+	if got := mcpHost.CallCount("Calibrate"); got != 1 {
+		t.Errorf("Calibrate call count = %d, want 1", got)
+	}
+}
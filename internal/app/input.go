@@ -0,0 +1,280 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/agent/orchestrator"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/audio/bargein"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+)
+
+// maxUtteranceDuration bounds the audio collected for a single utterance. A
+// speaker who talks for longer is transcribed in parts.
+const maxUtteranceDuration = 30 * time.Second
+
+// inputPipeline turns the players' input audio into NPC turns. Each
+// participant's stream is converted to the VAD format and run through the
+// session's VAD segmenter, whose per-frame results drive the barge-in
+// detector. The speech between a VAD speech start and end is attributed to a
+// speaker, transcribed and dispatched to the orchestrator as one utterance.
+//
+// inputPipeline is safe for concurrent use.
+type inputPipeline struct {
+	cfg inputConfig
+
+	mu        sync.Mutex
+	listening map[string]<-chan audio.AudioFrame // streams being consumed by ID
+	err       error                              // first frame error, returned by wait
+	wg        sync.WaitGroup
+}
+
+// inputConfig holds the stages of an [inputPipeline].
+type inputConfig struct {
+	// Segmenter detects speech per speaker. Required.
+	Segmenter *vad.Segmenter
+
+	// BargeIn receives the VAD result of every frame. Required.
+	BargeIn *bargein.Detector
+
+	// STT transcribes utterances. If nil, speech only drives barge-in.
+	STT stt.Provider
+
+	// Attribute returns the speaker of an utterance received on the stream
+	// sourceID. If nil, the utterance is attributed to its stream.
+	Attribute func(ctx context.Context, sourceID string, segment audio.AudioFrame) string
+
+	// Forget, if set, is called with the ID of every stream once it is no
+	// longer consumed.
+	Forget func(sourceID string)
+
+	// Record, if set, receives every transcribed utterance as a player
+	// transcript entry before it is dispatched.
+	Record func(entry memory.TranscriptEntry)
+
+	// Dispatch hands a transcribed utterance to the NPCs. Required.
+	Dispatch func(ctx context.Context, speaker string, transcript stt.Transcript)
+}
+
+// newInputPipeline returns an inputPipeline with the stages in cfg.
+func newInputPipeline(cfg inputConfig) *inputPipeline {
+	return &inputPipeline{
+		cfg:       cfg,
+		listening: make(map[string]<-chan audio.AudioFrame),
+	}
+}
+
+// orchestratorDispatch returns an [inputConfig] Dispatch function
+// that hands utterances to orch. Utterances addressed to no NPC are dropped.
+func orchestratorDispatch(orch *orchestrator.Orchestrator) func(context.Context, string, stt.Transcript) {
+	return func(ctx context.Context, speaker string, transcript stt.Transcript) {
+		if _, err := orch.Dispatch(ctx, speaker, transcript); err != nil && !errors.Is(err, orchestrator.ErrNoTarget) {
+			slog.Warn("input: dispatch utterance", "speaker", speaker, "err", err)
+		}
+	}
+}
+
+// listen starts consuming every stream in streams that is not consumed yet,
+// until it closes or ctx is cancelled. It is called with the connection's
+// input streams when the session starts and again whenever a participant
+// joins.
+func (p *inputPipeline) listen(ctx context.Context, streams map[string]<-chan audio.AudioFrame) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for id, ch := range streams {
+		if p.listening[id] == ch {
+			continue
+		}
+		p.listening[id] = ch
+		p.wg.Go(func() { p.consume(ctx, id, ch) })
+	}
+}
+
+// wait blocks until all streams and utterances in progress have been
+// handled and returns the first error a stream's frames failed with. The
+// caller must cancel the context passed to listen first.
+func (p *inputPipeline) wait() error {
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// fail records err as the pipeline's error unless one is recorded already.
+func (p *inputPipeline) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// consume runs the frames of speaker id's stream ch through the VAD and
+// hands every complete utterance to transcribe. A frame the VAD fails on is
+// logged and skipped.
+func (p *inputPipeline) consume(ctx context.Context, id string, ch <-chan audio.AudioFrame) {
+	defer func() {
+		p.mu.Lock()
+		if p.listening[id] == ch {
+			delete(p.listening, id)
+		}
+		p.mu.Unlock()
+		p.cfg.Segmenter.Reset(id)
+		p.cfg.BargeIn.Reset(id)
+		if p.cfg.Forget != nil {
+			p.cfg.Forget(id)
+		}
+	}()
+
+	const frameDur = vadFrameSizeMs * time.Millisecond
+	frameBytes := vadSampleRate * vadFrameSizeMs / 1000 * 2
+	maxBytes := int(maxUtteranceDuration/frameDur) * frameBytes
+	conv := audio.FormatConverter{Target: audio.Format{SampleRate: vadSampleRate, Channels: 1}}
+
+	var (
+		pending   []byte    // converted audio not yet run through the VAD
+		utterance []byte    // speech of the current utterance; nil outside speech
+		started   time.Time // when the current utterance started
+	)
+	for {
+		var frame audio.AudioFrame
+		select {
+		case <-ctx.Done():
+			return
+		case f, ok := <-ch:
+			if !ok {
+				return
+			}
+			frame = f
+		}
+		pending = append(pending, conv.Convert(frame).Data...)
+		for len(pending) >= frameBytes {
+			chunk := pending[:frameBytes]
+			ev, err := p.cfg.Segmenter.ProcessFrame(id, chunk)
+			if err != nil {
+				slog.Warn("input: voice activity detection failed", "speaker", id, "err", err)
+				p.fail(fmt.Errorf("input: speaker %s: %w", id, err))
+				pending = append(pending[:0], pending[frameBytes:]...)
+				continue
+			}
+			p.cfg.BargeIn.Process(id, ev, frameDur)
+
+			if len(utterance) == 0 {
+				started = time.Now()
+			}
+			switch ev.Type {
+			case vad.VADSpeechStart, vad.VADSpeechContinue:
+				utterance = append(utterance, chunk...)
+			case vad.VADSpeechEnd:
+				utterance = append(utterance, chunk...)
+				p.utter(ctx, id, started, utterance)
+				utterance = nil
+			}
+			if len(utterance) >= maxBytes {
+				p.utter(ctx, id, started, utterance)
+				utterance = nil
+			}
+			pending = append(pending[:0], pending[frameBytes:]...)
+		}
+	}
+}
+
+// utter attributes, transcribes, records and dispatches the utterance pcm
+// that started on stream id at started, in the background.
+func (p *inputPipeline) utter(ctx context.Context, id string, started time.Time, pcm []byte) {
+	if p.cfg.STT == nil || len(pcm) == 0 {
+		return
+	}
+	p.wg.Go(func() {
+		speaker := id
+		if p.cfg.Attribute != nil {
+			speaker = p.cfg.Attribute(ctx, id, audio.AudioFrame{Data: pcm, SampleRate: vadSampleRate, Channels: 1})
+		}
+		transcript, err := p.transcribe(ctx, pcm)
+		if err != nil {
+			slog.Warn("input: transcribe utterance", "speaker", speaker, "err", err)
+			return
+		}
+		if transcript.Text == "" {
+			return
+		}
+		if p.cfg.Record != nil {
+			p.cfg.Record(playerEntry(speaker, started, pcm, transcript))
+		}
+		p.cfg.Dispatch(ctx, speaker, transcript)
+	})
+}
+
+// transcribe transcribes pcm, 16-bit mono PCM in the VAD format, in an STT
+// session of its own and returns the joined final transcripts.
+func (p *inputPipeline) transcribe(ctx context.Context, pcm []byte) (stt.Transcript, error) {
+	sess, err := p.cfg.STT.StartStream(ctx, stt.StreamConfig{SampleRate: vadSampleRate, Channels: 1})
+	if err != nil {
+		return stt.Transcript{}, fmt.Errorf("start stream: %w", err)
+	}
+	if err := sess.SendAudio(pcm); err != nil {
+		_ = sess.Close()
+		return stt.Transcript{}, fmt.Errorf("send audio: %w", err)
+	}
+	// Close flushes the pending audio; the finals channel closes after the
+	// last transcript.
+	if err := sess.Close(); err != nil {
+		return stt.Transcript{}, fmt.Errorf("close stream: %w", err)
+	}
+
+	var (
+		texts []string
+		out   stt.Transcript
+	)
+	finals := sess.Finals()
+	for {
+		select {
+		case <-ctx.Done():
+			return stt.Transcript{}, ctx.Err()
+		case t, ok := <-finals:
+			if !ok {
+				out.Text = strings.Join(texts, " ")
+				out.IsFinal = true
+				return out, nil
+			}
+			text := strings.TrimSpace(t.Text)
+			if text == "" {
+				continue
+			}
+			if len(texts) == 0 {
+				out = t
+				out.Words = nil
+			}
+			if out.Language == "" {
+				out.Language = t.Language
+			}
+			out.Words = append(out.Words, t.Words...)
+			texts = append(texts, text)
+		}
+	}
+}
+
+// playerEntry returns the transcript entry of speaker's utterance pcm, which
+// started at started and was transcribed as t.
+func playerEntry(speaker string, started time.Time, pcm []byte, t stt.Transcript) memory.TranscriptEntry {
+	entry := memory.TranscriptEntry{
+		SpeakerID: speaker,
+		Text:      t.Text,
+		RawText:   t.Text,
+		Timestamp: started,
+		Duration:  time.Duration(len(pcm)/2) * time.Second / vadSampleRate,
+		Language:  t.Language,
+	}
+	for _, w := range t.Words {
+		entry.Words = append(entry.Words, memory.WordTiming{Word: w.Word, Start: w.Start, End: w.End})
+	}
+	return entry
+}
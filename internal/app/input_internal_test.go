@@ -0,0 +1,285 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/audio/bargein"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	sttmock "github.com/MrWong99/glyphoxa/pkg/provider/stt/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+)
+
+// levelVAD is a vad.Engine whose sessions treat every frame starting with a
+// non-zero byte as speech.
+type levelVAD struct{}
+
+func (levelVAD) NewSession(vad.Config) (vad.SessionHandle, error) { return &levelSession{}, nil }
+
+type levelSession struct{ speaking bool }
+
+func (s *levelSession) ProcessFrame(frame []byte) (vad.VADEvent, error) {
+	loud := frame[0] != 0
+	switch {
+	case loud && !s.speaking:
+		s.speaking = true
+		return vad.VADEvent{Type: vad.VADSpeechStart, Probability: 1}, nil
+	case loud:
+		return vad.VADEvent{Type: vad.VADSpeechContinue, Probability: 1}, nil
+	case s.speaking:
+		s.speaking = false
+		return vad.VADEvent{Type: vad.VADSpeechEnd}, nil
+	default:
+		return vad.VADEvent{Type: vad.VADSilence}, nil
+	}
+}
+
+func (s *levelSession) Reset()       { s.speaking = false }
+func (s *levelSession) Close() error { return nil }
+
+// vadFrame returns a 20 ms input frame in the VAD format, filled with level.
+func vadFrame(level byte) audio.AudioFrame {
+	return audio.AudioFrame{
+		Data:       bytes.Repeat([]byte{level}, vadSampleRate*vadFrameSizeMs/1000*2),
+		SampleRate: vadSampleRate,
+		Channels:   1,
+	}
+}
+
+// dispatched records the utterances handed to an inputPipeline.
+type dispatched struct {
+	mu          sync.Mutex
+	speakers    []string
+	transcripts []stt.Transcript
+	done        chan struct{}
+}
+
+func newDispatched() *dispatched { return &dispatched{done: make(chan struct{}, 16)} }
+
+func (d *dispatched) dispatch(_ context.Context, speaker string, t stt.Transcript) {
+	d.mu.Lock()
+	d.speakers = append(d.speakers, speaker)
+	d.transcripts = append(d.transcripts, t)
+	d.mu.Unlock()
+	d.done <- struct{}{}
+}
+
+func TestInputPipeline_BargeIn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		frames int // loud frames sent before silence
+		want   bool
+	}{
+		{name: "sustained speech", frames: 15, want: true},
+		{name: "short blip", frames: 5, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu     sync.Mutex
+				barged []string
+			)
+			det := bargein.New(func(id string) {
+				mu.Lock()
+				barged = append(barged, id)
+				mu.Unlock()
+			}, bargein.WithGracePeriod(200*time.Millisecond))
+			seg := vad.NewSegmenter(levelVAD{}, vad.Config{SampleRate: vadSampleRate, FrameSizeMs: vadFrameSizeMs})
+			p := newInputPipeline(inputConfig{Segmenter: seg, BargeIn: det, Dispatch: newDispatched().dispatch})
+
+			src := make(chan audio.AudioFrame)
+			p.listen(t.Context(), map[string]<-chan audio.AudioFrame{"player-1": src})
+			for range tt.frames {
+				src <- vadFrame(0x40)
+			}
+			src <- vadFrame(0)
+			close(src)
+			_ = p.wait()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if got := len(barged) == 1 && barged[0] == "player-1"; got != tt.want {
+				t.Errorf("barge-ins = %v, want one for player-1: %v", barged, tt.want)
+			}
+		})
+	}
+}
+
+// errBadFrame is returned by a failingVAD session for frames filled with 0xFF.
+var errBadFrame = errors.New("bad frame")
+
+// failingVAD is a levelVAD whose sessions fail on frames starting with 0xFF.
+type failingVAD struct{}
+
+func (failingVAD) NewSession(vad.Config) (vad.SessionHandle, error) {
+	return &failingSession{}, nil
+}
+
+type failingSession struct{ levelSession }
+
+func (s *failingSession) ProcessFrame(frame []byte) (vad.VADEvent, error) {
+	if frame[0] == 0xFF {
+		return vad.VADEvent{}, errBadFrame
+	}
+	return s.levelSession.ProcessFrame(frame)
+}
+
+func TestInputPipeline_SkipsFailedFrames(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		barged []string
+	)
+	det := bargein.New(func(id string) {
+		mu.Lock()
+		barged = append(barged, id)
+		mu.Unlock()
+	}, bargein.WithGracePeriod(200*time.Millisecond))
+	seg := vad.NewSegmenter(failingVAD{}, vad.Config{SampleRate: vadSampleRate, FrameSizeMs: vadFrameSizeMs})
+	p := newInputPipeline(inputConfig{Segmenter: seg, BargeIn: det, Dispatch: newDispatched().dispatch})
+
+	src := make(chan audio.AudioFrame, 17)
+	src <- vadFrame(0xFF)
+	for range 15 {
+		src <- vadFrame(0x40)
+	}
+	src <- vadFrame(0)
+	close(src)
+	p.listen(t.Context(), map[string]<-chan audio.AudioFrame{"player-1": src})
+	if err := p.wait(); !errors.Is(err, errBadFrame) {
+		t.Errorf("wait() = %v, want %v", err, errBadFrame)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(barged) != 1 || barged[0] != "player-1" {
+		t.Errorf("barge-ins = %v, want one for player-1 after the failed frame", barged)
+	}
+}
+
+func TestInputPipeline_Utterance(t *testing.T) {
+	t.Parallel()
+
+	finals := make(chan stt.Transcript, 2)
+	finals <- stt.Transcript{Text: " Grimjaw, ", IsFinal: true, Language: "en"}
+	finals <- stt.Transcript{Text: "open the gate.", IsFinal: true}
+	close(finals)
+	sess := &sttmock.Session{FinalsCh: finals}
+	sttP := &sttmock.Provider{Session: sess}
+
+	d := newDispatched()
+	var recorded []memory.TranscriptEntry
+	seg := vad.NewSegmenter(levelVAD{}, vad.Config{SampleRate: vadSampleRate, FrameSizeMs: vadFrameSizeMs})
+	p := newInputPipeline(inputConfig{
+		Segmenter: seg,
+		BargeIn:   bargein.New(func(string) {}),
+		STT:       sttP,
+		Attribute: func(_ context.Context, sourceID string, segment audio.AudioFrame) string {
+			if segment.SampleRate != vadSampleRate || segment.Channels != 1 || len(segment.Data) == 0 {
+				t.Errorf("Attribute segment = %d bytes at %d Hz × %d, want 16 kHz mono speech", len(segment.Data), segment.SampleRate, segment.Channels)
+			}
+			return sourceID + "#2"
+		},
+		Record: func(entry memory.TranscriptEntry) {
+			// Called before Dispatch, whose done signal orders this write
+			// before the reads below.
+			recorded = append(recorded, entry)
+		},
+		Dispatch: d.dispatch,
+	})
+
+	// Stereo 48 kHz input is converted to the VAD format.
+	before := time.Now()
+	src := make(chan audio.AudioFrame, 8)
+	for range 3 {
+		src <- audio.AudioFrame{Data: bytes.Repeat([]byte{0x40}, 48000*20/1000*2*2), SampleRate: 48000, Channels: 2}
+	}
+	src <- audio.AudioFrame{Data: make([]byte, 48000*20/1000*2*2), SampleRate: 48000, Channels: 2}
+	close(src)
+
+	p.listen(t.Context(), map[string]<-chan audio.AudioFrame{"player-1": src})
+	select {
+	case <-d.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("utterance was not dispatched")
+	}
+	_ = p.wait()
+
+	if len(sttP.StartStreamCalls) != 1 {
+		t.Fatalf("StartStream calls = %d, want 1", len(sttP.StartStreamCalls))
+	}
+	if cfg := sttP.StartStreamCalls[0].Cfg; cfg.SampleRate != vadSampleRate || cfg.Channels != 1 {
+		t.Errorf("stream format = %d Hz × %d, want %d Hz mono", cfg.SampleRate, cfg.Channels, vadSampleRate)
+	}
+	if sess.SendAudioCallCount() == 0 {
+		t.Error("no audio was sent to the STT session")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.transcripts) != 1 {
+		t.Fatalf("dispatched %d utterances, want 1", len(d.transcripts))
+	}
+	if got := d.speakers[0]; got != "player-1#2" {
+		t.Errorf("speaker = %q, want the attributed %q", got, "player-1#2")
+	}
+	got := d.transcripts[0]
+	if got.Text != "Grimjaw, open the gate." || !got.IsFinal || got.Language != "en" {
+		t.Errorf("transcript = %+v, want final %q in en", got, "Grimjaw, open the gate.")
+	}
+
+	if len(recorded) != 1 {
+		t.Fatalf("recorded %d entries, want 1", len(recorded))
+	}
+	entry := recorded[0]
+	if entry.SpeakerID != "player-1#2" || entry.Text != got.Text || entry.Language != "en" || entry.IsNPC() {
+		t.Errorf("entry = %+v, want a player line by player-1#2 with the transcript", entry)
+	}
+	if entry.Timestamp.Before(before) || entry.Timestamp.After(time.Now()) {
+		t.Errorf("Timestamp = %v, want the start of the utterance", entry.Timestamp)
+	}
+	// Three speech frames and the closing frame of the segment.
+	if want := 4 * vadFrameSizeMs * time.Millisecond; entry.Duration != want {
+		t.Errorf("Duration = %v, want %v", entry.Duration, want)
+	}
+}
+
+func TestInputPipeline_ListenSkipsConsumedStreams(t *testing.T) {
+	t.Parallel()
+
+	seg := vad.NewSegmenter(levelVAD{}, vad.Config{SampleRate: vadSampleRate, FrameSizeMs: vadFrameSizeMs})
+	p := newInputPipeline(inputConfig{Segmenter: seg, BargeIn: bargein.New(func(string) {}), Dispatch: newDispatched().dispatch})
+
+	first, second := make(chan audio.AudioFrame), make(chan audio.AudioFrame)
+	ctx := t.Context()
+	p.listen(ctx, map[string]<-chan audio.AudioFrame{"player-1": first})
+	p.listen(ctx, map[string]<-chan audio.AudioFrame{"player-1": first, "player-2": second})
+
+	p.mu.Lock()
+	n := len(p.listening)
+	p.mu.Unlock()
+	if n != 2 {
+		t.Errorf("listening to %d streams, want 2", n)
+	}
+
+	close(first)
+	close(second)
+	_ = p.wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.listening) != 0 {
+		t.Errorf("listening to %d streams after they closed, want 0", len(p.listening))
+	}
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MrWong99/glyphoxa/internal/agent"
+	"github.com/MrWong99/glyphoxa/internal/engine"
+)
+
+// RenderPrompt assembles the prompt the NPC identified by npcID would send to
+// its engine if a player said input, without running a turn: no engine is
+// called and the NPC's history is left unchanged. npcID matches an agent's ID
+// or, case-insensitively, its name. A non-nil scene replaces the hot context
+// for this rendering only.
+//
+// It returns the prompt together with a human-readable dump of it, meant for
+// debugging personas, retrieval, and behaviour rules.
+func (a *App) RenderPrompt(ctx context.Context, npcID, input string, scene *agent.SceneContext) (engine.PromptContext, string, error) {
+	ag := a.findAgent(npcID)
+	if ag == nil {
+		return engine.PromptContext{}, "", fmt.Errorf("app: render prompt: NPC %q not found", npcID)
+	}
+	r, ok := ag.(agent.PromptRenderer)
+	if !ok {
+		return engine.PromptContext{}, "", fmt.Errorf("app: render prompt: NPC %q cannot render prompts", npcID)
+	}
+	prompt, err := r.RenderPrompt(ctx, input, scene)
+	if err != nil {
+		return engine.PromptContext{}, "", fmt.Errorf("app: render prompt for %q: %w", npcID, err)
+	}
+	return prompt, formatPrompt(prompt), nil
+}
+
+// findAgent returns the agent whose ID is id or whose name matches id
+// case-insensitively, or nil if there is none.
+func (a *App) findAgent(id string) agent.NPCAgent {
+	for _, ag := range a.agents {
+		if ag.ID() == id {
+			return ag
+		}
+	}
+	for _, ag := range a.agents {
+		if strings.EqualFold(ag.Name(), id) {
+			return ag
+		}
+	}
+	return nil
+}
+
+// formatPrompt renders p as plain text, one section per prompt part.
+func formatPrompt(p engine.PromptContext) string {
+	var b strings.Builder
+	b.WriteString("=== System prompt ===\n")
+	b.WriteString(p.SystemPrompt)
+	b.WriteString("\n\n=== Hot context ===\n")
+	b.WriteString(p.HotContext)
+	if len(p.PreFetchResults) > 0 {
+		b.WriteString("\n\n=== Pre-fetched results ===")
+		for _, r := range p.PreFetchResults {
+			b.WriteString("\n- ")
+			b.WriteString(r)
+		}
+	}
+	b.WriteString("\n\n=== Messages ===")
+	for _, m := range p.Messages {
+		role := m.Role
+		if m.Name != "" {
+			role += " " + m.Name
+		}
+		fmt.Fprintf(&b, "\n[%s] %s", role, m.Content)
+	}
+	fmt.Fprintf(&b, "\n\n=== Budget tier: %s ===", p.BudgetTier)
+	if p.Language != "" {
+		fmt.Fprintf(&b, "\n=== Language: %s ===", p.Language)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+)
+
+// Warmer is implemented by providers that need a warm-up step before they can
+// serve their first request quickly — for example loading a local model into
+// memory or opening a connection pool. After [New] returns, Warmup is called
+// on every provider that implements it before [App.Ready] is closed.
+type Warmer interface {
+	// Warmup prepares the provider for use. It should return promptly when
+	// ctx is cancelled.
+	Warmup(ctx context.Context) error
+}
+
+// Ready returns a channel that is closed once the application is ready to
+// accept audio: all subsystems are initialised and every provider implementing
+// [Warmer] has finished warming up.
+func (a *App) Ready() <-chan struct{} { return a.ready }
+
+// warmup warms all providers implementing [Warmer] concurrently and closes
+// a.ready when they are done. A failed warm-up is logged but does not keep the
+// application from becoming ready; the provider may still recover on its
+// first real request.
+func (a *App) warmup(ctx context.Context) {
+	defer close(a.ready)
+
+	named := []struct {
+		name     string
+		provider any
+	}{
+		{"llm", a.providers.LLM},
+		{"stt", a.providers.STT},
+		{"tts", a.providers.TTS},
+		{"s2s", a.providers.S2S},
+		{"embeddings", a.providers.Embeddings},
+		{"vad", a.providers.VAD},
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, p := range named {
+		w, ok := p.provider.(Warmer)
+		if !ok {
+			continue
+		}
+		wg.Go(func() {
+			if err := w.Warmup(ctx); err != nil {
+				slog.Warn("provider warm-up failed", "provider", p.name, "err", err)
+			}
+		})
+	}
+	wg.Wait()
+	slog.Info("providers ready", "elapsed", time.Since(start))
+}
+
+// gatedConnection wraps an [audio.Connection] and drops all input audio until
+// ready is closed, so that player speech captured while providers are still
+// warming up never reaches the voice pipeline.
+type gatedConnection struct {
+	audio.Connection
+	ready <-chan struct{}
+
+	mu      sync.Mutex
+	streams map[string]gatedStream
+}
+
+// gatedStream pairs a participant's source channel with its gated copy.
+type gatedStream struct {
+	src <-chan audio.AudioFrame
+	out <-chan audio.AudioFrame
+}
+
+// gateConnection returns conn unchanged if ready is nil or already closed,
+// and otherwise wraps it in a gatedConnection.
+func gateConnection(conn audio.Connection, ready <-chan struct{}) audio.Connection {
+	if ready == nil || isClosed(ready) {
+		return conn
+	}
+	return &gatedConnection{
+		Connection: conn,
+		ready:      ready,
+		streams:    make(map[string]gatedStream),
+	}
+}
+
+// InputStreams implements [audio.Connection]. Streams of participants seen
+// before ready fires are replaced by gated copies that drop frames until then;
+// streams that appear after ready fires are returned as-is.
+func (c *gatedConnection) InputStreams() map[string]<-chan audio.AudioFrame {
+	src := c.Connection.InputStreams()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ready := isClosed(c.ready)
+	out := make(map[string]<-chan audio.AudioFrame, len(src))
+	for id, ch := range src {
+		if gs, ok := c.streams[id]; ok && gs.src == ch {
+			out[id] = gs.out
+			continue
+		}
+		if ready {
+			out[id] = ch
+			continue
+		}
+		gated := make(chan audio.AudioFrame, cap(ch))
+		go c.forward(ch, gated)
+		c.streams[id] = gatedStream{src: ch, out: gated}
+		out[id] = gated
+	}
+	return out
+}
+
+// forward copies frames from src to dst, dropping them until c.ready is
+// closed. Like the platform's own input streams it never blocks: frames are
+// dropped when dst is full. dst is closed when src closes.
+func (c *gatedConnection) forward(src <-chan audio.AudioFrame, dst chan<- audio.AudioFrame) {
+	defer close(dst)
+	dropped := 0
+	for frame := range src {
+		if !isClosed(c.ready) {
+			dropped++
+			continue
+		}
+		if dropped > 0 {
+			slog.Debug("dropped input audio received before providers were ready", "frames", dropped)
+			dropped = 0
+		}
+		select {
+		case dst <- frame:
+		default:
+		}
+	}
+}
+
+// isClosed reports whether ch is closed without blocking.
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
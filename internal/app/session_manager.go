@@ -1,9 +1,11 @@
 package app
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -11,19 +13,35 @@ import (
 	"github.com/MrWong99/glyphoxa/internal/agent"
 	"github.com/MrWong99/glyphoxa/internal/agent/orchestrator"
 	"github.com/MrWong99/glyphoxa/internal/config"
+	"github.com/MrWong99/glyphoxa/internal/engine/limit"
 	"github.com/MrWong99/glyphoxa/internal/entity"
 	"github.com/MrWong99/glyphoxa/internal/hotctx"
 	"github.com/MrWong99/glyphoxa/internal/mcp"
 	"github.com/MrWong99/glyphoxa/internal/session"
+	"github.com/MrWong99/glyphoxa/internal/vocab"
 	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/audio/bargein"
+	"github.com/MrWong99/glyphoxa/pkg/audio/diarize"
 	audiomixer "github.com/MrWong99/glyphoxa/pkg/audio/mixer"
+	"github.com/MrWong99/glyphoxa/pkg/idgen"
 	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+	"github.com/MrWong99/glyphoxa/pkg/tokenize"
 )
 
 // consolidationInterval is the consolidation period for alpha mode sessions.
 const consolidationInterval = 5 * time.Minute
 
+// Frame format of the audio fed to VAD sessions: the mono 16 kHz stream that
+// is also sent to STT, in 20 ms frames.
+const (
+	vadSampleRate  = 16000
+	vadFrameSizeMs = 20
+)
+
 // SessionInfo holds metadata about an active session.
 type SessionInfo struct {
 	// SessionID is the unique identifier for this session.
@@ -54,6 +72,9 @@ type SessionManager struct {
 	consolidator *session.Consolidator
 	mixer        audio.Mixer
 	agents       []agent.NPCAgent
+	bargeIn      *bargein.Detector
+	segmenter    *vad.Segmenter
+	vocab        *vocab.Vocabulary
 	cancel       context.CancelFunc
 
 	// closers are called in reverse order during Stop.
@@ -65,8 +86,16 @@ type SessionManager struct {
 	providers    *Providers
 	sessionStore memory.SessionStore
 	graph        memory.KnowledgeGraph
+	npcStates    agent.StateStore
 	mcpHost      mcp.Host
 	entities     entity.Store
+	transcripts  *TranscriptMerger
+	turns        *TurnBus
+	ready        <-chan struct{}
+	diarizer     diarize.Diarizer
+	engines      *limit.Limiter
+	ids          idgen.Generator
+	turnLocks    *orchestrator.TurnLocks
 }
 
 // SessionManagerConfig holds all dependencies for a [SessionManager].
@@ -78,18 +107,68 @@ type SessionManagerConfig struct {
 	Graph        memory.KnowledgeGraph
 	MCPHost      mcp.Host
 	Entities     entity.Store
+
+	// NPCStates, if set, persists the working memory of the session's NPCs
+	// after every turn. Pass [App.NPCStates].
+	NPCStates agent.StateStore
+
+	// Transcripts, if set, receives every line spoken in the session: the
+	// transcript entries of the NPC engines and the transcribed player
+	// utterances, tagged with the session ID. Pass [App.TranscriptMerger].
+	Transcripts *TranscriptMerger
+
+	// TurnBus, if set, receives a [TurnCompleted] event for every turn of the
+	// session's NPCs.
+	TurnBus *TurnBus
+
+	// Ready, if set, gates input audio: sessions may start before it is
+	// closed, but player audio received until then is dropped. Pass
+	// [App.Ready] so no audio reaches providers that are still warming up.
+	Ready <-chan struct{}
+
+	// Diarizer, if set, attributes speech segments to speakers for
+	// [SessionManager.AttributeSpeaker]. Use a [diarize.Clusterer] with a
+	// speaker-embedding model when several players share one input stream.
+	// Defaults to [diarize.Passthrough], which suits platforms with one stream
+	// per participant such as Discord.
+	Diarizer diarize.Diarizer
+
+	// Engines, if set, counts and caps the session's NPC engines. Pass
+	// [App.Engines] so sessions and the app share one limit. Defaults to a
+	// limiter of its own, created from server.max_concurrent_npcs.
+	Engines *limit.Limiter
+
+	// IDs, if set, generates the unique part of session IDs. Pass
+	// [App.IDGenerator]. Defaults to [idgen.Default].
+	IDs idgen.Generator
 }
 
 // NewSessionManager creates a SessionManager with the given dependencies.
 func NewSessionManager(cfg SessionManagerConfig) *SessionManager {
+	diarizer := cfg.Diarizer
+	if diarizer == nil {
+		diarizer = diarize.Passthrough{}
+	}
+	engines := cfg.Engines
+	if engines == nil {
+		engines = newEngineLimiter(cfg.Config.Server.MaxConcurrentNPCs)
+	}
 	return &SessionManager{
 		platform:     cfg.Platform,
 		cfg:          cfg.Config,
 		providers:    cfg.Providers,
 		sessionStore: cfg.SessionStore,
 		graph:        cfg.Graph,
+		npcStates:    cfg.NPCStates,
 		mcpHost:      cfg.MCPHost,
 		entities:     cfg.Entities,
+		transcripts:  cfg.Transcripts,
+		turns:        cfg.TurnBus,
+		ready:        cfg.Ready,
+		diarizer:     diarizer,
+		engines:      engines,
+		ids:          cmp.Or(cfg.IDs, idgen.Default()),
+		turnLocks:    orchestrator.NewTurnLocks(),
 	}
 }
 
@@ -106,22 +185,29 @@ func (sm *SessionManager) Start(ctx context.Context, channelID string, dmUserID
 		return fmt.Errorf("session: a session is already active (id=%s)", sm.info.SessionID)
 	}
 
-	// Generate session ID.
+	// Generate session ID. The generated part keeps IDs unique even for
+	// sessions started within the same minute, and time-sortable.
 	campaignName := sm.cfg.Campaign.Name
 	if campaignName == "" {
 		campaignName = "default"
 	}
 	now := time.Now().UTC()
-	sessionID := fmt.Sprintf("session-%s-%s",
-		sanitizeName(campaignName),
-		now.Format("20060102T1504Z"),
-	)
+	sessionID := fmt.Sprintf("session-%s-%s", sanitizeName(campaignName), sm.ids.NewID())
+
+	// Tag provider requests made for this session, if enabled.
+	baseCtx := context.Background()
+	if sm.cfg.Providers.SessionTagging {
+		ctx = ident.WithSession(ctx, sessionID)
+		baseCtx = ident.WithSession(baseCtx, sessionID)
+	}
 
 	// Connect to voice channel.
 	conn, err := sm.platform.Connect(ctx, channelID)
 	if err != nil {
 		return fmt.Errorf("session: connect to voice channel: %w", err)
 	}
+	participants, _ := conn.(audio.ParticipantResolver)
+	conn = gateConnection(conn, sm.ready)
 
 	// Create mixer for this session, wired to the voice connection output.
 	outStream := conn.OutputStream()
@@ -129,12 +215,27 @@ func (sm *SessionManager) Start(ctx context.Context, channelID string, dmUserID
 	var closers []func() error
 	pm := audiomixer.New(func(frame audio.AudioFrame) {
 		outStream <- frame
-	})
+	}, mixerOptions(sm.cfg.BargeIn, sm.cfg.Output)...)
 	mixer = pm
 	closers = append(closers, pm.Close)
 
-	// Create hot-context assembler.
-	assembler := hotctx.NewAssembler(sm.sessionStore, sm.graph)
+	// Debounce player barge-in so only sustained speech interrupts NPCs.
+	var bargeInOpts []bargein.Option
+	if ms := sm.cfg.BargeIn.GracePeriodMs; ms > 0 {
+		bargeInOpts = append(bargeInOpts, bargein.WithGracePeriod(time.Duration(ms)*time.Millisecond))
+	}
+	detector := bargein.New(pm.BargeIn, bargeInOpts...)
+
+	// Per-speaker voice activity detection, if a VAD engine is configured.
+	var segmenter *vad.Segmenter
+	if sm.providers.VAD != nil {
+		segmenter = newSegmenter(sm.providers.VAD, sm.cfg.VAD, participants)
+		closers = append(closers, segmenter.Close)
+	}
+
+	// Collect the session vocabulary and create the hot-context assembler.
+	voc := newVocabulary(ctx, sm.cfg, sm.graph, sm.entities)
+	assembler := newAssembler(sm.sessionStore, sm.graph, sm.providers, voc)
 
 	// Create NPC agents from config.
 	agents, agentClosers, err := sm.loadAgents(ctx, assembler, mixer, sessionID)
@@ -146,11 +247,16 @@ func (sm *SessionManager) Start(ctx context.Context, channelID string, dmUserID
 	}
 	closers = append(closers, agentClosers...)
 
-	// Create orchestrator with loaded agents.
-	orch := orchestrator.New(agents)
+	// Create orchestrator with loaded agents. Unless concurrent turns are
+	// enabled, the session's turns are serialised.
+	var turnLocks *orchestrator.TurnLocks
+	if !sm.cfg.Server.ConcurrentTurns {
+		turnLocks = sm.turnLocks
+	}
+	orch := orchestrator.New(agents, orchestrator.WithTurnIsolation(turnLocks, sessionID))
 
 	// Create a session-scoped context for background work.
-	sessionCtx, cancel := context.WithCancel(context.Background())
+	sessionCtx, cancel := context.WithCancel(baseCtx)
 
 	// Start consolidator if we have a session store and a context manager.
 	// For the alpha, create a minimal consolidator that periodically writes
@@ -162,6 +268,7 @@ func (sm *SessionManager) Start(ctx context.Context, channelID string, dmUserID
 			MaxTokens:      128000,
 			ThresholdRatio: 0.75,
 			Summariser:     &noopSummariser{},
+			Counter:        tokenize.ForModel(sm.cfg.Providers.LLM.Model),
 		})
 		consolid = session.NewConsolidator(session.ConsolidatorConfig{
 			Store:      sm.sessionStore,
@@ -172,12 +279,58 @@ func (sm *SessionManager) Start(ctx context.Context, channelID string, dmUserID
 		consolid.Start(sessionCtx)
 	}
 
+	// Add the NPCs' lines to the merged table transcript.
+	if sm.transcripts != nil {
+		for _, ag := range agents {
+			go sm.mergeTranscripts(sessionCtx, ag, sessionID)
+		}
+	}
+
+	// Feed player audio through VAD and barge-in detection and dispatch
+	// the transcribed utterances. Without a VAD engine there is no way to
+	// segment the input streams. The pipeline is drained before the engines
+	// are closed: closers run in reverse order after cancel.
+	if segmenter != nil {
+		var sttP stt.Provider
+		if sm.providers.STT != nil {
+			sttP = voc.WrapSTT(sm.providers.STT)
+		}
+		var record func(memory.TranscriptEntry)
+		if sm.transcripts != nil {
+			record = func(entry memory.TranscriptEntry) {
+				sm.transcripts.Add(sessionID, SourcePlayer, entry)
+			}
+		}
+		input := newInputPipeline(inputConfig{
+			Segmenter: segmenter,
+			BargeIn:   detector,
+			STT:       sttP,
+			Attribute: sm.AttributeSpeaker,
+			Forget:    sm.diarizer.Forget,
+			Record:    record,
+			Dispatch:  orchestratorDispatch(orch),
+		})
+		conn.OnParticipantChange(func(ev audio.Event) {
+			switch ev.Type {
+			case audio.EventJoin:
+				input.listen(sessionCtx, conn.InputStreams())
+			case audio.EventLeave:
+				sm.forgetParticipant(conn.InputStreams(), participants, ev.UserID)
+			}
+		})
+		input.listen(sessionCtx, conn.InputStreams())
+		closers = append(closers, input.wait)
+	}
+
 	sm.active = true
 	sm.conn = conn
 	sm.orch = orch
 	sm.consolidator = consolid
 	sm.mixer = mixer
 	sm.agents = agents
+	sm.bargeIn = detector
+	sm.segmenter = segmenter
+	sm.vocab = voc
 	sm.cancel = cancel
 	sm.closers = closers
 	sm.info = SessionInfo{
@@ -247,6 +400,9 @@ func (sm *SessionManager) Stop(ctx context.Context) error {
 	sm.consolidator = nil
 	sm.mixer = nil
 	sm.agents = nil
+	sm.bargeIn = nil
+	sm.segmenter = nil
+	sm.vocab = nil
 	sm.cancel = nil
 	sm.closers = nil
 	sm.info = SessionInfo{}
@@ -279,13 +435,88 @@ func (sm *SessionManager) Orchestrator() *orchestrator.Orchestrator {
 	return sm.orch
 }
 
+// Connection returns the active session's voice connection, or nil if no
+// session is active. Input streams are gated by [SessionManagerConfig.Ready].
+func (sm *SessionManager) Connection() audio.Connection {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.conn
+}
+
+// BargeInDetector returns the debounced barge-in detector of the active
+// session, or nil if no session is active. When a VAD provider is configured
+// the session feeds it the VAD result of every input frame; sustained player
+// speech interrupts NPC playback.
+func (sm *SessionManager) BargeInDetector() *bargein.Detector {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.bargeIn
+}
+
+// VADSegmenter returns the per-speaker VAD segmenter of the active session, or
+// nil if no session is active or no VAD provider is configured. Speaker
+// thresholds come from [config.VADConfig].
+func (sm *SessionManager) VADSegmenter() *vad.Segmenter {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.segmenter
+}
+
+// Vocabulary returns the vocabulary of the active session, or nil if no
+// session is active. It holds campaign.vocabulary and the names of all known
+// entities, and grows as entities are added with [SessionManager.PropagateEntity].
+func (sm *SessionManager) Vocabulary() *vocab.Vocabulary {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.vocab
+}
+
+// STT returns the configured STT provider with the vocabulary of the active
+// session added to the keywords of every stream, or nil if no session is
+// active or no STT provider is configured. Streams opened on it receive
+// entities added mid-session as new keywords.
+func (sm *SessionManager) STT() stt.Provider {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.vocab == nil || sm.providers == nil || sm.providers.STT == nil {
+		return nil
+	}
+	return sm.vocab.WrapSTT(sm.providers.STT)
+}
+
+// AttributeSpeaker returns the speaker ID of segment, a complete utterance
+// received on the input stream sourceID. The session's input pipeline passes
+// it to the orchestrator as the speaker of each utterance, which makes it the
+// SpeakerID of the resulting transcript entries. If attribution fails, sourceID is returned so
+// the utterance is still attributed to its stream.
+func (sm *SessionManager) AttributeSpeaker(ctx context.Context, sourceID string, segment audio.AudioFrame) string {
+	id, err := sm.diarizer.Attribute(ctx, sourceID, segment)
+	if err != nil {
+		slog.Warn("session: speaker attribution failed; using input stream ID",
+			"source_id", sourceID, "err", err)
+		return sourceID
+	}
+	return id
+}
+
+// forgetParticipant makes the diarizer forget the speakers of every stream in
+// streams that belongs to userID, who left the voice channel. Platforms whose
+// streams outlive their participants, like Discord, resolve them through
+// participants, which may be nil.
+func (sm *SessionManager) forgetParticipant(streams map[string]<-chan audio.AudioFrame, participants audio.ParticipantResolver, userID string) {
+	for id := range streams {
+		if id == userID || (participants != nil && participants.ParticipantID(id) == userID) {
+			sm.diarizer.Forget(id)
+		}
+	}
+}
+
 // PropagateEntity persists a new entity and propagates it to the knowledge
 // graph for mid-session use. Steps:
 //  1. Add entity to the entity store.
 //  2. Convert to memory.Entity and add to the knowledge graph.
-//  3. (Best-effort) STT keyword boosting and phonetic index are logged but
-//     not yet wired through agents; providers that support mid-session keyword
-//     updates will be integrated in a future release.
+//  3. Add the entity name to the vocabulary of the active session, which
+//     updates the NPC prompts and the keywords of open STT streams.
 //
 // Returns the stored entity (with generated ID) and any error.
 func (sm *SessionManager) PropagateEntity(ctx context.Context, def entity.EntityDefinition) (entity.EntityDefinition, error) {
@@ -314,6 +545,11 @@ func (sm *SessionManager) PropagateEntity(ctx context.Context, def entity.Entity
 		if len(stored.Tags) > 0 {
 			attrs["tags"] = stored.Tags
 		}
+		// Tag the entity with the running campaign so graph exports can be
+		// scoped to it.
+		if _, ok := attrs[memory.CampaignAttribute]; !ok && sm.cfg.Campaign.Name != "" {
+			attrs[memory.CampaignAttribute] = sm.cfg.Campaign.Name
+		}
 
 		memEntity := memory.Entity{
 			ID:         stored.ID,
@@ -330,14 +566,40 @@ func (sm *SessionManager) PropagateEntity(ctx context.Context, def entity.Entity
 		}
 	}
 
-	// Step 3: STT keyword boost (best-effort, not yet wired through agents).
-	// Future: iterate over active agents and call SessionHandle.SetKeywords
-	// with the entity name added to the boost list.
-	slog.Debug("propagate entity: STT keyword boost not yet wired for mid-session updates", "name", stored.Name)
+	// Step 3: Add the name to the session vocabulary.
+	if sm.vocab != nil {
+		sm.vocab.Add(stored.Name)
+	}
 
 	return stored, nil
 }
 
+// SetNPCs replaces the NPC definitions used by sessions started from now on.
+// An active session keeps the NPCs it was started with.
+//
+// The definitions are checked against the instantiated providers first:
+// providers are only created at startup, so NPCs that select an LLM provider
+// which is not running are rejected, and the current definitions are kept.
+func (sm *SessionManager) SetNPCs(npcs []config.NPCConfig) error {
+	for _, npc := range npcs {
+		if npc.LLM == nil || npc.LLM.Provider == "" {
+			continue
+		}
+		if _, err := npcLLM(sm.providers, npc); err != nil {
+			return fmt.Errorf("session: set npcs: %w (restart to create new providers)", err)
+		}
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	// Copy the config so the caller's value is never modified.
+	cfg := *sm.cfg
+	cfg.NPCs = slices.Clone(npcs)
+	sm.cfg = &cfg
+	return nil
+}
+
 // loadAgents creates per-NPC engines and agents, mirroring App.initAgents.
 // Returns the loaded agents and a list of closers for engine cleanup.
 func (sm *SessionManager) loadAgents(ctx context.Context, assembler *hotctx.Assembler, mixer audio.Mixer, sessionID string) ([]agent.NPCAgent, []func() error, error) {
@@ -356,6 +618,19 @@ func (sm *SessionManager) loadAgents(ctx context.Context, assembler *hotctx.Asse
 	if sm.providers.TTS != nil {
 		loaderOpts = append(loaderOpts, agent.WithTTS(sm.providers.TTS))
 	}
+	historyOpts, err := historyLoaderOptions(sm.cfg.Memory, sm.providers.LLM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create history policy: %w", err)
+	}
+	loaderOpts = append(loaderOpts, historyOpts...)
+	loaderOpts = append(loaderOpts, recapLoaderOptions(sm.cfg.Memory.SceneRecap, sm.providers.LLM)...)
+	loaderOpts = append(loaderOpts, gateLoaderOptions(sm.cfg.STTGate)...)
+	if sm.turns != nil {
+		loaderOpts = append(loaderOpts, agent.WithTurnObserver(turnPublisher(sm.turns, sessionID)))
+	}
+	if sm.npcStates != nil {
+		loaderOpts = append(loaderOpts, agent.WithStateStore(sm.npcStates))
+	}
 
 	loader, err := agent.NewLoader(assembler, sessionID, loaderOpts...)
 	if err != nil {
@@ -365,8 +640,8 @@ func (sm *SessionManager) loadAgents(ctx context.Context, assembler *hotctx.Asse
 	var agents []agent.NPCAgent
 	var closers []func() error
 
-	for i, npc := range sm.cfg.NPCs {
-		eng, err := buildEngine(sm.providers, npc)
+	for i, npc := range assignVoices(ctx, sm.providers.TTS, sm.cfg.NPCs, sm.cfg.VoiceAssignment) {
+		eng, err := buildEngine(sm.providers, npc, sm.engines)
 		if err != nil {
 			// Clean up already-created engines on failure.
 			for j := len(closers) - 1; j >= 0; j-- {
@@ -377,16 +652,23 @@ func (sm *SessionManager) loadAgents(ctx context.Context, assembler *hotctx.Asse
 		closers = append(closers, eng.Close)
 
 		identity := agent.NPCIdentity{
-			Name:           npc.Name,
-			Personality:    npc.Personality,
-			Voice:          configVoiceProfile(npc.Voice),
-			KnowledgeScope: npc.KnowledgeScope,
+			Name:                 npc.Name,
+			Personality:          npc.Personality,
+			Voice:                configVoiceProfile(npc.Voice),
+			KnowledgeScope:       npc.KnowledgeScope,
+			BehaviorRules:        npc.BehaviorRules,
+			ColdOpen:             npc.ColdOpen,
+			AwarenessRadius:      npc.AwarenessRadius,
+			StyleExemplars:       npc.StyleExemplars,
+			StockResponses:       configStockResponses(npc.StockResponses),
+			Aliases:              npcAliases(ctx, sm.graph, npc),
+			RespondOnlyWhenNamed: npc.RespondOnlyWhenNamed,
 		}
 
 		npcID := fmt.Sprintf("npc-%d-%s", i, npc.Name)
 		tier := configBudgetTier(npc.BudgetTier)
 
-		ag, err := loader.Load(npcID, identity, eng, tier)
+		ag, err := loader.Load(ctx, npcID, identity, eng, tier)
 		if err != nil {
 			for j := len(closers) - 1; j >= 0; j-- {
 				_ = closers[j]()
@@ -397,10 +679,26 @@ func (sm *SessionManager) loadAgents(ctx context.Context, assembler *hotctx.Asse
 		slog.Info("session: loaded NPC agent", "name", npc.Name, "engine", npc.Engine, "tier", tier)
 	}
 
-	_ = ctx // reserved for future async agent init
 	return agents, closers, nil
 }
 
+// mergeTranscripts adds every transcript entry from the agent's engine to the
+// merged transcript until ctx is cancelled or the channel closes.
+func (sm *SessionManager) mergeTranscripts(ctx context.Context, ag agent.NPCAgent, sessionID string) {
+	ch := ag.Engine().Transcripts()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry, ok := <-ch:
+			if !ok {
+				return
+			}
+			sm.transcripts.Add(sessionID, SourceNPC, entry)
+		}
+	}
+}
+
 // sanitizeName replaces spaces with hyphens and lowercases a name
 // for use in session IDs.
 func sanitizeName(name string) string {
@@ -409,6 +707,31 @@ func sanitizeName(name string) string {
 	return name
 }
 
+// newSegmenter builds a VAD segmenter for 16 kHz, 20 ms frames using the
+// thresholds in cfg. The per-speaker overrides are keyed by user ID; if
+// participants is non-nil, it resolves the input streams to users.
+func newSegmenter(engine vad.Engine, cfg config.VADConfig, participants audio.ParticipantResolver) *vad.Segmenter {
+	base := vad.Config{
+		SampleRate:       vadSampleRate,
+		FrameSizeMs:      vadFrameSizeMs,
+		SpeechThreshold:  vad.DefaultSpeechThreshold,
+		SilenceThreshold: vad.DefaultSilenceThreshold,
+	}
+	base = vad.Thresholds{Speech: cfg.SpeechThreshold, Silence: cfg.SilenceThreshold}.Apply(base)
+
+	opts := make([]vad.SegmenterOption, 0, len(cfg.Speakers)+1)
+	if participants != nil {
+		opts = append(opts, vad.WithSpeakerResolver(participants.ParticipantID))
+	}
+	for id, t := range cfg.Speakers {
+		opts = append(opts, vad.WithSpeakerThresholds(id, vad.Thresholds{
+			Speech:  t.SpeechThreshold,
+			Silence: t.SilenceThreshold,
+		}))
+	}
+	return vad.NewSegmenter(engine, base, opts...)
+}
+
 // noopSummariser is a placeholder summariser that returns an empty string.
 // Used during alpha to satisfy the ContextManager's Summariser requirement
 // without needing an LLM provider.
@@ -2,15 +2,31 @@ package app_test
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/MrWong99/glyphoxa/internal/app"
 	"github.com/MrWong99/glyphoxa/internal/config"
+	"github.com/MrWong99/glyphoxa/internal/engine/limit"
 	"github.com/MrWong99/glyphoxa/internal/entity"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/audio/bargein"
+	"github.com/MrWong99/glyphoxa/pkg/audio/diarize"
 	audiomock "github.com/MrWong99/glyphoxa/pkg/audio/mock"
+	"github.com/MrWong99/glyphoxa/pkg/idgen"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
 	memorymock "github.com/MrWong99/glyphoxa/pkg/memory/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	sttmock "github.com/MrWong99/glyphoxa/pkg/provider/stt/mock"
+	ttsmock "github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+	vadmock "github.com/MrWong99/glyphoxa/pkg/provider/vad/mock"
 )
 
 func newTestSessionManager() (*app.SessionManager, *audiomock.Platform, *audiomock.Connection) {
@@ -287,6 +303,37 @@ func TestSessionManager_SessionIDFormat(t *testing.T) {
 	}
 }
 
+func TestSessionManager_SessionIDGenerator(t *testing.T) {
+	t.Parallel()
+
+	ids := []string{"019a0000-0000-7000-8000-000000000001", "019a0000-0000-7000-8000-000000000002"}
+	next := 0
+	sm := app.NewSessionManager(app.SessionManagerConfig{
+		Platform:     &audiomock.Platform{ConnectResult: &audiomock.Connection{}},
+		Config:       &config.Config{Campaign: config.CampaignConfig{Name: "Curse of Strahd"}},
+		Providers:    &app.Providers{},
+		SessionStore: &memorymock.SessionStore{},
+		IDs: idgen.Func(func() string {
+			id := ids[next]
+			next++
+			return id
+		}),
+	})
+
+	// Sessions started within the same minute still get distinct IDs.
+	for _, id := range ids {
+		if err := sm.Start(context.Background(), "ch-1", "user-1"); err != nil {
+			t.Fatalf("Start() error: %v", err)
+		}
+		if got, want := sm.Info().SessionID, "session-curse-of-strahd-"+id; got != want {
+			t.Errorf("SessionID = %q, want %q", got, want)
+		}
+		if err := sm.Stop(context.Background()); err != nil {
+			t.Fatalf("Stop() error: %v", err)
+		}
+	}
+}
+
 func TestSessionManager_PropagateEntity(t *testing.T) {
 	t.Parallel()
 
@@ -337,12 +384,15 @@ func TestSessionManager_PropagateEntity(t *testing.T) {
 		t.Errorf("stored Name = %q, want %q", got.Name, "Gundren Rockseeker")
 	}
 
-	// Verify entity was added to the knowledge graph.
+	// Verify entity was added to the knowledge graph, tagged with the campaign.
 	calls := graph.Calls()
 	found := false
 	for _, c := range calls {
 		if c.Method == "AddEntity" {
 			found = true
+			if e := c.Args[0].(memory.Entity); e.Attributes[memory.CampaignAttribute] != "TestCampaign" {
+				t.Errorf("graph entity campaign = %v, want %q", e.Attributes[memory.CampaignAttribute], "TestCampaign")
+			}
 			break
 		}
 	}
@@ -351,6 +401,63 @@ func TestSessionManager_PropagateEntity(t *testing.T) {
 	}
 }
 
+func TestSessionManager_Vocabulary(t *testing.T) {
+	t.Parallel()
+
+	sess := &sttmock.Session{}
+	sttProvider := &sttmock.Provider{Session: sess}
+	sm := app.NewSessionManager(app.SessionManagerConfig{
+		Platform: &audiomock.Platform{ConnectResult: &audiomock.Connection{}},
+		Config: &config.Config{
+			Campaign: config.CampaignConfig{Vocabulary: []string{"Vel'Shara"}},
+		},
+		Providers:    &app.Providers{STT: sttProvider},
+		SessionStore: &memorymock.SessionStore{},
+		Graph: &memorymock.KnowledgeGraph{FindEntitiesResult: []memory.Entity{
+			{ID: "npc-1", Name: "Grimjaw"},
+		}},
+		Entities: entity.NewMemStore(),
+	})
+	if sm.STT() != nil || sm.Vocabulary() != nil {
+		t.Error("STT() and Vocabulary() should be nil before Start")
+	}
+
+	ctx := context.Background()
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = sm.Stop(ctx) }()
+
+	want := []string{"Vel'Shara", "Grimjaw"}
+	if got := sm.Vocabulary().Terms(); !slices.Equal(got, want) {
+		t.Errorf("Terms() = %q, want %q", got, want)
+	}
+
+	// The vocabulary reaches the STT request.
+	if _, err := sm.STT().StartStream(ctx, stt.StreamConfig{}); err != nil {
+		t.Fatalf("StartStream() error: %v", err)
+	}
+	var requested []string
+	for _, kw := range sttProvider.StartStreamCalls[0].Cfg.Keywords {
+		requested = append(requested, kw.Keyword)
+	}
+	if !slices.Equal(requested, want) {
+		t.Errorf("requested keywords = %q, want %q", requested, want)
+	}
+
+	// An entity created mid-session reaches the open stream.
+	if _, err := sm.PropagateEntity(ctx, entity.EntityDefinition{Name: "Gundren Rockseeker", Type: entity.EntityNPC}); err != nil {
+		t.Fatalf("PropagateEntity() error: %v", err)
+	}
+	if len(sess.SetKeywordsCalls) != 1 {
+		t.Fatalf("SetKeywords calls = %d, want 1", len(sess.SetKeywordsCalls))
+	}
+	kws := sess.SetKeywordsCalls[0].Keywords
+	if last := kws[len(kws)-1].Keyword; last != "Gundren Rockseeker" {
+		t.Errorf("last keyword = %q, want the new entity's name", last)
+	}
+}
+
 func TestSessionManager_PropagateEntity_NoStore(t *testing.T) {
 	t.Parallel()
 
@@ -421,3 +528,557 @@ func TestSessionManager_PropagateEntity_NoGraph(t *testing.T) {
 		t.Errorf("stored Name = %q, want %q", got.Name, "Test Entity")
 	}
 }
+
+func TestSessionManager_NPCLLMOverride(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		override  *config.NPCLLMConfig
+		wantModel string
+		// wantExtra reports whether the per-provider LLM (rather than the
+		// global one) should receive the completion request.
+		wantExtra bool
+	}{
+		{
+			name:      "model only uses global provider",
+			override:  &config.NPCLLMConfig{Model: "gpt-4o-mini"},
+			wantModel: "gpt-4o-mini",
+		},
+		{
+			name:      "provider and model select extra provider",
+			override:  &config.NPCLLMConfig{Provider: "anthropic", Model: "claude-sonnet-4"},
+			wantModel: "claude-sonnet-4",
+			wantExtra: true,
+		},
+		{
+			name:      "no override leaves model empty",
+			wantModel: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			chunks := []llm.Chunk{{Text: "Aye, traveller.", FinishReason: "stop"}}
+			globalLLM := &llmmock.Provider{StreamChunks: chunks}
+			extraLLM := &llmmock.Provider{StreamChunks: chunks}
+
+			conn := &audiomock.Connection{}
+			cfg := &config.Config{
+				Campaign: config.CampaignConfig{Name: "Ironhold"},
+				NPCs: []config.NPCConfig{{
+					Name:   "Grimjaw",
+					Engine: config.EngineCascaded,
+					LLM:    tc.override,
+				}},
+			}
+			sm := app.NewSessionManager(app.SessionManagerConfig{
+				Platform: &audiomock.Platform{ConnectResult: conn},
+				Config:   cfg,
+				Providers: &app.Providers{
+					LLM:     globalLLM,
+					NPCLLMs: map[string]llm.Provider{"anthropic": extraLLM},
+					TTS:     &ttsmock.Provider{},
+				},
+				SessionStore: &memorymock.SessionStore{},
+				Graph:        &memorymock.KnowledgeGraph{},
+			})
+
+			ctx := context.Background()
+			if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+				t.Fatalf("Start() error: %v", err)
+			}
+			t.Cleanup(func() { _ = sm.Stop(context.Background()) })
+
+			ag := sm.Orchestrator().AgentByName("Grimjaw")
+			if ag == nil {
+				t.Fatal("AgentByName(Grimjaw) = nil")
+			}
+			if err := ag.HandleUtterance(ctx, "player-1", stt.Transcript{Text: "Hello there", IsFinal: true}); err != nil {
+				t.Fatalf("HandleUtterance() error: %v", err)
+			}
+
+			used, unused := globalLLM, extraLLM
+			if tc.wantExtra {
+				used, unused = extraLLM, globalLLM
+			}
+			if len(unused.StreamCalls) != 0 {
+				t.Errorf("unexpected LLM received %d stream calls, want 0", len(unused.StreamCalls))
+			}
+			if len(used.StreamCalls) == 0 {
+				t.Fatal("expected LLM to receive a stream call")
+			}
+			if got := used.StreamCalls[0].Req.Model; got != tc.wantModel {
+				t.Errorf("CompletionRequest.Model = %q, want %q", got, tc.wantModel)
+			}
+		})
+	}
+}
+
+func TestSessionManager_NPCLLMOverride_MissingProvider(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		NPCs: []config.NPCConfig{{
+			Name:   "Sage",
+			Engine: config.EngineCascaded,
+			LLM:    &config.NPCLLMConfig{Provider: "anthropic", Model: "claude-sonnet-4"},
+		}},
+	}
+	sm := app.NewSessionManager(app.SessionManagerConfig{
+		Platform:     &audiomock.Platform{ConnectResult: &audiomock.Connection{}},
+		Config:       cfg,
+		Providers:    &app.Providers{LLM: &llmmock.Provider{}, TTS: &ttsmock.Provider{}},
+		SessionStore: &memorymock.SessionStore{},
+	})
+
+	err := sm.Start(context.Background(), "voice-channel-1", "dm-user-1")
+	if err == nil {
+		_ = sm.Stop(context.Background())
+		t.Fatal("Start() expected error for unavailable NPC LLM provider, got nil")
+	}
+	if !strings.Contains(err.Error(), `llm provider "anthropic"`) {
+		t.Errorf("error = %v, want mention of llm provider \"anthropic\"", err)
+	}
+}
+
+func TestSessionManager_SetNPCs(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		NPCs: []config.NPCConfig{{Name: "Grimjaw", Engine: config.EngineCascaded}},
+	}
+	sm := app.NewSessionManager(app.SessionManagerConfig{
+		Platform:     &audiomock.Platform{ConnectResult: &audiomock.Connection{}},
+		Config:       cfg,
+		Providers:    &app.Providers{LLM: &llmmock.Provider{}, TTS: &ttsmock.Provider{}},
+		SessionStore: &memorymock.SessionStore{},
+	})
+	ctx := context.Background()
+
+	// Start a session, then change the NPCs while it runs.
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	if err := sm.SetNPCs([]config.NPCConfig{{Name: "Sage", Engine: config.EngineCascaded}}); err != nil {
+		t.Fatalf("SetNPCs() error: %v", err)
+	}
+	if sm.Orchestrator().AgentByName("Grimjaw") == nil {
+		t.Error("active session should keep its NPCs")
+	}
+	if err := sm.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+
+	// An NPC that needs a provider that is not running is rejected.
+	err := sm.SetNPCs([]config.NPCConfig{{
+		Name:   "Oracle",
+		Engine: config.EngineCascaded,
+		LLM:    &config.NPCLLMConfig{Provider: "anthropic"},
+	}})
+	if err == nil || !strings.Contains(err.Error(), `llm provider "anthropic"`) {
+		t.Errorf("SetNPCs() error = %v, want mention of llm provider \"anthropic\"", err)
+	}
+
+	// The next session uses the accepted NPCs.
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(func() { _ = sm.Stop(context.Background()) })
+
+	if sm.Orchestrator().AgentByName("Sage") == nil {
+		t.Error("new session should load the updated NPCs")
+	}
+	if sm.Orchestrator().AgentByName("Grimjaw") != nil {
+		t.Error("new session should not load removed NPCs")
+	}
+	if cfg.NPCs[0].Name != "Grimjaw" {
+		t.Error("SetNPCs must not modify the caller's config")
+	}
+}
+
+func TestSessionManager_EngineLimit(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		NPCs: []config.NPCConfig{
+			{Name: "Grimjaw", Engine: config.EngineCascaded},
+			{Name: "Sage", Engine: config.EngineCascaded},
+		},
+	}
+	newManager := func(engines *limit.Limiter) *app.SessionManager {
+		return app.NewSessionManager(app.SessionManagerConfig{
+			Platform:     &audiomock.Platform{ConnectResult: &audiomock.Connection{}},
+			Config:       cfg,
+			Providers:    &app.Providers{LLM: &llmmock.Provider{}, TTS: &ttsmock.Provider{}},
+			SessionStore: &memorymock.SessionStore{},
+			Engines:      engines,
+		})
+	}
+	ctx := context.Background()
+
+	// Too few slots: the session does not start and frees what it took.
+	engines := limit.New(1)
+	if err := newManager(engines).Start(ctx, "voice-channel-1", "dm-user-1"); !errors.Is(err, limit.ErrLimitReached) {
+		t.Fatalf("Start() error = %v, want %v", err, limit.ErrLimitReached)
+	}
+	if got := engines.Stats().Active; got != 0 {
+		t.Errorf("open engines after failed Start = %d, want 0", got)
+	}
+
+	// Enough slots: the engines count until the session stops.
+	engines = limit.New(2)
+	sm := newManager(engines)
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	if got := engines.Stats().Active; got != 2 {
+		t.Errorf("open engines during session = %d, want 2", got)
+	}
+	if err := sm.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if got := engines.Stats().Active; got != 0 {
+		t.Errorf("open engines after Stop = %d, want 0", got)
+	}
+}
+
+func TestSessionManager_Connection(t *testing.T) {
+	t.Parallel()
+
+	sm, _, conn := newTestSessionManager()
+	if sm.Connection() != nil {
+		t.Fatal("Connection() should be nil before Start")
+	}
+
+	ctx := context.Background()
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	// Without a Ready channel the connection is not gated.
+	if sm.Connection() != audio.Connection(conn) {
+		t.Error("Connection() should be the platform connection without a Ready gate")
+	}
+
+	if err := sm.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if sm.Connection() != nil {
+		t.Error("Connection() should be nil after Stop")
+	}
+}
+
+func TestSessionManager_BargeInDetector(t *testing.T) {
+	t.Parallel()
+
+	sm, _, _ := newTestSessionManager()
+	if sm.BargeInDetector() != nil {
+		t.Fatal("BargeInDetector() should be nil before Start")
+	}
+
+	ctx := context.Background()
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	det := sm.BargeInDetector()
+	if det == nil {
+		t.Fatal("BargeInDetector() = nil during active session")
+	}
+	if got := det.GracePeriod(); got != bargein.DefaultGracePeriod {
+		t.Errorf("GracePeriod() = %v, want %v", got, bargein.DefaultGracePeriod)
+	}
+
+	if err := sm.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if sm.BargeInDetector() != nil {
+		t.Error("BargeInDetector() should be nil after Stop")
+	}
+}
+
+func TestSessionManager_ReadyGate(t *testing.T) {
+	t.Parallel()
+
+	src := make(chan audio.AudioFrame)
+	conn := &audiomock.Connection{
+		InputStreamsResult: map[string]<-chan audio.AudioFrame{"player-1": src},
+	}
+	ready := make(chan struct{})
+	sm := app.NewSessionManager(app.SessionManagerConfig{
+		Platform:     &audiomock.Platform{ConnectResult: conn},
+		Config:       &config.Config{},
+		Providers:    &app.Providers{},
+		SessionStore: &memorymock.SessionStore{},
+		Ready:        ready,
+	})
+
+	ctx := context.Background()
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = sm.Stop(ctx) }()
+
+	gated := sm.Connection().InputStreams()["player-1"]
+	if gated == nil {
+		t.Fatal("InputStreams() has no stream for player-1")
+	}
+	if got := sm.Connection().InputStreams()["player-1"]; got != gated {
+		t.Error("InputStreams() returned a different gated stream on the second call")
+	}
+
+	// src is unbuffered, so once the last send returns every earlier frame
+	// has been handled by the gate. Only the last one may still be in flight
+	// when ready fires.
+	const preReady = 3
+	for i := 1; i <= preReady; i++ {
+		src <- audio.AudioFrame{Timestamp: time.Duration(i)}
+	}
+	close(ready)
+	const postReady = time.Duration(100)
+	src <- audio.AudioFrame{Timestamp: postReady}
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case f := <-gated:
+			if f.Timestamp == postReady {
+				return
+			}
+			if f.Timestamp < preReady {
+				t.Fatalf("frame %d received before ready was delivered", f.Timestamp)
+			}
+		case <-timeout:
+			t.Fatal("frame received after ready was not delivered")
+		}
+	}
+}
+
+func TestSessionManager_ReadyGate_AlreadyReady(t *testing.T) {
+	t.Parallel()
+
+	src := make(chan audio.AudioFrame)
+	conn := &audiomock.Connection{
+		InputStreamsResult: map[string]<-chan audio.AudioFrame{"player-1": src},
+	}
+	ready := make(chan struct{})
+	close(ready)
+	sm := app.NewSessionManager(app.SessionManagerConfig{
+		Platform:     &audiomock.Platform{ConnectResult: conn},
+		Config:       &config.Config{},
+		Providers:    &app.Providers{},
+		SessionStore: &memorymock.SessionStore{},
+		Ready:        ready,
+	})
+
+	ctx := context.Background()
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = sm.Stop(ctx) }()
+
+	if sm.Connection() != audio.Connection(conn) {
+		t.Error("Connection() should be the platform connection when already ready")
+	}
+}
+
+func TestSessionManager_VADSpeakerThresholds(t *testing.T) {
+	t.Parallel()
+
+	eng := &vadmock.Engine{}
+	softIn, loudIn := make(chan audio.AudioFrame), make(chan audio.AudioFrame)
+	conn := &audiomock.Connection{
+		InputStreamsResult: map[string]<-chan audio.AudioFrame{"soft-player": softIn, "loud-player": loudIn},
+	}
+	sm := app.NewSessionManager(app.SessionManagerConfig{
+		Platform: &audiomock.Platform{ConnectResult: conn},
+		Config: &config.Config{
+			VAD: config.VADConfig{
+				SpeechThreshold: 0.6,
+				Speakers: map[string]config.VADThresholds{
+					"soft-player": {SpeechThreshold: 0.25, SilenceThreshold: 0.15},
+				},
+			},
+		},
+		Providers:    &app.Providers{VAD: eng},
+		SessionStore: &memorymock.SessionStore{},
+	})
+
+	ctx := context.Background()
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = sm.Stop(ctx) }()
+
+	if sm.VADSegmenter() == nil {
+		t.Fatal("VADSegmenter() = nil with a VAD provider configured")
+	}
+
+	// The streams are unbuffered: once the second frame is taken, the
+	// session has run the first one through the VAD.
+	frame := audio.AudioFrame{Data: make([]byte, 640), SampleRate: 16000, Channels: 1}
+	for _, ch := range []chan audio.AudioFrame{softIn, loudIn} {
+		ch <- frame
+		ch <- frame
+	}
+
+	if len(eng.NewSessionCalls) != 2 {
+		t.Fatalf("NewSession calls = %d, want 2", len(eng.NewSessionCalls))
+	}
+	soft, loud := eng.NewSessionCalls[0].Cfg, eng.NewSessionCalls[1].Cfg
+	if soft.SpeechThreshold != 0.25 || soft.SilenceThreshold != 0.15 {
+		t.Errorf("soft-player thresholds = (%v, %v), want (0.25, 0.15)", soft.SpeechThreshold, soft.SilenceThreshold)
+	}
+	if loud.SpeechThreshold != 0.6 || loud.SilenceThreshold != vad.DefaultSilenceThreshold {
+		t.Errorf("loud-player thresholds = (%v, %v), want (0.6, %v)", loud.SpeechThreshold, loud.SilenceThreshold, vad.DefaultSilenceThreshold)
+	}
+}
+
+// resolvingConnection is a connection whose input streams are keyed by SSRC,
+// like Discord's, and that resolves them to user IDs.
+type resolvingConnection struct {
+	*audiomock.Connection
+	users map[string]string // SSRC -> user ID
+}
+
+func (c resolvingConnection) ParticipantID(streamID string) string { return c.users[streamID] }
+
+func TestSessionManager_VADSpeakerThresholdsByUserID(t *testing.T) {
+	t.Parallel()
+
+	eng := &vadmock.Engine{}
+	in := make(chan audio.AudioFrame)
+	conn := resolvingConnection{
+		Connection: &audiomock.Connection{InputStreamsResult: map[string]<-chan audio.AudioFrame{"1111": in}},
+		users:      map[string]string{"1111": "soft-user"},
+	}
+	sm := app.NewSessionManager(app.SessionManagerConfig{
+		Platform: &audiomock.Platform{ConnectResult: conn},
+		Config: &config.Config{
+			VAD: config.VADConfig{
+				Speakers: map[string]config.VADThresholds{"soft-user": {SpeechThreshold: 0.25}},
+			},
+		},
+		Providers:    &app.Providers{VAD: eng},
+		SessionStore: &memorymock.SessionStore{},
+	})
+
+	ctx := context.Background()
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = sm.Stop(ctx) }()
+
+	frame := audio.AudioFrame{Data: make([]byte, 640), SampleRate: 16000, Channels: 1}
+	in <- frame
+	in <- frame
+
+	if len(eng.NewSessionCalls) != 1 {
+		t.Fatalf("NewSession calls = %d, want 1", len(eng.NewSessionCalls))
+	}
+	if got := eng.NewSessionCalls[0].Cfg.SpeechThreshold; got != 0.25 {
+		t.Errorf("speech threshold of stream 1111 = %v, want soft-user's 0.25", got)
+	}
+}
+
+// fakeDiarizer attributes a segment to the speaker named by its first byte,
+// or fails when err is set.
+type fakeDiarizer struct{ err error }
+
+func (f fakeDiarizer) Attribute(_ context.Context, sourceID string, segment audio.AudioFrame) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	return sourceID + "/" + string(segment.Data[:1]), nil
+}
+
+func (fakeDiarizer) Forget(string) {}
+
+var _ diarize.Diarizer = fakeDiarizer{}
+
+// forgettingDiarizer is a fakeDiarizer that records the streams it forgets.
+type forgettingDiarizer struct {
+	fakeDiarizer
+	mu     sync.Mutex
+	forgot []string
+}
+
+func (d *forgettingDiarizer) Forget(sourceID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.forgot = append(d.forgot, sourceID)
+}
+
+func (d *forgettingDiarizer) forgotten() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Sorted(slices.Values(d.forgot))
+}
+
+func TestSessionManager_ForgetsDepartedSpeakers(t *testing.T) {
+	t.Parallel()
+
+	conn := resolvingConnection{
+		Connection: &audiomock.Connection{InputStreamsResult: map[string]<-chan audio.AudioFrame{
+			"1111": make(chan audio.AudioFrame),
+			"2222": make(chan audio.AudioFrame),
+		}},
+		users: map[string]string{"1111": "leaving-user", "2222": "staying-user"},
+	}
+	d := &forgettingDiarizer{}
+	sm := app.NewSessionManager(app.SessionManagerConfig{
+		Platform:     &audiomock.Platform{ConnectResult: conn},
+		Config:       &config.Config{},
+		Providers:    &app.Providers{VAD: &vadmock.Engine{}},
+		SessionStore: &memorymock.SessionStore{},
+		Diarizer:     d,
+	})
+
+	ctx := context.Background()
+	if err := sm.Start(ctx, "voice-channel-1", "dm-user-1"); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	conn.EmitEvent(audio.Event{Type: audio.EventLeave, UserID: "leaving-user"})
+	if got := d.forgotten(); !slices.Equal(got, []string{"1111"}) {
+		t.Errorf("forgotten after leave = %v, want [1111]", got)
+	}
+
+	// Stopping the session ends every stream.
+	if err := sm.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if got := d.forgotten(); !slices.Equal(got, []string{"1111", "1111", "2222"}) {
+		t.Errorf("forgotten after stop = %v, want [1111 1111 2222]", got)
+	}
+}
+
+func TestSessionManager_AttributeSpeaker(t *testing.T) {
+	t.Parallel()
+
+	segment := audio.AudioFrame{Data: []byte("b...")}
+	tests := []struct {
+		name     string
+		diarizer diarize.Diarizer
+		want     string
+	}{
+		{name: "default passthrough", want: "room-mic"},
+		{name: "diarizer", diarizer: fakeDiarizer{}, want: "room-mic/b"},
+		{name: "error falls back to stream", diarizer: fakeDiarizer{err: errors.New("boom")}, want: "room-mic"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			sm := app.NewSessionManager(app.SessionManagerConfig{
+				Platform:     &audiomock.Platform{ConnectResult: &audiomock.Connection{}},
+				Config:       &config.Config{},
+				Providers:    &app.Providers{},
+				SessionStore: &memorymock.SessionStore{},
+				Diarizer:     tc.diarizer,
+			})
+			if got := sm.AttributeSpeaker(context.Background(), "room-mic", segment); got != tc.want {
+				t.Errorf("AttributeSpeaker() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
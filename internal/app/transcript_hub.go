@@ -0,0 +1,174 @@
+package app
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// defaultSubscriberBuffer is the per-subscriber channel capacity used when
+// [WithSubscriberBuffer] is not supplied.
+const defaultSubscriberBuffer = 64
+
+// TranscriptEvent is a single transcript entry delivered to hub subscribers,
+// tagged with the session it belongs to.
+type TranscriptEvent struct {
+	// SessionID identifies the session that produced the entry.
+	SessionID string
+
+	// Source tells whether an NPC or a player spoke the line.
+	Source TranscriptSource
+
+	// Entry is the transcript entry itself.
+	Entry memory.TranscriptEntry
+}
+
+// TranscriptHub fans out transcript entries from all engines and players to
+// external subscribers such as WebSocket or SSE handlers serving live
+// captions. The [App] publishes the output of its [TranscriptMerger], so
+// subscribers receive the whole table's transcript in timestamp order.
+//
+// Each subscriber owns a bounded buffer. When a subscriber falls behind and
+// its buffer is full, the oldest pending event is dropped to make room for the
+// newest one, so a slow consumer never blocks publishers or other subscribers.
+//
+// All methods are safe for concurrent use.
+type TranscriptHub struct {
+	mu      sync.Mutex
+	subs    map[*TranscriptSubscription]struct{}
+	bufSize int
+	closed  bool
+}
+
+// TranscriptHubOption is a functional option for [NewTranscriptHub].
+type TranscriptHubOption func(*TranscriptHub)
+
+// WithSubscriberBuffer sets the per-subscriber buffer capacity. Values < 1
+// are ignored.
+func WithSubscriberBuffer(n int) TranscriptHubOption {
+	return func(h *TranscriptHub) {
+		if n > 0 {
+			h.bufSize = n
+		}
+	}
+}
+
+// NewTranscriptHub creates an empty [TranscriptHub].
+func NewTranscriptHub(opts ...TranscriptHubOption) *TranscriptHub {
+	h := &TranscriptHub{
+		subs:    make(map[*TranscriptSubscription]struct{}),
+		bufSize: defaultSubscriberBuffer,
+	}
+	for _, o := range opts {
+		o(h)
+	}
+	return h
+}
+
+// Subscribe registers a new subscriber. If sessionID is non-empty, only
+// entries published for that session are delivered; an empty sessionID
+// receives entries from every session.
+//
+// The caller must call [TranscriptSubscription.Close] when done. Subscribing
+// to a closed hub returns a subscription whose channel is already closed.
+func (h *TranscriptHub) Subscribe(sessionID string) *TranscriptSubscription {
+	sub := &TranscriptSubscription{
+		hub:       h,
+		sessionID: sessionID,
+		ch:        make(chan TranscriptEvent, h.bufSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.ch)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish delivers ev to every subscriber whose filter matches ev.SessionID.
+// It never blocks: full subscriber buffers drop their oldest event.
+func (h *TranscriptHub) Publish(ev TranscriptEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	for sub := range h.subs {
+		if sub.sessionID != "" && sub.sessionID != ev.SessionID {
+			continue
+		}
+		sub.deliver(ev)
+	}
+}
+
+// Subscribers returns the number of currently registered subscribers.
+func (h *TranscriptHub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Close unregisters all subscribers and closes their channels. Subsequent
+// calls to Publish are no-ops. Close is idempotent.
+func (h *TranscriptHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for sub := range h.subs {
+		close(sub.ch)
+		delete(h.subs, sub)
+	}
+}
+
+// TranscriptSubscription is a single consumer registered on a [TranscriptHub].
+type TranscriptSubscription struct {
+	hub       *TranscriptHub
+	sessionID string
+	ch        chan TranscriptEvent
+	dropped   atomic.Uint64
+}
+
+// Events returns the channel on which matching transcript events are
+// delivered. It is closed when the subscription or the hub is closed.
+func (s *TranscriptSubscription) Events() <-chan TranscriptEvent { return s.ch }
+
+// Dropped returns how many events were discarded because this subscriber's
+// buffer was full.
+func (s *TranscriptSubscription) Dropped() uint64 { return s.dropped.Load() }
+
+// Close unregisters the subscription and closes its event channel. Close is
+// idempotent.
+func (s *TranscriptSubscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.hub.subs[s]; !ok {
+		return
+	}
+	delete(s.hub.subs, s)
+	close(s.ch)
+}
+
+// deliver enqueues ev without blocking, evicting the oldest buffered event if
+// the buffer is full. The caller must hold the hub mutex.
+func (s *TranscriptSubscription) deliver(ev TranscriptEvent) {
+	for {
+		select {
+		case s.ch <- ev:
+			return
+		default:
+		}
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+			// The consumer drained the buffer concurrently; retry the send.
+		}
+	}
+}
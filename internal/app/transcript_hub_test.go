@@ -0,0 +1,150 @@
+package app_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/app"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// ─── TestTranscriptHub_FanOut ───────────────────────────────────────────────
+
+func TestTranscriptHub_FanOut(t *testing.T) {
+	t.Parallel()
+
+	hub := app.NewTranscriptHub()
+	defer hub.Close()
+
+	a := hub.Subscribe("")
+	b := hub.Subscribe("")
+	defer a.Close()
+	defer b.Close()
+
+	hub.Publish(app.TranscriptEvent{SessionID: "session-1", Entry: memory.TranscriptEntry{SpeakerName: "Grimjaw", Text: "Welcome!"}})
+
+	for name, sub := range map[string]*app.TranscriptSubscription{"a": a, "b": b} {
+		select {
+		case ev := <-sub.Events():
+			if ev.SessionID != "session-1" {
+				t.Errorf("%s: SessionID = %q, want %q", name, ev.SessionID, "session-1")
+			}
+			if ev.Entry.Text != "Welcome!" {
+				t.Errorf("%s: Text = %q, want %q", name, ev.Entry.Text, "Welcome!")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: did not receive published entry", name)
+		}
+	}
+}
+
+// ─── TestTranscriptHub_SessionFilter ────────────────────────────────────────
+
+func TestTranscriptHub_SessionFilter(t *testing.T) {
+	t.Parallel()
+
+	hub := app.NewTranscriptHub()
+	defer hub.Close()
+
+	sub := hub.Subscribe("session-2")
+	defer sub.Close()
+
+	hub.Publish(app.TranscriptEvent{SessionID: "session-1", Entry: memory.TranscriptEntry{Text: "ignored"}})
+	hub.Publish(app.TranscriptEvent{SessionID: "session-2", Entry: memory.TranscriptEntry{Text: "wanted"}})
+
+	select {
+	case ev := <-sub.Events():
+		if ev.Entry.Text != "wanted" {
+			t.Errorf("Text = %q, want %q", ev.Entry.Text, "wanted")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("did not receive entry for subscribed session")
+	}
+	select {
+	case ev := <-sub.Events():
+		t.Errorf("unexpected extra event: %+v", ev)
+	default:
+	}
+}
+
+// ─── TestTranscriptHub_SlowSubscriber ───────────────────────────────────────
+
+func TestTranscriptHub_SlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	const (
+		bufSize = 4
+		total   = 100
+	)
+
+	hub := app.NewTranscriptHub(app.WithSubscriberBuffer(bufSize))
+	defer hub.Close()
+
+	slow := hub.Subscribe("") // never read until publishing is done
+	fast := hub.Subscribe("")
+	defer slow.Close()
+
+	// The fast consumer acknowledges each entry so publishing proceeds in
+	// lock-step with it while the slow consumer's buffer overflows.
+	acks := make(chan string)
+	go func() {
+		for ev := range fast.Events() {
+			acks <- ev.Entry.Text
+		}
+	}()
+
+	for i := range total {
+		want := fmt.Sprintf("line %d", i)
+		hub.Publish(app.TranscriptEvent{SessionID: "s", Entry: memory.TranscriptEntry{Text: want}})
+		select {
+		case got := <-acks:
+			if got != want {
+				t.Fatalf("fast subscriber got %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("fast subscriber blocked at entry %d", i)
+		}
+	}
+
+	// The slow subscriber keeps only the newest bufSize entries.
+	if got, want := slow.Dropped(), uint64(total-bufSize); got != want {
+		t.Errorf("slow Dropped() = %d, want %d", got, want)
+	}
+	first := <-slow.Events()
+	if want := fmt.Sprintf("line %d", total-bufSize); first.Entry.Text != want {
+		t.Errorf("slow oldest buffered = %q, want %q", first.Entry.Text, want)
+	}
+}
+
+// ─── TestTranscriptHub_Close ────────────────────────────────────────────────
+
+func TestTranscriptHub_Close(t *testing.T) {
+	t.Parallel()
+
+	hub := app.NewTranscriptHub()
+	sub := hub.Subscribe("")
+	other := hub.Subscribe("")
+
+	sub.Close()
+	sub.Close() // idempotent
+	if got := hub.Subscribers(); got != 1 {
+		t.Errorf("Subscribers() = %d after one Close, want 1", got)
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Error("closed subscription channel should be closed")
+	}
+
+	hub.Close()
+	hub.Close() // idempotent
+	hub.Publish(app.TranscriptEvent{SessionID: "s", Entry: memory.TranscriptEntry{Text: "after close"}})
+	if _, ok := <-other.Events(); ok {
+		t.Error("hub Close should close remaining subscriber channels")
+	}
+	other.Close() // safe after hub Close
+
+	late := hub.Subscribe("")
+	if _, ok := <-late.Events(); ok {
+		t.Error("Subscribe on closed hub should return a closed channel")
+	}
+}
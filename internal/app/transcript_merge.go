@@ -0,0 +1,258 @@
+package app
+
+import (
+	"container/heap"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// DefaultReorderWindow is how long a [TranscriptMerger] holds back an entry,
+// measured from the entry's timestamp, when [WithReorderWindow] is not
+// supplied.
+const DefaultReorderWindow = 500 * time.Millisecond
+
+// TranscriptSource tells which side of the table a merged transcript line
+// came from.
+type TranscriptSource string
+
+const (
+	// SourceNPC marks a line spoken by an NPC, as reported by its engine.
+	SourceNPC TranscriptSource = "npc"
+
+	// SourcePlayer marks a line spoken by a player, as transcribed by STT.
+	SourcePlayer TranscriptSource = "player"
+)
+
+// MergedTranscriptEntry is a single line of the merged table transcript.
+type MergedTranscriptEntry struct {
+	// SessionID identifies the session the line was spoken in.
+	SessionID string
+
+	// Source tells whether an NPC or a player spoke the line.
+	Source TranscriptSource
+
+	// Entry is the transcript entry itself. Entry.SpeakerID identifies the
+	// individual speaker.
+	Entry memory.TranscriptEntry
+}
+
+// TranscriptMerger interleaves transcript entries from any number of sources —
+// every NPC engine and the players' STT — into one stream ordered by
+// [memory.TranscriptEntry.Timestamp].
+//
+// Sources report entries with some delay (STT finalises an utterance after
+// the player stopped speaking, engines after the NPC's turn), so entries
+// arrive slightly out of order. The merger holds every entry back until the
+// reorder window has passed since its timestamp and releases held entries in
+// timestamp order. An entry arriving after a later one was already released
+// is emitted as soon as possible rather than dropped.
+//
+// Like [TranscriptHub], the merger never blocks its producers: when the
+// consumer falls behind and the output buffer is full, the oldest pending
+// line is dropped.
+//
+// All methods are safe for concurrent use.
+type TranscriptMerger struct {
+	window  time.Duration
+	bufSize int
+
+	mu      sync.Mutex
+	pending mergeHeap
+	seq     uint64
+	closed  bool
+
+	wake    chan struct{}
+	done    chan struct{}
+	out     chan MergedTranscriptEntry
+	dropped atomic.Uint64
+}
+
+// TranscriptMergerOption is a functional option for [NewTranscriptMerger].
+type TranscriptMergerOption func(*TranscriptMerger)
+
+// WithReorderWindow sets how long entries are held back to be put in order.
+// Larger windows tolerate more source latency at the cost of a later stream.
+// Negative values are ignored; zero releases entries as soon as they arrive.
+func WithReorderWindow(d time.Duration) TranscriptMergerOption {
+	return func(m *TranscriptMerger) {
+		if d >= 0 {
+			m.window = d
+		}
+	}
+}
+
+// WithMergeBuffer sets the capacity of the output channel. Values < 1 are
+// ignored.
+func WithMergeBuffer(n int) TranscriptMergerOption {
+	return func(m *TranscriptMerger) {
+		if n > 0 {
+			m.bufSize = n
+		}
+	}
+}
+
+// NewTranscriptMerger creates a [TranscriptMerger] and starts its release
+// loop. Call [TranscriptMerger.Close] to stop it.
+func NewTranscriptMerger(opts ...TranscriptMergerOption) *TranscriptMerger {
+	m := &TranscriptMerger{
+		window:  DefaultReorderWindow,
+		bufSize: defaultSubscriberBuffer,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	m.out = make(chan MergedTranscriptEntry, m.bufSize)
+	go m.run()
+	return m
+}
+
+// Add queues entry, spoken in sessionID and coming from source, for ordered
+// delivery. It never blocks. Adding to a closed merger is a no-op.
+func (m *TranscriptMerger) Add(sessionID string, source TranscriptSource, entry memory.TranscriptEntry) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.seq++
+	heap.Push(&m.pending, mergeItem{
+		entry: MergedTranscriptEntry{SessionID: sessionID, Source: source, Entry: entry},
+		seq:   m.seq,
+	})
+	m.mu.Unlock()
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Entries returns the merged, timestamp-ordered stream. It is closed once
+// [TranscriptMerger.Close] has flushed all pending entries.
+func (m *TranscriptMerger) Entries() <-chan MergedTranscriptEntry { return m.out }
+
+// Dropped returns how many entries were discarded because the output buffer
+// was full.
+func (m *TranscriptMerger) Dropped() uint64 { return m.dropped.Load() }
+
+// Close stops accepting entries, releases everything still held back in
+// timestamp order, and closes the [TranscriptMerger.Entries] channel. Close is
+// idempotent.
+func (m *TranscriptMerger) Close() {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.closed = true
+	m.mu.Unlock()
+	close(m.done)
+}
+
+// run releases entries whose reorder window has passed until the merger is
+// closed.
+func (m *TranscriptMerger) run() {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		ready, next := m.release(time.Now())
+		for _, e := range ready {
+			m.deliver(e)
+		}
+		if next > 0 {
+			timer.Reset(next)
+		}
+
+		select {
+		case <-m.done:
+			m.mu.Lock()
+			for m.pending.Len() > 0 {
+				m.deliver(heap.Pop(&m.pending).(mergeItem).entry)
+			}
+			m.mu.Unlock()
+			close(m.out)
+			return
+		case <-m.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// release pops every pending entry whose window has passed at now, in order,
+// and returns them with the delay until the next entry is due (zero if none
+// is pending).
+func (m *TranscriptMerger) release(now time.Time) ([]MergedTranscriptEntry, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ready []MergedTranscriptEntry
+	for m.pending.Len() > 0 {
+		due := m.pending[0].entry.Entry.Timestamp.Add(m.window)
+		if wait := due.Sub(now); wait > 0 {
+			return ready, wait
+		}
+		ready = append(ready, heap.Pop(&m.pending).(mergeItem).entry)
+	}
+	return ready, 0
+}
+
+// deliver enqueues e without blocking, evicting the oldest buffered entry if
+// the output buffer is full. Only the run loop sends on m.out.
+func (m *TranscriptMerger) deliver(e MergedTranscriptEntry) {
+	for {
+		select {
+		case m.out <- e:
+			return
+		default:
+		}
+		select {
+		case <-m.out:
+			m.dropped.Add(1)
+		default:
+			// The consumer drained the buffer concurrently; retry the send.
+		}
+	}
+}
+
+// mergeItem is a pending entry with its arrival sequence number, which keeps
+// entries with equal timestamps in arrival order.
+type mergeItem struct {
+	entry MergedTranscriptEntry
+	seq   uint64
+}
+
+// mergeHeap implements [container/heap.Interface] as a min-heap ordered by
+// entry timestamp, then arrival.
+type mergeHeap []mergeItem
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	ti, tj := h[i].entry.Entry.Timestamp, h[j].entry.Entry.Timestamp
+	if !ti.Equal(tj) {
+		return ti.Before(tj)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+// Push appends x to the heap. Called by [container/heap.Push].
+func (h *mergeHeap) Push(x any) { *h = append(*h, x.(mergeItem)) }
+
+// Pop removes and returns the last element. Called by [container/heap.Pop].
+func (h *mergeHeap) Pop() any {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	*h = old[:n-1]
+	return it
+}
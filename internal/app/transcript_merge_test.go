@@ -0,0 +1,144 @@
+package app_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/app"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// collectMerged reads n entries from m, failing the test after timeout.
+func collectMerged(t *testing.T, m *app.TranscriptMerger, n int, timeout time.Duration) []app.MergedTranscriptEntry {
+	t.Helper()
+	var got []app.MergedTranscriptEntry
+	deadline := time.After(timeout)
+	for len(got) < n {
+		select {
+		case e, ok := <-m.Entries():
+			if !ok {
+				t.Fatalf("Entries closed after %d of %d entries", len(got), n)
+			}
+			got = append(got, e)
+		case <-deadline:
+			t.Fatalf("received %d of %d entries before timeout", len(got), n)
+		}
+	}
+	return got
+}
+
+// ─── TestTranscriptMerger_OrdersWithinWindow ───────────────────────────────
+
+func TestTranscriptMerger_OrdersWithinWindow(t *testing.T) {
+	t.Parallel()
+
+	m := app.NewTranscriptMerger(app.WithReorderWindow(200 * time.Millisecond))
+	defer m.Close()
+
+	base := time.Now()
+	at := func(ms int) time.Time { return base.Add(time.Duration(ms) * time.Millisecond) }
+
+	// Each source reports its own lines in order, but the sources lag each
+	// other, so the combined arrival order is scrambled.
+	npc := []memory.TranscriptEntry{
+		{SpeakerID: "grimjaw", NPCID: "grimjaw", Text: "What'll it be?", Timestamp: at(10)},
+		{SpeakerID: "grimjaw", NPCID: "grimjaw", Text: "Coming right up.", Timestamp: at(40)},
+	}
+	player := []memory.TranscriptEntry{
+		{SpeakerID: "alice", Text: "Evening!", Timestamp: at(0)},
+		{SpeakerID: "bob", Text: "An ale, please.", Timestamp: at(20)},
+		{SpeakerID: "alice", Text: "Make it two.", Timestamp: at(30)},
+	}
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for _, e := range npc {
+			m.Add("session-1", app.SourceNPC, e)
+		}
+	})
+	wg.Go(func() {
+		for _, e := range player {
+			m.Add("session-1", app.SourcePlayer, e)
+		}
+	})
+	wg.Wait()
+
+	got := collectMerged(t, m, 5, 2*time.Second)
+
+	want := []struct {
+		source  app.TranscriptSource
+		speaker string
+	}{
+		{app.SourcePlayer, "alice"},
+		{app.SourceNPC, "grimjaw"},
+		{app.SourcePlayer, "bob"},
+		{app.SourcePlayer, "alice"},
+		{app.SourceNPC, "grimjaw"},
+	}
+	for i, w := range want {
+		if got[i].SessionID != "session-1" {
+			t.Errorf("entry %d SessionID = %q, want %q", i, got[i].SessionID, "session-1")
+		}
+		if got[i].Source != w.source || got[i].Entry.SpeakerID != w.speaker {
+			t.Errorf("entry %d = (%s, %s %q), want (%s, %s)",
+				i, got[i].Source, got[i].Entry.SpeakerID, got[i].Entry.Text, w.source, w.speaker)
+		}
+		if i > 0 && got[i].Entry.Timestamp.Before(got[i-1].Entry.Timestamp) {
+			t.Errorf("entry %d (%q) is out of order", i, got[i].Entry.Text)
+		}
+	}
+}
+
+// ─── TestTranscriptMerger_LateEntry ─────────────────────────────────────────
+
+func TestTranscriptMerger_LateEntry(t *testing.T) {
+	t.Parallel()
+
+	m := app.NewTranscriptMerger(app.WithReorderWindow(10 * time.Millisecond))
+	defer m.Close()
+
+	now := time.Now()
+	m.Add("session-1", app.SourceNPC, memory.TranscriptEntry{Text: "first", Timestamp: now})
+	collectMerged(t, m, 1, time.Second)
+
+	// Older than the window allows for: still delivered, not dropped.
+	m.Add("session-1", app.SourcePlayer, memory.TranscriptEntry{Text: "late", Timestamp: now.Add(-time.Second)})
+	got := collectMerged(t, m, 1, time.Second)
+	if got[0].Entry.Text != "late" {
+		t.Errorf("Text = %q, want %q", got[0].Entry.Text, "late")
+	}
+}
+
+// ─── TestTranscriptMerger_Close ─────────────────────────────────────────────
+
+func TestTranscriptMerger_Close(t *testing.T) {
+	t.Parallel()
+
+	m := app.NewTranscriptMerger(app.WithReorderWindow(time.Hour))
+
+	now := time.Now()
+	m.Add("session-1", app.SourcePlayer, memory.TranscriptEntry{Text: "b", Timestamp: now.Add(time.Second)})
+	m.Add("session-1", app.SourceNPC, memory.TranscriptEntry{Text: "a", Timestamp: now})
+	m.Close()
+	m.Close() // idempotent
+	m.Add("session-1", app.SourceNPC, memory.TranscriptEntry{Text: "ignored", Timestamp: now})
+
+	var texts []string
+	timeout := time.After(time.Second)
+	for done := false; !done; {
+		select {
+		case e, ok := <-m.Entries():
+			if !ok {
+				done = true
+				break
+			}
+			texts = append(texts, e.Entry.Text)
+		case <-timeout:
+			t.Fatal("Entries not closed after Close")
+		}
+	}
+	if len(texts) != 2 || texts[0] != "a" || texts[1] != "b" {
+		t.Errorf("flushed entries = %q, want [a b]", texts)
+	}
+}
@@ -0,0 +1,235 @@
+package app
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/agent"
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/mcp"
+)
+
+// defaultTurnBuffer is the number of events a [TurnBus] queues when
+// [WithTurnBuffer] is not supplied.
+const defaultTurnBuffer = 64
+
+// TurnCompleted is published on the [TurnBus] after an NPC finished replying
+// to a player: the reply text has been generated and its audio has finished
+// streaming.
+type TurnCompleted struct {
+	// SessionID identifies the session the turn belongs to.
+	SessionID string
+
+	// NPCID and NPCName identify the NPC that replied.
+	NPCID   string
+	NPCName string
+
+	// Speaker is the player the NPC replied to, and Input what they said.
+	Speaker string
+	Input   string
+
+	// Text is the NPC's reply.
+	Text string
+
+	// Duration is the time from receiving the player's utterance until the
+	// reply finished streaming.
+	Duration time.Duration
+
+	// Latency breaks down how long generating the reply took.
+	Latency engine.Latency
+
+	// ToolCalls lists the names of the tools the NPC called, in call order.
+	ToolCalls []string
+
+	// Cost describes what the turn consumed.
+	Cost TurnCost
+
+	// Err is the error that cut the reply audio short, or nil.
+	Err error
+}
+
+// TurnCost describes the cost drivers of a turn.
+type TurnCost struct {
+	// StrongModel reports whether the turn was escalated from the fast to the
+	// strong model.
+	StrongModel bool
+
+	// BudgetTier is the tool budget the NPC answered with.
+	BudgetTier mcp.BudgetTier
+
+	// StockLine reports whether the reply was one of the NPC's stock
+	// responses, spoken without calling a model.
+	StockLine bool
+}
+
+// TurnHandler receives [TurnCompleted] events. See [TurnBus.Subscribe].
+type TurnHandler func(TurnCompleted)
+
+// TurnBus delivers a [TurnCompleted] event for every NPC turn to registered
+// handlers, so integrations such as VTT loggers or animation triggers can react
+// to NPC replies.
+//
+// Publishing never blocks: events are queued in a bounded buffer, and when
+// the handlers fall behind and the buffer is full, the oldest queued event is
+// dropped. A single goroutine calls the handlers one after another in
+// registration order, so a slow handler delays the others but never the
+// voice pipeline. A handler that panics is logged and skipped.
+//
+// All methods are safe for concurrent use.
+type TurnBus struct {
+	queue   chan TurnCompleted
+	dropped atomic.Uint64
+	done    chan struct{}
+
+	mu       sync.Mutex
+	handlers []*turnSubscription
+	closed   bool
+}
+
+// turnSubscription is a registered handler. Its identity is its address.
+type turnSubscription struct {
+	handler TurnHandler
+}
+
+// TurnBusOption is a functional option for [NewTurnBus].
+type TurnBusOption func(*turnBusConfig)
+
+// turnBusConfig holds the settings applied by [TurnBusOption] values.
+type turnBusConfig struct {
+	bufSize int
+}
+
+// WithTurnBuffer sets how many events the bus queues for its handlers.
+// Values < 1 are ignored.
+func WithTurnBuffer(n int) TurnBusOption {
+	return func(c *turnBusConfig) {
+		if n > 0 {
+			c.bufSize = n
+		}
+	}
+}
+
+// NewTurnBus creates a [TurnBus] and starts its dispatch goroutine. Call
+// [TurnBus.Close] to stop it.
+func NewTurnBus(opts ...TurnBusOption) *TurnBus {
+	cfg := turnBusConfig{bufSize: defaultTurnBuffer}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	b := &TurnBus{
+		queue: make(chan TurnCompleted, cfg.bufSize),
+		done:  make(chan struct{}),
+	}
+	go b.dispatch()
+	return b
+}
+
+// Subscribe registers h to receive every event published after it returns.
+// The returned function unregisters h; it is idempotent. Events already being
+// dispatched may still reach h after it was unregistered.
+func (b *TurnBus) Subscribe(h TurnHandler) (unsubscribe func()) {
+	sub := &turnSubscription{handler: h}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return func() {}
+	}
+	b.handlers = append(b.handlers, sub)
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.handlers = slices.DeleteFunc(b.handlers, func(s *turnSubscription) bool { return s == sub })
+	}
+}
+
+// Publish queues ev for delivery to all handlers. It never blocks: when the
+// queue is full, the oldest queued event is dropped. Publishing to a closed
+// bus is a no-op.
+func (b *TurnBus) Publish(ev TurnCompleted) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	for {
+		select {
+		case b.queue <- ev:
+			return
+		default:
+		}
+		select {
+		case <-b.queue:
+			b.dropped.Add(1)
+		default:
+			// The dispatcher drained the queue concurrently; retry the send.
+		}
+	}
+}
+
+// Dropped returns how many events were discarded because the queue was full.
+func (b *TurnBus) Dropped() uint64 { return b.dropped.Load() }
+
+// Close stops accepting events, delivers the events still queued and waits
+// for the dispatch goroutine to exit. Close is idempotent.
+func (b *TurnBus) Close() {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+	<-b.done
+}
+
+// dispatch calls the handlers for each queued event until the queue is
+// closed and drained.
+func (b *TurnBus) dispatch() {
+	defer close(b.done)
+	for ev := range b.queue {
+		b.mu.Lock()
+		handlers := slices.Clone(b.handlers)
+		b.mu.Unlock()
+
+		for _, sub := range handlers {
+			b.deliver(sub.handler, ev)
+		}
+	}
+}
+
+// deliver calls h with ev, recovering from a panic in h.
+func (b *TurnBus) deliver(h TurnHandler, ev TurnCompleted) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("turn bus: handler panicked", "npc", ev.NPCID, "panic", r)
+		}
+	}()
+	h(ev)
+}
+
+// turnPublisher returns an [agent.TurnObserver] that publishes the turns of
+// session sessionID on b.
+func turnPublisher(b *TurnBus, sessionID string) agent.TurnObserver {
+	return func(t agent.TurnInfo) {
+		b.Publish(TurnCompleted{
+			SessionID: sessionID,
+			NPCID:     t.NPCID,
+			NPCName:   t.NPCName,
+			Speaker:   t.Speaker,
+			Input:     t.Input,
+			Text:      t.Text,
+			Duration:  t.Duration,
+			Latency:   t.Latency,
+			ToolCalls: t.ToolCalls,
+			Cost: TurnCost{
+				StrongModel: t.UsedStrongModel,
+				BudgetTier:  t.BudgetTier,
+				StockLine:   t.StockLine,
+			},
+			Err: t.Err,
+		})
+	}
+}
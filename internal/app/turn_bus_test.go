@@ -0,0 +1,158 @@
+package app_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/app"
+)
+
+// recvTurn waits for an event on ch or fails the test after a timeout.
+func recvTurn(t *testing.T, ch <-chan app.TurnCompleted) app.TurnCompleted {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for TurnCompleted")
+		return app.TurnCompleted{}
+	}
+}
+
+// ─── TestTurnBus_Subscribe ──────────────────────────────────────────────────
+
+func TestTurnBus_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	bus := app.NewTurnBus()
+	defer bus.Close()
+
+	a := make(chan app.TurnCompleted, 4)
+	b := make(chan app.TurnCompleted, 4)
+	bus.Subscribe(func(ev app.TurnCompleted) { a <- ev })
+	unsubscribe := bus.Subscribe(func(ev app.TurnCompleted) { b <- ev })
+
+	bus.Publish(app.TurnCompleted{NPCID: "npc-1", Text: "Well met."})
+	if got := recvTurn(t, a); got.NPCID != "npc-1" || got.Text != "Well met." {
+		t.Errorf("handler a: got %+v", got)
+	}
+	if got := recvTurn(t, b); got.NPCID != "npc-1" {
+		t.Errorf("handler b: got %+v", got)
+	}
+
+	unsubscribe()
+	unsubscribe() // idempotent
+	bus.Publish(app.TurnCompleted{NPCID: "npc-2"})
+	if got := recvTurn(t, a); got.NPCID != "npc-2" {
+		t.Errorf("handler a after unsubscribe of b: got %+v", got)
+	}
+
+	bus.Close()
+	if len(b) != 0 {
+		t.Errorf("unsubscribed handler received %d events", len(b))
+	}
+}
+
+// ─── TestTurnBus_SlowHandler ────────────────────────────────────────────────
+
+func TestTurnBus_SlowHandler(t *testing.T) {
+	t.Parallel()
+
+	const buf = 3
+	bus := app.NewTurnBus(app.WithTurnBuffer(buf))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var once sync.Once
+	var mu sync.Mutex
+	var got []string
+	bus.Subscribe(func(ev app.TurnCompleted) {
+		once.Do(func() { close(started) })
+		<-release
+		mu.Lock()
+		got = append(got, ev.NPCID)
+		mu.Unlock()
+	})
+
+	// The first event occupies the handler; the next ones fill the queue.
+	bus.Publish(app.TurnCompleted{NPCID: "npc-0"})
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		for i := 1; i <= buf+2; i++ {
+			bus.Publish(app.TurnCompleted{NPCID: fmt.Sprintf("npc-%d", i)})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a slow handler")
+	}
+
+	if d := bus.Dropped(); d != 2 {
+		t.Errorf("Dropped() = %d, want 2", d)
+	}
+
+	close(release)
+	bus.Close()
+
+	want := []string{"npc-0", "npc-3", "npc-4", "npc-5"}
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("delivered %v, want %v (oldest queued events dropped)", got, want)
+	}
+}
+
+// ─── TestTurnBus_HandlerPanic ───────────────────────────────────────────────
+
+func TestTurnBus_HandlerPanic(t *testing.T) {
+	t.Parallel()
+
+	bus := app.NewTurnBus()
+	defer bus.Close()
+
+	ch := make(chan app.TurnCompleted, 2)
+	bus.Subscribe(func(app.TurnCompleted) { panic("plugin bug") })
+	bus.Subscribe(func(ev app.TurnCompleted) { ch <- ev })
+
+	bus.Publish(app.TurnCompleted{NPCID: "npc-1"})
+	bus.Publish(app.TurnCompleted{NPCID: "npc-2"})
+
+	if got := recvTurn(t, ch); got.NPCID != "npc-1" {
+		t.Errorf("first event: got %+v", got)
+	}
+	if got := recvTurn(t, ch); got.NPCID != "npc-2" {
+		t.Errorf("second event: got %+v", got)
+	}
+}
+
+// ─── TestTurnBus_Close ──────────────────────────────────────────────────────
+
+func TestTurnBus_Close(t *testing.T) {
+	t.Parallel()
+
+	bus := app.NewTurnBus()
+	ch := make(chan app.TurnCompleted, 4)
+	bus.Subscribe(func(ev app.TurnCompleted) { ch <- ev })
+
+	bus.Publish(app.TurnCompleted{NPCID: "npc-1"})
+	bus.Close()
+	bus.Close() // idempotent
+
+	// Events queued before Close are still delivered.
+	if len(ch) != 1 {
+		t.Fatalf("want 1 delivered event after Close, got %d", len(ch))
+	}
+
+	// Publishing and subscribing after Close are no-ops.
+	bus.Publish(app.TurnCompleted{NPCID: "npc-2"})
+	bus.Subscribe(func(app.TurnCompleted) { t.Error("handler subscribed after Close was called") })()
+	if len(ch) != 1 {
+		t.Errorf("event published after Close was delivered")
+	}
+}
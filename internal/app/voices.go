@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/MrWong99/glyphoxa/internal/config"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// assignVoices returns a copy of npcs in which every cascaded NPC configured
+// without a voice ID has one, picked from the voices of ttsP by hashing its
+// name (see [tts.AssignVoice]). The same NPC therefore keeps its voice across
+// sessions and restarts without any configuration.
+//
+// The pool is cfg.Voices or, if empty, every voice ttsP lists. Voices
+// configured explicitly for other NPCs are left out of the pool unless that
+// empties it, so ambient NPCs do not borrow the voices of the main cast, and
+// voices known to speak another language than the NPC are skipped the same
+// way. Speech-to-speech NPCs are left alone: their voices come from the S2S
+// provider, not ttsP. When assignment is disabled, no TTS provider is
+// configured, or its voices cannot be listed, npcs is returned unchanged.
+func assignVoices(ctx context.Context, ttsP tts.Provider, npcs []config.NPCConfig, cfg config.VoiceAssignmentConfig) []config.NPCConfig {
+	if cfg.Disabled || ttsP == nil {
+		return npcs
+	}
+	unvoiced := func(npc config.NPCConfig) bool {
+		return npc.Voice.VoiceID == "" && npc.Engine != config.EngineS2S
+	}
+	if !slices.ContainsFunc(npcs, unvoiced) {
+		return npcs
+	}
+
+	var pool []tts.VoiceProfile
+	if len(cfg.Voices) > 0 {
+		for _, id := range cfg.Voices {
+			pool = append(pool, tts.VoiceProfile{ID: id})
+		}
+	} else {
+		voices, err := ttsP.ListVoices(ctx)
+		if err != nil {
+			slog.Warn("voice assignment: list voices failed, NPCs without a voice use the provider default", "err", err)
+			return npcs
+		}
+		pool = voices
+	}
+
+	free := slices.DeleteFunc(slices.Clone(pool), func(v tts.VoiceProfile) bool {
+		return slices.ContainsFunc(npcs, func(npc config.NPCConfig) bool { return npc.Voice.VoiceID == v.ID })
+	})
+	if len(free) == 0 {
+		free = pool
+	}
+
+	out := slices.Clone(npcs)
+	for i, npc := range out {
+		if !unvoiced(npc) {
+			continue
+		}
+		candidates := tts.VoicesForLanguage(free, npc.Voice.Language)
+		if len(candidates) == 0 {
+			candidates = free
+		}
+		v, ok := tts.AssignVoice(cfg.Seed, npc.Name, candidates)
+		if !ok {
+			continue
+		}
+		out[i].Voice.VoiceID = v.ID
+		slog.Info("voice assignment: assigned voice", "npc", npc.Name, "voice", v.ID)
+	}
+	return out
+}
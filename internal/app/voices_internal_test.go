@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/internal/config"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	ttsmock "github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+)
+
+// voicePool returns a TTS mock listing n voices with IDs voice-0 … voice-(n-1).
+func voicePool(n int) *ttsmock.Provider {
+	p := &ttsmock.Provider{}
+	for i := range n {
+		p.ListVoicesResult = append(p.ListVoicesResult, tts.VoiceProfile{ID: fmt.Sprintf("voice-%d", i)})
+	}
+	return p
+}
+
+// voiceIDs returns the voice ID of every NPC in npcs, keyed by name.
+func voiceIDs(npcs []config.NPCConfig) map[string]string {
+	ids := make(map[string]string, len(npcs))
+	for _, npc := range npcs {
+		ids[npc.Name] = npc.Voice.VoiceID
+	}
+	return ids
+}
+
+func TestAssignVoices_Deterministic(t *testing.T) {
+	t.Parallel()
+
+	npcs := []config.NPCConfig{
+		{Name: "Barkeep", Engine: config.EngineCascaded},
+		{Name: "Fishmonger", Engine: config.EngineSentenceCascade},
+	}
+	first := voiceIDs(assignVoices(context.Background(), voicePool(8), npcs, config.VoiceAssignmentConfig{}))
+	if first["Barkeep"] == "" || first["Fishmonger"] == "" {
+		t.Fatalf("voices not assigned: %v", first)
+	}
+	if first["Barkeep"] == first["Fishmonger"] {
+		t.Errorf("Barkeep and Fishmonger share voice %q", first["Barkeep"])
+	}
+
+	// The same NPC keeps its voice, also next to other NPCs.
+	again := voiceIDs(assignVoices(context.Background(), voicePool(8), npcs[:1], config.VoiceAssignmentConfig{}))
+	if again["Barkeep"] != first["Barkeep"] {
+		t.Errorf("Barkeep got %q, then %q", first["Barkeep"], again["Barkeep"])
+	}
+
+	// The caller's slice is not modified.
+	if npcs[0].Voice.VoiceID != "" {
+		t.Errorf("input modified: %+v", npcs[0].Voice)
+	}
+}
+
+func TestAssignVoices(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		npcs  []config.NPCConfig
+		cfg   config.VoiceAssignmentConfig
+		tts   *ttsmock.Provider
+		want  map[string]string
+		lists int // expected ListVoices calls
+	}{
+		{
+			name: "configured voice kept and not reused",
+			npcs: []config.NPCConfig{
+				{Name: "Greymantle", Engine: config.EngineCascaded, Voice: config.VoiceConfig{VoiceID: "voice-0"}},
+				{Name: "Barkeep", Engine: config.EngineCascaded},
+			},
+			tts:   voicePool(2),
+			want:  map[string]string{"Greymantle": "voice-0", "Barkeep": "voice-1"},
+			lists: 1,
+		},
+		{
+			name: "pool exhausted by configured voices",
+			npcs: []config.NPCConfig{
+				{Name: "Greymantle", Engine: config.EngineCascaded, Voice: config.VoiceConfig{VoiceID: "voice-0"}},
+				{Name: "Barkeep", Engine: config.EngineCascaded},
+			},
+			tts:   voicePool(1),
+			want:  map[string]string{"Greymantle": "voice-0", "Barkeep": "voice-0"},
+			lists: 1,
+		},
+		{
+			name: "language",
+			npcs: []config.NPCConfig{
+				{Name: "Barkeep", Engine: config.EngineCascaded, Voice: config.VoiceConfig{Language: "de-DE"}},
+			},
+			tts: &ttsmock.Provider{ListVoicesResult: []tts.VoiceProfile{
+				{ID: "english-1", Language: "en-US"},
+				{ID: "german", Language: "de"},
+				{ID: "english-2", Language: "en-GB"},
+			}},
+			want:  map[string]string{"Barkeep": "german"},
+			lists: 1,
+		},
+		{
+			name:  "configured pool",
+			npcs:  []config.NPCConfig{{Name: "Barkeep", Engine: config.EngineCascaded}},
+			cfg:   config.VoiceAssignmentConfig{Voices: []string{"pooled"}},
+			tts:   voicePool(4),
+			want:  map[string]string{"Barkeep": "pooled"},
+			lists: 0,
+		},
+		{
+			name:  "s2s untouched",
+			npcs:  []config.NPCConfig{{Name: "Barkeep", Engine: config.EngineS2S}},
+			tts:   voicePool(4),
+			want:  map[string]string{"Barkeep": ""},
+			lists: 0,
+		},
+		{
+			name:  "disabled",
+			npcs:  []config.NPCConfig{{Name: "Barkeep", Engine: config.EngineCascaded}},
+			cfg:   config.VoiceAssignmentConfig{Disabled: true},
+			tts:   voicePool(4),
+			want:  map[string]string{"Barkeep": ""},
+			lists: 0,
+		},
+		{
+			name:  "list voices fails",
+			npcs:  []config.NPCConfig{{Name: "Barkeep", Engine: config.EngineCascaded}},
+			tts:   &ttsmock.Provider{ListVoicesErr: errors.New("unreachable")},
+			want:  map[string]string{"Barkeep": ""},
+			lists: 1,
+		},
+		{
+			name:  "no voices",
+			npcs:  []config.NPCConfig{{Name: "Barkeep", Engine: config.EngineCascaded}},
+			tts:   &ttsmock.Provider{},
+			want:  map[string]string{"Barkeep": ""},
+			lists: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := voiceIDs(assignVoices(context.Background(), tt.tts, tt.npcs, tt.cfg))
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("%s: voice %q, want %q", name, got[name], want)
+				}
+			}
+			if n := len(tt.tts.ListVoicesCalls); n != tt.lists {
+				t.Errorf("ListVoices called %d times, want %d", n, tt.lists)
+			}
+		})
+	}
+}
@@ -2,7 +2,12 @@
 // for the Glyphoxa voice AI system.
 package config
 
-import "github.com/MrWong99/glyphoxa/internal/mcp"
+import (
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/mcp"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
 
 // LogLevel controls log verbosity for the Glyphoxa server.
 type LogLevel string
@@ -33,8 +38,8 @@ const (
 	// EngineS2S uses an end-to-end speech model.
 	EngineS2S Engine = "s2s"
 
-	// EngineSentenceCascade uses the experimental dual-model sentence cascade
-	// (fast opener + strong continuation). See docs/design/05-sentence-cascade.md.
+	// EngineSentenceCascade uses a single strong LLM whose reply is
+	// synthesised sentence by sentence while it is still being generated.
 	EngineSentenceCascade Engine = "sentence_cascade"
 )
 
@@ -43,7 +48,12 @@ func (e Engine) IsValid() bool {
 	return e == EngineCascaded || e == EngineS2S || e == EngineSentenceCascade
 }
 
-// CascadeMode controls the behaviour of the sentence cascade engine.
+// CascadeMode was meant to switch the dual-model cascade on per NPC.
+//
+// Deprecated: no engine reads it. The dual-model cascade is selected with
+// [EngineCascaded] and the single-model sentence cascade with
+// [EngineSentenceCascade]. The type is kept so existing configuration files
+// still load.
 type CascadeMode string
 
 const (
@@ -85,6 +95,104 @@ func (b BudgetTier) IsValid() bool {
 	return false
 }
 
+// TTSLanguageFallback selects what happens when the TTS provider does not
+// support the language a line should be spoken in.
+type TTSLanguageFallback string
+
+const (
+	// TTSLanguageFallbackDefault speaks the line in the provider's default
+	// language (default).
+	TTSLanguageFallbackDefault TTSLanguageFallback = "default"
+
+	// TTSLanguageFallbackProvider switches to the first provider in the TTS
+	// fallback chain that supports the language, and uses the default
+	// language only when none does.
+	TTSLanguageFallbackProvider TTSLanguageFallback = "provider"
+)
+
+// IsValid reports whether f is a recognised language fallback policy.
+func (f TTSLanguageFallback) IsValid() bool {
+	switch f {
+	case TTSLanguageFallbackDefault, TTSLanguageFallbackProvider, "":
+		return true
+	}
+	return false
+}
+
+// OutputPreempt selects what happens to an NPC reply that arrives while
+// another NPC is speaking. See [OutputConfig.Preempt].
+type OutputPreempt string
+
+const (
+	// OutputPreemptQueue plays the reply once the speaking NPC has finished
+	// (default).
+	OutputPreemptQueue OutputPreempt = "queue"
+
+	// OutputPreemptNewest interrupts the speaking NPC and drops the waiting
+	// replies of other NPCs: the most recently addressed NPC wins.
+	OutputPreemptNewest OutputPreempt = "newest"
+)
+
+// IsValid reports whether p is a recognised preemption policy.
+func (p OutputPreempt) IsValid() bool {
+	switch p {
+	case OutputPreemptQueue, OutputPreemptNewest, "":
+		return true
+	}
+	return false
+}
+
+// TTSErrorAudioEarcon is the [ProvidersConfig.TTSErrorAudio] value that
+// selects the built-in error chime instead of an audio file.
+const TTSErrorAudioEarcon = "earcon"
+
+// SafetyFilter selects the content-safety filter NPC speech is checked with.
+type SafetyFilter string
+
+const (
+	// SafetyFilterNone disables content filtering (default).
+	SafetyFilterNone SafetyFilter = "none"
+
+	// SafetyFilterWordlist flags speech containing a word from
+	// [SafetyConfig.Words].
+	SafetyFilterWordlist SafetyFilter = "wordlist"
+)
+
+// IsValid reports whether f is a recognised safety filter.
+func (f SafetyFilter) IsValid() bool {
+	switch f {
+	case SafetyFilterNone, SafetyFilterWordlist, "":
+		return true
+	}
+	return false
+}
+
+// SafetyPolicy decides what happens to NPC speech flagged by the safety
+// filter.
+type SafetyPolicy string
+
+const (
+	// SafetyPolicyBlock withholds the flagged sentence and ends the reply
+	// (default).
+	SafetyPolicyBlock SafetyPolicy = "block"
+
+	// SafetyPolicyRedact speaks the sentence with the flagged words removed.
+	SafetyPolicyRedact SafetyPolicy = "redact"
+
+	// SafetyPolicyRegenerate asks the LLM once more for a reply without the
+	// flagged content, and blocks it if it is flagged again.
+	SafetyPolicyRegenerate SafetyPolicy = "regenerate"
+)
+
+// IsValid reports whether p is a recognised safety policy.
+func (p SafetyPolicy) IsValid() bool {
+	switch p {
+	case SafetyPolicyBlock, SafetyPolicyRedact, SafetyPolicyRegenerate, "":
+		return true
+	}
+	return false
+}
+
 // Config is the root configuration structure for Glyphoxa.
 // It is typically loaded from a YAML file using [Load] or [LoadFromReader].
 type Config struct {
@@ -95,6 +203,128 @@ type Config struct {
 	Memory    MemoryConfig    `yaml:"memory"`
 	MCP       MCPConfig       `yaml:"mcp"`
 	Campaign  CampaignConfig  `yaml:"campaign"`
+	BargeIn   BargeInConfig   `yaml:"barge_in"`
+	Output    OutputConfig    `yaml:"output"`
+	VAD       VADConfig       `yaml:"vad"`
+	Safety    SafetyConfig    `yaml:"safety"`
+
+	VoiceAssignment VoiceAssignmentConfig `yaml:"voice_assignment"`
+	STTGate         STTGateConfig         `yaml:"stt_gate"`
+}
+
+// STTGateConfig controls which STT transcripts NPCs answer. Background noise
+// often comes back from STT as an empty or low-confidence transcript; such
+// turns are skipped instead of prompting the LLM with noise. Transcripts
+// without any letters or digits are always skipped unless the gate is
+// disabled.
+type STTGateConfig struct {
+	// Disabled answers every transcript, even an empty one.
+	Disabled bool `yaml:"disabled"`
+
+	// MinConfidence is the lowest STT confidence, between 0 and 1, a
+	// transcript needs to be answered. Zero disables the confidence check.
+	// Transcripts from providers that report no confidence always pass it.
+	MinConfidence float64 `yaml:"min_confidence"`
+
+	// Reply is played when a turn is skipped: empty stays silent,
+	// [STTGateReplyEarcon] plays a short questioning chime, and any other
+	// text is spoken by the addressed NPC, such as "Sorry, I didn't catch
+	// that."
+	Reply string `yaml:"reply,omitempty"`
+}
+
+// STTGateReplyEarcon is the [STTGateConfig.Reply] value that plays a chime
+// instead of speaking a line.
+const STTGateReplyEarcon = "earcon"
+
+// VoiceAssignmentConfig controls the voices of NPCs configured without a
+// voice.voice_id. Each such NPC gets a voice from the TTS provider's voice
+// list picked by hashing its name, so unnamed and ambient NPCs sound distinct
+// and keep their voice across sessions and restarts. It applies to the
+// cascaded engines; speech-to-speech NPCs keep their provider's default voice.
+type VoiceAssignmentConfig struct {
+	// Disabled leaves NPCs without a voice ID on the TTS provider's default
+	// voice.
+	Disabled bool `yaml:"disabled"`
+
+	// Voices restricts assignment to these voice IDs instead of every voice
+	// the TTS provider lists.
+	Voices []string `yaml:"voices,omitempty"`
+
+	// Seed is mixed into the hash. Changing it reshuffles which NPC gets
+	// which voice.
+	Seed string `yaml:"seed,omitempty"`
+}
+
+// SafetyConfig configures the content-safety filter that LLM-generated NPC
+// speech is checked with before it is synthesised. It applies to the cascaded
+// engines; speech-to-speech models produce audio directly and are not
+// filtered.
+type SafetyConfig struct {
+	// Filter selects the filter. Empty or "none" disables filtering.
+	Filter SafetyFilter `yaml:"filter"`
+
+	// Policy decides what happens to flagged speech. Defaults to "block".
+	Policy SafetyPolicy `yaml:"policy"`
+
+	// Words lists the words and phrases the "wordlist" filter flags. Matching
+	// ignores case and only counts whole words.
+	Words []string `yaml:"words,omitempty"`
+}
+
+// BargeInConfig controls when player speech interrupts a speaking NPC.
+type BargeInConfig struct {
+	// GracePeriodMs is how long (in milliseconds) a player must speak
+	// continuously, as judged by the VAD provider, before NPC speech is
+	// interrupted. Short noises such as coughs stay below this threshold.
+	// Zero uses the built-in default of 300 ms; a negative value is invalid.
+	GracePeriodMs int `yaml:"grace_period_ms"`
+
+	// FadeOutMs is the length (in milliseconds) of the volume ramp played
+	// when NPC speech is interrupted, avoiding an audible click. Zero uses
+	// the built-in default of 20 ms; a negative value cuts audio off
+	// without a fade.
+	FadeOutMs int `yaml:"fade_out_ms"`
+}
+
+// OutputConfig controls how NPC replies share the voice channel. Only one NPC
+// speaks at a time; replies that arrive while another NPC is speaking wait
+// their turn unless Preempt says otherwise.
+type OutputConfig struct {
+	// MaxQueue is the number of replies that may wait while an NPC speaks.
+	// Further replies are dropped. Zero means no limit.
+	MaxQueue int `yaml:"max_queue"`
+
+	// Preempt decides what happens to a reply that arrives while another
+	// NPC speaks. Defaults to "queue".
+	Preempt OutputPreempt `yaml:"preempt"`
+}
+
+// VADConfig controls the sensitivity of voice activity detection on player
+// audio. Thresholds are speech probabilities in [0.0, 1.0]; zero keeps the
+// built-in default.
+type VADConfig struct {
+	// SpeechThreshold is the probability above which a frame counts as
+	// speech. Zero uses the default of 0.5.
+	SpeechThreshold float64 `yaml:"speech_threshold"`
+
+	// SilenceThreshold is the probability below which a frame counts as
+	// silence. Zero uses the default of 0.35. Must not exceed the effective
+	// speech threshold.
+	SilenceThreshold float64 `yaml:"silence_threshold"`
+
+	// Speakers overrides the thresholds for individual speakers, keyed by
+	// the audio platform's participant ID (the Discord user ID), to which
+	// the session resolves each input stream. Lower thresholds suit
+	// soft-spoken players; higher ones suit loud players or noisy
+	// microphones. Zero fields inherit the channel-wide values.
+	Speakers map[string]VADThresholds `yaml:"speakers,omitempty"`
+}
+
+// VADThresholds is a per-speaker override of the [VADConfig] thresholds.
+type VADThresholds struct {
+	SpeechThreshold  float64 `yaml:"speech_threshold"`
+	SilenceThreshold float64 `yaml:"silence_threshold"`
 }
 
 // DiscordConfig holds settings for the Discord bot subsystem.
@@ -132,6 +362,12 @@ type CampaignConfig struct {
 	// VTTImports lists paths to VTT export files (Foundry VTT JSON or
 	// Roll20 JSON) to import at startup.
 	VTTImports []VTTImportConfig `yaml:"vtt_imports,omitempty"`
+
+	// Vocabulary lists DM-provided proper nouns of the campaign world (e.g.,
+	// "Eldrinax", "Vel'Shara"). Together with the names of all knowledge graph
+	// entities they are sent to STT as keyword hints and added to NPC prompts
+	// as a spelling reference.
+	Vocabulary []string `yaml:"vocabulary,omitempty"`
 }
 
 // VTTImportConfig describes a single VTT file to import.
@@ -153,6 +389,24 @@ type ServerConfig struct {
 
 	// TLS configures TLS for the server. When nil, the server runs plain HTTP.
 	TLS *TLSConfig `yaml:"tls"`
+
+	// MaxConcurrentNPCs caps the number of NPC engines open at the same time
+	// across all sessions. Starting a session or waking an idle NPC fails
+	// while the limit is reached. Engines closed by an NPC's idle timeout do
+	// not count. Zero means unlimited.
+	MaxConcurrentNPCs int `yaml:"max_concurrent_npcs"`
+
+	// ConcurrentTurns lets the turns of different NPCs in the same session run
+	// concurrently. By default they are processed one at a time, so the
+	// context injected for one turn cannot leak into another. Turns of
+	// different sessions always run concurrently.
+	ConcurrentTurns bool `yaml:"concurrent_turns"`
+
+	// L1Retention is how long L1 transcript entries are kept (e.g. "720h").
+	// Older entries are deleted by a background job once they have been
+	// consolidated into the L2 semantic index; entries that have not are kept
+	// regardless of age. Zero keeps all entries.
+	L1Retention time.Duration `yaml:"l1_retention"`
 }
 
 // TLSConfig holds TLS certificate paths for enabling HTTPS.
@@ -174,6 +428,36 @@ type ProvidersConfig struct {
 	Embeddings ProviderEntry `yaml:"embeddings"`
 	VAD        ProviderEntry `yaml:"vad"`
 	Audio      ProviderEntry `yaml:"audio"`
+
+	// TTSFallbacks are additional TTS providers tried in order when the
+	// primary TTS provider fails or, with TTSLanguageFallback "provider",
+	// does not support the requested language.
+	TTSFallbacks []ProviderEntry `yaml:"tts_fallbacks"`
+
+	// NPCLLMs are additional LLM providers that NPCs select by name with
+	// llm.provider. Each entry carries its own credentials; its model is the
+	// default for NPCs that select it without setting llm.model.
+	NPCLLMs []ProviderEntry `yaml:"npc_llms"`
+
+	// TTSLanguageFallback selects how a language the TTS provider does not
+	// support is handled. Empty means [TTSLanguageFallbackDefault]. A TTS
+	// provider's supported languages are read from its "languages" option or,
+	// when absent, queried from the provider at startup.
+	TTSLanguageFallback TTSLanguageFallback `yaml:"tts_language_fallback"`
+
+	// TTSErrorAudio is played by cascaded NPCs in place of a reply whose
+	// synthesis failed entirely, so players always hear that something went
+	// wrong. It is the path to a WAV or MP3 file, or [TTSErrorAudioEarcon] for
+	// the built-in error chime. The clip is decoded once at startup. Empty
+	// keeps such turns silent.
+	TTSErrorAudio string `yaml:"tts_error_audio,omitempty"`
+
+	// SessionTagging adds the session ID to the requests providers send
+	// during a session, in the X-Glyphoxa-Session header, so they can be
+	// correlated in the providers' logs. Off by default: the session ID
+	// contains the campaign name and start time. The User-Agent header
+	// identifying Glyphoxa is sent regardless.
+	SessionTagging bool `yaml:"session_tagging"`
 }
 
 // ProviderEntry is the common configuration block shared by all provider types.
@@ -215,19 +499,138 @@ type NPCConfig struct {
 	// Used for routing player questions and building retrieval queries.
 	KnowledgeScope []string `yaml:"knowledge_scope"`
 
+	// BehaviorRules are hard constraints on the NPC's responses (e.g.,
+	// "Never break character"), appended to its system prompt as a numbered
+	// list.
+	BehaviorRules []string `yaml:"behavior_rules,omitempty"`
+
 	// Tools lists MCP tool names this NPC is permitted to invoke.
 	Tools []string `yaml:"tools"`
 
 	// BudgetTier constrains which tools are offered to the LLM based on latency.
 	BudgetTier BudgetTier `yaml:"budget_tier"`
 
-	// CascadeMode controls the dual-model sentence cascade for this NPC.
-	// Only effective when Engine is [EngineSentenceCascade]. Defaults to "off".
+	// ColdOpen makes the NPC greet a player in character, drawing on its
+	// identity and the current scene, the first time it is addressed in a
+	// session.
+	ColdOpen bool `yaml:"cold_open,omitempty"`
+
+	// MaxToolRounds caps the rounds of tool calls the NPC's model may make in
+	// a single turn. Once reached, the model must answer without further
+	// tools. Zero uses the engine default of 3; a negative value is invalid.
+	MaxToolRounds int `yaml:"max_tool_rounds,omitempty"`
+
+	// Aliases are alternative names the NPC answers to, in addition to Name
+	// and any aliases recorded on its knowledge-graph entity.
+	Aliases []string `yaml:"aliases,omitempty"`
+
+	// RespondOnlyWhenNamed makes the NPC answer only utterances that mention
+	// its name or an alias, instead of also picking up follow-ups and
+	// unaddressed speech. Useful for background NPCs in crowded scenes.
+	RespondOnlyWhenNamed bool `yaml:"respond_only_when_named,omitempty"`
+
+	// AwarenessRadius is the number of knowledge-graph hops around the NPC
+	// searched for passages relevant to each utterance. Passages about closer
+	// entities are weighted higher than those about distant ones. Zero (the
+	// default) disables retrieval; a negative value is invalid.
+	AwarenessRadius int `yaml:"awareness_radius,omitempty"`
+
+	// StyleExemplars is the number of the NPC's own most recent transcript
+	// lines shown to it as speaking style references, so its voice does not
+	// drift over a long campaign. Zero (the default) disables them; a negative
+	// value is invalid.
+	StyleExemplars int `yaml:"style_exemplars,omitempty"`
+
+	// PostProcessors lists built-in text post-processors, by name, applied in
+	// order to the NPC's responses before synthesis and before they are
+	// recorded in the transcript (e.g. "strip_bracketed_actions",
+	// "strip_markdown").
+	PostProcessors []string `yaml:"post_processors,omitempty"`
+
+	// StockResponses maps intents ("greeting", "dismissal") to canned lines
+	// the NPC answers matching utterances with, bypassing the LLM. Each
+	// session picks lines at random by weight from a seed derived from the
+	// session and NPC. Requires providers.tts.
+	StockResponses map[string][]StockLineConfig `yaml:"stock_responses,omitempty"`
+
+	// CascadeMode is accepted for compatibility with older configuration
+	// files and logged as deprecated when set.
+	//
+	// Deprecated: it has no effect; choose the pipeline with Engine.
 	CascadeMode CascadeMode `yaml:"cascade_mode"`
 
-	// CascadeConfig holds sentence-cascade-specific settings.
-	// Only used when Engine is [EngineSentenceCascade].
+	// CascadeConfig holds the cascade settings. The cascaded engine uses all
+	// of them; the sentence cascade ([EngineSentenceCascade]) only uses
+	// StrongModel and FallbackLine.
 	CascadeConfig *CascadeConfig `yaml:"cascade,omitempty"`
+
+	// LLM optionally overrides the global LLM provider and/or model for this
+	// NPC (e.g., a cheap model for a guard, a strong one for a sage). When nil,
+	// the NPC uses providers.llm unchanged.
+	LLM *NPCLLMConfig `yaml:"llm,omitempty"`
+
+	// S2SFallback enables falling back to the cascaded STT → LLM → TTS
+	// pipeline (built from providers.llm and providers.tts) while the s2s
+	// provider is unreachable. Only valid when Engine is [EngineS2S].
+	S2SFallback *S2SFallbackConfig `yaml:"s2s_fallback,omitempty"`
+
+	// IdleTimeoutMinutes closes the NPC's engine after this many minutes
+	// without a turn, releasing its provider connections. The engine is
+	// recreated on the NPC's next turn. 0 (default) keeps it open for the
+	// whole session.
+	IdleTimeoutMinutes int `yaml:"idle_timeout_minutes,omitempty"`
+
+	// MinResponseLatencyMs is the minimum time in milliseconds between the
+	// start of a turn and the first audio of the NPC's reply. Faster replies
+	// are held back until it has passed, so quick NPCs seem to think before
+	// they answer; generation itself is not delayed. 0 (default) plays audio
+	// as soon as it is ready.
+	MinResponseLatencyMs int `yaml:"min_response_latency_ms,omitempty"`
+}
+
+// StockIntents lists the intents [NPCConfig.StockResponses] may contain.
+var StockIntents = []string{"greeting", "dismissal"}
+
+// StockLineConfig is a canned NPC line. See [NPCConfig.StockResponses].
+type StockLineConfig struct {
+	// Text is the line to speak. The placeholders {name}, {location} and
+	// {time} are replaced with the NPC's name and the current scene's
+	// location and time of day.
+	Text string `yaml:"text"`
+
+	// Weight is the line's chance of being picked relative to the other
+	// lines of its intent. Defaults to 1; a negative value is invalid.
+	Weight int `yaml:"weight,omitempty"`
+}
+
+// S2SFallbackConfig configures the s2s → cascade fallback for an NPC.
+type S2SFallbackConfig struct {
+	// FailureThreshold is the number of consecutive s2s failures after which
+	// turns are routed straight to the cascade. Turns the s2s engine fails on
+	// are always retried on the cascade. Defaults to 1.
+	FailureThreshold int `yaml:"failure_threshold,omitempty"`
+
+	// RetryAfterSeconds is how long the NPC stays on the cascade before the
+	// s2s provider is probed again. Defaults to 30.
+	RetryAfterSeconds int `yaml:"retry_after_seconds,omitempty"`
+
+	// Voice is the providers.tts voice the cascade speaks with. The NPC's
+	// own voice names an s2s voice, which the TTS provider does not know.
+	// VoiceID is required; an empty Language uses the NPC's voice language.
+	Voice VoiceConfig `yaml:"voice"`
+}
+
+// NPCLLMConfig overrides the LLM used by a single NPC.
+type NPCLLMConfig struct {
+	// Provider selects an LLM provider by name (e.g., "anthropic"): the
+	// globally configured providers.llm or one of providers.npc_llms, which
+	// holds its credentials. When empty, providers.llm is used.
+	Provider string `yaml:"provider,omitempty"`
+
+	// Model selects the model to request for this NPC (e.g., "gpt-4o-mini").
+	// Required when Provider differs from the global LLM provider and its
+	// providers.npc_llms entry sets no model.
+	Model string `yaml:"model,omitempty"`
 }
 
 // CascadeConfig holds configuration for the dual-model sentence cascade engine.
@@ -243,6 +646,42 @@ type CascadeConfig struct {
 	// OpenerInstruction is appended to the fast model's system prompt to guide
 	// the opening sentence. Defaults to a built-in instruction if empty.
 	OpenerInstruction string `yaml:"opener_instruction,omitempty"`
+
+	// FallbackLine is spoken when the fast model returns an empty or
+	// whitespace-only response even after a nudge retry. Defaults to "...".
+	FallbackLine string `yaml:"fallback_line,omitempty"`
+
+	// SplitTranscript records the opener and the strong model's continuation
+	// as separate session transcript entries instead of one combined entry.
+	// Useful for analysing the hand-over between the two models.
+	SplitTranscript bool `yaml:"split_transcript,omitempty"`
+
+	// RepeatWindow is the number of recent replies whose opening line a new
+	// opener is compared against. A near-identical opener is regenerated once
+	// with a nudge to vary the phrasing. 0 disables the check.
+	RepeatWindow int `yaml:"repeat_window,omitempty"`
+
+	// RepeatThreshold is the normalised text similarity in (0, 1] at or above
+	// which an opener counts as a repeat. Defaults to 0.85 if zero.
+	RepeatThreshold float64 `yaml:"repeat_threshold,omitempty"`
+
+	// ContinuationStop lists stop sequences for the strong model's
+	// continuation, such as "\n\n" or "Player:". Empty uses none.
+	ContinuationStop []string `yaml:"continuation_stop,omitempty"`
+
+	// MaxContinuationTokens caps the tokens the strong model may generate
+	// for its continuation. 0 keeps the provider's limit.
+	MaxContinuationTokens int `yaml:"max_continuation_tokens,omitempty"`
+
+	// ResumeAfterInterrupt tells the NPC on its next turn that the player
+	// cut off its previous reply and what it had said, so it can react
+	// naturally ("Yes? ... as I was saying.").
+	ResumeAfterInterrupt bool `yaml:"resume_after_interrupt,omitempty"`
+
+	// InterruptInstruction is the note added to the prompt after an
+	// interrupted reply. Defaults to a built-in instruction if empty.
+	// Unused unless ResumeAfterInterrupt is set.
+	InterruptInstruction string `yaml:"interrupt_instruction,omitempty"`
 }
 
 // VoiceConfig specifies the TTS voice parameters for an NPC.
@@ -258,6 +697,25 @@ type VoiceConfig struct {
 
 	// SpeedFactor adjusts speaking rate in the range [0.5, 2.0]. 1.0 means default.
 	SpeedFactor float64 `yaml:"speed_factor"`
+
+	// Language is the BCP-47 tag of the language the NPC speaks (e.g., "de-DE").
+	// A language detected by STT takes precedence. Empty uses the TTS
+	// provider's default language.
+	Language string `yaml:"language"`
+
+	// Stability, SimilarityBoost, Style and UseSpeakerBoost override the
+	// provider's voice settings for this NPC. They are in the range [0, 1]
+	// and only honoured by providers that support them (ElevenLabs). Unset
+	// fields use the provider's configured values.
+	Stability       *float64 `yaml:"stability,omitempty"`
+	SimilarityBoost *float64 `yaml:"similarity_boost,omitempty"`
+	Style           *float64 `yaml:"style,omitempty"`
+	UseSpeakerBoost *bool    `yaml:"use_speaker_boost,omitempty"`
+
+	// SeedPhrase is a short reference phrase synthesised in front of every
+	// sentence and trimmed from the output, keeping a cloned voice's timbre
+	// consistent. Only honoured by providers that support it (Coqui).
+	SeedPhrase string `yaml:"seed_phrase,omitempty"`
 }
 
 // MemoryConfig holds settings for the long-term memory / semantic retrieval layer.
@@ -269,6 +727,63 @@ type MemoryConfig struct {
 	// EmbeddingDimensions is the vector dimension used for the embeddings column.
 	// Must match the model configured in Providers.Embeddings.
 	EmbeddingDimensions int `yaml:"embedding_dimensions"`
+
+	// DistanceMetric is the metric embeddings are compared by in semantic
+	// search: "cosine" (the default), "l2" or "inner_product". Changing it
+	// for an existing database requires re-creating the vector index.
+	DistanceMetric memory.DistanceMetric `yaml:"distance_metric"`
+
+	// HistorySummaryThreshold is the number of messages an NPC keeps in its
+	// working conversation history before the oldest half is compressed into
+	// an LLM-generated summary. Recent turns are always kept verbatim.
+	// Zero disables compression; otherwise it must be at least 2.
+	HistorySummaryThreshold int `yaml:"history_summary_threshold"`
+
+	// SceneRecap injects a rolling recap of the scene so far into every NPC
+	// prompt, separate from the verbatim recent turns. The zero value
+	// disables it.
+	SceneRecap SceneRecapConfig `yaml:"scene_recap"`
+
+	// Language is the BCP-47 tag of the language assumed for transcript
+	// entries and memory chunks that carry none. It selects the full-text
+	// search rules (stemming, stop words) their text is indexed with. Empty
+	// means English.
+	Language string `yaml:"language"`
+
+	// TranscriptFilter skips persisting short or low-information player
+	// utterances such as "um" or "yeah". Filtered utterances are still
+	// processed by the voice pipeline. The zero value persists everything.
+	TranscriptFilter TranscriptFilterConfig `yaml:"transcript_filter"`
+}
+
+// SceneRecapConfig controls the scene recap of each NPC: a one-paragraph
+// summary of the scene so far, updated incrementally by the LLM.
+type SceneRecapConfig struct {
+	// Every is the number of turns between two refreshes of the recap. Each
+	// refresh sends the previous recap and the turns since to the LLM.
+	// Zero disables the recap.
+	Every int `yaml:"every"`
+
+	// MaxWords caps the length of the recap. Zero uses 120.
+	MaxWords int `yaml:"max_words"`
+}
+
+// TranscriptFilterConfig controls which player utterances are written to the
+// session transcript store.
+type TranscriptFilterConfig struct {
+	// MinWords is the number of words an utterance needs to be persisted.
+	// Zero disables the check.
+	MinWords int `yaml:"min_words"`
+
+	// MinChars is the number of letters and digits an utterance needs to be
+	// persisted. Zero disables the check.
+	MinChars int `yaml:"min_chars"`
+
+	// SkipFillers drops utterances made up only of filler words.
+	SkipFillers bool `yaml:"skip_fillers"`
+
+	// Fillers overrides the built-in filler word list used by SkipFillers.
+	Fillers []string `yaml:"fillers"`
 }
 
 // MCPConfig holds the list of Model Context Protocol servers to connect to.
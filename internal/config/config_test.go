@@ -3,8 +3,11 @@ package config_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/MrWong99/glyphoxa/internal/config"
 	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
@@ -134,6 +137,293 @@ server:
 	}
 }
 
+func TestValidate_NegativeBargeInGrace(t *testing.T) {
+	t.Parallel()
+	yaml := `
+barge_in:
+  grace_period_ms: -5
+`
+	_, err := config.LoadFromReader(strings.NewReader(yaml))
+	if err == nil {
+		t.Fatal("expected error for negative barge_in.grace_period_ms, got nil")
+	}
+	if !strings.Contains(err.Error(), "grace_period_ms") {
+		t.Errorf("error should mention grace_period_ms, got: %v", err)
+	}
+}
+
+func TestValidate_Output(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "defaults", yaml: "output: {}\n"},
+		{name: "newest with limit", yaml: "output:\n  max_queue: 2\n  preempt: newest\n"},
+		{name: "negative max queue", yaml: "output:\n  max_queue: -1\n", wantErr: "max_queue"},
+		{name: "unknown preempt", yaml: "output:\n  preempt: loudest\n", wantErr: "preempt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := config.LoadFromReader(strings.NewReader(tt.yaml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want mention of %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_VAD(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "empty", yaml: "vad: {}"},
+		{
+			name: "speaker overrides",
+			yaml: `
+vad:
+  speech_threshold: 0.6
+  speakers:
+    "1234":
+      speech_threshold: 0.3
+      silence_threshold: 0.2
+    "5678":
+      speech_threshold: 0.8
+`,
+		},
+		{name: "speech out of range", yaml: "vad:\n  speech_threshold: 1.5\n", wantErr: "vad.speech_threshold"},
+		{name: "negative silence", yaml: "vad:\n  silence_threshold: -0.1\n", wantErr: "vad.silence_threshold"},
+		{name: "silence above speech", yaml: "vad:\n  speech_threshold: 0.3\n", wantErr: "must not exceed"},
+		{
+			name: "speaker silence above inherited speech",
+			yaml: `
+vad:
+  speakers:
+    "1234":
+      silence_threshold: 0.6
+`,
+			wantErr: `vad.speakers["1234"].silence_threshold`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := config.LoadFromReader(strings.NewReader(tc.yaml))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_HistorySummaryThreshold(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		threshold int
+		wantErr   bool
+	}{
+		{threshold: 0},
+		{threshold: 2},
+		{threshold: 40},
+		{threshold: 1, wantErr: true},
+		{threshold: -3, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.threshold), func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf("memory:\n  history_summary_threshold: %d\n", tc.threshold)
+			_, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "history_summary_threshold") {
+					t.Fatalf("expected history_summary_threshold error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_SceneRecap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{name: "unset", yaml: "memory: {}\n"},
+		{name: "valid", yaml: "memory:\n  scene_recap:\n    every: 4\n    max_words: 80\n"},
+		{name: "negative every", yaml: "memory:\n  scene_recap:\n    every: -1\n", wantErr: true},
+		{name: "negative max_words", yaml: "memory:\n  scene_recap:\n    every: 3\n    max_words: -10\n", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := config.LoadFromReader(strings.NewReader(tc.yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "scene_recap") {
+					t.Fatalf("expected scene_recap error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.name == "valid" {
+				if r := cfg.Memory.SceneRecap; r.Every != 4 || r.MaxWords != 80 {
+					t.Errorf("scene_recap = %+v", r)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_TranscriptFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{name: "unset", yaml: "memory:\n  history_summary_threshold: 0\n"},
+		{name: "valid", yaml: "memory:\n  transcript_filter:\n    min_words: 2\n    skip_fillers: true\n    fillers: [ja, genau]\n"},
+		{name: "negative min_words", yaml: "memory:\n  transcript_filter:\n    min_words: -1\n", wantErr: true},
+		{name: "negative min_chars", yaml: "memory:\n  transcript_filter:\n    min_chars: -5\n", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := config.LoadFromReader(strings.NewReader(tc.yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "transcript_filter") {
+					t.Fatalf("expected transcript_filter error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.name == "valid" {
+				tf := cfg.Memory.TranscriptFilter
+				if tf.MinWords != 2 || !tf.SkipFillers || len(tf.Fillers) != 2 {
+					t.Errorf("transcript_filter = %+v", tf)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_TTSFallbacks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "valid chain and policy",
+			yaml: `
+providers:
+  tts:
+    name: elevenlabs
+    options:
+      languages: [en]
+  tts_fallbacks:
+    - name: azure
+      options:
+        region: westeurope
+  tts_language_fallback: provider
+npcs:
+  - name: Greta
+    voice:
+      voice_id: v1
+      language: de-DE
+`,
+		},
+		{
+			name: "invalid policy",
+			yaml: `
+providers:
+  tts:
+    name: elevenlabs
+  tts_language_fallback: guess
+`,
+			wantErr: "tts_language_fallback",
+		},
+		{
+			name: "fallback without name",
+			yaml: `
+providers:
+  tts:
+    name: elevenlabs
+  tts_fallbacks:
+    - api_key: k
+`,
+			wantErr: "tts_fallbacks[0].name",
+		},
+		{
+			name: "fallbacks without primary",
+			yaml: `
+providers:
+  tts_fallbacks:
+    - name: azure
+`,
+			wantErr: "requires providers.tts",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := config.LoadFromReader(strings.NewReader(tc.yaml))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.Providers.TTSLanguageFallback != config.TTSLanguageFallbackProvider || len(cfg.Providers.TTSFallbacks) != 1 {
+				t.Errorf("providers = %+v", cfg.Providers)
+			}
+			if cfg.NPCs[0].Voice.Language != "de-DE" {
+				t.Errorf("voice language = %q, want de-DE", cfg.NPCs[0].Voice.Language)
+			}
+		})
+	}
+}
+
 func TestValidate_MissingNPCName(t *testing.T) {
 	t.Parallel()
 	yaml := `
@@ -193,6 +483,998 @@ npcs:
 	}
 }
 
+func TestValidate_VoiceSettings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		voice   string
+		wantErr string
+	}{
+		{name: "in range", voice: "stability: 0\n      similarity_boost: 1\n      style: 0.4\n      use_speaker_boost: false"},
+		{name: "stability above 1", voice: "stability: 1.5", wantErr: "voice.stability 1.50 is out of range [0, 1]"},
+		{name: "negative similarity_boost", voice: "similarity_boost: -0.2", wantErr: "voice.similarity_boost -0.20 is out of range [0, 1]"},
+		{name: "style above 1", voice: "style: 2", wantErr: "voice.style 2.00 is out of range [0, 1]"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			yaml := "npcs:\n  - name: TestNPC\n    voice:\n      " + tc.voice + "\n"
+			_, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_NPCLLMOverride(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "model only uses global provider",
+			yaml: `
+providers:
+  llm:
+    name: openai
+    model: gpt-4o
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Guard
+    engine: cascaded
+    llm:
+      model: gpt-4o-mini
+`,
+		},
+		{
+			name: "other provider with model",
+			yaml: `
+providers:
+  llm:
+    name: openai
+    model: gpt-4o
+  tts:
+    name: elevenlabs
+  npc_llms:
+    - name: anthropic
+      api_key: sk-ant-test
+npcs:
+  - name: Sage
+    engine: cascaded
+    llm:
+      provider: anthropic
+      model: claude-sonnet-4
+`,
+		},
+		{
+			name: "other provider with default model",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+  npc_llms:
+    - name: anthropic
+      api_key: sk-ant-test
+      model: claude-sonnet-4
+npcs:
+  - name: Sage
+    engine: cascaded
+    llm:
+      provider: anthropic
+`,
+		},
+		{
+			name: "unconfigured provider",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Sage
+    engine: cascaded
+    llm:
+      provider: anthropic
+      model: claude-sonnet-4
+`,
+			wantErr: `llm.provider "anthropic" is not configured`,
+		},
+		{
+			name: "npc_llms entry without name",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  npc_llms:
+    - api_key: sk-test
+`,
+			wantErr: "providers.npc_llms[0].name is required",
+		},
+		{
+			name: "npc_llms entry repeats global provider",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  npc_llms:
+    - name: openai
+`,
+			wantErr: "already configured as providers.llm",
+		},
+		{
+			name: "duplicate npc_llms entry",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  npc_llms:
+    - name: anthropic
+    - name: anthropic
+`,
+			wantErr: `duplicate provider "anthropic"`,
+		},
+		{
+			name: "empty override",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Guard
+    engine: cascaded
+    llm: {}
+`,
+			wantErr: "llm must set provider and/or model",
+		},
+		{
+			name: "other provider without model",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+  npc_llms:
+    - name: anthropic
+      api_key: sk-ant-test
+npcs:
+  - name: Sage
+    engine: cascaded
+    llm:
+      provider: anthropic
+`,
+			wantErr: "llm.model is required",
+		},
+		{
+			name: "model without any provider",
+			yaml: `
+npcs:
+  - name: Guard
+    llm:
+      model: gpt-4o-mini
+`,
+			wantErr: "requires providers.llm",
+		},
+		{
+			name: "s2s engine",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  s2s:
+    name: openai-realtime
+npcs:
+  - name: Bard
+    engine: s2s
+    llm:
+      model: gpt-4o-mini
+`,
+			wantErr: "not supported with engine",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := config.LoadFromReader(strings.NewReader(tc.yaml))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tc.wantErr)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error should contain %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_MaxToolRounds(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		rounds  int
+		wantErr bool
+	}{
+		{rounds: 0},
+		{rounds: 5},
+		{rounds: -1, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.rounds), func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Sage
+    engine: cascaded
+    max_tool_rounds: %d
+`, tc.rounds)
+			_, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "max_tool_rounds") {
+					t.Fatalf("expected max_tool_rounds error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_Safety(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		safety  string
+		wantErr string
+	}{
+		{name: "disabled", safety: ""},
+		{name: "wordlist", safety: "filter: wordlist\n  policy: redact\n  words: [blast]"},
+		{name: "unknown filter", safety: "filter: oracle", wantErr: "safety.filter"},
+		{name: "unknown policy", safety: "filter: none\n  policy: shout", wantErr: "safety.policy"},
+		{name: "wordlist without words", safety: "filter: wordlist", wantErr: "safety.words"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			yaml := "safety:\n  " + tc.safety + "\n"
+			cfg, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected %s error, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.name == "wordlist" && (cfg.Safety.Policy != config.SafetyPolicyRedact || len(cfg.Safety.Words) != 1) {
+				t.Errorf("Safety = %+v, want redact policy with one word", cfg.Safety)
+			}
+		})
+	}
+}
+
+func TestValidate_VoiceAssignment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "defaults", yaml: "voice_assignment: {}\n"},
+		{name: "pool and seed", yaml: "voice_assignment:\n  voices: [v1, v2]\n  seed: tavern\n"},
+		{name: "disabled", yaml: "voice_assignment:\n  disabled: true\n"},
+		{name: "empty voice ID", yaml: "voice_assignment:\n  voices: [v1, \"\"]\n", wantErr: "voice_assignment.voices"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := config.LoadFromReader(strings.NewReader(tt.yaml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want mention of %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_MaxConcurrentNPCs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		max     int
+		wantErr bool
+	}{
+		{max: 0},
+		{max: 8},
+		{max: -1, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.max), func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+server:
+  max_concurrent_npcs: %d
+providers:
+  llm:
+    name: openai
+`, tc.max)
+			cfg, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "max_concurrent_npcs") {
+					t.Fatalf("expected max_concurrent_npcs error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.Server.MaxConcurrentNPCs; got != tc.max {
+				t.Errorf("MaxConcurrentNPCs = %d, want %d", got, tc.max)
+			}
+		})
+	}
+}
+
+func TestValidate_L1Retention(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		retention string
+		want      time.Duration
+		wantErr   bool
+	}{
+		{retention: "0s"},
+		{retention: "720h", want: 720 * time.Hour},
+		{retention: "-1h", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.retention, func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+server:
+  l1_retention: %s
+providers:
+  llm:
+    name: openai
+`, tc.retention)
+			cfg, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "l1_retention") {
+					t.Fatalf("expected l1_retention error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.Server.L1Retention; got != tc.want {
+				t.Errorf("L1Retention = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidate_STTGate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		confidence string
+		want       float64
+		wantErr    bool
+	}{
+		{confidence: "0"},
+		{confidence: "0.6", want: 0.6},
+		{confidence: "1", want: 1},
+		{confidence: "-0.1", wantErr: true},
+		{confidence: "1.5", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.confidence, func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+providers:
+  llm:
+    name: openai
+stt_gate:
+  min_confidence: %s
+  reply: earcon
+`, tc.confidence)
+			cfg, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "stt_gate.min_confidence") {
+					t.Fatalf("expected stt_gate.min_confidence error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.STTGate.MinConfidence; got != tc.want {
+				t.Errorf("MinConfidence = %v, want %v", got, tc.want)
+			}
+			if got := cfg.STTGate.Reply; got != config.STTGateReplyEarcon {
+				t.Errorf("Reply = %q, want %q", got, config.STTGateReplyEarcon)
+			}
+		})
+	}
+}
+
+func TestValidate_DistanceMetric(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		metric  string
+		wantErr bool
+	}{
+		{metric: `""`},
+		{metric: "cosine"},
+		{metric: "l2"},
+		{metric: "inner_product"},
+		{metric: "manhattan", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.metric, func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+memory:
+  distance_metric: %s
+providers:
+  llm:
+    name: openai
+`, tc.metric)
+			_, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "distance_metric") {
+					t.Fatalf("expected distance_metric error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_IdleTimeoutMinutes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		minutes int
+		wantErr bool
+	}{
+		{minutes: 0},
+		{minutes: 15},
+		{minutes: -1, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.minutes), func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Sage
+    engine: cascaded
+    idle_timeout_minutes: %d
+`, tc.minutes)
+			cfg, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "idle_timeout_minutes") {
+					t.Fatalf("expected idle_timeout_minutes error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.NPCs[0].IdleTimeoutMinutes; got != tc.minutes {
+				t.Errorf("IdleTimeoutMinutes = %d, want %d", got, tc.minutes)
+			}
+		})
+	}
+}
+
+func TestValidate_MinResponseLatencyMs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ms      int
+		wantErr bool
+	}{
+		{ms: 0},
+		{ms: 400},
+		{ms: -1, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.ms), func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Sage
+    engine: cascaded
+    min_response_latency_ms: %d
+`, tc.ms)
+			cfg, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "min_response_latency_ms") {
+					t.Fatalf("expected min_response_latency_ms error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.NPCs[0].MinResponseLatencyMs; got != tc.ms {
+				t.Errorf("MinResponseLatencyMs = %d, want %d", got, tc.ms)
+			}
+		})
+	}
+}
+
+func TestValidate_AwarenessRadius(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		radius  int
+		wantErr bool
+	}{
+		{radius: 0},
+		{radius: 2},
+		{radius: -1, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.radius), func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Sage
+    engine: cascaded
+    awareness_radius: %d
+`, tc.radius)
+			cfg, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "awareness_radius") {
+					t.Fatalf("expected awareness_radius error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.NPCs[0].AwarenessRadius; got != tc.radius {
+				t.Errorf("AwarenessRadius = %d, want %d", got, tc.radius)
+			}
+		})
+	}
+}
+
+func TestValidate_StyleExemplars(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		k       int
+		wantErr bool
+	}{
+		{k: 0},
+		{k: 5},
+		{k: -1, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.k), func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Sage
+    engine: cascaded
+    style_exemplars: %d
+`, tc.k)
+			cfg, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "style_exemplars") {
+					t.Fatalf("expected style_exemplars error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.NPCs[0].StyleExemplars; got != tc.k {
+				t.Errorf("StyleExemplars = %d, want %d", got, tc.k)
+			}
+		})
+	}
+}
+
+func TestValidate_StockResponses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		tts     string
+		stock   string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			tts:  "elevenlabs",
+			stock: `
+      greeting:
+        - text: "Welcome to {location}!"
+          weight: 3
+        - text: "Buying or browsing?"
+      dismissal:
+        - text: "Safe travels."`,
+		},
+		{name: "unknown intent", tts: "elevenlabs", stock: "\n      haggle:\n        - text: No.", wantErr: `unknown intent "haggle"`},
+		{name: "empty text", tts: "elevenlabs", stock: "\n      greeting:\n        - weight: 2", wantErr: "stock_responses.greeting[0].text must not be empty"},
+		{name: "negative weight", tts: "elevenlabs", stock: "\n      greeting:\n        - text: Hi\n          weight: -1", wantErr: "stock_responses.greeting[0].weight must be >= 0"},
+		{name: "no TTS provider", stock: "\n      greeting:\n        - text: Hi", wantErr: "stock_responses requires a TTS provider"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+providers:
+  tts:
+    name: %q
+npcs:
+  - name: Merchant
+    stock_responses:%s
+`, tc.tts, tc.stock)
+			cfg, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			greeting := cfg.NPCs[0].StockResponses["greeting"]
+			if len(greeting) != 2 || greeting[0].Weight != 3 || greeting[1].Text != "Buying or browsing?" {
+				t.Errorf("greeting lines = %+v", greeting)
+			}
+		})
+	}
+}
+
+func TestValidate_CascadeRepeat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		window    int
+		threshold float64
+		wantErr   string
+	}{
+		{name: "disabled"},
+		{name: "window with default threshold", window: 3},
+		{name: "window and threshold", window: 3, threshold: 0.9},
+		{name: "negative window", window: -1, wantErr: "repeat_window"},
+		{name: "threshold above one", window: 3, threshold: 1.5, wantErr: "repeat_threshold"},
+		{name: "negative threshold", window: 3, threshold: -0.1, wantErr: "repeat_threshold"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Sage
+    engine: sentence_cascade
+    cascade:
+      repeat_window: %d
+      repeat_threshold: %g
+`, tc.window, tc.threshold)
+			cfg, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected %s error, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			c := cfg.NPCs[0].CascadeConfig
+			if c.RepeatWindow != tc.window || c.RepeatThreshold != tc.threshold {
+				t.Errorf("repeat = %d/%g, want %d/%g", c.RepeatWindow, c.RepeatThreshold, tc.window, tc.threshold)
+			}
+		})
+	}
+}
+
+func TestValidate_CascadeContinuation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		cascade  string
+		wantStop []string
+		wantMax  int
+		wantErr  string
+	}{
+		{name: "unset"},
+		{
+			name:     "stop and limit",
+			cascade:  "continuation_stop: [\"\\n\\n\", \"Player:\"]\n      max_continuation_tokens: 200",
+			wantStop: []string{"\n\n", "Player:"},
+			wantMax:  200,
+		},
+		{name: "negative limit", cascade: "max_continuation_tokens: -1", wantErr: "max_continuation_tokens"},
+		{name: "empty stop sequence", cascade: `continuation_stop: [""]`, wantErr: "continuation_stop[0]"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Sage
+    engine: cascaded
+    cascade:
+      fast_model: small
+      %s
+`, tc.cascade)
+			cfg, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected %s error, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			c := cfg.NPCs[0].CascadeConfig
+			if !slices.Equal(c.ContinuationStop, tc.wantStop) || c.MaxContinuationTokens != tc.wantMax {
+				t.Errorf("continuation = %q/%d, want %q/%d", c.ContinuationStop, c.MaxContinuationTokens, tc.wantStop, tc.wantMax)
+			}
+		})
+	}
+}
+
+func TestValidate_PostProcessors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		list    string
+		wantErr bool
+	}{
+		{name: "none", list: "[]"},
+		{name: "built-ins", list: "[strip_bracketed_actions, strip_markdown]"},
+		{name: "unknown", list: "[strip_markdown, shout]", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			yaml := fmt.Sprintf(`
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Sage
+    engine: cascaded
+    post_processors: %s
+`, tc.list)
+			_, err := config.LoadFromReader(strings.NewReader(yaml))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), `post_processors: engine: unknown post-processor "shout"`) {
+					t.Fatalf("expected post_processors error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_S2SFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+  s2s:
+    name: openai-realtime
+npcs:
+  - name: Bard
+    engine: s2s
+    s2s_fallback:
+      failure_threshold: 2
+      retry_after_seconds: 60
+      voice:
+        voice_id: pNInz6obpgDQGcFmaJgB
+    llm:
+      model: gpt-4o-mini
+`,
+		},
+		{
+			name: "cascaded engine",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+npcs:
+  - name: Bard
+    engine: cascaded
+    s2s_fallback: {}
+`,
+			wantErr: "s2s_fallback is only supported with engine",
+		},
+		{
+			name: "missing tts provider",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  s2s:
+    name: openai-realtime
+npcs:
+  - name: Bard
+    engine: s2s
+    s2s_fallback: {}
+`,
+			wantErr: "s2s_fallback requires a TTS provider",
+		},
+		{
+			name: "negative retry",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+  s2s:
+    name: openai-realtime
+npcs:
+  - name: Bard
+    engine: s2s
+    s2s_fallback:
+      retry_after_seconds: -1
+      voice:
+        voice_id: pNInz6obpgDQGcFmaJgB
+`,
+			wantErr: "retry_after_seconds must be >= 0",
+		},
+		{
+			name: "missing voice",
+			yaml: `
+providers:
+  llm:
+    name: openai
+  tts:
+    name: elevenlabs
+  s2s:
+    name: openai-realtime
+npcs:
+  - name: Bard
+    engine: s2s
+    voice:
+      voice_id: alloy
+    s2s_fallback: {}
+`,
+			wantErr: "s2s_fallback.voice.voice_id is required",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := config.LoadFromReader(strings.NewReader(tc.yaml))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tc.wantErr)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error should contain %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestValidate_MCPMissingCommand(t *testing.T) {
 	t.Parallel()
 	yaml := `
@@ -409,10 +1691,10 @@ func (s *stubSTT) StartStream(_ context.Context, _ stt.StreamConfig) (stt.Sessio
 // stubTTS implements tts.Provider.
 type stubTTS struct{}
 
-func (s *stubTTS) SynthesizeStream(_ context.Context, _ <-chan string, _ tts.VoiceProfile) (<-chan []byte, error) {
+func (s *stubTTS) SynthesizeStream(_ context.Context, _ <-chan string, _ tts.VoiceProfile) (*tts.Stream, error) {
 	ch := make(chan []byte)
 	close(ch)
-	return ch, nil
+	return tts.NewStream(ch), nil
 }
 func (s *stubTTS) ListVoices(_ context.Context) ([]tts.VoiceProfile, error) { return nil, nil }
 func (s *stubTTS) CloneVoice(_ context.Context, _ [][]byte) (*tts.VoiceProfile, error) {
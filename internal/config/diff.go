@@ -1,12 +1,25 @@
 package config
 
+import (
+	"reflect"
+	"strings"
+)
+
 // ConfigDiff describes what changed between two configs.
-// Only fields that can be safely hot-reloaded are tracked.
+// The hot-reloadable parts (NPC definitions, MCP servers and the log level)
+// are tracked in detail; changes anywhere else are only listed in
+// RestartRequired.
 type ConfigDiff struct {
-	NPCsChanged     bool      // true if any NPC personality, voice, or budget_tier changed
+	NPCsChanged     bool      // true if any NPC was added, removed or changed
 	NPCChanges      []NPCDiff // per-NPC diffs
+	MCPChanged      bool      // true if the MCP server list changed
 	LogLevelChanged bool
 	NewLogLevel     LogLevel
+
+	// RestartRequired lists the YAML keys of changed top-level sections that
+	// only take effect after a restart, such as "providers" or "memory". A
+	// change to server.log_level alone does not list "server".
+	RestartRequired []string
 }
 
 // NPCDiff describes what changed for a single NPC between two configs.
@@ -15,6 +28,7 @@ type NPCDiff struct {
 	PersonalityChanged bool
 	VoiceChanged       bool
 	BudgetTierChanged  bool
+	OtherChanged       bool // any other setting, such as engine, tools or knowledge
 	Added              bool
 	Removed            bool
 }
@@ -30,6 +44,12 @@ func Diff(old, new *Config) ConfigDiff {
 		d.NewLogLevel = new.Server.LogLevel
 	}
 
+	if !reflect.DeepEqual(old.MCP, new.MCP) {
+		d.MCPChanged = true
+	}
+
+	d.RestartRequired = restartRequired(old, new)
+
 	// Build NPC lookup maps keyed by name.
 	oldNPCs := make(map[string]*NPCConfig, len(old.NPCs))
 	for i := range old.NPCs {
@@ -52,7 +72,7 @@ func Diff(old, new *Config) ConfigDiff {
 			continue
 		}
 		nd := diffNPC(name, oldNPC, newNPC)
-		if nd.PersonalityChanged || nd.VoiceChanged || nd.BudgetTierChanged {
+		if nd.PersonalityChanged || nd.VoiceChanged || nd.BudgetTierChanged || nd.OtherChanged {
 			d.NPCChanges = append(d.NPCChanges, nd)
 			d.NPCsChanged = true
 		}
@@ -88,5 +108,33 @@ func diffNPC(name string, old, new *NPCConfig) NPCDiff {
 		nd.BudgetTierChanged = true
 	}
 
+	// Compare the rest with the fields above blanked out.
+	o, n := *old, *new
+	o.Personality, n.Personality = "", ""
+	o.Voice, n.Voice = VoiceConfig{}, VoiceConfig{}
+	o.BudgetTier, n.BudgetTier = "", ""
+	nd.OtherChanged = !reflect.DeepEqual(o, n)
+
 	return nd
 }
+
+// restartRequired returns the YAML keys of the top-level sections that differ
+// between old and new, leaving out the hot-reloadable npcs and mcp sections
+// and server.log_level.
+func restartRequired(old, new *Config) []string {
+	o, n := *old, *new
+	o.NPCs, n.NPCs = nil, nil
+	o.MCP, n.MCP = MCPConfig{}, MCPConfig{}
+	o.Server.LogLevel, n.Server.LogLevel = "", ""
+
+	var keys []string
+	ov, nv := reflect.ValueOf(o), reflect.ValueOf(n)
+	for i := range ov.NumField() {
+		if reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			continue
+		}
+		key, _, _ := strings.Cut(ov.Type().Field(i).Tag.Get("yaml"), ",")
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -1,6 +1,7 @@
 package config_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/MrWong99/glyphoxa/internal/config"
@@ -221,3 +222,103 @@ func TestDiff_MultipleChanges(t *testing.T) {
 		t.Error("expected C Added=true")
 	}
 }
+
+func TestDiff_NPCOtherChanged(t *testing.T) {
+	t.Parallel()
+	old := &config.Config{
+		NPCs: []config.NPCConfig{
+			{Name: "Bob", Personality: "grumpy", Tools: []string{"dice"}},
+		},
+	}
+	new := &config.Config{
+		NPCs: []config.NPCConfig{
+			{Name: "Bob", Personality: "grumpy", Tools: []string{"dice", "lookup"}},
+		},
+	}
+
+	d := config.Diff(old, new)
+	if !d.NPCsChanged {
+		t.Error("expected NPCsChanged=true")
+	}
+	if len(d.NPCChanges) != 1 {
+		t.Fatalf("expected 1 NPC change, got %d", len(d.NPCChanges))
+	}
+	nc := d.NPCChanges[0]
+	if !nc.OtherChanged {
+		t.Error("expected OtherChanged=true")
+	}
+	if nc.PersonalityChanged || nc.VoiceChanged || nc.BudgetTierChanged {
+		t.Errorf("expected only OtherChanged, got %+v", nc)
+	}
+}
+
+func TestDiff_MCPChanged(t *testing.T) {
+	t.Parallel()
+	old := &config.Config{}
+	new := &config.Config{
+		MCP: config.MCPConfig{Servers: []config.MCPServerConfig{
+			{Name: "dice", Transport: "stdio", Command: "dice-server"},
+		}},
+	}
+
+	d := config.Diff(old, new)
+	if !d.MCPChanged {
+		t.Error("expected MCPChanged=true")
+	}
+	if len(d.RestartRequired) != 0 {
+		t.Errorf("expected no restart for an MCP change, got %v", d.RestartRequired)
+	}
+}
+
+func TestDiff_RestartRequired(t *testing.T) {
+	t.Parallel()
+	base := config.Config{
+		Server:    config.ServerConfig{ListenAddr: ":8080", LogLevel: config.LogInfo},
+		Providers: config.ProvidersConfig{LLM: config.ProviderEntry{Name: "openai", Model: "gpt-4o"}},
+		NPCs:      []config.NPCConfig{{Name: "Alice"}},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *config.Config)
+		want   []string
+	}{
+		{
+			name:   "log level only",
+			modify: func(c *config.Config) { c.Server.LogLevel = config.LogDebug },
+			want:   nil,
+		},
+		{
+			name:   "npcs only",
+			modify: func(c *config.Config) { c.NPCs = append(c.NPCs, config.NPCConfig{Name: "Bob"}) },
+			want:   nil,
+		},
+		{
+			name:   "provider model",
+			modify: func(c *config.Config) { c.Providers.LLM.Model = "gpt-4o-mini" },
+			want:   []string{"providers"},
+		},
+		{
+			name: "listen address and memory",
+			modify: func(c *config.Config) {
+				c.Server.ListenAddr = ":9090"
+				c.Memory.PostgresDSN = "postgres://localhost/other"
+			},
+			want: []string{"server", "memory"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			old, new := base, base
+			new.NPCs = slices.Clone(base.NPCs)
+			tc.modify(&new)
+
+			d := config.Diff(&old, &new)
+			if !slices.Equal(d.RestartRequired, tc.want) {
+				t.Errorf("RestartRequired: got %v, want %v", d.RestartRequired, tc.want)
+			}
+		})
+	}
+}
@@ -5,10 +5,14 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"slices"
 
+	enginepkg "github.com/MrWong99/glyphoxa/internal/engine"
 	"github.com/MrWong99/glyphoxa/internal/mcp"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
 	"gopkg.in/yaml.v3"
 )
 
@@ -16,11 +20,11 @@ import (
 // Used by [Validate] to warn about unrecognised provider names.
 var ValidProviderNames = map[string][]string{
 	"llm":        {"openai", "anthropic", "ollama", "gemini", "deepseek", "mistral", "groq", "llamacpp", "llamafile"},
-	"stt":        {"deepgram", "whisper", "whisper-native"},
-	"tts":        {"elevenlabs", "coqui"},
+	"stt":        {"deepgram", "whisper", "whisper-native", "azure"},
+	"tts":        {"elevenlabs", "coqui", "polly", "azure", "cartesia", "piper", "onnx"},
 	"s2s":        {"openai-realtime", "gemini-live"},
 	"embeddings": {"openai", "ollama"},
-	"vad":        {"silero"},
+	"vad":        {"silero", "energy"},
 	"audio":      {"discord"},
 }
 
@@ -64,6 +68,49 @@ func Validate(cfg *Config) error {
 	if cfg.Server.LogLevel != "" && !cfg.Server.LogLevel.IsValid() {
 		errs = append(errs, fmt.Errorf("server.log_level %q is invalid; valid values: debug, info, warn, error", cfg.Server.LogLevel))
 	}
+	if cfg.Server.MaxConcurrentNPCs < 0 {
+		errs = append(errs, fmt.Errorf("server.max_concurrent_npcs must be >= 0, got %d", cfg.Server.MaxConcurrentNPCs))
+	}
+	if cfg.Server.L1Retention < 0 {
+		errs = append(errs, fmt.Errorf("server.l1_retention must be >= 0, got %s", cfg.Server.L1Retention))
+	}
+
+	// Barge-in
+	if cfg.BargeIn.GracePeriodMs < 0 {
+		errs = append(errs, fmt.Errorf("barge_in.grace_period_ms must be >= 0, got %d", cfg.BargeIn.GracePeriodMs))
+	}
+
+	// Output
+	if cfg.Output.MaxQueue < 0 {
+		errs = append(errs, fmt.Errorf("output.max_queue must be >= 0, got %d", cfg.Output.MaxQueue))
+	}
+	if !cfg.Output.Preempt.IsValid() {
+		errs = append(errs, fmt.Errorf("output.preempt %q is invalid; valid values: queue, newest", cfg.Output.Preempt))
+	}
+
+	// VAD
+	errs = append(errs, validateVAD(cfg.VAD)...)
+
+	// Safety
+	if !cfg.Safety.Filter.IsValid() {
+		errs = append(errs, fmt.Errorf("safety.filter %q is invalid; valid values: none, wordlist", cfg.Safety.Filter))
+	}
+	if !cfg.Safety.Policy.IsValid() {
+		errs = append(errs, fmt.Errorf("safety.policy %q is invalid; valid values: block, redact, regenerate", cfg.Safety.Policy))
+	}
+	if cfg.Safety.Filter == SafetyFilterWordlist && len(cfg.Safety.Words) == 0 {
+		errs = append(errs, fmt.Errorf("safety.words must not be empty with filter %q", SafetyFilterWordlist))
+	}
+
+	// Voice assignment
+	if slices.Contains(cfg.VoiceAssignment.Voices, "") {
+		errs = append(errs, errors.New("voice_assignment.voices must not contain empty voice IDs"))
+	}
+
+	// STT gate
+	if g := cfg.STTGate.MinConfidence; g < 0 || g > 1 {
+		errs = append(errs, fmt.Errorf("stt_gate.min_confidence %v must be between 0 and 1", g))
+	}
 
 	// Provider name validation — warn for unknown provider names.
 	validateProviderName("llm", cfg.Providers.LLM.Name)
@@ -73,6 +120,35 @@ func Validate(cfg *Config) error {
 	validateProviderName("embeddings", cfg.Providers.Embeddings.Name)
 	validateProviderName("vad", cfg.Providers.VAD.Name)
 	validateProviderName("audio", cfg.Providers.Audio.Name)
+	for i, fb := range cfg.Providers.TTSFallbacks {
+		if fb.Name == "" {
+			errs = append(errs, fmt.Errorf("providers.tts_fallbacks[%d].name is required", i))
+			continue
+		}
+		validateProviderName("tts", fb.Name)
+	}
+	npcLLMs := make(map[string]ProviderEntry, len(cfg.Providers.NPCLLMs))
+	for i, e := range cfg.Providers.NPCLLMs {
+		switch _, dup := npcLLMs[e.Name]; {
+		case e.Name == "":
+			errs = append(errs, fmt.Errorf("providers.npc_llms[%d].name is required", i))
+			continue
+		case e.Name == cfg.Providers.LLM.Name:
+			errs = append(errs, fmt.Errorf("providers.npc_llms[%d]: %q is already configured as providers.llm", i, e.Name))
+			continue
+		case dup:
+			errs = append(errs, fmt.Errorf("providers.npc_llms[%d]: duplicate provider %q", i, e.Name))
+			continue
+		}
+		npcLLMs[e.Name] = e
+		validateProviderName("llm", e.Name)
+	}
+	if len(cfg.Providers.TTSFallbacks) > 0 && cfg.Providers.TTS.Name == "" {
+		errs = append(errs, errors.New("providers.tts_fallbacks requires providers.tts to be configured"))
+	}
+	if f := cfg.Providers.TTSLanguageFallback; !f.IsValid() {
+		errs = append(errs, fmt.Errorf("providers.tts_language_fallback %q is invalid; valid values: default, provider", f))
+	}
 
 	// Provider availability warnings
 	if cfg.Providers.LLM.Name == "" && cfg.Providers.S2S.Name == "" {
@@ -81,12 +157,19 @@ func Validate(cfg *Config) error {
 		}
 	}
 
-	// Embeddings ↔ memory dimensions
-	if cfg.Providers.Embeddings.Name != "" && cfg.Memory.EmbeddingDimensions <= 0 {
-		slog.Warn("providers.embeddings is configured but memory.embedding_dimensions is not set; defaulting to 1536")
-	}
-
 	// Memory availability
+	if t := cfg.Memory.HistorySummaryThreshold; t < 0 || t == 1 {
+		errs = append(errs, fmt.Errorf("memory.history_summary_threshold must be 0 (disabled) or >= 2, got %d", t))
+	}
+	if m := cfg.Memory.DistanceMetric; m != "" && !m.IsValid() {
+		errs = append(errs, fmt.Errorf("memory.distance_metric %q is invalid; valid values: %s, %s, %s", m, memory.MetricCosine, memory.MetricL2, memory.MetricInnerProduct))
+	}
+	if r := cfg.Memory.SceneRecap; r.Every < 0 || r.MaxWords < 0 {
+		errs = append(errs, fmt.Errorf("memory.scene_recap values must be >= 0, got every=%d max_words=%d", r.Every, r.MaxWords))
+	}
+	if tf := cfg.Memory.TranscriptFilter; tf.MinWords < 0 || tf.MinChars < 0 {
+		errs = append(errs, fmt.Errorf("memory.transcript_filter thresholds must be >= 0, got min_words=%d min_chars=%d", tf.MinWords, tf.MinChars))
+	}
 	if cfg.Memory.PostgresDSN == "" && len(cfg.NPCs) > 0 {
 		slog.Warn("memory.postgres_dsn is empty; long-term memory will not be available for NPCs")
 	}
@@ -119,6 +202,18 @@ func Validate(cfg *Config) error {
 		if npc.Voice.PitchShift < -10 || npc.Voice.PitchShift > 10 {
 			errs = append(errs, fmt.Errorf("%s.voice.pitch_shift %.2f is out of range [-10, 10]", prefix, npc.Voice.PitchShift))
 		}
+		for _, s := range []struct {
+			key string
+			v   *float64
+		}{
+			{"stability", npc.Voice.Stability},
+			{"similarity_boost", npc.Voice.SimilarityBoost},
+			{"style", npc.Voice.Style},
+		} {
+			if s.v != nil && (*s.v < 0 || *s.v > 1) {
+				errs = append(errs, fmt.Errorf("%s.voice.%s %.2f is out of range [0, 1]", prefix, s.key, *s.v))
+			}
+		}
 
 		// Engine ↔ provider cross-validation
 		engine := npc.Engine
@@ -136,6 +231,107 @@ func Validate(cfg *Config) error {
 			}
 		}
 
+		if npc.MaxToolRounds < 0 {
+			errs = append(errs, fmt.Errorf("%s.max_tool_rounds must be >= 0, got %d", prefix, npc.MaxToolRounds))
+		}
+		if npc.AwarenessRadius < 0 {
+			errs = append(errs, fmt.Errorf("%s.awareness_radius must be >= 0, got %d", prefix, npc.AwarenessRadius))
+		}
+		if npc.StyleExemplars < 0 {
+			errs = append(errs, fmt.Errorf("%s.style_exemplars must be >= 0, got %d", prefix, npc.StyleExemplars))
+		}
+		if npc.IdleTimeoutMinutes < 0 {
+			errs = append(errs, fmt.Errorf("%s.idle_timeout_minutes must be >= 0, got %d", prefix, npc.IdleTimeoutMinutes))
+		}
+		if npc.MinResponseLatencyMs < 0 {
+			errs = append(errs, fmt.Errorf("%s.min_response_latency_ms must be >= 0, got %d", prefix, npc.MinResponseLatencyMs))
+		}
+		if c := npc.CascadeConfig; c != nil {
+			if c.RepeatWindow < 0 {
+				errs = append(errs, fmt.Errorf("%s.cascade.repeat_window must be >= 0, got %d", prefix, c.RepeatWindow))
+			}
+			if c.RepeatThreshold < 0 || c.RepeatThreshold > 1 {
+				errs = append(errs, fmt.Errorf("%s.cascade.repeat_threshold must be between 0 and 1, got %g", prefix, c.RepeatThreshold))
+			}
+			if c.MaxContinuationTokens < 0 {
+				errs = append(errs, fmt.Errorf("%s.cascade.max_continuation_tokens must be >= 0, got %d", prefix, c.MaxContinuationTokens))
+			}
+			for i, seq := range c.ContinuationStop {
+				if seq == "" {
+					errs = append(errs, fmt.Errorf("%s.cascade.continuation_stop[%d] must not be empty", prefix, i))
+				}
+			}
+		}
+		if _, err := enginepkg.LookupPostProcessors(npc.PostProcessors); err != nil {
+			errs = append(errs, fmt.Errorf("%s.post_processors: %w", prefix, err))
+		}
+		if npc.CascadeMode != "" {
+			slog.Warn("cascade_mode is deprecated and has no effect; choose the pipeline with engine",
+				"npc", npc.Name,
+				"cascade_mode", npc.CascadeMode,
+			)
+		}
+
+		// Stock responses
+		for _, intent := range slices.Sorted(maps.Keys(npc.StockResponses)) {
+			if !slices.Contains(StockIntents, intent) {
+				errs = append(errs, fmt.Errorf("%s.stock_responses: unknown intent %q, valid intents are %v", prefix, intent, StockIntents))
+				continue
+			}
+			for j, line := range npc.StockResponses[intent] {
+				if line.Text == "" {
+					errs = append(errs, fmt.Errorf("%s.stock_responses.%s[%d].text must not be empty", prefix, intent, j))
+				}
+				if line.Weight < 0 {
+					errs = append(errs, fmt.Errorf("%s.stock_responses.%s[%d].weight must be >= 0, got %d", prefix, intent, j, line.Weight))
+				}
+			}
+		}
+		if len(npc.StockResponses) > 0 && cfg.Providers.TTS.Name == "" {
+			errs = append(errs, fmt.Errorf("%s.stock_responses requires a TTS provider but providers.tts is not configured", prefix))
+		}
+
+		// s2s → cascade fallback
+		if fb := npc.S2SFallback; fb != nil {
+			if engine != EngineS2S {
+				errs = append(errs, fmt.Errorf("%s.s2s_fallback is only supported with engine %q", prefix, EngineS2S))
+			}
+			if cfg.Providers.LLM.Name == "" {
+				errs = append(errs, fmt.Errorf("%s.s2s_fallback requires an LLM provider but providers.llm is not configured", prefix))
+			}
+			if cfg.Providers.TTS.Name == "" {
+				errs = append(errs, fmt.Errorf("%s.s2s_fallback requires a TTS provider but providers.tts is not configured", prefix))
+			}
+			if fb.FailureThreshold < 0 {
+				errs = append(errs, fmt.Errorf("%s.s2s_fallback.failure_threshold must be >= 0, got %d", prefix, fb.FailureThreshold))
+			}
+			if fb.RetryAfterSeconds < 0 {
+				errs = append(errs, fmt.Errorf("%s.s2s_fallback.retry_after_seconds must be >= 0, got %d", prefix, fb.RetryAfterSeconds))
+			}
+			if fb.Voice.VoiceID == "" {
+				errs = append(errs, fmt.Errorf("%s.s2s_fallback.voice.voice_id is required: the s2s voice is not a providers.tts voice", prefix))
+			}
+		}
+
+		// Per-NPC LLM override
+		if o := npc.LLM; o != nil {
+			entry, extra := npcLLMs[o.Provider]
+			switch {
+			case o.Provider == "" && o.Model == "":
+				errs = append(errs, fmt.Errorf("%s.llm must set provider and/or model", prefix))
+			case o.Provider == "" && cfg.Providers.LLM.Name == "":
+				errs = append(errs, fmt.Errorf("%s.llm.model requires providers.llm or %s.llm.provider to be configured", prefix, prefix))
+			case o.Provider == "" || o.Provider == cfg.Providers.LLM.Name:
+			case !extra:
+				errs = append(errs, fmt.Errorf("%s.llm.provider %q is not configured; add it to providers.npc_llms with its credentials", prefix, o.Provider))
+			case o.Model == "" && entry.Model == "":
+				errs = append(errs, fmt.Errorf("%s.llm.model is required when providers.npc_llms entry %q sets no model", prefix, o.Provider))
+			}
+			if engine == EngineS2S && npc.S2SFallback == nil {
+				errs = append(errs, fmt.Errorf("%s.llm is not supported with engine %q unless s2s_fallback is enabled", prefix, engine))
+			}
+		}
+
 		// Voice provider ↔ TTS provider cross-validation
 		if npc.Voice.Provider != "" && cfg.Providers.TTS.Name != "" && npc.Voice.Provider != cfg.Providers.TTS.Name {
 			slog.Warn("NPC voice provider does not match configured TTS provider",
@@ -185,3 +381,46 @@ func validateProviderName(kind, name string) {
 		"known", known,
 	)
 }
+
+// validateVAD checks that all VAD thresholds lie within [0, 1] and that the
+// effective silence threshold of every speaker does not exceed its speech
+// threshold.
+func validateVAD(v VADConfig) []error {
+	var errs []error
+	check := func(field string, val float64) {
+		if val < 0 || val > 1 {
+			errs = append(errs, fmt.Errorf("%s must be in [0, 1], got %v", field, val))
+		}
+	}
+	ordered := func(prefix string, speech, silence float64) {
+		if speech == 0 {
+			speech = vad.DefaultSpeechThreshold
+		}
+		if silence == 0 {
+			silence = vad.DefaultSilenceThreshold
+		}
+		if silence > speech {
+			errs = append(errs, fmt.Errorf("%ssilence_threshold (%v) must not exceed speech_threshold (%v)", prefix, silence, speech))
+		}
+	}
+
+	check("vad.speech_threshold", v.SpeechThreshold)
+	check("vad.silence_threshold", v.SilenceThreshold)
+	ordered("vad.", v.SpeechThreshold, v.SilenceThreshold)
+
+	for _, id := range slices.Sorted(maps.Keys(v.Speakers)) {
+		t := v.Speakers[id]
+		prefix := fmt.Sprintf("vad.speakers[%q].", id)
+		check(prefix+"speech_threshold", t.SpeechThreshold)
+		check(prefix+"silence_threshold", t.SilenceThreshold)
+		speech, silence := v.SpeechThreshold, v.SilenceThreshold
+		if t.SpeechThreshold > 0 {
+			speech = t.SpeechThreshold
+		}
+		if t.SilenceThreshold > 0 {
+			silence = t.SilenceThreshold
+		}
+		ordered(prefix, speech, silence)
+	}
+	return errs
+}
@@ -7,20 +7,32 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/fsnotify/fsnotify"
 )
 
-// Watcher monitors a config file for changes and calls a callback when the
-// file is modified. It uses polling (not fsnotify) to keep dependencies minimal.
+// Watcher monitors a config file for changes. Each time the file's content
+// changes to a valid config, the new config is passed to the callback and
+// sent on [Watcher.Changes]. Invalid files are logged and skipped; the last
+// valid config stays current.
+//
+// The watcher is notified of writes through fsnotify. It watches the file's
+// directory rather than the file itself, so editors that save by renaming a
+// temporary file over the original are picked up too. The file is also
+// polled, which covers file systems without change notifications.
 type Watcher struct {
 	path     string
 	interval time.Duration
 	onChange func(old, new *Config)
+	changes  chan *Config
 
 	mu       sync.Mutex
 	current  *Config
 	done     chan struct{}
+	stopped  chan struct{}
 	stopOnce sync.Once
 
 	// last known file state for change detection
@@ -41,13 +53,16 @@ func WithInterval(d time.Duration) WatcherOption {
 }
 
 // NewWatcher creates a config file watcher. It loads the initial config
-// immediately and starts polling in a background goroutine.
+// immediately and starts watching in a background goroutine. onChange may be
+// nil when changes are read from [Watcher.Changes] instead.
 func NewWatcher(path string, onChange func(old, new *Config), opts ...WatcherOption) (*Watcher, error) {
 	w := &Watcher{
 		path:     path,
 		interval: 5 * time.Second,
 		onChange: onChange,
+		changes:  make(chan *Config, 1),
 		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
 	}
 	for _, opt := range opts {
 		opt(w)
@@ -62,10 +77,29 @@ func NewWatcher(path string, onChange func(old, new *Config), opts ...WatcherOpt
 	w.lastHash = hash
 	w.lastMtime = mtime
 
-	go w.poll()
+	// Without notifications the watcher still works by polling alone.
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Warn("config watcher: file notifications unavailable, polling only", "path", path, "err", err)
+		fsw = nil
+	} else if err := fsw.Add(filepath.Dir(path)); err != nil {
+		slog.Warn("config watcher: file notifications unavailable, polling only", "path", path, "err", err)
+		_ = fsw.Close()
+		fsw = nil
+	}
+
+	go w.run(fsw)
 	return w, nil
 }
 
+// Changes returns a channel that receives each newly loaded config. Only the
+// latest config is kept while the receiver is busy, so a slow receiver skips
+// intermediate versions but never misses the final one. The channel is
+// closed by [Watcher.Stop].
+func (w *Watcher) Changes() <-chan *Config {
+	return w.changes
+}
+
 // Current returns the most recently loaded valid config.
 func (w *Watcher) Current() *Config {
 	w.mu.Lock()
@@ -73,30 +107,60 @@ func (w *Watcher) Current() *Config {
 	return w.current
 }
 
-// Stop stops the file watcher.
+// Stop stops the file watcher and closes [Watcher.Changes]. It waits for a
+// running callback to return, so it must not be called from onChange.
 func (w *Watcher) Stop() {
 	w.stopOnce.Do(func() {
 		close(w.done)
 	})
+	<-w.stopped
 }
 
-// poll runs in a background goroutine, checking the config file periodically.
-func (w *Watcher) poll() {
+// run runs in a background goroutine, checking the config file whenever fsw
+// reports an event for it and at every polling interval. fsw may be nil.
+func (w *Watcher) run(fsw *fsnotify.Watcher) {
+	defer close(w.stopped)
+	defer close(w.changes)
+
+	var (
+		events <-chan fsnotify.Event
+		errs   <-chan error
+	)
+	if fsw != nil {
+		defer fsw.Close()
+		events, errs = fsw.Events, fsw.Errors
+	}
+
 	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
 
+	name := filepath.Clean(w.path)
 	for {
 		select {
 		case <-w.done:
 			return
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if filepath.Clean(ev.Name) == name && ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				w.reload()
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			slog.Warn("config watcher: file notification error", "path", w.path, "err", err)
 		case <-ticker.C:
 			w.check()
 		}
 	}
 }
 
-// check reads the config file and, if it has changed and is valid, calls
-// onChange and updates the current config.
+// check reloads the config file if its modification time has changed since
+// the last load.
 func (w *Watcher) check() {
 	// Quick mtime check first to avoid hashing unchanged files.
 	info, err := os.Stat(w.path)
@@ -112,8 +176,14 @@ func (w *Watcher) check() {
 	if info.ModTime().Equal(mtime) {
 		return
 	}
+	w.reload()
+}
 
-	// Mtime changed — read and hash.
+// reload reads the config file and, if its content has changed and is valid,
+// updates the current config, calls onChange and sends the config on
+// [Watcher.Changes]. Writes in quick succession can share a modification
+// time, so reload compares content hashes only.
+func (w *Watcher) reload() {
 	cfg, hash, newMtime, err := w.loadAndHash()
 	if err != nil {
 		slog.Warn("config watcher: failed to load config", "path", w.path, "err", err)
@@ -141,6 +211,14 @@ func (w *Watcher) check() {
 	if w.onChange != nil {
 		w.onChange(old, cfg)
 	}
+
+	// Replace a config the receiver has not picked up yet. Only this
+	// goroutine sends, so the second send cannot block.
+	select {
+	case <-w.changes:
+	default:
+	}
+	w.changes <- cfg
 }
 
 // loadAndHash reads the config file, parses + validates it, and returns the
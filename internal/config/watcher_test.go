@@ -231,3 +231,72 @@ func TestWatcher_TouchWithoutContentChange(t *testing.T) {
 		t.Errorf("callback should not fire for touch-only, got %d calls", calls)
 	}
 }
+
+func TestWatcher_Changes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		write func(t *testing.T, path string)
+	}{
+		{
+			name:  "in-place write",
+			write: func(t *testing.T, path string) { writeFile(t, path, watcherUpdatedYAML) },
+		},
+		{
+			name: "rename over the file",
+			write: func(t *testing.T, path string) {
+				tmp := path + ".tmp"
+				writeFile(t, tmp, watcherUpdatedYAML)
+				if err := os.Rename(tmp, path); err != nil {
+					t.Fatalf("failed to rename %q: %v", tmp, err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			cfgPath := filepath.Join(dir, "config.yaml")
+			writeFile(t, cfgPath, watcherValidYAML)
+
+			// A long interval makes sure the change is noticed through file
+			// notifications rather than polling.
+			w, err := config.NewWatcher(cfgPath, nil, config.WithInterval(time.Hour))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer w.Stop()
+
+			tc.write(t, cfgPath)
+
+			select {
+			case cfg := <-w.Changes():
+				if cfg.Server.LogLevel != config.LogDebug {
+					t.Errorf("log_level: got %q, want %q", cfg.Server.LogLevel, config.LogDebug)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("no config received within timeout")
+			}
+		})
+	}
+}
+
+func TestWatcher_StopClosesChanges(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	writeFile(t, cfgPath, watcherValidYAML)
+
+	w, err := config.NewWatcher(cfgPath, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Stop()
+
+	if _, ok := <-w.Changes(); ok {
+		t.Error("Changes() should be closed after Stop")
+	}
+}
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/MrWong99/glyphoxa/internal/discord"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// maxLintLines caps the number of issues listed by /graph lint. Long
+// messages can hit the embed description limit before that; see
+// formatIssues.
+const maxLintLines = 30
+
+// errNoIntegrityCheck is returned when the configured knowledge graph cannot
+// check its own integrity.
+var errNoIntegrityCheck = errors.New("knowledge graph does not support integrity checks")
+
+// GraphCommands handles the /graph slash command group.
+type GraphCommands struct {
+	perms *discord.PermissionChecker
+	// getGraph returns the knowledge graph to inspect, or nil if long-term
+	// memory is not configured.
+	getGraph func() memory.KnowledgeGraph
+}
+
+// NewGraphCommands creates a GraphCommands handler. getGraph may be nil, in
+// which case /graph lint reports that no graph is configured.
+func NewGraphCommands(perms *discord.PermissionChecker, getGraph func() memory.KnowledgeGraph) *GraphCommands {
+	return &GraphCommands{
+		perms:    perms,
+		getGraph: getGraph,
+	}
+}
+
+// Register registers all /graph subcommands with the router.
+func (gc *GraphCommands) Register(router *discord.CommandRouter) {
+	router.RegisterCommand("graph", gc.Definition(), func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		discord.RespondEphemeral(s, i, "Please use a subcommand: `/graph lint`.")
+	})
+	router.RegisterHandler("graph/lint", gc.handleLint)
+}
+
+// Definition returns the /graph ApplicationCommand for Discord registration.
+func (gc *GraphCommands) Definition() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "graph",
+		Description: "Inspect the campaign knowledge graph",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "lint",
+				Description: "Report dangling references, orphan entities, and conflicting relationships",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+		},
+	}
+}
+
+// handleLint handles /graph lint.
+func (gc *GraphCommands) handleLint(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !gc.perms.IsDM(i) {
+		discord.RespondEphemeral(s, i, "You need the DM role to inspect the knowledge graph.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	embed, err := gc.lint(ctx)
+	switch {
+	case errors.Is(err, errNoGraph):
+		discord.RespondEphemeral(s, i, "No knowledge graph is configured.")
+		return
+	case errors.Is(err, errNoIntegrityCheck):
+		discord.RespondEphemeral(s, i, "The configured knowledge graph does not support integrity checks.")
+		return
+	case err != nil:
+		discord.RespondError(s, i, err)
+		return
+	}
+	discord.RespondEmbed(s, i, embed)
+}
+
+// lint runs the integrity check and builds the /graph lint embed.
+func (gc *GraphCommands) lint(ctx context.Context) (*discordgo.MessageEmbed, error) {
+	var graph memory.KnowledgeGraph
+	if gc.getGraph != nil {
+		graph = gc.getGraph()
+	}
+	if graph == nil {
+		return nil, errNoGraph
+	}
+	checker, ok := graph.(memory.IntegrityChecker)
+	if !ok {
+		return nil, errNoIntegrityCheck
+	}
+
+	issues, err := checker.CheckIntegrity(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("check integrity: %w", err)
+	}
+
+	color := 0x2ECC71 // green
+	if len(issues) > 0 {
+		color = 0xE67E22 // orange
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Knowledge graph lint: %d issue(s)", len(issues)),
+		Description: formatIssues(issues),
+		Color:       color,
+	}, nil
+}
+
+// issueLabels maps issue kinds to the short labels shown by /graph lint.
+var issueLabels = map[memory.IssueKind]string{
+	memory.IssueDanglingRelationship:     "Dangling",
+	memory.IssueOrphanEntity:             "Orphan",
+	memory.IssueConflictingRelationships: "Conflict",
+}
+
+// formatIssues renders issues as one line each, in the order returned by the
+// checker. At most maxLintLines issues are listed, and no more than fit into
+// an embed description; the rest are counted in a closing "…and N more"
+// line.
+func formatIssues(issues []memory.Issue) string {
+	if len(issues) == 0 {
+		return "_No issues found._"
+	}
+
+	// Room kept for the closing line, sized for the largest possible count.
+	budget := maxEmbedDescriptionLen - len(fmt.Sprintf("\n_…and %d more_", len(issues)))
+	lines := make([]string, 0, min(len(issues), maxLintLines)+1)
+	size := 0
+	for _, issue := range issues[:min(len(issues), maxLintLines)] {
+		label, ok := issueLabels[issue.Kind]
+		if !ok {
+			label = string(issue.Kind)
+		}
+		line := fmt.Sprintf("**%s** %s", label, issue.Message)
+		if len(lines) > 0 {
+			size++ // newline separator
+		}
+		if size+len(line) > budget {
+			break
+		}
+		size += len(line)
+		lines = append(lines, line)
+	}
+	if more := len(issues) - len(lines); more > 0 {
+		lines = append(lines, fmt.Sprintf("_…and %d more_", more))
+	}
+	return strings.Join(lines, "\n")
+}
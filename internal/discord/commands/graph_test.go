@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/internal/discord"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	memorymock "github.com/MrWong99/glyphoxa/pkg/memory/mock"
+)
+
+func newGraphCommands(graph memory.KnowledgeGraph) *GraphCommands {
+	return NewGraphCommands(discord.NewPermissionChecker(""), func() memory.KnowledgeGraph { return graph })
+}
+
+func TestGraphLint_ListsIssues(t *testing.T) {
+	t.Parallel()
+
+	graph := &memorymock.KnowledgeGraph{
+		CheckIntegrityResult: []memory.Issue{
+			{Kind: memory.IssueDanglingRelationship, Message: `relationship "a" -[knows]-> "ghost" references missing entity "ghost"`},
+			{Kind: memory.IssueOrphanEntity, Message: `npc "Hermit" (hermit) has no relationships`},
+			{Kind: memory.IssueConflictingRelationships, Message: `"guild" and "watch" are linked by both "allied_with" and "enemy_of"`},
+		},
+	}
+	gc := newGraphCommands(graph)
+
+	embed, err := gc.lint(context.Background())
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if embed.Title != "Knowledge graph lint: 3 issue(s)" {
+		t.Errorf("Title = %q", embed.Title)
+	}
+	lines := strings.Split(embed.Description, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), embed.Description)
+	}
+	for i, prefix := range []string{"**Dangling** ", "**Orphan** ", "**Conflict** "} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line[%d] = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+	if graph.CallCount("CheckIntegrity") != 1 {
+		t.Errorf("CheckIntegrity calls = %d, want 1", graph.CallCount("CheckIntegrity"))
+	}
+}
+
+func TestGraphLint_Clean(t *testing.T) {
+	t.Parallel()
+
+	embed, err := newGraphCommands(&memorymock.KnowledgeGraph{}).lint(context.Background())
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if embed.Description != "_No issues found._" {
+		t.Errorf("Description = %q", embed.Description)
+	}
+}
+
+func TestGraphLint_Errors(t *testing.T) {
+	t.Parallel()
+
+	checkErr := errors.New("connection reset")
+	tests := []struct {
+		name string
+		gc   *GraphCommands
+		want error
+	}{
+		{
+			name: "no graph",
+			gc:   NewGraphCommands(discord.NewPermissionChecker(""), nil),
+			want: errNoGraph,
+		},
+		{
+			name: "no integrity check",
+			// Embedding only the interface hides CheckIntegrity.
+			gc:   newGraphCommands(struct{ memory.KnowledgeGraph }{&memorymock.KnowledgeGraph{}}),
+			want: errNoIntegrityCheck,
+		},
+		{
+			name: "check fails",
+			gc:   newGraphCommands(&memorymock.KnowledgeGraph{CheckIntegrityErr: checkErr}),
+			want: checkErr,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := tc.gc.lint(context.Background()); !errors.Is(err, tc.want) {
+				t.Errorf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatIssues_Truncates(t *testing.T) {
+	t.Parallel()
+
+	issues := make([]memory.Issue, maxLintLines+5)
+	for i := range issues {
+		issues[i] = memory.Issue{Kind: memory.IssueOrphanEntity, Message: "lonely"}
+	}
+	lines := strings.Split(formatIssues(issues), "\n")
+	if len(lines) != maxLintLines+1 {
+		t.Fatalf("got %d lines, want %d", len(lines), maxLintLines+1)
+	}
+	if lines[maxLintLines] != "_…and 5 more_" {
+		t.Errorf("last line = %q", lines[maxLintLines])
+	}
+}
+
+func TestFormatIssues_TruncatesLongMessages(t *testing.T) {
+	t.Parallel()
+
+	issues := make([]memory.Issue, 10)
+	for i := range issues {
+		issues[i] = memory.Issue{Kind: memory.IssueDanglingRelationship, Message: strings.Repeat("x", 1000)}
+	}
+	got := formatIssues(issues)
+	if len(got) > maxEmbedDescriptionLen {
+		t.Errorf("description is %d bytes, want at most %d", len(got), maxEmbedDescriptionLen)
+	}
+	lines := strings.Split(got, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 4 issues and the closing line", len(lines))
+	}
+	if lines[4] != "_…and 6 more_" {
+		t.Errorf("last line = %q", lines[4])
+	}
+}
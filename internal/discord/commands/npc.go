@@ -4,12 +4,17 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 
 	"github.com/MrWong99/glyphoxa/internal/agent/orchestrator"
 	"github.com/MrWong99/glyphoxa/internal/discord"
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/entity"
+	"github.com/MrWong99/glyphoxa/pkg/idgen"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
 )
 
 // NPCCommands handles /npc slash command group.
@@ -17,21 +22,38 @@ type NPCCommands struct {
 	perms *discord.PermissionChecker
 	// getOrch returns the current session's orchestrator, or nil if no session is active.
 	getOrch func() *orchestrator.Orchestrator
+	// getGraph returns the knowledge graph used by /npc knows and /npc teach,
+	// or nil if long-term memory is not configured.
+	getGraph func() memory.KnowledgeGraph
+	// ids generates the IDs of entities created by /npc teach. Nil means
+	// idgen.Default.
+	ids idgen.Generator
+
+	mu           sync.Mutex
+	pendingTeach map[string]pendingTeachRequest // keyed by confirmation token
 }
 
-// NewNPCCommands creates an NPCCommands handler.
-func NewNPCCommands(perms *discord.PermissionChecker, getOrch func() *orchestrator.Orchestrator) *NPCCommands {
+// NewNPCCommands creates an NPCCommands handler. getGraph may be nil, in
+// which case /npc knows and /npc teach report that no graph is configured.
+func NewNPCCommands(perms *discord.PermissionChecker, getOrch func() *orchestrator.Orchestrator, getGraph func() memory.KnowledgeGraph) *NPCCommands {
 	return &NPCCommands{
-		perms:   perms,
-		getOrch: getOrch,
+		perms:    perms,
+		getOrch:  getOrch,
+		getGraph: getGraph,
 	}
 }
 
+// SetIDGenerator sets the generator for the IDs of entities that /npc teach
+// adds to the knowledge graph. Defaults to [idgen.Default].
+func (nc *NPCCommands) SetIDGenerator(g idgen.Generator) {
+	nc.ids = g
+}
+
 // Register registers all /npc subcommands with the router.
 func (nc *NPCCommands) Register(router *discord.CommandRouter) {
 	def := nc.Definition()
 	router.RegisterCommand("npc", def, func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		discord.RespondEphemeral(s, i, "Please use a subcommand: `/npc list`, `/npc mute`, `/npc unmute`, `/npc speak`, `/npc muteall`, `/npc unmuteall`.")
+		discord.RespondEphemeral(s, i, "Please use a subcommand: `/npc list`, `/npc mute`, `/npc unmute`, `/npc speak`, `/npc muteall`, `/npc unmuteall`, `/npc knows`, `/npc teach`, `/npc event`.")
 	})
 	router.RegisterHandler("npc/list", nc.handleList)
 	router.RegisterHandler("npc/mute", nc.handleMute)
@@ -39,10 +61,19 @@ func (nc *NPCCommands) Register(router *discord.CommandRouter) {
 	router.RegisterHandler("npc/speak", nc.handleSpeak)
 	router.RegisterHandler("npc/muteall", nc.handleMuteAll)
 	router.RegisterHandler("npc/unmuteall", nc.handleUnmuteAll)
+	router.RegisterHandler("npc/knows", nc.handleKnows)
+	router.RegisterHandler("npc/teach", nc.handleTeach)
+	router.RegisterHandler("npc/event", nc.handleEvent)
 
 	router.RegisterAutocomplete("npc/mute", nc.handleAutocomplete)
 	router.RegisterAutocomplete("npc/unmute", nc.handleAutocomplete)
 	router.RegisterAutocomplete("npc/speak", nc.handleAutocomplete)
+	router.RegisterAutocomplete("npc/knows", nc.handleAutocomplete)
+	router.RegisterAutocomplete("npc/teach", nc.handleAutocomplete)
+	router.RegisterAutocomplete("npc/event", nc.handleAutocomplete)
+
+	router.RegisterComponentPrefix(npcTeachCreatePrefix, nc.handleTeachCreate)
+	router.RegisterComponentPrefix(npcTeachCancelPrefix, nc.handleTeachCancel)
 }
 
 // Definition returns the /npc ApplicationCommand for Discord registration.
@@ -114,6 +145,83 @@ func (nc *NPCCommands) Definition() *discordgo.ApplicationCommand {
 				Description: "Unmute all NPCs",
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
 			},
+			{
+				Name:        "knows",
+				Description: "List an NPC's knowledge graph relationships",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:         "name",
+						Description:  "NPC name",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Required:     true,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Name:        "teach",
+				Description: "Add a DM-confirmed relationship to an NPC's knowledge",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:         "name",
+						Description:  "NPC name",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Required:     true,
+						Autocomplete: true,
+					},
+					{
+						Name:        "rel",
+						Description: "Relationship type (e.g. KNOWS, ALLY_OF, FEARS)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+					{
+						Name:        "target",
+						Description: "Name of the related entity",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+					{
+						Name:        "target_type",
+						Description: "Entity type used if the target has to be created (default: npc)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "NPC", Value: string(entity.EntityNPC)},
+							{Name: "Location", Value: string(entity.EntityLocation)},
+							{Name: "Item", Value: string(entity.EntityItem)},
+							{Name: "Faction", Value: string(entity.EntityFaction)},
+							{Name: "Quest", Value: string(entity.EntityQuest)},
+							{Name: "Lore", Value: string(entity.EntityLore)},
+						},
+					},
+				},
+			},
+			{
+				Name:        "event",
+				Description: "Tell NPCs about something that just happened in the scene",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "text",
+						Description: "What happened (e.g. The tavern is on fire!)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+					{
+						Name:        "urgent",
+						Description: "Cut off NPCs mid-reply so they react at once (default: false)",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+					},
+					{
+						Name:         "name",
+						Description:  "Only tell this NPC (default: every unmuted NPC)",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Autocomplete: true,
+					},
+				},
+			},
 		},
 	}
 }
@@ -244,6 +352,51 @@ func (nc *NPCCommands) handleSpeak(s *discordgo.Session, i *discordgo.Interactio
 	discord.RespondEphemeral(s, i, fmt.Sprintf("**%s** is speaking: %q", a.Name(), text))
 }
 
+// handleEvent handles /npc event <text> [urgent] [name].
+func (nc *NPCCommands) handleEvent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !nc.perms.IsDM(i) {
+		discord.RespondEphemeral(s, i, "You need the DM role to manage NPCs.")
+		return
+	}
+
+	orch := nc.getOrch()
+	if orch == nil {
+		discord.RespondEphemeral(s, i, "No active session.")
+		return
+	}
+
+	update := engine.ContextUpdate{
+		Scene:    subcommandStringOption(i, "text"),
+		Priority: subcommandBoolOption(i, "urgent"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	target := "every unmuted NPC"
+	if name := subcommandStringOption(i, "name"); name != "" {
+		a := orch.AgentByName(name)
+		if a == nil {
+			discord.RespondEphemeral(s, i, fmt.Sprintf("NPC %q not found.", name))
+			return
+		}
+		if err := orch.InjectContext(ctx, a.ID(), update); err != nil {
+			discord.RespondError(s, i, err)
+			return
+		}
+		target = "**" + a.Name() + "**"
+	} else if err := orch.BroadcastContext(ctx, update); err != nil {
+		discord.RespondError(s, i, err)
+		return
+	}
+
+	if update.Priority {
+		discord.RespondEphemeral(s, i, fmt.Sprintf("Told %s at once: %q", target, update.Scene))
+		return
+	}
+	discord.RespondEphemeral(s, i, fmt.Sprintf("Told %s from their next reply on: %q", target, update.Scene))
+}
+
 // handleMuteAll handles /npc muteall.
 func (nc *NPCCommands) handleMuteAll(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if !nc.perms.IsDM(i) {
@@ -279,7 +432,7 @@ func (nc *NPCCommands) handleUnmuteAll(s *discordgo.Session, i *discordgo.Intera
 }
 
 // handleAutocomplete provides autocomplete for the "name" option across
-// /npc mute, /npc unmute, and /npc speak.
+// /npc mute, /npc unmute, /npc speak, /npc knows, and /npc teach.
 func (nc *NPCCommands) handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	orch := nc.getOrch()
 	if orch == nil {
@@ -325,6 +478,17 @@ func (nc *NPCCommands) handleAutocomplete(s *discordgo.Session, i *discordgo.Int
 	})
 }
 
+// subcommandBoolOption returns the boolean value of the named option of the
+// invoked subcommand, or false if it was not given.
+func subcommandBoolOption(i *discordgo.InteractionCreate, name string) bool {
+	for _, opt := range subcommandOptions(i) {
+		if opt.Name == name {
+			return opt.BoolValue()
+		}
+	}
+	return false
+}
+
 // subcommandStringOption extracts a string option value from a subcommand interaction.
 func subcommandStringOption(i *discordgo.InteractionCreate, name string) string {
 	data := i.ApplicationCommandData()
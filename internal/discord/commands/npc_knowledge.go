@@ -0,0 +1,397 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/MrWong99/glyphoxa/internal/discord"
+	"github.com/MrWong99/glyphoxa/internal/entity"
+	"github.com/MrWong99/glyphoxa/pkg/idgen"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+const (
+	npcTeachCreatePrefix = "npc_teach_create:"
+	npcTeachCancelPrefix = "npc_teach_cancel:"
+
+	// maxKnowsLines caps the number of relationships listed by /npc knows so
+	// the embed stays within Discord's 4096-character description limit.
+	maxKnowsLines = 40
+
+	// teachConfirmTTL is how long a /npc teach confirmation prompt can be
+	// answered. It matches the lifetime of the interaction token that sent
+	// the prompt.
+	teachConfirmTTL = 15 * time.Minute
+)
+
+// teachRequest is a pending /npc teach mutation.
+type teachRequest struct {
+	npcName    string
+	relType    string
+	targetName string
+	targetType string
+}
+
+// pendingTeachRequest is a teachRequest awaiting the DM's confirmation.
+type pendingTeachRequest struct {
+	req     teachRequest
+	expires time.Time
+}
+
+var (
+	// errNoGraph is returned when no knowledge graph is configured.
+	errNoGraph = errors.New("knowledge graph is not configured")
+
+	// errNPCNotInGraph is returned by knows when the NPC has no graph entity.
+	errNPCNotInGraph = errors.New("npc is not in the knowledge graph")
+)
+
+// handleKnows handles /npc knows <name>.
+func (nc *NPCCommands) handleKnows(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !nc.perms.IsDM(i) {
+		discord.RespondEphemeral(s, i, "You need the DM role to manage NPCs.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	name := subcommandStringOption(i, "name")
+	embed, err := nc.knows(ctx, name)
+	switch {
+	case errors.Is(err, errNoGraph):
+		discord.RespondEphemeral(s, i, "No knowledge graph is configured.")
+		return
+	case errors.Is(err, errNPCNotInGraph):
+		discord.RespondEphemeral(s, i, fmt.Sprintf("NPC %q is not in the knowledge graph.", name))
+		return
+	case err != nil:
+		discord.RespondError(s, i, err)
+		return
+	}
+	discord.RespondEmbed(s, i, embed)
+}
+
+// knows builds the /npc knows embed listing the relationships of the NPC
+// called name.
+func (nc *NPCCommands) knows(ctx context.Context, name string) (*discordgo.MessageEmbed, error) {
+	graph := nc.graph()
+	if graph == nil {
+		return nil, errNoGraph
+	}
+
+	npc, err := findGraphEntity(ctx, graph, name, string(entity.EntityNPC))
+	if err != nil {
+		return nil, err
+	}
+	if npc == nil {
+		return nil, fmt.Errorf("%q: %w", name, errNPCNotInGraph)
+	}
+
+	snap, err := graph.IdentitySnapshot(ctx, npc.ID)
+	if err != nil {
+		return nil, fmt.Errorf("identity snapshot: %w", err)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("What %s knows", npc.Name),
+		Description: formatKnowledge(snap),
+		Color:       0x5865F2,
+	}, nil
+}
+
+// formatKnowledge renders snap's relationships as one line per edge,
+// outgoing edges first. DM-confirmed facts are marked with ✅.
+func formatKnowledge(snap *memory.NPCIdentity) string {
+	if len(snap.Relationships) == 0 {
+		return "_No known relationships._"
+	}
+
+	names := make(map[string]memory.Entity, len(snap.RelatedEntities))
+	for _, e := range snap.RelatedEntities {
+		names[e.ID] = e
+	}
+	describe := func(id string) string {
+		e, ok := names[id]
+		if !ok {
+			return fmt.Sprintf("`%s`", id)
+		}
+		return fmt.Sprintf("**%s** (%s)", e.Name, e.Type)
+	}
+
+	var outgoing, incoming []string
+	for _, r := range snap.Relationships {
+		mark := ""
+		if r.Provenance.DMConfirmed {
+			mark = " ✅"
+		}
+		if r.SourceID == snap.Entity.ID {
+			outgoing = append(outgoing, fmt.Sprintf("→ `%s` %s%s", r.RelType, describe(r.TargetID), mark))
+		} else {
+			incoming = append(incoming, fmt.Sprintf("← %s `%s`%s", describe(r.SourceID), r.RelType, mark))
+		}
+	}
+
+	lines := append(outgoing, incoming...)
+	if len(lines) > maxKnowsLines {
+		more := len(lines) - maxKnowsLines
+		lines = append(lines[:maxKnowsLines], fmt.Sprintf("_…and %d more_", more))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleTeach handles /npc teach <name> <rel> <target> [target_type].
+func (nc *NPCCommands) handleTeach(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !nc.perms.IsDM(i) {
+		discord.RespondEphemeral(s, i, "You need the DM role to manage NPCs.")
+		return
+	}
+
+	req := teachRequest{
+		npcName:    subcommandStringOption(i, "name"),
+		relType:    normaliseRelType(subcommandStringOption(i, "rel")),
+		targetName: subcommandStringOption(i, "target"),
+		targetType: subcommandStringOption(i, "target_type"),
+	}
+	if req.targetType == "" {
+		req.targetType = string(entity.EntityNPC)
+	}
+	if req.npcName == "" || req.relType == "" || req.targetName == "" {
+		discord.RespondEphemeral(s, i, "Please provide an NPC name, a relationship, and a target.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	missing, err := nc.teach(ctx, req, false)
+	if err != nil {
+		discord.RespondError(s, i, err)
+		return
+	}
+	if len(missing) == 0 {
+		discord.RespondEphemeral(s, i, fmt.Sprintf("**%s** now knows: `%s` → **%s** (DM confirmed).", req.npcName, req.relType, req.targetName))
+		return
+	}
+
+	token, err := nc.storePendingTeach(req)
+	if err != nil {
+		discord.RespondError(s, i, err)
+		return
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "Unknown Entities",
+				Description: fmt.Sprintf("Not in the knowledge graph: %s. Create them and teach the relationship?", strings.Join(missing, ", ")),
+				Color:       0xFFAA00,
+			}},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Cancel",
+						Style:    discordgo.SecondaryButton,
+						CustomID: npcTeachCancelPrefix + token,
+					},
+					discordgo.Button{
+						Label:    "Create & Teach",
+						Style:    discordgo.PrimaryButton,
+						CustomID: npcTeachCreatePrefix + token,
+					},
+				}},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Warn("discord: failed to send teach confirmation", "err", err)
+	}
+}
+
+// handleTeachCreate handles the "Create & Teach" button.
+func (nc *NPCCommands) handleTeachCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !nc.perms.IsDM(i) {
+		discord.RespondEphemeral(s, i, "You need the DM role to manage NPCs.")
+		return
+	}
+
+	token := strings.TrimPrefix(i.MessageComponentData().CustomID, npcTeachCreatePrefix)
+	req, ok := nc.takePendingTeach(token)
+	if !ok {
+		discord.RespondEphemeral(s, i, "This request has expired. Please run `/npc teach` again.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := nc.teach(ctx, req, true); err != nil {
+		discord.RespondError(s, i, err)
+		return
+	}
+	discord.RespondEphemeral(s, i, fmt.Sprintf("Created missing entities. **%s** now knows: `%s` → **%s** (DM confirmed).", req.npcName, req.relType, req.targetName))
+}
+
+// handleTeachCancel handles the "Cancel" button.
+func (nc *NPCCommands) handleTeachCancel(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	nc.takePendingTeach(strings.TrimPrefix(i.MessageComponentData().CustomID, npcTeachCancelPrefix))
+	discord.RespondEphemeral(s, i, "Teach cancelled.")
+}
+
+// teach adds the DM-confirmed relationship described by req. When either
+// endpoint is missing from the graph and create is false, teach returns the
+// missing names without mutating the graph. When create is true, missing
+// endpoints are created first: the NPC as type "npc", the target as
+// req.targetType.
+func (nc *NPCCommands) teach(ctx context.Context, req teachRequest, create bool) ([]string, error) {
+	graph := nc.graph()
+	if graph == nil {
+		return nil, errNoGraph
+	}
+
+	npc, err := findGraphEntity(ctx, graph, req.npcName, string(entity.EntityNPC))
+	if err != nil {
+		return nil, err
+	}
+	target, err := findGraphEntity(ctx, graph, req.targetName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	if npc == nil {
+		missing = append(missing, fmt.Sprintf("NPC **%s**", req.npcName))
+	}
+	if target == nil {
+		missing = append(missing, fmt.Sprintf("%s **%s**", req.targetType, req.targetName))
+	}
+	if len(missing) > 0 && !create {
+		return missing, nil
+	}
+
+	if npc == nil {
+		if npc, err = nc.createGraphEntity(ctx, graph, req.npcName, string(entity.EntityNPC)); err != nil {
+			return nil, err
+		}
+	}
+	if target == nil {
+		if target, err = nc.createGraphEntity(ctx, graph, req.targetName, req.targetType); err != nil {
+			return nil, err
+		}
+	}
+
+	rel := memory.Relationship{
+		SourceID: npc.ID,
+		TargetID: target.ID,
+		RelType:  req.relType,
+		Provenance: memory.Provenance{
+			Timestamp:   time.Now(),
+			Confidence:  1.0,
+			Source:      "stated",
+			DMConfirmed: true,
+		},
+	}
+	if err := graph.AddRelationship(ctx, rel); err != nil {
+		return nil, fmt.Errorf("add relationship: %w", err)
+	}
+	return nil, nil
+}
+
+// graph returns the configured knowledge graph, or nil.
+func (nc *NPCCommands) graph() memory.KnowledgeGraph {
+	if nc.getGraph == nil {
+		return nil
+	}
+	return nc.getGraph()
+}
+
+// storePendingTeach remembers req until the DM answers the confirmation
+// prompt, for at most teachConfirmTTL, and returns the token identifying it.
+// Requests whose prompt was never answered are dropped once they expire.
+func (nc *NPCCommands) storePendingTeach(req teachRequest) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	now := time.Now()
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	if nc.pendingTeach == nil {
+		nc.pendingTeach = make(map[string]pendingTeachRequest)
+	}
+	for t, p := range nc.pendingTeach {
+		if now.After(p.expires) {
+			delete(nc.pendingTeach, t)
+		}
+	}
+	nc.pendingTeach[token] = pendingTeachRequest{req: req, expires: now.Add(teachConfirmTTL)}
+	return token, nil
+}
+
+// takePendingTeach removes and returns the pending request for token. It
+// reports false if there is none or it has expired.
+func (nc *NPCCommands) takePendingTeach(token string) (teachRequest, bool) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	p, ok := nc.pendingTeach[token]
+	delete(nc.pendingTeach, token)
+	if !ok || time.Now().After(p.expires) {
+		return teachRequest{}, false
+	}
+	return p.req, true
+}
+
+// findGraphEntity returns the entity whose name matches name
+// case-insensitively, restricted to entityType when non-empty. It returns
+// (nil, nil) when no entity matches.
+func findGraphEntity(ctx context.Context, graph memory.KnowledgeGraph, name, entityType string) (*memory.Entity, error) {
+	found, err := graph.FindEntities(ctx, memory.EntityFilter{Type: entityType, Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("find entity %q: %w", name, err)
+	}
+	for idx := range found {
+		if !strings.EqualFold(found[idx].Name, name) {
+			continue
+		}
+		if entityType != "" && found[idx].Type != entityType {
+			continue
+		}
+		return &found[idx], nil
+	}
+	return nil, nil
+}
+
+// createGraphEntity adds a new entity with a generated ID to graph.
+func (nc *NPCCommands) createGraphEntity(ctx context.Context, graph memory.KnowledgeGraph, name, entityType string) (*memory.Entity, error) {
+	ids := nc.ids
+	if ids == nil {
+		ids = idgen.Default()
+	}
+	e := memory.Entity{
+		ID:         ids.NewID(),
+		Type:       entityType,
+		Name:       name,
+		Attributes: map[string]any{},
+	}
+	if err := graph.AddEntity(ctx, e); err != nil {
+		return nil, fmt.Errorf("create entity %q: %w", name, err)
+	}
+	return &e, nil
+}
+
+// normaliseRelType converts free-form DM input such as "knows about" into the
+// upper-snake-case relationship convention used by the graph (KNOWS_ABOUT).
+func normaliseRelType(rel string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(strings.ReplaceAll(rel, "-", " ")), "_"))
+}
@@ -0,0 +1,296 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/discord"
+	"github.com/MrWong99/glyphoxa/pkg/idgen"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	memorymock "github.com/MrWong99/glyphoxa/pkg/memory/mock"
+)
+
+// knowledgeFixture returns a graph mock containing Grimjaw, the tavern he
+// works at, and a guild that distrusts him.
+func knowledgeFixture() *memorymock.KnowledgeGraph {
+	grimjaw := memory.Entity{ID: "grimjaw", Type: "npc", Name: "Grimjaw"}
+	tavern := memory.Entity{ID: "tavern", Type: "location", Name: "The Rusty Tankard"}
+	guild := memory.Entity{ID: "guild", Type: "faction", Name: "Thieves Guild"}
+	return &memorymock.KnowledgeGraph{
+		FindEntitiesResult: []memory.Entity{grimjaw, tavern, guild},
+		IdentitySnapshotResult: &memory.NPCIdentity{
+			Entity: grimjaw,
+			Relationships: []memory.Relationship{
+				{SourceID: "guild", TargetID: "grimjaw", RelType: "DISTRUSTS"},
+				{SourceID: "grimjaw", TargetID: "tavern", RelType: "WORKS_AT", Provenance: memory.Provenance{DMConfirmed: true}},
+			},
+			RelatedEntities: []memory.Entity{tavern, guild},
+		},
+	}
+}
+
+func newKnowledgeCommands(graph memory.KnowledgeGraph) *NPCCommands {
+	return NewNPCCommands(discord.NewPermissionChecker(""), nil, func() memory.KnowledgeGraph { return graph })
+}
+
+// callsOf returns the recorded calls of graph for method.
+func callsOf(graph *memorymock.KnowledgeGraph, method string) []memorymock.Call {
+	var out []memorymock.Call
+	for _, c := range graph.Calls() {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func TestNPCKnows_ListingFormat(t *testing.T) {
+	t.Parallel()
+
+	graph := knowledgeFixture()
+	nc := newKnowledgeCommands(graph)
+
+	embed, err := nc.knows(context.Background(), "grimjaw")
+	if err != nil {
+		t.Fatalf("knows: %v", err)
+	}
+	if embed.Title != "What Grimjaw knows" {
+		t.Errorf("Title = %q, want %q", embed.Title, "What Grimjaw knows")
+	}
+
+	// Outgoing edges first, DM-confirmed facts marked.
+	want := "→ `WORKS_AT` **The Rusty Tankard** (location) ✅\n" +
+		"← **Thieves Guild** (faction) `DISTRUSTS`"
+	if embed.Description != want {
+		t.Errorf("Description =\n%s\nwant\n%s", embed.Description, want)
+	}
+
+	snaps := callsOf(graph, "IdentitySnapshot")
+	if len(snaps) != 1 || snaps[0].Args[0] != "grimjaw" {
+		t.Errorf("IdentitySnapshot calls = %+v, want one for %q", snaps, "grimjaw")
+	}
+}
+
+func TestNPCKnows_Errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no graph", func(t *testing.T) {
+		t.Parallel()
+		nc := NewNPCCommands(discord.NewPermissionChecker(""), nil, nil)
+		if _, err := nc.knows(context.Background(), "Grimjaw"); !errors.Is(err, errNoGraph) {
+			t.Errorf("err = %v, want errNoGraph", err)
+		}
+	})
+
+	t.Run("npc not in graph", func(t *testing.T) {
+		t.Parallel()
+		nc := newKnowledgeCommands(knowledgeFixture())
+		// A location with a matching name is not an NPC.
+		if _, err := nc.knows(context.Background(), "The Rusty Tankard"); !errors.Is(err, errNPCNotInGraph) {
+			t.Errorf("err = %v, want errNPCNotInGraph", err)
+		}
+	})
+}
+
+func TestFormatKnowledge_Empty(t *testing.T) {
+	t.Parallel()
+
+	got := formatKnowledge(&memory.NPCIdentity{Entity: memory.Entity{ID: "x"}})
+	if got != "_No known relationships._" {
+		t.Errorf("formatKnowledge = %q", got)
+	}
+}
+
+func TestFormatKnowledge_Truncates(t *testing.T) {
+	t.Parallel()
+
+	snap := &memory.NPCIdentity{Entity: memory.Entity{ID: "npc"}}
+	for range maxKnowsLines + 5 {
+		snap.Relationships = append(snap.Relationships, memory.Relationship{SourceID: "npc", TargetID: "ghost", RelType: "KNOWS"})
+	}
+	lines := strings.Split(formatKnowledge(snap), "\n")
+	if len(lines) != maxKnowsLines+1 {
+		t.Fatalf("got %d lines, want %d", len(lines), maxKnowsLines+1)
+	}
+	if lines[maxKnowsLines] != "_…and 5 more_" {
+		t.Errorf("last line = %q", lines[maxKnowsLines])
+	}
+	// Unknown related entities fall back to their ID.
+	if !strings.Contains(lines[0], "`ghost`") {
+		t.Errorf("line[0] = %q, want ID fallback", lines[0])
+	}
+}
+
+func TestNPCTeach_ExistingEntities(t *testing.T) {
+	t.Parallel()
+
+	graph := knowledgeFixture()
+	nc := newKnowledgeCommands(graph)
+
+	missing, err := nc.teach(context.Background(), teachRequest{
+		npcName:    "Grimjaw",
+		relType:    normaliseRelType("member of"),
+		targetName: "thieves guild",
+		targetType: "npc",
+	}, false)
+	if err != nil {
+		t.Fatalf("teach: %v", err)
+	}
+	if len(missing) != 0 {
+		t.Fatalf("missing = %v, want none", missing)
+	}
+
+	if n := len(callsOf(graph, "AddEntity")); n != 0 {
+		t.Errorf("AddEntity calls = %d, want 0", n)
+	}
+	adds := callsOf(graph, "AddRelationship")
+	if len(adds) != 1 {
+		t.Fatalf("AddRelationship calls = %d, want 1", len(adds))
+	}
+	rel := adds[0].Args[0].(memory.Relationship)
+	if rel.SourceID != "grimjaw" || rel.TargetID != "guild" || rel.RelType != "MEMBER_OF" {
+		t.Errorf("relationship = %s -%s-> %s, want grimjaw -MEMBER_OF-> guild", rel.SourceID, rel.RelType, rel.TargetID)
+	}
+	if !rel.Provenance.DMConfirmed {
+		t.Error("taught relationship must be DM confirmed")
+	}
+	if rel.Provenance.Confidence != 1.0 {
+		t.Errorf("Confidence = %v, want 1.0", rel.Provenance.Confidence)
+	}
+}
+
+func TestNPCTeach_MissingTarget(t *testing.T) {
+	t.Parallel()
+
+	graph := knowledgeFixture()
+	nc := newKnowledgeCommands(graph)
+	nc.SetIDGenerator(idgen.Func(func() string { return "npc-black-dragon" }))
+	req := teachRequest{npcName: "Grimjaw", relType: "FEARS", targetName: "The Black Dragon", targetType: "npc"}
+
+	// Without confirmation nothing is written and the missing entity is reported.
+	missing, err := nc.teach(context.Background(), req, false)
+	if err != nil {
+		t.Fatalf("teach: %v", err)
+	}
+	if len(missing) != 1 || !strings.Contains(missing[0], "The Black Dragon") {
+		t.Fatalf("missing = %v, want [The Black Dragon]", missing)
+	}
+	if n := len(callsOf(graph, "AddEntity")) + len(callsOf(graph, "AddRelationship")); n != 0 {
+		t.Fatalf("graph mutated %d times before confirmation", n)
+	}
+
+	// After confirmation the target is created and linked.
+	if _, err := nc.teach(context.Background(), req, true); err != nil {
+		t.Fatalf("teach create: %v", err)
+	}
+	created := callsOf(graph, "AddEntity")
+	if len(created) != 1 {
+		t.Fatalf("AddEntity calls = %d, want 1", len(created))
+	}
+	dragon := created[0].Args[0].(memory.Entity)
+	if dragon.Name != "The Black Dragon" || dragon.Type != "npc" || dragon.ID != "npc-black-dragon" {
+		t.Errorf("created entity = %+v", dragon)
+	}
+	adds := callsOf(graph, "AddRelationship")
+	if len(adds) != 1 || adds[0].Args[0].(memory.Relationship).TargetID != dragon.ID {
+		t.Errorf("AddRelationship calls = %+v, want edge to created entity", adds)
+	}
+}
+
+func TestNPCTeach_GraphError(t *testing.T) {
+	t.Parallel()
+
+	graph := knowledgeFixture()
+	graph.AddRelationshipErr = errors.New("db down")
+	nc := newKnowledgeCommands(graph)
+
+	_, err := nc.teach(context.Background(), teachRequest{npcName: "Grimjaw", relType: "KNOWS", targetName: "Thieves Guild"}, false)
+	if err == nil || !strings.Contains(err.Error(), "db down") {
+		t.Errorf("err = %v, want wrapped graph error", err)
+	}
+}
+
+func TestNPCTeach_PendingRequests(t *testing.T) {
+	t.Parallel()
+
+	nc := newKnowledgeCommands(nil)
+	req := teachRequest{npcName: "Grimjaw", relType: "KNOWS", targetName: "Elara"}
+
+	token, err := nc.storePendingTeach(req)
+	if err != nil {
+		t.Fatalf("storePendingTeach: %v", err)
+	}
+	if len(npcTeachCreatePrefix+token) > 100 {
+		t.Errorf("custom ID %q exceeds Discord's 100-character limit", npcTeachCreatePrefix+token)
+	}
+	got, ok := nc.takePendingTeach(token)
+	if !ok || got != req {
+		t.Errorf("takePendingTeach = %+v, %v; want %+v, true", got, ok, req)
+	}
+	if _, ok := nc.takePendingTeach(token); ok {
+		t.Error("pending request should be consumed after the first take")
+	}
+}
+
+func TestNPCTeach_PendingRequestsExpire(t *testing.T) {
+	t.Parallel()
+
+	nc := newKnowledgeCommands(nil)
+	req := teachRequest{npcName: "Grimjaw", relType: "KNOWS", targetName: "Elara"}
+
+	stale, err := nc.storePendingTeach(req)
+	if err != nil {
+		t.Fatalf("storePendingTeach: %v", err)
+	}
+	expire := func(token string) {
+		nc.mu.Lock()
+		defer nc.mu.Unlock()
+		p := nc.pendingTeach[token]
+		p.expires = time.Now().Add(-time.Second)
+		nc.pendingTeach[token] = p
+	}
+
+	expire(stale)
+	if _, ok := nc.takePendingTeach(stale); ok {
+		t.Error("takePendingTeach returned an expired request")
+	}
+
+	// Storing a new request drops the expired ones nobody answered.
+	stale, _ = nc.storePendingTeach(req)
+	expire(stale)
+	fresh, err := nc.storePendingTeach(req)
+	if err != nil {
+		t.Fatalf("storePendingTeach: %v", err)
+	}
+	nc.mu.Lock()
+	_, kept := nc.pendingTeach[stale]
+	n := len(nc.pendingTeach)
+	nc.mu.Unlock()
+	if kept || n != 1 {
+		t.Errorf("pending requests = %d, stale kept = %v; want only the fresh one", n, kept)
+	}
+	if got, ok := nc.takePendingTeach(fresh); !ok || got != req {
+		t.Errorf("takePendingTeach = %+v, %v; want %+v, true", got, ok, req)
+	}
+}
+
+func TestNormaliseRelType(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"knows":          "KNOWS",
+		"member of":      "MEMBER_OF",
+		"  ally-of  ":    "ALLY_OF",
+		"LOCATED_AT":     "LOCATED_AT",
+		"owes  money to": "OWES_MONEY_TO",
+		"":               "",
+	}
+	for in, want := range tests {
+		if got := normaliseRelType(in); got != want {
+			t.Errorf("normaliseRelType(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
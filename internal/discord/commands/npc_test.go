@@ -18,14 +18,14 @@ func newTestOrchestrator(agents ...agent.NPCAgent) *orchestrator.Orchestrator {
 func TestNPCDefinition(t *testing.T) {
 	t.Parallel()
 
-	nc := NewNPCCommands(discord.NewPermissionChecker(""), nil)
+	nc := NewNPCCommands(discord.NewPermissionChecker(""), nil, nil)
 	def := nc.Definition()
 
 	if def.Name != "npc" {
 		t.Errorf("Name = %q, want %q", def.Name, "npc")
 	}
 
-	expectedSubs := []string{"list", "mute", "unmute", "speak", "muteall", "unmuteall"}
+	expectedSubs := []string{"list", "mute", "unmute", "speak", "muteall", "unmuteall", "knows", "teach", "event"}
 	if len(def.Options) != len(expectedSubs) {
 		t.Fatalf("Options count = %d, want %d", len(def.Options), len(expectedSubs))
 	}
@@ -51,6 +51,18 @@ func TestNPCDefinition(t *testing.T) {
 		t.Error("mute name option should have autocomplete enabled")
 	}
 
+	// Verify event has a required "text" and an "urgent" flag.
+	eventOpts := def.Options[8].Options
+	if len(eventOpts) != 3 {
+		t.Fatalf("event options count = %d, want 3", len(eventOpts))
+	}
+	if eventOpts[0].Name != "text" || !eventOpts[0].Required {
+		t.Errorf("event option[0] = %q (required %v), want required %q", eventOpts[0].Name, eventOpts[0].Required, "text")
+	}
+	if eventOpts[1].Name != "urgent" || eventOpts[1].Type != discordgo.ApplicationCommandOptionBoolean {
+		t.Errorf("event option[1] = %q (type %d), want boolean %q", eventOpts[1].Name, eventOpts[1].Type, "urgent")
+	}
+
 	// Verify speak has "name" and "text" options.
 	speakOpts := def.Options[3].Options
 	if len(speakOpts) != 2 {
@@ -68,7 +80,7 @@ func TestNPCList_NoSession(t *testing.T) {
 	t.Parallel()
 
 	perms := discord.NewPermissionChecker("")
-	nc := NewNPCCommands(perms, func() *orchestrator.Orchestrator { return nil })
+	nc := NewNPCCommands(perms, func() *orchestrator.Orchestrator { return nil }, nil)
 
 	// Verify the handler does not panic when no orchestrator is available.
 	// We cannot easily test the Discord response without a real session,
@@ -102,7 +114,7 @@ func TestNPCList_WithAgents(t *testing.T) {
 	orch := newTestOrchestrator(npc1, npc2)
 
 	perms := discord.NewPermissionChecker("")
-	nc := NewNPCCommands(perms, func() *orchestrator.Orchestrator { return orch })
+	nc := NewNPCCommands(perms, func() *orchestrator.Orchestrator { return orch }, nil)
 
 	// Verify orchestrator has agents.
 	agents := nc.getOrch().ActiveAgents()
@@ -236,11 +248,44 @@ func TestSubcommandStringOption(t *testing.T) {
 	}
 }
 
+func TestSubcommandBoolOption(t *testing.T) {
+	t.Parallel()
+
+	i := &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionApplicationCommand,
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name: "npc",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{
+						Name: "event",
+						Type: discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandInteractionDataOption{
+							{
+								Name:  "urgent",
+								Type:  discordgo.ApplicationCommandOptionBoolean,
+								Value: true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if !subcommandBoolOption(i, "urgent") {
+		t.Error("subcommandBoolOption = false, want true")
+	}
+	if subcommandBoolOption(i, "nonexistent") {
+		t.Error("subcommandBoolOption for missing = true, want false")
+	}
+}
+
 func TestNPCRegister(t *testing.T) {
 	t.Parallel()
 
 	perms := discord.NewPermissionChecker("")
-	nc := NewNPCCommands(perms, func() *orchestrator.Orchestrator { return nil })
+	nc := NewNPCCommands(perms, func() *orchestrator.Orchestrator { return nil }, nil)
 	router := discord.NewCommandRouter()
 
 	nc.Register(router)
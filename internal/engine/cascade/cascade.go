@@ -14,16 +14,20 @@
 //     the fast model's first sentence as a forced continuation prefix.
 //  5. TTS continues with the strong model's output → seamless single utterance.
 //
-// This is opt-in per NPC via the cascade_mode configuration field and is not
-// recommended for simple greetings or combat callouts where a single fast model
+// This is opt-in per NPC via the engine configuration field ("cascaded") and is
+// not recommended for simple greetings or combat callouts where a single fast model
 // suffices.
 package cascade
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/MrWong99/glyphoxa/internal/engine"
 	"github.com/MrWong99/glyphoxa/pkg/audio"
@@ -31,6 +35,7 @@ import (
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
 	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/text/segment"
 )
 
 const (
@@ -41,10 +46,38 @@ const (
 	// defaultTranscriptBuf is the default buffer depth of the transcript channel.
 	defaultTranscriptBuf = 32
 
+	// defaultSpeaker attributes transcript entries when no [WithSpeaker]
+	// option is given.
+	defaultSpeaker = "cascade"
+
 	// defaultTextBuf is the buffer depth of the text channel passed to TTS in the
 	// dual-model path. Sized to absorb the opener plus several strong-model sentences
 	// without blocking the synthesis goroutine.
 	defaultTextBuf = 16
+
+	// defaultEmptyFallback is the line spoken when the fast model produces no
+	// usable text even after the nudge retry.
+	defaultEmptyFallback = "..."
+
+	// defaultEmptyNudge is the user-role instruction appended to the fast
+	// model's request when retrying after an empty or whitespace-only response.
+	defaultEmptyNudge = "Please respond in character with at least one short spoken sentence."
+
+	// DefaultMaxToolRounds is the default number of tool-call rounds the strong
+	// model may run per turn before it must answer without tools.
+	DefaultMaxToolRounds = 3
+
+	// DefaultTTSSampleRate is the TTS sample rate in Hz assumed when
+	// [WithTTSFormat] is not used.
+	DefaultTTSSampleRate = engine.DefaultTTSSampleRate
+
+	// DefaultInterruptionNote is the instruction added to the prompt after an
+	// interrupted reply when [WithInterruptionNote] is given an empty note.
+	DefaultInterruptionNote = "The player interrupted you before you finished your last reply. React naturally to being cut off, for example by briefly acknowledging it, and only pick up your point again if it still matters."
+
+	// toolLimitInstruction is appended to the strong model's system prompt once
+	// the tool-call round limit is reached.
+	toolLimitInstruction = "You have reached the tool call limit for this turn. Answer the player now using the information you already have, without calling any more tools."
 )
 
 // Engine implements [engine.VoiceEngine] using a dual-model sentence cascade.
@@ -65,6 +98,24 @@ type Engine struct {
 	openerSuffix  string
 	transcriptBuf int
 
+	// fastModel and strongModel override the model used by the respective LLM
+	// provider on every request. Empty means use the provider's default.
+	fastModel   string
+	strongModel string
+
+	// continuationStop and maxContinuationTokens bound the strong model's
+	// continuation. Empty and 0 leave the provider defaults in place.
+	continuationStop      []string
+	maxContinuationTokens int
+
+	// emptyFallback is the text synthesised when the fast model returns an
+	// empty or whitespace-only response. Defaults to "...".
+	emptyFallback string
+
+	// emptyNudge is appended as a user message when retrying an empty fast-model
+	// response. An empty string disables the retry.
+	emptyNudge string
+
 	// ttsSampleRate is the sample rate in Hz of PCM audio produced by the TTS
 	// provider (e.g., 22050 for Coqui XTTS, 16000 for ElevenLabs). Defaults to
 	// 22050 if not set via [WithTTSFormat].
@@ -74,10 +125,51 @@ type Engine struct {
 	// (1 = mono, 2 = stereo). Defaults to 1 if not set via [WithTTSFormat].
 	ttsChannels int
 
+	// errorAudio is played in place of a reply whose synthesis failed
+	// entirely, in the TTS format. Nil keeps such turns silent.
+	errorAudio []byte
+
+	// maxToolRounds caps the tool-call rounds of the strong model per turn.
+	maxToolRounds int
+
+	// postProcessors transform every sentence before it is synthesised and
+	// returned as response text.
+	postProcessors engine.PostProcessors
+
+	// safety checks every sentence after post-processing and before it is
+	// synthesised.
+	safety engine.SafetyFilter
+
+	// segmenter finds the sentence ends of the streamed replies.
+	segmenter *segment.Segmenter
+
+	// speakerID and speakerName attribute the NPC transcript entries
+	// published on the Transcripts channel.
+	speakerID   string
+	speakerName string
+
+	// splitTranscript publishes the opener and the strong model's
+	// continuation as separate transcript entries.
+	splitTranscript bool
+
+	// repeatWindow is the number of recent openers a new opener is checked
+	// against; 0 disables the check. repeatThreshold is the similarity at or
+	// above which it counts as a repeat.
+	repeatWindow    int
+	repeatThreshold float64
+
+	// interruptionNote is added to the system prompt of the turn after an
+	// interrupted reply; empty disables it. See [WithInterruptionNote].
+	interruptionNote string
+
 	mu            sync.Mutex
 	toolHandler   func(name, args string) (string, error)
 	tools         []llm.ToolDefinition
 	pendingUpdate *engine.ContextUpdate
+	recent        []string // openers of the last repeatWindow replies
+	replyTurn     uint64   // number of the most recent reply
+	lastReply     string   // text of reply replyTurn sent to TTS so far
+	interrupted   bool     // reply replyTurn was cut off
 	transcriptCh  chan memory.TranscriptEntry
 	done          chan struct{}
 	closed        bool
@@ -87,15 +179,21 @@ type Engine struct {
 	wg sync.WaitGroup
 }
 
-// Compile-time assertion that Engine satisfies the engine.VoiceEngine interface.
-var _ engine.VoiceEngine = (*Engine)(nil)
+// Compile-time assertions that Engine satisfies the engine interfaces.
+var (
+	_ engine.VoiceEngine   = (*Engine)(nil)
+	_ engine.Greeter       = (*Engine)(nil)
+	_ engine.Interruptible = (*Engine)(nil)
+)
 
 // Option is a functional option for configuring an Engine during construction.
 type Option func(*Engine)
 
 // WithSTT configures an STT provider for audio input processing.
-// When set, [Engine.Process] will transcribe audio frames before LLM generation.
-// If nil, audio input is ignored and text from the PromptContext is used directly.
+// When set, [Engine.Process] transcribes the audio of a non-empty frame and
+// appends the transcript to the prompt as a user message before LLM
+// generation. Frames without audio, as passed by callers that run STT
+// themselves, and a nil s leave the PromptContext as it is.
 func WithSTT(s stt.Provider) Option {
 	return func(e *Engine) { e.sttP = s }
 }
@@ -124,24 +222,156 @@ func WithTTSFormat(sampleRate, channels int) Option {
 	}
 }
 
+// WithErrorAudio sets a clip that is played when synthesis of a reply fails
+// entirely: either the TTS stream cannot be started or it ends without
+// producing any audio. pcm must be in the format set by [WithTTSFormat]. A
+// failed start is then reported through [engine.Response.Err] instead of
+// an error from Process. Without it, such turns stay silent.
+func WithErrorAudio(pcm []byte) Option {
+	return func(e *Engine) { e.errorAudio = pcm }
+}
+
+// WithFastModel overrides the model requested from the fast LLM provider via
+// [llm.CompletionRequest.Model]. An empty model keeps the provider default.
+func WithFastModel(model string) Option {
+	return func(e *Engine) { e.fastModel = model }
+}
+
+// WithStrongModel overrides the model requested from the strong LLM provider
+// via [llm.CompletionRequest.Model]. An empty model keeps the provider default.
+func WithStrongModel(model string) Option {
+	return func(e *Engine) { e.strongModel = model }
+}
+
+// WithContinuationStop sets stop sequences for the strong model's
+// continuation only, such as "\n\n" to end the reply before the model
+// starts a new paragraph or "User:" to stop it speaking for the player. The
+// fast model's opener is not affected. Providers without stop sequence
+// support ignore them.
+func WithContinuationStop(seqs ...string) Option {
+	return func(e *Engine) { e.continuationStop = slices.Clone(seqs) }
+}
+
+// WithMaxContinuationTokens caps the tokens the strong model may generate for
+// its continuation, so it cannot ramble on after the opener. Values < 1, the
+// default, keep the provider's limit.
+func WithMaxContinuationTokens(n int) Option {
+	return func(e *Engine) { e.maxContinuationTokens = max(n, 0) }
+}
+
+// WithEmptyResponseFallback sets the line synthesised when the fast model
+// returns an empty or whitespace-only response (after the optional nudge
+// retry) or its opener is blocked by the safety filter. The default is "...". An empty s keeps the default.
+func WithEmptyResponseFallback(s string) Option {
+	return func(e *Engine) {
+		if s != "" {
+			e.emptyFallback = s
+		}
+	}
+}
+
+// WithEmptyResponseNudge sets the instruction sent as an extra user message
+// when the fast model's first response is empty or whitespace-only. The
+// request is retried once with the nudge before falling back to the line set
+// by [WithEmptyResponseFallback]. Pass an empty string to disable the retry.
+func WithEmptyResponseNudge(s string) Option {
+	return func(e *Engine) { e.emptyNudge = s }
+}
+
+// WithMaxToolRounds sets how many rounds of tool calls the strong model may
+// issue per turn. Each round executes all tool calls of one model response via
+// the handler registered with [Engine.OnToolCall] and feeds the results back.
+// Once the limit is reached the model is asked again without tools and
+// instructed to answer with what it has. Values < 1 are ignored; the default
+// is [DefaultMaxToolRounds].
+func WithMaxToolRounds(n int) Option {
+	return func(e *Engine) {
+		if n > 0 {
+			e.maxToolRounds = n
+		}
+	}
+}
+
+// WithPostProcessors sets the text post-processors applied, in order, to each
+// sentence of the NPC's reply before it is synthesised. The response text
+// carries the processed opener, so the transcript matches what was spoken.
+func WithPostProcessors(p ...engine.TextPostProcessor) Option {
+	return func(e *Engine) {
+		e.postProcessors = append(e.postProcessors, p...)
+	}
+}
+
+// WithSpeaker sets the NPC that the transcript entries published on
+// [Engine.Transcripts] are attributed to. name is used as both speaker and
+// NPC identifier. Defaults to "cascade".
+func WithSpeaker(name string) Option {
+	return func(e *Engine) {
+		if name != "" {
+			e.speakerID = name
+			e.speakerName = name
+		}
+	}
+}
+
+// WithSegmenter sets how the streamed replies are split into sentences: the
+// fast model's opener ends at the first sentence end, and the strong model's
+// continuation is synthesised sentence by sentence. Defaults to
+// [segment.New], which does not end a sentence after abbreviations such as
+// "Dr.". A nil seg keeps the default.
+func WithSegmenter(seg *segment.Segmenter) Option {
+	return func(e *Engine) {
+		if seg != nil {
+			e.segmenter = seg
+		}
+	}
+}
+
+// WithSplitTranscript controls how a dual-model reply is published on
+// [Engine.Transcripts]. By default the opener and the strong model's
+// continuation form a single entry, as they are heard. When split is true
+// they are published as two consecutive entries, so the hand-over between
+// the models stays visible in the session transcript. Replies produced by the
+// fast model alone are always a single entry.
+func WithSplitTranscript(split bool) Option {
+	return func(e *Engine) { e.splitTranscript = split }
+}
+
+// WithInterruptionNote makes the NPC aware of being cut off. After
+// [Engine.Interrupted] reports that a reply was interrupted, the system
+// prompt of the next turn carries note followed by the text of that reply,
+// so the model can react naturally ("Yes? ... as I was saying."). An empty
+// note uses [DefaultInterruptionNote]. Without this option interruptions are
+// not mentioned to the model.
+func WithInterruptionNote(note string) Option {
+	return func(e *Engine) { e.interruptionNote = cmp.Or(note, DefaultInterruptionNote) }
+}
+
 // New constructs a cascade Engine backed by the given providers and voice profile.
 // Options are applied after the engine is initialised with its defaults.
 func New(fastLLM, strongLLM llm.Provider, ttsP tts.Provider, voice tts.VoiceProfile, opts ...Option) *Engine {
 	e := &Engine{
-		fastLLM:       fastLLM,
-		strongLLM:     strongLLM,
-		ttsP:          ttsP,
-		voice:         voice,
-		openerSuffix:  defaultOpenerSuffix,
-		transcriptBuf: defaultTranscriptBuf,
-		done:          make(chan struct{}),
+		fastLLM:         fastLLM,
+		strongLLM:       strongLLM,
+		ttsP:            ttsP,
+		voice:           voice,
+		openerSuffix:    defaultOpenerSuffix,
+		transcriptBuf:   defaultTranscriptBuf,
+		emptyFallback:   defaultEmptyFallback,
+		emptyNudge:      defaultEmptyNudge,
+		maxToolRounds:   DefaultMaxToolRounds,
+		speakerID:       defaultSpeaker,
+		speakerName:     defaultSpeaker,
+		repeatThreshold: DefaultRepeatThreshold,
+		safety:          engine.NopSafetyFilter{},
+		segmenter:       segment.New(),
+		done:            make(chan struct{}),
 	}
 	for _, o := range opts {
 		o(e)
 	}
 	// Apply defaults for TTS format if not set by options.
 	if e.ttsSampleRate == 0 {
-		e.ttsSampleRate = 22050
+		e.ttsSampleRate = DefaultTTSSampleRate
 	}
 	if e.ttsChannels == 0 {
 		e.ttsChannels = 1
@@ -156,92 +386,237 @@ func New(fastLLM, strongLLM llm.Provider, ttsP tts.Provider, voice tts.VoiceProf
 // Process handles a complete voice interaction using the dual-model sentence cascade.
 //
 // It applies any pending [engine.ContextUpdate] from a prior [Engine.InjectContext]
-// call, then:
+// call and the interruption note of an interrupted previous reply (see
+// [WithInterruptionNote]) and, with an STT provider (see [WithSTT]), transcribes the audio of
+// frame, then:
 //  1. Sends the prompt to the fast model with an opener instruction.
-//  2. Collects the first sentence of the fast model's reply.
+//  2. Collects the first sentence of the fast model's reply, regenerating it
+//     once if it repeats a recent line (see [WithRepeatWindow]) or is flagged
+//     by the safety filter (see [WithSafetyFilter]).
 //  3. If the fast model's response is a single sentence, synthesises it directly
 //     (single-model path — no strong model involved).
 //  4. Otherwise, begins TTS on the opener immediately and in a background goroutine
 //     calls the strong model with the opener as a forced assistant-role continuation
-//     prefix, forwarding its output to the same TTS stream.
+//     prefix, forwarding its output to the same TTS stream. A continuation that
+//     starts by repeating the opener has the repetition removed before it is
+//     spoken (see also [WithContinuationStop] and [WithMaxContinuationTokens]).
 //
 // The returned [engine.Response] is available as soon as TTS synthesis starts;
-// audio continues streaming after Process returns.
-func (e *Engine) Process(ctx context.Context, _ audio.AudioFrame, prompt engine.PromptContext) (*engine.Response, error) {
-	// Apply and consume any pending context update atomically.
+// audio continues streaming after Process returns. Its [engine.Timings] cover
+// every stage of the turn and are final once the Audio channel closes.
+func (e *Engine) Process(ctx context.Context, frame audio.AudioFrame, prompt engine.PromptContext) (*engine.Response, error) {
+	start := time.Now()
+
+	prompt, turn := e.beginTurn(prompt)
 	e.mu.Lock()
-	if e.pendingUpdate != nil {
-		prompt = mergeContextUpdate(prompt, *e.pendingUpdate)
-		e.pendingUpdate = nil
-	}
 	tools := make([]llm.ToolDefinition, len(e.tools))
 	copy(tools, e.tools)
 	e.mu.Unlock()
 
+	// ── Stage 0: STT (only when audio was passed in) ─────────────────────────
+
+	var sttLatency time.Duration
+	if e.sttP != nil && len(frame.Data) > 0 {
+		transcript, err := e.transcribe(ctx, frame)
+		if err != nil {
+			return nil, err
+		}
+		prompt.Messages = append(slices.Clip(prompt.Messages), llm.Message{Role: "user", Content: transcript.Text})
+		prompt.Language = cmp.Or(prompt.Language, transcript.Language)
+		sttLatency = time.Since(start)
+	}
+
 	// ── Stage 1: Fast model → opener ─────────────────────────────────────────
 
-	fastReq := e.buildFastPrompt(prompt)
+	fastReq := e.buildFastPrompt(prompt, e.openerSuffix)
 	fastCh, err := e.fastLLM.StreamCompletion(ctx, fastReq)
 	if err != nil {
 		return nil, fmt.Errorf("cascade: fast model stream failed: %w", err)
 	}
 
 	opener, fastFull := e.collectFirstSentence(ctx, fastCh)
-	if opener == "" {
-		opener = "..." // guard: prevent silent TTS on empty opener
+	if strings.TrimSpace(opener) == "" {
+		// Guard: never produce a silent turn on an empty opener.
+		opener, fastFull = e.recoverEmptyOpener(ctx, fastReq)
 	}
+	opener, fastFull = e.avoidRepeat(ctx, fastReq, opener, fastFull)
+	opener, spoken, fastFull := e.screenOpener(ctx, fastReq, opener, fastFull)
+	openerLatency := time.Since(start)
 
 	// ── Stage 2a: Single-model path (fast model was complete in one sentence) ─
 
 	if fastFull {
+		text := spoken
 		textCh := make(chan string, 1)
-		textCh <- opener
+		if text != "" {
+			textCh <- text
+		}
 		close(textCh)
 
-		audioCh, err := e.ttsP.SynthesizeStream(ctx, textCh, e.voice)
+		voice := engine.VoiceFor(e.voice, prompt)
+		stream, err := e.ttsP.SynthesizeStream(ctx, textCh, voice)
 		if err != nil {
-			return nil, fmt.Errorf("cascade: TTS start failed: %w", err)
+			return engine.TTSFailed(fmt.Errorf("cascade: TTS start failed: %w", err), e.errorAudio, e.ttsSampleRate, e.ttsChannels)
+		}
+		resp := &engine.Response{Text: text, OpenerText: text, Audio: stream.Audio(), SampleRate: e.ttsSampleRate, Channels: e.ttsChannels}
+		resp.SetTiming(engine.StageSTT, sttLatency)
+		resp.SetTiming(engine.StageOpener, openerLatency)
+		if text != "" {
+			resp.Audio = engine.GuardAudio(ctx, resp, stream, e.errorAudio, start)
+		} else {
+			resp.SetTiming(engine.StageTotal, openerLatency)
 		}
-		return &engine.Response{Text: opener, Audio: audioCh, SampleRate: e.ttsSampleRate, Channels: e.ttsChannels}, nil
+		resp.SetLatency(engine.Latency{Opener: openerLatency, Total: openerLatency})
+		e.recordReply(turn, text)
+		e.wg.Go(func() { e.publishTranscript(text, "", voice.Language, start) })
+		return resp, nil
 	}
 
 	// ── Stage 2b: Dual-model path ─────────────────────────────────────────────
 
 	// Create the shared text channel that feeds the TTS stream.
 	textCh := make(chan string, defaultTextBuf)
-	audioCh, err := e.ttsP.SynthesizeStream(ctx, textCh, e.voice)
+	voice := engine.VoiceFor(e.voice, prompt)
+	stream, err := e.ttsP.SynthesizeStream(ctx, textCh, voice)
 	if err != nil {
-		return nil, fmt.Errorf("cascade: TTS start failed: %w", err)
+		return engine.TTSFailed(fmt.Errorf("cascade: TTS start failed: %w", err), e.errorAudio, e.ttsSampleRate, e.ttsChannels)
 	}
 
+	// The strong model continues from the opener as the fast model wrote it.
 	strongReq := e.buildStrongPrompt(prompt, tools, opener)
-	resp := &engine.Response{Text: opener, Audio: audioCh, SampleRate: e.ttsSampleRate, Channels: e.ttsChannels}
+	resp := &engine.Response{Text: spoken, OpenerText: spoken, SampleRate: e.ttsSampleRate, Channels: e.ttsChannels}
+	resp.SetTiming(engine.StageSTT, sttLatency)
+	resp.SetTiming(engine.StageOpener, openerLatency)
+	resp.Audio = engine.GuardAudio(ctx, resp, stream, e.errorAudio, start)
+	resp.SetUsedStrongModel()
+	resp.SetLatency(engine.Latency{Opener: openerLatency})
 
 	// Background goroutine: send opener → strong model → close textCh.
 	e.wg.Go(func() {
 		defer close(textCh)
 
 		// Deliver the opener to TTS immediately so playback begins.
-		select {
-		case textCh <- opener:
-		case <-ctx.Done():
-			return
-		}
-
-		// Launch the strong model.
-		strongCh, err := e.strongLLM.StreamCompletion(ctx, strongReq)
-		if err != nil {
-			resp.SetStreamErr(fmt.Errorf("cascade: strong model stream failed: %w", err))
+		if !sendText(ctx, textCh, spoken) {
 			return
 		}
+		e.recordReply(turn, spoken)
 
-		// Forward the strong model's output as sentence-level chunks to TTS.
-		e.forwardSentences(ctx, strongCh, textCh, resp)
+		strongStart := time.Now()
+		continuation := e.runStrongModel(ctx, strongReq, opener, textCh, resp)
+		e.recordReply(turn, strings.TrimSpace(spoken+" "+continuation))
+		// Recorded before textCh closes, so it is visible once Audio closes.
+		strongLatency := time.Since(strongStart)
+		resp.SetTiming(engine.StageStrong, strongLatency)
+		resp.SetLatency(engine.Latency{
+			Opener: openerLatency,
+			Strong: strongLatency,
+			Total:  time.Since(start),
+		})
+		e.publishTranscript(spoken, continuation, voice.Language, start)
 	})
 
 	return resp, nil
 }
 
+// Greet implements [engine.Greeter]. The greeting is generated by the fast
+// model alone, with [engine.DefaultGreetingInstruction] in place of the opener
+// instruction, and synthesised in full. Like [Engine.Process] it applies any
+// pending context update and interruption note first and checks the greeting with the safety filter,
+// and falls back to the configured empty-response line if the model produces
+// no text or the greeting is blocked.
+func (e *Engine) Greet(ctx context.Context, prompt engine.PromptContext) (*engine.Response, error) {
+	begin := time.Now()
+	prompt, turn := e.beginTurn(prompt)
+
+	req := e.buildFastPrompt(prompt, engine.DefaultGreetingInstruction)
+	ch, err := e.fastLLM.StreamCompletion(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("cascade: greeting stream failed: %w", err)
+	}
+
+	greeting := collectText(ctx, ch)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("cascade: greeting: %w", err)
+	}
+	greeting = e.screenGreeting(ctx, req, e.postProcessors.Apply(greeting))
+	if greeting == "" {
+		slog.Warn("cascade: fast model returned empty greeting, using fallback line", "fallback", e.emptyFallback)
+		greeting = e.emptyFallback
+	}
+	ready := time.Since(begin)
+
+	textCh := make(chan string, 1)
+	textCh <- greeting
+	close(textCh)
+
+	voice := engine.VoiceFor(e.voice, prompt)
+	stream, err := e.ttsP.SynthesizeStream(ctx, textCh, voice)
+	if err != nil {
+		return engine.TTSFailed(fmt.Errorf("cascade: TTS start failed: %w", err), e.errorAudio, e.ttsSampleRate, e.ttsChannels)
+	}
+	start := time.Now()
+	e.recordReply(turn, greeting)
+	e.wg.Go(func() { e.publishTranscript(greeting, "", voice.Language, start) })
+	resp := &engine.Response{Text: greeting, SampleRate: e.ttsSampleRate, Channels: e.ttsChannels}
+	resp.SetTiming(engine.StageOpener, ready)
+	resp.Audio = engine.GuardAudio(ctx, resp, stream, e.errorAudio, begin)
+	return resp, nil
+}
+
+// transcribe transcribes the audio of frame with the STT provider in a
+// session of its own and returns the joined final transcripts. The language
+// is that of the first final transcript that reports one.
+func (e *Engine) transcribe(ctx context.Context, frame audio.AudioFrame) (stt.Transcript, error) {
+	sess, err := e.sttP.StartStream(ctx, stt.StreamConfig{SampleRate: frame.SampleRate, Channels: frame.Channels})
+	if err != nil {
+		return stt.Transcript{}, fmt.Errorf("cascade: STT start failed: %w", err)
+	}
+	if err := sess.SendAudio(frame.Data); err != nil {
+		_ = sess.Close()
+		return stt.Transcript{}, fmt.Errorf("cascade: STT send failed: %w", err)
+	}
+	// Close flushes the pending audio; the finals channel closes after the
+	// last transcript.
+	if err := sess.Close(); err != nil {
+		return stt.Transcript{}, fmt.Errorf("cascade: STT close failed: %w", err)
+	}
+
+	var (
+		texts []string
+		out   stt.Transcript
+	)
+	finals := sess.Finals()
+	for {
+		select {
+		case <-ctx.Done():
+			return stt.Transcript{}, fmt.Errorf("cascade: STT: %w", ctx.Err())
+		case t, ok := <-finals:
+			if !ok {
+				out.Text = strings.Join(texts, " ")
+				out.IsFinal = true
+				return out, nil
+			}
+			if text := strings.TrimSpace(t.Text); text != "" {
+				texts = append(texts, text)
+			}
+			out.Language = cmp.Or(out.Language, t.Language)
+		}
+	}
+}
+
+// Interrupted implements [engine.Interruptible]. With [WithInterruptionNote]
+// the next turn's prompt tells the model that its most recent reply was cut
+// off and what it had said; otherwise Interrupted does nothing. The text
+// quoted is the reply as far as it had been generated, which may be more
+// than the player heard.
+func (e *Engine) Interrupted() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.interruptionNote != "" && e.replyTurn > 0 {
+		e.interrupted = true
+	}
+}
+
 // InjectContext queues a context update to be merged on the next [Engine.Process]
 // call. It is non-blocking and safe to call concurrently.
 func (e *Engine) InjectContext(_ context.Context, update engine.ContextUpdate) error {
@@ -315,19 +690,53 @@ func (e *Engine) Wait() {
 
 // ─── Internal helpers ─────────────────────────────────────────────────────────
 
+// beginTurn starts a new reply. It applies and consumes the pending context
+// update and, if the previous reply was interrupted, appends the
+// interruption note quoting that reply to the system prompt of prompt. The
+// returned turn number identifies the new reply to [Engine.recordReply].
+func (e *Engine) beginTurn(prompt engine.PromptContext) (engine.PromptContext, uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.pendingUpdate != nil {
+		prompt = engine.MergeContextUpdate(prompt, *e.pendingUpdate)
+		e.pendingUpdate = nil
+	}
+	if e.interrupted {
+		note := e.interruptionNote
+		if e.lastReply != "" {
+			note += fmt.Sprintf("\n\nYou had said: %q", e.lastReply)
+		}
+		prompt.SystemPrompt += "\n\n" + note
+		e.interrupted = false
+	}
+	e.replyTurn++
+	e.lastReply = ""
+	return prompt, e.replyTurn
+}
+
+// recordReply records text as the reply of turn, as sent to TTS so far. It
+// does nothing once a later turn has begun.
+func (e *Engine) recordReply(turn uint64, text string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if turn == e.replyTurn {
+		e.lastReply = text
+	}
+}
+
 // buildFastPrompt constructs the [llm.CompletionRequest] for the fast model.
-// It appends the opener instruction to the system prompt and excludes tools so
-// the fast model stays fast and on-topic.
-func (e *Engine) buildFastPrompt(prompt engine.PromptContext) llm.CompletionRequest {
+// It appends instruction (the opener or greeting instruction) to the system
+// prompt and excludes tools so the fast model stays fast and on-topic.
+func (e *Engine) buildFastPrompt(prompt engine.PromptContext, instruction string) llm.CompletionRequest {
 	var sb strings.Builder
 	sb.WriteString(prompt.SystemPrompt)
 	if prompt.HotContext != "" {
 		sb.WriteString("\n\n")
 		sb.WriteString(prompt.HotContext)
 	}
-	if e.openerSuffix != "" {
+	if instruction != "" {
 		sb.WriteString("\n\n")
-		sb.WriteString(e.openerSuffix)
+		sb.WriteString(instruction)
 	}
 
 	msgs := make([]llm.Message, len(prompt.Messages))
@@ -336,13 +745,15 @@ func (e *Engine) buildFastPrompt(prompt engine.PromptContext) llm.CompletionRequ
 	return llm.CompletionRequest{
 		SystemPrompt: sb.String(),
 		Messages:     msgs,
+		Model:        e.fastModel,
 		// Tools intentionally omitted: fast model does not use tools.
 	}
 }
 
 // buildStrongPrompt constructs the [llm.CompletionRequest] for the strong model.
 // It injects the fast model's opener as a forced assistant-role continuation
-// prefix so the strong model generates a seamless continuation.
+// prefix so the strong model generates a seamless continuation, bounded by
+// the configured stop sequences and token limit.
 func (e *Engine) buildStrongPrompt(prompt engine.PromptContext, tools []llm.ToolDefinition, opener string) llm.CompletionRequest {
 	var sb strings.Builder
 	sb.WriteString(prompt.SystemPrompt)
@@ -363,20 +774,24 @@ func (e *Engine) buildStrongPrompt(prompt engine.PromptContext, tools []llm.Tool
 		SystemPrompt: sb.String(),
 		Messages:     msgs,
 		Tools:        tools,
+		Model:        e.strongModel,
+		MaxTokens:    e.maxContinuationTokens,
+		Stop:         e.continuationStop,
 	}
 }
 
 // collectFirstSentence reads token chunks from ch and returns the first complete
-// sentence — defined as text ending with '.', '!', or '?' immediately followed by
-// a whitespace character. If the stream ends before a sentence boundary is
-// detected, the entire accumulated text is returned with full=true (meaning the
-// fast model's response was one sentence or fewer, so the strong model is
-// unnecessary).
+// sentence, as found by the engine's segmenter (see [WithSegmenter]). If the
+// stream ends before a sentence boundary is detected, the entire accumulated
+// text is returned with full=true (meaning the fast model's response was one
+// sentence or fewer, so the strong model is unnecessary).
 //
 // When full is false, remaining chunks in ch are drained in a background goroutine
-// to prevent the provider's goroutine from leaking.
+// to prevent the provider's goroutine from leaking. A multi-byte character split
+// across chunks is reassembled before the text is inspected.
 func (e *Engine) collectFirstSentence(ctx context.Context, ch <-chan llm.Chunk) (sentence string, full bool) {
 	var buf strings.Builder
+	var runes engine.RuneJoiner
 	for {
 		select {
 		case <-ctx.Done():
@@ -386,7 +801,7 @@ func (e *Engine) collectFirstSentence(ctx context.Context, ch <-chan llm.Chunk)
 				// Channel closed without a finish-reason chunk.
 				return buf.String(), true
 			}
-			buf.WriteString(chunk.Text)
+			buf.WriteString(runes.Push(chunk.Text))
 
 			// A finish-reason marks the end of the stream — the entire
 			// response fits in this buffer, so no strong model is needed.
@@ -396,45 +811,187 @@ func (e *Engine) collectFirstSentence(ctx context.Context, ch <-chan llm.Chunk)
 
 			// Look for a sentence boundary only while the stream is live.
 			s := buf.String()
-			if idx := firstSentenceBoundary(s); idx >= 0 {
+			if idx := e.segmenter.Boundary(s); idx >= 0 {
 				// Drain remaining fast-model output to avoid goroutine leaks.
-				go drainChunks(ch)
+				go engine.DrainChunks(ch)
 				return s[:idx+1], false
 			}
 		}
 	}
 }
 
+// recoverEmptyOpener handles an empty or whitespace-only fast-model response.
+// If a nudge is configured, the request is retried once with the nudge appended
+// as a user message. When the retry also yields no usable text (or fails), the
+// configured fallback line is returned with full=true so it is synthesised via
+// the single-model path.
+func (e *Engine) recoverEmptyOpener(ctx context.Context, req llm.CompletionRequest) (sentence string, full bool) {
+	if e.emptyNudge != "" && ctx.Err() == nil {
+		slog.Warn("cascade: fast model returned empty response, retrying with nudge")
+
+		retry := req
+		retry.Messages = make([]llm.Message, len(req.Messages)+1)
+		copy(retry.Messages, req.Messages)
+		retry.Messages[len(req.Messages)] = llm.Message{Role: "user", Content: e.emptyNudge}
+
+		ch, err := e.fastLLM.StreamCompletion(ctx, retry)
+		if err != nil {
+			slog.Warn("cascade: fast model retry failed", "err", err)
+		} else {
+			sentence, full = e.collectFirstSentence(ctx, ch)
+			if strings.TrimSpace(sentence) != "" {
+				return sentence, full
+			}
+		}
+	}
+
+	slog.Warn("cascade: fast model returned empty response, using fallback line", "fallback", e.emptyFallback)
+	return e.emptyFallback, true
+}
+
+// runStrongModel streams the strong model's reply to textCh and runs the
+// tool-dispatch loop: while the model responds with tool calls, they are
+// executed via the registered handler and the model is called again with the
+// results appended. After e.maxToolRounds rounds the model is called once more
+// without tools and told to answer. Errors are recorded via resp.
+//
+// A continuation flagged by the safety filter for regeneration is requested
+// once more, continuing after the sentences already spoken; a blocked one
+// ends the reply.
+//
+// Until the first sentence of the continuation is spoken, a repetition of
+// opener at the start of the model's output is dropped: models sometimes
+// restate the forced prefix instead of continuing it.
+//
+// It returns the continuation as it was sent to TTS.
+func (e *Engine) runStrongModel(ctx context.Context, req llm.CompletionRequest, opener string, textCh chan<- string, resp *engine.Response) string {
+	var spoken []string
+	regenerated := false
+	for round := 0; ; round++ {
+		if round == e.maxToolRounds {
+			slog.Warn("cascade: tool call limit reached, requesting final answer", "rounds", round)
+			req.Tools = nil
+			req.SystemPrompt += "\n\n" + toolLimitInstruction
+		}
+
+		strongCh, err := e.strongLLM.StreamCompletion(ctx, req)
+		if err != nil {
+			resp.SetStreamErr(fmt.Errorf("cascade: strong model stream failed: %w", err))
+			return strings.Join(spoken, " ")
+		}
+
+		// Forward the strong model's output as sentence-level chunks to TTS.
+		roundStart := len(spoken)
+		turn, action := e.forwardSentences(ctx, strongCh, textCh, &spoken, repeatOf(opener, spoken), !regenerated)
+		if action == engine.SafetyRegenerate {
+			regenerated = true
+			req = engine.SafetyRetry(req, spoken[roundStart:], safetyInstruction)
+			if strongCh, err = e.strongLLM.StreamCompletion(ctx, req); err != nil {
+				resp.SetStreamErr(fmt.Errorf("cascade: strong model regenerate failed: %w", err))
+				return strings.Join(spoken, " ")
+			}
+			turn, action = e.forwardSentences(ctx, strongCh, textCh, &spoken, repeatOf(opener, spoken), false)
+		}
+		if action == engine.SafetyBlock {
+			return strings.Join(spoken, " ")
+		}
+		if len(turn.ToolCalls) == 0 || len(req.Tools) == 0 || ctx.Err() != nil {
+			return strings.Join(spoken, " ")
+		}
+
+		e.mu.Lock()
+		handler := e.toolHandler
+		e.mu.Unlock()
+		if handler == nil {
+			return strings.Join(spoken, " ")
+		}
+		req.Messages = engine.AppendToolResults(req.Messages, turn, handler)
+	}
+}
+
 // forwardSentences reads token chunks from ch, accumulates them into complete
-// sentences, and writes each sentence to textCh. Any text remaining when the
-// stream ends is flushed as a final fragment. Errors are recorded via resp.
-func (e *Engine) forwardSentences(ctx context.Context, ch <-chan llm.Chunk, textCh chan<- string, resp *engine.Response) {
-	var buf strings.Builder
+// sentences, and writes each post-processed sentence to textCh. Any text
+// remaining when the stream ends is flushed as a final fragment. Reasoning
+// carried by the chunks is never forwarded.
+//
+// It returns the stream as an assistant message: the raw text, the tool calls
+// requested, if any, and the reasoning that preceded them. Every sentence sent
+// to textCh is also appended to spoken.
+//
+// Each sentence is checked with the safety filter before it is sent. When a
+// sentence is to be blocked or regenerated (only if canRegenerate is set),
+// forwarding stops, the rest of the stream is discarded, and the action is
+// returned along with a message without tool calls. Otherwise the action is
+// [engine.SafetyAllow].
+//
+// If repeat is not empty and the stream starts with it, ignoring case and
+// leading whitespace, that prefix is not spoken; it is still part of the
+// returned text. Sentence detection waits until the stream has either
+// diverged from repeat or covered it.
+//
+// Chunks may split a multi-byte character; it is reassembled before sentence
+// detection, so sentences and the returned text only contain whole runes.
+func (e *Engine) forwardSentences(ctx context.Context, ch <-chan llm.Chunk, textCh chan<- string, spoken *[]string, repeat string, canRegenerate bool) (llm.Message, engine.SafetyAction) {
+	repeat = strings.TrimSpace(repeat)
+	var buf, text strings.Builder
+	var runes engine.RuneJoiner
+	turn := llm.Message{Role: "assistant"}
+	action := engine.SafetyAllow
+	done := func() (llm.Message, engine.SafetyAction) {
+		turn.Content = text.String()
+		return turn, action
+	}
+	send := func(sentence string) bool {
+		sentence, action = engine.Screen(ctx, e.safety, e.speakerName, e.postProcessors.Apply(sentence), canRegenerate)
+		if action != engine.SafetyAllow {
+			turn.ToolCalls = nil
+			go engine.DrainChunks(ch)
+			return false
+		}
+		if sentence != "" {
+			*spoken = append(*spoken, sentence)
+		}
+		return sendText(ctx, textCh, sentence)
+	}
 	for {
 		select {
 		case <-ctx.Done():
-			return
+			return done()
 		case chunk, ok := <-ch:
 			if !ok {
-				// Channel closed: flush remaining text.
-				if buf.Len() > 0 {
-					select {
-					case textCh <- buf.String():
-					case <-ctx.Done():
-					}
+				// Channel closed: flush remaining text, unless all of it
+				// repeats the start of the opener.
+				if _, decided := trimRepeat(buf.String(), repeat); decided {
+					send(buf.String())
 				}
-				return
+				return done()
+			}
+
+			if t := runes.Push(chunk.Text); t != "" {
+				buf.WriteString(t)
+				text.WriteString(t)
 			}
+			turn.ToolCalls = append(turn.ToolCalls, chunk.ToolCalls...)
+			turn.Thinking = append(turn.Thinking, chunk.Thinking...)
 
-			if chunk.Text != "" {
-				buf.WriteString(chunk.Text)
+			if repeat != "" {
+				trimmed, decided := trimRepeat(buf.String(), repeat)
+				if !decided {
+					if chunk.FinishReason != "" {
+						return done()
+					}
+					continue
+				}
+				buf.Reset()
+				buf.WriteString(trimmed)
+				repeat = ""
 			}
 
 			// Flush complete sentences eagerly for lower TTS latency.
 			// Call buf.String() once per iteration to avoid redundant allocations.
 			for {
 				s := buf.String()
-				idx := firstSentenceBoundary(s)
+				idx := e.segmenter.Boundary(s)
 				if idx < 0 {
 					break
 				}
@@ -442,77 +999,97 @@ func (e *Engine) forwardSentences(ctx context.Context, ch <-chan llm.Chunk, text
 				rest := s[idx+1:]
 				buf.Reset()
 				buf.WriteString(strings.TrimLeft(rest, " \t\n\r"))
-				select {
-				case textCh <- sentence:
-				case <-ctx.Done():
-					return
+				if !send(sentence) {
+					return done()
 				}
 			}
 
 			// On the final chunk, flush any remaining partial sentence.
 			if chunk.FinishReason != "" {
-				if buf.Len() > 0 {
-					select {
-					case textCh <- buf.String():
-					case <-ctx.Done():
-					}
-				}
-				return
+				send(buf.String())
+				return done()
 			}
 		}
 	}
 }
 
-// firstSentenceBoundary returns the index of the first '.', '!', or '?'
-// character that is immediately followed by a whitespace character (' ', '\n',
-// '\r', or '\t'). Returns -1 if no such boundary exists in s.
-func firstSentenceBoundary(s string) int {
-	for i := 0; i < len(s)-1; i++ {
-		switch s[i] {
-		case '.', '!', '?':
-			switch s[i+1] {
-			case ' ', '\n', '\r', '\t':
-				return i
-			}
-		}
+// repeatOf returns the opener to strip from the start of the strong model's
+// output: opener while no sentence of the continuation has been spoken, and
+// "" afterwards.
+func repeatOf(opener string, spoken []string) string {
+	if len(spoken) > 0 {
+		return ""
 	}
-	return -1
+	return opener
 }
 
-// drainChunks discards all remaining chunks from ch. Used to prevent the LLM
-// provider's internal goroutine from blocking when collectFirstSentence returns
-// before the stream is exhausted.
-func drainChunks(ch <-chan llm.Chunk) {
-	for range ch {
+// trimRepeat removes a repetition of prefix, ignoring case and leading
+// whitespace, from the start of text. decided is false while text is still a
+// shorter match of prefix's start, so more text is needed to tell whether it
+// is a repetition; text is then returned unchanged, as it is when it does not
+// repeat prefix. An empty prefix is always decided.
+func trimRepeat(text, prefix string) (trimmed string, decided bool) {
+	if prefix == "" {
+		return text, true
+	}
+	t := strings.TrimLeft(text, " \t\n\r")
+	if len(t) < len(prefix) {
+		return text, !strings.EqualFold(t, prefix[:len(t)])
+	}
+	if strings.EqualFold(t[:len(prefix)], prefix) {
+		return strings.TrimLeft(t[len(prefix):], " \t\n\r"), true
 	}
+	return text, true
 }
 
-// mergeContextUpdate applies a [engine.ContextUpdate] onto a [engine.PromptContext],
-// returning the merged result. Zero-value fields in update are ignored.
-func mergeContextUpdate(prompt engine.PromptContext, update engine.ContextUpdate) engine.PromptContext {
-	if update.Identity != "" {
-		prompt.SystemPrompt = update.Identity
+// publishTranscript publishes the NPC's reply on the transcript channel,
+// timestamped with start and tagged with the language it was spoken in. The
+// opener and continuation are joined into one entry unless the engine was
+// configured with [WithSplitTranscript], in which case each non-empty part
+// becomes its own entry. Publishing stops when the engine is closed.
+func (e *Engine) publishTranscript(opener, continuation, language string, start time.Time) {
+	parts := []string{strings.TrimSpace(opener + " " + continuation)}
+	if e.splitTranscript {
+		parts = []string{opener, continuation}
 	}
-	if update.Scene != "" {
-		prompt.HotContext = update.Scene
+	for _, text := range parts {
+		if !engine.PublishTranscript(e.transcriptCh, e.done, e.speakerID, e.speakerName, text, language, start) {
+			return
+		}
 	}
-	if len(update.RecentUtterances) > 0 {
-		extra := make([]llm.Message, len(update.RecentUtterances))
-		for i, u := range update.RecentUtterances {
-			role := "user"
-			if u.IsNPC() {
-				role = "assistant"
-			}
-			extra[i] = llm.Message{
-				Role:    role,
-				Content: u.Text,
-				Name:    u.SpeakerName,
+}
+
+// sendText sends text to textCh unless it is empty, for example because
+// post-processing removed a sentence that was only a stage direction. It
+// reports false if ctx was cancelled first.
+func sendText(ctx context.Context, textCh chan<- string, text string) bool {
+	if text == "" {
+		return true
+	}
+	select {
+	case textCh <- text:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// collectText reads ch to completion and returns the streamed text with
+// surrounding whitespace trimmed. If ctx is done first, the rest of ch is
+// drained in the background and the text received so far is returned; the
+// caller checks ctx.
+func collectText(ctx context.Context, ch <-chan llm.Chunk) string {
+	var sb strings.Builder
+	for {
+		select {
+		case chunk, ok := <-ch:
+			if !ok {
+				return strings.TrimSpace(sb.String())
 			}
+			sb.WriteString(chunk.Text)
+		case <-ctx.Done():
+			go engine.DrainChunks(ch)
+			return strings.TrimSpace(sb.String())
 		}
-		msgs := make([]llm.Message, len(prompt.Messages)+len(extra))
-		copy(msgs, prompt.Messages)
-		copy(msgs[len(prompt.Messages):], extra)
-		prompt.Messages = msgs
 	}
-	return prompt
 }
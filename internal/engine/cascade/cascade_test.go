@@ -2,11 +2,16 @@ package cascade_test
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
+	"unicode/utf8"
 
+	"errors"
 	enginepkg "github.com/MrWong99/glyphoxa/internal/engine"
 	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
 	"github.com/MrWong99/glyphoxa/pkg/audio"
@@ -86,6 +91,54 @@ func TestProcess_FastModelOnly(t *testing.T) {
 	}
 }
 
+// ─── TestProcess_DetectedLanguage ─────────────────────────────────────────────
+
+// TestProcess_DetectedLanguage verifies that the language detected for the
+// player's utterance is requested from TTS, and that the configured voice is
+// used unchanged otherwise.
+func TestProcess_DetectedLanguage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		language string
+		want     string
+	}{
+		{name: "detected language", language: "de-DE", want: "de-DE"},
+		{name: "no detection keeps the voice language", want: "en"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &llmmock.Provider{
+				StreamChunks: []llm.Chunk{{Text: "Willkommen.", FinishReason: "stop"}},
+			}
+			ttsProv := newTTS()
+			e := cascade.New(fastLLM, &llmmock.Provider{}, ttsProv, tts.VoiceProfile{ID: "v1", Language: "en"})
+			t.Cleanup(func() { _ = e.Close() })
+
+			resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{
+				SystemPrompt: "You are an innkeeper.",
+				Language:     tc.language,
+			})
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			drainAudio(resp.Audio)
+			e.Wait()
+
+			if len(ttsProv.SynthesizeStreamCalls) != 1 {
+				t.Fatalf("TTS SynthesizeStream calls: want 1, got %d", len(ttsProv.SynthesizeStreamCalls))
+			}
+			voice := ttsProv.SynthesizeStreamCalls[0].Voice
+			if voice.Language != tc.want || voice.ID != "v1" {
+				t.Errorf("voice = %+v, want ID v1 with language %q", voice, tc.want)
+			}
+		})
+	}
+}
+
 // ─── TestProcess_DualModel ────────────────────────────────────────────────────
 
 // TestProcess_DualModel verifies that when the fast model emits a sentence
@@ -135,6 +188,183 @@ func TestProcess_DualModel(t *testing.T) {
 	}
 }
 
+// ─── TestProcess_ModelUsage ──────────────────────────────────────────────────
+
+// TestProcess_ModelUsage verifies the Response reports which models produced
+// the reply, the opener text and the latency breakdown.
+func TestProcess_ModelUsage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		fastChunks []llm.Chunk
+		wantStrong bool
+		wantOpener string
+		wantText   string
+	}{
+		{
+			name:       "fast only",
+			fastChunks: []llm.Chunk{{Text: "Well met, traveller.", FinishReason: "stop"}},
+			wantOpener: "Well met, traveller.",
+			wantText:   "Well met, traveller.",
+		},
+		{
+			name: "dual model",
+			fastChunks: []llm.Chunk{
+				{Text: "Ah, traveller! "},
+				{Text: "and more text", FinishReason: "stop"},
+			},
+			wantStrong: true,
+			wantOpener: "Ah, traveller!",
+			wantText:   "Ah, traveller!",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &llmmock.Provider{StreamChunks: tc.fastChunks}
+			strongLLM := &llmmock.Provider{
+				StreamChunks: []llm.Chunk{{Text: "What brings you here?", FinishReason: "stop"}},
+			}
+
+			e := cascade.New(fastLLM, strongLLM, newTTS(), tts.VoiceProfile{})
+			t.Cleanup(func() { _ = e.Close() })
+
+			resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{})
+			if err != nil {
+				t.Fatalf("Process: unexpected error: %v", err)
+			}
+			drainAudio(resp.Audio)
+			e.Wait()
+
+			if got := resp.UsedStrongModel(); got != tc.wantStrong {
+				t.Errorf("UsedStrongModel: want %v, got %v", tc.wantStrong, got)
+			}
+			if resp.OpenerText != tc.wantOpener {
+				t.Errorf("OpenerText: want %q, got %q", tc.wantOpener, resp.OpenerText)
+			}
+			if resp.Text != tc.wantText {
+				t.Errorf("Text: want %q, got %q", tc.wantText, resp.Text)
+			}
+			if err := resp.Err(); err != nil {
+				t.Errorf("Err: unexpected error: %v", err)
+			}
+
+			lat := resp.Latency()
+			if lat.Opener <= 0 {
+				t.Errorf("Latency.Opener: want > 0, got %v", lat.Opener)
+			}
+			if lat.Total < lat.Opener {
+				t.Errorf("Latency.Total %v is less than Latency.Opener %v", lat.Total, lat.Opener)
+			}
+			if tc.wantStrong {
+				if lat.Strong <= 0 {
+					t.Errorf("Latency.Strong: want > 0, got %v", lat.Strong)
+				}
+			} else if lat.Strong != 0 {
+				t.Errorf("Latency.Strong: want 0 without strong model, got %v", lat.Strong)
+			}
+		})
+	}
+}
+
+// ─── TestProcess_Transcript ──────────────────────────────────────────────────
+
+// TestProcess_Transcript verifies that the reply is published on the
+// Transcripts channel as one entry by default and as opener plus continuation
+// when WithSplitTranscript is enabled, tagged with the language it was spoken in.
+func TestProcess_Transcript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		split bool
+		want  []string
+	}{
+		{name: "combined", want: []string{"Ah, traveller! What brings you here?"}},
+		{name: "split", split: true, want: []string{"Ah, traveller!", "What brings you here?"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &llmmock.Provider{
+				StreamChunks: []llm.Chunk{
+					{Text: "Ah, traveller! "},
+					{Text: "and more text", FinishReason: "stop"},
+				},
+			}
+			strongLLM := &llmmock.Provider{
+				StreamChunks: []llm.Chunk{{Text: "What brings you here?", FinishReason: "stop"}},
+			}
+
+			e := cascade.New(fastLLM, strongLLM, newTTS(), tts.VoiceProfile{Language: "en-GB"},
+				cascade.WithSpeaker("Greymantle"),
+				cascade.WithSplitTranscript(tc.split),
+			)
+
+			resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{})
+			if err != nil {
+				t.Fatalf("Process: unexpected error: %v", err)
+			}
+			drainAudio(resp.Audio)
+			e.Wait()
+			if err := e.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			var got []string
+			for entry := range e.Transcripts() {
+				if entry.NPCID != "Greymantle" || entry.SpeakerName != "Greymantle" {
+					t.Errorf("entry attributed to %q/%q, want Greymantle", entry.NPCID, entry.SpeakerName)
+				}
+				if entry.Language != "en-GB" {
+					t.Errorf("entry language = %q, want the voice's en-GB", entry.Language)
+				}
+				got = append(got, entry.Text)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("transcript entries: want %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("fast only", func(t *testing.T) {
+		t.Parallel()
+
+		fastLLM := &llmmock.Provider{
+			StreamChunks: []llm.Chunk{{Text: "Well met, traveller.", FinishReason: "stop"}},
+		}
+		e := cascade.New(fastLLM, &llmmock.Provider{}, newTTS(), tts.VoiceProfile{Language: "en-GB"},
+			cascade.WithSplitTranscript(true),
+		)
+
+		resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{Language: "de-DE"})
+		if err != nil {
+			t.Fatalf("Process: unexpected error: %v", err)
+		}
+		drainAudio(resp.Audio)
+		e.Wait()
+		if err := e.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+
+		var got []string
+		for entry := range e.Transcripts() {
+			if entry.Language != "de-DE" {
+				t.Errorf("entry language = %q, want the detected de-DE", entry.Language)
+			}
+			got = append(got, entry.Text)
+		}
+		if want := []string{"Well met, traveller."}; !slices.Equal(got, want) {
+			t.Errorf("transcript entries: want %q, got %q", want, got)
+		}
+	})
+}
+
 // ─── TestProcess_OpenerSentenceDetection ─────────────────────────────────────
 
 // TestProcess_OpenerSentenceDetection verifies the sentence-boundary heuristic
@@ -175,6 +405,26 @@ func TestProcess_OpenerSentenceDetection(t *testing.T) {
 			wantOpener:   "What do you seek?",
 			wantFastFull: false,
 		},
+		{
+			name: "abbreviation does not end the opener",
+			fastChunks: []llm.Chunk{
+				{Text: "Ask Dr. "},
+				{Text: "Smith. "},
+				{Text: "He knows.", FinishReason: "stop"},
+			},
+			wantOpener:   "Ask Dr. Smith.",
+			wantFastFull: false,
+		},
+		{
+			name: "decimal split across chunks",
+			fastChunks: []llm.Chunk{
+				{Text: "It is 3."},
+				{Text: "14 leagues away. "},
+				{Text: "Go.", FinishReason: "stop"},
+			},
+			wantOpener:   "It is 3.14 leagues away.",
+			wantFastFull: false,
+		},
 		{
 			name: "single sentence finish reason no boundary",
 			fastChunks: []llm.Chunk{
@@ -788,3 +1038,748 @@ func TestInjectContext_SceneAndUtterances(t *testing.T) {
 		t.Errorf("recent utterance not found in messages: %+v", req.Messages)
 	}
 }
+
+// ─── TestProcess_EmptyResponseFallback ───────────────────────────────────────
+
+// TestProcess_EmptyResponseFallback verifies that an empty or whitespace-only
+// fast-model response is retried once with a nudge and then replaced by the
+// configured fallback line, so the turn is never silent.
+func TestProcess_EmptyResponseFallback(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		chunks        []llm.Chunk
+		opts          []cascade.Option
+		wantText      string
+		wantFastCalls int
+	}{
+		{
+			name:          "empty with default nudge",
+			chunks:        []llm.Chunk{{Text: "", FinishReason: "stop"}},
+			wantText:      "...",
+			wantFastCalls: 2,
+		},
+		{
+			name:          "whitespace only with custom fallback",
+			chunks:        []llm.Chunk{{Text: "  \n\t ", FinishReason: "stop"}},
+			opts:          []cascade.Option{cascade.WithEmptyResponseFallback("Hmm.")},
+			wantText:      "Hmm.",
+			wantFastCalls: 2,
+		},
+		{
+			name:   "retry disabled",
+			chunks: []llm.Chunk{{Text: " ", FinishReason: "stop"}},
+			opts: []cascade.Option{
+				cascade.WithEmptyResponseNudge(""),
+				cascade.WithEmptyResponseFallback("Hmm."),
+			},
+			wantText:      "Hmm.",
+			wantFastCalls: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &llmmock.Provider{StreamChunks: tc.chunks}
+			strongLLM := &llmmock.Provider{}
+			ttsProv := newTTS()
+
+			e := cascade.New(fastLLM, strongLLM, ttsProv, tts.VoiceProfile{}, tc.opts...)
+			t.Cleanup(func() { _ = e.Close() })
+
+			resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{
+				SystemPrompt: "You are a silent monk.",
+				Messages:     []llm.Message{{Role: "user", Content: "Hello?"}},
+			})
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			drainAudio(resp.Audio)
+			e.Wait()
+
+			if resp.Text != tc.wantText {
+				t.Errorf("resp.Text: want %q, got %q", tc.wantText, resp.Text)
+			}
+			if len(fastLLM.StreamCalls) != tc.wantFastCalls {
+				t.Fatalf("fastLLM StreamCompletion calls: want %d, got %d", tc.wantFastCalls, len(fastLLM.StreamCalls))
+			}
+			if len(strongLLM.StreamCalls) != 0 {
+				t.Errorf("strongLLM StreamCompletion calls: want 0, got %d", len(strongLLM.StreamCalls))
+			}
+			if len(ttsProv.SynthesizeStreamCalls) != 1 {
+				t.Errorf("TTS SynthesizeStream calls: want 1, got %d", len(ttsProv.SynthesizeStreamCalls))
+			}
+
+			// The retry must carry the original history plus the nudge.
+			if tc.wantFastCalls == 2 {
+				msgs := fastLLM.StreamCalls[1].Req.Messages
+				if len(msgs) != 2 {
+					t.Fatalf("retry message count: want 2, got %d", len(msgs))
+				}
+				if msgs[1].Role != "user" || msgs[1].Content == "" {
+					t.Errorf("retry nudge: want non-empty user message, got %+v", msgs[1])
+				}
+			}
+		})
+	}
+}
+
+// ─── TestProcess_ToolRoundLimit ───────────────────────────────────────────────
+
+// toolLoopLLM is a strong model that requests a tool on every response. Only
+// once it is called without tools does it also produce an answer.
+type toolLoopLLM struct {
+	llmmock.Provider
+
+	mu   sync.Mutex
+	reqs []llm.CompletionRequest
+}
+
+func (p *toolLoopLLM) StreamCompletion(_ context.Context, req llm.CompletionRequest) (<-chan llm.Chunk, error) {
+	p.mu.Lock()
+	p.reqs = append(p.reqs, req)
+	n := len(p.reqs)
+	p.mu.Unlock()
+
+	chunk := llm.Chunk{
+		ToolCalls:    []llm.ToolCall{{ID: fmt.Sprintf("call-%d", n), Name: "query_lore", Arguments: `{}`}},
+		FinishReason: "tool_calls",
+	}
+	if len(req.Tools) == 0 {
+		chunk.Text = "The vault lies beneath the chapel."
+		chunk.FinishReason = "stop"
+	}
+	ch := make(chan llm.Chunk, 1)
+	ch <- chunk
+	close(ch)
+	return ch, nil
+}
+
+// textRecorder is a TTS provider that records every sentence it receives.
+type textRecorder struct {
+	ttsmock.Provider
+
+	mu    sync.Mutex
+	texts []string
+}
+
+func (r *textRecorder) SynthesizeStream(_ context.Context, text <-chan string, _ tts.VoiceProfile) (*tts.Stream, error) {
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		for s := range text {
+			r.mu.Lock()
+			r.texts = append(r.texts, s)
+			r.mu.Unlock()
+		}
+	}()
+	return tts.NewStream(out), nil
+}
+
+func TestProcess_ToolRoundLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		opts      []cascade.Option
+		wantCalls int
+	}{
+		{name: "default limit", wantCalls: cascade.DefaultMaxToolRounds},
+		{name: "custom limit", opts: []cascade.Option{cascade.WithMaxToolRounds(1)}, wantCalls: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &llmmock.Provider{
+				StreamChunks: []llm.Chunk{{Text: "Let me think. "}, {Text: "Hmm.", FinishReason: "stop"}},
+			}
+			strongLLM := &toolLoopLLM{}
+			ttsProv := &textRecorder{}
+
+			e := cascade.New(fastLLM, strongLLM, ttsProv, tts.VoiceProfile{}, tc.opts...)
+			t.Cleanup(func() { _ = e.Close() })
+			if err := e.SetTools([]llm.ToolDefinition{{Name: "query_lore"}}); err != nil {
+				t.Fatalf("SetTools: %v", err)
+			}
+			var handled atomic.Int32
+			e.OnToolCall(func(name, args string) (string, error) {
+				handled.Add(1)
+				return `{"result": "nothing found"}`, nil
+			})
+
+			resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{
+				SystemPrompt: "You are a lore keeper.",
+				Messages:     []llm.Message{{Role: "user", Content: "Where is the vault?"}},
+			})
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			drainAudio(resp.Audio)
+			e.Wait()
+
+			if got := int(handled.Load()); got != tc.wantCalls {
+				t.Errorf("tool handler calls: want %d, got %d", tc.wantCalls, got)
+			}
+
+			strongLLM.mu.Lock()
+			reqs := strongLLM.reqs
+			strongLLM.mu.Unlock()
+			if len(reqs) != tc.wantCalls+1 {
+				t.Fatalf("strong model calls: want %d, got %d", tc.wantCalls+1, len(reqs))
+			}
+			for i, req := range reqs[:tc.wantCalls] {
+				if len(req.Tools) == 0 {
+					t.Errorf("strong call %d: tools missing before the limit", i)
+				}
+			}
+			final := reqs[tc.wantCalls]
+			if len(final.Tools) != 0 {
+				t.Errorf("final strong call: want no tools, got %d", len(final.Tools))
+			}
+			if !strings.Contains(final.SystemPrompt, "tool call limit") {
+				t.Errorf("final strong call: system prompt lacks the limit instruction: %q", final.SystemPrompt)
+			}
+
+			// The final request carries every tool result of the earlier rounds.
+			var toolMsgs int
+			for _, m := range final.Messages {
+				if m.Role == "tool" {
+					toolMsgs++
+				}
+			}
+			if toolMsgs != tc.wantCalls {
+				t.Errorf("tool result messages: want %d, got %d", tc.wantCalls, toolMsgs)
+			}
+
+			ttsProv.mu.Lock()
+			texts := ttsProv.texts
+			ttsProv.mu.Unlock()
+			if len(texts) == 0 || texts[len(texts)-1] != "The vault lies beneath the chapel." {
+				t.Errorf("TTS text: want final answer last, got %q", texts)
+			}
+		})
+	}
+}
+
+// ─── TestProcess_ThinkingAndToolResults ───────────────────────────────────────
+
+// reasoningLLM is a strong model shaped like a native Anthropic stream: it
+// thinks, speaks a sentence and calls a tool, then answers once the tool
+// result is threaded back.
+type reasoningLLM struct {
+	llmmock.Provider
+
+	mu   sync.Mutex
+	reqs []llm.CompletionRequest
+}
+
+func (p *reasoningLLM) StreamCompletion(_ context.Context, req llm.CompletionRequest) (<-chan llm.Chunk, error) {
+	p.mu.Lock()
+	p.reqs = append(p.reqs, req)
+	n := len(p.reqs)
+	p.mu.Unlock()
+
+	chunks := []llm.Chunk{
+		{Text: "Let me check "},
+		{Text: "the archives. "},
+		{
+			FinishReason: "tool_calls",
+			ToolCalls:    []llm.ToolCall{{ID: "toolu_1", Name: "query_lore", Arguments: `{"topic":"vault"}`}},
+			Thinking:     []llm.ThinkingBlock{{Text: "SECRET: the player is lying.", Signature: "sig-1"}},
+		},
+	}
+	if n > 1 {
+		chunks = []llm.Chunk{{Text: "The vault lies beneath the chapel.", FinishReason: "stop"}}
+	}
+	ch := make(chan llm.Chunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch, nil
+}
+
+// TestProcess_ThinkingAndToolResults verifies that reasoning blocks are never
+// spoken and that the assistant turn — text, thinking and tool calls — is
+// threaded back together with the tool results.
+func TestProcess_ThinkingAndToolResults(t *testing.T) {
+	t.Parallel()
+
+	fastLLM := &llmmock.Provider{
+		StreamChunks: []llm.Chunk{{Text: "Hmm, the vault. "}, {Text: "One moment.", FinishReason: "stop"}},
+	}
+	strongLLM := &reasoningLLM{}
+	ttsProv := &textRecorder{}
+
+	e := cascade.New(fastLLM, strongLLM, ttsProv, tts.VoiceProfile{})
+	t.Cleanup(func() { _ = e.Close() })
+	if err := e.SetTools([]llm.ToolDefinition{{Name: "query_lore"}}); err != nil {
+		t.Fatalf("SetTools: %v", err)
+	}
+	e.OnToolCall(func(name, args string) (string, error) {
+		return `{"result": "beneath the chapel"}`, nil
+	})
+
+	resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{
+		SystemPrompt: "You are a lore keeper.",
+		Messages:     []llm.Message{{Role: "user", Content: "Where is the vault?"}},
+	})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	drainAudio(resp.Audio)
+	e.Wait()
+
+	ttsProv.mu.Lock()
+	spoken := strings.Join(ttsProv.texts, " ")
+	ttsProv.mu.Unlock()
+	if strings.Contains(spoken, "SECRET") {
+		t.Errorf("thinking reached TTS: %q", spoken)
+	}
+	if !strings.Contains(spoken, "Let me check the archives.") || !strings.Contains(spoken, "The vault lies beneath the chapel.") {
+		t.Errorf("TTS text: want both strong model replies, got %q", spoken)
+	}
+
+	strongLLM.mu.Lock()
+	reqs := strongLLM.reqs
+	strongLLM.mu.Unlock()
+	if len(reqs) != 2 {
+		t.Fatalf("strong model calls: want 2, got %d", len(reqs))
+	}
+	msgs := reqs[1].Messages
+	if len(msgs) < 2 {
+		t.Fatalf("second request has %d messages, want at least 2", len(msgs))
+	}
+	turn, result := msgs[len(msgs)-2], msgs[len(msgs)-1]
+	if turn.Role != "assistant" || turn.Content != "Let me check the archives. " {
+		t.Errorf("assistant turn = %+v", turn)
+	}
+	if len(turn.Thinking) != 1 || turn.Thinking[0].Signature != "sig-1" {
+		t.Errorf("assistant turn thinking = %+v, want the signed block", turn.Thinking)
+	}
+	if len(turn.ToolCalls) != 1 || turn.ToolCalls[0].ID != "toolu_1" || turn.ToolCalls[0].Arguments != `{"topic":"vault"}` {
+		t.Errorf("assistant turn tool calls = %+v", turn.ToolCalls)
+	}
+	if result.Role != "tool" || result.ToolCallID != "toolu_1" || result.Content != `{"result": "beneath the chapel"}` {
+		t.Errorf("tool result = %+v", result)
+	}
+}
+
+// ─── TestProcess_PostProcessors ───────────────────────────────────────────────
+
+func TestProcess_PostProcessors(t *testing.T) {
+	t.Parallel()
+
+	fastLLM := &llmmock.Provider{
+		StreamChunks: []llm.Chunk{{Text: "*sighs* Ah, **traveller**! "}, {Text: "more", FinishReason: "stop"}},
+	}
+	strongLLM := &llmmock.Provider{
+		StreamChunks: []llm.Chunk{
+			{Text: "(leans closer) "},
+			{Text: "What brings you **here**? "},
+			{Text: "[nods]", FinishReason: "stop"},
+		},
+	}
+	ttsProv := &textRecorder{}
+
+	e := cascade.New(fastLLM, strongLLM, ttsProv, tts.VoiceProfile{},
+		cascade.WithPostProcessors(enginepkg.StripBracketedActions, enginepkg.StripMarkdown),
+	)
+	t.Cleanup(func() { _ = e.Close() })
+
+	resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{
+		SystemPrompt: "You are a guild master.",
+	})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	drainAudio(resp.Audio)
+	e.Wait()
+
+	if resp.Text != "Ah, traveller!" {
+		t.Errorf("resp.Text = %q, want %q", resp.Text, "Ah, traveller!")
+	}
+
+	ttsProv.mu.Lock()
+	texts := ttsProv.texts
+	ttsProv.mu.Unlock()
+	for _, s := range texts {
+		if strings.ContainsAny(s, "*()[]") {
+			t.Errorf("TTS received unprocessed text %q", s)
+		}
+		if strings.TrimSpace(s) == "" {
+			t.Errorf("TTS received empty text in %q", texts)
+		}
+	}
+	if len(texts) == 0 || texts[0] != "Ah, traveller!" {
+		t.Errorf("TTS texts = %q, want processed opener first", texts)
+	}
+	if !slices.Contains(texts, "What brings you here?") {
+		t.Errorf("TTS texts = %q, want processed strong sentence", texts)
+	}
+
+	// The strong model continues from the opener as the fast model wrote it.
+	if len(strongLLM.StreamCalls) != 1 {
+		t.Fatalf("strongLLM calls: want 1, got %d", len(strongLLM.StreamCalls))
+	}
+	msgs := strongLLM.StreamCalls[0].Req.Messages
+	if len(msgs) == 0 || !strings.Contains(msgs[len(msgs)-1].Content, "*sighs*") {
+		t.Errorf("strong model prefix lacks the raw opener: %+v", msgs)
+	}
+}
+
+// ─── TestGreet ────────────────────────────────────────────────────────────────
+
+// byteChunks splits s into one chunk per byte, tearing every multi-byte
+// character apart, and marks the last chunk as the end of the stream.
+func byteChunks(s string) []llm.Chunk {
+	chunks := make([]llm.Chunk, len(s))
+	for i := range len(s) {
+		chunks[i] = llm.Chunk{Text: s[i : i+1]}
+	}
+	chunks[len(chunks)-1].FinishReason = "stop"
+	return chunks
+}
+
+func TestProcess_SplitUTF8(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		fast       []llm.Chunk
+		strong     []llm.Chunk
+		wantOpener string
+		wantTTS    []string
+	}{
+		{
+			name:       "runes split across every chunk",
+			fast:       byteChunks("Grüß dich, Wanderer! Mehr folgt"),
+			strong:     byteChunks("Der Drache 🐉 schläft. 龍は眠る。 Ça va?"),
+			wantOpener: "Grüß dich, Wanderer!",
+			wantTTS:    []string{"Grüß dich, Wanderer!", "Der Drache 🐉 schläft.", "龍は眠る。 Ça va?"},
+		},
+		{
+			name:       "rune split at a sentence boundary",
+			fast:       []llm.Chunk{{Text: "Olá, amigo! Que"}, {Text: " tal", FinishReason: "stop"}},
+			strong:     []llm.Chunk{{Text: "Bem-vindo ao caf\xc3"}, {Text: "\xa9. Senta-te, por favor. "}, {Text: "\xe2\x80"}, {Text: "\xa6", FinishReason: "stop"}},
+			wantOpener: "Olá, amigo!",
+			wantTTS:    []string{"Olá, amigo!", "Bem-vindo ao café.", "Senta-te, por favor.", "…"},
+		},
+		{
+			name:       "stream ends inside a rune",
+			fast:       []llm.Chunk{{Text: "À bientôt, mon ami"}, {Text: "\xc3", FinishReason: "stop"}},
+			wantOpener: "À bientôt, mon ami",
+			wantTTS:    []string{"À bientôt, mon ami"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &llmmock.Provider{StreamChunks: tc.fast}
+			strongLLM := &llmmock.Provider{StreamChunks: tc.strong}
+			ttsProv := &textRecorder{}
+			e := cascade.New(fastLLM, strongLLM, ttsProv, tts.VoiceProfile{})
+			t.Cleanup(func() { _ = e.Close() })
+
+			resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{})
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			drainAudio(resp.Audio)
+			e.Wait()
+
+			if resp.Text != tc.wantOpener {
+				t.Errorf("resp.Text = %q, want %q", resp.Text, tc.wantOpener)
+			}
+
+			ttsProv.mu.Lock()
+			texts := ttsProv.texts
+			ttsProv.mu.Unlock()
+			for _, s := range texts {
+				if !utf8.ValidString(s) {
+					t.Errorf("TTS received invalid UTF-8 %q", s)
+				}
+			}
+			if !slices.Equal(texts, tc.wantTTS) {
+				t.Errorf("TTS texts = %q, want %q", texts, tc.wantTTS)
+			}
+		})
+	}
+}
+
+func TestGreet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		chunks   []llm.Chunk
+		wantText string
+	}{
+		{
+			name: "multi-sentence greeting is kept whole",
+			chunks: []llm.Chunk{
+				{Text: "Welcome, traveller! "},
+				{Text: "Mind the step.", FinishReason: "stop"},
+			},
+			wantText: "Welcome, traveller! Mind the step.",
+		},
+		{
+			name:     "empty greeting uses fallback",
+			chunks:   []llm.Chunk{{Text: "  ", FinishReason: "stop"}},
+			wantText: "...",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &llmmock.Provider{StreamChunks: tc.chunks}
+			strongLLM := &llmmock.Provider{}
+			ttsProv := newTTS()
+
+			e := cascade.New(fastLLM, strongLLM, ttsProv, tts.VoiceProfile{})
+			t.Cleanup(func() { _ = e.Close() })
+
+			resp, err := e.Greet(context.Background(), enginepkg.PromptContext{
+				SystemPrompt: "You are a gruff innkeeper.",
+				HotContext:   "Location: The Prancing Pony",
+				Messages:     []llm.Message{{Role: "user", Content: "Hello?"}},
+			})
+			if err != nil {
+				t.Fatalf("Greet: %v", err)
+			}
+			drainAudio(resp.Audio)
+
+			if resp.Text != tc.wantText {
+				t.Errorf("resp.Text: want %q, got %q", tc.wantText, resp.Text)
+			}
+			if len(strongLLM.StreamCalls) != 0 {
+				t.Errorf("strong model calls: want 0, got %d", len(strongLLM.StreamCalls))
+			}
+			if len(fastLLM.StreamCalls) != 1 {
+				t.Fatalf("fast model calls: want 1, got %d", len(fastLLM.StreamCalls))
+			}
+			sys := fastLLM.StreamCalls[0].Req.SystemPrompt
+			if !strings.Contains(sys, enginepkg.DefaultGreetingInstruction) {
+				t.Errorf("system prompt lacks greeting instruction: %q", sys)
+			}
+			if !strings.Contains(sys, "The Prancing Pony") {
+				t.Errorf("system prompt lacks the scene: %q", sys)
+			}
+		})
+	}
+}
+
+// ─── TestProcess_ErrorAudio ───────────────────────────────────────────────────
+
+// TestProcess_ErrorAudio verifies that the error audio is played when
+// synthesis of a reply fails entirely, and only then, and that synthesis
+// failures are reported through resp.Err.
+func TestProcess_ErrorAudio(t *testing.T) {
+	t.Parallel()
+
+	errTTS := errors.New("tts down")
+	errorClip := []byte("error-chime")
+	single := []llm.Chunk{{Text: "Well met, traveller.", FinishReason: "stop"}}
+	dual := []llm.Chunk{{Text: "Ah, traveller! "}, {Text: "and more", FinishReason: "stop"}}
+
+	tests := []struct {
+		name      string
+		chunks    []llm.Chunk
+		ttsProv   *ttsmock.Provider
+		want      [][]byte
+		wantErr   bool // error reported through resp.Err
+		wantText  bool // the reply text is kept
+		withClip  bool
+		wantStart bool // Process itself fails
+	}{
+		{
+			name:     "start failure single model",
+			chunks:   single,
+			ttsProv:  &ttsmock.Provider{SynthesizeErr: errTTS},
+			want:     [][]byte{errorClip},
+			wantErr:  true,
+			withClip: true,
+		},
+		{
+			name:     "start failure dual model",
+			chunks:   dual,
+			ttsProv:  &ttsmock.Provider{SynthesizeErr: errTTS},
+			want:     [][]byte{errorClip},
+			wantErr:  true,
+			withClip: true,
+		},
+		{
+			name:     "stream without audio",
+			chunks:   dual,
+			ttsProv:  &ttsmock.Provider{},
+			want:     [][]byte{errorClip},
+			wantText: true,
+			withClip: true,
+		},
+		{
+			name:     "successful synthesis",
+			chunks:   single,
+			ttsProv:  newTTS(),
+			want:     [][]byte{[]byte("audio")},
+			wantText: true,
+			withClip: true,
+		},
+		{
+			name:     "stream failure without audio",
+			chunks:   dual,
+			ttsProv:  &ttsmock.Provider{StreamErr: errTTS},
+			want:     [][]byte{errorClip},
+			wantErr:  true,
+			wantText: true,
+			withClip: true,
+		},
+		{
+			name:     "stream failure after audio",
+			chunks:   single,
+			ttsProv:  &ttsmock.Provider{SynthesizeChunks: [][]byte{[]byte("audio")}, StreamErr: errTTS},
+			want:     [][]byte{[]byte("audio")},
+			wantErr:  true,
+			wantText: true,
+			withClip: true,
+		},
+		{
+			name:     "stream failure without error audio",
+			chunks:   single,
+			ttsProv:  &ttsmock.Provider{StreamErr: errTTS},
+			wantErr:  true,
+			wantText: true,
+		},
+		{
+			name:      "start failure without error audio",
+			chunks:    single,
+			ttsProv:   &ttsmock.Provider{SynthesizeErr: errTTS},
+			wantStart: true,
+		},
+		{
+			name:     "stream without audio and without error audio",
+			chunks:   single,
+			ttsProv:  &ttsmock.Provider{},
+			wantText: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts []cascade.Option
+			if tc.withClip {
+				opts = append(opts, cascade.WithErrorAudio(errorClip))
+			}
+			fastLLM := &llmmock.Provider{StreamChunks: tc.chunks}
+			strongLLM := &llmmock.Provider{StreamChunks: []llm.Chunk{{Text: "What brings you here?", FinishReason: "stop"}}}
+			e := cascade.New(fastLLM, strongLLM, tc.ttsProv, tts.VoiceProfile{}, opts...)
+			t.Cleanup(func() { _ = e.Close() })
+
+			resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{
+				SystemPrompt: "You are an innkeeper.",
+			})
+			if tc.wantStart {
+				if !errors.Is(err, errTTS) {
+					t.Fatalf("Process error = %v, want %v", err, errTTS)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			var got [][]byte
+			for chunk := range resp.Audio {
+				got = append(got, chunk)
+			}
+			e.Wait()
+
+			if !slices.EqualFunc(got, tc.want, slices.Equal) {
+				t.Errorf("audio = %q, want %q", got, tc.want)
+			}
+			if gotErr := resp.Err(); (gotErr != nil) != tc.wantErr || (tc.wantErr && !errors.Is(gotErr, errTTS)) {
+				t.Errorf("resp.Err() = %v, want error %v", gotErr, tc.wantErr)
+			}
+			if (resp.Text != "") != tc.wantText {
+				t.Errorf("resp.Text = %q, want text kept: %v", resp.Text, tc.wantText)
+			}
+		})
+	}
+}
+
+// TestGreet_ErrorAudio verifies that a greeting whose synthesis cannot be
+// started plays the error audio.
+// stalledLLM streams one chunk and then keeps its stream open until release
+// is closed, like a provider that stopped responding.
+type stalledLLM struct {
+	llmmock.Provider
+	release chan struct{}
+}
+
+func (p *stalledLLM) StreamCompletion(context.Context, llm.CompletionRequest) (<-chan llm.Chunk, error) {
+	ch := make(chan llm.Chunk)
+	go func() {
+		defer close(ch)
+		ch <- llm.Chunk{Text: "Wel"}
+		<-p.release
+	}()
+	return ch, nil
+}
+
+func TestGreet_CancelledWhileStreaming(t *testing.T) {
+	t.Parallel()
+
+	fastLLM := &stalledLLM{release: make(chan struct{})}
+	t.Cleanup(func() { close(fastLLM.release) })
+	e := cascade.New(fastLLM, &llmmock.Provider{}, newTTS(), tts.VoiceProfile{})
+	t.Cleanup(func() { _ = e.Close() })
+
+	ctx, cancel := context.WithCancel(t.Context())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := e.Greet(ctx, enginepkg.PromptContext{SystemPrompt: "You are an innkeeper."})
+		errCh <- err
+	}()
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Greet error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Greet did not return after its context was cancelled")
+	}
+}
+
+func TestGreet_ErrorAudio(t *testing.T) {
+	t.Parallel()
+
+	fastLLM := &llmmock.Provider{StreamChunks: []llm.Chunk{{Text: "Welcome!", FinishReason: "stop"}}}
+	ttsProv := &ttsmock.Provider{SynthesizeErr: errors.New("tts down")}
+	e := cascade.New(fastLLM, &llmmock.Provider{}, ttsProv, tts.VoiceProfile{}, cascade.WithErrorAudio([]byte("chime")))
+	t.Cleanup(func() { _ = e.Close() })
+
+	resp, err := e.Greet(context.Background(), enginepkg.PromptContext{SystemPrompt: "You are an innkeeper."})
+	if err != nil {
+		t.Fatalf("Greet: %v", err)
+	}
+	var got []byte
+	for chunk := range resp.Audio {
+		got = append(got, chunk...)
+	}
+	if string(got) != "chime" {
+		t.Errorf("audio = %q, want the error audio", got)
+	}
+	if resp.Err() == nil {
+		t.Error("resp.Err() = nil, want the TTS start error")
+	}
+}
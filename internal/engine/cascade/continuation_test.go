@@ -0,0 +1,145 @@
+package cascade_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	enginepkg "github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// ─── TestProcess_ContinuationRepeatsOpener ────────────────────────────────────
+
+func TestProcess_ContinuationRepeatsOpener(t *testing.T) {
+	t.Parallel()
+
+	const opener = "Ah, the artifact!"
+
+	tests := []struct {
+		name   string
+		strong []llm.Chunk
+		want   []string // text sent to TTS after the opener
+	}{
+		{
+			name:   "repeated opener stripped",
+			strong: reply("Ah, the artifact! It was forged long ago."),
+			want:   []string{"It was forged long ago."},
+		},
+		{
+			name: "repeat split across chunks",
+			strong: []llm.Chunk{
+				{Text: " Ah, the"}, {Text: " arti"}, {Text: "fact! It was "}, {Text: "forged long ago.", FinishReason: "stop"},
+			},
+			want: []string{"It was forged long ago."},
+		},
+		{
+			name:   "repeat in different case",
+			strong: reply("ah, THE artifact!\nIt was forged long ago."),
+			want:   []string{"It was forged long ago."},
+		},
+		{
+			name:   "only the opener",
+			strong: reply("Ah, the artifact!"),
+			want:   nil,
+		},
+		{
+			name:   "partial repeat only",
+			strong: reply("Ah, the"),
+			want:   nil,
+		},
+		{
+			name:   "genuine continuation kept",
+			strong: reply("It was forged long ago. Ah, the artifact! Yes."),
+			want:   []string{"It was forged long ago.", "Ah, the artifact!", "Yes."},
+		},
+		{
+			name:   "diverging start kept",
+			strong: reply("Ah, the old days. It was forged then."),
+			want:   []string{"Ah, the old days.", "It was forged then."},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &llmmock.Provider{
+				StreamChunks: []llm.Chunk{{Text: opener + " "}, {Text: "remaining", FinishReason: "stop"}},
+			}
+			strongLLM := &chunkScriptLLM{replies: [][]llm.Chunk{tc.strong}}
+			ttsProv := &textRecorder{}
+
+			e := cascade.New(fastLLM, strongLLM, ttsProv, tts.VoiceProfile{})
+			t.Cleanup(func() { _ = e.Close() })
+
+			resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{
+				Messages: []llm.Message{{Role: "user", Content: "Tell me about the artifact."}},
+			})
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			drainAudio(resp.Audio)
+			e.Wait()
+
+			ttsProv.mu.Lock()
+			texts := slices.Clone(ttsProv.texts)
+			ttsProv.mu.Unlock()
+			if len(texts) == 0 || texts[0] != opener {
+				t.Fatalf("TTS text: want the opener first, got %q", texts)
+			}
+			if got := texts[1:]; !slices.Equal(got, tc.want) {
+				t.Errorf("continuation sent to TTS: want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+// ─── TestProcess_ContinuationLimits ───────────────────────────────────────────
+
+func TestProcess_ContinuationLimits(t *testing.T) {
+	t.Parallel()
+
+	fastLLM := &llmmock.Provider{
+		StreamChunks: []llm.Chunk{{Text: "Well met. "}, {Text: "remaining", FinishReason: "stop"}},
+	}
+	strongLLM := &llmmock.Provider{
+		StreamChunks: []llm.Chunk{{Text: "What brings you here?", FinishReason: "stop"}},
+	}
+	stop := []string{"\n\n", "Player:"}
+
+	e := cascade.New(fastLLM, strongLLM, newTTS(), tts.VoiceProfile{},
+		cascade.WithContinuationStop(stop...),
+		cascade.WithMaxContinuationTokens(120),
+	)
+	t.Cleanup(func() { _ = e.Close() })
+	stop[0] = "changed" // the engine keeps its own copy
+
+	resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{
+		Messages: []llm.Message{{Role: "user", Content: "Hello!"}},
+	})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	drainAudio(resp.Audio)
+	e.Wait()
+
+	if len(strongLLM.StreamCalls) != 1 {
+		t.Fatalf("strong model calls: want 1, got %d", len(strongLLM.StreamCalls))
+	}
+	strongReq := strongLLM.StreamCalls[0].Req
+	if want := []string{"\n\n", "Player:"}; !slices.Equal(strongReq.Stop, want) {
+		t.Errorf("strong Stop: want %q, got %q", want, strongReq.Stop)
+	}
+	if strongReq.MaxTokens != 120 {
+		t.Errorf("strong MaxTokens: want 120, got %d", strongReq.MaxTokens)
+	}
+
+	fastReq := fastLLM.StreamCalls[0].Req
+	if len(fastReq.Stop) != 0 || fastReq.MaxTokens != 0 {
+		t.Errorf("fast model request must not be limited, got Stop %q, MaxTokens %d", fastReq.Stop, fastReq.MaxTokens)
+	}
+}
@@ -0,0 +1,99 @@
+package cascade_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	enginepkg "github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// ─── TestProcess_InterruptionNote ─────────────────────────────────────────────
+
+func TestProcess_InterruptionNote(t *testing.T) {
+	t.Parallel()
+
+	const said = `You had said: "Well met. What brings you here?"`
+
+	tests := []struct {
+		name      string
+		opts      []cascade.Option
+		interrupt bool
+		wantNote  string // expected in the second turn's prompts; empty for none
+	}{
+		{
+			name:      "interrupted reply is quoted",
+			opts:      []cascade.Option{cascade.WithInterruptionNote("")},
+			interrupt: true,
+			wantNote:  cascade.DefaultInterruptionNote + "\n\n" + said,
+		},
+		{
+			name:      "custom note",
+			opts:      []cascade.Option{cascade.WithInterruptionNote("You were cut off.")},
+			interrupt: true,
+			wantNote:  "You were cut off.\n\n" + said,
+		},
+		{
+			name:     "finished reply",
+			opts:     []cascade.Option{cascade.WithInterruptionNote("")},
+			wantNote: "",
+		},
+		{
+			name:      "option not set",
+			interrupt: true,
+			wantNote:  "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &llmmock.Provider{
+				StreamChunks: []llm.Chunk{{Text: "Well met. "}, {Text: "remaining", FinishReason: "stop"}},
+			}
+			strongLLM := &llmmock.Provider{
+				StreamChunks: []llm.Chunk{{Text: "What brings you here?", FinishReason: "stop"}},
+			}
+
+			e := cascade.New(fastLLM, strongLLM, newTTS(), tts.VoiceProfile{}, tc.opts...)
+			t.Cleanup(func() { _ = e.Close() })
+
+			prompt := enginepkg.PromptContext{
+				SystemPrompt: "You are Greymantle.",
+				Messages:     []llm.Message{{Role: "user", Content: "Hello!"}},
+			}
+			// Turn 1 is cut off (or not), turn 2 follows it and turn 3
+			// comes after an uninterrupted turn 2.
+			for turn := range 3 {
+				resp, err := e.Process(context.Background(), emptyAudioFrame, prompt)
+				if err != nil {
+					t.Fatalf("Process turn %d: %v", turn+1, err)
+				}
+				drainAudio(resp.Audio)
+				e.Wait()
+				if turn == 0 && tc.interrupt {
+					e.Interrupted()
+				}
+			}
+
+			for turn, want := range []string{"", tc.wantNote, ""} {
+				fastPrompt := fastLLM.StreamCalls[turn].Req.SystemPrompt
+				strongPrompt := strongLLM.StreamCalls[turn].Req.SystemPrompt
+				for model, got := range map[string]string{"fast": fastPrompt, "strong": strongPrompt} {
+					hasNote := strings.Contains(got, "You had said:") || strings.Contains(got, "cut off")
+					switch {
+					case want == "" && hasNote:
+						t.Errorf("turn %d %s prompt: want no interruption note, got %q", turn+1, model, got)
+					case want != "" && !strings.Contains(got, "You are Greymantle.\n\n"+want):
+						t.Errorf("turn %d %s prompt: want the note %q after the system prompt, got %q", turn+1, model, want, got)
+					}
+				}
+			}
+		})
+	}
+}
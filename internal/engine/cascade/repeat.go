@@ -0,0 +1,115 @@
+package cascade
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/antzucaro/matchr"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+const (
+	// DefaultRepeatThreshold is the similarity at or above which a new opener
+	// counts as a repeat of a recent one when no threshold is configured.
+	DefaultRepeatThreshold = 0.85
+
+	// repeatNudge is the user-role instruction appended to the fast model's
+	// request when its opener repeats a recent line. %q is the repeated line.
+	repeatNudge = "You recently said %q. Respond in character again, but vary your phrasing and do not repeat that line."
+)
+
+// WithRepeatWindow enables the repeat check: the opener of each reply is
+// compared against the openers of the last n replies, and when it is too
+// similar (see [WithRepeatThreshold]) the fast model is asked once more to
+// vary its phrasing. The check runs before synthesis, so the repeat is never
+// spoken. n <= 0 disables the check, which is the default.
+func WithRepeatWindow(n int) Option {
+	return func(e *Engine) { e.repeatWindow = max(n, 0) }
+}
+
+// WithRepeatThreshold sets the similarity in (0, 1] at or above which an
+// opener counts as a repeat. Similarity is computed on normalised text
+// (case, punctuation and spacing ignored) as one minus the edit distance
+// divided by the length of the longer line, so 1 means identical. Values
+// outside (0, 1] are ignored; the default is [DefaultRepeatThreshold].
+func WithRepeatThreshold(t float64) Option {
+	return func(e *Engine) {
+		if t > 0 && t <= 1 {
+			e.repeatThreshold = t
+		}
+	}
+}
+
+// avoidRepeat returns opener unchanged unless it repeats one of the recent
+// openers. A repeat is regenerated once with a nudge; the regenerated opener
+// is used if it is not empty, whether or not it is still similar. The opener
+// finally used is remembered for later turns.
+func (e *Engine) avoidRepeat(ctx context.Context, req llm.CompletionRequest, opener string, full bool) (string, bool) {
+	if e.repeatWindow == 0 {
+		return opener, full
+	}
+
+	if prev, ok := e.recentRepeat(opener); ok && ctx.Err() == nil {
+		slog.Info("cascade: opener repeats a recent line, regenerating", "line", prev)
+
+		retry := req
+		retry.Messages = make([]llm.Message, len(req.Messages)+1)
+		copy(retry.Messages, req.Messages)
+		retry.Messages[len(req.Messages)] = llm.Message{Role: "user", Content: fmt.Sprintf(repeatNudge, prev)}
+
+		ch, err := e.fastLLM.StreamCompletion(ctx, retry)
+		if err != nil {
+			slog.Warn("cascade: fast model regenerate failed", "err", err)
+		} else if sentence, sentenceFull := e.collectFirstSentence(ctx, ch); strings.TrimSpace(sentence) != "" {
+			opener, full = sentence, sentenceFull
+		}
+	}
+
+	e.mu.Lock()
+	e.recent = append(e.recent, opener)
+	if len(e.recent) > e.repeatWindow {
+		e.recent = e.recent[len(e.recent)-e.repeatWindow:]
+	}
+	e.mu.Unlock()
+	return opener, full
+}
+
+// recentRepeat returns the recent opener that line is too similar to, if any.
+func (e *Engine) recentRepeat(line string) (string, bool) {
+	norm := normalizeLine(line)
+	if norm == "" {
+		return "", false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, prev := range e.recent {
+		if lineSimilarity(norm, normalizeLine(prev)) >= e.repeatThreshold {
+			return prev, true
+		}
+	}
+	return "", false
+}
+
+// normalizeLine lowercases s and reduces it to its words separated by single
+// spaces, dropping punctuation.
+func normalizeLine(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// lineSimilarity returns the similarity of two normalised lines in [0, 1]:
+// one minus their edit distance divided by the length of the longer one.
+func lineSimilarity(a, b string) float64 {
+	longest := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(matchr.Levenshtein(a, b))/float64(longest)
+}
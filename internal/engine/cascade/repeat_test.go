@@ -0,0 +1,154 @@
+package cascade_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	enginepkg "github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// TestProcess_RepeatWindow verifies that an opener similar to one of the
+// recent openers is regenerated once with a nudge.
+func TestProcess_RepeatWindow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		replies       []string // fast-model reply of each turn
+		opts          []cascade.Option
+		wantFastCalls int
+	}{
+		{
+			name:          "disabled by default",
+			replies:       []string{"Well met, traveller.", "Well met, traveller."},
+			wantFastCalls: 2,
+		},
+		{
+			name:          "identical line regenerated",
+			replies:       []string{"Well met, traveller.", "Well met, traveller."},
+			opts:          []cascade.Option{cascade.WithRepeatWindow(3)},
+			wantFastCalls: 3,
+		},
+		{
+			name:          "near-identical line regenerated",
+			replies:       []string{"Well met, traveller.", "Well met, traveler!"},
+			opts:          []cascade.Option{cascade.WithRepeatWindow(3)},
+			wantFastCalls: 3,
+		},
+		{
+			name:          "different line kept",
+			replies:       []string{"Well met, traveller.", "The roads are dangerous tonight."},
+			opts:          []cascade.Option{cascade.WithRepeatWindow(3)},
+			wantFastCalls: 2,
+		},
+		{
+			name:          "repeat outside window",
+			replies:       []string{"Well met, traveller.", "The roads are dangerous tonight.", "Well met, traveller."},
+			opts:          []cascade.Option{cascade.WithRepeatWindow(1)},
+			wantFastCalls: 3,
+		},
+		{
+			name:    "threshold raised",
+			replies: []string{"Well met, traveller.", "Well met, traveler!"},
+			opts: []cascade.Option{
+				cascade.WithRepeatWindow(3),
+				cascade.WithRepeatThreshold(1),
+			},
+			wantFastCalls: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &llmmock.Provider{}
+			e := cascade.New(fastLLM, &llmmock.Provider{}, newTTS(), tts.VoiceProfile{}, tc.opts...)
+			t.Cleanup(func() { _ = e.Close() })
+
+			for _, reply := range tc.replies {
+				fastLLM.StreamChunks = []llm.Chunk{{Text: reply, FinishReason: "stop"}}
+				resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{
+					Messages: []llm.Message{{Role: "user", Content: "Hello?"}},
+				})
+				if err != nil {
+					t.Fatalf("Process: %v", err)
+				}
+				drainAudio(resp.Audio)
+				e.Wait()
+			}
+
+			if len(fastLLM.StreamCalls) != tc.wantFastCalls {
+				t.Fatalf("fastLLM StreamCompletion calls: want %d, got %d", tc.wantFastCalls, len(fastLLM.StreamCalls))
+			}
+			if tc.wantFastCalls > len(tc.replies) {
+				// The regenerate carries the original history plus the nudge.
+				msgs := fastLLM.StreamCalls[len(fastLLM.StreamCalls)-1].Req.Messages
+				if len(msgs) != 2 {
+					t.Fatalf("regenerate message count: want 2, got %d", len(msgs))
+				}
+				if msgs[1].Role != "user" || !strings.Contains(msgs[1].Content, "Well met, traveller.") {
+					t.Errorf("regenerate nudge: want user message quoting the repeated line, got %+v", msgs[1])
+				}
+			}
+		})
+	}
+}
+
+// scriptedLLM answers the n-th StreamCompletion call with replies[n], and
+// every later call with the last reply.
+type scriptedLLM struct {
+	llmmock.Provider
+
+	mu      sync.Mutex
+	replies []string
+	calls   int
+}
+
+func (p *scriptedLLM) StreamCompletion(_ context.Context, _ llm.CompletionRequest) (<-chan llm.Chunk, error) {
+	p.mu.Lock()
+	reply := p.replies[min(p.calls, len(p.replies)-1)]
+	p.calls++
+	p.mu.Unlock()
+
+	ch := make(chan llm.Chunk, 1)
+	ch <- llm.Chunk{Text: reply, FinishReason: "stop"}
+	close(ch)
+	return ch, nil
+}
+
+// TestProcess_RepeatRegenerated verifies that the regenerated opener replaces
+// the repeated one in the response and is remembered for later turns.
+func TestProcess_RepeatRegenerated(t *testing.T) {
+	t.Parallel()
+
+	fastLLM := &scriptedLLM{replies: []string{
+		"Well met, traveller.",
+		"Well met, traveller.", // repeat → regenerated
+		"Back again, friend?",
+		"Back again, friend?", // repeats the regenerated line → regenerated
+		"Mind the stairs.",
+	}}
+	e := cascade.New(fastLLM, &llmmock.Provider{}, newTTS(), tts.VoiceProfile{}, cascade.WithRepeatWindow(2))
+	t.Cleanup(func() { _ = e.Close() })
+
+	want := []string{"Well met, traveller.", "Back again, friend?", "Mind the stairs."}
+	for i, w := range want {
+		resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{})
+		if err != nil {
+			t.Fatalf("Process %d: %v", i, err)
+		}
+		drainAudio(resp.Audio)
+		e.Wait()
+
+		if resp.Text != w {
+			t.Errorf("turn %d: resp.Text = %q, want %q", i, resp.Text, w)
+		}
+	}
+}
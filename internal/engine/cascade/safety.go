@@ -0,0 +1,85 @@
+package cascade
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+const (
+	// safetyNudge is the user-role instruction appended to the fast model's
+	// request when its reply was rejected by the safety filter.
+	safetyNudge = "Your previous reply contained content that is not allowed at this table. Respond in character again without it."
+
+	// safetyInstruction is appended to the strong model's system prompt when
+	// its continuation was rejected by the safety filter.
+	safetyInstruction = "Your previous continuation contained content that is not allowed at this table. Continue in character without it."
+)
+
+// WithSafetyFilter sets the filter every sentence of the NPC's reply is
+// checked with after post-processing and before synthesis. The filter's
+// verdict decides whether a sentence is spoken, redacted, withheld, or
+// regenerated. A nil filter keeps the default [engine.NopSafetyFilter].
+//
+// A regenerated opener or greeting is requested from the fast model again; a
+// regenerated continuation is requested from the strong model again,
+// continuing after the sentences already spoken. A reply whose opener is
+// blocked is replaced by the line set with [WithEmptyResponseFallback].
+func WithSafetyFilter(f engine.SafetyFilter) Option {
+	return func(e *Engine) {
+		if f != nil {
+			e.safety = f
+		}
+	}
+}
+
+// screenOpener post-processes opener and checks it with the safety filter. It
+// returns the opener as the model wrote it, the text to speak, and whether the
+// reply ends with the opener. A flagged opener is regenerated at most once;
+// a blocked one is replaced by the fallback line and ends the reply.
+func (e *Engine) screenOpener(ctx context.Context, req llm.CompletionRequest, opener string, full bool) (raw, spoken string, fullOut bool) {
+	spoken, action := engine.Screen(ctx, e.safety, e.speakerName, e.postProcessors.Apply(opener), true)
+	if action == engine.SafetyRegenerate {
+		action = engine.SafetyBlock
+		ch, err := e.fastLLM.StreamCompletion(ctx, withUserMessage(req, safetyNudge))
+		if err != nil {
+			slog.Warn("cascade: fast model regenerate failed", "err", err)
+		} else if opener, full = e.collectFirstSentence(ctx, ch); strings.TrimSpace(opener) != "" {
+			spoken, action = engine.Screen(ctx, e.safety, e.speakerName, e.postProcessors.Apply(opener), false)
+		}
+	}
+	if action == engine.SafetyBlock {
+		return e.emptyFallback, e.emptyFallback, true
+	}
+	return opener, spoken, full
+}
+
+// screenGreeting checks a post-processed greeting with the safety filter. A
+// flagged greeting is regenerated at most once from req; a blocked one is
+// returned as the empty string.
+func (e *Engine) screenGreeting(ctx context.Context, req llm.CompletionRequest, greeting string) string {
+	greeting, action := engine.Screen(ctx, e.safety, e.speakerName, greeting, true)
+	if action != engine.SafetyRegenerate {
+		return greeting
+	}
+	ch, err := e.fastLLM.StreamCompletion(ctx, withUserMessage(req, safetyNudge))
+	if err != nil {
+		slog.Warn("cascade: greeting regenerate failed", "err", err)
+		return ""
+	}
+	greeting, _ = engine.Screen(ctx, e.safety, e.speakerName, e.postProcessors.Apply(collectText(ctx, ch)), false)
+	return greeting
+}
+
+// withUserMessage returns a copy of req with a user message carrying content
+// appended.
+func withUserMessage(req llm.CompletionRequest, content string) llm.CompletionRequest {
+	msgs := make([]llm.Message, len(req.Messages)+1)
+	copy(msgs, req.Messages)
+	msgs[len(req.Messages)] = llm.Message{Role: "user", Content: content}
+	req.Messages = msgs
+	return req
+}
@@ -0,0 +1,308 @@
+package cascade_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	enginepkg "github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
+	enginemock "github.com/MrWong99/glyphoxa/internal/engine/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// chunkScriptLLM is like scriptedLLM, but replies with whole chunk streams
+// and records the requests it receives.
+type chunkScriptLLM struct {
+	llmmock.Provider
+
+	replies [][]llm.Chunk
+
+	mu   sync.Mutex
+	reqs []llm.CompletionRequest
+}
+
+func (p *chunkScriptLLM) StreamCompletion(_ context.Context, req llm.CompletionRequest) (<-chan llm.Chunk, error) {
+	p.mu.Lock()
+	n := len(p.reqs)
+	p.reqs = append(p.reqs, req)
+	p.mu.Unlock()
+
+	chunks := p.replies[min(n, len(p.replies)-1)]
+	ch := make(chan llm.Chunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (p *chunkScriptLLM) requests() []llm.CompletionRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.reqs)
+}
+
+// reply returns a single-chunk model reply.
+func reply(text string) []llm.Chunk {
+	return []llm.Chunk{{Text: text, FinishReason: "stop"}}
+}
+
+// ─── TestProcess_SafetyFilter ─────────────────────────────────────────────────
+
+func TestProcess_SafetyFilter(t *testing.T) {
+	t.Parallel()
+
+	// A fast reply that hands over to the strong model after "Aye."
+	dual := []llm.Chunk{{Text: "Aye. "}, {Text: "Let me think", FinishReason: "stop"}}
+
+	tests := []struct {
+		name            string
+		fast            [][]llm.Chunk
+		strong          [][]llm.Chunk
+		verdicts        map[string]enginepkg.SafetyVerdict
+		checkErr        error
+		wantText        string
+		wantTTS         []string
+		wantFastCalls   int
+		wantStrongCalls int
+	}{
+		{
+			name:          "clean reply spoken",
+			fast:          [][]llm.Chunk{reply("Well met.")},
+			wantText:      "Well met.",
+			wantTTS:       []string{"Well met."},
+			wantFastCalls: 1,
+		},
+		{
+			name:          "blocked opener replaced by fallback",
+			fast:          [][]llm.Chunk{reply("Curse you.")},
+			verdicts:      map[string]enginepkg.SafetyVerdict{"Curse you.": {Action: enginepkg.SafetyBlock}},
+			wantText:      "...",
+			wantTTS:       []string{"..."},
+			wantFastCalls: 1,
+		},
+		{
+			name:          "redacted opener",
+			fast:          [][]llm.Chunk{reply("Curse you, stranger.")},
+			verdicts:      map[string]enginepkg.SafetyVerdict{"Curse you, stranger.": {Action: enginepkg.SafetyRedact, Text: "Stranger."}},
+			wantText:      "Stranger.",
+			wantTTS:       []string{"Stranger."},
+			wantFastCalls: 1,
+		},
+		{
+			name:          "regenerated opener",
+			fast:          [][]llm.Chunk{reply("Curse you."), reply("Well met.")},
+			verdicts:      map[string]enginepkg.SafetyVerdict{"Curse you.": {Action: enginepkg.SafetyRegenerate}},
+			wantText:      "Well met.",
+			wantTTS:       []string{"Well met."},
+			wantFastCalls: 2,
+		},
+		{
+			name: "opener flagged again after regenerating is blocked",
+			fast: [][]llm.Chunk{reply("Curse you."), reply("Curse you twice.")},
+			verdicts: map[string]enginepkg.SafetyVerdict{
+				"Curse you.":       {Action: enginepkg.SafetyRegenerate},
+				"Curse you twice.": {Action: enginepkg.SafetyRegenerate},
+			},
+			wantText:      "...",
+			wantTTS:       []string{"..."},
+			wantFastCalls: 2,
+		},
+		{
+			name:          "failing filter blocks",
+			fast:          [][]llm.Chunk{reply("Well met.")},
+			checkErr:      errors.New("moderation API unavailable"),
+			wantText:      "...",
+			wantTTS:       []string{"..."},
+			wantFastCalls: 1,
+		},
+		{
+			name:            "blocked continuation ends the reply",
+			fast:            [][]llm.Chunk{dual},
+			strong:          [][]llm.Chunk{reply("The vault is near. Curse the gods. It lies below.")},
+			verdicts:        map[string]enginepkg.SafetyVerdict{"Curse the gods.": {Action: enginepkg.SafetyBlock}},
+			wantText:        "Aye.",
+			wantTTS:         []string{"Aye.", "The vault is near."},
+			wantFastCalls:   1,
+			wantStrongCalls: 1,
+		},
+		{
+			name:            "redacted continuation sentence",
+			fast:            [][]llm.Chunk{dual},
+			strong:          [][]llm.Chunk{reply("The vault is near. Curse the gods. It lies below.")},
+			verdicts:        map[string]enginepkg.SafetyVerdict{"Curse the gods.": {Action: enginepkg.SafetyRedact}},
+			wantText:        "Aye.",
+			wantTTS:         []string{"Aye.", "The vault is near.", "It lies below."},
+			wantFastCalls:   1,
+			wantStrongCalls: 1,
+		},
+		{
+			name:            "regenerated continuation",
+			fast:            [][]llm.Chunk{dual},
+			strong:          [][]llm.Chunk{reply("The vault is near. Curse the gods. It lies below."), reply("It lies below the chapel.")},
+			verdicts:        map[string]enginepkg.SafetyVerdict{"Curse the gods.": {Action: enginepkg.SafetyRegenerate}},
+			wantText:        "Aye.",
+			wantTTS:         []string{"Aye.", "The vault is near.", "It lies below the chapel."},
+			wantFastCalls:   1,
+			wantStrongCalls: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &chunkScriptLLM{replies: tc.fast}
+			strongLLM := &chunkScriptLLM{replies: tc.strong}
+			ttsProv := &textRecorder{}
+			filter := &enginemock.SafetyFilter{Verdicts: tc.verdicts, CheckError: tc.checkErr}
+
+			e := cascade.New(fastLLM, strongLLM, ttsProv, tts.VoiceProfile{}, cascade.WithSafetyFilter(filter))
+			t.Cleanup(func() { _ = e.Close() })
+
+			resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{
+				Messages: []llm.Message{{Role: "user", Content: "Where is the vault?"}},
+			})
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			drainAudio(resp.Audio)
+			e.Wait()
+
+			if resp.Text != tc.wantText {
+				t.Errorf("resp.Text = %q, want %q", resp.Text, tc.wantText)
+			}
+			ttsProv.mu.Lock()
+			texts := ttsProv.texts
+			ttsProv.mu.Unlock()
+			if !slices.Equal(texts, tc.wantTTS) {
+				t.Errorf("TTS texts = %q, want %q", texts, tc.wantTTS)
+			}
+			if got := len(fastLLM.requests()); got != tc.wantFastCalls {
+				t.Errorf("fast model calls = %d, want %d", got, tc.wantFastCalls)
+			}
+			if got := len(strongLLM.requests()); got != tc.wantStrongCalls {
+				t.Errorf("strong model calls = %d, want %d", got, tc.wantStrongCalls)
+			}
+		})
+	}
+}
+
+// ─── TestProcess_SafetyRegenerateRequests ─────────────────────────────────────
+
+// TestProcess_SafetyRegenerateRequests verifies what the models are asked when
+// a reply is regenerated: the fast model gets a nudge, the strong model
+// continues after the sentences already spoken.
+func TestProcess_SafetyRegenerateRequests(t *testing.T) {
+	t.Parallel()
+
+	fastLLM := &chunkScriptLLM{replies: [][]llm.Chunk{
+		reply("Curse you."),
+		{{Text: "Aye. "}, {Text: "Let me think", FinishReason: "stop"}},
+	}}
+	strongLLM := &chunkScriptLLM{replies: [][]llm.Chunk{
+		reply("The vault is near. Curse the gods."),
+		reply("It lies below."),
+	}}
+	filter := &enginemock.SafetyFilter{Verdicts: map[string]enginepkg.SafetyVerdict{
+		"Curse you.":      {Action: enginepkg.SafetyRegenerate},
+		"Curse the gods.": {Action: enginepkg.SafetyRegenerate},
+	}}
+
+	e := cascade.New(fastLLM, strongLLM, &textRecorder{}, tts.VoiceProfile{}, cascade.WithSafetyFilter(filter))
+	t.Cleanup(func() { _ = e.Close() })
+
+	resp, err := e.Process(context.Background(), emptyAudioFrame, enginepkg.PromptContext{
+		SystemPrompt: "You are a priest.",
+		Messages:     []llm.Message{{Role: "user", Content: "Where is the vault?"}},
+	})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	drainAudio(resp.Audio)
+	e.Wait()
+
+	fast := fastLLM.requests()
+	if len(fast) != 2 {
+		t.Fatalf("fast model calls = %d, want 2", len(fast))
+	}
+	if msgs := fast[1].Messages; len(msgs) != 2 || msgs[1].Role != "user" {
+		t.Errorf("regenerated opener request messages = %+v, want the nudge appended", msgs)
+	}
+
+	strong := strongLLM.requests()
+	if len(strong) != 2 {
+		t.Fatalf("strong model calls = %d, want 2", len(strong))
+	}
+	if strings.Contains(strong[0].SystemPrompt, "not allowed") {
+		t.Error("first strong request already carries the safety instruction")
+	}
+	if !strings.Contains(strong[1].SystemPrompt, "not allowed") {
+		t.Errorf("regenerated strong request lacks the safety instruction: %q", strong[1].SystemPrompt)
+	}
+	msgs := strong[1].Messages
+	if last := msgs[len(msgs)-1]; last.Role != "assistant" || last.Content != "Aye. The vault is near." {
+		t.Errorf("regenerated strong request prefix = %+v, want opener and spoken sentence", last)
+	}
+}
+
+// ─── TestGreet_SafetyFilter ───────────────────────────────────────────────────
+
+func TestGreet_SafetyFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		replies       [][]llm.Chunk
+		action        enginepkg.SafetyAction
+		wantText      string
+		wantFastCalls int
+	}{
+		{
+			name:          "blocked greeting uses fallback",
+			replies:       [][]llm.Chunk{reply("Curse you all.")},
+			action:        enginepkg.SafetyBlock,
+			wantText:      "...",
+			wantFastCalls: 1,
+		},
+		{
+			name:          "regenerated greeting",
+			replies:       [][]llm.Chunk{reply("Curse you all."), reply("Welcome, friends!")},
+			action:        enginepkg.SafetyRegenerate,
+			wantText:      "Welcome, friends!",
+			wantFastCalls: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &chunkScriptLLM{replies: tc.replies}
+			filter := &enginemock.SafetyFilter{Verdicts: map[string]enginepkg.SafetyVerdict{
+				"Curse you all.": {Action: tc.action},
+			}}
+			e := cascade.New(fastLLM, &llmmock.Provider{}, newTTS(), tts.VoiceProfile{}, cascade.WithSafetyFilter(filter))
+			t.Cleanup(func() { _ = e.Close() })
+
+			resp, err := e.Greet(context.Background(), enginepkg.PromptContext{SystemPrompt: "You are an innkeeper."})
+			if err != nil {
+				t.Fatalf("Greet: %v", err)
+			}
+			drainAudio(resp.Audio)
+
+			if resp.Text != tc.wantText {
+				t.Errorf("resp.Text = %q, want %q", resp.Text, tc.wantText)
+			}
+			if got := len(fastLLM.requests()); got != tc.wantFastCalls {
+				t.Errorf("fast model calls = %d, want %d", got, tc.wantFastCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package cascade_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	enginepkg "github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	sttmock "github.com/MrWong99/glyphoxa/pkg/provider/stt/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	ttsmock "github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+)
+
+// slowLLM delays every stream by delay before replying like the embedded mock.
+type slowLLM struct {
+	llmmock.Provider
+	delay time.Duration
+}
+
+func (p *slowLLM) StreamCompletion(ctx context.Context, req llm.CompletionRequest) (<-chan llm.Chunk, error) {
+	time.Sleep(p.delay)
+	return p.Provider.StreamCompletion(ctx, req)
+}
+
+// echoTTS emits one audio chunk per text fragment as it arrives and closes
+// the stream once the text channel closes, like a streaming TTS provider.
+type echoTTS struct {
+	ttsmock.Provider
+}
+
+func (p *echoTTS) SynthesizeStream(_ context.Context, text <-chan string, _ tts.VoiceProfile) (*tts.Stream, error) {
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		for s := range text {
+			out <- []byte(s)
+		}
+	}()
+	return tts.NewStream(out), nil
+}
+
+// ─── TestProcess_Timings ──────────────────────────────────────────────────────
+
+func TestProcess_Timings(t *testing.T) {
+	t.Parallel()
+
+	const delay = 20 * time.Millisecond
+
+	tests := []struct {
+		name       string
+		fastChunks []llm.Chunk
+		withSTT    bool
+		wantStrong bool
+	}{
+		{
+			name:       "dual model with STT",
+			fastChunks: []llm.Chunk{{Text: "Ah, traveller! "}, {Text: "and more text", FinishReason: "stop"}},
+			withSTT:    true,
+			wantStrong: true,
+		},
+		{
+			name:       "dual model from text",
+			fastChunks: []llm.Chunk{{Text: "Ah, traveller! "}, {Text: "and more text", FinishReason: "stop"}},
+			wantStrong: true,
+		},
+		{
+			name:       "fast only",
+			fastChunks: []llm.Chunk{{Text: "Well met, traveller.", FinishReason: "stop"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fastLLM := &slowLLM{Provider: llmmock.Provider{StreamChunks: tc.fastChunks}, delay: delay}
+			strongLLM := &slowLLM{
+				Provider: llmmock.Provider{StreamChunks: []llm.Chunk{{Text: "What brings you here?", FinishReason: "stop"}}},
+				delay:    delay,
+			}
+
+			var opts []cascade.Option
+			frame := emptyAudioFrame
+			if tc.withSTT {
+				sess := &sttmock.Session{
+					PartialsCh: make(chan stt.Transcript),
+					FinalsCh:   make(chan stt.Transcript, 2),
+				}
+				sess.FinalsCh <- stt.Transcript{Text: "Hello", IsFinal: true, Language: "de"}
+				sess.FinalsCh <- stt.Transcript{Text: "there.", IsFinal: true}
+				close(sess.FinalsCh)
+				opts = append(opts, cascade.WithSTT(&sttmock.Provider{Session: sess}))
+				frame = audio.AudioFrame{Data: make([]byte, 320), SampleRate: 16000, Channels: 1}
+			}
+
+			e := cascade.New(fastLLM, strongLLM, &echoTTS{}, tts.VoiceProfile{}, opts...)
+			t.Cleanup(func() { _ = e.Close() })
+
+			resp, err := e.Process(context.Background(), frame, enginepkg.PromptContext{})
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			drainAudio(resp.Audio)
+			e.Wait()
+
+			tm := resp.Timings()
+			if tc.withSTT {
+				if tm.STT <= 0 {
+					t.Errorf("STT: want > 0, got %v", tm.STT)
+				}
+				msgs := fastLLM.StreamCalls[0].Req.Messages
+				if len(msgs) == 0 || msgs[len(msgs)-1].Content != "Hello there." {
+					t.Errorf("fast model messages: want the transcript last, got %+v", msgs)
+				}
+			} else if tm.STT != 0 {
+				t.Errorf("STT: want 0 without audio input, got %v", tm.STT)
+			}
+
+			if tm.Opener < tm.STT+delay {
+				t.Errorf("Opener %v: want at least STT %v plus the fast model's %v", tm.Opener, tm.STT, delay)
+			}
+			if tm.FirstAudio < tm.Opener {
+				t.Errorf("FirstAudio %v is before Opener %v", tm.FirstAudio, tm.Opener)
+			}
+			if tm.Total < tm.FirstAudio {
+				t.Errorf("Total %v is before FirstAudio %v", tm.Total, tm.FirstAudio)
+			}
+			if tc.wantStrong {
+				if tm.Strong < delay {
+					t.Errorf("Strong: want at least %v, got %v", delay, tm.Strong)
+				}
+				if tm.Total < tm.Opener+tm.Strong {
+					t.Errorf("Total %v is less than Opener %v plus Strong %v", tm.Total, tm.Opener, tm.Strong)
+				}
+			} else if tm.Strong != 0 {
+				t.Errorf("Strong: want 0 without strong model, got %v", tm.Strong)
+			}
+
+			if lat := resp.Latency(); lat.Opener != tm.Opener || lat.Strong != tm.Strong {
+				t.Errorf("Latency %+v disagrees with Timings %+v", lat, tm)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ package engine
 import (
 	"context"
 	"sync/atomic"
+	"time"
 
 	"github.com/MrWong99/glyphoxa/internal/mcp"
 	"github.com/MrWong99/glyphoxa/pkg/audio"
@@ -51,6 +52,11 @@ type PromptContext struct {
 	// BudgetTier controls which tools are offered to the LLM based on latency
 	// constraints. See [mcp.BudgetTier] for tier definitions.
 	BudgetTier mcp.BudgetTier
+
+	// Language is the BCP-47 tag of the language the player spoke in, as
+	// detected by STT. When set, engines that synthesise speech request it
+	// through the TTS voice profile; empty keeps the voice's language.
+	Language string
 }
 
 // ContextUpdate carries a mid-session context refresh pushed via
@@ -68,6 +74,13 @@ type ContextUpdate struct {
 	// RecentUtterances are the latest transcript entries to append to the
 	// engine's conversation history before the next process call.
 	RecentUtterances []memory.TranscriptEntry
+
+	// Priority marks an urgent update (e.g. "the building is on fire") that
+	// must not wait for the next turn. Engines merge it like any other update;
+	// the caller driving the turn is expected to interrupt the reply in flight
+	// and call [VoiceEngine.Process] again so that it applies at once. It is
+	// opt-in per update: without it an update never disturbs a reply.
+	Priority bool
 }
 
 // Response is the result of a successful [VoiceEngine.Process] call.
@@ -97,9 +110,89 @@ type Response struct {
 	// results back to the engine via a follow-up [VoiceEngine.Process] call.
 	ToolCalls []llm.ToolCall
 
+	// OpenerText is the fast model's opening sentence, as spoken, for engines
+	// that split a reply between a fast and a strong model. When the fast
+	// model answered on its own it is the whole reply. Empty for single-model
+	// engines.
+	OpenerText string
+
 	// streamErr stores the error that caused the Audio channel to close early.
 	// Access via Err and SetStreamErr.
 	streamErr atomic.Pointer[error]
+
+	// usedStrong records whether the turn was escalated to a strong model.
+	// Access via UsedStrongModel and SetUsedStrongModel.
+	usedStrong atomic.Bool
+
+	// latency stores the latency breakdown. Access via Latency and SetLatency.
+	latency atomic.Pointer[Latency]
+
+	// timings stores the stage timings in nanoseconds, indexed by
+	// [TimingStage]. Access via Timings and SetTiming.
+	timings [numTimingStages]atomic.Int64
+}
+
+// TimingStage identifies a stage of a turn recorded in [Timings].
+type TimingStage int
+
+// Stages recorded in [Timings].
+const (
+	// StageSTT is the transcription of the input audio.
+	StageSTT TimingStage = iota
+	// StageOpener is the generation of the first sentence of the reply.
+	StageOpener
+	// StageStrong is the strong model's generation of the rest of the reply.
+	StageStrong
+	// StageFirstAudio is the delivery of the first audio chunk.
+	StageFirstAudio
+	// StageTotal is the whole turn, up to the end of its audio.
+	StageTotal
+
+	numTimingStages
+)
+
+// Timings records how long the stages of a turn took, for latency tuning.
+// Every value is measured with the monotonic clock from the start of
+// [VoiceEngine.Process]; Strong is the one duration of a stage in itself.
+// Stages an engine does not run, or that have not finished yet, are zero.
+//
+// Unlike [Latency], which covers text generation, Timings extends to the
+// audio the listener hears.
+type Timings struct {
+	// STT is the time spent transcribing audio passed to Process. Zero when
+	// the input arrived as text.
+	STT time.Duration
+
+	// Opener is the time until the first sentence was ready to be
+	// synthesised, including STT.
+	Opener time.Duration
+
+	// Strong is the time the strong model took to generate the rest of the
+	// reply, including tool-call rounds. Zero when it was not used.
+	Strong time.Duration
+
+	// FirstAudio is the time until the first audio chunk was delivered on
+	// [Response.Audio].
+	FirstAudio time.Duration
+
+	// Total is the time until [Response.Audio] closed.
+	Total time.Duration
+}
+
+// Latency breaks down how long generating a reply took, measured from the
+// start of [VoiceEngine.Process]. Engines fill in the stages they run; the
+// others stay zero.
+type Latency struct {
+	// Opener is the time until the fast model's first sentence was ready to
+	// be synthesised.
+	Opener time.Duration
+
+	// Strong is the time the strong model took to generate the rest of the
+	// reply, including tool-call rounds. Zero when it was not used.
+	Strong time.Duration
+
+	// Total is the time until the whole reply text was generated.
+	Total time.Duration
 }
 
 // Err returns the error that caused the Audio channel to close prematurely,
@@ -119,6 +212,57 @@ func (r *Response) SetStreamErr(err error) {
 	r.streamErr.Store(&err)
 }
 
+// UsedStrongModel reports whether a dual-model engine handed the turn to its
+// strong model after the fast model's opener. It is always false for engines
+// with a single model.
+func (r *Response) UsedStrongModel() bool {
+	return r.usedStrong.Load()
+}
+
+// SetUsedStrongModel marks the turn as escalated to the strong model. Engines
+// call it before returning the Response from [VoiceEngine.Process].
+func (r *Response) SetUsedStrongModel() {
+	r.usedStrong.Store(true)
+}
+
+// Latency returns the latency breakdown recorded by the engine. Stages that
+// finish after Process returns are filled in by the time the Audio channel
+// closes; read it after draining Audio for final values.
+func (r *Response) Latency() Latency {
+	if p := r.latency.Load(); p != nil {
+		return *p
+	}
+	return Latency{}
+}
+
+// SetLatency records the latency breakdown of the turn. Engines may call it
+// repeatedly as stages complete; the last call wins.
+func (r *Response) SetLatency(l Latency) {
+	r.latency.Store(&l)
+}
+
+// Timings returns the stage timings recorded by the engine. Like
+// [Response.Latency], read it after draining Audio for final values.
+func (r *Response) Timings() Timings {
+	return Timings{
+		STT:        time.Duration(r.timings[StageSTT].Load()),
+		Opener:     time.Duration(r.timings[StageOpener].Load()),
+		Strong:     time.Duration(r.timings[StageStrong].Load()),
+		FirstAudio: time.Duration(r.timings[StageFirstAudio].Load()),
+		Total:      time.Duration(r.timings[StageTotal].Load()),
+	}
+}
+
+// SetTiming records the timing of one stage of the turn; see [Timings] for
+// what each stage measures. It is safe to call from several goroutines, so
+// stages finishing in the background can record themselves. Unknown stages
+// are ignored.
+func (r *Response) SetTiming(stage TimingStage, d time.Duration) {
+	if stage >= 0 && stage < numTimingStages {
+		r.timings[stage].Store(int64(d))
+	}
+}
+
 // VoiceEngine handles the complete speech-in / speech-out pipeline for one NPC.
 //
 // A single VoiceEngine instance is owned by one NPC agent. Multiple agents must
@@ -145,7 +289,8 @@ type VoiceEngine interface {
 	// InjectContext pushes an out-of-band context update into the running session.
 	// The engine merges update into its state and applies it on the next call to
 	// [VoiceEngine.Process]. InjectContext is non-blocking and returns as soon as
-	// the update is queued.
+	// the update is queued. A [ContextUpdate.Priority] update is queued the
+	// same way; restarting the reply in flight is up to the caller.
 	InjectContext(ctx context.Context, update ContextUpdate) error
 
 	// SetTools replaces the full set of tools offered to the LLM. The new list
@@ -173,3 +318,30 @@ type VoiceEngine interface {
 	// call multiple times; subsequent calls return nil.
 	Close() error
 }
+
+// DefaultGreetingInstruction is the instruction used to turn an NPC's first
+// reply of a session into a greeting. Engines implementing [Greeter] append
+// it to the system prompt; callers falling back to [VoiceEngine.Process] may
+// do the same.
+const DefaultGreetingInstruction = "This is the first time the player addresses you this session. Greet them in character with one or two short sentences that fit the current scene, and react naturally to what they said."
+
+// Greeter is an optional [VoiceEngine] capability: generating a short,
+// context-aware greeting the first time a player addresses the NPC in a
+// session (a "cold open"). Callers detect it with a type assertion.
+type Greeter interface {
+	// Greet generates the NPC's greeting from prompt, which carries the NPC
+	// identity, the current scene, and the player's opening utterance. The
+	// returned [Response] is used exactly like one from [VoiceEngine.Process].
+	Greet(ctx context.Context, prompt PromptContext) (*Response, error)
+}
+
+// Interruptible is an optional [VoiceEngine] capability: being told that the
+// player cut off the NPC's most recent reply, so that the NPC can react to it
+// on its next turn instead of carrying on as if it had finished. Callers
+// detect it with a type assertion.
+type Interruptible interface {
+	// Interrupted reports that playback of the most recent reply from
+	// [VoiceEngine.Process] or [Greeter.Greet] was cut short. It must not
+	// block.
+	Interrupted()
+}
@@ -0,0 +1,256 @@
+// Package fallback provides an [engine.VoiceEngine] that degrades from a
+// primary engine to a secondary one when the primary becomes unavailable.
+//
+// Its main use is keeping s2s NPCs talking during a realtime provider outage:
+// the primary is an s2s engine and the secondary is a cascaded
+// STT → LLM → TTS engine built from the configured providers. Every turn the
+// primary fails on is served by the secondary, so players never notice more
+// than a voice change. After a configurable number of consecutive primary
+// failures the [Engine] stops trying the primary for a cool-down period and
+// routes turns straight to the secondary, then probes the primary again.
+//
+// This package is internal because it encapsulates application-private voice
+// pipeline logic and is not intended for import by external code.
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+// Compile-time assertion that Engine satisfies the engine.VoiceEngine interface.
+var _ engine.VoiceEngine = (*Engine)(nil)
+
+const (
+	// DefaultFailureThreshold is the number of consecutive primary failures
+	// after which turns are routed straight to the secondary engine.
+	DefaultFailureThreshold = 1
+
+	// DefaultRetryAfter is how long the engine stays on the secondary before
+	// probing the primary again.
+	DefaultRetryAfter = 30 * time.Second
+
+	// defaultTranscriptBuf is the buffer depth of the merged transcript
+	// channel returned by [Engine.Transcripts].
+	defaultTranscriptBuf = 64
+)
+
+// Option is a functional option for configuring an [Engine].
+type Option func(*Engine)
+
+// WithFailureThreshold sets how many consecutive primary failures switch the
+// engine to the secondary. Values < 1 are ignored.
+func WithFailureThreshold(n int) Option {
+	return func(e *Engine) {
+		if n > 0 {
+			e.threshold = n
+		}
+	}
+}
+
+// WithRetryAfter sets how long the engine routes turns to the secondary
+// before probing the primary again. Non-positive values are ignored.
+func WithRetryAfter(d time.Duration) Option {
+	return func(e *Engine) {
+		if d > 0 {
+			e.retryAfter = d
+		}
+	}
+}
+
+// Engine is a [engine.VoiceEngine] that tries a primary engine first and falls
+// back to a secondary engine when the primary fails.
+//
+// Tools, tool handlers, and context updates are forwarded to both engines so
+// the secondary is ready to take over at any time. Transcripts from both
+// engines are merged into a single channel.
+//
+// Engine is safe for concurrent use.
+type Engine struct {
+	primary    engine.VoiceEngine
+	secondary  engine.VoiceEngine
+	threshold  int
+	retryAfter time.Duration
+
+	mu          sync.Mutex
+	failures    int       // consecutive primary failures
+	degradedAt  time.Time // when the engine switched to the secondary; zero while healthy
+	closed      bool
+	transcripts chan memory.TranscriptEntry
+	done        chan struct{}
+	wg          sync.WaitGroup
+}
+
+// New creates an [Engine] that prefers primary and falls back to secondary.
+// Both engines are owned by the returned Engine and closed by [Engine.Close].
+func New(primary, secondary engine.VoiceEngine, opts ...Option) *Engine {
+	e := &Engine{
+		primary:     primary,
+		secondary:   secondary,
+		threshold:   DefaultFailureThreshold,
+		retryAfter:  DefaultRetryAfter,
+		transcripts: make(chan memory.TranscriptEntry, defaultTranscriptBuf),
+		done:        make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	e.wg.Add(2)
+	go e.forwardTranscripts(primary.Transcripts())
+	go e.forwardTranscripts(secondary.Transcripts())
+	return e
+}
+
+// Degraded reports whether turns are currently routed straight to the
+// secondary engine.
+func (e *Engine) Degraded() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !e.degradedAt.IsZero()
+}
+
+// Process implements [engine.VoiceEngine]. It runs the turn on the primary
+// engine unless the engine is degraded, and re-runs it on the secondary when
+// the primary returns an error.
+func (e *Engine) Process(ctx context.Context, input audio.AudioFrame, prompt engine.PromptContext) (*engine.Response, error) {
+	if !e.tryPrimary() {
+		return e.processSecondary(ctx, input, prompt, nil)
+	}
+
+	resp, err := e.primary.Process(ctx, input, prompt)
+	if err == nil {
+		e.recordSuccess()
+		return resp, nil
+	}
+	if ctx.Err() != nil {
+		// The caller gave up; this says nothing about the primary's health.
+		return nil, fmt.Errorf("fallback: %w", err)
+	}
+	e.recordFailure(err)
+	return e.processSecondary(ctx, input, prompt, err)
+}
+
+// tryPrimary reports whether the next turn should be attempted on the primary.
+func (e *Engine) tryPrimary() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.degradedAt.IsZero() || time.Since(e.degradedAt) >= e.retryAfter
+}
+
+// recordSuccess resets the failure counter and leaves degraded mode.
+func (e *Engine) recordSuccess() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.degradedAt.IsZero() {
+		slog.Info("fallback: primary engine recovered")
+	}
+	e.failures = 0
+	e.degradedAt = time.Time{}
+}
+
+// recordFailure counts a primary failure and enters (or extends) degraded
+// mode once the threshold is reached.
+func (e *Engine) recordFailure(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failures++
+	if e.failures < e.threshold {
+		slog.Warn("fallback: primary engine failed", "err", err, "failures", e.failures)
+		return
+	}
+	if e.degradedAt.IsZero() {
+		slog.Warn("fallback: primary engine unavailable; switching to secondary",
+			"err", err, "failures", e.failures, "retry_after", e.retryAfter)
+	}
+	e.degradedAt = time.Now()
+}
+
+// processSecondary runs the turn on the secondary engine. primaryErr, if
+// non-nil, is included in the returned error when the secondary fails too.
+func (e *Engine) processSecondary(ctx context.Context, input audio.AudioFrame, prompt engine.PromptContext, primaryErr error) (*engine.Response, error) {
+	resp, err := e.secondary.Process(ctx, input, prompt)
+	if err != nil {
+		if primaryErr != nil {
+			return nil, fmt.Errorf("fallback: secondary engine: %w (primary: %w)", err, primaryErr)
+		}
+		return nil, fmt.Errorf("fallback: secondary engine: %w", err)
+	}
+	return resp, nil
+}
+
+// InjectContext implements [engine.VoiceEngine]. The update is forwarded to
+// both engines.
+func (e *Engine) InjectContext(ctx context.Context, update engine.ContextUpdate) error {
+	return errors.Join(
+		e.primary.InjectContext(ctx, update),
+		e.secondary.InjectContext(ctx, update),
+	)
+}
+
+// SetTools implements [engine.VoiceEngine]. The tool list is forwarded to
+// both engines.
+func (e *Engine) SetTools(tools []llm.ToolDefinition) error {
+	return errors.Join(e.primary.SetTools(tools), e.secondary.SetTools(tools))
+}
+
+// OnToolCall implements [engine.VoiceEngine]. The handler is registered on
+// both engines.
+func (e *Engine) OnToolCall(handler func(name string, args string) (string, error)) {
+	e.primary.OnToolCall(handler)
+	e.secondary.OnToolCall(handler)
+}
+
+// Transcripts implements [engine.VoiceEngine]. It returns a channel carrying
+// the merged transcripts of both engines. The channel is closed by
+// [Engine.Close].
+func (e *Engine) Transcripts() <-chan memory.TranscriptEntry {
+	return e.transcripts
+}
+
+// forwardTranscripts copies entries from src into the merged channel until
+// src closes or the engine is closed.
+func (e *Engine) forwardTranscripts(src <-chan memory.TranscriptEntry) {
+	defer e.wg.Done()
+	for {
+		select {
+		case <-e.done:
+			return
+		case entry, ok := <-src:
+			if !ok {
+				return
+			}
+			select {
+			case e.transcripts <- entry:
+			case <-e.done:
+				return
+			}
+		}
+	}
+}
+
+// Close implements [engine.VoiceEngine]. It closes both engines and the
+// merged transcript channel. Subsequent calls are no-ops and return nil.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	close(e.done)
+	e.mu.Unlock()
+
+	err := errors.Join(e.primary.Close(), e.secondary.Close())
+	e.wg.Wait()
+	close(e.transcripts)
+	return err
+}
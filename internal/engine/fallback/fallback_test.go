@@ -0,0 +1,260 @@
+package fallback_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
+	"github.com/MrWong99/glyphoxa/internal/engine/fallback"
+	enginemock "github.com/MrWong99/glyphoxa/internal/engine/mock"
+	s2sengine "github.com/MrWong99/glyphoxa/internal/engine/s2s"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+	providers2s "github.com/MrWong99/glyphoxa/pkg/provider/s2s"
+	s2smock "github.com/MrWong99/glyphoxa/pkg/provider/s2s/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	ttsmock "github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+)
+
+// ─── helpers ─────────────────────────────────────────────────────────────────
+
+var errOutage = errors.New("dial tcp: connection refused")
+
+// prompt is a minimal single-turn prompt.
+var prompt = engine.PromptContext{
+	SystemPrompt: "You are Grimjaw the blacksmith.",
+	Messages:     []llm.Message{{Role: "user", Content: "Can you fix my sword?"}},
+}
+
+// drainAudio reads the audio channel to completion.
+func drainAudio(ch <-chan []byte) {
+	for range ch {
+	}
+}
+
+// closedAudio returns a pre-closed audio channel for mock responses.
+func closedAudio() <-chan []byte {
+	ch := make(chan []byte)
+	close(ch)
+	return ch
+}
+
+// ─── TestProcess_S2SOutageFallsBackToCascade ─────────────────────────────────
+
+func TestProcess_S2SOutageFallsBackToCascade(t *testing.T) {
+	t.Parallel()
+
+	s2sProv := &s2smock.Provider{ConnectErr: errOutage}
+	primary := s2sengine.New(s2sProv, providers2s.SessionConfig{})
+
+	llmProv := &llmmock.Provider{
+		StreamChunks: []llm.Chunk{{Text: "Aye, leave it with me.", FinishReason: "stop"}},
+	}
+	ttsProv := &ttsmock.Provider{SynthesizeChunks: [][]byte{[]byte("pcm")}}
+	secondary := cascade.New(llmProv, llmProv, ttsProv, tts.VoiceProfile{})
+
+	e := fallback.New(primary, secondary)
+	t.Cleanup(func() { _ = e.Close() })
+
+	for turn := range 2 {
+		resp, err := e.Process(context.Background(), audio.AudioFrame{}, prompt)
+		if err != nil {
+			t.Fatalf("turn %d: Process: %v", turn, err)
+		}
+		if resp.Text != "Aye, leave it with me." {
+			t.Errorf("turn %d: Text = %q, want cascade reply", turn, resp.Text)
+		}
+		drainAudio(resp.Audio)
+	}
+
+	if !e.Degraded() {
+		t.Error("Degraded() = false after s2s connect failure, want true")
+	}
+	// The second turn skips the unreachable provider entirely.
+	if got := len(s2sProv.ConnectCalls); got != 1 {
+		t.Errorf("s2s Connect calls = %d, want 1", got)
+	}
+	if got := len(llmProv.StreamCalls); got != 2 {
+		t.Errorf("cascade LLM calls = %d, want 2", got)
+	}
+	if got := llmProv.StreamCalls[0].Req.Messages; len(got) == 0 || got[len(got)-1].Content != "Can you fix my sword?" {
+		t.Errorf("cascade did not receive the player's turn: %+v", got)
+	}
+}
+
+// ─── TestProcess_FailureThreshold ────────────────────────────────────────────
+
+func TestProcess_FailureThreshold(t *testing.T) {
+	t.Parallel()
+
+	primary := &enginemock.VoiceEngine{ProcessError: errOutage}
+	secondary := &enginemock.VoiceEngine{ProcessResult: &engine.Response{Text: "fallback", Audio: closedAudio()}}
+
+	e := fallback.New(primary, secondary, fallback.WithFailureThreshold(2))
+	t.Cleanup(func() { _ = e.Close() })
+
+	for turn := range 3 {
+		resp, err := e.Process(context.Background(), audio.AudioFrame{}, prompt)
+		if err != nil {
+			t.Fatalf("turn %d: Process: %v", turn, err)
+		}
+		if resp.Text != "fallback" {
+			t.Errorf("turn %d: Text = %q, want %q", turn, resp.Text, "fallback")
+		}
+		if want := turn < 1; e.Degraded() == want {
+			t.Errorf("turn %d: Degraded() = %v, want %v", turn, e.Degraded(), !want)
+		}
+	}
+
+	// Two failing attempts reach the threshold; the third turn goes straight
+	// to the secondary.
+	if got := len(primary.ProcessCalls); got != 2 {
+		t.Errorf("primary Process calls = %d, want 2", got)
+	}
+	if got := len(secondary.ProcessCalls); got != 3 {
+		t.Errorf("secondary Process calls = %d, want 3", got)
+	}
+}
+
+// ─── TestProcess_PrimaryRecovers ─────────────────────────────────────────────
+
+func TestProcess_PrimaryRecovers(t *testing.T) {
+	t.Parallel()
+
+	primary := &enginemock.VoiceEngine{ProcessError: errOutage}
+	secondary := &enginemock.VoiceEngine{ProcessResult: &engine.Response{Text: "fallback", Audio: closedAudio()}}
+
+	const retryAfter = 20 * time.Millisecond
+	e := fallback.New(primary, secondary, fallback.WithRetryAfter(retryAfter))
+	t.Cleanup(func() { _ = e.Close() })
+
+	if _, err := e.Process(context.Background(), audio.AudioFrame{}, prompt); err != nil {
+		t.Fatalf("Process during outage: %v", err)
+	}
+	if !e.Degraded() {
+		t.Fatal("expected degraded mode after primary failure")
+	}
+
+	primary.ProcessError = nil
+	primary.ProcessResult = &engine.Response{Text: "primary", Audio: closedAudio()}
+	time.Sleep(2 * retryAfter)
+
+	resp, err := e.Process(context.Background(), audio.AudioFrame{}, prompt)
+	if err != nil {
+		t.Fatalf("Process after recovery: %v", err)
+	}
+	if resp.Text != "primary" {
+		t.Errorf("Text = %q, want primary reply after retry interval", resp.Text)
+	}
+	if e.Degraded() {
+		t.Error("Degraded() = true after successful primary turn, want false")
+	}
+}
+
+// ─── TestProcess_BothFail ────────────────────────────────────────────────────
+
+func TestProcess_BothFail(t *testing.T) {
+	t.Parallel()
+
+	errLLM := errors.New("llm down")
+	primary := &enginemock.VoiceEngine{ProcessError: errOutage}
+	secondary := &enginemock.VoiceEngine{ProcessError: errLLM}
+
+	e := fallback.New(primary, secondary)
+	t.Cleanup(func() { _ = e.Close() })
+
+	_, err := e.Process(context.Background(), audio.AudioFrame{}, prompt)
+	if !errors.Is(err, errLLM) || !errors.Is(err, errOutage) {
+		t.Fatalf("error = %v, want both primary and secondary errors", err)
+	}
+	if !strings.HasPrefix(err.Error(), "fallback: ") {
+		t.Errorf("error %q should be prefixed with %q", err, "fallback: ")
+	}
+}
+
+// ─── TestProcess_CancelledContextKeepsPrimary ────────────────────────────────
+
+func TestProcess_CancelledContextKeepsPrimary(t *testing.T) {
+	t.Parallel()
+
+	primary := &enginemock.VoiceEngine{ProcessError: context.Canceled}
+	secondary := &enginemock.VoiceEngine{}
+
+	e := fallback.New(primary, secondary)
+	t.Cleanup(func() { _ = e.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := e.Process(ctx, audio.AudioFrame{}, prompt); err == nil {
+		t.Fatal("expected error for cancelled context")
+	}
+	if e.Degraded() {
+		t.Error("a cancelled turn must not count as a primary failure")
+	}
+	if got := len(secondary.ProcessCalls); got != 0 {
+		t.Errorf("secondary Process calls = %d, want 0", got)
+	}
+}
+
+// ─── TestEngine_ForwardsToBoth ───────────────────────────────────────────────
+
+func TestEngine_ForwardsToBoth(t *testing.T) {
+	t.Parallel()
+
+	primaryTranscripts := make(chan memory.TranscriptEntry, 1)
+	secondaryTranscripts := make(chan memory.TranscriptEntry, 1)
+	primary := &enginemock.VoiceEngine{TranscriptsResult: primaryTranscripts}
+	secondary := &enginemock.VoiceEngine{TranscriptsResult: secondaryTranscripts}
+
+	e := fallback.New(primary, secondary)
+
+	tools := []llm.ToolDefinition{{Name: "roll_dice"}}
+	if err := e.SetTools(tools); err != nil {
+		t.Fatalf("SetTools: %v", err)
+	}
+	e.OnToolCall(func(string, string) (string, error) { return "{}", nil })
+	if err := e.InjectContext(context.Background(), engine.ContextUpdate{Scene: "forge"}); err != nil {
+		t.Fatalf("InjectContext: %v", err)
+	}
+
+	for name, m := range map[string]*enginemock.VoiceEngine{"primary": primary, "secondary": secondary} {
+		if len(m.SetToolsCalls) != 1 || m.CallCountOnToolCall != 1 || len(m.InjectContextCalls) != 1 {
+			t.Errorf("%s: SetTools=%d OnToolCall=%d InjectContext=%d, want 1 each",
+				name, len(m.SetToolsCalls), m.CallCountOnToolCall, len(m.InjectContextCalls))
+		}
+	}
+
+	primaryTranscripts <- memory.TranscriptEntry{Text: "from s2s"}
+	secondaryTranscripts <- memory.TranscriptEntry{Text: "from cascade"}
+	got := map[string]bool{}
+	for range 2 {
+		select {
+		case entry := <-e.Transcripts():
+			got[entry.Text] = true
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for merged transcripts")
+		}
+	}
+	if !got["from s2s"] || !got["from cascade"] {
+		t.Errorf("merged transcripts = %v, want entries from both engines", got)
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if primary.CallCountClose != 1 || secondary.CallCountClose != 1 {
+		t.Errorf("Close calls = %d/%d, want 1/1", primary.CallCountClose, secondary.CallCountClose)
+	}
+	if _, ok := <-e.Transcripts(); ok {
+		t.Error("Transcripts channel should be closed after Close")
+	}
+}
@@ -0,0 +1,21 @@
+package engine
+
+import (
+	"cmp"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// DefaultTTSSampleRate is the sample rate in Hz assumed for TTS providers
+// that do not report theirs (see [tts.FormatReporter]).
+const DefaultTTSSampleRate = 22050
+
+// TTSFormat returns the format of the PCM p synthesises, which engines and
+// agents tag the audio they speak with p with. A provider that does not
+// report its sample rate is assumed to produce [DefaultTTSSampleRate].
+func TTSFormat(p tts.Provider) audio.Format {
+	format := tts.OutputFormat(p)
+	format.SampleRate = cmp.Or(format.SampleRate, DefaultTTSSampleRate)
+	return format
+}
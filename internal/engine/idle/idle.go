@@ -0,0 +1,420 @@
+// Package idle provides an [engine.VoiceEngine] that closes the engine it
+// wraps after a period of inactivity and recreates it on the next turn.
+//
+// Engines hold resources for as long as they are open: s2s engines keep a
+// realtime connection (and its quota) alive, cascaded engines keep their
+// buffers and goroutines. An NPC the players have not talked to for a while
+// does not need any of that. The [Engine] closes its inner engine once no turn
+// has run for the configured timeout and builds a fresh one from its
+// [Factory] when the NPC is addressed again.
+//
+// A recreated engine is restored before its first turn: the tools and tool
+// handler registered on the [Engine] are applied again, as is the most recent
+// identity and scene from [Engine.InjectContext]. The conversation itself is
+// rehydrated from memory by the caller, whose prompt carries the recent
+// session transcript and history on every turn.
+//
+// This package is internal because it encapsulates application-private voice
+// pipeline logic and is not intended for import by external code.
+package idle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+// Compile-time assertions that Engine satisfies the engine interfaces.
+var (
+	_ engine.VoiceEngine   = (*Engine)(nil)
+	_ engine.Greeter       = (*Engine)(nil)
+	_ engine.Interruptible = (*Engine)(nil)
+)
+
+// defaultTranscriptBuf is the buffer depth of the transcript channel returned
+// by [Engine.Transcripts].
+const defaultTranscriptBuf = 64
+
+// Factory creates the engine an [Engine] wraps. It is called by [New] and
+// again for the first turn after every eviction.
+type Factory func() (engine.VoiceEngine, error)
+
+// Engine is a [engine.VoiceEngine] that evicts its inner engine after a period
+// of inactivity and lazily recreates it. A turn counts as active from the
+// start of [Engine.Process] or [Engine.Greet] until its response audio has
+// finished streaming; the idle timeout starts when the last active turn ends.
+//
+// Transcripts of all inner engines are forwarded to the single channel
+// returned by [Engine.Transcripts], which stays open across evictions.
+//
+// Engine is safe for concurrent use.
+type Engine struct {
+	factory Factory
+	timeout time.Duration
+
+	mu       sync.Mutex
+	inner    engine.VoiceEngine // nil while evicted
+	active   int                // turns whose response is still streaming
+	lastUsed time.Time          // end of the most recent turn
+	timer    *time.Timer
+	closed   bool
+
+	// State replayed onto a recreated inner engine.
+	tools    []llm.ToolDefinition
+	toolsSet bool
+	handler  func(name string, args string) (string, error)
+	restore  engine.ContextUpdate // latest identity and scene
+	gen      uint64               // bumped whenever the state above changes
+
+	transcripts chan memory.TranscriptEntry
+	done        chan struct{}
+	wg          sync.WaitGroup
+}
+
+// New creates an [Engine] that closes the engine built by factory after it
+// has been idle for timeout. The first engine is built immediately, so
+// configuration errors surface here rather than on the first turn. timeout
+// must be positive.
+func New(factory Factory, timeout time.Duration) (*Engine, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("idle: timeout must be positive, got %s", timeout)
+	}
+	inner, err := factory()
+	if err != nil {
+		return nil, fmt.Errorf("idle: create engine: %w", err)
+	}
+	e := &Engine{
+		factory:     factory,
+		timeout:     timeout,
+		inner:       inner,
+		lastUsed:    time.Now(),
+		transcripts: make(chan memory.TranscriptEntry, defaultTranscriptBuf),
+		done:        make(chan struct{}),
+	}
+	e.forward(inner)
+	e.timer = time.AfterFunc(timeout, e.evict)
+	return e, nil
+}
+
+// Loaded reports whether an inner engine is currently open, i.e. whether the
+// engine has not been evicted since its last turn.
+func (e *Engine) Loaded() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.inner != nil
+}
+
+// Process implements [engine.VoiceEngine]. It recreates the inner engine if it
+// was evicted and runs the turn on it.
+func (e *Engine) Process(ctx context.Context, input audio.AudioFrame, prompt engine.PromptContext) (*engine.Response, error) {
+	inner, err := e.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := inner.Process(ctx, input, prompt)
+	return e.track(resp, err)
+}
+
+// Greet implements [engine.Greeter]. Inner engines without greeting support
+// answer a regular [engine.VoiceEngine.Process] call with
+// [engine.DefaultGreetingInstruction] appended to the system prompt.
+func (e *Engine) Greet(ctx context.Context, prompt engine.PromptContext) (*engine.Response, error) {
+	inner, err := e.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if g, ok := inner.(engine.Greeter); ok {
+		return e.track(g.Greet(ctx, prompt))
+	}
+	prompt.SystemPrompt += "\n\n" + engine.DefaultGreetingInstruction
+	return e.track(inner.Process(ctx, audio.AudioFrame{SampleRate: 16000, Channels: 1}, prompt))
+}
+
+// Interrupted implements [engine.Interruptible]. It is passed on to the inner
+// engine if that is open and supports it; an evicted engine has no reply to
+// resume.
+func (e *Engine) Interrupted() {
+	e.mu.Lock()
+	inner := e.inner
+	e.mu.Unlock()
+	if ir, ok := inner.(engine.Interruptible); ok {
+		ir.Interrupted()
+	}
+}
+
+// acquire returns the inner engine, recreating it if it was evicted, and
+// marks a turn as active. Every successful call must be paired with release.
+//
+// The engine is built and restored without holding e.mu, since both may do
+// network I/O. If another turn installs an engine in the meantime, that one
+// is used and the new one is closed.
+func (e *Engine) acquire(ctx context.Context) (engine.VoiceEngine, error) {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil, errClosed
+	}
+	if e.inner != nil {
+		e.active++
+		inner := e.inner
+		e.mu.Unlock()
+		return inner, nil
+	}
+	state := e.restoreStateLocked()
+	e.mu.Unlock()
+
+	inner, err := e.factory()
+	if err != nil {
+		return nil, fmt.Errorf("idle: recreate engine: %w", err)
+	}
+	if err := state.apply(ctx, inner); err != nil {
+		_ = inner.Close()
+		return nil, fmt.Errorf("idle: restore engine: %w", err)
+	}
+
+	e.mu.Lock()
+	if e.closed || e.inner != nil {
+		current, closed := e.inner, e.closed
+		if !closed {
+			e.active++
+		}
+		e.mu.Unlock()
+		_ = inner.Close()
+		if closed {
+			return nil, errClosed
+		}
+		return current, nil
+	}
+	e.inner = inner
+	e.active++
+	e.forward(inner)
+	// Tools, handler or context registered while the engine was being built
+	// only reached the saved state; apply them again.
+	stale := e.gen != state.gen
+	if stale {
+		state = e.restoreStateLocked()
+	}
+	e.mu.Unlock()
+
+	slog.Info("idle: engine recreated")
+	if stale {
+		if err := state.apply(ctx, inner); err != nil {
+			slog.Warn("idle: restore engine", "err", err)
+		}
+	}
+	return inner, nil
+}
+
+// errClosed is returned for turns on a closed [Engine].
+var errClosed = errors.New("idle: engine is closed")
+
+// restoreState is the state replayed onto a recreated inner engine.
+type restoreState struct {
+	gen      uint64 // e.gen when the state was taken
+	tools    []llm.ToolDefinition
+	toolsSet bool
+	handler  func(name string, args string) (string, error)
+	update   engine.ContextUpdate
+}
+
+// restoreStateLocked returns the current state to replay onto a recreated
+// inner engine. e.mu must be held.
+func (e *Engine) restoreStateLocked() restoreState {
+	return restoreState{
+		gen:      e.gen,
+		tools:    e.tools,
+		toolsSet: e.toolsSet,
+		handler:  e.handler,
+		update:   e.restore,
+	}
+}
+
+// apply applies the registered tools, tool handler, and the latest identity
+// and scene to inner.
+func (s restoreState) apply(ctx context.Context, inner engine.VoiceEngine) error {
+	if s.toolsSet {
+		if err := inner.SetTools(s.tools); err != nil {
+			return err
+		}
+	}
+	if s.handler != nil {
+		inner.OnToolCall(s.handler)
+	}
+	if s.update.Identity != "" || s.update.Scene != "" {
+		return inner.InjectContext(ctx, s.update)
+	}
+	return nil
+}
+
+// track keeps the turn active until the audio of resp has finished
+// streaming, then releases it. On error the turn is released immediately.
+func (e *Engine) track(resp *engine.Response, err error) (*engine.Response, error) {
+	if err != nil {
+		e.release()
+		return nil, err
+	}
+	if resp.Audio == nil {
+		e.release()
+		return resp, nil
+	}
+	out := make(chan []byte)
+	go func(in <-chan []byte) {
+		defer e.release()
+		defer close(out)
+		for chunk := range in {
+			out <- chunk
+		}
+	}(resp.Audio)
+	resp.Audio = out
+	return resp, nil
+}
+
+// release ends an active turn and restarts the idle timeout once no turn is
+// active any more.
+func (e *Engine) release() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.active--
+	e.lastUsed = time.Now()
+	if e.active == 0 && !e.closed {
+		e.timer.Reset(e.timeout)
+	}
+}
+
+// evict closes the inner engine if no turn has run for the idle timeout. It
+// runs on the idle timer.
+func (e *Engine) evict() {
+	e.mu.Lock()
+	if e.closed || e.inner == nil || e.active > 0 {
+		e.mu.Unlock()
+		return
+	}
+	if idle := time.Since(e.lastUsed); idle < e.timeout {
+		e.timer.Reset(e.timeout - idle)
+		e.mu.Unlock()
+		return
+	}
+	inner := e.inner
+	e.inner = nil
+	e.mu.Unlock()
+
+	slog.Info("idle: closing idle engine", "idle_timeout", e.timeout)
+	if err := inner.Close(); err != nil {
+		slog.Warn("idle: close idle engine", "err", err)
+	}
+}
+
+// InjectContext implements [engine.VoiceEngine]. The update is forwarded to
+// the inner engine if one is open. Its identity and scene are remembered and
+// applied to engines recreated after an eviction; recent utterances are not,
+// since they are part of the prompt of every turn.
+func (e *Engine) InjectContext(ctx context.Context, update engine.ContextUpdate) error {
+	e.mu.Lock()
+	if update.Identity != "" {
+		e.restore.Identity = update.Identity
+	}
+	if update.Scene != "" {
+		e.restore.Scene = update.Scene
+	}
+	e.gen++
+	inner := e.inner
+	e.mu.Unlock()
+
+	if inner == nil {
+		return nil
+	}
+	return inner.InjectContext(ctx, update)
+}
+
+// SetTools implements [engine.VoiceEngine]. The tool list is forwarded to the
+// inner engine if one is open and applied to recreated engines.
+func (e *Engine) SetTools(tools []llm.ToolDefinition) error {
+	e.mu.Lock()
+	e.tools = append([]llm.ToolDefinition(nil), tools...)
+	e.toolsSet = true
+	e.gen++
+	inner := e.inner
+	e.mu.Unlock()
+
+	if inner == nil {
+		return nil
+	}
+	return inner.SetTools(tools)
+}
+
+// OnToolCall implements [engine.VoiceEngine]. The handler is registered on the
+// inner engine if one is open and on recreated engines.
+func (e *Engine) OnToolCall(handler func(name string, args string) (string, error)) {
+	e.mu.Lock()
+	e.handler = handler
+	e.gen++
+	inner := e.inner
+	e.mu.Unlock()
+
+	if inner != nil {
+		inner.OnToolCall(handler)
+	}
+}
+
+// Transcripts implements [engine.VoiceEngine]. It returns a channel carrying
+// the transcripts of every inner engine. The channel is closed by
+// [Engine.Close].
+func (e *Engine) Transcripts() <-chan memory.TranscriptEntry {
+	return e.transcripts
+}
+
+// forward starts copying the transcripts of inner into e.transcripts until
+// inner's channel closes or the engine is closed.
+func (e *Engine) forward(inner engine.VoiceEngine) {
+	src := inner.Transcripts()
+	e.wg.Go(func() {
+		for {
+			select {
+			case <-e.done:
+				return
+			case entry, ok := <-src:
+				if !ok {
+					return
+				}
+				select {
+				case e.transcripts <- entry:
+				case <-e.done:
+					return
+				}
+			}
+		}
+	})
+}
+
+// Close implements [engine.VoiceEngine]. It stops the idle timer, closes the
+// inner engine if one is open, and closes the transcript channel. Subsequent
+// calls are no-ops and return nil.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	close(e.done)
+	e.timer.Stop()
+	inner := e.inner
+	e.inner = nil
+	e.mu.Unlock()
+
+	var err error
+	if inner != nil {
+		err = inner.Close()
+	}
+	e.wg.Wait()
+	close(e.transcripts)
+	return err
+}
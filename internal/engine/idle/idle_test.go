@@ -0,0 +1,450 @@
+package idle_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/engine/idle"
+	enginemock "github.com/MrWong99/glyphoxa/internal/engine/mock"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+// ─── helpers ─────────────────────────────────────────────────────────────────
+
+const timeout = 20 * time.Millisecond
+
+var prompt = engine.PromptContext{
+	SystemPrompt: "You are Grimjaw the blacksmith.",
+	Messages:     []llm.Message{{Role: "user", Content: "Can you fix my sword?"}},
+}
+
+// closableEngine is a mock engine that signals when it is closed, so tests
+// can wait for an eviction without racing on the mock's counters.
+type closableEngine struct {
+	*enginemock.VoiceEngine
+	closed chan struct{}
+}
+
+func (c *closableEngine) Close() error {
+	err := c.VoiceEngine.Close()
+	close(c.closed)
+	return err
+}
+
+// factory hands out a fresh closableEngine per call and records them.
+type factory struct {
+	mu      sync.Mutex
+	engines []*closableEngine
+	err     error
+	audio   func() <-chan []byte
+}
+
+func (f *factory) New() (engine.VoiceEngine, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	audioCh := closedAudio()
+	if f.audio != nil {
+		audioCh = f.audio()
+	}
+	e := &closableEngine{
+		VoiceEngine: &enginemock.VoiceEngine{
+			ProcessResult: &engine.Response{Text: "Aye.", Audio: audioCh},
+		},
+		closed: make(chan struct{}),
+	}
+	f.engines = append(f.engines, e)
+	return e, nil
+}
+
+func (f *factory) engine(i int) *closableEngine {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.engines[i]
+}
+
+func (f *factory) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.engines)
+}
+
+// closedAudio returns a pre-closed audio channel for mock responses.
+func closedAudio() <-chan []byte {
+	ch := make(chan []byte)
+	close(ch)
+	return ch
+}
+
+// waitClosed fails the test if e is not closed within a second.
+func waitClosed(t *testing.T, e *closableEngine) {
+	t.Helper()
+	select {
+	case <-e.closed:
+	case <-time.After(time.Second):
+		t.Fatal("idle engine was not closed")
+	}
+}
+
+func newEngine(t *testing.T, f *factory) *idle.Engine {
+	t.Helper()
+	e, err := idle.New(f.New, timeout)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = e.Close() })
+	return e
+}
+
+// ─── TestNew ─────────────────────────────────────────────────────────────────
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		factory idle.Factory
+		timeout time.Duration
+	}{
+		{
+			name:    "non-positive timeout",
+			factory: (&factory{}).New,
+			timeout: 0,
+		},
+		{
+			name:    "factory error",
+			factory: (&factory{err: errors.New("no api key")}).New,
+			timeout: time.Minute,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := idle.New(tc.factory, tc.timeout); err == nil {
+				t.Error("New: want error, got nil")
+			}
+		})
+	}
+}
+
+// ─── TestProcess_EvictsAndRecreates ──────────────────────────────────────────
+
+func TestProcess_EvictsAndRecreates(t *testing.T) {
+	t.Parallel()
+
+	f := &factory{}
+	e := newEngine(t, f)
+	ctx := context.Background()
+
+	tools := []llm.ToolDefinition{{Name: "roll_dice"}}
+	if err := e.SetTools(tools); err != nil {
+		t.Fatalf("SetTools: %v", err)
+	}
+	e.OnToolCall(func(string, string) (string, error) { return "17", nil })
+	update := engine.ContextUpdate{
+		Identity:         "Grimjaw, a gruff dwarf blacksmith.",
+		Scene:            "The forge at dusk.",
+		RecentUtterances: []memory.TranscriptEntry{{SpeakerName: "Thorin", Text: "Hello!"}},
+	}
+	if err := e.InjectContext(ctx, update); err != nil {
+		t.Fatalf("InjectContext: %v", err)
+	}
+
+	first := f.engine(0)
+	waitClosed(t, first)
+	if e.Loaded() {
+		t.Error("Loaded() = true after idle timeout, want false")
+	}
+	if first.CallCountClose != 1 {
+		t.Errorf("first engine closed %d times, want 1", first.CallCountClose)
+	}
+
+	resp, err := e.Process(ctx, audio.AudioFrame{}, prompt)
+	if err != nil {
+		t.Fatalf("Process after eviction: %v", err)
+	}
+	for range resp.Audio {
+	}
+	if resp.Text != "Aye." {
+		t.Errorf("Text = %q, want %q", resp.Text, "Aye.")
+	}
+	if got := f.count(); got != 2 {
+		t.Fatalf("factory called %d times, want 2", got)
+	}
+
+	second := f.engine(1)
+	if len(second.ProcessCalls) != 1 || second.ProcessCalls[0].Prompt.SystemPrompt != prompt.SystemPrompt {
+		t.Errorf("recreated engine ProcessCalls = %+v, want the turn's prompt", second.ProcessCalls)
+	}
+	if len(second.SetToolsCalls) != 1 || len(second.SetToolsCalls[0].Tools) != 1 {
+		t.Errorf("recreated engine SetToolsCalls = %+v, want the registered tools", second.SetToolsCalls)
+	}
+	if got, err := second.InvokeToolCall("roll_dice", "{}"); err != nil || got != "17" {
+		t.Errorf("recreated engine tool handler = (%q, %v), want the registered handler", got, err)
+	}
+	if len(second.InjectContextCalls) != 1 {
+		t.Fatalf("recreated engine InjectContextCalls = %d, want 1", len(second.InjectContextCalls))
+	}
+	restored := second.InjectContextCalls[0].Update
+	if restored.Identity != update.Identity || restored.Scene != update.Scene {
+		t.Errorf("restored context = %+v, want identity and scene of %+v", restored, update)
+	}
+	if len(restored.RecentUtterances) != 0 {
+		t.Errorf("restored context replays %d utterances, want 0", len(restored.RecentUtterances))
+	}
+}
+
+// ─── TestProcess_RecreatesWithoutLock ────────────────────────────────────────
+
+// blockingFactory wraps a factory so that, once armed, every call signals on
+// entered and waits for release before building the engine.
+type blockingFactory struct {
+	*factory
+	armed   atomic.Bool
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingFactory) New() (engine.VoiceEngine, error) {
+	if b.armed.Load() {
+		b.entered <- struct{}{}
+		<-b.release
+	}
+	return b.factory.New()
+}
+
+func newBlockingEngine(t *testing.T) (*idle.Engine, *blockingFactory) {
+	t.Helper()
+	b := &blockingFactory{factory: &factory{}, entered: make(chan struct{}, 2), release: make(chan struct{})}
+	e, err := idle.New(b.New, timeout)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = e.Close() })
+	waitClosed(t, b.engine(0))
+	b.armed.Store(true)
+	return e, b
+}
+
+func TestProcess_RecreatesWithoutLock(t *testing.T) {
+	t.Parallel()
+
+	e, b := newBlockingEngine(t)
+	errc := make(chan error, 1)
+	go func() {
+		resp, err := e.Process(context.Background(), audio.AudioFrame{}, prompt)
+		if err == nil {
+			audio.Drain(resp.Audio)
+		}
+		errc <- err
+	}()
+	<-b.entered
+
+	// The engine is still being built, but the engine stays usable.
+	done := make(chan struct{})
+	update := engine.ContextUpdate{Scene: "The forge has gone cold."}
+	go func() {
+		defer close(done)
+		_ = e.Loaded()
+		if err := e.InjectContext(context.Background(), update); err != nil {
+			t.Errorf("InjectContext: %v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("InjectContext blocked while the engine was being built")
+	}
+
+	close(b.release)
+	if err := <-errc; err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	// The update made while building reaches the new engine.
+	second := b.engine(1)
+	if n := len(second.InjectContextCalls); n == 0 || second.InjectContextCalls[n-1].Update.Scene != update.Scene {
+		t.Errorf("recreated engine InjectContextCalls = %+v, want the update made while building", second.InjectContextCalls)
+	}
+}
+
+func TestProcess_ConcurrentRecreate(t *testing.T) {
+	t.Parallel()
+
+	e, b := newBlockingEngine(t)
+	// Both turns may run on the same mock engine and get the same response,
+	// so it must carry no audio for the idle engine to wrap.
+	b.mu.Lock()
+	b.audio = func() <-chan []byte { return nil }
+	b.mu.Unlock()
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			if _, err := e.Process(context.Background(), audio.AudioFrame{}, prompt); err != nil {
+				t.Errorf("Process: %v", err)
+			}
+		})
+	}
+	<-b.entered
+	<-b.entered
+	close(b.release)
+	wg.Wait()
+
+	// Both turns built an engine; one was installed and the other closed.
+	if got := b.count(); got != 3 {
+		t.Fatalf("factory called %d times, want 3", got)
+	}
+	first, second := b.engine(1), b.engine(2)
+	processed := len(first.ProcessCalls) + len(second.ProcessCalls)
+	if processed != 2 {
+		t.Errorf("turns processed = %d, want 2", processed)
+	}
+	if first.CallCountClose+second.CallCountClose != 1 {
+		t.Errorf("surplus engines closed = %d, want 1", first.CallCountClose+second.CallCountClose)
+	}
+	if first.CallCountClose == 1 && len(first.ProcessCalls) > 0 || second.CallCountClose == 1 && len(second.ProcessCalls) > 0 {
+		t.Error("a turn ran on the engine that was closed")
+	}
+}
+
+// ─── TestProcess_NoEvictionWhileStreaming ────────────────────────────────────
+
+func TestProcess_NoEvictionWhileStreaming(t *testing.T) {
+	t.Parallel()
+
+	audioCh := make(chan []byte)
+	f := &factory{audio: func() <-chan []byte { return audioCh }}
+	e := newEngine(t, f)
+
+	resp, err := e.Process(context.Background(), audio.AudioFrame{}, prompt)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	time.Sleep(5 * timeout)
+	if !e.Loaded() {
+		t.Fatal("engine evicted while its response was still streaming")
+	}
+
+	go func() {
+		audioCh <- []byte("pcm")
+		close(audioCh)
+	}()
+	for range resp.Audio {
+	}
+	waitClosed(t, f.engine(0))
+}
+
+// ─── TestTranscripts_SurviveEviction ─────────────────────────────────────────
+
+func TestTranscripts_SurviveEviction(t *testing.T) {
+	t.Parallel()
+
+	f := &factory{}
+	var sources []chan memory.TranscriptEntry
+	e, err := idle.New(func() (engine.VoiceEngine, error) {
+		inner, err := f.New()
+		if err != nil {
+			return nil, err
+		}
+		ch := make(chan memory.TranscriptEntry, 1)
+		sources = append(sources, ch)
+		inner.(*closableEngine).TranscriptsResult = ch
+		return inner, nil
+	}, timeout)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := e.Transcripts()
+
+	recv := func(want string) {
+		t.Helper()
+		select {
+		case got := <-out:
+			if got.Text != want {
+				t.Errorf("transcript = %q, want %q", got.Text, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for transcript %q", want)
+		}
+	}
+
+	sources[0] <- memory.TranscriptEntry{Text: "before"}
+	recv("before")
+
+	waitClosed(t, f.engine(0))
+	resp, err := e.Process(context.Background(), audio.AudioFrame{}, prompt)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	for range resp.Audio {
+	}
+
+	sources[1] <- memory.TranscriptEntry{Text: "after"}
+	recv("after")
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, ok := <-out; ok {
+		t.Error("transcript channel still open after Close")
+	}
+}
+
+// ─── TestProcess_RecreateError ───────────────────────────────────────────────
+
+func TestProcess_RecreateError(t *testing.T) {
+	t.Parallel()
+
+	f := &factory{}
+	e := newEngine(t, f)
+	waitClosed(t, f.engine(0))
+
+	errQuota := errors.New("quota exceeded")
+	f.mu.Lock()
+	f.err = errQuota
+	f.mu.Unlock()
+	if _, err := e.Process(context.Background(), audio.AudioFrame{}, prompt); !errors.Is(err, errQuota) {
+		t.Fatalf("Process error = %v, want %v", err, errQuota)
+	}
+
+	// The next turn tries again.
+	f.mu.Lock()
+	f.err = nil
+	f.mu.Unlock()
+	resp, err := e.Process(context.Background(), audio.AudioFrame{}, prompt)
+	if err != nil {
+		t.Fatalf("Process after recovery: %v", err)
+	}
+	for range resp.Audio {
+	}
+	if got := f.count(); got != 2 {
+		t.Errorf("factory created %d engines, want 2", got)
+	}
+}
+
+// ─── TestInterrupted_Forwarded ───────────────────────────────────────────────
+
+func TestInterrupted_Forwarded(t *testing.T) {
+	t.Parallel()
+
+	inner := &enginemock.InterruptibleEngine{}
+	e, err := idle.New(func() (engine.VoiceEngine, error) { return inner, nil }, timeout)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = e.Close() })
+
+	e.Interrupted()
+	if got := inner.CallCountInterrupted.Load(); got != 1 {
+		t.Errorf("inner Interrupted calls = %d, want 1", got)
+	}
+}
@@ -0,0 +1,191 @@
+// Package limit caps the number of NPC voice engines that are open at the
+// same time and keeps count of them.
+//
+// A server has finite capacity: every engine holds provider connections,
+// buffers, and goroutines, and s2s engines each keep a realtime connection
+// whose quota is usually small. A [Limiter] hands out one slot per engine it
+// builds and takes it back when the engine is closed. Building an engine while
+// all slots are taken fails with [ErrLimitReached] rather than waiting, so the
+// caller can report the problem while the players are still at the table.
+//
+// Engines evicted by an idle wrapper give their slot back and need a free one
+// again when they are recreated.
+//
+// This package is internal because it encapsulates application-private voice
+// pipeline logic and is not intended for import by external code.
+package limit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+)
+
+// ErrLimitReached is returned by [Limiter.Build] when the maximum number of
+// concurrently open engines is reached.
+var ErrLimitReached = errors.New("limit: maximum number of concurrent NPC engines reached")
+
+// Stats is a snapshot of the engines counted by a [Limiter].
+type Stats struct {
+	// Active is the number of open engines.
+	Active int `json:"active"`
+
+	// Max is the configured maximum. Zero means unlimited.
+	Max int `json:"max"`
+
+	// ByKind breaks Active down by the kind passed to [Limiter.Build].
+	ByKind map[string]int `json:"by_kind,omitempty"`
+}
+
+// Option is a functional option for [New].
+type Option func(*Limiter)
+
+// WithObserver registers fn to be called whenever an engine of the given kind
+// is opened (delta 1) or closed (delta -1). Use it to feed an up-down counter
+// metric. fn is called without the limiter's lock held, but must not block.
+func WithObserver(fn func(kind string, delta int)) Option {
+	return func(l *Limiter) { l.observe = fn }
+}
+
+// Limiter counts open engines and refuses to build more than its maximum.
+//
+// Limiter is safe for concurrent use.
+type Limiter struct {
+	max     int
+	observe func(kind string, delta int)
+
+	mu     sync.Mutex
+	active int
+	byKind map[string]int
+}
+
+// New creates a [Limiter] that allows at most n engines to be open at once.
+// An n of zero or less only counts engines without limiting them.
+func New(n int, opts ...Option) *Limiter {
+	l := &Limiter{
+		max:    max(n, 0),
+		byKind: make(map[string]int),
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// Build takes a slot for an engine of the given kind (e.g. "cascaded" or
+// "s2s") and calls build to create it. It returns [ErrLimitReached] without
+// calling build when no slot is free. The slot is given back when build fails
+// or when the returned engine is closed.
+//
+// The returned engine implements [engine.Greeter] if and only if the built
+// engine does. It always implements [engine.Interruptible], which is passed
+// on to the built engine where supported.
+func (l *Limiter) Build(kind string, build func() (engine.VoiceEngine, error)) (engine.VoiceEngine, error) {
+	if err := l.acquire(kind); err != nil {
+		return nil, err
+	}
+	eng, err := build()
+	if err != nil {
+		l.release(kind)
+		return nil, err
+	}
+	t := &tracked{VoiceEngine: eng, release: func() { l.release(kind) }}
+	if g, ok := eng.(engine.Greeter); ok {
+		return &trackedGreeter{tracked: t, greeter: g}, nil
+	}
+	return t, nil
+}
+
+// Stats returns a snapshot of the open engines.
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := Stats{Active: l.active, Max: l.max}
+	for kind, n := range l.byKind {
+		if n > 0 {
+			if s.ByKind == nil {
+				s.ByKind = make(map[string]int)
+			}
+			s.ByKind[kind] = n
+		}
+	}
+	return s
+}
+
+// Check reports an error when all slots are taken. It matches the signature
+// of a readiness check, so a full server can stop accepting new sessions.
+func (l *Limiter) Check(context.Context) error {
+	s := l.Stats()
+	if s.Max > 0 && s.Active >= s.Max {
+		return fmt.Errorf("%w (%d of %d in use)", ErrLimitReached, s.Active, s.Max)
+	}
+	return nil
+}
+
+// acquire takes a slot for an engine of kind.
+func (l *Limiter) acquire(kind string) error {
+	l.mu.Lock()
+	if l.max > 0 && l.active >= l.max {
+		err := fmt.Errorf("%w (%d of %d in use)", ErrLimitReached, l.active, l.max)
+		l.mu.Unlock()
+		return err
+	}
+	l.active++
+	l.byKind[kind]++
+	l.mu.Unlock()
+
+	if l.observe != nil {
+		l.observe(kind, 1)
+	}
+	return nil
+}
+
+// release gives back a slot taken by acquire.
+func (l *Limiter) release(kind string) {
+	l.mu.Lock()
+	l.active--
+	l.byKind[kind]--
+	l.mu.Unlock()
+
+	if l.observe != nil {
+		l.observe(kind, -1)
+	}
+}
+
+// tracked is an engine built by [Limiter.Build] that gives its slot back when
+// it is closed.
+type tracked struct {
+	engine.VoiceEngine
+	release func()
+	once    sync.Once
+}
+
+// Close closes the engine and gives its slot back. Only the first call
+// releases the slot.
+func (t *tracked) Close() error {
+	err := t.VoiceEngine.Close()
+	t.once.Do(t.release)
+	return err
+}
+
+// Interrupted implements [engine.Interruptible]. It is passed on to the built
+// engine if that supports it.
+func (t *tracked) Interrupted() {
+	if ir, ok := t.VoiceEngine.(engine.Interruptible); ok {
+		ir.Interrupted()
+	}
+}
+
+// trackedGreeter is a [tracked] engine whose inner engine supports greetings.
+type trackedGreeter struct {
+	*tracked
+	greeter engine.Greeter
+}
+
+// Greet implements [engine.Greeter].
+func (t *trackedGreeter) Greet(ctx context.Context, prompt engine.PromptContext) (*engine.Response, error) {
+	return t.greeter.Greet(ctx, prompt)
+}
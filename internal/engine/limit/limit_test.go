@@ -0,0 +1,177 @@
+package limit_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/engine/limit"
+	enginemock "github.com/MrWong99/glyphoxa/internal/engine/mock"
+)
+
+// greeterEngine is a mock engine that supports greetings.
+type greeterEngine struct {
+	*enginemock.VoiceEngine
+}
+
+func (greeterEngine) Greet(context.Context, engine.PromptContext) (*engine.Response, error) {
+	return &engine.Response{Text: "Welcome!"}, nil
+}
+
+func newMock() (engine.VoiceEngine, error) {
+	return &enginemock.VoiceEngine{}, nil
+}
+
+func TestBuild_EnforcesLimit(t *testing.T) {
+	t.Parallel()
+
+	l := limit.New(2)
+	first, err := l.Build("cascaded", newMock)
+	if err != nil {
+		t.Fatalf("Build 1: %v", err)
+	}
+	if _, err := l.Build("s2s", newMock); err != nil {
+		t.Fatalf("Build 2: %v", err)
+	}
+
+	called := false
+	_, err = l.Build("s2s", func() (engine.VoiceEngine, error) {
+		called = true
+		return newMock()
+	})
+	if !errors.Is(err, limit.ErrLimitReached) {
+		t.Fatalf("Build 3 error = %v, want %v", err, limit.ErrLimitReached)
+	}
+	if called {
+		t.Error("build func called although the limit was reached")
+	}
+	if err := l.Check(context.Background()); !errors.Is(err, limit.ErrLimitReached) {
+		t.Errorf("Check at capacity = %v, want %v", err, limit.ErrLimitReached)
+	}
+
+	// Closing an engine frees its slot, once.
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	_ = first.Close()
+	if got := l.Stats(); got.Active != 1 || got.ByKind["s2s"] != 1 || got.ByKind["cascaded"] != 0 {
+		t.Errorf("Stats after close = %+v, want 1 open s2s engine", got)
+	}
+	if err := l.Check(context.Background()); err != nil {
+		t.Errorf("Check with a free slot = %v, want nil", err)
+	}
+	if _, err := l.Build("cascaded", newMock); err != nil {
+		t.Errorf("Build after close: %v", err)
+	}
+	if _, err := l.Build("cascaded", newMock); !errors.Is(err, limit.ErrLimitReached) {
+		t.Errorf("Build over limit again = %v, want %v", err, limit.ErrLimitReached)
+	}
+}
+
+func TestBuild_Unlimited(t *testing.T) {
+	t.Parallel()
+
+	l := limit.New(0)
+	var engines []engine.VoiceEngine
+	for range 10 {
+		eng, err := l.Build("cascaded", newMock)
+		if err != nil {
+			t.Fatalf("Build: %v", err)
+		}
+		engines = append(engines, eng)
+	}
+	if got := l.Stats(); got.Active != 10 || got.Max != 0 {
+		t.Errorf("Stats = %+v, want 10 open engines without a maximum", got)
+	}
+	for _, eng := range engines {
+		_ = eng.Close()
+	}
+	if got := l.Stats(); got.Active != 0 || got.ByKind != nil {
+		t.Errorf("Stats after closing all = %+v, want none open", got)
+	}
+}
+
+func TestBuild_ErrorReleasesSlot(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	deltas := map[string]int{}
+	l := limit.New(1, limit.WithObserver(func(kind string, delta int) {
+		mu.Lock()
+		deltas[kind] += delta
+		mu.Unlock()
+	}))
+
+	errNoKey := errors.New("no api key")
+	if _, err := l.Build("s2s", func() (engine.VoiceEngine, error) { return nil, errNoKey }); !errors.Is(err, errNoKey) {
+		t.Fatalf("Build error = %v, want %v", err, errNoKey)
+	}
+	if got := l.Stats().Active; got != 0 {
+		t.Errorf("Active after failed build = %d, want 0", got)
+	}
+	eng, err := l.Build("s2s", newMock)
+	if err != nil {
+		t.Fatalf("Build after failure: %v", err)
+	}
+
+	mu.Lock()
+	if deltas["s2s"] != 1 {
+		t.Errorf("observed s2s engines = %d, want 1", deltas["s2s"])
+	}
+	mu.Unlock()
+
+	_ = eng.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if deltas["s2s"] != 0 {
+		t.Errorf("observed s2s engines after close = %d, want 0", deltas["s2s"])
+	}
+}
+
+func TestBuild_PreservesGreeter(t *testing.T) {
+	t.Parallel()
+
+	l := limit.New(0)
+	plain, err := l.Build("s2s", newMock)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if _, ok := plain.(engine.Greeter); ok {
+		t.Error("engine without greeting support is a Greeter after Build")
+	}
+
+	greeting, err := l.Build("cascaded", func() (engine.VoiceEngine, error) {
+		return greeterEngine{&enginemock.VoiceEngine{}}, nil
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	g, ok := greeting.(engine.Greeter)
+	if !ok {
+		t.Fatal("engine with greeting support is not a Greeter after Build")
+	}
+	resp, err := g.Greet(context.Background(), engine.PromptContext{})
+	if err != nil || resp.Text != "Welcome!" {
+		t.Errorf("Greet = (%+v, %v), want the inner engine's greeting", resp, err)
+	}
+}
+
+func TestBuild_ForwardsInterrupted(t *testing.T) {
+	t.Parallel()
+
+	inner := &enginemock.InterruptibleEngine{}
+	eng, err := limit.New(0).Build("cascaded", func() (engine.VoiceEngine, error) { return inner, nil })
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	ir, ok := eng.(engine.Interruptible)
+	if !ok {
+		t.Fatal("built engine is not Interruptible")
+	}
+	ir.Interrupted()
+	if got := inner.CallCountInterrupted.Load(); got != 1 {
+		t.Errorf("inner Interrupted calls = %d, want 1", got)
+	}
+}
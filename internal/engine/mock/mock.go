@@ -1,5 +1,5 @@
-// Package mock provides an in-memory mock implementation of [engine.VoiceEngine]
-// for use in unit tests.
+// Package mock provides in-memory mock implementations of [engine.VoiceEngine]
+// and [engine.SafetyFilter] for use in unit tests.
 //
 // The mock records every method call and allows the test to configure return values
 // via exported fields. It is safe for concurrent use.
@@ -20,6 +20,7 @@ package mock
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/MrWong99/glyphoxa/internal/engine"
 	"github.com/MrWong99/glyphoxa/pkg/audio"
@@ -165,3 +166,85 @@ func (v *VoiceEngine) InvokeToolCall(name, args string) (string, error) {
 	}
 	return result, err
 }
+
+// Compile-time interface assertion.
+var _ engine.Greeter = (*GreeterEngine)(nil)
+
+// GreetCall records the arguments of a single [GreeterEngine.Greet] call.
+type GreetCall struct {
+	// Prompt is the prompt context passed to Greet.
+	Prompt engine.PromptContext
+}
+
+// GreeterEngine is a [VoiceEngine] mock that also implements [engine.Greeter].
+type GreeterEngine struct {
+	VoiceEngine
+
+	gmu sync.Mutex
+
+	// GreetResult is returned by [GreeterEngine.Greet] (may be nil).
+	GreetResult *engine.Response
+
+	// GreetError is the error returned by [GreeterEngine.Greet].
+	GreetError error
+
+	// GreetCalls records all Greet invocations.
+	GreetCalls []GreetCall
+}
+
+// Greet implements [engine.Greeter].
+func (g *GreeterEngine) Greet(_ context.Context, prompt engine.PromptContext) (*engine.Response, error) {
+	g.gmu.Lock()
+	defer g.gmu.Unlock()
+	g.GreetCalls = append(g.GreetCalls, GreetCall{Prompt: prompt})
+	return g.GreetResult, g.GreetError
+}
+
+// Compile-time interface assertion.
+var _ engine.Interruptible = (*InterruptibleEngine)(nil)
+
+// InterruptibleEngine is a [VoiceEngine] mock that also implements
+// [engine.Interruptible].
+type InterruptibleEngine struct {
+	VoiceEngine
+
+	// CallCountInterrupted counts the Interrupted calls.
+	CallCountInterrupted atomic.Int32
+}
+
+// Interrupted implements [engine.Interruptible].
+func (i *InterruptibleEngine) Interrupted() {
+	i.CallCountInterrupted.Add(1)
+}
+
+// Compile-time interface assertion.
+var _ engine.SafetyFilter = (*SafetyFilter)(nil)
+
+// SafetyFilter is a mock implementation of [engine.SafetyFilter].
+type SafetyFilter struct {
+	mu sync.Mutex
+
+	// Verdicts maps checked text to the verdict returned for it. Text without
+	// an entry is allowed.
+	Verdicts map[string]engine.SafetyVerdict
+
+	// CheckError is returned by [SafetyFilter.Check].
+	CheckError error
+
+	// CheckCalls records the text of every Check invocation.
+	CheckCalls []string
+}
+
+// Check implements [engine.SafetyFilter].
+func (f *SafetyFilter) Check(_ context.Context, text string) (engine.SafetyVerdict, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.CheckCalls = append(f.CheckCalls, text)
+	if f.CheckError != nil {
+		return engine.SafetyVerdict{}, f.CheckError
+	}
+	if v, ok := f.Verdicts[text]; ok {
+		return v, nil
+	}
+	return engine.SafetyVerdict{Action: engine.SafetyAllow}, nil
+}
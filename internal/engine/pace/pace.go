@@ -0,0 +1,136 @@
+// Package pace provides an [engine.VoiceEngine] that holds back the audio of
+// a reply until a minimum response time has passed.
+//
+// A fast model with a cached voice can answer before the player has drawn
+// breath, which sounds robotic: people pause before they reply. The [Engine]
+// measures each turn from the start of [Engine.Process] and, if the first
+// audio is ready before the configured floor, keeps it until the floor has
+// elapsed. Generation is not slowed down: the inner engine's audio is read
+// and buffered while playback waits, so replies that take longer than the
+// floor are passed through without any added delay.
+//
+// This package is internal because it encapsulates application-private voice
+// pipeline logic and is not intended for import by external code.
+package pace
+
+import (
+	"context"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+)
+
+// Compile-time assertions that the engines satisfy the engine interfaces.
+var (
+	_ engine.VoiceEngine   = (*Engine)(nil)
+	_ engine.Greeter       = (*greeterEngine)(nil)
+	_ engine.Interruptible = (*Engine)(nil)
+)
+
+// Engine is a [engine.VoiceEngine] that delays the start of each reply's
+// audio to a minimum time after the turn began. All other methods are those
+// of the inner engine.
+//
+// Engine is safe for concurrent use if the inner engine is.
+type Engine struct {
+	engine.VoiceEngine
+	floor time.Duration
+}
+
+// greeterEngine is an [Engine] whose inner engine supports greetings.
+type greeterEngine struct {
+	*Engine
+	greeter engine.Greeter
+}
+
+// New wraps inner so that no reply audio is emitted earlier than floor after
+// the start of the turn. A floor of zero or less returns inner unchanged.
+//
+// The returned engine implements [engine.Greeter] if and only if inner does;
+// greetings are held back in the same way. It always implements
+// [engine.Interruptible], which is passed on to inner where supported.
+func New(inner engine.VoiceEngine, floor time.Duration) engine.VoiceEngine {
+	if floor <= 0 {
+		return inner
+	}
+	e := &Engine{VoiceEngine: inner, floor: floor}
+	if g, ok := inner.(engine.Greeter); ok {
+		return &greeterEngine{Engine: e, greeter: g}
+	}
+	return e
+}
+
+// Process implements [engine.VoiceEngine]. It runs the turn on the inner
+// engine and holds back the response audio until the floor has elapsed.
+func (e *Engine) Process(ctx context.Context, input audio.AudioFrame, prompt engine.PromptContext) (*engine.Response, error) {
+	start := time.Now()
+	resp, err := e.VoiceEngine.Process(ctx, input, prompt)
+	if err != nil {
+		return nil, err
+	}
+	e.hold(ctx, resp, start)
+	return resp, nil
+}
+
+// Greet implements [engine.Greeter].
+func (g *greeterEngine) Greet(ctx context.Context, prompt engine.PromptContext) (*engine.Response, error) {
+	start := time.Now()
+	resp, err := g.greeter.Greet(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	g.hold(ctx, resp, start)
+	return resp, nil
+}
+
+// Interrupted implements [engine.Interruptible]. It is passed on to the inner
+// engine if that supports it.
+func (e *Engine) Interrupted() {
+	if ir, ok := e.VoiceEngine.(engine.Interruptible); ok {
+		ir.Interrupted()
+	}
+}
+
+// hold replaces resp.Audio with a channel that emits nothing before the
+// floor has elapsed since start. Meanwhile the inner channel is read into a
+// buffer, so the inner engine never waits for playback. Cancelling ctx ends
+// the wait early.
+func (e *Engine) hold(ctx context.Context, resp *engine.Response, start time.Time) {
+	wait := e.floor - time.Since(start)
+	if resp == nil || resp.Audio == nil || wait <= 0 {
+		return
+	}
+	out := make(chan []byte)
+	go func(in <-chan []byte) {
+		defer close(out)
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		var held [][]byte
+	waiting:
+		for {
+			select {
+			case chunk, ok := <-in:
+				if !ok {
+					in = nil // keep waiting for the floor with the reply complete
+					continue
+				}
+				held = append(held, chunk)
+			case <-timer.C:
+				break waiting
+			case <-ctx.Done():
+				break waiting
+			}
+		}
+		for _, chunk := range held {
+			out <- chunk
+		}
+		if in != nil {
+			for chunk := range in {
+				out <- chunk
+			}
+		}
+	}(resp.Audio)
+	resp.Audio = out
+}
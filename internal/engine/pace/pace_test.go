@@ -0,0 +1,191 @@
+package pace_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	enginemock "github.com/MrWong99/glyphoxa/internal/engine/mock"
+	"github.com/MrWong99/glyphoxa/internal/engine/pace"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+)
+
+// readyAudio returns a closed channel holding chunks, as produced by an engine
+// that has finished synthesising before Process returns.
+func readyAudio(chunks ...string) <-chan []byte {
+	ch := make(chan []byte, len(chunks))
+	for _, c := range chunks {
+		ch <- []byte(c)
+	}
+	close(ch)
+	return ch
+}
+
+// drain reads audio until it is closed and returns the concatenated chunks
+// and the time the first chunk arrived.
+func drain(audio <-chan []byte) (string, time.Time) {
+	var (
+		got   string
+		first time.Time
+	)
+	for chunk := range audio {
+		if first.IsZero() {
+			first = time.Now()
+		}
+		got += string(chunk)
+	}
+	return got, first
+}
+
+func TestProcess_HoldsFastReply(t *testing.T) {
+	t.Parallel()
+
+	const floor = 150 * time.Millisecond
+	inner := &enginemock.VoiceEngine{ProcessResult: &engine.Response{Text: "Aye.", Audio: readyAudio("a", "b", "c")}}
+	eng := pace.New(inner, floor)
+
+	start := time.Now()
+	resp, err := eng.Process(context.Background(), audio.AudioFrame{}, engine.PromptContext{})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if resp.Text != "Aye." {
+		t.Errorf("Text = %q, want the inner engine's reply", resp.Text)
+	}
+	got, first := drain(resp.Audio)
+	if got != "abc" {
+		t.Errorf("audio = %q, want %q", got, "abc")
+	}
+	if elapsed := first.Sub(start); elapsed < floor {
+		t.Errorf("first audio after %v, want at least %v", elapsed, floor)
+	}
+}
+
+func TestProcess_DoesNotBlockGeneration(t *testing.T) {
+	t.Parallel()
+
+	// An unbuffered channel: every send blocks until the chunk is read.
+	in := make(chan []byte)
+	inner := &enginemock.VoiceEngine{ProcessResult: &engine.Response{Audio: in}}
+	eng := pace.New(inner, time.Hour)
+
+	// The test context ends the wait when the test is done.
+	resp, err := eng.Process(t.Context(), audio.AudioFrame{}, engine.PromptContext{})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 3 {
+			in <- []byte("x")
+		}
+		close(in)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("inner engine blocked while playback was held back")
+	}
+	select {
+	case chunk := <-resp.Audio:
+		t.Errorf("audio %q emitted before the floor", chunk)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestProcess_SlowReplyNotDelayed(t *testing.T) {
+	t.Parallel()
+
+	const floor = 50 * time.Millisecond
+	in := make(chan []byte, 1)
+	inner := &enginemock.VoiceEngine{ProcessResult: &engine.Response{Audio: in}}
+	eng := pace.New(inner, floor)
+
+	resp, err := eng.Process(context.Background(), audio.AudioFrame{}, engine.PromptContext{})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	// The first audio arrives well after the floor and must be passed on at once.
+	time.Sleep(2 * floor)
+	sent := time.Now()
+	in <- []byte("late")
+	close(in)
+	got, first := drain(resp.Audio)
+	if got != "late" {
+		t.Errorf("audio = %q, want %q", got, "late")
+	}
+	if lag := first.Sub(sent); lag > floor {
+		t.Errorf("audio passed on after %v, want no added delay", lag)
+	}
+}
+
+func TestProcess_CancelEndsWait(t *testing.T) {
+	t.Parallel()
+
+	inner := &enginemock.VoiceEngine{ProcessResult: &engine.Response{Audio: readyAudio("a")}}
+	eng := pace.New(inner, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := eng.Process(ctx, audio.AudioFrame{}, engine.PromptContext{})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		drain(resp.Audio)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("audio channel not closed after cancellation")
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	plain := &enginemock.VoiceEngine{}
+	if got := pace.New(plain, 0); got != engine.VoiceEngine(plain) {
+		t.Error("New with zero floor did not return the inner engine")
+	}
+	if _, ok := pace.New(plain, time.Second).(engine.Greeter); ok {
+		t.Error("engine without greeting support is a Greeter after New")
+	}
+
+	const floor = 100 * time.Millisecond
+	greeter := &enginemock.GreeterEngine{GreetResult: &engine.Response{Text: "Welcome!", Audio: readyAudio("hi")}}
+	g, ok := pace.New(greeter, floor).(engine.Greeter)
+	if !ok {
+		t.Fatal("engine with greeting support is not a Greeter after New")
+	}
+	start := time.Now()
+	resp, err := g.Greet(context.Background(), engine.PromptContext{})
+	if err != nil {
+		t.Fatalf("Greet: %v", err)
+	}
+	if _, first := drain(resp.Audio); first.Sub(start) < floor {
+		t.Errorf("greeting audio after %v, want at least %v", first.Sub(start), floor)
+	}
+}
+
+func TestInterrupted_Forwarded(t *testing.T) {
+	t.Parallel()
+
+	inner := &enginemock.InterruptibleEngine{}
+	ir, ok := pace.New(inner, time.Second).(engine.Interruptible)
+	if !ok {
+		t.Fatal("paced engine is not Interruptible")
+	}
+	ir.Interrupted()
+	if got := inner.CallCountInterrupted.Load(); got != 1 {
+		t.Errorf("inner Interrupted calls = %d, want 1", got)
+	}
+
+	// Inner engines without support are unaffected.
+	pace.New(&enginemock.VoiceEngine{}, time.Second).(engine.Interruptible).Interrupted()
+}
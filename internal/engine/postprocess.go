@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// TextPostProcessor transforms NPC response text after the LLM produced it and
+// before it is synthesised and recorded in the session transcript. Typical
+// uses are removing stage directions or markup the TTS would read aloud.
+//
+// Streaming engines apply post-processors to one sentence at a time, so a
+// processor must give sensible results on sentence fragments.
+type TextPostProcessor func(string) string
+
+// PostProcessors is an ordered chain of [TextPostProcessor]s.
+type PostProcessors []TextPostProcessor
+
+// Apply runs s through every processor in order and returns the result with
+// surrounding whitespace trimmed. A nil or empty chain returns s unchanged.
+func (p PostProcessors) Apply(s string) string {
+	if len(p) == 0 {
+		return s
+	}
+	for _, f := range p {
+		s = f(s)
+	}
+	return strings.TrimSpace(s)
+}
+
+var (
+	// markdownEmphasis matches **bold**, __bold__, ~~strikethrough~~,
+	// *italic*, and _italic_ spans on a single line, strongest marker first.
+	// Underscores only count at word boundaries so snake_case survives.
+	markdownEmphasis = []*regexp.Regexp{
+		regexp.MustCompile(`\*\*(\S(?:[^\n]*?\S)?)\*\*`),
+		regexp.MustCompile(`\b__(\S(?:[^\n]*?\S)?)__\b`),
+		regexp.MustCompile(`~~(\S(?:[^\n]*?\S)?)~~`),
+		regexp.MustCompile(`\*(\S(?:[^*\n]*?\S)?)\*`),
+		regexp.MustCompile(`\b_(\S(?:[^\n]*?\S)?)_\b`),
+	}
+
+	// markdownLinePrefix matches headings, block quotes, and list markers at
+	// the start of a line.
+	markdownLinePrefix = regexp.MustCompile(`(?m)^[ \t]*(?:#{1,6}[ \t]+|>[ \t]?|[-*+][ \t]+|\d+\.[ \t]+)`)
+
+	// markdownLink matches [text](url) links.
+	markdownLink = regexp.MustCompile(`\[([^\]\n]*)\]\([^)\n]*\)`)
+
+	// bracketedAction matches *action*, (action), and [action] spans on a
+	// single line, including leading horizontal whitespace. **Bold** spans
+	// are matched too so their inner *...* is not mistaken for an action;
+	// StripBracketedActions keeps them.
+	bracketedAction = regexp.MustCompile(`[ \t]*(?:\*\*[^*\n]+\*\*|\*[^*\n]+\*|\([^()\n]*\)|\[[^\[\]\n]*\])`)
+
+	// multiSpace matches runs of horizontal whitespace left behind by removals.
+	multiSpace = regexp.MustCompile(`[ \t]{2,}`)
+)
+
+// StripMarkdown is a [TextPostProcessor] that removes Markdown formatting —
+// emphasis markers, headings, block quotes, list markers, inline code ticks,
+// and link targets — while keeping the text itself.
+func StripMarkdown(s string) string {
+	s = markdownLink.ReplaceAllString(s, "$1")
+	s = markdownLinePrefix.ReplaceAllString(s, "")
+	for _, re := range markdownEmphasis {
+		s = re.ReplaceAllString(s, "$1")
+	}
+	return strings.ReplaceAll(s, "`", "")
+}
+
+// StripBracketedActions is a [TextPostProcessor] that removes stage directions
+// such as "*sighs*", "(laughs)", or "[slams the table]" so they are not
+// spoken aloud.
+func StripBracketedActions(s string) string {
+	s = bracketedAction.ReplaceAllStringFunc(s, func(m string) string {
+		if strings.HasPrefix(strings.TrimLeft(m, " \t"), "**") {
+			return m
+		}
+		return ""
+	})
+	return multiSpace.ReplaceAllString(s, " ")
+}
+
+// builtinPostProcessors maps configuration names to built-in processors.
+var builtinPostProcessors = map[string]TextPostProcessor{
+	"strip_markdown":          StripMarkdown,
+	"strip_bracketed_actions": StripBracketedActions,
+}
+
+// PostProcessorNames returns the configuration names of the built-in
+// post-processors in sorted order.
+func PostProcessorNames() []string {
+	return slices.Sorted(maps.Keys(builtinPostProcessors))
+}
+
+// LookupPostProcessors resolves configuration names to a chain of built-in
+// post-processors, preserving their order.
+func LookupPostProcessors(names []string) (PostProcessors, error) {
+	chain := make(PostProcessors, 0, len(names))
+	for _, name := range names {
+		f, ok := builtinPostProcessors[name]
+		if !ok {
+			return nil, fmt.Errorf("engine: unknown post-processor %q (known: %s)", name, strings.Join(PostProcessorNames(), ", "))
+		}
+		chain = append(chain, f)
+	}
+	return chain, nil
+}
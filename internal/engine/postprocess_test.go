@@ -0,0 +1,129 @@
+package engine_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+)
+
+func TestPostProcessors_Order(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	tag := func(name string) engine.TextPostProcessor {
+		return func(s string) string {
+			calls = append(calls, name)
+			return s + name
+		}
+	}
+
+	chain := engine.PostProcessors{tag("a"), tag("b"), tag("c")}
+	if got := chain.Apply("x"); got != "xabc" {
+		t.Errorf("Apply = %q, want %q", got, "xabc")
+	}
+	if !slices.Equal(calls, []string{"a", "b", "c"}) {
+		t.Errorf("call order = %v, want [a b c]", calls)
+	}
+}
+
+func TestPostProcessors_BuiltinOrderMatters(t *testing.T) {
+	t.Parallel()
+
+	const in = "*sighs* Fine, **traveller**."
+
+	// Stripping actions first removes the emphasised stage direction;
+	// stripping Markdown first turns it into plain words that are kept.
+	actionsFirst, err := engine.LookupPostProcessors([]string{"strip_bracketed_actions", "strip_markdown"})
+	if err != nil {
+		t.Fatalf("LookupPostProcessors: %v", err)
+	}
+	markdownFirst, err := engine.LookupPostProcessors([]string{"strip_markdown", "strip_bracketed_actions"})
+	if err != nil {
+		t.Fatalf("LookupPostProcessors: %v", err)
+	}
+
+	if got := actionsFirst.Apply(in); got != "Fine, traveller." {
+		t.Errorf("actions first = %q, want %q", got, "Fine, traveller.")
+	}
+	if got := markdownFirst.Apply(in); got != "sighs Fine, traveller." {
+		t.Errorf("markdown first = %q, want %q", got, "sighs Fine, traveller.")
+	}
+}
+
+func TestPostProcessors_Empty(t *testing.T) {
+	t.Parallel()
+
+	var chain engine.PostProcessors
+	if got := chain.Apply("  as is  "); got != "  as is  " {
+		t.Errorf("empty chain Apply = %q, want input unchanged", got)
+	}
+}
+
+func TestLookupPostProcessors_Unknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := engine.LookupPostProcessors([]string{"strip_markdown", "translate_to_klingon"})
+	if err == nil {
+		t.Fatal("expected error for unknown post-processor")
+	}
+	if !strings.Contains(err.Error(), "translate_to_klingon") {
+		t.Errorf("error %q does not name the unknown post-processor", err)
+	}
+}
+
+func TestStripMarkdown(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "bold", in: "You dare **threaten** me?", want: "You dare threaten me?"},
+		{name: "italic stars", in: "That is *not* for sale.", want: "That is not for sale."},
+		{name: "underscore emphasis", in: "A _very_ old __map__.", want: "A very old map."},
+		{name: "snake case kept", in: "The rune reads fire_bolt_scroll.", want: "The rune reads fire_bolt_scroll."},
+		{name: "strikethrough", in: "Ten ~~gold~~ silver.", want: "Ten gold silver."},
+		{name: "inline code", in: "Say `mellon` and enter.", want: "Say mellon and enter."},
+		{name: "link", in: "See [the map](https://example.com/map).", want: "See the map."},
+		{name: "heading", in: "## The Tale\nLong ago...", want: "The Tale\nLong ago..."},
+		{name: "list and quote", in: "- bread\n* ale\n1. cheese\n> so it is", want: "bread\nale\ncheese\nso it is"},
+		{name: "plain text", in: "Welcome, friend.", want: "Welcome, friend."},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := engine.StripMarkdown(tc.in); got != tc.want {
+				t.Errorf("StripMarkdown(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStripBracketedActions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "asterisks", in: "*sighs* Very well.", want: "Very well."},
+		{name: "parentheses", in: "Ha! (laughs heartily) Good one.", want: "Ha! Good one."},
+		{name: "square brackets", in: "Enough! [slams the table]", want: "Enough!"},
+		{name: "several", in: "(coughs) Who *squints* are you?", want: "Who are you?"},
+		{name: "only an action", in: "*nods*", want: ""},
+		{name: "plain text", in: "Welcome, friend.", want: "Welcome, friend."},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := engine.PostProcessors{engine.StripBracketedActions}.Apply(tc.in)
+			if got != tc.want {
+				t.Errorf("StripBracketedActions(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// MergeContextUpdate applies update onto prompt and returns the merged
+// result. Zero-value fields in update are ignored; recent utterances are
+// appended to the prompt's messages, NPC lines in the assistant role.
+func MergeContextUpdate(prompt PromptContext, update ContextUpdate) PromptContext {
+	if update.Identity != "" {
+		prompt.SystemPrompt = update.Identity
+	}
+	if update.Scene != "" {
+		prompt.HotContext = update.Scene
+	}
+	if len(update.RecentUtterances) > 0 {
+		msgs := slices.Clone(prompt.Messages)
+		for _, u := range update.RecentUtterances {
+			role := "user"
+			if u.IsNPC() {
+				role = "assistant"
+			}
+			msgs = append(msgs, llm.Message{Role: role, Content: u.Text, Name: u.SpeakerName})
+		}
+		prompt.Messages = msgs
+	}
+	return prompt
+}
+
+// VoiceFor returns voice speaking the language detected for prompt, if there
+// is one.
+func VoiceFor(voice tts.VoiceProfile, prompt PromptContext) tts.VoiceProfile {
+	if prompt.Language != "" {
+		voice.Language = prompt.Language
+	}
+	return voice
+}
+
+// AppendToolResults executes the tool calls of the assistant turn via handler
+// and returns msgs extended by the turn itself and one tool-role result
+// message per call. The turn keeps its text and reasoning so providers that
+// validate the round trip (Anthropic extended thinking) see the exact
+// assistant message they produced. Handler errors are reported to the model
+// as the tool result.
+func AppendToolResults(msgs []llm.Message, turn llm.Message, handler func(name, args string) (string, error)) []llm.Message {
+	out := make([]llm.Message, 0, len(msgs)+1+len(turn.ToolCalls))
+	out = append(out, msgs...)
+	out = append(out, turn)
+	for _, tc := range turn.ToolCalls {
+		result, err := handler(tc.Name, tc.Arguments)
+		if err != nil {
+			result = fmt.Sprintf(`{"error": %q}`, err.Error())
+		}
+		out = append(out, llm.Message{Role: "tool", Content: result, ToolCallID: tc.ID})
+	}
+	return out
+}
+
+// DrainChunks discards all remaining chunks from ch so the LLM provider's
+// goroutine is not blocked once a reply stops being read.
+func DrainChunks(ch <-chan llm.Chunk) {
+	for range ch {
+	}
+}
+
+// TTSFailed handles a TTS stream of a reply that could not be started.
+// Without errorAudio it returns err. Otherwise it returns a response that
+// plays errorAudio, in the given format, and reports err through
+// [Response.Err]; its text is empty because the NPC's reply was never heard.
+func TTSFailed(err error, errorAudio []byte, sampleRate, channels int) (*Response, error) {
+	if errorAudio == nil {
+		return nil, err
+	}
+	slog.Warn("engine: synthesis failed, playing error audio", "err", err)
+	audioCh := make(chan []byte, 1)
+	audioCh <- errorAudio
+	close(audioCh)
+	resp := &Response{Audio: audioCh, SampleRate: sampleRate, Channels: channels}
+	resp.SetStreamErr(err)
+	return resp, nil
+}
+
+// GuardAudio forwards the audio of stream, the speech of resp. When synthesis
+// fails, the failure is logged and reported through resp's [Response.Err]
+// unless an earlier error was recorded there. If the stream ends without any
+// audio, which happens when every sentence failed to synthesise, errorAudio
+// is appended if set. Nothing is added once ctx is done, so interrupted
+// replies stay silent.
+//
+// The time from start until the first audio chunk and until the end of the
+// audio are recorded as resp's [StageFirstAudio] and [StageTotal] timings.
+func GuardAudio(ctx context.Context, resp *Response, stream *tts.Stream, errorAudio []byte, start time.Time) <-chan []byte {
+	in := stream.Audio()
+	out := make(chan []byte, cap(in))
+	go func() {
+		defer close(out)
+		defer func() { resp.SetTiming(StageTotal, time.Since(start)) }()
+		heard := false
+		for chunk := range in {
+			if !heard && len(chunk) > 0 {
+				heard = true
+				resp.SetTiming(StageFirstAudio, time.Since(start))
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				audio.Drain(in)
+				return
+			}
+		}
+		if err := stream.Err(); err != nil {
+			slog.Error("engine: speech synthesis failed", "err", err)
+			if resp.Err() == nil {
+				resp.SetStreamErr(fmt.Errorf("engine: TTS stream failed: %w", err))
+			}
+		}
+		if heard || errorAudio == nil || ctx.Err() != nil {
+			return
+		}
+		slog.Warn("engine: synthesis produced no audio, playing error audio")
+		resp.SetTiming(StageFirstAudio, time.Since(start))
+		select {
+		case out <- errorAudio:
+		case <-ctx.Done():
+		}
+	}()
+	return out
+}
+
+// PublishTranscript sends text, spoken by the NPC npcID named npcName in
+// language, on an engine's transcript channel ch, timestamped with start.
+// Empty text is not published. It gives up once done is closed, when the
+// engine shuts down, and reports whether the entry was sent or skipped.
+func PublishTranscript(ch chan<- memory.TranscriptEntry, done <-chan struct{}, npcID, npcName, text, language string, start time.Time) bool {
+	if text == "" {
+		return true
+	}
+	entry := memory.TranscriptEntry{
+		SpeakerID:   npcID,
+		SpeakerName: npcName,
+		Text:        text,
+		NPCID:       npcID,
+		Timestamp:   start,
+		Language:    language,
+	}
+	select {
+	case ch <- entry:
+		return true
+	case <-done:
+		return false
+	}
+}
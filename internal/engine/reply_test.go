@@ -0,0 +1,188 @@
+package engine_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+func TestMergeContextUpdate(t *testing.T) {
+	t.Parallel()
+
+	orig := engine.PromptContext{
+		SystemPrompt: "You are Grimjaw.",
+		HotContext:   "The tavern is quiet.",
+		Messages:     []llm.Message{{Role: "user", Content: "Hello."}},
+	}
+	got := engine.MergeContextUpdate(orig, engine.ContextUpdate{
+		Scene: "A brawl breaks out.",
+		RecentUtterances: []memory.TranscriptEntry{
+			{SpeakerName: "Alice", Text: "Duck!"},
+			{SpeakerName: "Grimjaw", NPCID: "grimjaw", Text: "Not in my tavern!"},
+		},
+	})
+
+	if got.SystemPrompt != orig.SystemPrompt {
+		t.Errorf("SystemPrompt = %q, want it unchanged", got.SystemPrompt)
+	}
+	if got.HotContext != "A brawl breaks out." {
+		t.Errorf("HotContext = %q, want the new scene", got.HotContext)
+	}
+	want := []llm.Message{
+		{Role: "user", Content: "Hello."},
+		{Role: "user", Content: "Duck!", Name: "Alice"},
+		{Role: "assistant", Content: "Not in my tavern!", Name: "Grimjaw"},
+	}
+	if len(got.Messages) != len(want) {
+		t.Fatalf("Messages = %+v, want %+v", got.Messages, want)
+	}
+	for i := range want {
+		if got.Messages[i].Role != want[i].Role || got.Messages[i].Content != want[i].Content || got.Messages[i].Name != want[i].Name {
+			t.Errorf("Messages[%d] = %+v, want %+v", i, got.Messages[i], want[i])
+		}
+	}
+	if len(orig.Messages) != 1 {
+		t.Errorf("original prompt was modified: %+v", orig.Messages)
+	}
+}
+
+func TestAppendToolResults(t *testing.T) {
+	t.Parallel()
+
+	turn := llm.Message{Role: "assistant", ToolCalls: []llm.ToolCall{
+		{ID: "1", Name: "roll", Arguments: `{"dice":"d20"}`},
+		{ID: "2", Name: "broken"},
+	}}
+	got := engine.AppendToolResults([]llm.Message{{Role: "user", Content: "Roll for it."}}, turn, func(name, _ string) (string, error) {
+		if name == "broken" {
+			return "", errors.New("tool offline")
+		}
+		return `{"result":17}`, nil
+	})
+
+	if len(got) != 4 {
+		t.Fatalf("got %d messages, want 4", len(got))
+	}
+	if got[1].Role != "assistant" || len(got[1].ToolCalls) != 2 {
+		t.Errorf("messages[1] = %+v, want the assistant turn", got[1])
+	}
+	if got[2].ToolCallID != "1" || got[2].Content != `{"result":17}` {
+		t.Errorf("messages[2] = %+v, want the roll result", got[2])
+	}
+	if got[3].ToolCallID != "2" || got[3].Content != `{"error": "tool offline"}` {
+		t.Errorf("messages[3] = %+v, want the handler error", got[3])
+	}
+}
+
+func TestTTSFailed(t *testing.T) {
+	t.Parallel()
+
+	errStart := errors.New("no voice")
+	if resp, err := engine.TTSFailed(errStart, nil, 22050, 1); resp != nil || !errors.Is(err, errStart) {
+		t.Errorf("without error audio = (%v, %v), want (nil, %v)", resp, err, errStart)
+	}
+
+	resp, err := engine.TTSFailed(errStart, []byte{1, 2}, 22050, 1)
+	if err != nil {
+		t.Fatalf("with error audio: %v", err)
+	}
+	if resp.Text != "" || resp.SampleRate != 22050 || resp.Channels != 1 {
+		t.Errorf("response = %+v, want silent text in 22050 Hz mono", resp)
+	}
+	if got := <-resp.Audio; len(got) != 2 {
+		t.Errorf("audio = %v, want the error audio", got)
+	}
+	if !errors.Is(resp.Err(), errStart) {
+		t.Errorf("Err() = %v, want %v", resp.Err(), errStart)
+	}
+}
+
+func TestGuardAudio(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		chunks     [][]byte
+		streamErr  error
+		errorAudio []byte
+		want       int // chunks received
+		wantErr    bool
+	}{
+		{name: "audio passes through", chunks: [][]byte{{1}, {2}}, errorAudio: []byte{9}, want: 2},
+		{name: "silent stream plays error audio", streamErr: errors.New("quota"), errorAudio: []byte{9}, want: 1, wantErr: true},
+		{name: "silent stream without error audio", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			in := make(chan []byte, len(tt.chunks))
+			for _, c := range tt.chunks {
+				in <- c
+			}
+			close(in)
+			stream := tts.NewStream(in)
+			if tt.streamErr != nil {
+				stream.SetErr(tt.streamErr)
+			}
+
+			resp := &engine.Response{}
+			var got int
+			for range engine.GuardAudio(t.Context(), resp, stream, tt.errorAudio, time.Now()) {
+				got++
+			}
+			if got != tt.want {
+				t.Errorf("received %d chunks, want %d", got, tt.want)
+			}
+			if (resp.Err() != nil) != tt.wantErr {
+				t.Errorf("Err() = %v, want error: %v", resp.Err(), tt.wantErr)
+			}
+			if resp.Timings().Total <= 0 {
+				t.Errorf("total timing not recorded")
+			}
+		})
+	}
+}
+
+func TestPublishTranscript(t *testing.T) {
+	t.Parallel()
+
+	ch := make(chan memory.TranscriptEntry, 1)
+	done := make(chan struct{})
+	start := time.Now()
+
+	if !engine.PublishTranscript(ch, done, "grimjaw", "Grimjaw", "", "en", start) || len(ch) != 0 {
+		t.Error("empty text was published")
+	}
+	if !engine.PublishTranscript(ch, done, "grimjaw", "Grimjaw", "Welcome!", "en", start) {
+		t.Fatal("PublishTranscript = false, want true")
+	}
+	got := <-ch
+	if got.NPCID != "grimjaw" || got.SpeakerName != "Grimjaw" || got.Text != "Welcome!" || got.Language != "en" || !got.Timestamp.Equal(start) {
+		t.Errorf("entry = %+v", got)
+	}
+
+	// A full channel gives up once the engine is closed.
+	ch <- memory.TranscriptEntry{}
+	close(done)
+	if engine.PublishTranscript(ch, done, "grimjaw", "Grimjaw", "Goodbye.", "en", start) {
+		t.Error("PublishTranscript = true after done was closed, want false")
+	}
+}
+
+func TestVoiceFor(t *testing.T) {
+	t.Parallel()
+
+	voice := tts.VoiceProfile{ID: "v1", Language: "en"}
+	if got := engine.VoiceFor(voice, engine.PromptContext{}); got.Language != "en" {
+		t.Errorf("Language = %q, want the voice's %q", got.Language, "en")
+	}
+	if got := engine.VoiceFor(voice, engine.PromptContext{Language: "de"}); got.Language != "de" || got.ID != "v1" {
+		t.Errorf("voice = %+v, want v1 speaking de", got)
+	}
+}
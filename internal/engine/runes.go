@@ -0,0 +1,40 @@
+package engine
+
+import "unicode/utf8"
+
+// RuneJoiner reassembles streamed text whose chunks may split a multi-byte
+// UTF-8 character. It holds back an incomplete sequence at the end of a chunk
+// until the next chunk completes it, so callers only ever see whole runes and
+// sentence detection, post-processing and TTS never receive a torn character.
+//
+// A sequence that is still incomplete when the stream ends cannot be decoded
+// and is discarded.
+//
+// The zero value is ready to use. A RuneJoiner is not safe for concurrent use.
+type RuneJoiner struct {
+	pending []byte
+}
+
+// Push returns text prefixed with the bytes held back from the previous chunk.
+// A trailing incomplete UTF-8 sequence is cut off and held back for the next
+// call. Invalid bytes are passed through unchanged.
+func (j *RuneJoiner) Push(text string) string {
+	if len(j.pending) > 0 {
+		text = string(j.pending) + text
+		j.pending = j.pending[:0]
+	}
+
+	// Only the last rune can be incomplete; its first byte lies at most
+	// utf8.UTFMax-1 bytes before the end.
+	for i := len(text) - 1; i >= max(len(text)-utf8.UTFMax+1, 0); i-- {
+		if !utf8.RuneStart(text[i]) {
+			continue
+		}
+		if !utf8.FullRuneInString(text[i:]) {
+			j.pending = append(j.pending, text[i:]...)
+			return text[:i]
+		}
+		break
+	}
+	return text
+}
@@ -0,0 +1,27 @@
+package engine_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+)
+
+func TestRuneJoiner(t *testing.T) {
+	t.Parallel()
+
+	const text = "Grüß dich 🐉!"
+	var j engine.RuneJoiner
+	var sb strings.Builder
+	for i := range len(text) {
+		out := j.Push(text[i : i+1])
+		if !utf8.ValidString(out) {
+			t.Fatalf("Push returned torn text %q", out)
+		}
+		sb.WriteString(out)
+	}
+	if sb.String() != text {
+		t.Errorf("joined text = %q, want %q", sb.String(), text)
+	}
+}
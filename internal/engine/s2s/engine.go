@@ -6,7 +6,9 @@
 // and keeps it alive across subsequent calls. If the session dies (its Err()
 // method returns non-nil), the next [Engine.Process] call transparently
 // reconnects. Transcript entries are fanned-out from the session to a stable
-// channel returned by [Engine.Transcripts].
+// channel returned by [Engine.Transcripts]. A reconnected session continues the
+// transcript numbering of its predecessor, and entries it delivers again or out
+// of order are dropped, so consumers never record a line twice.
 //
 // This package is internal because it encapsulates application-private voice
 // pipeline logic and is not intended for import by external code.
@@ -14,9 +16,12 @@ package s2s
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/MrWong99/glyphoxa/internal/engine"
@@ -39,11 +44,25 @@ const (
 	// channel returned by [Engine.Transcripts].
 	defaultTranscriptBuf = 64
 
+	// repeatWindow is how long after an entry was emitted a reconnected
+	// session's entry with the same speaker and text is treated as a repeat
+	// of it.
+	repeatWindow = 30 * time.Second
+
 	// defaultAudioBuf is the buffer depth of the per-turn audio channels created
 	// inside [Engine.Process].
 	defaultAudioBuf = 64
+
+	// DefaultMaxToolRounds is the default number of tool calls the model may
+	// make per turn before further calls are refused.
+	DefaultMaxToolRounds = 3
 )
 
+// errToolLimit is returned to the model in place of a tool result once the
+// per-turn tool call limit is reached. Providers forward the message to the
+// model, which is thereby told to answer without further tools.
+var errToolLimit = errors.New("tool call limit reached for this turn; answer the player now using the information you already have, without calling any more tools")
+
 // Option is a functional option for configuring an [Engine].
 type Option func(*Engine)
 
@@ -76,6 +95,20 @@ func WithTTSFormat(sampleRate, channels int) Option {
 	}
 }
 
+// WithMaxToolRounds sets how many tool calls the model may make per turn. S2S
+// providers dispatch tool calls one at a time and request a new model
+// response after each result, so every call counts as one round. Calls beyond
+// the limit are not executed; the model instead receives an error result
+// telling it to answer without tools. Values < 1 are ignored; the default is
+// [DefaultMaxToolRounds].
+func WithMaxToolRounds(n int) Option {
+	return func(e *Engine) {
+		if n > 0 {
+			e.maxToolRounds = n
+		}
+	}
+}
+
 // Engine is a [engine.VoiceEngine] implementation that wraps an [providers2s.Provider].
 // It manages session lifecycle, fans-out transcripts, and bridges per-turn audio
 // channels from the continuous S2S audio stream.
@@ -97,15 +130,36 @@ type Engine struct {
 	// (1 = mono, 2 = stereo). Defaults to 1 if not set via [WithTTSFormat].
 	ttsChannels int
 
+	// maxToolRounds caps the number of tool calls executed per turn.
+	maxToolRounds int
+
+	// postProcessors transform the text of NPC transcript entries.
+	postProcessors engine.PostProcessors
+
 	mu          sync.Mutex
 	session     providers2s.SessionHandle
 	toolHandler func(name string, args string) (string, error)
 	tools       []llm.ToolDefinition
+	toolRounds  int // tool calls dispatched since the last Process call
 
 	transcriptCh chan memory.TranscriptEntry
 	done         chan struct{}
 	closed       bool
 
+	// generation counts the sessions opened so far; each forwardTranscripts
+	// goroutine knows the generation of its session and stops emitting once
+	// a successor is connected.
+	generation atomic.Uint64
+
+	// emitMu serialises transcript emission across the forwarders of the
+	// current and superseded sessions. lastSeq is the Sequence of the last
+	// entry emitted on transcriptCh; it is written under emitMu and passed
+	// to reconnected sessions so their numbering continues. recent holds the
+	// entries emitted within repeatWindow, guarded by emitMu.
+	emitMu  sync.Mutex
+	lastSeq atomic.Uint64
+	recent  []emitted
+
 	// wg tracks all background goroutines spawned by the engine:
 	//   - forwardTranscripts goroutines (one per session)
 	//   - forwardAudio goroutines (one per Process call)
@@ -114,6 +168,26 @@ type Engine struct {
 	wg sync.WaitGroup
 }
 
+// emitted records a transcript entry emitted by the engine for detecting
+// repeats.
+type emitted struct {
+	generation uint64
+	speakerID  string
+	npcID      string
+	text       string
+	at         time.Time
+}
+
+// WithPostProcessors sets the text post-processors applied, in order, to the
+// NPC's transcript entries before they are emitted on [Engine.Transcripts].
+// S2S providers synthesise speech themselves, so the spoken audio is not
+// affected; only the recorded text is.
+func WithPostProcessors(p ...engine.TextPostProcessor) Option {
+	return func(e *Engine) {
+		e.postProcessors = append(e.postProcessors, p...)
+	}
+}
+
 // New creates a new Engine wrapping provider and pre-configured with cfg.
 // Options are applied in order. The engine does not connect to the provider
 // until the first [Engine.Process] call.
@@ -123,6 +197,7 @@ func New(provider providers2s.Provider, cfg providers2s.SessionConfig, opts ...O
 		sessionCfg:    cfg,
 		transcriptBuf: defaultTranscriptBuf,
 		turnTimeout:   defaultTurnTimeout,
+		maxToolRounds: DefaultMaxToolRounds,
 		done:          make(chan struct{}),
 	}
 	for _, opt := range opts {
@@ -160,8 +235,10 @@ func (e *Engine) ensureSessionLocked(ctx context.Context) error {
 		e.session = nil
 	}
 
-	// Connect a new session.
-	sess, err := e.provider.Connect(ctx, e.sessionCfg)
+	// Connect a new session that continues the transcript numbering.
+	cfg := e.sessionCfg
+	cfg.TranscriptSequenceStart = e.lastSeq.Load()
+	sess, err := e.provider.Connect(ctx, cfg)
 	if err != nil {
 		return fmt.Errorf("s2s: connect: %w", err)
 	}
@@ -171,18 +248,31 @@ func (e *Engine) ensureSessionLocked(ctx context.Context) error {
 		_ = sess.SetTools(e.tools)
 	}
 	if e.toolHandler != nil {
-		sess.OnToolCall(e.toolHandler)
+		sess.OnToolCall(e.dispatchTool)
 	}
 
 	sess.OnError(func(err error) {
 		slog.Warn("s2s non-fatal error", "err", err)
 	})
+	if obs, ok := sess.(providers2s.ResponseObserver); ok {
+		obs.OnResponseDone(func(done providers2s.ResponseDone) {
+			slog.Debug("s2s response done",
+				"id", done.ID,
+				"status", done.Status,
+				"reason", done.Reason,
+				"input_tokens", done.Usage.PromptTokens,
+				"output_tokens", done.Usage.CompletionTokens,
+			)
+		})
+	}
 
 	e.session = sess
 
-	// Fan-out transcripts from the new session into the stable engine channel.
+	// Fan-out transcripts from the new session into the stable engine
+	// channel. From now on, entries of the superseded session are dropped.
+	gen := e.generation.Add(1)
 	e.wg.Add(1)
-	go e.forwardTranscripts(sess.Transcripts())
+	go e.forwardTranscripts(gen, sess.Transcripts())
 
 	return nil
 }
@@ -206,6 +296,7 @@ func (e *Engine) Process(ctx context.Context, input audio.AudioFrame, prompt eng
 		return nil, fmt.Errorf("s2s: ensure session: %w", err)
 	}
 	session := e.session
+	e.toolRounds = 0
 	// Capture the audio channel while holding the lock so that a racing Close()
 	// cannot nil out e.session before we read it.
 	sessionAudioCh := e.session.Audio()
@@ -218,7 +309,7 @@ func (e *Engine) Process(ctx context.Context, input audio.AudioFrame, prompt eng
 	}
 	if prompt.HotContext != "" {
 		_ = session.InjectTextContext([]providers2s.ContextItem{
-			{Role: "system", Content: prompt.HotContext},
+			{Role: providers2s.RoleSystem, Content: prompt.HotContext},
 		})
 	}
 
@@ -293,10 +384,10 @@ func (e *Engine) forwardAudio(dst chan<- []byte, src <-chan []byte) {
 	}
 }
 
-// forwardTranscripts reads TranscriptEntry values from src (a session's
-// Transcripts channel) and forwards them to e.transcriptCh. It exits when
-// src closes or the engine is closed.
-func (e *Engine) forwardTranscripts(src <-chan memory.TranscriptEntry) {
+// forwardTranscripts reads TranscriptEntry values from src (the Transcripts
+// channel of the session of generation gen) and forwards them to
+// e.transcriptCh. It exits when src closes or the engine is closed.
+func (e *Engine) forwardTranscripts(gen uint64, src <-chan memory.TranscriptEntry) {
 	defer e.wg.Done()
 
 	for {
@@ -307,15 +398,50 @@ func (e *Engine) forwardTranscripts(src <-chan memory.TranscriptEntry) {
 			if !ok {
 				return
 			}
-			select {
-			case e.transcriptCh <- entry:
-			case <-e.done:
+			if entry.IsNPC() {
+				entry.Text = e.postProcessors.Apply(entry.Text)
+			}
+			if !e.emitTranscript(gen, entry) {
 				return
 			}
 		}
 	}
 }
 
+// emitTranscript sends entry, received from the session of generation gen,
+// on e.transcriptCh, numbered to follow the previously emitted entry. Two
+// kinds of entries are dropped: those of a session that has been superseded
+// by a reconnect, and those that repeat an entry of an earlier session, with
+// the same speaker and text, emitted within repeatWindow; a reconnected
+// session may say its predecessor's last line again. A session repeating its
+// own line is not affected. It returns false if the engine was closed.
+func (e *Engine) emitTranscript(gen uint64, entry memory.TranscriptEntry) bool {
+	e.emitMu.Lock()
+	defer e.emitMu.Unlock()
+
+	if current := e.generation.Load(); gen != current {
+		slog.Debug("s2s: dropping transcript entry of a superseded session", "generation", gen, "current", current)
+		return true
+	}
+	now := time.Now()
+	e.recent = slices.DeleteFunc(e.recent, func(r emitted) bool { return now.Sub(r.at) > repeatWindow })
+	for _, r := range e.recent {
+		if r.generation < gen && r.speakerID == entry.SpeakerID && r.npcID == entry.NPCID && r.text == entry.Text {
+			slog.Debug("s2s: dropping transcript entry repeated after a reconnect", "speaker", entry.SpeakerID)
+			return true
+		}
+	}
+	e.recent = append(e.recent, emitted{generation: gen, speakerID: entry.SpeakerID, npcID: entry.NPCID, text: entry.Text, at: now})
+	entry.Sequence = e.lastSeq.Add(1)
+
+	select {
+	case e.transcriptCh <- entry:
+		return true
+	case <-e.done:
+		return false
+	}
+}
+
 // InjectContext implements [engine.VoiceEngine]. It pushes an out-of-band
 // context update into the running session. If no session is open yet the
 // update is silently dropped (it will be applied via Process's prompt parameter
@@ -331,13 +457,13 @@ func (e *Engine) InjectContext(_ context.Context, update engine.ContextUpdate) e
 
 	var items []providers2s.ContextItem
 	if update.Identity != "" {
-		items = append(items, providers2s.ContextItem{Role: "system", Content: update.Identity})
+		items = append(items, providers2s.ContextItem{Role: providers2s.RoleSystem, Content: update.Identity})
 	}
 	if update.Scene != "" {
-		items = append(items, providers2s.ContextItem{Role: "system", Content: update.Scene})
+		items = append(items, providers2s.ContextItem{Role: providers2s.RoleSystem, Content: update.Scene})
 	}
 	for _, u := range update.RecentUtterances {
-		items = append(items, providers2s.ContextItem{Role: "user", Content: u.Text})
+		items = append(items, providers2s.ContextItem{Role: providers2s.RoleUser, Content: u.Text})
 	}
 
 	if len(items) > 0 {
@@ -362,15 +488,36 @@ func (e *Engine) SetTools(tools []llm.ToolDefinition) error {
 
 // OnToolCall implements [engine.VoiceEngine]. It stores handler and registers
 // it on the active session if one is open. The handler is also applied to any
-// future session created by ensureSessionLocked.
+// future session created by ensureSessionLocked. Calls beyond the per-turn
+// limit set by [WithMaxToolRounds] do not reach handler.
 func (e *Engine) OnToolCall(handler func(name string, args string) (string, error)) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	e.toolHandler = handler
 	if e.session != nil {
-		e.session.OnToolCall(handler)
+		e.session.OnToolCall(e.dispatchTool)
+	}
+}
+
+// dispatchTool is the tool call handler registered on sessions. It enforces
+// the per-turn tool call limit and forwards calls within the limit to the
+// handler registered via [Engine.OnToolCall].
+func (e *Engine) dispatchTool(name, args string) (string, error) {
+	e.mu.Lock()
+	handler := e.toolHandler
+	e.toolRounds++
+	round := e.toolRounds
+	e.mu.Unlock()
+
+	if handler == nil {
+		return "", fmt.Errorf("s2s: no handler for tool %q", name)
+	}
+	if round > e.maxToolRounds {
+		slog.Warn("s2s: tool call limit reached", "tool", name, "limit", e.maxToolRounds)
+		return "", errToolLimit
 	}
+	return handler(name, args)
 }
 
 // Transcripts implements [engine.VoiceEngine]. It returns a stable read-only
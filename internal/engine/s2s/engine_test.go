@@ -339,6 +339,52 @@ func TestOnToolCall_RegistersHandler(t *testing.T) {
 	}
 }
 
+// ─── TestToolCall_RoundLimit ──────────────────────────────────────────────────
+
+// TestToolCall_RoundLimit simulates a model that keeps requesting tools: calls
+// beyond the per-turn limit must not reach the handler and must return an
+// error telling the model to answer. The next turn starts a fresh budget.
+func TestToolCall_RoundLimit(t *testing.T) {
+	t.Parallel()
+
+	sess := newSession()
+	p := &s2smock.Provider{Session: sess}
+	e := newTestEngine(p, s2s.WithMaxToolRounds(2))
+	t.Cleanup(func() { _ = e.Close() })
+
+	var handled int
+	e.OnToolCall(func(name, args string) (string, error) {
+		handled++
+		return "ok", nil
+	})
+
+	for turn := range 2 {
+		resp := mustProcess(t, e, nil)
+		go drainAudio(resp.Audio)
+
+		dispatch := sess.Handler()
+		if dispatch == nil {
+			t.Fatal("no tool handler registered on session")
+		}
+		for call := range 5 {
+			result, err := dispatch("query_lore", "{}")
+			if call < 2 {
+				if err != nil || result != "ok" {
+					t.Errorf("turn %d call %d: got (%q, %v), want (\"ok\", nil)", turn, call, result, err)
+				}
+				continue
+			}
+			if err == nil {
+				t.Errorf("turn %d call %d: want tool limit error, got result %q", turn, call, result)
+			}
+		}
+	}
+
+	if handled != 4 {
+		t.Errorf("handler calls: want 4 (2 per turn), got %d", handled)
+	}
+}
+
 // ─── TestOnError_WiredToSession ───────────────────────────────────────────────
 
 func TestOnError_WiredToSession(t *testing.T) {
@@ -393,6 +439,141 @@ func TestTranscripts_ForwardsFromSession(t *testing.T) {
 	}
 }
 
+// ─── TestTranscripts_ReconnectDeduplicates ───────────────────────────────────
+
+// numberingProvider numbers the transcript entries of its sessions like the
+// real providers do, continuing from [providers2s.SessionConfig]
+// TranscriptSequenceStart.
+type numberingProvider struct {
+	*s2smock.Provider
+}
+
+func (p numberingProvider) Connect(ctx context.Context, cfg providers2s.SessionConfig) (providers2s.SessionHandle, error) {
+	h, err := p.Provider.Connect(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	in := h.Transcripts()
+	out := make(chan memory.TranscriptEntry, 16)
+	go func() {
+		defer close(out)
+		seq := cfg.TranscriptSequenceStart
+		for entry := range in {
+			seq++
+			entry.Sequence = seq
+			out <- entry
+		}
+	}()
+	return numberedSession{SessionHandle: h, transcripts: out}, nil
+}
+
+// numberedSession is a session whose transcripts were numbered by a
+// numberingProvider.
+type numberedSession struct {
+	providers2s.SessionHandle
+	transcripts chan memory.TranscriptEntry
+}
+
+func (s numberedSession) Transcripts() <-chan memory.TranscriptEntry { return s.transcripts }
+
+func TestTranscripts_ReconnectDeduplicates(t *testing.T) {
+	t.Parallel()
+
+	first, second := newSession(), newSession()
+	t.Cleanup(func() {
+		close(first.TranscriptsCh)
+		close(second.TranscriptsCh)
+	})
+	p := &s2smock.Provider{Session: first}
+	e := s2s.New(numberingProvider{p}, providers2s.SessionConfig{}, s2s.WithTurnTimeout(shortTimeout))
+	t.Cleanup(func() { _ = e.Close() })
+
+	recv := func() memory.TranscriptEntry {
+		t.Helper()
+		select {
+		case got := <-e.Transcripts():
+			return got
+		case <-time.After(500 * time.Millisecond):
+			t.Fatal("timed out waiting for transcript entry from engine")
+			return memory.TranscriptEntry{}
+		}
+	}
+	expect := func(text string, seq uint64) {
+		t.Helper()
+		if got := recv(); got.Text != text || got.Sequence != seq {
+			t.Errorf("transcript = %q #%d, want %q #%d", got.Text, got.Sequence, text, seq)
+		}
+	}
+
+	resp := mustProcess(t, e, []byte("turn1"))
+	go drainAudio(resp.Audio)
+	first.TranscriptsCh <- memory.TranscriptEntry{SpeakerID: "player-1", Text: "Who goes there?"}
+	first.TranscriptsCh <- memory.TranscriptEntry{SpeakerID: "npc", NPCID: "npc", Text: "A friend."}
+	expect("Who goes there?", 1)
+	expect("A friend.", 2)
+
+	// The session dies; the next Process reconnects to a session that says
+	// the last line again before continuing. The provider numbers the
+	// repeat as a new entry.
+	first.ErrResult = errors.New("session died")
+	p.Session = second
+	resp = mustProcess(t, e, []byte("turn2"))
+	go drainAudio(resp.Audio)
+
+	if n := len(p.ConnectCalls); n != 2 {
+		t.Fatalf("want 2 ConnectCalls (reconnect), got %d", n)
+	}
+	if start := p.ConnectCalls[1].Cfg.TranscriptSequenceStart; start != 2 {
+		t.Errorf("reconnect TranscriptSequenceStart = %d, want 2", start)
+	}
+
+	second.TranscriptsCh <- memory.TranscriptEntry{SpeakerID: "npc", NPCID: "npc", Text: "A friend."}
+	second.TranscriptsCh <- memory.TranscriptEntry{SpeakerID: "player-1", Text: "Then pass."}
+	expect("Then pass.", 3)
+
+	// A late entry of the superseded session is dropped, while the new
+	// session may repeat its own lines.
+	first.TranscriptsCh <- memory.TranscriptEntry{SpeakerID: "npc", NPCID: "npc", Text: "Stale line."}
+	second.TranscriptsCh <- memory.TranscriptEntry{SpeakerID: "npc", NPCID: "npc", Text: "Aye."}
+	second.TranscriptsCh <- memory.TranscriptEntry{SpeakerID: "npc", NPCID: "npc", Text: "Aye."}
+	expect("Aye.", 4)
+	expect("Aye.", 5)
+	select {
+	case got := <-e.Transcripts():
+		t.Errorf("unexpected transcript %q #%d", got.Text, got.Sequence)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// ─── TestTranscripts_PostProcessors ──────────────────────────────────────────
+
+func TestTranscripts_PostProcessors(t *testing.T) {
+	t.Parallel()
+
+	sess := newSession()
+	p := &s2smock.Provider{Session: sess}
+	e := newTestEngine(p, s2s.WithPostProcessors(enginepkg.StripBracketedActions))
+	t.Cleanup(func() { _ = e.Close() })
+
+	resp := mustProcess(t, e, nil)
+	go drainAudio(resp.Audio)
+
+	// Only the NPC's text is post-processed; player speech is recorded as is.
+	sess.TranscriptsCh <- memory.TranscriptEntry{SpeakerID: "user", Text: "(whispers) Hello?"}
+	sess.TranscriptsCh <- memory.TranscriptEntry{SpeakerID: "model", NPCID: "gemini", Text: "*bows* Greetings."}
+
+	for _, want := range []string{"(whispers) Hello?", "Greetings."} {
+		select {
+		case got := <-e.Transcripts():
+			if got.Text != want {
+				t.Errorf("transcript text = %q, want %q", got.Text, want)
+			}
+		case <-time.After(500 * time.Millisecond):
+			t.Fatal("timed out waiting for transcript entry from engine")
+		}
+	}
+}
+
 // ─── TestClose_Idempotent ─────────────────────────────────────────────────────
 
 func TestClose_Idempotent(t *testing.T) {
@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+// SafetyAction is the outcome of checking NPC text with a [SafetyFilter].
+type SafetyAction int
+
+const (
+	// SafetyAllow speaks the text unchanged.
+	SafetyAllow SafetyAction = iota
+
+	// SafetyBlock withholds the text and ends the reply.
+	SafetyBlock
+
+	// SafetyRedact speaks [SafetyVerdict.Text] in place of the text.
+	SafetyRedact
+
+	// SafetyRegenerate discards the text and asks the model for a new reply.
+	// Engines regenerate at most once per turn; a second violation is
+	// blocked.
+	SafetyRegenerate
+)
+
+// String returns the configuration name of the action.
+func (a SafetyAction) String() string {
+	switch a {
+	case SafetyAllow:
+		return "allow"
+	case SafetyBlock:
+		return "block"
+	case SafetyRedact:
+		return "redact"
+	case SafetyRegenerate:
+		return "regenerate"
+	default:
+		return fmt.Sprintf("SafetyAction(%d)", int(a))
+	}
+}
+
+// SafetyVerdict is the result of [SafetyFilter.Check].
+type SafetyVerdict struct {
+	// Action decides what happens to the checked text.
+	Action SafetyAction
+
+	// Text is the redacted text spoken with [SafetyRedact]. It may be empty,
+	// in which case nothing of the checked text is spoken.
+	Text string
+
+	// Reason describes the violation. It is logged, never spoken.
+	Reason string
+}
+
+// SafetyFilter guards NPC speech against disallowed content. Engines check
+// LLM output with it after post-processing and before synthesis, so a
+// violation is never spoken or recorded in the session transcript.
+//
+// Streaming engines check one sentence at a time. A filter that fails is
+// treated as having blocked the text.
+//
+// Implementations must be safe for concurrent use.
+type SafetyFilter interface {
+	// Check inspects text and returns what to do with it.
+	Check(ctx context.Context, text string) (SafetyVerdict, error)
+}
+
+// NopSafetyFilter is a [SafetyFilter] that allows all text. It is the default
+// when no filter is configured.
+type NopSafetyFilter struct{}
+
+// Check implements [SafetyFilter]. It always returns [SafetyAllow].
+func (NopSafetyFilter) Check(context.Context, string) (SafetyVerdict, error) {
+	return SafetyVerdict{Action: SafetyAllow}, nil
+}
+
+// Screen checks text, spoken by the NPC npc, with f and returns the text to
+// speak and the action taken. [SafetyRedact] is resolved here and reported
+// as [SafetyAllow] with the redacted text; [SafetyRegenerate] is downgraded
+// to [SafetyBlock] unless canRegenerate is set. A filter error blocks the
+// text. The returned text is empty unless the action is [SafetyAllow].
+func Screen(ctx context.Context, f SafetyFilter, npc, text string, canRegenerate bool) (string, SafetyAction) {
+	if text == "" {
+		return "", SafetyAllow
+	}
+	v, err := f.Check(ctx, text)
+	if err != nil {
+		slog.Warn("engine: safety filter failed, withholding text", "npc", npc, "err", err)
+		return "", SafetyBlock
+	}
+	if v.Action != SafetyAllow {
+		slog.Info("engine: safety filter flagged reply", "npc", npc, "action", v.Action, "reason", v.Reason)
+	}
+	switch v.Action {
+	case SafetyAllow:
+		return text, SafetyAllow
+	case SafetyRedact:
+		return strings.TrimSpace(v.Text), SafetyAllow
+	case SafetyRegenerate:
+		if canRegenerate {
+			return "", SafetyRegenerate
+		}
+	}
+	return "", SafetyBlock
+}
+
+// SafetyRetry returns req prepared for regenerating a flagged reply: the
+// sentences already spoken this round are added to the assistant prefix, so
+// the model continues after them, and the system prompt is extended by
+// instruction.
+func SafetyRetry(req llm.CompletionRequest, spoken []string, instruction string) llm.CompletionRequest {
+	req.SystemPrompt += "\n\n" + instruction
+	msgs := make([]llm.Message, len(req.Messages), len(req.Messages)+1)
+	copy(msgs, req.Messages)
+	if len(spoken) > 0 {
+		text := strings.Join(spoken, " ")
+		if n := len(msgs); n > 0 && msgs[n-1].Role == "assistant" && len(msgs[n-1].ToolCalls) == 0 {
+			msgs[n-1].Content = strings.TrimSpace(msgs[n-1].Content + " " + text)
+		} else {
+			msgs = append(msgs, llm.Message{Role: "assistant", Content: text})
+		}
+	}
+	req.Messages = msgs
+	return req
+}
+
+// WordlistFilter is a [SafetyFilter] that flags text containing a word or
+// phrase from a fixed list. Matching ignores case and only counts whole
+// words, so "ass" does not flag "class". Redaction removes the matches.
+type WordlistFilter struct {
+	re     *regexp.Regexp // nil for an empty list
+	action SafetyAction
+}
+
+// Compile-time interface assertions.
+var (
+	_ SafetyFilter = NopSafetyFilter{}
+	_ SafetyFilter = (*WordlistFilter)(nil)
+)
+
+// NewWordlistFilter creates a [WordlistFilter] that returns action for text
+// containing any of words. Blank entries are ignored.
+func NewWordlistFilter(words []string, action SafetyAction) *WordlistFilter {
+	var alts []string
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			alts = append(alts, regexp.QuoteMeta(w))
+		}
+	}
+	f := &WordlistFilter{action: action}
+	if len(alts) == 0 {
+		return f
+	}
+	// Longest first, so a phrase wins over a word it starts with.
+	slices.SortFunc(alts, func(a, b string) int { return len(b) - len(a) })
+	f.re = regexp.MustCompile(`(?i)(?:` + strings.Join(alts, "|") + `)`)
+	return f
+}
+
+// Check implements [SafetyFilter].
+func (f *WordlistFilter) Check(_ context.Context, text string) (SafetyVerdict, error) {
+	matches := f.matches(text)
+	if len(matches) == 0 {
+		return SafetyVerdict{Action: SafetyAllow}, nil
+	}
+	v := SafetyVerdict{
+		Action: f.action,
+		Reason: fmt.Sprintf("contains listed word %q", strings.ToLower(text[matches[0][0]:matches[0][1]])),
+	}
+	if f.action == SafetyRedact {
+		var sb strings.Builder
+		last := 0
+		for _, m := range matches {
+			sb.WriteString(text[last:m[0]])
+			last = m[1]
+		}
+		sb.WriteString(text[last:])
+		v.Text = tidyRedaction(sb.String())
+	}
+	return v, nil
+}
+
+// matches returns the index pairs of the listed words in text that stand on
+// word boundaries.
+func (f *WordlistFilter) matches(text string) [][]int {
+	if f.re == nil {
+		return nil
+	}
+	all := f.re.FindAllStringIndex(text, -1)
+	return slices.DeleteFunc(all, func(m []int) bool {
+		before, _ := utf8.DecodeLastRuneInString(text[:m[0]])
+		after, _ := utf8.DecodeRuneInString(text[m[1]:])
+		return isWordRune(before) || isWordRune(after)
+	})
+}
+
+// spaceBeforePunct matches horizontal whitespace left in front of punctuation
+// by a removed word.
+var spaceBeforePunct = regexp.MustCompile(`[ \t]+([,.!?;:])`)
+
+// tidyRedaction cleans up the gaps left by removed words: runs of spaces are
+// collapsed, spaces before punctuation and punctuation at the start are
+// dropped.
+func tidyRedaction(s string) string {
+	s = multiSpace.ReplaceAllString(s, " ")
+	s = spaceBeforePunct.ReplaceAllString(s, "$1")
+	return strings.TrimSpace(strings.TrimLeft(s, " \t,;:"))
+}
+
+// isWordRune reports whether r is part of a word.
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsNumber(r))
+}
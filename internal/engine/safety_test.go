@@ -0,0 +1,183 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+func TestWordlistFilter(t *testing.T) {
+	t.Parallel()
+
+	words := []string{"blast", "  ", "Son of a goblin"}
+	tests := []struct {
+		name       string
+		action     engine.SafetyAction
+		text       string
+		wantAction engine.SafetyAction
+		wantText   string
+	}{
+		{
+			name:       "clean text allowed",
+			action:     engine.SafetyBlock,
+			text:       "Welcome to the Prancing Pony.",
+			wantAction: engine.SafetyAllow,
+		},
+		{
+			name:       "substring of a longer word allowed",
+			action:     engine.SafetyBlock,
+			text:       "The blasting powder is in the cellar.",
+			wantAction: engine.SafetyAllow,
+		},
+		{
+			name:       "word flagged case-insensitively",
+			action:     engine.SafetyBlock,
+			text:       "BLAST it all!",
+			wantAction: engine.SafetyBlock,
+		},
+		{
+			name:       "phrase flagged",
+			action:     engine.SafetyRegenerate,
+			text:       "You son of a goblin!",
+			wantAction: engine.SafetyRegenerate,
+		},
+		{
+			name:       "matches redacted",
+			action:     engine.SafetyRedact,
+			text:       "Blast, you son of a goblin!",
+			wantAction: engine.SafetyRedact,
+			wantText:   "you!",
+		},
+		{
+			name:       "non-ASCII letters are word characters",
+			action:     engine.SafetyBlock,
+			text:       "Seht, die Überblastung!",
+			wantAction: engine.SafetyAllow,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := engine.NewWordlistFilter(words, tc.action)
+			v, err := f.Check(context.Background(), tc.text)
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if v.Action != tc.wantAction {
+				t.Errorf("Action = %v, want %v", v.Action, tc.wantAction)
+			}
+			if v.Text != tc.wantText {
+				t.Errorf("Text = %q, want %q", v.Text, tc.wantText)
+			}
+			if v.Action != engine.SafetyAllow && v.Reason == "" {
+				t.Error("Reason is empty for a flagged text")
+			}
+		})
+	}
+}
+
+func TestNopSafetyFilter(t *testing.T) {
+	t.Parallel()
+
+	v, err := engine.NopSafetyFilter{}.Check(context.Background(), "anything at all")
+	if err != nil || v.Action != engine.SafetyAllow {
+		t.Errorf("Check = (%+v, %v), want allow", v, err)
+	}
+	if v, _ := engine.NewWordlistFilter(nil, engine.SafetyBlock).Check(context.Background(), "blast"); v.Action != engine.SafetyAllow {
+		t.Errorf("empty wordlist: Action = %v, want allow", v.Action)
+	}
+}
+
+// failingFilter is a SafetyFilter whose checks always fail.
+type failingFilter struct{}
+
+func (failingFilter) Check(context.Context, string) (engine.SafetyVerdict, error) {
+	return engine.SafetyVerdict{}, errors.New("moderation unavailable")
+}
+
+func TestScreen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		filter        engine.SafetyFilter
+		text          string
+		canRegenerate bool
+		wantText      string
+		wantAction    engine.SafetyAction
+	}{
+		{name: "clean text", filter: engine.NewWordlistFilter([]string{"blast"}, engine.SafetyBlock), text: "Welcome!", wantText: "Welcome!", wantAction: engine.SafetyAllow},
+		{name: "empty text", filter: failingFilter{}, wantAction: engine.SafetyAllow},
+		{name: "blocked", filter: engine.NewWordlistFilter([]string{"blast"}, engine.SafetyBlock), text: "Blast!", wantAction: engine.SafetyBlock},
+		{name: "redacted", filter: engine.NewWordlistFilter([]string{"blast"}, engine.SafetyRedact), text: "Blast, welcome!", wantText: "welcome!", wantAction: engine.SafetyAllow},
+		{name: "regenerated", filter: engine.NewWordlistFilter([]string{"blast"}, engine.SafetyRegenerate), text: "Blast!", canRegenerate: true, wantAction: engine.SafetyRegenerate},
+		{name: "regenerate downgraded", filter: engine.NewWordlistFilter([]string{"blast"}, engine.SafetyRegenerate), text: "Blast!", wantAction: engine.SafetyBlock},
+		{name: "filter error blocks", filter: failingFilter{}, text: "Welcome!", wantAction: engine.SafetyBlock},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			text, action := engine.Screen(context.Background(), tc.filter, "Grimjaw", tc.text, tc.canRegenerate)
+			if text != tc.wantText || action != tc.wantAction {
+				t.Errorf("Screen = (%q, %v), want (%q, %v)", text, action, tc.wantText, tc.wantAction)
+			}
+		})
+	}
+}
+
+func TestSafetyRetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		messages []llm.Message
+		spoken   []string
+		want     []llm.Message
+	}{
+		{
+			name:     "nothing spoken",
+			messages: []llm.Message{{Role: "user", Content: "Hi."}},
+			want:     []llm.Message{{Role: "user", Content: "Hi."}},
+		},
+		{
+			name:     "spoken becomes assistant prefix",
+			messages: []llm.Message{{Role: "user", Content: "Hi."}},
+			spoken:   []string{"Well met.", "Sit down."},
+			want:     []llm.Message{{Role: "user", Content: "Hi."}, {Role: "assistant", Content: "Well met. Sit down."}},
+		},
+		{
+			name:     "spoken extends existing prefix",
+			messages: []llm.Message{{Role: "user", Content: "Hi."}, {Role: "assistant", Content: "Well met."}},
+			spoken:   []string{"Sit down."},
+			want:     []llm.Message{{Role: "user", Content: "Hi."}, {Role: "assistant", Content: "Well met. Sit down."}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := llm.CompletionRequest{SystemPrompt: "You are Grimjaw.", Messages: tc.messages}
+			got := engine.SafetyRetry(req, tc.spoken, "Keep it clean.")
+			if got.SystemPrompt != "You are Grimjaw.\n\nKeep it clean." {
+				t.Errorf("SystemPrompt = %q", got.SystemPrompt)
+			}
+			if len(got.Messages) != len(tc.want) {
+				t.Fatalf("Messages = %+v, want %+v", got.Messages, tc.want)
+			}
+			for i := range tc.want {
+				if got.Messages[i].Role != tc.want[i].Role || got.Messages[i].Content != tc.want[i].Content {
+					t.Errorf("Messages[%d] = %+v, want %+v", i, got.Messages[i], tc.want[i])
+				}
+			}
+			if tc.messages[len(tc.messages)-1].Content != req.Messages[len(req.Messages)-1].Content {
+				t.Error("the original request was modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,564 @@
+// Package sentencecascade implements a single-model voice engine that
+// pipelines speech synthesis at the sentence level.
+//
+// Unlike the dual-model [cascade.Engine], which opens every reply with a fast
+// model and lets a strong model continue, the sentence cascade sends each turn
+// to one strong LLM. The reply is cut into sentences while it streams in, and
+// every complete sentence is handed to TTS immediately while the LLM keeps
+// generating, so the NPC starts speaking after its first sentence instead of
+// after the whole reply.
+//
+// # Pipeline
+//
+//  1. The prompt goes to the LLM together with the NPC's tools.
+//  2. As soon as the stream holds a complete sentence, it is post-processed,
+//     checked with the safety filter and sent to the TTS stream.
+//  3. Tool calls are executed between rounds and the model is asked again;
+//     the sentences of every round feed the same TTS stream, so the reply is
+//     heard as one utterance.
+//
+// [Engine.Process] returns once the first sentence has been handed to TTS;
+// the rest of the reply is generated and synthesised in the background.
+package sentencecascade
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/text/segment"
+)
+
+const (
+	// defaultTranscriptBuf is the default buffer depth of the transcript channel.
+	defaultTranscriptBuf = 32
+
+	// defaultSpeaker attributes transcript entries when no [WithSpeaker]
+	// option is given.
+	defaultSpeaker = "sentence_cascade"
+
+	// defaultTextBuf is the buffer depth of the text channel passed to TTS.
+	// It absorbs several sentences so the LLM stream is never held up by
+	// synthesis.
+	defaultTextBuf = 16
+
+	// defaultEmptyFallback is the line spoken when a reply ends without a
+	// single sentence to speak.
+	defaultEmptyFallback = "..."
+
+	// toolLimitInstruction is appended to the system prompt once the
+	// tool-call round limit is reached.
+	toolLimitInstruction = "You have reached the tool call limit for this turn. Answer the player now using the information you already have, without calling any more tools."
+
+	// safetyInstruction is appended to the system prompt when a sentence of
+	// the reply was rejected by the safety filter.
+	safetyInstruction = "Your previous reply contained content that is not allowed at this table. Continue in character without it."
+)
+
+// Engine implements [engine.VoiceEngine] with a single LLM whose reply is
+// synthesised sentence by sentence while it is generated.
+//
+// Engine is safe for concurrent use. Each [Engine.Process] call generates its
+// reply on its own goroutine; [Engine.Wait] blocks until all of them are done.
+type Engine struct {
+	llmP  llm.Provider
+	ttsP  tts.Provider
+	voice tts.VoiceProfile
+
+	// model overrides the model requested from the LLM provider. Empty means
+	// use the provider's default.
+	model string
+
+	transcriptBuf int
+
+	// ttsSampleRate and ttsChannels describe the PCM audio produced by the
+	// TTS provider. They default to [cascade.DefaultTTSSampleRate] mono.
+	ttsSampleRate int
+	ttsChannels   int
+
+	// errorAudio is played in place of a reply whose synthesis failed
+	// entirely, in the TTS format. Nil keeps such turns silent.
+	errorAudio []byte
+
+	// emptyFallback is spoken when a reply ends without any sentence.
+	emptyFallback string
+
+	// maxToolRounds caps the tool-call rounds per turn.
+	maxToolRounds int
+
+	// postProcessors transform every sentence before it is synthesised.
+	postProcessors engine.PostProcessors
+
+	// safety checks every sentence after post-processing and before it is
+	// synthesised.
+	safety engine.SafetyFilter
+
+	// segmenter finds the sentence ends of the streamed reply.
+	segmenter *segment.Segmenter
+
+	// speakerID and speakerName attribute the NPC transcript entries
+	// published on the Transcripts channel.
+	speakerID   string
+	speakerName string
+
+	mu            sync.Mutex
+	toolHandler   func(name, args string) (string, error)
+	tools         []llm.ToolDefinition
+	pendingUpdate *engine.ContextUpdate
+	transcriptCh  chan memory.TranscriptEntry
+	done          chan struct{}
+	closed        bool
+
+	// wg tracks the reply goroutines spawned by Process.
+	wg sync.WaitGroup
+}
+
+// Compile-time assertion that Engine satisfies the engine interface.
+var _ engine.VoiceEngine = (*Engine)(nil)
+
+// Option is a functional option for configuring an Engine during construction.
+type Option func(*Engine)
+
+// WithModel overrides the model requested from the LLM provider via
+// [llm.CompletionRequest.Model]. An empty model keeps the provider default.
+func WithModel(model string) Option {
+	return func(e *Engine) { e.model = model }
+}
+
+// WithTranscriptBuffer sets the buffer capacity of the transcript channel
+// returned by [Engine.Transcripts]. Default is 32.
+func WithTranscriptBuffer(n int) Option {
+	return func(e *Engine) { e.transcriptBuf = n }
+}
+
+// WithTTSFormat sets the format of the audio produced by the TTS provider.
+// sampleRate is in Hz and channels is 1 (mono) or 2 (stereo). If not called,
+// defaults are [cascade.DefaultTTSSampleRate] mono.
+func WithTTSFormat(sampleRate, channels int) Option {
+	return func(e *Engine) {
+		e.ttsSampleRate = sampleRate
+		e.ttsChannels = channels
+	}
+}
+
+// WithErrorAudio sets a clip that is played when synthesis of a reply fails
+// entirely: either the TTS stream cannot be started or it ends without
+// producing any audio. pcm must be in the format set by [WithTTSFormat]. A
+// failed start is then reported through [engine.Response.Err] instead of
+// an error from Process. Without it, such turns stay silent.
+func WithErrorAudio(pcm []byte) Option {
+	return func(e *Engine) { e.errorAudio = pcm }
+}
+
+// WithEmptyResponseFallback sets the line spoken when a reply ends without a
+// sentence to speak, because the model returned no text or its first
+// sentence was blocked by the safety filter. The default is "...". An empty s
+// keeps the default.
+func WithEmptyResponseFallback(s string) Option {
+	return func(e *Engine) {
+		if s != "" {
+			e.emptyFallback = s
+		}
+	}
+}
+
+// WithMaxToolRounds sets how many rounds of tool calls the model may issue
+// per turn. Once the limit is reached the model is asked again without tools
+// and instructed to answer with what it has. Values < 1 are ignored; the
+// default is [cascade.DefaultMaxToolRounds].
+func WithMaxToolRounds(n int) Option {
+	return func(e *Engine) {
+		if n > 0 {
+			e.maxToolRounds = n
+		}
+	}
+}
+
+// WithPostProcessors sets the text post-processors applied, in order, to each
+// sentence of the NPC's reply before it is synthesised.
+func WithPostProcessors(p ...engine.TextPostProcessor) Option {
+	return func(e *Engine) {
+		e.postProcessors = append(e.postProcessors, p...)
+	}
+}
+
+// WithSafetyFilter sets the filter every sentence of the NPC's reply is
+// checked with after post-processing and before synthesis. A sentence flagged
+// for regeneration is requested from the model once more, continuing after
+// the sentences already spoken; a blocked one ends the reply. A nil filter
+// keeps the default [engine.NopSafetyFilter].
+func WithSafetyFilter(f engine.SafetyFilter) Option {
+	return func(e *Engine) {
+		if f != nil {
+			e.safety = f
+		}
+	}
+}
+
+// WithSpeaker sets the NPC that the transcript entries published on
+// [Engine.Transcripts] are attributed to. name is used as both speaker and
+// NPC identifier. Defaults to "sentence_cascade".
+func WithSpeaker(name string) Option {
+	return func(e *Engine) {
+		if name != "" {
+			e.speakerID = name
+			e.speakerName = name
+		}
+	}
+}
+
+// WithSegmenter sets how the streamed reply is split into sentences, each
+// synthesised as soon as it is complete. Defaults to [segment.New], which
+// does not end a sentence after abbreviations such as "Dr.". A nil seg keeps
+// the default.
+func WithSegmenter(seg *segment.Segmenter) Option {
+	return func(e *Engine) {
+		if seg != nil {
+			e.segmenter = seg
+		}
+	}
+}
+
+// New constructs an Engine that answers with llmP and speaks with ttsP in
+// voice. Options are applied after the engine is initialised with its
+// defaults.
+func New(llmP llm.Provider, ttsP tts.Provider, voice tts.VoiceProfile, opts ...Option) *Engine {
+	e := &Engine{
+		llmP:          llmP,
+		ttsP:          ttsP,
+		voice:         voice,
+		transcriptBuf: defaultTranscriptBuf,
+		emptyFallback: defaultEmptyFallback,
+		maxToolRounds: cascade.DefaultMaxToolRounds,
+		speakerID:     defaultSpeaker,
+		speakerName:   defaultSpeaker,
+		safety:        engine.NopSafetyFilter{},
+		segmenter:     segment.New(),
+		done:          make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	if e.ttsSampleRate == 0 {
+		e.ttsSampleRate = cascade.DefaultTTSSampleRate
+	}
+	if e.ttsChannels == 0 {
+		e.ttsChannels = 1
+	}
+	e.transcriptCh = make(chan memory.TranscriptEntry, e.transcriptBuf)
+	return e
+}
+
+// ─── VoiceEngine interface ────────────────────────────────────────────────────
+
+// Process generates the NPC's reply to prompt and synthesises it sentence by
+// sentence. It applies any pending [engine.ContextUpdate] from a prior
+// [Engine.InjectContext] call first.
+//
+// Process returns as soon as the first sentence has been handed to TTS, or the
+// reply ended without one. The returned [engine.Response] carries that
+// sentence as its text; the remaining sentences are generated and
+// synthesised in the background and the complete reply is published on
+// [Engine.Transcripts]. Errors of the later stages are reported through
+// [engine.Response.Err].
+func (e *Engine) Process(ctx context.Context, _ audio.AudioFrame, prompt engine.PromptContext) (*engine.Response, error) {
+	start := time.Now()
+
+	// Apply and consume any pending context update atomically.
+	e.mu.Lock()
+	if e.pendingUpdate != nil {
+		prompt = engine.MergeContextUpdate(prompt, *e.pendingUpdate)
+		e.pendingUpdate = nil
+	}
+	tools := slices.Clone(e.tools)
+	e.mu.Unlock()
+
+	req := e.buildRequest(prompt, tools)
+	llmCh, err := e.llmP.StreamCompletion(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("sentence_cascade: LLM stream failed: %w", err)
+	}
+
+	textCh := make(chan string, defaultTextBuf)
+	voice := engine.VoiceFor(e.voice, prompt)
+	stream, err := e.ttsP.SynthesizeStream(ctx, textCh, voice)
+	if err != nil {
+		go engine.DrainChunks(llmCh)
+		return engine.TTSFailed(fmt.Errorf("sentence_cascade: TTS start failed: %w", err), e.errorAudio, e.ttsSampleRate, e.ttsChannels)
+	}
+
+	resp := &engine.Response{SampleRate: e.ttsSampleRate, Channels: e.ttsChannels}
+	resp.Audio = engine.GuardAudio(ctx, resp, stream, e.errorAudio, start)
+
+	r := &reply{textCh: textCh, first: make(chan string, 1), start: start}
+	e.wg.Go(func() {
+		e.generate(ctx, req, llmCh, r, resp)
+		if len(r.spoken) == 0 && ctx.Err() == nil {
+			slog.Warn("sentence_cascade: reply has no speakable text, using fallback line", "npc", e.speakerName, "fallback", e.emptyFallback)
+			r.send(ctx, e.emptyFallback)
+		}
+		r.finish()
+		// Recorded before textCh closes, so it is visible once Audio closes.
+		resp.SetLatency(engine.Latency{Opener: r.firstLatency, Total: time.Since(start)})
+		close(textCh)
+		engine.PublishTranscript(e.transcriptCh, e.done, e.speakerID, e.speakerName, strings.Join(r.spoken, " "), voice.Language, start)
+	})
+
+	resp.Text = <-r.first
+	return resp, nil
+}
+
+// InjectContext queues a context update to be merged on the next [Engine.Process]
+// call. It is non-blocking and safe to call concurrently.
+func (e *Engine) InjectContext(_ context.Context, update engine.ContextUpdate) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pendingUpdate = &update
+	return nil
+}
+
+// SetTools replaces the tool set offered to the model on the next
+// [Engine.Process] call. Pass a nil or empty slice to disable tool calling.
+func (e *Engine) SetTools(tools []llm.ToolDefinition) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(tools) == 0 {
+		e.tools = nil
+		return nil
+	}
+	e.tools = slices.Clone(tools)
+	return nil
+}
+
+// OnToolCall registers handler as the executor for LLM tool calls. Only the
+// most recently registered handler is active.
+func (e *Engine) OnToolCall(handler func(name string, args string) (string, error)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.toolHandler = handler
+}
+
+// Transcripts returns a read-only channel that emits [memory.TranscriptEntry]
+// values, one per reply. The channel is closed when the engine is closed.
+func (e *Engine) Transcripts() <-chan memory.TranscriptEntry {
+	return e.transcriptCh
+}
+
+// Close releases all resources held by the engine and closes the Transcripts
+// channel once all replies in flight have finished. Close is safe to call
+// multiple times; subsequent calls return nil.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	close(e.done)
+	e.mu.Unlock()
+
+	e.wg.Wait()
+	close(e.transcriptCh)
+	return nil
+}
+
+// Wait blocks until all replies started by [Engine.Process] have been fully
+// generated. This is primarily useful in tests to synchronise before
+// inspecting mock call records.
+func (e *Engine) Wait() {
+	e.wg.Wait()
+}
+
+// ─── Reply generation ─────────────────────────────────────────────────────────
+
+// reply tracks the sentences of one reply as they are handed to TTS.
+type reply struct {
+	textCh chan<- string
+	spoken []string
+
+	// first receives the first sentence sent to TTS, or the empty string if
+	// the reply ends without one. It is signalled exactly once.
+	first    chan string
+	signaled bool
+
+	start        time.Time
+	firstLatency time.Duration
+}
+
+// send hands sentence to TTS unless it is empty. It reports false if ctx was
+// cancelled first.
+func (r *reply) send(ctx context.Context, sentence string) bool {
+	if sentence == "" {
+		return true
+	}
+	r.spoken = append(r.spoken, sentence)
+	select {
+	case r.textCh <- sentence:
+	case <-ctx.Done():
+		return false
+	}
+	if !r.signaled {
+		r.signaled = true
+		r.firstLatency = time.Since(r.start)
+		r.first <- sentence
+	}
+	return true
+}
+
+// finish releases a Process call still waiting for the first sentence.
+func (r *reply) finish() {
+	if !r.signaled {
+		r.signaled = true
+		r.first <- ""
+	}
+}
+
+// generate streams the model's reply from ch into r and runs the tool-dispatch
+// loop: while the model responds with tool calls, they are executed via the
+// registered handler and the model is called again with the results
+// appended. After e.maxToolRounds rounds the model is called once more
+// without tools and told to answer. Errors are recorded via resp.
+//
+// A sentence flagged by the safety filter for regeneration is requested once
+// more per turn, continuing after the sentences already spoken; a blocked one
+// ends the reply.
+func (e *Engine) generate(ctx context.Context, req llm.CompletionRequest, ch <-chan llm.Chunk, r *reply, resp *engine.Response) {
+	regenerated := false
+	for round := 0; ; round++ {
+		if round > 0 {
+			if round == e.maxToolRounds {
+				slog.Warn("sentence_cascade: tool call limit reached, requesting final answer", "rounds", round)
+				req.Tools = nil
+				req.SystemPrompt += "\n\n" + toolLimitInstruction
+			}
+			var err error
+			if ch, err = e.llmP.StreamCompletion(ctx, req); err != nil {
+				resp.SetStreamErr(fmt.Errorf("sentence_cascade: LLM stream failed: %w", err))
+				return
+			}
+		}
+
+		roundStart := len(r.spoken)
+		turn, action := e.forwardSentences(ctx, ch, r, !regenerated)
+		if action == engine.SafetyRegenerate {
+			regenerated = true
+			req = engine.SafetyRetry(req, r.spoken[roundStart:], safetyInstruction)
+			retryCh, err := e.llmP.StreamCompletion(ctx, req)
+			if err != nil {
+				resp.SetStreamErr(fmt.Errorf("sentence_cascade: LLM regenerate failed: %w", err))
+				return
+			}
+			turn, action = e.forwardSentences(ctx, retryCh, r, false)
+		}
+		if action == engine.SafetyBlock {
+			return
+		}
+		if len(turn.ToolCalls) == 0 || len(req.Tools) == 0 || ctx.Err() != nil {
+			return
+		}
+
+		e.mu.Lock()
+		handler := e.toolHandler
+		e.mu.Unlock()
+		if handler == nil {
+			return
+		}
+		req.Messages = engine.AppendToolResults(req.Messages, turn, handler)
+	}
+}
+
+// forwardSentences reads token chunks from ch, cuts them into sentences with
+// the engine's segmenter (see [WithSegmenter]), and sends each post-processed sentence to TTS
+// through r as soon as it is complete. Text remaining when the stream ends is
+// flushed as a final fragment.
+//
+// It returns the stream as an assistant message: the raw text, the tool calls
+// requested, if any, and the reasoning that preceded them.
+//
+// Each sentence is checked with the safety filter before it is sent. When a
+// sentence is to be blocked or regenerated (only if canRegenerate is set),
+// forwarding stops, the rest of the stream is discarded, and the action is
+// returned along with a message without tool calls. Otherwise the action is
+// [engine.SafetyAllow].
+func (e *Engine) forwardSentences(ctx context.Context, ch <-chan llm.Chunk, r *reply, canRegenerate bool) (llm.Message, engine.SafetyAction) {
+	var buf, text strings.Builder
+	var runes engine.RuneJoiner
+	turn := llm.Message{Role: "assistant"}
+	action := engine.SafetyAllow
+	done := func() (llm.Message, engine.SafetyAction) {
+		turn.Content = text.String()
+		return turn, action
+	}
+	send := func(sentence string) bool {
+		sentence, action = engine.Screen(ctx, e.safety, e.speakerName, strings.TrimSpace(e.postProcessors.Apply(sentence)), canRegenerate)
+		if action != engine.SafetyAllow {
+			turn.ToolCalls = nil
+			go engine.DrainChunks(ch)
+			return false
+		}
+		return r.send(ctx, sentence)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return done()
+		case chunk, ok := <-ch:
+			if !ok {
+				send(buf.String())
+				return done()
+			}
+
+			if t := runes.Push(chunk.Text); t != "" {
+				buf.WriteString(t)
+				text.WriteString(t)
+			}
+			turn.ToolCalls = append(turn.ToolCalls, chunk.ToolCalls...)
+			turn.Thinking = append(turn.Thinking, chunk.Thinking...)
+
+			for {
+				s := buf.String()
+				idx := e.segmenter.Boundary(s)
+				if idx < 0 {
+					break
+				}
+				buf.Reset()
+				buf.WriteString(strings.TrimLeft(s[idx+1:], " \t\n\r"))
+				if !send(s[:idx+1]) {
+					return done()
+				}
+			}
+
+			if chunk.FinishReason != "" {
+				send(buf.String())
+				return done()
+			}
+		}
+	}
+}
+
+// ─── Internal helpers ─────────────────────────────────────────────────────────
+
+// buildRequest constructs the [llm.CompletionRequest] for prompt, offering
+// tools to the model.
+func (e *Engine) buildRequest(prompt engine.PromptContext, tools []llm.ToolDefinition) llm.CompletionRequest {
+	system := prompt.SystemPrompt
+	if prompt.HotContext != "" {
+		system += "\n\n" + prompt.HotContext
+	}
+	return llm.CompletionRequest{
+		SystemPrompt: system,
+		Messages:     slices.Clone(prompt.Messages),
+		Tools:        tools,
+		Model:        e.model,
+	}
+}
@@ -0,0 +1,408 @@
+package sentencecascade_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/internal/engine"
+	"github.com/MrWong99/glyphoxa/internal/engine/cascade"
+	sentencecascade "github.com/MrWong99/glyphoxa/internal/engine/sentence_cascade"
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	ttsmock "github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+	"github.com/MrWong99/glyphoxa/pkg/text/segment"
+)
+
+// ─── helpers ─────────────────────────────────────────────────────────────────
+
+// liveLLM is an LLM whose single stream is fed by the test through chunks.
+type liveLLM struct {
+	llmmock.Provider
+
+	chunks chan llm.Chunk
+}
+
+func (p *liveLLM) StreamCompletion(context.Context, llm.CompletionRequest) (<-chan llm.Chunk, error) {
+	return p.chunks, nil
+}
+
+// toolLoopLLM requests a tool on every response. Only once it is called
+// without tools does it also produce an answer.
+type toolLoopLLM struct {
+	llmmock.Provider
+
+	mu   sync.Mutex
+	reqs []llm.CompletionRequest
+}
+
+func (p *toolLoopLLM) StreamCompletion(_ context.Context, req llm.CompletionRequest) (<-chan llm.Chunk, error) {
+	p.mu.Lock()
+	p.reqs = append(p.reqs, req)
+	n := len(p.reqs)
+	p.mu.Unlock()
+
+	chunk := llm.Chunk{
+		ToolCalls:    []llm.ToolCall{{ID: fmt.Sprintf("call-%d", n), Name: "query_lore", Arguments: `{}`}},
+		FinishReason: "tool_calls",
+	}
+	if len(req.Tools) == 0 {
+		chunk.Text = "The vault lies beneath the chapel."
+		chunk.FinishReason = "stop"
+	}
+	ch := make(chan llm.Chunk, 1)
+	ch <- chunk
+	close(ch)
+	return ch, nil
+}
+
+// textRecorder is a TTS provider that records every sentence it receives.
+type textRecorder struct {
+	ttsmock.Provider
+
+	mu    sync.Mutex
+	texts []string
+}
+
+func (r *textRecorder) SynthesizeStream(_ context.Context, text <-chan string, _ tts.VoiceProfile) (*tts.Stream, error) {
+	out := make(chan []byte, 16)
+	go func() {
+		defer close(out)
+		for s := range text {
+			r.mu.Lock()
+			r.texts = append(r.texts, s)
+			r.mu.Unlock()
+			out <- []byte("audio")
+		}
+	}()
+	return tts.NewStream(out), nil
+}
+
+func (r *textRecorder) got() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.texts)
+}
+
+// drainAudio reads the audio channel to completion so engine goroutines are
+// not left blocked.
+func drainAudio(ch <-chan []byte) {
+	for range ch {
+	}
+}
+
+// process runs one turn with prompt text as the player's utterance and
+// waits for the whole reply.
+func process(t *testing.T, e *sentencecascade.Engine, text string) *engine.Response {
+	t.Helper()
+	resp, err := e.Process(context.Background(), audio.AudioFrame{}, engine.PromptContext{
+		SystemPrompt: "You are a lore keeper.",
+		Messages:     []llm.Message{{Role: "user", Content: text}},
+	})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	drainAudio(resp.Audio)
+	e.Wait()
+	return resp
+}
+
+// ─── tests ───────────────────────────────────────────────────────────────────
+
+func TestProcess_SynthesisesSentencesWhileGenerating(t *testing.T) {
+	t.Parallel()
+
+	model := &liveLLM{chunks: make(chan llm.Chunk)}
+	rec := &textRecorder{}
+	e := sentencecascade.New(model, rec, tts.VoiceProfile{}, sentencecascade.WithSpeaker("Greymantle"))
+	t.Cleanup(func() { _ = e.Close() })
+
+	go func() { model.chunks <- llm.Chunk{Text: "The vault is old. It lies"} }()
+	resp, err := e.Process(context.Background(), audio.AudioFrame{}, engine.PromptContext{SystemPrompt: "You are a lore keeper."})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if resp.Text != "The vault is old." {
+		t.Errorf("resp.Text = %q, want the first sentence", resp.Text)
+	}
+	if resp.SampleRate != cascade.DefaultTTSSampleRate || resp.Channels != 1 {
+		t.Errorf("format = %d Hz, %d channels; want %d Hz mono", resp.SampleRate, resp.Channels, cascade.DefaultTTSSampleRate)
+	}
+
+	// The first sentence reaches TTS while the model is still generating.
+	deadline := time.Now().Add(time.Second)
+	for len(rec.got()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("first sentence was not synthesised before the reply was complete")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	model.chunks <- llm.Chunk{Text: " beneath the chapel. Beware!", FinishReason: "stop"}
+	close(model.chunks)
+	drainAudio(resp.Audio)
+	e.Wait()
+
+	want := []string{"The vault is old.", "It lies beneath the chapel.", "Beware!"}
+	if got := rec.got(); !slices.Equal(got, want) {
+		t.Errorf("TTS texts = %q, want %q", got, want)
+	}
+	if err := resp.Err(); err != nil {
+		t.Errorf("resp.Err() = %v", err)
+	}
+	if l := resp.Latency(); l.Opener <= 0 || l.Total < l.Opener {
+		t.Errorf("latency = %+v, want first-sentence and total latency", l)
+	}
+
+	select {
+	case entry := <-e.Transcripts():
+		if entry.Text != strings.Join(want, " ") || entry.NPCID != "Greymantle" {
+			t.Errorf("transcript = %+v, want the whole reply by Greymantle", entry)
+		}
+	default:
+		t.Error("no transcript entry published")
+	}
+}
+
+func TestProcess_Segmenter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []sentencecascade.Option
+		want []string
+	}{
+		{name: "default keeps titles", want: []string{"Ask Dr. Smith.", "He knows."}},
+		{name: "simple", opts: []sentencecascade.Option{sentencecascade.WithSegmenter(segment.Simple())}, want: []string{"Ask Dr.", "Smith.", "He knows."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			model := &llmmock.Provider{StreamChunks: []llm.Chunk{
+				{Text: "Ask Dr. Smith. "},
+				{Text: "He knows.", FinishReason: "stop"},
+			}}
+			rec := &textRecorder{}
+			e := sentencecascade.New(model, rec, tts.VoiceProfile{}, tt.opts...)
+			t.Cleanup(func() { _ = e.Close() })
+
+			process(t, e, "Who can help?")
+			if got := rec.got(); !slices.Equal(got, tt.want) {
+				t.Errorf("TTS texts = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcess_Request(t *testing.T) {
+	t.Parallel()
+
+	model := &llmmock.Provider{StreamChunks: []llm.Chunk{{Text: "Aye.", FinishReason: "stop"}}}
+	e := sentencecascade.New(model, &textRecorder{}, tts.VoiceProfile{}, sentencecascade.WithModel("big-model"))
+	t.Cleanup(func() { _ = e.Close() })
+	if err := e.SetTools([]llm.ToolDefinition{{Name: "query_lore"}}); err != nil {
+		t.Fatalf("SetTools: %v", err)
+	}
+	if err := e.InjectContext(context.Background(), engine.ContextUpdate{
+		Scene:            "The library is dark.",
+		RecentUtterances: []memory.TranscriptEntry{{SpeakerName: "Bard", Text: "Hello."}},
+	}); err != nil {
+		t.Fatalf("InjectContext: %v", err)
+	}
+
+	process(t, e, "Where is the vault?")
+
+	if len(model.StreamCalls) != 1 {
+		t.Fatalf("StreamCompletion calls = %d, want 1", len(model.StreamCalls))
+	}
+	req := model.StreamCalls[0].Req
+	if req.Model != "big-model" {
+		t.Errorf("Model = %q, want big-model", req.Model)
+	}
+	if req.SystemPrompt != "You are a lore keeper.\n\nThe library is dark." {
+		t.Errorf("SystemPrompt = %q, want persona and scene", req.SystemPrompt)
+	}
+	if len(req.Tools) != 1 {
+		t.Errorf("Tools = %v, want query_lore", req.Tools)
+	}
+	if n := len(req.Messages); n != 2 || req.Messages[1].Content != "Hello." {
+		t.Errorf("Messages = %+v, want the utterance followed by the injected one", req.Messages)
+	}
+}
+
+func TestProcess_ToolRoundLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		opts      []sentencecascade.Option
+		wantCalls int
+	}{
+		{name: "default limit", wantCalls: cascade.DefaultMaxToolRounds},
+		{name: "custom limit", opts: []sentencecascade.Option{sentencecascade.WithMaxToolRounds(1)}, wantCalls: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			model := &toolLoopLLM{}
+			rec := &textRecorder{}
+			e := sentencecascade.New(model, rec, tts.VoiceProfile{}, tc.opts...)
+			t.Cleanup(func() { _ = e.Close() })
+			if err := e.SetTools([]llm.ToolDefinition{{Name: "query_lore"}}); err != nil {
+				t.Fatalf("SetTools: %v", err)
+			}
+			var handled atomic.Int32
+			e.OnToolCall(func(name, args string) (string, error) {
+				handled.Add(1)
+				return `{"result": "nothing found"}`, nil
+			})
+
+			resp := process(t, e, "Where is the vault?")
+
+			if got := int(handled.Load()); got != tc.wantCalls {
+				t.Errorf("tool handler calls = %d, want %d", got, tc.wantCalls)
+			}
+			model.mu.Lock()
+			reqs := model.reqs
+			model.mu.Unlock()
+			if len(reqs) != tc.wantCalls+1 {
+				t.Fatalf("model calls = %d, want %d", len(reqs), tc.wantCalls+1)
+			}
+			final := reqs[tc.wantCalls]
+			if len(final.Tools) != 0 || !strings.Contains(final.SystemPrompt, "tool call limit") {
+				t.Errorf("final call: tools %d, system prompt %q; want no tools and the limit instruction", len(final.Tools), final.SystemPrompt)
+			}
+			if want := "The vault lies beneath the chapel."; resp.Text != want || !slices.Equal(rec.got(), []string{want}) {
+				t.Errorf("reply = %q, TTS = %q; want %q", resp.Text, rec.got(), want)
+			}
+		})
+	}
+}
+
+func TestProcess_EmptyReplyFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		chunks []llm.Chunk
+		opts   []sentencecascade.Option
+		want   string
+	}{
+		{name: "no text", chunks: []llm.Chunk{{FinishReason: "stop"}}, want: "..."},
+		{name: "whitespace", chunks: []llm.Chunk{{Text: "  \n", FinishReason: "stop"}}, want: "..."},
+		{
+			name:   "blocked first sentence",
+			chunks: []llm.Chunk{{Text: "Curses upon you. Leave!", FinishReason: "stop"}},
+			opts: []sentencecascade.Option{
+				sentencecascade.WithSafetyFilter(engine.NewWordlistFilter([]string{"curses"}, engine.SafetyBlock)),
+				sentencecascade.WithEmptyResponseFallback("Hmm."),
+			},
+			want: "Hmm.",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := &textRecorder{}
+			e := sentencecascade.New(&llmmock.Provider{StreamChunks: tc.chunks}, rec, tts.VoiceProfile{}, tc.opts...)
+			t.Cleanup(func() { _ = e.Close() })
+
+			resp := process(t, e, "Hello?")
+			if resp.Text != tc.want || !slices.Equal(rec.got(), []string{tc.want}) {
+				t.Errorf("reply = %q, TTS = %q; want %q", resp.Text, rec.got(), tc.want)
+			}
+		})
+	}
+}
+
+func TestProcess_SafetyFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		action engine.SafetyAction
+		want   []string
+	}{
+		{name: "block ends the reply", action: engine.SafetyBlock, want: []string{"Welcome."}},
+		{name: "redact", action: engine.SafetyRedact, want: []string{"Welcome.", "The is here.", "Sit."}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			model := &llmmock.Provider{StreamChunks: []llm.Chunk{{Text: "Welcome. The dragon is here. Sit.", FinishReason: "stop"}}}
+			rec := &textRecorder{}
+			e := sentencecascade.New(model, rec, tts.VoiceProfile{},
+				sentencecascade.WithSafetyFilter(engine.NewWordlistFilter([]string{"dragon"}, tc.action)),
+			)
+			t.Cleanup(func() { _ = e.Close() })
+
+			process(t, e, "Hello?")
+			if got := rec.got(); !slices.Equal(got, tc.want) {
+				t.Errorf("TTS texts = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProcess_Errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("LLM", func(t *testing.T) {
+		t.Parallel()
+		e := sentencecascade.New(&llmmock.Provider{StreamErr: errors.New("offline")}, &textRecorder{}, tts.VoiceProfile{})
+		t.Cleanup(func() { _ = e.Close() })
+		if _, err := e.Process(context.Background(), audio.AudioFrame{}, engine.PromptContext{}); err == nil {
+			t.Error("Process succeeded, want the LLM error")
+		}
+	})
+
+	t.Run("TTS with error audio", func(t *testing.T) {
+		t.Parallel()
+		model := &llmmock.Provider{StreamChunks: []llm.Chunk{{Text: "Aye.", FinishReason: "stop"}}}
+		ttsProv := &ttsmock.Provider{SynthesizeErr: errors.New("offline")}
+		e := sentencecascade.New(model, ttsProv, tts.VoiceProfile{}, sentencecascade.WithErrorAudio([]byte("chime")))
+		t.Cleanup(func() { _ = e.Close() })
+		resp, err := e.Process(context.Background(), audio.AudioFrame{}, engine.PromptContext{})
+		if err != nil {
+			t.Fatalf("Process: %v", err)
+		}
+		var got []string
+		for chunk := range resp.Audio {
+			got = append(got, string(chunk))
+		}
+		if !slices.Equal(got, []string{"chime"}) || resp.Err() == nil {
+			t.Errorf("audio = %q, err = %v; want the error audio and the TTS error", got, resp.Err())
+		}
+	})
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+
+	e := sentencecascade.New(&llmmock.Provider{}, &textRecorder{}, tts.VoiceProfile{})
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if _, ok := <-e.Transcripts(); ok {
+		t.Error("Transcripts channel still open after Close")
+	}
+}
@@ -2,11 +2,11 @@ package entity
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
 	"slices"
 	"sync"
+
+	"github.com/MrWong99/glyphoxa/pkg/idgen"
 )
 
 // Compile-time assertion that MemStore satisfies the Store interface.
@@ -18,23 +18,39 @@ var _ Store = (*MemStore)(nil)
 type MemStore struct {
 	mu       sync.RWMutex
 	entities map[string]EntityDefinition
+	ids      idgen.Generator // nil means idgen.Default
+}
+
+// MemStoreOption configures a [MemStore].
+type MemStoreOption func(*MemStore)
+
+// WithIDGenerator sets the generator that assigns IDs to entities added
+// without one. Defaults to [idgen.Default].
+func WithIDGenerator(g idgen.Generator) MemStoreOption {
+	return func(s *MemStore) {
+		s.ids = g
+	}
 }
 
 // NewMemStore returns an initialised [MemStore].
-func NewMemStore() *MemStore {
-	return &MemStore{
+func NewMemStore(opts ...MemStoreOption) *MemStore {
+	s := &MemStore{
 		entities: make(map[string]EntityDefinition),
 	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
 }
 
 // Add implements [Store.Add].
 func (s *MemStore) Add(ctx context.Context, entity EntityDefinition) (EntityDefinition, error) {
 	if entity.ID == "" {
-		id, err := generateID()
-		if err != nil {
-			return EntityDefinition{}, fmt.Errorf("entity: generate id: %w", err)
+		if s.ids != nil {
+			entity.ID = s.ids.NewID()
+		} else {
+			entity.ID = idgen.New()
 		}
-		entity.ID = id
 	}
 
 	s.mu.Lock()
@@ -123,16 +139,6 @@ func (s *MemStore) BulkImport(ctx context.Context, entities []EntityDefinition)
 // Helpers
 // ─────────────────────────────────────────────────────────────────────────────
 
-// generateID produces a random 16-byte hex string using crypto/rand.
-// The resulting string is 32 hex characters and is statistically unique.
-func generateID() (string, error) {
-	buf := make([]byte, 16)
-	if _, err := rand.Read(buf); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(buf), nil
-}
-
 // matchesOpts reports whether e satisfies all conditions in opts.
 func matchesOpts(e EntityDefinition, opts ListOptions) bool {
 	if opts.Type != "" && e.Type != opts.Type {
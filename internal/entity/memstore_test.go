@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/MrWong99/glyphoxa/internal/entity"
+	"github.com/MrWong99/glyphoxa/pkg/idgen"
 )
 
 func TestAdd(t *testing.T) {
@@ -27,6 +28,18 @@ func TestAdd(t *testing.T) {
 		}
 	})
 
+	t.Run("with empty ID uses the ID generator", func(t *testing.T) {
+		t.Parallel()
+		s := entity.NewMemStore(entity.WithIDGenerator(idgen.Func(func() string { return "npc-generated" })))
+		got, err := s.Add(ctx, entity.EntityDefinition{Name: "Radagast", Type: entity.EntityNPC})
+		if err != nil {
+			t.Fatalf("Add: unexpected error: %v", err)
+		}
+		if got.ID != "npc-generated" {
+			t.Fatalf("Add: expected ID %q, got %q", "npc-generated", got.ID)
+		}
+	})
+
 	t.Run("with explicit ID is preserved", func(t *testing.T) {
 		t.Parallel()
 		s := entity.NewMemStore()
@@ -2,12 +2,14 @@
 //
 // The package exposes two endpoints:
 //
-//   - /healthz — liveness probe; always returns 200 OK.
+//   - /healthz — liveness probe; always returns 200 OK, along with the
+//     values of any registered [Detail] reporters.
 //   - /readyz  — readiness probe; returns 200 only when all registered
 //     [Checker] functions pass.
 //
 // Responses are JSON objects with a top-level "status" field ("ok" or "fail")
-// and a "checks" map containing the result of each named checker.
+// and a "checks" map containing the result of each named checker. /healthz
+// responses carry a "details" map instead.
 package health
 
 import (
@@ -33,16 +35,29 @@ type Checker struct {
 	Check func(ctx context.Context) error
 }
 
+// Detail is a named piece of status information reported by /healthz, such
+// as resource usage. Unlike a [Checker] it never fails the probe.
+type Detail struct {
+	// Name is the key of the value in the "details" map.
+	Name string
+
+	// Value returns the current value. It must be JSON-encodable, safe for
+	// concurrent use, and fast.
+	Value func() any
+}
+
 // result is the JSON response body for health endpoints.
 type result struct {
-	Status string            `json:"status"`
-	Checks map[string]string `json:"checks,omitempty"`
+	Status  string            `json:"status"`
+	Checks  map[string]string `json:"checks,omitempty"`
+	Details map[string]any    `json:"details,omitempty"`
 }
 
 // Handler serves /healthz and /readyz endpoints. It is safe for concurrent
-// use; the checker list is fixed at construction time.
+// use; the checker and detail lists are fixed before it serves requests.
 type Handler struct {
 	checkers []Checker
+	details  []Detail
 }
 
 // New creates a [Handler] that evaluates the given checkers on each /readyz
@@ -53,10 +68,25 @@ func New(checkers ...Checker) *Handler {
 	return &Handler{checkers: c}
 }
 
+// WithDetails adds details reported by /healthz and returns h. It must be
+// called before the handler serves requests.
+func (h *Handler) WithDetails(details ...Detail) *Handler {
+	h.details = append(h.details, details...)
+	return h
+}
+
 // Healthz is a liveness probe that always returns 200 OK. A running process
-// that can serve HTTP is considered alive.
+// that can serve HTTP is considered alive. The response carries the current
+// value of every registered [Detail].
 func (h *Handler) Healthz(w http.ResponseWriter, _ *http.Request) {
-	writeJSON(w, http.StatusOK, result{Status: "ok"})
+	res := result{Status: "ok"}
+	if len(h.details) > 0 {
+		res.Details = make(map[string]any, len(h.details))
+		for _, d := range h.details {
+			res.Details[d.Name] = d.Value()
+		}
+	}
+	writeJSON(w, http.StatusOK, res)
 }
 
 // Readyz is a readiness probe that returns 200 only when every registered
@@ -41,6 +41,32 @@ func TestHealthz_ContentType(t *testing.T) {
 	}
 }
 
+func TestHealthz_Details(t *testing.T) {
+	active := 2
+	h := New().WithDetails(Detail{
+		Name:  "engines",
+		Value: func() any { return map[string]int{"active": active, "max": 8} },
+	})
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	rec := httptest.NewRecorder()
+	h.Healthz(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var body struct {
+		Status  string                    `json:"status"`
+		Details map[string]map[string]int `json:"details"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode JSON: %v", err)
+	}
+	if got := body.Details["engines"]; got["active"] != 2 || got["max"] != 8 {
+		t.Errorf("details.engines = %v, want active 2 and max 8", got)
+	}
+}
+
 func TestReadyz_AllCheckersPass(t *testing.T) {
 	h := New(
 		Checker{Name: "database", Check: func(_ context.Context) error { return nil }},
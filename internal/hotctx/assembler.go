@@ -20,6 +20,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
 )
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -43,6 +44,35 @@ type HotContext struct {
 	// were injected before assembly (e.g., from [PreFetcher]).
 	PreFetchResults []memory.ContextResult
 
+	// Knowledge contains cold-layer passages retrieved for the current
+	// utterance, weighted by graph distance (see [Assembler.Retrieve]).
+	// Assemble leaves it empty; callers fill it in.
+	Knowledge []memory.ContextResult
+
+	// StyleExemplars holds lines the NPC spoke earlier, possibly in past
+	// sessions, rendered as references for its speaking style rather than as
+	// conversation (see [Assembler.StyleExemplars]). Assemble leaves it empty;
+	// callers fill it in.
+	StyleExemplars []memory.TranscriptEntry
+
+	// SceneRecap is a one-paragraph summary of the scene so far, covering
+	// more than the verbatim recent turns. Assemble leaves it empty; callers
+	// fill it in.
+	SceneRecap string
+
+	// Mood is a short description of how the NPC currently feels. Assemble
+	// leaves it empty; callers fill it in.
+	Mood string
+
+	// Vocabulary lists the campaign's proper nouns, rendered as a spelling
+	// reference (see [WithVocabulary]).
+	Vocabulary []string
+
+	// Rules are hard constraints on the NPC's responses, rendered as a
+	// numbered list at the end of the prompt. Assemble leaves it empty;
+	// callers fill it in from the NPC's behaviour rules.
+	Rules []string
+
 	// AssemblyDuration records how long [Assembler.Assemble] took.
 	AssemblyDuration time.Duration
 }
@@ -72,6 +102,10 @@ type Assembler struct {
 	graph          memory.KnowledgeGraph
 	recentDuration time.Duration
 	maxEntries     int
+	awarenessDecay float64
+	retrievalTopK  int
+	embedder       embeddings.Provider
+	vocabulary     func() []string
 }
 
 // Option is a functional option for [NewAssembler].
@@ -90,6 +124,12 @@ func WithMaxTranscriptEntries(n int) Option {
 	return func(a *Assembler) { a.maxEntries = n }
 }
 
+// WithVocabulary sets the source of [HotContext.Vocabulary]. terms is called
+// on every [Assembler.Assemble], so the vocabulary may change between turns.
+func WithVocabulary(terms func() []string) Option {
+	return func(a *Assembler) { a.vocabulary = terms }
+}
+
 // NewAssembler creates an [Assembler] with sensible defaults.
 // Apply [Option] values to override the defaults.
 func NewAssembler(sessionStore memory.SessionStore, graph memory.KnowledgeGraph, opts ...Option) *Assembler {
@@ -98,6 +138,8 @@ func NewAssembler(sessionStore memory.SessionStore, graph memory.KnowledgeGraph,
 		graph:          graph,
 		recentDuration: 5 * time.Minute,
 		maxEntries:     50,
+		awarenessDecay: DefaultAwarenessDecay,
+		retrievalTopK:  defaultRetrievalTopK,
 	}
 	for _, o := range opts {
 		o(a)
@@ -162,12 +204,16 @@ func (a *Assembler) Assemble(ctx context.Context, npcID string, sessionID string
 		return nil, err
 	}
 
-	return &HotContext{
+	hctx := &HotContext{
 		Identity:         identity,
 		RecentTranscript: transcript,
 		SceneContext:     scene,
-		AssemblyDuration: time.Since(start),
-	}, nil
+	}
+	if a.vocabulary != nil {
+		hctx.Vocabulary = a.vocabulary()
+	}
+	hctx.AssemblyDuration = time.Since(start)
+	return hctx, nil
 }
 
 // buildSceneContext builds scene context for npcID by:
@@ -271,3 +271,28 @@ func TestAssemble_WithOptions(t *testing.T) {
 		t.Error("GetRecent was not called with WithRecentDuration(10min)")
 	}
 }
+
+// TestAssemble_Vocabulary verifies that the vocabulary source is read on every
+// assembly, so terms added between turns reach the next prompt.
+func TestAssemble_Vocabulary(t *testing.T) {
+	kg := &mock.KnowledgeGraph{IdentitySnapshotResult: makeIdentity("npc-1", "Grimjaw")}
+	terms := []string{"Eldrinax"}
+	a := hotctx.NewAssembler(&mock.SessionStore{}, kg, hotctx.WithVocabulary(func() []string { return terms }))
+
+	hctx, err := a.Assemble(context.Background(), "npc-1", "session-abc")
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	if len(hctx.Vocabulary) != 1 || hctx.Vocabulary[0] != "Eldrinax" {
+		t.Errorf("Vocabulary = %q, want [Eldrinax]", hctx.Vocabulary)
+	}
+
+	terms = append(terms, "Thornwick")
+	hctx, err = a.Assemble(context.Background(), "npc-1", "session-abc")
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+	if len(hctx.Vocabulary) != 2 {
+		t.Errorf("Vocabulary after adding a term = %q, want 2 terms", hctx.Vocabulary)
+	}
+}
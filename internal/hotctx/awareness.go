@@ -0,0 +1,150 @@
+package hotctx
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
+)
+
+// DefaultAwarenessDecay is the factor by which the score of a retrieved chunk
+// is multiplied for every hop between the NPC and the chunk's entity.
+const DefaultAwarenessDecay = 0.5
+
+// defaultRetrievalTopK caps the number of chunks returned by
+// [Assembler.Retrieve] unless overridden with [WithRetrievalTopK].
+const defaultRetrievalTopK = 5
+
+// embeddingCandidates is the number of vector search candidates fetched per
+// returned chunk. Distance weighting reorders the candidates, so more are
+// fetched than kept.
+const embeddingCandidates = 4
+
+// WithAwarenessDecay sets the per-hop weight applied by [Assembler.Retrieve]:
+// a chunk about an entity n hops away from the NPC has its score multiplied by
+// decay^n. Values outside (0, 1] are ignored. Defaults to
+// [DefaultAwarenessDecay].
+func WithAwarenessDecay(decay float64) Option {
+	return func(a *Assembler) {
+		if decay > 0 && decay <= 1 {
+			a.awarenessDecay = decay
+		}
+	}
+}
+
+// WithRetrievalTopK caps the number of chunks returned by
+// [Assembler.Retrieve]. Defaults to 5.
+func WithRetrievalTopK(k int) Option {
+	return func(a *Assembler) {
+		if k > 0 {
+			a.retrievalTopK = k
+		}
+	}
+}
+
+// WithEmbeddings makes [Assembler.Retrieve] search by vector similarity to an
+// embedding of the query instead of by full-text search. When p fails to embed
+// a query, that retrieval falls back to full-text search.
+func WithEmbeddings(p embeddings.Provider) Option {
+	return func(a *Assembler) { a.embedder = p }
+}
+
+// AwarenessScope returns the entities within radius hops of npcID in the
+// knowledge graph, mapped to their shortest hop distance. The NPC itself is
+// included at distance 0. A radius of zero or less yields only the NPC.
+//
+// The neighbourhood is expanded one hop at a time via
+// [memory.KnowledgeGraph.Neighbors] and stops early once a hop adds no new
+// entities.
+func (a *Assembler) AwarenessScope(ctx context.Context, npcID string, radius int) (map[string]int, error) {
+	scope := map[string]int{npcID: 0}
+	for depth := 1; depth <= radius; depth++ {
+		neighbours, err := a.graph.Neighbors(ctx, npcID, depth)
+		if err != nil {
+			return nil, fmt.Errorf("hot context: neighbours of %q at depth %d: %w", npcID, depth, err)
+		}
+		added := false
+		for _, e := range neighbours {
+			if _, ok := scope[e.ID]; !ok {
+				scope[e.ID] = depth
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	return scope, nil
+}
+
+// Retrieve queries the cold layer for chunks relevant to query, restricted to
+// the entities within radius hops of npcID (see [Assembler.AwarenessScope]).
+// Each chunk's score is multiplied by decay^hops of its entity, so an NPC
+// knows more about what is close to it than about distant parts of the world.
+// Results are sorted by weighted score, highest first, and capped at the
+// configured top-K.
+//
+// With [WithEmbeddings], chunks are found by vector similarity. If the query
+// cannot be embedded, for example because the embeddings provider went down
+// mid-session, Retrieve logs a warning and uses full-text search instead.
+//
+// Retrieve returns nil without querying when radius is zero or less, or when
+// the knowledge graph does not implement [memory.GraphRAGQuerier].
+func (a *Assembler) Retrieve(ctx context.Context, npcID, query string, radius int) ([]memory.ContextResult, error) {
+	rag, ok := a.graph.(memory.GraphRAGQuerier)
+	if !ok || radius <= 0 {
+		return nil, nil
+	}
+
+	scope, err := a.AwarenessScope(ctx, npcID, radius)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(scope))
+	for id := range scope {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	results, err := a.query(ctx, rag, npcID, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("hot context: retrieve for %q: %w", npcID, err)
+	}
+
+	weighted := results[:0]
+	for _, r := range results {
+		hops, ok := scope[r.Entity.ID]
+		if !ok {
+			continue // outside the awareness radius
+		}
+		r.Score *= math.Pow(a.awarenessDecay, float64(hops))
+		weighted = append(weighted, r)
+	}
+	slices.SortStableFunc(weighted, func(x, y memory.ContextResult) int {
+		return cmp.Compare(y.Score, x.Score)
+	})
+	if len(weighted) > a.retrievalTopK {
+		weighted = weighted[:a.retrievalTopK]
+	}
+	return weighted, nil
+}
+
+// query searches the chunks about the entities in scope, by vector similarity
+// when an embeddings provider is configured and by full-text search otherwise.
+func (a *Assembler) query(ctx context.Context, rag memory.GraphRAGQuerier, npcID, query string, scope []string) ([]memory.ContextResult, error) {
+	if a.embedder == nil {
+		return rag.QueryWithContext(ctx, query, scope)
+	}
+	vec, err := a.embedder.Embed(ctx, query)
+	if err != nil {
+		slog.Warn("hot context: embedding query failed, falling back to full-text search",
+			"npc", npcID, "model", a.embedder.ModelID(), "err", err)
+		return rag.QueryWithContext(ctx, query, scope)
+	}
+	return rag.QueryWithEmbedding(ctx, vec, a.retrievalTopK*embeddingCandidates, scope)
+}
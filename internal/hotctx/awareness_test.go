@@ -0,0 +1,246 @@
+package hotctx_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/internal/hotctx"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/memory/mock"
+	embeddingsmock "github.com/MrWong99/glyphoxa/pkg/provider/embeddings/mock"
+)
+
+// ringGraph is a GraphRAG mock whose Neighbors answer depends on the depth:
+// rings[d-1] holds the entities first reached at d hops from the NPC.
+type ringGraph struct {
+	*mock.GraphRAGQuerier
+	rings [][]memory.Entity
+}
+
+func (g *ringGraph) Neighbors(_ context.Context, _ string, depth int, _ ...memory.TraversalOpt) ([]memory.Entity, error) {
+	var out []memory.Entity
+	for d := 0; d < depth && d < len(g.rings); d++ {
+		out = append(out, g.rings[d]...)
+	}
+	return out, nil
+}
+
+// newRingGraph builds a graph with the tavern one hop, the town two hops and
+// the capital three hops away from npc-1.
+func newRingGraph() *ringGraph {
+	return &ringGraph{
+		GraphRAGQuerier: &mock.GraphRAGQuerier{},
+		rings: [][]memory.Entity{
+			{{ID: "tavern", Name: "Tavern"}},
+			{{ID: "town", Name: "Town"}},
+			{{ID: "capital", Name: "Capital"}},
+		},
+	}
+}
+
+func TestAwarenessScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		radius int
+		want   map[string]int
+	}{
+		{name: "zero radius", radius: 0, want: map[string]int{"npc-1": 0}},
+		{name: "one hop", radius: 1, want: map[string]int{"npc-1": 0, "tavern": 1}},
+		{name: "two hops", radius: 2, want: map[string]int{"npc-1": 0, "tavern": 1, "town": 2}},
+		{name: "beyond graph", radius: 10, want: map[string]int{"npc-1": 0, "tavern": 1, "town": 2, "capital": 3}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := hotctx.NewAssembler(&mock.SessionStore{}, newRingGraph())
+			got, err := a.AwarenessScope(context.Background(), "npc-1", tc.radius)
+			if err != nil {
+				t.Fatalf("AwarenessScope: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("scope = %v, want %v", got, tc.want)
+			}
+			for id, hops := range tc.want {
+				if got[id] != hops {
+					t.Errorf("scope[%q] = %d, want %d", id, got[id], hops)
+				}
+			}
+		})
+	}
+}
+
+// TestRetrieve_WeightsByDistance verifies that chunks about closer entities
+// are up-weighted relative to chunks about farther ones.
+func TestRetrieve_WeightsByDistance(t *testing.T) {
+	t.Parallel()
+
+	g := newRingGraph()
+	// Raw scores favour the farther entities.
+	g.QueryWithContextResult = []memory.ContextResult{
+		{Entity: memory.Entity{ID: "town"}, Content: "town gossip", Score: 0.9},
+		{Entity: memory.Entity{ID: "tavern"}, Content: "tavern gossip", Score: 0.6},
+		{Entity: memory.Entity{ID: "npc-1"}, Content: "own history", Score: 0.4},
+	}
+
+	a := hotctx.NewAssembler(&mock.SessionStore{}, g)
+	got, err := a.Retrieve(context.Background(), "npc-1", "any news?", 2)
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+
+	// 0.4·1 = 0.4, 0.6·0.5 = 0.3, 0.9·0.25 = 0.225
+	wantOrder := []string{"npc-1", "tavern", "town"}
+	wantScores := []float64{0.4, 0.3, 0.225}
+	if len(got) != len(wantOrder) {
+		t.Fatalf("got %d results, want %d", len(got), len(wantOrder))
+	}
+	for i, r := range got {
+		if r.Entity.ID != wantOrder[i] {
+			t.Errorf("result[%d] = %q, want %q", i, r.Entity.ID, wantOrder[i])
+		}
+		if math.Abs(r.Score-wantScores[i]) > 1e-9 {
+			t.Errorf("result[%d] score = %v, want %v", i, r.Score, wantScores[i])
+		}
+	}
+
+	if n := g.CallCount("QueryWithContext"); n != 1 {
+		t.Fatalf("QueryWithContext called %d times, want 1", n)
+	}
+	var scope []string
+	for _, c := range g.Calls() {
+		if c.Method == "QueryWithContext" {
+			scope = c.Args[1].([]string)
+		}
+	}
+	if want := []string{"npc-1", "tavern", "town"}; !slices.Equal(scope, want) {
+		t.Errorf("graph scope = %v, want %v", scope, want)
+	}
+}
+
+func TestRetrieve_Options(t *testing.T) {
+	t.Parallel()
+
+	g := newRingGraph()
+	g.QueryWithContextResult = []memory.ContextResult{
+		{Entity: memory.Entity{ID: "tavern"}, Score: 1},
+		{Entity: memory.Entity{ID: "town"}, Score: 1},
+		{Entity: memory.Entity{ID: "capital"}, Score: 1},
+	}
+
+	a := hotctx.NewAssembler(&mock.SessionStore{}, g,
+		hotctx.WithAwarenessDecay(0.8),
+		hotctx.WithRetrievalTopK(2),
+	)
+	got, err := a.Retrieve(context.Background(), "npc-1", "q", 3)
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want 2", len(got))
+	}
+	if got[0].Entity.ID != "tavern" || math.Abs(got[0].Score-0.8) > 1e-9 {
+		t.Errorf("result[0] = %q (%v), want tavern (0.8)", got[0].Entity.ID, got[0].Score)
+	}
+	if got[1].Entity.ID != "town" || math.Abs(got[1].Score-0.64) > 1e-9 {
+		t.Errorf("result[1] = %q (%v), want town (0.64)", got[1].Entity.ID, got[1].Score)
+	}
+}
+
+func TestRetrieve_Disabled(t *testing.T) {
+	t.Parallel()
+
+	t.Run("zero radius", func(t *testing.T) {
+		t.Parallel()
+
+		g := newRingGraph()
+		a := hotctx.NewAssembler(&mock.SessionStore{}, g)
+		got, err := a.Retrieve(context.Background(), "npc-1", "q", 0)
+		if err != nil || got != nil {
+			t.Errorf("Retrieve = %v, %v; want nil, nil", got, err)
+		}
+		if n := g.CallCount("QueryWithContext"); n != 0 {
+			t.Errorf("QueryWithContext called %d times, want 0", n)
+		}
+	})
+
+	t.Run("graph without GraphRAG", func(t *testing.T) {
+		t.Parallel()
+
+		a := hotctx.NewAssembler(&mock.SessionStore{}, &mock.KnowledgeGraph{})
+		got, err := a.Retrieve(context.Background(), "npc-1", "q", 2)
+		if err != nil || got != nil {
+			t.Errorf("Retrieve = %v, %v; want nil, nil", got, err)
+		}
+	})
+}
+
+func TestRetrieve_QueryError(t *testing.T) {
+	t.Parallel()
+
+	g := newRingGraph()
+	g.QueryWithContextErr = errors.New("db down")
+
+	a := hotctx.NewAssembler(&mock.SessionStore{}, g)
+	if _, err := a.Retrieve(context.Background(), "npc-1", "q", 1); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestRetrieve_Embeddings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		embedErr   error
+		wantMethod string
+		wantID     string
+	}{
+		{name: "vector search", wantMethod: "QueryWithEmbedding", wantID: "tavern"},
+		{name: "embeddings down falls back to FTS", embedErr: errors.New("embeddings: 503"), wantMethod: "QueryWithContext", wantID: "town"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := newRingGraph()
+			g.QueryWithEmbeddingResult = []memory.ContextResult{{Entity: memory.Entity{ID: "tavern"}, Score: 1}}
+			g.QueryWithContextResult = []memory.ContextResult{{Entity: memory.Entity{ID: "town"}, Score: 1}}
+			emb := &embeddingsmock.Provider{EmbedResult: []float32{0.1, 0.2}, EmbedErr: tc.embedErr}
+
+			a := hotctx.NewAssembler(&mock.SessionStore{}, g, hotctx.WithEmbeddings(emb), hotctx.WithRetrievalTopK(3))
+			got, err := a.Retrieve(context.Background(), "npc-1", "any news?", 2)
+			if err != nil {
+				t.Fatalf("Retrieve: %v", err)
+			}
+			if len(got) != 1 || got[0].Entity.ID != tc.wantID {
+				t.Fatalf("Retrieve = %+v, want the %s result", got, tc.wantMethod)
+			}
+
+			if calls := emb.EmbedCalls; len(calls) != 1 || calls[0].Text != "any news?" {
+				t.Errorf("Embed calls = %+v, want one for the query", calls)
+			}
+			for _, method := range []string{"QueryWithEmbedding", "QueryWithContext"} {
+				want := 0
+				if method == tc.wantMethod {
+					want = 1
+				}
+				if n := g.CallCount(method); n != want {
+					t.Errorf("%s called %d times, want %d", method, n, want)
+				}
+			}
+			for _, c := range g.Calls() {
+				if c.Method == "QueryWithEmbedding" && c.Args[1].(int) < 3 {
+					t.Errorf("QueryWithEmbedding topK = %v, want at least the retrieval top-K", c.Args[1])
+				}
+			}
+		})
+	}
+}
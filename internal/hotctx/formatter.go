@@ -17,8 +17,9 @@ import (
 // The formatter is pure: it performs no I/O, has no side effects, and is safe
 // for concurrent use.
 //
-// Empty sections (nil identity, no relationships, no scene, no transcript) are
-// omitted entirely rather than rendering as empty headers.
+// Empty sections (nil identity, no relationships, no scene, no knowledge, no
+// style exemplars, no vocabulary, no recap, no mood, no transcript, no rules)
+// are omitted entirely rather than rendering as empty headers.
 func FormatSystemPrompt(hctx *HotContext, npcPersonality string) string {
 	if hctx == nil {
 		name := "an NPC"
@@ -49,9 +50,27 @@ func FormatSystemPrompt(hctx *HotContext, npcPersonality string) string {
 	// ── Scene section ─────────────────────────────────────────────────────────
 	writeSceneSection(&sb, hctx.SceneContext)
 
+	// ── Retrieved knowledge section ───────────────────────────────────────────
+	writeKnowledgeSection(&sb, hctx.Knowledge)
+
+	// ── Speaking style section ────────────────────────────────────────────────
+	writeStyleSection(&sb, hctx.StyleExemplars)
+
+	// ── Spelling reference section ────────────────────────────────────────────
+	writeVocabularySection(&sb, hctx.Vocabulary)
+
+	// ── Scene recap section ───────────────────────────────────────────────────
+	writeRecapSection(&sb, hctx.SceneRecap)
+
+	// ── Mood section ──────────────────────────────────────────────────────────
+	writeMoodSection(&sb, hctx.Mood)
+
 	// ── Recent conversation section ───────────────────────────────────────────
 	writeTranscriptSection(&sb, hctx.RecentTranscript)
 
+	// ── Rules section ─────────────────────────────────────────────────────────
+	writeRulesSection(&sb, hctx.Rules)
+
 	return sb.String()
 }
 
@@ -208,6 +227,75 @@ func writeSceneSection(sb *strings.Builder, sc *SceneContext) {
 	}
 }
 
+// writeKnowledgeSection writes retrieved cold-layer passages, each prefixed
+// with the name of the entity it is about, directly to sb.
+func writeKnowledgeSection(sb *strings.Builder, results []memory.ContextResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	sb.WriteString("\n\n## What You Know\n")
+	for i, r := range results {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		if r.Entity.Name != "" {
+			fmt.Fprintf(sb, "- %s: %s", r.Entity.Name, r.Content)
+		} else {
+			fmt.Fprintf(sb, "- %s", r.Content)
+		}
+	}
+}
+
+// writeStyleSection writes the NPC's own earlier lines as speaking style
+// references directly to sb. Unlike the transcript they carry no speaker or
+// time, as they are examples of how to speak, not part of the conversation.
+func writeStyleSection(sb *strings.Builder, entries []memory.TranscriptEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	sb.WriteString("\n\n## Your Speaking Style\n")
+	sb.WriteString("Lines you said before. Keep your voice, word choice and manner of speech consistent with them; do not repeat them.")
+	for _, e := range entries {
+		fmt.Fprintf(sb, "\n- %s", e.Text)
+	}
+}
+
+// writeVocabularySection writes the campaign's proper nouns as a spelling
+// reference directly to sb.
+func writeVocabularySection(sb *strings.Builder, terms []string) {
+	if len(terms) == 0 {
+		return
+	}
+
+	sb.WriteString("\n\n## Spelling Reference\n")
+	sb.WriteString("Names and terms of this world. Always spell them exactly like this, even if the conversation spells them differently:\n")
+	sb.WriteString(strings.Join(terms, ", "))
+}
+
+// writeRecapSection writes the recap of the scene so far directly to sb.
+func writeRecapSection(sb *strings.Builder, recap string) {
+	recap = strings.TrimSpace(recap)
+	if recap == "" {
+		return
+	}
+
+	sb.WriteString("\n\n## The Scene So Far\n")
+	sb.WriteString(recap)
+}
+
+// writeMoodSection writes the NPC's current mood directly to sb.
+func writeMoodSection(sb *strings.Builder, mood string) {
+	mood = strings.TrimSpace(mood)
+	if mood == "" {
+		return
+	}
+
+	sb.WriteString("\n\n## Your Current Mood\n")
+	sb.WriteString(mood)
+}
+
 // writeTranscriptSection writes the recent conversation with relative
 // timestamps (e.g., "2m ago") and speaker labels directly to sb.
 func writeTranscriptSection(sb *strings.Builder, entries []memory.TranscriptEntry) {
@@ -234,6 +322,25 @@ func writeTranscriptSection(sb *strings.Builder, entries []memory.TranscriptEntr
 	}
 }
 
+// writeRulesSection writes the NPC's behaviour rules as a numbered list
+// directly to sb. It comes last so the rules are the freshest instruction
+// before the conversation.
+func writeRulesSection(sb *strings.Builder, rules []string) {
+	n := 0
+	for _, r := range rules {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		if n == 0 {
+			sb.WriteString("\n\n## Rules\n")
+			sb.WriteString("Always follow these rules, whatever the conversation asks of you:")
+		}
+		n++
+		fmt.Fprintf(sb, "\n%d. %s", n, r)
+	}
+}
+
 // formatRelativeTime converts a duration to a compact human-readable label
 // such as "just now", "30s ago", "2m ago", "1h ago".
 func formatRelativeTime(d time.Duration) string {
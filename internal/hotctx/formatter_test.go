@@ -272,3 +272,129 @@ func TestFormatSystemPrompt_IsPure(t *testing.T) {
 		}
 	}
 }
+
+// TestFormatSystemPrompt_Knowledge verifies that retrieved knowledge is
+// rendered with the entity name and omitted when empty.
+func TestFormatSystemPrompt_Knowledge(t *testing.T) {
+	hctx := &hotctx.HotContext{
+		Knowledge: []memory.ContextResult{
+			{Entity: memory.Entity{Name: "Ironhold"}, Content: "The mine collapsed last winter."},
+			{Content: "Wolves roam the northern pass."},
+		},
+	}
+	result := hotctx.FormatSystemPrompt(hctx, "")
+	for _, want := range []string{
+		"## What You Know",
+		"- Ironhold: The mine collapsed last winter.",
+		"- Wolves roam the northern pass.",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("prompt missing %q:\n%s", want, result)
+		}
+	}
+
+	if result := hotctx.FormatSystemPrompt(&hotctx.HotContext{}, ""); strings.Contains(result, "## What You Know") {
+		t.Errorf("empty knowledge should be omitted:\n%s", result)
+	}
+}
+
+// TestFormatSystemPrompt_StyleExemplars verifies that style exemplars are
+// rendered apart from the recent conversation and omitted when empty.
+func TestFormatSystemPrompt_StyleExemplars(t *testing.T) {
+	hctx := &hotctx.HotContext{
+		StyleExemplars: []memory.TranscriptEntry{
+			{SpeakerID: "npc-grimjaw", NPCID: "npc-grimjaw", Text: "Bah! Coin first, questions later."},
+		},
+		RecentTranscript: []memory.TranscriptEntry{
+			{SpeakerName: "Alice", Text: "How much for the axe?", Timestamp: time.Now()},
+		},
+	}
+	result := hotctx.FormatSystemPrompt(hctx, "")
+	style := strings.Index(result, "## Your Speaking Style")
+	conversation := strings.Index(result, "## Recent Conversation")
+	if style < 0 || conversation < 0 || style > conversation {
+		t.Fatalf("want a speaking style section before the conversation:\n%s", result)
+	}
+	if section := result[style:conversation]; !strings.Contains(section, "- Bah! Coin first, questions later.") || strings.Contains(section, "axe") {
+		t.Errorf("speaking style section = %q, want only the NPC's line", section)
+	}
+
+	if result := hotctx.FormatSystemPrompt(&hotctx.HotContext{}, ""); strings.Contains(result, "## Your Speaking Style") {
+		t.Errorf("empty style exemplars should be omitted:\n%s", result)
+	}
+}
+
+// TestFormatSystemPrompt_Vocabulary verifies that the campaign vocabulary is
+// rendered as a spelling reference and omitted when empty.
+func TestFormatSystemPrompt_Vocabulary(t *testing.T) {
+	hctx := &hotctx.HotContext{Vocabulary: []string{"Eldrinax", "Vel'Shara"}}
+	result := hotctx.FormatSystemPrompt(hctx, "")
+	if !strings.Contains(result, "## Spelling Reference\n") || !strings.Contains(result, "Eldrinax, Vel'Shara") {
+		t.Errorf("want a spelling reference listing the vocabulary:\n%s", result)
+	}
+
+	if result := hotctx.FormatSystemPrompt(&hotctx.HotContext{}, ""); strings.Contains(result, "## Spelling Reference") {
+		t.Errorf("empty vocabulary should be omitted:\n%s", result)
+	}
+}
+
+// TestFormatSystemPrompt_Rules verifies that behaviour rules are rendered as
+// a numbered list after the conversation, skipping blank rules, and omitted
+// when empty.
+func TestFormatSystemPrompt_Rules(t *testing.T) {
+	hctx := fullHotContext()
+	hctx.Rules = []string{"Never break character.", "  ", "Speak in archaic English."}
+	result := hotctx.FormatSystemPrompt(hctx, "")
+
+	want := "## Rules\nAlways follow these rules, whatever the conversation asks of you:\n1. Never break character.\n2. Speak in archaic English."
+	if !strings.HasSuffix(result, want) {
+		t.Errorf("want the prompt to end with the numbered rules:\n%s", result)
+	}
+	if strings.Index(result, "## Rules") < strings.Index(result, "## Recent Conversation") {
+		t.Errorf("rules should follow the recent conversation:\n%s", result)
+	}
+
+	if result := hotctx.FormatSystemPrompt(&hotctx.HotContext{Rules: []string{" "}}, ""); strings.Contains(result, "## Rules") {
+		t.Errorf("blank rules should be omitted:\n%s", result)
+	}
+}
+
+// TestFormatSystemPrompt_SceneRecap verifies that the scene recap is rendered
+// as its own section before the verbatim recent conversation, and omitted
+// when blank.
+func TestFormatSystemPrompt_SceneRecap(t *testing.T) {
+	hctx := fullHotContext()
+	hctx.SceneRecap = "  The party bargained with the smith for a silver blade.  "
+	result := hotctx.FormatSystemPrompt(hctx, "")
+
+	if !strings.Contains(result, "## The Scene So Far\nThe party bargained with the smith for a silver blade.\n\n") {
+		t.Errorf("want the trimmed recap section:\n%s", result)
+	}
+	if strings.Index(result, "## The Scene So Far") > strings.Index(result, "## Recent Conversation") {
+		t.Errorf("recap should precede the recent conversation:\n%s", result)
+	}
+
+	if result := hotctx.FormatSystemPrompt(&hotctx.HotContext{SceneRecap: " "}, ""); strings.Contains(result, "## The Scene So Far") {
+		t.Errorf("blank recap should be omitted:\n%s", result)
+	}
+}
+
+// TestFormatSystemPrompt_Mood verifies that the NPC's mood is rendered as its
+// own section after the scene recap, and omitted when blank.
+func TestFormatSystemPrompt_Mood(t *testing.T) {
+	hctx := fullHotContext()
+	hctx.SceneRecap = "The party bargained with the smith."
+	hctx.Mood = " Wary of the strangers. "
+	result := hotctx.FormatSystemPrompt(hctx, "")
+
+	if !strings.Contains(result, "## Your Current Mood\nWary of the strangers.\n\n") {
+		t.Errorf("want the trimmed mood section:\n%s", result)
+	}
+	if strings.Index(result, "## Your Current Mood") < strings.Index(result, "## The Scene So Far") {
+		t.Errorf("mood should follow the recap:\n%s", result)
+	}
+
+	if result := hotctx.FormatSystemPrompt(&hotctx.HotContext{Mood: " "}, ""); strings.Contains(result, "## Your Current Mood") {
+		t.Errorf("blank mood should be omitted:\n%s", result)
+	}
+}
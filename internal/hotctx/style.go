@@ -0,0 +1,39 @@
+package hotctx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// StyleExemplars returns up to k of the most recent lines npcID has spoken,
+// across all sessions, oldest first. They are meant as examples of the NPC's
+// speaking style (see [HotContext.StyleExemplars]), so entries spoken by
+// anyone else are never returned. A k of zero or less returns nil without
+// querying.
+func (a *Assembler) StyleExemplars(ctx context.Context, npcID string, k int) ([]memory.TranscriptEntry, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	entries, err := a.sessionStore.Search(ctx, "", memory.SearchOpts{
+		SpeakerID: npcID,
+		NPCOnly:   true,
+		Limit:     k,
+		Latest:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hot context: style exemplars for %q: %w", npcID, err)
+	}
+
+	lines := entries[:0]
+	for _, e := range entries {
+		if e.NPCID == npcID && e.Text != "" {
+			lines = append(lines, e)
+		}
+	}
+	if len(lines) > k {
+		lines = lines[len(lines)-k:]
+	}
+	return lines, nil
+}
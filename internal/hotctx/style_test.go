@@ -0,0 +1,74 @@
+package hotctx_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/internal/hotctx"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/memory/mock"
+)
+
+func TestStyleExemplars(t *testing.T) {
+	t.Parallel()
+
+	store := &mock.SessionStore{
+		SearchResult: []memory.TranscriptEntry{
+			{SpeakerID: "npc-grimjaw", NPCID: "npc-grimjaw", Text: "Bah! Coin first."},
+			{SpeakerID: "player-1", Text: "Grimjaw, how much for the axe?"},
+			{SpeakerID: "npc-grimjaw", NPCID: "npc-elara", Text: "Grimjaw is grumpy today."},
+			{SpeakerID: "npc-grimjaw", NPCID: "npc-grimjaw", Text: "Forty gold, and not a copper less."},
+		},
+	}
+	a := hotctx.NewAssembler(store, &mock.KnowledgeGraph{})
+
+	got, err := a.StyleExemplars(context.Background(), "npc-grimjaw", 3)
+	if err != nil {
+		t.Fatalf("StyleExemplars: %v", err)
+	}
+	texts := make([]string, len(got))
+	for i, e := range got {
+		texts[i] = e.Text
+	}
+	if want := []string{"Bah! Coin first.", "Forty gold, and not a copper less."}; !slices.Equal(texts, want) {
+		t.Errorf("StyleExemplars = %q, want only the NPC's own lines %q", texts, want)
+	}
+
+	calls := store.Calls()
+	if len(calls) != 1 || calls[0].Method != "Search" {
+		t.Fatalf("calls = %+v, want one Search", calls)
+	}
+	if query := calls[0].Args[0].(string); query != "" {
+		t.Errorf("Search query = %q, want empty", query)
+	}
+	want := memory.SearchOpts{SpeakerID: "npc-grimjaw", NPCOnly: true, Limit: 3, Latest: true}
+	if opts := calls[0].Args[1].(memory.SearchOpts); opts != want {
+		t.Errorf("Search opts = %+v, want %+v", opts, want)
+	}
+}
+
+func TestStyleExemplars_Disabled(t *testing.T) {
+	t.Parallel()
+
+	store := &mock.SessionStore{}
+	a := hotctx.NewAssembler(store, &mock.KnowledgeGraph{})
+	got, err := a.StyleExemplars(context.Background(), "npc-grimjaw", 0)
+	if err != nil || got != nil {
+		t.Errorf("StyleExemplars = %v, %v; want nil, nil", got, err)
+	}
+	if n := store.CallCount("Search"); n != 0 {
+		t.Errorf("Search called %d times, want 0", n)
+	}
+}
+
+func TestStyleExemplars_Error(t *testing.T) {
+	t.Parallel()
+
+	store := &mock.SessionStore{SearchErr: errors.New("db down")}
+	a := hotctx.NewAssembler(store, &mock.KnowledgeGraph{})
+	if _, err := a.StyleExemplars(context.Background(), "npc-grimjaw", 3); err == nil {
+		t.Fatal("expected an error")
+	}
+}
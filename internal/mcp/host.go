@@ -106,6 +106,10 @@ type Host interface {
 	// tool listing request fails.
 	RegisterServer(ctx context.Context, cfg ServerConfig) error
 
+	// UnregisterServer disconnects the named server and removes its tools.
+	// Unknown names are ignored. Built-in tools are not affected.
+	UnregisterServer(name string) error
+
 	// AvailableTools returns all tools whose assigned [BudgetTier] is ≤
 	// tier, sorted by EstimatedDurationMs ascending (fastest first).
 	//
@@ -193,6 +193,28 @@ func (h *Host) RegisterServer(ctx context.Context, cfg mcp.ServerConfig) error {
 	return nil
 }
 
+// UnregisterServer closes the connection to the named server and removes its
+// tools. Unknown names are ignored.
+func (h *Host) UnregisterServer(name string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	conn, ok := h.servers[name]
+	if !ok {
+		return nil
+	}
+	delete(h.servers, name)
+	for tool, t := range h.tools {
+		if t.serverName == name {
+			delete(h.tools, tool)
+		}
+	}
+	if err := conn.session.Close(); err != nil {
+		return fmt.Errorf("mcp host: error closing server %q: %w", name, err)
+	}
+	return nil
+}
+
 // buildToolEntry converts an official SDK Tool into an internal toolEntry.
 func buildToolEntry(t mcpsdk.Tool, serverName string) toolEntry {
 	p50, maxMs := extractLatencyHints(t)
@@ -3,12 +3,15 @@ package mcphost
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/MrWong99/glyphoxa/internal/mcp"
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // ──────────────────────────────────────────────────────────────────────────────
@@ -424,3 +427,44 @@ func assertNotContains(t *testing.T, tools []llm.ToolDefinition, name string) {
 		t.Errorf("expected tool %q to be absent, but it was present", name)
 	}
 }
+
+// TestUnregisterServer verifies that unregistering a server removes its tools
+// and leaves built-in tools alone, and that unknown names are ignored.
+func TestUnregisterServer(t *testing.T) {
+	t.Parallel()
+
+	srv := mcpsdk.NewServer(&mcpsdk.Implementation{Name: "lore", Version: "1.0.0"}, nil)
+	type lookupArgs struct {
+		Topic string `json:"topic"`
+	}
+	mcpsdk.AddTool(srv, &mcpsdk.Tool{Name: "lookup_lore", Description: "looks up lore"},
+		func(_ context.Context, _ *mcpsdk.CallToolRequest, args lookupArgs) (*mcpsdk.CallToolResult, any, error) {
+			return &mcpsdk.CallToolResult{Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: args.Topic}}}, nil, nil
+		})
+	ts := httptest.NewServer(mcpsdk.NewStreamableHTTPHandler(func(*http.Request) *mcpsdk.Server { return srv }, nil))
+	defer ts.Close()
+
+	h := New()
+	defer h.Close()
+
+	must(t, h.RegisterBuiltin(echoTool("greet", 100)))
+	must(t, h.RegisterServer(context.Background(), mcp.ServerConfig{
+		Name:      "lore",
+		Transport: mcp.TransportStreamableHTTP,
+		URL:       ts.URL,
+	}))
+	if toolNamed(h.AvailableTools(mcp.BudgetDeep), "lookup_lore") == nil {
+		t.Fatal("lookup_lore not available after RegisterServer")
+	}
+
+	must(t, h.UnregisterServer("lore"))
+	must(t, h.UnregisterServer("unknown"))
+
+	got := h.AvailableTools(mcp.BudgetDeep)
+	if toolNamed(got, "lookup_lore") != nil {
+		t.Error("lookup_lore still available after UnregisterServer")
+	}
+	if toolNamed(got, "greet") == nil {
+		t.Error("built-in tool greet removed by UnregisterServer")
+	}
+}
@@ -48,6 +48,11 @@ type Host struct {
 	// RegisterServerErr is returned by [Host.RegisterServer] when non-nil.
 	RegisterServerErr error
 
+	// ──── UnregisterServer ─────────────────────────────────────────────────
+
+	// UnregisterServerErr is returned by [Host.UnregisterServer] when non-nil.
+	UnregisterServerErr error
+
 	// ──── AvailableTools ───────────────────────────────────────────────────
 
 	// AvailableToolsResult is returned by [Host.AvailableTools].
@@ -113,6 +118,14 @@ func (h *Host) RegisterServer(_ context.Context, cfg mcp.ServerConfig) error {
 	return h.RegisterServerErr
 }
 
+// UnregisterServer implements [mcp.Host].
+func (h *Host) UnregisterServer(name string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls = append(h.calls, Call{Method: "UnregisterServer", Args: []any{name}})
+	return h.UnregisterServerErr
+}
+
 // AvailableTools implements [mcp.Host].
 func (h *Host) AvailableTools(tier mcp.BudgetTier) []llm.ToolDefinition {
 	h.mu.Lock()
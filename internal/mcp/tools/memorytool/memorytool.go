@@ -148,7 +148,7 @@ func makeGetSummaryHandler(graph memory.KnowledgeGraph) func(context.Context, st
 			return "", fmt.Errorf("memory tool: get_summary: %w", err)
 		}
 		if snapshot == nil {
-			return "", fmt.Errorf("memory tool: get_summary: entity %q not found", a.EntityID)
+			return "", fmt.Errorf("memory tool: get_summary: entity %q: %w", a.EntityID, memory.ErrEntityNotFound)
 		}
 
 		res, err := json.Marshal(snapshot)
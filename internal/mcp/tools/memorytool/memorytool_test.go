@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -269,7 +270,23 @@ func TestGetSummary_NotFound(t *testing.T) {
 
 	_, err := handler(context.Background(), `{"entity_id":"nonexistent"}`)
 	if err == nil {
-		t.Error("expected error for missing entity")
+		t.Fatal("expected error for missing entity")
+	}
+	if !errors.Is(err, memory.ErrEntityNotFound) {
+		t.Errorf("expected errors.Is(err, memory.ErrEntityNotFound), got %v", err)
+	}
+}
+
+func TestGetSummary_NotFoundFromGraph(t *testing.T) {
+	t.Parallel()
+	graph := &mock.KnowledgeGraph{
+		IdentitySnapshotErr: fmt.Errorf("knowledge graph: identity snapshot %q: %w", "ghost", memory.ErrEntityNotFound),
+	}
+	handler := makeGetSummaryHandler(graph)
+
+	_, err := handler(context.Background(), `{"entity_id":"ghost"}`)
+	if !errors.Is(err, memory.ErrEntityNotFound) {
+		t.Errorf("expected wrapped memory.ErrEntityNotFound to survive, got %v", err)
 	}
 }
 
@@ -75,6 +75,11 @@ type Metrics struct {
 	// all sessions.
 	ActiveParticipants metric.Int64UpDownCounter
 
+	// ActiveEngines tracks the number of open NPC voice engines. Use with
+	// attribute:
+	//   attribute.String("engine", ...)
+	ActiveEngines metric.Int64UpDownCounter
+
 	// --- HTTP middleware ---
 
 	// HTTPRequestDuration tracks HTTP request processing time. Use with attributes:
@@ -172,6 +177,11 @@ func NewMetrics(mp metric.MeterProvider) (*Metrics, error) {
 	); err != nil {
 		return nil, err
 	}
+	if met.ActiveEngines, err = m.Int64UpDownCounter("glyphoxa.active_engines",
+		metric.WithDescription("Number of open NPC voice engines by engine type."),
+	); err != nil {
+		return nil, err
+	}
 
 	// HTTP middleware histogram.
 	if met.HTTPRequestDuration, err = m.Float64Histogram("glyphoxa.http.request.duration",
@@ -252,3 +262,11 @@ func (m *Metrics) RecordProviderError(ctx context.Context, provider, kind string
 		),
 	)
 }
+
+// RecordActiveEngines is a convenience method that adjusts the open engine
+// gauge for the given engine type by delta.
+func (m *Metrics) RecordActiveEngines(ctx context.Context, engine string, delta int) {
+	m.ActiveEngines.Add(ctx, int64(delta),
+		metric.WithAttributes(attribute.String("engine", engine)),
+	)
+}
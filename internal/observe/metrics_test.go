@@ -230,6 +230,9 @@ func TestGauges(t *testing.T) {
 	m.ActiveSessions.Add(ctx, 1)
 	m.ActiveSessions.Add(ctx, 1)
 	m.ActiveParticipants.Add(ctx, 3)
+	m.RecordActiveEngines(ctx, "s2s", 1)
+	m.RecordActiveEngines(ctx, "s2s", 1)
+	m.RecordActiveEngines(ctx, "s2s", -1)
 
 	rm := collect(t, reader)
 
@@ -240,6 +243,7 @@ func TestGauges(t *testing.T) {
 		{"glyphoxa.active_npcs", 5},
 		{"glyphoxa.active_sessions", 2},
 		{"glyphoxa.active_participants", 3},
+		{"glyphoxa.active_engines", 1},
 	}
 
 	for _, tc := range gauges {
@@ -95,16 +95,28 @@ func (fg *FallbackGroup[T]) Execute(fn func(T) error) error {
 // returning both the result value and error. This is a package-level function
 // because Go does not support method-level type parameters.
 func ExecuteWithResult[T any, R any](fg *FallbackGroup[T], fn func(T) (R, error)) (R, error) {
+	return executeEntries(fg, nil, func(_ string, value T) (R, error) {
+		return fn(value)
+	})
+}
+
+// executeEntries is [ExecuteWithResult] restricted to the entries for which
+// keep reports true (all entries when keep is nil). fn also receives the name
+// of the entry it is called for.
+func executeEntries[T any, R any](fg *FallbackGroup[T], keep func(name string) bool, fn func(name string, value T) (R, error)) (R, error) {
 	var (
 		lastErr error
 		zero    R
 	)
 	for i := range fg.entries {
 		entry := &fg.entries[i]
+		if keep != nil && !keep(entry.name) {
+			continue
+		}
 		var result R
 		err := entry.breaker.Execute(func() error {
 			var innerErr error
-			result, innerErr = fn(entry.value)
+			result, innerErr = fn(entry.name, entry.value)
 			return innerErr
 		})
 		if err == nil {
@@ -2,23 +2,57 @@ package resilience
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
 
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
 )
 
+// LanguagePolicy selects how a [TTSFallback] handles a requested
+// [tts.VoiceProfile.Language] that a provider does not support.
+type LanguagePolicy int
+
+const (
+	// LanguagePolicyDefault keeps the normal provider order and lets a provider
+	// that does not support the language speak in its default language instead.
+	LanguagePolicyDefault LanguagePolicy = iota
+
+	// LanguagePolicySwitchProvider routes synthesis to the providers in the
+	// chain that support the language. When none does, it behaves like
+	// [LanguagePolicyDefault].
+	LanguagePolicySwitchProvider
+)
+
 // TTSFallback implements [tts.Provider] with automatic failover across multiple
 // TTS backends. Each backend has its own circuit breaker.
+//
+// Providers may declare the languages they support (see
+// [TTSFallback.SetLanguages] and [TTSFallback.DiscoverLanguages]). A request
+// for a language a provider does not support is handled according to the
+// [LanguagePolicy] instead of producing garbled audio. Providers without a
+// declared language set are assumed to support every language.
 type TTSFallback struct {
-	group *FallbackGroup[tts.Provider]
+	group     *FallbackGroup[tts.Provider]
+	policy    LanguagePolicy
+	languages map[string]tts.LanguageSet
 }
 
-// Compile-time interface assertion.
-var _ tts.Provider = (*TTSFallback)(nil)
+// Compile-time interface assertions.
+var (
+	_ tts.Provider          = (*TTSFallback)(nil)
+	_ tts.MarkupSynthesizer = (*TTSFallback)(nil)
+	_ tts.FormatReporter    = (*TTSFallback)(nil)
+	_ tts.ChannelReporter   = (*TTSFallback)(nil)
+)
 
 // NewTTSFallback creates a [TTSFallback] with primary as the preferred backend.
 func NewTTSFallback(primary tts.Provider, primaryName string, cfg FallbackConfig) *TTSFallback {
 	return &TTSFallback{
-		group: NewFallbackGroup(primary, primaryName, cfg),
+		group:     NewFallbackGroup(primary, primaryName, cfg),
+		languages: make(map[string]tts.LanguageSet),
 	}
 }
 
@@ -27,15 +61,133 @@ func (f *TTSFallback) AddFallback(name string, provider tts.Provider) {
 	f.group.AddFallback(name, provider)
 }
 
-// SynthesizeStream consumes text fragments and returns a channel of audio bytes,
+// SetLanguagePolicy sets how unsupported languages are handled. The default
+// is [LanguagePolicyDefault].
+//
+// Like AddFallback, it must only be called during initialisation.
+func (f *TTSFallback) SetLanguagePolicy(policy LanguagePolicy) {
+	f.policy = policy
+}
+
+// SetLanguages declares the languages the provider registered as name
+// supports, replacing any discovered set.
+//
+// Like AddFallback, it must only be called during initialisation.
+func (f *TTSFallback) SetLanguages(name string, langs tts.LanguageSet) {
+	f.languages[name] = langs
+}
+
+// DiscoverLanguages queries every provider without a declared language set
+// via [tts.SupportedLanguages]. Providers that cannot be queried keep an
+// unknown set; their errors are joined into the returned error.
+//
+// Like AddFallback, it must only be called during initialisation.
+func (f *TTSFallback) DiscoverLanguages(ctx context.Context) error {
+	var errs []error
+	for _, entry := range f.group.entries {
+		if f.languages[entry.name].Known() {
+			continue
+		}
+		langs, err := tts.SupportedLanguages(ctx, entry.value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.name, err))
+			continue
+		}
+		f.languages[entry.name] = langs
+	}
+	return errors.Join(errs...)
+}
+
+// Warmup warms every provider in the chain that has a
+// Warmup(context.Context) error method, concurrently. Failures are joined into
+// the returned error, prefixed with the provider's name.
+func (f *TTSFallback) Warmup(ctx context.Context) error {
+	type warmer interface {
+		Warmup(ctx context.Context) error
+	}
+
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	for _, entry := range f.group.entries {
+		w, ok := entry.value.(warmer)
+		if !ok {
+			continue
+		}
+		wg.Go(func() {
+			if err := w.Warmup(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", entry.name, err))
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// SynthesizeStream consumes text fragments and returns a stream of audio bytes,
 // trying the first healthy provider. Only the initial stream setup is covered by
-// failover; mid-stream errors are the caller's responsibility.
-func (f *TTSFallback) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (<-chan []byte, error) {
-	return ExecuteWithResult(f.group, func(p tts.Provider) (<-chan []byte, error) {
-		return p.SynthesizeStream(ctx, text, voice)
+// failover; mid-stream errors are reported by the chosen provider through
+// [tts.Stream.Err] and are the caller's responsibility.
+//
+// When voice.Language is set, providers are chosen and the language adjusted
+// according to the [LanguagePolicy].
+func (f *TTSFallback) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	return f.synthesize(voice, func(p tts.Provider, v tts.VoiceProfile) (*tts.Stream, error) {
+		return p.SynthesizeStream(ctx, text, v)
+	})
+}
+
+// SynthesizeMarkupStream implements [tts.MarkupSynthesizer] like
+// SynthesizeStream. The chosen provider translates the markup if it supports
+// it; otherwise the tags are stripped (see [tts.SynthesizeMarkup]).
+func (f *TTSFallback) SynthesizeMarkupStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	return f.synthesize(voice, func(p tts.Provider, v tts.VoiceProfile) (*tts.Stream, error) {
+		return tts.SynthesizeMarkup(ctx, p, text, v)
 	})
 }
 
+// synthesize starts a stream with the first healthy provider, choosing
+// providers and adjusting voice according to the [LanguagePolicy].
+func (f *TTSFallback) synthesize(voice tts.VoiceProfile, start func(p tts.Provider, v tts.VoiceProfile) (*tts.Stream, error)) (*tts.Stream, error) {
+	lang := voice.Language
+	supports := func(name string) bool {
+		return f.languages[name].Supports(lang)
+	}
+
+	var keep func(name string) bool
+	if f.policy == LanguagePolicySwitchProvider && slices.ContainsFunc(f.group.entries, func(e fallbackEntry[tts.Provider]) bool {
+		return supports(e.name)
+	}) {
+		keep = supports
+	}
+
+	return executeEntries(f.group, keep, func(name string, p tts.Provider) (*tts.Stream, error) {
+		v := voice
+		if !supports(name) {
+			slog.Warn("tts: language not supported by provider, using its default language",
+				"provider", name, "language", lang)
+			v.Language = ""
+		}
+		return start(p, v)
+	})
+}
+
+// SampleRate implements [tts.FormatReporter] with the sample rate of the
+// primary provider. Fallbacks are expected to synthesise the same format.
+func (f *TTSFallback) SampleRate() int {
+	return tts.OutputFormat(f.group.entries[0].value).SampleRate
+}
+
+// Channels implements [tts.ChannelReporter] with the channel count of the
+// primary provider.
+func (f *TTSFallback) Channels() int {
+	return tts.OutputFormat(f.group.entries[0].value).Channels
+}
+
 // ListVoices returns available voices from the first healthy provider.
 func (f *TTSFallback) ListVoices(ctx context.Context) ([]tts.VoiceProfile, error) {
 	return ExecuteWithResult(f.group, func(p tts.Provider) ([]tts.VoiceProfile, error) {
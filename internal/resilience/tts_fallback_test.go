@@ -3,6 +3,8 @@ package resilience
 import (
 	"context"
 	"errors"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
@@ -26,7 +28,7 @@ func TestTTSFallback_SynthesizeStream_PrimarySuccess(t *testing.T) {
 	textCh <- "hello"
 	close(textCh)
 
-	audioCh, err := fb.SynthesizeStream(context.Background(), textCh, tts.VoiceProfile{
+	stream, err := fb.SynthesizeStream(context.Background(), textCh, tts.VoiceProfile{
 		ID:   "v1",
 		Name: "TestVoice",
 	})
@@ -35,7 +37,7 @@ func TestTTSFallback_SynthesizeStream_PrimarySuccess(t *testing.T) {
 	}
 
 	var chunks [][]byte
-	for chunk := range audioCh {
+	for chunk := range stream.Audio() {
 		chunks = append(chunks, chunk)
 	}
 	if len(chunks) != 2 {
@@ -69,13 +71,13 @@ func TestTTSFallback_SynthesizeStream_Failover(t *testing.T) {
 	textCh <- "hello"
 	close(textCh)
 
-	audioCh, err := fb.SynthesizeStream(context.Background(), textCh, tts.VoiceProfile{})
+	stream, err := fb.SynthesizeStream(context.Background(), textCh, tts.VoiceProfile{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
 	var chunks [][]byte
-	for chunk := range audioCh {
+	for chunk := range stream.Audio() {
 		chunks = append(chunks, chunk)
 	}
 	if len(chunks) != 1 {
@@ -104,6 +106,44 @@ func TestTTSFallback_SynthesizeStream_AllFail(t *testing.T) {
 	}
 }
 
+// markupProvider is a mock provider with markup support.
+type markupProvider struct {
+	ttsmock.Provider
+	markupCalls int
+}
+
+func (m *markupProvider) SynthesizeMarkupStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	m.markupCalls++
+	return m.SynthesizeStream(ctx, text, voice)
+}
+
+func TestTTSFallback_SynthesizeMarkupStream(t *testing.T) {
+	primary := &ttsmock.Provider{SynthesizeErr: errors.New("primary down")}
+	secondary := &markupProvider{Provider: ttsmock.Provider{SynthesizeChunks: [][]byte{[]byte("fallback-audio")}}}
+
+	fb := NewTTSFallback(primary, "primary", FallbackConfig{
+		CircuitBreaker: CircuitBreakerConfig{MaxFailures: 3},
+	})
+	fb.AddFallback("secondary", secondary)
+
+	textCh := make(chan string, 1)
+	textCh <- `Hold <break time="1s"/>still.`
+	close(textCh)
+
+	stream, err := fb.SynthesizeMarkupStream(context.Background(), textCh, tts.VoiceProfile{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range stream.Audio() {
+	}
+	if len(primary.SynthesizeStreamCalls) != 1 {
+		t.Errorf("primary called %d times, want 1", len(primary.SynthesizeStreamCalls))
+	}
+	if secondary.markupCalls != 1 {
+		t.Errorf("secondary markup calls = %d, want 1", secondary.markupCalls)
+	}
+}
+
 func TestTTSFallback_ListVoices_Failover(t *testing.T) {
 	primary := &ttsmock.Provider{
 		ListVoicesErr: errors.New("primary down"),
@@ -153,3 +193,192 @@ func TestTTSFallback_CloneVoice_Failover(t *testing.T) {
 		t.Fatalf("voice.ID = %q, want cloned-v1", voice.ID)
 	}
 }
+
+// synthesizeVoice runs one synthesis through fb and returns the provider that
+// was used and the voice it received.
+func synthesizeVoice(t *testing.T, fb *TTSFallback, voice tts.VoiceProfile, providers map[string]*ttsmock.Provider) (string, tts.VoiceProfile) {
+	t.Helper()
+	textCh := make(chan string)
+	close(textCh)
+	stream, err := fb.SynthesizeStream(context.Background(), textCh, voice)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range stream.Audio() {
+	}
+	for name, p := range providers {
+		if len(p.SynthesizeStreamCalls) > 0 {
+			return name, p.SynthesizeStreamCalls[0].Voice
+		}
+	}
+	t.Fatal("no provider was called")
+	return "", tts.VoiceProfile{}
+}
+
+func TestTTSFallback_SynthesizeStream_UnsupportedLanguage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		policy       LanguagePolicy
+		language     string
+		withUnknown  bool // add a provider without a language set
+		wantProvider string
+		wantLanguage string
+	}{
+		{
+			name:         "supported language stays on primary",
+			policy:       LanguagePolicySwitchProvider,
+			language:     "en-GB",
+			wantProvider: "primary",
+			wantLanguage: "en-GB",
+		},
+		{
+			name:         "default policy uses the default language",
+			policy:       LanguagePolicyDefault,
+			language:     "de-DE",
+			wantProvider: "primary",
+			wantLanguage: "",
+		},
+		{
+			name:         "switch policy picks a supporting provider",
+			policy:       LanguagePolicySwitchProvider,
+			language:     "de-DE",
+			wantProvider: "german",
+			wantLanguage: "de-DE",
+		},
+		{
+			name:         "switch policy without a supporting provider uses the default language",
+			policy:       LanguagePolicySwitchProvider,
+			language:     "ja",
+			wantProvider: "primary",
+			wantLanguage: "",
+		},
+		{
+			name:         "provider with unknown languages is trusted",
+			policy:       LanguagePolicySwitchProvider,
+			language:     "fr",
+			withUnknown:  true,
+			wantProvider: "any",
+			wantLanguage: "fr",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			providers := map[string]*ttsmock.Provider{
+				"primary": {SynthesizeChunks: [][]byte{[]byte("en")}},
+				"german":  {SynthesizeChunks: [][]byte{[]byte("de")}},
+				"any":     {SynthesizeChunks: [][]byte{[]byte("?")}},
+			}
+			fb := NewTTSFallback(providers["primary"], "primary", FallbackConfig{
+				CircuitBreaker: CircuitBreakerConfig{MaxFailures: 3},
+			})
+			fb.AddFallback("german", providers["german"])
+			fb.SetLanguagePolicy(tc.policy)
+			fb.SetLanguages("primary", tts.NewLanguageSet("en-US"))
+			fb.SetLanguages("german", tts.NewLanguageSet("de"))
+			if tc.withUnknown {
+				fb.AddFallback("any", providers["any"])
+			}
+
+			name, voice := synthesizeVoice(t, fb, tts.VoiceProfile{ID: "v1", Language: tc.language}, providers)
+			if name != tc.wantProvider {
+				t.Errorf("provider = %q, want %q", name, tc.wantProvider)
+			}
+			if voice.Language != tc.wantLanguage {
+				t.Errorf("voice language = %q, want %q", voice.Language, tc.wantLanguage)
+			}
+			if voice.ID != "v1" {
+				t.Errorf("voice ID = %q, want v1", voice.ID)
+			}
+		})
+	}
+}
+
+func TestTTSFallback_DiscoverLanguages(t *testing.T) {
+	t.Parallel()
+
+	primary := &ttsmock.Provider{
+		SynthesizeChunks: [][]byte{[]byte("en")},
+		ListVoicesResult: []tts.VoiceProfile{{ID: "v1", Metadata: map[string]string{"locale": "en-US"}}},
+	}
+	broken := &ttsmock.Provider{ListVoicesErr: errors.New("offline")}
+	german := &ttsmock.Provider{
+		SynthesizeChunks: [][]byte{[]byte("de")},
+		ListVoicesResult: []tts.VoiceProfile{{ID: "v2", Metadata: map[string]string{"language": "de"}}},
+	}
+
+	fb := NewTTSFallback(primary, "primary", FallbackConfig{
+		CircuitBreaker: CircuitBreakerConfig{MaxFailures: 3},
+	})
+	fb.AddFallback("broken", broken)
+	fb.AddFallback("german", german)
+	fb.SetLanguagePolicy(LanguagePolicySwitchProvider)
+
+	if err := fb.DiscoverLanguages(context.Background()); err == nil {
+		t.Error("DiscoverLanguages: want the error of the broken provider")
+	}
+	if !fb.languages["primary"].Supports("en") || fb.languages["primary"].Supports("de") {
+		t.Errorf("primary languages = %q, want [en]", fb.languages["primary"].Languages())
+	}
+	if fb.languages["broken"].Known() {
+		t.Error("broken provider must keep an unknown language set")
+	}
+
+	// "broken" supports every language as far as we know, so it is tried
+	// before "german" and fails; "german" then speaks German.
+	broken.SynthesizeErr = errors.New("down")
+	name, voice := synthesizeVoice(t, fb, tts.VoiceProfile{Language: "de-DE"},
+		map[string]*ttsmock.Provider{"primary": primary, "german": german})
+	if name != "german" || voice.Language != "de-DE" {
+		t.Errorf("used %q with language %q, want german with de-DE", name, voice.Language)
+	}
+	if len(primary.SynthesizeStreamCalls) != 0 {
+		t.Error("primary does not support German and must not be called")
+	}
+}
+
+// warmingTTS is a TTS provider with a Warmup method that returns err.
+type warmingTTS struct {
+	ttsmock.Provider
+	err    error
+	warmed atomic.Bool
+}
+
+func (p *warmingTTS) Warmup(context.Context) error {
+	p.warmed.Store(true)
+	return p.err
+}
+
+func TestTTSFallback_Warmup(t *testing.T) {
+	t.Parallel()
+
+	primary := &warmingTTS{}
+	plain := &ttsmock.Provider{}
+	broken := &warmingTTS{err: errors.New("model missing")}
+
+	fb := NewTTSFallback(primary, "primary", FallbackConfig{})
+	fb.AddFallback("plain", plain)
+	fb.AddFallback("broken", broken)
+
+	err := fb.Warmup(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "broken: model missing") {
+		t.Errorf("Warmup error = %v, want the error of the broken provider", err)
+	}
+	if !primary.warmed.Load() || !broken.warmed.Load() {
+		t.Error("Warmup must warm every provider that supports it")
+	}
+}
+
+func TestTTSFallback_OutputFormat(t *testing.T) {
+	t.Parallel()
+
+	fb := NewTTSFallback(&ttsmock.Provider{SampleRateResult: 16000}, "primary", FallbackConfig{})
+	fb.AddFallback("secondary", &ttsmock.Provider{SampleRateResult: 24000})
+
+	if got := tts.OutputFormat(fb); got.SampleRate != 16000 || got.Channels != 1 {
+		t.Errorf("OutputFormat = %+v, want the primary's 16 kHz mono", got)
+	}
+}
@@ -0,0 +1,84 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+// CompressPolicy is a summarise-and-compress history policy for long-running
+// NPC conversations.
+//
+// Instead of dropping the oldest messages once a conversation grows too long,
+// CompressPolicy replaces the oldest half of the history with a single
+// role=system summary message produced by a [Summariser], keeping the most
+// recent turns verbatim. Because a previous summary message is itself part of
+// the oldest half on the next compression, the summary accumulates over the
+// lifetime of the conversation.
+//
+// CompressPolicy holds no mutable state and is safe for concurrent use by
+// multiple agents.
+type CompressPolicy struct {
+	threshold  int
+	summariser Summariser
+}
+
+// CompressPolicyConfig configures a [CompressPolicy].
+type CompressPolicyConfig struct {
+	// Threshold is the number of messages above which the history is
+	// compressed. Must be at least 2.
+	Threshold int
+
+	// Summariser condenses the oldest half of the history. Must not be nil.
+	Summariser Summariser
+}
+
+// NewCompressPolicy creates a [CompressPolicy] from cfg. It returns an error
+// if Threshold is less than 2 or Summariser is nil.
+func NewCompressPolicy(cfg CompressPolicyConfig) (*CompressPolicy, error) {
+	if cfg.Threshold < 2 {
+		return nil, fmt.Errorf("session: compress policy threshold must be >= 2, got %d", cfg.Threshold)
+	}
+	if cfg.Summariser == nil {
+		return nil, errors.New("session: compress policy requires a non-nil Summariser")
+	}
+	return &CompressPolicy{
+		threshold:  cfg.Threshold,
+		summariser: cfg.Summariser,
+	}, nil
+}
+
+// Threshold returns the message count above which the history is compressed.
+func (p *CompressPolicy) Threshold() int { return p.threshold }
+
+// Apply returns messages unchanged while len(messages) <= Threshold. Once the
+// threshold is exceeded, the oldest half is summarised and the returned slice
+// consists of one system summary message followed by the remaining recent
+// messages in their original order.
+//
+// The input slice is never modified. On summarisation failure Apply returns
+// the error and a nil slice; callers should keep their existing history.
+func (p *CompressPolicy) Apply(ctx context.Context, messages []llm.Message) ([]llm.Message, error) {
+	if len(messages) <= p.threshold {
+		return messages, nil
+	}
+
+	half := len(messages) / 2
+	oldest := messages[:half]
+	recent := messages[half:]
+
+	summary, err := p.summariser.Summarise(ctx, oldest)
+	if err != nil {
+		return nil, fmt.Errorf("session: compress history: %w", err)
+	}
+
+	out := make([]llm.Message, 0, len(recent)+1)
+	out = append(out, llm.Message{
+		Role:    "system",
+		Content: fmt.Sprintf("[Previous conversation summary]: %s", summary),
+	})
+	out = append(out, recent...)
+	return out, nil
+}
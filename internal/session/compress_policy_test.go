@@ -0,0 +1,185 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+)
+
+// conversation returns n alternating user/assistant messages numbered from 0.
+func conversation(n int) []llm.Message {
+	msgs := make([]llm.Message, n)
+	for i := range msgs {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		msgs[i] = llm.Message{Role: role, Content: fmt.Sprintf("turn %d", i)}
+	}
+	return msgs
+}
+
+func TestNewCompressPolicy_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     CompressPolicyConfig
+		wantErr string
+	}{
+		{
+			name:    "threshold too small",
+			cfg:     CompressPolicyConfig{Threshold: 1, Summariser: &mockSummariser{}},
+			wantErr: "threshold must be >= 2",
+		},
+		{
+			name:    "nil summariser",
+			cfg:     CompressPolicyConfig{Threshold: 10},
+			wantErr: "non-nil Summariser",
+		},
+		{
+			name: "valid",
+			cfg:  CompressPolicyConfig{Threshold: 2, Summariser: &mockSummariser{}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewCompressPolicy(tc.cfg)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if p.Threshold() != tc.cfg.Threshold {
+					t.Errorf("Threshold() = %d, want %d", p.Threshold(), tc.cfg.Threshold)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompressPolicy_BelowThreshold(t *testing.T) {
+	p := &llmmock.Provider{}
+	policy, err := NewCompressPolicy(CompressPolicyConfig{
+		Threshold:  6,
+		Summariser: NewLLMSummariser(p),
+	})
+	if err != nil {
+		t.Fatalf("NewCompressPolicy: %v", err)
+	}
+
+	msgs := conversation(6)
+	got, err := policy.Apply(context.Background(), msgs)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(got) != 6 {
+		t.Errorf("len(history) = %d, want 6", len(got))
+	}
+	if len(p.CompleteCalls) != 0 {
+		t.Errorf("expected no LLM calls at the threshold, got %d", len(p.CompleteCalls))
+	}
+}
+
+func TestCompressPolicy_CompressesOldestHalf(t *testing.T) {
+	p := &llmmock.Provider{
+		CompleteResponse: &llm.CompletionResponse{Content: "The party haggled over a sword."},
+	}
+	policy, err := NewCompressPolicy(CompressPolicyConfig{
+		Threshold:  6,
+		Summariser: NewLLMSummariser(p),
+	})
+	if err != nil {
+		t.Fatalf("NewCompressPolicy: %v", err)
+	}
+
+	msgs := conversation(8)
+	got, err := policy.Apply(context.Background(), msgs)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	// One summary followed by the 4 most recent turns, verbatim and in order.
+	if len(got) != 5 {
+		t.Fatalf("len(history) = %d, want 5", len(got))
+	}
+	if got[0].Role != "system" {
+		t.Errorf("summary role = %q, want %q", got[0].Role, "system")
+	}
+	if !strings.Contains(got[0].Content, "The party haggled over a sword.") {
+		t.Errorf("summary content = %q, want LLM summary", got[0].Content)
+	}
+	for i, m := range got[1:] {
+		if want := msgs[4+i]; m.Role != want.Role || m.Content != want.Content {
+			t.Errorf("recent[%d] = %+v, want %+v", i, m, want)
+		}
+	}
+
+	// Only the oldest half was sent to the LLM.
+	if len(p.CompleteCalls) != 1 {
+		t.Fatalf("expected 1 Complete call, got %d", len(p.CompleteCalls))
+	}
+	prompt := p.CompleteCalls[0].Req.Messages[0].Content
+	for i := range 8 {
+		turn := fmt.Sprintf("turn %d", i)
+		if inPrompt := strings.Contains(prompt, turn); inPrompt != (i < 4) {
+			t.Errorf("%q in summarisation prompt = %v, want %v", turn, inPrompt, i < 4)
+		}
+	}
+
+	// The caller's slice is left intact.
+	if msgs[0].Content != "turn 0" || len(msgs) != 8 {
+		t.Error("Apply modified the input slice")
+	}
+}
+
+func TestCompressPolicy_RunningSummary(t *testing.T) {
+	sum := &mockSummariser{result: "summary"}
+	policy, err := NewCompressPolicy(CompressPolicyConfig{Threshold: 4, Summariser: sum})
+	if err != nil {
+		t.Fatalf("NewCompressPolicy: %v", err)
+	}
+
+	history, err := policy.Apply(context.Background(), conversation(6))
+	if err != nil {
+		t.Fatalf("first Apply: %v", err)
+	}
+	history = append(history, conversation(4)...)
+	if _, err := policy.Apply(context.Background(), history); err != nil {
+		t.Fatalf("second Apply: %v", err)
+	}
+
+	// The earlier summary is folded into the next one.
+	if sum.calls != 2 {
+		t.Fatalf("expected 2 Summarise calls, got %d", sum.calls)
+	}
+	if first := sum.msgs[1][0]; first.Role != "system" || !strings.Contains(first.Content, "summary") {
+		t.Errorf("second compression input[0] = %+v, want previous summary", first)
+	}
+}
+
+func TestCompressPolicy_SummariserError(t *testing.T) {
+	p := &llmmock.Provider{CompleteErr: errors.New("rate limited")}
+	policy, err := NewCompressPolicy(CompressPolicyConfig{
+		Threshold:  2,
+		Summariser: NewLLMSummariser(p),
+	})
+	if err != nil {
+		t.Fatalf("NewCompressPolicy: %v", err)
+	}
+
+	got, err := policy.Apply(context.Background(), conversation(4))
+	if err == nil {
+		t.Fatal("expected error from failing summariser")
+	}
+	if got != nil {
+		t.Errorf("expected nil history on error, got %d messages", len(got))
+	}
+}
@@ -6,18 +6,15 @@ import (
 	"sync"
 
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	"github.com/MrWong99/glyphoxa/pkg/tokenize"
 )
 
-// charsPerToken is the heuristic ratio used for token estimation.
-// English text averages roughly 4 characters per token across common
-// LLM tokenizers. This avoids pulling in a tokenizer dependency.
-const charsPerToken = 4
-
 // ContextManager tracks token usage in a conversation and triggers
 // summarisation when approaching the context window limit.
 //
 // It maintains an ordered list of [llm.Message] values and accumulated
-// summaries. When the estimated token count exceeds thresholdRatio × maxTokens,
+// summaries. Token counts come from a [tokenize.Counter] matching the
+// provider's tokenizer. When the counted tokens exceed thresholdRatio × maxTokens,
 // the oldest half of the messages is summarised and replaced by a compact
 // summary message. This keeps the working set within the provider's context
 // window while preserving narratively important information.
@@ -27,6 +24,7 @@ type ContextManager struct {
 	maxTokens      int
 	thresholdRatio float64
 	summariser     Summariser
+	counter        tokenize.Counter
 
 	mu            sync.Mutex
 	currentTokens int
@@ -46,25 +44,36 @@ type ContextManagerConfig struct {
 	// Summariser is used to compress older messages when the threshold is
 	// exceeded. Must not be nil.
 	Summariser Summariser
+
+	// Counter counts message tokens. Use [tokenize.ForModel] to get the
+	// counter matching the provider's model. Defaults to
+	// [tokenize.Approximate] if nil.
+	Counter tokenize.Counter
 }
 
 // NewContextManager creates a new [ContextManager] with the given configuration.
-// If ThresholdRatio is zero or negative, 0.75 is used.
+// If ThresholdRatio is zero or negative, 0.75 is used. If Counter is nil,
+// [tokenize.Approximate] is used.
 func NewContextManager(cfg ContextManagerConfig) *ContextManager {
 	ratio := cfg.ThresholdRatio
 	if ratio <= 0 {
 		ratio = 0.75
 	}
+	counter := cfg.Counter
+	if counter == nil {
+		counter = tokenize.Approximate{}
+	}
 	return &ContextManager{
 		maxTokens:      cfg.MaxTokens,
 		thresholdRatio: ratio,
 		summariser:     cfg.Summariser,
+		counter:        counter,
 		messages:       make([]llm.Message, 0),
 		summaries:      make([]string, 0),
 	}
 }
 
-// AddMessages appends messages and estimates token count.
+// AddMessages appends messages and counts their tokens.
 // If the accumulated tokens exceed threshold × maxTokens, the oldest half
 // of the messages is automatically summarised and replaced.
 func (cm *ContextManager) AddMessages(ctx context.Context, msgs ...llm.Message) error {
@@ -72,7 +81,7 @@ func (cm *ContextManager) AddMessages(ctx context.Context, msgs ...llm.Message)
 	defer cm.mu.Unlock()
 
 	for _, m := range msgs {
-		tokens := estimateTokens(m)
+		tokens := countTokens(cm.counter, m)
 		cm.messages = append(cm.messages, m)
 		cm.currentTokens += tokens
 	}
@@ -140,7 +149,7 @@ func (cm *ContextManager) summariseOldest(ctx context.Context) error {
 	// don't depend on cm.messages[:half] being unchanged after relock.
 	removedTokens := 0
 	for _, m := range toSummarise {
-		removedTokens += estimateTokens(m)
+		removedTokens += countTokens(cm.counter, m)
 	}
 
 	// Temporarily release the lock for the (potentially slow) LLM call.
@@ -162,23 +171,19 @@ func (cm *ContextManager) summariseOldest(ctx context.Context) error {
 	cm.currentTokens -= removedTokens
 
 	// Add summary tokens.
-	summaryTokens := len(summary) / charsPerToken
+	summaryTokens := cm.counter.Count(summary)
 	cm.summaries = append(cm.summaries, summary)
 	cm.currentTokens += summaryTokens
 
 	return nil
 }
 
-// estimateTokens returns a rough token count for a single message using
-// the 1-token-per-4-characters heuristic.
-func estimateTokens(m llm.Message) int {
-	chars := len(m.Content) + len(m.Role) + len(m.Name)
+// countTokens returns the token count of a single message: its role, name,
+// content, and tool calls, each counted with c.
+func countTokens(c tokenize.Counter, m llm.Message) int {
+	tokens := c.Count(m.Role) + c.Count(m.Name) + c.Count(m.Content)
 	for _, tc := range m.ToolCalls {
-		chars += len(tc.Name) + len(tc.Arguments) + len(tc.ID)
-	}
-	tokens := chars / charsPerToken
-	if tokens == 0 && chars > 0 {
-		tokens = 1
+		tokens += c.Count(tc.ID) + c.Count(tc.Name) + c.Count(tc.Arguments)
 	}
 	return tokens
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	"github.com/MrWong99/glyphoxa/pkg/tokenize"
 )
 
 // mockSummariser is a test double for Summariser.
@@ -22,7 +23,7 @@ func (m *mockSummariser) Summarise(_ context.Context, messages []llm.Message) (s
 	return m.result, m.err
 }
 
-func TestEstimateTokens(t *testing.T) {
+func TestCountTokens(t *testing.T) {
 	tests := []struct {
 		name    string
 		msg     llm.Message
@@ -62,9 +63,9 @@ func TestEstimateTokens(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := estimateTokens(tt.msg)
+			got := countTokens(tokenize.Approximate{}, tt.msg)
 			if got < tt.wantMin || got > tt.wantMax {
-				t.Errorf("estimateTokens() = %d, want [%d, %d]", got, tt.wantMin, tt.wantMax)
+				t.Errorf("countTokens() = %d, want [%d, %d]", got, tt.wantMin, tt.wantMax)
 			}
 		})
 	}
@@ -228,3 +229,39 @@ func TestContextManager_TokenEstimate(t *testing.T) {
 		t.Errorf("expected token count to increase, got before=%d after=%d", before, after)
 	}
 }
+
+func TestContextManager_Counter(t *testing.T) {
+	counter, err := tokenize.NewTiktoken(tokenize.EncodingCL100K)
+	if err != nil {
+		t.Fatalf("NewTiktoken: %v", err)
+	}
+
+	// "お誕生日おめでとう" is 9 cl100k tokens but 27 bytes, so the character
+	// heuristic would count 7 tokens for the content.
+	s := &mockSummariser{result: "hello world"}
+	cm := NewContextManager(ContextManagerConfig{
+		MaxTokens:  20, // summarise above 15 tokens
+		Summariser: s,
+		Counter:    counter,
+	})
+
+	// role "user" = 1 token, content = 9 tokens.
+	if err := cm.AddMessages(context.Background(), llm.Message{Role: "user", Content: "お誕生日おめでとう"}); err != nil {
+		t.Fatalf("AddMessages: %v", err)
+	}
+	if got := cm.TokenEstimate(); got != 10 {
+		t.Fatalf("TokenEstimate() = %d, want 10", got)
+	}
+
+	// role "assistant" = 1 token, content = 6 tokens: 17 > 15 summarises the
+	// first message into "hello world" (2 tokens).
+	if err := cm.AddMessages(context.Background(), llm.Message{Role: "assistant", Content: "tiktoken is great!"}); err != nil {
+		t.Fatalf("AddMessages: %v", err)
+	}
+	if s.calls != 1 {
+		t.Fatalf("expected 1 summarisation call, got %d", s.calls)
+	}
+	if got := cm.TokenEstimate(); got != 17-10+2 {
+		t.Errorf("TokenEstimate() after summarisation = %d, want %d", got, 17-10+2)
+	}
+}
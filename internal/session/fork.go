@@ -0,0 +1,23 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// ErrForkUnsupported is returned by the ForkSession methods of the store
+// wrappers in this package when the wrapped store does not implement
+// [memory.SessionForker].
+var ErrForkUnsupported = errors.New("session: store does not support forking")
+
+// forkSession forks sourceID in store if store supports it.
+func forkSession(ctx context.Context, store memory.SessionStore, sourceID string, opts []memory.ForkOpt) (string, error) {
+	forker, ok := store.(memory.SessionForker)
+	if !ok {
+		return "", fmt.Errorf("fork session %q: %w", sourceID, ErrForkUnsupported)
+	}
+	return forker.ForkSession(ctx, sourceID, opts...)
+}
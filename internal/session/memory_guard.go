@@ -94,11 +94,32 @@ func (mg *MemoryGuard) EntryCount(ctx context.Context, sessionID string) (int, e
 	return n, nil
 }
 
+// ForkSession forks sourceID in the underlying store. Unlike the other
+// methods it does not swallow errors: a DM asking for a fork must learn that
+// it failed rather than continue on a session that does not exist. Returns
+// [ErrForkUnsupported] if the underlying store cannot fork sessions.
+func (mg *MemoryGuard) ForkSession(ctx context.Context, sourceID string, opts ...memory.ForkOpt) (string, error) {
+	return forkSession(ctx, mg.store, sourceID, opts)
+}
+
+// PruneTranscripts prunes the underlying store. Like ForkSession it does not
+// swallow errors, so the caller can tell a failed run from one that found
+// nothing to delete. Returns [ErrPruneUnsupported] if the underlying store
+// cannot prune transcripts.
+func (mg *MemoryGuard) PruneTranscripts(ctx context.Context, cutoff time.Time) (int, error) {
+	return pruneTranscripts(ctx, mg.store, cutoff)
+}
+
 // IsDegraded reports whether the store is currently operating in degraded
 // mode (i.e., the most recent operation on the underlying store failed).
 func (mg *MemoryGuard) IsDegraded() bool {
 	return mg.degraded.Load()
 }
 
-// Compile-time check that MemoryGuard satisfies memory.SessionStore.
-var _ memory.SessionStore = (*MemoryGuard)(nil)
+// Compile-time check that MemoryGuard satisfies memory.SessionStore,
+// memory.SessionForker and memory.TranscriptPruner.
+var (
+	_ memory.SessionStore     = (*MemoryGuard)(nil)
+	_ memory.SessionForker    = (*MemoryGuard)(nil)
+	_ memory.TranscriptPruner = (*MemoryGuard)(nil)
+)
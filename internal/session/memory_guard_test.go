@@ -185,3 +185,77 @@ func TestMemoryGuard_ImplementsSessionStore(t *testing.T) {
 	// This is a compile-time check, but let's also verify at runtime.
 	var _ memory.SessionStore = NewMemoryGuard(&memorymock.SessionStore{})
 }
+
+func TestMemoryGuard_ForkSession(t *testing.T) {
+	t.Run("forwards to the store", func(t *testing.T) {
+		store := &memorymock.SessionStore{ForkSessionResult: "s1-fork-abc"}
+		mg := NewMemoryGuard(store)
+
+		got, err := mg.ForkSession(context.Background(), "s1", memory.ForkChunks())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "s1-fork-abc" {
+			t.Errorf("ForkSession = %q, want %q", got, "s1-fork-abc")
+		}
+		calls := store.Calls()
+		if len(calls) != 1 || calls[0].Args[0] != "s1" || calls[0].Args[1] != (memory.ForkParams{Chunks: true}) {
+			t.Errorf("unexpected calls: %+v", calls)
+		}
+	})
+
+	t.Run("errors are not swallowed", func(t *testing.T) {
+		store := &memorymock.SessionStore{ForkSessionErr: memory.ErrSessionNotFound}
+		mg := NewMemoryGuard(store)
+
+		if _, err := mg.ForkSession(context.Background(), "s1"); !errors.Is(err, memory.ErrSessionNotFound) {
+			t.Errorf("expected ErrSessionNotFound, got %v", err)
+		}
+	})
+
+	t.Run("unsupported store", func(t *testing.T) {
+		mg := NewMemoryGuard(struct{ memory.SessionStore }{&memorymock.SessionStore{}})
+
+		if _, err := mg.ForkSession(context.Background(), "s1"); !errors.Is(err, ErrForkUnsupported) {
+			t.Errorf("expected ErrForkUnsupported, got %v", err)
+		}
+	})
+}
+
+func TestMemoryGuard_PruneTranscripts(t *testing.T) {
+	cutoff := time.Now().Add(-24 * time.Hour)
+
+	t.Run("forwards to the store", func(t *testing.T) {
+		store := &memorymock.SessionStore{PruneTranscriptsResult: 3}
+		mg := NewMemoryGuard(store)
+
+		n, err := mg.PruneTranscripts(context.Background(), cutoff)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 3 {
+			t.Errorf("PruneTranscripts = %d, want 3", n)
+		}
+		calls := store.Calls()
+		if len(calls) != 1 || !calls[0].Args[0].(time.Time).Equal(cutoff) {
+			t.Errorf("unexpected calls: %+v", calls)
+		}
+	})
+
+	t.Run("errors are not swallowed", func(t *testing.T) {
+		store := &memorymock.SessionStore{PruneTranscriptsErr: errors.New("connection refused")}
+		mg := NewMemoryGuard(store)
+
+		if _, err := mg.PruneTranscripts(context.Background(), cutoff); err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+
+	t.Run("unsupported store", func(t *testing.T) {
+		mg := NewMemoryGuard(struct{ memory.SessionStore }{&memorymock.SessionStore{}})
+
+		if _, err := mg.PruneTranscripts(context.Background(), cutoff); !errors.Is(err, ErrPruneUnsupported) {
+			t.Errorf("expected ErrPruneUnsupported, got %v", err)
+		}
+	})
+}
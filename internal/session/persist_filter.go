@@ -0,0 +1,181 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// DefaultFillers is the list of backchannel and filler words used by
+// [PersistFilter] when [PersistFilterConfig.SkipFillers] is set and no
+// custom list is given.
+var DefaultFillers = []string{
+	"ah", "aha", "alright", "cool", "er", "hm", "hmm", "huh", "mhm", "mm",
+	"no", "nope", "oh", "ok", "okay", "right", "sure", "uh", "uh-huh", "uhm",
+	"um", "wow", "yeah", "yep", "yes",
+}
+
+// PersistFilterConfig configures a [PersistFilter]. The zero value keeps
+// every utterance.
+type PersistFilterConfig struct {
+	// MinWords is the number of words a player utterance needs to be
+	// persisted. Zero disables the check.
+	MinWords int
+
+	// MinChars is the number of letters and digits a player utterance needs
+	// to be persisted. Zero disables the check.
+	MinChars int
+
+	// SkipFillers drops player utterances made up only of filler words
+	// such as "um" or "yeah".
+	SkipFillers bool
+
+	// Fillers replaces [DefaultFillers] when non-empty. Matching is
+	// case-insensitive.
+	Fillers []string
+}
+
+// PersistFilter decides which transcript entries are worth writing to the
+// session store. Backchannels like "um" or "yeah" bloat the L1 transcript and
+// pollute retrieval without carrying information, so they are skipped.
+//
+// Only player utterances are filtered; NPC lines are always kept. The filter
+// applies to persistence alone — skipped utterances still reach the voice
+// pipeline, transcript subscribers and interruption handling.
+//
+// PersistFilter holds no mutable state and is safe for concurrent use.
+type PersistFilter struct {
+	minWords int
+	minChars int
+	fillers  map[string]struct{} // nil unless SkipFillers is set
+}
+
+// NewPersistFilter creates a [PersistFilter] from cfg. Negative thresholds
+// are treated as zero.
+func NewPersistFilter(cfg PersistFilterConfig) *PersistFilter {
+	f := &PersistFilter{
+		minWords: max(cfg.MinWords, 0),
+		minChars: max(cfg.MinChars, 0),
+	}
+	if cfg.SkipFillers {
+		words := cfg.Fillers
+		if len(words) == 0 {
+			words = DefaultFillers
+		}
+		f.fillers = make(map[string]struct{}, len(words))
+		for _, w := range words {
+			f.fillers[strings.ToLower(strings.TrimSpace(w))] = struct{}{}
+		}
+	}
+	return f
+}
+
+// Enabled reports whether the filter can skip any entry.
+func (f *PersistFilter) Enabled() bool {
+	return f.minWords > 0 || f.minChars > 0 || f.fillers != nil
+}
+
+// Keep reports whether entry should be persisted.
+func (f *PersistFilter) Keep(entry memory.TranscriptEntry) bool {
+	if entry.IsNPC() || !f.Enabled() {
+		return true
+	}
+
+	words := splitWords(entry.Text)
+	if len(words) < f.minWords {
+		return false
+	}
+	if f.minChars > 0 {
+		chars := 0
+		for _, w := range words {
+			for _, r := range w {
+				if unicode.IsLetter(r) || unicode.IsDigit(r) {
+					chars++
+				}
+			}
+		}
+		if chars < f.minChars {
+			return false
+		}
+	}
+	if f.fillers != nil {
+		for _, w := range words {
+			if _, ok := f.fillers[w]; !ok {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// splitWords returns the lower-cased words of text. Apostrophes and hyphens
+// inside a word are kept, so "uh-huh" and "don't" count as one word.
+func splitWords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+	})
+	out := words[:0]
+	for _, w := range words {
+		if w = strings.Trim(w, "'-"); w != "" {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// FilteredStore wraps a [memory.SessionStore] and drops entries rejected by
+// a [PersistFilter] in WriteEntry. All other methods are passed through
+// unchanged.
+//
+// FilteredStore implements [memory.SessionStore] and is safe for concurrent
+// use if the wrapped store is.
+type FilteredStore struct {
+	memory.SessionStore
+	filter *PersistFilter
+}
+
+// NewFilteredStore creates a [FilteredStore] that writes to store only the
+// entries filter keeps.
+func NewFilteredStore(store memory.SessionStore, filter *PersistFilter) *FilteredStore {
+	return &FilteredStore{SessionStore: store, filter: filter}
+}
+
+// WriteEntry writes entry to the underlying store unless the filter rejects
+// it, in which case it returns nil without writing.
+func (fs *FilteredStore) WriteEntry(ctx context.Context, sessionID string, entry memory.TranscriptEntry) error {
+	if !fs.filter.Keep(entry) {
+		slog.Debug("session: skipping low-information utterance",
+			"session_id", sessionID,
+			"speaker", entry.SpeakerName,
+			"text", entry.Text,
+		)
+		return nil
+	}
+	return fs.SessionStore.WriteEntry(ctx, sessionID, entry)
+}
+
+// ForkSession forks sourceID in the underlying store. The source's entries
+// were filtered when written, so they are copied unchanged. Returns
+// [ErrForkUnsupported] if the underlying store cannot fork sessions.
+func (fs *FilteredStore) ForkSession(ctx context.Context, sourceID string, opts ...memory.ForkOpt) (string, error) {
+	return forkSession(ctx, fs.SessionStore, sourceID, opts)
+}
+
+// PruneTranscripts prunes the underlying store. Returns
+// [ErrPruneUnsupported] if the underlying store cannot prune transcripts.
+func (fs *FilteredStore) PruneTranscripts(ctx context.Context, cutoff time.Time) (int, error) {
+	return pruneTranscripts(ctx, fs.SessionStore, cutoff)
+}
+
+// Compile-time check that FilteredStore satisfies memory.SessionStore,
+// memory.SessionForker and memory.TranscriptPruner.
+var (
+	_ memory.SessionStore     = (*FilteredStore)(nil)
+	_ memory.SessionForker    = (*FilteredStore)(nil)
+	_ memory.TranscriptPruner = (*FilteredStore)(nil)
+)
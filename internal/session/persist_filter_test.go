@@ -0,0 +1,137 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	memorymock "github.com/MrWong99/glyphoxa/pkg/memory/mock"
+)
+
+func TestPersistFilter_Keep(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		cfg   PersistFilterConfig
+		entry memory.TranscriptEntry
+		want  bool
+	}{
+		{name: "zero config keeps everything", entry: memory.TranscriptEntry{Text: "um"}, want: true},
+		{name: "below min words", cfg: PersistFilterConfig{MinWords: 3}, entry: memory.TranscriptEntry{Text: "Sounds good."}, want: false},
+		{name: "at min words", cfg: PersistFilterConfig{MinWords: 3}, entry: memory.TranscriptEntry{Text: "Where's the blacksmith?"}, want: true},
+		{name: "punctuation is not a word", cfg: PersistFilterConfig{MinWords: 1}, entry: memory.TranscriptEntry{Text: " ... ?!"}, want: false},
+		{name: "below min chars", cfg: PersistFilterConfig{MinChars: 4}, entry: memory.TranscriptEntry{Text: "Ok!"}, want: false},
+		{name: "at min chars", cfg: PersistFilterConfig{MinChars: 4}, entry: memory.TranscriptEntry{Text: "Gold?"}, want: true},
+		{name: "filler only", cfg: PersistFilterConfig{SkipFillers: true}, entry: memory.TranscriptEntry{Text: "Um, yeah... uh-huh."}, want: false},
+		{name: "filler case insensitive", cfg: PersistFilterConfig{SkipFillers: true}, entry: memory.TranscriptEntry{Text: "OKAY"}, want: false},
+		{name: "filler with content", cfg: PersistFilterConfig{SkipFillers: true}, entry: memory.TranscriptEntry{Text: "Yeah, I'll take the sword."}, want: true},
+		{name: "custom fillers", cfg: PersistFilterConfig{SkipFillers: true, Fillers: []string{"Ja", "genau"}}, entry: memory.TranscriptEntry{Text: "Ja, genau."}, want: false},
+		{name: "custom fillers replace defaults", cfg: PersistFilterConfig{SkipFillers: true, Fillers: []string{"ja"}}, entry: memory.TranscriptEntry{Text: "yeah"}, want: true},
+		{name: "npc lines are kept", cfg: PersistFilterConfig{MinWords: 5, SkipFillers: true}, entry: memory.TranscriptEntry{Text: "Hmm.", NPCID: "grimjaw"}, want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := NewPersistFilter(tc.cfg).Keep(tc.entry); got != tc.want {
+				t.Errorf("Keep(%q) = %v, want %v", tc.entry.Text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPersistFilter_Enabled(t *testing.T) {
+	t.Parallel()
+
+	if NewPersistFilter(PersistFilterConfig{MinWords: -1}).Enabled() {
+		t.Error("filter with no positive threshold should be disabled")
+	}
+	if !NewPersistFilter(PersistFilterConfig{SkipFillers: true}).Enabled() {
+		t.Error("filter skipping fillers should be enabled")
+	}
+}
+
+func TestFilteredStore_WriteEntry(t *testing.T) {
+	t.Parallel()
+
+	store := &memorymock.SessionStore{}
+	fs := NewFilteredStore(store, NewPersistFilter(PersistFilterConfig{MinWords: 2, SkipFillers: true}))
+
+	utterances := []memory.TranscriptEntry{
+		{SpeakerID: "p1", Text: "Um."},
+		{SpeakerID: "p1", Text: "Yeah yeah."},
+		{SpeakerID: "p1", Text: "What do you know about the cult?"},
+		{SpeakerID: "grimjaw", NPCID: "grimjaw", Text: "Hmm."},
+		{SpeakerID: "p1", Text: "Okay"},
+	}
+	for _, e := range utterances {
+		if err := fs.WriteEntry(context.Background(), "s1", e); err != nil {
+			t.Fatalf("WriteEntry(%q): %v", e.Text, err)
+		}
+	}
+
+	var written []string
+	for _, c := range store.Calls() {
+		if c.Method == "WriteEntry" {
+			written = append(written, c.Args[1].(memory.TranscriptEntry).Text)
+		}
+	}
+	want := []string{"What do you know about the cult?", "Hmm."}
+	if len(written) != len(want) || written[0] != want[0] || written[1] != want[1] {
+		t.Errorf("written entries = %q, want %q", written, want)
+	}
+
+	// Reads pass through to the wrapped store.
+	if _, err := fs.GetRecent(context.Background(), "s1", 0); err != nil {
+		t.Fatalf("GetRecent: %v", err)
+	}
+	if store.CallCount("GetRecent") != 1 {
+		t.Errorf("GetRecent calls = %d, want 1", store.CallCount("GetRecent"))
+	}
+}
+
+func TestFilteredStore_ForkSession(t *testing.T) {
+	t.Parallel()
+
+	filter := NewPersistFilter(PersistFilterConfig{SkipFillers: true})
+
+	store := &memorymock.SessionStore{ForkSessionResult: "s1-fork-abc"}
+	fs := NewFilteredStore(store, filter)
+	got, err := fs.ForkSession(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("ForkSession: %v", err)
+	}
+	if got != "s1-fork-abc" || store.CallCount("ForkSession") != 1 {
+		t.Errorf("ForkSession = %q with %d calls, want the wrapped store's result", got, store.CallCount("ForkSession"))
+	}
+
+	unsupported := NewFilteredStore(struct{ memory.SessionStore }{&memorymock.SessionStore{}}, filter)
+	if _, err := unsupported.ForkSession(context.Background(), "s1"); !errors.Is(err, ErrForkUnsupported) {
+		t.Errorf("ForkSession on a store without fork support: got %v, want ErrForkUnsupported", err)
+	}
+}
+
+func TestFilteredStore_PruneTranscripts(t *testing.T) {
+	t.Parallel()
+
+	filter := NewPersistFilter(PersistFilterConfig{SkipFillers: true})
+
+	store := &memorymock.SessionStore{PruneTranscriptsResult: 2}
+	fs := NewFilteredStore(store, filter)
+	n, err := fs.PruneTranscripts(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("PruneTranscripts: %v", err)
+	}
+	if n != 2 || store.CallCount("PruneTranscripts") != 1 {
+		t.Errorf("PruneTranscripts = %d with %d calls, want the wrapped store's result", n, store.CallCount("PruneTranscripts"))
+	}
+
+	unsupported := NewFilteredStore(struct{ memory.SessionStore }{&memorymock.SessionStore{}}, filter)
+	if _, err := unsupported.PruneTranscripts(context.Background(), time.Now()); !errors.Is(err, ErrPruneUnsupported) {
+		t.Errorf("PruneTranscripts on a store without pruning support: got %v, want ErrPruneUnsupported", err)
+	}
+}
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// defaultPruneInterval is the default period between pruning runs.
+const defaultPruneInterval = time.Hour
+
+// ErrPruneUnsupported is returned by the PruneTranscripts methods of the
+// store wrappers in this package when the wrapped store does not implement
+// [memory.TranscriptPruner].
+var ErrPruneUnsupported = errors.New("session: store does not support pruning")
+
+// Pruner periodically deletes L1 transcript entries that are older than the
+// retention window and have been consolidated into L2, keeping the session
+// store small enough for fast queries. Which entries count as consolidated
+// is up to the store (see [memory.TranscriptPruner]); entries that are not
+// are never deleted.
+//
+// All methods are safe for concurrent use.
+type Pruner struct {
+	store     memory.TranscriptPruner
+	retention time.Duration
+	interval  time.Duration
+
+	mu       sync.Mutex
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// PrunerConfig configures a [Pruner].
+type PrunerConfig struct {
+	// Store is the L1 session store to prune.
+	Store memory.TranscriptPruner
+
+	// Retention is how long entries are kept. Consolidated entries older
+	// than Retention are deleted. Must be positive.
+	Retention time.Duration
+
+	// Interval is how often to prune. Defaults to 1 hour if zero.
+	Interval time.Duration
+}
+
+// NewPruner creates a new [Pruner] with the given configuration.
+func NewPruner(cfg PrunerConfig) *Pruner {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultPruneInterval
+	}
+	return &Pruner{
+		store:     cfg.Store,
+		retention: cfg.Retention,
+		interval:  interval,
+		done:      make(chan struct{}),
+	}
+}
+
+// Start prunes once and then periodically in a background goroutine.
+// The goroutine runs until [Pruner.Stop] is called or ctx is cancelled.
+func (p *Pruner) Start(ctx context.Context) {
+	go p.loop(ctx)
+}
+
+// Stop halts the pruning loop. Safe to call multiple times.
+func (p *Pruner) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// PruneNow performs an immediate pruning run and returns the number of
+// entries deleted.
+func (p *Pruner) PruneNow(ctx context.Context) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cutoff := time.Now().Add(-p.retention)
+	n, err := p.store.PruneTranscripts(ctx, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("prune transcripts before %s: %w", cutoff.Format(time.RFC3339), err)
+	}
+	return n, nil
+}
+
+// loop runs the periodic pruning ticker.
+func (p *Pruner) loop(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := p.PruneNow(ctx)
+		switch {
+		case err != nil:
+			slog.Warn("transcript pruning failed", "error", err)
+		case n > 0:
+			slog.Info("pruned transcript entries", "count", n, "retention", p.retention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pruneTranscripts prunes store if store supports it.
+func pruneTranscripts(ctx context.Context, store memory.SessionStore, cutoff time.Time) (int, error) {
+	pruner, ok := store.(memory.TranscriptPruner)
+	if !ok {
+		return 0, fmt.Errorf("prune transcripts: %w", ErrPruneUnsupported)
+	}
+	return pruner.PruneTranscripts(ctx, cutoff)
+}
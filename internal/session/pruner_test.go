@@ -0,0 +1,84 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	memorymock "github.com/MrWong99/glyphoxa/pkg/memory/mock"
+)
+
+func TestPruner_PruneNow(t *testing.T) {
+	t.Parallel()
+
+	t.Run("prunes entries older than the retention window", func(t *testing.T) {
+		t.Parallel()
+		store := &memorymock.SessionStore{PruneTranscriptsResult: 4}
+		p := NewPruner(PrunerConfig{Store: store, Retention: 24 * time.Hour})
+
+		before := time.Now()
+		n, err := p.PruneNow(context.Background())
+		after := time.Now()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 4 {
+			t.Errorf("PruneNow = %d, want 4", n)
+		}
+
+		calls := store.Calls()
+		if len(calls) != 1 {
+			t.Fatalf("expected 1 call, got %+v", calls)
+		}
+		cutoff := calls[0].Args[0].(time.Time)
+		if cutoff.Before(before.Add(-24*time.Hour)) || cutoff.After(after.Add(-24*time.Hour)) {
+			t.Errorf("cutoff = %v, want 24h before the run", cutoff)
+		}
+	})
+
+	t.Run("store error is returned", func(t *testing.T) {
+		t.Parallel()
+		storeErr := errors.New("connection refused")
+		store := &memorymock.SessionStore{PruneTranscriptsErr: storeErr}
+		p := NewPruner(PrunerConfig{Store: store, Retention: time.Hour})
+
+		if _, err := p.PruneNow(context.Background()); !errors.Is(err, storeErr) {
+			t.Errorf("expected store error, got %v", err)
+		}
+	})
+}
+
+func TestPruner_DefaultInterval(t *testing.T) {
+	t.Parallel()
+
+	p := NewPruner(PrunerConfig{Store: &memorymock.SessionStore{}, Retention: time.Hour})
+	if p.interval != time.Hour {
+		t.Errorf("expected default interval of 1h, got %v", p.interval)
+	}
+}
+
+func TestPruner_StartStop(t *testing.T) {
+	t.Parallel()
+
+	store := &memorymock.SessionStore{}
+	p := NewPruner(PrunerConfig{
+		Store:     store,
+		Retention: time.Hour,
+		Interval:  10 * time.Millisecond, // very short for testing
+	})
+
+	p.Start(t.Context())
+
+	// Wait long enough for the initial run and at least one tick.
+	time.Sleep(50 * time.Millisecond)
+
+	p.Stop()
+
+	if n := store.CallCount("PruneTranscripts"); n < 2 {
+		t.Errorf("expected an initial and at least one periodic run, got %d", n)
+	}
+
+	// Calling Stop again should not panic.
+	p.Stop()
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+// DefaultRecapWords is the recap length an [LLMRecapper] asks for when
+// created with a maxWords of zero.
+const DefaultRecapWords = 120
+
+// recapPrompt is the system prompt sent to the LLM when updating a scene
+// recap. The %d verb is the word limit.
+const recapPrompt = `You maintain the recap of an ongoing scene in a tabletop RPG session.
+You receive the current recap, which may be empty, and the conversation that followed it.
+Reply with the updated recap: a single paragraph of at most %d words, written in the past tense,
+that covers the whole scene so far. Keep who is present, what was decided, revealed or promised,
+and the current mood. Drop small talk. Reply with the recap only.`
+
+// LLMRecapper keeps a rolling one-paragraph scene recap up to date with an
+// LLM. It implements agent.SceneRecapper; each call sends only the previous
+// recap and the new turns, never the whole scene.
+type LLMRecapper struct {
+	llm      llm.Provider
+	maxWords int
+}
+
+// NewLLMRecapper creates a new [LLMRecapper] backed by the given provider
+// that keeps recaps to maxWords words. A maxWords of zero or less uses
+// [DefaultRecapWords].
+func NewLLMRecapper(provider llm.Provider, maxWords int) *LLMRecapper {
+	if maxWords <= 0 {
+		maxWords = DefaultRecapWords
+	}
+	return &LLMRecapper{llm: provider, maxWords: maxWords}
+}
+
+// Recap returns recap updated with turns. Without turns, recap is returned
+// unchanged and the LLM is not called. Replies longer than the configured
+// word limit are cut to it, so a verbose model cannot grow the prompt.
+func (r *LLMRecapper) Recap(ctx context.Context, recap string, turns []llm.Message) (string, error) {
+	if len(turns) == 0 {
+		return recap, nil
+	}
+
+	var sb strings.Builder
+	if recap != "" {
+		fmt.Fprintf(&sb, "Current recap: %s\n\n", recap)
+	} else {
+		sb.WriteString("Current recap: (none yet)\n\n")
+	}
+	sb.WriteString("Conversation since:\n")
+	for _, m := range turns {
+		speaker := m.Role
+		if m.Name != "" {
+			speaker = m.Name
+		}
+		fmt.Fprintf(&sb, "[%s]: %s\n", speaker, m.Content)
+	}
+
+	resp, err := r.llm.Complete(ctx, llm.CompletionRequest{
+		SystemPrompt: fmt.Sprintf(recapPrompt, r.maxWords),
+		Messages:     []llm.Message{{Role: "user", Content: sb.String()}},
+		Temperature:  0.3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("recap: %w", err)
+	}
+
+	words := strings.Fields(resp.Content)
+	if len(words) > r.maxWords {
+		words = words[:r.maxWords]
+	}
+	return strings.Join(words, " "), nil
+}
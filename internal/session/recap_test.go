@@ -0,0 +1,86 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+	llmmock "github.com/MrWong99/glyphoxa/pkg/provider/llm/mock"
+)
+
+func TestLLMRecapper_Recap(t *testing.T) {
+	t.Parallel()
+
+	turns := []llm.Message{
+		{Role: "user", Name: "Kara", Content: "We need a blade that cuts ghosts."},
+		{Role: "assistant", Name: "Brom", Content: "Silver, then. Forty gold."},
+	}
+
+	t.Run("no turns keeps the recap without calling the LLM", func(t *testing.T) {
+		t.Parallel()
+		p := &llmmock.Provider{}
+		got, err := NewLLMRecapper(p, 0).Recap(context.Background(), "old recap", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "old recap" {
+			t.Errorf("Recap = %q, want the old recap", got)
+		}
+		if len(p.CompleteCalls) != 0 {
+			t.Errorf("Complete calls = %d, want 0", len(p.CompleteCalls))
+		}
+	})
+
+	t.Run("sends the previous recap and the new turns", func(t *testing.T) {
+		t.Parallel()
+		p := &llmmock.Provider{
+			CompleteResponse: &llm.CompletionResponse{Content: "Kara asked Brom for a silver blade."},
+		}
+		got, err := NewLLMRecapper(p, 50).Recap(context.Background(), "The party entered the smithy.", turns)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "Kara asked Brom for a silver blade." {
+			t.Errorf("Recap = %q", got)
+		}
+
+		if len(p.CompleteCalls) != 1 {
+			t.Fatalf("Complete calls = %d, want 1", len(p.CompleteCalls))
+		}
+		req := p.CompleteCalls[0].Req
+		if !strings.Contains(req.SystemPrompt, "at most 50 words") {
+			t.Errorf("system prompt should carry the word limit, got %q", req.SystemPrompt)
+		}
+		content := req.Messages[0].Content
+		for _, want := range []string{"Current recap: The party entered the smithy.", "[Kara]: We need a blade", "[Brom]: Silver, then."} {
+			if !strings.Contains(content, want) {
+				t.Errorf("request content missing %q:\n%s", want, content)
+			}
+		}
+	})
+
+	t.Run("cuts replies to the word limit", func(t *testing.T) {
+		t.Parallel()
+		p := &llmmock.Provider{
+			CompleteResponse: &llm.CompletionResponse{Content: "one two  three\nfour five"},
+		}
+		got, err := NewLLMRecapper(p, 3).Recap(context.Background(), "", turns)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "one two three" {
+			t.Errorf("Recap = %q, want %q", got, "one two three")
+		}
+	})
+
+	t.Run("propagates LLM errors", func(t *testing.T) {
+		t.Parallel()
+		p := &llmmock.Provider{CompleteErr: errors.New("model overloaded")}
+		_, err := NewLLMRecapper(p, 0).Recap(context.Background(), "", turns)
+		if err == nil || !strings.Contains(err.Error(), "model overloaded") {
+			t.Errorf("want wrapped LLM error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,268 @@
+// Package vocab maintains the vocabulary of a campaign: the proper nouns of
+// its world, such as NPC, place and faction names, that general-purpose
+// speech recognition and language models tend to get wrong.
+//
+// A [Vocabulary] combines the names of all entities in the knowledge graph
+// with terms the DM configured. It is used in two places:
+//
+//   - As STT keyword hints: [Vocabulary.WrapSTT] returns an STT provider that
+//     requests the vocabulary with every stream it opens and pushes updates to
+//     streams that are still open, so players' words are recognised.
+//   - As a spelling reference in NPC prompts (see hotctx.WithVocabulary), so
+//     NPC replies and the transcripts built from them spell names correctly.
+//
+// The vocabulary grows with the graph: [Vocabulary.Refresh] reloads the
+// entity names and [Vocabulary.Add] adds names of entities created
+// mid-session.
+//
+// This package is internal because it encapsulates application-private voice
+// pipeline logic and is not intended for import by external code.
+package vocab
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+)
+
+const (
+	// DefaultMaxTerms is the default cap on the number of terms in a
+	// vocabulary. STT providers limit the number of keywords per request.
+	DefaultMaxTerms = 100
+
+	// DefaultBoost is the default keyword boost requested from STT providers.
+	DefaultBoost = 5.0
+)
+
+// Option is a functional option for [New].
+type Option func(*Vocabulary)
+
+// WithMaxTerms caps the number of terms. Configured terms are kept first,
+// then entity names in the order they were added. Values < 1 are ignored.
+// Defaults to [DefaultMaxTerms].
+func WithMaxTerms(n int) Option {
+	return func(v *Vocabulary) {
+		if n > 0 {
+			v.maxTerms = n
+		}
+	}
+}
+
+// WithBoost sets the keyword boost requested for every term. The scale is
+// provider-specific. Defaults to [DefaultBoost].
+func WithBoost(b float64) Option {
+	return func(v *Vocabulary) { v.boost = b }
+}
+
+// Vocabulary is the list of proper nouns of a campaign.
+//
+// Vocabulary is safe for concurrent use.
+type Vocabulary struct {
+	graph    memory.KnowledgeGraph // may be nil
+	maxTerms int
+	boost    float64
+
+	mu       sync.Mutex
+	fixed    []string // configured terms
+	names    []string // entity names, in the order they were added
+	terms    []string // fixed + names, deduplicated and capped
+	sessions map[*session]struct{}
+}
+
+// New creates a [Vocabulary] holding terms, the DM-provided vocabulary.
+// Entity names are loaded from graph by [Vocabulary.Refresh]; graph may be
+// nil when there is no knowledge graph.
+func New(graph memory.KnowledgeGraph, terms []string, opts ...Option) *Vocabulary {
+	v := &Vocabulary{
+		graph:    graph,
+		maxTerms: DefaultMaxTerms,
+		boost:    DefaultBoost,
+		fixed:    slices.Clone(terms),
+		sessions: make(map[*session]struct{}),
+	}
+	for _, o := range opts {
+		o(v)
+	}
+	v.terms = v.build()
+	return v
+}
+
+// Terms returns the vocabulary, configured terms first. The returned slice
+// must not be modified.
+func (v *Vocabulary) Terms() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.terms
+}
+
+// Keywords returns the vocabulary as STT keyword hints.
+func (v *Vocabulary) Keywords() []stt.KeywordBoost {
+	return v.keywords(v.Terms())
+}
+
+// Refresh reloads the entity names from the knowledge graph and updates open
+// STT streams if the vocabulary changed.
+func (v *Vocabulary) Refresh(ctx context.Context) error {
+	if v.graph == nil {
+		return nil
+	}
+	entities, err := v.graph.FindEntities(ctx, memory.EntityFilter{})
+	if err != nil {
+		return fmt.Errorf("vocab: load entity names: %w", err)
+	}
+	names := make([]string, 0, len(entities))
+	for _, e := range entities {
+		names = append(names, e.Name)
+	}
+	// Sort so the cap keeps the same names across refreshes.
+	slices.Sort(names)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.names = names
+	v.update()
+	return nil
+}
+
+// Add adds names, e.g. of entities created mid-session, and updates open STT
+// streams if the vocabulary changed.
+func (v *Vocabulary) Add(names ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.names = append(v.names, names...)
+	v.update()
+}
+
+// update rebuilds the terms and pushes them to the open streams if they
+// changed. Pushing under v.mu keeps concurrent updates in order. v.mu must be
+// held.
+func (v *Vocabulary) update() {
+	terms := v.build()
+	if slices.Equal(terms, v.terms) {
+		return
+	}
+	v.terms = terms
+	keywords := v.keywords(terms)
+	for s := range v.sessions {
+		s.setVocabulary(keywords)
+	}
+}
+
+// build returns the configured terms followed by the entity names, without
+// blanks and case-insensitive duplicates, capped at maxTerms. v.mu must be
+// held.
+func (v *Vocabulary) build() []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, t := range slices.Concat(v.fixed, v.names) {
+		t = strings.TrimSpace(t)
+		key := strings.ToLower(t)
+		if t == "" || seen[key] {
+			continue
+		}
+		if len(terms) == v.maxTerms {
+			break
+		}
+		seen[key] = true
+		terms = append(terms, t)
+	}
+	return terms
+}
+
+// keywords converts terms to keyword hints with the configured boost.
+func (v *Vocabulary) keywords(terms []string) []stt.KeywordBoost {
+	kws := make([]stt.KeywordBoost, len(terms))
+	for i, t := range terms {
+		kws[i] = stt.KeywordBoost{Keyword: t, Boost: v.boost}
+	}
+	return kws
+}
+
+// WrapSTT returns an STT provider that adds the vocabulary to the keywords
+// of every stream opened on p. While a stream is open, changes to the
+// vocabulary are passed on with [stt.SessionHandle.SetKeywords]; providers
+// that cannot update keywords mid-stream keep the vocabulary the stream was
+// opened with.
+func (v *Vocabulary) WrapSTT(p stt.Provider) stt.Provider {
+	return &provider{Provider: p, vocab: v}
+}
+
+// provider is the STT provider returned by [Vocabulary.WrapSTT].
+type provider struct {
+	stt.Provider
+	vocab *Vocabulary
+}
+
+// StartStream implements [stt.Provider].
+func (p *provider) StartStream(ctx context.Context, cfg stt.StreamConfig) (stt.SessionHandle, error) {
+	own, voc := cfg.Keywords, p.vocab.Keywords()
+	cfg.Keywords = mergeKeywords(own, voc)
+	h, err := p.Provider.StartStream(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := &session{SessionHandle: h, vocab: p.vocab, own: own, voc: voc}
+	p.vocab.mu.Lock()
+	p.vocab.sessions[s] = struct{}{}
+	p.vocab.mu.Unlock()
+	return s, nil
+}
+
+// session is an STT stream opened by a [provider]. It keeps the keywords
+// requested by the caller separate from the vocabulary so that updates to
+// either one keep the other.
+type session struct {
+	stt.SessionHandle
+	vocab *Vocabulary
+
+	mu  sync.Mutex
+	own []stt.KeywordBoost // keywords set by the caller
+	voc []stt.KeywordBoost // vocabulary last passed to the stream
+}
+
+// SetKeywords implements [stt.SessionHandle]. The vocabulary stays part of
+// the stream's keywords.
+func (s *session) SetKeywords(keywords []stt.KeywordBoost) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.own = keywords
+	return s.SessionHandle.SetKeywords(mergeKeywords(keywords, s.voc))
+}
+
+// setVocabulary passes a changed vocabulary on to the stream.
+func (s *session) setVocabulary(voc []stt.KeywordBoost) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.voc = voc
+	if err := s.SessionHandle.SetKeywords(mergeKeywords(s.own, voc)); err != nil {
+		// Most often the provider cannot update keywords mid-stream.
+		slog.Debug("vocab: STT stream keeps its previous keywords", "err", err)
+	}
+}
+
+// Close implements [stt.SessionHandle]. The stream no longer receives
+// vocabulary updates.
+func (s *session) Close() error {
+	s.vocab.mu.Lock()
+	delete(s.vocab.sessions, s)
+	s.vocab.mu.Unlock()
+	return s.SessionHandle.Close()
+}
+
+// mergeKeywords returns own followed by the entries of voc whose keyword is
+// not already in own.
+func mergeKeywords(own, voc []stt.KeywordBoost) []stt.KeywordBoost {
+	out := slices.Clone(own)
+	for _, kw := range voc {
+		if !slices.ContainsFunc(own, func(o stt.KeywordBoost) bool { return strings.EqualFold(o.Keyword, kw.Keyword) }) {
+			out = append(out, kw)
+		}
+	}
+	return out
+}
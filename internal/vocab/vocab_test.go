@@ -0,0 +1,150 @@
+package vocab_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/internal/vocab"
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	memorymock "github.com/MrWong99/glyphoxa/pkg/memory/mock"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	sttmock "github.com/MrWong99/glyphoxa/pkg/provider/stt/mock"
+)
+
+// keywordNames returns the keywords of kws in order.
+func keywordNames(kws []stt.KeywordBoost) []string {
+	names := make([]string, len(kws))
+	for i, kw := range kws {
+		names[i] = kw.Keyword
+	}
+	return names
+}
+
+func TestTerms(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		terms []string
+		add   []string
+		opts  []vocab.Option
+		want  []string
+	}{
+		{
+			name:  "configured terms first",
+			terms: []string{"Eldrinax", "Vel'Shara"},
+			add:   []string{"Thornwick"},
+			want:  []string{"Eldrinax", "Vel'Shara", "Thornwick"},
+		},
+		{
+			name:  "blanks and case-insensitive duplicates dropped",
+			terms: []string{" Eldrinax ", "", "eldrinax"},
+			add:   []string{"ELDRINAX", "Thornwick", "  "},
+			want:  []string{"Eldrinax", "Thornwick"},
+		},
+		{
+			name:  "capped",
+			terms: []string{"Eldrinax"},
+			add:   []string{"Thornwick", "Grimjaw"},
+			opts:  []vocab.Option{vocab.WithMaxTerms(2)},
+			want:  []string{"Eldrinax", "Thornwick"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			v := vocab.New(nil, tc.terms, tc.opts...)
+			v.Add(tc.add...)
+			if got := v.Terms(); !slices.Equal(got, tc.want) {
+				t.Errorf("Terms() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	t.Parallel()
+
+	kg := &memorymock.KnowledgeGraph{FindEntitiesResult: []memory.Entity{
+		{ID: "2", Name: "Thornwick"},
+		{ID: "1", Name: "Grimjaw"},
+	}}
+	v := vocab.New(kg, []string{"Eldrinax"})
+	if err := v.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	want := []string{"Eldrinax", "Grimjaw", "Thornwick"}
+	if got := v.Terms(); !slices.Equal(got, want) {
+		t.Errorf("Terms() = %q, want %q", got, want)
+	}
+
+	kg.FindEntitiesErr = errors.New("db down")
+	if err := v.Refresh(context.Background()); !errors.Is(err, kg.FindEntitiesErr) {
+		t.Errorf("Refresh error = %v, want it to wrap %v", err, kg.FindEntitiesErr)
+	}
+	if got := v.Terms(); !slices.Equal(got, want) {
+		t.Errorf("Terms() after failed refresh = %q, want %q", got, want)
+	}
+}
+
+func TestWrapSTT_StartStream(t *testing.T) {
+	t.Parallel()
+
+	inner := &sttmock.Provider{}
+	v := vocab.New(nil, []string{"Eldrinax", "Thornwick"}, vocab.WithBoost(3))
+	p := v.WrapSTT(inner)
+
+	own := []stt.KeywordBoost{{Keyword: "thornwick", Boost: 8}, {Keyword: "Grimjaw", Boost: 8}}
+	if _, err := p.StartStream(context.Background(), stt.StreamConfig{Keywords: own}); err != nil {
+		t.Fatalf("StartStream: %v", err)
+	}
+	if len(inner.StartStreamCalls) != 1 {
+		t.Fatalf("inner StartStream calls = %d, want 1", len(inner.StartStreamCalls))
+	}
+	got := inner.StartStreamCalls[0].Cfg.Keywords
+	want := []stt.KeywordBoost{
+		{Keyword: "thornwick", Boost: 8},
+		{Keyword: "Grimjaw", Boost: 8},
+		{Keyword: "Eldrinax", Boost: 3},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("requested keywords = %v, want %v", got, want)
+	}
+}
+
+func TestWrapSTT_UpdatesOpenStreams(t *testing.T) {
+	t.Parallel()
+
+	sess := &sttmock.Session{}
+	v := vocab.New(nil, []string{"Eldrinax"})
+	p := v.WrapSTT(&sttmock.Provider{Session: sess})
+
+	h, err := p.StartStream(context.Background(), stt.StreamConfig{})
+	if err != nil {
+		t.Fatalf("StartStream: %v", err)
+	}
+
+	v.Add("Thornwick")
+	v.Add("thornwick") // unchanged vocabulary: no update
+	if err := h.SetKeywords([]stt.KeywordBoost{{Keyword: "Grimjaw", Boost: 1}}); err != nil {
+		t.Fatalf("SetKeywords: %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	v.Add("Vel'Shara") // stream closed: no update
+
+	var got [][]string
+	for _, c := range sess.SetKeywordsCalls {
+		got = append(got, keywordNames(c.Keywords))
+	}
+	want := [][]string{
+		{"Eldrinax", "Thornwick"},
+		{"Grimjaw", "Eldrinax", "Thornwick"},
+	}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("SetKeywords calls = %q, want %q", got, want)
+	}
+}
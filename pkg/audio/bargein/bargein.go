@@ -0,0 +1,118 @@
+// Package bargein debounces player barge-in so that NPC speech is only
+// interrupted by sustained player speech, not by coughs, clicks, or other
+// short noises.
+//
+// A [Detector] consumes the per-frame [vad.VADEvent] stream of each speaker
+// and accumulates the duration of continuous speech. Once a speaker has been
+// talking for at least the configured grace period, the barge-in callback
+// (typically [audio.Mixer]'s BargeIn) fires exactly once for that speech
+// segment. Any silence or speech-end event resets the speaker's counter.
+//
+// Durations are accounted in audio time (frame count × frame duration), not
+// wall-clock time, so the detector is deterministic and independent of
+// processing jitter.
+package bargein
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+)
+
+// DefaultGracePeriod is the amount of continuous speech required before a
+// barge-in fires when no explicit grace period is configured.
+const DefaultGracePeriod = 300 * time.Millisecond
+
+// Option configures a [Detector] during construction.
+type Option func(*Detector)
+
+// WithGracePeriod sets how long a speaker must talk continuously before the
+// barge-in callback fires. A zero duration fires on the first speech frame.
+// Negative values are ignored.
+func WithGracePeriod(d time.Duration) Option {
+	return func(det *Detector) {
+		if d >= 0 {
+			det.grace = d
+		}
+	}
+}
+
+// speakerState tracks the current speech segment of a single speaker.
+type speakerState struct {
+	speech time.Duration // accumulated continuous speech in this segment
+	fired  bool          // barge-in already signalled for this segment
+}
+
+// Detector is a per-speaker debounced barge-in state machine.
+//
+// All exported methods are safe for concurrent use.
+type Detector struct {
+	onBargeIn func(speakerID string)
+	grace     time.Duration
+
+	mu       sync.Mutex
+	speakers map[string]*speakerState
+}
+
+// New creates a [Detector] that calls onBargeIn once per sustained speech
+// segment. onBargeIn must not be nil; it is invoked synchronously from
+// [Detector.Process] after the internal lock is released.
+func New(onBargeIn func(speakerID string), opts ...Option) *Detector {
+	d := &Detector{
+		onBargeIn: onBargeIn,
+		grace:     DefaultGracePeriod,
+		speakers:  make(map[string]*speakerState),
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+// GracePeriod returns the configured grace period.
+func (d *Detector) GracePeriod() time.Duration { return d.grace }
+
+// Process feeds one VAD result for speakerID's audio frame of length
+// frameDur. It returns true if this frame triggered a barge-in.
+//
+// [vad.VADSpeechStart] and [vad.VADSpeechContinue] extend the current speech
+// segment; [vad.VADSpeechEnd] and [vad.VADSilence] end it and reset the
+// counter, so short blips never accumulate across gaps.
+func (d *Detector) Process(speakerID string, ev vad.VADEvent, frameDur time.Duration) bool {
+	d.mu.Lock()
+	st, ok := d.speakers[speakerID]
+	if !ok {
+		st = &speakerState{}
+		d.speakers[speakerID] = st
+	}
+
+	fire := false
+	switch ev.Type {
+	case vad.VADSpeechStart:
+		// A new segment always starts from zero, even if no end was observed.
+		*st = speakerState{speech: frameDur}
+	case vad.VADSpeechContinue:
+		st.speech += frameDur
+	case vad.VADSpeechEnd, vad.VADSilence:
+		*st = speakerState{}
+	}
+	if !st.fired && st.speech > 0 && st.speech >= d.grace {
+		st.fired = true
+		fire = true
+	}
+	d.mu.Unlock()
+
+	if fire {
+		d.onBargeIn(speakerID)
+	}
+	return fire
+}
+
+// Reset clears the state of speakerID, e.g. when their audio stream is
+// restarted.
+func (d *Detector) Reset(speakerID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.speakers, speakerID)
+}
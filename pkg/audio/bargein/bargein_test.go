@@ -0,0 +1,178 @@
+package bargein_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio/bargein"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+)
+
+const frame = 20 * time.Millisecond
+
+// recorder collects barge-in callbacks.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) onBargeIn(speakerID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, speakerID)
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.calls)
+}
+
+var (
+	start    = vad.VADEvent{Type: vad.VADSpeechStart, Probability: 0.9}
+	cont     = vad.VADEvent{Type: vad.VADSpeechContinue, Probability: 0.9}
+	end      = vad.VADEvent{Type: vad.VADSpeechEnd, Probability: 0.1}
+	silence  = vad.VADEvent{Type: vad.VADSilence, Probability: 0.0}
+	speech   = func(n int) []vad.VADEvent { return append([]vad.VADEvent{start}, repeat(cont, n-1)...) }
+	blipThen = func(gap vad.VADEvent) []vad.VADEvent { return append(speech(3), gap) }
+)
+
+func repeat(ev vad.VADEvent, n int) []vad.VADEvent {
+	out := make([]vad.VADEvent, n)
+	for i := range out {
+		out[i] = ev
+	}
+	return out
+}
+
+func concat(seqs ...[]vad.VADEvent) []vad.VADEvent {
+	var out []vad.VADEvent
+	for _, s := range seqs {
+		out = append(out, s...)
+	}
+	return out
+}
+
+func TestDetector_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		grace     time.Duration
+		events    []vad.VADEvent
+		wantFires int
+		// wantFireAt is the 0-based index of the frame that fires the first
+		// barge-in, or -1 if none is expected.
+		wantFireAt int
+	}{
+		{
+			name:       "cough blip does not interrupt",
+			grace:      200 * time.Millisecond,
+			events:     concat(speech(3), []vad.VADEvent{end}, repeat(silence, 5)),
+			wantFireAt: -1,
+		},
+		{
+			name:  "repeated blips do not accumulate",
+			grace: 200 * time.Millisecond,
+			events: concat(
+				blipThen(end), blipThen(silence), blipThen(end), blipThen(silence),
+			),
+			wantFireAt: -1,
+		},
+		{
+			name:       "sustained speech interrupts after grace period",
+			grace:      200 * time.Millisecond,
+			events:     speech(15),
+			wantFires:  1,
+			wantFireAt: 9, // 10 frames × 20ms = 200ms
+		},
+		{
+			name:       "fires once per segment",
+			grace:      100 * time.Millisecond,
+			events:     concat(speech(20), []vad.VADEvent{end}, speech(6)),
+			wantFires:  2,
+			wantFireAt: 4,
+		},
+		{
+			name:       "zero grace fires on first speech frame",
+			grace:      0,
+			events:     speech(3),
+			wantFires:  1,
+			wantFireAt: 0,
+		},
+		{
+			name:       "speech start restarts the counter",
+			grace:      100 * time.Millisecond,
+			events:     concat(speech(4), speech(4)),
+			wantFireAt: -1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := &recorder{}
+			det := bargein.New(rec.onBargeIn, bargein.WithGracePeriod(tc.grace))
+
+			firstFire := -1
+			for i, ev := range tc.events {
+				if det.Process("player-1", ev, frame) && firstFire < 0 {
+					firstFire = i
+				}
+			}
+
+			if got := rec.count(); got != tc.wantFires {
+				t.Errorf("barge-in fired %d times, want %d", got, tc.wantFires)
+			}
+			if firstFire != tc.wantFireAt {
+				t.Errorf("first barge-in at frame %d, want %d", firstFire, tc.wantFireAt)
+			}
+		})
+	}
+}
+
+func TestDetector_PerSpeaker(t *testing.T) {
+	t.Parallel()
+
+	rec := &recorder{}
+	det := bargein.New(rec.onBargeIn, bargein.WithGracePeriod(60*time.Millisecond))
+
+	// Interleave two speakers: alice talks continuously, bob only blips.
+	det.Process("alice", start, frame)
+	det.Process("bob", start, frame)
+	det.Process("alice", cont, frame)
+	det.Process("bob", end, frame)
+	det.Process("alice", cont, frame)
+	det.Process("bob", silence, frame)
+
+	if len(rec.calls) != 1 || rec.calls[0] != "alice" {
+		t.Errorf("barge-in calls = %v, want [alice]", rec.calls)
+	}
+}
+
+func TestDetector_Reset(t *testing.T) {
+	t.Parallel()
+
+	rec := &recorder{}
+	det := bargein.New(rec.onBargeIn, bargein.WithGracePeriod(60*time.Millisecond))
+
+	det.Process("alice", start, frame)
+	det.Process("alice", cont, frame)
+	det.Reset("alice")
+	det.Process("alice", cont, frame)
+
+	if got := rec.count(); got != 0 {
+		t.Errorf("barge-in fired %d times after Reset, want 0", got)
+	}
+}
+
+func TestWithGracePeriod_Negative(t *testing.T) {
+	t.Parallel()
+
+	det := bargein.New(func(string) {}, bargein.WithGracePeriod(-time.Second))
+	if got := det.GracePeriod(); got != bargein.DefaultGracePeriod {
+		t.Errorf("GracePeriod() = %v, want default %v", got, bargein.DefaultGracePeriod)
+	}
+}
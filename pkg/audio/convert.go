@@ -1,6 +1,7 @@
 package audio
 
 import (
+	"bytes"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -55,36 +56,156 @@ func (c *FormatConverter) Convert(frame AudioFrame) AudioFrame {
 		)
 	})
 
-	pcm := frame.Data
-	currentRate := frame.SampleRate
-	currentChannels := frame.Channels
+	return AudioFrame{
+		Data:       convertPCM(frame.Data, Format{SampleRate: frame.SampleRate, Channels: frame.Channels}, c.Target),
+		SampleRate: c.Target.SampleRate,
+		Channels:   c.Target.Channels,
+		Timestamp:  frame.Timestamp,
+	}
+}
 
-	// Step 1: Resample first (avoids resampling stereo when target is mono).
-	if currentRate != c.Target.SampleRate {
-		if currentChannels == 1 {
-			pcm = ResampleMono16(pcm, currentRate, c.Target.SampleRate)
+// convertPCM converts 16-bit PCM from one format to another; see [Resample].
+func convertPCM(pcm []byte, from, to Format) []byte {
+	return Resample(pcm, from.SampleRate, to.SampleRate, from.Channels, to.Channels)
+}
+
+// Resample converts 16-bit little-endian PCM from srcRate Hz with srcCh
+// interleaved channels to dstRate Hz with dstCh channels. Mono and stereo are
+// supported: mono is upmixed by duplicating each sample into both channels,
+// stereo is downmixed by averaging L and R. Conversion order: resample first
+// (at the source channel count, so mono audio bound for stereo is resampled
+// once), then channel convert. Rates are converted by linear interpolation;
+// see [ResampleMono16].
+//
+// A converted output holds srcFrames*dstRate/srcRate frames, rounded down,
+// where srcFrames is len(pcm)/(2*srcCh); a trailing partial frame is dropped.
+// For example, 100 mono samples at 22050 Hz become 217 stereo frames (868
+// bytes) at 48000 Hz. If the formats match, or a rate or channel count is
+// unsupported, pcm is returned unchanged.
+func Resample(pcm []byte, srcRate, dstRate, srcCh, dstCh int) []byte {
+	if srcRate <= 0 || dstRate <= 0 || srcCh < 1 || srcCh > 2 || dstCh < 1 || dstCh > 2 {
+		return pcm
+	}
+	if srcRate != dstRate {
+		if srcCh == 1 {
+			pcm = ResampleMono16(pcm, srcRate, dstRate)
 		} else {
-			pcm = ResampleStereo16(pcm, currentRate, c.Target.SampleRate)
+			pcm = ResampleStereo16(pcm, srcRate, dstRate)
 		}
-		currentRate = c.Target.SampleRate
 	}
 
-	// Step 2: Channel conversion.
-	if currentChannels != c.Target.Channels {
-		if currentChannels == 1 && c.Target.Channels == 2 {
-			pcm = MonoToStereo(pcm)
-		} else if currentChannels == 2 && c.Target.Channels == 1 {
-			pcm = StereoToMono(pcm)
+	if srcCh == 1 && dstCh == 2 {
+		pcm = MonoToStereo(pcm)
+	} else if srcCh == 2 && dstCh == 1 {
+		pcm = StereoToMono(pcm)
+	}
+	return pcm
+}
+
+// StreamResampler converts a stream of 16-bit PCM that arrives in pieces,
+// like [Resample] converts it in one go: samples are interpolated across
+// piece boundaries, so the concatenated output of Write and Flush equals
+// Resample applied to the concatenated input. Pieces need not hold whole
+// frames.
+//
+// Create one per stream; it is not safe for concurrent use.
+type StreamResampler struct {
+	srcRate, dstRate int
+	srcCh, dstCh     int
+	passthrough      bool // formats match or are unsupported; Resample returns its input
+
+	partial []byte // trailing bytes of an incomplete source frame
+	frames  []byte // source frames from index base on, still needed
+	base    int    // index of the first frame in frames
+	total   int    // source frames received
+	emitted int    // output frames produced
+}
+
+// NewStreamResampler returns a StreamResampler from srcRate Hz with srcCh
+// channels to dstRate Hz with dstCh channels; see [Resample] for the
+// supported formats.
+func NewStreamResampler(srcRate, dstRate, srcCh, dstCh int) *StreamResampler {
+	unsupported := srcRate <= 0 || dstRate <= 0 || srcCh < 1 || srcCh > 2 || dstCh < 1 || dstCh > 2
+	return &StreamResampler{
+		srcRate:     srcRate,
+		dstRate:     dstRate,
+		srcCh:       srcCh,
+		dstCh:       dstCh,
+		passthrough: unsupported || (srcRate == dstRate && srcCh == dstCh),
+	}
+}
+
+// Write converts pcm, the next piece of the stream, and returns the output
+// that can be produced so far. Output frames that need the next source frame
+// for interpolation are held back until it arrives or Flush is called.
+func (r *StreamResampler) Write(pcm []byte) []byte {
+	if r.passthrough {
+		return pcm
+	}
+	frameSize := 2 * r.srcCh
+	buf := append(r.partial, pcm...)
+	whole := len(buf) - len(buf)%frameSize
+	r.frames = append(r.frames, buf[:whole]...)
+	r.partial = bytes.Clone(buf[whole:])
+	r.total += whole / frameSize
+	return r.produce(false)
+}
+
+// Flush returns the remaining output at the end of the stream. A trailing
+// partial frame is dropped, as [Resample] drops it.
+func (r *StreamResampler) Flush() []byte {
+	if r.passthrough {
+		return nil
+	}
+	r.partial = nil
+	return r.produce(true)
+}
+
+// produce interpolates the output frames whose source frames have arrived;
+// with final set, the last frames repeat the final source frame like
+// [ResampleMono16] does. Source frames no longer needed are released.
+func (r *StreamResampler) produce(final bool) []byte {
+	frameSize := 2 * r.srcCh
+	var out []byte
+	if r.srcRate == r.dstRate {
+		out = r.frames
+		r.frames, r.base, r.emitted = nil, r.total, r.total
+	} else {
+		ratio := float64(r.srcRate) / float64(r.dstRate)
+		limit := int(int64(r.total) * int64(r.dstRate) / int64(r.srcRate))
+		sample := func(frame, ch int) int16 {
+			off := (frame-r.base)*frameSize + 2*ch
+			return int16(r.frames[off]) | int16(r.frames[off+1])<<8
+		}
+		for ; r.emitted < limit; r.emitted++ {
+			srcPos := float64(r.emitted) * ratio
+			srcIdx := int(srcPos)
+			next := srcIdx + 1
+			if next >= r.total {
+				if !final {
+					break
+				}
+				next = srcIdx
+			}
+			frac := srcPos - float64(srcIdx)
+			for ch := range r.srcCh {
+				s0, s1 := sample(srcIdx, ch), sample(next, ch)
+				v := int16(float64(s0)*(1-frac) + float64(s1)*frac)
+				out = append(out, byte(v), byte(v>>8))
+			}
+		}
+		if keep := min(int(float64(r.emitted)*ratio), r.total); keep > r.base {
+			r.frames = r.frames[(keep-r.base)*frameSize:]
+			r.base = keep
 		}
-		currentChannels = c.Target.Channels
 	}
 
-	return AudioFrame{
-		Data:       pcm,
-		SampleRate: currentRate,
-		Channels:   currentChannels,
-		Timestamp:  frame.Timestamp,
+	if r.srcCh == 1 && r.dstCh == 2 {
+		out = MonoToStereo(out)
+	} else if r.srcCh == 2 && r.dstCh == 1 {
+		out = StereoToMono(out)
 	}
+	return out
 }
 
 // ConvertStream wraps an input channel with a conversion goroutine. It closes
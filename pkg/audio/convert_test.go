@@ -1,7 +1,9 @@
 package audio_test
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
 	"testing"
 
 	"github.com/MrWong99/glyphoxa/pkg/audio"
@@ -117,6 +119,95 @@ func TestResampleStereo16(t *testing.T) {
 	}
 }
 
+func TestResample_Length(t *testing.T) {
+	tests := []struct {
+		name                           string
+		inBytes                        int
+		srcRate, dstRate, srcCh, dstCh int
+		wantBytes                      int
+	}{
+		// 100 samples * 48000/22050 = 217.7 → 217 frames * 4 bytes.
+		{"22.05k mono to 48k stereo", 200, 22050, 48000, 1, 2, 868},
+		{"24k mono to 48k stereo", 960, 24000, 48000, 1, 2, 3840},
+		{"44.1k stereo to 48k stereo", 441 * 4, 44100, 48000, 2, 2, 480 * 4},
+		{"48k stereo to 16k mono", 960 * 4, 48000, 16000, 2, 1, 320 * 2},
+		{"same rate upmix", 20, 48000, 48000, 1, 2, 40},
+		{"same rate downmix", 40, 48000, 48000, 2, 1, 20},
+		// 4 whole frames plus half a frame: the partial frame is dropped.
+		{"partial stereo frame", 18, 16000, 48000, 2, 2, 48},
+		{"too short to resample", 2, 48000, 8000, 1, 1, 0},
+		{"matching format", 18, 48000, 48000, 2, 2, 18},
+		{"unsupported channels", 120, 16000, 48000, 6, 2, 120},
+		{"zero rate", 100, 0, 48000, 1, 2, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := audio.Resample(make([]byte, tt.inBytes), tt.srcRate, tt.dstRate, tt.srcCh, tt.dstCh)
+			if len(out) != tt.wantBytes {
+				t.Errorf("Resample(%d bytes, %d→%d Hz, %d→%d ch) = %d bytes, want %d",
+					tt.inBytes, tt.srcRate, tt.dstRate, tt.srcCh, tt.dstCh, len(out), tt.wantBytes)
+			}
+		})
+	}
+}
+
+func TestResample_MonoToStereo(t *testing.T) {
+	// 3 mono samples at 16kHz → 6 stereo frames at 32kHz with L == R.
+	pcm := samplesToBytes([]int16{0, 300, 600})
+	got := bytesToSamples(audio.Resample(pcm, 16000, 32000, 1, 2))
+	want := []int16{0, 0, 150, 150, 300, 300, 450, 450, 600, 600, 600, 600}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d samples, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sample[%d] = %d, want %d", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStreamResampler_MatchesResample(t *testing.T) {
+	t.Parallel()
+
+	// A ramp with a wiggle, so interpolation errors show up.
+	samples := make([]int16, 2205)
+	for i := range samples {
+		samples[i] = int16(i*7 - 6000 + (i%5)*300)
+	}
+	pcm := samplesToBytes(samples)
+	pcm = append(pcm, 0x7f) // trailing partial frame
+
+	tests := []struct {
+		name             string
+		srcRate, dstRate int
+		srcCh, dstCh     int
+	}{
+		{name: "upsample to discord", srcRate: 22050, dstRate: 48000, srcCh: 1, dstCh: 2},
+		{name: "downsample", srcRate: 24000, dstRate: 16000, srcCh: 1, dstCh: 1},
+		{name: "stereo upsample", srcRate: 16000, dstRate: 48000, srcCh: 2, dstCh: 2},
+		{name: "stereo to mono", srcRate: 22050, dstRate: 22050, srcCh: 2, dstCh: 1},
+		{name: "same format", srcRate: 22050, dstRate: 22050, srcCh: 1, dstCh: 1},
+	}
+	for _, tc := range tests {
+		for _, piece := range []int{1, 3, 4096, len(pcm)} {
+			t.Run(fmt.Sprintf("%s/%d", tc.name, piece), func(t *testing.T) {
+				t.Parallel()
+
+				want := audio.Resample(pcm, tc.srcRate, tc.dstRate, tc.srcCh, tc.dstCh)
+				r := audio.NewStreamResampler(tc.srcRate, tc.dstRate, tc.srcCh, tc.dstCh)
+				var got []byte
+				for off := 0; off < len(pcm); off += piece {
+					got = append(got, r.Write(pcm[off:min(off+piece, len(pcm))])...)
+				}
+				got = append(got, r.Flush()...)
+				if !bytes.Equal(got, want) {
+					t.Errorf("streamed output = %d bytes, differs from Resample's %d bytes", len(got), len(want))
+				}
+			})
+		}
+	}
+}
+
 func TestFormatConverter_NoOp(t *testing.T) {
 	conv := audio.FormatConverter{
 		Target: audio.Format{SampleRate: 48000, Channels: 2},
@@ -0,0 +1,213 @@
+// Package diarize attributes speech to individual speakers when several
+// people share one audio input stream, such as a room microphone used by a
+// whole table.
+//
+// Voice platforms like Discord deliver one stream per participant, so the
+// stream ID already identifies the speaker. A shared source breaks that
+// assumption. A [Diarizer] maps each speech segment received on a stream to a
+// stable speaker ID, which then becomes the TranscriptEntry.SpeakerID of the
+// utterance.
+//
+// [Clusterer] implements online diarization on top of a pluggable [Embedder]
+// that turns a segment into a voice fingerprint (speaker embedding). Segments
+// whose fingerprints are similar enough are attributed to the same speaker.
+// [Passthrough] is the default and attributes every segment to its stream.
+package diarize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+)
+
+// DefaultThreshold is the minimum cosine similarity between a segment's
+// embedding and a known speaker's centroid for the segment to be attributed
+// to that speaker.
+const DefaultThreshold = 0.75
+
+// Diarizer attributes speech segments to speakers.
+//
+// Implementations must be safe for concurrent use.
+type Diarizer interface {
+	// Attribute returns the stable speaker ID for segment, a complete
+	// utterance received on the input stream sourceID. The same voice on the
+	// same stream must map to the same ID for the lifetime of the Diarizer.
+	Attribute(ctx context.Context, sourceID string, segment audio.AudioFrame) (string, error)
+
+	// Forget discards what the Diarizer learned about the input stream
+	// sourceID, for example after the stream ended or its participant left.
+	Forget(sourceID string)
+}
+
+// Passthrough is a [Diarizer] that attributes every segment to the input
+// stream it arrived on. It is the right choice when every participant has
+// their own stream.
+type Passthrough struct{}
+
+// Compile-time interface assertions.
+var (
+	_ Diarizer = Passthrough{}
+	_ Diarizer = (*Clusterer)(nil)
+)
+
+// Attribute implements [Diarizer]. It returns sourceID.
+func (Passthrough) Attribute(_ context.Context, sourceID string, _ audio.AudioFrame) (string, error) {
+	return sourceID, nil
+}
+
+// Forget implements [Diarizer]. Passthrough keeps no state, so it does nothing.
+func (Passthrough) Forget(string) {}
+
+// Embedder computes speaker embeddings (voice fingerprints).
+//
+// Implementations must be safe for concurrent use.
+type Embedder interface {
+	// Embed returns the speaker embedding of segment. All embeddings returned
+	// by one Embedder must have the same dimensionality.
+	Embed(ctx context.Context, segment audio.AudioFrame) ([]float32, error)
+}
+
+// Option is a functional option for configuring a [Clusterer].
+type Option func(*Clusterer)
+
+// WithThreshold sets the minimum cosine similarity for a segment to be
+// attributed to a known speaker. Higher values split voices more eagerly.
+// Values outside (0, 1] are ignored.
+func WithThreshold(t float64) Option {
+	return func(c *Clusterer) {
+		if t > 0 && t <= 1 {
+			c.threshold = t
+		}
+	}
+}
+
+// WithMaxSpeakers caps the number of speakers per input stream. Once the cap
+// is reached, segments are attributed to the most similar known speaker even
+// below the threshold. Values < 1 (the default) mean no cap.
+func WithMaxSpeakers(n int) Option {
+	return func(c *Clusterer) {
+		if n > 0 {
+			c.maxSpeakers = n
+		}
+	}
+}
+
+// speaker is one cluster of segments attributed to the same voice.
+type speaker struct {
+	id       string
+	centroid []float32
+	count    int
+}
+
+// Clusterer is a [Diarizer] that groups segments into speakers by comparing
+// their embeddings against the running centroid of every speaker seen on the
+// same input stream.
+//
+// The first speaker of a stream is given the stream ID itself, so a stream
+// carrying a single voice is attributed exactly as with [Passthrough]. Further
+// speakers are numbered "<sourceID>#2", "<sourceID>#3", and so on.
+//
+// Clusterer is safe for concurrent use.
+type Clusterer struct {
+	embedder    Embedder
+	threshold   float64
+	maxSpeakers int
+
+	mu       sync.Mutex
+	speakers map[string][]*speaker // keyed by source ID
+}
+
+// NewClusterer creates a [Clusterer] that fingerprints segments with embedder.
+func NewClusterer(embedder Embedder, opts ...Option) *Clusterer {
+	c := &Clusterer{
+		embedder:  embedder,
+		threshold: DefaultThreshold,
+		speakers:  make(map[string][]*speaker),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Attribute implements [Diarizer]. It embeds segment and assigns it to the
+// most similar speaker of sourceID, or to a new speaker if none is similar
+// enough.
+func (c *Clusterer) Attribute(ctx context.Context, sourceID string, segment audio.AudioFrame) (string, error) {
+	emb, err := c.embedder.Embed(ctx, segment)
+	if err != nil {
+		return "", fmt.Errorf("diarize: embed segment: %w", err)
+	}
+	if len(emb) == 0 {
+		return "", errors.New("diarize: embedder returned an empty embedding")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	known := c.speakers[sourceID]
+	var (
+		best    *speaker
+		bestSim = math.Inf(-1)
+	)
+	for _, s := range known {
+		if len(s.centroid) != len(emb) {
+			return "", fmt.Errorf("diarize: embedding has %d dimensions, want %d", len(emb), len(s.centroid))
+		}
+		if sim := cosine(s.centroid, emb); sim > bestSim {
+			best, bestSim = s, sim
+		}
+	}
+
+	capped := c.maxSpeakers > 0 && len(known) >= c.maxSpeakers
+	if best != nil && (bestSim >= c.threshold || capped) {
+		best.add(emb)
+		return best.id, nil
+	}
+
+	id := sourceID
+	if len(known) > 0 {
+		id = fmt.Sprintf("%s#%d", sourceID, len(known)+1)
+	}
+	c.speakers[sourceID] = append(known, &speaker{
+		id:       id,
+		centroid: append([]float32(nil), emb...),
+		count:    1,
+	})
+	return id, nil
+}
+
+// Forget implements [Diarizer]. It discards the speakers known for
+// sourceID.
+func (c *Clusterer) Forget(sourceID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.speakers, sourceID)
+}
+
+// add folds emb into the speaker's running-mean centroid.
+func (s *speaker) add(emb []float32) {
+	s.count++
+	for i, v := range emb {
+		s.centroid[i] += (v - s.centroid[i]) / float32(s.count)
+	}
+}
+
+// cosine returns the cosine similarity of a and b, which must have the same
+// length. Zero vectors have similarity 0 with everything.
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,162 @@
+package diarize_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/audio/diarize"
+)
+
+// fakeEmbedder returns the embedding registered for the first byte of a
+// segment, standing in for a speaker-embedding model.
+type fakeEmbedder struct {
+	mu     sync.Mutex
+	voices map[byte][]float32
+	err    error
+}
+
+func (f *fakeEmbedder) Embed(_ context.Context, segment audio.AudioFrame) ([]float32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.voices[segment.Data[0]], nil
+}
+
+// voice returns a segment spoken by the voice registered under v.
+func voice(v byte) audio.AudioFrame {
+	return audio.AudioFrame{Data: []byte{v, 0, 0, 0}, SampleRate: 16000, Channels: 1}
+}
+
+// Voices A and a are near-identical; B and C are clearly different.
+func newFakeEmbedder() *fakeEmbedder {
+	return &fakeEmbedder{voices: map[byte][]float32{
+		'A': {1, 0, 0},
+		'a': {0.95, 0.1, 0}, // A on a bad day
+		'B': {0, 1, 0},
+		'C': {0, 0, 1},
+	}}
+}
+
+func TestPassthrough(t *testing.T) {
+	t.Parallel()
+
+	var d diarize.Diarizer = diarize.Passthrough{}
+	got, err := d.Attribute(context.Background(), "user-1", voice('A'))
+	if err != nil || got != "user-1" {
+		t.Errorf("Attribute = (%q, %v), want (%q, nil)", got, err, "user-1")
+	}
+}
+
+func TestClusterer_Attribute(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		opts   []diarize.Option
+		source string
+		voices string
+		want   []string
+	}{
+		{
+			name:   "single voice keeps the stream ID",
+			source: "mic",
+			voices: "AaA",
+			want:   []string{"mic", "mic", "mic"},
+		},
+		{
+			name:   "new voices get numbered IDs",
+			source: "mic",
+			voices: "ABaCB",
+			want:   []string{"mic", "mic#2", "mic", "mic#3", "mic#2"},
+		},
+		{
+			name:   "strict threshold splits similar voices",
+			opts:   []diarize.Option{diarize.WithThreshold(0.999)},
+			source: "mic",
+			voices: "Aa",
+			want:   []string{"mic", "mic#2"},
+		},
+		{
+			name:   "max speakers assigns the nearest",
+			opts:   []diarize.Option{diarize.WithMaxSpeakers(2)},
+			source: "mic",
+			voices: "ABC",
+			want:   []string{"mic", "mic#2", "mic"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := diarize.NewClusterer(newFakeEmbedder(), tc.opts...)
+			for i, v := range []byte(tc.voices) {
+				got, err := c.Attribute(context.Background(), tc.source, voice(v))
+				if err != nil {
+					t.Fatalf("segment %d: Attribute error: %v", i, err)
+				}
+				if got != tc.want[i] {
+					t.Errorf("segment %d (%c) attributed to %q, want %q", i, v, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClusterer_SourcesAreIndependent(t *testing.T) {
+	t.Parallel()
+
+	c := diarize.NewClusterer(newFakeEmbedder())
+	ctx := context.Background()
+
+	for _, step := range []struct {
+		source string
+		voice  byte
+		want   string
+	}{
+		{"table", 'A', "table"},
+		{"table", 'B', "table#2"},
+		{"alice", 'B', "alice"}, // same voice, different stream
+		{"table", 'B', "table#2"},
+	} {
+		got, err := c.Attribute(ctx, step.source, voice(step.voice))
+		if err != nil {
+			t.Fatalf("Attribute(%q) error: %v", step.source, err)
+		}
+		if got != step.want {
+			t.Errorf("Attribute(%q, %c) = %q, want %q", step.source, step.voice, got, step.want)
+		}
+	}
+
+	c.Forget("table")
+	if got, _ := c.Attribute(ctx, "table", voice('B')); got != "table" {
+		t.Errorf("after Forget, Attribute = %q, want %q", got, "table")
+	}
+}
+
+func TestClusterer_Errors(t *testing.T) {
+	t.Parallel()
+
+	embedErr := errors.New("model not loaded")
+	c := diarize.NewClusterer(&fakeEmbedder{err: embedErr})
+	if _, err := c.Attribute(context.Background(), "mic", voice('A')); !errors.Is(err, embedErr) {
+		t.Errorf("Attribute error = %v, want %v", err, embedErr)
+	}
+
+	emb := newFakeEmbedder()
+	emb.voices['X'] = []float32{1, 0} // wrong dimensionality
+	c = diarize.NewClusterer(emb)
+	if _, err := c.Attribute(context.Background(), "mic", voice('A')); err != nil {
+		t.Fatalf("Attribute error: %v", err)
+	}
+	if _, err := c.Attribute(context.Background(), "mic", voice('X')); err == nil {
+		t.Error("Attribute with mismatched dimensions should return an error")
+	}
+	if _, err := c.Attribute(context.Background(), "mic", voice('?')); err == nil {
+		t.Error("Attribute with an empty embedding should return an error")
+	}
+}
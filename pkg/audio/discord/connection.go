@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/audio/opus"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -19,6 +20,10 @@ const (
 	outputChannelBuffer = 64
 )
 
+// encoders is shared by all connections so that the Opus encoder of a
+// finished send loop is reused by the next one.
+var encoders opus.EncoderPool
+
 // Connection wraps a discordgo.VoiceConnection and adapts it to the
 // [audio.Connection] interface. It demuxes incoming Opus packets by SSRC
 // into per-participant PCM input streams, and encodes outgoing PCM frames
@@ -32,7 +37,7 @@ type Connection struct {
 
 	inputsMu sync.RWMutex
 	inputs   map[string]chan audio.AudioFrame // keyed by SSRC string
-	ssrcUser map[uint32]string                // SSRC -> userID mapping
+	ssrcUser map[uint32]string                // SSRC -> userID, from speaking updates
 
 	output chan audio.AudioFrame
 
@@ -66,6 +71,9 @@ func newConnection(vc *discordgo.VoiceConnection, session *discordgo.Session, gu
 	// Register a VoiceStateUpdate handler to detect participant join/leave.
 	c.removeHandler = session.AddHandler(c.handleVoiceStateUpdate)
 
+	// Learn which user transmits on which SSRC.
+	vc.AddHandler(c.handleSpeakingUpdate)
+
 	// Start the receive loop (reads Opus from Discord, demuxes by SSRC, decodes to PCM).
 	go c.recvLoop()
 
@@ -131,7 +139,7 @@ func (c *Connection) Disconnect() error {
 // by SSRC, decodes Opus to PCM, and delivers AudioFrames to per-participant channels.
 func (c *Connection) recvLoop() {
 	// Each SSRC gets its own decoder to maintain state across frames.
-	decoders := make(map[uint32]*opusDecoder)
+	decoders := make(map[uint32]*opus.Decoder)
 
 	for {
 		select {
@@ -152,7 +160,7 @@ func (c *Connection) recvLoop() {
 			dec, exists := decoders[ssrc]
 			if !exists {
 				var err error
-				dec, err = newOpusDecoder()
+				dec, err = opus.NewDecoder()
 				if err != nil {
 					slog.Error("discord: failed to create opus decoder", "ssrc", ssrcStr, "error", err)
 					continue
@@ -166,7 +174,6 @@ func (c *Connection) recvLoop() {
 			if !chExists {
 				ch = make(chan audio.AudioFrame, inputChannelBuffer)
 				c.inputs[ssrcStr] = ch
-				c.ssrcUser[ssrc] = ssrcStr
 			}
 			c.inputsMu.Unlock()
 
@@ -178,7 +185,7 @@ func (c *Connection) recvLoop() {
 				})
 			}
 
-			pcm, err := dec.decode(pkt.Opus)
+			pcm, err := dec.Decode(pkt.Opus)
 			if err != nil {
 				slog.Warn("discord: opus decode error", "ssrc", ssrcStr, "error", err)
 				continue
@@ -186,9 +193,9 @@ func (c *Connection) recvLoop() {
 
 			frame := audio.AudioFrame{
 				Data:       pcm,
-				SampleRate: opusSampleRate,
-				Channels:   opusChannels,
-				Timestamp:  time.Duration(pkt.Timestamp) * time.Second / time.Duration(opusSampleRate),
+				SampleRate: opus.SampleRate,
+				Channels:   opus.Channels,
+				Timestamp:  time.Duration(pkt.Timestamp) * time.Second / time.Duration(opus.SampleRate),
 			}
 
 			select {
@@ -205,21 +212,18 @@ func (c *Connection) recvLoop() {
 // chunks, encodes them to Opus, and sends the encoded data via the Discord
 // voice connection.
 func (c *Connection) sendLoop() {
-	enc, err := newOpusEncoder()
+	enc, err := encoders.Get()
 	if err != nil {
 		slog.Error("discord: failed to create opus encoder", "error", err)
 		return
 	}
+	defer encoders.Put(enc)
 
-	conv := audio.FormatConverter{Target: audio.Format{SampleRate: opusSampleRate, Channels: opusChannels}}
+	conv := audio.FormatConverter{Target: audio.Format{SampleRate: opus.SampleRate, Channels: opus.Channels}}
 
 	// Signal speaking when we start sending audio.
 	speakingSet := false
 
-	// opusFrameBytes is the exact PCM input size for one Opus frame:
-	// 960 samples/channel × 2 channels × 2 bytes/sample = 3840 bytes.
-	const opusFrameBytes = opusFrameSize * opusChannels * 2
-
 	var buf []byte
 
 	for {
@@ -246,17 +250,17 @@ func (c *Connection) sendLoop() {
 			buf = append(buf, data...)
 
 			// Encode and send complete Opus frames.
-			for len(buf) >= opusFrameBytes {
-				opus, eErr := enc.encode(buf[:opusFrameBytes])
+			for len(buf) >= opus.FrameBytes {
+				packet, eErr := enc.Encode(buf[:opus.FrameBytes])
 				if eErr != nil {
 					slog.Warn("discord: opus encode error", "error", eErr)
-					buf = buf[opusFrameBytes:]
+					buf = buf[opus.FrameBytes:]
 					continue
 				}
-				buf = buf[opusFrameBytes:]
+				buf = buf[opus.FrameBytes:]
 
 				select {
-				case c.vc.OpusSend <- opus:
+				case c.vc.OpusSend <- packet:
 				case <-c.done:
 					return
 				}
@@ -319,9 +323,34 @@ func (c *Connection) emitEvent(ev audio.Event) {
 	}
 }
 
-// SSRCToUserID returns the user ID associated with the given SSRC, if known.
-// This mapping is populated as audio packets arrive and VoiceStateUpdate events
-// provide user identity. Returns an empty string if the SSRC is unknown.
+// handleSpeakingUpdate records the user transmitting on the SSRC of vs.
+// Discord announces an SSRC's user in a speaking update when they start to
+// speak.
+func (c *Connection) handleSpeakingUpdate(_ *discordgo.VoiceConnection, vs *discordgo.VoiceSpeakingUpdate) {
+	if vs.UserID == "" {
+		return
+	}
+	c.inputsMu.Lock()
+	defer c.inputsMu.Unlock()
+	c.ssrcUser[uint32(vs.SSRC)] = vs.UserID
+}
+
+// ParticipantID implements [audio.ParticipantResolver]. Input streams are
+// keyed by SSRC; ParticipantID returns the ID of the user transmitting on
+// streamID, or "" if Discord has not announced it yet.
+func (c *Connection) ParticipantID(streamID string) string {
+	ssrc, err := strconv.ParseUint(streamID, 10, 32)
+	if err != nil {
+		return ""
+	}
+	c.inputsMu.RLock()
+	defer c.inputsMu.RUnlock()
+	return c.ssrcUser[uint32(ssrc)]
+}
+
+// SSRCToUserID returns the user ID associated with the given SSRC, as
+// announced by Discord's speaking updates. Returns the SSRC as a string if
+// the SSRC is unknown.
 func (c *Connection) SSRCToUserID(ssrc uint32) string {
 	c.inputsMu.RLock()
 	defer c.inputsMu.RUnlock()
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/audio/opus"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -13,6 +14,7 @@ import (
 
 var _ audio.Platform = (*Platform)(nil)
 var _ audio.Connection = (*Connection)(nil)
+var _ audio.ParticipantResolver = (*Connection)(nil)
 
 // ─── test helpers ─────────────────────────────────────────────────────────────
 
@@ -195,11 +197,11 @@ func TestConnection_RecvDemux(t *testing.T) {
 	for ssrc, ch := range streams {
 		select {
 		case frame := <-ch:
-			if frame.SampleRate != opusSampleRate {
-				t.Errorf("SSRC %s: SampleRate = %d, want %d", ssrc, frame.SampleRate, opusSampleRate)
+			if frame.SampleRate != opus.SampleRate {
+				t.Errorf("SSRC %s: SampleRate = %d, want %d", ssrc, frame.SampleRate, opus.SampleRate)
 			}
-			if frame.Channels != opusChannels {
-				t.Errorf("SSRC %s: Channels = %d, want %d", ssrc, frame.Channels, opusChannels)
+			if frame.Channels != opus.Channels {
+				t.Errorf("SSRC %s: Channels = %d, want %d", ssrc, frame.Channels, opus.Channels)
 			}
 			if len(frame.Data) == 0 {
 				t.Errorf("SSRC %s: frame data is empty", ssrc)
@@ -210,6 +212,34 @@ func TestConnection_RecvDemux(t *testing.T) {
 	}
 }
 
+// TestConnection_ParticipantID verifies that input streams, keyed by SSRC,
+// resolve to the user announced in a speaking update, also after the SSRC's
+// first packets arrived.
+func TestConnection_ParticipantID(t *testing.T) {
+	t.Parallel()
+
+	c := newTestConnection(t)
+	c.handleSpeakingUpdate(c.vc, &discordgo.VoiceSpeakingUpdate{UserID: "user-1", SSRC: 100, Speaking: true})
+	c.vc.OpusRecv <- &discordgo.Packet{SSRC: 100, Opus: []byte{0xF8, 0xFF, 0xFE}}
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-c.InputStreams()["100"]:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for frame")
+	}
+
+	tests := map[string]string{
+		"100":      "user-1",
+		"200":      "", // not announced yet
+		"not-ssrc": "",
+	}
+	for stream, want := range tests {
+		if got := c.ParticipantID(stream); got != want {
+			t.Errorf("ParticipantID(%q) = %q, want %q", stream, got, want)
+		}
+	}
+}
+
 // TestConnection_SendEncodes verifies that frames written to OutputStream
 // are encoded and appear on OpusSend.
 func TestConnection_SendEncodes(t *testing.T) {
@@ -219,19 +249,19 @@ func TestConnection_SendEncodes(t *testing.T) {
 
 	// Create a PCM frame of the right size for 20ms stereo 48kHz.
 	// 960 samples * 2 channels * 2 bytes/sample = 3840 bytes.
-	pcmSize := opusFrameSize * opusChannels * 2
+	pcmSize := opus.FrameBytes
 	pcm := make([]byte, pcmSize)
 	frame := audio.AudioFrame{
 		Data:       pcm,
-		SampleRate: opusSampleRate,
-		Channels:   opusChannels,
+		SampleRate: opus.SampleRate,
+		Channels:   opus.Channels,
 	}
 
 	c.OutputStream() <- frame
 
 	select {
-	case opus := <-c.vc.OpusSend:
-		if len(opus) == 0 {
+	case packet := <-c.vc.OpusSend:
+		if len(packet) == 0 {
 			t.Error("OpusSend: received empty Opus packet")
 		}
 	case <-time.After(time.Second):
@@ -0,0 +1,115 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// FileFormat identifies the container or encoding of an audio file.
+type FileFormat string
+
+const (
+	// FileFormatPCM is headerless 16-bit little-endian PCM. Its sample rate and
+	// channel count cannot be detected and must be supplied by the caller.
+	FileFormatPCM FileFormat = "pcm"
+
+	// FileFormatWAV is a RIFF/WAVE container holding 16-bit integer PCM.
+	FileFormatWAV FileFormat = "wav"
+
+	// FileFormatMP3 is an MPEG-1/2 Layer III stream, optionally preceded by an
+	// ID3v2 tag.
+	FileFormatMP3 FileFormat = "mp3"
+)
+
+// SniffFileFormat detects the format of an audio file from its leading magic
+// bytes. Data that is neither WAV nor MP3 is reported as [FileFormatPCM],
+// since headerless PCM has no signature of its own.
+func SniffFileFormat(data []byte) FileFormat {
+	switch {
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return FileFormatWAV
+	case len(data) >= 3 && string(data[0:3]) == "ID3":
+		return FileFormatMP3
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0 && data[1]&0x06 == 0x02:
+		// MPEG audio frame sync (11 set bits) with layer bits = Layer III.
+		return FileFormatMP3
+	default:
+		return FileFormatPCM
+	}
+}
+
+// DecodeFile sniffs the format of an audio file and converts it to 16-bit
+// little-endian PCM in the target format.
+//
+// WAV files must contain 16-bit integer PCM; their format is read from the
+// header. MP3 files are decoded in full. Any other input is treated as
+// headerless PCM in the raw format, which must be set in that case.
+//
+// Headerless PCM can start with bytes that look like an MPEG frame sync. If
+// the raw format is set and data sniffed as MP3 fails to decode, it is
+// treated as raw PCM instead.
+func DecodeFile(data []byte, raw, target Format) ([]byte, error) {
+	var (
+		pcm []byte
+		src Format
+	)
+	switch format := SniffFileFormat(data); format {
+	case FileFormatWAV:
+		info, err := ParseWAV(data)
+		if err != nil {
+			return nil, err
+		}
+		if info.AudioFormat != 1 || info.BitsPerSample != 16 {
+			return nil, fmt.Errorf("audio: unsupported WAV encoding (format tag %d, %d bits); only 16-bit PCM is supported",
+				info.AudioFormat, info.BitsPerSample)
+		}
+		pcm = data[info.DataOffset : info.DataOffset+info.DataSize]
+		src = Format{SampleRate: info.SampleRate, Channels: info.Channels}
+	case FileFormatMP3:
+		var err error
+		pcm, src, err = decodeMP3(data)
+		if err != nil {
+			if raw.SampleRate <= 0 || raw.Channels <= 0 {
+				return nil, err
+			}
+			pcm, src = data, raw
+		}
+	default:
+		if raw.SampleRate <= 0 || raw.Channels <= 0 {
+			return nil, errors.New("audio: input is not WAV or MP3 and no raw PCM format was given")
+		}
+		pcm = data
+		src = raw
+	}
+
+	if src.Channels != 1 && src.Channels != 2 {
+		return nil, fmt.Errorf("audio: unsupported channel count %d", src.Channels)
+	}
+	if len(pcm)%(2*src.Channels) != 0 {
+		// Drop a trailing partial sample frame rather than rejecting the file.
+		pcm = pcm[:len(pcm)-len(pcm)%(2*src.Channels)]
+	}
+
+	if target.Channels != 1 && target.Channels != 2 {
+		return nil, fmt.Errorf("audio: unsupported target channel count %d", target.Channels)
+	}
+	return convertPCM(pcm, src, target), nil
+}
+
+// decodeMP3 decodes the MP3 file data in full.
+func decodeMP3(data []byte) ([]byte, Format, error) {
+	dec, err := mp3.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		return nil, Format{}, fmt.Errorf("audio: decode mp3: %w", err)
+	}
+	pcm, err := io.ReadAll(dec)
+	if err != nil {
+		return nil, Format{}, fmt.Errorf("audio: decode mp3: %w", err)
+	}
+	// The decoder always emits interleaved 16-bit stereo.
+	return pcm, Format{SampleRate: dec.SampleRate(), Channels: 2}, nil
+}
@@ -0,0 +1,229 @@
+package audio_test
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+)
+
+// sttFormat is the 16 kHz mono format expected by STT providers.
+var sttFormat = audio.Format{SampleRate: 16000, Channels: 1}
+
+// sine returns dur seconds of a 440 Hz tone as interleaved 16-bit PCM.
+func sine(f audio.Format, dur float64) []byte {
+	n := int(float64(f.SampleRate) * dur)
+	samples := make([]int16, 0, n*f.Channels)
+	for i := range n {
+		v := int16(8000 * math.Sin(2*math.Pi*440*float64(i)/float64(f.SampleRate)))
+		for range f.Channels {
+			samples = append(samples, v)
+		}
+	}
+	return samplesToBytes(samples)
+}
+
+// wavFile wraps pcm in a RIFF/WAVE container with an extra LIST chunk before
+// the data chunk, as written by many editors.
+func wavFile(pcm []byte, f audio.Format, bits, formatTag int) []byte {
+	le := binary.LittleEndian
+	var b []byte
+	u32 := func(v int) { b = le.AppendUint32(b, uint32(v)) }
+	u16 := func(v int) { b = le.AppendUint16(b, uint16(v)) }
+
+	b = append(b, "RIFF"...)
+	u32(4 + 24 + 12 + 8 + len(pcm))
+	b = append(b, "WAVE"...)
+	b = append(b, "fmt "...)
+	u32(16)
+	u16(formatTag)
+	u16(f.Channels)
+	u32(f.SampleRate)
+	u32(f.SampleRate * f.Channels * bits / 8)
+	u16(f.Channels * bits / 8)
+	u16(bits)
+	b = append(b, "LIST"...)
+	u32(4)
+	b = append(b, "INFO"...)
+	b = append(b, "data"...)
+	u32(len(pcm))
+	return append(b, pcm...)
+}
+
+// rms returns the root-mean-square amplitude of 16-bit PCM.
+func rms(pcm []byte) float64 {
+	samples := bytesToSamples(pcm)
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+func TestSniffFileFormat(t *testing.T) {
+	t.Parallel()
+
+	mp3, err := os.ReadFile("testdata/speech.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want audio.FileFormat
+	}{
+		{name: "wav", data: wavFile(nil, sttFormat, 16, 1), want: audio.FileFormatWAV},
+		{name: "mp3 frame sync", data: mp3, want: audio.FileFormatMP3},
+		{name: "mp3 with id3 tag", data: append([]byte("ID3\x04\x00\x00\x00\x00\x00\x00"), mp3...), want: audio.FileFormatMP3},
+		{name: "riff but not wave", data: []byte("RIFF\x00\x00\x00\x00AVI LIST"), want: audio.FileFormatPCM},
+		{name: "raw pcm", data: sine(sttFormat, 0.01), want: audio.FileFormatPCM},
+		{name: "empty", data: nil, want: audio.FileFormatPCM},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := audio.SniffFileFormat(tc.data); got != tc.want {
+				t.Errorf("SniffFileFormat = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeFile_ToSTTFormat(t *testing.T) {
+	t.Parallel()
+
+	mp3, err := os.ReadFile("testdata/speech.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stereo44k := audio.Format{SampleRate: 44100, Channels: 2}
+	mono48k := audio.Format{SampleRate: 48000, Channels: 1}
+
+	tests := []struct {
+		name string
+		data []byte
+		raw  audio.Format
+		// wantSeconds is the expected duration of the decoded audio.
+		wantSeconds float64
+	}{
+		{name: "wav 44.1kHz stereo", data: wavFile(sine(stereo44k, 0.5), stereo44k, 16, 1), wantSeconds: 0.5},
+		{name: "wav already 16kHz mono", data: wavFile(sine(sttFormat, 0.25), sttFormat, 16, 1), wantSeconds: 0.25},
+		{name: "raw pcm 48kHz mono", data: sine(mono48k, 0.5), raw: mono48k, wantSeconds: 0.5},
+		// Raw PCM whose first sample reads as an MPEG Layer III frame sync.
+		{name: "raw pcm starting with mp3 sync", data: append([]byte{0xFF, 0xFB}, sine(mono48k, 0.5)[2:]...), raw: mono48k, wantSeconds: 0.5},
+		// 25 MPEG-2 frames × 576 samples at 22.05 kHz.
+		{name: "mp3 22.05kHz", data: mp3, wantSeconds: 25 * 576 / 22050.0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pcm, err := audio.DecodeFile(tc.data, tc.raw, sttFormat)
+			if err != nil {
+				t.Fatalf("DecodeFile: %v", err)
+			}
+
+			wantBytes := int(tc.wantSeconds*16000) * 2
+			if diff := len(pcm) - wantBytes; diff < -4 || diff > 4 {
+				t.Errorf("decoded %d bytes, want %d (16kHz mono, %.3fs)", len(pcm), wantBytes, tc.wantSeconds)
+			}
+			if got := rms(pcm); got < 100 {
+				t.Errorf("decoded audio RMS = %.1f, want audible signal", got)
+			}
+		})
+	}
+}
+
+func TestDecodeFile_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+		raw  audio.Format
+	}{
+		{name: "raw pcm without format", data: sine(sttFormat, 0.01)},
+		{name: "8-bit wav", data: wavFile(make([]byte, 100), sttFormat, 8, 1)},
+		{name: "float wav", data: wavFile(make([]byte, 400), sttFormat, 32, 3)},
+		{name: "wav without data chunk", data: []byte("RIFF\x04\x00\x00\x00WAVE")},
+		{name: "truncated mp3", data: []byte{0xFF, 0xF3, 0x60}},
+		{name: "too many channels", data: wavFile(make([]byte, 120), audio.Format{SampleRate: 16000, Channels: 6}, 16, 1)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := audio.DecodeFile(tc.data, tc.raw, sttFormat); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseWAV(t *testing.T) {
+	t.Parallel()
+
+	pcm := sine(audio.Format{SampleRate: 22050, Channels: 2}, 0.01)
+	wav := wavFile(pcm, audio.Format{SampleRate: 22050, Channels: 2}, 16, 1)
+
+	info, err := audio.ParseWAV(wav)
+	if err != nil {
+		t.Fatalf("ParseWAV: %v", err)
+	}
+	want := audio.WAVInfo{
+		DataOffset:    len(wav) - len(pcm),
+		DataSize:      len(pcm),
+		SampleRate:    22050,
+		Channels:      2,
+		BitsPerSample: 16,
+		AudioFormat:   1,
+	}
+	if info != want {
+		t.Errorf("ParseWAV = %+v, want %+v", info, want)
+	}
+
+	// A streaming placeholder size of zero covers the rest of the buffer.
+	binary.LittleEndian.PutUint32(wav[info.DataOffset-4:], 0)
+	if info, _ := audio.ParseWAV(wav); info.DataSize != len(pcm) {
+		t.Errorf("DataSize with placeholder = %d, want %d", info.DataSize, len(pcm))
+	}
+}
+
+func TestEncodeWAV(t *testing.T) {
+	t.Parallel()
+
+	f := audio.Format{SampleRate: 48000, Channels: 2}
+	pcm := sine(f, 0.01)
+	wav := audio.EncodeWAV(pcm, f.SampleRate, f.Channels)
+
+	info, err := audio.ParseWAV(wav)
+	if err != nil {
+		t.Fatalf("ParseWAV: %v", err)
+	}
+	want := audio.WAVInfo{
+		DataOffset:    44,
+		DataSize:      len(pcm),
+		SampleRate:    48000,
+		Channels:      2,
+		BitsPerSample: 16,
+		AudioFormat:   1,
+	}
+	if info != want {
+		t.Errorf("ParseWAV(EncodeWAV) = %+v, want %+v", info, want)
+	}
+	got, err := audio.DecodeFile(wav, audio.Format{}, f)
+	if err != nil {
+		t.Fatalf("DecodeFile: %v", err)
+	}
+	if string(got) != string(pcm) {
+		t.Error("decoded PCM differs from the encoded PCM")
+	}
+}
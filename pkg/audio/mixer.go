@@ -59,6 +59,12 @@ type AudioSegment struct {
 	// in FIFO order.
 	Priority int
 
+	// OnInterrupt, if non-nil, is called when playback of the segment is cut
+	// short by [Mixer.Interrupt], a player barge-in or a preempting segment.
+	// It is not called for segments that are dropped before they start
+	// playing. The mixer calls it on its own goroutine; it must not block.
+	OnInterrupt func()
+
 	// streamErr stores the error that caused the Audio channel to close early.
 	// Access via Err and SetStreamErr.
 	streamErr atomic.Pointer[error]
@@ -0,0 +1,42 @@
+package mixer
+
+import "encoding/binary"
+
+// fadeOut returns frames frames of 16-bit little-endian PCM whose volume
+// ramps linearly down to silence, ending on an exact zero sample.
+//
+// The ramp is applied to the start of next when it holds at least one whole
+// frame; otherwise, the last whole frame of last is repeated and ramped. Only
+// whole frames are used. Returns nil if neither buffer holds a whole frame or
+// channels or frames is not positive.
+func fadeOut(last, next []byte, channels, frames int) []byte {
+	if channels <= 0 || frames <= 0 {
+		return nil
+	}
+	frameSize := 2 * channels
+	src := next
+	if len(src) < frameSize {
+		n := len(last) / frameSize
+		if n == 0 {
+			return nil
+		}
+		hold := last[(n-1)*frameSize : n*frameSize]
+		src = make([]byte, 0, frames*frameSize)
+		for range frames {
+			src = append(src, hold...)
+		}
+	}
+	frames = min(frames, len(src)/frameSize)
+
+	out := make([]byte, frames*frameSize)
+	for i := range frames {
+		// Gain falls from just below 1 to exactly 0 on the final frame.
+		gain := float64(frames-1-i) / float64(frames)
+		for c := range channels {
+			off := i*frameSize + 2*c
+			s := int16(binary.LittleEndian.Uint16(src[off:]))
+			binary.LittleEndian.PutUint16(out[off:], uint16(int16(float64(s)*gain)))
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package mixer
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestFadeOut(t *testing.T) {
+	t.Parallel()
+
+	// pcm returns n stereo frames whose samples all equal level.
+	pcm := func(n int, level int16) []byte {
+		b := make([]byte, n*4)
+		for i := range 2 * n {
+			binary.LittleEndian.PutUint16(b[2*i:], uint16(level))
+		}
+		return b
+	}
+
+	tests := []struct {
+		name       string
+		last, next []byte
+		channels   int
+		frames     int
+		wantFrames int
+	}{
+		{name: "ramps next", last: pcm(1, 100), next: pcm(8, 1000), channels: 2, frames: 4, wantFrames: 4},
+		{name: "holds last", last: pcm(2, 1000), channels: 2, frames: 4, wantFrames: 4},
+		{name: "short next", last: pcm(1, 1000), next: pcm(2, 1000), channels: 2, frames: 4, wantFrames: 2},
+		{name: "no whole frame", last: []byte{1, 2}, channels: 2, frames: 4},
+		{name: "no channels", last: pcm(2, 1000), channels: 0, frames: 4},
+		{name: "no frames", last: pcm(2, 1000), channels: 2, frames: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := fadeOut(tt.last, tt.next, tt.channels, tt.frames)
+			if tt.wantFrames == 0 {
+				if got != nil {
+					t.Fatalf("fadeOut = %d bytes, want nil", len(got))
+				}
+				return
+			}
+			if len(got) != tt.wantFrames*2*tt.channels {
+				t.Fatalf("fadeOut = %d bytes, want %d frames", len(got), tt.wantFrames)
+			}
+			if first := int16(binary.LittleEndian.Uint16(got)); first != 1000*int16(tt.wantFrames-1)/int16(tt.wantFrames) {
+				t.Errorf("first sample = %d, want the ramp's start", first)
+			}
+			if end := int16(binary.LittleEndian.Uint16(got[len(got)-2:])); end != 0 {
+				t.Errorf("last sample = %d, want 0", end)
+			}
+		})
+	}
+}
@@ -47,3 +47,15 @@ func (h *segmentHeap) Pop() any {
 	*h = old[:n-1]
 	return e
 }
+
+// lowest returns the index of the element that would be dequeued last. h
+// must not be empty.
+func (h segmentHeap) lowest() int {
+	last := 0
+	for i := 1; i < len(h); i++ {
+		if h.Less(last, i) {
+			last = i
+		}
+	}
+	return last
+}
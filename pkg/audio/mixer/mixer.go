@@ -18,6 +18,10 @@ const (
 	// segments when no explicit gap is configured via [WithGap].
 	DefaultGap = 300 * time.Millisecond
 
+	// DefaultFadeOut is the length of the volume ramp played when a segment
+	// is interrupted and no duration is configured via [WithFadeOut].
+	DefaultFadeOut = 20 * time.Millisecond
+
 	// defaultQueueCap is the initial capacity hint for the priority queue.
 	defaultQueueCap = 16
 )
@@ -34,6 +38,17 @@ func WithGap(d time.Duration) Option {
 	}
 }
 
+// WithFadeOut sets the length of the volume ramp down to silence played when
+// a segment is interrupted, so playback does not stop with an audible click.
+// Zero disables the fade; negative values are ignored.
+func WithFadeOut(d time.Duration) Option {
+	return func(m *PriorityMixer) {
+		if d >= 0 {
+			m.fadeOut = d
+		}
+	}
+}
+
 // WithQueueCapacity sets the initial capacity hint for the internal priority
 // queue. This does not impose a hard limit — the queue grows as needed.
 func WithQueueCapacity(n int) Option {
@@ -44,12 +59,38 @@ func WithQueueCapacity(n int) Option {
 	}
 }
 
+// WithMaxQueue limits the number of segments waiting behind the one that is
+// playing. When the queue is full, a new segment replaces the lowest-priority
+// queued segment if it outranks it, and is dropped otherwise; ties keep the
+// queued segment. Zero or negative means no limit (the default).
+func WithMaxQueue(n int) Option {
+	return func(m *PriorityMixer) {
+		m.maxQueue = max(n, 0)
+	}
+}
+
+// WithPreemptNewest makes the most recently addressed NPC win the floor. A
+// segment from a different NPC than the one playing interrupts it if its
+// priority is at least as high, instead of waiting, and every queued segment
+// of other NPCs at the same or lower priority is dropped. Segments of the
+// same NPC still queue behind each other. By default, equal-priority
+// segments wait their turn.
+func WithPreemptNewest() Option {
+	return func(m *PriorityMixer) {
+		m.preemptNewest = true
+	}
+}
+
 // PriorityMixer is a concrete [audio.Mixer] that schedules [audio.AudioSegment]
 // playback using a priority queue backed by [container/heap].
 //
 // Higher-priority segments preempt lower-priority ones currently playing.
-// Equal-priority segments are played in FIFO order. A configurable silence gap
-// (with jitter) is inserted between consecutive segments to sound natural.
+// Equal-priority segments are played in FIFO order, so only one NPC speaks at
+// a time even on platforms that cannot mix several voices; [WithMaxQueue] and
+// [WithPreemptNewest] tune what happens to replies that would have to wait. A
+// configurable silence gap (with jitter) is inserted between consecutive
+// segments to sound natural. Interrupted segments end with a short fade-out
+// (see [WithFadeOut]).
 //
 // All exported methods are safe for concurrent use.
 type PriorityMixer struct {
@@ -59,10 +100,13 @@ type PriorityMixer struct {
 	queue          segmentHeap
 	seq            uint64              // monotonic counter for FIFO ordering
 	gap            time.Duration       // base silence gap between segments
+	fadeOut        time.Duration       // ramp length applied on interrupt
 	playing        *audio.AudioSegment // currently playing segment, or nil
 	playingPri     int                 // priority of the currently playing segment
 	cancelPlaying  chan struct{}       // closed to interrupt the current segment
 	bargeInHandler func(string)        // last-writer-wins barge-in callback
+	maxQueue       int                 // queued segment limit; 0 means unlimited
+	preemptNewest  bool                // a new NPC's segment interrupts the playing one
 
 	notify chan struct{} // signalled when a new segment is enqueued or interrupt fires
 	done   chan struct{} // closed by Close to stop the dispatch goroutine
@@ -79,11 +123,12 @@ type PriorityMixer struct {
 // resources.
 func New(output func(audio.AudioFrame), opts ...Option) *PriorityMixer {
 	m := &PriorityMixer{
-		output: output,
-		queue:  make(segmentHeap, 0, defaultQueueCap),
-		gap:    DefaultGap,
-		notify: make(chan struct{}, 1),
-		done:   make(chan struct{}),
+		output:  output,
+		queue:   make(segmentHeap, 0, defaultQueueCap),
+		gap:     DefaultGap,
+		fadeOut: DefaultFadeOut,
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
 	}
 	for _, o := range opts {
 		o(m)
@@ -118,6 +163,35 @@ func (m *PriorityMixer) Enqueue(segment *audio.AudioSegment, priority int) {
 		return
 	}
 
+	// With newest-wins preemption, replies of other NPCs that are still
+	// waiting have been overtaken by this one.
+	newest := m.preemptNewest && (m.playing == nil || m.playing.NPCID != segment.NPCID)
+	if newest {
+		m.dropQueuedLocked(func(e entry) bool {
+			return e.segment.NPCID != segment.NPCID && e.priority <= priority
+		})
+	}
+
+	if m.maxQueue > 0 && m.queue.Len() >= m.maxQueue {
+		i := m.queue.lowest()
+		if m.queue[i].priority >= priority {
+			slog.Warn("mixer: queue full, dropping segment",
+				"npcID", segment.NPCID,
+				"priority", priority,
+				"maxQueue", m.maxQueue,
+			)
+			go audio.Drain(segment.Audio)
+			return
+		}
+		e := heap.Remove(&m.queue, i).(entry)
+		slog.Warn("mixer: queue full, dropping lower-priority segment",
+			"npcID", e.segment.NPCID,
+			"priority", e.priority,
+			"maxQueue", m.maxQueue,
+		)
+		go audio.Drain(e.segment.Audio)
+	}
+
 	m.seq++
 	heap.Push(&m.queue, entry{
 		segment:  segment,
@@ -125,8 +199,9 @@ func (m *PriorityMixer) Enqueue(segment *audio.AudioSegment, priority int) {
 		seq:      m.seq,
 	})
 
-	// Preempt the current segment if the new one has higher priority.
-	if m.playing != nil && priority > m.playingPri {
+	// Preempt the current segment if the new one has higher priority, or
+	// comes from another NPC and the newest reply wins.
+	if m.playing != nil && (priority > m.playingPri || newest && priority >= m.playingPri) {
 		m.interruptLocked(audio.DMOverride, false)
 	}
 
@@ -220,7 +295,7 @@ func (m *PriorityMixer) Close() error {
 // interruptLocked cancels the currently playing segment and optionally clears
 // the queue. Must be called with m.mu held.
 func (m *PriorityMixer) interruptLocked(reason audio.InterruptReason, clearQueue bool) {
-	_ = reason // available for future reason-specific behaviour (e.g., fade-out)
+	_ = reason // available for future reason-specific behaviour
 
 	if m.cancelPlaying != nil {
 		close(m.cancelPlaying)
@@ -236,6 +311,22 @@ func (m *PriorityMixer) interruptLocked(reason audio.InterruptReason, clearQueue
 	}
 }
 
+// dropQueuedLocked removes and drains every queued segment for which drop
+// reports true. Must be called with m.mu held.
+func (m *PriorityMixer) dropQueuedLocked(drop func(entry) bool) {
+	kept := m.queue[:0]
+	for _, e := range m.queue {
+		if drop(e) {
+			go audio.Drain(e.segment.Audio)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	clear(m.queue[len(kept):])
+	m.queue = kept
+	heap.Init(&m.queue)
+}
+
 // dispatch is the background goroutine that pulls segments from the queue and
 // streams their audio chunks to the output callback. It runs until [Close] is
 // called.
@@ -322,15 +413,22 @@ func (m *PriorityMixer) dequeue() (seg *audio.AudioSegment, _ int, cancel chan s
 }
 
 // play streams audio chunks from seg to the output callback until the segment
-// ends or cancel is closed (interrupt).
+// ends or cancel is closed (interrupt). On interrupt, a fade-out ramp is
+// emitted before the segment is abandoned and its OnInterrupt callback is
+// called.
 func (m *PriorityMixer) play(seg *audio.AudioSegment, cancel chan struct{}) {
+	var last []byte // most recently emitted chunk
 	for {
 		select {
 		case <-m.done:
 			go audio.Drain(seg.Audio)
 			return
 		case <-cancel:
+			m.fade(seg, last)
 			go audio.Drain(seg.Audio)
+			if seg.OnInterrupt != nil {
+				seg.OnInterrupt()
+			}
 			return
 		case chunk, ok := <-seg.Audio:
 			if !ok {
@@ -341,10 +439,42 @@ func (m *PriorityMixer) play(seg *audio.AudioSegment, cancel chan struct{}) {
 				SampleRate: seg.SampleRate,
 				Channels:   seg.Channels,
 			})
+			last = chunk
 		}
 	}
 }
 
+// fade emits the fade-out ramp for an interrupted segment. If the segment's
+// next chunk is already available, the ramp is applied to its beginning so
+// the audio continues naturally while it fades; otherwise the last emitted
+// sample is held and ramped to silence. Does nothing when the fade is
+// disabled or no audio was played yet.
+func (m *PriorityMixer) fade(seg *audio.AudioSegment, last []byte) {
+	frames := int(m.fadeOut * time.Duration(seg.SampleRate) / time.Second)
+	if frames <= 0 || len(last) == 0 {
+		return
+	}
+
+	var next []byte
+	select {
+	case chunk, ok := <-seg.Audio:
+		if ok {
+			next = chunk
+		}
+	default:
+	}
+
+	ramp := fadeOut(last, next, seg.Channels, frames)
+	if len(ramp) == 0 {
+		return
+	}
+	m.output(audio.AudioFrame{
+		Data:       ramp,
+		SampleRate: seg.SampleRate,
+		Channels:   seg.Channels,
+	})
+}
+
 // gapWithJitter returns the configured gap duration with ±1/6 jitter applied.
 // Returns zero if the base gap is zero.
 func (m *PriorityMixer) gapWithJitter() time.Duration {
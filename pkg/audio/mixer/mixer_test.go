@@ -1,6 +1,8 @@
 package mixer_test
 
 import (
+	"encoding/binary"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -244,6 +246,117 @@ func TestInterruptPlayerBargeInClearsQueue(t *testing.T) {
 	}
 }
 
+func TestInterruptCallsOnInterrupt(t *testing.T) {
+	t.Parallel()
+
+	output, _ := collectOutput()
+	m := mixer.New(output, mixer.WithGap(0))
+	defer m.Close()
+
+	var interrupted [3]atomic.Int32
+	onInterrupt := func(i int) func() { return func() { interrupted[i].Add(1) } }
+
+	// A segment that finishes naturally.
+	finished := makeSegment("npc-1", 1, []byte("done"))
+	finished.OnInterrupt = onInterrupt(0)
+	m.Enqueue(finished, 1)
+	time.Sleep(30 * time.Millisecond)
+
+	// A segment interrupted while playing, and one dropped from the queue.
+	playing, sendCh := makeOpenSegment("npc-1", 1)
+	playing.OnInterrupt = onInterrupt(1)
+	m.Enqueue(playing, 1)
+	sendCh <- []byte("playing")
+	time.Sleep(30 * time.Millisecond)
+	queued := makeSegment("npc-2", 1, []byte("queued"))
+	queued.OnInterrupt = onInterrupt(2)
+	m.Enqueue(queued, 1)
+
+	m.Interrupt(audio.PlayerBargeIn)
+	close(sendCh)
+	time.Sleep(50 * time.Millisecond)
+
+	for i, want := range []int32{0, 1, 0} {
+		if got := interrupted[i].Load(); got != want {
+			t.Errorf("segment %d: OnInterrupt calls = %d, want %d", i, got, want)
+		}
+	}
+}
+
+// constantPCM returns n mono 16-bit little-endian samples of value v.
+func constantPCM(v int16, n int) []byte {
+	b := make([]byte, 2*n)
+	for i := range n {
+		binary.LittleEndian.PutUint16(b[2*i:], uint16(v))
+	}
+	return b
+}
+
+func TestInterruptFadesOut(t *testing.T) {
+	t.Parallel()
+
+	const level = 10000
+
+	tests := []struct {
+		name       string
+		opts       []mixer.Option
+		wantFrames int // samples in the fade chunk; 0 means no fade
+	}{
+		{name: "default", wantFrames: 48000 * int(mixer.DefaultFadeOut/time.Millisecond) / 1000},
+		{name: "custom", opts: []mixer.Option{mixer.WithFadeOut(5 * time.Millisecond)}, wantFrames: 240},
+		{name: "disabled", opts: []mixer.Option{mixer.WithFadeOut(0)}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			output, get := collectOutput()
+			m := mixer.New(output, append([]mixer.Option{mixer.WithGap(0)}, tc.opts...)...)
+			defer m.Close()
+
+			seg, sendCh := makeOpenSegment("npc-1", 1)
+			m.Enqueue(seg, 1)
+			sendCh <- constantPCM(level, 480)
+			time.Sleep(30 * time.Millisecond)
+
+			m.Interrupt(audio.PlayerBargeIn)
+			close(sendCh)
+			time.Sleep(50 * time.Millisecond)
+
+			chunks := get()
+			if tc.wantFrames == 0 {
+				if len(chunks) != 1 {
+					t.Fatalf("got %d chunks, want only the played one", len(chunks))
+				}
+				return
+			}
+			if len(chunks) != 2 {
+				t.Fatalf("got %d chunks, want played chunk + fade", len(chunks))
+			}
+
+			fade := chunks[1]
+			if got := len(fade) / 2; got != tc.wantFrames {
+				t.Fatalf("fade has %d samples, want %d", got, tc.wantFrames)
+			}
+			prev := int16(level)
+			for i := range tc.wantFrames {
+				s := int16(binary.LittleEndian.Uint16(fade[2*i:]))
+				if s > prev || s < 0 {
+					t.Fatalf("sample %d = %d after %d: fade does not ramp toward zero", i, s, prev)
+				}
+				prev = s
+			}
+			if first := int16(binary.LittleEndian.Uint16(fade)); first < level*9/10 {
+				t.Errorf("first fade sample = %d, want close to %d (no abrupt drop)", first, level)
+			}
+			if prev != 0 {
+				t.Errorf("last fade sample = %d, want 0", prev)
+			}
+		})
+	}
+}
+
 func TestBargeInHandler(t *testing.T) {
 	t.Parallel()
 
@@ -554,3 +667,147 @@ func TestMixer_RejectsInvalidFormat(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 	// Segment should be rejected and audio drained (no panic, no output)
 }
+
+// waitForChunks polls get until it returns at least n chunks and returns
+// them, failing the test after a second.
+func waitForChunks(t *testing.T, get func() [][]byte, n int) [][]byte {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		chunks := get()
+		if len(chunks) >= n {
+			return chunks
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d chunks %q, want %d", len(chunks), chunks, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// chunkStrings converts chunks to strings for comparison.
+func chunkStrings(chunks [][]byte) []string {
+	out := make([]string, len(chunks))
+	for i, c := range chunks {
+		out[i] = string(c)
+	}
+	return out
+}
+
+func TestConcurrentResponsesPlaySequentially(t *testing.T) {
+	t.Parallel()
+
+	output, get := collectOutput()
+	m := mixer.New(output, mixer.WithGap(0), mixer.WithFadeOut(0))
+	defer m.Close()
+
+	segA, sendA := makeOpenSegment("npc-a", 1)
+	segB, sendB := makeOpenSegment("npc-b", 1)
+	var wg sync.WaitGroup
+	wg.Go(func() { m.Enqueue(segA, 1) })
+	wg.Go(func() { m.Enqueue(segB, 1) })
+	wg.Wait()
+
+	// Both NPCs produce audio at the same time.
+	sendA <- []byte("a-1")
+	sendB <- []byte("b-1")
+	first := waitForChunks(t, get, 1)[0][0]
+	sendA <- []byte("a-2")
+	sendB <- []byte("b-2")
+	time.Sleep(30 * time.Millisecond)
+	if got := len(get()); got != 2 {
+		t.Fatalf("got %d chunks while the first NPC speaks, want 2", got)
+	}
+	close(sendA)
+	close(sendB)
+
+	want := []string{"a-1", "a-2", "b-1", "b-2"}
+	if first == 'b' {
+		want = []string{"b-1", "b-2", "a-1", "a-2"}
+	}
+	got := chunkStrings(waitForChunks(t, get, 4))
+	if !slices.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPreemptNewest(t *testing.T) {
+	t.Parallel()
+
+	output, get := collectOutput()
+	m := mixer.New(output, mixer.WithGap(0), mixer.WithFadeOut(0), mixer.WithPreemptNewest())
+	defer m.Close()
+
+	segA, sendA := makeOpenSegment("npc-a", 1)
+	m.Enqueue(segA, 1)
+	sendA <- []byte("a-1")
+	waitForChunks(t, get, 1)
+
+	// A second reply of the same NPC waits for the first.
+	m.Enqueue(makeSegment("npc-a", 1, []byte("a-next")), 1)
+	// Another NPC's reply is overtaken before it gets to play.
+	m.Enqueue(makeSegment("npc-c", 1, []byte("c-1")), 1)
+
+	// The most recently addressed NPC interrupts npc-a and drops npc-c.
+	segB, sendB := makeOpenSegment("npc-b", 1)
+	m.Enqueue(segB, 1)
+	sendB <- []byte("b-1")
+	sendA <- []byte("a-2")
+	close(sendB)
+	defer close(sendA)
+
+	time.Sleep(50 * time.Millisecond)
+	got := chunkStrings(get())
+	if want := []string{"a-1", "b-1"}; !slices.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPreemptNewest_SameNPCQueues(t *testing.T) {
+	t.Parallel()
+
+	output, get := collectOutput()
+	m := mixer.New(output, mixer.WithGap(0), mixer.WithFadeOut(0), mixer.WithPreemptNewest())
+	defer m.Close()
+
+	segA, sendA := makeOpenSegment("npc-a", 1)
+	m.Enqueue(segA, 1)
+	sendA <- []byte("a-1")
+	waitForChunks(t, get, 1)
+
+	m.Enqueue(makeSegment("npc-a", 1, []byte("a-next")), 1)
+	sendA <- []byte("a-2")
+	close(sendA)
+
+	got := chunkStrings(waitForChunks(t, get, 3))
+	if want := []string{"a-1", "a-2", "a-next"}; !slices.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestWithMaxQueue(t *testing.T) {
+	t.Parallel()
+
+	output, get := collectOutput()
+	m := mixer.New(output, mixer.WithGap(0), mixer.WithMaxQueue(1))
+	defer m.Close()
+
+	seg, send := makeOpenSegment("npc-a", 5)
+	m.Enqueue(seg, 5)
+	send <- []byte("playing")
+	waitForChunks(t, get, 1)
+
+	m.Enqueue(makeSegment("npc-b", 1, []byte("low")), 1)
+	// The queue is full: an equal-priority segment is dropped...
+	m.Enqueue(makeSegment("npc-c", 1, []byte("dropped")), 1)
+	// ...and a higher-priority one replaces the queued segment.
+	m.Enqueue(makeSegment("npc-d", 3, []byte("high")), 3)
+	close(send)
+
+	waitForChunks(t, get, 2)
+	time.Sleep(30 * time.Millisecond)
+	got := chunkStrings(get())
+	if want := []string{"playing", "high"}; !slices.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
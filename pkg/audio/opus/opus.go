@@ -0,0 +1,158 @@
+// Package opus converts between raw PCM and Opus packets in the format
+// Discord voice uses: 48 kHz stereo in 20 ms frames.
+//
+// An [Encoder] turns one frame of 16-bit little-endian PCM ([FrameBytes]
+// bytes) into an Opus packet; a [Decoder] turns a packet back into PCM.
+// Both keep codec state across consecutive frames, so use one per audio
+// stream and do not share them between goroutines.
+//
+// Encoders are cheap to reuse but not to create, so code that encodes many
+// short utterances takes them from an [EncoderPool]:
+//
+//	var pool opus.EncoderPool
+//
+//	enc, err := pool.Get()
+//	if err != nil {
+//	    return err
+//	}
+//	defer pool.Put(enc)
+//	packet, err := enc.Encode(frame)
+package opus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"layeh.com/gopus"
+)
+
+// Format of the PCM handled by this package.
+const (
+	// SampleRate is the PCM sample rate in Hz.
+	SampleRate = 48000
+
+	// Channels is the number of interleaved PCM channels.
+	Channels = 2
+
+	// FrameDurationMs is the length of one Opus frame in milliseconds.
+	FrameDurationMs = 20
+
+	// FrameSize is the number of samples per channel in one frame.
+	FrameSize = SampleRate * FrameDurationMs / 1000 // 960
+
+	// FrameBytes is the size of one frame of 16-bit PCM:
+	// 960 samples/channel × 2 channels × 2 bytes/sample = 3840 bytes.
+	FrameBytes = FrameSize * Channels * 2
+)
+
+// ErrFrameSize is returned by [Encoder.Encode] for PCM that is not exactly
+// one frame long.
+var ErrFrameSize = errors.New("opus: PCM must be exactly one frame")
+
+// Encoder encodes 20 ms frames of 48 kHz stereo PCM into Opus packets.
+// Create one with [NewEncoder] or take one from an [EncoderPool].
+//
+// An Encoder is not safe for concurrent use.
+type Encoder struct {
+	enc     *gopus.Encoder
+	samples []int16 // reused conversion buffer of FrameSize*Channels samples
+}
+
+// NewEncoder creates an [Encoder] tuned for general audio, which suits both
+// speech and the music and effects NPCs may play.
+func NewEncoder() (*Encoder, error) {
+	enc, err := gopus.NewEncoder(SampleRate, Channels, gopus.Audio)
+	if err != nil {
+		return nil, fmt.Errorf("opus: create encoder: %w", err)
+	}
+	return &Encoder{enc: enc, samples: make([]int16, FrameSize*Channels)}, nil
+}
+
+// Encode encodes one frame of interleaved 16-bit little-endian PCM into an
+// Opus packet. pcm must be exactly [FrameBytes] long; other lengths return
+// [ErrFrameSize]. The returned packet is newly allocated and owned by the
+// caller.
+func (e *Encoder) Encode(pcm []byte) ([]byte, error) {
+	if len(pcm) != FrameBytes {
+		return nil, fmt.Errorf("%w: got %d bytes, want %d", ErrFrameSize, len(pcm), FrameBytes)
+	}
+	for i := range e.samples {
+		e.samples[i] = int16(pcm[i*2]) | int16(pcm[i*2+1])<<8
+	}
+	packet, err := e.enc.Encode(e.samples, FrameSize, FrameBytes)
+	if err != nil {
+		return nil, fmt.Errorf("opus: encode: %w", err)
+	}
+	return packet, nil
+}
+
+// Reset clears the codec state, so the next frame is encoded as the start
+// of a new stream.
+func (e *Encoder) Reset() {
+	e.enc.ResetState()
+}
+
+// Decoder decodes Opus packets into 48 kHz stereo PCM. Create one with
+// [NewDecoder]; each incoming stream, such as one Discord participant, needs
+// its own.
+//
+// A Decoder is not safe for concurrent use.
+type Decoder struct {
+	dec *gopus.Decoder
+}
+
+// NewDecoder creates a [Decoder].
+func NewDecoder() (*Decoder, error) {
+	dec, err := gopus.NewDecoder(SampleRate, Channels)
+	if err != nil {
+		return nil, fmt.Errorf("opus: create decoder: %w", err)
+	}
+	return &Decoder{dec: dec}, nil
+}
+
+// Decode decodes an Opus packet of up to one frame into interleaved 16-bit
+// little-endian PCM. The returned slice is newly allocated and owned by the
+// caller.
+func (d *Decoder) Decode(packet []byte) ([]byte, error) {
+	samples, err := d.dec.Decode(packet, FrameSize, false)
+	if err != nil {
+		return nil, fmt.Errorf("opus: decode: %w", err)
+	}
+	pcm := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		pcm[i*2] = byte(s)
+		pcm[i*2+1] = byte(s >> 8)
+	}
+	return pcm, nil
+}
+
+// Reset clears the codec state, as after a gap in the stream.
+func (d *Decoder) Reset() {
+	d.dec.ResetState()
+}
+
+// EncoderPool reuses [Encoder] values across short-lived audio streams. The
+// zero value is ready to use and safe for concurrent use.
+type EncoderPool struct {
+	pool sync.Pool
+}
+
+// Get returns a pooled [Encoder] in its initial state, or a new one if the
+// pool is empty.
+func (p *EncoderPool) Get() (*Encoder, error) {
+	if e, ok := p.pool.Get().(*Encoder); ok {
+		return e, nil
+	}
+	return NewEncoder()
+}
+
+// Put resets e and returns it to the pool. e must not be used afterwards.
+// A nil e is ignored.
+func (p *EncoderPool) Put(e *Encoder) {
+	if e == nil {
+		return
+	}
+	e.Reset()
+	p.pool.Put(e)
+}
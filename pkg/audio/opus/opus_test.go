@@ -0,0 +1,238 @@
+package opus_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio/opus"
+)
+
+// sineFrame returns one frame of a 440 Hz stereo sine wave at half scale.
+func sineFrame() []byte {
+	pcm := make([]byte, opus.FrameBytes)
+	for i := range opus.FrameSize {
+		s := int16(math.Sin(2*math.Pi*440*float64(i)/opus.SampleRate) * math.MaxInt16 / 2)
+		for ch := range opus.Channels {
+			binary.LittleEndian.PutUint16(pcm[(i*opus.Channels+ch)*2:], uint16(s))
+		}
+	}
+	return pcm
+}
+
+// energy returns the mean squared sample value of 16-bit PCM.
+func energy(pcm []byte) float64 {
+	var sum float64
+	for i := 0; i+1 < len(pcm); i += 2 {
+		s := float64(int16(binary.LittleEndian.Uint16(pcm[i:])))
+		sum += s * s
+	}
+	return sum / float64(len(pcm)/2)
+}
+
+// ─── TestRoundTrip ────────────────────────────────────────────────────────────
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	enc, err := opus.NewEncoder()
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	dec, err := opus.NewDecoder()
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+
+	frame := sineFrame()
+	var pcm []byte
+	// The codec needs a few frames to settle, so compare the last one.
+	for range 5 {
+		packet, err := enc.Encode(frame)
+		if err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		if len(packet) == 0 || len(packet) >= opus.FrameBytes {
+			t.Fatalf("Encode: want a compressed packet, got %d bytes", len(packet))
+		}
+		pcm, err = dec.Decode(packet)
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+	}
+
+	if len(pcm) != opus.FrameBytes {
+		t.Fatalf("Decode: want %d bytes, got %d", opus.FrameBytes, len(pcm))
+	}
+	want := energy(frame)
+	if got := energy(pcm); got < want/2 || got > want*2 {
+		t.Errorf("decoded energy %.0f is far from the input's %.0f", got, want)
+	}
+}
+
+// ─── TestEncode_FrameSize ─────────────────────────────────────────────────────
+
+func TestEncode_FrameSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "empty", size: 0},
+		{name: "short", size: opus.FrameBytes - 2},
+		{name: "long", size: opus.FrameBytes + 2},
+		{name: "mono frame", size: opus.FrameSize * 2},
+	}
+
+	enc, err := opus.NewEncoder()
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+
+	for _, tc := range tests {
+		// Subtests share enc, so they run sequentially.
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := enc.Encode(make([]byte, tc.size))
+			if !errors.Is(err, opus.ErrFrameSize) {
+				t.Errorf("Encode(%d bytes): want ErrFrameSize, got %v", tc.size, err)
+			}
+		})
+	}
+}
+
+// ─── TestDecode_Invalid ───────────────────────────────────────────────────────
+
+func TestDecode_Invalid(t *testing.T) {
+	t.Parallel()
+
+	dec, err := opus.NewDecoder()
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	// TOC byte for a 20 ms CELT frame followed by a truncated code 3 header.
+	if _, err := dec.Decode([]byte{0xFB}); err == nil {
+		t.Error("Decode: want an error for a malformed packet, got nil")
+	}
+}
+
+// ─── TestEncoderPool ──────────────────────────────────────────────────────────
+
+func TestEncoderPool(t *testing.T) {
+	t.Parallel()
+
+	var pool opus.EncoderPool
+	frame := sineFrame()
+
+	enc, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	first, err := enc.Encode(frame)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	// Advance the codec state so a missing reset would change the output.
+	for range 3 {
+		if _, err := enc.Encode(frame); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+	pool.Put(enc)
+	pool.Put(nil) // ignored
+
+	// Whether or not the pool hands back the same encoder, it must start
+	// from a fresh state.
+	enc, err = pool.Get()
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	again, err := enc.Encode(frame)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if string(again) != string(first) {
+		t.Errorf("pooled encoder: first packet differs from a fresh encoder's (%d vs %d bytes)", len(again), len(first))
+	}
+}
+
+// ─── Benchmarks ───────────────────────────────────────────────────────────────
+
+func BenchmarkEncode(b *testing.B) {
+	enc, err := opus.NewEncoder()
+	if err != nil {
+		b.Fatalf("NewEncoder: %v", err)
+	}
+	frame := sineFrame()
+
+	b.SetBytes(opus.FrameBytes)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := enc.Encode(frame); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	enc, err := opus.NewEncoder()
+	if err != nil {
+		b.Fatalf("NewEncoder: %v", err)
+	}
+	dec, err := opus.NewDecoder()
+	if err != nil {
+		b.Fatalf("NewDecoder: %v", err)
+	}
+	packet, err := enc.Encode(sineFrame())
+	if err != nil {
+		b.Fatalf("Encode: %v", err)
+	}
+
+	b.SetBytes(opus.FrameBytes)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := dec.Decode(packet); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkUtterance encodes a short one-second utterance per iteration,
+// comparing a new encoder per utterance with one taken from a pool.
+func BenchmarkUtterance(b *testing.B) {
+	const frames = 1000 / opus.FrameDurationMs
+	frame := sineFrame()
+
+	encode := func(b *testing.B, enc *opus.Encoder) {
+		for range frames {
+			if _, err := enc.Encode(frame); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			enc, err := opus.NewEncoder()
+			if err != nil {
+				b.Fatal(err)
+			}
+			encode(b, enc)
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		var pool opus.EncoderPool
+		b.ReportAllocs()
+		for b.Loop() {
+			enc, err := pool.Get()
+			if err != nil {
+				b.Fatal(err)
+			}
+			encode(b, enc)
+			pool.Put(enc)
+		}
+	})
+}
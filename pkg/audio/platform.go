@@ -96,6 +96,15 @@ type Connection interface {
 	Disconnect() error
 }
 
+// ParticipantResolver is implemented by a [Connection] whose input streams are
+// not keyed by the participants' user IDs. Discord, for example, keys them by
+// the SSRC of the RTP stream, which changes whenever a user reconnects.
+type ParticipantResolver interface {
+	// ParticipantID returns the user ID of the participant whose audio
+	// arrives on the input stream streamID, or "" if it is not known yet.
+	ParticipantID(streamID string) string
+}
+
 // Platform is the entry point for a voice-channel provider.
 // Implementations wrap provider-specific SDKs (Discord, TeamSpeak, …) and
 // expose a uniform [Connection] abstraction.
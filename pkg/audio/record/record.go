@@ -0,0 +1,273 @@
+// Package record writes the speech of a voice channel to disk as one WAV clip
+// per speech segment, leaving out the silences in between.
+//
+// A full-session recording is mostly silence: players listen, think, and
+// roll dice far longer than they talk. A [Recorder] runs voice activity
+// detection on every speaker's audio and only keeps the frames around speech.
+// Each segment gets a short pre-roll, so the first syllable is not clipped by
+// the detector's start latency, and a post-roll, so trailing words and short
+// pauses stay in the same clip. The result is a compact set of clips, each
+// tagged with its speaker and start time (see [Clip]).
+//
+// Durations are accounted in audio time (frame count × frame duration), so
+// clip boundaries do not depend on processing jitter.
+package record
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+)
+
+const (
+	// DefaultPreRoll is the audio kept before the detected start of speech
+	// when no explicit pre-roll is configured.
+	DefaultPreRoll = 300 * time.Millisecond
+
+	// DefaultPostRoll is the audio kept after the detected end of speech when
+	// no explicit post-roll is configured. Speech resuming within the
+	// post-roll continues the same clip.
+	DefaultPostRoll = 500 * time.Millisecond
+)
+
+// Clip describes one speech segment written to disk.
+type Clip struct {
+	// SpeakerID is the participant whose speech the clip holds.
+	SpeakerID string
+
+	// Path is the WAV file the clip was written to.
+	Path string
+
+	// Start is when the first frame of the clip, including pre-roll, was
+	// passed to [Recorder.Write].
+	Start time.Time
+
+	// Duration is the length of the audio in the clip.
+	Duration time.Duration
+}
+
+// Option configures a [Recorder] during construction.
+type Option func(*Recorder)
+
+// WithPreRoll sets how much audio before the detected start of speech is
+// included in a clip. Negative values are ignored. Defaults to
+// [DefaultPreRoll].
+func WithPreRoll(d time.Duration) Option {
+	return func(r *Recorder) {
+		if d >= 0 {
+			r.preRoll = d
+		}
+	}
+}
+
+// WithPostRoll sets how much audio after the detected end of speech is
+// included in a clip. Negative values are ignored. Defaults to
+// [DefaultPostRoll].
+func WithPostRoll(d time.Duration) Option {
+	return func(r *Recorder) {
+		if d >= 0 {
+			r.postRoll = d
+		}
+	}
+}
+
+// WithClock replaces the clock used to timestamp frames. Intended for tests.
+func WithClock(now func() time.Time) Option {
+	return func(r *Recorder) {
+		if now != nil {
+			r.now = now
+		}
+	}
+}
+
+// Recorder writes the speech segments of each speaker to a directory as WAV
+// files. Audio is 16-bit little-endian mono PCM in the frame format of the
+// VAD configuration.
+//
+// Recorder is safe for concurrent use. Frames of a single speaker must still
+// be written from one goroutine at a time, in order.
+type Recorder struct {
+	dir      string
+	seg      *vad.Segmenter
+	preRoll  time.Duration
+	postRoll time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	speakers map[string]*speaker
+	clips    []Clip
+	closed   bool
+}
+
+// frame is a buffered audio frame and the time it was written.
+type frame struct {
+	pcm []byte
+	at  time.Time
+}
+
+// speaker is the recording state of one speaker.
+type speaker struct {
+	// preRoll holds the most recent frames while no clip is open.
+	preRoll []frame
+
+	// clip holds the frames of the open clip, or nil.
+	clip []frame
+
+	// silent counts the non-speech frames since speech last ended.
+	silent int
+}
+
+// New creates a [Recorder] that writes clips to dir, creating it if needed,
+// and detects speech with sessions of engine configured with cfg.
+// cfg.SampleRate and cfg.FrameSizeMs must be positive.
+func New(engine vad.Engine, cfg vad.Config, dir string, opts ...Option) (*Recorder, error) {
+	if cfg.SampleRate <= 0 || cfg.FrameSizeMs <= 0 {
+		return nil, fmt.Errorf("record: sample rate and frame size must be positive, got %d Hz and %d ms", cfg.SampleRate, cfg.FrameSizeMs)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("record: create directory: %w", err)
+	}
+	r := &Recorder{
+		dir:      dir,
+		seg:      vad.NewSegmenter(engine, cfg),
+		preRoll:  DefaultPreRoll,
+		postRoll: DefaultPostRoll,
+		now:      time.Now,
+		speakers: make(map[string]*speaker),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r, nil
+}
+
+// Write passes one VAD frame of speakerID's audio to the recorder. Frames
+// around speech are kept; all others are dropped once they have left the
+// pre-roll window. When a speech segment ends, its clip is written to disk
+// before Write returns.
+func (r *Recorder) Write(speakerID string, pcm []byte) error {
+	ev, err := r.seg.ProcessFrame(speakerID, pcm)
+	if err != nil {
+		return fmt.Errorf("record: %w", err)
+	}
+	f := frame{pcm: append([]byte(nil), pcm...), at: r.now()}
+	speech := ev.Type == vad.VADSpeechStart || ev.Type == vad.VADSpeechContinue
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return errors.New("record: recorder is closed")
+	}
+	sp := r.speakers[speakerID]
+	if sp == nil {
+		sp = &speaker{}
+		r.speakers[speakerID] = sp
+	}
+
+	switch {
+	case speech && sp.clip == nil:
+		sp.clip = append(sp.preRoll, f)
+		sp.preRoll = nil
+		sp.silent = 0
+	case speech:
+		sp.clip = append(sp.clip, f)
+		sp.silent = 0
+	case sp.clip != nil && sp.silent < r.frames(speakerID, r.postRoll):
+		sp.clip = append(sp.clip, f)
+		sp.silent++
+	default:
+		if sp.clip != nil {
+			if err := r.flush(speakerID, sp); err != nil {
+				return err
+			}
+		}
+		sp.preRoll = append(sp.preRoll, f)
+		if n := r.frames(speakerID, r.preRoll); len(sp.preRoll) > n {
+			sp.preRoll = sp.preRoll[len(sp.preRoll)-n:]
+		}
+	}
+	return nil
+}
+
+// Clips returns the clips written so far, in the order they were written.
+func (r *Recorder) Clips() []Clip {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Clip(nil), r.clips...)
+}
+
+// Close writes the clips of speech segments that are still open and releases
+// the VAD sessions. Calling Close more than once is safe.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	var errs []error
+	for id, sp := range r.speakers {
+		if sp.clip != nil {
+			errs = append(errs, r.flush(id, sp))
+		}
+	}
+	r.mu.Unlock()
+
+	errs = append(errs, r.seg.Close())
+	return errors.Join(errs...)
+}
+
+// frames returns the number of speakerID's frames that make up at least d.
+func (r *Recorder) frames(speakerID string, d time.Duration) int {
+	frameDur := time.Duration(r.seg.ConfigFor(speakerID).FrameSizeMs) * time.Millisecond
+	return int((d + frameDur - 1) / frameDur)
+}
+
+// flush writes the open clip of sp to disk and closes it. r.mu must be held.
+func (r *Recorder) flush(speakerID string, sp *speaker) error {
+	frames := sp.clip
+	sp.clip = nil
+	sp.silent = 0
+
+	var pcm []byte
+	for _, f := range frames {
+		pcm = append(pcm, f.pcm...)
+	}
+	rate := r.seg.ConfigFor(speakerID).SampleRate
+	// Numbering the clips across speakers keeps the names unique and sorts
+	// them in the order they were written.
+	path := filepath.Join(r.dir, fmt.Sprintf("%04d_%s.wav", len(r.clips)+1, fileName(speakerID)))
+	if err := os.WriteFile(path, audio.EncodeWAV(pcm, rate, 1), 0o644); err != nil {
+		return fmt.Errorf("record: write clip: %w", err)
+	}
+	r.clips = append(r.clips, Clip{
+		SpeakerID: speakerID,
+		Path:      path,
+		Start:     frames[0].at,
+		Duration:  time.Duration(len(pcm)/2) * time.Second / time.Duration(rate),
+	})
+	return nil
+}
+
+// fileName makes speakerID safe for use in a file name.
+func fileName(speakerID string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, speakerID)
+	if name == "" {
+		return "speaker"
+	}
+	return name
+}
@@ -0,0 +1,260 @@
+package record_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/audio/record"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+)
+
+const (
+	sampleRate = 16000
+	frameMs    = 20
+	frameBytes = sampleRate * frameMs / 1000 * 2
+)
+
+var cfg = vad.Config{SampleRate: sampleRate, FrameSizeMs: frameMs}
+
+// energyVAD classifies frames with any non-zero sample as speech.
+type energyVAD struct{}
+
+func (energyVAD) NewSession(vad.Config) (vad.SessionHandle, error) { return &energySession{}, nil }
+
+type energySession struct{ speaking bool }
+
+func (s *energySession) ProcessFrame(frame []byte) (vad.VADEvent, error) {
+	loud := false
+	for i := 0; i+1 < len(frame); i += 2 {
+		if binary.LittleEndian.Uint16(frame[i:]) != 0 {
+			loud = true
+			break
+		}
+	}
+	was := s.speaking
+	s.speaking = loud
+	switch {
+	case loud && !was:
+		return vad.VADEvent{Type: vad.VADSpeechStart, Probability: 1}, nil
+	case loud:
+		return vad.VADEvent{Type: vad.VADSpeechContinue, Probability: 1}, nil
+	case was:
+		return vad.VADEvent{Type: vad.VADSpeechEnd}, nil
+	default:
+		return vad.VADEvent{Type: vad.VADSilence}, nil
+	}
+}
+
+func (s *energySession) Reset()       { s.speaking = false }
+func (s *energySession) Close() error { return nil }
+
+// speechFrame returns a frame of a constant non-zero level, tagged with n so
+// frames can be told apart in the written clips.
+func speechFrame(n int) []byte {
+	f := make([]byte, frameBytes)
+	for i := 0; i < len(f); i += 2 {
+		binary.LittleEndian.PutUint16(f[i:], uint16(1000+n))
+	}
+	return f
+}
+
+func silenceFrame() []byte { return make([]byte, frameBytes) }
+
+// fakeClock advances by one frame every call.
+func fakeClock(start time.Time) func() time.Time {
+	n := 0
+	return func() time.Time {
+		t := start.Add(time.Duration(n) * frameMs * time.Millisecond)
+		n++
+		return t
+	}
+}
+
+// readClip returns the PCM of a written clip.
+func readClip(t *testing.T, path string) []byte {
+	t.Helper()
+	wav, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read clip: %v", err)
+	}
+	info, err := audio.ParseWAV(wav)
+	if err != nil {
+		t.Fatalf("ParseWAV: %v", err)
+	}
+	if info.SampleRate != sampleRate || info.Channels != 1 {
+		t.Errorf("clip format = %d Hz, %d channels; want %d Hz mono", info.SampleRate, info.Channels, sampleRate)
+	}
+	return wav[info.DataOffset : info.DataOffset+info.DataSize]
+}
+
+func TestRecorder_WritesOnlySpeech(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	start := time.Date(2026, 10, 17, 19, 0, 0, 0, time.UTC)
+	rec, err := record.New(energyVAD{}, cfg, dir,
+		record.WithPreRoll(2*frameMs*time.Millisecond),
+		record.WithPostRoll(2*frameMs*time.Millisecond),
+		record.WithClock(fakeClock(start)),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// 10 silent, 5 speech, 10 silent, 3 speech, 10 silent frames.
+	var frames [][]byte
+	add := func(n int, speech bool) {
+		for range n {
+			if speech {
+				frames = append(frames, speechFrame(len(frames)))
+			} else {
+				frames = append(frames, silenceFrame())
+			}
+		}
+	}
+	add(10, false)
+	add(5, true)
+	add(10, false)
+	add(3, true)
+	add(10, false)
+	for i, f := range frames {
+		if err := rec.Write("player-1", f); err != nil {
+			t.Fatalf("Write frame %d: %v", i, err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	clips := rec.Clips()
+	if len(clips) != 2 {
+		t.Fatalf("got %d clips, want 2: %+v", len(clips), clips)
+	}
+	// Each clip is 2 frames pre-roll + speech + 2 frames post-roll.
+	tests := []struct {
+		firstFrame, frames int
+	}{
+		{firstFrame: 8, frames: 2 + 5 + 2},
+		{firstFrame: 23, frames: 2 + 3 + 2},
+	}
+	for i, want := range tests {
+		c := clips[i]
+		if c.SpeakerID != "player-1" {
+			t.Errorf("clip %d speaker = %q, want player-1", i, c.SpeakerID)
+		}
+		if wantStart := start.Add(time.Duration(want.firstFrame) * frameMs * time.Millisecond); !c.Start.Equal(wantStart) {
+			t.Errorf("clip %d start = %v, want %v", i, c.Start, wantStart)
+		}
+		if wantDur := time.Duration(want.frames) * frameMs * time.Millisecond; c.Duration != wantDur {
+			t.Errorf("clip %d duration = %v, want %v", i, c.Duration, wantDur)
+		}
+		if filepath.Dir(c.Path) != dir {
+			t.Errorf("clip %d path = %q, want a file in %q", i, c.Path, dir)
+		}
+
+		pcm := readClip(t, c.Path)
+		var wantPCM []byte
+		for _, f := range frames[want.firstFrame : want.firstFrame+want.frames] {
+			wantPCM = append(wantPCM, f...)
+		}
+		if string(pcm) != string(wantPCM) {
+			t.Errorf("clip %d holds %d bytes that differ from frames %d to %d", i, len(pcm), want.firstFrame, want.firstFrame+want.frames-1)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("directory holds %d files, want 2", len(entries))
+	}
+}
+
+func TestRecorder_PostRollJoinsShortPauses(t *testing.T) {
+	t.Parallel()
+
+	rec, err := record.New(energyVAD{}, cfg, t.TempDir(),
+		record.WithPreRoll(0),
+		record.WithPostRoll(3*frameMs*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// A two-frame pause is shorter than the post-roll: one clip.
+	seq := []bool{true, true, false, false, true, true, false, false, false, false}
+	for i, speech := range seq {
+		f := silenceFrame()
+		if speech {
+			f = speechFrame(i)
+		}
+		if err := rec.Write("player-1", f); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	clips := rec.Clips()
+	if len(clips) != 1 {
+		t.Fatalf("got %d clips, want 1: %+v", len(clips), clips)
+	}
+	// 6 frames up to the last speech frame plus 3 frames post-roll.
+	if want := 9 * frameMs * time.Millisecond; clips[0].Duration != want {
+		t.Errorf("duration = %v, want %v", clips[0].Duration, want)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestRecorder_SpeakersAndClose(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	rec, err := record.New(energyVAD{}, cfg, dir, record.WithPreRoll(0), record.WithPostRoll(0))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// Alice is still talking when the recorder closes; Bob stays silent.
+	for i := range 3 {
+		if err := rec.Write("alice/1", speechFrame(i)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if err := rec.Write("bob", silenceFrame()); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+
+	clips := rec.Clips()
+	if len(clips) != 1 || clips[0].SpeakerID != "alice/1" {
+		t.Fatalf("clips = %+v, want one clip of alice/1", clips)
+	}
+	if filepath.Dir(clips[0].Path) != dir {
+		t.Errorf("clip path %q escapes %q", clips[0].Path, dir)
+	}
+	if want := 3 * frameMs * time.Millisecond; clips[0].Duration != want {
+		t.Errorf("duration = %v, want %v", clips[0].Duration, want)
+	}
+	if err := rec.Write("alice/1", speechFrame(0)); err == nil {
+		t.Error("Write after Close: want error, got nil")
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	if _, err := record.New(energyVAD{}, vad.Config{SampleRate: sampleRate}, t.TempDir()); err == nil {
+		t.Error("New without frame size: want error, got nil")
+	}
+}
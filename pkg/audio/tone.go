@@ -0,0 +1,127 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// toneAmplitude is the peak level of generated tones as a fraction of
+	// full scale. Earcons should sit well below speech.
+	toneAmplitude = 0.25
+
+	// toneFadeMs is the length of the linear fade at each end of a tone,
+	// which keeps the start and stop from clicking.
+	toneFadeMs = 5
+)
+
+// Tone returns a sine tone of freq Hz lasting durMs milliseconds as 16-bit
+// little-endian mono PCM at sampleRate. The tone fades in and out over a few
+// milliseconds to avoid clicks. A non-positive freq yields silence of the
+// given length; a non-positive durMs or sampleRate yields nil.
+func Tone(freq float64, durMs, sampleRate int) []byte {
+	if durMs <= 0 || sampleRate <= 0 {
+		return nil
+	}
+	n := int(int64(sampleRate) * int64(durMs) / 1000)
+	out := make([]byte, n*2)
+	if freq <= 0 {
+		return out
+	}
+
+	fade := min(sampleRate*toneFadeMs/1000, n/2)
+	step := 2 * math.Pi * freq / float64(sampleRate)
+	for i := range n {
+		gain := toneAmplitude
+		if fade > 0 {
+			if i < fade {
+				gain *= float64(i) / float64(fade)
+			} else if i >= n-fade {
+				gain *= float64(n-1-i) / float64(fade)
+			}
+		}
+		s := int16(math.Round(math.Sin(step*float64(i)) * gain * math.MaxInt16))
+		out[i*2] = byte(s)
+		out[i*2+1] = byte(s >> 8)
+	}
+	return out
+}
+
+// Earcon is a short, named sound that signals a system state to the players,
+// such as an NPC listening or thinking.
+type Earcon int
+
+const (
+	// EarconListening is a rising two-note chime played when an NPC starts
+	// listening.
+	EarconListening Earcon = iota
+
+	// EarconThinking is a single soft note played while an NPC prepares a
+	// reply.
+	EarconThinking
+
+	// EarconMuted is a low double blip played when an NPC is muted.
+	EarconMuted
+
+	// EarconError is a falling two-note chime played when a turn fails.
+	EarconError
+
+	// EarconUnclear is a questioning rising two-note chime played when an
+	// NPC did not understand what was said.
+	EarconUnclear
+)
+
+// note is one step of an earcon. A zero freq is a pause.
+type note struct {
+	freq  float64
+	durMs int
+}
+
+// earconNotes holds the note sequence of each earcon.
+var earconNotes = map[Earcon][]note{
+	EarconListening: {{660, 70}, {0, 20}, {880, 90}},
+	EarconThinking:  {{520, 120}},
+	EarconMuted:     {{330, 50}, {0, 40}, {330, 50}},
+	EarconError:     {{440, 110}, {0, 20}, {294, 160}},
+	EarconUnclear:   {{440, 80}, {0, 30}, {554, 130}},
+}
+
+// String returns the earcon's name.
+func (e Earcon) String() string {
+	switch e {
+	case EarconListening:
+		return "listening"
+	case EarconThinking:
+		return "thinking"
+	case EarconMuted:
+		return "muted"
+	case EarconError:
+		return "error"
+	case EarconUnclear:
+		return "unclear"
+	default:
+		return fmt.Sprintf("Earcon(%d)", int(e))
+	}
+}
+
+// Duration returns how long the earcon plays. It is zero for an unknown
+// earcon.
+func (e Earcon) Duration() time.Duration {
+	var ms int
+	for _, n := range earconNotes[e] {
+		ms += n.durMs
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// PCM renders the earcon as 16-bit little-endian mono PCM at sampleRate. Use
+// a [FormatConverter] to match a platform's output format. It returns nil for
+// an unknown earcon or a non-positive sampleRate.
+func (e Earcon) PCM(sampleRate int) []byte {
+	var out []byte
+	for _, n := range earconNotes[e] {
+		out = append(out, Tone(n.freq, n.durMs, sampleRate)...)
+	}
+	return out
+}
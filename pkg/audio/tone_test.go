@@ -0,0 +1,118 @@
+package audio_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+)
+
+// zeroCrossings counts the sign changes between consecutive non-zero samples.
+func zeroCrossings(samples []int16) int {
+	var n int
+	var prev int16
+	for _, s := range samples {
+		if s == 0 {
+			continue
+		}
+		if prev != 0 && (prev < 0) != (s < 0) {
+			n++
+		}
+		prev = s
+	}
+	return n
+}
+
+func TestTone(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		freq       float64
+		durMs      int
+		sampleRate int
+	}{
+		{name: "A4 at 48kHz", freq: 440, durMs: 250, sampleRate: 48000},
+		{name: "high tone at 16kHz", freq: 1000, durMs: 100, sampleRate: 16000},
+		{name: "low tone at 22.05kHz", freq: 200, durMs: 500, sampleRate: 22050},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pcm := audio.Tone(tc.freq, tc.durMs, tc.sampleRate)
+			wantSamples := tc.sampleRate * tc.durMs / 1000
+			if len(pcm) != wantSamples*2 {
+				t.Fatalf("len = %d bytes, want %d", len(pcm), wantSamples*2)
+			}
+
+			samples := bytesToSamples(pcm)
+			// A sine wave crosses zero twice per cycle.
+			wantCrossings := 2 * tc.freq * float64(tc.durMs) / 1000
+			if got := float64(zeroCrossings(samples)); math.Abs(got-wantCrossings) > 2 {
+				t.Errorf("zero crossings = %v, want about %v", got, wantCrossings)
+			}
+
+			var peak int16
+			for _, s := range samples {
+				peak = max(peak, s)
+			}
+			if peak == 0 || peak > math.MaxInt16/2 {
+				t.Errorf("peak = %d, want a quiet but audible tone", peak)
+			}
+			if samples[0] != 0 || samples[len(samples)-1] != 0 {
+				t.Errorf("edges = %d, %d, want faded to 0", samples[0], samples[len(samples)-1])
+			}
+		})
+	}
+}
+
+func TestTone_Degenerate(t *testing.T) {
+	t.Parallel()
+
+	if got := audio.Tone(440, 0, 48000); got != nil {
+		t.Errorf("zero duration: len = %d, want nil", len(got))
+	}
+	if got := audio.Tone(440, 100, 0); got != nil {
+		t.Errorf("zero sample rate: len = %d, want nil", len(got))
+	}
+
+	silence := audio.Tone(0, 10, 48000)
+	if len(silence) != 960 {
+		t.Fatalf("silence len = %d, want 960", len(silence))
+	}
+	for i, b := range silence {
+		if b != 0 {
+			t.Fatalf("silence byte %d = %d, want 0", i, b)
+		}
+	}
+}
+
+func TestEarcon_PCM(t *testing.T) {
+	t.Parallel()
+
+	earcons := []audio.Earcon{audio.EarconListening, audio.EarconThinking, audio.EarconMuted, audio.EarconError, audio.EarconUnclear}
+	for _, e := range earcons {
+		for _, rate := range []int{16000, 48000} {
+			pcm := e.PCM(rate)
+			wantBytes := int(e.Duration().Milliseconds()) * rate / 1000 * 2
+			if e.Duration() <= 0 {
+				t.Errorf("%v: Duration = %v, want positive", e, e.Duration())
+			}
+			if len(pcm) != wantBytes {
+				t.Errorf("%v at %d Hz: len = %d bytes, want %d", e, rate, len(pcm), wantBytes)
+			}
+			if zeroCrossings(bytesToSamples(pcm)) == 0 {
+				t.Errorf("%v at %d Hz: PCM is silent", e, rate)
+			}
+		}
+	}
+
+	if got := audio.Earcon(99).PCM(48000); got != nil {
+		t.Errorf("unknown earcon: len = %d, want nil", len(got))
+	}
+	if got := audio.Earcon(99).String(); got != "Earcon(99)" {
+		t.Errorf("unknown earcon String = %q", got)
+	}
+}
@@ -0,0 +1,127 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// WAVInfo holds the format metadata extracted from a RIFF/WAVE header.
+type WAVInfo struct {
+	// DataOffset is the byte offset of the first sample in the data chunk.
+	DataOffset int
+
+	// DataSize is the size in bytes of the data chunk as declared in its
+	// header, clamped to the bytes actually present. Streaming encoders often
+	// write a placeholder size, so this may be the remainder of the buffer.
+	DataSize int
+
+	// SampleRate is the number of samples per second (e.g., 22050, 44100, 48000).
+	SampleRate int
+
+	// Channels is the channel count: 1 = mono, 2 = stereo.
+	Channels int
+
+	// BitsPerSample is the sample bit depth (e.g., 16). Zero if the fmt chunk
+	// was missing.
+	BitsPerSample int
+
+	// AudioFormat is the WAVE format tag: 1 = integer PCM, 3 = IEEE float.
+	// Zero if the fmt chunk was missing.
+	AudioFormat int
+}
+
+// ParseWAV scans the RIFF/WAVE container in wav and returns the data offset
+// and audio format from the "fmt " sub-chunk. This is more robust than
+// hardcoding a fixed 44-byte offset because the fmt chunk size may vary.
+//
+// If the data chunk appears before any fmt chunk, SampleRate and Channels
+// default to 22050 Hz mono.
+//
+// Returns an error if wav is not a valid RIFF/WAVE container or if the data
+// chunk cannot be located.
+func ParseWAV(wav []byte) (WAVInfo, error) {
+	if len(wav) < 12 {
+		return WAVInfo{}, errors.New("audio: WAV data too short to be a valid RIFF file")
+	}
+	if string(wav[0:4]) != "RIFF" {
+		return WAVInfo{}, errors.New("audio: WAV data missing RIFF header")
+	}
+	if string(wav[8:12]) != "WAVE" {
+		return WAVInfo{}, errors.New("audio: WAV data missing WAVE identifier")
+	}
+
+	var info WAVInfo
+	foundFmt := false
+
+	// Walk RIFF chunks starting immediately after the 12-byte RIFF/WAVE header.
+	offset := 12
+	for offset+8 <= len(wav) {
+		chunkID := string(wav[offset : offset+4])
+		chunkSize := int(binary.LittleEndian.Uint32(wav[offset+4 : offset+8]))
+
+		switch chunkID {
+		case "fmt ":
+			if chunkSize >= 16 && offset+8+16 <= len(wav) {
+				fmtData := wav[offset+8:]
+				info.AudioFormat = int(binary.LittleEndian.Uint16(fmtData[0:2]))
+				info.Channels = int(binary.LittleEndian.Uint16(fmtData[2:4]))
+				info.SampleRate = int(binary.LittleEndian.Uint32(fmtData[4:8]))
+				info.BitsPerSample = int(binary.LittleEndian.Uint16(fmtData[14:16]))
+				foundFmt = true
+			}
+		case "data":
+			info.DataOffset = offset + 8
+			info.DataSize = min(chunkSize, len(wav)-info.DataOffset)
+			if chunkSize == 0 {
+				// Placeholder size written by a streaming encoder.
+				info.DataSize = len(wav) - info.DataOffset
+			}
+			if !foundFmt {
+				// fmt chunk should appear before data, but be defensive.
+				info.SampleRate = 22050
+				info.Channels = 1
+			}
+			return info, nil
+		}
+
+		// Advance past this chunk (chunks are word-aligned: pad by 1 if odd size).
+		offset += 8 + chunkSize
+		if chunkSize%2 != 0 {
+			offset++
+		}
+	}
+	return WAVInfo{}, errors.New("audio: WAV data missing data chunk")
+}
+
+// EncodeWAV wraps 16-bit signed little-endian PCM in a canonical 44-byte
+// RIFF/WAVE header, producing a complete WAV file.
+func EncodeWAV(pcm []byte, sampleRate, channels int) []byte {
+	const bitsPerSample = 16
+	byteRate := sampleRate * channels * bitsPerSample / 8
+	blockAlign := channels * bitsPerSample / 8
+	dataSize := len(pcm)
+
+	buf := make([]byte, 44+dataSize)
+
+	// RIFF chunk descriptor
+	copy(buf[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(36+dataSize)) // file size − 8
+	copy(buf[8:12], "WAVE")
+
+	// fmt sub-chunk
+	copy(buf[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(buf[16:20], 16)                 // sub-chunk size (PCM)
+	binary.LittleEndian.PutUint16(buf[20:22], 1)                  // audio format: PCM
+	binary.LittleEndian.PutUint16(buf[22:24], uint16(channels))   // num channels
+	binary.LittleEndian.PutUint32(buf[24:28], uint32(sampleRate)) // sample rate
+	binary.LittleEndian.PutUint32(buf[28:32], uint32(byteRate))   // byte rate
+	binary.LittleEndian.PutUint16(buf[32:34], uint16(blockAlign)) // block align
+	binary.LittleEndian.PutUint16(buf[34:36], bitsPerSample)      // bits per sample
+
+	// data sub-chunk
+	copy(buf[36:40], "data")
+	binary.LittleEndian.PutUint32(buf[40:44], uint32(dataSize))
+	copy(buf[44:], pcm)
+
+	return buf
+}
@@ -0,0 +1,129 @@
+package idgen
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+// uuidv7Pattern matches a canonical version 7 UUID with the RFC 9562 variant.
+var uuidv7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestUUIDv7_Format(t *testing.T) {
+	t.Parallel()
+
+	at := time.UnixMilli(0x0123456789ab)
+	g := &UUIDv7{now: func() time.Time { return at }}
+	for range 100 {
+		id := g.NewID()
+		if !uuidv7Pattern.MatchString(id) {
+			t.Fatalf("NewID() = %q, want a version 7 UUID", id)
+		}
+		if id[:13] != "01234567-89ab" {
+			t.Fatalf("NewID() = %q, want timestamp prefix %q", id, "01234567-89ab")
+		}
+	}
+}
+
+func TestUUIDv7_Unique(t *testing.T) {
+	t.Parallel()
+
+	const (
+		workers   = 8
+		perWorker = 5000
+	)
+	g := NewUUIDv7()
+	ids := make([][]string, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Go(func() {
+			for range perWorker {
+				ids[w] = append(ids[w], g.NewID())
+			}
+		})
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, workers*perWorker)
+	for _, batch := range ids {
+		for _, id := range batch {
+			if seen[id] {
+				t.Fatalf("duplicate ID %q", id)
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func TestUUIDv7_Monotonic(t *testing.T) {
+	t.Parallel()
+
+	base := time.UnixMilli(1_700_000_000_000)
+	tests := []struct {
+		name  string
+		clock []time.Duration // offsets from base, used in turn
+		n     int
+	}{
+		{name: "same millisecond", clock: []time.Duration{0}, n: 10000},
+		{name: "advancing clock", clock: []time.Duration{0, time.Millisecond, 2 * time.Millisecond}, n: 3000},
+		{name: "clock steps back", clock: []time.Duration{time.Second, 0, -time.Hour}, n: 3000},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu   sync.Mutex
+				tick int
+			)
+			g := &UUIDv7{now: func() time.Time {
+				mu.Lock()
+				defer mu.Unlock()
+				d := tc.clock[min(tick*len(tc.clock)/tc.n, len(tc.clock)-1)]
+				tick++
+				return base.Add(d)
+			}}
+
+			prev := g.NewID()
+			for range tc.n - 1 {
+				id := g.NewID()
+				if id <= prev {
+					t.Fatalf("NewID() = %q after %q, want increasing IDs", id, prev)
+				}
+				if !uuidv7Pattern.MatchString(id) {
+					t.Fatalf("NewID() = %q, want a version 7 UUID", id)
+				}
+				prev = id
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	t.Parallel()
+
+	if Default() != Default() {
+		t.Error("Default() returned different generators")
+	}
+	a, b := New(), Default().NewID()
+	if !uuidv7Pattern.MatchString(a) || b <= a {
+		t.Errorf("New() = %q, Default().NewID() = %q, want increasing version 7 UUIDs", a, b)
+	}
+}
+
+func TestFunc(t *testing.T) {
+	t.Parallel()
+
+	n := 0
+	var g Generator = Func(func() string {
+		n++
+		return "id-" + string(rune('0'+n))
+	})
+	if got := g.NewID(); got != "id-1" {
+		t.Errorf("NewID() = %q, want %q", got, "id-1")
+	}
+	if got := g.NewID(); got != "id-2" {
+		t.Errorf("NewID() = %q, want %q", got, "id-2")
+	}
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EntityKey is the position of an entity in the order of
+// [KnowledgeGraph.FindEntities]: by name, then by ID. [EntityFilter.Cursor]
+// encodes the key of the last entity of the previous page.
+type EntityKey struct {
+	Name string
+	ID   string
+}
+
+// RelationshipKey is the position of a relationship in the order of
+// [KnowledgeGraph.GetRelationships]: by creation time, then by source, target
+// and type. The cursor of [WithRelCursor] encodes the key of the last
+// relationship of the previous page.
+type RelationshipKey struct {
+	CreatedAt time.Time
+	SourceID  string
+	TargetID  string
+	RelType   string
+}
+
+// EntityCursor returns the opaque cursor that makes
+// [KnowledgeGraph.FindEntities] resume after e.
+func EntityCursor(e Entity) string {
+	return encodeCursor([]string{e.Name, e.ID})
+}
+
+// RelationshipCursor returns the opaque cursor that makes
+// [KnowledgeGraph.GetRelationships] resume after r.
+func RelationshipCursor(r Relationship) string {
+	return encodeCursor([]string{r.CreatedAt.UTC().Format(time.RFC3339Nano), r.SourceID, r.TargetID, r.RelType})
+}
+
+// NextEntityCursor returns the cursor of the page following page, a result of
+// [KnowledgeGraph.FindEntities] with [EntityFilter.Limit] set to limit. It
+// returns "" when page is the last page: when limit is not positive or page
+// holds fewer than limit entities.
+func NextEntityCursor(page []Entity, limit int) string {
+	if limit <= 0 || len(page) < limit {
+		return ""
+	}
+	return EntityCursor(page[len(page)-1])
+}
+
+// NextRelationshipCursor returns the cursor of the page following page, a
+// result of [KnowledgeGraph.GetRelationships] with [WithRelLimit] set to
+// limit. It returns "" when page is the last page: when limit is not positive
+// or page holds fewer than limit relationships.
+func NextRelationshipCursor(page []Relationship, limit int) string {
+	if limit <= 0 || len(page) < limit {
+		return ""
+	}
+	return RelationshipCursor(page[len(page)-1])
+}
+
+// DecodeEntityCursor returns the key encoded by a cursor of [EntityCursor].
+// It returns an error wrapping [ErrInvalidCursor] for any other string. This
+// helper lets storage backends read cursors without knowing their encoding.
+func DecodeEntityCursor(cursor string) (EntityKey, error) {
+	fields, err := decodeCursor(cursor, 2)
+	if err != nil {
+		return EntityKey{}, err
+	}
+	return EntityKey{Name: fields[0], ID: fields[1]}, nil
+}
+
+// DecodeRelationshipCursor returns the key encoded by a cursor of
+// [RelationshipCursor]. It returns an error wrapping [ErrInvalidCursor] for
+// any other string.
+func DecodeRelationshipCursor(cursor string) (RelationshipKey, error) {
+	fields, err := decodeCursor(cursor, 4)
+	if err != nil {
+		return RelationshipKey{}, err
+	}
+	at, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return RelationshipKey{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return RelationshipKey{CreatedAt: at, SourceID: fields[1], TargetID: fields[2], RelType: fields[3]}, nil
+}
+
+// encodeCursor encodes fields as URL-safe base64 of a JSON array, so cursors
+// can be passed around in URLs and tool arguments unchanged.
+func encodeCursor(fields []string) string {
+	data, _ := json.Marshal(fields) // a []string always marshals
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reverses [encodeCursor], checking that the cursor holds n
+// fields.
+func decodeCursor(cursor string, n int) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var fields []string
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if len(fields) != n {
+		return nil, fmt.Errorf("%w: %d fields, want %d", ErrInvalidCursor, len(fields), n)
+	}
+	return fields, nil
+}
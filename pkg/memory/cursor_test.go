@@ -0,0 +1,83 @@
+package memory_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+func TestEntityCursor_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	e := memory.Entity{ID: "npc-1", Name: `Grimjaw "the" Bold`}
+	key, err := memory.DecodeEntityCursor(memory.EntityCursor(e))
+	if err != nil {
+		t.Fatalf("DecodeEntityCursor: %v", err)
+	}
+	if want := (memory.EntityKey{Name: e.Name, ID: e.ID}); key != want {
+		t.Errorf("key: got %+v, want %+v", key, want)
+	}
+}
+
+func TestRelationshipCursor_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 3, 1, 20, 15, 0, 123456789, time.FixedZone("CET", 3600))
+	r := memory.Relationship{SourceID: "npc-1", TargetID: "loc-1", RelType: "lives_in", CreatedAt: at}
+	key, err := memory.DecodeRelationshipCursor(memory.RelationshipCursor(r))
+	if err != nil {
+		t.Fatalf("DecodeRelationshipCursor: %v", err)
+	}
+	if !key.CreatedAt.Equal(at) || key.SourceID != r.SourceID || key.TargetID != r.TargetID || key.RelType != r.RelType {
+		t.Errorf("key: got %+v, want %+v", key, r)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	t.Parallel()
+
+	entityCursor := memory.EntityCursor(memory.Entity{ID: "npc-1", Name: "Grimjaw"})
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "%%%"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("npc-1"))},
+		{"wrong field count", entityCursor},
+		{"bad time", base64.RawURLEncoding.EncodeToString([]byte(`["yesterday","a","b","c"]`))},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := memory.DecodeRelationshipCursor(tc.cursor); !errors.Is(err, memory.ErrInvalidCursor) {
+				t.Errorf("DecodeRelationshipCursor(%q): got %v, want ErrInvalidCursor", tc.cursor, err)
+			}
+		})
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	t.Parallel()
+
+	entities := []memory.Entity{{ID: "a", Name: "A"}, {ID: "b", Name: "B"}}
+	if got := memory.NextEntityCursor(entities, 2); got != memory.EntityCursor(entities[1]) {
+		t.Errorf("full page: got %q, want cursor of last entity", got)
+	}
+	if got := memory.NextEntityCursor(entities, 3); got != "" {
+		t.Errorf("short page: got %q, want empty", got)
+	}
+	if got := memory.NextEntityCursor(entities, 0); got != "" {
+		t.Errorf("no limit: got %q, want empty", got)
+	}
+
+	rels := []memory.Relationship{{SourceID: "a", TargetID: "b", RelType: "knows"}}
+	if got := memory.NextRelationshipCursor(rels, 1); got != memory.RelationshipCursor(rels[0]) {
+		t.Errorf("full page: got %q, want cursor of last relationship", got)
+	}
+	if got := memory.NextRelationshipCursor(nil, 1); got != "" {
+		t.Errorf("empty page: got %q, want empty", got)
+	}
+}
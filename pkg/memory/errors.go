@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrEntityNotFound is returned (wrapped) by [KnowledgeGraph] operations that
+// require an existing entity, such as [KnowledgeGraph.UpdateEntity] and
+// [KnowledgeGraph.IdentitySnapshot], when the referenced entity does not exist.
+// Use [errors.Is] to test for it; the wrapping error names the missing ID.
+var ErrEntityNotFound = errors.New("entity not found")
+
+// ErrRelationshipNotFound is returned (wrapped) by operations that require an
+// existing relationship when the referenced edge does not exist. Use
+// [errors.Is] to test for it.
+//
+// Note that [KnowledgeGraph.DeleteRelationship] is idempotent and never
+// returns this error.
+var ErrRelationshipNotFound = errors.New("relationship not found")
+
+// ErrEmbeddingMismatch is returned (wrapped) when an embedding does not fit
+// the vectors already stored in a [SemanticIndex], because its dimension
+// differs or because the store was populated by a different embedding model.
+// Vectors from different models live in different spaces and must not be
+// compared; re-embed the stored chunks with the new model instead. Use
+// [errors.Is] to test for it.
+var ErrEmbeddingMismatch = errors.New("embedding model mismatch")
+
+// ErrEmbeddingDimMismatch is returned (wrapped) when an embedding's length
+// differs from the dimension of the vectors already stored, typically after
+// switching embedding providers without re-embedding. Stores check it before
+// touching the database, so the error names both dimensions instead of
+// surfacing a driver error. It wraps [ErrEmbeddingMismatch], so errors.Is
+// matches either.
+var ErrEmbeddingDimMismatch = fmt.Errorf("embedding dimension mismatch: %w", ErrEmbeddingMismatch)
+
+// ErrDistanceMetricMismatch is returned (wrapped) when a [SemanticIndex] is
+// opened with a [DistanceMetric] other than the one its vector index was
+// built for. An index only accelerates queries of its own metric, so the
+// index must be re-created before the metric can change. Use [errors.Is] to
+// test for it.
+var ErrDistanceMetricMismatch = errors.New("distance metric mismatch")
+
+// ErrInvalidAlpha is returned (wrapped) by [HybridQuerier.QueryHybrid] when
+// its alpha weight is outside [0, 1]. Use [errors.Is] to test for it.
+var ErrInvalidAlpha = errors.New("hybrid alpha must be between 0 and 1")
+
+// ErrInvalidCursor is returned (wrapped) by [KnowledgeGraph.FindEntities] and
+// [KnowledgeGraph.GetRelationships] when a pagination cursor was not produced
+// by [EntityCursor] or [RelationshipCursor]. Use [errors.Is] to test for it.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// ErrSessionNotFound is returned (wrapped) by operations that require an
+// existing session, such as [SessionForker.ForkSession], when no entries are
+// stored under the given session ID. Use [errors.Is] to test for it.
+var ErrSessionNotFound = errors.New("session not found")
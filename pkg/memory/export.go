@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Graph export formats accepted by [GraphExporter.ExportGraph] and
+// [EncodeGraph].
+const (
+	// GraphFormatDOT is the Graphviz DOT language, rendered with e.g.
+	// "dot -Tsvg".
+	GraphFormatDOT = "dot"
+
+	// GraphFormatGraphML is the XML-based GraphML format understood by
+	// editors such as yEd, Gephi and Cytoscape.
+	GraphFormatGraphML = "graphml"
+)
+
+// CampaignAttribute is the entity attribute naming the campaign an entity
+// belongs to. [ExportCampaign] selects entities by it.
+const CampaignAttribute = "campaign"
+
+// exportOptions accumulates options for [GraphExporter.ExportGraph].
+// Unexported — callers configure it via [ExportOpt] functional options.
+type exportOptions struct {
+	entityTypes []string
+	campaign    string
+}
+
+// ExportOpt is a functional option for [GraphExporter.ExportGraph].
+type ExportOpt func(*exportOptions)
+
+// ExportEntityTypes restricts the export to entities whose Type is in the
+// provided list. An empty list (the default) exports all entity types.
+func ExportEntityTypes(types ...string) ExportOpt {
+	return func(o *exportOptions) {
+		o.entityTypes = append(o.entityTypes, types...)
+	}
+}
+
+// ExportCampaign restricts the export to entities whose [CampaignAttribute]
+// equals campaign. An empty campaign (the default) exports all entities.
+func ExportCampaign(campaign string) ExportOpt {
+	return func(o *exportOptions) { o.campaign = campaign }
+}
+
+// GraphExporter is implemented by [KnowledgeGraph] backends that can render
+// their graph for visualisation tools, letting a DM see the relationship web
+// of a campaign.
+type GraphExporter interface {
+	// ExportGraph renders the entities selected by opts and the relationships
+	// between them in the given format ([GraphFormatDOT] or
+	// [GraphFormatGraphML]). Relationships to entities outside the selection
+	// are left out. Returns an error for an unsupported format.
+	ExportGraph(ctx context.Context, format string, opts ...ExportOpt) ([]byte, error)
+}
+
+// EncodeGraph renders entities and the relationships between them in the
+// given format. Relationships whose source or target is not among entities
+// are skipped. Nodes are ordered by ID and edges by source, target and type,
+// so equal graphs always produce identical output.
+//
+// Backends implementing [GraphExporter] select the entities and
+// relationships and delegate the rendering to EncodeGraph.
+func EncodeGraph(format string, entities []Entity, rels []Relationship) ([]byte, error) {
+	nodes := slices.SortedFunc(slices.Values(entities), func(a, b Entity) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	known := make(map[string]bool, len(nodes))
+	for _, e := range nodes {
+		known[e.ID] = true
+	}
+	var edges []Relationship
+	for _, r := range rels {
+		if known[r.SourceID] && known[r.TargetID] {
+			edges = append(edges, r)
+		}
+	}
+	slices.SortFunc(edges, func(a, b Relationship) int {
+		return cmp.Or(
+			strings.Compare(a.SourceID, b.SourceID),
+			strings.Compare(a.TargetID, b.TargetID),
+			strings.Compare(a.RelType, b.RelType),
+		)
+	})
+
+	switch strings.ToLower(format) {
+	case GraphFormatDOT:
+		return encodeDOT(nodes, edges), nil
+	case GraphFormatGraphML:
+		return encodeGraphML(nodes, edges)
+	default:
+		return nil, fmt.Errorf("unsupported graph export format %q (want %q or %q)", format, GraphFormatDOT, GraphFormatGraphML)
+	}
+}
+
+// encodeDOT renders a directed Graphviz graph. Nodes are labelled with the
+// entity name and carry the entity type; edges are labelled with the
+// relationship type.
+func encodeDOT(nodes []Entity, edges []Relationship) []byte {
+	var b bytes.Buffer
+	b.WriteString("digraph knowledge_graph {\n")
+	for _, e := range nodes {
+		fmt.Fprintf(&b, "  %s [label=%s, type=%s];\n", dotQuote(e.ID), dotQuote(e.Name), dotQuote(e.Type))
+	}
+	for _, r := range edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(r.SourceID), dotQuote(r.TargetID), dotQuote(r.RelType))
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// dotQuote returns s as a quoted DOT ID.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+	return `"` + r.Replace(s) + `"`
+}
+
+// GraphML document structure. Only the elements written by encodeGraphML are
+// modelled.
+type (
+	graphMLDoc struct {
+		XMLName xml.Name     `xml:"graphml"`
+		XMLNS   string       `xml:"xmlns,attr"`
+		Keys    []graphMLKey `xml:"key"`
+		Graph   graphMLGraph `xml:"graph"`
+	}
+	graphMLKey struct {
+		ID       string `xml:"id,attr"`
+		For      string `xml:"for,attr"`
+		AttrName string `xml:"attr.name,attr"`
+		AttrType string `xml:"attr.type,attr"`
+	}
+	graphMLGraph struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	}
+	graphMLNode struct {
+		ID   string        `xml:"id,attr"`
+		Data []graphMLData `xml:"data"`
+	}
+	graphMLEdge struct {
+		ID     string        `xml:"id,attr"`
+		Source string        `xml:"source,attr"`
+		Target string        `xml:"target,attr"`
+		Data   []graphMLData `xml:"data"`
+	}
+	graphMLData struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+// encodeGraphML renders a directed GraphML graph. Nodes carry the entity name
+// and type, edges the relationship type.
+func encodeGraphML(nodes []Entity, edges []Relationship) ([]byte, error) {
+	doc := graphMLDoc{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "name", For: "node", AttrName: "name", AttrType: "string"},
+			{ID: "type", For: "node", AttrName: "type", AttrType: "string"},
+			{ID: "rel_type", For: "edge", AttrName: "rel_type", AttrType: "string"},
+		},
+		Graph: graphMLGraph{ID: "knowledge_graph", EdgeDefault: "directed"},
+	}
+	for _, e := range nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID:   e.ID,
+			Data: []graphMLData{{Key: "name", Value: e.Name}, {Key: "type", Value: e.Type}},
+		})
+	}
+	for i, r := range edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			ID:     fmt.Sprintf("e%d", i),
+			Source: r.SourceID,
+			Target: r.TargetID,
+			Data:   []graphMLData{{Key: "rel_type", Value: r.RelType}},
+		})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode graphml: %w", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
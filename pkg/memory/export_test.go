@@ -0,0 +1,82 @@
+package memory_test
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+func TestEncodeGraph(t *testing.T) {
+	t.Parallel()
+
+	entities := []memory.Entity{
+		{ID: "tower", Type: "location", Name: `The "Black" Tower`},
+		{ID: "elara", Type: "npc", Name: "Elara"},
+	}
+	rels := []memory.Relationship{
+		{SourceID: "elara", TargetID: "tower", RelType: "LOCATED_AT"},
+		{SourceID: "elara", TargetID: "ghost", RelType: "KNOWS"}, // endpoint not exported
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: memory.GraphFormatDOT,
+			want: `digraph knowledge_graph {
+  "elara" [label="Elara", type="npc"];
+  "tower" [label="The \"Black\" Tower", type="location"];
+  "elara" -> "tower" [label="LOCATED_AT"];
+}
+`,
+		},
+		{
+			format: "GraphML",
+			want: xml.Header + `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="name" for="node" attr.name="name" attr.type="string"></key>
+  <key id="type" for="node" attr.name="type" attr.type="string"></key>
+  <key id="rel_type" for="edge" attr.name="rel_type" attr.type="string"></key>
+  <graph id="knowledge_graph" edgedefault="directed">
+    <node id="elara">
+      <data key="name">Elara</data>
+      <data key="type">npc</data>
+    </node>
+    <node id="tower">
+      <data key="name">The &#34;Black&#34; Tower</data>
+      <data key="type">location</data>
+    </node>
+    <edge id="e0" source="elara" target="tower">
+      <data key="rel_type">LOCATED_AT</data>
+    </edge>
+  </graph>
+</graphml>
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := memory.EncodeGraph(tc.format, entities, rels)
+			if err != nil {
+				t.Fatalf("EncodeGraph: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("EncodeGraph(%q) =\n%s\nwant\n%s", tc.format, got, tc.want)
+			}
+		})
+	}
+
+	t.Run("unsupported format", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := memory.EncodeGraph("svg", entities, rels)
+		if err == nil || !strings.Contains(err.Error(), "svg") {
+			t.Errorf("EncodeGraph(svg) error = %v, want unsupported format", err)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+)
+
+// forkOptions accumulates options for [SessionForker.ForkSession].
+// Unexported — callers configure it via [ForkOpt] functional options.
+type forkOptions struct {
+	chunks bool
+}
+
+// ForkOpt is a functional option for [SessionForker.ForkSession].
+type ForkOpt func(*forkOptions)
+
+// ForkChunks also copies the source session's L2 chunks, so semantic
+// retrieval on the fork finds what was said before the fork point. By default
+// only the L1 transcript is copied.
+func ForkChunks() ForkOpt {
+	return func(o *forkOptions) { o.chunks = true }
+}
+
+// ForkParams holds the resolved parameters from a slice of [ForkOpt].
+type ForkParams struct {
+	Chunks bool
+}
+
+// ApplyForkOpts applies a slice of [ForkOpt] functional options and returns
+// the resolved fork parameters as a [ForkParams]. This helper allows external
+// packages to read the option values without accessing the unexported
+// [forkOptions] type.
+func ApplyForkOpts(opts []ForkOpt) ForkParams {
+	o := &forkOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return ForkParams{Chunks: o.chunks}
+}
+
+// SessionForker is implemented by [SessionStore] backends that can branch a
+// session, letting a DM explore an alternate dialogue without changing canon.
+//
+// A fork is an independent session: entries written to it never appear in
+// the source session, and later entries of the source never appear in the
+// fork. Run agents on the fork by handing them its ID instead of the source's.
+// The knowledge graph (L3) is campaign-wide and stays shared, so facts an NPC
+// records on a fork do reach the source's NPCs.
+type SessionForker interface {
+	// ForkSession copies the transcript of sourceID, and with [ForkChunks]
+	// its L2 chunks, to a new session and returns the new session's ID.
+	// Returns an error wrapping [ErrSessionNotFound] when sourceID has no
+	// entries.
+	ForkSession(ctx context.Context, sourceID string, opts ...ForkOpt) (newID string, err error)
+}
+
+// NewForkID returns a fresh session ID for a fork of sourceID. The ID starts
+// with sourceID, so forks sort and read next to their origin.
+func NewForkID(sourceID string) string {
+	return sourceID + "-fork-" + strings.ToLower(rand.Text()[:8])
+}
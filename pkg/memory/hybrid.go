@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// rrfK is the rank offset of reciprocal-rank fusion. The customary 60 keeps
+// the first few ranks of one list from drowning out agreement between both.
+const rrfK = 60
+
+// HybridQuerier is implemented by [GraphRAGQuerier] backends that can combine
+// full-text and vector search in a single query. Full-text search finds
+// proper nouns and rare words that embeddings tend to blur, while vector
+// search finds paraphrases that share no words with the query; fusing both
+// rankings recalls more of the relevant chunks than either alone.
+type HybridQuerier interface {
+	GraphRAGQuerier
+
+	// QueryHybrid runs [GraphRAGQuerier.QueryWithContext] for query and
+	// [GraphRAGQuerier.QueryWithEmbedding] for embedding against the same
+	// snapshot of the store and fuses both rankings with [FuseRankings].
+	//
+	// alpha weighs the vector ranking against the full-text ranking: 0 ranks
+	// by full-text search alone, 1 by vector similarity alone. Values outside
+	// [0, 1] are rejected with [ErrInvalidAlpha]. graphScope and the
+	// embedding's dimensionality are handled as by the two queries. At most
+	// topK results are returned, ranked by descending Score.
+	QueryHybrid(ctx context.Context, query string, embedding []float32, topK int, graphScope []string, alpha float64) ([]ContextResult, error)
+}
+
+// FuseRankings merges the full-text results fts and the vector results vec,
+// each ordered best first, by weighted reciprocal-rank fusion. A result at
+// rank r (starting at 1) contributes (1-alpha)/(60+r) from fts and
+// alpha/(60+r) from vec; results are identified by entity ID and content.
+//
+// Scores are normalised to 0–1, so a result ranked first by both lists (or
+// first by the only list with non-zero weight) scores 1. Only ranks matter:
+// the Score values of fts and vec, which are not comparable with each other,
+// are ignored. At most topK results are returned, ranked by descending score
+// with ties broken by entity ID and content. The result is never nil.
+func FuseRankings(fts, vec []ContextResult, alpha float64, topK int) []ContextResult {
+	type key struct{ entityID, content string }
+	var (
+		fused []ContextResult
+		index = make(map[key]int)
+	)
+	add := func(results []ContextResult, weight float64) {
+		if weight == 0 {
+			return
+		}
+		for rank, r := range results {
+			k := key{r.Entity.ID, r.Content}
+			i, ok := index[k]
+			if !ok {
+				i = len(fused)
+				index[k] = i
+				r.Score = 0
+				fused = append(fused, r)
+			}
+			fused[i].Score += weight * (rrfK + 1) / float64(rrfK+rank+1)
+		}
+	}
+	add(fts, 1-alpha)
+	add(vec, alpha)
+
+	slices.SortFunc(fused, func(a, b ContextResult) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			cmp.Compare(a.Entity.ID, b.Entity.ID),
+			cmp.Compare(a.Content, b.Content),
+		)
+	})
+	if fused == nil {
+		fused = []ContextResult{}
+	}
+	return fused[:min(len(fused), max(topK, 0))]
+}
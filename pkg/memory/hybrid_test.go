@@ -0,0 +1,97 @@
+package memory_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// contextResult returns a result for the chunk content of entity id.
+func contextResult(id, content string, score float64) memory.ContextResult {
+	return memory.ContextResult{Entity: memory.Entity{ID: id}, Content: content, Score: score}
+}
+
+func TestFuseRankings(t *testing.T) {
+	t.Parallel()
+
+	// "Eldrinax" is a proper noun only full-text search finds.
+	fts := []memory.ContextResult{
+		contextResult("npc-1", "Eldrinax guards the gate.", 0.09),
+		contextResult("npc-2", "The gate is guarded at night.", 0.03),
+	}
+	vec := []memory.ContextResult{
+		contextResult("npc-2", "The gate is guarded at night.", 0.91),
+		contextResult("npc-3", "Watchmen patrol the walls.", 0.80),
+	}
+
+	type ranked struct {
+		id    string
+		score float64
+	}
+	tests := []struct {
+		name  string
+		alpha float64
+		topK  int
+		want  []ranked
+	}{
+		{
+			name:  "balanced",
+			alpha: 0.5,
+			topK:  5,
+			want:  []ranked{{"npc-2", 0.5*61/62 + 0.5}, {"npc-1", 0.5}, {"npc-3", 0.5 * 61 / 62}},
+		},
+		{
+			name:  "full-text only",
+			alpha: 0,
+			topK:  5,
+			want:  []ranked{{"npc-1", 1}, {"npc-2", 61.0 / 62}},
+		},
+		{
+			name:  "vector only",
+			alpha: 1,
+			topK:  5,
+			want:  []ranked{{"npc-2", 1}, {"npc-3", 61.0 / 62}},
+		},
+		{
+			name:  "topK",
+			alpha: 0.5,
+			topK:  1,
+			want:  []ranked{{"npc-2", 0.5*61/62 + 0.5}},
+		},
+		{
+			name:  "zero topK",
+			alpha: 0.5,
+			topK:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := memory.FuseRankings(fts, vec, tt.alpha, tt.topK)
+			if got == nil {
+				t.Fatal("FuseRankings returned nil")
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("FuseRankings = %+v, want %d results", got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				if got[i].Entity.ID != w.id || math.Abs(got[i].Score-w.score) > 1e-9 {
+					t.Errorf("result %d = %s (%v), want %s (%v)", i, got[i].Entity.ID, got[i].Score, w.id, w.score)
+				}
+				if got[i].Score < 0 || got[i].Score > 1 {
+					t.Errorf("result %d score %v outside [0, 1]", i, got[i].Score)
+				}
+			}
+		})
+	}
+
+	// The inputs keep their scores.
+	if fts[0].Score != 0.09 || vec[0].Score != 0.91 {
+		t.Errorf("inputs modified: %+v, %+v", fts[0], vec[0])
+	}
+	if got := memory.FuseRankings(nil, nil, 0.5, 5); got == nil || len(got) != 0 {
+		t.Errorf("FuseRankings(nil, nil) = %v, want empty non-nil slice", got)
+	}
+}
@@ -0,0 +1,530 @@
+package inmem
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// AddEntity implements [memory.KnowledgeGraph]. It upserts an entity. If an
+// entity with the same ID already exists it is completely replaced, keeping
+// its CreatedAt and refreshing UpdatedAt. The timestamps of entity are
+// ignored.
+func (s *Store) AddEntity(_ context.Context, entity memory.Entity) error {
+	attrs, err := normalizeAttributes(entity.Attributes)
+	if err != nil {
+		return fmt.Errorf("knowledge graph: marshal attributes: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+	entity.Attributes = attrs
+	entity.CreatedAt = t
+	entity.UpdatedAt = t
+	if old, ok := s.entities[entity.ID]; ok {
+		entity.CreatedAt = old.CreatedAt
+	}
+	s.entities[entity.ID] = entity
+	return nil
+}
+
+// GetEntity implements [memory.KnowledgeGraph]. It retrieves an entity by ID.
+// Returns (nil, nil) when the entity does not exist.
+func (s *Store) GetEntity(_ context.Context, id string) (*memory.Entity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.entity(id)
+	if !ok {
+		return nil, nil
+	}
+	return &e, nil
+}
+
+// UpdateEntity implements [memory.KnowledgeGraph]. It merges attrs into the
+// entity's top-level Attributes, like PostgreSQL's jsonb || operator, and
+// refreshes UpdatedAt. Returns an error wrapping [memory.ErrEntityNotFound]
+// when the entity does not exist.
+func (s *Store) UpdateEntity(_ context.Context, id string, attrs map[string]any) error {
+	update, err := normalizeAttributes(attrs)
+	if err != nil {
+		return fmt.Errorf("knowledge graph: marshal update attrs: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entities[id]
+	if !ok {
+		return fmt.Errorf("knowledge graph: update entity %q: %w", id, memory.ErrEntityNotFound)
+	}
+	merged := cloneAttributes(e.Attributes)
+	maps.Copy(merged, update)
+	e.Attributes = merged
+	e.UpdatedAt = now()
+	s.entities[id] = e
+	return nil
+}
+
+// DeleteEntity implements [memory.KnowledgeGraph]. It removes the entity and
+// all its relationships. Chunks associated with the entity are kept but no
+// longer match GraphRAG queries. Deleting a non-existent entity is not an
+// error.
+func (s *Store) DeleteEntity(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.edges[id] {
+		s.deleteRel(key)
+	}
+	delete(s.edges, id)
+	delete(s.entities, id)
+	return nil
+}
+
+// FindEntities implements [memory.KnowledgeGraph]. It returns all entities
+// matching filter, ordered by name and ID, starting after filter.Cursor and
+// capped at filter.Limit. All other non-zero filter fields are applied as AND
+// conditions; AttributeQuery matches like PostgreSQL's jsonb @> operator.
+func (s *Store) FindEntities(_ context.Context, filter memory.EntityFilter) ([]memory.Entity, error) {
+	query, err := normalizeAttributes(filter.AttributeQuery)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: marshal attribute query: %w", err)
+	}
+	var after *memory.EntityKey
+	if filter.Cursor != "" {
+		key, err := memory.DecodeEntityCursor(filter.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("knowledge graph: find entities: %w", err)
+		}
+		after = &key
+	}
+	name := strings.ToLower(filter.Name)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []memory.Entity{}
+	for id, e := range s.entities {
+		if filter.Type != "" && e.Type != filter.Type {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(e.Name), name) {
+			continue
+		}
+		if !jsonContains(e.Attributes, query) {
+			continue
+		}
+		if after != nil && cmp.Or(cmp.Compare(e.Name, after.Name), cmp.Compare(id, after.ID)) <= 0 {
+			continue
+		}
+		e, _ = s.entity(id)
+		result = append(result, e)
+	}
+	slices.SortFunc(result, func(a, b memory.Entity) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result, nil
+}
+
+// AddRelationship implements [memory.KnowledgeGraph]. It upserts a directed
+// edge between two entities. If the edge (SourceID, TargetID, RelType) already
+// exists its attributes and provenance are replaced and its CreatedAt is kept.
+// Returns an error wrapping [memory.ErrEntityNotFound] when either endpoint
+// entity does not exist.
+func (s *Store) AddRelationship(_ context.Context, rel memory.Relationship) error {
+	attrs, err := normalizeAttributes(rel.Attributes)
+	if err != nil {
+		return fmt.Errorf("knowledge graph: marshal relationship attributes: %w", err)
+	}
+	rel.Attributes = attrs
+	rel.Provenance.Timestamp = rel.Provenance.Timestamp.Round(0)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range []string{rel.SourceID, rel.TargetID} {
+		if _, ok := s.entities[id]; !ok {
+			return fmt.Errorf("knowledge graph: add relationship %q -> %q: %w", rel.SourceID, rel.TargetID, memory.ErrEntityNotFound)
+		}
+	}
+
+	key := relKey{source: rel.SourceID, target: rel.TargetID, relType: rel.RelType}
+	if old, ok := s.rels[key]; ok {
+		rel.CreatedAt = old.rel.CreatedAt
+		old.rel = rel
+		return nil
+	}
+
+	rel.CreatedAt = now()
+	s.seq++
+	s.rels[key] = &relEntry{rel: rel, seq: s.seq}
+	for _, id := range []string{rel.SourceID, rel.TargetID} {
+		if s.edges[id] == nil {
+			s.edges[id] = make(map[relKey]struct{})
+		}
+		s.edges[id][key] = struct{}{}
+	}
+	return nil
+}
+
+// GetRelationships implements [memory.KnowledgeGraph]. It returns relationships
+// associated with entityID in the order they were created. By default only
+// outgoing edges are returned; use [memory.WithIncoming] to include inbound
+// edges and [memory.WithRelTypes] to filter by edge type.
+//
+// A [memory.WithRelCursor] cursor resumes after its relationship. When that
+// relationship was deleted in the meantime, relationships created at the same
+// instant are ordered by source, target and type to find the position.
+func (s *Store) GetRelationships(_ context.Context, entityID string, opts ...memory.RelQueryOpt) ([]memory.Relationship, error) {
+	params := memory.ApplyRelQueryOpts(opts)
+	dirIn := params.DirectionIn
+	dirOut := params.DirectionOut
+	var after *memory.RelationshipKey
+	if params.Cursor != "" {
+		key, err := memory.DecodeRelationshipCursor(params.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("knowledge graph: get relationships: %w", err)
+		}
+		after = &key
+	}
+
+	// Default: outgoing only when neither direction is explicitly set.
+	if !dirIn && !dirOut {
+		dirOut = true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rels := s.relsOf(entityID, func(key relKey) bool {
+		if len(params.RelTypes) > 0 && !slices.Contains(params.RelTypes, key.relType) {
+			return false
+		}
+		if params.DMConfirmedOnly && !s.rels[key].rel.Provenance.DMConfirmed {
+			return false
+		}
+		return (dirOut && key.source == entityID) || (dirIn && key.target == entityID)
+	})
+	if after != nil {
+		rels = slices.DeleteFunc(rels, func(r memory.Relationship) bool { return !s.relAfter(r, *after) })
+	}
+	if params.Limit > 0 && len(rels) > params.Limit {
+		rels = rels[:params.Limit]
+	}
+	return rels, nil
+}
+
+// DeleteRelationship implements [memory.KnowledgeGraph]. It removes the
+// directed edge identified by (sourceID, targetID, relType). Deleting a
+// non-existent edge is not an error.
+func (s *Store) DeleteRelationship(_ context.Context, sourceID, targetID, relType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteRel(relKey{source: sourceID, target: targetID, relType: relType})
+	return nil
+}
+
+// ConfirmRelationship implements [memory.KnowledgeGraph]. It sets the
+// DMConfirmed flag of the edge's provenance, leaving everything else as is.
+// Returns an error wrapping [memory.ErrRelationshipNotFound] when the edge
+// does not exist.
+func (s *Store) ConfirmRelationship(_ context.Context, sourceID, targetID, relType string, confirmed bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.rels[relKey{source: sourceID, target: targetID, relType: relType}]
+	if !ok {
+		return fmt.Errorf("knowledge graph: confirm relationship %q -> %q (%s): %w", sourceID, targetID, relType, memory.ErrRelationshipNotFound)
+	}
+	entry.rel.Provenance.DMConfirmed = confirmed
+	return nil
+}
+
+// Neighbors implements [memory.KnowledgeGraph]. It performs a breadth-first
+// search from entityID up to depth hops, following edges in both directions,
+// and returns all reachable entities ordered by ID (the start entity is
+// excluded).
+//
+// [memory.TraversalOpt] options restrict which edge and node types are
+// followed on every hop, and cap the result set size.
+func (s *Store) Neighbors(_ context.Context, entityID string, depth int, opts ...memory.TraversalOpt) ([]memory.Entity, error) {
+	tparams := memory.ApplyTraversalOpts(opts)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []memory.Entity{}
+	if _, ok := s.entities[entityID]; !ok {
+		return result, nil
+	}
+
+	visited := map[string]bool{entityID: true}
+	frontier := []string{entityID}
+	for range depth {
+		var next []string
+		for _, id := range frontier {
+			for _, other := range s.adjacent(id, tparams.RelTypes) {
+				if visited[other] {
+					continue
+				}
+				if len(tparams.NodeTypes) > 0 && !slices.Contains(tparams.NodeTypes, s.entities[other].Type) {
+					continue
+				}
+				visited[other] = true
+				next = append(next, other)
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+		for _, id := range next {
+			e, _ := s.entity(id)
+			result = append(result, e)
+		}
+		frontier = next
+	}
+
+	slices.SortFunc(result, func(a, b memory.Entity) int { return cmp.Compare(a.ID, b.ID) })
+	if tparams.MaxNodes > 0 && len(result) > tparams.MaxNodes {
+		result = result[:tparams.MaxNodes]
+	}
+	return result, nil
+}
+
+// FindPath implements [memory.KnowledgeGraph]. It returns the shortest sequence
+// of entities (including fromID and toID) connecting fromID to toID following
+// edges in both directions, up to maxDepth hops. A path from an entity to
+// itself consists of that entity alone.
+//
+// Returns an empty (non-nil) slice when no path exists within maxDepth.
+func (s *Store) FindPath(_ context.Context, fromID, toID string, maxDepth int) ([]memory.Entity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, fromOK := s.entities[fromID]
+	_, toOK := s.entities[toID]
+	if !fromOK || !toOK {
+		return []memory.Entity{}, nil
+	}
+
+	// parent maps each visited entity to the one it was reached from.
+	parent := map[string]string{fromID: ""}
+	frontier := []string{fromID}
+	for depth := 0; depth < maxDepth && !hasKey(parent, toID); depth++ {
+		var next []string
+		for _, id := range frontier {
+			for _, other := range s.adjacent(id, nil) {
+				if hasKey(parent, other) {
+					continue
+				}
+				parent[other] = id
+				next = append(next, other)
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+		frontier = next
+	}
+	if !hasKey(parent, toID) {
+		return []memory.Entity{}, nil
+	}
+
+	var path []memory.Entity
+	for id := toID; id != ""; id = parent[id] {
+		e, _ := s.entity(id)
+		path = append(path, e)
+	}
+	slices.Reverse(path)
+	return path, nil
+}
+
+// VisibleSubgraph implements [memory.KnowledgeGraph]. It returns the NPC
+// entity itself, all entities it has direct relationships with, and those
+// relationships (both outgoing and incoming edges).
+func (s *Store) VisibleSubgraph(_ context.Context, npcID string) ([]memory.Entity, []memory.Relationship, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rels := s.relsOf(npcID, nil)
+	entities := []memory.Entity{}
+	if e, ok := s.entity(npcID); ok {
+		entities = append(entities, e)
+	}
+	entities = append(entities, s.relatedEntities(npcID, rels)...)
+	return entities, rels, nil
+}
+
+// IdentitySnapshot implements [memory.KnowledgeGraph]. It assembles a compact
+// [memory.NPCIdentity] for npcID containing the NPC's entity record, all its
+// direct relationships, and the entities those relationships reference.
+// Returns an error wrapping [memory.ErrEntityNotFound] when npcID does not exist.
+func (s *Store) IdentitySnapshot(_ context.Context, npcID string) (*memory.NPCIdentity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entity, ok := s.entity(npcID)
+	if !ok {
+		return nil, fmt.Errorf("knowledge graph: identity snapshot %q: %w", npcID, memory.ErrEntityNotFound)
+	}
+	rels := s.relsOf(npcID, nil)
+	return &memory.NPCIdentity{
+		Entity:          entity,
+		Relationships:   rels,
+		RelatedEntities: s.relatedEntities(npcID, rels),
+	}, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Private helpers — the caller must hold s.mu
+// ─────────────────────────────────────────────────────────────────────────────
+
+// entity returns a copy of the entity with the given ID.
+func (s *Store) entity(id string) (memory.Entity, bool) {
+	e, ok := s.entities[id]
+	if !ok {
+		return memory.Entity{}, false
+	}
+	e.Attributes = cloneAttributes(e.Attributes)
+	return e, true
+}
+
+// relsOf returns copies of the relationships that start or end at entityID
+// and satisfy keep (nil keeps all), in the order they were created.
+func (s *Store) relsOf(entityID string, keep func(relKey) bool) []memory.Relationship {
+	entries := make([]*relEntry, 0, len(s.edges[entityID]))
+	for key := range s.edges[entityID] {
+		if keep == nil || keep(key) {
+			entries = append(entries, s.rels[key])
+		}
+	}
+	slices.SortFunc(entries, func(a, b *relEntry) int {
+		return cmp.Or(a.rel.CreatedAt.Compare(b.rel.CreatedAt), cmp.Compare(a.seq, b.seq))
+	})
+
+	rels := make([]memory.Relationship, len(entries))
+	for i, entry := range entries {
+		rels[i] = entry.rel
+		rels[i].Attributes = cloneAttributes(entry.rel.Attributes)
+	}
+	return rels
+}
+
+// relAfter reports whether r comes after the relationship at key in the order
+// of [Store.relsOf]. Ties in creation time are broken by insertion order if
+// the relationship at key still exists, and by source, target and type
+// otherwise.
+func (s *Store) relAfter(r memory.Relationship, key memory.RelationshipKey) bool {
+	if c := r.CreatedAt.Compare(key.CreatedAt); c != 0 {
+		return c > 0
+	}
+	prev, ok := s.rels[relKey{source: key.SourceID, target: key.TargetID, relType: key.RelType}]
+	if ok && prev.rel.CreatedAt.Equal(key.CreatedAt) {
+		return s.rels[relKey{source: r.SourceID, target: r.TargetID, relType: r.RelType}].seq > prev.seq
+	}
+	return cmp.Or(
+		cmp.Compare(r.SourceID, key.SourceID),
+		cmp.Compare(r.TargetID, key.TargetID),
+		cmp.Compare(r.RelType, key.RelType),
+	) > 0
+}
+
+// relatedEntities returns copies of the entities other than entityID that
+// rels reference, in order of first appearance.
+func (s *Store) relatedEntities(entityID string, rels []memory.Relationship) []memory.Entity {
+	seen := map[string]bool{entityID: true}
+	related := []memory.Entity{}
+	for _, r := range rels {
+		for _, id := range []string{r.SourceID, r.TargetID} {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if e, ok := s.entity(id); ok {
+				related = append(related, e)
+			}
+		}
+	}
+	return related
+}
+
+// adjacent returns the IDs of the entities connected to id by an edge in
+// either direction whose type is in relTypes (all types if empty), sorted so
+// that traversals are deterministic.
+func (s *Store) adjacent(id string, relTypes []string) []string {
+	var ids []string
+	for key := range s.edges[id] {
+		if len(relTypes) > 0 && !slices.Contains(relTypes, key.relType) {
+			continue
+		}
+		other := key.target
+		if other == id {
+			other = key.source
+		}
+		ids = append(ids, other)
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// deleteRel removes the relationship identified by key, if it exists.
+func (s *Store) deleteRel(key relKey) {
+	if _, ok := s.rels[key]; !ok {
+		return
+	}
+	delete(s.rels, key)
+	delete(s.edges[key.source], key)
+	delete(s.edges[key.target], key)
+}
+
+// hasKey reports whether m holds key.
+func hasKey(m map[string]string, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// jsonContains reports whether got contains want under the rules of
+// PostgreSQL's jsonb @> operator: objects contain objects whose keys they all
+// have with contained values, arrays contain arrays whose every element they
+// contain, and scalars must be equal. Both values must be decoded JSON.
+func jsonContains(got, want any) bool {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, wv := range w {
+			gv, ok := g[k]
+			if !ok || !jsonContains(gv, wv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok {
+			return false
+		}
+		for _, wv := range w {
+			if !slices.ContainsFunc(g, func(gv any) bool { return jsonContains(gv, wv) }) {
+				return false
+			}
+		}
+		return true
+	default:
+		return got == want
+	}
+}
@@ -0,0 +1,246 @@
+package inmem
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// maxContextResults caps the results of [Store.QueryWithContext], like the
+// PostgreSQL store.
+const maxContextResults = 20
+
+// IndexChunk implements [memory.SemanticIndex]. It upserts a pre-embedded
+// [memory.Chunk]. If a chunk with the same ID already exists it is completely
+// replaced. The first chunk indexed with an embedding fixes the store's
+// embedding dimension; later embeddings of another dimension are rejected
+// with [memory.ErrEmbeddingDimMismatch]. Chunks without an embedding are only
+// found by [Store.QueryWithContext].
+func (s *Store) IndexChunk(_ context.Context, chunk memory.Chunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(chunk.Embedding) > 0 {
+		if err := s.checkDimensions(chunk.Embedding); err != nil {
+			return fmt.Errorf("semantic index: index chunk %q: %w", chunk.ID, err)
+		}
+		if s.dims == 0 {
+			s.dims = len(chunk.Embedding)
+		}
+	}
+	chunk.Embedding = slices.Clone(chunk.Embedding)
+	chunk.Timestamp = chunk.Timestamp.Round(0)
+	s.chunks[chunk.ID] = chunk
+	return nil
+}
+
+// Search implements [memory.SemanticIndex]. It compares the query embedding
+// with the embedding of every chunk matching filter and returns the topK
+// closest by cosine distance (most similar first). A query embedding whose
+// dimension differs from the stored vectors is rejected with
+// [memory.ErrEmbeddingDimMismatch].
+func (s *Store) Search(_ context.Context, embedding []float32, topK int, filter memory.ChunkFilter) ([]memory.ChunkResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkDimensions(embedding); err != nil {
+		return nil, fmt.Errorf("semantic index: search: %w", err)
+	}
+
+	results := []memory.ChunkResult{}
+	for _, c := range s.chunks {
+		if len(c.Embedding) == 0 || !matchesFilter(c, filter) {
+			continue
+		}
+		c.Embedding = slices.Clone(c.Embedding)
+		results = append(results, memory.ChunkResult{Chunk: c, Distance: cosineDistance(embedding, c.Embedding)})
+	}
+	slices.SortFunc(results, func(a, b memory.ChunkResult) int {
+		return cmp.Or(cmp.Compare(a.Distance, b.Distance), cmp.Compare(a.Chunk.ID, b.Chunk.ID))
+	})
+	return results[:min(len(results), max(topK, 0))], nil
+}
+
+// QueryWithContext implements [memory.GraphRAGQuerier]. It matches the query
+// against the content of the chunks associated with an entity in graphScope
+// (or with any entity when graphScope is empty).
+//
+// The query is split into words, and each chunk is scored by the share of
+// words its content contains, ignoring case. Chunks containing none of the
+// words are left out. At most 20 results are returned, ranked by descending
+// score.
+func (s *Store) QueryWithContext(_ context.Context, query string, graphScope []string) ([]memory.ContextResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.queryWords(query, graphScope, maxContextResults), nil
+}
+
+// QueryHybrid implements [memory.HybridQuerier]. The word matching of
+// [Store.QueryWithContext] and the cosine ranking of
+// [Store.QueryWithEmbedding] run under one read lock, each contributing at
+// least 20 candidates (topK when larger), and are fused by
+// [memory.FuseRankings].
+func (s *Store) QueryHybrid(_ context.Context, query string, embedding []float32, topK int, graphScope []string, alpha float64) ([]memory.ContextResult, error) {
+	if !(alpha >= 0 && alpha <= 1) {
+		return nil, fmt.Errorf("knowledge graph: query hybrid: alpha %v: %w", alpha, memory.ErrInvalidAlpha)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkDimensions(embedding); err != nil {
+		return nil, fmt.Errorf("knowledge graph: query hybrid: %w", err)
+	}
+	candidates := max(topK, maxContextResults)
+	fts := s.queryWords(query, graphScope, candidates)
+	vec := s.queryVector(embedding, graphScope, candidates)
+	return memory.FuseRankings(fts, vec, alpha, topK), nil
+}
+
+// QueryWithEmbedding implements [memory.GraphRAGQuerier]. It compares the
+// query embedding with the embedding of every chunk associated with an entity
+// in graphScope (or with any entity when graphScope is empty) and returns the
+// topK closest by cosine distance. Score is set to 1 - distance, so higher
+// scores indicate better matches.
+//
+// A query embedding whose dimension differs from the stored vectors is
+// rejected with [memory.ErrEmbeddingDimMismatch].
+func (s *Store) QueryWithEmbedding(_ context.Context, embedding []float32, topK int, graphScope []string) ([]memory.ContextResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkDimensions(embedding); err != nil {
+		return nil, fmt.Errorf("knowledge graph: query with embedding: %w", err)
+	}
+	return s.queryVector(embedding, graphScope, topK), nil
+}
+
+// DistanceMetric implements [memory.MetricReporter]. The store always
+// compares embeddings by [memory.MetricCosine].
+func (s *Store) DistanceMetric() memory.DistanceMetric { return memory.MetricCosine }
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Private helpers — the caller must hold s.mu
+// ─────────────────────────────────────────────────────────────────────────────
+
+// checkDimensions returns an error wrapping [memory.ErrEmbeddingDimMismatch]
+// if embedding does not have the store's embedding dimension.
+func (s *Store) checkDimensions(embedding []float32) error {
+	if s.dims > 0 && len(embedding) != s.dims {
+		return fmt.Errorf("embedding has %d dimensions, store holds %d-dimensional vectors: %w",
+			len(embedding), s.dims, memory.ErrEmbeddingDimMismatch)
+	}
+	return nil
+}
+
+// scopedChunks returns the chunks associated with an existing entity whose
+// ID is in graphScope, or with any existing entity when graphScope is empty.
+// The chunks share their embeddings with the store.
+func (s *Store) scopedChunks(graphScope []string) []memory.Chunk {
+	var chunks []memory.Chunk
+	for _, c := range s.chunks {
+		if _, ok := s.entities[c.EntityID]; !ok {
+			continue
+		}
+		if len(graphScope) > 0 && !slices.Contains(graphScope, c.EntityID) {
+			continue
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks
+}
+
+// queryWords returns the limit chunks in graphScope that contain the most
+// words of query, scored as described for [Store.QueryWithContext].
+func (s *Store) queryWords(query string, graphScope []string, limit int) []memory.ContextResult {
+	words := strings.Fields(strings.ToLower(query))
+	results := []memory.ContextResult{}
+	if len(words) == 0 {
+		return results
+	}
+	for _, c := range s.scopedChunks(graphScope) {
+		content := strings.ToLower(c.Content)
+		matched := 0
+		for _, w := range words {
+			if strings.Contains(content, w) {
+				matched++
+			}
+		}
+		if matched == 0 {
+			continue
+		}
+		e, _ := s.entity(c.EntityID)
+		results = append(results, memory.ContextResult{
+			Entity:  e,
+			Content: c.Content,
+			Score:   float64(matched) / float64(len(words)),
+		})
+	}
+	sortContextResults(results)
+	return results[:min(len(results), limit)]
+}
+
+// queryVector returns the topK chunks in graphScope closest to embedding,
+// scored as described for [Store.QueryWithEmbedding].
+func (s *Store) queryVector(embedding []float32, graphScope []string, topK int) []memory.ContextResult {
+	results := []memory.ContextResult{}
+	for _, c := range s.scopedChunks(graphScope) {
+		if len(c.Embedding) == 0 {
+			continue
+		}
+		e, _ := s.entity(c.EntityID)
+		results = append(results, memory.ContextResult{
+			Entity:  e,
+			Content: c.Content,
+			Score:   1 - cosineDistance(embedding, c.Embedding),
+		})
+	}
+	sortContextResults(results)
+	return results[:min(len(results), max(topK, 0))]
+}
+
+// matchesFilter reports whether c satisfies every non-zero field of filter.
+func matchesFilter(c memory.Chunk, filter memory.ChunkFilter) bool {
+	switch {
+	case filter.SessionID != "" && c.SessionID != filter.SessionID,
+		filter.SpeakerID != "" && c.SpeakerID != filter.SpeakerID,
+		filter.EntityID != "" && c.EntityID != filter.EntityID,
+		!filter.After.IsZero() && !c.Timestamp.After(filter.After),
+		!filter.Before.IsZero() && !c.Timestamp.Before(filter.Before):
+		return false
+	}
+	return true
+}
+
+// sortContextResults orders results by descending score, breaking ties by
+// entity ID and content so that the order is deterministic.
+func sortContextResults(results []memory.ContextResult) {
+	slices.SortFunc(results, func(a, b memory.ContextResult) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			cmp.Compare(a.Entity.ID, b.Entity.ID),
+			cmp.Compare(a.Content, b.Content),
+		)
+	})
+}
+
+// cosineDistance returns 1 minus the cosine similarity of a and b, which
+// ranges from 0 (same direction) to 2 (opposite directions). A zero vector is
+// treated as orthogonal to everything, giving a distance of 1.
+func cosineDistance(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return 1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))
+}
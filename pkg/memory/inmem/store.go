@@ -0,0 +1,146 @@
+// Package inmem implements the L3 knowledge graph ([memory.GraphRAGQuerier])
+// and the L2 semantic index ([memory.SemanticIndex]) in process memory.
+//
+// Entities, relationships and chunks are kept in maps and slices guarded by a
+// [sync.RWMutex]. Nothing is persisted: the graph is lost when the process
+// exits. The store has no dependencies, which makes it a good fit for tests,
+// demos and small single-process deployments, and a reference to check the
+// behaviour of the database-backed stores against.
+//
+// The store follows the semantics of the PostgreSQL store where the
+// interfaces leave room for choice:
+//
+//   - Attributes are normalised through JSON on the way in, so numbers come
+//     back as float64 and nested values as map[string]any and []any, exactly
+//     as they would after a round-trip through a jsonb column.
+//   - [Store.AddRelationship] enforces referential integrity and
+//     [Store.DeleteEntity] removes the entity's relationships with it.
+//   - [Store.Neighbors] and [Store.FindPath] follow edges in both directions
+//     with a plain breadth-first search.
+//   - [Store.QueryWithContext] scores chunks by the share of query words they
+//     contain (case-insensitive substring match) instead of full-text ranking,
+//     [Store.QueryWithEmbedding] and [Store.Search] compare every stored
+//     embedding by cosine distance, and [Store.QueryHybrid] fuses the two
+//     rankings.
+//
+// Typical usage:
+//
+//	store := inmem.NewStore()
+//	if err := store.AddEntity(ctx, memory.Entity{ID: "npc-1", Type: "npc", Name: "Grimjaw"}); err != nil { … }
+package inmem
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// Compile-time interface checks.
+var (
+	_ memory.KnowledgeGraph  = (*Store)(nil)
+	_ memory.GraphRAGQuerier = (*Store)(nil)
+	_ memory.SemanticIndex   = (*Store)(nil)
+	_ memory.MetricReporter  = (*Store)(nil)
+	_ memory.HybridQuerier   = (*Store)(nil)
+)
+
+// Store is an in-memory [memory.GraphRAGQuerier] and [memory.SemanticIndex].
+//
+// Values passed in and returned never share memory with the store. All
+// methods are safe for concurrent use. Use [NewStore] to create one.
+type Store struct {
+	mu sync.RWMutex
+
+	// entities holds every entity by ID.
+	entities map[string]memory.Entity
+
+	// rels holds every relationship by its (source, target, type) key.
+	rels map[relKey]*relEntry
+
+	// edges holds, per entity ID, the keys of the relationships that start or
+	// end at it.
+	edges map[string]map[relKey]struct{}
+
+	// seq numbers relationships in insertion order, which breaks ties between
+	// relationships created at the same instant.
+	seq uint64
+
+	// chunks holds every indexed chunk by ID.
+	chunks map[string]memory.Chunk
+
+	// dims is the embedding dimension, fixed by the first chunk indexed with
+	// an embedding. Zero means no embedding has been indexed yet.
+	dims int
+}
+
+// relKey identifies a relationship: at most one edge of each type exists
+// between the same source and target.
+type relKey struct {
+	source, target, relType string
+}
+
+// relEntry is a stored relationship and its insertion sequence number.
+type relEntry struct {
+	rel memory.Relationship
+	seq uint64
+}
+
+// NewStore returns an empty [Store].
+func NewStore() *Store {
+	return &Store{
+		entities: make(map[string]memory.Entity),
+		rels:     make(map[relKey]*relEntry),
+		edges:    make(map[string]map[relKey]struct{}),
+		chunks:   make(map[string]memory.Chunk),
+	}
+}
+
+// now returns the current time without its monotonic clock reading, so that
+// stored timestamps compare like the ones read back from a database.
+func now() time.Time {
+	return time.Now().Round(0)
+}
+
+// normalizeAttributes returns a copy of attrs in the form it takes after a
+// JSON round-trip. A nil map becomes an empty one.
+func normalizeAttributes(attrs map[string]any) (map[string]any, error) {
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	if out == nil {
+		out = map[string]any{}
+	}
+	return out, nil
+}
+
+// cloneAttributes returns a deep copy of normalised attributes.
+func cloneAttributes(attrs map[string]any) map[string]any {
+	out := make(map[string]any, len(attrs))
+	for k, v := range attrs {
+		out[k] = cloneValue(v)
+	}
+	return out
+}
+
+// cloneValue returns a deep copy of the decoded JSON value v.
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return cloneAttributes(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, x := range v {
+			out[i] = cloneValue(x)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,1085 @@
+package inmem_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/memory/inmem"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// L3 — Entity CRUD
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestL3_EntityCRUD(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+
+	entity := memory.Entity{
+		ID:   "ent-grimjaw",
+		Type: "npc",
+		Name: "Grimjaw",
+		Attributes: map[string]any{
+			"occupation": "blacksmith",
+			"alignment":  "neutral",
+		},
+	}
+
+	// Add.
+	if err := store.AddEntity(ctx, entity); err != nil {
+		t.Fatalf("AddEntity: %v", err)
+	}
+
+	// Get.
+	got, err := store.GetEntity(ctx, entity.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got == nil {
+		t.Fatal("GetEntity: expected entity, got nil")
+	}
+	if got.Name != entity.Name {
+		t.Errorf("Name: want %q, got %q", entity.Name, got.Name)
+	}
+	if got.Attributes["occupation"] != "blacksmith" {
+		t.Errorf("Attributes: expected occupation=blacksmith, got %v", got.Attributes)
+	}
+
+	// Update merges new key while preserving existing.
+	if err := store.UpdateEntity(ctx, entity.ID, map[string]any{"mood": "grumpy"}); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	updated, _ := store.GetEntity(ctx, entity.ID)
+	if updated.Attributes["mood"] != "grumpy" {
+		t.Errorf("UpdateEntity: want mood=grumpy, got %v", updated.Attributes)
+	}
+	if updated.Attributes["occupation"] != "blacksmith" {
+		t.Errorf("UpdateEntity: occupation should not be removed, got %v", updated.Attributes)
+	}
+
+	// UpdateEntity on missing ID returns error.
+	if err := store.UpdateEntity(ctx, "does-not-exist", map[string]any{}); !errors.Is(err, memory.ErrEntityNotFound) {
+		t.Errorf("UpdateEntity missing: want memory.ErrEntityNotFound, got %v", err)
+	}
+
+	// GetEntity for missing ID returns (nil, nil).
+	missing, err := store.GetEntity(ctx, "does-not-exist")
+	if err != nil {
+		t.Fatalf("GetEntity missing: unexpected error: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("GetEntity missing: want nil, got %+v", missing)
+	}
+
+	// Delete.
+	if err := store.DeleteEntity(ctx, entity.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	afterDelete, _ := store.GetEntity(ctx, entity.ID)
+	if afterDelete != nil {
+		t.Error("DeleteEntity: entity still present after delete")
+	}
+
+	// Delete non-existent is not an error.
+	if err := store.DeleteEntity(ctx, "never-existed"); err != nil {
+		t.Errorf("DeleteEntity non-existent: unexpected error: %v", err)
+	}
+}
+
+func TestL3_FindEntities(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+
+	for _, e := range []memory.Entity{
+		{ID: "loc-tavern", Type: "location", Name: "The Rusty Tankard", Attributes: map[string]any{"atmosphere": "lively"}},
+		{ID: "npc-elara", Type: "npc", Name: "Elara the Mage", Attributes: map[string]any{"class": "wizard"}},
+		{ID: "npc-thorin", Type: "npc", Name: "Thorin", Attributes: map[string]any{"class": "fighter"}},
+		{ID: "item-sword", Type: "item", Name: "Sword of Dawn", Attributes: map[string]any{"magical": true}},
+	} {
+		mustAddEntity(t, ctx, store, e)
+	}
+
+	tests := []struct {
+		name      string
+		filter    memory.EntityFilter
+		wantIDs   []string
+		wantCount int
+	}{
+		{"by type npc", memory.EntityFilter{Type: "npc"}, nil, 2},
+		{"by name substring", memory.EntityFilter{Name: "Elara"}, []string{"npc-elara"}, 1},
+		{"by attribute", memory.EntityFilter{AttributeQuery: map[string]any{"magical": true}}, []string{"item-sword"}, 1},
+		{"no match", memory.EntityFilter{Type: "faction"}, nil, 0},
+		{"empty filter", memory.EntityFilter{}, nil, 4},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := store.FindEntities(ctx, tc.filter)
+			if err != nil {
+				t.Fatalf("FindEntities: %v", err)
+			}
+			if tc.wantCount > 0 && len(results) != tc.wantCount {
+				t.Errorf("want %d, got %d", tc.wantCount, len(results))
+			}
+			for _, wid := range tc.wantIDs {
+				if !containsEntity(results, wid) {
+					t.Errorf("expected entity %q not found in results %v", wid, entityIDs(results))
+				}
+			}
+		})
+	}
+}
+
+func TestL3_Pagination(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+
+	for _, e := range []memory.Entity{
+		{ID: "npc-brom", Type: "npc", Name: "Brom"},
+		{ID: "npc-aldric-2", Type: "npc", Name: "Aldric"},
+		{ID: "npc-aldric-1", Type: "npc", Name: "Aldric"},
+		{ID: "npc-cora", Type: "npc", Name: "Cora"},
+		{ID: "npc-dain", Type: "npc", Name: "Dain"},
+		{ID: "loc-bridge", Type: "location", Name: "Bridge"},
+	} {
+		mustAddEntity(t, ctx, store, e)
+	}
+
+	// Entities: pages of two, ordered by name and ID, filter kept.
+	var gotIDs []string
+	filter := memory.EntityFilter{Type: "npc", Limit: 2}
+	for page := 0; ; page++ {
+		if page > 3 {
+			t.Fatalf("FindEntities: no last page, got %v", gotIDs)
+		}
+		entities, err := store.FindEntities(ctx, filter)
+		if err != nil {
+			t.Fatalf("FindEntities page %d: %v", page, err)
+		}
+		gotIDs = append(gotIDs, entityIDs(entities)...)
+		if filter.Cursor = memory.NextEntityCursor(entities, filter.Limit); filter.Cursor == "" {
+			break
+		}
+	}
+	if want := []string{"npc-aldric-1", "npc-aldric-2", "npc-brom", "npc-cora", "npc-dain"}; !slices.Equal(gotIDs, want) {
+		t.Errorf("paged entities: got %v, want %v", gotIDs, want)
+	}
+	if _, err := store.FindEntities(ctx, memory.EntityFilter{Cursor: "not a cursor"}); !errors.Is(err, memory.ErrInvalidCursor) {
+		t.Errorf("FindEntities with bad cursor: got %v, want ErrInvalidCursor", err)
+	}
+
+	// Relationships: pages of three match the unpaginated listing.
+	for _, target := range []string{"npc-brom", "npc-cora", "npc-dain", "loc-bridge"} {
+		if err := store.AddRelationship(ctx, memory.Relationship{SourceID: "npc-aldric-1", TargetID: target, RelType: "knows"}); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+	all, err := store.GetRelationships(ctx, "npc-aldric-1")
+	if err != nil {
+		t.Fatalf("GetRelationships: %v", err)
+	}
+	var paged []memory.Relationship
+	cursor := ""
+	for page := 0; ; page++ {
+		if page > 2 {
+			t.Fatalf("GetRelationships: no last page, got %d relationships", len(paged))
+		}
+		rels, err := store.GetRelationships(ctx, "npc-aldric-1", memory.WithRelLimit(3), memory.WithRelCursor(cursor))
+		if err != nil {
+			t.Fatalf("GetRelationships page %d: %v", page, err)
+		}
+		paged = append(paged, rels...)
+		if cursor = memory.NextRelationshipCursor(rels, 3); cursor == "" {
+			break
+		}
+	}
+	targets := func(rels []memory.Relationship) []string {
+		var ids []string
+		for _, r := range rels {
+			ids = append(ids, r.TargetID)
+		}
+		return ids
+	}
+	if got, want := targets(paged), targets(all); len(want) != 4 || !slices.Equal(got, want) {
+		t.Errorf("paged relationships: got %v, want %v", got, want)
+	}
+	if _, err := store.GetRelationships(ctx, "npc-aldric-1", memory.WithRelCursor("not a cursor")); !errors.Is(err, memory.ErrInvalidCursor) {
+		t.Errorf("GetRelationships with bad cursor: got %v, want ErrInvalidCursor", err)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// L3 — Relationship CRUD
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestL3_RelationshipCRUD(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+
+	grimjaw := memory.Entity{ID: "rel-grimjaw", Type: "npc", Name: "Grimjaw"}
+	tavern := memory.Entity{ID: "rel-tavern", Type: "location", Name: "The Rusty Tankard"}
+	guild := memory.Entity{ID: "rel-guild", Type: "faction", Name: "Blacksmiths Guild"}
+	for _, e := range []memory.Entity{grimjaw, tavern, guild} {
+		mustAddEntity(t, ctx, store, e)
+	}
+
+	rels := []memory.Relationship{
+		{
+			SourceID: grimjaw.ID, TargetID: tavern.ID, RelType: "LOCATED_AT",
+			Attributes: map[string]any{"since": "year 1200"},
+			Provenance: memory.Provenance{SessionID: "s1", Confidence: 0.9, Source: "stated"},
+		},
+		{
+			SourceID: grimjaw.ID, TargetID: guild.ID, RelType: "MEMBER_OF",
+			Attributes: map[string]any{},
+			Provenance: memory.Provenance{Confidence: 0.8, Source: "inferred"},
+		},
+	}
+	for _, r := range rels {
+		if err := store.AddRelationship(ctx, r); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+
+	// AddRelationship to a missing endpoint reports the missing entity.
+	err := store.AddRelationship(ctx, memory.Relationship{SourceID: grimjaw.ID, TargetID: "does-not-exist", RelType: "KNOWS"})
+	if !errors.Is(err, memory.ErrEntityNotFound) {
+		t.Errorf("AddRelationship missing endpoint: want memory.ErrEntityNotFound, got %v", err)
+	}
+
+	// GetRelationships: outgoing from grimjaw (default).
+	out, err := store.GetRelationships(ctx, grimjaw.ID)
+	if err != nil {
+		t.Fatalf("GetRelationships: %v", err)
+	}
+	if len(out) != 2 {
+		t.Errorf("outgoing: want 2, got %d", len(out))
+	}
+
+	// Filter by rel type.
+	locRels, err := store.GetRelationships(ctx, grimjaw.ID, memory.WithRelTypes("LOCATED_AT"))
+	if err != nil {
+		t.Fatalf("WithRelTypes: %v", err)
+	}
+	if len(locRels) != 1 {
+		t.Errorf("WithRelTypes: want 1, got %d", len(locRels))
+	}
+
+	// Incoming: tavern should see the edge from grimjaw.
+	inc, err := store.GetRelationships(ctx, tavern.ID, memory.WithIncoming())
+	if err != nil {
+		t.Fatalf("incoming: %v", err)
+	}
+	if len(inc) != 1 {
+		t.Errorf("incoming: want 1, got %d", len(inc))
+	}
+
+	// Provenance round-trip.
+	if len(locRels) > 0 && locRels[0].Provenance.Confidence != 0.9 {
+		t.Errorf("Provenance.Confidence: want 0.9, got %v", locRels[0].Provenance.Confidence)
+	}
+	if len(locRels) > 0 && locRels[0].Attributes["since"] != "year 1200" {
+		t.Errorf("Attributes[since]: want year 1200, got %v", locRels[0].Attributes)
+	}
+
+	// Upsert: replace with new attribute value.
+	updated := rels[0]
+	updated.Attributes = map[string]any{"since": "year 1205"}
+	if err := store.AddRelationship(ctx, updated); err != nil {
+		t.Fatalf("AddRelationship upsert: %v", err)
+	}
+	got, _ := store.GetRelationships(ctx, grimjaw.ID, memory.WithRelTypes("LOCATED_AT"))
+	if len(got) > 0 && got[0].Attributes["since"] != "year 1205" {
+		t.Errorf("upsert: want year 1205, got %v", got[0].Attributes)
+	}
+
+	// Delete.
+	if err := store.DeleteRelationship(ctx, grimjaw.ID, guild.ID, "MEMBER_OF"); err != nil {
+		t.Fatalf("DeleteRelationship: %v", err)
+	}
+	after, _ := store.GetRelationships(ctx, grimjaw.ID)
+	if len(after) != 1 {
+		t.Errorf("after delete: want 1, got %d", len(after))
+	}
+
+	// Delete non-existent is not an error.
+	if err := store.DeleteRelationship(ctx, "x", "y", "KNOWS"); err != nil {
+		t.Errorf("DeleteRelationship non-existent: unexpected error: %v", err)
+	}
+}
+
+func TestL3_ConfirmRelationship(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+
+	mustAddEntity(t, ctx, store, memory.Entity{ID: "npc-grimjaw", Type: "npc", Name: "Grimjaw"})
+	mustAddEntity(t, ctx, store, memory.Entity{ID: "fac-guild", Type: "faction", Name: "Thieves Guild"})
+	prov := memory.Provenance{SessionID: "session-1", Confidence: 0.45, Source: "inferred"}
+	for _, rel := range []memory.Relationship{
+		{SourceID: "npc-grimjaw", TargetID: "fac-guild", RelType: "MEMBER_OF", Attributes: map[string]any{"rank": "fence"}, Provenance: prov},
+		{SourceID: "npc-grimjaw", TargetID: "fac-guild", RelType: "OWES", Provenance: prov},
+	} {
+		if err := store.AddRelationship(ctx, rel); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+
+	// get returns the MEMBER_OF edge.
+	get := func() memory.Relationship {
+		t.Helper()
+		rels, err := store.GetRelationships(ctx, "npc-grimjaw", memory.WithRelTypes("MEMBER_OF"))
+		if err != nil || len(rels) != 1 {
+			t.Fatalf("GetRelationships: %d relationships, err %v", len(rels), err)
+		}
+		return rels[0]
+	}
+	// canon returns the types of the confirmed edges.
+	canon := func() []string {
+		t.Helper()
+		rels, err := store.GetRelationships(ctx, "npc-grimjaw", memory.WithDMConfirmedOnly())
+		if err != nil {
+			t.Fatalf("GetRelationships(WithDMConfirmedOnly): %v", err)
+		}
+		var types []string
+		for _, r := range rels {
+			types = append(types, r.RelType)
+		}
+		return types
+	}
+
+	if got := canon(); len(got) != 0 {
+		t.Errorf("confirmed before confirming: got %v, want none", got)
+	}
+	for _, confirmed := range []bool{true, false} {
+		if err := store.ConfirmRelationship(ctx, "npc-grimjaw", "fac-guild", "MEMBER_OF", confirmed); err != nil {
+			t.Fatalf("ConfirmRelationship(%v): %v", confirmed, err)
+		}
+		r := get()
+		if r.Provenance.DMConfirmed != confirmed {
+			t.Errorf("DMConfirmed: got %v, want %v", r.Provenance.DMConfirmed, confirmed)
+		}
+		// The rest of the provenance and the attributes survive the toggle.
+		p := r.Provenance
+		if p.SessionID != prov.SessionID || p.Confidence != prov.Confidence || p.Source != prov.Source {
+			t.Errorf("provenance after confirm(%v): got %+v, want %+v", confirmed, p, prov)
+		}
+		if r.Attributes["rank"] != "fence" {
+			t.Errorf("attributes after confirm(%v): got %v", confirmed, r.Attributes)
+		}
+		want := []string(nil)
+		if confirmed {
+			want = []string{"MEMBER_OF"}
+		}
+		if got := canon(); !slices.Equal(got, want) {
+			t.Errorf("confirmed after confirm(%v): got %v, want %v", confirmed, got, want)
+		}
+	}
+
+	// The edge is directed: the reverse edge does not exist.
+	err := store.ConfirmRelationship(ctx, "fac-guild", "npc-grimjaw", "MEMBER_OF", true)
+	if !errors.Is(err, memory.ErrRelationshipNotFound) {
+		t.Errorf("ConfirmRelationship on missing edge: got %v, want ErrRelationshipNotFound", err)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// L3 — Graph traversal
+// ─────────────────────────────────────────────────────────────────────────────
+
+// buildTestGraph creates a 5-node directed graph:
+//
+//	grimjaw → (KNOWS)      → elara
+//	grimjaw → (MEMBER_OF)  → guild
+//	elara   → (LOCATED_AT) → tower
+//	guild   → (ALLIED_WITH)→ mages
+func buildTestGraph(t *testing.T, ctx context.Context, store *inmem.Store) (grimjaw, elara, guild, tower, mages memory.Entity) {
+	t.Helper()
+	grimjaw = memory.Entity{ID: "g-grimjaw", Type: "npc", Name: "Grimjaw"}
+	elara = memory.Entity{ID: "g-elara", Type: "npc", Name: "Elara"}
+	guild = memory.Entity{ID: "g-guild", Type: "faction", Name: "Blacksmiths Guild"}
+	tower = memory.Entity{ID: "g-tower", Type: "location", Name: "Elara's Tower"}
+	mages = memory.Entity{ID: "g-mages", Type: "faction", Name: "Mages Council"}
+	for _, e := range []memory.Entity{grimjaw, elara, guild, tower, mages} {
+		mustAddEntity(t, ctx, store, e)
+	}
+	for _, r := range []memory.Relationship{
+		{SourceID: grimjaw.ID, TargetID: elara.ID, RelType: "KNOWS", Attributes: map[string]any{}},
+		{SourceID: grimjaw.ID, TargetID: guild.ID, RelType: "MEMBER_OF", Attributes: map[string]any{}},
+		{SourceID: elara.ID, TargetID: tower.ID, RelType: "LOCATED_AT", Attributes: map[string]any{}},
+		{SourceID: guild.ID, TargetID: mages.ID, RelType: "ALLIED_WITH", Attributes: map[string]any{}},
+	} {
+		if err := store.AddRelationship(ctx, r); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+	return
+}
+
+func TestL3_Neighbors(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+	grimjaw, _, _, _, _ := buildTestGraph(t, ctx, store)
+
+	// Depth 1: directly connected elara + guild.
+	n1, err := store.Neighbors(ctx, grimjaw.ID, 1)
+	if err != nil {
+		t.Fatalf("Neighbors(1): %v", err)
+	}
+	if len(n1) != 2 {
+		t.Errorf("Neighbors(1): want 2, got %d %v", len(n1), entityIDs(n1))
+	}
+
+	// Depth 2: adds tower + mages.
+	n2, err := store.Neighbors(ctx, grimjaw.ID, 2)
+	if err != nil {
+		t.Fatalf("Neighbors(2): %v", err)
+	}
+	if len(n2) != 4 {
+		t.Errorf("Neighbors(2): want 4, got %d %v", len(n2), entityIDs(n2))
+	}
+
+	// Depth 3: same as depth 2 (no additional reachable nodes).
+	n3, err := store.Neighbors(ctx, grimjaw.ID, 3)
+	if err != nil {
+		t.Fatalf("Neighbors(3): %v", err)
+	}
+	if len(n3) != 4 {
+		t.Errorf("Neighbors(3): want 4, got %d %v", len(n3), entityIDs(n3))
+	}
+
+	// RelType filter: only KNOWS → should find elara (and at depth 2: tower).
+	nKnows, err := store.Neighbors(ctx, grimjaw.ID, 2, memory.TraverseRelTypes("KNOWS", "LOCATED_AT"))
+	if err != nil {
+		t.Fatalf("Neighbors KNOWS: %v", err)
+	}
+	ids := entityIDs(nKnows)
+	if !containsStr(ids, "g-elara") {
+		t.Errorf("KNOWS filter: expected g-elara in %v", ids)
+	}
+	if containsStr(ids, "g-guild") {
+		t.Errorf("KNOWS filter: g-guild should not be in %v", ids)
+	}
+
+	// NodeType filter: only faction nodes.
+	nFaction, err := store.Neighbors(ctx, grimjaw.ID, 3, memory.TraverseNodeTypes("faction"))
+	if err != nil {
+		t.Fatalf("Neighbors faction: %v", err)
+	}
+	if len(nFaction) == 0 {
+		t.Error("faction node filter: expected at least 1 result")
+	}
+	for _, e := range nFaction {
+		if e.Type != "faction" {
+			t.Errorf("faction filter: got entity with type %q", e.Type)
+		}
+	}
+
+	// MaxNodes cap.
+	nCapped, err := store.Neighbors(ctx, grimjaw.ID, 3, memory.TraverseMaxNodes(2))
+	if err != nil {
+		t.Fatalf("Neighbors max nodes: %v", err)
+	}
+	if len(nCapped) > 2 {
+		t.Errorf("MaxNodes(2): want ≤2, got %d", len(nCapped))
+	}
+}
+
+func TestL3_FindPath(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+	grimjaw, _, _, tower, _ := buildTestGraph(t, ctx, store)
+
+	// grimjaw → elara → tower requires 2 hops.
+	path, err := store.FindPath(ctx, grimjaw.ID, tower.ID, 5)
+	if err != nil {
+		t.Fatalf("FindPath: %v", err)
+	}
+	if len(path) != 3 {
+		t.Errorf("FindPath: want length 3, got %d %v", len(path), entityIDs(path))
+	}
+	if len(path) > 0 && path[0].ID != grimjaw.ID {
+		t.Errorf("FindPath: want start %s, got %s", grimjaw.ID, path[0].ID)
+	}
+	if len(path) > 0 && path[len(path)-1].ID != tower.ID {
+		t.Errorf("FindPath: want end %s, got %s", tower.ID, path[len(path)-1].ID)
+	}
+
+	// maxDepth=1 is not enough to reach tower — expect empty.
+	short, err := store.FindPath(ctx, grimjaw.ID, tower.ID, 1)
+	if err != nil {
+		t.Fatalf("FindPath short: %v", err)
+	}
+	if len(short) != 0 {
+		t.Errorf("FindPath short: want empty, got %v", entityIDs(short))
+	}
+
+	// A path from a node to itself is just that node.
+	self, err := store.FindPath(ctx, grimjaw.ID, grimjaw.ID, 5)
+	if err != nil {
+		t.Fatalf("FindPath self: %v", err)
+	}
+	if len(self) != 1 || self[0].ID != grimjaw.ID {
+		t.Errorf("FindPath self: want [%s], got %v", grimjaw.ID, entityIDs(self))
+	}
+
+	// Disconnected node — expect empty.
+	isolated := memory.Entity{ID: "g-isolated", Type: "npc", Name: "Nobody"}
+	mustAddEntity(t, ctx, store, isolated)
+	none, err := store.FindPath(ctx, grimjaw.ID, isolated.ID, 5)
+	if err != nil {
+		t.Fatalf("FindPath none: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("FindPath none: want empty, got %v", entityIDs(none))
+	}
+}
+
+func TestL3_VisibleSubgraph(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+	grimjaw, elara, guild, _, _ := buildTestGraph(t, ctx, store)
+
+	entities, rels, err := store.VisibleSubgraph(ctx, grimjaw.ID)
+	if err != nil {
+		t.Fatalf("VisibleSubgraph: %v", err)
+	}
+
+	ids := entityIDs(entities)
+	for _, want := range []string{grimjaw.ID, elara.ID, guild.ID} {
+		if !containsStr(ids, want) {
+			t.Errorf("VisibleSubgraph: missing %s in %v", want, ids)
+		}
+	}
+	if len(rels) != 2 {
+		t.Errorf("VisibleSubgraph rels: want 2, got %d", len(rels))
+	}
+}
+
+func TestL3_IdentitySnapshot(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+	grimjaw, elara, guild, _, _ := buildTestGraph(t, ctx, store)
+
+	snap, err := store.IdentitySnapshot(ctx, grimjaw.ID)
+	if err != nil {
+		t.Fatalf("IdentitySnapshot: %v", err)
+	}
+	if snap == nil {
+		t.Fatal("IdentitySnapshot: expected non-nil")
+	}
+	if snap.Entity.ID != grimjaw.ID {
+		t.Errorf("Entity.ID: want %s, got %s", grimjaw.ID, snap.Entity.ID)
+	}
+	if len(snap.Relationships) != 2 {
+		t.Errorf("Relationships: want 2, got %d", len(snap.Relationships))
+	}
+	relatedIDs := entityIDs(snap.RelatedEntities)
+	for _, want := range []string{elara.ID, guild.ID} {
+		if !containsStr(relatedIDs, want) {
+			t.Errorf("RelatedEntities: missing %s in %v", want, relatedIDs)
+		}
+	}
+
+	// IdentitySnapshot for missing entity returns error.
+	_, err = store.IdentitySnapshot(ctx, "does-not-exist")
+	if !errors.Is(err, memory.ErrEntityNotFound) {
+		t.Errorf("IdentitySnapshot missing: want memory.ErrEntityNotFound, got %v", err)
+	}
+}
+
+func TestL3_Semantics(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+	grimjaw, elara, guild, _, _ := buildTestGraph(t, ctx, store)
+
+	// Missing IDs yield empty, non-nil slices.
+	for name, call := range map[string]func() (int, bool, error){
+		"FindEntities": func() (int, bool, error) {
+			r, err := store.FindEntities(ctx, memory.EntityFilter{Type: "dragon"})
+			return len(r), r != nil, err
+		},
+		"GetRelationships": func() (int, bool, error) {
+			r, err := store.GetRelationships(ctx, "does-not-exist")
+			return len(r), r != nil, err
+		},
+		"Neighbors": func() (int, bool, error) {
+			r, err := store.Neighbors(ctx, "does-not-exist", 2)
+			return len(r), r != nil, err
+		},
+		"FindPath": func() (int, bool, error) {
+			r, err := store.FindPath(ctx, grimjaw.ID, "does-not-exist", 5)
+			return len(r), r != nil, err
+		},
+	} {
+		n, nonNil, err := call()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		if n != 0 || !nonNil {
+			t.Errorf("%s: want empty non-nil slice, got len %d (non-nil %v)", name, n, nonNil)
+		}
+	}
+
+	// Attributes come back as after a JSON round-trip.
+	mustAddEntity(t, ctx, store, memory.Entity{ID: "n-coins", Type: "item", Name: "Coins", Attributes: map[string]any{"count": 12}})
+	coins, err := store.GetEntity(ctx, "n-coins")
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got, ok := coins.Attributes["count"].(float64); !ok || got != 12 {
+		t.Errorf("Attributes[count]: want float64 12, got %T %v", coins.Attributes["count"], coins.Attributes["count"])
+	}
+
+	// Returned values do not share memory with the store.
+	coins.Attributes["count"] = 0.0
+	again, _ := store.GetEntity(ctx, "n-coins")
+	if again.Attributes["count"] != 12.0 {
+		t.Errorf("mutating a returned entity changed the store: got %v", again.Attributes["count"])
+	}
+
+	// Upserting an entity keeps CreatedAt and refreshes UpdatedAt.
+	before, _ := store.GetEntity(ctx, grimjaw.ID)
+	time.Sleep(time.Millisecond)
+	mustAddEntity(t, ctx, store, memory.Entity{ID: grimjaw.ID, Type: "npc", Name: "Grimjaw the Bold"})
+	after, _ := store.GetEntity(ctx, grimjaw.ID)
+	if after.Name != "Grimjaw the Bold" {
+		t.Errorf("upsert: want name %q, got %q", "Grimjaw the Bold", after.Name)
+	}
+	if !after.CreatedAt.Equal(before.CreatedAt) {
+		t.Errorf("upsert: CreatedAt changed from %v to %v", before.CreatedAt, after.CreatedAt)
+	}
+	if !after.UpdatedAt.After(before.UpdatedAt) {
+		t.Errorf("upsert: UpdatedAt not refreshed: %v -> %v", before.UpdatedAt, after.UpdatedAt)
+	}
+
+	// Relationships come back in creation order.
+	rels, err := store.GetRelationships(ctx, grimjaw.ID)
+	if err != nil {
+		t.Fatalf("GetRelationships: %v", err)
+	}
+	var targets []string
+	for _, r := range rels {
+		targets = append(targets, r.TargetID)
+	}
+	if !slices.Equal(targets, []string{elara.ID, guild.ID}) {
+		t.Errorf("GetRelationships order: want [%s %s], got %v", elara.ID, guild.ID, targets)
+	}
+	limited, _ := store.GetRelationships(ctx, grimjaw.ID, memory.WithRelLimit(1))
+	if len(limited) != 1 {
+		t.Errorf("WithRelLimit(1): want 1, got %d", len(limited))
+	}
+
+	// Neighbors are ordered by ID.
+	n2, _ := store.Neighbors(ctx, grimjaw.ID, 2)
+	if ids := entityIDs(n2); !slices.IsSorted(ids) {
+		t.Errorf("Neighbors: want IDs in order, got %v", ids)
+	}
+
+	// Deleting an entity removes its relationships.
+	if err := store.DeleteEntity(ctx, elara.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	out, _ := store.GetRelationships(ctx, grimjaw.ID)
+	if len(out) != 1 || out[0].TargetID != guild.ID {
+		t.Errorf("after DeleteEntity: want only the edge to %s, got %+v", guild.ID, out)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// L2 — Semantic index
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestL2_IndexAndSearch(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+
+	now := time.Now()
+	chunks := []memory.Chunk{
+		{ID: "chunk-1", SessionID: "s1", Content: "The blacksmith talks about the missing shipment.", Embedding: []float32{1, 0, 0, 0}, SpeakerID: "npc-grimjaw", EntityID: "entity-grimjaw", Timestamp: now},
+		{ID: "chunk-2", SessionID: "s1", Content: "The dragon guards treasure in the northern caves.", Embedding: []float32{0.8, 0.6, 0, 0}, SpeakerID: "player-1", Timestamp: now.Add(-2 * time.Hour)},
+		{ID: "chunk-3", SessionID: "s2", Content: "The guild master reveals plans for an uprising.", Embedding: []float32{0, 0, 1, 0}, SpeakerID: "npc-master", EntityID: "entity-master", Timestamp: now},
+		{ID: "chunk-4", SessionID: "s2", Content: "Text without an embedding.", Timestamp: now},
+	}
+	for _, c := range chunks {
+		if err := store.IndexChunk(ctx, c); err != nil {
+			t.Fatalf("IndexChunk %s: %v", c.ID, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		query  []float32
+		topK   int
+		filter memory.ChunkFilter
+		want   []string
+	}{
+		{"ordered by distance", []float32{1, 0, 0, 0}, 10, memory.ChunkFilter{}, []string{"chunk-1", "chunk-2", "chunk-3"}},
+		{"topK", []float32{1, 0, 0, 0}, 1, memory.ChunkFilter{}, []string{"chunk-1"}},
+		{"zero topK", []float32{1, 0, 0, 0}, 0, memory.ChunkFilter{}, []string{}},
+		{"session", []float32{1, 0, 0, 0}, 10, memory.ChunkFilter{SessionID: "s2"}, []string{"chunk-3"}},
+		{"speaker", []float32{1, 0, 0, 0}, 10, memory.ChunkFilter{SpeakerID: "player-1"}, []string{"chunk-2"}},
+		{"entity", []float32{1, 0, 0, 0}, 10, memory.ChunkFilter{EntityID: "entity-master"}, []string{"chunk-3"}},
+		{"after", []float32{1, 0, 0, 0}, 10, memory.ChunkFilter{After: now.Add(-time.Hour)}, []string{"chunk-1", "chunk-3"}},
+		{"before", []float32{1, 0, 0, 0}, 10, memory.ChunkFilter{Before: now.Add(-time.Hour)}, []string{"chunk-2"}},
+		{"no match", []float32{1, 0, 0, 0}, 10, memory.ChunkFilter{SessionID: "s9"}, []string{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			results, err := store.Search(ctx, tc.query, tc.topK, tc.filter)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if results == nil {
+				t.Fatal("Search: want non-nil slice")
+			}
+			if got := chunkIDs(results); !slices.Equal(got, tc.want) {
+				t.Errorf("Search: want %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	// Distances are cosine distances.
+	results, _ := store.Search(ctx, []float32{1, 0, 0, 0}, 2, memory.ChunkFilter{})
+	if len(results) == 2 && (results[0].Distance > 1e-9 || math.Abs(results[1].Distance-0.2) > 1e-6) {
+		t.Errorf("Distance: want 0 and 0.2, got %v and %v", results[0].Distance, results[1].Distance)
+	}
+}
+
+func TestL2_Upsert(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+
+	chunk := memory.Chunk{ID: "chunk-1", SessionID: "s1", Content: "original", Embedding: []float32{1, 0, 0, 0}}
+	if err := store.IndexChunk(ctx, chunk); err != nil {
+		t.Fatalf("IndexChunk: %v", err)
+	}
+	chunk.Content = "Updated content after upsert."
+	chunk.Embedding = []float32{0, 0, 0, 1}
+	if err := store.IndexChunk(ctx, chunk); err != nil {
+		t.Fatalf("IndexChunk upsert: %v", err)
+	}
+
+	results, err := store.Search(ctx, []float32{0, 0, 0, 1}, 10, memory.ChunkFilter{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Chunk.Content != chunk.Content {
+		t.Fatalf("upsert: want one chunk with content %q, got %+v", chunk.Content, results)
+	}
+
+	// The stored embedding does not share memory with the caller's.
+	chunk.Embedding[3] = 0
+	results[0].Chunk.Embedding[3] = 0
+	again, _ := store.Search(ctx, []float32{0, 0, 0, 1}, 1, memory.ChunkFilter{})
+	if len(again) != 1 || again[0].Chunk.Embedding[3] != 1 {
+		t.Errorf("stored embedding changed: %+v", again)
+	}
+}
+
+func TestL2_DimensionMismatch(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+
+	// The first embedding fixes the dimension.
+	if err := store.IndexChunk(ctx, memory.Chunk{ID: "chunk-1", SessionID: "s1", Content: "x", Embedding: []float32{1, 0, 0, 0}}); err != nil {
+		t.Fatalf("IndexChunk: %v", err)
+	}
+
+	wrong := []float32{1, 0, 0, 0, 0, 0}
+
+	_, err := store.Search(ctx, wrong, 3, memory.ChunkFilter{})
+	if !errors.Is(err, memory.ErrEmbeddingMismatch) {
+		t.Fatalf("Search: want ErrEmbeddingMismatch, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "6 dimensions") || !strings.Contains(err.Error(), "4-dimensional") {
+		t.Errorf("Search error should name both dimensions, got %q", err)
+	}
+
+	err = store.IndexChunk(ctx, memory.Chunk{ID: "chunk-2", SessionID: "s1", Content: "x", Embedding: wrong})
+	if !errors.Is(err, memory.ErrEmbeddingMismatch) {
+		t.Errorf("IndexChunk: want ErrEmbeddingMismatch, got %v", err)
+	}
+
+	if _, err := store.QueryWithEmbedding(ctx, wrong, 3, nil); !errors.Is(err, memory.ErrEmbeddingMismatch) {
+		t.Errorf("QueryWithEmbedding: want ErrEmbeddingMismatch, got %v", err)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// GraphRAG
+// ─────────────────────────────────────────────────────────────────────────────
+
+// indexRAGChunks adds an NPC entity and indexes two chunks associated with it
+// and one associated with no entity.
+func indexRAGChunks(t *testing.T, ctx context.Context, store *inmem.Store) memory.Entity {
+	t.Helper()
+	npc := memory.Entity{ID: "rag-npc-1", Type: "npc", Name: "Grimjaw"}
+	mustAddEntity(t, ctx, store, npc)
+	for _, c := range []memory.Chunk{
+		{
+			ID: "rag-chunk-1", SessionID: "rag-s1", EntityID: npc.ID,
+			Content:   "The blacksmith has a secret shipment of weapons hidden in the cellar.",
+			Embedding: []float32{1, 0, 0, 0}, Timestamp: time.Now(),
+		},
+		{
+			ID: "rag-chunk-2", SessionID: "rag-s1", EntityID: npc.ID,
+			Content:   "Grimjaw owes money to the thieves guild and fears reprisal.",
+			Embedding: []float32{0, 1, 0, 0}, Timestamp: time.Now(),
+		},
+		{
+			ID: "rag-chunk-3", SessionID: "rag-s1",
+			Content:   "Nobody in particular mentions the shipment.",
+			Embedding: []float32{1, 0, 0, 0}, Timestamp: time.Now(),
+		},
+	} {
+		if err := store.IndexChunk(ctx, c); err != nil {
+			t.Fatalf("IndexChunk: %v", err)
+		}
+	}
+	return npc
+}
+
+func TestGraphRAG_QueryWithContext(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+	npc := indexRAGChunks(t, ctx, store)
+
+	tests := []struct {
+		name       string
+		query      string
+		scope      []string
+		wantScores []float64
+	}{
+		{"all words", "Shipment WEAPONS", nil, []float64{1}},
+		{"ranked by share of words", "shipment weapons guild", nil, []float64{2.0 / 3, 1.0 / 3}},
+		{"scoped", "thieves guild", []string{npc.ID}, []float64{1}},
+		{"scope excludes entity", "blacksmith shipment", []string{"other-entity-id"}, nil},
+		{"no match", "zzz-no-match-xyz-abc", nil, nil},
+		{"empty query", "  ", nil, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			results, err := store.QueryWithContext(ctx, tc.query, tc.scope)
+			if err != nil {
+				t.Fatalf("QueryWithContext: %v", err)
+			}
+			if results == nil {
+				t.Fatal("QueryWithContext: want non-nil slice")
+			}
+			var scores []float64
+			for _, r := range results {
+				if r.Entity.ID != npc.ID {
+					t.Errorf("result entity: want %s, got %q", npc.ID, r.Entity.ID)
+				}
+				scores = append(scores, r.Score)
+			}
+			if !slices.Equal(scores, tc.wantScores) {
+				t.Errorf("scores: want %v, got %v", tc.wantScores, scores)
+			}
+		})
+	}
+}
+
+func TestGraphRAG_QueryWithEmbedding(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+	npc := indexRAGChunks(t, ctx, store)
+
+	results, err := store.QueryWithEmbedding(ctx, []float32{1, 0, 0, 0}, 5, nil)
+	if err != nil {
+		t.Fatalf("QueryWithEmbedding: %v", err)
+	}
+	// rag-chunk-3 has no entity and is left out.
+	if len(results) != 2 {
+		t.Fatalf("QueryWithEmbedding: want 2 results, got %d", len(results))
+	}
+	if !strings.Contains(results[0].Content, "shipment") || results[0].Score != 1 {
+		t.Errorf("best match: want the shipment chunk with score 1, got %+v", results[0])
+	}
+	if results[1].Score != 0 {
+		t.Errorf("orthogonal match: want score 0, got %v", results[1].Score)
+	}
+	if results[0].Entity.Name != npc.Name {
+		t.Errorf("Entity.Name: want %q, got %q", npc.Name, results[0].Entity.Name)
+	}
+
+	top1, _ := store.QueryWithEmbedding(ctx, []float32{0, 1, 0, 0}, 1, []string{npc.ID})
+	if len(top1) != 1 || !strings.Contains(top1[0].Content, "thieves") {
+		t.Errorf("topK=1: want the thieves chunk, got %+v", top1)
+	}
+
+	excluded, err := store.QueryWithEmbedding(ctx, []float32{1, 0, 0, 0}, 5, []string{"other-entity-id"})
+	if err != nil {
+		t.Fatalf("QueryWithEmbedding excluded: %v", err)
+	}
+	if excluded == nil || len(excluded) != 0 {
+		t.Errorf("QueryWithEmbedding excluded: want empty non-nil slice, got %v", excluded)
+	}
+}
+
+func TestGraphRAG_QueryHybrid(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+	npc := indexRAGChunks(t, ctx, store)
+
+	// The embedding points at the shipment chunk, the words at the thieves
+	// chunk, which full-text search finds but the embedding ranks second.
+	query, embedding := "thieves guild", []float32{1, 0, 0, 0}
+
+	tests := []struct {
+		name        string
+		alpha       float64
+		topK        int
+		wantContent []string
+	}{
+		{"balanced", 0.5, 5, []string{"thieves", "shipment"}},
+		{"full-text only", 0, 5, []string{"thieves"}},
+		{"vector only", 1, 5, []string{"shipment", "thieves"}},
+		{"topK", 0.5, 1, []string{"thieves"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			results, err := store.QueryHybrid(ctx, query, embedding, tc.topK, nil, tc.alpha)
+			if err != nil {
+				t.Fatalf("QueryHybrid: %v", err)
+			}
+			if len(results) != len(tc.wantContent) {
+				t.Fatalf("QueryHybrid = %+v, want %d results", results, len(tc.wantContent))
+			}
+			for i, want := range tc.wantContent {
+				r := results[i]
+				if !strings.Contains(r.Content, want) {
+					t.Errorf("result %d = %q, want the %s chunk", i, r.Content, want)
+				}
+				if r.Entity.ID != npc.ID {
+					t.Errorf("result %d entity: want %s, got %q", i, npc.ID, r.Entity.ID)
+				}
+				if r.Score <= 0 || r.Score > 1 {
+					t.Errorf("result %d score %v outside (0, 1]", i, r.Score)
+				}
+			}
+		})
+	}
+
+	excluded, err := store.QueryHybrid(ctx, query, embedding, 5, []string{"other-entity-id"}, 0.5)
+	if err != nil {
+		t.Fatalf("QueryHybrid excluded: %v", err)
+	}
+	if excluded == nil || len(excluded) != 0 {
+		t.Errorf("QueryHybrid excluded: want empty non-nil slice, got %v", excluded)
+	}
+
+	for _, alpha := range []float64{-0.1, 1.1, math.NaN()} {
+		if _, err := store.QueryHybrid(ctx, query, embedding, 5, nil, alpha); !errors.Is(err, memory.ErrInvalidAlpha) {
+			t.Errorf("QueryHybrid alpha %v: want ErrInvalidAlpha, got %v", alpha, err)
+		}
+	}
+	if _, err := store.QueryHybrid(ctx, query, []float32{1, 0}, 5, nil, 0.5); !errors.Is(err, memory.ErrEmbeddingDimMismatch) {
+		t.Errorf("QueryHybrid wrong dimension: want ErrEmbeddingDimMismatch, got %v", err)
+	}
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	t.Parallel()
+	store := inmem.NewStore()
+	ctx := context.Background()
+	grimjaw, _, _, _, _ := buildTestGraph(t, ctx, store)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			id := "c-" + string(rune('a'+i))
+			mustAddEntity(t, ctx, store, memory.Entity{ID: id, Type: "npc", Name: id})
+			if err := store.AddRelationship(ctx, memory.Relationship{SourceID: grimjaw.ID, TargetID: id, RelType: "KNOWS"}); err != nil {
+				t.Errorf("AddRelationship: %v", err)
+			}
+			if err := store.UpdateEntity(ctx, grimjaw.ID, map[string]any{id: true}); err != nil {
+				t.Errorf("UpdateEntity: %v", err)
+			}
+			if _, err := store.Neighbors(ctx, grimjaw.ID, 2); err != nil {
+				t.Errorf("Neighbors: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	snap, err := store.IdentitySnapshot(ctx, grimjaw.ID)
+	if err != nil {
+		t.Fatalf("IdentitySnapshot: %v", err)
+	}
+	if len(snap.Relationships) != 10 {
+		t.Errorf("Relationships: want 10, got %d", len(snap.Relationships))
+	}
+	if len(snap.Entity.Attributes) != 8 {
+		t.Errorf("Attributes: want 8 merged keys, got %v", snap.Entity.Attributes)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+func mustAddEntity(t *testing.T, ctx context.Context, store *inmem.Store, e memory.Entity) {
+	t.Helper()
+	if e.Attributes == nil {
+		e.Attributes = map[string]any{}
+	}
+	if err := store.AddEntity(ctx, e); err != nil {
+		t.Fatalf("mustAddEntity %s: %v", e.ID, err)
+	}
+}
+
+func entityIDs(entities []memory.Entity) []string {
+	ids := make([]string, len(entities))
+	for i, e := range entities {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func containsEntity(entities []memory.Entity, id string) bool {
+	for _, e := range entities {
+		if e.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStr(slice []string, s string) bool {
+	return slices.Contains(slice, s)
+}
+
+func chunkIDs(results []memory.ChunkResult) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Chunk.ID
+	}
+	return ids
+}
@@ -0,0 +1,61 @@
+package memory
+
+import "context"
+
+// IssueKind classifies a knowledge graph [Issue].
+type IssueKind string
+
+const (
+	// IssueDanglingRelationship marks a relationship whose source or target
+	// entity does not exist.
+	IssueDanglingRelationship IssueKind = "dangling_relationship"
+
+	// IssueOrphanEntity marks an entity that takes part in no relationship.
+	IssueOrphanEntity IssueKind = "orphan_entity"
+
+	// IssueConflictingRelationships marks two relationships between the same
+	// pair of entities whose types contradict each other (see
+	// [ConflictingRelTypes]).
+	IssueConflictingRelationships IssueKind = "conflicting_relationships"
+)
+
+// Issue is a single inconsistency found by [IntegrityChecker.CheckIntegrity].
+type Issue struct {
+	// Kind classifies the issue.
+	Kind IssueKind
+
+	// EntityIDs lists the entities involved: the missing endpoint IDs for a
+	// dangling relationship, the entity itself for an orphan, and the two
+	// endpoints for conflicting relationships.
+	EntityIDs []string
+
+	// Relationships lists the offending edges. Empty for orphan entities.
+	Relationships []Relationship
+
+	// Message is a human-readable description of the issue.
+	Message string
+}
+
+// ConflictingRelTypes returns the pairs of relationship types that must not
+// both connect the same two entities, in either direction. Types are lower
+// case and matched case-insensitively. A graph holding, say, both
+// "allied_with" and "enemy_of" between two factions has picked up
+// contradictory facts over the course of a campaign.
+func ConflictingRelTypes() [][2]string {
+	return [][2]string{
+		{"allied_with", "enemy_of"},
+		{"allied_with", "at_war_with"},
+		{"friend_of", "enemy_of"},
+		{"trusts", "distrusts"},
+		{"loves", "hates"},
+	}
+}
+
+// IntegrityChecker is implemented by [KnowledgeGraph] backends that can lint
+// their graph for inconsistencies accumulated over a long campaign.
+type IntegrityChecker interface {
+	// CheckIntegrity reports relationships pointing to missing entities,
+	// entities without any relationship, and pairs of relationships with
+	// conflicting types. Returns an empty (non-nil) slice for a clean graph.
+	CheckIntegrity(ctx context.Context) ([]Issue, error)
+}
@@ -0,0 +1,24 @@
+package memory
+
+import "context"
+
+// EntityTypeNPC is the [Entity.Type] of non-player characters.
+const EntityTypeNPC = "npc"
+
+// KnowledgeQuerier is implemented by [KnowledgeGraph] backends that can answer
+// "who knows what" questions across NPCs, e.g. for a DM planning which NPC can
+// lead the players to a secret.
+//
+// An NPC knows an entity when the entity is in its [KnowledgeGraph.VisibleSubgraph]:
+// when a relationship connects the two, in either direction.
+type KnowledgeQuerier interface {
+	// WhoKnows returns the IDs of the NPCs (entities of type [EntityTypeNPC])
+	// that know targetID, sorted by ID. targetID itself is never included.
+	// Returns an empty (non-nil) slice when no NPC knows it.
+	WhoKnows(ctx context.Context, targetID string) ([]string, error)
+
+	// EntitiesKnownBy returns the IDs of the entities npcID knows, sorted by
+	// ID; it is the inverse of WhoKnows. npcID itself is never included.
+	// Returns an empty (non-nil) slice when the NPC knows nothing.
+	EntitiesKnownBy(ctx context.Context, npcID string) ([]string, error)
+}
@@ -0,0 +1,56 @@
+package memory
+
+// DistanceMetric selects how a [SemanticIndex] measures the distance between
+// two embeddings, and therefore how [ChunkResult.Distance] is read. Whatever
+// the metric, a lower distance means a closer match.
+type DistanceMetric string
+
+const (
+	// MetricCosine is the cosine distance: 1 minus the cosine similarity,
+	// from 0 (same direction) to 2 (opposite directions). It ignores vector
+	// length and is the default.
+	MetricCosine DistanceMetric = "cosine"
+
+	// MetricL2 is the Euclidean (L2) distance, from 0 (identical vectors)
+	// upwards.
+	MetricL2 DistanceMetric = "l2"
+
+	// MetricInnerProduct is the negative inner product -(a·b), so that lower
+	// still means closer. For unit-length vectors it ranges from -1
+	// (identical) to 1 (opposite) and ranks like [MetricCosine]. Use it for
+	// embedding models trained for dot-product similarity.
+	MetricInnerProduct DistanceMetric = "inner_product"
+)
+
+// IsValid reports whether m is one of the defined metrics.
+func (m DistanceMetric) IsValid() bool {
+	switch m {
+	case MetricCosine, MetricL2, MetricInnerProduct:
+		return true
+	}
+	return false
+}
+
+// Similarity converts a distance measured with m into a score where higher
+// means a closer match: 1 - distance for [MetricCosine] (the cosine
+// similarity), -distance for [MetricInnerProduct] (the inner product) and
+// 1 / (1 + distance) for [MetricL2], which maps 0 to 1 and grows towards 0.
+// Any other metric is treated as [MetricCosine].
+func (m DistanceMetric) Similarity(distance float64) float64 {
+	switch m {
+	case MetricL2:
+		return 1 / (1 + distance)
+	case MetricInnerProduct:
+		return -distance
+	default:
+		return 1 - distance
+	}
+}
+
+// MetricReporter is implemented by [SemanticIndex] and [GraphRAGQuerier]
+// backends that report the metric their vector search uses, so callers can
+// interpret [ChunkResult.Distance] and compare results across stores.
+type MetricReporter interface {
+	// DistanceMetric returns the metric used to rank embeddings.
+	DistanceMetric() DistanceMetric
+}
@@ -0,0 +1,33 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+func TestDistanceMetric(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		metric     memory.DistanceMetric
+		valid      bool
+		distance   float64
+		similarity float64
+	}{
+		{metric: memory.MetricCosine, valid: true, distance: 0.25, similarity: 0.75},
+		{metric: memory.MetricL2, valid: true, distance: 1, similarity: 0.5},
+		{metric: memory.MetricL2, valid: true, distance: 0, similarity: 1},
+		{metric: memory.MetricInnerProduct, valid: true, distance: -0.8, similarity: 0.8},
+		{metric: "", distance: 0.25, similarity: 0.75},
+		{metric: "manhattan", distance: 0.25, similarity: 0.75},
+	}
+	for _, tt := range tests {
+		if got := tt.metric.IsValid(); got != tt.valid {
+			t.Errorf("%q.IsValid() = %v, want %v", tt.metric, got, tt.valid)
+		}
+		if got := tt.metric.Similarity(tt.distance); got != tt.similarity {
+			t.Errorf("%q.Similarity(%v) = %v, want %v", tt.metric, tt.distance, got, tt.similarity)
+		}
+	}
+}
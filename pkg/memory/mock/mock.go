@@ -18,6 +18,7 @@ package mock
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"time"
 
@@ -68,6 +69,20 @@ type SessionStore struct {
 
 	// EntryCountErr is returned by [SessionStore.EntryCount] when non-nil.
 	EntryCountErr error
+
+	// ForkSessionResult is the session ID returned by [SessionStore.ForkSession].
+	ForkSessionResult string
+
+	// ForkSessionErr is returned by [SessionStore.ForkSession] when non-nil.
+	ForkSessionErr error
+
+	// PruneTranscriptsResult is the count returned by
+	// [SessionStore.PruneTranscripts].
+	PruneTranscriptsResult int
+
+	// PruneTranscriptsErr is returned by [SessionStore.PruneTranscripts] when
+	// non-nil.
+	PruneTranscriptsErr error
 }
 
 // Calls returns a copy of all recorded method invocations.
@@ -141,8 +156,29 @@ func (m *SessionStore) EntryCount(_ context.Context, sessionID string) (int, err
 	return m.EntryCountResult, m.EntryCountErr
 }
 
-// Ensure SessionStore satisfies the interface at compile time.
-var _ memory.SessionStore = (*SessionStore)(nil)
+// ForkSession implements [memory.SessionForker]. The recorded arguments are
+// sourceID and the resolved [memory.ForkParams].
+func (m *SessionStore) ForkSession(_ context.Context, sourceID string, opts ...memory.ForkOpt) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: "ForkSession", Args: []any{sourceID, memory.ApplyForkOpts(opts)}})
+	return m.ForkSessionResult, m.ForkSessionErr
+}
+
+// PruneTranscripts implements [memory.TranscriptPruner].
+func (m *SessionStore) PruneTranscripts(_ context.Context, cutoff time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: "PruneTranscripts", Args: []any{cutoff}})
+	return m.PruneTranscriptsResult, m.PruneTranscriptsErr
+}
+
+// Ensure SessionStore satisfies the interfaces at compile time.
+var (
+	_ memory.SessionStore     = (*SessionStore)(nil)
+	_ memory.SessionForker    = (*SessionStore)(nil)
+	_ memory.TranscriptPruner = (*SessionStore)(nil)
+)
 
 // ─────────────────────────────────────────────────────────────────────────────
 // SemanticIndex mock (L2)
@@ -257,6 +293,9 @@ type KnowledgeGraph struct {
 	// ──── DeleteRelationship ───────────────────────────────────────────────
 	DeleteRelationshipErr error
 
+	// ──── ConfirmRelationship ──────────────────────────────────────────────
+	ConfirmRelationshipErr error
+
 	// ──── Neighbors ────────────────────────────────────────────────────────
 	NeighborsResult []memory.Entity
 	NeighborsErr    error
@@ -273,6 +312,22 @@ type KnowledgeGraph struct {
 	// ──── IdentitySnapshot ─────────────────────────────────────────────────
 	IdentitySnapshotResult *memory.NPCIdentity
 	IdentitySnapshotErr    error
+
+	// ──── CheckIntegrity ───────────────────────────────────────────────────
+	CheckIntegrityResult []memory.Issue
+	CheckIntegrityErr    error
+
+	// ──── ExportGraph ──────────────────────────────────────────────────────
+	ExportGraphResult []byte
+	ExportGraphErr    error
+
+	// ──── WhoKnows ─────────────────────────────────────────────────────────
+	WhoKnowsResult []string
+	WhoKnowsErr    error
+
+	// ──── EntitiesKnownBy ──────────────────────────────────────────────────
+	EntitiesKnownByResult []string
+	EntitiesKnownByErr    error
 }
 
 // Calls returns a copy of all recorded method invocations.
@@ -378,6 +433,14 @@ func (m *KnowledgeGraph) DeleteRelationship(_ context.Context, sourceID, targetI
 	return m.DeleteRelationshipErr
 }
 
+// ConfirmRelationship implements [memory.KnowledgeGraph].
+func (m *KnowledgeGraph) ConfirmRelationship(_ context.Context, sourceID, targetID, relType string, confirmed bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: "ConfirmRelationship", Args: []any{sourceID, targetID, relType, confirmed}})
+	return m.ConfirmRelationshipErr
+}
+
 // Neighbors implements [memory.KnowledgeGraph].
 func (m *KnowledgeGraph) Neighbors(_ context.Context, entityID string, depth int, opts ...memory.TraversalOpt) ([]memory.Entity, error) {
 	m.mu.Lock()
@@ -439,8 +502,57 @@ func (m *KnowledgeGraph) IdentitySnapshot(_ context.Context, npcID string) (*mem
 	return m.IdentitySnapshotResult, m.IdentitySnapshotErr
 }
 
-// Ensure KnowledgeGraph satisfies the interface at compile time.
-var _ memory.KnowledgeGraph = (*KnowledgeGraph)(nil)
+// CheckIntegrity implements [memory.IntegrityChecker].
+func (m *KnowledgeGraph) CheckIntegrity(_ context.Context) ([]memory.Issue, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: "CheckIntegrity"})
+	if m.CheckIntegrityResult == nil {
+		return []memory.Issue{}, m.CheckIntegrityErr
+	}
+	out := make([]memory.Issue, len(m.CheckIntegrityResult))
+	copy(out, m.CheckIntegrityResult)
+	return out, m.CheckIntegrityErr
+}
+
+// ExportGraph implements [memory.GraphExporter]. The resolved options are
+// recorded as a [memory.ExportParams] argument.
+func (m *KnowledgeGraph) ExportGraph(_ context.Context, format string, opts ...memory.ExportOpt) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: "ExportGraph", Args: []any{format, memory.ApplyExportOpts(opts)}})
+	return m.ExportGraphResult, m.ExportGraphErr
+}
+
+// WhoKnows implements [memory.KnowledgeQuerier].
+func (m *KnowledgeGraph) WhoKnows(_ context.Context, targetID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: "WhoKnows", Args: []any{targetID}})
+	if m.WhoKnowsResult == nil {
+		return []string{}, m.WhoKnowsErr
+	}
+	return slices.Clone(m.WhoKnowsResult), m.WhoKnowsErr
+}
+
+// EntitiesKnownBy implements [memory.KnowledgeQuerier].
+func (m *KnowledgeGraph) EntitiesKnownBy(_ context.Context, npcID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: "EntitiesKnownBy", Args: []any{npcID}})
+	if m.EntitiesKnownByResult == nil {
+		return []string{}, m.EntitiesKnownByErr
+	}
+	return slices.Clone(m.EntitiesKnownByResult), m.EntitiesKnownByErr
+}
+
+// Ensure KnowledgeGraph satisfies the interfaces at compile time.
+var (
+	_ memory.KnowledgeGraph   = (*KnowledgeGraph)(nil)
+	_ memory.IntegrityChecker = (*KnowledgeGraph)(nil)
+	_ memory.GraphExporter    = (*KnowledgeGraph)(nil)
+	_ memory.KnowledgeQuerier = (*KnowledgeGraph)(nil)
+)
 
 // ─────────────────────────────────────────────────────────────────────────────
 // GraphRAGQuerier mock (extends KnowledgeGraph)
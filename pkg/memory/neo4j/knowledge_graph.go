@@ -0,0 +1,704 @@
+package neo4j
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	neo4jdriver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// entityProjection returns a Cypher map projection of the entity node v that
+// [entityFromValue] decodes.
+func entityProjection(v string) string {
+	return v + " {.id, .type, .name, .attributes, .created_at, .updated_at}"
+}
+
+// relProjection is a Cypher map of the RELATES edge r that [relFromValue]
+// decodes.
+const relProjection = `{
+	source_id:  startNode(r).id,
+	target_id:  endNode(r).id,
+	rel_type:   r.rel_type,
+	attributes: r.attributes,
+	provenance: r.provenance,
+	created_at: r.created_at
+}`
+
+// AddEntity implements [memory.KnowledgeGraph]. It upserts an entity node. If
+// an entity with the same ID already exists it is completely replaced and its
+// updated_at timestamp is refreshed.
+func (s *Store) AddEntity(ctx context.Context, entity memory.Entity) error {
+	attrs, err := marshalJSON(entity.Attributes)
+	if err != nil {
+		return fmt.Errorf("knowledge graph: marshal attributes: %w", err)
+	}
+
+	const q = `
+		MERGE (e:Entity {id: $id})
+		ON CREATE SET e.created_at = datetime()
+		SET e.type       = $type,
+		    e.name       = $name,
+		    e.attributes = $attributes,
+		    e.updated_at = datetime()`
+
+	_, err = s.write(ctx, q, map[string]any{
+		"id":         entity.ID,
+		"type":       entity.Type,
+		"name":       entity.Name,
+		"attributes": attrs,
+	})
+	if err != nil {
+		return fmt.Errorf("knowledge graph: add entity: %w", err)
+	}
+	return nil
+}
+
+// GetEntity implements [memory.KnowledgeGraph]. It retrieves an entity by ID.
+// Returns (nil, nil) when the entity does not exist.
+func (s *Store) GetEntity(ctx context.Context, id string) (*memory.Entity, error) {
+	q := `
+		MATCH (e:Entity {id: $id})
+		RETURN ` + entityProjection("e") + ` AS entity`
+
+	records, err := s.read(ctx, q, map[string]any{"id": id})
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: get entity: %w", err)
+	}
+	entities, err := collectEntities(records)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: get entity: %w", err)
+	}
+	if len(entities) == 0 {
+		return nil, nil
+	}
+	return &entities[0], nil
+}
+
+// UpdateEntity implements [memory.KnowledgeGraph]. It merges attrs into the
+// entity's Attributes map and refreshes updated_at. Attributes are stored as
+// JSON, so the merge happens in Go within one write transaction; refreshing
+// updated_at first locks the node against concurrent updates. Returns an
+// error wrapping [memory.ErrEntityNotFound] when the entity does not exist.
+func (s *Store) UpdateEntity(ctx context.Context, id string, attrs map[string]any) error {
+	session := s.driver.NewSession(ctx, neo4jdriver.SessionConfig{DatabaseName: s.database})
+	defer session.Close(ctx)
+
+	found, err := neo4jdriver.ExecuteWrite(ctx, session, func(tx neo4jdriver.ManagedTransaction) (bool, error) {
+		const lock = `
+			MATCH (e:Entity {id: $id})
+			SET e.updated_at = datetime()
+			RETURN e.attributes AS attributes`
+
+		res, err := tx.Run(ctx, lock, map[string]any{"id": id})
+		if err != nil {
+			return false, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return false, err
+		}
+		if len(records) == 0 {
+			return false, nil
+		}
+
+		current, err := attributesFromValue(records[0].Values[0])
+		if err != nil {
+			return false, err
+		}
+		for k, v := range attrs {
+			current[k] = v
+		}
+		merged, err := marshalJSON(current)
+		if err != nil {
+			return false, fmt.Errorf("marshal update attrs: %w", err)
+		}
+
+		const set = `
+			MATCH (e:Entity {id: $id})
+			SET e.attributes = $attributes`
+
+		if _, err := tx.Run(ctx, set, map[string]any{"id": id, "attributes": merged}); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("knowledge graph: update entity: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("knowledge graph: update entity %q: %w", id, memory.ErrEntityNotFound)
+	}
+	return nil
+}
+
+// DeleteEntity implements [memory.KnowledgeGraph]. It removes the entity node
+// and all its relationships. Deleting a non-existent entity is not an error.
+func (s *Store) DeleteEntity(ctx context.Context, id string) error {
+	const q = `MATCH (e:Entity {id: $id}) DETACH DELETE e`
+	if _, err := s.write(ctx, q, map[string]any{"id": id}); err != nil {
+		return fmt.Errorf("knowledge graph: delete entity: %w", err)
+	}
+	return nil
+}
+
+// FindEntities implements [memory.KnowledgeGraph]. It returns all entities
+// matching filter, ordered by name and ID. Type, name and cursor are matched
+// in Cypher; the attribute query is applied to the decoded attributes with the
+// containment rules of the PostgreSQL store's jsonb @> operator, so the limit
+// is only applied in Cypher when there is no attribute query.
+func (s *Store) FindEntities(ctx context.Context, filter memory.EntityFilter) ([]memory.Entity, error) {
+	// Normalise the query the way stored attributes are decoded, so that
+	// for example an int matches the float64 read back from JSON.
+	var attrQuery map[string]any
+	if len(filter.AttributeQuery) > 0 {
+		data, err := json.Marshal(filter.AttributeQuery)
+		if err != nil {
+			return nil, fmt.Errorf("knowledge graph: marshal attribute query: %w", err)
+		}
+		if err := json.Unmarshal(data, &attrQuery); err != nil {
+			return nil, fmt.Errorf("knowledge graph: marshal attribute query: %w", err)
+		}
+	}
+
+	var after memory.EntityKey
+	if filter.Cursor != "" {
+		var err error
+		if after, err = memory.DecodeEntityCursor(filter.Cursor); err != nil {
+			return nil, fmt.Errorf("knowledge graph: find entities: %w", err)
+		}
+	}
+
+	q := `
+		MATCH (e:Entity)
+		WHERE ($type = '' OR e.type = $type)
+		  AND ($name = '' OR toLower(e.name) CONTAINS toLower($name))
+		  AND (NOT $paged OR e.name > $afterName OR (e.name = $afterName AND e.id > $afterID))
+		RETURN ` + entityProjection("e") + ` AS entity
+		ORDER BY e.name, e.id`
+	if filter.Limit > 0 && attrQuery == nil {
+		q += fmt.Sprintf("\nLIMIT %d", filter.Limit)
+	}
+
+	records, err := s.read(ctx, q, map[string]any{
+		"type":      filter.Type,
+		"name":      filter.Name,
+		"paged":     filter.Cursor != "",
+		"afterName": after.Name,
+		"afterID":   after.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: find entities: %w", err)
+	}
+	entities, err := collectEntities(records)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: find entities: %w", err)
+	}
+	if attrQuery == nil {
+		return entities, nil
+	}
+
+	result := entities[:0]
+	for _, e := range entities {
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			break
+		}
+		if jsonContains(map[string]any(e.Attributes), attrQuery) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// AddRelationship implements [memory.KnowledgeGraph]. It upserts a directed
+// RELATES edge between two entities. If the edge (SourceID, TargetID, RelType)
+// already exists its attributes and provenance are replaced. Returns an error
+// wrapping [memory.ErrEntityNotFound] when either endpoint entity does not
+// exist.
+func (s *Store) AddRelationship(ctx context.Context, rel memory.Relationship) error {
+	attrs, err := marshalJSON(rel.Attributes)
+	if err != nil {
+		return fmt.Errorf("knowledge graph: marshal relationship attributes: %w", err)
+	}
+	prov, err := marshalJSON(rel.Provenance)
+	if err != nil {
+		return fmt.Errorf("knowledge graph: marshal relationship provenance: %w", err)
+	}
+
+	const q = `
+		MATCH (s:Entity {id: $source}), (t:Entity {id: $target})
+		MERGE (s)-[r:RELATES {rel_type: $relType}]->(t)
+		ON CREATE SET r.created_at = datetime()
+		SET r.attributes = $attributes,
+		    r.provenance = $provenance
+		RETURN count(r) AS written`
+
+	records, err := s.write(ctx, q, map[string]any{
+		"source":     rel.SourceID,
+		"target":     rel.TargetID,
+		"relType":    rel.RelType,
+		"attributes": attrs,
+		"provenance": prov,
+	})
+	if err != nil {
+		return fmt.Errorf("knowledge graph: add relationship: %w", err)
+	}
+	if written, _, _ := neo4jdriver.GetRecordValue[int64](records[0], "written"); written == 0 {
+		return fmt.Errorf("knowledge graph: add relationship %q -> %q: %w", rel.SourceID, rel.TargetID, memory.ErrEntityNotFound)
+	}
+	return nil
+}
+
+// GetRelationships implements [memory.KnowledgeGraph]. It returns relationships
+// associated with entityID, oldest first, with ties ordered by source, target
+// and type. By default only outgoing edges are returned; use
+// [memory.WithIncoming] to include inbound edges and [memory.WithRelTypes] to
+// filter by edge type. Provenance is stored as JSON, so
+// [memory.WithDMConfirmedOnly] is applied to the decoded relationships, and
+// the limit is then only applied in Cypher without it.
+func (s *Store) GetRelationships(ctx context.Context, entityID string, opts ...memory.RelQueryOpt) ([]memory.Relationship, error) {
+	params := memory.ApplyRelQueryOpts(opts)
+	dirIn := params.DirectionIn
+	dirOut := params.DirectionOut
+
+	// Default: outgoing only when neither direction is explicitly set.
+	if !dirIn && !dirOut {
+		dirOut = true
+	}
+	var after memory.RelationshipKey
+	if params.Cursor != "" {
+		var err error
+		if after, err = memory.DecodeRelationshipCursor(params.Cursor); err != nil {
+			return nil, fmt.Errorf("knowledge graph: get relationships: %w", err)
+		}
+	}
+
+	q := `
+		MATCH (e:Entity {id: $id})-[r:RELATES]-()
+		WHERE ((startNode(r) = e AND $out) OR (endNode(r) = e AND $in))
+		  AND (size($relTypes) = 0 OR r.rel_type IN $relTypes)
+		WITH DISTINCT r, startNode(r).id AS source, endNode(r).id AS target
+		WHERE NOT $paged OR r.created_at > $afterAt OR (r.created_at = $afterAt AND (
+		      source > $afterSource OR (source = $afterSource AND (
+		      target > $afterTarget OR (target = $afterTarget AND r.rel_type > $afterType)))))
+		RETURN ` + relProjection + ` AS rel
+		ORDER BY r.created_at, source, target, r.rel_type`
+	if params.Limit > 0 && !params.DMConfirmedOnly {
+		q += fmt.Sprintf("\nLIMIT %d", params.Limit)
+	}
+
+	records, err := s.read(ctx, q, map[string]any{
+		"id":          entityID,
+		"out":         dirOut,
+		"in":          dirIn,
+		"relTypes":    list(params.RelTypes),
+		"paged":       params.Cursor != "",
+		"afterAt":     after.CreatedAt,
+		"afterSource": after.SourceID,
+		"afterTarget": after.TargetID,
+		"afterType":   after.RelType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: get relationships: %w", err)
+	}
+	rels, err := collectRelationships(records)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: get relationships: %w", err)
+	}
+	if !params.DMConfirmedOnly {
+		return rels, nil
+	}
+
+	result := rels[:0]
+	for _, r := range rels {
+		if params.Limit > 0 && len(result) == params.Limit {
+			break
+		}
+		if r.Provenance.DMConfirmed {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// DeleteRelationship implements [memory.KnowledgeGraph]. It removes the
+// directed edge identified by (sourceID, targetID, relType). Deleting a
+// non-existent edge is not an error.
+func (s *Store) DeleteRelationship(ctx context.Context, sourceID, targetID, relType string) error {
+	const q = `
+		MATCH (:Entity {id: $source})-[r:RELATES {rel_type: $relType}]->(:Entity {id: $target})
+		DELETE r`
+
+	_, err := s.write(ctx, q, map[string]any{"source": sourceID, "target": targetID, "relType": relType})
+	if err != nil {
+		return fmt.Errorf("knowledge graph: delete relationship: %w", err)
+	}
+	return nil
+}
+
+// ConfirmRelationship implements [memory.KnowledgeGraph]. It sets the
+// DMConfirmed key of the edge's provenance. Provenance is stored as JSON, so
+// the merge happens in Go within one write transaction, keeping every other
+// key; rewriting the property first locks the edge against concurrent
+// updates. Returns an error wrapping [memory.ErrRelationshipNotFound] when the
+// edge does not exist.
+func (s *Store) ConfirmRelationship(ctx context.Context, sourceID, targetID, relType string, confirmed bool) error {
+	session := s.driver.NewSession(ctx, neo4jdriver.SessionConfig{DatabaseName: s.database})
+	defer session.Close(ctx)
+
+	params := map[string]any{"source": sourceID, "target": targetID, "relType": relType}
+	found, err := neo4jdriver.ExecuteWrite(ctx, session, func(tx neo4jdriver.ManagedTransaction) (bool, error) {
+		const lock = `
+			MATCH (:Entity {id: $source})-[r:RELATES {rel_type: $relType}]->(:Entity {id: $target})
+			SET r.provenance = r.provenance
+			RETURN r.provenance AS provenance`
+
+		res, err := tx.Run(ctx, lock, params)
+		if err != nil {
+			return false, err
+		}
+		records, err := res.Collect(ctx)
+		if err != nil {
+			return false, err
+		}
+		if len(records) == 0 {
+			return false, nil
+		}
+
+		prov, err := attributesFromValue(records[0].Values[0])
+		if err != nil {
+			return false, fmt.Errorf("unmarshal relationship provenance: %w", err)
+		}
+		prov[provenanceConfirmedKey] = confirmed
+		merged, err := marshalJSON(prov)
+		if err != nil {
+			return false, fmt.Errorf("marshal relationship provenance: %w", err)
+		}
+
+		const set = `
+			MATCH (:Entity {id: $source})-[r:RELATES {rel_type: $relType}]->(:Entity {id: $target})
+			SET r.provenance = $provenance`
+
+		params["provenance"] = merged
+		if _, err := tx.Run(ctx, set, params); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("knowledge graph: confirm relationship: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("knowledge graph: confirm relationship %q -> %q (%s): %w", sourceID, targetID, relType, memory.ErrRelationshipNotFound)
+	}
+	return nil
+}
+
+// provenanceConfirmedKey is the key of [memory.Provenance.DMConfirmed] in the
+// provenance property, which holds the provenance as encoded by json.Marshal.
+const provenanceConfirmedKey = "DMConfirmed"
+
+// Neighbors implements [memory.KnowledgeGraph]. It matches variable-length
+// paths of 1 to depth RELATES edges from entityID, in either direction, and
+// returns the distinct entities they reach (the start entity is excluded),
+// ordered by ID.
+//
+// [memory.TraverseRelTypes] restricts every edge on a path and
+// [memory.TraverseNodeTypes] every node after the start, so a node is only
+// reached through nodes of the allowed types. [memory.TraverseMaxNodes] caps
+// the result set size.
+func (s *Store) Neighbors(ctx context.Context, entityID string, depth int, opts ...memory.TraversalOpt) ([]memory.Entity, error) {
+	if depth < 1 {
+		return []memory.Entity{}, nil
+	}
+	tparams := memory.ApplyTraversalOpts(opts)
+
+	// Path lengths cannot be query parameters, so depth is formatted in.
+	q := fmt.Sprintf(`
+		MATCH p = (start:Entity {id: $id})-[:RELATES*1..%d]-(e:Entity)
+		WHERE e.id <> $id
+		  AND (size($relTypes) = 0 OR all(r IN relationships(p) WHERE r.rel_type IN $relTypes))
+		  AND (size($nodeTypes) = 0 OR all(n IN tail(nodes(p)) WHERE n.type IN $nodeTypes))
+		WITH DISTINCT e
+		RETURN %s AS entity
+		ORDER BY e.id`, depth, entityProjection("e"))
+	if tparams.MaxNodes > 0 {
+		q += fmt.Sprintf("\nLIMIT %d", tparams.MaxNodes)
+	}
+
+	records, err := s.read(ctx, q, map[string]any{
+		"id":        entityID,
+		"relTypes":  list(tparams.RelTypes),
+		"nodeTypes": list(tparams.NodeTypes),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: neighbors: %w", err)
+	}
+	result, err := collectEntities(records)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: neighbors: %w", err)
+	}
+	return result, nil
+}
+
+// FindPath implements [memory.KnowledgeGraph]. It returns the shortest
+// sequence of entities (including fromID and toID) connecting fromID to toID
+// over RELATES edges in either direction, up to maxDepth hops, using Cypher's
+// shortestPath.
+//
+// Returns an empty (non-nil) slice when no path exists within maxDepth.
+func (s *Store) FindPath(ctx context.Context, fromID, toID string, maxDepth int) ([]memory.Entity, error) {
+	// shortestPath rejects paths whose start and end are the same node.
+	if fromID == toID {
+		e, err := s.GetEntity(ctx, fromID)
+		if err != nil {
+			return nil, fmt.Errorf("knowledge graph: find path: %w", err)
+		}
+		if e == nil {
+			return []memory.Entity{}, nil
+		}
+		return []memory.Entity{*e}, nil
+	}
+	if maxDepth < 1 {
+		return []memory.Entity{}, nil
+	}
+
+	q := fmt.Sprintf(`
+		MATCH (a:Entity {id: $from}), (b:Entity {id: $to})
+		MATCH p = shortestPath((a)-[:RELATES*..%d]-(b))
+		UNWIND nodes(p) AS e
+		RETURN %s AS entity`, maxDepth, entityProjection("e"))
+
+	records, err := s.read(ctx, q, map[string]any{"from": fromID, "to": toID})
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: find path: %w", err)
+	}
+	result, err := collectEntities(records)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: find path: %w", err)
+	}
+	return result, nil
+}
+
+// VisibleSubgraph implements [memory.KnowledgeGraph]. It returns the NPC
+// entity itself, all entities it has direct relationships with, and those
+// relationships (both outgoing and incoming edges).
+func (s *Store) VisibleSubgraph(ctx context.Context, npcID string) ([]memory.Entity, []memory.Relationship, error) {
+	rels, err := s.GetRelationships(ctx, npcID, memory.WithOutgoing(), memory.WithIncoming())
+	if err != nil {
+		return nil, nil, fmt.Errorf("knowledge graph: visible subgraph: %w", err)
+	}
+
+	entities, err := s.fetchEntitiesIn(ctx, append([]string{npcID}, relatedIDs(npcID, rels)...))
+	if err != nil {
+		return nil, nil, fmt.Errorf("knowledge graph: visible subgraph: %w", err)
+	}
+	return entities, rels, nil
+}
+
+// IdentitySnapshot implements [memory.KnowledgeGraph]. It assembles a compact
+// [memory.NPCIdentity] for npcID containing the NPC's entity record, all its
+// direct relationships, and the entities those relationships reference.
+// Returns an error wrapping [memory.ErrEntityNotFound] when npcID does not exist.
+func (s *Store) IdentitySnapshot(ctx context.Context, npcID string) (*memory.NPCIdentity, error) {
+	entity, err := s.GetEntity(ctx, npcID)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: identity snapshot: %w", err)
+	}
+	if entity == nil {
+		return nil, fmt.Errorf("knowledge graph: identity snapshot %q: %w", npcID, memory.ErrEntityNotFound)
+	}
+
+	rels, err := s.GetRelationships(ctx, npcID, memory.WithOutgoing(), memory.WithIncoming())
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: identity snapshot: %w", err)
+	}
+
+	related, err := s.fetchEntitiesIn(ctx, relatedIDs(npcID, rels))
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: identity snapshot: %w", err)
+	}
+
+	return &memory.NPCIdentity{
+		Entity:          *entity,
+		Relationships:   rels,
+		RelatedEntities: related,
+	}, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+// fetchEntitiesIn returns the entities whose IDs are in ids, ordered by ID.
+// IDs without an entity are skipped.
+func (s *Store) fetchEntitiesIn(ctx context.Context, ids []string) ([]memory.Entity, error) {
+	if len(ids) == 0 {
+		return []memory.Entity{}, nil
+	}
+	q := `
+		MATCH (e:Entity)
+		WHERE e.id IN $ids
+		RETURN ` + entityProjection("e") + ` AS entity
+		ORDER BY e.id`
+
+	records, err := s.read(ctx, q, map[string]any{"ids": ids})
+	if err != nil {
+		return nil, fmt.Errorf("fetch entities in: %w", err)
+	}
+	return collectEntities(records)
+}
+
+// relatedIDs returns the IDs of the entities at the other end of rels from
+// id, without duplicates, in order of first appearance.
+func relatedIDs(id string, rels []memory.Relationship) []string {
+	seen := map[string]struct{}{id: {}}
+	var ids []string
+	for _, r := range rels {
+		for _, rid := range []string{r.SourceID, r.TargetID} {
+			if _, ok := seen[rid]; !ok {
+				seen[rid] = struct{}{}
+				ids = append(ids, rid)
+			}
+		}
+	}
+	return ids
+}
+
+// collectEntities decodes the "entity" column of records. It returns an
+// empty (non-nil) slice when there are no records.
+func collectEntities(records []*neo4jdriver.Record) ([]memory.Entity, error) {
+	entities := make([]memory.Entity, 0, len(records))
+	for _, rec := range records {
+		m, _, err := neo4jdriver.GetRecordValue[map[string]any](rec, "entity")
+		if err != nil {
+			return nil, err
+		}
+		e, err := entityFromValue(m)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, e)
+	}
+	return entities, nil
+}
+
+// entityFromValue decodes an [entityProjection] map.
+func entityFromValue(m map[string]any) (memory.Entity, error) {
+	attrs, err := attributesFromValue(m["attributes"])
+	if err != nil {
+		return memory.Entity{}, fmt.Errorf("unmarshal entity attributes: %w", err)
+	}
+	e := memory.Entity{Attributes: attrs}
+	e.ID, _ = m["id"].(string)
+	e.Type, _ = m["type"].(string)
+	e.Name, _ = m["name"].(string)
+	e.CreatedAt, _ = m["created_at"].(time.Time)
+	e.UpdatedAt, _ = m["updated_at"].(time.Time)
+	return e, nil
+}
+
+// collectRelationships decodes the "rel" column of records. It returns an
+// empty (non-nil) slice when there are no records.
+func collectRelationships(records []*neo4jdriver.Record) ([]memory.Relationship, error) {
+	rels := make([]memory.Relationship, 0, len(records))
+	for _, rec := range records {
+		m, _, err := neo4jdriver.GetRecordValue[map[string]any](rec, "rel")
+		if err != nil {
+			return nil, err
+		}
+		attrs, err := attributesFromValue(m["attributes"])
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal relationship attributes: %w", err)
+		}
+		r := memory.Relationship{Attributes: attrs}
+		r.SourceID, _ = m["source_id"].(string)
+		r.TargetID, _ = m["target_id"].(string)
+		r.RelType, _ = m["rel_type"].(string)
+		r.CreatedAt, _ = m["created_at"].(time.Time)
+		if prov, _ := m["provenance"].(string); prov != "" {
+			if err := json.Unmarshal([]byte(prov), &r.Provenance); err != nil {
+				return nil, fmt.Errorf("unmarshal relationship provenance: %w", err)
+			}
+		}
+		rels = append(rels, r)
+	}
+	return rels, nil
+}
+
+// attributesFromValue decodes an attributes property stored as a JSON string.
+// A missing or null value yields an empty (non-nil) map.
+func attributesFromValue(v any) (map[string]any, error) {
+	attrs := map[string]any{}
+	if s, _ := v.(string); s != "" {
+		if err := json.Unmarshal([]byte(s), &attrs); err != nil {
+			return nil, err
+		}
+	}
+	if attrs == nil { // the JSON literal null
+		attrs = map[string]any{}
+	}
+	return attrs, nil
+}
+
+// marshalJSON encodes v as a JSON string for storage in a property.
+func marshalJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// list returns s, or an empty slice if s is nil, so that it is sent to the
+// server as an empty list rather than null.
+func list(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// jsonContains reports whether got contains want under the rules of
+// PostgreSQL's jsonb @> operator: objects contain objects whose keys they all
+// have with contained values, arrays contain arrays whose every element they
+// contain, and scalars must be equal. Both values must be decoded JSON.
+func jsonContains(got, want any) bool {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, wv := range w {
+			gv, ok := g[k]
+			if !ok || !jsonContains(gv, wv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok {
+			return false
+		}
+		for _, wv := range w {
+			found := false
+			for _, gv := range g {
+				if jsonContains(gv, wv) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	default:
+		return got == want
+	}
+}
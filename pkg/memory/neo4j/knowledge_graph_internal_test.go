@@ -0,0 +1,47 @@
+package neo4j
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONContains(t *testing.T) {
+	t.Parallel()
+
+	got := `{"class": "wizard", "level": 7, "magical": true, "tags": ["arcane", "old"], "home": {"city": "Waterdeep", "ward": "Sea"}}`
+
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{"empty query", `{}`, true},
+		{"string", `{"class": "wizard"}`, true},
+		{"string mismatch", `{"class": "fighter"}`, false},
+		{"number", `{"level": 7}`, true},
+		{"bool", `{"magical": true}`, true},
+		{"missing key", `{"alignment": "good"}`, false},
+		{"array subset", `{"tags": ["old"]}`, true},
+		{"array not subset", `{"tags": ["old", "new"]}`, false},
+		{"nested object subset", `{"home": {"city": "Waterdeep"}}`, true},
+		{"nested object mismatch", `{"home": {"city": "Neverwinter"}}`, false},
+		{"type mismatch", `{"level": "7"}`, false},
+	}
+
+	var attrs map[string]any
+	if err := json.Unmarshal([]byte(got), &attrs); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var query map[string]any
+			if err := json.Unmarshal([]byte(tt.query), &query); err != nil {
+				t.Fatal(err)
+			}
+			if got := jsonContains(attrs, query); got != tt.want {
+				t.Errorf("jsonContains(%s) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Package neo4j implements the L3 knowledge graph ([memory.KnowledgeGraph])
+// on a Neo4j database using Cypher.
+//
+// Entities are stored as nodes labelled Entity, keyed by a unique id
+// property. Relationships are stored as edges of the single type RELATES,
+// with the relationship's type in the rel_type property, because Cypher
+// cannot take edge types as query parameters. Entity and relationship
+// attributes and relationship provenance are stored as JSON strings, since
+// Neo4j properties cannot hold nested maps.
+//
+// Traversals treat edges as undirected, like the PostgreSQL store:
+// [Store.Neighbors] matches variable-length paths and [Store.FindPath] uses
+// shortestPath.
+//
+// The store holds no transcript chunks or embeddings, so it does not
+// implement [memory.GraphRAGQuerier]; awareness retrieval is skipped for
+// NPCs whose knowledge graph lives in Neo4j.
+//
+// Typical usage:
+//
+//	store, err := neo4j.NewStore(ctx, "neo4j://localhost:7687",
+//	    neo4j.WithBasicAuth("neo4j", password))
+//	if err != nil { … }
+//	defer store.Close(ctx)
+package neo4j
+
+import (
+	"context"
+	"fmt"
+
+	neo4jdriver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// Compile-time interface check.
+var _ memory.KnowledgeGraph = (*Store)(nil)
+
+// Store is a Neo4j-backed [memory.KnowledgeGraph].
+//
+// All operations are safe for concurrent use.
+type Store struct {
+	driver   neo4jdriver.DriverWithContext
+	database string
+}
+
+// Option configures a [Store].
+type Option func(*storeOptions)
+
+// storeOptions holds the settings applied by [Option] values.
+type storeOptions struct {
+	auth     neo4jdriver.AuthToken
+	database string
+}
+
+// WithBasicAuth authenticates with a username and password. Without it the
+// store connects without authentication.
+func WithBasicAuth(username, password string) Option {
+	return func(o *storeOptions) {
+		o.auth = neo4jdriver.BasicAuth(username, password, "")
+	}
+}
+
+// WithDatabase selects the database to use. The default is the server's
+// default database. An empty name is ignored.
+func WithDatabase(name string) Option {
+	return func(o *storeOptions) {
+		if name != "" {
+			o.database = name
+		}
+	}
+}
+
+// schema lists the statements that create the constraints and indexes the
+// store relies on. Each is idempotent.
+var schema = []string{
+	`CREATE CONSTRAINT entity_id IF NOT EXISTS FOR (e:Entity) REQUIRE e.id IS UNIQUE`,
+	`CREATE INDEX entity_type IF NOT EXISTS FOR (e:Entity) ON (e.type)`,
+	`CREATE INDEX relates_rel_type IF NOT EXISTS FOR ()-[r:RELATES]-() ON (r.rel_type)`,
+}
+
+// NewStore connects to the Neo4j server at uri (e.g., "neo4j://localhost:7687"),
+// verifies the connection, and creates the constraints and indexes the store
+// needs if they do not exist yet.
+func NewStore(ctx context.Context, uri string, opts ...Option) (*Store, error) {
+	o := storeOptions{auth: neo4jdriver.NoAuth()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	driver, err := neo4jdriver.NewDriverWithContext(uri, o.auth)
+	if err != nil {
+		return nil, fmt.Errorf("neo4j store: create driver: %w", err)
+	}
+	if err := driver.VerifyConnectivity(ctx); err != nil {
+		_ = driver.Close(ctx)
+		return nil, fmt.Errorf("neo4j store: verify connectivity: %w", err)
+	}
+
+	s := &Store{driver: driver, database: o.database}
+	for _, stmt := range schema {
+		if _, err := s.write(ctx, stmt, nil); err != nil {
+			_ = driver.Close(ctx)
+			return nil, fmt.Errorf("neo4j store: create schema: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// Close releases all connections held by the driver. It should be called when
+// the Store is no longer needed, typically via defer.
+func (s *Store) Close(ctx context.Context) error {
+	return s.driver.Close(ctx)
+}
+
+// read runs a read-only query and returns all its records.
+func (s *Store) read(ctx context.Context, query string, params map[string]any) ([]*neo4jdriver.Record, error) {
+	res, err := neo4jdriver.ExecuteQuery(ctx, s.driver, query, params,
+		neo4jdriver.EagerResultTransformer,
+		neo4jdriver.ExecuteQueryWithDatabase(s.database),
+		neo4jdriver.ExecuteQueryWithReadersRouting(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return res.Records, nil
+}
+
+// write runs a query that modifies the graph and returns all its records.
+func (s *Store) write(ctx context.Context, query string, params map[string]any) ([]*neo4jdriver.Record, error) {
+	res, err := neo4jdriver.ExecuteQuery(ctx, s.driver, query, params,
+		neo4jdriver.EagerResultTransformer,
+		neo4jdriver.ExecuteQueryWithDatabase(s.database),
+		neo4jdriver.ExecuteQueryWithWritersRouting(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return res.Records, nil
+}
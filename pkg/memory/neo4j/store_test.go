@@ -0,0 +1,680 @@
+package neo4j_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	neo4jdriver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/memory/neo4j"
+)
+
+// testURI returns the test server URI from the environment, or skips the
+// test if GLYPHOXA_TEST_NEO4J_URI is not set. GLYPHOXA_TEST_NEO4J_USER and
+// GLYPHOXA_TEST_NEO4J_PASSWORD supply credentials when the server requires
+// them.
+func testURI(t *testing.T) (uri string, opts []neo4j.Option) {
+	t.Helper()
+	uri = os.Getenv("GLYPHOXA_TEST_NEO4J_URI")
+	if uri == "" {
+		t.Skip("GLYPHOXA_TEST_NEO4J_URI not set — skipping Neo4j integration tests")
+	}
+	if user := os.Getenv("GLYPHOXA_TEST_NEO4J_USER"); user != "" {
+		opts = append(opts, neo4j.WithBasicAuth(user, os.Getenv("GLYPHOXA_TEST_NEO4J_PASSWORD")))
+	}
+	return uri, opts
+}
+
+// newTestStore creates a [neo4j.Store] on an empty database.
+// It calls t.Cleanup to close the store when the test finishes.
+func newTestStore(t *testing.T) *neo4j.Store {
+	t.Helper()
+	uri, opts := testURI(t)
+	ctx := context.Background()
+
+	store, err := neo4j.NewStore(ctx, uri, opts...)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(context.Background()) })
+
+	clearGraph(t, ctx, uri)
+	return store
+}
+
+// clearGraph deletes every node and relationship in the default database
+// through a separate driver, leaving the store's constraints in place.
+func clearGraph(t *testing.T, ctx context.Context, uri string) {
+	t.Helper()
+	auth := neo4jdriver.NoAuth()
+	if user := os.Getenv("GLYPHOXA_TEST_NEO4J_USER"); user != "" {
+		auth = neo4jdriver.BasicAuth(user, os.Getenv("GLYPHOXA_TEST_NEO4J_PASSWORD"), "")
+	}
+	driver, err := neo4jdriver.NewDriverWithContext(uri, auth)
+	if err != nil {
+		t.Fatalf("driver: %v", err)
+	}
+	defer driver.Close(ctx)
+	if _, err := neo4jdriver.ExecuteQuery(ctx, driver, "MATCH (n) DETACH DELETE n", nil, neo4jdriver.EagerResultTransformer); err != nil {
+		t.Fatalf("clear graph: %v", err)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// L3 — Entity CRUD
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestL3_EntityCRUD(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	entity := memory.Entity{
+		ID:   "ent-grimjaw",
+		Type: "npc",
+		Name: "Grimjaw",
+		Attributes: map[string]any{
+			"occupation": "blacksmith",
+			"alignment":  "neutral",
+		},
+	}
+
+	// Add.
+	if err := store.AddEntity(ctx, entity); err != nil {
+		t.Fatalf("AddEntity: %v", err)
+	}
+
+	// Get.
+	got, err := store.GetEntity(ctx, entity.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got == nil {
+		t.Fatal("GetEntity: expected entity, got nil")
+	}
+	if got.Name != entity.Name {
+		t.Errorf("Name: want %q, got %q", entity.Name, got.Name)
+	}
+	if got.Attributes["occupation"] != "blacksmith" {
+		t.Errorf("Attributes: expected occupation=blacksmith, got %v", got.Attributes)
+	}
+
+	// Update merges new key while preserving existing.
+	if err := store.UpdateEntity(ctx, entity.ID, map[string]any{"mood": "grumpy"}); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	updated, _ := store.GetEntity(ctx, entity.ID)
+	if updated.Attributes["mood"] != "grumpy" {
+		t.Errorf("UpdateEntity: want mood=grumpy, got %v", updated.Attributes)
+	}
+	if updated.Attributes["occupation"] != "blacksmith" {
+		t.Errorf("UpdateEntity: occupation should not be removed, got %v", updated.Attributes)
+	}
+
+	// UpdateEntity on missing ID returns error.
+	if err := store.UpdateEntity(ctx, "does-not-exist", map[string]any{}); !errors.Is(err, memory.ErrEntityNotFound) {
+		t.Errorf("UpdateEntity missing: want memory.ErrEntityNotFound, got %v", err)
+	}
+
+	// GetEntity for missing ID returns (nil, nil).
+	missing, err := store.GetEntity(ctx, "does-not-exist")
+	if err != nil {
+		t.Fatalf("GetEntity missing: unexpected error: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("GetEntity missing: want nil, got %+v", missing)
+	}
+
+	// Delete.
+	if err := store.DeleteEntity(ctx, entity.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	afterDelete, _ := store.GetEntity(ctx, entity.ID)
+	if afterDelete != nil {
+		t.Error("DeleteEntity: entity still present after delete")
+	}
+
+	// Delete non-existent is not an error.
+	if err := store.DeleteEntity(ctx, "never-existed"); err != nil {
+		t.Errorf("DeleteEntity non-existent: unexpected error: %v", err)
+	}
+}
+
+func TestL3_FindEntities(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	for _, e := range []memory.Entity{
+		{ID: "loc-tavern", Type: "location", Name: "The Rusty Tankard", Attributes: map[string]any{"atmosphere": "lively"}},
+		{ID: "npc-elara", Type: "npc", Name: "Elara the Mage", Attributes: map[string]any{"class": "wizard"}},
+		{ID: "npc-thorin", Type: "npc", Name: "Thorin", Attributes: map[string]any{"class": "fighter"}},
+		{ID: "item-sword", Type: "item", Name: "Sword of Dawn", Attributes: map[string]any{"magical": true}},
+	} {
+		mustAddEntity(t, ctx, store, e)
+	}
+
+	tests := []struct {
+		name      string
+		filter    memory.EntityFilter
+		wantIDs   []string
+		wantCount int
+	}{
+		{"by type npc", memory.EntityFilter{Type: "npc"}, nil, 2},
+		{"by name substring", memory.EntityFilter{Name: "Elara"}, []string{"npc-elara"}, 1},
+		{"by attribute", memory.EntityFilter{AttributeQuery: map[string]any{"magical": true}}, []string{"item-sword"}, 1},
+		{"no match", memory.EntityFilter{Type: "faction"}, nil, 0},
+		{"empty filter", memory.EntityFilter{}, nil, 4},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := store.FindEntities(ctx, tc.filter)
+			if err != nil {
+				t.Fatalf("FindEntities: %v", err)
+			}
+			if tc.wantCount > 0 && len(results) != tc.wantCount {
+				t.Errorf("want %d, got %d", tc.wantCount, len(results))
+			}
+			for _, wid := range tc.wantIDs {
+				if !containsEntity(results, wid) {
+					t.Errorf("expected entity %q not found in results %v", wid, entityIDs(results))
+				}
+			}
+		})
+	}
+}
+
+func TestL3_Pagination(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	for _, e := range []memory.Entity{
+		{ID: "npc-brom", Type: "npc", Name: "Brom"},
+		{ID: "npc-aldric-2", Type: "npc", Name: "Aldric"},
+		{ID: "npc-aldric-1", Type: "npc", Name: "Aldric"},
+		{ID: "npc-cora", Type: "npc", Name: "Cora"},
+		{ID: "npc-dain", Type: "npc", Name: "Dain"},
+		{ID: "loc-bridge", Type: "location", Name: "Bridge"},
+	} {
+		mustAddEntity(t, ctx, store, e)
+	}
+
+	// Entities: pages of two, ordered by name and ID, filter kept.
+	var gotIDs []string
+	filter := memory.EntityFilter{Type: "npc", Limit: 2}
+	for page := 0; ; page++ {
+		if page > 3 {
+			t.Fatalf("FindEntities: no last page, got %v", gotIDs)
+		}
+		entities, err := store.FindEntities(ctx, filter)
+		if err != nil {
+			t.Fatalf("FindEntities page %d: %v", page, err)
+		}
+		gotIDs = append(gotIDs, entityIDs(entities)...)
+		if filter.Cursor = memory.NextEntityCursor(entities, filter.Limit); filter.Cursor == "" {
+			break
+		}
+	}
+	if want := []string{"npc-aldric-1", "npc-aldric-2", "npc-brom", "npc-cora", "npc-dain"}; !slices.Equal(gotIDs, want) {
+		t.Errorf("paged entities: got %v, want %v", gotIDs, want)
+	}
+	if _, err := store.FindEntities(ctx, memory.EntityFilter{Cursor: "not a cursor"}); !errors.Is(err, memory.ErrInvalidCursor) {
+		t.Errorf("FindEntities with bad cursor: got %v, want ErrInvalidCursor", err)
+	}
+
+	// Relationships: pages of three match the unpaginated listing.
+	for _, target := range []string{"npc-brom", "npc-cora", "npc-dain", "loc-bridge"} {
+		if err := store.AddRelationship(ctx, memory.Relationship{SourceID: "npc-aldric-1", TargetID: target, RelType: "knows"}); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+	all, err := store.GetRelationships(ctx, "npc-aldric-1")
+	if err != nil {
+		t.Fatalf("GetRelationships: %v", err)
+	}
+	var paged []memory.Relationship
+	cursor := ""
+	for page := 0; ; page++ {
+		if page > 2 {
+			t.Fatalf("GetRelationships: no last page, got %d relationships", len(paged))
+		}
+		rels, err := store.GetRelationships(ctx, "npc-aldric-1", memory.WithRelLimit(3), memory.WithRelCursor(cursor))
+		if err != nil {
+			t.Fatalf("GetRelationships page %d: %v", page, err)
+		}
+		paged = append(paged, rels...)
+		if cursor = memory.NextRelationshipCursor(rels, 3); cursor == "" {
+			break
+		}
+	}
+	targets := func(rels []memory.Relationship) []string {
+		var ids []string
+		for _, r := range rels {
+			ids = append(ids, r.TargetID)
+		}
+		return ids
+	}
+	if got, want := targets(paged), targets(all); len(want) != 4 || !slices.Equal(got, want) {
+		t.Errorf("paged relationships: got %v, want %v", got, want)
+	}
+	if _, err := store.GetRelationships(ctx, "npc-aldric-1", memory.WithRelCursor("not a cursor")); !errors.Is(err, memory.ErrInvalidCursor) {
+		t.Errorf("GetRelationships with bad cursor: got %v, want ErrInvalidCursor", err)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// L3 — Relationship CRUD
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestL3_RelationshipCRUD(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	grimjaw := memory.Entity{ID: "rel-grimjaw", Type: "npc", Name: "Grimjaw"}
+	tavern := memory.Entity{ID: "rel-tavern", Type: "location", Name: "The Rusty Tankard"}
+	guild := memory.Entity{ID: "rel-guild", Type: "faction", Name: "Blacksmiths Guild"}
+	for _, e := range []memory.Entity{grimjaw, tavern, guild} {
+		mustAddEntity(t, ctx, store, e)
+	}
+
+	rels := []memory.Relationship{
+		{
+			SourceID: grimjaw.ID, TargetID: tavern.ID, RelType: "LOCATED_AT",
+			Attributes: map[string]any{"since": "year 1200"},
+			Provenance: memory.Provenance{SessionID: "s1", Confidence: 0.9, Source: "stated"},
+		},
+		{
+			SourceID: grimjaw.ID, TargetID: guild.ID, RelType: "MEMBER_OF",
+			Attributes: map[string]any{},
+			Provenance: memory.Provenance{Confidence: 0.8, Source: "inferred"},
+		},
+	}
+	for _, r := range rels {
+		if err := store.AddRelationship(ctx, r); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+
+	// AddRelationship to a missing endpoint reports the missing entity.
+	err := store.AddRelationship(ctx, memory.Relationship{SourceID: grimjaw.ID, TargetID: "does-not-exist", RelType: "KNOWS"})
+	if !errors.Is(err, memory.ErrEntityNotFound) {
+		t.Errorf("AddRelationship missing endpoint: want memory.ErrEntityNotFound, got %v", err)
+	}
+
+	// GetRelationships: outgoing from grimjaw (default).
+	out, err := store.GetRelationships(ctx, grimjaw.ID)
+	if err != nil {
+		t.Fatalf("GetRelationships: %v", err)
+	}
+	if len(out) != 2 {
+		t.Errorf("outgoing: want 2, got %d", len(out))
+	}
+
+	// Filter by rel type.
+	locRels, err := store.GetRelationships(ctx, grimjaw.ID, memory.WithRelTypes("LOCATED_AT"))
+	if err != nil {
+		t.Fatalf("WithRelTypes: %v", err)
+	}
+	if len(locRels) != 1 {
+		t.Errorf("WithRelTypes: want 1, got %d", len(locRels))
+	}
+
+	// Incoming: tavern should see the edge from grimjaw.
+	inc, err := store.GetRelationships(ctx, tavern.ID, memory.WithIncoming())
+	if err != nil {
+		t.Fatalf("incoming: %v", err)
+	}
+	if len(inc) != 1 {
+		t.Errorf("incoming: want 1, got %d", len(inc))
+	}
+
+	// Provenance round-trip.
+	if len(locRels) > 0 && locRels[0].Provenance.Confidence != 0.9 {
+		t.Errorf("Provenance.Confidence: want 0.9, got %v", locRels[0].Provenance.Confidence)
+	}
+	if len(locRels) > 0 && locRels[0].Attributes["since"] != "year 1200" {
+		t.Errorf("Attributes[since]: want year 1200, got %v", locRels[0].Attributes)
+	}
+
+	// Upsert: replace with new attribute value.
+	updated := rels[0]
+	updated.Attributes = map[string]any{"since": "year 1205"}
+	if err := store.AddRelationship(ctx, updated); err != nil {
+		t.Fatalf("AddRelationship upsert: %v", err)
+	}
+	got, _ := store.GetRelationships(ctx, grimjaw.ID, memory.WithRelTypes("LOCATED_AT"))
+	if len(got) > 0 && got[0].Attributes["since"] != "year 1205" {
+		t.Errorf("upsert: want year 1205, got %v", got[0].Attributes)
+	}
+
+	// Delete.
+	if err := store.DeleteRelationship(ctx, grimjaw.ID, guild.ID, "MEMBER_OF"); err != nil {
+		t.Fatalf("DeleteRelationship: %v", err)
+	}
+	after, _ := store.GetRelationships(ctx, grimjaw.ID)
+	if len(after) != 1 {
+		t.Errorf("after delete: want 1, got %d", len(after))
+	}
+
+	// Delete non-existent is not an error.
+	if err := store.DeleteRelationship(ctx, "x", "y", "KNOWS"); err != nil {
+		t.Errorf("DeleteRelationship non-existent: unexpected error: %v", err)
+	}
+}
+
+func TestL3_ConfirmRelationship(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	mustAddEntity(t, ctx, store, memory.Entity{ID: "npc-grimjaw", Type: "npc", Name: "Grimjaw"})
+	mustAddEntity(t, ctx, store, memory.Entity{ID: "fac-guild", Type: "faction", Name: "Thieves Guild"})
+	prov := memory.Provenance{SessionID: "session-1", Confidence: 0.45, Source: "inferred"}
+	for _, rel := range []memory.Relationship{
+		{SourceID: "npc-grimjaw", TargetID: "fac-guild", RelType: "MEMBER_OF", Attributes: map[string]any{"rank": "fence"}, Provenance: prov},
+		{SourceID: "npc-grimjaw", TargetID: "fac-guild", RelType: "OWES", Provenance: prov},
+	} {
+		if err := store.AddRelationship(ctx, rel); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+
+	// get returns the MEMBER_OF edge.
+	get := func() memory.Relationship {
+		t.Helper()
+		rels, err := store.GetRelationships(ctx, "npc-grimjaw", memory.WithRelTypes("MEMBER_OF"))
+		if err != nil || len(rels) != 1 {
+			t.Fatalf("GetRelationships: %d relationships, err %v", len(rels), err)
+		}
+		return rels[0]
+	}
+	// canon returns the types of the confirmed edges.
+	canon := func() []string {
+		t.Helper()
+		rels, err := store.GetRelationships(ctx, "npc-grimjaw", memory.WithDMConfirmedOnly())
+		if err != nil {
+			t.Fatalf("GetRelationships(WithDMConfirmedOnly): %v", err)
+		}
+		var types []string
+		for _, r := range rels {
+			types = append(types, r.RelType)
+		}
+		return types
+	}
+
+	if got := canon(); len(got) != 0 {
+		t.Errorf("confirmed before confirming: got %v, want none", got)
+	}
+	for _, confirmed := range []bool{true, false} {
+		if err := store.ConfirmRelationship(ctx, "npc-grimjaw", "fac-guild", "MEMBER_OF", confirmed); err != nil {
+			t.Fatalf("ConfirmRelationship(%v): %v", confirmed, err)
+		}
+		r := get()
+		if r.Provenance.DMConfirmed != confirmed {
+			t.Errorf("DMConfirmed: got %v, want %v", r.Provenance.DMConfirmed, confirmed)
+		}
+		// The rest of the provenance and the attributes survive the toggle.
+		p := r.Provenance
+		if p.SessionID != prov.SessionID || p.Confidence != prov.Confidence || p.Source != prov.Source {
+			t.Errorf("provenance after confirm(%v): got %+v, want %+v", confirmed, p, prov)
+		}
+		if r.Attributes["rank"] != "fence" {
+			t.Errorf("attributes after confirm(%v): got %v", confirmed, r.Attributes)
+		}
+		want := []string(nil)
+		if confirmed {
+			want = []string{"MEMBER_OF"}
+		}
+		if got := canon(); !slices.Equal(got, want) {
+			t.Errorf("confirmed after confirm(%v): got %v, want %v", confirmed, got, want)
+		}
+	}
+
+	// The edge is directed: the reverse edge does not exist.
+	err := store.ConfirmRelationship(ctx, "fac-guild", "npc-grimjaw", "MEMBER_OF", true)
+	if !errors.Is(err, memory.ErrRelationshipNotFound) {
+		t.Errorf("ConfirmRelationship on missing edge: got %v, want ErrRelationshipNotFound", err)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// L3 — Graph traversal
+// ─────────────────────────────────────────────────────────────────────────────
+
+// buildTestGraph creates a 5-node directed graph:
+//
+//	grimjaw → (KNOWS)      → elara
+//	grimjaw → (MEMBER_OF)  → guild
+//	elara   → (LOCATED_AT) → tower
+//	guild   → (ALLIED_WITH)→ mages
+func buildTestGraph(t *testing.T, ctx context.Context, store *neo4j.Store) (grimjaw, elara, guild, tower, mages memory.Entity) {
+	t.Helper()
+	grimjaw = memory.Entity{ID: "g-grimjaw", Type: "npc", Name: "Grimjaw"}
+	elara = memory.Entity{ID: "g-elara", Type: "npc", Name: "Elara"}
+	guild = memory.Entity{ID: "g-guild", Type: "faction", Name: "Blacksmiths Guild"}
+	tower = memory.Entity{ID: "g-tower", Type: "location", Name: "Elara's Tower"}
+	mages = memory.Entity{ID: "g-mages", Type: "faction", Name: "Mages Council"}
+	for _, e := range []memory.Entity{grimjaw, elara, guild, tower, mages} {
+		mustAddEntity(t, ctx, store, e)
+	}
+	for _, r := range []memory.Relationship{
+		{SourceID: grimjaw.ID, TargetID: elara.ID, RelType: "KNOWS", Attributes: map[string]any{}},
+		{SourceID: grimjaw.ID, TargetID: guild.ID, RelType: "MEMBER_OF", Attributes: map[string]any{}},
+		{SourceID: elara.ID, TargetID: tower.ID, RelType: "LOCATED_AT", Attributes: map[string]any{}},
+		{SourceID: guild.ID, TargetID: mages.ID, RelType: "ALLIED_WITH", Attributes: map[string]any{}},
+	} {
+		if err := store.AddRelationship(ctx, r); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+	return
+}
+
+func TestL3_Neighbors(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	grimjaw, _, _, _, _ := buildTestGraph(t, ctx, store)
+
+	// Depth 1: directly connected elara + guild.
+	n1, err := store.Neighbors(ctx, grimjaw.ID, 1)
+	if err != nil {
+		t.Fatalf("Neighbors(1): %v", err)
+	}
+	if len(n1) != 2 {
+		t.Errorf("Neighbors(1): want 2, got %d %v", len(n1), entityIDs(n1))
+	}
+
+	// Depth 2: adds tower + mages.
+	n2, err := store.Neighbors(ctx, grimjaw.ID, 2)
+	if err != nil {
+		t.Fatalf("Neighbors(2): %v", err)
+	}
+	if len(n2) != 4 {
+		t.Errorf("Neighbors(2): want 4, got %d %v", len(n2), entityIDs(n2))
+	}
+
+	// Depth 3: same as depth 2 (no additional reachable nodes).
+	n3, err := store.Neighbors(ctx, grimjaw.ID, 3)
+	if err != nil {
+		t.Fatalf("Neighbors(3): %v", err)
+	}
+	if len(n3) != 4 {
+		t.Errorf("Neighbors(3): want 4, got %d %v", len(n3), entityIDs(n3))
+	}
+
+	// RelType filter: only KNOWS → should find elara (and at depth 2: tower).
+	nKnows, err := store.Neighbors(ctx, grimjaw.ID, 2, memory.TraverseRelTypes("KNOWS", "LOCATED_AT"))
+	if err != nil {
+		t.Fatalf("Neighbors KNOWS: %v", err)
+	}
+	ids := entityIDs(nKnows)
+	if !containsStr(ids, "g-elara") {
+		t.Errorf("KNOWS filter: expected g-elara in %v", ids)
+	}
+	if containsStr(ids, "g-guild") {
+		t.Errorf("KNOWS filter: g-guild should not be in %v", ids)
+	}
+
+	// NodeType filter: only faction nodes.
+	nFaction, err := store.Neighbors(ctx, grimjaw.ID, 3, memory.TraverseNodeTypes("faction"))
+	if err != nil {
+		t.Fatalf("Neighbors faction: %v", err)
+	}
+	if len(nFaction) == 0 {
+		t.Error("faction node filter: expected at least 1 result")
+	}
+	for _, e := range nFaction {
+		if e.Type != "faction" {
+			t.Errorf("faction filter: got entity with type %q", e.Type)
+		}
+	}
+
+	// MaxNodes cap.
+	nCapped, err := store.Neighbors(ctx, grimjaw.ID, 3, memory.TraverseMaxNodes(2))
+	if err != nil {
+		t.Fatalf("Neighbors max nodes: %v", err)
+	}
+	if len(nCapped) > 2 {
+		t.Errorf("MaxNodes(2): want ≤2, got %d", len(nCapped))
+	}
+}
+
+func TestL3_FindPath(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	grimjaw, _, _, tower, _ := buildTestGraph(t, ctx, store)
+
+	// grimjaw → elara → tower requires 2 hops.
+	path, err := store.FindPath(ctx, grimjaw.ID, tower.ID, 5)
+	if err != nil {
+		t.Fatalf("FindPath: %v", err)
+	}
+	if len(path) != 3 {
+		t.Errorf("FindPath: want length 3, got %d %v", len(path), entityIDs(path))
+	}
+	if len(path) > 0 && path[0].ID != grimjaw.ID {
+		t.Errorf("FindPath: want start %s, got %s", grimjaw.ID, path[0].ID)
+	}
+	if len(path) > 0 && path[len(path)-1].ID != tower.ID {
+		t.Errorf("FindPath: want end %s, got %s", tower.ID, path[len(path)-1].ID)
+	}
+
+	// maxDepth=1 is not enough to reach tower — expect empty.
+	short, err := store.FindPath(ctx, grimjaw.ID, tower.ID, 1)
+	if err != nil {
+		t.Fatalf("FindPath short: %v", err)
+	}
+	if len(short) != 0 {
+		t.Errorf("FindPath short: want empty, got %v", entityIDs(short))
+	}
+
+	// A path from a node to itself is just that node.
+	self, err := store.FindPath(ctx, grimjaw.ID, grimjaw.ID, 5)
+	if err != nil {
+		t.Fatalf("FindPath self: %v", err)
+	}
+	if len(self) != 1 || self[0].ID != grimjaw.ID {
+		t.Errorf("FindPath self: want [%s], got %v", grimjaw.ID, entityIDs(self))
+	}
+
+	// Disconnected node — expect empty.
+	isolated := memory.Entity{ID: "g-isolated", Type: "npc", Name: "Nobody"}
+	mustAddEntity(t, ctx, store, isolated)
+	none, err := store.FindPath(ctx, grimjaw.ID, isolated.ID, 5)
+	if err != nil {
+		t.Fatalf("FindPath none: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("FindPath none: want empty, got %v", entityIDs(none))
+	}
+}
+
+func TestL3_VisibleSubgraph(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	grimjaw, elara, guild, _, _ := buildTestGraph(t, ctx, store)
+
+	entities, rels, err := store.VisibleSubgraph(ctx, grimjaw.ID)
+	if err != nil {
+		t.Fatalf("VisibleSubgraph: %v", err)
+	}
+
+	ids := entityIDs(entities)
+	for _, want := range []string{grimjaw.ID, elara.ID, guild.ID} {
+		if !containsStr(ids, want) {
+			t.Errorf("VisibleSubgraph: missing %s in %v", want, ids)
+		}
+	}
+	if len(rels) != 2 {
+		t.Errorf("VisibleSubgraph rels: want 2, got %d", len(rels))
+	}
+}
+
+func TestL3_IdentitySnapshot(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	grimjaw, elara, guild, _, _ := buildTestGraph(t, ctx, store)
+
+	snap, err := store.IdentitySnapshot(ctx, grimjaw.ID)
+	if err != nil {
+		t.Fatalf("IdentitySnapshot: %v", err)
+	}
+	if snap == nil {
+		t.Fatal("IdentitySnapshot: expected non-nil")
+	}
+	if snap.Entity.ID != grimjaw.ID {
+		t.Errorf("Entity.ID: want %s, got %s", grimjaw.ID, snap.Entity.ID)
+	}
+	if len(snap.Relationships) != 2 {
+		t.Errorf("Relationships: want 2, got %d", len(snap.Relationships))
+	}
+	relatedIDs := entityIDs(snap.RelatedEntities)
+	for _, want := range []string{elara.ID, guild.ID} {
+		if !containsStr(relatedIDs, want) {
+			t.Errorf("RelatedEntities: missing %s in %v", want, relatedIDs)
+		}
+	}
+
+	// IdentitySnapshot for missing entity returns error.
+	_, err = store.IdentitySnapshot(ctx, "does-not-exist")
+	if !errors.Is(err, memory.ErrEntityNotFound) {
+		t.Errorf("IdentitySnapshot missing: want memory.ErrEntityNotFound, got %v", err)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+func mustAddEntity(t *testing.T, ctx context.Context, store *neo4j.Store, e memory.Entity) {
+	t.Helper()
+	if e.Attributes == nil {
+		e.Attributes = map[string]any{}
+	}
+	if err := store.AddEntity(ctx, e); err != nil {
+		t.Fatalf("mustAddEntity %s: %v", e.ID, err)
+	}
+}
+
+func entityIDs(entities []memory.Entity) []string {
+	ids := make([]string, len(entities))
+	for i, e := range entities {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func containsEntity(entities []memory.Entity, id string) bool {
+	for _, e := range entities {
+		if e.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStr(slice []string, s string) bool {
+	return slices.Contains(slice, s)
+}
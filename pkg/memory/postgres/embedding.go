@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	pgvector "github.com/pgvector/pgvector-go"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
+)
+
+// reembedBatchSize is the number of chunks sent to the embeddings provider in
+// one EmbedBatch call during [Store.ReembedAll].
+const reembedBatchSize = 64
+
+// EmbeddingModel returns the embedding model recorded for the stored vectors
+// and the dimension of the embeddings column. model is empty when no model has
+// been recorded yet, e.g. for a database created before models were tracked.
+func (s *Store) EmbeddingModel(ctx context.Context) (model string, dims int, err error) {
+	const q = `SELECT model, dimensions FROM embedding_meta WHERE id`
+	if err := s.pool.QueryRow(ctx, q).Scan(&model, &dims); err != nil {
+		return "", 0, fmt.Errorf("postgres store: embedding model: %w", err)
+	}
+	return model, dims, nil
+}
+
+// BindEmbeddingModel verifies that the stored vectors were produced by model
+// with dims dimensions and records model if none is recorded yet. A dims of 0
+// skips the dimension check.
+//
+// When the store was populated by a different model, or its embeddings column
+// has a different dimension, BindEmbeddingModel returns an error wrapping
+// [memory.ErrEmbeddingMismatch] (or [memory.ErrEmbeddingDimMismatch] for the
+// latter): vectors from different models must not be
+// mixed. Run [Store.ReembedAll] (the "glyphoxa memory reembed" command) to
+// migrate the store to the new model.
+func (s *Store) BindEmbeddingModel(ctx context.Context, model string, dims int) error {
+	recorded, stored, err := s.EmbeddingModel(ctx)
+	if err != nil {
+		return err
+	}
+	if recorded != "" && model != "" && recorded != model {
+		return fmt.Errorf("postgres store: stored vectors were embedded with %q, configured model is %q; run \"glyphoxa memory reembed\" to migrate: %w",
+			recorded, model, memory.ErrEmbeddingMismatch)
+	}
+	if dims > 0 && dims != stored {
+		return fmt.Errorf("postgres store: stored vectors have %d dimensions, configured model %q produces %d; run \"glyphoxa memory reembed\" to migrate: %w",
+			stored, model, dims, memory.ErrEmbeddingDimMismatch)
+	}
+	if recorded == "" && model != "" {
+		const q = `UPDATE embedding_meta SET model = $1, updated_at = now() WHERE id`
+		if _, err := s.pool.Exec(ctx, q, model); err != nil {
+			return fmt.Errorf("postgres store: record embedding model: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReembedAll re-computes the embedding of every stored chunk with p and
+// records p's model, migrating the embeddings column to p's dimension if it
+// differs. It returns the number of chunks re-embedded.
+//
+// All embeddings are computed before the database is touched, and the update
+// runs in a single transaction, so a failure leaves the store unchanged. The
+// HNSW index is rebuilt as part of the migration. Writes to the semantic index
+// should be paused while ReembedAll runs.
+func (s *Store) ReembedAll(ctx context.Context, p embeddings.Provider) (int, error) {
+	ids, contents, err := s.allChunkContents(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	vectors := make([][]float32, 0, len(contents))
+	for start := 0; start < len(contents); start += reembedBatchSize {
+		batch := contents[start:min(start+reembedBatchSize, len(contents))]
+		out, err := p.EmbedBatch(ctx, batch)
+		if err != nil {
+			return 0, fmt.Errorf("postgres store: reembed: %w", err)
+		}
+		if len(out) != len(batch) {
+			return 0, fmt.Errorf("postgres store: reembed: provider returned %d embeddings for %d chunks", len(out), len(batch))
+		}
+		vectors = append(vectors, out...)
+	}
+
+	dims := p.Dimensions()
+	if len(vectors) > 0 {
+		dims = len(vectors[0])
+	}
+	for i, v := range vectors {
+		if len(v) != dims {
+			return 0, fmt.Errorf("postgres store: reembed chunk %q: embedding has %d dimensions, expected %d: %w",
+				ids[i], len(v), dims, memory.ErrEmbeddingDimMismatch)
+		}
+	}
+	if dims <= 0 {
+		return 0, fmt.Errorf("postgres store: reembed: provider %q does not report its dimensions", p.ModelID())
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("postgres store: reembed: begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	statements := []string{
+		`DROP INDEX IF EXISTS idx_chunks_embedding`,
+		fmt.Sprintf(`ALTER TABLE chunks ALTER COLUMN embedding TYPE vector(%d) USING NULL`, dims),
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return 0, fmt.Errorf("postgres store: reembed: migrate column: %w", err)
+		}
+	}
+
+	batch := &pgx.Batch{}
+	for i, id := range ids {
+		batch.Queue(`UPDATE chunks SET embedding = $2 WHERE id = $1`, id, pgvector.NewVector(vectors[i]))
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, fmt.Errorf("postgres store: reembed: update chunks: %w", err)
+	}
+
+	indexQ := fmt.Sprintf(`CREATE INDEX idx_chunks_embedding ON chunks USING hnsw (embedding %s)`, metricOps[s.metric].opclass)
+	if _, err := tx.Exec(ctx, indexQ); err != nil {
+		return 0, fmt.Errorf("postgres store: reembed: rebuild index: %w", err)
+	}
+
+	const metaQ = `UPDATE embedding_meta SET model = $1, dimensions = $2, updated_at = now() WHERE id`
+	if _, err := tx.Exec(ctx, metaQ, p.ModelID(), dims); err != nil {
+		return 0, fmt.Errorf("postgres store: reembed: record model: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("postgres store: reembed: commit: %w", err)
+	}
+
+	// Pooled connections cache statements prepared against the old column
+	// type; replace them so later queries are planned afresh.
+	s.pool.Reset()
+	s.dims.Store(int64(dims))
+	return len(ids), nil
+}
+
+// allChunkContents returns the ID and content of every stored chunk.
+func (s *Store) allChunkContents(ctx context.Context) (ids, contents []string, err error) {
+	rows, err := s.pool.Query(ctx, `SELECT id, content FROM chunks ORDER BY id`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("postgres store: reembed: list chunks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			return nil, nil, fmt.Errorf("postgres store: reembed: scan chunk: %w", err)
+		}
+		ids = append(ids, id)
+		contents = append(contents, content)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("postgres store: reembed: list chunks: %w", err)
+	}
+	return ids, contents, nil
+}
+
+// loadEmbeddingMeta reads the dimension of the embeddings column and makes
+// sure embedding_meta holds a row describing it.
+func (s *Store) loadEmbeddingMeta(ctx context.Context) error {
+	// pgvector stores the declared dimension as the column's type modifier.
+	const dimsQ = `SELECT atttypmod FROM pg_attribute
+WHERE attrelid = 'chunks'::regclass AND attname = 'embedding'`
+	var dims int
+	if err := s.pool.QueryRow(ctx, dimsQ).Scan(&dims); err != nil {
+		return fmt.Errorf("read embedding dimensions: %w", err)
+	}
+
+	const metaQ = `INSERT INTO embedding_meta (dimensions) VALUES ($1)
+ON CONFLICT (id) DO UPDATE SET dimensions = EXCLUDED.dimensions
+WHERE embedding_meta.dimensions <> EXCLUDED.dimensions`
+	if _, err := s.pool.Exec(ctx, metaQ, dims); err != nil {
+		return fmt.Errorf("record embedding dimensions: %w", err)
+	}
+
+	s.dims.Store(int64(dims))
+	return nil
+}
+
+// checkDimensions returns an error wrapping [memory.ErrEmbeddingDimMismatch] if
+// embedding does not have want dimensions. A want of 0 accepts any embedding.
+func checkDimensions(embedding []float32, want int64) error {
+	if want > 0 && int64(len(embedding)) != want {
+		return fmt.Errorf("embedding has %d dimensions, store holds %d-dimensional vectors: %w",
+			len(embedding), want, memory.ErrEmbeddingDimMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// newPoollessStore returns a Store holding dims-dimensional vectors without a
+// connection pool, so any query it sends to the database panics.
+func newPoollessStore(dims int64) *Store {
+	s := &Store{}
+	s.dims.Store(dims)
+	s.semantic = &SemanticIndexImpl{dims: &s.dims}
+	return s
+}
+
+func TestSearch_DimensionMismatchBeforeQuery(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	wrong := []float32{1, 0, 0, 0, 0, 0}
+
+	tests := []struct {
+		name string
+		call func(*Store) error
+	}{
+		{"L2 Search", func(s *Store) error {
+			_, err := s.L2().Search(ctx, wrong, 3, memory.ChunkFilter{})
+			return err
+		}},
+		{"QueryWithEmbedding", func(s *Store) error {
+			_, err := s.QueryWithEmbedding(ctx, wrong, 3, nil)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.call(newPoollessStore(4))
+			if !errors.Is(err, memory.ErrEmbeddingDimMismatch) {
+				t.Fatalf("want ErrEmbeddingDimMismatch, got %v", err)
+			}
+			if !errors.Is(err, memory.ErrEmbeddingMismatch) {
+				t.Errorf("error %v does not match ErrEmbeddingMismatch", err)
+			}
+			if !strings.Contains(err.Error(), "6 dimensions") || !strings.Contains(err.Error(), "4-dimensional") {
+				t.Errorf("error should name both dimensions, got %q", err)
+			}
+		})
+	}
+}
+
+func TestCheckDimensions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		length  int
+		want    int64
+		wantErr bool
+	}{
+		{"match", 4, 4, false},
+		{"shorter", 3, 4, true},
+		{"longer", 5, 4, true},
+		{"empty", 0, 4, true},
+		{"unknown dimension", 7, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := checkDimensions(make([]float32, tt.length), tt.want)
+			if got := errors.Is(err, memory.ErrEmbeddingDimMismatch); got != tt.wantErr {
+				t.Errorf("checkDimensions(%d, %d) = %v, want mismatch %v", tt.length, tt.want, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyOptions_DistanceMetric(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    memory.DistanceMetric
+		wantErr bool
+	}{
+		{name: "default", want: memory.MetricCosine},
+		{name: "empty ignored", opts: []Option{WithDistanceMetric("")}, want: memory.MetricCosine},
+		{name: "l2", opts: []Option{WithDistanceMetric(memory.MetricL2)}, want: memory.MetricL2},
+		{name: "inner product", opts: []Option{WithDistanceMetric(memory.MetricInnerProduct)}, want: memory.MetricInnerProduct},
+		{name: "unknown", opts: []Option{WithDistanceMetric("manhattan")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			o, err := applyOptions(tt.opts)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "manhattan") {
+					t.Fatalf("applyOptions error = %v, want one naming the metric", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyOptions: %v", err)
+			}
+			if o.metric != tt.want {
+				t.Errorf("metric = %q, want %q", o.metric, tt.want)
+			}
+			if _, ok := metricOps[o.metric]; !ok {
+				t.Errorf("no pgvector operators for %q", o.metric)
+			}
+		})
+	}
+}
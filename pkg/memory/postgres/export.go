@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// Compile-time assertion that Store satisfies the memory.GraphExporter interface.
+var _ memory.GraphExporter = (*Store)(nil)
+
+// ExportGraph implements [memory.GraphExporter]. Entities are selected in a
+// single query, filtered by type and campaign attribute as requested, and the
+// relationships between them in a second one. Rendering is left to
+// [memory.EncodeGraph].
+func (s *Store) ExportGraph(ctx context.Context, format string, opts ...memory.ExportOpt) ([]byte, error) {
+	params := memory.ApplyExportOpts(opts)
+
+	var args []any
+	next := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var conditions []string
+	if len(params.EntityTypes) > 0 {
+		conditions = append(conditions, "type = ANY("+next(params.EntityTypes)+"::text[])")
+	}
+	if params.Campaign != "" {
+		attrJSON, err := json.Marshal(map[string]string{memory.CampaignAttribute: params.Campaign})
+		if err != nil {
+			return nil, fmt.Errorf("knowledge graph: export: marshal campaign filter: %w", err)
+		}
+		conditions = append(conditions, "attributes @> "+next(string(attrJSON))+"::jsonb")
+	}
+
+	q := "SELECT id, type, name, attributes, created_at, updated_at\nFROM   entities"
+	if len(conditions) > 0 {
+		q += "\nWHERE " + strings.Join(conditions, "\n  AND ")
+	}
+
+	rows, err := s.pool.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: export entities: %w", err)
+	}
+	entities, err := collectEntities(rows)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: export entities: %w", err)
+	}
+
+	ids := make([]string, len(entities))
+	for i, e := range entities {
+		ids[i] = e.ID
+	}
+	const relQ = `
+		SELECT source_id, target_id, rel_type, attributes, provenance, created_at
+		FROM   relationships
+		WHERE  source_id = ANY($1::text[]) AND target_id = ANY($1::text[])`
+
+	rows, err = s.pool.Query(ctx, relQ, ids)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: export relationships: %w", err)
+	}
+	rels, err := collectRelationships(rows)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: export relationships: %w", err)
+	}
+
+	out, err := memory.EncodeGraph(format, entities, rels)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: export: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// Compile-time assertion that SessionStoreImpl satisfies the
+// memory.SessionForker interface.
+var _ memory.SessionForker = (*SessionStoreImpl)(nil)
+
+// ForkSession implements [memory.SessionForker]. The source's rows are copied
+// under the new session ID in a single transaction, keeping their timestamps
+// and order; with [memory.ForkChunks] its chunks are copied too, their IDs
+// prefixed with the new session ID. Embeddings are copied as stored, so no
+// embedding calls are made.
+func (s *SessionStoreImpl) ForkSession(ctx context.Context, sourceID string, opts ...memory.ForkOpt) (string, error) {
+	params := memory.ApplyForkOpts(opts)
+	newID := memory.NewForkID(sourceID)
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("session store: fork session: begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	const copyEntries = `
+		INSERT INTO session_entries
+		    (session_id, speaker_id, speaker_name, text, raw_text, npc_id, timestamp, duration_ns, language, fts_config, words)
+		SELECT $2, speaker_id, speaker_name, text, raw_text, npc_id, timestamp, duration_ns, language, fts_config, words
+		FROM   session_entries
+		WHERE  session_id = $1
+		ORDER  BY id`
+	tag, err := tx.Exec(ctx, copyEntries, sourceID, newID)
+	if err != nil {
+		return "", fmt.Errorf("session store: fork session: copy entries: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return "", fmt.Errorf("session store: fork session %q: %w", sourceID, memory.ErrSessionNotFound)
+	}
+
+	if params.Chunks {
+		const copyChunks = `
+			INSERT INTO chunks
+			    (id, session_id, content, embedding, speaker_id, entity_id, topic, timestamp, language, fts_config)
+			SELECT $2 || '/' || id, $2, content, embedding, speaker_id, entity_id, topic, timestamp, language, fts_config
+			FROM   chunks
+			WHERE  session_id = $1`
+		if _, err := tx.Exec(ctx, copyChunks, sourceID, newID); err != nil {
+			return "", fmt.Errorf("session store: fork session: copy chunks: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("session store: fork session: commit: %w", err)
+	}
+	return newID, nil
+}
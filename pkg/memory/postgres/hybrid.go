@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// Compile-time assertion that Store satisfies the memory.HybridQuerier
+// interface.
+var _ memory.HybridQuerier = (*Store)(nil)
+
+// maxContextResults caps the results of [Store.QueryWithContext] and is the
+// least number of candidates each ranking of [Store.QueryHybrid] contributes.
+const maxContextResults = 20
+
+// dbQuerier is the query method shared by the connection pool and [pgx.Tx],
+// so the GraphRAG queries run alone or inside a transaction.
+type dbQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// QueryHybrid implements [memory.HybridQuerier]. The full-text and vector
+// queries of [Store.QueryWithContext] and [Store.QueryWithEmbedding] run in
+// one read-only, repeatable-read transaction, so both rank the same snapshot
+// of the chunks table. Each contributes at least 20 candidates (topK when
+// larger), which are fused by [memory.FuseRankings].
+func (s *Store) QueryHybrid(ctx context.Context, query string, embedding []float32, topK int, graphScope []string, alpha float64) ([]memory.ContextResult, error) {
+	if !(alpha >= 0 && alpha <= 1) {
+		return nil, fmt.Errorf("knowledge graph: query hybrid: alpha %v: %w", alpha, memory.ErrInvalidAlpha)
+	}
+	if err := checkDimensions(embedding, s.dims.Load()); err != nil {
+		return nil, fmt.Errorf("knowledge graph: query hybrid: %w", err)
+	}
+	candidates := max(topK, maxContextResults)
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: query hybrid: begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	fts, err := queryFTS(ctx, tx, query, graphScope, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: query hybrid: full-text: %w", err)
+	}
+	vec, err := s.queryVector(ctx, tx, embedding, candidates, graphScope)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: query hybrid: vector: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("knowledge graph: query hybrid: commit: %w", err)
+	}
+	return memory.FuseRankings(fts, vec, alpha, topK), nil
+}
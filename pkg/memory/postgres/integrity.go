@@ -0,0 +1,183 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// Compile-time assertion that Store satisfies the memory.IntegrityChecker interface.
+var _ memory.IntegrityChecker = (*Store)(nil)
+
+// CheckIntegrity implements [memory.IntegrityChecker]. Each kind of issue is
+// found by a single query; issues are returned grouped by kind (dangling
+// relationships, orphan entities, conflicting relationships) and ordered by
+// entity ID within each group.
+func (s *Store) CheckIntegrity(ctx context.Context) ([]memory.Issue, error) {
+	issues := []memory.Issue{}
+	for _, check := range []func(context.Context) ([]memory.Issue, error){
+		s.danglingRelationships,
+		s.orphanEntities,
+		s.conflictingRelationships,
+	} {
+		found, err := check(ctx)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+	return issues, nil
+}
+
+// danglingRelationships reports relationships whose source or target entity
+// does not exist. The foreign keys of the relationships table normally rule
+// this out, but rows written before they were in place or restored from a
+// partial dump can still violate it.
+func (s *Store) danglingRelationships(ctx context.Context) ([]memory.Issue, error) {
+	const q = `
+		SELECT r.source_id, r.target_id, r.rel_type, r.attributes, r.provenance, r.created_at,
+		       src.id IS NULL, tgt.id IS NULL
+		FROM   relationships r
+		LEFT JOIN entities src ON src.id = r.source_id
+		LEFT JOIN entities tgt ON tgt.id = r.target_id
+		WHERE  src.id IS NULL OR tgt.id IS NULL
+		ORDER  BY r.source_id, r.target_id, r.rel_type`
+
+	rows, err := s.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: check dangling relationships: %w", err)
+	}
+	defer rows.Close()
+
+	var issues []memory.Issue
+	for rows.Next() {
+		var (
+			r                  memory.Relationship
+			attrsJSON          []byte
+			provJSON           []byte
+			noSource, noTarget bool
+		)
+		if err := rows.Scan(&r.SourceID, &r.TargetID, &r.RelType, &attrsJSON, &provJSON, &r.CreatedAt, &noSource, &noTarget); err != nil {
+			return nil, fmt.Errorf("knowledge graph: check dangling relationships: scan: %w", err)
+		}
+		if err := unmarshalRelationshipJSON(&r, attrsJSON, provJSON); err != nil {
+			return nil, fmt.Errorf("knowledge graph: check dangling relationships: %w", err)
+		}
+
+		var missing []string
+		if noSource {
+			missing = append(missing, r.SourceID)
+		}
+		if noTarget && r.TargetID != r.SourceID {
+			missing = append(missing, r.TargetID)
+		}
+		issues = append(issues, memory.Issue{
+			Kind:          memory.IssueDanglingRelationship,
+			EntityIDs:     missing,
+			Relationships: []memory.Relationship{r},
+			Message:       fmt.Sprintf("relationship %q -[%s]-> %q references missing entity %q", r.SourceID, r.RelType, r.TargetID, missing[0]),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("knowledge graph: check dangling relationships: %w", err)
+	}
+	return issues, nil
+}
+
+// orphanEntities reports entities that are neither source nor target of any
+// relationship.
+func (s *Store) orphanEntities(ctx context.Context) ([]memory.Issue, error) {
+	const q = `
+		SELECT id, type, name, attributes, created_at, updated_at
+		FROM   entities e
+		WHERE  NOT EXISTS (
+		           SELECT 1 FROM relationships r
+		           WHERE  r.source_id = e.id OR r.target_id = e.id
+		       )
+		ORDER  BY id`
+
+	rows, err := s.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: check orphan entities: %w", err)
+	}
+	entities, err := collectEntities(rows)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: check orphan entities: %w", err)
+	}
+
+	issues := make([]memory.Issue, 0, len(entities))
+	for _, e := range entities {
+		issues = append(issues, memory.Issue{
+			Kind:      memory.IssueOrphanEntity,
+			EntityIDs: []string{e.ID},
+			Message:   fmt.Sprintf("%s %q (%s) has no relationships", e.Type, e.Name, e.ID),
+		})
+	}
+	return issues, nil
+}
+
+// conflictingRelationships reports pairs of relationships between the same
+// two entities, in either direction, whose types are listed together in
+// [memory.ConflictingRelTypes]. Types are compared case-insensitively, since
+// DM-taught edges are upper case while extracted ones are usually not.
+func (s *Store) conflictingRelationships(ctx context.Context) ([]memory.Issue, error) {
+	pairs := memory.ConflictingRelTypes()
+	left := make([]string, len(pairs))
+	right := make([]string, len(pairs))
+	for i, p := range pairs {
+		left[i], right[i] = p[0], p[1]
+	}
+
+	const q = `
+		WITH conflicts (a_type, b_type) AS (
+		    SELECT * FROM unnest($1::text[], $2::text[])
+		)
+		SELECT a.source_id, a.target_id, a.rel_type, a.attributes, a.provenance, a.created_at,
+		       b.source_id, b.target_id, b.rel_type, b.attributes, b.provenance, b.created_at
+		FROM   relationships a
+		JOIN   conflicts c ON c.a_type = lower(a.rel_type)
+		JOIN   relationships b
+		       ON  lower(b.rel_type) = c.b_type
+		       AND ((b.source_id = a.source_id AND b.target_id = a.target_id)
+		         OR (b.source_id = a.target_id AND b.target_id = a.source_id))
+		ORDER  BY a.source_id, a.target_id, a.rel_type, b.rel_type`
+
+	rows, err := s.pool.Query(ctx, q, left, right)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: check conflicting relationships: %w", err)
+	}
+	defer rows.Close()
+
+	var issues []memory.Issue
+	for rows.Next() {
+		var (
+			a, b          memory.Relationship
+			aAttrs, aProv []byte
+			bAttrs, bProv []byte
+		)
+		if err := rows.Scan(
+			&a.SourceID, &a.TargetID, &a.RelType, &aAttrs, &aProv, &a.CreatedAt,
+			&b.SourceID, &b.TargetID, &b.RelType, &bAttrs, &bProv, &b.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("knowledge graph: check conflicting relationships: scan: %w", err)
+		}
+		if err := unmarshalRelationshipJSON(&a, aAttrs, aProv); err != nil {
+			return nil, fmt.Errorf("knowledge graph: check conflicting relationships: %w", err)
+		}
+		if err := unmarshalRelationshipJSON(&b, bAttrs, bProv); err != nil {
+			return nil, fmt.Errorf("knowledge graph: check conflicting relationships: %w", err)
+		}
+		issues = append(issues, memory.Issue{
+			Kind:          memory.IssueConflictingRelationships,
+			EntityIDs:     []string{a.SourceID, a.TargetID},
+			Relationships: []memory.Relationship{a, b},
+			Message: fmt.Sprintf("%q and %q are linked by both %q and %q",
+				a.SourceID, a.TargetID, a.RelType, b.RelType),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("knowledge graph: check conflicting relationships: %w", err)
+	}
+	return issues, nil
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	pgvector "github.com/pgvector/pgvector-go"
 
 	"github.com/MrWong99/glyphoxa/pkg/memory"
@@ -71,7 +72,8 @@ func (s *Store) GetEntity(ctx context.Context, id string) (*memory.Entity, error
 
 // UpdateEntity implements [memory.KnowledgeGraph]. It merges attrs into the
 // entity's Attributes map using PostgreSQL's jsonb || operator and refreshes
-// updated_at. Returns an error when the entity does not exist.
+// updated_at. Returns an error wrapping [memory.ErrEntityNotFound] when the
+// entity does not exist.
 func (s *Store) UpdateEntity(ctx context.Context, id string, attrs map[string]any) error {
 	attrsJSON, err := json.Marshal(attrs)
 	if err != nil {
@@ -89,7 +91,7 @@ func (s *Store) UpdateEntity(ctx context.Context, id string, attrs map[string]an
 		return fmt.Errorf("knowledge graph: update entity: %w", err)
 	}
 	if tag.RowsAffected() == 0 {
-		return fmt.Errorf("knowledge graph: update entity: entity %q not found", id)
+		return fmt.Errorf("knowledge graph: update entity %q: %w", id, memory.ErrEntityNotFound)
 	}
 	return nil
 }
@@ -106,7 +108,9 @@ func (s *Store) DeleteEntity(ctx context.Context, id string) error {
 }
 
 // FindEntities implements [memory.KnowledgeGraph]. It returns all entities
-// matching filter. All non-zero filter fields are applied as AND conditions.
+// matching filter, ordered by name and ID. All non-zero filter fields are
+// applied as AND conditions; filter.Cursor becomes a keyset condition on
+// (name, id) and filter.Limit a LIMIT clause.
 func (s *Store) FindEntities(ctx context.Context, filter memory.EntityFilter) ([]memory.Entity, error) {
 	var args []any
 	next := func(v any) string {
@@ -128,12 +132,22 @@ func (s *Store) FindEntities(ctx context.Context, filter memory.EntityFilter) ([
 		}
 		conditions = append(conditions, "attributes @> "+next(string(attrJSON))+"::jsonb")
 	}
+	if filter.Cursor != "" {
+		after, err := memory.DecodeEntityCursor(filter.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("knowledge graph: find entities: %w", err)
+		}
+		conditions = append(conditions, "(name, id) > ("+next(after.Name)+", "+next(after.ID)+")")
+	}
 
 	q := "SELECT id, type, name, attributes, created_at, updated_at\nFROM   entities"
 	if len(conditions) > 0 {
 		q += "\nWHERE " + strings.Join(conditions, "\n  AND ")
 	}
-	q += "\nORDER BY name"
+	q += "\nORDER BY name, id"
+	if filter.Limit > 0 {
+		q += "\nLIMIT " + next(filter.Limit)
+	}
 
 	rows, err := s.pool.Query(ctx, q, args...)
 	if err != nil {
@@ -148,7 +162,8 @@ func (s *Store) FindEntities(ctx context.Context, filter memory.EntityFilter) ([
 
 // AddRelationship implements [memory.KnowledgeGraph]. It upserts a directed
 // edge between two entities. If the edge (SourceID, TargetID, RelType) already
-// exists it is completely replaced.
+// exists it is completely replaced. Returns an error wrapping
+// [memory.ErrEntityNotFound] when either endpoint entity does not exist.
 func (s *Store) AddRelationship(ctx context.Context, rel memory.Relationship) error {
 	attrsJSON, err := json.Marshal(rel.Attributes)
 	if err != nil {
@@ -174,6 +189,9 @@ func (s *Store) AddRelationship(ctx context.Context, rel memory.Relationship) er
 		attrsJSON,
 		provJSON,
 	)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("knowledge graph: add relationship %q -> %q: %w: %w", rel.SourceID, rel.TargetID, memory.ErrEntityNotFound, err)
+	}
 	if err != nil {
 		return fmt.Errorf("knowledge graph: add relationship: %w", err)
 	}
@@ -181,9 +199,12 @@ func (s *Store) AddRelationship(ctx context.Context, rel memory.Relationship) er
 }
 
 // GetRelationships implements [memory.KnowledgeGraph]. It returns relationships
-// associated with entityID. By default only outgoing edges are returned; use
+// associated with entityID, ordered by created_at and then by source, target
+// and type. By default only outgoing edges are returned; use
 // [memory.WithIncoming] to include inbound edges and [memory.WithRelTypes] to
-// filter by edge type.
+// filter by edge type. [memory.WithDMConfirmedOnly] becomes a containment
+// test on the provenance column, and a [memory.WithRelCursor] cursor a keyset
+// condition on the order.
 func (s *Store) GetRelationships(ctx context.Context, entityID string, opts ...memory.RelQueryOpt) ([]memory.Relationship, error) {
 	params := memory.ApplyRelQueryOpts(opts)
 	dirIn := params.DirectionIn
@@ -215,15 +236,25 @@ func (s *Store) GetRelationships(ctx context.Context, entityID string, opts ...m
 	if len(relTypes) > 0 {
 		conditions = append(conditions, "rel_type = ANY("+next(relTypes)+"::text[])")
 	}
+	if params.DMConfirmedOnly {
+		conditions = append(conditions, "provenance @> "+next(`{"`+provenanceConfirmedKey+`": true}`)+"::jsonb")
+	}
+	if params.Cursor != "" {
+		after, err := memory.DecodeRelationshipCursor(params.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("knowledge graph: get relationships: %w", err)
+		}
+		conditions = append(conditions, "(created_at, source_id, target_id, rel_type) > ("+
+			next(after.CreatedAt)+", "+next(after.SourceID)+", "+next(after.TargetID)+", "+next(after.RelType)+")")
+	}
 
 	q := "SELECT source_id, target_id, rel_type, attributes, provenance, created_at\n" +
 		"FROM   relationships\n" +
 		"WHERE  " + strings.Join(conditions, "\n  AND ") + "\n" +
-		"ORDER  BY created_at"
+		"ORDER  BY created_at, source_id, target_id, rel_type"
 
 	if limit > 0 {
-		args = append(args, limit)
-		q += fmt.Sprintf("\nLIMIT $%d", len(args))
+		q += "\nLIMIT " + next(limit)
 	}
 
 	rows, err := s.pool.Query(ctx, q, args...)
@@ -251,6 +282,31 @@ func (s *Store) DeleteRelationship(ctx context.Context, sourceID, targetID, relT
 	return nil
 }
 
+// provenanceConfirmedKey is the key of [memory.Provenance.DMConfirmed] in the
+// provenance column, which holds the provenance as encoded by json.Marshal.
+const provenanceConfirmedKey = "DMConfirmed"
+
+// ConfirmRelationship implements [memory.KnowledgeGraph]. It sets the
+// DMConfirmed key of the edge's provenance with a JSONB merge, so the other
+// provenance keys, including any the Go type does not know, are kept.
+// Returns an error wrapping [memory.ErrRelationshipNotFound] when the edge
+// does not exist.
+func (s *Store) ConfirmRelationship(ctx context.Context, sourceID, targetID, relType string, confirmed bool) error {
+	const q = `
+		UPDATE relationships
+		SET    provenance = provenance || jsonb_build_object($4::text, $5::boolean)
+		WHERE  source_id = $1 AND target_id = $2 AND rel_type = $3`
+
+	tag, err := s.pool.Exec(ctx, q, sourceID, targetID, relType, provenanceConfirmedKey, confirmed)
+	if err != nil {
+		return fmt.Errorf("knowledge graph: confirm relationship: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("knowledge graph: confirm relationship %q -> %q (%s): %w", sourceID, targetID, relType, memory.ErrRelationshipNotFound)
+	}
+	return nil
+}
+
 // Neighbors implements [memory.KnowledgeGraph]. It performs a bidirectional
 // breadth-first traversal from entityID up to depth hops using a PostgreSQL
 // recursive CTE and returns all reachable entities (the start entity is
@@ -446,13 +502,14 @@ func (s *Store) VisibleSubgraph(ctx context.Context, npcID string) ([]memory.Ent
 // IdentitySnapshot implements [memory.KnowledgeGraph]. It assembles a compact
 // [memory.NPCIdentity] for npcID containing the NPC's entity record, all its
 // direct relationships, and the entities those relationships reference.
+// Returns an error wrapping [memory.ErrEntityNotFound] when npcID does not exist.
 func (s *Store) IdentitySnapshot(ctx context.Context, npcID string) (*memory.NPCIdentity, error) {
 	entity, err := s.GetEntity(ctx, npcID)
 	if err != nil {
 		return nil, fmt.Errorf("knowledge graph: identity snapshot: %w", err)
 	}
 	if entity == nil {
-		return nil, fmt.Errorf("knowledge graph: identity snapshot: entity %q not found", npcID)
+		return nil, fmt.Errorf("knowledge graph: identity snapshot %q: %w", npcID, memory.ErrEntityNotFound)
 	}
 
 	rels, err := s.GetRelationships(ctx, npcID, memory.WithOutgoing(), memory.WithIncoming())
@@ -500,8 +557,20 @@ func (s *Store) IdentitySnapshot(ctx context.Context, npcID string) (*memory.NPC
 //
 // The query uses PostgreSQL full-text search (ts_rank) against chunk content,
 // scoped to chunks whose entity_id is in graphScope (or all chunks when graphScope
-// is empty). Results are returned ranked by descending relevance score.
+// is empty). Each chunk is matched with the text search configuration of its
+// own language, so the query matches word forms in whichever language the
+// chunk was written. Results are returned ranked by descending relevance score.
 func (s *Store) QueryWithContext(ctx context.Context, query string, graphScope []string) ([]memory.ContextResult, error) {
+	results, err := queryFTS(ctx, s.pool, query, graphScope, maxContextResults)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: query with context: %w", err)
+	}
+	return results, nil
+}
+
+// queryFTS runs the full-text search of [Store.QueryWithContext] on db,
+// returning at most limit results.
+func queryFTS(ctx context.Context, db dbQuerier, query string, graphScope []string, limit int) ([]memory.ContextResult, error) {
 	var args []any
 	next := func(v any) string {
 		args = append(args, v)
@@ -514,21 +583,22 @@ func (s *Store) QueryWithContext(ctx context.Context, query string, graphScope [
 	if len(graphScope) > 0 {
 		scopeFilter = "\n  AND  c.entity_id = ANY(" + next(graphScope) + "::text[])"
 	}
+	limitArg := next(limit)
 
 	q := fmt.Sprintf(`
 		SELECT e.id, e.type, e.name, e.attributes, e.created_at, e.updated_at,
 		       c.content,
-		       ts_rank(to_tsvector('english', c.content),
-		               plainto_tsquery('english', %s)) AS score
+		       ts_rank(to_tsvector(c.fts_config, c.content),
+		               plainto_tsquery(c.fts_config, %s)) AS score
 		FROM   chunks  c
 		JOIN   entities e ON e.id = c.entity_id
-		WHERE  to_tsvector('english', c.content) @@ plainto_tsquery('english', %s)%s
+		WHERE  to_tsvector(c.fts_config, c.content) @@ plainto_tsquery(c.fts_config, %s)%s
 		ORDER  BY score DESC
-		LIMIT  20`, queryArg, queryArg, scopeFilter)
+		LIMIT  %s`, queryArg, queryArg, scopeFilter, limitArg)
 
-	rows, err := s.pool.Query(ctx, q, args...)
+	rows, err := db.Query(ctx, q, args...)
 	if err != nil {
-		return nil, fmt.Errorf("knowledge graph: query with context: %w", err)
+		return nil, err
 	}
 
 	results, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (memory.ContextResult, error) {
@@ -554,7 +624,7 @@ func (s *Store) QueryWithContext(ctx context.Context, query string, graphScope [
 		return cr, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("knowledge graph: query with context: scan: %w", err)
+		return nil, fmt.Errorf("scan: %w", err)
 	}
 	if results == nil {
 		results = []memory.ContextResult{}
@@ -563,16 +633,34 @@ func (s *Store) QueryWithContext(ctx context.Context, query string, graphScope [
 }
 
 // QueryWithEmbedding implements [memory.GraphRAGQuerier]. It performs a
-// graph-augmented retrieval query using pgvector cosine similarity — the true
-// GraphRAG path. Chunks whose embeddings are closest (cosine distance) to the
-// query embedding are returned, optionally scoped to a set of entity IDs.
+// graph-augmented retrieval query using pgvector similarity — the true
+// GraphRAG path. Chunks whose embeddings are closest to the query embedding
+// by the store's distance metric (see [Store.DistanceMetric]) are returned,
+// optionally scoped to a set of entity IDs.
 //
-// Results are ranked by ascending cosine distance (most similar first). The
-// Score field is set to 1 - distance so higher scores indicate better matches,
+// Results are ranked by ascending distance (most similar first). The Score
+// field is set to [memory.DistanceMetric.Similarity] of the distance — the
+// cosine similarity by default — so higher scores indicate better matches,
 // consistent with [Store.QueryWithContext].
 //
 // topK limits the number of results. An empty graphScope searches all chunks.
+// A query embedding whose dimension differs from the stored vectors is
+// rejected with [memory.ErrEmbeddingDimMismatch] before the database is
+// queried.
 func (s *Store) QueryWithEmbedding(ctx context.Context, embedding []float32, topK int, graphScope []string) ([]memory.ContextResult, error) {
+	if err := checkDimensions(embedding, s.dims.Load()); err != nil {
+		return nil, fmt.Errorf("knowledge graph: query with embedding: %w", err)
+	}
+	results, err := s.queryVector(ctx, s.pool, embedding, topK, graphScope)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: query with embedding: %w", err)
+	}
+	return results, nil
+}
+
+// queryVector runs the vector search of [Store.QueryWithEmbedding] on db.
+// The caller must have checked the embedding's dimensions.
+func (s *Store) queryVector(ctx context.Context, db dbQuerier, embedding []float32, topK int, graphScope []string) ([]memory.ContextResult, error) {
 	queryVec := pgvector.NewVector(embedding)
 
 	args := []any{queryVec} // $1 = query embedding vector
@@ -592,16 +680,16 @@ func (s *Store) QueryWithEmbedding(ctx context.Context, embedding []float32, top
 	q := fmt.Sprintf(`
 		SELECT e.id, e.type, e.name, e.attributes, e.created_at, e.updated_at,
 		       c.content,
-		       c.embedding <=> $1 AS distance
+		       c.embedding %s $1 AS distance
 		FROM   chunks  c
 		JOIN   entities e ON e.id = c.entity_id
 		WHERE  c.embedding IS NOT NULL%s
 		ORDER  BY distance
-		LIMIT  %s`, scopeFilter, limitArg)
+		LIMIT  %s`, metricOps[s.metric].operator, scopeFilter, limitArg)
 
-	rows, err := s.pool.Query(ctx, q, args...)
+	rows, err := db.Query(ctx, q, args...)
 	if err != nil {
-		return nil, fmt.Errorf("knowledge graph: query with embedding: %w", err)
+		return nil, err
 	}
 
 	results, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (memory.ContextResult, error) {
@@ -626,11 +714,11 @@ func (s *Store) QueryWithEmbedding(ctx context.Context, embedding []float32, top
 			return memory.ContextResult{}, fmt.Errorf("unmarshal entity attributes: %w", err)
 		}
 		// Convert distance (lower = better) to score (higher = better).
-		cr.Score = 1.0 - distance
+		cr.Score = s.metric.Similarity(distance)
 		return cr, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("knowledge graph: query with embedding: scan: %w", err)
+		return nil, fmt.Errorf("scan: %w", err)
 	}
 	if results == nil {
 		results = []memory.ContextResult{}
@@ -696,18 +784,8 @@ func collectRelationships(rows pgx.Rows) ([]memory.Relationship, error) {
 		); err != nil {
 			return memory.Relationship{}, err
 		}
-		if len(attrsJSON) > 0 {
-			if err := json.Unmarshal(attrsJSON, &r.Attributes); err != nil {
-				return memory.Relationship{}, fmt.Errorf("unmarshal rel attributes: %w", err)
-			}
-		}
-		if r.Attributes == nil {
-			r.Attributes = map[string]any{}
-		}
-		if len(provJSON) > 0 {
-			if err := json.Unmarshal(provJSON, &r.Provenance); err != nil {
-				return memory.Relationship{}, fmt.Errorf("unmarshal rel provenance: %w", err)
-			}
+		if err := unmarshalRelationshipJSON(&r, attrsJSON, provJSON); err != nil {
+			return memory.Relationship{}, err
 		}
 		return r, nil
 	})
@@ -720,6 +798,25 @@ func collectRelationships(rows pgx.Rows) ([]memory.Relationship, error) {
 	return rels, nil
 }
 
+// unmarshalRelationshipJSON decodes the attributes and provenance JSONB
+// columns of a relationship row into r.
+func unmarshalRelationshipJSON(r *memory.Relationship, attrsJSON, provJSON []byte) error {
+	if len(attrsJSON) > 0 {
+		if err := json.Unmarshal(attrsJSON, &r.Attributes); err != nil {
+			return fmt.Errorf("unmarshal rel attributes: %w", err)
+		}
+	}
+	if r.Attributes == nil {
+		r.Attributes = map[string]any{}
+	}
+	if len(provJSON) > 0 {
+		if err := json.Unmarshal(provJSON, &r.Provenance); err != nil {
+			return fmt.Errorf("unmarshal rel provenance: %w", err)
+		}
+	}
+	return nil
+}
+
 // fetchEntitiesIn returns entities whose IDs are in the provided list.
 func (s *Store) fetchEntitiesIn(ctx context.Context, ids []string) ([]memory.Entity, error) {
 	if len(ids) == 0 {
@@ -762,6 +859,16 @@ func (s *Store) fetchEntitiesOrdered(ctx context.Context, ids []string) ([]memor
 	return ordered, nil
 }
 
+// isForeignKeyViolation reports whether err is a PostgreSQL
+// foreign-key-violation (SQLSTATE 23503).
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23503"
+	}
+	return false
+}
+
 // isNoRows reports whether err is the pgx "no rows" sentinel.
 func isNoRows(err error) bool {
 	return errors.Is(err, pgx.ErrNoRows)
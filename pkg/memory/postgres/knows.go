@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// Compile-time assertion that Store satisfies the memory.KnowledgeQuerier interface.
+var _ memory.KnowledgeQuerier = (*Store)(nil)
+
+// WhoKnows implements [memory.KnowledgeQuerier]. A single query collects the
+// NPC at the other end of every relationship touching targetID.
+func (s *Store) WhoKnows(ctx context.Context, targetID string) ([]string, error) {
+	const q = `
+		SELECT DISTINCT e.id
+		FROM   relationships r
+		JOIN   entities      e ON e.id = CASE WHEN r.source_id = $1 THEN r.target_id ELSE r.source_id END
+		WHERE  (r.source_id = $1 OR r.target_id = $1)
+		  AND  e.type = $2
+		  AND  e.id <> $1
+		ORDER  BY e.id`
+
+	rows, err := s.pool.Query(ctx, q, targetID, memory.EntityTypeNPC)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: who knows: %w", err)
+	}
+	ids, err := collectIDs(rows)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: who knows: %w", err)
+	}
+	return ids, nil
+}
+
+// EntitiesKnownBy implements [memory.KnowledgeQuerier]. A single query
+// collects the entity at the other end of every relationship touching npcID;
+// relationships to missing entities are skipped.
+func (s *Store) EntitiesKnownBy(ctx context.Context, npcID string) ([]string, error) {
+	const q = `
+		SELECT DISTINCT e.id
+		FROM   relationships r
+		JOIN   entities      e ON e.id = CASE WHEN r.source_id = $1 THEN r.target_id ELSE r.source_id END
+		WHERE  (r.source_id = $1 OR r.target_id = $1)
+		  AND  e.id <> $1
+		ORDER  BY e.id`
+
+	rows, err := s.pool.Query(ctx, q, npcID)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: entities known by: %w", err)
+	}
+	ids, err := collectIDs(rows)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge graph: entities known by: %w", err)
+	}
+	return ids, nil
+}
+
+// collectIDs scans rows of a single text column into a non-nil slice.
+func collectIDs(rows pgx.Rows) ([]string, error) {
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	return ids, nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// DefaultLanguage is the language assumed for entries, chunks and queries
+// without one, unless [WithDefaultLanguage] says otherwise. It matches the
+// English-only full-text search of earlier schema versions.
+const DefaultLanguage = "en"
+
+// textSearchConfigs maps primary language subtags to the built-in PostgreSQL
+// text search configurations available since PostgreSQL 12.
+var textSearchConfigs = map[string]string{
+	"ar": "arabic",
+	"da": "danish",
+	"de": "german",
+	"en": "english",
+	"es": "spanish",
+	"fi": "finnish",
+	"fr": "french",
+	"ga": "irish",
+	"hu": "hungarian",
+	"id": "indonesian",
+	"it": "italian",
+	"lt": "lithuanian",
+	"nb": "norwegian",
+	"ne": "nepali",
+	"nl": "dutch",
+	"nn": "norwegian",
+	"no": "norwegian",
+	"pt": "portuguese",
+	"ro": "romanian",
+	"ru": "russian",
+	"sr": "serbian",
+	"sv": "swedish",
+	"ta": "tamil",
+	"tr": "turkish",
+}
+
+// TextSearchConfig returns the PostgreSQL text search configuration for the
+// BCP-47 tag lang ("de-DE" → "german"). Languages without a stemming
+// configuration use "simple", which matches words without stemming. An empty
+// lang returns "".
+func TextSearchConfig(lang string) string {
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		return ""
+	}
+	base, _, _ := strings.Cut(lang, "-")
+	base, _, _ = strings.Cut(base, "_")
+	if cfg, ok := textSearchConfigs[strings.ToLower(base)]; ok {
+		return cfg
+	}
+	return "simple"
+}
+
+// Option configures a [Store].
+type Option func(*storeOptions)
+
+// storeOptions holds the settings applied by [Option] values.
+type storeOptions struct {
+	ftsConfig string                // text search configuration for untagged text
+	metric    memory.DistanceMetric // metric embeddings are compared by
+}
+
+// applyOptions returns the settings of opts on top of the defaults. It fails
+// if they name an unknown distance metric.
+func applyOptions(opts []Option) (storeOptions, error) {
+	o := storeOptions{ftsConfig: TextSearchConfig(DefaultLanguage), metric: memory.MetricCosine}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !o.metric.IsValid() {
+		return storeOptions{}, fmt.Errorf("unknown distance metric %q; valid values: %s, %s, %s",
+			o.metric, memory.MetricCosine, memory.MetricL2, memory.MetricInnerProduct)
+	}
+	return o, nil
+}
+
+// WithDefaultLanguage sets the BCP-47 tag of the language assumed for
+// transcript entries, chunks and queries that carry none. The default is
+// [DefaultLanguage]. An empty lang is ignored.
+func WithDefaultLanguage(lang string) Option {
+	return func(o *storeOptions) {
+		if cfg := TextSearchConfig(lang); cfg != "" {
+			o.ftsConfig = cfg
+		}
+	}
+}
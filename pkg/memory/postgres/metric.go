@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// Compile-time assertions that the L2 index and the GraphRAG querier report
+// their distance metric.
+var (
+	_ memory.MetricReporter = (*SemanticIndexImpl)(nil)
+	_ memory.MetricReporter = (*Store)(nil)
+)
+
+// vectorOps are the pgvector distance operator and HNSW operator class of a
+// [memory.DistanceMetric].
+type vectorOps struct {
+	operator string
+	opclass  string
+}
+
+// metricOps maps every supported metric to its pgvector operators.
+var metricOps = map[memory.DistanceMetric]vectorOps{
+	memory.MetricCosine:       {operator: "<=>", opclass: "vector_cosine_ops"},
+	memory.MetricL2:           {operator: "<->", opclass: "vector_l2_ops"},
+	memory.MetricInnerProduct: {operator: "<#>", opclass: "vector_ip_ops"},
+}
+
+// WithDistanceMetric sets the metric embeddings are compared by. The default
+// is [memory.MetricCosine]. An empty metric is ignored.
+//
+// The metric picks the operator class of the HNSW index that [Migrate]
+// creates and the distance operator of [SemanticIndexImpl.Search] and
+// [Store.QueryWithEmbedding]. An existing index keeps the operator class it
+// was built with, so changing the metric of a populated database requires
+// re-creating the index: drop it with
+//
+//	DROP INDEX idx_chunks_embedding;
+//
+// and open the store again. Until then [NewStore] fails with an error
+// wrapping [memory.ErrDistanceMetricMismatch].
+func WithDistanceMetric(m memory.DistanceMetric) Option {
+	return func(o *storeOptions) {
+		if m != "" {
+			o.metric = m
+		}
+	}
+}
+
+// DistanceMetric implements [memory.MetricReporter]. It returns the metric
+// [SemanticIndexImpl.Search] ranks chunks by, and so the scale of
+// [memory.ChunkResult.Distance].
+func (s *SemanticIndexImpl) DistanceMetric() memory.DistanceMetric { return s.metric }
+
+// DistanceMetric implements [memory.MetricReporter]. It returns the metric
+// [Store.QueryWithEmbedding] ranks chunks by; scores are converted from it
+// with [memory.DistanceMetric.Similarity].
+func (s *Store) DistanceMetric() memory.DistanceMetric { return s.metric }
+
+// checkIndexMetric returns an error wrapping [memory.ErrDistanceMetricMismatch]
+// if the embedding index was built for another metric than want. A missing
+// index, or one with an operator class of no known metric, is accepted.
+func checkIndexMetric(ctx context.Context, pool *pgxpool.Pool, want memory.DistanceMetric) error {
+	const q = `
+		SELECT opc.opcname
+		FROM   pg_index    i
+		JOIN   pg_class    c   ON c.oid = i.indexrelid
+		JOIN   pg_opclass  opc ON opc.oid = i.indclass[0]
+		WHERE  c.relname = 'idx_chunks_embedding'`
+
+	var opclass string
+	err := pool.QueryRow(ctx, q).Scan(&opclass)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read embedding index operator class: %w", err)
+	}
+	for m, ops := range metricOps {
+		if ops.opclass == opclass && m != want {
+			return fmt.Errorf("embedding index was built for the %s metric, store is configured for %s; drop index idx_chunks_embedding to re-create it: %w",
+				m, want, memory.ErrDistanceMetricMismatch)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/memory"
+)
+
+// Compile-time assertion that SessionStoreImpl satisfies the
+// memory.TranscriptPruner interface.
+var _ memory.TranscriptPruner = (*SessionStoreImpl)(nil)
+
+// PruneTranscripts implements [memory.TranscriptPruner]. It deletes the rows
+// of session_entries older than cutoff that are covered by a row of the
+// chunks table: one of the same session with an equal or later timestamp.
+// The check and the delete run in a single statement, so a session's newest
+// chunk is read at the moment its entries are removed.
+func (s *SessionStoreImpl) PruneTranscripts(ctx context.Context, cutoff time.Time) (int, error) {
+	const q = `
+		DELETE FROM session_entries e
+		WHERE  e.timestamp < $1
+		  AND  EXISTS (
+		           SELECT 1
+		           FROM   chunks c
+		           WHERE  c.session_id = e.session_id
+		             AND  c.timestamp >= e.timestamp)`
+
+	tag, err := s.pool.Exec(ctx, q, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("session store: prune transcripts: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -44,9 +44,22 @@ CREATE TABLE IF NOT EXISTS session_entries (
     raw_text     TEXT         NOT NULL DEFAULT '',
     npc_id       TEXT         NOT NULL DEFAULT '',
     timestamp    TIMESTAMPTZ  NOT NULL DEFAULT now(),
-    duration_ns  BIGINT       NOT NULL DEFAULT 0
+    duration_ns  BIGINT       NOT NULL DEFAULT 0,
+    language     TEXT         NOT NULL DEFAULT '',
+    fts_config   REGCONFIG    NOT NULL DEFAULT 'english',
+    words        JSONB
 );
 
+-- Language tagging: language is the entry's BCP-47 tag, fts_config the text
+-- search configuration its text is indexed with. Rows written before the
+-- columns existed were indexed as English.
+ALTER TABLE session_entries ADD COLUMN IF NOT EXISTS language   TEXT      NOT NULL DEFAULT '';
+ALTER TABLE session_entries ADD COLUMN IF NOT EXISTS fts_config REGCONFIG NOT NULL DEFAULT 'english';
+
+-- Word timings: a JSON array of {word, start_ns, end_ns} objects, NULL when
+-- the STT provider reported none.
+ALTER TABLE session_entries ADD COLUMN IF NOT EXISTS words JSONB;
+
 CREATE INDEX IF NOT EXISTS idx_session_entries_session_id
     ON session_entries (session_id);
 
@@ -56,8 +69,13 @@ CREATE INDEX IF NOT EXISTS idx_session_entries_timestamp
 CREATE INDEX IF NOT EXISTS idx_session_entries_session_timestamp
     ON session_entries (session_id, timestamp);
 
-CREATE INDEX IF NOT EXISTS idx_session_entries_fts
-    ON session_entries USING GIN (to_tsvector('english', text));
+CREATE INDEX IF NOT EXISTS idx_session_entries_speaker_timestamp
+    ON session_entries (speaker_id, timestamp);
+
+DROP INDEX IF EXISTS idx_session_entries_fts;
+
+CREATE INDEX IF NOT EXISTS idx_session_entries_fts_lang
+    ON session_entries USING GIN (to_tsvector(fts_config, text));
 `
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -100,9 +118,10 @@ CREATE INDEX IF NOT EXISTS idx_rel_provenance_confidence
     ON relationships ((provenance->>'confidence'));
 `
 
-// ddlL2 returns the L2 DDL with the embedding dimension substituted.
-// The vector dimension is baked into the column type at schema creation time.
-func ddlL2(embeddingDimensions int) string {
+// ddlL2 returns the L2 DDL with the embedding dimension and the operator
+// class of the embedding index substituted. The vector dimension is baked into
+// the column type at schema creation time.
+func ddlL2(embeddingDimensions int, opclass string) string {
 	return fmt.Sprintf(`
 CREATE EXTENSION IF NOT EXISTS vector;
 
@@ -114,15 +133,30 @@ CREATE TABLE IF NOT EXISTS chunks (
     speaker_id  TEXT         NOT NULL DEFAULT '',
     entity_id   TEXT         NOT NULL DEFAULT '',
     topic       TEXT         NOT NULL DEFAULT '',
-    timestamp   TIMESTAMPTZ  NOT NULL DEFAULT now()
+    timestamp   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    language    TEXT         NOT NULL DEFAULT '',
+    fts_config  REGCONFIG    NOT NULL DEFAULT 'english'
 );
 
+ALTER TABLE chunks ADD COLUMN IF NOT EXISTS language   TEXT      NOT NULL DEFAULT '';
+ALTER TABLE chunks ADD COLUMN IF NOT EXISTS fts_config REGCONFIG NOT NULL DEFAULT 'english';
+
 CREATE INDEX IF NOT EXISTS idx_chunks_session_id
     ON chunks (session_id);
 
+CREATE INDEX IF NOT EXISTS idx_chunks_fts
+    ON chunks USING GIN (to_tsvector(fts_config, content));
+
 CREATE INDEX IF NOT EXISTS idx_chunks_embedding
-    ON chunks USING hnsw (embedding vector_cosine_ops);
-`, embeddingDimensions)
+    ON chunks USING hnsw (embedding %s);
+
+CREATE TABLE IF NOT EXISTS embedding_meta (
+    id          BOOLEAN      PRIMARY KEY DEFAULT TRUE CHECK (id),
+    model       TEXT         NOT NULL DEFAULT '',
+    dimensions  INT          NOT NULL,
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+`, embeddingDimensions, opclass)
 }
 
 // Migrate creates or ensures all required database tables and extensions exist.
@@ -131,11 +165,22 @@ CREATE INDEX IF NOT EXISTS idx_chunks_embedding
 //
 // embeddingDimensions must match the vector model configured for your deployment
 // (e.g., 1536 for OpenAI text-embedding-3-small, 768 for nomic-embed-text).
-// Changing this value after the first migration requires a manual schema update.
-func Migrate(ctx context.Context, pool *pgxpool.Pool, embeddingDimensions int) error {
+// It only takes effect when the chunks table is created; switching models
+// later is done with [Store.ReembedAll].
+//
+// The embedding index is created for the metric set with [WithDistanceMetric]
+// (cosine by default); other options are ignored. If the index already exists
+// for another metric, Migrate returns an error wrapping
+// [memory.ErrDistanceMetricMismatch].
+func Migrate(ctx context.Context, pool *pgxpool.Pool, embeddingDimensions int, opts ...Option) error {
+	o, err := applyOptions(opts)
+	if err != nil {
+		return fmt.Errorf("postgres migrate: %w", err)
+	}
+
 	statements := []string{
 		ddlSessionEntries,
-		ddlL2(embeddingDimensions),
+		ddlL2(embeddingDimensions, metricOps[o.metric].opclass),
 		ddlKnowledgeGraph,
 	}
 
@@ -144,5 +189,8 @@ func Migrate(ctx context.Context, pool *pgxpool.Pool, embeddingDimensions int) e
 			return fmt.Errorf("postgres migrate: %w", err)
 		}
 	}
+	if err := checkIndexMetric(ctx, pool, o.metric); err != nil {
+		return fmt.Errorf("postgres migrate: %w", err)
+	}
 	return nil
 }
@@ -1,9 +1,11 @@
 package postgres
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"strings"
+	"sync/atomic"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -18,17 +20,27 @@ import (
 // Obtain one via [Store.L2] rather than constructing directly.
 // All methods are safe for concurrent use.
 type SemanticIndexImpl struct {
-	pool *pgxpool.Pool
+	pool      *pgxpool.Pool
+	dims      *atomic.Int64 // owned by the Store; see [Store.ReembedAll]
+	ftsConfig string        // text search configuration for untagged chunks
+	metric    memory.DistanceMetric
 }
 
 // IndexChunk implements [memory.SemanticIndex]. It upserts a pre-embedded
 // [memory.Chunk] into the chunks table. If a chunk with the same ID already
-// exists it is completely replaced.
+// exists it is completely replaced. An embedding whose dimension differs from
+// the stored vectors is rejected with [memory.ErrEmbeddingDimMismatch]. The
+// content is indexed for full-text search with the text search configuration
+// of chunk.Language, or of the store's default language if it has none.
 func (s *SemanticIndexImpl) IndexChunk(ctx context.Context, chunk memory.Chunk) error {
+	if err := checkDimensions(chunk.Embedding, s.dims.Load()); err != nil {
+		return fmt.Errorf("semantic index: index chunk %q: %w", chunk.ID, err)
+	}
+
 	const q = `
 		INSERT INTO chunks
-		    (id, session_id, content, embedding, speaker_id, entity_id, topic, timestamp)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		    (id, session_id, content, embedding, speaker_id, entity_id, topic, timestamp, language, fts_config)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::regconfig)
 		ON CONFLICT (id) DO UPDATE SET
 		    session_id  = EXCLUDED.session_id,
 		    content     = EXCLUDED.content,
@@ -36,7 +48,9 @@ func (s *SemanticIndexImpl) IndexChunk(ctx context.Context, chunk memory.Chunk)
 		    speaker_id  = EXCLUDED.speaker_id,
 		    entity_id   = EXCLUDED.entity_id,
 		    topic       = EXCLUDED.topic,
-		    timestamp   = EXCLUDED.timestamp`
+		    timestamp   = EXCLUDED.timestamp,
+		    language    = EXCLUDED.language,
+		    fts_config  = EXCLUDED.fts_config`
 
 	vec := pgvector.NewVector(chunk.Embedding)
 	_, err := s.pool.Exec(ctx, q,
@@ -48,6 +62,8 @@ func (s *SemanticIndexImpl) IndexChunk(ctx context.Context, chunk memory.Chunk)
 		chunk.EntityID,
 		chunk.Topic,
 		chunk.Timestamp,
+		chunk.Language,
+		cmp.Or(TextSearchConfig(chunk.Language), s.ftsConfig),
 	)
 	if err != nil {
 		return fmt.Errorf("semantic index: index chunk: %w", err)
@@ -56,11 +72,18 @@ func (s *SemanticIndexImpl) IndexChunk(ctx context.Context, chunk memory.Chunk)
 }
 
 // Search implements [memory.SemanticIndex]. It finds the topK chunks whose
-// embeddings are closest (cosine distance) to the supplied query embedding,
-// optionally filtered by filter.
+// embeddings are closest to the supplied query embedding by the store's
+// distance metric (see [SemanticIndexImpl.DistanceMetric]), optionally
+// filtered by filter.
 //
-// Results are ordered by ascending cosine distance (most similar first).
+// Results are ordered by ascending distance (most similar first). A
+// query embedding whose dimension differs from the stored vectors is rejected
+// with [memory.ErrEmbeddingDimMismatch] before the database is queried.
 func (s *SemanticIndexImpl) Search(ctx context.Context, embedding []float32, topK int, filter memory.ChunkFilter) ([]memory.ChunkResult, error) {
+	if err := checkDimensions(embedding, s.dims.Load()); err != nil {
+		return nil, fmt.Errorf("semantic index: search: %w", err)
+	}
+
 	queryVec := pgvector.NewVector(embedding)
 
 	args := []any{queryVec} // $1 = query vector
@@ -95,12 +118,12 @@ func (s *SemanticIndexImpl) Search(ctx context.Context, embedding []float32, top
 	limitArg := fmt.Sprintf("$%d", len(args))
 
 	q := fmt.Sprintf(`
-		SELECT id, session_id, content, embedding, speaker_id, entity_id, topic, timestamp,
-		       embedding <=> $1 AS distance
+		SELECT id, session_id, content, embedding, speaker_id, entity_id, topic, timestamp, language,
+		       embedding %s $1 AS distance
 		FROM   chunks
 		%s
 		ORDER  BY distance
-		LIMIT  %s`, whereClause, limitArg)
+		LIMIT  %s`, metricOps[s.metric].operator, whereClause, limitArg)
 
 	rows, err := s.pool.Query(ctx, q, args...)
 	if err != nil {
@@ -121,6 +144,7 @@ func (s *SemanticIndexImpl) Search(ctx context.Context, embedding []float32, top
 			&cr.Chunk.EntityID,
 			&cr.Chunk.Topic,
 			&cr.Chunk.Timestamp,
+			&cr.Chunk.Language,
 			&cr.Distance,
 		); err != nil {
 			return memory.ChunkResult{}, err
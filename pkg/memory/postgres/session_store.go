@@ -1,8 +1,11 @@
 package postgres
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,18 +21,26 @@ import (
 // Obtain one via [Store.L1] rather than constructing directly.
 // All methods are safe for concurrent use.
 type SessionStoreImpl struct {
-	pool *pgxpool.Pool
+	pool      *pgxpool.Pool
+	ftsConfig string // text search configuration for untagged entries
 }
 
 // WriteEntry implements [memory.SessionStore]. It appends entry to the
-// session_entries table under sessionID.
+// session_entries table under sessionID. The text is indexed with the text
+// search configuration of entry.Language, or of the store's default language
+// if the entry has none.
 func (s *SessionStoreImpl) WriteEntry(ctx context.Context, sessionID string, entry memory.TranscriptEntry) error {
+	words, err := encodeWords(entry.Words)
+	if err != nil {
+		return fmt.Errorf("session store: write entry: %w", err)
+	}
+
 	const q = `
 		INSERT INTO session_entries
-		    (session_id, speaker_id, speaker_name, text, raw_text, npc_id, timestamp, duration_ns)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+		    (session_id, speaker_id, speaker_name, text, raw_text, npc_id, timestamp, duration_ns, language, fts_config, words)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::regconfig, $11::jsonb)`
 
-	_, err := s.pool.Exec(ctx, q,
+	_, err = s.pool.Exec(ctx, q,
 		sessionID,
 		entry.SpeakerID,
 		entry.SpeakerName,
@@ -38,6 +49,9 @@ func (s *SessionStoreImpl) WriteEntry(ctx context.Context, sessionID string, ent
 		entry.NPCID,
 		entry.Timestamp,
 		entry.Duration.Nanoseconds(),
+		entry.Language,
+		cmp.Or(TextSearchConfig(entry.Language), s.ftsConfig),
+		words,
 	)
 	if err != nil {
 		return fmt.Errorf("session store: write entry: %w", err)
@@ -50,7 +64,7 @@ func (s *SessionStoreImpl) WriteEntry(ctx context.Context, sessionID string, ent
 // chronologically (oldest first).
 func (s *SessionStoreImpl) GetRecent(ctx context.Context, sessionID string, duration time.Duration) ([]memory.TranscriptEntry, error) {
 	const q = `
-		SELECT speaker_id, speaker_name, text, raw_text, npc_id, timestamp, duration_ns
+		SELECT speaker_id, speaker_name, text, raw_text, npc_id, timestamp, duration_ns, language, words
 		FROM   session_entries
 		WHERE  session_id = $1
 		  AND  timestamp  >= now() - ($2::bigint * interval '1 microsecond')
@@ -67,15 +81,30 @@ func (s *SessionStoreImpl) GetRecent(ctx context.Context, sessionID string, dura
 // search over the text column and applies optional filters from opts.
 //
 // The query is passed to plainto_tsquery so no special operator syntax is required.
+// It is parsed with the text search configuration of opts.Language; without
+// one, each entry is matched with the configuration of its own language.
+// An empty query skips the text match. The text match and all filters form a
+// single WHERE clause, so the planner can combine the full-text GIN index with
+// the session, speaker and timestamp b-tree indexes instead of filtering rows
+// after the fact.
 func (s *SessionStoreImpl) Search(ctx context.Context, query string, opts memory.SearchOpts) ([]memory.TranscriptEntry, error) {
-	args := []any{query} // $1 = FTS query string
+	if opts.NPCOnly && opts.PlayersOnly {
+		return nil, fmt.Errorf("session store: search: NPCOnly and PlayersOnly are mutually exclusive")
+	}
+
+	var args []any
 	next := func(v any) string {
 		args = append(args, v)
 		return fmt.Sprintf("$%d", len(args))
 	}
 
-	conditions := []string{
-		"to_tsvector('english', text) @@ plainto_tsquery('english', $1)",
+	var conditions []string
+	if query != "" {
+		config := "fts_config"
+		if opts.Language != "" {
+			config = next(TextSearchConfig(opts.Language)) + "::regconfig"
+		}
+		conditions = append(conditions, "to_tsvector(fts_config, text) @@ plainto_tsquery("+config+", "+next(query)+")")
 	}
 	if opts.SessionID != "" {
 		conditions = append(conditions, "session_id = "+next(opts.SessionID))
@@ -89,22 +118,40 @@ func (s *SessionStoreImpl) Search(ctx context.Context, query string, opts memory
 	if opts.SpeakerID != "" {
 		conditions = append(conditions, "speaker_id = "+next(opts.SpeakerID))
 	}
+	if opts.NPCOnly {
+		conditions = append(conditions, "npc_id <> ''")
+	}
+	if opts.PlayersOnly {
+		conditions = append(conditions, "npc_id = ''")
+	}
 
-	q := "SELECT speaker_id, speaker_name, text, raw_text, npc_id, timestamp, duration_ns\n" +
-		"FROM   session_entries\n" +
-		"WHERE  " + strings.Join(conditions, "\n  AND  ") + "\n" +
-		"ORDER  BY timestamp"
+	q := "SELECT speaker_id, speaker_name, text, raw_text, npc_id, timestamp, duration_ns, language, words\n" +
+		"FROM   session_entries\n"
+	if len(conditions) > 0 {
+		q += "WHERE  " + strings.Join(conditions, "\n  AND  ") + "\n"
+	}
+	q += "ORDER  BY timestamp"
 
+	latest := opts.Latest && opts.Limit > 0
+	if latest {
+		q += " DESC"
+	}
 	if opts.Limit > 0 {
-		args = append(args, opts.Limit)
-		q += fmt.Sprintf("\nLIMIT $%d", len(args))
+		q += "\nLIMIT " + next(opts.Limit)
 	}
 
 	rows, err := s.pool.Query(ctx, q, args...)
 	if err != nil {
 		return nil, fmt.Errorf("session store: search: %w", err)
 	}
-	return collectEntries(rows)
+	entries, err := collectEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if latest {
+		slices.Reverse(entries)
+	}
+	return entries, nil
 }
 
 // EntryCount implements [memory.SessionStore]. It returns the total number of
@@ -119,12 +166,26 @@ func (s *SessionStoreImpl) EntryCount(ctx context.Context, sessionID string) (in
 	return count, nil
 }
 
+// encodeWords returns words as a JSON array for the words column, or nil
+// (stored as NULL) if there are none.
+func encodeWords(words []memory.WordTiming) ([]byte, error) {
+	if len(words) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(words)
+	if err != nil {
+		return nil, fmt.Errorf("encode words: %w", err)
+	}
+	return b, nil
+}
+
 // collectEntries scans pgx rows into a slice of TranscriptEntry values.
 func collectEntries(rows pgx.Rows) ([]memory.TranscriptEntry, error) {
 	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (memory.TranscriptEntry, error) {
 		var (
 			e          memory.TranscriptEntry
 			durationNS int64
+			words      []byte
 		)
 		if err := row.Scan(
 			&e.SpeakerID,
@@ -134,10 +195,17 @@ func collectEntries(rows pgx.Rows) ([]memory.TranscriptEntry, error) {
 			&e.NPCID,
 			&e.Timestamp,
 			&durationNS,
+			&e.Language,
+			&words,
 		); err != nil {
 			return memory.TranscriptEntry{}, err
 		}
 		e.Duration = time.Duration(durationNS)
+		if words != nil {
+			if err := json.Unmarshal(words, &e.Words); err != nil {
+				return memory.TranscriptEntry{}, fmt.Errorf("decode words: %w", err)
+			}
+		}
 		return e, nil
 	})
 	if err != nil {
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -39,6 +40,14 @@ type Store struct {
 	pool     *pgxpool.Pool
 	sessions *SessionStoreImpl
 	semantic *SemanticIndexImpl
+
+	// dims is the vector size of the chunks.embedding column, shared with
+	// the L2 index. It changes only when [Store.ReembedAll] migrates the column.
+	dims atomic.Int64
+
+	// metric is the distance metric embeddings are compared by, shared with
+	// the L2 index. See [WithDistanceMetric].
+	metric memory.DistanceMetric
 }
 
 // NewStore creates a new Store, establishes a connection pool to the PostgreSQL
@@ -47,9 +56,22 @@ type Store struct {
 //
 // embeddingDimensions must match the output dimension of the embedding model
 // used to produce [memory.Chunk.Embedding] values (e.g., 1536 for OpenAI
-// text-embedding-3-small). Changing this value after the first migration
-// requires a manual schema change.
-func NewStore(ctx context.Context, dsn string, embeddingDimensions int) (*Store, error) {
+// text-embedding-3-small). It sizes the embeddings column when it is first
+// created; an existing column keeps its size, and embeddings that do not fit
+// it are rejected with [memory.ErrEmbeddingDimMismatch]. Use
+// [Store.BindEmbeddingModel] to verify the configured model at startup and
+// [Store.ReembedAll] to switch models.
+//
+// Full-text search uses the text search configuration matching each entry's
+// and chunk's language; see [WithDefaultLanguage] for text without one.
+// Embeddings are compared by cosine distance unless [WithDistanceMetric]
+// selects another metric.
+func NewStore(ctx context.Context, dsn string, embeddingDimensions int, opts ...Option) (*Store, error) {
+	o, err := applyOptions(opts)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: %w", err)
+	}
+
 	cfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("postgres store: parse dsn: %w", err)
@@ -71,16 +93,22 @@ func NewStore(ctx context.Context, dsn string, embeddingDimensions int) (*Store,
 		return nil, fmt.Errorf("postgres store: ping: %w", err)
 	}
 
-	if err := Migrate(ctx, pool, embeddingDimensions); err != nil {
+	if err := Migrate(ctx, pool, embeddingDimensions, opts...); err != nil {
 		pool.Close()
 		return nil, fmt.Errorf("postgres store: migrate: %w", err)
 	}
 
-	return &Store{
+	s := &Store{
 		pool:     pool,
-		sessions: &SessionStoreImpl{pool: pool},
-		semantic: &SemanticIndexImpl{pool: pool},
-	}, nil
+		sessions: &SessionStoreImpl{pool: pool, ftsConfig: o.ftsConfig},
+		metric:   o.metric,
+	}
+	s.semantic = &SemanticIndexImpl{pool: pool, dims: &s.dims, ftsConfig: o.ftsConfig, metric: o.metric}
+	if err := s.loadEmbeddingMeta(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("postgres store: %w", err)
+	}
+	return s, nil
 }
 
 // L1 returns the L1 session log implementation which satisfies [memory.SessionStore].
@@ -89,6 +117,10 @@ func (s *Store) L1() *SessionStoreImpl { return s.sessions }
 // L2 returns the L2 semantic index implementation which satisfies [memory.SemanticIndex].
 func (s *Store) L2() *SemanticIndexImpl { return s.semantic }
 
+// Pool returns the connection pool shared by all layers, for application
+// tables that live in the same database as the memory store.
+func (s *Store) Pool() *pgxpool.Pool { return s.pool }
+
 // Close releases all connections held by the underlying connection pool.
 // It should be called when the Store is no longer needed, typically via defer.
 func (s *Store) Close() {
@@ -2,6 +2,8 @@ package postgres_test
 
 import (
 	"context"
+	"errors"
+	"math"
 	"os"
 	"slices"
 	"strings"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/MrWong99/glyphoxa/pkg/memory"
 	"github.com/MrWong99/glyphoxa/pkg/memory/postgres"
+	embeddingsmock "github.com/MrWong99/glyphoxa/pkg/provider/embeddings/mock"
 )
 
 const testEmbeddingDim = 4
@@ -31,7 +34,7 @@ func testDSN(t *testing.T) string {
 
 // newTestStore creates a fresh [postgres.Store] with a clean schema.
 // It calls t.Cleanup to close the store when the test finishes.
-func newTestStore(t *testing.T) *postgres.Store {
+func newTestStore(t *testing.T, opts ...postgres.Option) *postgres.Store {
 	t.Helper()
 	dsn := testDSN(t)
 	ctx := context.Background()
@@ -41,7 +44,7 @@ func newTestStore(t *testing.T) *postgres.Store {
 	t.Cleanup(cleanPool.Close)
 	dropSchema(t, ctx, cleanPool)
 
-	store, err := postgres.NewStore(ctx, dsn, testEmbeddingDim)
+	store, err := postgres.NewStore(ctx, dsn, testEmbeddingDim, opts...)
 	if err != nil {
 		t.Fatalf("NewStore: %v", err)
 	}
@@ -77,6 +80,7 @@ func dropSchema(t *testing.T, ctx context.Context, pool *pgxpool.Pool) {
 		"DROP TABLE IF EXISTS entities CASCADE",
 		"DROP TABLE IF EXISTS chunks CASCADE",
 		"DROP TABLE IF EXISTS session_entries CASCADE",
+		"DROP TABLE IF EXISTS embedding_meta CASCADE",
 	} {
 		if _, err := pool.Exec(ctx, stmt); err != nil {
 			t.Fatalf("dropSchema %q: %v", stmt, err)
@@ -103,6 +107,13 @@ func TestL1_WriteAndGetRecent(t *testing.T) {
 			RawText:     "I approach the blacksmith cautiously.",
 			Timestamp:   now.Add(-10 * time.Minute),
 			Duration:    2 * time.Second,
+			Words: []memory.WordTiming{
+				{Word: "I", Start: 0, End: 120 * time.Millisecond},
+				{Word: "approach", Start: 120 * time.Millisecond, End: 600 * time.Millisecond},
+				{Word: "the", Start: 600 * time.Millisecond, End: 700 * time.Millisecond},
+				{Word: "blacksmith", Start: 700 * time.Millisecond, End: 1300 * time.Millisecond},
+				{Word: "cautiously.", Start: 1300 * time.Millisecond, End: 2 * time.Second},
+			},
 		},
 		{
 			SpeakerID:   "npc-grimjaw",
@@ -161,6 +172,16 @@ func TestL1_WriteAndGetRecent(t *testing.T) {
 	if len(recent) > 0 && recent[0].Duration != entries[0].Duration {
 		t.Errorf("Duration: want %v, got %v", entries[0].Duration, recent[0].Duration)
 	}
+
+	// Word timings are round-tripped; entries without them stay nil.
+	if len(recent) == 3 {
+		if !slices.Equal(recent[0].Words, entries[0].Words) {
+			t.Errorf("Words: want %v, got %v", entries[0].Words, recent[0].Words)
+		}
+		if recent[1].Words != nil {
+			t.Errorf("Words of entry without timings: want nil, got %v", recent[1].Words)
+		}
+	}
 }
 
 func TestL1_Search(t *testing.T) {
@@ -234,6 +255,345 @@ func TestL1_Search(t *testing.T) {
 	}
 }
 
+func TestTextSearchConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{lang: "de-DE", want: "german"},
+		{lang: "en", want: "english"},
+		{lang: "pt_BR", want: "portuguese"},
+		{lang: "NB", want: "norwegian"},
+		{lang: "ja", want: "simple"},
+		{lang: "", want: ""},
+	}
+	for _, tc := range tests {
+		if got := postgres.TextSearchConfig(tc.lang); got != tc.want {
+			t.Errorf("TextSearchConfig(%q) = %q, want %q", tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestL1_SearchLanguage(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	l1 := store.L1()
+
+	// German stemming reduces "Schwerter" and "Schwert" to the same stem;
+	// English stemming leaves "schwerter" alone.
+	sessionID := "language-session"
+	writeL1Entries(t, ctx, l1, sessionID, []memory.TranscriptEntry{
+		{SpeakerID: "p1", Language: "de-DE", Text: "Die Zwerge schmiedeten mächtige Schwerter in den Bergen."},
+		{SpeakerID: "p2", Text: "Die Elfen kauften die Schwerter der Zwerge."},
+		{SpeakerID: "p3", Language: "en-US", Text: "The dwarves forged mighty swords in the mountains."},
+	})
+
+	tests := []struct {
+		name         string
+		query        string
+		language     string
+		wantSpeakers []string
+	}{
+		{name: "german query", query: "Schwert", language: "de", wantSpeakers: []string{"p1"}},
+		{name: "german stop words", query: "den Bergen", language: "de-AT", wantSpeakers: []string{"p1"}},
+		{name: "per-entry language", query: "Schwert", wantSpeakers: []string{"p1"}},
+		{name: "english query", query: "sword", language: "en", wantSpeakers: []string{"p3"}},
+		// Untagged entries are indexed as English, where "Schwerter" keeps its
+		// full form.
+		{name: "english query on untagged entry", query: "Schwerter", language: "en", wantSpeakers: []string{"p2"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := l1.Search(ctx, tc.query, memory.SearchOpts{SessionID: sessionID, Language: tc.language})
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			var speakers []string
+			for _, r := range results {
+				speakers = append(speakers, r.SpeakerID)
+			}
+			if !slices.Equal(speakers, tc.wantSpeakers) {
+				t.Errorf("matched speakers = %v, want %v", speakers, tc.wantSpeakers)
+			}
+			if len(results) > 0 && results[0].SpeakerID == "p1" && results[0].Language != "de-DE" {
+				t.Errorf("language = %q, want de-DE", results[0].Language)
+			}
+		})
+	}
+}
+
+func TestL1_DefaultLanguage(t *testing.T) {
+	store := newTestStore(t, postgres.WithDefaultLanguage("de-DE"))
+	ctx := context.Background()
+	l1 := store.L1()
+
+	writeL1Entries(t, ctx, l1, "default-language", []memory.TranscriptEntry{
+		{SpeakerID: "p1", Text: "Die Elfen kauften die Schwerter der Zwerge."},
+	})
+	results, err := l1.Search(ctx, "Schwert", memory.SearchOpts{SessionID: "default-language", Language: "de"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("want the untagged entry indexed as German, got %d results", len(results))
+	}
+}
+
+func TestL1_SearchCombinedFilters(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	l1 := store.L1()
+
+	now := time.Now()
+	sessionID := "combined-session"
+	writeL1Entries(t, ctx, l1, sessionID, []memory.TranscriptEntry{
+		// Grimjaw on the guild: two hours ago, and twice within the last hour.
+		{SpeakerID: "npc-grimjaw", NPCID: "npc-grimjaw", Text: "The guild owes me for the last shipment.", Timestamp: now.Add(-2 * time.Hour)},
+		{SpeakerID: "npc-grimjaw", NPCID: "npc-grimjaw", Text: "The guild master cannot be trusted.", Timestamp: now.Add(-30 * time.Minute)},
+		{SpeakerID: "npc-grimjaw", NPCID: "npc-grimjaw", Text: "Ask the guild about the missing ore.", Timestamp: now.Add(-10 * time.Minute)},
+		// Grimjaw on something else.
+		{SpeakerID: "npc-grimjaw", NPCID: "npc-grimjaw", Text: "My forge burns hot tonight.", Timestamp: now.Add(-20 * time.Minute)},
+		// Another NPC and a player on the guild within the last hour.
+		{SpeakerID: "npc-elara", NPCID: "npc-elara", Text: "The guild hall is closed today.", Timestamp: now.Add(-15 * time.Minute)},
+		{SpeakerID: "player-1", SpeakerName: "Alice", Text: "What do you know about the guild?", Timestamp: now.Add(-12 * time.Minute)},
+	})
+	// Grimjaw on the guild in another session.
+	writeL1Entries(t, ctx, l1, "other-session", []memory.TranscriptEntry{
+		{SpeakerID: "npc-grimjaw", NPCID: "npc-grimjaw", Text: "The guild pays poorly.", Timestamp: now.Add(-5 * time.Minute)},
+	})
+
+	lastHour := now.Add(-time.Hour)
+	tests := []struct {
+		name      string
+		opts      memory.SearchOpts
+		wantTexts []string
+	}{
+		{
+			name: "text only",
+			opts: memory.SearchOpts{SessionID: sessionID},
+			wantTexts: []string{
+				"The guild owes me for the last shipment.",
+				"The guild master cannot be trusted.",
+				"Ask the guild about the missing ore.",
+				"The guild hall is closed today.",
+				"What do you know about the guild?",
+			},
+		},
+		{
+			name: "text and time",
+			opts: memory.SearchOpts{SessionID: sessionID, After: lastHour},
+			wantTexts: []string{
+				"The guild master cannot be trusted.",
+				"The guild hall is closed today.",
+				"What do you know about the guild?",
+				"Ask the guild about the missing ore.",
+			},
+		},
+		{
+			name: "text, speaker and time",
+			opts: memory.SearchOpts{SessionID: sessionID, SpeakerID: "npc-grimjaw", After: lastHour},
+			wantTexts: []string{
+				"The guild master cannot be trusted.",
+				"Ask the guild about the missing ore.",
+			},
+		},
+		{
+			name: "text, speaker and time window",
+			opts: memory.SearchOpts{SessionID: sessionID, SpeakerID: "npc-grimjaw", After: lastHour, Before: now.Add(-15 * time.Minute)},
+			wantTexts: []string{
+				"The guild master cannot be trusted.",
+			},
+		},
+		{
+			name: "NPCs only within the last hour",
+			opts: memory.SearchOpts{SessionID: sessionID, After: lastHour, NPCOnly: true},
+			wantTexts: []string{
+				"The guild master cannot be trusted.",
+				"The guild hall is closed today.",
+				"Ask the guild about the missing ore.",
+			},
+		},
+		{
+			name: "players only",
+			opts: memory.SearchOpts{SessionID: sessionID, PlayersOnly: true},
+			wantTexts: []string{
+				"What do you know about the guild?",
+			},
+		},
+		{
+			name:      "player speaker with NPCs only",
+			opts:      memory.SearchOpts{SessionID: sessionID, SpeakerID: "player-1", NPCOnly: true},
+			wantTexts: []string{},
+		},
+		{
+			name: "all filters with limit",
+			opts: memory.SearchOpts{SessionID: sessionID, SpeakerID: "npc-grimjaw", After: lastHour, Before: now, NPCOnly: true, Limit: 1},
+			wantTexts: []string{
+				"The guild master cannot be trusted.",
+			},
+		},
+		{
+			name: "all sessions",
+			opts: memory.SearchOpts{SpeakerID: "npc-grimjaw", After: now.Add(-7 * time.Minute), NPCOnly: true},
+			wantTexts: []string{
+				"The guild pays poorly.",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := l1.Search(ctx, "guild", tc.opts)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			got := make([]string, len(results))
+			for i, r := range results {
+				got[i] = r.Text
+			}
+			slices.Sort(got)
+			want := slices.Sorted(slices.Values(tc.wantTexts))
+			if !slices.Equal(got, want) {
+				t.Errorf("Search texts = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if _, err := l1.Search(ctx, "guild", memory.SearchOpts{NPCOnly: true, PlayersOnly: true}); err == nil {
+		t.Error("Search with NPCOnly and PlayersOnly: want error, got nil")
+	}
+
+	// An empty query lists by filters alone; Latest keeps the newest entries.
+	latest, err := l1.Search(ctx, "", memory.SearchOpts{SpeakerID: "npc-grimjaw", NPCOnly: true, Limit: 3, Latest: true})
+	if err != nil {
+		t.Fatalf("Search latest: %v", err)
+	}
+	got := make([]string, len(latest))
+	for i, r := range latest {
+		got[i] = r.Text
+	}
+	want := []string{"My forge burns hot tonight.", "Ask the guild about the missing ore.", "The guild pays poorly."}
+	if !slices.Equal(got, want) {
+		t.Errorf("Search latest texts = %q, want %q in chronological order", got, want)
+	}
+}
+
+func TestL1_ForkSession(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	l1 := store.L1()
+
+	const source = "canon"
+	base := time.Now().Add(-time.Minute)
+	writeL1Entries(t, ctx, l1, source, []memory.TranscriptEntry{
+		{SpeakerID: "p1", Text: "Will you sell us the map?", Timestamp: base},
+		{SpeakerID: "npc1", NPCID: "npc1", Text: "Not for any price.", Timestamp: base.Add(time.Second)},
+	})
+	if err := store.L2().IndexChunk(ctx, memory.Chunk{
+		ID: "canon-chunk", SessionID: source, Content: "The map is not for sale.",
+		Embedding: []float32{1, 0, 0, 0}, Timestamp: base,
+	}); err != nil {
+		t.Fatalf("IndexChunk: %v", err)
+	}
+
+	fork, err := l1.ForkSession(ctx, source, memory.ForkChunks())
+	if err != nil {
+		t.Fatalf("ForkSession: %v", err)
+	}
+	if fork == source || !strings.HasPrefix(fork, source) {
+		t.Errorf("fork ID = %q, want a new ID derived from %q", fork, source)
+	}
+
+	got, err := l1.GetRecent(ctx, fork, time.Hour)
+	if err != nil {
+		t.Fatalf("GetRecent(fork): %v", err)
+	}
+	if len(got) != 2 || got[0].Text != "Will you sell us the map?" || got[1].NPCID != "npc1" {
+		t.Errorf("fork entries = %+v, want the source's entries in order", got)
+	}
+	chunks, err := store.L2().Search(ctx, []float32{1, 0, 0, 0}, 5, memory.ChunkFilter{SessionID: fork})
+	if err != nil {
+		t.Fatalf("L2 Search(fork): %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].Chunk.Content != "The map is not for sale." {
+		t.Errorf("fork chunks = %v, want the source's chunk", chunkIDs(chunks))
+	}
+
+	// Writes to either side stay on that side.
+	writeL1Entries(t, ctx, l1, fork, []memory.TranscriptEntry{{SpeakerID: "p1", Text: "Then we take it by force."}})
+	writeL1Entries(t, ctx, l1, source, []memory.TranscriptEntry{{SpeakerID: "p1", Text: "Then we leave."}})
+	for id, want := range map[string]int{source: 3, fork: 3} {
+		n, err := l1.EntryCount(ctx, id)
+		if err != nil {
+			t.Fatalf("EntryCount(%s): %v", id, err)
+		}
+		if n != want {
+			t.Errorf("EntryCount(%s) = %d, want %d", id, n, want)
+		}
+	}
+	leaked, err := l1.Search(ctx, "force", memory.SearchOpts{SessionID: source})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(leaked) != 0 {
+		t.Errorf("fork entry leaked into the source session: %+v", leaked)
+	}
+
+	if _, err := l1.ForkSession(ctx, "no-such-session"); !errors.Is(err, memory.ErrSessionNotFound) {
+		t.Errorf("ForkSession(unknown) error = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestL1_PruneTranscripts(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	l1 := store.L1()
+
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	writeL1Entries(t, ctx, l1, "consolidated", []memory.TranscriptEntry{
+		{SpeakerID: "p1", Text: "Old and indexed.", Timestamp: old},
+		{SpeakerID: "p1", Text: "Old but after the last chunk.", Timestamp: old.Add(time.Hour)},
+		{SpeakerID: "p1", Text: "Recent.", Timestamp: now.Add(-time.Hour)},
+	})
+	writeL1Entries(t, ctx, l1, "unconsolidated", []memory.TranscriptEntry{
+		{SpeakerID: "p1", Text: "Old, never indexed.", Timestamp: old},
+	})
+	if err := store.L2().IndexChunk(ctx, memory.Chunk{
+		ID: "consolidated-chunk", SessionID: "consolidated", Content: "Old and indexed.",
+		Embedding: []float32{1, 0, 0, 0}, Timestamp: old.Add(time.Minute),
+	}); err != nil {
+		t.Fatalf("IndexChunk: %v", err)
+	}
+
+	n, err := l1.PruneTranscripts(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("PruneTranscripts: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("PruneTranscripts = %d, want 1", n)
+	}
+
+	for session, want := range map[string][]string{
+		"consolidated":   {"Old but after the last chunk.", "Recent."},
+		"unconsolidated": {"Old, never indexed."},
+	} {
+		got, err := l1.Search(ctx, "", memory.SearchOpts{SessionID: session})
+		if err != nil {
+			t.Fatalf("Search(%s): %v", session, err)
+		}
+		var texts []string
+		for _, e := range got {
+			texts = append(texts, e.Text)
+		}
+		if !slices.Equal(texts, want) {
+			t.Errorf("%s entries = %q, want %q", session, texts, want)
+		}
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // L2 — SemanticIndex
 // ─────────────────────────────────────────────────────────────────────────────
@@ -358,6 +718,166 @@ func TestL2_IndexAndSearch(t *testing.T) {
 	}
 }
 
+func TestL2_DimensionMismatch(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	l2 := store.L2()
+
+	wrong := []float32{1, 0, 0, 0, 0, 0}
+
+	_, err := l2.Search(ctx, wrong, 3, memory.ChunkFilter{})
+	if !errors.Is(err, memory.ErrEmbeddingMismatch) {
+		t.Fatalf("Search: want ErrEmbeddingMismatch, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "6 dimensions") || !strings.Contains(err.Error(), "4-dimensional") {
+		t.Errorf("Search error should name both dimensions, got %q", err)
+	}
+
+	err = l2.IndexChunk(ctx, memory.Chunk{ID: "chunk-1", SessionID: "s1", Content: "x", Embedding: wrong})
+	if !errors.Is(err, memory.ErrEmbeddingMismatch) {
+		t.Errorf("IndexChunk: want ErrEmbeddingMismatch, got %v", err)
+	}
+
+	if _, err := store.QueryWithEmbedding(ctx, wrong, 3, nil); !errors.Is(err, memory.ErrEmbeddingMismatch) {
+		t.Errorf("QueryWithEmbedding: want ErrEmbeddingMismatch, got %v", err)
+	}
+}
+
+func TestL2_DistanceMetric(t *testing.T) {
+	ctx := context.Background()
+	query := []float32{1, 0, 0, 0}
+	chunk := memory.Chunk{ID: "chunk-1", SessionID: "s1", Content: "x", Embedding: []float32{0, 2, 0, 0}}
+
+	tests := []struct {
+		metric   memory.DistanceMetric
+		distance float64
+	}{
+		{memory.MetricCosine, 1},
+		{memory.MetricL2, 2.23606797749979}, // √5
+		{memory.MetricInnerProduct, 0},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.metric), func(t *testing.T) {
+			store := newTestStore(t, postgres.WithDistanceMetric(tt.metric))
+			if got := store.L2().DistanceMetric(); got != tt.metric {
+				t.Errorf("DistanceMetric = %q, want %q", got, tt.metric)
+			}
+			if err := store.L2().IndexChunk(ctx, chunk); err != nil {
+				t.Fatalf("IndexChunk: %v", err)
+			}
+			results, err := store.L2().Search(ctx, query, 1, memory.ChunkFilter{})
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(results) != 1 || math.Abs(results[0].Distance-tt.distance) > 1e-6 {
+				t.Errorf("Search = %+v, want distance %v", results, tt.distance)
+			}
+		})
+	}
+
+	// The index of an existing database keeps its metric.
+	newTestStore(t, postgres.WithDistanceMetric(memory.MetricL2))
+	_, err := postgres.NewStore(ctx, testDSN(t), testEmbeddingDim, postgres.WithDistanceMetric(memory.MetricCosine))
+	if !errors.Is(err, memory.ErrDistanceMetricMismatch) {
+		t.Errorf("NewStore with another metric: want ErrDistanceMetricMismatch, got %v", err)
+	}
+}
+
+func TestL2_BindEmbeddingModel(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.BindEmbeddingModel(ctx, "embed-a", testEmbeddingDim); err != nil {
+		t.Fatalf("first bind: %v", err)
+	}
+	model, dims, err := store.EmbeddingModel(ctx)
+	if err != nil {
+		t.Fatalf("EmbeddingModel: %v", err)
+	}
+	if model != "embed-a" || dims != testEmbeddingDim {
+		t.Errorf("EmbeddingModel = (%q, %d), want (%q, %d)", model, dims, "embed-a", testEmbeddingDim)
+	}
+
+	if err := store.BindEmbeddingModel(ctx, "embed-a", testEmbeddingDim); err != nil {
+		t.Errorf("rebind same model: %v", err)
+	}
+	if err := store.BindEmbeddingModel(ctx, "embed-b", testEmbeddingDim); !errors.Is(err, memory.ErrEmbeddingMismatch) {
+		t.Errorf("bind other model: want ErrEmbeddingMismatch, got %v", err)
+	}
+	if err := store.BindEmbeddingModel(ctx, "embed-a", 8); !errors.Is(err, memory.ErrEmbeddingMismatch) {
+		t.Errorf("bind other dimension: want ErrEmbeddingMismatch, got %v", err)
+	}
+}
+
+func TestL2_ReembedAll(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	l2 := store.L2()
+
+	if err := store.BindEmbeddingModel(ctx, "embed-a", testEmbeddingDim); err != nil {
+		t.Fatalf("bind: %v", err)
+	}
+	for i, id := range []string{"chunk-1", "chunk-2", "chunk-3"} {
+		emb := make([]float32, testEmbeddingDim)
+		emb[i] = 1
+		c := memory.Chunk{ID: id, SessionID: "s1", Content: "content of " + id, Embedding: emb, Timestamp: time.Now()}
+		if err := l2.IndexChunk(ctx, c); err != nil {
+			t.Fatalf("IndexChunk %s: %v", id, err)
+		}
+	}
+
+	// Chunks are re-embedded in ID order; each lands on its own axis.
+	provider := &embeddingsmock.Provider{
+		EmbedBatchResult: [][]float32{
+			{1, 0, 0, 0, 0, 0},
+			{0, 1, 0, 0, 0, 0},
+			{0, 0, 1, 0, 0, 0},
+		},
+		DimensionsValue: 6,
+		ModelIDValue:    "embed-b",
+	}
+	n, err := store.ReembedAll(ctx, provider)
+	if err != nil {
+		t.Fatalf("ReembedAll: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("ReembedAll: want 3 chunks, got %d", n)
+	}
+	if len(provider.EmbedBatchCalls) != 1 || len(provider.EmbedBatchCalls[0].Texts) != 3 {
+		t.Fatalf("EmbedBatch calls: want one call with 3 texts, got %+v", provider.EmbedBatchCalls)
+	}
+
+	model, dims, err := store.EmbeddingModel(ctx)
+	if err != nil {
+		t.Fatalf("EmbeddingModel: %v", err)
+	}
+	if model != "embed-b" || dims != 6 {
+		t.Errorf("EmbeddingModel = (%q, %d), want (%q, %d)", model, dims, "embed-b", 6)
+	}
+
+	// Every chunk carries its new vector and is found with it.
+	for i, id := range []string{"chunk-1", "chunk-2", "chunk-3"} {
+		results, err := l2.Search(ctx, provider.EmbedBatchResult[i], 1, memory.ChunkFilter{})
+		if err != nil {
+			t.Fatalf("Search %s: %v", id, err)
+		}
+		if len(results) != 1 || results[0].Chunk.ID != id {
+			t.Fatalf("Search nearest to new %s vector: got %v", id, chunkIDs(results))
+		}
+		if got := len(results[0].Chunk.Embedding); got != 6 {
+			t.Errorf("%s embedding: want 6 dimensions, got %d", id, got)
+		}
+	}
+
+	// The old dimension and model are now rejected.
+	if _, err := l2.Search(ctx, []float32{1, 0, 0, 0}, 1, memory.ChunkFilter{}); !errors.Is(err, memory.ErrEmbeddingMismatch) {
+		t.Errorf("Search with old dimension: want ErrEmbeddingMismatch, got %v", err)
+	}
+	if err := store.BindEmbeddingModel(ctx, "embed-a", testEmbeddingDim); !errors.Is(err, memory.ErrEmbeddingMismatch) {
+		t.Errorf("bind old model: want ErrEmbeddingMismatch, got %v", err)
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // L3 — Entity CRUD
 // ─────────────────────────────────────────────────────────────────────────────
@@ -409,8 +929,8 @@ func TestL3_EntityCRUD(t *testing.T) {
 	}
 
 	// UpdateEntity on missing ID returns error.
-	if err := store.UpdateEntity(ctx, "does-not-exist", map[string]any{}); err == nil {
-		t.Error("UpdateEntity missing: expected error, got nil")
+	if err := store.UpdateEntity(ctx, "does-not-exist", map[string]any{}); !errors.Is(err, memory.ErrEntityNotFound) {
+		t.Errorf("UpdateEntity missing: want memory.ErrEntityNotFound, got %v", err)
 	}
 
 	// GetEntity for missing ID returns (nil, nil).
@@ -481,6 +1001,84 @@ func TestL3_FindEntities(t *testing.T) {
 	}
 }
 
+func TestL3_Pagination(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	for _, e := range []memory.Entity{
+		{ID: "npc-brom", Type: "npc", Name: "Brom"},
+		{ID: "npc-aldric-2", Type: "npc", Name: "Aldric"},
+		{ID: "npc-aldric-1", Type: "npc", Name: "Aldric"},
+		{ID: "npc-cora", Type: "npc", Name: "Cora"},
+		{ID: "npc-dain", Type: "npc", Name: "Dain"},
+		{ID: "loc-bridge", Type: "location", Name: "Bridge"},
+	} {
+		mustAddEntity(t, ctx, store, e)
+	}
+
+	// Entities: pages of two, ordered by name and ID, filter kept.
+	var gotIDs []string
+	filter := memory.EntityFilter{Type: "npc", Limit: 2}
+	for page := 0; ; page++ {
+		if page > 3 {
+			t.Fatalf("FindEntities: no last page, got %v", gotIDs)
+		}
+		entities, err := store.FindEntities(ctx, filter)
+		if err != nil {
+			t.Fatalf("FindEntities page %d: %v", page, err)
+		}
+		gotIDs = append(gotIDs, entityIDs(entities)...)
+		if filter.Cursor = memory.NextEntityCursor(entities, filter.Limit); filter.Cursor == "" {
+			break
+		}
+	}
+	if want := []string{"npc-aldric-1", "npc-aldric-2", "npc-brom", "npc-cora", "npc-dain"}; !slices.Equal(gotIDs, want) {
+		t.Errorf("paged entities: got %v, want %v", gotIDs, want)
+	}
+	if _, err := store.FindEntities(ctx, memory.EntityFilter{Cursor: "not a cursor"}); !errors.Is(err, memory.ErrInvalidCursor) {
+		t.Errorf("FindEntities with bad cursor: got %v, want ErrInvalidCursor", err)
+	}
+
+	// Relationships: pages of three match the unpaginated listing.
+	for _, target := range []string{"npc-brom", "npc-cora", "npc-dain", "loc-bridge"} {
+		if err := store.AddRelationship(ctx, memory.Relationship{SourceID: "npc-aldric-1", TargetID: target, RelType: "knows"}); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+	all, err := store.GetRelationships(ctx, "npc-aldric-1")
+	if err != nil {
+		t.Fatalf("GetRelationships: %v", err)
+	}
+	var paged []memory.Relationship
+	cursor := ""
+	for page := 0; ; page++ {
+		if page > 2 {
+			t.Fatalf("GetRelationships: no last page, got %d relationships", len(paged))
+		}
+		rels, err := store.GetRelationships(ctx, "npc-aldric-1", memory.WithRelLimit(3), memory.WithRelCursor(cursor))
+		if err != nil {
+			t.Fatalf("GetRelationships page %d: %v", page, err)
+		}
+		paged = append(paged, rels...)
+		if cursor = memory.NextRelationshipCursor(rels, 3); cursor == "" {
+			break
+		}
+	}
+	targets := func(rels []memory.Relationship) []string {
+		var ids []string
+		for _, r := range rels {
+			ids = append(ids, r.TargetID)
+		}
+		return ids
+	}
+	if got, want := targets(paged), targets(all); len(want) != 4 || !slices.Equal(got, want) {
+		t.Errorf("paged relationships: got %v, want %v", got, want)
+	}
+	if _, err := store.GetRelationships(ctx, "npc-aldric-1", memory.WithRelCursor("not a cursor")); !errors.Is(err, memory.ErrInvalidCursor) {
+		t.Errorf("GetRelationships with bad cursor: got %v, want ErrInvalidCursor", err)
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // L3 — Relationship CRUD
 // ─────────────────────────────────────────────────────────────────────────────
@@ -514,6 +1112,12 @@ func TestL3_RelationshipCRUD(t *testing.T) {
 		}
 	}
 
+	// AddRelationship to a missing endpoint reports the missing entity.
+	err := store.AddRelationship(ctx, memory.Relationship{SourceID: grimjaw.ID, TargetID: "does-not-exist", RelType: "KNOWS"})
+	if !errors.Is(err, memory.ErrEntityNotFound) {
+		t.Errorf("AddRelationship missing endpoint: want memory.ErrEntityNotFound, got %v", err)
+	}
+
 	// GetRelationships: outgoing from grimjaw (default).
 	out, err := store.GetRelationships(ctx, grimjaw.ID)
 	if err != nil {
@@ -575,6 +1179,120 @@ func TestL3_RelationshipCRUD(t *testing.T) {
 	}
 }
 
+func TestL3_ConfirmRelationship(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	mustAddEntity(t, ctx, store, memory.Entity{ID: "npc-grimjaw", Type: "npc", Name: "Grimjaw"})
+	mustAddEntity(t, ctx, store, memory.Entity{ID: "fac-guild", Type: "faction", Name: "Thieves Guild"})
+	prov := memory.Provenance{SessionID: "session-1", Confidence: 0.45, Source: "inferred"}
+	for _, rel := range []memory.Relationship{
+		{SourceID: "npc-grimjaw", TargetID: "fac-guild", RelType: "MEMBER_OF", Attributes: map[string]any{"rank": "fence"}, Provenance: prov},
+		{SourceID: "npc-grimjaw", TargetID: "fac-guild", RelType: "OWES", Provenance: prov},
+	} {
+		if err := store.AddRelationship(ctx, rel); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+
+	// get returns the MEMBER_OF edge.
+	get := func() memory.Relationship {
+		t.Helper()
+		rels, err := store.GetRelationships(ctx, "npc-grimjaw", memory.WithRelTypes("MEMBER_OF"))
+		if err != nil || len(rels) != 1 {
+			t.Fatalf("GetRelationships: %d relationships, err %v", len(rels), err)
+		}
+		return rels[0]
+	}
+	// canon returns the types of the confirmed edges.
+	canon := func() []string {
+		t.Helper()
+		rels, err := store.GetRelationships(ctx, "npc-grimjaw", memory.WithDMConfirmedOnly())
+		if err != nil {
+			t.Fatalf("GetRelationships(WithDMConfirmedOnly): %v", err)
+		}
+		var types []string
+		for _, r := range rels {
+			types = append(types, r.RelType)
+		}
+		return types
+	}
+
+	if got := canon(); len(got) != 0 {
+		t.Errorf("confirmed before confirming: got %v, want none", got)
+	}
+	for _, confirmed := range []bool{true, false} {
+		if err := store.ConfirmRelationship(ctx, "npc-grimjaw", "fac-guild", "MEMBER_OF", confirmed); err != nil {
+			t.Fatalf("ConfirmRelationship(%v): %v", confirmed, err)
+		}
+		r := get()
+		if r.Provenance.DMConfirmed != confirmed {
+			t.Errorf("DMConfirmed: got %v, want %v", r.Provenance.DMConfirmed, confirmed)
+		}
+		// The rest of the provenance and the attributes survive the toggle.
+		p := r.Provenance
+		if p.SessionID != prov.SessionID || p.Confidence != prov.Confidence || p.Source != prov.Source {
+			t.Errorf("provenance after confirm(%v): got %+v, want %+v", confirmed, p, prov)
+		}
+		if r.Attributes["rank"] != "fence" {
+			t.Errorf("attributes after confirm(%v): got %v", confirmed, r.Attributes)
+		}
+		want := []string(nil)
+		if confirmed {
+			want = []string{"MEMBER_OF"}
+		}
+		if got := canon(); !slices.Equal(got, want) {
+			t.Errorf("confirmed after confirm(%v): got %v, want %v", confirmed, got, want)
+		}
+	}
+
+	// The edge is directed: the reverse edge does not exist.
+	err := store.ConfirmRelationship(ctx, "fac-guild", "npc-grimjaw", "MEMBER_OF", true)
+	if !errors.Is(err, memory.ErrRelationshipNotFound) {
+		t.Errorf("ConfirmRelationship on missing edge: got %v, want ErrRelationshipNotFound", err)
+	}
+}
+
+func TestL3_ConfirmRelationship_JSONBMerge(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	pool := mustPool(t, ctx, testDSN(t))
+	t.Cleanup(pool.Close)
+
+	mustAddEntity(t, ctx, store, memory.Entity{ID: "npc-grimjaw", Type: "npc", Name: "Grimjaw"})
+	mustAddEntity(t, ctx, store, memory.Entity{ID: "fac-guild", Type: "faction", Name: "Thieves Guild"})
+	rel := memory.Relationship{
+		SourceID: "npc-grimjaw", TargetID: "fac-guild", RelType: "MEMBER_OF",
+		Provenance: memory.Provenance{Confidence: 0.45, Source: "inferred"},
+	}
+	if err := store.AddRelationship(ctx, rel); err != nil {
+		t.Fatalf("AddRelationship: %v", err)
+	}
+	// A key written by another tool, unknown to memory.Provenance.
+	if _, err := pool.Exec(ctx, `UPDATE relationships SET provenance = provenance || '{"reviewer": "dm-1"}'`); err != nil {
+		t.Fatalf("add reviewer: %v", err)
+	}
+
+	if err := store.ConfirmRelationship(ctx, rel.SourceID, rel.TargetID, rel.RelType, true); err != nil {
+		t.Fatalf("ConfirmRelationship: %v", err)
+	}
+	var (
+		reviewer, source string
+		confidence       float64
+		confirmed        bool
+	)
+	err := pool.QueryRow(ctx, `
+		SELECT provenance->>'reviewer', provenance->>'Source',
+		       (provenance->>'Confidence')::float8, (provenance->>'DMConfirmed')::boolean
+		FROM   relationships`).Scan(&reviewer, &source, &confidence, &confirmed)
+	if err != nil {
+		t.Fatalf("read provenance: %v", err)
+	}
+	if reviewer != "dm-1" || source != "inferred" || confidence != 0.45 || !confirmed {
+		t.Errorf("provenance: reviewer %q, source %q, confidence %v, confirmed %v", reviewer, source, confidence, confirmed)
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // L3 — Graph traversal
 // ─────────────────────────────────────────────────────────────────────────────
@@ -766,8 +1484,222 @@ func TestL3_IdentitySnapshot(t *testing.T) {
 
 	// IdentitySnapshot for missing entity returns error.
 	_, err = store.IdentitySnapshot(ctx, "does-not-exist")
-	if err == nil {
-		t.Error("IdentitySnapshot missing: expected error, got nil")
+	if !errors.Is(err, memory.ErrEntityNotFound) {
+		t.Errorf("IdentitySnapshot missing: want memory.ErrEntityNotFound, got %v", err)
+	}
+}
+
+func TestL3_WhoKnows(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	grimjaw, elara, guild, tower, mages := buildTestGraph(t, ctx, store)
+
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		// Outgoing edge of an NPC: grimjaw → elara.
+		{elara.ID, []string{grimjaw.ID}},
+		// Incoming edges count too: elara is connected to grimjaw.
+		{grimjaw.ID, []string{elara.ID}},
+		{guild.ID, []string{grimjaw.ID}},
+		{tower.ID, []string{elara.ID}},
+		// Only the guild, a faction, is connected to the mages.
+		{mages.ID, []string{}},
+		{"g-unknown", []string{}},
+	}
+	for _, tc := range tests {
+		got, err := store.WhoKnows(ctx, tc.target)
+		if err != nil {
+			t.Fatalf("WhoKnows(%s): %v", tc.target, err)
+		}
+		if got == nil || !slices.Equal(got, tc.want) {
+			t.Errorf("WhoKnows(%s) = %#v, want %#v", tc.target, got, tc.want)
+		}
+	}
+}
+
+func TestL3_EntitiesKnownBy(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	grimjaw, elara, guild, tower, _ := buildTestGraph(t, ctx, store)
+
+	tests := []struct {
+		npc  string
+		want []string
+	}{
+		{grimjaw.ID, []string{elara.ID, guild.ID}},
+		{elara.ID, []string{grimjaw.ID, tower.ID}},
+		{"g-unknown", []string{}},
+	}
+	for _, tc := range tests {
+		got, err := store.EntitiesKnownBy(ctx, tc.npc)
+		if err != nil {
+			t.Fatalf("EntitiesKnownBy(%s): %v", tc.npc, err)
+		}
+		if got == nil || !slices.Equal(got, tc.want) {
+			t.Errorf("EntitiesKnownBy(%s) = %#v, want %#v", tc.npc, got, tc.want)
+		}
+	}
+
+	// The two queries are inverse: every entity an NPC knows lists the NPC.
+	for _, id := range []string{elara.ID, guild.ID} {
+		who, err := store.WhoKnows(ctx, id)
+		if err != nil {
+			t.Fatalf("WhoKnows(%s): %v", id, err)
+		}
+		if !slices.Contains(who, grimjaw.ID) {
+			t.Errorf("WhoKnows(%s) = %v, want it to contain %s", id, who, grimjaw.ID)
+		}
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// L3 — CheckIntegrity
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestL3_CheckIntegrity(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	for _, e := range []memory.Entity{
+		{ID: "lint-guild", Type: "faction", Name: "Thieves Guild"},
+		{ID: "lint-watch", Type: "faction", Name: "City Watch"},
+		{ID: "lint-grimjaw", Type: "npc", Name: "Grimjaw"},
+		{ID: "lint-hermit", Type: "npc", Name: "Hermit"},
+	} {
+		mustAddEntity(t, ctx, store, e)
+	}
+	for _, r := range []memory.Relationship{
+		{SourceID: "lint-guild", TargetID: "lint-watch", RelType: "allied_with"},
+		{SourceID: "lint-watch", TargetID: "lint-guild", RelType: "ENEMY_OF"},
+		{SourceID: "lint-grimjaw", TargetID: "lint-guild", RelType: "member_of"},
+	} {
+		if err := store.AddRelationship(ctx, r); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+
+	// The foreign keys prevent dangling edges, so simulate a graph that
+	// predates them by dropping the constraint and inserting one directly.
+	pool := mustPool(t, ctx, testDSN(t))
+	t.Cleanup(pool.Close)
+	for _, stmt := range []string{
+		"ALTER TABLE relationships DROP CONSTRAINT relationships_target_id_fkey",
+		"INSERT INTO relationships (source_id, target_id, rel_type) VALUES ('lint-grimjaw', 'lint-ghost', 'knows')",
+	} {
+		if _, err := pool.Exec(ctx, stmt); err != nil {
+			t.Fatalf("%q: %v", stmt, err)
+		}
+	}
+
+	issues, err := store.CheckIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("CheckIntegrity: %v", err)
+	}
+
+	type key struct {
+		kind memory.IssueKind
+		ids  string
+	}
+	var got []key
+	for _, issue := range issues {
+		got = append(got, key{issue.Kind, strings.Join(issue.EntityIDs, ",")})
+		if issue.Message == "" {
+			t.Errorf("issue %+v has no message", issue)
+		}
+	}
+	want := []key{
+		{memory.IssueDanglingRelationship, "lint-ghost"},
+		{memory.IssueOrphanEntity, "lint-hermit"},
+		{memory.IssueConflictingRelationships, "lint-guild,lint-watch"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("issues = %+v, want %+v", got, want)
+	}
+
+	conflict := issues[len(issues)-1]
+	if len(conflict.Relationships) != 2 ||
+		conflict.Relationships[0].RelType != "allied_with" ||
+		conflict.Relationships[1].RelType != "ENEMY_OF" {
+		t.Errorf("conflicting relationships = %+v", conflict.Relationships)
+	}
+}
+
+func TestL3_ExportGraph(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	grimjaw, elara, guild, _, _ := buildTestGraph(t, ctx, store)
+
+	dot, err := store.ExportGraph(ctx, memory.GraphFormatDOT)
+	if err != nil {
+		t.Fatalf("ExportGraph(dot): %v", err)
+	}
+	for _, want := range []string{
+		"digraph knowledge_graph {",
+		`"g-grimjaw" [label="Grimjaw", type="npc"];`,
+		`"g-tower" [label="Elara's Tower", type="location"];`,
+		`"g-mages" [label="Mages Council", type="faction"];`,
+		`"g-grimjaw" -> "g-elara" [label="KNOWS"];`,
+		`"g-grimjaw" -> "g-guild" [label="MEMBER_OF"];`,
+		`"g-elara" -> "g-tower" [label="LOCATED_AT"];`,
+		`"g-guild" -> "g-mages" [label="ALLIED_WITH"];`,
+	} {
+		if !strings.Contains(string(dot), want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+
+	graphml, err := store.ExportGraph(ctx, memory.GraphFormatGraphML)
+	if err != nil {
+		t.Fatalf("ExportGraph(graphml): %v", err)
+	}
+	for _, want := range []string{
+		`<graph id="knowledge_graph" edgedefault="directed">`,
+		`<node id="g-elara">`,
+		`<data key="name">Blacksmiths Guild</data>`,
+		`source="g-guild" target="g-mages"`,
+		`<data key="rel_type">LOCATED_AT</data>`,
+	} {
+		if !strings.Contains(string(graphml), want) {
+			t.Errorf("GraphML output missing %q:\n%s", want, graphml)
+		}
+	}
+	if got := strings.Count(string(graphml), "<node "); got != 5 {
+		t.Errorf("GraphML has %d nodes, want 5", got)
+	}
+	if got := strings.Count(string(graphml), "<edge "); got != 4 {
+		t.Errorf("GraphML has %d edges, want 4", got)
+	}
+
+	// Scoping by type drops the other entities and every edge touching them.
+	npcs, err := store.ExportGraph(ctx, memory.GraphFormatDOT, memory.ExportEntityTypes("npc"))
+	if err != nil {
+		t.Fatalf("ExportGraph(npc): %v", err)
+	}
+	if !strings.Contains(string(npcs), `"g-grimjaw" -> "g-elara"`) || strings.Contains(string(npcs), "g-guild") {
+		t.Errorf("npc-scoped DOT output:\n%s", npcs)
+	}
+
+	// Scoping by campaign keeps only entities tagged with it.
+	for _, id := range []string{grimjaw.ID, elara.ID, guild.ID} {
+		if err := store.UpdateEntity(ctx, id, map[string]any{memory.CampaignAttribute: "Curse of Strahd"}); err != nil {
+			t.Fatalf("UpdateEntity: %v", err)
+		}
+	}
+	campaign, err := store.ExportGraph(ctx, memory.GraphFormatDOT, memory.ExportCampaign("Curse of Strahd"))
+	if err != nil {
+		t.Fatalf("ExportGraph(campaign): %v", err)
+	}
+	if got := strings.Count(string(campaign), " -> "); got != 2 {
+		t.Errorf("campaign-scoped DOT output has %d edges, want 2:\n%s", got, campaign)
+	}
+	if strings.Contains(string(campaign), "g-tower") || strings.Contains(string(campaign), "g-mages") {
+		t.Errorf("campaign-scoped DOT output contains untagged entities:\n%s", campaign)
+	}
+
+	if _, err := store.ExportGraph(ctx, "svg"); err == nil {
+		t.Error("ExportGraph(svg): expected unsupported format error")
 	}
 }
 
@@ -845,6 +1777,109 @@ func TestGraphRAG_QueryWithContext(t *testing.T) {
 	}
 }
 
+func TestGraphRAG_QueryHybrid(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	l2 := store.L2()
+
+	npc := memory.Entity{ID: "rag-npc-1", Type: "npc", Name: "Grimjaw", Attributes: map[string]any{}}
+	mustAddEntity(t, ctx, store, npc)
+	for _, c := range []memory.Chunk{
+		{
+			ID: "rag-chunk-1", SessionID: "rag-s1", EntityID: npc.ID,
+			Content:   "The blacksmith has a secret shipment of weapons hidden in the cellar.",
+			Embedding: []float32{1, 0, 0, 0}, Timestamp: time.Now(),
+		},
+		{
+			ID: "rag-chunk-2", SessionID: "rag-s1", EntityID: npc.ID,
+			Content:   "Grimjaw owes money to the thieves guild and fears reprisal.",
+			Embedding: []float32{0, 1, 0, 0}, Timestamp: time.Now(),
+		},
+	} {
+		if err := l2.IndexChunk(ctx, c); err != nil {
+			t.Fatalf("IndexChunk: %v", err)
+		}
+	}
+
+	// The embedding points at the shipment chunk, the words at the thieves
+	// chunk; fused, the chunk found by both rankings comes first.
+	results, err := store.QueryHybrid(ctx, "thieves guild", []float32{1, 0, 0, 0}, 5, nil, 0.5)
+	if err != nil {
+		t.Fatalf("QueryHybrid: %v", err)
+	}
+	if len(results) != 2 || !strings.Contains(results[0].Content, "thieves") {
+		t.Fatalf("QueryHybrid = %+v, want the thieves chunk, then the shipment chunk", results)
+	}
+	for _, r := range results {
+		if r.Score <= 0 || r.Score > 1 || r.Entity.ID != npc.ID {
+			t.Errorf("QueryHybrid result %+v: want score in (0, 1] and entity %s", r, npc.ID)
+		}
+	}
+
+	vectorOnly, err := store.QueryHybrid(ctx, "thieves guild", []float32{1, 0, 0, 0}, 1, nil, 1)
+	if err != nil {
+		t.Fatalf("QueryHybrid vector only: %v", err)
+	}
+	if len(vectorOnly) != 1 || !strings.Contains(vectorOnly[0].Content, "shipment") || vectorOnly[0].Score != 1 {
+		t.Errorf("QueryHybrid vector only = %+v, want the shipment chunk with score 1", vectorOnly)
+	}
+
+	excluded, err := store.QueryHybrid(ctx, "thieves guild", []float32{1, 0, 0, 0}, 5, []string{"other-entity-id"}, 0.5)
+	if err != nil {
+		t.Fatalf("QueryHybrid excluded: %v", err)
+	}
+	if len(excluded) != 0 {
+		t.Errorf("QueryHybrid excluded: expected 0, got %d", len(excluded))
+	}
+
+	if _, err := store.QueryHybrid(ctx, "thieves", []float32{1, 0, 0, 0}, 5, nil, 2); !errors.Is(err, memory.ErrInvalidAlpha) {
+		t.Errorf("QueryHybrid alpha 2: want ErrInvalidAlpha, got %v", err)
+	}
+}
+
+func TestGraphRAG_QueryWithContext_Language(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	l2 := store.L2()
+
+	npc := memory.Entity{ID: "rag-npc-de", Type: "npc", Name: "Grimmbart", Attributes: map[string]any{}}
+	mustAddEntity(t, ctx, store, npc)
+	for _, c := range []memory.Chunk{
+		{
+			ID: "rag-de-1", SessionID: "rag-de", EntityID: npc.ID, Language: "de-DE",
+			Content:   "Der Schmied versteckt die Waffen im Keller.",
+			Embedding: []float32{1, 0, 0, 0}, Timestamp: time.Now(),
+		},
+		{
+			ID: "rag-de-2", SessionID: "rag-de", EntityID: npc.ID,
+			Content:   "Grimmbart hortet Waffen unter der Brücke.",
+			Embedding: []float32{0, 1, 0, 0}, Timestamp: time.Now(),
+		},
+	} {
+		if err := l2.IndexChunk(ctx, c); err != nil {
+			t.Fatalf("IndexChunk: %v", err)
+		}
+	}
+
+	// "Waffe" and "Waffen" share a German stem but not an English one, so
+	// only the chunk tagged as German matches.
+	results, err := store.QueryWithContext(ctx, "Waffe", nil)
+	if err != nil {
+		t.Fatalf("QueryWithContext: %v", err)
+	}
+	if len(results) != 1 || !strings.Contains(results[0].Content, "Keller") {
+		t.Errorf("QueryWithContext = %+v, want only the German chunk", results)
+	}
+
+	hits, err := l2.Search(ctx, []float32{1, 0, 0, 0}, 1, memory.ChunkFilter{SessionID: "rag-de"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Chunk.Language != "de-DE" {
+		t.Errorf("Search = %+v, want the chunk with language de-DE", hits)
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // Helpers
 // ─────────────────────────────────────────────────────────────────────────────
@@ -0,0 +1,20 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// TranscriptPruner is implemented by [SessionStore] backends that can delete
+// old transcript entries once they have been consolidated into the semantic
+// index (L2), so that the L1 log does not grow without bound.
+//
+// An entry counts as consolidated when its session has an L2 [Chunk] with a
+// Timestamp no earlier than the entry's. Entries not yet covered by a chunk
+// are kept regardless of their age, so pruning never loses a transcript that
+// exists nowhere else.
+type TranscriptPruner interface {
+	// PruneTranscripts deletes the consolidated entries whose Timestamp is
+	// before cutoff, across all sessions, and returns how many were deleted.
+	PruneTranscripts(ctx context.Context, cutoff time.Time) (int, error)
+}
@@ -2,10 +2,12 @@ package memory
 
 // RelQueryParams holds the resolved parameters from a slice of [RelQueryOpt].
 type RelQueryParams struct {
-	RelTypes     []string
-	DirectionIn  bool
-	DirectionOut bool
-	Limit        int
+	RelTypes        []string
+	DirectionIn     bool
+	DirectionOut    bool
+	Limit           int
+	Cursor          string
+	DMConfirmedOnly bool
 }
 
 // ApplyRelQueryOpts applies a slice of [RelQueryOpt] functional options and
@@ -18,10 +20,12 @@ func ApplyRelQueryOpts(opts []RelQueryOpt) RelQueryParams {
 		opt(o)
 	}
 	return RelQueryParams{
-		RelTypes:     o.relTypes,
-		DirectionIn:  o.directionIn,
-		DirectionOut: o.directionOut,
-		Limit:        o.limit,
+		RelTypes:        o.relTypes,
+		DirectionIn:     o.directionIn,
+		DirectionOut:    o.directionOut,
+		Limit:           o.limit,
+		Cursor:          o.cursor,
+		DMConfirmedOnly: o.confirmed,
 	}
 }
 
@@ -47,3 +51,24 @@ func ApplyTraversalOpts(opts []TraversalOpt) TraversalParams {
 		MaxNodes:  o.maxNodes,
 	}
 }
+
+// ExportParams holds the resolved parameters from a slice of [ExportOpt].
+type ExportParams struct {
+	EntityTypes []string
+	Campaign    string
+}
+
+// ApplyExportOpts applies a slice of [ExportOpt] functional options and
+// returns the resolved export parameters as an [ExportParams]. This helper
+// allows external packages to read the option values without accessing the
+// unexported [exportOptions] type.
+func ApplyExportOpts(opts []ExportOpt) ExportParams {
+	o := &exportOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return ExportParams{
+		EntityTypes: o.entityTypes,
+		Campaign:    o.campaign,
+	}
+}
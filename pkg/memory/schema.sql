@@ -53,7 +53,19 @@ CREATE TABLE IF NOT EXISTS session_entries (
 
     -- duration_ns stores utterance length in nanoseconds.
     -- Stored as int8 to avoid precision loss from interval rounding.
-    duration_ns     BIGINT      NOT NULL DEFAULT 0
+    duration_ns     BIGINT      NOT NULL DEFAULT 0,
+
+    -- language is the BCP-47 tag of the spoken language; empty if unknown.
+    language        TEXT        NOT NULL DEFAULT '',
+
+    -- fts_config is the text search configuration matching language (or the
+    -- store's default language), used to index text.
+    fts_config      REGCONFIG   NOT NULL DEFAULT 'english',
+
+    -- words holds per-word timings as a JSON array of
+    -- {"word", "start_ns", "end_ns"} objects; NULL if the STT provider
+    -- reported none.
+    words           JSONB
 );
 
 -- Primary access pattern: recent entries for a single session.
@@ -69,9 +81,9 @@ CREATE INDEX IF NOT EXISTS idx_session_entries_npc
     ON session_entries (npc_id, timestamp DESC)
     WHERE npc_id <> '';
 
--- Full-text search over corrected transcript text.
+-- Full-text search over corrected transcript text, in each entry's language.
 CREATE INDEX IF NOT EXISTS idx_session_entries_text_fts
-    ON session_entries USING GIN (to_tsvector('english', text));
+    ON session_entries USING GIN (to_tsvector(fts_config, text));
 
 -- ─────────────────────────────────────────────────────────────────────────────
 -- L2 – Semantic Chunks
@@ -105,13 +117,23 @@ CREATE TABLE IF NOT EXISTS chunks (
     topic       TEXT        NOT NULL DEFAULT '',
 
     -- timestamp is when this chunk was recorded.
-    timestamp   TIMESTAMPTZ NOT NULL
+    timestamp   TIMESTAMPTZ NOT NULL,
+
+    -- language is the BCP-47 tag of content's language; empty if unknown.
+    language    TEXT        NOT NULL DEFAULT '',
+
+    -- fts_config is the text search configuration matching language.
+    fts_config  REGCONFIG   NOT NULL DEFAULT 'english'
 );
 
 -- Session-scoped lookup.
 CREATE INDEX IF NOT EXISTS idx_chunks_session_id
     ON chunks (session_id, timestamp DESC);
 
+-- Full-text search over chunk content, in each chunk's language.
+CREATE INDEX IF NOT EXISTS idx_chunks_fts
+    ON chunks USING GIN (to_tsvector(fts_config, content));
+
 -- Speaker-scoped lookup within a session.
 CREATE INDEX IF NOT EXISTS idx_chunks_speaker
     ON chunks (session_id, speaker_id);
@@ -128,6 +150,23 @@ CREATE INDEX IF NOT EXISTS idx_chunks_embedding
     ON chunks USING ivfflat (embedding vector_cosine_ops)
     WITH (lists = 100);
 
+-- embedding_meta records which embedding model produced the vectors in chunks.
+-- It holds a single row. Vectors from different models must not be mixed;
+-- switching models re-embeds every chunk (see Store.ReembedAll).
+CREATE TABLE IF NOT EXISTS embedding_meta (
+    -- id pins the table to one row.
+    id          BOOLEAN     PRIMARY KEY DEFAULT TRUE CHECK (id),
+
+    -- model is the embedding model ID; empty until first recorded.
+    model       TEXT        NOT NULL DEFAULT '',
+
+    -- dimensions is the vector size of chunks.embedding.
+    dimensions  INT         NOT NULL,
+
+    -- updated_at is when the model was last recorded.
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
 -- ─────────────────────────────────────────────────────────────────────────────
 -- L3 – Knowledge Graph: Entities
 -- ─────────────────────────────────────────────────────────────────────────────
@@ -199,9 +238,9 @@ CREATE TABLE IF NOT EXISTS relationships (
     attributes  JSONB       NOT NULL DEFAULT '{}',
 
     -- provenance records the evidence trail for this relationship.
-    -- Schema mirrors the Provenance Go struct:
-    -- { "session_id": "...", "timestamp": "...", "confidence": 0.9,
-    --   "source": "stated", "dm_confirmed": false }
+    -- Schema mirrors the Provenance Go struct, keyed by its field names:
+    -- { "SessionID": "...", "Timestamp": "...", "Confidence": 0.9,
+    --   "Source": "stated", "DMConfirmed": false }
     provenance  JSONB       NOT NULL DEFAULT '{}',
 
     -- created_at is set on first insertion.
@@ -223,6 +262,6 @@ CREATE INDEX IF NOT EXISTS idx_relationships_rel_type
     ON relationships (rel_type);
 
 -- Provenance containment queries (e.g., all DM-confirmed facts).
--- Example: WHERE provenance @> '{"dm_confirmed": true}'
+-- Example: WHERE provenance @> '{"DMConfirmed": true}'
 CREATE INDEX IF NOT EXISTS idx_relationships_provenance
     ON relationships USING GIN (provenance);
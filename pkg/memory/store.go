@@ -45,9 +45,27 @@ type SearchOpts struct {
 	// An empty string matches all speakers.
 	SpeakerID string
 
+	// NPCOnly restricts results to entries spoken by NPCs (see
+	// [TranscriptEntry.IsNPC]). Mutually exclusive with PlayersOnly.
+	NPCOnly bool
+
+	// PlayersOnly restricts results to entries spoken by players, i.e. not by
+	// NPCs. Mutually exclusive with NPCOnly.
+	PlayersOnly bool
+
 	// Limit caps the number of results returned.
 	// A value of 0 means the implementation may apply its own default.
 	Limit int
+
+	// Latest makes Limit keep the most recent matching entries instead of the
+	// oldest ones. Results are still returned in chronological order.
+	Latest bool
+
+	// Language is the BCP-47 tag of the query's language. Stores with
+	// language-aware full-text search parse the query with the rules of this
+	// language, so a German query matches German word forms. Empty matches
+	// every entry using the rules of the entry's own language.
+	Language string
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -84,6 +102,11 @@ type Chunk struct {
 
 	// Timestamp is when this chunk was recorded.
 	Timestamp time.Time
+
+	// Language is the BCP-47 tag of the language of Content. Like
+	// [TranscriptEntry.Language] it selects the full-text search rules; empty
+	// means the store's default language.
+	Language string
 }
 
 // ChunkFilter narrows a semantic search to a subset of indexed chunks (L2).
@@ -111,8 +134,9 @@ type ChunkResult struct {
 	// Chunk is the retrieved segment.
 	Chunk Chunk
 
-	// Distance is the vector-space distance to the query embedding
-	// (e.g., cosine distance or L2 — interpretation is implementation-defined).
+	// Distance is the vector-space distance to the query embedding. Its
+	// scale depends on the store's [DistanceMetric]; stores implementing
+	// [MetricReporter] report which one they use.
 	Distance float64
 }
 
@@ -129,6 +153,10 @@ const (
 	AttrSpeakingStyle = "speaking_style"
 	AttrPersonality   = "personality"
 	AttrAlignment     = "alignment"
+
+	// AttrAliases lists alternative names of an entity, either as a list of
+	// strings or as one comma-separated string. NPCs answer to their aliases.
+	AttrAliases = "aliases"
 )
 
 // Entity represents a named object in the knowledge graph (L3).
@@ -215,6 +243,18 @@ type EntityFilter struct {
 	// An entity matches if every key/value pair in AttributeQuery is present
 	// in its Attributes map.
 	AttributeQuery map[string]any
+
+	// Limit caps the number of entities returned. Zero or less returns every
+	// matching entity. Use [NextEntityCursor] to get the cursor of the next
+	// page.
+	Limit int
+
+	// Cursor resumes the listing after the entity it was made from with
+	// [EntityCursor] or [NextEntityCursor]. Empty starts at the first entity.
+	// Keep the other fields unchanged between pages. Pages are keyed on the
+	// entity's position rather than counted, so entities added or removed
+	// between pages do not shift the following pages.
+	Cursor string
 }
 
 // relQueryOptions accumulates options for [KnowledgeGraph.GetRelationships].
@@ -224,6 +264,8 @@ type relQueryOptions struct {
 	directionIn  bool
 	directionOut bool
 	limit        int
+	cursor       string
+	confirmed    bool
 }
 
 // RelQueryOpt is a functional option for [KnowledgeGraph.GetRelationships].
@@ -256,6 +298,23 @@ func WithRelLimit(n int) RelQueryOpt {
 	return func(o *relQueryOptions) { o.limit = n }
 }
 
+// WithRelCursor resumes the listing after the relationship the cursor was
+// made from with [RelationshipCursor] or [NextRelationshipCursor]. Combine it
+// with [WithRelLimit] to page through an entity's relationships; keep the
+// other options unchanged between pages. An empty cursor starts at the first
+// relationship.
+func WithRelCursor(cursor string) RelQueryOpt {
+	return func(o *relQueryOptions) { o.cursor = cursor }
+}
+
+// WithDMConfirmedOnly restricts the returned relationships to those a Dungeon
+// Master has validated (see [Provenance.DMConfirmed]), e.g. to build a prompt
+// from canon facts only. By default confirmed and unconfirmed relationships
+// are returned.
+func WithDMConfirmedOnly() RelQueryOpt {
+	return func(o *relQueryOptions) { o.confirmed = true }
+}
+
 // traversalOptions accumulates options for [KnowledgeGraph.Neighbors].
 // Unexported — callers configure it via [TraversalOpt] functional options.
 type traversalOptions struct {
@@ -337,9 +396,12 @@ type SessionStore interface {
 	GetRecent(ctx context.Context, sessionID string, duration time.Duration) ([]TranscriptEntry, error)
 
 	// Search performs keyword / full-text search over stored entries.
-	// The query string is matched against the Text field.
-	// opts refines the result set by time range, speaker, or session scope.
-	// Returns an empty (non-nil) slice when no entries match.
+	// The query string is matched against the Text field; an empty query
+	// matches every entry, so entries can be listed by filters alone.
+	// opts refines the result set by time range, speaker, speaker kind, or
+	// session scope; all set filters apply together.
+	// Returns an empty (non-nil) slice when no entries match, and an error
+	// when both [SearchOpts.NPCOnly] and [SearchOpts.PlayersOnly] are set.
 	Search(ctx context.Context, query string, opts SearchOpts) ([]TranscriptEntry, error)
 
 	// EntryCount returns the total number of transcript entries stored for
@@ -395,32 +457,48 @@ type KnowledgeGraph interface {
 	// UpdateEntity merges attrs into the Attributes map of the specified entity
 	// and refreshes its UpdatedAt timestamp. Keys present in attrs overwrite
 	// existing values; absent keys are left unchanged.
-	// Returns an error when the entity does not exist.
+	// Returns an error wrapping [ErrEntityNotFound] when the entity does not exist.
 	UpdateEntity(ctx context.Context, id string, attrs map[string]any) error
 
 	// DeleteEntity removes the entity and all its associated relationships from
 	// the graph. Deleting a non-existent entity is not an error.
 	DeleteEntity(ctx context.Context, id string) error
 
-	// FindEntities returns all entities matching filter.
-	// Returns an empty (non-nil) slice when no entities match.
+	// FindEntities returns the entities matching filter, ordered by name and
+	// then by ID. [EntityFilter.Limit] and [EntityFilter.Cursor] page
+	// through the result; without a limit all matching entities are returned.
+	// Returns an empty (non-nil) slice when no entities match, and an error
+	// wrapping [ErrInvalidCursor] for a malformed cursor.
 	FindEntities(ctx context.Context, filter EntityFilter) ([]Entity, error)
 
 	// AddRelationship upserts a directed edge between two entities.
 	// If a relationship with the same (SourceID, TargetID, RelType) already
-	// exists it is completely replaced.
+	// exists it is completely replaced. Implementations that enforce
+	// referential integrity return an error wrapping [ErrEntityNotFound] when
+	// either endpoint does not exist.
 	AddRelationship(ctx context.Context, rel Relationship) error
 
 	// GetRelationships returns relationships associated with entityID.
 	// By default only outgoing edges are returned; use [WithIncoming] to include
-	// inbound edges, and [WithRelTypes] to filter by edge type.
-	// Returns an empty (non-nil) slice when no relationships match.
+	// inbound edges, and [WithRelTypes] to filter by edge type. Relationships
+	// are ordered by creation time; [WithRelLimit] and [WithRelCursor] page
+	// through them.
+	// Returns an empty (non-nil) slice when no relationships match, and an
+	// error wrapping [ErrInvalidCursor] for a malformed cursor.
 	GetRelationships(ctx context.Context, entityID string, opts ...RelQueryOpt) ([]Relationship, error)
 
 	// DeleteRelationship removes the directed edge identified by (sourceID,
 	// targetID, relType). Deleting a non-existent edge is not an error.
 	DeleteRelationship(ctx context.Context, sourceID, targetID, relType string) error
 
+	// ConfirmRelationship sets [Provenance.DMConfirmed] of the directed edge
+	// identified by (sourceID, targetID, relType) to confirmed, recording
+	// that a Dungeon Master has validated the fact or withdrawn that
+	// validation. The rest of the provenance and the attributes are left
+	// unchanged. Returns an error wrapping [ErrRelationshipNotFound] when the
+	// edge does not exist.
+	ConfirmRelationship(ctx context.Context, sourceID, targetID, relType string, confirmed bool) error
+
 	// Neighbors performs a breadth-first traversal from entityID up to depth
 	// hops and returns all reachable entities (the start entity is excluded).
 	// [TraversalOpt] options can restrict which edge or node types are followed.
@@ -440,6 +518,7 @@ type KnowledgeGraph interface {
 
 	// IdentitySnapshot assembles a compact [NPCIdentity] for npcID, suitable for
 	// injecting into a system prompt or context window.
+	// Returns an error wrapping [ErrEntityNotFound] when npcID does not exist.
 	IdentitySnapshot(ctx context.Context, npcID string) (*NPCIdentity, error)
 }
 
@@ -456,10 +535,13 @@ type KnowledgeGraph interface {
 //     requires no embedding provider. Useful as a fallback when embeddings
 //     are unavailable or during entity extraction where embedding budget is
 //     limited.
-//   - [GraphRAGQuerier.QueryWithEmbedding] uses pgvector cosine similarity
+//   - [GraphRAGQuerier.QueryWithEmbedding] uses vector similarity
 //     against pre-computed chunk embeddings. This is the true GraphRAG path
 //     described in the design docs and produces higher-quality results when
 //     an embedding provider is available.
+//
+// Backends that can fuse both rankings in one query implement
+// [HybridQuerier].
 type GraphRAGQuerier interface {
 	KnowledgeGraph
 
@@ -476,9 +558,10 @@ type GraphRAGQuerier interface {
 	QueryWithContext(ctx context.Context, query string, graphScope []string) ([]ContextResult, error)
 
 	// QueryWithEmbedding performs a GraphRAG query using vector similarity:
-	// it finds the topK chunks whose embeddings are closest (cosine distance)
-	// to the provided query embedding, scoped to entities in graphScope.
-	// Results are ranked by ascending cosine distance (most similar first).
+	// it finds the topK chunks whose embeddings are closest (by the store's
+	// [DistanceMetric], cosine unless configured otherwise) to the provided
+	// query embedding, scoped to entities in graphScope.
+	// Results are ranked by descending Score (most similar first).
 	//
 	// graphScope limits results to chunks whose entity association is in the
 	// list. An empty graphScope searches all chunks.
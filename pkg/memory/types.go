@@ -27,6 +27,38 @@ type TranscriptEntry struct {
 
 	// Duration is the length of the utterance.
 	Duration time.Duration
+
+	// Words holds the timing of each spoken word, for subtitle rendering and
+	// lip-sync. Nil when the STT provider does not report word timings or the
+	// entry was not transcribed from speech.
+	Words []WordTiming
+
+	// Language is the BCP-47 tag of the language the entry is spoken in,
+	// as detected by STT or declared for the NPC (e.g., "de-DE"). Stores with
+	// language-aware full-text search index the text with the rules of this
+	// language. Empty means unknown; such stores then assume their default
+	// language.
+	Language string
+
+	// Sequence is the position of the entry in the stream that emitted it,
+	// starting at 1 and increasing by one per entry. Consumers use it to detect
+	// entries that a reconnected stream delivers twice or out of order. Zero
+	// means the source does not number its entries.
+	Sequence uint64
+}
+
+// WordTiming is the position of one word within a transcribed utterance.
+// Start and End are offsets as reported by the STT provider, usually from the
+// start of the audio stream or utterance.
+type WordTiming struct {
+	// Word is the word as transcribed.
+	Word string `json:"word"`
+
+	// Start is when the word starts.
+	Start time.Duration `json:"start_ns"`
+
+	// End is when the word ends.
+	End time.Duration `json:"end_ns"`
 }
 
 // IsNPC reports whether this entry was produced by an NPC agent.
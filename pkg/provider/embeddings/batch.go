@@ -0,0 +1,120 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchOption configures a [Batcher].
+type BatchOption func(*Batcher)
+
+// WithBatchSize caps the number of texts sent in one request. Larger batches
+// are split into consecutive sub-batches of at most n texts. Values < 1 mean
+// no cap.
+func WithBatchSize(n int) BatchOption {
+	return func(b *Batcher) {
+		b.size = max(n, 0)
+	}
+}
+
+// WithRateLimit paces requests to at most n per period, spaced evenly: after
+// one request the next starts no earlier than period/n later. This applies to
+// all requests of the provider, single embeddings included. Values of n or
+// period < 1 disable pacing.
+func WithRateLimit(n int, period time.Duration) BatchOption {
+	return func(b *Batcher) {
+		if n < 1 || period <= 0 {
+			b.interval = 0
+			return
+		}
+		b.interval = period / time.Duration(n)
+	}
+}
+
+// Batcher splits embedding requests into sub-batches that fit a provider's
+// batch limit and paces them to stay within its rate limit. Providers create
+// one Batcher and route every request through it.
+//
+// The zero value sends every batch in one request without pacing. Batcher is
+// safe for concurrent use; pacing is shared by all callers.
+type Batcher struct {
+	size     int           // 0 means no cap
+	interval time.Duration // 0 means no pacing
+
+	mu   sync.Mutex
+	next time.Time // earliest start of the next request
+}
+
+// NewBatcher creates a [Batcher] configured by opts.
+func NewBatcher(opts ...BatchOption) *Batcher {
+	b := &Batcher{}
+	for _, o := range opts {
+		o(b)
+	}
+	return b
+}
+
+// BatchSize returns the maximum number of texts per request, or 0 if there is
+// no cap.
+func (b *Batcher) BatchSize() int { return b.size }
+
+// Wait blocks until the rate limit allows the next request. It returns
+// ctx.Err() if ctx is done first.
+func (b *Batcher) Wait(ctx context.Context) error {
+	if b.interval <= 0 {
+		return ctx.Err()
+	}
+	b.mu.Lock()
+	now := time.Now()
+	start := b.next
+	if start.Before(now) {
+		start = now
+	}
+	b.next = start.Add(b.interval)
+	b.mu.Unlock()
+
+	wait := start.Sub(now)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// EmbedBatch embeds texts with embed, one paced request per sub-batch, and
+// returns the vectors in the order of texts. embed must return one vector per
+// text it is given. The first error stops the remaining requests; partial
+// results are not returned.
+func (b *Batcher) EmbedBatch(ctx context.Context, texts []string, embed func(ctx context.Context, texts []string) ([][]float32, error)) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	size := b.size
+	if size == 0 {
+		size = len(texts)
+	}
+	result := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		batch := texts[start:min(start+size, len(texts))]
+		if err := b.Wait(ctx); err != nil {
+			return nil, err
+		}
+		vecs, err := embed(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(vecs) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(vecs))
+		}
+		result = append(result, vecs...)
+	}
+	return result, nil
+}
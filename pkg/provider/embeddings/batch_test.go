@@ -0,0 +1,130 @@
+package embeddings_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
+)
+
+// texts returns n distinct texts.
+func texts(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprintf("text-%d", i)
+	}
+	return out
+}
+
+// recorder is an embed function that records the size of every request and
+// encodes each text's position in its vector.
+type recorder struct {
+	sizes []int
+	err   error
+}
+
+func (r *recorder) embed(_ context.Context, batch []string) ([][]float32, error) {
+	r.sizes = append(r.sizes, len(batch))
+	if r.err != nil {
+		return nil, r.err
+	}
+	vecs := make([][]float32, len(batch))
+	for i, text := range batch {
+		var n int
+		fmt.Sscanf(text, "text-%d", &n)
+		vecs[i] = []float32{float32(n)}
+	}
+	return vecs, nil
+}
+
+func TestBatcher_EmbedBatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		opts      []embeddings.BatchOption
+		n         int
+		wantSizes []int
+	}{
+		{name: "no cap", n: 250, wantSizes: []int{250}},
+		{name: "exact multiple", opts: []embeddings.BatchOption{embeddings.WithBatchSize(100)}, n: 300, wantSizes: []int{100, 100, 100}},
+		{name: "remainder", opts: []embeddings.BatchOption{embeddings.WithBatchSize(64)}, n: 150, wantSizes: []int{64, 64, 22}},
+		{name: "smaller than batch", opts: []embeddings.BatchOption{embeddings.WithBatchSize(64)}, n: 10, wantSizes: []int{10}},
+		{name: "invalid size ignored", opts: []embeddings.BatchOption{embeddings.WithBatchSize(-1)}, n: 5, wantSizes: []int{5}},
+		{name: "empty", opts: []embeddings.BatchOption{embeddings.WithBatchSize(64)}, n: 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &recorder{}
+			got, err := embeddings.NewBatcher(tc.opts...).EmbedBatch(context.Background(), texts(tc.n), r.embed)
+			if err != nil {
+				t.Fatalf("EmbedBatch: %v", err)
+			}
+			if !slices.Equal(r.sizes, tc.wantSizes) {
+				t.Errorf("request sizes = %v, want %v", r.sizes, tc.wantSizes)
+			}
+			if len(got) != tc.n {
+				t.Fatalf("len(result) = %d, want %d", len(got), tc.n)
+			}
+			for i, vec := range got {
+				if vec[0] != float32(i) {
+					t.Fatalf("result[%d] belongs to text %v; order not preserved", i, vec[0])
+				}
+			}
+		})
+	}
+}
+
+func TestBatcher_EmbedBatch_Errors(t *testing.T) {
+	t.Parallel()
+
+	errDown := errors.New("server down")
+	r := &recorder{err: errDown}
+	b := embeddings.NewBatcher(embeddings.WithBatchSize(10))
+	if _, err := b.EmbedBatch(context.Background(), texts(30), r.embed); !errors.Is(err, errDown) {
+		t.Errorf("error = %v, want %v", err, errDown)
+	}
+	if len(r.sizes) != 1 {
+		t.Errorf("requests = %d, want the first error to stop the rest", len(r.sizes))
+	}
+
+	short := func(context.Context, []string) ([][]float32, error) { return [][]float32{{1}}, nil }
+	if _, err := b.EmbedBatch(context.Background(), texts(3), short); err == nil {
+		t.Error("EmbedBatch with too few vectors: want error, got nil")
+	}
+}
+
+func TestBatcher_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	// 20 requests per second: one every 50ms.
+	const interval = 50 * time.Millisecond
+	b := embeddings.NewBatcher(embeddings.WithBatchSize(1), embeddings.WithRateLimit(20, time.Second))
+
+	var starts []time.Time
+	embed := func(_ context.Context, batch []string) ([][]float32, error) {
+		starts = append(starts, time.Now())
+		return make([][]float32, len(batch)), nil
+	}
+	if _, err := b.EmbedBatch(context.Background(), texts(4), embed); err != nil {
+		t.Fatalf("EmbedBatch: %v", err)
+	}
+	// Slots are reserved ahead, so a late timer may let the next request
+	// follow quickly; on average the requests are still paced.
+	if elapsed, want := starts[3].Sub(starts[0]), 3*interval; elapsed < want-5*time.Millisecond {
+		t.Errorf("4 requests took %v, want at least %v", elapsed, want)
+	}
+
+	// The pacing carries over to the next call and is cut short by ctx.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait with cancelled ctx = %v, want context.Canceled", err)
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 )
 
 // DefaultBaseURL is the default base URL for a locally running Ollama instance.
@@ -50,6 +51,7 @@ type Provider struct {
 	baseURL    string
 	model      string
 	httpClient *http.Client
+	batcher    *embeddings.Batcher
 
 	// dimensions holds the resolved vector length. When zero after construction,
 	// it is populated lazily by detectOnce.
@@ -62,6 +64,7 @@ type Provider struct {
 type config struct {
 	timeout    time.Duration
 	dimensions int
+	batching   []embeddings.BatchOption
 }
 
 // Option is a functional option for Provider.
@@ -84,6 +87,15 @@ func WithDimensions(dims int) Option {
 	}
 }
 
+// WithBatching configures how EmbedBatch splits and paces its requests, e.g.
+// WithBatching(embeddings.WithBatchSize(64)) to keep requests to a small
+// server short. By default every batch is sent in one unpaced request.
+func WithBatching(opts ...embeddings.BatchOption) Option {
+	return func(c *config) {
+		c.batching = append(c.batching, opts...)
+	}
+}
+
 // New constructs a new Ollama Provider.
 //
 // baseURL is the base URL of the Ollama server (e.g., "http://localhost:11434").
@@ -92,8 +104,8 @@ func WithDimensions(dims int) Option {
 // model is the Ollama model name to use for embeddings (e.g., "nomic-embed-text").
 // It must not be empty.
 //
-// Optional configuration is applied via functional options (see WithTimeout and
-// WithDimensions).
+// Optional configuration is applied via functional options (see WithTimeout,
+// WithDimensions and WithBatching).
 func New(baseURL string, model string, opts ...Option) (*Provider, error) {
 	if model == "" {
 		return nil, fmt.Errorf("ollama embeddings: model must not be empty")
@@ -109,7 +121,7 @@ func New(baseURL string, model string, opts ...Option) (*Provider, error) {
 		o(cfg)
 	}
 
-	httpClient := &http.Client{}
+	httpClient := &http.Client{Transport: ident.Transport(nil)}
 	if cfg.timeout > 0 {
 		httpClient.Timeout = cfg.timeout
 	}
@@ -118,6 +130,7 @@ func New(baseURL string, model string, opts ...Option) (*Provider, error) {
 		baseURL:    baseURL,
 		model:      model,
 		httpClient: httpClient,
+		batcher:    embeddings.NewBatcher(cfg.batching...),
 		dimensions: cfg.dimensions,
 	}
 
@@ -152,6 +165,9 @@ type embedResponse struct {
 // Returns an error if the HTTP request fails, the server returns a non-200 status,
 // the response cannot be decoded, or ctx is cancelled.
 func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := p.batcher.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("ollama embeddings: embed: %w", err)
+	}
 	vecs, err := p.callEmbed(ctx, []string{text})
 	if err != nil {
 		return nil, fmt.Errorf("ollama embeddings: embed: %w", err)
@@ -163,7 +179,8 @@ func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
 }
 
 // EmbedBatch implements embeddings.Provider by computing embedding vectors for
-// a slice of texts in a single Ollama /api/embed request.
+// a slice of texts in a single Ollama /api/embed request, or in several paced
+// requests if the batch is larger than the batch size (see WithBatching).
 //
 // The returned slice has the same length as texts and is ordered identically
 // (result[i] corresponds to texts[i]). On any error, nil is returned — partial
@@ -175,13 +192,10 @@ func (p *Provider) EmbedBatch(ctx context.Context, texts []string) ([][]float32,
 	if len(texts) == 0 {
 		return nil, nil
 	}
-	vecs, err := p.callEmbed(ctx, texts)
+	vecs, err := p.batcher.EmbedBatch(ctx, texts, p.callEmbed)
 	if err != nil {
 		return nil, fmt.Errorf("ollama embeddings: embed batch: %w", err)
 	}
-	if len(vecs) != len(texts) {
-		return nil, fmt.Errorf("ollama embeddings: embed batch: expected %d embeddings, got %d", len(texts), len(vecs))
-	}
 	return vecs, nil
 }
 
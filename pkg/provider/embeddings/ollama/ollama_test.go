@@ -5,15 +5,21 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
 	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings/ollama"
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 )
 
 // mockEmbedServer starts a test HTTP server that handles /api/embed requests
-// and returns canned embeddings. It verifies that the request model matches
-// wantModel and that the input count matches the number of responses provided.
+// and returns canned embeddings. It verifies that the request identifies
+// Glyphoxa, that the request model matches wantModel, and that the input
+// count matches the number of responses provided.
 //
 // responses must contain at least as many vectors as the maximum number of
 // inputs expected across all calls to this server.
@@ -30,6 +36,9 @@ func mockEmbedServer(t *testing.T, wantModel string, responses [][]float32) *htt
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
+		if ua := r.Header.Get("User-Agent"); ua != ident.UserAgent() {
+			t.Errorf("User-Agent: got %q, want %q", ua, ident.UserAgent())
+		}
 
 		var req struct {
 			Model string   `json:"model"`
@@ -140,6 +149,61 @@ func TestEmbedBatch(t *testing.T) {
 	}
 }
 
+// TestEmbedBatch_BatchSize verifies that a batch larger than the configured
+// batch size is sent as consecutive sub-batches and re-assembled in order.
+func TestEmbedBatch_BatchSize(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		sizes []int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		sizes = append(sizes, len(req.Input))
+		mu.Unlock()
+		// Encode each input's number in its vector.
+		vecs := make([][]float32, len(req.Input))
+		for i, in := range req.Input {
+			n, _ := strconv.Atoi(in)
+			vecs[i] = []float32{float32(n)}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"model": "nomic-embed-text", "embeddings": vecs})
+	}))
+	defer srv.Close()
+
+	p, err := ollama.New(srv.URL, "nomic-embed-text", ollama.WithBatching(embeddings.WithBatchSize(50)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	texts := make([]string, 120)
+	for i := range texts {
+		texts[i] = strconv.Itoa(i)
+	}
+	got, err := p.EmbedBatch(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedBatch: %v", err)
+	}
+
+	if want := []int{50, 50, 20}; !slices.Equal(sizes, want) {
+		t.Errorf("request sizes = %v, want %v", sizes, want)
+	}
+	if len(got) != len(texts) {
+		t.Fatalf("length: got %d, want %d", len(got), len(texts))
+	}
+	for i, vec := range got {
+		if vec[0] != float32(i) {
+			t.Fatalf("vec[%d] belongs to text %v; order not preserved", i, vec[0])
+		}
+	}
+}
+
 // TestEmbedBatch_Empty verifies that passing a nil or empty slice returns
 // (nil, nil) without issuing any network request.
 func TestEmbedBatch_Empty(t *testing.T) {
@@ -217,6 +281,15 @@ func TestDimensions_AutoDetect(t *testing.T) {
 	if callCount != 1 {
 		t.Errorf("expected exactly 1 probe request, got %d", callCount)
 	}
+
+	// The reported dimension must match what the model actually returns.
+	vec, err := p.Embed(context.Background(), "The innkeeper knows everyone.")
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(vec) != p.Dimensions() {
+		t.Errorf("len(Embed()) = %d, Dimensions() = %d", len(vec), p.Dimensions())
+	}
 }
 
 // TestDimensions_WithDimensionsOption verifies that WithDimensions bypasses
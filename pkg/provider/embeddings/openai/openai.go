@@ -4,8 +4,10 @@ package openai
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	oai "github.com/openai/openai-go"
@@ -13,18 +15,34 @@ import (
 	"github.com/openai/openai-go/packages/param"
 
 	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 )
 
 // DefaultModel is the default OpenAI embeddings model.
 const DefaultModel = oai.EmbeddingModelTextEmbedding3Small
 
+// DefaultBatchSize is the maximum number of inputs OpenAI accepts in one
+// embeddings request. EmbedBatch splits larger batches unless [WithBatching]
+// sets a different size.
+const DefaultBatchSize = 2048
+
 // Ensure Provider implements the embeddings.Provider interface.
 var _ embeddings.Provider = (*Provider)(nil)
 
+// probeTimeout bounds the probe request Dimensions issues for models missing
+// from the built-in table.
+const probeTimeout = 10 * time.Second
+
 // Provider implements embeddings.Provider using the OpenAI API.
 type Provider struct {
-	client oai.Client
-	model  string
+	client  oai.Client
+	model   string
+	batcher *embeddings.Batcher
+
+	// probedDims is the vector length measured by the probe request for a
+	// model missing from the built-in table. Written once under detectOnce.
+	probedDims int
+	detectOnce sync.Once
 }
 
 // config holds optional configuration for the provider.
@@ -32,6 +50,7 @@ type config struct {
 	baseURL      string
 	organization string
 	timeout      time.Duration
+	batching     []embeddings.BatchOption
 }
 
 // Option is a functional option for Provider.
@@ -58,6 +77,16 @@ func WithTimeout(d time.Duration) Option {
 	}
 }
 
+// WithBatching configures how EmbedBatch splits and paces its requests, e.g.
+// WithBatching(embeddings.WithBatchSize(256), embeddings.WithRateLimit(3000, time.Minute)).
+// The batch size defaults to [DefaultBatchSize]; requests are not paced by
+// default.
+func WithBatching(opts ...embeddings.BatchOption) Option {
+	return func(c *config) {
+		c.batching = append(c.batching, opts...)
+	}
+}
+
 // New constructs a new OpenAI Embeddings Provider.
 // If model is empty, DefaultModel (text-embedding-3-small) is used.
 func New(apiKey string, model string, opts ...Option) (*Provider, error) {
@@ -82,18 +111,22 @@ func New(apiKey string, model string, opts ...Option) (*Provider, error) {
 	if cfg.organization != "" {
 		reqOpts = append(reqOpts, option.WithOrganization(cfg.organization))
 	}
-	if cfg.timeout > 0 {
-		reqOpts = append(reqOpts, option.WithHTTPClient(&http.Client{
-			Timeout: cfg.timeout,
-		}))
-	}
+	// A zero timeout leaves the client without one, like the SDK default.
+	reqOpts = append(reqOpts, option.WithHTTPClient(&http.Client{
+		Timeout:   cfg.timeout,
+		Transport: ident.Transport(nil),
+	}))
 
 	client := oai.NewClient(reqOpts...)
-	return &Provider{client: client, model: model}, nil
+	batching := append([]embeddings.BatchOption{embeddings.WithBatchSize(DefaultBatchSize)}, cfg.batching...)
+	return &Provider{client: client, model: model, batcher: embeddings.NewBatcher(batching...)}, nil
 }
 
 // Embed implements embeddings.Provider.
 func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := p.batcher.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("openai embeddings: embed: %w", err)
+	}
 	resp, err := p.client.Embeddings.New(ctx, oai.EmbeddingNewParams{
 		Model: p.model,
 		Input: oai.EmbeddingNewParamsInputUnion{
@@ -109,12 +142,18 @@ func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
 	return float64ToFloat32(resp.Data[0].Embedding), nil
 }
 
-// EmbedBatch implements embeddings.Provider.
+// EmbedBatch implements embeddings.Provider. Batches larger than the batch
+// size are sent as several paced requests (see [WithBatching]).
 func (p *Provider) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
-	if len(texts) == 0 {
-		return nil, nil
+	result, err := p.batcher.EmbedBatch(ctx, texts, p.embedBatch)
+	if err != nil {
+		return nil, fmt.Errorf("openai embeddings: embed batch: %w", err)
 	}
+	return result, nil
+}
 
+// embedBatch embeds texts in a single request.
+func (p *Provider) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
 	resp, err := p.client.Embeddings.New(ctx, oai.EmbeddingNewParams{
 		Model: p.model,
 		Input: oai.EmbeddingNewParamsInputUnion{
@@ -122,16 +161,16 @@ func (p *Provider) EmbedBatch(ctx context.Context, texts []string) ([][]float32,
 		},
 	})
 	if err != nil {
-		return nil, fmt.Errorf("openai embeddings: embed batch: %w", err)
+		return nil, err
 	}
 	if len(resp.Data) != len(texts) {
-		return nil, fmt.Errorf("openai embeddings: expected %d embeddings, got %d", len(texts), len(resp.Data))
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
 	}
 
 	result := make([][]float32, len(texts))
 	for _, e := range resp.Data {
 		if int(e.Index) >= len(texts) {
-			return nil, fmt.Errorf("openai embeddings: unexpected index %d", e.Index)
+			return nil, fmt.Errorf("unexpected index %d", e.Index)
 		}
 		result[e.Index] = float64ToFloat32(e.Embedding)
 	}
@@ -139,8 +178,26 @@ func (p *Provider) EmbedBatch(ctx context.Context, texts []string) ([][]float32,
 }
 
 // Dimensions implements embeddings.Provider.
+//
+// Known models are looked up in a built-in table. For any other model (e.g.
+// one served by an OpenAI-compatible server) a single probe embedding is
+// requested on the first call and its length is cached. Returns 0 if the
+// probe fails.
 func (p *Provider) Dimensions() int {
-	return modelDimensions(p.model)
+	if d := modelDimensions(p.model); d != 0 {
+		return d
+	}
+	p.detectOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		defer cancel()
+		vec, err := p.Embed(ctx, "probe")
+		if err != nil {
+			slog.Warn("openai embeddings: probe for dimensions failed", "model", p.model, "err", err)
+			return
+		}
+		p.probedDims = len(vec)
+	})
+	return p.probedDims
 }
 
 // ModelID implements embeddings.Provider.
@@ -148,7 +205,8 @@ func (p *Provider) ModelID() string {
 	return p.model
 }
 
-// modelDimensions returns the embedding dimensions for known OpenAI models.
+// modelDimensions returns the embedding dimensions for known OpenAI models, or
+// 0 for models that have to be probed.
 func modelDimensions(model string) int {
 	lower := strings.ToLower(model)
 	switch {
@@ -159,7 +217,7 @@ func modelDimensions(model string) int {
 	case strings.Contains(lower, "text-embedding-ada-002"):
 		return 1536
 	default:
-		return 1536 // sensible default for unknown models
+		return 0
 	}
 }
 
@@ -1,7 +1,17 @@
 package openai
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/embeddings"
 )
 
 // TestModelDimensions_TextEmbedding3Small verifies 1536 dims for 3-small.
@@ -28,11 +38,11 @@ func TestModelDimensions_Ada002(t *testing.T) {
 	}
 }
 
-// TestModelDimensions_Unknown verifies that unknown models return a positive default.
+// TestModelDimensions_Unknown verifies that unknown models are left to the probe.
 func TestModelDimensions_Unknown(t *testing.T) {
 	d := modelDimensions("some-future-model")
-	if d <= 0 {
-		t.Errorf("unknown model: expected positive dimensions, got %d", d)
+	if d != 0 {
+		t.Errorf("unknown model: expected 0 dimensions, got %d", d)
 	}
 }
 
@@ -111,3 +121,175 @@ func TestFloat64ToFloat32(t *testing.T) {
 		}
 	}
 }
+
+// embeddingServer starts a fake OpenAI embeddings endpoint that answers every
+// input with a vector of length dim and counts the requests it receives.
+func embeddingServer(t *testing.T, dim int, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var req struct {
+			Model string `json:"model"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"object": "list",
+			"model":  req.Model,
+			"data": []map[string]any{
+				{"object": "embedding", "index": 0, "embedding": make([]float64, dim)},
+			},
+			"usage": map[string]any{"prompt_tokens": 1, "total_tokens": 1},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestDimensions_ProbeUnknownModel verifies that the dimension of a model
+// missing from the table is measured once and matches an embedding's length.
+func TestDimensions_ProbeUnknownModel(t *testing.T) {
+	const dim = 384
+	var requests atomic.Int32
+	srv := embeddingServer(t, dim, &requests)
+
+	p, err := New("sk-test", "local-minilm", WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for i := range 3 {
+		if got := p.Dimensions(); got != dim {
+			t.Errorf("call %d: Dimensions() = %d, want %d", i, got, dim)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("probe requests = %d, want 1", n)
+	}
+
+	vec, err := p.Embed(context.Background(), "The innkeeper knows everyone.")
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(vec) != p.Dimensions() {
+		t.Errorf("len(Embed()) = %d, Dimensions() = %d", len(vec), p.Dimensions())
+	}
+}
+
+// TestDimensions_KnownModelSkipsProbe verifies that a model in the table is
+// answered without a request.
+func TestDimensions_KnownModelSkipsProbe(t *testing.T) {
+	var requests atomic.Int32
+	srv := embeddingServer(t, 1536, &requests)
+
+	p, err := New("sk-test", "text-embedding-3-small", WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got := p.Dimensions(); got != 1536 {
+		t.Errorf("Dimensions() = %d, want 1536", got)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("requests = %d, want 0", n)
+	}
+}
+
+// TestDimensions_ProbeFailure verifies that a failed probe reports 0.
+func TestDimensions_ProbeFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"no such model"}}`, http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	p, err := New("sk-test", "missing-model", WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got := p.Dimensions(); got != 0 {
+		t.Errorf("Dimensions() = %d, want 0", got)
+	}
+}
+
+// batchServer starts a fake OpenAI embeddings endpoint that answers every
+// input with a vector holding the input's number and records the number of
+// inputs of each request.
+func batchServer(t *testing.T, mu *sync.Mutex, sizes *[]int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		*sizes = append(*sizes, len(req.Input))
+		mu.Unlock()
+		data := make([]map[string]any, len(req.Input))
+		for i, in := range req.Input {
+			n, _ := strconv.Atoi(in)
+			data[i] = map[string]any{"object": "embedding", "index": i, "embedding": []float64{float64(n)}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"object": "list",
+			"model":  req.Model,
+			"data":   data,
+			"usage":  map[string]any{"prompt_tokens": 1, "total_tokens": 1},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestEmbedBatch_BatchSize verifies that large batches are split into
+// sub-batches of the configured size, or of OpenAI's limit by default, and
+// re-assembled in order.
+func TestEmbedBatch_BatchSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		n         int
+		wantSizes []int
+	}{
+		{name: "default limit", n: 5000, wantSizes: []int{DefaultBatchSize, DefaultBatchSize, 5000 - 2*DefaultBatchSize}},
+		{name: "configured", opts: []Option{WithBatching(embeddings.WithBatchSize(100))}, n: 250, wantSizes: []int{100, 100, 50}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mu    sync.Mutex
+				sizes []int
+			)
+			srv := batchServer(t, &mu, &sizes)
+			p, err := New("sk-test", "text-embedding-3-small", append([]Option{WithBaseURL(srv.URL)}, tc.opts...)...)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			texts := make([]string, tc.n)
+			for i := range texts {
+				texts[i] = strconv.Itoa(i)
+			}
+			got, err := p.EmbedBatch(context.Background(), texts)
+			if err != nil {
+				t.Fatalf("EmbedBatch: %v", err)
+			}
+			if !slices.Equal(sizes, tc.wantSizes) {
+				t.Errorf("request sizes = %v, want %v", sizes, tc.wantSizes)
+			}
+			if len(got) != tc.n {
+				t.Fatalf("len(EmbedBatch()) = %d, want %d", len(got), tc.n)
+			}
+			for i, vec := range got {
+				if vec[0] != float32(i) {
+					t.Fatalf("vec[%d] belongs to text %v; order not preserved", i, vec[0])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Package ident identifies Glyphoxa on requests to provider APIs.
+//
+// Every REST and websocket request a provider sends carries a
+// "User-Agent: glyphoxa/<version>" header, so upstream support teams can tell
+// Glyphoxa traffic apart when debugging rate limits or errors. Providers add it
+// by sending HTTP requests through [Transport] and by passing websocket
+// handshake headers through [Header].
+//
+// Requests can additionally be tagged with the Glyphoxa session they belong
+// to. The tag is read from the request context, so it is only sent when the
+// caller attached one with [WithSession]; sessions do so only when tagging is
+// enabled in the configuration.
+package ident
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// SessionHeader is the header that carries the session tag.
+const SessionHeader = "X-Glyphoxa-Session"
+
+// Version is the Glyphoxa version reported in the User-Agent header. When
+// empty, the main module version recorded in the binary's build information
+// is used, falling back to "dev". Release builds may set it with
+//
+//	-ldflags "-X github.com/MrWong99/glyphoxa/pkg/provider/ident.Version=v1.2.3"
+var Version string
+
+// buildVersion caches the version read from the build information.
+var buildVersion = sync.OnceValue(func() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v := info.Main.Version; v != "" && v != "(devel)" {
+			return v
+		}
+	}
+	return "dev"
+})
+
+// UserAgent returns the User-Agent product token, "glyphoxa/<version>".
+func UserAgent() string {
+	v := Version
+	if v == "" {
+		v = buildVersion()
+	}
+	return "glyphoxa/" + v
+}
+
+// sessionKey is the context key of the session tag.
+type sessionKey struct{}
+
+// WithSession returns a copy of ctx that tags provider requests made with it
+// with the session tag. An empty tag leaves ctx unchanged.
+func WithSession(ctx context.Context, tag string) context.Context {
+	if tag == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, tag)
+}
+
+// Session returns the session tag attached to ctx with [WithSession], or the
+// empty string.
+func Session(ctx context.Context) string {
+	tag, _ := ctx.Value(sessionKey{}).(string)
+	return tag
+}
+
+// Header adds the identification headers for a request made with ctx to h and
+// returns it. A nil h is allocated. A User-Agent already present in h, such as
+// one set by a vendor SDK, is kept after Glyphoxa's product token.
+func Header(ctx context.Context, h http.Header) http.Header {
+	if h == nil {
+		h = http.Header{}
+	}
+	ua := UserAgent()
+	if cur := h.Get("User-Agent"); cur != "" && !strings.HasPrefix(cur, ua) {
+		ua += " " + cur
+	} else if cur != "" {
+		ua = cur
+	}
+	h.Set("User-Agent", ua)
+	if tag := Session(ctx); tag != "" {
+		h.Set(SessionHeader, tag)
+	}
+	return h
+}
+
+// Transport returns an [http.RoundTripper] that adds the identification
+// headers to every request before passing it to base. A nil base uses
+// [http.DefaultTransport].
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+// transport is the [http.RoundTripper] returned by [Transport].
+type transport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements [http.RoundTripper]. The request is cloned, as round
+// trippers must not modify it.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	Header(req.Context(), req.Header)
+	return t.base.RoundTrip(req)
+}
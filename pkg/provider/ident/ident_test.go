@@ -0,0 +1,109 @@
+package ident_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
+)
+
+func TestUserAgent(t *testing.T) {
+	t.Parallel()
+
+	ua := ident.UserAgent()
+	if !strings.HasPrefix(ua, "glyphoxa/") || len(ua) == len("glyphoxa/") {
+		t.Errorf("UserAgent() = %q, want glyphoxa/<version>", ua)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	t.Parallel()
+
+	ua := ident.UserAgent()
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		in          http.Header
+		wantUA      string
+		wantSession string
+	}{
+		{
+			name:   "nil header without session",
+			ctx:    context.Background(),
+			wantUA: ua,
+		},
+		{
+			name:        "session tag",
+			ctx:         ident.WithSession(context.Background(), "session-tavern-20261017T1900Z"),
+			in:          http.Header{"Authorization": {"Token secret"}},
+			wantUA:      ua,
+			wantSession: "session-tavern-20261017T1900Z",
+		},
+		{
+			name:   "empty session tag ignored",
+			ctx:    ident.WithSession(context.Background(), ""),
+			wantUA: ua,
+		},
+		{
+			name:   "SDK user agent kept",
+			ctx:    context.Background(),
+			in:     http.Header{"User-Agent": {"Anthropic/Go 1.0"}},
+			wantUA: ua + " Anthropic/Go 1.0",
+		},
+		{
+			name:   "already identified",
+			ctx:    context.Background(),
+			in:     http.Header{"User-Agent": {ua + " Anthropic/Go 1.0"}},
+			wantUA: ua + " Anthropic/Go 1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := ident.Header(tc.ctx, tc.in)
+			if got := h.Get("User-Agent"); got != tc.wantUA {
+				t.Errorf("User-Agent = %q, want %q", got, tc.wantUA)
+			}
+			if got := h.Get(ident.SessionHeader); got != tc.wantSession {
+				t.Errorf("%s = %q, want %q", ident.SessionHeader, got, tc.wantSession)
+			}
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: ident.Transport(nil)}
+	ctx := ident.WithSession(context.Background(), "session-1")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if ua := got.Get("User-Agent"); ua != ident.UserAgent() {
+		t.Errorf("User-Agent = %q, want %q", ua, ident.UserAgent())
+	}
+	if tag := got.Get(ident.SessionHeader); tag != "session-1" {
+		t.Errorf("%s = %q, want %q", ident.SessionHeader, tag, "session-1")
+	}
+	if req.Header.Get("User-Agent") != "" {
+		t.Error("Transport modified the caller's request")
+	}
+}
@@ -0,0 +1,484 @@
+// Package anthropic provides a native Anthropic Messages API provider built on
+// the official SDK. It implements the llm.Provider interface.
+//
+// The generic any-llm adapter flattens Anthropic's content blocks into
+// OpenAI-style messages, which loses what Anthropic needs to validate a
+// conversation: signed thinking blocks must be returned ahead of the tool_use
+// blocks they led to, all tool_result blocks of a round must open the next
+// user turn, and an assistant prefill may neither end in whitespace nor be
+// combined with extended thinking. This provider keeps the blocks intact:
+//
+//   - Thinking is streamed separately from the reply text. It never appears in
+//     [llm.Chunk.Text]; the complete, signed blocks are attached to the final
+//     chunk as [llm.Chunk.Thinking] and replayed from [llm.Message.Thinking].
+//   - Consecutive messages of the same role are merged into one turn, thinking
+//     blocks first in assistant turns and tool results first in user turns.
+//   - A trailing assistant message is sent as a prefill with trailing
+//     whitespace removed. With extended thinking enabled, where prefill is not
+//     allowed, it is moved into the system prompt as the reply's beginning.
+//
+// Typical usage:
+//
+//	p, err := anthropic.New("claude-sonnet-4-5",
+//	    anthropic.WithAPIKey(key),
+//	    anthropic.WithThinkingBudget(2048),
+//	)
+package anthropic
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	anthropicsdk "github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+// Compile-time interface assertion.
+var _ llm.Provider = (*Provider)(nil)
+
+const (
+	// DefaultMaxTokens is the completion token limit used when neither the
+	// request nor [WithMaxTokens] sets one. The Messages API requires a limit.
+	DefaultMaxTokens = 4096
+
+	// MinThinkingBudget is the smallest extended-thinking budget the API accepts.
+	MinThinkingBudget = 1024
+
+	envAPIKey = "ANTHROPIC_API_KEY"
+
+	// prefillInstruction replaces an assistant prefill when extended thinking
+	// is enabled, which does not allow prefilling the reply.
+	prefillInstruction = "Your reply has already begun with the following words, which the listener has heard: %q. Continue directly from them without repeating them."
+)
+
+// Option is a functional option for configuring an Anthropic Provider.
+type Option func(*Provider)
+
+// WithAPIKey sets the API key. Defaults to the ANTHROPIC_API_KEY environment
+// variable.
+func WithAPIKey(key string) Option {
+	return func(p *Provider) {
+		p.apiKey = key
+	}
+}
+
+// WithBaseURL overrides the API base URL (default "https://api.anthropic.com").
+func WithBaseURL(url string) Option {
+	return func(p *Provider) {
+		p.baseURL = url
+	}
+}
+
+// WithMaxTokens sets the completion token limit used when a request does not
+// set MaxTokens. Defaults to [DefaultMaxTokens]. Values < 1 are ignored.
+func WithMaxTokens(n int) Option {
+	return func(p *Provider) {
+		if n > 0 {
+			p.maxTokens = n
+		}
+	}
+}
+
+// WithThinkingBudget enables extended thinking with a budget of n tokens.
+// n must be at least [MinThinkingBudget]; 0 disables extended thinking (the
+// default). The completion token limit is raised above the budget when
+// necessary, and the request temperature is not sent, as the API requires.
+func WithThinkingBudget(n int) Option {
+	return func(p *Provider) {
+		p.thinkingBudget = n
+	}
+}
+
+// Provider implements llm.Provider backed by the Anthropic Messages API.
+// It is safe for concurrent use.
+type Provider struct {
+	client         anthropicsdk.Client
+	model          string
+	apiKey         string
+	baseURL        string
+	maxTokens      int
+	thinkingBudget int
+}
+
+// New creates an Anthropic Provider for model (e.g., "claude-sonnet-4-5").
+// It returns an error if model is empty, no API key is configured, or the
+// thinking budget is invalid.
+func New(model string, opts ...Option) (*Provider, error) {
+	if model == "" {
+		return nil, errors.New("anthropic: model must not be empty")
+	}
+	p := &Provider{
+		model:     model,
+		maxTokens: DefaultMaxTokens,
+	}
+	for _, o := range opts {
+		o(p)
+	}
+
+	if p.apiKey == "" {
+		p.apiKey = os.Getenv(envAPIKey)
+	}
+	if p.apiKey == "" {
+		return nil, fmt.Errorf("anthropic: no API key configured (set api_key or %s)", envAPIKey)
+	}
+	if p.thinkingBudget != 0 && p.thinkingBudget < MinThinkingBudget {
+		return nil, fmt.Errorf("anthropic: thinking budget %d is below the minimum of %d", p.thinkingBudget, MinThinkingBudget)
+	}
+
+	clientOpts := []option.RequestOption{
+		option.WithAPIKey(p.apiKey),
+		option.WithHTTPClient(&http.Client{Transport: ident.Transport(nil)}),
+	}
+	if p.baseURL != "" {
+		clientOpts = append(clientOpts, option.WithBaseURL(p.baseURL))
+	}
+	p.client = anthropicsdk.NewClient(clientOpts...)
+	return p, nil
+}
+
+// StreamCompletion implements llm.Provider. Text deltas are emitted as they
+// arrive; the final chunk carries the finish reason, the complete tool calls,
+// and the signed thinking blocks.
+func (p *Provider) StreamCompletion(ctx context.Context, req llm.CompletionRequest) (<-chan llm.Chunk, error) {
+	params := p.buildParams(req)
+	stream := p.client.Messages.NewStreaming(ctx, params)
+
+	ch := make(chan llm.Chunk, 32)
+	go func() {
+		defer close(ch)
+		defer stream.Close()
+
+		send := func(c llm.Chunk) bool {
+			select {
+			case ch <- c:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var msg anthropicsdk.Message
+		for stream.Next() {
+			event := stream.Current()
+			if err := msg.Accumulate(event); err != nil {
+				send(llm.Chunk{FinishReason: "error", Text: fmt.Sprintf("anthropic: %v", err)})
+				return
+			}
+			switch ev := event.AsAny().(type) {
+			case anthropicsdk.ContentBlockDeltaEvent:
+				// Only text deltas are spoken; thinking and tool input
+				// deltas are delivered whole on the final chunk.
+				if d, ok := ev.Delta.AsAny().(anthropicsdk.TextDelta); ok && d.Text != "" {
+					if !send(llm.Chunk{Text: d.Text}) {
+						return
+					}
+				}
+			case anthropicsdk.MessageStopEvent:
+				final := convertMessage(&msg)
+				send(llm.Chunk{
+					FinishReason: final.finishReason,
+					ToolCalls:    final.toolCalls,
+					Thinking:     final.thinking,
+				})
+				return
+			}
+		}
+		if err := stream.Err(); err != nil {
+			send(llm.Chunk{FinishReason: "error", Text: fmt.Sprintf("anthropic: %v", err)})
+		}
+	}()
+	return ch, nil
+}
+
+// Complete implements llm.Provider.
+func (p *Provider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	msg, err := p.client.Messages.New(ctx, p.buildParams(req))
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: completion: %w", err)
+	}
+	out := convertMessage(msg)
+	return &llm.CompletionResponse{
+		Content:   out.text,
+		ToolCalls: out.toolCalls,
+		Thinking:  out.thinking,
+		Usage: llm.Usage{
+			PromptTokens:     int(msg.Usage.InputTokens),
+			CompletionTokens: int(msg.Usage.OutputTokens),
+			TotalTokens:      int(msg.Usage.InputTokens + msg.Usage.OutputTokens),
+		},
+	}, nil
+}
+
+// CountTokens implements llm.Provider with the same rough approximation as
+// the other providers: ~4 characters per token plus a per-message overhead.
+func (p *Provider) CountTokens(messages []llm.Message) (int, error) {
+	total := 0
+	for _, m := range messages {
+		chars := len(m.Content)
+		for _, tc := range m.ToolCalls {
+			chars += len(tc.Name) + len(tc.Arguments)
+		}
+		for _, tb := range m.Thinking {
+			chars += len(tb.Text)
+		}
+		total += (chars+3)/4 + 4
+	}
+	return total, nil
+}
+
+// Capabilities implements llm.Provider.
+func (p *Provider) Capabilities() llm.ModelCapabilities {
+	caps := llm.ModelCapabilities{
+		ContextWindow:       200_000,
+		MaxOutputTokens:     8_192,
+		SupportsToolCalling: true,
+		SupportsVision:      true,
+		SupportsStreaming:   true,
+	}
+	if strings.Contains(strings.ToLower(p.model), "claude-3-opus") {
+		caps.MaxOutputTokens = 4_096
+	}
+	return caps
+}
+
+// ---- request conversion ----
+
+// buildParams converts req into Messages API parameters.
+func (p *Provider) buildParams(req llm.CompletionRequest) anthropicsdk.MessageNewParams {
+	thinking := p.thinkingBudget > 0
+
+	system := []string{}
+	if req.SystemPrompt != "" {
+		system = append(system, req.SystemPrompt)
+	}
+	for _, m := range req.Messages {
+		if m.Role == "system" && m.Content != "" {
+			system = append(system, m.Content)
+		}
+	}
+
+	history, prefill := splitPrefill(req.Messages)
+	messages := convertMessages(history)
+	if prefill != "" {
+		if thinking {
+			system = append(system, fmt.Sprintf(prefillInstruction, prefill))
+		} else {
+			messages = appendTurn(messages, anthropicsdk.MessageParamRoleAssistant, anthropicsdk.NewTextBlock(prefill))
+		}
+	}
+
+	maxTokens := cmp.Or(req.MaxTokens, p.maxTokens)
+	if thinking && maxTokens <= p.thinkingBudget {
+		maxTokens = p.thinkingBudget + p.maxTokens
+	}
+
+	params := anthropicsdk.MessageNewParams{
+		Model:     anthropicsdk.Model(cmp.Or(req.Model, p.model)),
+		Messages:  messages,
+		MaxTokens: int64(maxTokens),
+	}
+	if len(req.Stop) > 0 {
+		params.StopSequences = req.Stop
+	}
+	if len(system) > 0 {
+		params.System = []anthropicsdk.TextBlockParam{{Text: strings.Join(system, "\n\n")}}
+	}
+	if thinking {
+		params.Thinking = anthropicsdk.ThinkingConfigParamOfEnabled(int64(p.thinkingBudget))
+	} else if req.Temperature != 0 {
+		params.Temperature = anthropicsdk.Float(req.Temperature)
+	}
+	for _, td := range req.Tools {
+		params.Tools = append(params.Tools, convertTool(td))
+	}
+	return params
+}
+
+// splitPrefill separates a trailing assistant message without tool calls —
+// the forced beginning of the reply — from the history. The prefill is
+// returned without trailing whitespace, which the API rejects.
+func splitPrefill(msgs []llm.Message) (history []llm.Message, prefill string) {
+	if n := len(msgs); n > 0 && msgs[n-1].Role == "assistant" && len(msgs[n-1].ToolCalls) == 0 {
+		return msgs[:n-1], strings.TrimRight(msgs[n-1].Content, " \t\r\n")
+	}
+	return msgs, ""
+}
+
+// convertMessages converts the conversation history into Messages API turns.
+// System messages are skipped; they are part of the system prompt.
+func convertMessages(msgs []llm.Message) []anthropicsdk.MessageParam {
+	var out []anthropicsdk.MessageParam
+	for _, m := range msgs {
+		switch m.Role {
+		case "user":
+			if m.Content != "" {
+				out = appendTurn(out, anthropicsdk.MessageParamRoleUser, anthropicsdk.NewTextBlock(m.Content))
+			}
+		case "tool":
+			out = appendTurn(out, anthropicsdk.MessageParamRoleUser,
+				anthropicsdk.NewToolResultBlock(m.ToolCallID, m.Content, false))
+		case "assistant":
+			var blocks []anthropicsdk.ContentBlockParamUnion
+			for _, tb := range m.Thinking {
+				if tb.Redacted != "" {
+					blocks = append(blocks, anthropicsdk.NewRedactedThinkingBlock(tb.Redacted))
+				} else {
+					blocks = append(blocks, anthropicsdk.NewThinkingBlock(tb.Signature, tb.Text))
+				}
+			}
+			if strings.TrimSpace(m.Content) != "" {
+				blocks = append(blocks, anthropicsdk.NewTextBlock(m.Content))
+			}
+			for _, tc := range m.ToolCalls {
+				blocks = append(blocks, anthropicsdk.NewToolUseBlock(tc.ID, toolInput(tc.Arguments), tc.Name))
+			}
+			out = appendTurn(out, anthropicsdk.MessageParamRoleAssistant, blocks...)
+		}
+	}
+	return out
+}
+
+// appendTurn appends blocks as a turn of role, merging them into the last
+// turn if it has the same role. Within a merged turn, thinking blocks lead
+// assistant turns and tool results lead user turns, as the API requires.
+func appendTurn(turns []anthropicsdk.MessageParam, role anthropicsdk.MessageParamRole, blocks ...anthropicsdk.ContentBlockParamUnion) []anthropicsdk.MessageParam {
+	if len(blocks) == 0 {
+		return turns
+	}
+	n := len(turns)
+	if n == 0 || turns[n-1].Role != role {
+		return append(turns, anthropicsdk.MessageParam{Role: role, Content: blocks})
+	}
+
+	merged := append(slices.Clone(turns[n-1].Content), blocks...)
+	leads := func(b anthropicsdk.ContentBlockParamUnion) bool {
+		if role == anthropicsdk.MessageParamRoleAssistant {
+			return b.OfThinking != nil || b.OfRedactedThinking != nil
+		}
+		return b.OfToolResult != nil
+	}
+	slices.SortStableFunc(merged, func(a, b anthropicsdk.ContentBlockParamUnion) int {
+		switch {
+		case leads(a) && !leads(b):
+			return -1
+		case leads(b) && !leads(a):
+			return 1
+		}
+		return 0
+	})
+	turns[n-1].Content = merged
+	return turns
+}
+
+// toolInput returns the JSON-encoded arguments as a tool_use input object,
+// substituting an empty object for missing or malformed arguments.
+func toolInput(args string) json.RawMessage {
+	if strings.HasPrefix(strings.TrimSpace(args), "{") && json.Valid([]byte(args)) {
+		return json.RawMessage(args)
+	}
+	return json.RawMessage("{}")
+}
+
+// convertTool converts a tool definition. The JSON Schema's properties and
+// required fields map to the input schema; any other keywords are passed
+// through unchanged.
+func convertTool(td llm.ToolDefinition) anthropicsdk.ToolUnionParam {
+	schema := anthropicsdk.ToolInputSchemaParam{}
+	for k, v := range td.Parameters {
+		switch k {
+		case "type":
+		case "properties":
+			schema.Properties = v
+		case "required":
+			schema.Required = toStrings(v)
+		default:
+			if schema.ExtraFields == nil {
+				schema.ExtraFields = map[string]any{}
+			}
+			schema.ExtraFields[k] = v
+		}
+	}
+	tool := &anthropicsdk.ToolParam{Name: td.Name, InputSchema: schema}
+	if td.Description != "" {
+		tool.Description = anthropicsdk.String(td.Description)
+	}
+	return anthropicsdk.ToolUnionParam{OfTool: tool}
+}
+
+// toStrings converts a JSON Schema "required" value to a string slice.
+func toStrings(v any) []string {
+	switch vs := v.(type) {
+	case []string:
+		return vs
+	case []any:
+		out := make([]string, 0, len(vs))
+		for _, e := range vs {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// ---- response conversion ----
+
+// reply is the provider-neutral content of a response message.
+type reply struct {
+	text         string
+	toolCalls    []llm.ToolCall
+	thinking     []llm.ThinkingBlock
+	finishReason string
+}
+
+// convertMessage extracts the text, tool calls, and thinking blocks of msg.
+func convertMessage(msg *anthropicsdk.Message) reply {
+	var (
+		out  reply
+		text strings.Builder
+	)
+	for _, block := range msg.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "thinking":
+			out.thinking = append(out.thinking, llm.ThinkingBlock{Text: block.Thinking, Signature: block.Signature})
+		case "redacted_thinking":
+			out.thinking = append(out.thinking, llm.ThinkingBlock{Redacted: block.Data})
+		case "tool_use":
+			out.toolCalls = append(out.toolCalls, llm.ToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: string(toolInput(string(block.Input))),
+			})
+		}
+	}
+	out.text = text.String()
+	out.finishReason = finishReason(msg.StopReason)
+	return out
+}
+
+// finishReason maps an Anthropic stop reason to the llm.Chunk convention.
+func finishReason(r anthropicsdk.StopReason) string {
+	switch r {
+	case anthropicsdk.StopReasonMaxTokens:
+		return "length"
+	case anthropicsdk.StopReasonToolUse:
+		return "tool_calls"
+	case anthropicsdk.StopReasonRefusal:
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
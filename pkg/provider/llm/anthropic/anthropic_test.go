@@ -0,0 +1,453 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
+	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
+)
+
+// toolUseStream is an Anthropic-shaped SSE stream in which the model thinks,
+// says one sentence, and then calls a tool.
+var toolUseStream = []string{
+	`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-test","content":[],"stop_reason":null,"usage":{"input_tokens":25,"output_tokens":1}}}`,
+	`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":"","signature":""}}`,
+	`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"The player asks about the vault. "}}`,
+	`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"I should look it up."}}`,
+	`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig-123"}}`,
+	`{"type":"content_block_stop","index":0}`,
+	`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+	`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Let me check "}}`,
+	`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"the archives."}}`,
+	`{"type":"content_block_stop","index":1}`,
+	`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"query_lore","input":{}}}`,
+	`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"topic\":"}}`,
+	`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":" \"vault\"}"}}`,
+	`{"type":"content_block_stop","index":2}`,
+	`{"type":"content_block_start","index":3,"content_block":{"type":"tool_use","id":"toolu_2","name":"roll_dice","input":{}}}`,
+	`{"type":"content_block_delta","index":3,"delta":{"type":"input_json_delta","partial_json":"{\"sides\": 20}"}}`,
+	`{"type":"content_block_stop","index":3}`,
+	`{"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":60}}`,
+	`{"type":"message_stop"}`,
+}
+
+// fakeAPI emulates POST /v1/messages. Streaming requests are answered with
+// events; other requests with body. Every request body is recorded, as are
+// the headers of the last request.
+type fakeAPI struct {
+	events []string
+	body   string
+
+	mu       sync.Mutex
+	requests []map[string]any
+	header   http.Header
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/messages" || r.Header.Get("X-Api-Key") != "test-key" {
+		http.Error(w, `{"type":"error","error":{"type":"not_found_error","message":"nope"}}`, http.StatusNotFound)
+		return
+	}
+	raw, _ := io.ReadAll(r.Body)
+	var req map[string]any
+	_ = json.Unmarshal(raw, &req)
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.header = r.Header.Clone()
+	f.mu.Unlock()
+
+	if req["stream"] != true {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, f.body)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, data := range f.events {
+		var ev struct{ Type string }
+		_ = json.Unmarshal([]byte(data), &ev)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	}
+}
+
+// lastRequest returns the most recent request body as JSON-decoded values.
+func (f *fakeAPI) lastRequest(t *testing.T) map[string]any {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.requests) == 0 {
+		t.Fatal("no request received")
+	}
+	return f.requests[len(f.requests)-1]
+}
+
+func newTestProvider(t *testing.T, api *fakeAPI, opts ...Option) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	p, err := New("claude-test", append([]Option{WithAPIKey("test-key"), WithBaseURL(srv.URL)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+// collect drains a completion stream.
+func collect(t *testing.T, ch <-chan llm.Chunk) []llm.Chunk {
+	t.Helper()
+	var chunks []llm.Chunk
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case c, ok := <-ch:
+			if !ok {
+				return chunks
+			}
+			chunks = append(chunks, c)
+		case <-timeout:
+			t.Fatal("stream not closed")
+		}
+	}
+}
+
+// asJSON re-encodes v for compact comparisons.
+func asJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv(envAPIKey, "")
+
+	tests := []struct {
+		name  string
+		model string
+		opts  []Option
+	}{
+		{name: "empty model", opts: []Option{WithAPIKey("k")}},
+		{name: "no api key", model: "claude-test"},
+		{name: "thinking budget too small", model: "claude-test", opts: []Option{WithAPIKey("k"), WithThinkingBudget(512)}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := New(tc.model, tc.opts...); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	t.Setenv(envAPIKey, "from-env")
+	p, err := New("claude-test")
+	if err != nil {
+		t.Fatalf("New with env key: %v", err)
+	}
+	if p.apiKey != "from-env" {
+		t.Errorf("apiKey = %q, want the environment value", p.apiKey)
+	}
+}
+
+func TestStreamCompletion_ThinkingAndToolUse(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{events: toolUseStream}
+	p := newTestProvider(t, api, WithThinkingBudget(2048))
+
+	ch, err := p.StreamCompletion(context.Background(), llm.CompletionRequest{
+		SystemPrompt: "You are Eldrinax, keeper of lore.",
+		Messages:     []llm.Message{{Role: "user", Content: "Where is the vault?"}},
+		Tools: []llm.ToolDefinition{{
+			Name:        "query_lore",
+			Description: "Look up campaign lore.",
+			Parameters: map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{"topic": map[string]any{"type": "string"}},
+				"required":             []any{"topic"},
+				"additionalProperties": false,
+			},
+		}},
+		Temperature: 0.7,
+	})
+	if err != nil {
+		t.Fatalf("StreamCompletion: %v", err)
+	}
+	chunks := collect(t, ch)
+
+	// Only the reply text is emitted as Text — never the reasoning.
+	var text strings.Builder
+	for _, c := range chunks {
+		text.WriteString(c.Text)
+	}
+	if text.String() != "Let me check the archives." {
+		t.Errorf("streamed text = %q, want only the reply text", text.String())
+	}
+
+	final := chunks[len(chunks)-1]
+	if final.FinishReason != "tool_calls" {
+		t.Errorf("FinishReason = %q, want tool_calls", final.FinishReason)
+	}
+	wantCalls := []llm.ToolCall{
+		{ID: "toolu_1", Name: "query_lore", Arguments: `{"topic": "vault"}`},
+		{ID: "toolu_2", Name: "roll_dice", Arguments: `{"sides": 20}`},
+	}
+	if asJSON(final.ToolCalls) != asJSON(wantCalls) {
+		t.Errorf("ToolCalls = %+v, want %+v", final.ToolCalls, wantCalls)
+	}
+	wantThinking := []llm.ThinkingBlock{{Text: "The player asks about the vault. I should look it up.", Signature: "sig-123"}}
+	if asJSON(final.Thinking) != asJSON(wantThinking) {
+		t.Errorf("Thinking = %+v, want %+v", final.Thinking, wantThinking)
+	}
+
+	req := api.lastRequest(t)
+	if asJSON(req["thinking"]) != `{"budget_tokens":2048,"type":"enabled"}` {
+		t.Errorf("thinking = %s", asJSON(req["thinking"]))
+	}
+	if _, ok := req["temperature"]; ok {
+		t.Error("temperature must not be sent with extended thinking")
+	}
+	if mt := req["max_tokens"].(float64); mt <= 2048 {
+		t.Errorf("max_tokens = %v, want more than the thinking budget", mt)
+	}
+	tool := asJSON(req["tools"])
+	if !strings.Contains(tool, `"required":["topic"]`) || !strings.Contains(tool, `"additionalProperties":false`) {
+		t.Errorf("tools = %s", tool)
+	}
+}
+
+func TestStreamCompletion_ToolRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{events: toolUseStream}
+	p := newTestProvider(t, api, WithThinkingBudget(2048))
+
+	// The history a caller builds after the first round: the assistant turn
+	// with its reasoning, text, and tool calls, then one result per call.
+	history := []llm.Message{
+		{Role: "user", Content: "Where is the vault?"},
+		{
+			Role:     "assistant",
+			Content:  "Let me check the archives.",
+			Thinking: []llm.ThinkingBlock{{Text: "I should look it up.", Signature: "sig-123"}, {Redacted: "opaque"}},
+			ToolCalls: []llm.ToolCall{
+				{ID: "toolu_1", Name: "query_lore", Arguments: `{"topic":"vault"}`},
+				{ID: "toolu_2", Name: "roll_dice", Arguments: `not json`},
+			},
+		},
+		{Role: "tool", ToolCallID: "toolu_1", Content: `{"result":"beneath the chapel"}`},
+		{Role: "tool", ToolCallID: "toolu_2", Content: `{"result":17}`},
+	}
+	ch, err := p.StreamCompletion(context.Background(), llm.CompletionRequest{Messages: history})
+	if err != nil {
+		t.Fatalf("StreamCompletion: %v", err)
+	}
+	collect(t, ch)
+
+	got := asJSON(api.lastRequest(t)["messages"])
+	want := asJSON([]any{
+		map[string]any{"role": "user", "content": []any{
+			map[string]any{"type": "text", "text": "Where is the vault?"},
+		}},
+		map[string]any{"role": "assistant", "content": []any{
+			map[string]any{"type": "thinking", "thinking": "I should look it up.", "signature": "sig-123"},
+			map[string]any{"type": "redacted_thinking", "data": "opaque"},
+			map[string]any{"type": "text", "text": "Let me check the archives."},
+			map[string]any{"type": "tool_use", "id": "toolu_1", "name": "query_lore", "input": map[string]any{"topic": "vault"}},
+			map[string]any{"type": "tool_use", "id": "toolu_2", "name": "roll_dice", "input": map[string]any{}},
+		}},
+		map[string]any{"role": "user", "content": []any{
+			map[string]any{"type": "tool_result", "tool_use_id": "toolu_1", "content": []any{map[string]any{"type": "text", "text": `{"result":"beneath the chapel"}`}}, "is_error": false},
+			map[string]any{"type": "tool_result", "tool_use_id": "toolu_2", "content": []any{map[string]any{"type": "text", "text": `{"result":17}`}}, "is_error": false},
+		}},
+	})
+	if got != want {
+		t.Errorf("messages:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestBuildParams_Stop(t *testing.T) {
+	t.Parallel()
+
+	p := &Provider{model: "claude-test", maxTokens: DefaultMaxTokens}
+	params := p.buildParams(llm.CompletionRequest{
+		Messages: []llm.Message{{Role: "user", Content: "Hello!"}},
+		Stop:     []string{"\n\n"},
+	})
+	raw, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(raw), `"stop_sequences":["\n\n"]`) {
+		t.Errorf("params = %s, want stop_sequences", raw)
+	}
+}
+
+func TestBuildParams_Prefill(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		budget     int
+		messages   []llm.Message
+		wantLast   string // JSON of the last message
+		wantSystem string // substring of the system prompt
+	}{
+		{
+			name: "prefill trailing whitespace trimmed",
+			messages: []llm.Message{
+				{Role: "user", Content: "Hello!"},
+				{Role: "assistant", Content: "Well met, traveller. \n"},
+			},
+			wantLast: `{"content":[{"text":"Well met, traveller.","type":"text"}],"role":"assistant"}`,
+		},
+		{
+			name: "whitespace-only prefill dropped",
+			messages: []llm.Message{
+				{Role: "user", Content: "Hello!"},
+				{Role: "assistant", Content: "  "},
+			},
+			wantLast: `{"content":[{"text":"Hello!","type":"text"}],"role":"user"}`,
+		},
+		{
+			name:   "prefill moved to system prompt with thinking",
+			budget: 1024,
+			messages: []llm.Message{
+				{Role: "user", Content: "Hello!"},
+				{Role: "assistant", Content: "Well met, traveller. "},
+			},
+			wantLast:   `{"content":[{"text":"Hello!","type":"text"}],"role":"user"}`,
+			wantSystem: `already begun with the following words, which the listener has heard: "Well met, traveller."`,
+		},
+		{
+			name: "opener merged ahead of later tool round",
+			messages: []llm.Message{
+				{Role: "user", Content: "Hello!"},
+				{Role: "assistant", Content: "Well met."},
+				{Role: "assistant", Thinking: []llm.ThinkingBlock{{Text: "t", Signature: "s"}}, ToolCalls: []llm.ToolCall{{ID: "a", Name: "f", Arguments: "{}"}}},
+				{Role: "tool", ToolCallID: "a", Content: "ok"},
+			},
+			wantLast: `{"content":[{"content":[{"text":"ok","type":"text"}],"is_error":false,"tool_use_id":"a","type":"tool_result"}],"role":"user"}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := &Provider{model: "claude-test", maxTokens: DefaultMaxTokens, thinkingBudget: tc.budget}
+			params := p.buildParams(llm.CompletionRequest{SystemPrompt: "You are an innkeeper.", Messages: tc.messages})
+
+			raw, err := json.Marshal(params)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var decoded struct {
+				System   []struct{ Text string } `json:"system"`
+				Messages []map[string]any        `json:"messages"`
+			}
+			if err := json.Unmarshal(raw, &decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if got := asJSON(decoded.Messages[len(decoded.Messages)-1]); got != tc.wantLast {
+				t.Errorf("last message:\n got %s\nwant %s", got, tc.wantLast)
+			}
+			if tc.wantSystem != "" && !strings.Contains(decoded.System[0].Text, tc.wantSystem) {
+				t.Errorf("system = %q, want it to contain %q", decoded.System[0].Text, tc.wantSystem)
+			}
+			// Thinking blocks must lead a merged assistant turn.
+			for _, m := range decoded.Messages {
+				if m["role"] != "assistant" {
+					continue
+				}
+				seenOther := false
+				for _, block := range m["content"].([]any) {
+					typ := block.(map[string]any)["type"]
+					if typ == "thinking" && seenOther {
+						t.Errorf("thinking block is not first in %s", asJSON(m))
+					}
+					seenOther = seenOther || typ != "thinking"
+				}
+			}
+		})
+	}
+}
+
+func TestComplete(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{body: `{"id":"msg_2","type":"message","role":"assistant","model":"claude-test",
+		"content":[{"type":"thinking","thinking":"Short answer.","signature":"sig-9"},{"type":"text","text":"Beneath the chapel."}],
+		"stop_reason":"end_turn","usage":{"input_tokens":12,"output_tokens":8}}`}
+	p := newTestProvider(t, api)
+
+	resp, err := p.Complete(context.Background(), llm.CompletionRequest{
+		Messages: []llm.Message{{Role: "user", Content: "Where is the vault?"}},
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Content != "Beneath the chapel." {
+		t.Errorf("Content = %q, want the text without reasoning", resp.Content)
+	}
+	if len(resp.Thinking) != 1 || resp.Thinking[0].Signature != "sig-9" {
+		t.Errorf("Thinking = %+v", resp.Thinking)
+	}
+	if resp.Usage.TotalTokens != 20 {
+		t.Errorf("TotalTokens = %d, want 20", resp.Usage.TotalTokens)
+	}
+}
+
+func TestComplete_IdentHeaders(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{body: `{"id":"msg_3","type":"message","role":"assistant","model":"claude-test",
+		"content":[{"type":"text","text":"Aye."}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`}
+	p := newTestProvider(t, api)
+
+	ctx := ident.WithSession(context.Background(), "session-tavern")
+	if _, err := p.Complete(ctx, llm.CompletionRequest{
+		Messages: []llm.Message{{Role: "user", Content: "Hello?"}},
+	}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	api.mu.Lock()
+	h := api.header
+	api.mu.Unlock()
+	if ua := h.Get("User-Agent"); !strings.HasPrefix(ua, ident.UserAgent()+" ") || !strings.Contains(ua, "Anthropic/Go") {
+		t.Errorf("User-Agent = %q, want Glyphoxa's token followed by the SDK's", ua)
+	}
+	if tag := h.Get(ident.SessionHeader); tag != "session-tavern" {
+		t.Errorf("%s = %q, want %q", ident.SessionHeader, tag, "session-tavern")
+	}
+}
+
+func TestStreamCompletion_APIError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"type":"error","error":{"type":"invalid_request_error","message":"bad prefill"}}`)
+	}))
+	t.Cleanup(srv.Close)
+	p, err := New("claude-test", WithAPIKey("k"), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ch, err := p.StreamCompletion(context.Background(), llm.CompletionRequest{
+		Messages: []llm.Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("StreamCompletion: %v", err)
+	}
+	chunks := collect(t, ch)
+	if len(chunks) != 1 || chunks[0].FinishReason != "error" {
+		t.Errorf("chunks = %+v, want a single error chunk", chunks)
+	}
+}
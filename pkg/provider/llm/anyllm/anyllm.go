@@ -11,6 +11,7 @@ package anyllm
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	anyllmlib "github.com/mozilla-ai/any-llm-go"
@@ -24,6 +25,7 @@ import (
 	"github.com/mozilla-ai/any-llm-go/providers/ollama"
 	anyllmoai "github.com/mozilla-ai/any-llm-go/providers/openai"
 
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
 )
 
@@ -43,6 +45,10 @@ type Provider struct {
 // opts are any-llm-go configuration options (e.g., anyllmlib.WithAPIKey, anyllmlib.WithBaseURL).
 // If no API key option is provided, the provider will fall back to the relevant
 // environment variable (e.g., OPENAI_API_KEY, ANTHROPIC_API_KEY, etc.).
+//
+// Requests are sent through the configured HTTP client with Glyphoxa's
+// identification headers added (see package ident). The "anthropic" backend
+// does not accept an HTTP client and sends requests without them.
 func New(providerName string, model string, opts ...anyllmlib.Option) (*Provider, error) {
 	if providerName == "" {
 		return nil, fmt.Errorf("anyllm: providerName must not be empty")
@@ -51,7 +57,7 @@ func New(providerName string, model string, opts ...anyllmlib.Option) (*Provider
 		return nil, fmt.Errorf("anyllm: model must not be empty")
 	}
 
-	backend, err := createBackend(providerName, opts...)
+	backend, err := createBackend(providerName, withIdent(opts)...)
 	if err != nil {
 		return nil, fmt.Errorf("anyllm: create %q backend: %w", providerName, err)
 	}
@@ -113,6 +119,20 @@ func NewLlamaFile(model string, opts ...anyllmlib.Option) (*Provider, error) {
 	return New("llamafile", model, opts...)
 }
 
+// withIdent returns opts extended by an HTTP client that adds Glyphoxa's
+// identification headers. The client is a copy of the one opts configure, so
+// a custom client or timeout is kept. Invalid opts are returned unchanged for
+// the backend to report.
+func withIdent(opts []anyllmlib.Option) []anyllmlib.Option {
+	cfg, err := anyllmlib.NewConfig(opts...)
+	if err != nil {
+		return opts
+	}
+	client := *cfg.HTTPClient()
+	client.Transport = ident.Transport(client.Transport)
+	return append(slices.Clip(opts), anyllmlib.WithHTTPClient(&client))
+}
+
 // createBackend creates the underlying any-llm-go provider for the given provider name.
 func createBackend(providerName string, opts ...anyllmlib.Option) (anyllmlib.Provider, error) {
 	switch strings.ToLower(providerName) {
@@ -281,6 +301,9 @@ func (p *Provider) buildParams(req llm.CompletionRequest) anyllmlib.CompletionPa
 		Model:    p.model,
 		Messages: messages,
 	}
+	if req.Model != "" {
+		params.Model = req.Model
+	}
 
 	if req.Temperature != 0 {
 		t := req.Temperature
@@ -290,6 +313,9 @@ func (p *Provider) buildParams(req llm.CompletionRequest) anyllmlib.CompletionPa
 		mt := req.MaxTokens
 		params.MaxTokens = &mt
 	}
+	if len(req.Stop) > 0 {
+		params.Stop = req.Stop
+	}
 
 	for _, td := range req.Tools {
 		params.Tools = append(params.Tools, anyllmlib.Tool{
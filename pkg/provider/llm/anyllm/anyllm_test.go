@@ -1,6 +1,7 @@
 package anyllm
 
 import (
+	"slices"
 	"testing"
 
 	anyllmlib "github.com/mozilla-ai/any-llm-go"
@@ -477,3 +478,30 @@ func TestCapabilities_ReturnsForModel(t *testing.T) {
 		t.Errorf("expected SupportsVision %v, got %v", expected.SupportsVision, caps.SupportsVision)
 	}
 }
+
+// ── buildParams ───────────────────────────────────────────────────────────────
+
+// TestBuildParams_ModelOverride checks that a per-request model replaces the
+// provider's configured model, and that an empty override keeps the default.
+func TestBuildParams_ModelOverride(t *testing.T) {
+	p := &Provider{model: "gpt-4o"}
+
+	if got := p.buildParams(llm.CompletionRequest{}).Model; got != "gpt-4o" {
+		t.Errorf("default model: want %q, got %q", "gpt-4o", got)
+	}
+	if got := p.buildParams(llm.CompletionRequest{Model: "gpt-4o-mini"}).Model; got != "gpt-4o-mini" {
+		t.Errorf("override model: want %q, got %q", "gpt-4o-mini", got)
+	}
+}
+
+func TestBuildParams_Stop(t *testing.T) {
+	p := &Provider{model: "gpt-4o"}
+
+	if got := p.buildParams(llm.CompletionRequest{}).Stop; got != nil {
+		t.Errorf("default stop: want nil, got %q", got)
+	}
+	got := p.buildParams(llm.CompletionRequest{Stop: []string{"\n\n", "User:"}}).Stop
+	if !slices.Equal(got, []string{"\n\n", "User:"}) {
+		t.Errorf("stop: want %q, got %q", []string{"\n\n", "User:"}, got)
+	}
+}
@@ -53,17 +53,27 @@ type CompletionRequest struct {
 	// Zero means use the provider default (usually the model's MaxOutputTokens).
 	MaxTokens int
 
+	// Stop lists sequences at which the model stops generating. The sequence
+	// itself is not part of the output and the stream ends with FinishReason
+	// "stop". Providers without stop sequence support ignore it.
+	Stop []string
+
 	// SystemPrompt is an optional high-priority instruction injected before the
 	// conversation history. Many providers give this special treatment (e.g.,
 	// OpenAI's "system" role, Anthropic's separate system field). If the provider
 	// does not natively support a dedicated system prompt, implementors should
 	// prepend it as a "system"-role message.
 	SystemPrompt string
+
+	// Model optionally overrides the provider's configured model for this
+	// request only (e.g., a per-NPC model override). Empty means use the model
+	// the provider was constructed with.
+	Model string
 }
 
 // Chunk is a single token or fragment emitted by a streaming completion.
-// Consumers must handle all three fields; a single chunk may carry text, a finish
-// signal, tool calls, or any combination thereof.
+// Consumers must handle all fields; a single chunk may carry text, a finish
+// signal, tool calls, reasoning, or any combination thereof.
 type Chunk struct {
 	// Text is the incremental text content of this chunk. May be empty if the chunk
 	// carries only ToolCalls or a FinishReason.
@@ -77,6 +87,12 @@ type Chunk struct {
 	// ToolCalls contains any tool invocations the model is requesting. For streaming
 	// providers this may be accumulated across multiple chunks by the caller.
 	ToolCalls []ToolCall
+
+	// Thinking contains the complete reasoning blocks of the response, set on
+	// the final chunk by providers that expose reasoning. It must never be
+	// spoken; callers that send ToolCalls back to the model should attach it
+	// to the assistant message via [Message.Thinking].
+	Thinking []ThinkingBlock
 }
 
 // CompletionResponse is returned by the non-streaming Complete method.
@@ -89,6 +105,10 @@ type CompletionResponse struct {
 	// responsible for executing them and appending the results to the conversation.
 	ToolCalls []ToolCall
 
+	// Thinking contains the model's reasoning blocks, if the provider exposes
+	// them. Like [Chunk.Thinking] it is never part of Content.
+	Thinking []ThinkingBlock
+
 	// Usage contains token accounting for this request/response pair.
 	Usage Usage
 }
@@ -16,6 +16,26 @@ type Message struct {
 
 	// ToolCallID is set when Role is "tool", identifying which tool call this responds to.
 	ToolCallID string
+
+	// Thinking holds the reasoning blocks the model produced before the
+	// ToolCalls of an assistant message. Providers that require reasoning to
+	// be sent back with tool calls (Anthropic extended thinking) replay it;
+	// all others ignore it.
+	Thinking []ThinkingBlock
+}
+
+// ThinkingBlock is one block of a model's internal reasoning, such as an
+// Anthropic extended-thinking block. Reasoning is never spoken; it is only
+// carried along so it can be returned to the provider unchanged.
+type ThinkingBlock struct {
+	// Text is the reasoning text. Empty for redacted blocks.
+	Text string
+
+	// Signature is the provider's opaque signature authenticating Text.
+	Signature string
+
+	// Redacted holds the encrypted content of a redacted block. Empty otherwise.
+	Redacted string
 }
 
 // ToolCall represents a tool/function invocation requested by the LLM.
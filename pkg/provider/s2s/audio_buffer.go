@@ -0,0 +1,154 @@
+package s2s
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultAudioFrameDuration is the amount of input audio that providers
+// coalesce into one upstream message by default. Small frames waste protocol
+// overhead; large frames add latency.
+const DefaultAudioFrameDuration = 100 * time.Millisecond
+
+// AudioFrameBytes returns the size in bytes of d of 16-bit PCM audio at the
+// given sample rate and channel count, rounded down to whole samples. It
+// returns 0 for a non-positive duration.
+func AudioFrameBytes(d time.Duration, sampleRate, channels int) int {
+	if d <= 0 {
+		return 0
+	}
+	samples := int(d * time.Duration(sampleRate) / time.Second)
+	return samples * channels * 2
+}
+
+// AudioBuffer coalesces the small PCM chunks passed to
+// [SessionHandle.SendAudio] into frames of a fixed size before they are sent
+// upstream. Audio that does not fill a frame is sent once no further chunk
+// arrives within the flush delay, or when [AudioBuffer.Flush] is called, so
+// the tail of an utterance is never held back.
+//
+// A frame size of zero disables buffering: every chunk is sent as-is.
+//
+// All methods are safe for concurrent use. Frames are sent in order and never
+// concurrently.
+type AudioBuffer struct {
+	send      func([]byte) error
+	frameSize int
+	delay     time.Duration
+
+	mu      sync.Mutex
+	buf     []byte
+	timer   *time.Timer
+	err     error // error from a timer-triggered flush, reported by the next call
+	stopped bool
+}
+
+// NewAudioBuffer returns an AudioBuffer that passes frames of frameSize bytes
+// to send. Partial frames are flushed after delay without new audio.
+func NewAudioBuffer(frameSize int, delay time.Duration, send func([]byte) error) *AudioBuffer {
+	return &AudioBuffer{
+		send:      send,
+		frameSize: max(frameSize, 0),
+		delay:     delay,
+	}
+}
+
+// Write appends chunk to the buffer and sends every complete frame. It
+// returns the first send error, including one from an earlier delayed flush.
+func (b *AudioBuffer) Write(chunk []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.takeErr(); err != nil {
+		return err
+	}
+	if b.frameSize == 0 {
+		return b.send(chunk)
+	}
+
+	b.buf = append(b.buf, chunk...)
+	for len(b.buf) >= b.frameSize {
+		frame := make([]byte, b.frameSize)
+		copy(frame, b.buf)
+		b.buf = b.buf[:copy(b.buf, b.buf[b.frameSize:])]
+		if err := b.send(frame); err != nil {
+			return err
+		}
+	}
+	b.armTimer()
+	return nil
+}
+
+// Flush sends any buffered audio immediately, even if it does not fill a
+// frame. Providers call it before control messages, such as an interruption,
+// that must not overtake audio already accepted by SendAudio.
+func (b *AudioBuffer) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.takeErr(); err != nil {
+		return err
+	}
+	return b.flushLocked()
+}
+
+// Stop discards buffered audio and cancels a pending delayed flush. Providers
+// call it when the session closes.
+func (b *AudioBuffer) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stopped = true
+	b.buf = nil
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+}
+
+// flushLocked sends the buffered audio. Must be called with b.mu held.
+func (b *AudioBuffer) flushLocked() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	if len(b.buf) == 0 {
+		return nil
+	}
+	frame := b.buf
+	b.buf = nil
+	return b.send(frame)
+}
+
+// armTimer schedules a delayed flush while audio is buffered. Must be called
+// with b.mu held.
+func (b *AudioBuffer) armTimer() {
+	if len(b.buf) == 0 || b.stopped {
+		return
+	}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.delay, b.delayedFlush)
+		return
+	}
+	b.timer.Reset(b.delay)
+}
+
+// delayedFlush sends a partial frame after the flush delay elapsed without
+// new audio.
+func (b *AudioBuffer) delayedFlush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopped {
+		return
+	}
+	if err := b.flushLocked(); err != nil && b.err == nil {
+		b.err = err
+	}
+}
+
+// takeErr returns and clears the error of a failed delayed flush. Must be
+// called with b.mu held.
+func (b *AudioBuffer) takeErr() error {
+	err := b.err
+	b.err = nil
+	return err
+}
@@ -0,0 +1,146 @@
+package s2s_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/s2s"
+)
+
+// frameRecorder collects the frames sent by an AudioBuffer.
+type frameRecorder struct {
+	mu     sync.Mutex
+	frames [][]byte
+	err    error
+}
+
+func (r *frameRecorder) send(frame []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames = append(r.frames, frame)
+	return r.err
+}
+
+func (r *frameRecorder) sizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]int, len(r.frames))
+	for i, f := range r.frames {
+		out[i] = len(f)
+	}
+	return out
+}
+
+func TestAudioFrameBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		d        time.Duration
+		rate     int
+		channels int
+		want     int
+	}{
+		{d: 100 * time.Millisecond, rate: 24000, channels: 1, want: 4800},
+		{d: 100 * time.Millisecond, rate: 16000, channels: 1, want: 3200},
+		{d: 20 * time.Millisecond, rate: 48000, channels: 2, want: 3840},
+		{d: 0, rate: 16000, channels: 1, want: 0},
+	}
+	for _, tc := range tests {
+		if got := s2s.AudioFrameBytes(tc.d, tc.rate, tc.channels); got != tc.want {
+			t.Errorf("AudioFrameBytes(%v, %d, %d) = %d, want %d", tc.d, tc.rate, tc.channels, got, tc.want)
+		}
+	}
+}
+
+func TestAudioBuffer_Coalesces(t *testing.T) {
+	t.Parallel()
+
+	rec := &frameRecorder{}
+	b := s2s.NewAudioBuffer(100, time.Hour, rec.send)
+	defer b.Stop()
+
+	var want []byte
+	for i := range 25 {
+		chunk := bytes.Repeat([]byte{byte(i)}, 10)
+		want = append(want, chunk...)
+		if err := b.Write(chunk); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if got := rec.sizes(); !slices.Equal(got, []int{100, 100}) {
+		t.Fatalf("frames before flush = %v, want [100 100]", got)
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := rec.sizes(); !slices.Equal(got, []int{100, 100, 50}) {
+		t.Fatalf("frames after flush = %v, want [100 100 50]", got)
+	}
+	if got := slices.Concat(rec.frames...); !slices.Equal(got, want) {
+		t.Error("frames do not reassemble to the written audio")
+	}
+}
+
+func TestAudioBuffer_DelayedFlush(t *testing.T) {
+	t.Parallel()
+
+	rec := &frameRecorder{}
+	b := s2s.NewAudioBuffer(100, 10*time.Millisecond, rec.send)
+	defer b.Stop()
+
+	if err := b.Write(make([]byte, 30)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(rec.sizes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := rec.sizes(); !slices.Equal(got, []int{30}) {
+		t.Errorf("frames = %v, want [30]", got)
+	}
+}
+
+func TestAudioBuffer_Unbuffered(t *testing.T) {
+	t.Parallel()
+
+	rec := &frameRecorder{}
+	b := s2s.NewAudioBuffer(0, time.Hour, rec.send)
+	defer b.Stop()
+
+	for _, n := range []int{3, 7, 5} {
+		if err := b.Write(make([]byte, n)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if got := rec.sizes(); !slices.Equal(got, []int{3, 7, 5}) {
+		t.Errorf("frames = %v, want [3 7 5]", got)
+	}
+}
+
+func TestAudioBuffer_SendError(t *testing.T) {
+	t.Parallel()
+
+	rec := &frameRecorder{err: errors.New("connection lost")}
+	b := s2s.NewAudioBuffer(10, 10*time.Millisecond, rec.send)
+	defer b.Stop()
+
+	if err := b.Write(make([]byte, 10)); err == nil {
+		t.Fatal("Write: expected the send error")
+	}
+
+	// An error from a delayed flush is reported by the next call.
+	if err := b.Write(make([]byte, 5)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(rec.sizes()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := b.Flush(); err == nil {
+		t.Error("Flush: expected the delayed flush error")
+	}
+}
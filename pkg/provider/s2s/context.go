@@ -0,0 +1,49 @@
+package s2s
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Canonical [ContextItem] roles. Providers translate these to their own wire
+// format (e.g., Gemini calls the assistant "model").
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// ErrUnknownRole is returned (wrapped) by [NormalizeContextItems] for a
+// [ContextItem] whose role is not recognised.
+var ErrUnknownRole = errors.New("s2s: unknown context item role")
+
+// roleAliases maps accepted role spellings to their canonical form.
+var roleAliases = map[string]string{
+	"":            RoleUser,
+	RoleSystem:    RoleSystem,
+	RoleUser:      RoleUser,
+	RoleAssistant: RoleAssistant,
+	"model":       RoleAssistant, // Gemini's name for the assistant
+}
+
+// NormalizeContextItems returns a copy of items with every role in canonical
+// form ([RoleSystem], [RoleUser] or [RoleAssistant]), keeping their order.
+// Roles are matched case-insensitively, an empty role means [RoleUser] and
+// "model" is accepted for [RoleAssistant], so the same items produce the same
+// conversation regardless of the provider a session runs on.
+//
+// Providers call NormalizeContextItems at the start of
+// [SessionHandle.InjectTextContext] and map the canonical roles to their wire
+// format. An unrecognised role yields an error wrapping [ErrUnknownRole].
+func NormalizeContextItems(items []ContextItem) ([]ContextItem, error) {
+	out := make([]ContextItem, len(items))
+	for i, item := range items {
+		role, ok := roleAliases[strings.ToLower(strings.TrimSpace(item.Role))]
+		if !ok {
+			return nil, fmt.Errorf("%w %q (item %d)", ErrUnknownRole, item.Role, i)
+		}
+		out[i] = ContextItem{Role: role, Content: item.Content}
+	}
+	return out, nil
+}
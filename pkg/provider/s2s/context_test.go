@@ -0,0 +1,62 @@
+package s2s_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/s2s"
+)
+
+func TestNormalizeContextItems(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		roles   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "canonical", roles: []string{"system", "user", "assistant"}, want: []string{"system", "user", "assistant"}},
+		{name: "case and whitespace", roles: []string{" System", "USER ", "Assistant"}, want: []string{"system", "user", "assistant"}},
+		{name: "gemini model role", roles: []string{"model", "Model"}, want: []string{"assistant", "assistant"}},
+		{name: "empty role is user", roles: []string{""}, want: []string{"user"}},
+		{name: "empty input", roles: []string{}, want: []string{}},
+		{name: "unknown role", roles: []string{"user", "narrator"}, wantErr: true},
+		{name: "tool role", roles: []string{"tool"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			items := make([]s2s.ContextItem, len(tc.roles))
+			for i, r := range tc.roles {
+				items[i] = s2s.ContextItem{Role: r, Content: r + " text"}
+			}
+			got, err := s2s.NormalizeContextItems(items)
+			if tc.wantErr {
+				if !errors.Is(err, s2s.ErrUnknownRole) {
+					t.Fatalf("want ErrUnknownRole, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			roles := make([]string, len(got))
+			for i, item := range got {
+				roles[i] = item.Role
+				if item.Content != items[i].Content {
+					t.Errorf("item %d content = %q, want %q", i, item.Content, items[i].Content)
+				}
+			}
+			if !slices.Equal(roles, tc.want) {
+				t.Errorf("roles = %q, want %q", roles, tc.want)
+			}
+			if len(items) > 0 && items[0].Role != tc.roles[0] {
+				t.Error("input items were modified")
+			}
+		})
+	}
+}
@@ -12,10 +12,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
 	"github.com/MrWong99/glyphoxa/pkg/provider/s2s"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
@@ -32,8 +36,18 @@ const (
 
 	keepaliveInterval = 20 * time.Second
 	keepaliveTimeout  = 5 * time.Second
+
+	// inputSampleRate is the sample rate of the PCM input audio sent upstream.
+	inputSampleRate = 16000
 )
 
+// PrebuiltVoices lists the voice names accepted by the Gemini Live API in
+// SessionConfig.Voice.ID. Connect rejects any other non-empty voice ID.
+var PrebuiltVoices = []string{"Aoede", "Charon", "Fenrir", "Kore", "Leda", "Orus", "Puck", "Zephyr"}
+
+// validModalities is the set of accepted response modalities (lower-case).
+var validModalities = []string{"audio", "text"}
+
 // ── Options ────────────────────────────────────────────────────────────────────
 
 // Option is a functional option for configuring a Provider.
@@ -50,21 +64,93 @@ func WithBaseURL(url string) Option {
 	return func(p *Provider) { p.baseURL = url }
 }
 
+// WithAudioFrameDuration sets how much input audio is coalesced into one
+// upstream message. Chunks passed to SendAudio are buffered until a frame is
+// full, or until no further audio arrives for the same duration. Zero sends
+// every chunk as-is. Negative values are ignored. Defaults to
+// [s2s.DefaultAudioFrameDuration].
+func WithAudioFrameDuration(d time.Duration) Option {
+	return func(p *Provider) {
+		if d >= 0 {
+			p.frameDuration = d
+		}
+	}
+}
+
+// WithResponseModalities sets the modalities the model responds with, e.g.
+// []string{"audio"} (the default) or []string{"text"}. Values are
+// case-insensitive and sent to the API in lower case; Connect returns an error
+// for anything other than "audio" and "text". An empty slice keeps the
+// default.
+func WithResponseModalities(modalities []string) Option {
+	return func(p *Provider) {
+		if len(modalities) > 0 {
+			p.modalities = make([]string, len(modalities))
+			for i, m := range modalities {
+				p.modalities[i] = strings.ToLower(m)
+			}
+		}
+	}
+}
+
+// WithInputTranscription sets whether the model transcribes the player's
+// speech. The transcripts arrive on [s2s.SessionHandle.Transcripts] as user
+// entries. Transcription is billed separately, so disable it when the session
+// transcript is not recorded. Defaults to true.
+func WithInputTranscription(enabled bool) Option {
+	return func(p *Provider) { p.inputTranscription = enabled }
+}
+
+// WithOutputTranscription sets whether the model transcribes its own spoken
+// responses. The transcripts arrive on [s2s.SessionHandle.Transcripts] as NPC
+// entries. Text parts of text-modality responses are emitted regardless.
+// Defaults to true.
+func WithOutputTranscription(enabled bool) Option {
+	return func(p *Provider) { p.outputTranscription = enabled }
+}
+
+// WithAffectiveDialog sets whether the model adapts the tone of its spoken
+// responses to the emotion in the player's voice. Only native audio models
+// support it; others reject the setup. Defaults to false.
+func WithAffectiveDialog(enabled bool) Option {
+	return func(p *Provider) { p.affectiveDialog = enabled }
+}
+
+// WithProactiveAudio sets whether the model may decide not to respond to
+// input that is not addressed to it, such as table talk between players.
+// Only native audio models support it; others reject the setup. Defaults to
+// false.
+func WithProactiveAudio(enabled bool) Option {
+	return func(p *Provider) { p.proactiveAudio = enabled }
+}
+
 // ── Provider ───────────────────────────────────────────────────────────────────
 
 // Provider implements s2s.Provider for Google's Gemini Live API.
 type Provider struct {
-	apiKey  string
-	model   string
-	baseURL string
+	apiKey        string
+	model         string
+	baseURL       string
+	modalities    []string
+	frameDuration time.Duration
+
+	inputTranscription  bool
+	outputTranscription bool
+	affectiveDialog     bool
+	proactiveAudio      bool
 }
 
 // New creates a new Gemini Live Provider with the given API key and options.
 func New(apiKey string, opts ...Option) *Provider {
 	p := &Provider{
-		apiKey:  apiKey,
-		model:   defaultModel,
-		baseURL: defaultBaseURL,
+		apiKey:        apiKey,
+		model:         defaultModel,
+		baseURL:       defaultBaseURL,
+		modalities:    []string{"audio"},
+		frameDuration: s2s.DefaultAudioFrameDuration,
+
+		inputTranscription:  true,
+		outputTranscription: true,
 	}
 	for _, o := range opts {
 		o(p)
@@ -78,32 +164,44 @@ func (p *Provider) Capabilities() s2s.S2SCapabilities {
 		ContextWindow:        1_000_000,
 		MaxSessionDurationMs: 15 * 60 * 1000,
 		SupportsResumption:   false,
-		Voices: []tts.VoiceProfile{
-			{ID: "Aoede", Name: "Aoede", Provider: "gemini"},
-			{ID: "Charon", Name: "Charon", Provider: "gemini"},
-			{ID: "Fenrir", Name: "Fenrir", Provider: "gemini"},
-			{ID: "Kore", Name: "Kore", Provider: "gemini"},
-			{ID: "Puck", Name: "Puck", Provider: "gemini"},
-		},
+		Voices:               prebuiltVoiceProfiles(),
 	}
 }
 
+// prebuiltVoiceProfiles converts [PrebuiltVoices] into voice profiles.
+func prebuiltVoiceProfiles() []tts.VoiceProfile {
+	voices := make([]tts.VoiceProfile, len(PrebuiltVoices))
+	for i, name := range PrebuiltVoices {
+		voices[i] = tts.VoiceProfile{ID: name, Name: name, Provider: "gemini"}
+	}
+	return voices
+}
+
+// validate checks cfg and the provider's modalities before any network I/O
+// so that misconfiguration fails fast.
+func (p *Provider) validate(cfg s2s.SessionConfig) error {
+	if cfg.Voice.ID != "" && !slices.Contains(PrebuiltVoices, cfg.Voice.ID) {
+		return fmt.Errorf("gemini: unknown voice %q; valid voices: %s", cfg.Voice.ID, strings.Join(PrebuiltVoices, ", "))
+	}
+	for _, m := range p.modalities {
+		if !slices.Contains(validModalities, m) {
+			return fmt.Errorf("gemini: unknown response modality %q; valid modalities: %s", m, strings.Join(validModalities, ", "))
+		}
+	}
+	return nil
+}
+
 // Connect establishes a new Gemini Live session with the given configuration.
 // The returned SessionHandle is ready to accept audio immediately after the
 // setup message is sent.
 func (p *Provider) Connect(ctx context.Context, cfg s2s.SessionConfig) (s2s.SessionHandle, error) {
-	wsURL := fmt.Sprintf(
-		"%s/google.ai.generativelanguage.v1beta.GenerativeService.BidiGenerateContent?key=%s",
-		p.baseURL, p.apiKey,
-	)
+	if err := p.validate(cfg); err != nil {
+		return nil, err
+	}
 
-	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
-		HTTPHeader: http.Header{
-			"Content-Type": []string{"application/json"},
-		},
-	})
+	conn, err := p.dial(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("gemini: dial: %w", err)
+		return nil, err
 	}
 
 	sessCtx, sessCancel := context.WithCancel(context.Background())
@@ -115,8 +213,11 @@ func (p *Provider) Connect(ctx context.Context, cfg s2s.SessionConfig) (s2s.Sess
 		ctx:         sessCtx,
 		cancel:      sessCancel,
 	}
+	sess.transcriptSeq.Store(cfg.TranscriptSequenceStart)
+	frameSize := s2s.AudioFrameBytes(p.frameDuration, inputSampleRate, 1)
+	sess.input = s2s.NewAudioBuffer(frameSize, p.frameDuration, sess.sendMediaChunk)
 
-	if err := sess.sendSetup(p.model, cfg); err != nil {
+	if err := sess.sendSetup(p.setup(cfg)); err != nil {
 		sessCancel()
 		conn.Close(websocket.StatusInternalError, "setup failed")
 		return nil, fmt.Errorf("gemini: setup: %w", err)
@@ -128,6 +229,99 @@ func (p *Provider) Connect(ctx context.Context, cfg s2s.SessionConfig) (s2s.Sess
 	return sess, nil
 }
 
+// Warmup validates the provider's options and opens and immediately closes a
+// connection to the Live API, so that misconfiguration, DNS, TLS and API key
+// failures surface at startup rather than when the first player speaks. No
+// session is set up and no audio is sent.
+func (p *Provider) Warmup(ctx context.Context) error {
+	if err := p.validate(s2s.SessionConfig{}); err != nil {
+		return err
+	}
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.Close(websocket.StatusNormalClosure, "warm-up")
+}
+
+// dial opens a WebSocket connection to the BidiGenerateContent endpoint.
+func (p *Provider) dial(ctx context.Context) (*websocket.Conn, error) {
+	wsURL := fmt.Sprintf(
+		"%s/google.ai.generativelanguage.%s.GenerativeService.BidiGenerateContent?key=%s",
+		p.baseURL, p.apiVersion(), p.apiKey,
+	)
+
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		HTTPHeader: ident.Header(ctx, http.Header{
+			"Content-Type": []string{"application/json"},
+		}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gemini: dial: %w", err)
+	}
+	return conn, nil
+}
+
+// apiVersion returns the API version of the endpoint. Affective dialog and
+// proactive audio are only available in the v1alpha API.
+func (p *Provider) apiVersion() string {
+	if p.affectiveDialog || p.proactiveAudio {
+		return "v1alpha"
+	}
+	return "v1beta"
+}
+
+// setup builds the BidiGenerateContent setup message for a session.
+func (p *Provider) setup(cfg s2s.SessionConfig) setupMessage {
+	msg := setupMessage{
+		Setup: setupConfig{
+			Model: fmt.Sprintf("models/%s", p.model),
+			GenerationConfig: generationConfig{
+				ResponseModalities: p.modalities,
+			},
+		},
+	}
+
+	if cfg.Instructions != "" {
+		msg.Setup.SystemInstruction = &systemInstruction{
+			Parts: []part{{Text: cfg.Instructions}},
+		}
+	}
+
+	if cfg.Voice.ID != "" {
+		msg.Setup.GenerationConfig.SpeechConfig = &speechConfig{
+			VoiceConfig: voiceConfig{
+				PrebuiltVoiceConfig: prebuiltVoiceConfig{VoiceName: cfg.Voice.ID},
+			},
+		}
+	}
+
+	if len(cfg.Tools) > 0 {
+		decls := make([]functionDeclaration, len(cfg.Tools))
+		for i, t := range cfg.Tools {
+			decls[i] = functionDeclaration{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			}
+		}
+		msg.Setup.Tools = []geminiTool{{FunctionDeclarations: decls}}
+	}
+
+	if p.inputTranscription {
+		msg.Setup.InputAudioTranscription = &audioTranscriptionConfig{}
+	}
+	if p.outputTranscription {
+		msg.Setup.OutputAudioTranscription = &audioTranscriptionConfig{}
+	}
+	msg.Setup.GenerationConfig.EnableAffectiveDialog = p.affectiveDialog
+	if p.proactiveAudio {
+		msg.Setup.Proactivity = &proactivityConfig{ProactiveAudio: true}
+	}
+
+	return msg
+}
+
 // ── Protocol message types (outgoing) ─────────────────────────────────────────
 
 type setupMessage struct {
@@ -135,15 +329,27 @@ type setupMessage struct {
 }
 
 type setupConfig struct {
-	Model             string             `json:"model"`
-	GenerationConfig  generationConfig   `json:"generationConfig"`
-	SystemInstruction *systemInstruction `json:"systemInstruction,omitempty"`
-	Tools             []geminiTool       `json:"tools,omitempty"`
+	Model                    string                    `json:"model"`
+	GenerationConfig         generationConfig          `json:"generationConfig"`
+	SystemInstruction        *systemInstruction        `json:"systemInstruction,omitempty"`
+	Tools                    []geminiTool              `json:"tools,omitempty"`
+	InputAudioTranscription  *audioTranscriptionConfig `json:"inputAudioTranscription,omitempty"`
+	OutputAudioTranscription *audioTranscriptionConfig `json:"outputAudioTranscription,omitempty"`
+	Proactivity              *proactivityConfig        `json:"proactivity,omitempty"`
 }
 
 type generationConfig struct {
-	ResponseModalities []string      `json:"responseModalities"`
-	SpeechConfig       *speechConfig `json:"speechConfig,omitempty"`
+	ResponseModalities    []string      `json:"responseModalities"`
+	SpeechConfig          *speechConfig `json:"speechConfig,omitempty"`
+	EnableAffectiveDialog bool          `json:"enableAffectiveDialog,omitempty"`
+}
+
+// audioTranscriptionConfig enables a transcription when present. It has no
+// fields.
+type audioTranscriptionConfig struct{}
+
+type proactivityConfig struct {
+	ProactiveAudio bool `json:"proactiveAudio"`
 }
 
 type speechConfig struct {
@@ -274,6 +480,12 @@ type session struct {
 	toolHandler  s2s.ToolCallHandler
 	errorHandler func(error)
 
+	// input coalesces SendAudio chunks into frames of the configured duration.
+	input *s2s.AudioBuffer
+
+	// transcriptSeq is the Sequence of the last emitted transcript entry.
+	transcriptSeq atomic.Uint64
+
 	mu     sync.Mutex
 	errVal error
 	done   chan struct{}
@@ -285,42 +497,7 @@ type session struct {
 }
 
 // sendSetup sends the initial BidiGenerateContent setup message.
-func (s *session) sendSetup(model string, cfg s2s.SessionConfig) error {
-	msg := setupMessage{
-		Setup: setupConfig{
-			Model: fmt.Sprintf("models/%s", model),
-			GenerationConfig: generationConfig{
-				ResponseModalities: []string{"audio"},
-			},
-		},
-	}
-
-	if cfg.Instructions != "" {
-		msg.Setup.SystemInstruction = &systemInstruction{
-			Parts: []part{{Text: cfg.Instructions}},
-		}
-	}
-
-	if cfg.Voice.ID != "" {
-		msg.Setup.GenerationConfig.SpeechConfig = &speechConfig{
-			VoiceConfig: voiceConfig{
-				PrebuiltVoiceConfig: prebuiltVoiceConfig{VoiceName: cfg.Voice.ID},
-			},
-		}
-	}
-
-	if len(cfg.Tools) > 0 {
-		decls := make([]functionDeclaration, len(cfg.Tools))
-		for i, t := range cfg.Tools {
-			decls[i] = functionDeclaration{
-				Name:        t.Name,
-				Description: t.Description,
-				Parameters:  t.Parameters,
-			}
-		}
-		msg.Setup.Tools = []geminiTool{{FunctionDeclarations: decls}}
-	}
-
+func (s *session) sendSetup(msg setupMessage) error {
 	return s.writeJSON(msg)
 }
 
@@ -408,6 +585,7 @@ func (s *session) handleServerContent(sc *serverContent) {
 					Text:        p.Text,
 					NPCID:       "gemini",
 					Timestamp:   time.Now(),
+					Sequence:    s.transcriptSeq.Add(1),
 				}
 				select {
 				case s.transcripts <- entry:
@@ -425,6 +603,7 @@ func (s *session) handleServerContent(sc *serverContent) {
 			SpeakerName: "User",
 			Text:        sc.InputTranscription.Text,
 			Timestamp:   time.Now(),
+			Sequence:    s.transcriptSeq.Add(1),
 		}
 		select {
 		case s.transcripts <- entry:
@@ -441,6 +620,7 @@ func (s *session) handleServerContent(sc *serverContent) {
 			Text:        sc.OutputTranscription.Text,
 			NPCID:       "gemini",
 			Timestamp:   time.Now(),
+			Sequence:    s.transcriptSeq.Add(1),
 		}
 		select {
 		case s.transcripts <- entry:
@@ -536,11 +716,16 @@ func (s *session) SendAudio(chunk []byte) error {
 	}
 	s.mu.Unlock()
 
-	encoded := base64.StdEncoding.EncodeToString(chunk)
+	return s.input.Write(chunk)
+}
+
+// sendMediaChunk sends one coalesced frame of input audio.
+func (s *session) sendMediaChunk(frame []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(frame)
 	msg := realtimeInputMessage{
 		RealtimeInput: realtimeInput{
 			MediaChunks: []mediaChunk{
-				{MIMEType: "audio/pcm;rate=16000", Data: encoded},
+				{MIMEType: fmt.Sprintf("audio/pcm;rate=%d", inputSampleRate), Data: encoded},
 			},
 		},
 	}
@@ -588,6 +773,8 @@ func (s *session) UpdateInstructions(_ string) error {
 }
 
 // InjectTextContext inserts ContextItems into the session as clientContent turns.
+// Gemini only knows "user" and "model" turns, so system items are sent as user
+// turns.
 func (s *session) InjectTextContext(items []s2s.ContextItem) error {
 	s.mu.Lock()
 	if s.closed {
@@ -599,17 +786,16 @@ func (s *session) InjectTextContext(items []s2s.ContextItem) error {
 	if len(items) == 0 {
 		return nil
 	}
+	items, err := s2s.NormalizeContextItems(items)
+	if err != nil {
+		return fmt.Errorf("gemini: %w", err)
+	}
 
 	turns := make([]contentTurn, len(items))
 	for i, item := range items {
-		role := item.Role
-		switch role {
-		case "assistant":
+		role := "user"
+		if item.Role == s2s.RoleAssistant {
 			role = "model"
-		case "model":
-			// already correct
-		default:
-			role = "user"
 		}
 		turns[i] = contentTurn{
 			Role:  role,
@@ -629,6 +815,11 @@ func (s *session) InjectTextContext(items []s2s.ContextItem) error {
 // Interrupt is not supported by the Gemini Live protocol; an error is always
 // returned.
 func (s *session) Interrupt() error {
+	// Interruption is not supported, but buffered speech still goes out
+	// without waiting for the flush delay.
+	if err := s.input.Flush(); err != nil {
+		return fmt.Errorf("gemini: flush audio: %w", err)
+	}
 	return fmt.Errorf("gemini: interrupt not supported")
 }
 
@@ -642,6 +833,7 @@ func (s *session) Close() error {
 	s.closed = true
 	s.mu.Unlock()
 
+	s.input.Stop()
 	s.cancel()    // unblocks receiveLoop and keepaliveLoop
 	close(s.done) // signals keepaliveLoop via done channel
 	s.conn.Close(websocket.StatusNormalClosure, "session closed")
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -239,6 +242,220 @@ func TestConnect_SendsSetup(t *testing.T) {
 	}
 }
 
+func TestWithResponseModalities_AppearsInSetup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []gemini.Option
+		want []string
+	}{
+		{name: "default audio", want: []string{"audio"}},
+		{name: "text only", opts: []gemini.Option{gemini.WithResponseModalities([]string{"text"})}, want: []string{"text"}},
+		{name: "upper case is normalised", opts: []gemini.Option{gemini.WithResponseModalities([]string{"TEXT", "Audio"})}, want: []string{"text", "audio"}},
+		{name: "empty keeps default", opts: []gemini.Option{gemini.WithResponseModalities(nil)}, want: []string{"audio"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := make(chan []string, 1)
+			srv := startGeminiServer(t, func(conn *websocket.Conn, _ *http.Request) {
+				var msg struct {
+					Setup struct {
+						GenerationConfig struct {
+							ResponseModalities []string `json:"responseModalities"`
+						} `json:"generationConfig"`
+					} `json:"setup"`
+				}
+				readJSON(t, conn, &msg)
+				got <- msg.Setup.GenerationConfig.ResponseModalities
+				sendSetupComplete(t, conn)
+				<-conn.CloseRead(context.Background()).Done()
+			})
+
+			opts := append([]gemini.Option{gemini.WithBaseURL(wsURL(srv))}, tc.opts...)
+			handle, err := gemini.New("key", opts...).Connect(context.Background(), s2s.SessionConfig{})
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer handle.Close()
+
+			select {
+			case m := <-got:
+				if !slices.Equal(m, tc.want) {
+					t.Errorf("responseModalities = %v; want %v", m, tc.want)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatal("timeout waiting for setup message")
+			}
+		})
+	}
+}
+
+func TestTranscriptionAndDialogOptions_AppearInSetup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		opts           []gemini.Option
+		wantInput      bool
+		wantOutput     bool
+		wantAffective  bool
+		wantProactive  bool
+		wantAPIVersion string
+	}{
+		{name: "defaults", wantInput: true, wantOutput: true, wantAPIVersion: "v1beta"},
+		{
+			name:           "transcription off",
+			opts:           []gemini.Option{gemini.WithInputTranscription(false), gemini.WithOutputTranscription(false)},
+			wantAPIVersion: "v1beta",
+		},
+		{
+			name:           "output transcription only",
+			opts:           []gemini.Option{gemini.WithInputTranscription(false)},
+			wantOutput:     true,
+			wantAPIVersion: "v1beta",
+		},
+		{
+			name:           "affective dialog",
+			opts:           []gemini.Option{gemini.WithAffectiveDialog(true)},
+			wantInput:      true,
+			wantOutput:     true,
+			wantAffective:  true,
+			wantAPIVersion: "v1alpha",
+		},
+		{
+			name:           "proactive audio",
+			opts:           []gemini.Option{gemini.WithProactiveAudio(true)},
+			wantInput:      true,
+			wantOutput:     true,
+			wantProactive:  true,
+			wantAPIVersion: "v1alpha",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			type setup struct {
+				path string
+				msg  struct {
+					Setup struct {
+						GenerationConfig struct {
+							EnableAffectiveDialog bool `json:"enableAffectiveDialog"`
+						} `json:"generationConfig"`
+						InputAudioTranscription  *struct{} `json:"inputAudioTranscription"`
+						OutputAudioTranscription *struct{} `json:"outputAudioTranscription"`
+						Proactivity              *struct {
+							ProactiveAudio bool `json:"proactiveAudio"`
+						} `json:"proactivity"`
+					} `json:"setup"`
+				}
+			}
+			got := make(chan setup, 1)
+			srv := startGeminiServer(t, func(conn *websocket.Conn, r *http.Request) {
+				var s setup
+				s.path = r.URL.Path
+				readJSON(t, conn, &s.msg)
+				got <- s
+				sendSetupComplete(t, conn)
+				<-conn.CloseRead(context.Background()).Done()
+			})
+
+			opts := append([]gemini.Option{gemini.WithBaseURL(wsURL(srv))}, tc.opts...)
+			handle, err := gemini.New("key", opts...).Connect(context.Background(), s2s.SessionConfig{})
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer handle.Close()
+
+			var s setup
+			select {
+			case s = <-got:
+			case <-time.After(3 * time.Second):
+				t.Fatal("timeout waiting for setup message")
+			}
+			if !strings.Contains(s.path, "."+tc.wantAPIVersion+".") {
+				t.Errorf("path = %q; want API version %s", s.path, tc.wantAPIVersion)
+			}
+			if got := s.msg.Setup.InputAudioTranscription != nil; got != tc.wantInput {
+				t.Errorf("inputAudioTranscription present = %v; want %v", got, tc.wantInput)
+			}
+			if got := s.msg.Setup.OutputAudioTranscription != nil; got != tc.wantOutput {
+				t.Errorf("outputAudioTranscription present = %v; want %v", got, tc.wantOutput)
+			}
+			if got := s.msg.Setup.GenerationConfig.EnableAffectiveDialog; got != tc.wantAffective {
+				t.Errorf("enableAffectiveDialog = %v; want %v", got, tc.wantAffective)
+			}
+			if got := s.msg.Setup.Proactivity != nil && s.msg.Setup.Proactivity.ProactiveAudio; got != tc.wantProactive {
+				t.Errorf("proactivity.proactiveAudio = %v; want %v", got, tc.wantProactive)
+			}
+		})
+	}
+}
+
+func TestConnect_ValidatesBeforeDial(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []gemini.Option
+		voice   string
+		wantErr string
+	}{
+		{name: "unknown voice", voice: "Gandalf", wantErr: `unknown voice "Gandalf"`},
+		{name: "voice is case-sensitive", voice: "puck", wantErr: `unknown voice "puck"`},
+		{
+			name:    "unknown modality",
+			opts:    []gemini.Option{gemini.WithResponseModalities([]string{"video"})},
+			voice:   "Puck",
+			wantErr: `unknown response modality "video"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var dialed atomic.Bool
+			srv := startGeminiServer(t, func(conn *websocket.Conn, _ *http.Request) {
+				dialed.Store(true)
+			})
+
+			opts := append([]gemini.Option{gemini.WithBaseURL(wsURL(srv))}, tc.opts...)
+			_, err := gemini.New("key", opts...).Connect(context.Background(), s2s.SessionConfig{
+				Voice: tts.VoiceProfile{ID: tc.voice},
+			})
+			if err == nil {
+				t.Fatal("Connect: expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error = %q; want it to contain %q", err, tc.wantErr)
+			}
+			if dialed.Load() {
+				t.Error("Connect dialled the server despite invalid config")
+			}
+		})
+	}
+}
+
+func TestCapabilities_ListsPrebuiltVoices(t *testing.T) {
+	t.Parallel()
+
+	caps := gemini.New("key").Capabilities()
+	if len(caps.Voices) != len(gemini.PrebuiltVoices) {
+		t.Fatalf("len(Voices) = %d; want %d", len(caps.Voices), len(gemini.PrebuiltVoices))
+	}
+	for i, v := range caps.Voices {
+		if v.ID != gemini.PrebuiltVoices[i] {
+			t.Errorf("Voices[%d].ID = %q; want %q", i, v.ID, gemini.PrebuiltVoices[i])
+		}
+	}
+}
+
 func TestConnect_IncludesAPIKeyInURL(t *testing.T) {
 	t.Parallel()
 
@@ -269,6 +486,60 @@ func TestConnect_IncludesAPIKeyInURL(t *testing.T) {
 	}
 }
 
+func TestWarmup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []gemini.Option
+		wantErr string // empty: warm-up succeeds
+	}{
+		{name: "valid"},
+		{
+			name:    "unknown modality",
+			opts:    []gemini.Option{gemini.WithResponseModalities([]string{"video"})},
+			wantErr: `unknown response modality "video"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var dialed atomic.Bool
+			closed := make(chan websocket.StatusCode, 1)
+			srv := startGeminiServer(t, func(conn *websocket.Conn, _ *http.Request) {
+				dialed.Store(true)
+				_, _, err := conn.Read(context.Background())
+				closed <- websocket.CloseStatus(err)
+			})
+
+			opts := append([]gemini.Option{gemini.WithBaseURL(wsURL(srv))}, tc.opts...)
+			err := gemini.New("key", opts...).Warmup(context.Background())
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("Warmup error = %v; want it to contain %q", err, tc.wantErr)
+				}
+				if dialed.Load() {
+					t.Error("Warmup dialled the server despite invalid config")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Warmup: %v", err)
+			}
+			select {
+			case code := <-closed:
+				if code != websocket.StatusNormalClosure {
+					t.Errorf("close status = %v; want %v", code, websocket.StatusNormalClosure)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatal("timeout waiting for the warm-up connection to close")
+			}
+		})
+	}
+}
+
 // ── TestSendAudio ──────────────────────────────────────────────────────────────
 
 func TestSendAudio_EncodesAndSends(t *testing.T) {
@@ -518,6 +789,125 @@ func TestTranscripts_InputTranscription(t *testing.T) {
 	}
 }
 
+func TestTranscripts_OnlyWhenEnabled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []gemini.Option
+		wantIn  bool
+		wantOut bool
+	}{
+		{name: "both", wantIn: true, wantOut: true},
+		{name: "input only", opts: []gemini.Option{gemini.WithOutputTranscription(false)}, wantIn: true},
+		{name: "none", opts: []gemini.Option{gemini.WithInputTranscription(false), gemini.WithOutputTranscription(false)}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Like the real API, the server only transcribes what the setup
+			// asked for.
+			srv := startGeminiServer(t, func(conn *websocket.Conn, _ *http.Request) {
+				var msg struct {
+					Setup struct {
+						InputAudioTranscription  *struct{} `json:"inputAudioTranscription"`
+						OutputAudioTranscription *struct{} `json:"outputAudioTranscription"`
+					} `json:"setup"`
+				}
+				readJSON(t, conn, &msg)
+				sendSetupComplete(t, conn)
+
+				content := map[string]any{}
+				if msg.Setup.InputAudioTranscription != nil {
+					content["inputTranscription"] = map[string]any{"text": "Open the gate!"}
+				}
+				if msg.Setup.OutputAudioTranscription != nil {
+					content["outputTranscription"] = map[string]any{"text": "Not before dawn."}
+				}
+				writeJSON(t, conn, map[string]any{"serverContent": content})
+			})
+
+			opts := append([]gemini.Option{gemini.WithBaseURL(wsURL(srv))}, tc.opts...)
+			handle, err := gemini.New("key", opts...).Connect(context.Background(), s2s.SessionConfig{})
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer handle.Close()
+
+			// The server closes the connection after its message, which
+			// closes the channel.
+			var gotIn, gotNPC bool
+			timeout := time.After(3 * time.Second)
+			for done := false; !done; {
+				select {
+				case entry, ok := <-handle.Transcripts():
+					if !ok {
+						done = true
+						break
+					}
+					if entry.IsNPC() {
+						gotNPC = true
+					} else {
+						gotIn = true
+					}
+				case <-timeout:
+					t.Fatal("timeout waiting for the transcripts channel to close")
+				}
+			}
+			if gotIn != tc.wantIn {
+				t.Errorf("input transcript received = %v; want %v", gotIn, tc.wantIn)
+			}
+			if gotNPC != tc.wantOut {
+				t.Errorf("output transcript received = %v; want %v", gotNPC, tc.wantOut)
+			}
+		})
+	}
+}
+
+func TestTranscripts_Sequence(t *testing.T) {
+	t.Parallel()
+
+	srv := startGeminiServer(t, func(conn *websocket.Conn, _ *http.Request) {
+		var raw map[string]any
+		readJSON(t, conn, &raw)
+		sendSetupComplete(t, conn)
+
+		writeJSON(t, conn, map[string]any{
+			"serverContent": map[string]any{
+				"inputTranscription": map[string]any{"text": "Who goes there?"},
+			},
+		})
+		writeJSON(t, conn, map[string]any{
+			"serverContent": map[string]any{
+				"outputTranscription": map[string]any{"text": "A friend."},
+			},
+		})
+
+		<-conn.CloseRead(context.Background()).Done()
+	})
+
+	// A reconnecting caller continues the numbering of the previous session.
+	p := newProvider(srv)
+	handle, err := p.Connect(context.Background(), s2s.SessionConfig{TranscriptSequenceStart: 7})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer handle.Close()
+
+	for _, want := range []uint64{8, 9} {
+		select {
+		case entry := <-handle.Transcripts():
+			if entry.Sequence != want {
+				t.Errorf("%q: Sequence = %d; want %d", entry.Text, entry.Sequence, want)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout waiting for transcript")
+		}
+	}
+}
+
 func TestTranscripts_ChannelNotNil(t *testing.T) {
 	t.Parallel()
 
@@ -860,6 +1250,76 @@ func TestInjectTextContext_SendsClientContent(t *testing.T) {
 	}
 }
 
+// TestInjectTextContext_NormalizesRoles verifies that the role spellings
+// accepted by s2s.NormalizeContextItems map to Gemini turn roles and that an
+// unknown role fails without sending anything.
+func TestInjectTextContext_NormalizesRoles(t *testing.T) {
+	t.Parallel()
+
+	type clientContentMsg struct {
+		ClientContent struct {
+			Turns []struct {
+				Role string `json:"role"`
+			} `json:"turns"`
+		} `json:"clientContent"`
+	}
+
+	contextMsg := make(chan clientContentMsg, 2)
+	srv := startGeminiServer(t, func(conn *websocket.Conn, _ *http.Request) {
+		var raw map[string]any
+		readJSON(t, conn, &raw)
+		sendSetupComplete(t, conn)
+		for {
+			_, data, err := conn.Read(context.Background())
+			if err != nil {
+				return
+			}
+			var msg clientContentMsg
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Errorf("unmarshal: %v", err)
+				return
+			}
+			contextMsg <- msg
+		}
+	})
+
+	p := newProvider(srv)
+	handle, err := p.Connect(context.Background(), s2s.SessionConfig{})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer handle.Close()
+
+	err = handle.InjectTextContext([]s2s.ContextItem{{Role: "user", Content: "ok"}, {Role: "narrator", Content: "bad"}})
+	if !errors.Is(err, s2s.ErrUnknownRole) {
+		t.Fatalf("unknown role: want ErrUnknownRole, got %v", err)
+	}
+
+	items := []s2s.ContextItem{
+		{Role: "system", Content: "The tavern is crowded."},
+		{Role: "User", Content: "Any rooms free?"},
+		{Role: "", Content: "Hello?"},
+		{Role: "model", Content: "One, upstairs."},
+		{Role: "assistant", Content: "Two silver."},
+	}
+	if err := handle.InjectTextContext(items); err != nil {
+		t.Fatalf("InjectTextContext: %v", err)
+	}
+
+	select {
+	case msg := <-contextMsg:
+		var roles []string
+		for _, turn := range msg.ClientContent.Turns {
+			roles = append(roles, turn.Role)
+		}
+		if want := []string{"user", "user", "user", "model", "model"}; !slices.Equal(roles, want) {
+			t.Errorf("roles = %q; want %q", roles, want)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for clientContent message")
+	}
+}
+
 func TestInjectTextContext_AfterClose_ReturnsError(t *testing.T) {
 	t.Parallel()
 
@@ -14,9 +14,11 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/MrWong99/glyphoxa/pkg/memory"
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 	"github.com/MrWong99/glyphoxa/pkg/provider/llm"
 	"github.com/MrWong99/glyphoxa/pkg/provider/s2s"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
@@ -26,10 +28,14 @@ import (
 // Compile-time assertions that Provider and session satisfy the s2s interfaces.
 var _ s2s.Provider = (*Provider)(nil)
 var _ s2s.SessionHandle = (*session)(nil)
+var _ s2s.ResponseObserver = (*session)(nil)
 
 const (
 	defaultModel   = "gpt-4o-realtime-preview"
 	defaultBaseURL = "wss://api.openai.com/v1/realtime"
+
+	// inputSampleRate is the sample rate of the "pcm16" input audio format.
+	inputSampleRate = 24000
 )
 
 // ── Options ────────────────────────────────────────────────────────────────────
@@ -48,21 +54,36 @@ func WithBaseURL(url string) Option {
 	return func(p *Provider) { p.baseURL = url }
 }
 
+// WithAudioFrameDuration sets how much input audio is coalesced into one
+// upstream message. Chunks passed to SendAudio are buffered until a frame is
+// full, or until no further audio arrives for the same duration. Zero sends
+// every chunk as-is. Negative values are ignored. Defaults to
+// [s2s.DefaultAudioFrameDuration].
+func WithAudioFrameDuration(d time.Duration) Option {
+	return func(p *Provider) {
+		if d >= 0 {
+			p.frameDuration = d
+		}
+	}
+}
+
 // ── Provider ───────────────────────────────────────────────────────────────────
 
 // Provider implements s2s.Provider for OpenAI's Realtime API.
 type Provider struct {
-	apiKey  string
-	model   string
-	baseURL string
+	apiKey        string
+	model         string
+	baseURL       string
+	frameDuration time.Duration
 }
 
 // New creates a new OpenAI Realtime Provider with the given API key and options.
 func New(apiKey string, opts ...Option) *Provider {
 	p := &Provider{
-		apiKey:  apiKey,
-		model:   defaultModel,
-		baseURL: defaultBaseURL,
+		apiKey:        apiKey,
+		model:         defaultModel,
+		baseURL:       defaultBaseURL,
+		frameDuration: s2s.DefaultAudioFrameDuration,
 	}
 	for _, o := range opts {
 		o(p)
@@ -93,16 +114,9 @@ func (p *Provider) Capabilities() s2s.S2SCapabilities {
 // The returned SessionHandle is ready to accept audio immediately after the
 // session.update message is sent.
 func (p *Provider) Connect(ctx context.Context, cfg s2s.SessionConfig) (s2s.SessionHandle, error) {
-	wsURL := fmt.Sprintf("%s?model=%s", p.baseURL, p.model)
-
-	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
-		HTTPHeader: http.Header{
-			"Authorization": []string{"Bearer " + p.apiKey},
-			"OpenAI-Beta":   []string{"realtime=v1"},
-		},
-	})
+	conn, err := p.dial(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("openai: dial: %w", err)
+		return nil, err
 	}
 
 	sessCtx, sessCancel := context.WithCancel(context.Background())
@@ -113,6 +127,9 @@ func (p *Provider) Connect(ctx context.Context, cfg s2s.SessionConfig) (s2s.Sess
 		ctx:         sessCtx,
 		cancel:      sessCancel,
 	}
+	sess.transcriptSeq.Store(cfg.TranscriptSequenceStart)
+	frameSize := s2s.AudioFrameBytes(p.frameDuration, inputSampleRate, 1)
+	sess.input = s2s.NewAudioBuffer(frameSize, p.frameDuration, sess.appendAudio)
 
 	if err := sess.sendSessionUpdate(cfg.Voice, cfg.Instructions, cfg.Tools); err != nil {
 		sessCancel()
@@ -125,6 +142,34 @@ func (p *Provider) Connect(ctx context.Context, cfg s2s.SessionConfig) (s2s.Sess
 	return sess, nil
 }
 
+// Warmup opens and immediately closes a connection to the Realtime API, so
+// that DNS, TLS and authentication failures surface at startup rather than
+// when the first player speaks. No session is configured and no audio is
+// sent.
+func (p *Provider) Warmup(ctx context.Context) error {
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.Close(websocket.StatusNormalClosure, "warm-up")
+}
+
+// dial opens a WebSocket connection to the Realtime API for p.model.
+func (p *Provider) dial(ctx context.Context) (*websocket.Conn, error) {
+	wsURL := fmt.Sprintf("%s?model=%s", p.baseURL, p.model)
+
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		HTTPHeader: ident.Header(ctx, http.Header{
+			"Authorization": []string{"Bearer " + p.apiKey},
+			"OpenAI-Beta":   []string{"realtime=v1"},
+		}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai: dial: %w", err)
+	}
+	return conn, nil
+}
+
 // ── Protocol message types (outgoing) ─────────────────────────────────────────
 
 type sessionUpdateMessage struct {
@@ -197,6 +242,24 @@ type serverEvent struct {
 
 	// error event
 	Error *serverErrorDetail `json:"error,omitempty"`
+
+	// response.done
+	Response *serverResponse `json:"response,omitempty"`
+}
+
+// serverResponse is the response object carried by a response.done event.
+type serverResponse struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	StatusDetails *struct {
+		Reason string             `json:"reason,omitempty"`
+		Error  *serverErrorDetail `json:"error,omitempty"`
+	} `json:"status_details,omitempty"`
+	Usage *struct {
+		TotalTokens  int `json:"total_tokens"`
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage,omitempty"`
 }
 
 // ── session ────────────────────────────────────────────────────────────────────
@@ -207,13 +270,21 @@ type session struct {
 	transcripts  chan memory.TranscriptEntry
 	toolHandler  s2s.ToolCallHandler
 	errorHandler func(error)
+	doneHandler  func(s2s.ResponseDone)
+
+	// input coalesces SendAudio chunks into frames of the configured duration.
+	input *s2s.AudioBuffer
+
+	// transcriptSeq is the Sequence of the last emitted transcript entry.
+	transcriptSeq atomic.Uint64
 
 	mu     sync.Mutex
 	errVal error
 	closed bool
 
 	// currentTxText accumulates response.audio_transcript.delta events until
-	// response.audio_transcript.done is received.
+	// response.audio_transcript.done or response.done is received. It is reset
+	// at the end of every response so the next one starts a new entry.
 	currentTxText string
 
 	ctx       context.Context
@@ -297,25 +368,10 @@ func (s *session) handleServerEvent(evt *serverEvent) {
 		s.mu.Unlock()
 
 	case "response.audio_transcript.done":
-		s.mu.Lock()
-		text := s.currentTxText
-		s.currentTxText = ""
-		s.mu.Unlock()
+		s.flushTranscript()
 
-		if text == "" {
-			return
-		}
-		entry := memory.TranscriptEntry{
-			SpeakerID:   "assistant",
-			SpeakerName: "NPC",
-			Text:        text,
-			NPCID:       "openai",
-			Timestamp:   time.Now(),
-		}
-		select {
-		case s.transcripts <- entry:
-		case <-s.ctx.Done():
-		}
+	case "response.done":
+		s.handleResponseDone(evt)
 
 	case "conversation.item.input_audio_transcription.completed":
 		if evt.Transcript == "" {
@@ -326,6 +382,7 @@ func (s *session) handleServerEvent(evt *serverEvent) {
 			SpeakerName: "User",
 			Text:        evt.Transcript,
 			Timestamp:   time.Now(),
+			Sequence:    s.transcriptSeq.Add(1),
 		}
 		select {
 		case s.transcripts <- entry:
@@ -340,6 +397,75 @@ func (s *session) handleServerEvent(evt *serverEvent) {
 	}
 }
 
+// flushTranscript emits the NPC transcript accumulated so far, if any, as one
+// entry and starts a new one.
+func (s *session) flushTranscript() {
+	s.mu.Lock()
+	text := s.currentTxText
+	s.currentTxText = ""
+	s.mu.Unlock()
+
+	if text == "" {
+		return
+	}
+	entry := memory.TranscriptEntry{
+		SpeakerID:   "assistant",
+		SpeakerName: "NPC",
+		Text:        text,
+		NPCID:       "openai",
+		Timestamp:   time.Now(),
+		Sequence:    s.transcriptSeq.Add(1),
+	}
+	select {
+	case s.transcripts <- entry:
+	case <-s.ctx.Done():
+	}
+}
+
+// handleResponseDone finalizes a model response. Transcript text the server
+// never closed with response.audio_transcript.done, e.g. because the response
+// was cancelled mid-sentence, is emitted as its own entry so it cannot run
+// into the next response. A failed response is reported to the error handler;
+// every response is reported to the response handler.
+func (s *session) handleResponseDone(evt *serverEvent) {
+	s.flushTranscript()
+
+	done := s2s.ResponseDone{Status: s2s.ResponseCompleted}
+	var failure *serverErrorDetail
+	if r := evt.Response; r != nil {
+		done.ID = r.ID
+		if r.Status != "" {
+			done.Status = r.Status
+		}
+		if d := r.StatusDetails; d != nil {
+			done.Reason = d.Reason
+			failure = d.Error
+		}
+		if u := r.Usage; u != nil {
+			done.Usage = llm.Usage{
+				PromptTokens:     u.InputTokens,
+				CompletionTokens: u.OutputTokens,
+				TotalTokens:      u.TotalTokens,
+			}
+		}
+	}
+
+	if done.Status == s2s.ResponseFailed {
+		detail := failure
+		if detail == nil {
+			detail = &serverErrorDetail{Message: "response failed"}
+		}
+		s.handleErrorEvent(&serverEvent{Type: "error", Error: detail})
+	}
+
+	s.mu.Lock()
+	handler := s.doneHandler
+	s.mu.Unlock()
+	if handler != nil {
+		handler(done)
+	}
+}
+
 func (s *session) handleErrorEvent(evt *serverEvent) {
 	s.mu.Lock()
 	handler := s.errorHandler
@@ -422,7 +548,12 @@ func (s *session) SendAudio(chunk []byte) error {
 	}
 	s.mu.Unlock()
 
-	encoded := base64.StdEncoding.EncodeToString(chunk)
+	return s.input.Write(chunk)
+}
+
+// appendAudio sends one coalesced frame of input audio.
+func (s *session) appendAudio(frame []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(frame)
 	return s.writeJSON(appendAudioMessage{
 		Type:  "input_audio_buffer.append",
 		Audio: encoded,
@@ -449,6 +580,14 @@ func (s *session) OnError(handler func(error)) {
 	s.errorHandler = handler
 }
 
+// OnResponseDone implements [s2s.ResponseObserver]. The handler is called for
+// every response.done event.
+func (s *session) OnResponseDone(handler func(s2s.ResponseDone)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doneHandler = handler
+}
+
 // OnToolCall registers a callback for tool invocations from the model.
 func (s *session) OnToolCall(handler s2s.ToolCallHandler) {
 	s.mu.Lock()
@@ -486,21 +625,20 @@ func (s *session) InjectTextContext(items []s2s.ContextItem) error {
 	}
 	s.mu.Unlock()
 
+	// OpenAI Realtime uses the canonical "system", "user" and "assistant"
+	// roles for conversation items as they are.
+	items, err := s2s.NormalizeContextItems(items)
+	if err != nil {
+		return fmt.Errorf("openai: %w", err)
+	}
+
 	for _, item := range items {
 		role := item.Role
-		// OpenAI Realtime supports "user", "assistant", and "system" roles for
-		// conversation items. Unknown roles are coerced to "user".
-		switch role {
-		case "assistant", "system":
-			// keep as-is
-		default:
-			role = "user"
-		}
 
 		// Choose the content part type based on role: assistant messages use
 		// "text", everything else uses "input_text".
 		partType := "input_text"
-		if role == "assistant" {
+		if role == s2s.RoleAssistant {
 			partType = "text"
 		}
 
@@ -523,6 +661,10 @@ func (s *session) InjectTextContext(items []s2s.ContextItem) error {
 
 // Interrupt sends a response.cancel event to stop the current model response.
 func (s *session) Interrupt() error {
+	// Buffered speech that triggered the barge-in goes out first.
+	if err := s.input.Flush(); err != nil {
+		return fmt.Errorf("openai: flush audio: %w", err)
+	}
 	return s.writeJSON(map[string]string{"type": "response.cancel"})
 }
 
@@ -536,6 +678,7 @@ func (s *session) Close() error {
 	s.closed = true
 	s.mu.Unlock()
 
+	s.input.Stop()
 	s.cancel()
 	s.conn.Close(websocket.StatusNormalClosure, "session closed")
 	return nil
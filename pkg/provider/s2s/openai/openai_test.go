@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -251,6 +253,48 @@ func TestConnect_SendsAuthHeaders(t *testing.T) {
 	}
 }
 
+func TestWarmup_DialsAndCloses(t *testing.T) {
+	t.Parallel()
+
+	authHeader := make(chan string, 1)
+	closed := make(chan websocket.StatusCode, 1)
+	srv := startOpenAIServer(t, func(conn *websocket.Conn, r *http.Request) {
+		authHeader <- r.Header.Get("Authorization")
+		_, _, err := conn.Read(context.Background())
+		closed <- websocket.CloseStatus(err)
+	})
+
+	p := openai.New("my-secret-token", openai.WithBaseURL(wsURL(srv)))
+	if err := p.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	if auth := <-authHeader; auth != "Bearer my-secret-token" {
+		t.Errorf("Authorization = %q; want Bearer my-secret-token", auth)
+	}
+	select {
+	case code := <-closed:
+		if code != websocket.StatusNormalClosure {
+			t.Errorf("close status = %v; want %v", code, websocket.StatusNormalClosure)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for the warm-up connection to close")
+	}
+}
+
+func TestWarmup_DialError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+	}))
+	t.Cleanup(srv.Close)
+
+	err := openai.New("bad-key", openai.WithBaseURL(wsURL(srv))).Warmup(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "openai: dial") {
+		t.Errorf("Warmup error = %v; want a dial error", err)
+	}
+}
+
 // ── TestSendAudio ──────────────────────────────────────────────────────────────
 
 func TestSendAudio_EncodesAndSends(t *testing.T) {
@@ -305,6 +349,76 @@ func TestSendAudio_EncodesAndSends(t *testing.T) {
 	}
 }
 
+// TestSendAudio_CoalescesFrames verifies that many small SendAudio chunks are
+// sent as fewer frames of the configured duration, and that the partial
+// remainder is flushed ahead of an interruption.
+func TestSendAudio_CoalescesFrames(t *testing.T) {
+	t.Parallel()
+
+	type clientMsg struct {
+		Type  string `json:"type"`
+		Audio string `json:"audio"`
+	}
+
+	// Frame sizes of every append message, in order, up to response.cancel.
+	framesCh := make(chan []int, 1)
+
+	srv := startOpenAIServer(t, func(conn *websocket.Conn, _ *http.Request) {
+		var raw map[string]any
+		readJSON(t, conn, &raw)
+
+		var frames []int
+		for {
+			var msg clientMsg
+			readJSON(t, conn, &msg)
+			if msg.Type == "response.cancel" {
+				break
+			}
+			pcm, err := base64.StdEncoding.DecodeString(msg.Audio)
+			if err != nil {
+				t.Errorf("base64 decode: %v", err)
+			}
+			frames = append(frames, len(pcm))
+		}
+		framesCh <- frames
+
+		<-conn.CloseRead(context.Background()).Done()
+	})
+
+	p := openai.New("key",
+		openai.WithBaseURL(wsURL(srv)),
+		openai.WithAudioFrameDuration(100*time.Millisecond),
+	)
+	handle, err := p.Connect(context.Background(), s2s.SessionConfig{})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer handle.Close()
+
+	// 50 chunks of 10 ms of 24 kHz PCM16 = 500 ms, then a 1 ms tail.
+	for range 50 {
+		if err := handle.SendAudio(make([]byte, 480)); err != nil {
+			t.Fatalf("SendAudio: %v", err)
+		}
+	}
+	if err := handle.SendAudio(make([]byte, 48)); err != nil {
+		t.Fatalf("SendAudio: %v", err)
+	}
+	if err := handle.Interrupt(); err != nil {
+		t.Fatalf("Interrupt: %v", err)
+	}
+
+	select {
+	case got := <-framesCh:
+		want := []int{4800, 4800, 4800, 4800, 4800, 48}
+		if !slices.Equal(got, want) {
+			t.Errorf("frame sizes = %v; want %v", got, want)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for audio frames")
+	}
+}
+
 func TestSendAudio_AfterClose_ReturnsError(t *testing.T) {
 	t.Parallel()
 
@@ -469,6 +583,170 @@ func TestTranscripts_UserSpeechTranscription(t *testing.T) {
 	}
 }
 
+func TestTranscripts_Sequence(t *testing.T) {
+	t.Parallel()
+
+	srv := startOpenAIServer(t, func(conn *websocket.Conn, _ *http.Request) {
+		var raw map[string]any
+		readJSON(t, conn, &raw)
+
+		writeJSON(t, conn, map[string]any{
+			"type":       "conversation.item.input_audio_transcription.completed",
+			"transcript": "Who goes there?",
+		})
+		writeJSON(t, conn, map[string]any{"type": "response.audio_transcript.delta", "delta": "A friend."})
+		writeJSON(t, conn, map[string]any{"type": "response.audio_transcript.done"})
+
+		<-conn.CloseRead(context.Background()).Done()
+	})
+
+	// A reconnecting caller continues the numbering of the previous session.
+	p := openai.New("key", openai.WithBaseURL(wsURL(srv)))
+	handle, err := p.Connect(context.Background(), s2s.SessionConfig{TranscriptSequenceStart: 7})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer handle.Close()
+
+	for _, want := range []uint64{8, 9} {
+		select {
+		case entry := <-handle.Transcripts():
+			if entry.Sequence != want {
+				t.Errorf("%q: Sequence = %d; want %d", entry.Text, entry.Sequence, want)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout waiting for transcript")
+		}
+	}
+}
+
+func TestResponseDone_SeparatesTranscripts(t *testing.T) {
+	t.Parallel()
+
+	ready := make(chan struct{})
+	srv := startOpenAIServer(t, func(conn *websocket.Conn, _ *http.Request) {
+		var raw map[string]any
+		readJSON(t, conn, &raw)
+		<-ready
+
+		// A response cut off by barge-in: no transcript done event.
+		writeJSON(t, conn, map[string]any{"type": "response.audio_transcript.delta", "delta": "Welcome to the "})
+		writeJSON(t, conn, map[string]any{"type": "response.audio_transcript.delta", "delta": "Prancing"})
+		writeJSON(t, conn, map[string]any{
+			"type": "response.done",
+			"response": map[string]any{
+				"id":             "resp_1",
+				"status":         "cancelled",
+				"status_details": map[string]any{"type": "cancelled", "reason": "turn_detected"},
+				"usage":          map[string]any{"total_tokens": 120, "input_tokens": 100, "output_tokens": 20},
+			},
+		})
+		// The next response.
+		writeJSON(t, conn, map[string]any{"type": "response.audio_transcript.delta", "delta": "What will it be?"})
+		writeJSON(t, conn, map[string]any{"type": "response.audio_transcript.done"})
+		writeJSON(t, conn, map[string]any{
+			"type": "response.done",
+			"response": map[string]any{
+				"id":     "resp_2",
+				"status": "completed",
+				"usage":  map[string]any{"total_tokens": 150, "input_tokens": 130, "output_tokens": 20},
+			},
+		})
+
+		<-conn.CloseRead(context.Background()).Done()
+	})
+
+	p := openai.New("key", openai.WithBaseURL(wsURL(srv)))
+	handle, err := p.Connect(context.Background(), s2s.SessionConfig{})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer handle.Close()
+
+	observer, ok := handle.(s2s.ResponseObserver)
+	if !ok {
+		t.Fatal("session does not implement s2s.ResponseObserver")
+	}
+	doneCh := make(chan s2s.ResponseDone, 2)
+	observer.OnResponseDone(func(d s2s.ResponseDone) { doneCh <- d })
+	close(ready)
+
+	var texts []string
+	for len(texts) < 2 {
+		select {
+		case entry, ok := <-handle.Transcripts():
+			if !ok {
+				t.Fatal("Transcripts channel closed unexpectedly")
+			}
+			texts = append(texts, entry.Text)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timeout waiting for transcripts, got %q", texts)
+		}
+	}
+	if want := []string{"Welcome to the Prancing", "What will it be?"}; !slices.Equal(texts, want) {
+		t.Errorf("transcripts = %q; want separate entries %q", texts, want)
+	}
+
+	want := []s2s.ResponseDone{
+		{ID: "resp_1", Status: s2s.ResponseCancelled, Reason: "turn_detected", Usage: llm.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}},
+		{ID: "resp_2", Status: s2s.ResponseCompleted, Usage: llm.Usage{PromptTokens: 130, CompletionTokens: 20, TotalTokens: 150}},
+	}
+	for i, w := range want {
+		select {
+		case got := <-doneCh:
+			if got != w {
+				t.Errorf("response %d = %+v; want %+v", i, got, w)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timeout waiting for response %d", i)
+		}
+	}
+}
+
+func TestResponseDone_FailedReportsError(t *testing.T) {
+	t.Parallel()
+
+	ready := make(chan struct{})
+	srv := startOpenAIServer(t, func(conn *websocket.Conn, _ *http.Request) {
+		var raw map[string]any
+		readJSON(t, conn, &raw)
+		<-ready
+
+		writeJSON(t, conn, map[string]any{
+			"type": "response.done",
+			"response": map[string]any{
+				"status": "failed",
+				"status_details": map[string]any{
+					"type":  "failed",
+					"error": map[string]any{"type": "server_error", "message": "The server had an error."},
+				},
+			},
+		})
+
+		<-conn.CloseRead(context.Background()).Done()
+	})
+
+	p := openai.New("key", openai.WithBaseURL(wsURL(srv)))
+	handle, err := p.Connect(context.Background(), s2s.SessionConfig{})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer handle.Close()
+
+	errCh := make(chan error, 1)
+	handle.OnError(func(e error) { errCh <- e })
+	close(ready)
+
+	select {
+	case gotErr := <-errCh:
+		if !strings.Contains(gotErr.Error(), "The server had an error.") {
+			t.Errorf("error = %q; want the failure message", gotErr)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for OnError handler to be called")
+	}
+}
+
 func TestTranscripts_ChannelNotNil(t *testing.T) {
 	t.Parallel()
 
@@ -866,6 +1144,80 @@ func TestInjectTextContext_SendsConversationItems(t *testing.T) {
 	}
 }
 
+// TestInjectTextContext_NormalizesRoles verifies that the role spellings
+// accepted by s2s.NormalizeContextItems map to OpenAI Realtime roles and that
+// an unknown role fails without sending anything.
+func TestInjectTextContext_NormalizesRoles(t *testing.T) {
+	t.Parallel()
+
+	type itemMsg struct {
+		Type string `json:"type"`
+		Item struct {
+			Role    string `json:"role"`
+			Content []struct {
+				Type string `json:"type"`
+			} `json:"content"`
+		} `json:"item"`
+	}
+
+	ctxItems := []s2s.ContextItem{
+		{Role: "system", Content: "The tavern is crowded."},
+		{Role: "User", Content: "Any rooms free?"},
+		{Role: "", Content: "Hello?"},
+		{Role: "model", Content: "One, upstairs."},
+		{Role: "assistant", Content: "Two silver."},
+	}
+	wantRoles := []string{"system", "user", "user", "assistant", "assistant"}
+	wantParts := []string{"input_text", "input_text", "input_text", "text", "text"}
+
+	received := make(chan itemMsg, len(ctxItems)+1)
+	srv := startOpenAIServer(t, func(conn *websocket.Conn, _ *http.Request) {
+		var raw map[string]any
+		readJSON(t, conn, &raw) // session.update
+		for {
+			_, data, err := conn.Read(context.Background())
+			if err != nil {
+				return
+			}
+			var msg itemMsg
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Errorf("unmarshal: %v", err)
+				return
+			}
+			received <- msg
+		}
+	})
+
+	p := openai.New("key", openai.WithBaseURL(wsURL(srv)))
+	handle, err := p.Connect(context.Background(), s2s.SessionConfig{})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer handle.Close()
+
+	err = handle.InjectTextContext([]s2s.ContextItem{{Role: "user", Content: "ok"}, {Role: "narrator", Content: "bad"}})
+	if !errors.Is(err, s2s.ErrUnknownRole) {
+		t.Fatalf("unknown role: want ErrUnknownRole, got %v", err)
+	}
+	if err := handle.InjectTextContext(ctxItems); err != nil {
+		t.Fatalf("InjectTextContext: %v", err)
+	}
+
+	for i := range ctxItems {
+		select {
+		case msg := <-received:
+			if msg.Item.Role != wantRoles[i] {
+				t.Errorf("item[%d] role = %q; want %q", i, msg.Item.Role, wantRoles[i])
+			}
+			if len(msg.Item.Content) == 0 || msg.Item.Content[0].Type != wantParts[i] {
+				t.Errorf("item[%d] content = %+v; want part type %q", i, msg.Item.Content, wantParts[i])
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timeout waiting for conversation item %d", i)
+		}
+	}
+}
+
 func TestInjectTextContext_AfterClose_ReturnsError(t *testing.T) {
 	t.Parallel()
 
@@ -37,8 +37,9 @@ type ToolCallHandler func(name string, args string) (string, error)
 // It is used to add background knowledge, NPC state updates, or corrected
 // transcripts without resending the full conversation history.
 type ContextItem struct {
-	// Role is the speaker role for this context item. Typical values match LLM
-	// message roles: "system", "user", "assistant".
+	// Role is the speaker role for this context item: [RoleSystem],
+	// [RoleUser] or [RoleAssistant]. See [NormalizeContextItems] for the
+	// accepted spellings.
 	Role string
 
 	// Content is the text content of the context item.
@@ -59,6 +60,13 @@ type SessionConfig struct {
 	// may invoke these during the session; tool calls are surfaced via the
 	// ToolCallHandler set with OnToolCall.
 	Tools []llm.ToolDefinition
+
+	// TranscriptSequenceStart is the sequence number the session's transcript
+	// numbering continues from: its entries carry [memory.TranscriptEntry]
+	// Sequence values TranscriptSequenceStart+1, +2, and so on. A caller that
+	// reconnects after a session died passes the last number it received, so
+	// numbering stays monotonic across sessions. Zero starts at 1.
+	TranscriptSequenceStart uint64
 }
 
 // S2SCapabilities describes static properties of the S2S provider.
@@ -110,7 +118,8 @@ type SessionHandle interface {
 
 	// Transcripts returns a read-only channel that emits TranscriptEntry values for
 	// both user speech (as recognised by the model) and NPC responses (as generated
-	// text). The channel is closed when the session ends.
+	// text). The channel is closed when the session ends. Entries are numbered
+	// in emission order, continuing from [SessionConfig.TranscriptSequenceStart].
 	Transcripts() <-chan memory.TranscriptEntry
 
 	// OnError registers a handler that is invoked whenever the provider encounters
@@ -145,6 +154,8 @@ type SessionHandle interface {
 	// context. This is used to surface important state (NPC health, quest updates)
 	// without waiting for the user to speak. Implementations should append items in
 	// order and truncate oldest context if the session's ContextWindow is exceeded.
+	// Roles are normalised with [NormalizeContextItems]; an unknown role fails
+	// the whole call before anything is sent.
 	InjectTextContext(items []ContextItem) error
 
 	// Interrupt signals the provider to stop generating the current response and
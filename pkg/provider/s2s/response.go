@@ -0,0 +1,46 @@
+package s2s
+
+import "github.com/MrWong99/glyphoxa/pkg/provider/llm"
+
+// Response statuses reported in [ResponseDone.Status].
+const (
+	ResponseCompleted  = "completed"
+	ResponseCancelled  = "cancelled"
+	ResponseIncomplete = "incomplete"
+	ResponseFailed     = "failed"
+)
+
+// ResponseDone describes a model response that has finished, successfully or
+// not.
+type ResponseDone struct {
+	// ID is the provider's identifier of the response, if it has one.
+	ID string
+
+	// Status is how the response ended: [ResponseCompleted],
+	// [ResponseCancelled] (e.g. after [SessionHandle.Interrupt]),
+	// [ResponseIncomplete] (e.g. the output token limit was hit) or
+	// [ResponseFailed]. Providers may report other statuses verbatim.
+	Status string
+
+	// Reason optionally explains a status other than [ResponseCompleted],
+	// such as "turn_detected" or "max_output_tokens".
+	Reason string
+
+	// Usage is the token accounting of the response. All counts are zero
+	// when the provider did not report usage.
+	Usage llm.Usage
+}
+
+// ResponseObserver is an optional capability of a [SessionHandle] that
+// reports when each model response is finished, together with its token
+// usage. Callers detect it with a type assertion.
+type ResponseObserver interface {
+	// OnResponseDone registers a handler that is invoked once for every
+	// finished model response, after the response's transcript entry has been
+	// emitted. Only one handler can be active at a time; calling
+	// OnResponseDone again replaces the previous handler. Passing nil clears
+	// the handler.
+	//
+	// The handler is invoked on an internal goroutine and must not block.
+	OnResponseDone(handler func(ResponseDone))
+}
@@ -0,0 +1,631 @@
+// Package azure provides an Azure AI Speech (Cognitive Services) STT provider
+// using the Speech service's streaming WebSocket protocol. It implements the
+// stt.Provider interface.
+//
+// The protocol exchanges framed messages over a single socket. Text messages
+// carry "Name: value" header lines, a blank line, and a JSON body; the Path
+// header names the message type. Binary audio messages start with a 2-byte
+// big-endian header length, followed by the headers and the audio payload.
+// The client sends a speech.config message, a WAV header, and then raw PCM;
+// the service answers with speech.hypothesis (interim) and speech.phrase
+// (final) messages and ends the turn with turn.end. An empty audio message
+// marks the end of the stream. The service's voice activity detection also
+// reports speech.startDetected and speech.endDetected; they are delivered as
+// [SpeechEvent] values to the handler registered via [WithSpeechHandler].
+//
+// A session covers a single service turn. The turn ends when the session is
+// closed or when the service ends it, for example after a long silence; the
+// Partials and Finals channels are closed in both cases.
+package azure
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	"github.com/coder/websocket"
+)
+
+const (
+	endpointFormat    = "wss://%s.stt.speech.microsoft.com/speech/recognition/conversation/cognitiveservices/v1"
+	defaultLanguage   = "en-US"
+	defaultSampleRate = 16000
+
+	// closeTimeout bounds how long Close waits for the service to deliver the
+	// final results after the end of the audio stream.
+	closeTimeout = 5 * time.Second
+
+	// ticksPerDuration converts the service's 100-nanosecond ticks.
+	ticksPerDuration = 100 * time.Nanosecond
+)
+
+// SpeechEventType identifies a voice activity change reported by the service.
+type SpeechEventType int
+
+const (
+	// SpeechStarted is reported when the service detects the start of speech
+	// (speech.startDetected).
+	SpeechStarted SpeechEventType = iota
+
+	// SpeechEnded is reported when the service detects the end of speech
+	// (speech.endDetected). The final transcript of the utterance follows.
+	SpeechEnded
+)
+
+// String returns a human-readable name for the event type.
+func (t SpeechEventType) String() string {
+	switch t {
+	case SpeechStarted:
+		return "speech_started"
+	case SpeechEnded:
+		return "speech_ended"
+	default:
+		return fmt.Sprintf("SpeechEventType(%d)", int(t))
+	}
+}
+
+// SpeechEvent is delivered to the handler registered via
+// [WithSpeechHandler].
+type SpeechEvent struct {
+	// Type is the voice activity change being reported.
+	Type SpeechEventType
+
+	// Offset is the position in the audio stream at which the change was
+	// detected, relative to session start, like [stt.Transcript.Timestamp].
+	Offset time.Duration
+}
+
+// Option is a functional option for configuring the Azure Provider.
+type Option func(*Provider)
+
+// WithRegion sets the Azure region of the Speech resource (e.g., "westeurope").
+func WithRegion(region string) Option {
+	return func(p *Provider) {
+		p.region = region
+	}
+}
+
+// WithLanguage sets the default BCP-47 recognition language (e.g., "en-US",
+// "de-DE"). A non-empty StreamConfig.Language takes precedence.
+func WithLanguage(language string) Option {
+	return func(p *Provider) {
+		p.language = language
+	}
+}
+
+// WithModel selects a Custom Speech model by the ID of its deployed endpoint,
+// as shown in Speech Studio. The default is the base model for the language.
+func WithModel(endpointID string) Option {
+	return func(p *Provider) {
+		p.model = endpointID
+	}
+}
+
+// WithSpeechHandler registers a callback that is invoked when the service
+// detects the start or end of speech. It is called from the session's read
+// goroutine and must not block.
+func WithSpeechHandler(fn func(SpeechEvent)) Option {
+	return func(p *Provider) {
+		p.onSpeech = fn
+	}
+}
+
+// WithSampleRate sets the default audio sample rate in Hz. A non-zero
+// StreamConfig.SampleRate takes precedence.
+func WithSampleRate(rate int) Option {
+	return func(p *Provider) {
+		p.sampleRate = rate
+	}
+}
+
+// WithEndpoint overrides the streaming endpoint URL derived from the region,
+// e.g. for sovereign clouds, private endpoints, or a local mock server in
+// tests.
+func WithEndpoint(endpoint string) Option {
+	return func(p *Provider) {
+		p.endpoint = endpoint
+	}
+}
+
+// Provider implements stt.Provider backed by Azure AI Speech.
+type Provider struct {
+	apiKey     string
+	region     string
+	language   string
+	model      string
+	sampleRate int
+	endpoint   string
+	onSpeech   func(SpeechEvent)
+}
+
+// New creates a new Azure Provider. apiKey must be non-empty, and either a
+// region ([WithRegion]) or an endpoint ([WithEndpoint]) must be set.
+func New(apiKey string, opts ...Option) (*Provider, error) {
+	if apiKey == "" {
+		return nil, errors.New("azure: apiKey must not be empty")
+	}
+	p := &Provider{
+		apiKey:     apiKey,
+		language:   defaultLanguage,
+		sampleRate: defaultSampleRate,
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	if p.endpoint == "" {
+		if p.region == "" {
+			return nil, errors.New("azure: region or endpoint must be set")
+		}
+		p.endpoint = fmt.Sprintf(endpointFormat, p.region)
+	}
+	return p, nil
+}
+
+// StartStream opens a streaming transcription session with Azure AI Speech.
+// It respects cfg.SampleRate, cfg.Channels, cfg.Language, and cfg.Keywords;
+// keywords are sent as a phrase list (boost values are ignored).
+func (p *Provider) StartStream(ctx context.Context, cfg stt.StreamConfig) (stt.SessionHandle, error) {
+	wsURL, err := p.buildURL(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("azure: build URL: %w", err)
+	}
+
+	connID := newID()
+	headers := ident.Header(ctx, nil)
+	headers.Set("Ocp-Apim-Subscription-Key", p.apiKey)
+	headers.Set("X-ConnectionId", connID)
+
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{HTTPHeader: headers})
+	if err != nil {
+		return nil, fmt.Errorf("azure: dial: %w", err)
+	}
+	conn.SetReadLimit(1 << 20)
+
+	sess := &session{
+		conn:      conn,
+		requestID: newID(),
+		partials:  make(chan stt.Transcript, 64),
+		finals:    make(chan stt.Transcript, 64),
+		audio:     make(chan []byte, 256),
+		done:      make(chan struct{}),
+		abort:     make(chan struct{}),
+		onSpeech:  p.onSpeech,
+	}
+
+	sr := cfg.SampleRate
+	if sr == 0 {
+		sr = p.sampleRate
+	}
+	channels := max(cfg.Channels, 1)
+	if err := sess.start(ctx, cfg.Keywords, sr, channels); err != nil {
+		conn.CloseNow()
+		return nil, err
+	}
+
+	sess.wg.Add(2)
+	go sess.readLoop(ctx)
+	go sess.writeLoop(ctx)
+
+	return sess, nil
+}
+
+// buildURL constructs the streaming endpoint URL for the given config.
+func (p *Provider) buildURL(cfg stt.StreamConfig) (string, error) {
+	u, err := url.Parse(p.endpoint)
+	if err != nil {
+		return "", err
+	}
+	lang := cfg.Language
+	if lang == "" {
+		lang = p.language
+	}
+
+	q := u.Query()
+	q.Set("language", lang)
+	q.Set("format", "detailed")
+	q.Set("wordLevelTimestamps", "true")
+	if p.model != "" {
+		q.Set("cid", p.model)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// ---- protocol ----
+
+// speechConfig is the body of the speech.config message.
+type speechConfig struct {
+	Context struct {
+		System struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"system"`
+		Audio struct {
+			Source struct {
+				Type string `json:"type"`
+			} `json:"source"`
+		} `json:"audio"`
+	} `json:"context"`
+}
+
+// speechContext is the body of the speech.context message carrying the
+// phrase list.
+type speechContext struct {
+	DGI struct {
+		Groups []phraseGroup `json:"Groups"`
+	} `json:"dgi"`
+}
+
+type phraseGroup struct {
+	Type  string       `json:"Type"`
+	Items []phraseItem `json:"Items"`
+}
+
+type phraseItem struct {
+	Text string `json:"Text"`
+}
+
+// detectedMessage is the body of a speech.startDetected or
+// speech.endDetected message.
+type detectedMessage struct {
+	Offset int64 `json:"Offset"`
+}
+
+// hypothesisMessage is the body of a speech.hypothesis message.
+type hypothesisMessage struct {
+	Text     string `json:"Text"`
+	Offset   int64  `json:"Offset"`
+	Duration int64  `json:"Duration"`
+}
+
+// phraseMessage is the body of a speech.phrase message in detailed format.
+type phraseMessage struct {
+	RecognitionStatus string `json:"RecognitionStatus"`
+	DisplayText       string `json:"DisplayText"`
+	Offset            int64  `json:"Offset"`
+	Duration          int64  `json:"Duration"`
+	NBest             []struct {
+		Confidence float64 `json:"Confidence"`
+		Display    string  `json:"Display"`
+		Words      []struct {
+			Word       string  `json:"Word"`
+			Offset     int64   `json:"Offset"`
+			Duration   int64   `json:"Duration"`
+			Confidence float64 `json:"Confidence"`
+		} `json:"Words"`
+	} `json:"NBest"`
+}
+
+// newID returns a random 32-character hex ID as used for X-RequestId and
+// X-ConnectionId.
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// timestamp returns the current time in the X-Timestamp format.
+func timestamp() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// textMessage frames a JSON body as a protocol text message.
+func textMessage(path, requestID string, body []byte) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Path: %s\r\nX-RequestId: %s\r\nX-Timestamp: %s\r\nContent-Type: application/json\r\n\r\n",
+		path, requestID, timestamp())
+	b.Write(body)
+	return b.Bytes()
+}
+
+// audioMessage frames data as a protocol binary audio message. An empty data
+// slice marks the end of the audio stream.
+func audioMessage(requestID string, data []byte) []byte {
+	header := fmt.Sprintf("Path: audio\r\nX-RequestId: %s\r\nX-Timestamp: %s\r\nContent-Type: audio/x-wav\r\n",
+		requestID, timestamp())
+	msg := make([]byte, 2, 2+len(header)+len(data))
+	binary.BigEndian.PutUint16(msg, uint16(len(header)))
+	msg = append(msg, header...)
+	return append(msg, data...)
+}
+
+// wavHeader returns a streaming RIFF/WAVE header for 16-bit PCM. The size
+// fields are zero because the stream length is unknown.
+func wavHeader(sampleRate, channels int) []byte {
+	h := make([]byte, 44)
+	copy(h[0:], "RIFF")
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], 1) // integer PCM
+	binary.LittleEndian.PutUint16(h[22:], uint16(channels))
+	binary.LittleEndian.PutUint32(h[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(sampleRate*channels*2))
+	binary.LittleEndian.PutUint16(h[32:], uint16(channels*2))
+	binary.LittleEndian.PutUint16(h[34:], 16)
+	copy(h[36:], "data")
+	return h
+}
+
+// parseMessage splits a protocol text message into its Path header and body.
+func parseMessage(data []byte) (path string, body []byte) {
+	head, body, _ := bytes.Cut(data, []byte("\r\n\r\n"))
+	for line := range strings.SplitSeq(string(head), "\r\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(k), "Path") {
+			return strings.TrimSpace(v), body
+		}
+	}
+	return "", body
+}
+
+// parseSpeechEvent converts a speech.startDetected or speech.endDetected
+// message into a SpeechEvent. Returns (zero, false) for other messages.
+func parseSpeechEvent(data []byte) (SpeechEvent, bool) {
+	path, body := parseMessage(data)
+	ev := SpeechEvent{Type: SpeechStarted}
+	switch path {
+	case "speech.startDetected":
+	case "speech.endDetected":
+		ev.Type = SpeechEnded
+	default:
+		return SpeechEvent{}, false
+	}
+	var d detectedMessage
+	if err := json.Unmarshal(body, &d); err != nil {
+		slog.Debug("azure: failed to parse speech event", "path", path, "err", err)
+		return SpeechEvent{}, false
+	}
+	ev.Offset = time.Duration(d.Offset) * ticksPerDuration
+	return ev, true
+}
+
+// parseTranscript converts a speech.hypothesis or speech.phrase message into
+// a Transcript. Returns (zero, false) for other messages and for phrases that
+// recognised nothing.
+func parseTranscript(data []byte) (stt.Transcript, bool) {
+	path, body := parseMessage(data)
+	switch path {
+	case "speech.hypothesis":
+		var h hypothesisMessage
+		if err := json.Unmarshal(body, &h); err != nil {
+			slog.Debug("azure: failed to parse hypothesis", "err", err)
+			return stt.Transcript{}, false
+		}
+		return stt.Transcript{
+			Text:      h.Text,
+			Timestamp: time.Duration(h.Offset) * ticksPerDuration,
+			Duration:  time.Duration(h.Duration) * ticksPerDuration,
+		}, h.Text != ""
+
+	case "speech.phrase":
+		var ph phraseMessage
+		if err := json.Unmarshal(body, &ph); err != nil {
+			slog.Debug("azure: failed to parse phrase", "err", err)
+			return stt.Transcript{}, false
+		}
+		if ph.RecognitionStatus != "Success" {
+			return stt.Transcript{}, false
+		}
+		t := stt.Transcript{
+			Text:      ph.DisplayText,
+			IsFinal:   true,
+			Timestamp: time.Duration(ph.Offset) * ticksPerDuration,
+			Duration:  time.Duration(ph.Duration) * ticksPerDuration,
+		}
+		if len(ph.NBest) > 0 {
+			best := ph.NBest[0]
+			if best.Display != "" {
+				t.Text = best.Display
+			}
+			t.Confidence = best.Confidence
+			for _, w := range best.Words {
+				start := time.Duration(w.Offset) * ticksPerDuration
+				t.Words = append(t.Words, stt.WordDetail{
+					Word:       w.Word,
+					Start:      start,
+					End:        start + time.Duration(w.Duration)*ticksPerDuration,
+					Confidence: w.Confidence,
+				})
+			}
+		}
+		return t, t.Text != ""
+	}
+	return stt.Transcript{}, false
+}
+
+// ---- session ----
+
+// session is a live Azure streaming session. It implements stt.SessionHandle.
+type session struct {
+	conn      *websocket.Conn
+	requestID string
+
+	partials chan stt.Transcript
+	finals   chan stt.Transcript
+	audio    chan []byte
+
+	onSpeech func(SpeechEvent)
+
+	done  chan struct{} // closed by Close
+	abort chan struct{} // closed when Close gives up waiting for the turn to end
+	once  sync.Once
+	wg    sync.WaitGroup
+}
+
+// start sends the speech.config message, the phrase list (if any), and the
+// WAV header that must precede the PCM.
+func (s *session) start(ctx context.Context, keywords []stt.KeywordBoost, sampleRate, channels int) error {
+	var cfg speechConfig
+	cfg.Context.System.Name = "glyphoxa"
+	cfg.Context.System.Version = "1.0.0"
+	cfg.Context.Audio.Source.Type = "Stream"
+	body, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("azure: marshal speech config: %w", err)
+	}
+	if err := s.conn.Write(ctx, websocket.MessageText, textMessage("speech.config", s.requestID, body)); err != nil {
+		return fmt.Errorf("azure: send speech config: %w", err)
+	}
+
+	if len(keywords) > 0 {
+		var sc speechContext
+		group := phraseGroup{Type: "Generic"}
+		for _, kw := range keywords {
+			group.Items = append(group.Items, phraseItem{Text: kw.Keyword})
+		}
+		sc.DGI.Groups = []phraseGroup{group}
+		body, err := json.Marshal(sc)
+		if err != nil {
+			return fmt.Errorf("azure: marshal speech context: %w", err)
+		}
+		if err := s.conn.Write(ctx, websocket.MessageText, textMessage("speech.context", s.requestID, body)); err != nil {
+			return fmt.Errorf("azure: send speech context: %w", err)
+		}
+	}
+
+	if err := s.conn.Write(ctx, websocket.MessageBinary, audioMessage(s.requestID, wavHeader(sampleRate, channels))); err != nil {
+		return fmt.Errorf("azure: send WAV header: %w", err)
+	}
+	return nil
+}
+
+// SendAudio queues a PCM audio chunk for delivery to the service.
+func (s *session) SendAudio(chunk []byte) error {
+	select {
+	case <-s.done:
+		return errors.New("azure: session is closed")
+	default:
+	}
+	select {
+	case s.audio <- chunk:
+		return nil
+	case <-s.done:
+		return errors.New("azure: session is closed")
+	}
+}
+
+// Partials returns the channel of interim transcripts.
+func (s *session) Partials() <-chan stt.Transcript { return s.partials }
+
+// Finals returns the channel of final transcripts.
+func (s *session) Finals() <-chan stt.Transcript { return s.finals }
+
+// SetKeywords returns an error: the phrase list is fixed when the turn
+// starts, so mid-session updates are not supported.
+func (s *session) SetKeywords(_ []stt.KeywordBoost) error {
+	return fmt.Errorf("azure: %w", errNotSupported)
+}
+
+var errNotSupported = errors.New("mid-session keyword updates are not supported")
+
+// Close sends the end of the audio stream, waits up to closeTimeout for the
+// service to deliver the remaining results and end the turn, and closes the
+// socket.
+func (s *session) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+
+		finished := make(chan struct{})
+		go func() {
+			s.wg.Wait()
+			close(finished)
+		}()
+		select {
+		case <-finished:
+		case <-time.After(closeTimeout):
+			close(s.abort)
+			s.conn.CloseNow()
+			<-finished
+		}
+		s.conn.Close(websocket.StatusNormalClosure, "session closed")
+	})
+	return nil
+}
+
+// writeLoop reads from the audio channel and sends audio messages. Once the
+// session is closed, it flushes the queued audio and sends the end-of-stream
+// marker.
+func (s *session) writeLoop(ctx context.Context) {
+	defer s.wg.Done()
+	for {
+		select {
+		case chunk := <-s.audio:
+			if err := s.conn.Write(ctx, websocket.MessageBinary, audioMessage(s.requestID, chunk)); err != nil {
+				slog.Warn("azure: send audio", "err", err)
+			}
+		case <-s.done:
+			for {
+				select {
+				case chunk := <-s.audio:
+					_ = s.conn.Write(ctx, websocket.MessageBinary, audioMessage(s.requestID, chunk))
+				default:
+					_ = s.conn.Write(ctx, websocket.MessageBinary, audioMessage(s.requestID, nil))
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// readLoop receives service messages and dispatches transcripts to the
+// partials and finals channels and speech events to the handler until the
+// turn ends or the socket fails.
+func (s *session) readLoop(ctx context.Context) {
+	defer s.wg.Done()
+	defer close(s.partials)
+	defer close(s.finals)
+
+	for {
+		typ, msg, err := s.conn.Read(ctx)
+		if err != nil {
+			select {
+			case <-s.done:
+			default:
+				slog.Warn("azure: stream ended", "err", err)
+			}
+			return
+		}
+		if typ != websocket.MessageText {
+			continue
+		}
+		if path, _ := parseMessage(msg); path == "turn.end" {
+			return
+		}
+		if ev, ok := parseSpeechEvent(msg); ok {
+			if s.onSpeech != nil {
+				s.onSpeech(ev)
+			}
+			continue
+		}
+
+		t, ok := parseTranscript(msg)
+		if !ok {
+			continue
+		}
+		ch := s.partials
+		if t.IsFinal {
+			ch = s.finals
+		}
+		select {
+		case ch <- t:
+		case <-s.abort:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
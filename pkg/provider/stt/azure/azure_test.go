@@ -0,0 +1,381 @@
+package azure
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	"github.com/coder/websocket"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(""); err == nil {
+		t.Error("New with empty key: expected error")
+	}
+	if _, err := New("key"); err == nil {
+		t.Error("New without region or endpoint: expected error")
+	}
+	p, err := New("key", WithRegion("westeurope"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if want := "wss://westeurope.stt.speech.microsoft.com/speech/recognition/conversation/cognitiveservices/v1"; p.endpoint != want {
+		t.Errorf("endpoint = %q, want %q", p.endpoint, want)
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		cfg     stt.StreamConfig
+		want    string
+		wantCID string
+	}{
+		{name: "provider default", want: "de-DE"},
+		{name: "stream config wins", cfg: stt.StreamConfig{Language: "fr-FR"}, want: "fr-FR"},
+		{name: "custom model", opts: []Option{WithModel("0f1e2d3c")}, want: "de-DE", wantCID: "0f1e2d3c"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := New("key", append([]Option{WithRegion("eastus"), WithLanguage("de-DE")}, tc.opts...)...)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			raw, err := p.buildURL(tc.cfg)
+			if err != nil {
+				t.Fatalf("buildURL: %v", err)
+			}
+			u, _ := url.Parse(raw)
+			q := u.Query()
+			if q.Get("language") != tc.want {
+				t.Errorf("language = %q, want %q", q.Get("language"), tc.want)
+			}
+			if q.Get("format") != "detailed" {
+				t.Errorf("format = %q, want detailed", q.Get("format"))
+			}
+			if q.Get("cid") != tc.wantCID {
+				t.Errorf("cid = %q, want %q", q.Get("cid"), tc.wantCID)
+			}
+		})
+	}
+}
+
+func TestParseTranscript(t *testing.T) {
+	t.Parallel()
+
+	msg := func(path, body string) []byte {
+		return []byte("X-RequestId:abc\r\nContent-Type:application/json; charset=utf-8\r\nPath:" + path + "\r\n\r\n" + body)
+	}
+
+	tests := []struct {
+		name   string
+		msg    []byte
+		want   stt.Transcript
+		wantOK bool
+	}{
+		{
+			name:   "hypothesis",
+			msg:    msg("speech.hypothesis", `{"Text":"hail","Offset":5000000,"Duration":3000000}`),
+			want:   stt.Transcript{Text: "hail", Timestamp: 500 * time.Millisecond, Duration: 300 * time.Millisecond},
+			wantOK: true,
+		},
+		{
+			name: "phrase",
+			msg: msg("speech.phrase", `{"RecognitionStatus":"Success","Offset":10000000,"Duration":20000000,`+
+				`"DisplayText":"Hail, Eldrinax.","NBest":[{"Confidence":0.93,"Display":"Hail, Eldrinax.",`+
+				`"Words":[{"Word":"hail","Offset":10000000,"Duration":4000000}]}]}`),
+			want: stt.Transcript{
+				Text:       "Hail, Eldrinax.",
+				IsFinal:    true,
+				Confidence: 0.93,
+				Timestamp:  time.Second,
+				Duration:   2 * time.Second,
+				Words:      []stt.WordDetail{{Word: "hail", Start: time.Second, End: 1400 * time.Millisecond}},
+			},
+			wantOK: true,
+		},
+		{
+			name: "no match",
+			msg:  msg("speech.phrase", `{"RecognitionStatus":"NoMatch","Offset":0,"Duration":0}`),
+		},
+		{
+			name: "other message",
+			msg:  msg("speech.startDetected", `{"Offset":0}`),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseTranscript(tc.msg)
+			if ok != tc.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tc.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.Text != tc.want.Text || got.IsFinal != tc.want.IsFinal || got.Confidence != tc.want.Confidence ||
+				got.Timestamp != tc.want.Timestamp || got.Duration != tc.want.Duration {
+				t.Errorf("transcript = %+v, want %+v", got, tc.want)
+			}
+			if len(got.Words) != len(tc.want.Words) {
+				t.Fatalf("words = %+v, want %+v", got.Words, tc.want.Words)
+			}
+			for i := range got.Words {
+				if got.Words[i] != tc.want.Words[i] {
+					t.Errorf("word %d = %+v, want %+v", i, got.Words[i], tc.want.Words[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSpeechEvent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		msg    []byte
+		want   SpeechEvent
+		wantOK bool
+	}{
+		{
+			name:   "start detected",
+			msg:    serverMessage("speech.startDetected", `{"Offset":5000000}`),
+			want:   SpeechEvent{Type: SpeechStarted, Offset: 500 * time.Millisecond},
+			wantOK: true,
+		},
+		{
+			name:   "end detected",
+			msg:    serverMessage("speech.endDetected", `{"Offset":32000000}`),
+			want:   SpeechEvent{Type: SpeechEnded, Offset: 3200 * time.Millisecond},
+			wantOK: true,
+		},
+		{
+			name: "malformed body",
+			msg:  serverMessage("speech.endDetected", `{"Offset":`),
+		},
+		{
+			name: "other message",
+			msg:  serverMessage("speech.hypothesis", `{"Text":"hail","Offset":0}`),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseSpeechEvent(tc.msg)
+			if ok != tc.wantOK || got != tc.want {
+				t.Errorf("parseSpeechEvent() = %+v, %v, want %+v, %v", got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestSpeechEventType_String(t *testing.T) {
+	t.Parallel()
+
+	for typ, want := range map[SpeechEventType]string{
+		SpeechStarted:      "speech_started",
+		SpeechEnded:        "speech_ended",
+		SpeechEventType(7): "SpeechEventType(7)",
+	} {
+		if got := typ.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}
+
+// splitAudio splits a binary audio message into its headers and payload.
+func splitAudio(t *testing.T, msg []byte) (string, []byte) {
+	t.Helper()
+	if len(msg) < 2 {
+		t.Fatalf("audio message too short: %d bytes", len(msg))
+	}
+	n := int(binary.BigEndian.Uint16(msg))
+	return string(msg[2 : 2+n]), msg[2+n:]
+}
+
+// serverMessage frames a service text message.
+func serverMessage(path, body string) []byte {
+	return []byte("X-RequestId:abc\r\nContent-Type:application/json; charset=utf-8\r\nPath:" + path + "\r\n\r\n" + body)
+}
+
+func TestStartStream_MockServer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		received []string // message kinds in arrival order
+		audio    []byte
+		keywords []string
+		ended    = make(chan struct{})
+	)
+	record := func(kind string) {
+		mu.Lock()
+		received = append(received, kind)
+		mu.Unlock()
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Ocp-Apim-Subscription-Key") != "test-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-ConnectionId") == "" || r.URL.Query().Get("language") != "en-GB" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		ctx := r.Context()
+
+		for {
+			typ, msg, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			if typ == websocket.MessageText {
+				path, body := parseMessage(msg)
+				record(path)
+				if path == "speech.context" {
+					var sc speechContext
+					_ = json.Unmarshal(body, &sc)
+					for _, g := range sc.DGI.Groups {
+						for _, it := range g.Items {
+							keywords = append(keywords, it.Text)
+						}
+					}
+				}
+				continue
+			}
+
+			headers, payload := splitAudio(t, msg)
+			if !strings.Contains(headers, "Path: audio") {
+				record("bad-audio-header")
+				continue
+			}
+			switch {
+			case strings.HasPrefix(string(payload), "RIFF"):
+				record("wav-header")
+			case len(payload) == 0:
+				record("end-of-audio")
+				_ = conn.Write(ctx, websocket.MessageText, serverMessage("speech.phrase",
+					`{"RecognitionStatus":"Success","DisplayText":"Goodbye.","Offset":20000000,"Duration":5000000}`))
+				_ = conn.Write(ctx, websocket.MessageText, serverMessage("turn.end", "{}"))
+				close(ended)
+				return
+			default:
+				record("audio")
+				audio = append(audio, payload...)
+				_ = conn.Write(ctx, websocket.MessageText, serverMessage("speech.startDetected", `{"Offset":0}`))
+				_ = conn.Write(ctx, websocket.MessageText, serverMessage("speech.hypothesis",
+					`{"Text":"hail","Offset":0,"Duration":2000000}`))
+				_ = conn.Write(ctx, websocket.MessageText, serverMessage("speech.phrase",
+					`{"RecognitionStatus":"Success","Offset":0,"Duration":4000000,"NBest":[{"Confidence":0.9,"Display":"Hail, Eldrinax."}]}`))
+				_ = conn.Write(ctx, websocket.MessageText, serverMessage("speech.endDetected", `{"Offset":4000000}`))
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	var events []SpeechEvent
+	p, err := New("test-key",
+		WithEndpoint("ws"+strings.TrimPrefix(srv.URL, "http")),
+		WithSpeechHandler(func(ev SpeechEvent) {
+			mu.Lock()
+			events = append(events, ev)
+			mu.Unlock()
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sess, err := p.StartStream(ctx, stt.StreamConfig{
+		SampleRate: 16000,
+		Channels:   1,
+		Language:   "en-GB",
+		Keywords:   []stt.KeywordBoost{{Keyword: "Eldrinax", Boost: 5}},
+	})
+	if err != nil {
+		t.Fatalf("StartStream: %v", err)
+	}
+
+	if err := sess.SendAudio([]byte{1, 2, 3, 4}); err != nil {
+		t.Fatalf("SendAudio: %v", err)
+	}
+
+	select {
+	case tr := <-sess.Partials():
+		if tr.Text != "hail" || tr.IsFinal {
+			t.Errorf("partial = %+v", tr)
+		}
+	case <-ctx.Done():
+		t.Fatal("no partial transcript")
+	}
+	select {
+	case tr := <-sess.Finals():
+		if tr.Text != "Hail, Eldrinax." || !tr.IsFinal || tr.Confidence != 0.9 {
+			t.Errorf("final = %+v", tr)
+		}
+	case <-ctx.Done():
+		t.Fatal("no final transcript")
+	}
+
+	if err := sess.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	<-ended
+
+	// The result delivered while closing is still on the channel.
+	var last []string
+	for tr := range sess.Finals() {
+		last = append(last, tr.Text)
+	}
+	if fmt.Sprint(last) != "[Goodbye.]" {
+		t.Errorf("finals after Close = %q, want [Goodbye.]", last)
+	}
+	if _, ok := <-sess.Partials(); ok {
+		t.Error("Partials should be closed after Close")
+	}
+	if err := sess.SendAudio([]byte{0}); err == nil {
+		t.Error("SendAudio after Close: expected error")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := "[speech.config speech.context wav-header audio end-of-audio]"
+	if got := fmt.Sprint(received); got != want {
+		t.Errorf("server received %s, want %s", got, want)
+	}
+	if fmt.Sprint(keywords) != "[Eldrinax]" {
+		t.Errorf("phrase list = %q, want [Eldrinax]", keywords)
+	}
+	if fmt.Sprint(audio) != "[1 2 3 4]" {
+		t.Errorf("audio payload = %v, want [1 2 3 4]", audio)
+	}
+	wantEvents := []SpeechEvent{{Type: SpeechStarted}, {Type: SpeechEnded, Offset: 400 * time.Millisecond}}
+	if !slices.Equal(events, wantEvents) {
+		t.Errorf("speech events = %+v, want %+v", events, wantEvents)
+	}
+}
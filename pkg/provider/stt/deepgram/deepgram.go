@@ -1,5 +1,11 @@
 // Package deepgram provides a Deepgram-backed STT provider using the Deepgram
 // streaming WebSocket API. It implements the stt.Provider interface.
+//
+// If the WebSocket drops mid-session, the session transparently redials with
+// exponential backoff. Audio sent while disconnected is held in a small
+// bounded buffer and flushed once the new socket is up; transcripts continue on
+// the same Partials/Finals channels. Register a handler with
+// [WithReconnectHandler] to observe reconnect attempts.
 package deepgram
 
 import (
@@ -8,12 +14,12 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"net/url"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
 	"github.com/coder/websocket"
 )
@@ -23,8 +29,60 @@ const (
 	defaultModel      = "nova-3"
 	defaultLanguage   = "en"
 	defaultSampleRate = 16000
+
+	defaultMaxReconnectAttempts = 5
+	defaultReconnectInitial     = 250 * time.Millisecond
+	defaultReconnectMax         = 5 * time.Second
+
+	// defaultReconnectBuffer is the audio held while reconnecting: 64 KiB is
+	// roughly two seconds of 16 kHz mono 16-bit PCM.
+	defaultReconnectBuffer = 64 * 1024
+)
+
+// ReconnectState describes a step of the session's reconnect cycle.
+type ReconnectState int
+
+const (
+	// Reconnecting is emitted before each redial attempt.
+	Reconnecting ReconnectState = iota
+
+	// Reconnected is emitted once a new socket is established. Buffered audio
+	// is flushed and transcription resumes.
+	Reconnected
+
+	// ReconnectFailed is emitted when all attempts are exhausted. The session's
+	// Partials and Finals channels are closed afterwards.
+	ReconnectFailed
 )
 
+// String returns a human-readable name for the state.
+func (s ReconnectState) String() string {
+	switch s {
+	case Reconnecting:
+		return "reconnecting"
+	case Reconnected:
+		return "reconnected"
+	case ReconnectFailed:
+		return "reconnect_failed"
+	default:
+		return fmt.Sprintf("ReconnectState(%d)", int(s))
+	}
+}
+
+// ReconnectEvent is delivered to the handler registered via
+// [WithReconnectHandler].
+type ReconnectEvent struct {
+	// State is the reconnect step being reported.
+	State ReconnectState
+
+	// Attempt is the 1-based redial attempt number.
+	Attempt int
+
+	// Err is the error that dropped the socket (for [Reconnecting]) or the
+	// last dial error (for [ReconnectFailed]). Nil for [Reconnected].
+	Err error
+}
+
 // Option is a functional option for configuring the Deepgram Provider.
 type Option func(*Provider)
 
@@ -49,12 +107,72 @@ func WithSampleRate(rate int) Option {
 	}
 }
 
+// WithEndpoint overrides the Deepgram streaming endpoint URL. Primarily used
+// in tests to point at a local mock server.
+func WithEndpoint(endpoint string) Option {
+	return func(p *Provider) {
+		p.endpoint = endpoint
+	}
+}
+
+// WithMaxReconnectAttempts sets how many times a session redials after its
+// socket drops. Zero disables reconnecting: a dropped socket ends the session.
+// Negative values are ignored.
+func WithMaxReconnectAttempts(n int) Option {
+	return func(p *Provider) {
+		if n >= 0 {
+			p.maxReconnects = n
+		}
+	}
+}
+
+// WithReconnectBackoff sets the delay before the first redial attempt and
+// the cap for the exponentially growing delay between attempts. Non-positive
+// values are ignored.
+func WithReconnectBackoff(initial, maxDelay time.Duration) Option {
+	return func(p *Provider) {
+		if initial > 0 {
+			p.backoffInitial = initial
+		}
+		if maxDelay > 0 {
+			p.backoffMax = maxDelay
+		}
+	}
+}
+
+// WithReconnectBuffer sets the maximum number of audio bytes held while the
+// session is reconnecting. When full, the oldest chunks are dropped; the most
+// recent chunk is always kept. Negative values are ignored.
+func WithReconnectBuffer(bytes int) Option {
+	return func(p *Provider) {
+		if bytes >= 0 {
+			p.bufferBytes = bytes
+		}
+	}
+}
+
+// WithReconnectHandler registers a callback that is invoked on every
+// reconnect step. It is called from the session's read goroutine and must not
+// block.
+func WithReconnectHandler(fn func(ReconnectEvent)) Option {
+	return func(p *Provider) {
+		p.onReconnect = fn
+	}
+}
+
 // Provider implements stt.Provider backed by the Deepgram streaming API.
 type Provider struct {
 	apiKey     string
 	model      string
 	language   string
 	sampleRate int
+	endpoint   string
+
+	maxReconnects  int
+	backoffInitial time.Duration
+	backoffMax     time.Duration
+	bufferBytes    int
+	onReconnect    func(ReconnectEvent)
 }
 
 // New creates a new Deepgram Provider. apiKey must be non-empty.
@@ -67,6 +185,12 @@ func New(apiKey string, opts ...Option) (*Provider, error) {
 		model:      defaultModel,
 		language:   defaultLanguage,
 		sampleRate: defaultSampleRate,
+		endpoint:   deepgramEndpoint,
+
+		maxReconnects:  defaultMaxReconnectAttempts,
+		backoffInitial: defaultReconnectInitial,
+		backoffMax:     defaultReconnectMax,
+		bufferBytes:    defaultReconnectBuffer,
 	}
 	for _, o := range opts {
 		o(p)
@@ -83,22 +207,34 @@ func (p *Provider) StartStream(ctx context.Context, cfg stt.StreamConfig) (stt.S
 		return nil, fmt.Errorf("deepgram: build URL: %w", err)
 	}
 
-	headers := http.Header{}
+	headers := ident.Header(ctx, nil)
 	headers.Set("Authorization", "Token "+p.apiKey)
 
-	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
-		HTTPHeader: headers,
-	})
+	dial := func(ctx context.Context) (*websocket.Conn, error) {
+		conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+			HTTPHeader: headers,
+		})
+		return conn, err
+	}
+
+	conn, err := dial(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("deepgram: dial: %w", err)
 	}
 
 	sess := &session{
-		conn:     conn,
-		partials: make(chan stt.Transcript, 64),
-		finals:   make(chan stt.Transcript, 64),
-		audio:    make(chan []byte, 256),
-		done:     make(chan struct{}),
+		conn:           conn,
+		dial:           dial,
+		maxReconnects:  p.maxReconnects,
+		backoffInitial: p.backoffInitial,
+		backoffMax:     p.backoffMax,
+		bufferBytes:    p.bufferBytes,
+		onReconnect:    p.onReconnect,
+		partials:       make(chan stt.Transcript, 64),
+		finals:         make(chan stt.Transcript, 64),
+		audio:          make(chan []byte, 256),
+		flush:          make(chan struct{}, 1),
+		done:           make(chan struct{}),
 	}
 
 	sess.wg.Add(2)
@@ -110,7 +246,7 @@ func (p *Provider) StartStream(ctx context.Context, cfg stt.StreamConfig) (stt.S
 
 // buildURL constructs the Deepgram streaming endpoint URL for the given config.
 func (p *Provider) buildURL(cfg stt.StreamConfig) (string, error) {
-	u, err := url.Parse(deepgramEndpoint)
+	u, err := url.Parse(p.endpoint)
 	if err != nil {
 		return "", err
 	}
@@ -160,21 +296,38 @@ type deepgramResponse struct {
 				End        float64 `json:"end"`
 				Confidence float64 `json:"confidence"`
 			} `json:"words"`
+			// Languages lists the detected languages, dominant first, when
+			// the session uses multilingual recognition (language=multi).
+			Languages []string `json:"languages"`
 		} `json:"alternatives"`
 	} `json:"channel"`
 }
 
 // session is a live Deepgram streaming session. It implements stt.SessionHandle.
 type session struct {
-	conn     *websocket.Conn
+	dial           func(ctx context.Context) (*websocket.Conn, error)
+	maxReconnects  int
+	backoffInitial time.Duration
+	backoffMax     time.Duration
+	bufferBytes    int
+	onReconnect    func(ReconnectEvent)
+
 	partials chan stt.Transcript
 	finals   chan stt.Transcript
 	audio    chan []byte
+	flush    chan struct{} // signalled after a reconnect to flush pending audio
 
 	done chan struct{}
 	once sync.Once
 	wg   sync.WaitGroup
 
+	// mu guards the connection and the audio held while reconnecting.
+	mu           sync.Mutex
+	conn         *websocket.Conn
+	reconnecting bool
+	pending      [][]byte
+	pendingBytes int
+
 	kwMu     sync.RWMutex
 	keywords []stt.KeywordBoost // stored for reference; Deepgram doesn't support mid-stream updates
 }
@@ -215,15 +368,25 @@ var errNotSupported = errors.New("mid-session keyword updates are not supported"
 func (s *session) Close() error {
 	s.once.Do(func() {
 		close(s.done)
+		conn := s.currentConn()
 		// Send a close message to Deepgram to flush pending audio.
-		_ = s.conn.Write(context.Background(), websocket.MessageText, []byte(`{"type":"CloseStream"}`))
+		_ = conn.Write(context.Background(), websocket.MessageText, []byte(`{"type":"CloseStream"}`))
 		s.wg.Wait()
-		s.conn.Close(websocket.StatusNormalClosure, "session closed")
+		s.currentConn().Close(websocket.StatusNormalClosure, "session closed")
 	})
 	return nil
 }
 
+// currentConn returns the active WebSocket connection.
+func (s *session) currentConn() *websocket.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn
+}
+
 // writeLoop reads from the audio channel and sends binary messages to Deepgram.
+// While the session is reconnecting, chunks are held in the pending buffer and
+// flushed in order once the new socket is up.
 func (s *session) writeLoop(ctx context.Context) {
 	defer s.wg.Done()
 	for {
@@ -232,9 +395,9 @@ func (s *session) writeLoop(ctx context.Context) {
 			if !ok {
 				return
 			}
-			if err := s.conn.Write(ctx, websocket.MessageBinary, chunk); err != nil {
-				return
-			}
+			s.send(ctx, chunk)
+		case <-s.flush:
+			s.send(ctx, nil)
 		case <-s.done:
 			// Drain the audio channel before exiting.
 			for {
@@ -243,7 +406,7 @@ func (s *session) writeLoop(ctx context.Context) {
 					if !ok {
 						return
 					}
-					_ = s.conn.Write(ctx, websocket.MessageBinary, chunk)
+					_ = s.currentConn().Write(ctx, websocket.MessageBinary, chunk)
 				default:
 					return
 				}
@@ -252,18 +415,70 @@ func (s *session) writeLoop(ctx context.Context) {
 	}
 }
 
+// send writes any pending audio followed by chunk (if non-nil). On a write
+// failure or while reconnecting, the unsent audio is kept in the pending
+// buffer; the read loop detects the broken socket and reconnects.
+func (s *session) send(ctx context.Context, chunk []byte) {
+	s.mu.Lock()
+	if chunk != nil {
+		s.bufferLocked(chunk)
+	}
+	if s.reconnecting || len(s.pending) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	conn := s.conn
+	batch := s.pending
+	s.pending, s.pendingBytes = nil, 0
+	s.mu.Unlock()
+
+	for i, c := range batch {
+		if err := conn.Write(ctx, websocket.MessageBinary, c); err != nil {
+			s.mu.Lock()
+			unsent := append(batch[i:], s.pending...)
+			s.pending, s.pendingBytes = nil, 0
+			for _, u := range unsent {
+				s.bufferLocked(u)
+			}
+			s.mu.Unlock()
+			return
+		}
+	}
+}
+
+// bufferLocked appends chunk to the pending buffer, evicting the oldest chunks
+// while the buffer exceeds bufferBytes. The newest chunk is always kept. The
+// caller must hold s.mu.
+func (s *session) bufferLocked(chunk []byte) {
+	s.pending = append(s.pending, chunk)
+	s.pendingBytes += len(chunk)
+	for len(s.pending) > 1 && s.pendingBytes > s.bufferBytes {
+		s.pendingBytes -= len(s.pending[0])
+		s.pending = s.pending[1:]
+	}
+}
+
 // readLoop receives JSON messages from Deepgram and dispatches them to the
-// partials and finals channels.
+// partials and finals channels. When the socket drops unexpectedly it
+// reconnects; the channels are closed only once the session ends for good.
 func (s *session) readLoop(ctx context.Context) {
 	defer s.wg.Done()
 	defer close(s.partials)
 	defer close(s.finals)
 
 	for {
-		_, msg, err := s.conn.Read(ctx)
+		_, msg, err := s.currentConn().Read(ctx)
 		if err != nil {
-			// Normal close or context cancellation — exit gracefully.
-			return
+			select {
+			case <-s.done:
+				// Normal close — exit gracefully.
+				return
+			default:
+			}
+			if ctx.Err() != nil || !s.reconnect(ctx, err) {
+				return
+			}
+			continue
 		}
 
 		t, ok := parseDeepgramResponse(msg)
@@ -285,6 +500,68 @@ func (s *session) readLoop(ctx context.Context) {
 	}
 }
 
+// reconnect redials Deepgram with exponential backoff after the socket failed
+// with cause. It reports whether a new connection was established.
+func (s *session) reconnect(ctx context.Context, cause error) bool {
+	if s.maxReconnects == 0 {
+		slog.Warn("deepgram: stream dropped", "err", cause)
+		return false
+	}
+
+	s.mu.Lock()
+	s.reconnecting = true
+	old := s.conn
+	s.mu.Unlock()
+	_ = old.CloseNow()
+
+	delay := s.backoffInitial
+	for attempt := 1; attempt <= s.maxReconnects; attempt++ {
+		slog.Warn("deepgram: stream dropped, reconnecting", "attempt", attempt, "delay", delay, "err", cause)
+		s.emit(ReconnectEvent{State: Reconnecting, Attempt: attempt, Err: cause})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-s.done:
+			timer.Stop()
+			return false
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		}
+
+		conn, err := s.dial(ctx)
+		if err == nil {
+			s.mu.Lock()
+			s.conn = conn
+			s.reconnecting = false
+			s.mu.Unlock()
+
+			slog.Info("deepgram: stream reconnected", "attempt", attempt)
+			s.emit(ReconnectEvent{State: Reconnected, Attempt: attempt})
+			select {
+			case s.flush <- struct{}{}:
+			default:
+			}
+			return true
+		}
+
+		cause = err
+		delay = min(delay*2, s.backoffMax)
+	}
+
+	slog.Error("deepgram: giving up reconnecting", "attempts", s.maxReconnects, "err", cause)
+	s.emit(ReconnectEvent{State: ReconnectFailed, Attempt: s.maxReconnects, Err: cause})
+	return false
+}
+
+// emit invokes the reconnect handler, if any.
+func (s *session) emit(ev ReconnectEvent) {
+	if s.onReconnect != nil {
+		s.onReconnect(ev)
+	}
+}
+
 // parseDeepgramResponse parses a raw Deepgram WebSocket message into a Transcript.
 // Returns (Transcript, true) on success, or (zero, false) if the message should be ignored.
 func parseDeepgramResponse(data []byte) (stt.Transcript, bool) {
@@ -311,10 +588,16 @@ func parseDeepgramResponse(data []byte) (stt.Transcript, bool) {
 		})
 	}
 
+	var language string
+	if len(alt.Languages) > 0 {
+		language = alt.Languages[0]
+	}
+
 	return stt.Transcript{
 		Text:       alt.Transcript,
 		IsFinal:    resp.IsFinal,
 		Confidence: alt.Confidence,
 		Words:      words,
+		Language:   language,
 	}, true
 }
@@ -1,11 +1,18 @@
 package deepgram
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	"github.com/coder/websocket"
 )
 
 // ---- URL / query-param tests ----
@@ -193,6 +200,27 @@ func TestParseDeepgramResponse_Partial(t *testing.T) {
 	assertEqual(t, "text", "Hello", tr.Text)
 }
 
+func TestParseDeepgramResponse_DetectedLanguage(t *testing.T) {
+	raw := []byte(`{
+		"type": "Results",
+		"is_final": true,
+		"channel": {
+			"alternatives": [{
+				"transcript": "Guten Morgen, innkeeper",
+				"confidence": 0.9,
+				"words": [],
+				"languages": ["de", "en"]
+			}]
+		}
+	}`)
+
+	tr, ok := parseDeepgramResponse(raw)
+	if !ok {
+		t.Fatal("expected ok=true")
+	}
+	assertEqual(t, "language", "de", tr.Language)
+}
+
 func TestParseDeepgramResponse_NonResultsType(t *testing.T) {
 	raw := []byte(`{"type":"Metadata","request_id":"abc"}`)
 	_, ok := parseDeepgramResponse(raw)
@@ -245,3 +273,254 @@ func assertEqual(t *testing.T, label, want, got string) {
 		t.Errorf("%s: want %q, got %q", label, want, got)
 	}
 }
+
+// ---- reconnect tests ----
+
+// finalResult is a minimal Deepgram "Results" message with is_final=true.
+func finalResult(text string) []byte {
+	return []byte(`{"type":"Results","is_final":true,"channel":{"alternatives":[{"transcript":"` + text + `","confidence":0.9}]}}`)
+}
+
+// startMockServer serves handler on ln and returns the running server.
+func startMockServer(ln net.Listener, handler func(conn *websocket.Conn)) *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		handler(conn)
+	}))
+	srv.Listener = ln
+	srv.Start()
+	return srv
+}
+
+// readBinary reads the next binary message from conn, skipping text frames.
+func readBinary(ctx context.Context, conn *websocket.Conn) (string, error) {
+	for {
+		typ, data, err := conn.Read(ctx)
+		if err != nil {
+			return "", err
+		}
+		if typ == websocket.MessageBinary {
+			return string(data), nil
+		}
+	}
+}
+
+func expectFinal(t *testing.T, sess stt.SessionHandle, want string) {
+	t.Helper()
+	select {
+	case got, ok := <-sess.Finals():
+		if !ok {
+			t.Fatalf("Finals closed while waiting for %q", want)
+		}
+		if got.Text != want {
+			t.Fatalf("final = %q, want %q", got.Text, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for final %q", want)
+	}
+}
+
+func TestStream_ReconnectsAfterServerRestart(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+
+	// First server: answers one chunk, then drops the socket when killed.
+	kill := make(chan struct{})
+	srv1 := startMockServer(ln, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		if _, err := readBinary(ctx, conn); err != nil {
+			return
+		}
+		_ = conn.Write(ctx, websocket.MessageText, finalResult("before drop"))
+		<-kill
+		_ = conn.CloseNow()
+	})
+
+	var (
+		mu     sync.Mutex
+		events []ReconnectEvent
+	)
+	reconnecting := make(chan struct{}, 1)
+	p, err := New("key",
+		WithEndpoint("ws://"+addr),
+		WithReconnectBackoff(20*time.Millisecond, 50*time.Millisecond),
+		WithMaxReconnectAttempts(100),
+		WithReconnectHandler(func(ev ReconnectEvent) {
+			mu.Lock()
+			events = append(events, ev)
+			mu.Unlock()
+			if ev.State == Reconnecting {
+				select {
+				case reconnecting <- struct{}{}:
+				default:
+				}
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	sess, err := p.StartStream(context.Background(), stt.StreamConfig{SampleRate: 16000, Channels: 1})
+	if err != nil {
+		t.Fatalf("StartStream: %v", err)
+	}
+	defer sess.Close()
+
+	if err := sess.SendAudio([]byte("chunk-1")); err != nil {
+		t.Fatalf("SendAudio: %v", err)
+	}
+	expectFinal(t, sess, "before drop")
+
+	// Kill the server entirely.
+	close(kill)
+	srv1.Close()
+
+	select {
+	case <-reconnecting:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reconnecting signal after server went away")
+	}
+
+	// Audio sent during the gap must be buffered and delivered after reconnect.
+	if err := sess.SendAudio([]byte("gap-audio")); err != nil {
+		t.Fatalf("SendAudio during gap: %v", err)
+	}
+
+	// Restart the server on the same address.
+	ln2, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("re-listen on %s: %v", addr, err)
+	}
+	gotAudio := make(chan string, 1)
+	srv2 := startMockServer(ln2, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		chunk, err := readBinary(ctx, conn)
+		if err != nil {
+			return
+		}
+		gotAudio <- chunk
+		_ = conn.Write(ctx, websocket.MessageText, finalResult("after reconnect"))
+		// Like Deepgram, close the socket once the client sends CloseStream.
+		for {
+			typ, _, err := conn.Read(ctx)
+			if err != nil || typ == websocket.MessageText {
+				_ = conn.Close(websocket.StatusNormalClosure, "")
+				return
+			}
+		}
+	})
+	defer srv2.Close()
+
+	select {
+	case chunk := <-gotAudio:
+		if chunk != "gap-audio" {
+			t.Errorf("first chunk after reconnect = %q, want %q", chunk, "gap-audio")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("buffered audio was not delivered after reconnect")
+	}
+	expectFinal(t, sess, "after reconnect")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if last := events[len(events)-1]; last.State != Reconnected {
+		t.Errorf("last reconnect event = %v, want %v", last.State, Reconnected)
+	}
+}
+
+func TestStream_ReconnectDisabledClosesFinals(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.CloseNow()
+	}))
+	defer srv.Close()
+
+	var fired bool
+	p, err := New("key",
+		WithEndpoint("ws"+srv.URL[len("http"):]),
+		WithMaxReconnectAttempts(0),
+		WithReconnectHandler(func(ReconnectEvent) { fired = true }),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	sess, err := p.StartStream(context.Background(), stt.StreamConfig{})
+	if err != nil {
+		t.Fatalf("StartStream: %v", err)
+	}
+	defer sess.Close()
+
+	select {
+	case _, ok := <-sess.Finals():
+		if ok {
+			t.Fatal("expected Finals to be closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Finals not closed after drop with reconnect disabled")
+	}
+	if fired {
+		t.Error("reconnect handler fired with reconnect disabled")
+	}
+}
+
+func TestStream_IdentHeaders(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.CloseNow()
+	}))
+	defer srv.Close()
+
+	p, err := New("key", WithEndpoint("ws"+srv.URL[len("http"):]), WithMaxReconnectAttempts(0))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := ident.WithSession(context.Background(), "session-tavern")
+	sess, err := p.StartStream(ctx, stt.StreamConfig{})
+	if err != nil {
+		t.Fatalf("StartStream: %v", err)
+	}
+	defer sess.Close()
+
+	h := <-headers
+	if got := h.Get("Authorization"); got != "Token key" {
+		t.Errorf("Authorization = %q, want %q", got, "Token key")
+	}
+	if got := h.Get("User-Agent"); got != ident.UserAgent() {
+		t.Errorf("User-Agent = %q, want %q", got, ident.UserAgent())
+	}
+	if got := h.Get(ident.SessionHeader); got != "session-tavern" {
+		t.Errorf("%s = %q, want %q", ident.SessionHeader, got, "session-tavern")
+	}
+}
+
+func TestReconnectState_String(t *testing.T) {
+	tests := []struct {
+		state ReconnectState
+		want  string
+	}{
+		{Reconnecting, "reconnecting"},
+		{Reconnected, "reconnected"},
+		{ReconnectFailed, "reconnect_failed"},
+		{ReconnectState(42), "ReconnectState(42)"},
+	}
+	for _, tc := range tests {
+		if got := tc.state.String(); got != tc.want {
+			t.Errorf("String() = %q, want %q", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package stt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+)
+
+// fileChunkMillis is the duration in milliseconds of the audio sent per
+// SendAudio call when streaming a file through a session.
+const fileChunkMillis = 100
+
+// FileConfig configures [TranscribeFile].
+type FileConfig struct {
+	// Stream is the session configuration passed to the provider. SampleRate
+	// and Channels select the PCM format the file is converted to; they
+	// default to 16 kHz mono.
+	Stream StreamConfig
+
+	// RawFormat is the format of headerless PCM input. It is consulted when
+	// the file is neither WAV nor MP3, and must be set in that case, or when
+	// it looks like MP3 but fails to decode.
+	RawFormat audio.Format
+}
+
+// TranscribeFile transcribes a complete audio file using a streaming
+// provider. The file may be WAV, MP3, or headerless 16-bit PCM; its format is
+// detected from magic bytes and converted to the session format before being
+// sent.
+//
+// The audio is streamed in 100 ms chunks, the session is closed to flush the
+// provider, and every final transcript is returned in arrival order. Partial
+// transcripts are discarded. The context bounds the whole operation; if it
+// expires before the provider closes its Finals channel, the transcripts
+// received so far are returned together with the context error.
+func TranscribeFile(ctx context.Context, p Provider, data []byte, cfg FileConfig) ([]Transcript, error) {
+	if cfg.Stream.SampleRate == 0 {
+		cfg.Stream.SampleRate = 16000
+	}
+	if cfg.Stream.Channels == 0 {
+		cfg.Stream.Channels = 1
+	}
+
+	target := audio.Format{SampleRate: cfg.Stream.SampleRate, Channels: cfg.Stream.Channels}
+	pcm, err := audio.DecodeFile(data, cfg.RawFormat, target)
+	if err != nil {
+		return nil, fmt.Errorf("stt: transcribe file: %w", err)
+	}
+
+	session, err := p.StartStream(ctx, cfg.Stream)
+	if err != nil {
+		return nil, fmt.Errorf("stt: transcribe file: start stream: %w", err)
+	}
+
+	// Drain partials so providers with bounded channels never block on them.
+	go func() {
+		for range session.Partials() {
+		}
+	}()
+
+	type collected struct {
+		finals []Transcript
+		err    error
+	}
+	done := make(chan collected, 1)
+	go func() {
+		var finals []Transcript
+		for {
+			select {
+			case t, ok := <-session.Finals():
+				if !ok {
+					done <- collected{finals: finals}
+					return
+				}
+				finals = append(finals, t)
+			case <-ctx.Done():
+				done <- collected{finals: finals, err: ctx.Err()}
+				return
+			}
+		}
+	}()
+
+	chunkSize := cfg.Stream.SampleRate * cfg.Stream.Channels * 2 * fileChunkMillis / 1000
+	var sendErr error
+	for len(pcm) > 0 && sendErr == nil && ctx.Err() == nil {
+		n := min(chunkSize, len(pcm))
+		sendErr = session.SendAudio(pcm[:n])
+		pcm = pcm[n:]
+	}
+	closeErr := session.Close()
+
+	res := <-done
+	if err := errors.Join(sendErr, closeErr, res.err); err != nil {
+		return res.finals, fmt.Errorf("stt: transcribe file: %w", err)
+	}
+	return res.finals, nil
+}
@@ -0,0 +1,173 @@
+package stt_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt/mock"
+)
+
+// newSession returns a mock session whose Finals channel already holds finals
+// and is closed, as a provider would after flushing on Close.
+func newSession(finals ...string) *mock.Session {
+	sess := &mock.Session{
+		PartialsCh: make(chan stt.Transcript, 1),
+		FinalsCh:   make(chan stt.Transcript, len(finals)),
+	}
+	sess.PartialsCh <- stt.Transcript{Text: "partial"}
+	close(sess.PartialsCh)
+	for _, f := range finals {
+		sess.FinalsCh <- stt.Transcript{Text: f, IsFinal: true}
+	}
+	close(sess.FinalsCh)
+	return sess
+}
+
+// sentBytes returns the total number of audio bytes sent to sess.
+func sentBytes(sess *mock.Session) int {
+	n := 0
+	for _, c := range sess.SendAudioCalls {
+		n += len(c.Chunk)
+	}
+	return n
+}
+
+func TestTranscribeFile(t *testing.T) {
+	t.Parallel()
+
+	mp3, err := os.ReadFile("../../audio/testdata/speech.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One second of 48 kHz mono silence as headerless PCM.
+	raw := make([]byte, 48000*2)
+	// Half a second of 8 kHz mono silence wrapped in a minimal WAV header.
+	wav := wavFile(make([]byte, 8000), 8000)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		raw       audio.Format
+		wantBytes int
+	}{
+		{name: "wav", data: wav, wantBytes: 16000},
+		{name: "raw pcm", data: raw, raw: audio.Format{SampleRate: 48000, Channels: 1}, wantBytes: 32000},
+		{name: "mp3", data: mp3, wantBytes: 25 * 576 * 16000 / 22050 * 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			sess := newSession("Hello there.", "General Kenobi.")
+			p := &mock.Provider{Session: sess}
+
+			got, err := stt.TranscribeFile(context.Background(), p, tc.data, stt.FileConfig{
+				Stream:    stt.StreamConfig{Language: "en-US"},
+				RawFormat: tc.raw,
+			})
+			if err != nil {
+				t.Fatalf("TranscribeFile: %v", err)
+			}
+
+			if len(got) != 2 || got[0].Text != "Hello there." || got[1].Text != "General Kenobi." {
+				t.Errorf("transcripts = %+v, want both finals in order", got)
+			}
+
+			cfg := p.StartStreamCalls[0].Cfg
+			if cfg.SampleRate != 16000 || cfg.Channels != 1 || cfg.Language != "en-US" {
+				t.Errorf("stream config = %+v, want 16kHz mono en-US", cfg)
+			}
+			if n := sentBytes(sess); n < tc.wantBytes-4 || n > tc.wantBytes+4 {
+				t.Errorf("sent %d bytes, want ~%d (16kHz mono)", n, tc.wantBytes)
+			}
+			for i, c := range sess.SendAudioCalls {
+				if len(c.Chunk) > 3200 {
+					t.Errorf("chunk %d is %d bytes, want <= 100ms (3200 bytes)", i, len(c.Chunk))
+				}
+			}
+			if sess.CloseCallCount != 1 {
+				t.Errorf("Close called %d times, want 1", sess.CloseCallCount)
+			}
+		})
+	}
+}
+
+func TestTranscribeFile_Errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("undecodable input", func(t *testing.T) {
+		t.Parallel()
+		p := &mock.Provider{}
+		if _, err := stt.TranscribeFile(context.Background(), p, []byte{1, 2, 3, 4}, stt.FileConfig{}); err == nil {
+			t.Fatal("expected error for raw PCM without a format")
+		}
+		if len(p.StartStreamCalls) != 0 {
+			t.Error("no session should be opened for undecodable input")
+		}
+	})
+
+	t.Run("start stream fails", func(t *testing.T) {
+		t.Parallel()
+		p := &mock.Provider{StartStreamErr: errors.New("unauthorised")}
+		_, err := stt.TranscribeFile(context.Background(), p, wavFile(make([]byte, 320), 16000), stt.FileConfig{})
+		if err == nil || !errors.Is(err, p.StartStreamErr) {
+			t.Errorf("err = %v, want wrapped StartStreamErr", err)
+		}
+	})
+
+	t.Run("send fails", func(t *testing.T) {
+		t.Parallel()
+		sess := newSession("partial result.")
+		sess.SendAudioErr = errors.New("socket closed")
+		p := &mock.Provider{Session: sess}
+
+		got, err := stt.TranscribeFile(context.Background(), p, wavFile(make([]byte, 16000), 16000), stt.FileConfig{})
+		if !errors.Is(err, sess.SendAudioErr) {
+			t.Errorf("err = %v, want wrapped SendAudioErr", err)
+		}
+		if len(sess.SendAudioCalls) != 1 {
+			t.Errorf("SendAudio called %d times, want to stop after the first failure", len(sess.SendAudioCalls))
+		}
+		if len(got) != 1 || sess.CloseCallCount != 1 {
+			t.Errorf("got %d transcripts and %d Close calls, want finals collected and session closed", len(got), sess.CloseCallCount)
+		}
+	})
+
+	t.Run("context expires", func(t *testing.T) {
+		t.Parallel()
+		// Finals is never closed, so only the context can end the wait.
+		sess := &mock.Session{PartialsCh: make(chan stt.Transcript), FinalsCh: make(chan stt.Transcript)}
+		p := &mock.Provider{Session: sess}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := stt.TranscribeFile(ctx, p, wavFile(make([]byte, 320), 16000), stt.FileConfig{})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	})
+}
+
+// wavFile wraps 16-bit mono pcm in a minimal RIFF/WAVE container.
+func wavFile(pcm []byte, sampleRate int) []byte {
+	le := binary.LittleEndian
+	b := []byte("RIFF")
+	b = le.AppendUint32(b, uint32(36+len(pcm)))
+	b = append(b, "WAVEfmt "...)
+	b = le.AppendUint32(b, 16)
+	b = le.AppendUint16(b, 1) // PCM
+	b = le.AppendUint16(b, 1) // mono
+	b = le.AppendUint32(b, uint32(sampleRate))
+	b = le.AppendUint32(b, uint32(sampleRate*2))
+	b = le.AppendUint16(b, 2)
+	b = le.AppendUint16(b, 16)
+	b = append(b, "data"...)
+	b = le.AppendUint32(b, uint32(len(pcm)))
+	return append(b, pcm...)
+}
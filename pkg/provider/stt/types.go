@@ -22,6 +22,10 @@ type Transcript struct {
 	// SpeakerID identifies the speaker when speaker diarization is active.
 	SpeakerID string
 
+	// Language is the BCP-47 tag of the language detected in the utterance.
+	// Empty when the provider does not detect languages.
+	Language string
+
 	// Timestamp marks when the utterance started, relative to session start.
 	Timestamp time.Duration
 
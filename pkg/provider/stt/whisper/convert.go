@@ -1,6 +1,12 @@
 package whisper
 
-import "encoding/binary"
+import (
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
+)
 
 // pcmToFloat32 converts 16-bit signed little-endian PCM audio to float32
 // samples normalised to the range [-1.0, 1.0]. The input length must be
@@ -35,3 +41,40 @@ func pcmToFloat32Mono(pcm []byte, channels int) []float32 {
 	}
 	return mono
 }
+
+// wordPiece is a text token of a whisper.cpp transcription with its timing
+// and probability.
+type wordPiece struct {
+	text       string
+	start, end time.Duration
+	p          float64
+}
+
+// joinWords merges whisper.cpp tokens into words. whisper.cpp splits words
+// into sub-word tokens: a token starting with a space begins a new word, any
+// other token continues the previous one. A word spans from the start of its
+// first token to the end of its last, and its confidence is the lowest
+// probability among its tokens. Returns nil if pieces holds no text.
+func joinWords(pieces []wordPiece) []stt.WordDetail {
+	var words []stt.WordDetail
+	for _, p := range pieces {
+		text := strings.TrimSpace(p.text)
+		if text == "" {
+			continue
+		}
+		if n := len(words); n > 0 && !strings.HasPrefix(p.text, " ") {
+			w := &words[n-1]
+			w.Word += text
+			w.End = max(w.End, p.end)
+			w.Confidence = min(w.Confidence, p.p)
+			continue
+		}
+		words = append(words, stt.WordDetail{
+			Word:       text,
+			Start:      p.start,
+			End:        p.end,
+			Confidence: p.p,
+		})
+	}
+	return words
+}
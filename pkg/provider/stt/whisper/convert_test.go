@@ -3,7 +3,11 @@ package whisper
 import (
 	"encoding/binary"
 	"math"
+	"slices"
 	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
 )
 
 func TestPcmToFloat32_Empty(t *testing.T) {
@@ -149,3 +153,56 @@ func TestPcmToFloat32Mono_ThreeChannels(t *testing.T) {
 		t.Errorf("mono[0] = %f; want %f", mono[0], want)
 	}
 }
+
+func TestJoinWords(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name   string
+		pieces []wordPiece
+		want   []stt.WordDetail
+	}{
+		{"empty", nil, nil},
+		{
+			name: "one token per word",
+			pieces: []wordPiece{
+				{text: " Hello", start: 0, end: 400 * ms, p: 0.9},
+				{text: " there", start: 400 * ms, end: 700 * ms, p: 0.8},
+			},
+			want: []stt.WordDetail{
+				{Word: "Hello", Start: 0, End: 400 * ms, Confidence: 0.9},
+				{Word: "there", Start: 400 * ms, End: 700 * ms, Confidence: 0.8},
+			},
+		},
+		{
+			name: "sub-word tokens and punctuation",
+			pieces: []wordPiece{
+				{text: " Eld", start: 100 * ms, end: 200 * ms, p: 0.9},
+				{text: "rin", start: 200 * ms, end: 300 * ms, p: 0.5},
+				{text: "ax", start: 300 * ms, end: 450 * ms, p: 0.7},
+				{text: "!", start: 450 * ms, end: 500 * ms, p: 0.95},
+			},
+			want: []stt.WordDetail{
+				{Word: "Eldrinax!", Start: 100 * ms, End: 500 * ms, Confidence: 0.5},
+			},
+		},
+		{
+			name: "first token without leading space",
+			pieces: []wordPiece{
+				{text: "Yes", start: 0, end: 300 * ms, p: 1},
+				{text: " ", start: 300 * ms, end: 300 * ms, p: 1},
+				{text: " no", start: 300 * ms, end: 500 * ms, p: 1},
+			},
+			want: []stt.WordDetail{
+				{Word: "Yes", Start: 0, End: 300 * ms, Confidence: 1},
+				{Word: "no", Start: 300 * ms, End: 500 * ms, Confidence: 1},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := joinWords(tc.pieces); !slices.Equal(got, tc.want) {
+				t.Errorf("joinWords() = %+v; want %+v", got, tc.want)
+			}
+		})
+	}
+}
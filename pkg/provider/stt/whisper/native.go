@@ -97,6 +97,26 @@ func (p *NativeProvider) Close() error {
 	return nil
 }
 
+// Warmup runs one inference over a second of silence and discards the result.
+// whisper.cpp allocates its compute buffers on the first run, which would
+// otherwise delay the first transcript of a session.
+func (p *NativeProvider) Warmup(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("whisper: warm-up: %w", err)
+	}
+	wctx, err := p.model.NewContext()
+	if err != nil {
+		return fmt.Errorf("whisper: create context: %w", err)
+	}
+	if err := wctx.SetLanguage(p.language); err != nil {
+		slog.Warn("whisper: failed to set language, using default", "language", p.language, "error", err)
+	}
+	if err := wctx.Process(make([]float32, whisperlib.SampleRate), nil, nil, nil); err != nil {
+		return fmt.Errorf("whisper: warm-up: %w", err)
+	}
+	return nil
+}
+
 // StartStream opens a new transcription session. The returned SessionHandle is
 // ready to accept audio immediately. It respects cfg.SampleRate, cfg.Channels,
 // and cfg.Language; if those are zero/empty the provider-level defaults apply.
@@ -236,7 +256,7 @@ func (s *nativeSession) processLoop(ctx context.Context) {
 		hadSpeech = false
 		silenceMs = 0
 
-		text, err := s.infer(pcm)
+		text, words, err := s.infer(pcm)
 		if err != nil {
 			slog.Error("whisper native inference failed", "error", err)
 			return
@@ -246,11 +266,11 @@ func (s *nativeSession) processLoop(ctx context.Context) {
 		}
 
 		select {
-		case s.partials <- stt.Transcript{Text: text, IsFinal: false}:
+		case s.partials <- stt.Transcript{Text: text, IsFinal: false, Words: words}:
 		default:
 		}
 		select {
-		case s.finals <- stt.Transcript{Text: text, IsFinal: true}:
+		case s.finals <- stt.Transcript{Text: text, IsFinal: true, Words: words}:
 		default:
 		}
 	}
@@ -295,8 +315,9 @@ func (s *nativeSession) processLoop(ctx context.Context) {
 }
 
 // infer converts the buffered PCM audio to float32, runs whisper.cpp
-// inference using a fresh context, and returns the concatenated text.
-func (s *nativeSession) infer(pcm []byte) (string, error) {
+// inference using a fresh context, and returns the concatenated text and its
+// words, timed relative to the start of pcm.
+func (s *nativeSession) infer(pcm []byte) (string, []stt.WordDetail, error) {
 	// Convert PCM to float32 mono samples.
 	samples := pcmToFloat32Mono(pcm, s.channels)
 
@@ -304,8 +325,9 @@ func (s *nativeSession) infer(pcm []byte) (string, error) {
 	// thread-safe, but the model can be shared across goroutines.
 	wctx, err := s.model.NewContext()
 	if err != nil {
-		return "", fmt.Errorf("whisper: create context: %w", err)
+		return "", nil, fmt.Errorf("whisper: create context: %w", err)
 	}
+	wctx.SetTokenTimestamps(true)
 
 	// Set language.
 	if err := wctx.SetLanguage(s.language); err != nil {
@@ -314,26 +336,35 @@ func (s *nativeSession) infer(pcm []byte) (string, error) {
 
 	// Run inference.
 	if err := wctx.Process(samples, nil, nil, nil); err != nil {
-		return "", fmt.Errorf("whisper: process audio: %w", err)
+		return "", nil, fmt.Errorf("whisper: process audio: %w", err)
 	}
 
 	// Collect segments.
-	var parts []string
+	var (
+		parts  []string
+		pieces []wordPiece
+	)
 	for {
 		segment, err := wctx.NextSegment()
 		if errors.Is(err, io.EOF) {
 			break
 		}
 		if err != nil {
-			return "", fmt.Errorf("whisper: read segment: %w", err)
+			return "", nil, fmt.Errorf("whisper: read segment: %w", err)
 		}
 		text := strings.TrimSpace(segment.Text)
 		if text != "" {
 			parts = append(parts, text)
 		}
+		for _, tok := range segment.Tokens {
+			if !wctx.IsText(tok) {
+				continue
+			}
+			pieces = append(pieces, wordPiece{text: tok.Text, start: tok.Start, end: tok.End, p: float64(tok.P)})
+		}
 	}
 
-	return strings.Join(parts, " "), nil
+	return strings.Join(parts, " "), joinWords(pieces), nil
 }
 
 // Compile-time assertion that nativeSession satisfies stt.SessionHandle.
@@ -11,6 +11,9 @@
 // server. This is still useful for driving UI activity indicators while the
 // primary Finals channel is used for session logging and LLM input.
 //
+// Transcripts carry word timings, relative to the start of the utterance,
+// when the server returns them in its verbose JSON response.
+//
 // Usage:
 //
 //	p, err := whisper.New("http://localhost:8080",
@@ -37,6 +40,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 	"github.com/MrWong99/glyphoxa/pkg/provider/stt"
 )
 
@@ -139,7 +144,7 @@ func New(serverURL string, opts ...Option) (*Provider, error) {
 		sampleRate:          defaultSampleRate,
 		silenceThresholdMs:  defaultSilenceThresholdMs,
 		maxBufferDurationMs: defaultMaxBufferDurationMs,
-		httpClient:          &http.Client{Timeout: 30 * time.Second},
+		httpClient:          &http.Client{Timeout: 30 * time.Second, Transport: ident.Transport(nil)},
 	}
 	for _, o := range opts {
 		o(p)
@@ -309,7 +314,7 @@ func (s *session) processLoop(ctx context.Context) {
 		hadSpeech = false
 		silenceMs = 0
 
-		text, err := s.infer(flushCtx, pcm)
+		text, words, err := s.infer(flushCtx, pcm)
 		if err != nil || text == "" {
 			return
 		}
@@ -317,11 +322,11 @@ func (s *session) processLoop(ctx context.Context) {
 		// Non-blocking sends: channels are buffered (64 elements). If they are
 		// somehow full we skip rather than deadlock during shutdown.
 		select {
-		case s.partials <- stt.Transcript{Text: text, IsFinal: false}:
+		case s.partials <- stt.Transcript{Text: text, IsFinal: false, Words: words}:
 		default:
 		}
 		select {
-		case s.finals <- stt.Transcript{Text: text, IsFinal: true}:
+		case s.finals <- stt.Transcript{Text: text, IsFinal: true, Words: words}:
 		default:
 		}
 	}
@@ -380,12 +385,13 @@ func (s *session) processLoop(ctx context.Context) {
 }
 
 // infer encodes pcm as a WAV file and POSTs it to the whisper.cpp /inference
-// endpoint as multipart/form-data. It returns the transcribed text or an error.
+// endpoint as multipart/form-data. It returns the transcribed text and, if
+// the server reports token timings, its words, or an error.
 //
 // infer reuses s.inferBuf to avoid allocating a new multipart buffer on every
 // flush. This is safe because infer is only called from processLoop.
-func (s *session) infer(ctx context.Context, pcm []byte) (string, error) {
-	wav := encodeWAV(pcm, s.sampleRate, s.channels)
+func (s *session) infer(ctx context.Context, pcm []byte) (string, []stt.WordDetail, error) {
+	wav := audio.EncodeWAV(pcm, s.sampleRate, s.channels)
 
 	s.inferBuf.Reset()
 	mw := multipart.NewWriter(&s.inferBuf)
@@ -393,94 +399,104 @@ func (s *session) infer(ctx context.Context, pcm []byte) (string, error) {
 	// Primary audio field.
 	fw, err := mw.CreateFormFile("file", "audio.wav")
 	if err != nil {
-		return "", fmt.Errorf("whisper: create form file: %w", err)
+		return "", nil, fmt.Errorf("whisper: create form file: %w", err)
 	}
 	if _, err := fw.Write(wav); err != nil {
-		return "", fmt.Errorf("whisper: write wav data: %w", err)
+		return "", nil, fmt.Errorf("whisper: write wav data: %w", err)
 	}
 
 	// Optional hint fields.
 	if s.language != "" {
 		if err := mw.WriteField("language", s.language); err != nil {
-			return "", fmt.Errorf("whisper: write language field: %w", err)
+			return "", nil, fmt.Errorf("whisper: write language field: %w", err)
 		}
 	}
 	if s.model != "" {
 		if err := mw.WriteField("model", s.model); err != nil {
-			return "", fmt.Errorf("whisper: write model field: %w", err)
+			return "", nil, fmt.Errorf("whisper: write model field: %w", err)
 		}
 	}
 
+	// Ask for per-token timings. Servers that do not support verbose JSON
+	// answer with the plain {"text"} object, which parses the same way.
+	if err := mw.WriteField("response_format", "verbose_json"); err != nil {
+		return "", nil, fmt.Errorf("whisper: write response format field: %w", err)
+	}
+	if err := mw.WriteField("word_timestamps", "true"); err != nil {
+		return "", nil, fmt.Errorf("whisper: write word timestamps field: %w", err)
+	}
+
 	if err := mw.Close(); err != nil {
-		return "", fmt.Errorf("whisper: close multipart writer: %w", err)
+		return "", nil, fmt.Errorf("whisper: close multipart writer: %w", err)
 	}
 
 	endpoint := s.serverURL + "/inference"
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &s.inferBuf)
 	if err != nil {
-		return "", fmt.Errorf("whisper: create request: %w", err)
+		return "", nil, fmt.Errorf("whisper: create request: %w", err)
 	}
 	req.Header.Set("Content-Type", mw.FormDataContentType())
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("whisper: http request: %w", err)
+		return "", nil, fmt.Errorf("whisper: http request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("whisper: server returned HTTP %d", resp.StatusCode)
+		return "", nil, fmt.Errorf("whisper: server returned HTTP %d", resp.StatusCode)
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("whisper: read response body: %w", err)
+		return "", nil, fmt.Errorf("whisper: read response body: %w", err)
 	}
 
-	var result struct {
-		Text string `json:"text"`
-	}
+	var result inferenceResult
 	if err := json.Unmarshal(data, &result); err != nil {
-		return "", fmt.Errorf("whisper: parse JSON response: %w", err)
+		return "", nil, fmt.Errorf("whisper: parse JSON response: %w", err)
 	}
 
-	return result.Text, nil
+	return result.Text, result.words(), nil
+}
+
+// inferenceResult is the JSON response of the whisper.cpp /inference
+// endpoint. Segments are only present in the verbose_json format.
+type inferenceResult struct {
+	Text     string `json:"text"`
+	Segments []struct {
+		Words []struct {
+			Word        string  `json:"word"`
+			Start       float64 `json:"start"` // seconds
+			End         float64 `json:"end"`   // seconds
+			Probability float64 `json:"probability"`
+		} `json:"words"`
+	} `json:"segments"`
+}
+
+// words returns the words of the transcription. whisper.cpp reports its
+// tokens as "words", so they are joined into whole words first.
+func (r inferenceResult) words() []stt.WordDetail {
+	var pieces []wordPiece
+	for _, seg := range r.Segments {
+		for _, w := range seg.Words {
+			pieces = append(pieces, wordPiece{
+				text:  w.Word,
+				start: secondsToDuration(w.Start),
+				end:   secondsToDuration(w.End),
+				p:     w.Probability,
+			})
+		}
+	}
+	return joinWords(pieces)
 }
 
 // ---- helpers ----------------------------------------------------------------
 
-// encodeWAV wraps raw 16-bit signed little-endian PCM data in a standard
-// RIFF/WAV container. The returned byte slice is suitable for direct inclusion
-// in a multipart form upload. No external dependencies are required.
-func encodeWAV(pcm []byte, sampleRate, channels int) []byte {
-	bps := bitsPerSample
-	byteRate := sampleRate * channels * bps / 8
-	blockAlign := channels * bps / 8
-	dataSize := len(pcm)
-
-	buf := make([]byte, 44+dataSize)
-
-	// RIFF chunk descriptor
-	copy(buf[0:4], "RIFF")
-	binary.LittleEndian.PutUint32(buf[4:8], uint32(36+dataSize)) // file size − 8
-	copy(buf[8:12], "WAVE")
-
-	// fmt sub-chunk
-	copy(buf[12:16], "fmt ")
-	binary.LittleEndian.PutUint32(buf[16:20], 16)                 // sub-chunk size (PCM)
-	binary.LittleEndian.PutUint16(buf[20:22], 1)                  // audio format: PCM
-	binary.LittleEndian.PutUint16(buf[22:24], uint16(channels))   // num channels
-	binary.LittleEndian.PutUint32(buf[24:28], uint32(sampleRate)) // sample rate
-	binary.LittleEndian.PutUint32(buf[28:32], uint32(byteRate))   // byte rate
-	binary.LittleEndian.PutUint16(buf[32:34], uint16(blockAlign)) // block align
-	binary.LittleEndian.PutUint16(buf[34:36], uint16(bps))        // bits per sample
-
-	// data sub-chunk
-	copy(buf[36:40], "data")
-	binary.LittleEndian.PutUint32(buf[40:44], uint32(dataSize))
-	copy(buf[44:], pcm)
-
-	return buf
+// secondsToDuration converts a time in seconds, as used in the whisper.cpp
+// JSON responses, to a duration rounded to the millisecond.
+func secondsToDuration(sec float64) time.Duration {
+	return time.Duration(math.Round(sec*1000)) * time.Millisecond
 }
 
 // computeRMS returns the root-mean-square energy of a 16-bit signed
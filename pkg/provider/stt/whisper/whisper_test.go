@@ -7,6 +7,7 @@ import (
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -240,11 +241,62 @@ func TestSpeechFollowedBySilenceTriggersInference(t *testing.T) {
 		if !tr.IsFinal {
 			t.Error("Finals() transcript should have IsFinal = true")
 		}
+		if tr.Words != nil {
+			t.Errorf("Finals().Words = %v; want nil without word timings", tr.Words)
+		}
 	case <-time.After(5 * time.Second):
 		t.Fatal("timed out waiting for final transcript")
 	}
 }
 
+func TestWordTimings(t *testing.T) {
+	var gotFormat, gotWordTimestamps atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotFormat.Store(r.FormValue("response_format"))
+		gotWordTimestamps.Store(r.FormValue("word_timestamps"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"text": " Hello Eldrinax.",
+			"segments": [{"words": [
+				{"word": " Hello", "start": 0.0, "end": 0.42, "probability": 0.98},
+				{"word": " Eld", "start": 0.5, "end": 0.71, "probability": 0.8},
+				{"word": "rin", "start": 0.71, "end": 0.9, "probability": 0.6},
+				{"word": "ax.", "start": 0.9, "end": 1.2, "probability": 0.9}
+			]}]
+		}`))
+	}))
+	defer srv.Close()
+
+	p, _ := whisper.New(srv.URL,
+		whisper.WithSilenceThresholdMs(100),
+		whisper.WithSampleRate(16000),
+	)
+	h := mustStartStream(t, p, stt.StreamConfig{SampleRate: 16000, Channels: 1})
+	defer h.Close()
+
+	_ = h.SendAudio(makeSpeechPCM(1600))
+	_ = h.SendAudio(makeSilencePCM(1600))
+
+	want := []stt.WordDetail{
+		{Word: "Hello", Start: 0, End: 420 * time.Millisecond, Confidence: 0.98},
+		{Word: "Eldrinax.", Start: 500 * time.Millisecond, End: 1200 * time.Millisecond, Confidence: 0.6},
+	}
+	select {
+	case tr := <-h.Finals():
+		if !slices.Equal(tr.Words, want) {
+			t.Errorf("Finals().Words = %+v; want %+v", tr.Words, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for final transcript")
+	}
+	if got := gotFormat.Load(); got != "verbose_json" {
+		t.Errorf("response_format = %v; want verbose_json", got)
+	}
+	if got := gotWordTimestamps.Load(); got != "true" {
+		t.Errorf("word_timestamps = %v; want true", got)
+	}
+}
+
 func TestPartialEmittedAlongsideFinal(t *testing.T) {
 	const wantText = "fire bolt"
 	srv := newMockServer(t, wantText, nil)
@@ -0,0 +1,63 @@
+package tts
+
+import (
+	"hash/fnv"
+	"slices"
+)
+
+// AssignVoice deterministically picks a voice for key, typically an NPC's
+// name, from voices. It lets NPCs without a configured voice sound distinct
+// from each other without manual setup.
+//
+// The pick uses rendezvous hashing: every voice is scored by a hash of seed,
+// key and the voice's ID, and the highest score wins. The same key therefore
+// always gets the same voice from the same pool, whatever the pool's order,
+// and adding or removing a voice only moves the keys that voice wins or won.
+// Changing seed reshuffles all assignments. Returns false if voices is empty.
+func AssignVoice(seed, key string, voices []VoiceProfile) (VoiceProfile, bool) {
+	var (
+		best      VoiceProfile
+		bestScore uint64
+		found     bool
+	)
+	for _, v := range voices {
+		score := voiceScore(seed, key, v.ID)
+		// Ties, which practically only occur for duplicate IDs, go to the
+		// smaller ID so the result does not depend on the pool's order.
+		if !found || score > bestScore || (score == bestScore && v.ID < best.ID) {
+			best, bestScore, found = v, score, true
+		}
+	}
+	return best, found
+}
+
+// VoicesForLanguage returns the voices in voices that speak lang, judged by
+// [VoiceLanguages]. Voices that report no language are kept, since they may
+// speak it. An empty lang keeps every voice.
+func VoicesForLanguage(voices []VoiceProfile, lang string) []VoiceProfile {
+	return slices.DeleteFunc(slices.Clone(voices), func(v VoiceProfile) bool {
+		return !VoiceLanguages(v).Supports(lang)
+	})
+}
+
+// voiceScore returns the rendezvous hashing score of voiceID for key.
+func voiceScore(seed, key, voiceID string) uint64 {
+	h := fnv.New64a()
+	for _, s := range []string{seed, key, voiceID} {
+		_, _ = h.Write([]byte(s))
+		_, _ = h.Write([]byte{0})
+	}
+	return mix64(h.Sum64())
+}
+
+// mix64 is the SplitMix64 finaliser. FNV-1a alone spreads inputs that differ
+// only in their last bytes poorly, which would make some voices win far more
+// often than others.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
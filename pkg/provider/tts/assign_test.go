@@ -0,0 +1,167 @@
+package tts_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// testVoices returns a pool of n voices with IDs voice-0 … voice-(n-1).
+func testVoices(n int) []tts.VoiceProfile {
+	voices := make([]tts.VoiceProfile, n)
+	for i := range voices {
+		voices[i] = tts.VoiceProfile{ID: fmt.Sprintf("voice-%d", i)}
+	}
+	return voices
+}
+
+func TestAssignVoice_Deterministic(t *testing.T) {
+	t.Parallel()
+
+	voices := testVoices(8)
+	reversed := slices.Clone(voices)
+	slices.Reverse(reversed)
+
+	for _, key := range []string{"Barkeep", "Town Guard", "Fishmonger", "Stable Boy"} {
+		first, ok := tts.AssignVoice("", key, voices)
+		if !ok {
+			t.Fatalf("AssignVoice(%q): no voice assigned", key)
+		}
+		for range 3 {
+			if again, _ := tts.AssignVoice("", key, voices); again.ID != first.ID {
+				t.Errorf("AssignVoice(%q) = %q, then %q", key, first.ID, again.ID)
+			}
+		}
+		if other, _ := tts.AssignVoice("", key, reversed); other.ID != first.ID {
+			t.Errorf("AssignVoice(%q) depends on pool order: %q vs %q", key, first.ID, other.ID)
+		}
+	}
+}
+
+func TestAssignVoice_DistinctKeys(t *testing.T) {
+	t.Parallel()
+
+	voices := testVoices(8)
+	keys := []string{"Barkeep", "Town Guard", "Fishmonger", "Stable Boy", "Old Hag", "Street Urchin"}
+
+	assigned := map[string]string{}
+	for _, key := range keys {
+		v, _ := tts.AssignVoice("", key, voices)
+		assigned[key] = v.ID
+	}
+	distinct := map[string]bool{}
+	for _, id := range assigned {
+		distinct[id] = true
+	}
+	if len(distinct) < len(keys)/2 {
+		t.Errorf("%d keys share only %d voices: %v", len(keys), len(distinct), assigned)
+	}
+	for _, pair := range [][2]string{{"Barkeep", "Fishmonger"}, {"Old Hag", "Street Urchin"}} {
+		if assigned[pair[0]] == assigned[pair[1]] {
+			t.Errorf("%s and %s share voice %q", pair[0], pair[1], assigned[pair[0]])
+		}
+	}
+}
+
+func TestAssignVoice_Distribution(t *testing.T) {
+	t.Parallel()
+
+	voices := testVoices(5)
+	counts := map[string]int{}
+	const keys = 5000
+	for i := range keys {
+		v, _ := tts.AssignVoice("", fmt.Sprintf("npc-%d", i), voices)
+		counts[v.ID]++
+	}
+	for _, v := range voices {
+		// Each voice should get about a fifth of the keys.
+		if n := counts[v.ID]; n < keys/5*8/10 || n > keys/5*12/10 {
+			t.Errorf("voice %q assigned %d of %d keys, want about %d", v.ID, n, keys, keys/5)
+		}
+	}
+}
+
+func TestAssignVoice_PoolChanges(t *testing.T) {
+	t.Parallel()
+
+	voices := testVoices(8)
+	grown := append(slices.Clone(voices), tts.VoiceProfile{ID: "voice-new"})
+
+	// Keys that do not move to the new voice keep their old one.
+	moved := 0
+	for i := range 200 {
+		key := fmt.Sprintf("npc-%d", i)
+		before, _ := tts.AssignVoice("", key, voices)
+		after, _ := tts.AssignVoice("", key, grown)
+		switch after.ID {
+		case before.ID:
+		case "voice-new":
+			moved++
+		default:
+			t.Errorf("%s moved from %q to %q instead of keeping its voice", key, before.ID, after.ID)
+		}
+	}
+	if moved == 0 {
+		t.Error("no key moved to the new voice")
+	}
+}
+
+func TestAssignVoice_Seed(t *testing.T) {
+	t.Parallel()
+
+	voices := testVoices(8)
+	changed := 0
+	for i := range 50 {
+		key := fmt.Sprintf("npc-%d", i)
+		a, _ := tts.AssignVoice("", key, voices)
+		b, _ := tts.AssignVoice("campaign-2", key, voices)
+		if a.ID != b.ID {
+			changed++
+		}
+	}
+	if changed == 0 {
+		t.Error("changing the seed reassigned no voice")
+	}
+}
+
+func TestAssignVoice_EmptyPool(t *testing.T) {
+	t.Parallel()
+
+	if v, ok := tts.AssignVoice("", "Barkeep", nil); ok {
+		t.Errorf("AssignVoice with no voices = %+v, want false", v)
+	}
+}
+
+func TestVoicesForLanguage(t *testing.T) {
+	t.Parallel()
+
+	voices := []tts.VoiceProfile{
+		{ID: "en", Language: "en-US"},
+		{ID: "de", Metadata: map[string]string{"locale": "de_DE"}},
+		{ID: "unknown"},
+	}
+
+	tests := []struct {
+		lang string
+		want []string
+	}{
+		{lang: "", want: []string{"en", "de", "unknown"}},
+		{lang: "de-AT", want: []string{"de", "unknown"}},
+		{lang: "en", want: []string{"en", "unknown"}},
+		{lang: "ja", want: []string{"unknown"}},
+	}
+	for _, tc := range tests {
+		var got []string
+		for _, v := range tts.VoicesForLanguage(voices, tc.lang) {
+			got = append(got, v.ID)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("VoicesForLanguage(%q) = %v, want %v", tc.lang, got, tc.want)
+		}
+	}
+	if len(voices) != 3 {
+		t.Errorf("VoicesForLanguage modified its input: %v", voices)
+	}
+}
@@ -0,0 +1,329 @@
+// Package azure provides an Azure AI Speech (Cognitive Services) TTS provider
+// using the Speech service's REST API. It implements the tts.Provider
+// interface.
+//
+// The REST API synthesises one SSML document per request, so SynthesizeStream
+// accumulates incoming text fragments into complete sentences and dispatches
+// concurrent requests with a small lookahead window, emitting the audio in the
+// original sentence order (see package pipeline). Audio is requested as a raw
+// PCM output format, 16 kHz 16-bit mono by default (see [WithOutputFormat]).
+//
+// Voices are addressed by their short name, e.g. "en-US-AvaNeural" or
+// "de-DE-ConradNeural"; all neural voices of the region are available.
+//
+// Typical usage:
+//
+//	p, err := azure.New(apiKey, azure.WithRegion("westeurope"))
+//	stream, err := p.SynthesizeStream(ctx, textCh, tts.VoiceProfile{ID: "en-GB-RyanNeural"})
+package azure
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/pipeline"
+)
+
+// Compile-time interface assertions.
+var (
+	_ tts.Provider       = (*Provider)(nil)
+	_ tts.FormatReporter = (*Provider)(nil)
+)
+
+const (
+	endpointFormat  = "https://%s.tts.speech.microsoft.com"
+	synthesisPath   = "/cognitiveservices/v1"
+	voicesPath      = "/cognitiveservices/voices/list"
+	defaultLanguage = "en-US"
+	defaultTimeout  = 30 * time.Second
+
+	// DefaultOutputFormat is the audio format requested when
+	// [WithOutputFormat] is not supplied: raw 16 kHz 16-bit mono PCM.
+	DefaultOutputFormat = "raw-16khz-16bit-mono-pcm"
+)
+
+// Option is a functional option for configuring the Azure Provider.
+type Option func(*Provider)
+
+// WithRegion sets the Azure region of the Speech resource (e.g., "westeurope").
+func WithRegion(region string) Option {
+	return func(p *Provider) {
+		p.region = region
+	}
+}
+
+// WithEndpoint overrides the base URL derived from the region, e.g. for
+// sovereign clouds, private endpoints, or a local mock server in tests.
+func WithEndpoint(baseURL string) Option {
+	return func(p *Provider) {
+		p.baseURL = baseURL
+	}
+}
+
+// WithOutputFormat sets the X-Microsoft-OutputFormat of synthesis requests.
+// Only raw PCM formats ("raw-<rate>khz-16bit-mono-pcm") are supported.
+// Defaults to [DefaultOutputFormat].
+func WithOutputFormat(format string) Option {
+	return func(p *Provider) {
+		p.outputFormat = format
+	}
+}
+
+// WithLanguage sets the xml:lang of the generated SSML documents. Defaults to
+// "en-US". The voice determines the spoken language; this only affects text
+// normalisation of multilingual voices. A non-empty [tts.VoiceProfile.Language]
+// overrides it per request.
+func WithLanguage(lang string) Option {
+	return func(p *Provider) {
+		p.language = lang
+	}
+}
+
+// WithTimeout sets the per-request HTTP timeout. Defaults to 30 s.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Provider) {
+		p.httpClient.Timeout = d
+	}
+}
+
+// WithConcurrency sets how many sentence synthesis requests may be in flight
+// at the same time. Defaults to [pipeline.DefaultConcurrency]. Values < 1 are
+// ignored.
+func WithConcurrency(n int) Option {
+	return func(p *Provider) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// WithMaxTextLength caps the characters sent in one synthesis request. Longer
+// sentences are split at clause or word boundaries and synthesised as several
+// requests in order. Values < 1, the default, disable splitting.
+func WithMaxTextLength(n int) Option {
+	return func(p *Provider) {
+		p.maxTextLength = n
+	}
+}
+
+// Provider implements tts.Provider backed by the Azure Speech REST API.
+// It is safe for concurrent use; multiple SynthesizeStream calls may run in parallel.
+type Provider struct {
+	apiKey        string
+	region        string
+	baseURL       string
+	outputFormat  string
+	language      string
+	concurrency   int
+	maxTextLength int
+	httpClient    *http.Client
+}
+
+// New creates an Azure TTS Provider. apiKey is the Speech resource key and
+// must be non-empty; either [WithRegion] or [WithEndpoint] is required.
+func New(apiKey string, opts ...Option) (*Provider, error) {
+	if apiKey == "" {
+		return nil, errors.New("azure: apiKey must not be empty")
+	}
+	p := &Provider{
+		apiKey:       apiKey,
+		outputFormat: DefaultOutputFormat,
+		language:     defaultLanguage,
+		concurrency:  pipeline.DefaultConcurrency,
+		httpClient:   &http.Client{Timeout: defaultTimeout, Transport: ident.Transport(nil)},
+	}
+	for _, o := range opts {
+		o(p)
+	}
+
+	if p.baseURL == "" {
+		if p.region == "" {
+			return nil, errors.New("azure: a region or endpoint is required")
+		}
+		p.baseURL = fmt.Sprintf(endpointFormat, p.region)
+	}
+	p.baseURL = strings.TrimRight(p.baseURL, "/")
+	if _, err := sampleRateOf(p.outputFormat); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SampleRate returns the sample rate in Hz of the PCM produced by the provider.
+func (p *Provider) SampleRate() int {
+	rate, _ := sampleRateOf(p.outputFormat) // validated in New
+	return rate
+}
+
+// sampleRateOf extracts the sample rate from a raw PCM output format name
+// such as "raw-24khz-16bit-mono-pcm".
+func sampleRateOf(format string) (int, error) {
+	rest, ok := strings.CutPrefix(format, "raw-")
+	if !ok || !strings.HasSuffix(rest, "-16bit-mono-pcm") {
+		return 0, fmt.Errorf("azure: output format %q is not a raw 16-bit mono PCM format", format)
+	}
+	khz, _, _ := strings.Cut(rest, "khz-")
+	n, err := strconv.Atoi(khz)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("azure: output format %q has no valid sample rate", format)
+	}
+	return n * 1000, nil
+}
+
+// SynthesizeStream consumes text fragments from the text channel, accumulates
+// them into complete sentences, and synthesises each sentence with one REST
+// request. The raw PCM is emitted on the audio channel in the original
+// sentence order.
+//
+// voice.ID must be an Azure voice short name (e.g., "en-US-AvaNeural").
+// voice.SpeedFactor and voice.PitchShift are applied with SSML prosody, the
+// latter in semitones. A sentence that is itself an SSML document
+// ("<speak …>…</speak>") is sent unchanged.
+//
+// The audio channel is closed when all text has been synthesised, when a
+// sentence fails, or when ctx is cancelled; [tts.Stream.Err] reports the
+// failure. The caller must drain the channel to prevent goroutine leaks.
+func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	if voice.ID == "" {
+		return nil, errors.New("azure: voice.ID must not be empty")
+	}
+	pl := pipeline.New(func(ctx context.Context, sentence string) ([]byte, error) {
+		return p.synthesize(ctx, sentence, voice)
+	}, pipeline.WithConcurrency(p.concurrency), pipeline.WithMaxSentenceLength(p.maxTextLength))
+	return pl.Run(ctx, text), nil
+}
+
+// synthesize performs a single synthesis request and returns the PCM.
+func (p *Provider) synthesize(ctx context.Context, sentence string, voice tts.VoiceProfile) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+synthesisPath,
+		strings.NewReader(p.ssml(sentence, voice)))
+	if err != nil {
+		return nil, fmt.Errorf("azure: build synthesis request: %w", err)
+	}
+	p.setHeaders(req)
+	req.Header.Set("Content-Type", "application/ssml+xml")
+	req.Header.Set("X-Microsoft-OutputFormat", p.outputFormat)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("azure: synthesis request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("azure: synthesis returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	pcm, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("azure: read synthesis response: %w", err)
+	}
+	return pcm, nil
+}
+
+// ssml builds the SSML document for sentence.
+func (p *Provider) ssml(sentence string, voice tts.VoiceProfile) string {
+	if strings.HasPrefix(strings.TrimSpace(sentence), "<speak") {
+		return sentence
+	}
+
+	var prosody []string
+	if voice.SpeedFactor > 0 && voice.SpeedFactor != 1 {
+		prosody = append(prosody, fmt.Sprintf(`rate="%+d%%"`, int(math.Round((voice.SpeedFactor-1)*100))))
+	}
+	if voice.PitchShift != 0 {
+		prosody = append(prosody, fmt.Sprintf(`pitch="%+gst"`, voice.PitchShift))
+	}
+
+	content := escapeSSML(sentence)
+	if len(prosody) > 0 {
+		content = "<prosody " + strings.Join(prosody, " ") + ">" + content + "</prosody>"
+	}
+	return fmt.Sprintf(`<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="%s"><voice name="%s">%s</voice></speak>`,
+		escapeSSML(cmp.Or(voice.Language, p.language)), escapeSSML(voice.ID), content)
+}
+
+// ssmlEscaper escapes the characters that are special in SSML text.
+var ssmlEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+	"'", "&apos;",
+)
+
+// escapeSSML escapes s for use as SSML text or attribute content.
+func escapeSSML(s string) string { return ssmlEscaper.Replace(s) }
+
+// setHeaders adds the authentication header shared by all requests.
+func (p *Provider) setHeaders(req *http.Request) {
+	req.Header.Set("Ocp-Apim-Subscription-Key", p.apiKey)
+}
+
+// voiceEntry is one element of the JSON array returned by the voices list.
+type voiceEntry struct {
+	ShortName   string `json:"ShortName"`
+	DisplayName string `json:"DisplayName"`
+	LocalName   string `json:"LocalName"`
+	Gender      string `json:"Gender"`
+	Locale      string `json:"Locale"`
+	VoiceType   string `json:"VoiceType"`
+}
+
+// ListVoices returns the voices available in the configured region. Metadata
+// carries the voice's gender, locale, local_name, and voice_type ("Neural").
+func (p *Provider) ListVoices(ctx context.Context) ([]tts.VoiceProfile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+voicesPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("azure: build voices request: %w", err)
+	}
+	p.setHeaders(req)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("azure: voices request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("azure: voices list returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var entries []voiceEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("azure: decode voices list: %w", err)
+	}
+	profiles := make([]tts.VoiceProfile, 0, len(entries))
+	for _, e := range entries {
+		profiles = append(profiles, tts.VoiceProfile{
+			ID:       e.ShortName,
+			Name:     e.DisplayName,
+			Provider: "azure",
+			Metadata: map[string]string{
+				"gender":     e.Gender,
+				"locale":     e.Locale,
+				"local_name": e.LocalName,
+				"voice_type": e.VoiceType,
+			},
+		})
+	}
+	return profiles, nil
+}
+
+// CloneVoice is not supported through the Speech REST API and always returns
+// an error. Custom neural voices are trained in Speech Studio and then used
+// by their short name like any other voice.
+func (p *Provider) CloneVoice(_ context.Context, _ [][]byte) (*tts.VoiceProfile, error) {
+	return nil, errors.New("azure: voice cloning is not supported")
+}
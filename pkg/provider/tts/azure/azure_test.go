@@ -0,0 +1,297 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// pcmFor returns two bytes of fake PCM per byte of body so the output order
+// can be checked.
+func pcmFor(body string) []byte {
+	return bytes.Repeat([]byte{byte(len(body)), 0}, len(body))
+}
+
+// fakeService emulates the Speech REST API. Synthesis requests are answered
+// with PCM derived from the SSML body.
+type fakeService struct {
+	mu     sync.Mutex
+	bodies []string
+	errs   []string
+}
+
+func (f *fakeService) fail(format string) {
+	f.mu.Lock()
+	f.errs = append(f.errs, format)
+	f.mu.Unlock()
+}
+
+func (f *fakeService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Ocp-Apim-Subscription-Key") != "test-key" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch r.URL.Path {
+	case voicesPath:
+		if r.Method != http.MethodGet {
+			f.fail("voices list method " + r.Method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[
+			{"Name":"Microsoft Server Speech Text to Speech Voice (en-GB, RyanNeural)","DisplayName":"Ryan",
+			 "LocalName":"Ryan","ShortName":"en-GB-RyanNeural","Gender":"Male","Locale":"en-GB","VoiceType":"Neural"},
+			{"DisplayName":"Conrad","LocalName":"Conrad","ShortName":"de-DE-ConradNeural","Gender":"Male",
+			 "Locale":"de-DE","VoiceType":"Neural"}
+		]`)
+	case synthesisPath:
+		if r.Method != http.MethodPost {
+			f.fail("synthesis method " + r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/ssml+xml" {
+			f.fail("Content-Type " + ct)
+		}
+		if of := r.Header.Get("X-Microsoft-OutputFormat"); of != "raw-24khz-16bit-mono-pcm" {
+			f.fail("X-Microsoft-OutputFormat " + of)
+		}
+		if ua := r.Header.Get("User-Agent"); ua != ident.UserAgent() {
+			f.fail("User-Agent " + ua)
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.bodies = append(f.bodies, string(body))
+		f.mu.Unlock()
+		_, _ = w.Write(pcmFor(string(body)))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestProvider(t *testing.T, svc http.Handler, opts ...Option) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(svc)
+	t.Cleanup(srv.Close)
+	p, err := New("test-key", append([]Option{WithEndpoint(srv.URL + "/")}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+// synthesizeAll feeds fragments to p and returns the concatenated output.
+func synthesizeAll(t *testing.T, p *Provider, voice tts.VoiceProfile, fragments ...string) []byte {
+	t.Helper()
+	textCh := make(chan string, len(fragments))
+	for _, f := range fragments {
+		textCh <- f
+	}
+	close(textCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := p.SynthesizeStream(ctx, textCh, voice)
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	var out []byte
+	for chunk := range stream.Audio() {
+		out = append(out, chunk...)
+	}
+	return out
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		apiKey  string
+		opts    []Option
+		wantErr bool
+		wantURL string
+		wantHz  int
+	}{
+		{name: "empty key", opts: []Option{WithRegion("eastus")}, wantErr: true},
+		{name: "no region or endpoint", apiKey: "k", wantErr: true},
+		{
+			name:    "region",
+			apiKey:  "k",
+			opts:    []Option{WithRegion("westeurope")},
+			wantURL: "https://westeurope.tts.speech.microsoft.com",
+			wantHz:  16000,
+		},
+		{
+			name:    "endpoint and format",
+			apiKey:  "k",
+			opts:    []Option{WithEndpoint("http://localhost:1234/"), WithOutputFormat("raw-48khz-16bit-mono-pcm")},
+			wantURL: "http://localhost:1234",
+			wantHz:  48000,
+		},
+		{name: "compressed format", apiKey: "k", opts: []Option{WithRegion("eastus"), WithOutputFormat("audio-24khz-48kbitrate-mono-mp3")}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := New(tc.apiKey, tc.opts...)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if p.baseURL != tc.wantURL {
+				t.Errorf("baseURL = %q, want %q", p.baseURL, tc.wantURL)
+			}
+			if p.SampleRate() != tc.wantHz {
+				t.Errorf("SampleRate = %d, want %d", p.SampleRate(), tc.wantHz)
+			}
+		})
+	}
+}
+
+func TestSynthesizeStream_MockServer(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeService{}
+	p := newTestProvider(t, svc, WithOutputFormat("raw-24khz-16bit-mono-pcm"), WithLanguage("en-GB"))
+
+	voice := tts.VoiceProfile{ID: "en-GB-RyanNeural"}
+	got := synthesizeAll(t, p, voice, "Welcome, ", "traveller. ", "Sit down!")
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if len(svc.errs) > 0 {
+		t.Fatalf("bad requests: %q", svc.errs)
+	}
+	first, second := p.ssml("Welcome, traveller.", voice), p.ssml("Sit down!", voice)
+	if want := append(pcmFor(first), pcmFor(second)...); !bytes.Equal(got, want) {
+		t.Errorf("PCM output = %v, want %v", got, want)
+	}
+	bodies := slices.Sorted(slices.Values(svc.bodies))
+	if !slices.Equal(bodies, []string{second, first}) {
+		t.Errorf("request bodies = %q", bodies)
+	}
+	if !strings.Contains(first, `xml:lang="en-GB"`) || !strings.Contains(first, `<voice name="en-GB-RyanNeural">Welcome, traveller.</voice>`) {
+		t.Errorf("SSML = %s", first)
+	}
+}
+
+func TestSSML(t *testing.T) {
+	t.Parallel()
+
+	p, err := New("k", WithRegion("eastus"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		sentence string
+		voice    tts.VoiceProfile
+		want     string
+	}{
+		{
+			name:     "plain text is escaped",
+			sentence: "Salt & pepper.",
+			voice:    tts.VoiceProfile{ID: "en-US-AvaNeural", SpeedFactor: 1},
+			want:     `<voice name="en-US-AvaNeural">Salt &amp; pepper.</voice>`,
+		},
+		{
+			name:     "speed and pitch use prosody",
+			sentence: "Run!",
+			voice:    tts.VoiceProfile{ID: "en-US-AvaNeural", SpeedFactor: 1.25, PitchShift: -2},
+			want:     `<voice name="en-US-AvaNeural"><prosody rate="+25%" pitch="-2st">Run!</prosody></voice>`,
+		},
+		{
+			name:     "voice language overrides the default",
+			sentence: "Guten Tag.",
+			voice:    tts.VoiceProfile{ID: "de-DE-ConradNeural", Language: "de-DE"},
+			want:     `xml:lang="de-DE"><voice name="de-DE-ConradNeural">Guten Tag.</voice>`,
+		},
+		{
+			name:     "slower speech",
+			sentence: "Slowly.",
+			voice:    tts.VoiceProfile{ID: "en-US-AvaNeural", SpeedFactor: 0.8},
+			want:     `<prosody rate="-20%">Slowly.</prosody>`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.ssml(tc.sentence, tc.voice); !strings.Contains(got, tc.want) {
+				t.Errorf("ssml = %s, want it to contain %s", got, tc.want)
+			}
+		})
+	}
+
+	doc := `<speak version="1.0" xml:lang="en-US"><voice name="x">Hi</voice></speak>`
+	if got := p.ssml(doc, tts.VoiceProfile{ID: "y", SpeedFactor: 2}); got != doc {
+		t.Errorf("SSML document was rewritten: %s", got)
+	}
+}
+
+func TestSynthesizeStream_Errors(t *testing.T) {
+	t.Parallel()
+
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+
+	if _, err := p.SynthesizeStream(context.Background(), make(chan string), tts.VoiceProfile{}); err == nil {
+		t.Error("SynthesizeStream without voice ID: expected error")
+	}
+
+	// A failing sentence closes the stream early instead of hanging.
+	if got := synthesizeAll(t, p, tts.VoiceProfile{ID: "en-US-AvaNeural"}, "Hello there."); len(got) != 0 {
+		t.Errorf("got %d bytes of audio after synthesis error, want 0", len(got))
+	}
+
+	if _, err := p.ListVoices(context.Background()); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("ListVoices error = %v, want status 429", err)
+	}
+}
+
+func TestListVoices(t *testing.T) {
+	t.Parallel()
+
+	p := newTestProvider(t, &fakeService{})
+	voices, err := p.ListVoices(context.Background())
+	if err != nil {
+		t.Fatalf("ListVoices: %v", err)
+	}
+	if len(voices) != 2 {
+		t.Fatalf("got %d voices, want 2", len(voices))
+	}
+	r := voices[0]
+	if r.ID != "en-GB-RyanNeural" || r.Name != "Ryan" || r.Provider != "azure" {
+		t.Errorf("voice = %+v", r)
+	}
+	if r.Metadata["gender"] != "Male" || r.Metadata["locale"] != "en-GB" || r.Metadata["voice_type"] != "Neural" {
+		t.Errorf("metadata = %v", r.Metadata)
+	}
+}
+
+func TestCloneVoice_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	p, err := New("k", WithRegion("eastus"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = p.CloneVoice(context.Background(), [][]byte{{1}})
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("CloneVoice error = %v, want not supported", err)
+	}
+}
@@ -0,0 +1,524 @@
+// Package cartesia provides a Cartesia Sonic-backed TTS provider using
+// Cartesia's streaming WebSocket API. It implements the tts.Provider
+// interface.
+//
+// A single WebSocket carries many concurrent generations, each identified by
+// a context ID. SynthesizeStream opens one socket per call, accumulates the
+// incoming text fragments into complete sentences, and submits every sentence
+// as its own context so that up to [WithConcurrency] sentences are generated
+// at once. Audio is emitted in the original sentence order (see package
+// pipeline); the first sentence is forwarded chunk by chunk as it arrives to
+// keep time-to-first-audio low.
+//
+// Audio is requested as raw 16-bit little-endian mono PCM at the rate set by
+// [WithSampleRate].
+//
+// Typical usage:
+//
+//	p, err := cartesia.New(apiKey,
+//	    cartesia.WithModel("sonic-2"),
+//	    cartesia.WithSampleRate(24000),
+//	)
+//	stream, err := p.SynthesizeStream(ctx, textCh, tts.VoiceProfile{ID: voiceID})
+package cartesia
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/pipeline"
+	"github.com/coder/websocket"
+)
+
+// Compile-time interface assertions.
+var (
+	_ tts.Provider       = (*Provider)(nil)
+	_ tts.FormatReporter = (*Provider)(nil)
+)
+
+const (
+	defaultBaseURL  = "https://api.cartesia.ai"
+	websocketPath   = "/tts/websocket"
+	voicesPath      = "/voices"
+	apiVersion      = "2025-04-16"
+	defaultLanguage = "en"
+	defaultTimeout  = 30 * time.Second
+
+	// voicesPageSize is the page size requested when listing voices.
+	voicesPageSize = 100
+
+	// contextBuffer is the number of responses buffered per generation
+	// context before the socket reader waits for the consumer.
+	contextBuffer = 64
+
+	// DefaultModel is the Cartesia model used when [WithModel] is not supplied.
+	DefaultModel = "sonic-2"
+
+	// DefaultSampleRate is the PCM sample rate in Hz used when
+	// [WithSampleRate] is not supplied.
+	DefaultSampleRate = 16000
+)
+
+// Option is a functional option for configuring a Cartesia Provider.
+type Option func(*Provider)
+
+// WithModel sets the Cartesia model ID (e.g., "sonic-2", "sonic-turbo").
+// Defaults to [DefaultModel].
+func WithModel(model string) Option {
+	return func(p *Provider) {
+		p.model = model
+	}
+}
+
+// WithVoiceID sets the voice used when a VoiceProfile passed to
+// SynthesizeStream has no ID.
+func WithVoiceID(id string) Option {
+	return func(p *Provider) {
+		p.voiceID = id
+	}
+}
+
+// WithSampleRate sets the PCM sample rate in Hz. Cartesia supports 8000,
+// 16000, 22050, 24000, 44100, and 48000. Defaults to [DefaultSampleRate].
+func WithSampleRate(hz int) Option {
+	return func(p *Provider) {
+		p.sampleRate = hz
+	}
+}
+
+// WithLanguage sets the ISO 639-1 language of the transcripts (e.g., "en",
+// "de"). Defaults to "en".
+func WithLanguage(lang string) Option {
+	return func(p *Provider) {
+		p.language = lang
+	}
+}
+
+// WithBaseURL overrides the API base URL (default "https://api.cartesia.ai").
+// The WebSocket URL is derived from it by switching the scheme to ws or wss.
+func WithBaseURL(u string) Option {
+	return func(p *Provider) {
+		p.baseURL = u
+	}
+}
+
+// WithConcurrency sets how many sentences may be generated at the same time
+// on one socket. Defaults to [pipeline.DefaultConcurrency]. Values < 1 are
+// ignored.
+func WithConcurrency(n int) Option {
+	return func(p *Provider) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// WithMaxTextLength caps the characters sent in one generation request. Longer
+// sentences are split at clause or word boundaries and synthesised as several
+// requests in order. Values < 1, the default, disable splitting.
+func WithMaxTextLength(n int) Option {
+	return func(p *Provider) {
+		p.maxTextLength = n
+	}
+}
+
+// Provider implements tts.Provider backed by the Cartesia streaming API.
+// It is safe for concurrent use; multiple SynthesizeStream calls may run in parallel.
+type Provider struct {
+	apiKey        string
+	model         string
+	voiceID       string
+	sampleRate    int
+	language      string
+	baseURL       string
+	concurrency   int
+	maxTextLength int
+	httpClient    *http.Client
+}
+
+// New creates a Cartesia Provider. apiKey must be non-empty.
+func New(apiKey string, opts ...Option) (*Provider, error) {
+	if apiKey == "" {
+		return nil, errors.New("cartesia: apiKey must not be empty")
+	}
+	p := &Provider{
+		apiKey:      apiKey,
+		model:       DefaultModel,
+		sampleRate:  DefaultSampleRate,
+		language:    defaultLanguage,
+		baseURL:     defaultBaseURL,
+		concurrency: pipeline.DefaultConcurrency,
+		httpClient:  &http.Client{Timeout: defaultTimeout, Transport: ident.Transport(nil)},
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	p.baseURL = strings.TrimRight(p.baseURL, "/")
+
+	switch p.sampleRate {
+	case 8000, 16000, 22050, 24000, 44100, 48000:
+	default:
+		return nil, fmt.Errorf("cartesia: sample rate %d is not supported", p.sampleRate)
+	}
+	return p, nil
+}
+
+// SampleRate returns the sample rate in Hz of the PCM produced by the provider.
+func (p *Provider) SampleRate() int { return p.sampleRate }
+
+// ---- WebSocket message types ----
+
+// generationRequest is the JSON message that starts one generation context.
+type generationRequest struct {
+	ModelID      string       `json:"model_id"`
+	Transcript   string       `json:"transcript"`
+	Voice        voiceSpec    `json:"voice"`
+	Language     string       `json:"language,omitempty"`
+	ContextID    string       `json:"context_id"`
+	OutputFormat outputFormat `json:"output_format"`
+	Continue     bool         `json:"continue"`
+}
+
+// voiceSpec selects the voice of a generation.
+type voiceSpec struct {
+	Mode string `json:"mode"`
+	ID   string `json:"id"`
+}
+
+// outputFormat describes the requested audio encoding.
+type outputFormat struct {
+	Container  string `json:"container"`
+	Encoding   string `json:"encoding"`
+	SampleRate int    `json:"sample_rate"`
+}
+
+// response is a message received from Cartesia over the WebSocket. Type is
+// "chunk" (Data holds base64 PCM), "done", "error", or an informational type
+// such as "timestamps".
+type response struct {
+	Type      string `json:"type"`
+	ContextID string `json:"context_id"`
+	Data      string `json:"data,omitempty"`
+	Done      bool   `json:"done"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ---- SynthesizeStream ----
+
+// SynthesizeStream opens a WebSocket to Cartesia, accumulates text fragments
+// from the text channel into complete sentences, and generates each sentence
+// in its own context. The raw PCM is emitted on the audio channel in the
+// original sentence order.
+//
+// voice.ID is the Cartesia voice ID; if empty, the voice set with
+// [WithVoiceID] is used. voice.Language, reduced to its primary subtag,
+// overrides [WithLanguage]. voice.SpeedFactor and voice.PitchShift are ignored.
+//
+// The audio channel is closed when all text has been synthesised, when a
+// generation fails, or when ctx is cancelled; [tts.Stream.Err] reports the
+// failure. The caller must drain the channel to prevent goroutine leaks.
+func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	voiceID := voice.ID
+	if voiceID == "" {
+		voiceID = p.voiceID
+	}
+	if voiceID == "" {
+		return nil, errors.New("cartesia: voice.ID must not be empty")
+	}
+
+	wsURL, err := p.websocketURL()
+	if err != nil {
+		return nil, err
+	}
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		HTTPClient: p.httpClient,
+		HTTPHeader: p.headers(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cartesia: dial: %w", err)
+	}
+	conn.SetReadLimit(-1)
+
+	language := p.language
+	if voice.Language != "" {
+		language = tts.BaseLanguage(voice.Language)
+	}
+	s := &stream{
+		p:        p,
+		conn:     conn,
+		voiceID:  voiceID,
+		language: language,
+		contexts: make(map[string]chan response),
+		closed:   make(chan struct{}),
+	}
+	go s.readLoop(ctx)
+
+	pl := pipeline.New(func(ctx context.Context, sentence string) ([]byte, error) {
+		var pcm []byte
+		err := s.generate(ctx, sentence, func(chunk []byte) bool {
+			pcm = append(pcm, chunk...)
+			return true
+		})
+		return pcm, err
+	},
+		pipeline.WithConcurrency(p.concurrency),
+		pipeline.WithMaxSentenceLength(p.maxTextLength),
+		pipeline.WithFirstSentenceStream(s.generate),
+	)
+	audio := pl.Run(ctx, text)
+
+	// Forward the pipeline's output so the socket can be closed once the
+	// stream is complete.
+	out := make(chan []byte, pipeline.DefaultBufferSize)
+	result := tts.NewStream(out)
+	go func() {
+		defer close(out)
+		defer conn.Close(websocket.StatusNormalClosure, "done")
+		for pcm := range audio.Audio() {
+			select {
+			case out <- pcm:
+			case <-ctx.Done():
+			}
+		}
+		result.SetErr(audio.Err())
+	}()
+	return result, nil
+}
+
+// websocketURL derives the WebSocket URL from the base URL.
+func (p *Provider) websocketURL() (string, error) {
+	u, err := url.Parse(p.baseURL + websocketPath)
+	if err != nil {
+		return "", fmt.Errorf("cartesia: parse base URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+	return u.String(), nil
+}
+
+// headers returns the authentication and version headers sent with every request.
+func (p *Provider) headers() http.Header {
+	h := http.Header{}
+	h.Set("X-API-Key", p.apiKey)
+	h.Set("Cartesia-Version", apiVersion)
+	return h
+}
+
+// stream multiplexes the generation contexts of one SynthesizeStream call
+// over a single WebSocket.
+type stream struct {
+	p        *Provider
+	conn     *websocket.Conn
+	voiceID  string
+	language string
+
+	mu       sync.Mutex
+	contexts map[string]chan response // by context ID
+	next     int                      // sequence number of the next context
+
+	closed  chan struct{} // closed when readLoop exits
+	readErr error         // valid after closed
+}
+
+// readLoop routes every response to the channel of its context until the
+// socket is closed.
+func (s *stream) readLoop(ctx context.Context) {
+	defer close(s.closed)
+	for {
+		_, msg, err := s.conn.Read(ctx)
+		if err != nil {
+			s.readErr = err
+			return
+		}
+		var resp response
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			continue
+		}
+		s.mu.Lock()
+		ch := s.contexts[resp.ContextID]
+		s.mu.Unlock()
+		if ch == nil {
+			continue
+		}
+		select {
+		case ch <- resp:
+		case <-ctx.Done():
+			s.readErr = ctx.Err()
+			return
+		}
+	}
+}
+
+// open registers a new generation context and returns its ID and response
+// channel.
+func (s *stream) open() (string, chan response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	id := "glyphoxa-" + strconv.Itoa(s.next)
+	ch := make(chan response, contextBuffer)
+	s.contexts[id] = ch
+	return id, ch
+}
+
+// release unregisters the context id.
+func (s *stream) release(id string) {
+	s.mu.Lock()
+	delete(s.contexts, id)
+	s.mu.Unlock()
+}
+
+// generate synthesises sentence in a fresh context and calls emit with each
+// PCM chunk as it arrives. It implements [pipeline.StreamFunc].
+func (s *stream) generate(ctx context.Context, sentence string, emit func([]byte) bool) error {
+	id, ch := s.open()
+	defer s.release(id)
+
+	req, err := json.Marshal(generationRequest{
+		ModelID:    s.p.model,
+		Transcript: sentence,
+		Voice:      voiceSpec{Mode: "id", ID: s.voiceID},
+		Language:   s.language,
+		ContextID:  id,
+		OutputFormat: outputFormat{
+			Container:  "raw",
+			Encoding:   "pcm_s16le",
+			SampleRate: s.p.sampleRate,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cartesia: encode request: %w", err)
+	}
+	if err := s.conn.Write(ctx, websocket.MessageText, req); err != nil {
+		return fmt.Errorf("cartesia: send request: %w", err)
+	}
+
+	for {
+		select {
+		case resp := <-ch:
+			switch resp.Type {
+			case "error":
+				return fmt.Errorf("cartesia: generation failed: %s", resp.Error)
+			case "chunk":
+				pcm, err := base64.StdEncoding.DecodeString(resp.Data)
+				if err != nil {
+					return fmt.Errorf("cartesia: decode audio chunk: %w", err)
+				}
+				if len(pcm) > 0 && !emit(pcm) {
+					return ctx.Err()
+				}
+			}
+			if resp.Done {
+				return nil
+			}
+		case <-s.closed:
+			return fmt.Errorf("cartesia: connection closed: %w", s.readErr)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ---- ListVoices ----
+
+// voicesPage is one page of the GET /voices response.
+type voicesPage struct {
+	Data    []cartesiaVoice `json:"data"`
+	HasMore bool            `json:"has_more"`
+}
+
+// cartesiaVoice is a single voice entry from the Cartesia API.
+type cartesiaVoice struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Language    string `json:"language"`
+	Gender      string `json:"gender"`
+}
+
+// ListVoices returns the voices available to the configured API key,
+// following the API's cursor pagination. Metadata carries the voice's
+// language, gender, and description when present.
+func (p *Provider) ListVoices(ctx context.Context) ([]tts.VoiceProfile, error) {
+	var (
+		profiles []tts.VoiceProfile
+		after    string
+	)
+	for {
+		page, err := p.fetchVoices(ctx, after)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.Data {
+			meta := make(map[string]string, 3)
+			if v.Language != "" {
+				meta["language"] = v.Language
+			}
+			if v.Gender != "" {
+				meta["gender"] = v.Gender
+			}
+			if v.Description != "" {
+				meta["description"] = v.Description
+			}
+			profiles = append(profiles, tts.VoiceProfile{
+				ID:       v.ID,
+				Name:     v.Name,
+				Provider: "cartesia",
+				Metadata: meta,
+			})
+		}
+		if !page.HasMore || len(page.Data) == 0 {
+			return profiles, nil
+		}
+		after = page.Data[len(page.Data)-1].ID
+	}
+}
+
+// fetchVoices requests the page of voices that follows the voice ID after.
+func (p *Provider) fetchVoices(ctx context.Context, after string) (*voicesPage, error) {
+	q := url.Values{"limit": {strconv.Itoa(voicesPageSize)}}
+	if after != "" {
+		q.Set("starting_after", after)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+voicesPath+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("cartesia: list voices: %w", err)
+	}
+	req.Header = p.headers()
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cartesia: list voices HTTP: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cartesia: list voices: unexpected status %d", resp.StatusCode)
+	}
+
+	var page voicesPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("cartesia: list voices decode: %w", err)
+	}
+	return &page, nil
+}
+
+// CloneVoice is not implemented and always returns an error.
+func (p *Provider) CloneVoice(_ context.Context, _ [][]byte) (*tts.VoiceProfile, error) {
+	return nil, errors.New("cartesia: voice cloning is not supported")
+}
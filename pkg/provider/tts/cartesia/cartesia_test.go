@@ -0,0 +1,304 @@
+package cartesia
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/coder/websocket"
+)
+
+// pcmFor returns two bytes of fake PCM per character of text so the output
+// order can be checked.
+func pcmFor(text string) []byte {
+	return bytes.Repeat([]byte{byte(len(text)), 0}, len(text))
+}
+
+// mockServer emulates the Cartesia WebSocket API. Each generation request is
+// answered with the PCM of its transcript, split over two chunks. Earlier
+// requests are answered more slowly than later ones, so responses of
+// concurrent contexts arrive out of order and interleaved.
+type mockServer struct {
+	mu       sync.Mutex
+	requests []generationRequest
+	headers  http.Header
+	fail     string // transcript answered with an error message
+}
+
+func (m *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != websocketPath {
+		http.NotFound(w, r)
+		return
+	}
+	m.mu.Lock()
+	m.headers = r.Header.Clone()
+	m.mu.Unlock()
+
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	ctx := r.Context()
+
+	send := func(resp response) {
+		data, _ := json.Marshal(resp)
+		_ = conn.Write(ctx, websocket.MessageText, data)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for i := 0; ; i++ {
+		_, msg, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		var req generationRequest
+		if err := json.Unmarshal(msg, &req); err != nil {
+			return
+		}
+		m.mu.Lock()
+		m.requests = append(m.requests, req)
+		m.mu.Unlock()
+
+		delay := time.Duration(max(3-i, 0)) * 30 * time.Millisecond
+		wg.Go(func() {
+			time.Sleep(delay)
+			if req.Transcript == m.fail {
+				send(response{Type: "error", ContextID: req.ContextID, Error: "voice not found", Done: true})
+				return
+			}
+			pcm := pcmFor(req.Transcript)
+			half := len(pcm) / 2
+			send(response{Type: "chunk", ContextID: req.ContextID, Data: base64.StdEncoding.EncodeToString(pcm[:half])})
+			send(response{Type: "chunk", ContextID: "unknown", Data: base64.StdEncoding.EncodeToString([]byte{9, 9})})
+			send(response{Type: "timestamps", ContextID: req.ContextID})
+			send(response{Type: "chunk", ContextID: req.ContextID, Data: base64.StdEncoding.EncodeToString(pcm[half:])})
+			send(response{Type: "done", ContextID: req.ContextID, Done: true})
+		})
+	}
+}
+
+func newTestProvider(t *testing.T, srv *mockServer, opts ...Option) *Provider {
+	t.Helper()
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	p, err := New("test-key", append([]Option{WithBaseURL(ts.URL)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+// synthesizeAll feeds fragments to p and returns the concatenated output.
+func synthesizeAll(t *testing.T, p *Provider, voice tts.VoiceProfile, fragments ...string) []byte {
+	t.Helper()
+	textCh := make(chan string, len(fragments))
+	for _, f := range fragments {
+		textCh <- f
+	}
+	close(textCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := p.SynthesizeStream(ctx, textCh, voice)
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	var out []byte
+	for chunk := range stream.Audio() {
+		out = append(out, chunk...)
+	}
+	return out
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(""); err == nil {
+		t.Error("New with empty key: expected error")
+	}
+	if _, err := New("k", WithSampleRate(12345)); err == nil {
+		t.Error("New with unsupported sample rate: expected error")
+	}
+	p, err := New("k")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if p.model != DefaultModel || p.SampleRate() != DefaultSampleRate {
+		t.Errorf("defaults: model %q, sample rate %d", p.model, p.SampleRate())
+	}
+	if u, _ := p.websocketURL(); u != "wss://api.cartesia.ai/tts/websocket" {
+		t.Errorf("websocketURL = %q", u)
+	}
+}
+
+func TestSynthesizeStream_MockServer(t *testing.T) {
+	t.Parallel()
+
+	srv := &mockServer{}
+	p := newTestProvider(t, srv, WithModel("sonic-turbo"), WithSampleRate(24000), WithLanguage("de"))
+
+	sentences := []string{"Welcome, traveller.", "Sit down!", "The stew is hot.", "Mind the dog."}
+	got := synthesizeAll(t, p, tts.VoiceProfile{ID: "voice-1"},
+		"Welcome, ", "traveller. Sit down! ", "The stew ", "is hot. Mind the dog.")
+
+	var want []byte
+	for _, s := range sentences {
+		want = append(want, pcmFor(s)...)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("PCM output out of order or incomplete:\n got %v\nwant %v", got, want)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.headers.Get("X-API-Key") != "test-key" || srv.headers.Get("Cartesia-Version") == "" {
+		t.Errorf("missing auth headers: %v", srv.headers)
+	}
+	var transcripts, ids []string
+	for _, req := range srv.requests {
+		transcripts = append(transcripts, req.Transcript)
+		ids = append(ids, req.ContextID)
+		if req.ModelID != "sonic-turbo" || req.Voice != (voiceSpec{Mode: "id", ID: "voice-1"}) || req.Language != "de" {
+			t.Errorf("request = %+v", req)
+		}
+		if req.OutputFormat != (outputFormat{Container: "raw", Encoding: "pcm_s16le", SampleRate: 24000}) {
+			t.Errorf("output format = %+v", req.OutputFormat)
+		}
+	}
+	slices.Sort(transcripts)
+	if !slices.Equal(transcripts, slices.Sorted(slices.Values(sentences))) {
+		t.Errorf("transcripts = %q", transcripts)
+	}
+	slices.Sort(ids)
+	if len(slices.Compact(ids)) != len(sentences) {
+		t.Errorf("context IDs are not unique: %q", ids)
+	}
+}
+
+func TestSynthesizeStream_DefaultVoice(t *testing.T) {
+	t.Parallel()
+
+	srv := &mockServer{}
+	p := newTestProvider(t, srv, WithVoiceID("narrator"))
+
+	if got := synthesizeAll(t, p, tts.VoiceProfile{}, "Hello."); !bytes.Equal(got, pcmFor("Hello.")) {
+		t.Errorf("PCM output = %v", got)
+	}
+	synthesizeAll(t, p, tts.VoiceProfile{Language: "de-AT"}, "Servus.")
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.requests) != 2 || srv.requests[0].Voice.ID != "narrator" || srv.requests[0].Language != defaultLanguage {
+		t.Errorf("requests = %+v, want voice narrator in the default language", srv.requests)
+	}
+	if srv.requests[1].Language != "de" {
+		t.Errorf("language = %q, want the voice language de", srv.requests[1].Language)
+	}
+
+	bare, err := New("k")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := bare.SynthesizeStream(context.Background(), make(chan string), tts.VoiceProfile{}); err == nil {
+		t.Error("SynthesizeStream without any voice: expected error")
+	}
+}
+
+func TestSynthesizeStream_GenerationError(t *testing.T) {
+	t.Parallel()
+
+	srv := &mockServer{fail: "Second."}
+	p := newTestProvider(t, srv)
+
+	textCh := make(chan string, 1)
+	textCh <- "First. Second. Third."
+	close(textCh)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := p.SynthesizeStream(ctx, textCh, tts.VoiceProfile{ID: "v"})
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+
+	// Audio up to the failed sentence is delivered, then the stream closes
+	// and reports the failure.
+	var got []byte
+	for chunk := range stream.Audio() {
+		got = append(got, chunk...)
+	}
+	if !bytes.Equal(got, pcmFor("First.")) {
+		t.Errorf("PCM output = %v, want only the first sentence", got)
+	}
+	if err := stream.Err(); err == nil || !strings.Contains(err.Error(), "generation failed") {
+		t.Errorf("Err() = %v, want the generation error", err)
+	}
+}
+
+func TestListVoices(t *testing.T) {
+	t.Parallel()
+
+	var afters []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != voicesPath || r.Header.Get("X-API-Key") != "test-key" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		after := r.URL.Query().Get("starting_after")
+		afters = append(afters, after)
+		if after == "" {
+			fmt.Fprint(w, `{"data":[{"id":"v1","name":"Barbershop Man","language":"en","gender":"masculine"}],"has_more":true}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"id":"v2","name":"Storyteller","description":"Warm and calm"}],"has_more":false}`)
+	}))
+	t.Cleanup(ts.Close)
+
+	p, err := New("test-key", WithBaseURL(ts.URL))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	voices, err := p.ListVoices(context.Background())
+	if err != nil {
+		t.Fatalf("ListVoices: %v", err)
+	}
+	if !slices.Equal(afters, []string{"", "v1"}) {
+		t.Errorf("starting_after values = %q", afters)
+	}
+	if len(voices) != 2 {
+		t.Fatalf("got %d voices, want 2", len(voices))
+	}
+	if v := voices[0]; v.ID != "v1" || v.Name != "Barbershop Man" || v.Provider != "cartesia" ||
+		v.Metadata["language"] != "en" || v.Metadata["gender"] != "masculine" {
+		t.Errorf("voice = %+v", v)
+	}
+	if d := voices[1].Metadata["description"]; d != "Warm and calm" {
+		t.Errorf("description = %q", d)
+	}
+	if _, ok := voices[1].Metadata["gender"]; ok {
+		t.Error("empty gender should be omitted from metadata")
+	}
+}
+
+func TestCloneVoice_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	p, err := New("k")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = p.CloneVoice(context.Background(), [][]byte{{1}})
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("CloneVoice error = %v, want not supported", err)
+	}
+}
@@ -15,7 +15,8 @@
 // Because both servers operate in batch mode (one HTTP call per utterance rather
 // than a streaming socket), SynthesizeStream accumulates incoming text fragments
 // into complete sentences and then dispatches concurrent HTTP requests with a
-// small lookahead buffer to minimise perceived latency.
+// small lookahead window to minimise perceived latency, emitting the audio in
+// the original sentence order (see package pipeline).
 //
 // Typical usage (standard server):
 //
@@ -25,7 +26,7 @@
 //	    // APIModeStandard is the default; this line is optional:
 //	    coqui.WithAPIMode(coqui.APIModeStandard),
 //	)
-//	audio, err := p.SynthesizeStream(ctx, textCh, voiceProfile)
+//	stream, err := p.SynthesizeStream(ctx, textCh, voiceProfile)
 //
 // Typical usage (XTTS v2 server):
 //
@@ -33,31 +34,50 @@
 //	    coqui.WithLanguage("en"),
 //	    coqui.WithAPIMode(coqui.APIModeXTTS),
 //	)
-//	audio, err := p.SynthesizeStream(ctx, textCh, voiceProfile)
+//	stream, err := p.SynthesizeStream(ctx, textCh, voiceProfile)
+//
+// Transient server failures, such as a 502 from a proxy in front of the
+// server, can be retried per sentence with [WithMaxRetries] and
+// [WithRetryBackoff]. A sentence that still fails ends the stream; its error
+// is reported by [tts.Stream.Err] and to the [WithErrorHandler] callback.
+//
+// Cloned XTTS voices tend to drift in timbre from one sentence to the next.
+// A voice whose [tts.VoiceProfile.Metadata] sets [tts.MetaSeedPhrase] has that
+// short reference phrase spoken before every sentence; the audio of the phrase
+// is trimmed from the output, so listeners only hear the sentence itself.
 package coqui
 
 import (
 	"bytes"
+	"cmp"
 	"context"
-	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
-	"unicode"
 
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/pipeline"
+	"github.com/MrWong99/glyphoxa/pkg/text/segment"
 )
 
-// Compile-time interface assertion.
-var _ tts.Provider = (*Provider)(nil)
+// Compile-time interface assertions.
+var (
+	_ tts.Provider        = (*Provider)(nil)
+	_ tts.FormatReporter  = (*Provider)(nil)
+	_ tts.ChannelReporter = (*Provider)(nil)
+)
 
 // ---- constants ----
 
@@ -70,16 +90,11 @@ const (
 	apiTTSEndpoint         = "/api/tts"
 	detailsEndpoint        = "/details"
 
-	// sentenceLookaheadBuf controls how many concurrent HTTP synthesis requests
-	// may be in-flight simultaneously. Higher values reduce perceived latency at
-	// the cost of additional server load.
-	sentenceLookaheadBuf = 4
+	// streamReadSize is the read buffer size used when streaming a WAV response.
+	streamReadSize = 4096
 
-	// audioChanBuf is the buffer depth of the returned audio channel.
-	audioChanBuf = 256
-
-	// pcmChunkSize is the size of each PCM chunk emitted on the audio channel.
-	pcmChunkSize = 4096
+	defaultRetryBackoff = 250 * time.Millisecond
+	maxRetryBackoff     = 5 * time.Second
 )
 
 // ---- APIMode ----
@@ -105,7 +120,8 @@ const (
 type Option func(*Provider)
 
 // WithLanguage sets the BCP-47 language code sent to the TTS server (e.g., "en",
-// "de", "fr"). Defaults to "en" if not set.
+// "de", "fr"). Defaults to "en" if not set. A non-empty
+// [tts.VoiceProfile.Language], reduced to its primary subtag, overrides it.
 func WithLanguage(lang string) Option {
 	return func(p *Provider) {
 		p.language = lang
@@ -129,36 +145,195 @@ func WithAPIMode(mode APIMode) Option {
 	}
 }
 
+// WithConcurrency sets how many sentence synthesis requests may be in flight
+// at the same time. Higher values reduce perceived latency at the cost of
+// additional server load; 1 disables lookahead. Defaults to
+// [pipeline.DefaultConcurrency]. Values < 1 are ignored.
+func WithConcurrency(n int) Option {
+	return func(p *Provider) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// WithMaxTextLength caps the characters sent in one synthesis request. Longer
+// sentences are split at clause or word boundaries and synthesised as several
+// requests in order. Values < 1, the default, disable splitting.
+func WithMaxTextLength(n int) Option {
+	return func(p *Provider) {
+		p.maxTextLength = n
+	}
+}
+
+// WithSegmenter sets how incoming text is split into sentences, one
+// synthesis request each. The default does not split after abbreviations
+// such as "Dr."; see [pipeline.WithSegmenter].
+func WithSegmenter(seg *segment.Segmenter) Option {
+	return func(p *Provider) {
+		p.segmenter = seg
+	}
+}
+
+// WithOutputSampleRate resamples the server's audio to hz before it is
+// emitted, so it can be played without a separate conversion step. Values < 1,
+// the default, emit the audio at the rate the server returns.
+func WithOutputSampleRate(hz int) Option {
+	return func(p *Provider) {
+		p.outputRate = max(hz, 0)
+	}
+}
+
+// WithOutputChannels sets the channel count of the emitted audio: 1 for mono
+// or 2 for stereo. Mono server audio is upmixed by duplicating each sample;
+// stereo is downmixed by averaging. Combine it with [WithOutputSampleRate] to
+// produce the 48 kHz stereo Discord expects:
+//
+//	coqui.New(url, coqui.WithOutputSampleRate(48000), coqui.WithOutputChannels(2))
+//
+// Other values, and the default 0, keep the server's channel count.
+func WithOutputChannels(n int) Option {
+	return func(p *Provider) {
+		if n == 1 || n == 2 {
+			p.outputChannels = n
+		}
+	}
+}
+
+// WithAdaptiveConcurrency replaces the fixed [WithConcurrency] lookahead with
+// one that adapts to the server. The provider measures how long recent
+// sentences took to synthesise and keeps between lo and hi requests in
+// flight: it backs off while the latency exceeds target and allows more
+// requests while the server keeps up. Measurements are shared by all
+// SynthesizeStream calls of the provider.
+//
+// A lo of 0 means 1 and a hi of 0 means the fixed concurrency. [New] fails if
+// target is not positive or hi is below lo.
+func WithAdaptiveConcurrency(lo, hi int, target time.Duration) Option {
+	return func(p *Provider) {
+		p.adaptiveCfg = &adaptiveConfig{lo: lo, hi: hi, target: target}
+	}
+}
+
+// WithFlushFirstSentence controls how the first sentence of each
+// SynthesizeStream call is delivered. When enabled, its PCM is forwarded as
+// soon as bytes arrive from the server instead of after the complete WAV
+// response, lowering time-to-first-audio at the cost of irregular chunk sizes
+// for that sentence. Later sentences are unaffected. Disabled by default.
+func WithFlushFirstSentence(enabled bool) Option {
+	return func(p *Provider) {
+		p.flushFirst = enabled
+	}
+}
+
+// WithMaxRetries sets how often a failed sentence synthesis request is
+// retried before the stream gives up. Only transient failures are retried:
+// network errors, truncated responses, and 5xx or 429 status codes. A
+// request whose audio has already been partly delivered (see
+// [WithFlushFirstSentence]) is never repeated. Defaults to 0, no retries.
+// Negative values are ignored.
+func WithMaxRetries(n int) Option {
+	return func(p *Provider) {
+		if n >= 0 {
+			p.maxRetries = n
+		}
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry of a failed
+// sentence. The delay doubles with every further retry, up to 5 s. Defaults
+// to 250 ms. Values <= 0 are ignored.
+func WithRetryBackoff(base time.Duration) Option {
+	return func(p *Provider) {
+		if base > 0 {
+			p.retryBackoff = base
+		}
+	}
+}
+
+// WithErrorHandler sets a callback that is invoked when a sentence could not
+// be synthesised, after all retries. It receives the sentence and the last
+// error, which [tts.Stream.Err] reports as well. It is not called when the
+// stream's context is cancelled.
+func WithErrorHandler(fn func(sentence string, err error)) Option {
+	return func(p *Provider) {
+		p.onError = fn
+	}
+}
+
 // ---- Provider ----
 
 // Provider implements tts.Provider backed by a locally-running Coqui TTS server.
 // It is safe for concurrent use; multiple SynthesizeStream calls may run in parallel.
 type Provider struct {
-	serverURL  string
-	language   string
-	httpClient *http.Client
-	apiMode    APIMode
+	serverURL      string
+	language       string
+	httpClient     *http.Client
+	apiMode        APIMode
+	concurrency    int
+	maxTextLength  int
+	segmenter      *segment.Segmenter // nil uses the pipeline's default
+	outputRate     int                // 0 keeps the server's sample rate
+	outputChannels int                // 0 keeps the server's channel count
+	flushFirst     bool
+	maxRetries     int
+	retryBackoff   time.Duration
+	onError        func(sentence string, err error) // may be nil
+
+	adaptiveCfg *adaptiveConfig    // set by WithAdaptiveConcurrency
+	adaptive    *pipeline.Adaptive // nil unless adaptive concurrency is enabled
+
+	seedMu sync.Mutex
+	seeds  map[seedKey]time.Duration // spoken length of each seed phrase
+}
+
+// seedKey identifies a seed phrase spoken by a particular voice.
+type seedKey struct {
+	voiceID, language, phrase string
+}
+
+// adaptiveConfig holds the bounds passed to [WithAdaptiveConcurrency].
+type adaptiveConfig struct {
+	lo, hi int
+	target time.Duration
 }
 
 // New creates a new Coqui Provider that targets the TTS server at serverURL
 // (e.g., "http://localhost:5002"). serverURL must be non-empty. Functional
-// options may override the language, per-request timeout, and API mode.
+// options may override the language, per-request timeout, API mode, and
+// synthesis concurrency. New returns an error if the bounds passed to
+// [WithAdaptiveConcurrency] are invalid.
 // The default API mode is APIModeStandard.
 func New(serverURL string, opts ...Option) (*Provider, error) {
 	if serverURL == "" {
 		return nil, errors.New("coqui: serverURL must not be empty")
 	}
 	p := &Provider{
-		serverURL: strings.TrimRight(serverURL, "/"),
-		language:  defaultLanguage,
-		apiMode:   APIModeStandard,
+		serverURL:    strings.TrimRight(serverURL, "/"),
+		language:     defaultLanguage,
+		apiMode:      APIModeStandard,
+		concurrency:  pipeline.DefaultConcurrency,
+		retryBackoff: defaultRetryBackoff,
 		httpClient: &http.Client{
-			Timeout: defaultTimeout,
+			Timeout:   defaultTimeout,
+			Transport: ident.Transport(nil),
 		},
+		seeds: make(map[seedKey]time.Duration),
 	}
 	for _, o := range opts {
 		o(p)
 	}
+	if cfg := p.adaptiveCfg; cfg != nil {
+		lo := cmp.Or(cfg.lo, 1)
+		hi := cmp.Or(cfg.hi, p.concurrency)
+		switch {
+		case cfg.target <= 0:
+			return nil, fmt.Errorf("coqui: adaptive concurrency target latency must be positive, got %s", cfg.target)
+		case lo < 1 || hi < lo:
+			return nil, fmt.Errorf("coqui: invalid adaptive concurrency bounds %d..%d", lo, hi)
+		}
+		p.adaptive = pipeline.NewAdaptive(lo, hi, cfg.target)
+	}
 	return p, nil
 }
 
@@ -171,12 +346,6 @@ type ttsRequest struct {
 	Language   string `json:"language"`
 }
 
-// audioResult carries a synthesised PCM byte slice or an error from a worker goroutine.
-type audioResult struct {
-	pcm []byte
-	err error
-}
-
 // studioSpeakersResponse represents the raw map[name]any returned by GET /studio_speakers.
 // We only care about the keys (voice names) so the values are left as json.RawMessage.
 type studioSpeakersResponse map[string]json.RawMessage
@@ -197,239 +366,377 @@ type detailsResponse struct {
 
 // ---- SynthesizeStream ----
 
+// SampleRate returns the sample rate in Hz set with [WithOutputSampleRate],
+// or 0 if the audio keeps the server's rate.
+func (p *Provider) SampleRate() int { return p.outputRate }
+
+// Channels returns the channel count set with [WithOutputChannels], or 0 if
+// the audio keeps the server's channel count.
+func (p *Provider) Channels() int { return p.outputChannels }
+
 // SynthesizeStream consumes text fragments from the text channel, accumulates them
 // into complete sentences (split on '.', '!', '?' followed by whitespace or EOF),
 // and for each sentence issues an HTTP synthesis request to the Coqui server.
 // WAV responses are stripped of their file headers and the raw PCM is emitted on
-// the returned channel in the original sentence order.
+// the stream's audio channel in the original sentence order.
 //
-// Up to sentenceLookaheadBuf HTTP requests may be in-flight concurrently to hide
-// network/server latency while preserving output ordering.
+// Up to the configured concurrency (see [WithConcurrency] and
+// [WithAdaptiveConcurrency]) HTTP requests may be in-flight at once to hide
+// network/server latency while preserving output ordering. Sentence splitting and ordered dispatch are provided by
+// [pipeline.Pipeline].
 //
-// The returned channel is closed when all text has been synthesised or when ctx
-// is cancelled. The caller must drain the channel to prevent goroutine leaks.
-func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (<-chan []byte, error) {
+// The audio channel is closed when all text has been synthesised, when a
+// sentence fails after its retries (see [WithMaxRetries]), or when ctx is
+// cancelled; [tts.Stream.Err] reports the failure. The caller must drain the
+// channel to prevent goroutine leaks.
+func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	return p.synthesizeStream(ctx, text, voice, false)
+}
+
+// SynthesizeMarkupStream implements [tts.MarkupSynthesizer]. Coqui has no
+// markup of its own, so the tags are stripped from every sentence before it
+// is sent to the server; punctuation inside a tag never splits a sentence.
+// Sentences consisting only of markup produce no request.
+func (p *Provider) SynthesizeMarkupStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	return p.synthesizeStream(ctx, text, voice, true)
+}
+
+// synthesizeStream implements SynthesizeStream and SynthesizeMarkupStream.
+func (p *Provider) synthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile, markup bool) (*tts.Stream, error) {
 	// XTTS mode always requires a voice ID (speaker_wav). Standard mode works
 	// without one for single-speaker models, so only enforce the check for XTTS.
 	if voice.ID == "" && p.apiMode == APIModeXTTS {
 		return nil, errors.New("coqui: voice.ID must not be empty (required for XTTS mode)")
 	}
 
-	audioCh := make(chan []byte, audioChanBuf)
-
-	go func() {
-		defer close(audioCh)
-
-		// sentences carries complete sentences from the accumulator to the dispatcher.
-		sentences := make(chan string, sentenceLookaheadBuf)
-
-		// resultQueue carries ordered future channels so the collector can drain in order.
-		resultQueue := make(chan chan audioResult, sentenceLookaheadBuf)
-
-		// --- Accumulator goroutine ---
-		// Reads text fragments, buffers them, and emits complete sentences.
-		go func() {
-			defer close(sentences)
-			var buf strings.Builder
-			for {
-				select {
-				case fragment, ok := <-text:
-					if !ok {
-						// Text channel closed: flush any remaining partial sentence.
-						if remaining := strings.TrimSpace(buf.String()); remaining != "" {
-							select {
-							case sentences <- remaining:
-							case <-ctx.Done():
-							}
-						}
-						return
-					}
-					buf.WriteString(fragment)
-					// Drain all complete sentences from the buffer.
-					for {
-						s := buf.String()
-						idx := findSentenceBoundary(s)
-						if idx < 0 {
-							break
-						}
-						sentence := strings.TrimSpace(s[:idx+1])
-						buf.Reset()
-						buf.WriteString(s[idx+1:])
-						if sentence == "" {
-							continue
-						}
-						select {
-						case sentences <- sentence:
-						case <-ctx.Done():
-							return
-						}
-					}
-				case <-ctx.Done():
-					return
-				}
-			}
-		}()
-
-		// --- Dispatcher goroutine ---
-		// Reads sentences and launches a concurrent HTTP request for each, placing
-		// an ordered result channel into resultQueue so the collector can drain in order.
-		go func() {
-			defer close(resultQueue)
-			for {
-				select {
-				case sentence, ok := <-sentences:
-					if !ok {
-						return
-					}
-					ch := make(chan audioResult, 1)
-					select {
-					case resultQueue <- ch:
-					case <-ctx.Done():
-						return
-					}
-					// Launch the HTTP call in its own goroutine.
-					go func(s string, out chan<- audioResult) {
-						pcm, err := p.synthesize(ctx, s, voice)
-						out <- audioResult{pcm: pcm, err: err}
-					}(sentence, ch)
-				case <-ctx.Done():
-					return
-				}
+	opts := []pipeline.Option{
+		pipeline.WithConcurrency(p.concurrency),
+		pipeline.WithAdaptiveConcurrency(p.adaptive),
+		pipeline.WithMaxSentenceLength(p.maxTextLength),
+		pipeline.WithMarkup(markup),
+		pipeline.WithSegmenter(p.segmenter),
+	}
+	if p.onError != nil {
+		opts = append(opts, pipeline.WithErrorHandler(p.onError))
+	}
+	// prepare returns the text to send for sentence, or "" to skip it.
+	prepare := func(sentence string) string {
+		if markup {
+			return strings.TrimSpace(tts.StripMarkup(sentence))
+		}
+		return sentence
+	}
+	if p.flushFirst {
+		opts = append(opts, pipeline.WithFirstSentenceStream(func(ctx context.Context, sentence string, emit func([]byte) bool) error {
+			if sentence = prepare(sentence); sentence == "" {
+				return nil
 			}
-		}()
-
-		// --- Collector ---
-		// Drains resultQueue in-order and emits PCM chunks to the audio channel.
-		for {
-			select {
-			case ch, ok := <-resultQueue:
-				if !ok {
-					return
-				}
-				select {
-				case result := <-ch:
-					if result.err != nil {
-						// On synthesis error we stop the stream. The caller can
-						// inspect ctx.Err() to distinguish cancellation from provider errors.
-						return
-					}
-					// Emit the PCM in fixed-size chunks.
-					pcm := result.pcm
-					for len(pcm) > 0 {
-						end := min(pcmChunkSize, len(pcm))
-						select {
-						case audioCh <- pcm[:end]:
-						case <-ctx.Done():
-							return
-						}
-						pcm = pcm[end:]
-					}
-				case <-ctx.Done():
-					return
-				}
-			case <-ctx.Done():
-				return
+			// Audio that has been played cannot be taken back, so only
+			// retry while nothing has been emitted.
+			var emitted bool
+			return p.retry(ctx, func() (bool, error) {
+				err := p.synthesizeStreaming(ctx, sentence, voice, func(piece []byte) bool {
+					emitted = true
+					return emit(piece)
+				})
+				return !emitted, err
+			})
+		}))
+	}
+	pl := pipeline.New(func(ctx context.Context, sentence string) ([]byte, error) {
+		if sentence = prepare(sentence); sentence == "" {
+			return nil, nil
+		}
+		var pcm []byte
+		err := p.retry(ctx, func() (bool, error) {
+			var err error
+			pcm, err = p.synthesize(ctx, sentence, voice)
+			return true, err
+		})
+		return pcm, err
+	}, opts...)
+	return pl.Run(ctx, text), nil
+}
+
+// retry calls attempt until it succeeds, its error is not transient, attempt
+// reports that it must not be repeated, or [WithMaxRetries] retries have
+// been made. Retries are delayed with exponential backoff starting at
+// [WithRetryBackoff]; cancelling ctx stops waiting and returns the last error.
+func (p *Provider) retry(ctx context.Context, attempt func() (repeatable bool, err error)) error {
+	delay := p.retryBackoff
+	for n := 0; ; n++ {
+		repeatable, err := attempt()
+		if err == nil || !repeatable || !transient(err) || ctx.Err() != nil {
+			return err
+		}
+		if n == p.maxRetries {
+			if n > 0 {
+				return fmt.Errorf("coqui: giving up after %d attempts: %w", n+1, err)
 			}
+			return err
 		}
-	}()
+		slog.Warn("coqui: synthesis failed, retrying", "attempt", n+1, "delay", delay, "err", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		delay = min(delay*2, maxRetryBackoff)
+	}
+}
 
-	return audioCh, nil
+// statusError reports a synthesis request answered with a status other than
+// 200 OK.
+type statusError struct {
+	label string // request method and endpoint
+	code  int
 }
 
-// synthesize dispatches to the appropriate implementation based on the configured
-// API mode.
-func (p *Provider) synthesize(ctx context.Context, sentence string, voice tts.VoiceProfile) ([]byte, error) {
-	if p.apiMode == APIModeStandard {
-		return p.synthesizeStandard(ctx, sentence, voice)
+func (e *statusError) Error() string {
+	return fmt.Sprintf("coqui: %s returned status %d", e.label, e.code)
+}
+
+// transient reports whether err may go away when the request is repeated:
+// anything but a cancelled context or a 4xx status other than 429.
+func transient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= 500 || se.code == http.StatusTooManyRequests
 	}
-	return p.synthesizeXTTS(ctx, sentence, voice)
+	return true
 }
 
-// synthesizeXTTS performs a single POST /tts_to_audio/ call (XTTS v2 mode) and
-// returns the raw PCM (WAV header stripped).
-func (p *Provider) synthesizeXTTS(ctx context.Context, sentence string, voice tts.VoiceProfile) ([]byte, error) {
-	body := ttsRequest{
-		Text:       sentence,
-		SpeakerWav: voice.ID,
-		Language:   p.language,
+// synthesize performs a single synthesis request and returns the raw PCM
+// (WAV header stripped) once the complete response has arrived. The audio of
+// the voice's seed phrase, if any, is trimmed from the start.
+func (p *Provider) synthesize(ctx context.Context, sentence string, voice tts.VoiceProfile) ([]byte, error) {
+	text, trim, err := p.seeded(ctx, sentence, voice)
+	if err != nil {
+		return nil, err
 	}
-	data, err := json.Marshal(body)
+	pcm, info, err := p.synthesizeWAV(ctx, text, voice)
 	if err != nil {
-		return nil, fmt.Errorf("coqui: marshal tts request: %w", err)
+		return nil, err
 	}
+	return p.convert(pcm[min(pcmBytes(trim, info), len(pcm)):], info), nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.serverURL+ttsEndpoint, bytes.NewReader(data))
-	if err != nil {
-		return nil, fmt.Errorf("coqui: create tts request: %w", err)
+// convert converts pcm from the server's format, described by info, to the
+// format set with [WithOutputSampleRate] and [WithOutputChannels]. Audio
+// that is not 16-bit PCM is returned unchanged.
+func (p *Provider) convert(pcm []byte, info audio.WAVInfo) []byte {
+	if info.BitsPerSample != 16 {
+		return pcm
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "audio/wav")
+	return audio.Resample(pcm, info.SampleRate, cmp.Or(p.outputRate, info.SampleRate),
+		info.Channels, cmp.Or(p.outputChannels, info.Channels))
+}
 
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("coqui: POST %s: %w", ttsEndpoint, err)
+// streamConverter returns functions that convert consecutive pieces of PCM in
+// the server's format, described by info, like [Provider.convert] converts
+// all of it at once, and return the rest of the output at the end.
+func (p *Provider) streamConverter(info audio.WAVInfo) (convert func([]byte) []byte, flush func() []byte) {
+	if info.BitsPerSample != 16 {
+		return func(pcm []byte) []byte { return pcm }, func() []byte { return nil }
 	}
-	defer resp.Body.Close()
+	r := audio.NewStreamResampler(info.SampleRate, cmp.Or(p.outputRate, info.SampleRate),
+		info.Channels, cmp.Or(p.outputChannels, info.Channels))
+	return r.Write, r.Flush
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("coqui: POST %s returned status %d", ttsEndpoint, resp.StatusCode)
+// synthesizeWAV performs a single synthesis request for text and returns the
+// raw PCM (WAV header stripped) together with its format.
+func (p *Provider) synthesizeWAV(ctx context.Context, text string, voice tts.VoiceProfile) ([]byte, audio.WAVInfo, error) {
+	body, err := p.fetch(ctx, text, voice)
+	if err != nil {
+		return nil, audio.WAVInfo{}, err
 	}
+	defer body.Close()
 
-	wav, err := io.ReadAll(resp.Body)
+	wav, err := io.ReadAll(body)
 	if err != nil {
-		return nil, fmt.Errorf("coqui: read WAV response: %w", err)
+		return nil, audio.WAVInfo{}, fmt.Errorf("coqui: read WAV response: %w", err)
 	}
 
-	info, err := parseWAV(wav)
+	info, err := audio.ParseWAV(wav)
 	if err != nil {
-		return nil, err
+		return nil, audio.WAVInfo{}, fmt.Errorf("coqui: %w", err)
 	}
+	return wav[info.DataOffset:], info, nil
+}
 
-	pcm := wav[info.DataOffset:]
-	return pcm, nil
+// seeded returns the text to synthesise for sentence and how much audio to
+// trim from the start of the result. Without a seed phrase these are sentence
+// itself and zero. With one, the phrase is prepended and its spoken length is
+// measured by synthesising it on its own once per voice and language.
+func (p *Provider) seeded(ctx context.Context, sentence string, voice tts.VoiceProfile) (string, time.Duration, error) {
+	phrase := strings.TrimSpace(voice.Metadata[tts.MetaSeedPhrase])
+	if phrase == "" {
+		return sentence, 0, nil
+	}
+	key := seedKey{voiceID: voice.ID, language: voice.Language, phrase: phrase}
+
+	p.seedMu.Lock()
+	trim, ok := p.seeds[key]
+	p.seedMu.Unlock()
+	if !ok {
+		pcm, info, err := p.synthesizeWAV(ctx, phrase, voice)
+		if err != nil {
+			return "", 0, fmt.Errorf("coqui: synthesize seed phrase: %w", err)
+		}
+		trim = pcmDuration(len(pcm), info)
+		p.seedMu.Lock()
+		p.seeds[key] = trim
+		p.seedMu.Unlock()
+	}
+	return phrase + " " + sentence, trim, nil
 }
 
-// synthesizeStandard performs a single GET /api/tts request (standard server mode)
-// using URL query parameters and returns the raw PCM (WAV header stripped).
-func (p *Provider) synthesizeStandard(ctx context.Context, sentence string, voice tts.VoiceProfile) ([]byte, error) {
-	params := url.Values{}
-	params.Set("text", sentence)
-	if voice.ID != "" {
-		params.Set("speaker_id", voice.ID)
+// synthesizeStreaming performs a single synthesis request like [Provider.synthesize]
+// but calls emit with PCM as soon as it arrives on the wire. Pieces hold whole
+// sample frames, except possibly the last one of a truncated response. The
+// pieces are converted to the output format by one [audio.StreamResampler],
+// so together they equal the audio synthesize returns.
+func (p *Provider) synthesizeStreaming(ctx context.Context, sentence string, voice tts.VoiceProfile, emit func([]byte) bool) error {
+	text, trim, err := p.seeded(ctx, sentence, voice)
+	if err != nil {
+		return err
 	}
-	if p.language != "" {
-		params.Set("language_id", p.language)
+	body, err := p.fetch(ctx, text, voice)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var (
+		buf      []byte              // header bytes until the data chunk is found, then partial frames
+		info     audio.WAVInfo       // server audio format, valid once frame > 0
+		frame    int                 // bytes per sample frame; 0 until the header is parsed
+		conv     func([]byte) []byte // converts pieces to the output format
+		flush    func() []byte       // returns the converter's remaining output
+		skip     int                 // seed phrase bytes still to be dropped
+		readBuf  = make([]byte, streamReadSize)
+		parseErr error
+	)
+	for {
+		n, err := body.Read(readBuf)
+		buf = append(buf, readBuf[:n]...)
+
+		if frame == 0 && n > 0 {
+			info, parseErr = audio.ParseWAV(buf)
+			if parseErr == nil {
+				frame = max(info.Channels*info.BitsPerSample/8, 1)
+				skip = pcmBytes(trim, info)
+				buf = buf[info.DataOffset:]
+				conv, flush = p.streamConverter(info)
+			}
+		}
+		if skip > 0 {
+			n := min(skip, len(buf))
+			buf = buf[n:]
+			skip -= n
+		}
+		if frame > 0 {
+			whole := len(buf) - len(buf)%frame
+			if whole > 0 {
+				piece := conv(bytes.Clone(buf[:whole]))
+				buf = buf[whole:]
+				if len(piece) > 0 && !emit(piece) {
+					return ctx.Err()
+				}
+			}
+		}
+
+		if err == io.EOF {
+			if frame == 0 {
+				return fmt.Errorf("coqui: %w", parseErr)
+			}
+			// Pass on a truncated trailing frame as synthesize would: unchanged
+			// without conversion, dropped with it.
+			if piece := append(conv(buf), flush()...); len(piece) > 0 {
+				emit(piece)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("coqui: read WAV response: %w", err)
+		}
 	}
+}
 
-	reqURL := p.serverURL + apiTTSEndpoint + "?" + params.Encode()
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+// fetch sends the synthesis request for sentence, using the endpoint of the
+// configured API mode, and returns the WAV response body. The caller must
+// close it.
+func (p *Provider) fetch(ctx context.Context, sentence string, voice tts.VoiceProfile) (io.ReadCloser, error) {
+	req, err := p.newRequest(ctx, sentence, voice)
 	if err != nil {
-		return nil, fmt.Errorf("coqui: create tts request: %w", err)
+		return nil, err
+	}
+	label := http.MethodPost + " " + ttsEndpoint
+	if p.apiMode == APIModeStandard {
+		label = http.MethodGet + " " + apiTTSEndpoint
 	}
-	req.Header.Set("Accept", "audio/wav")
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("coqui: GET %s: %w", apiTTSEndpoint, err)
+		return nil, fmt.Errorf("coqui: %s: %w", label, err)
 	}
-	defer resp.Body.Close()
-
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("coqui: GET %s returned status %d", apiTTSEndpoint, resp.StatusCode)
+		resp.Body.Close()
+		return nil, &statusError{label: label, code: resp.StatusCode}
 	}
+	return resp.Body, nil
+}
 
-	wav, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("coqui: read WAV response: %w", err)
+// newRequest builds the synthesis request: POST /tts_to_audio/ with a JSON
+// body in XTTS mode, GET /api/tts with query parameters in standard mode.
+func (p *Provider) newRequest(ctx context.Context, sentence string, voice tts.VoiceProfile) (*http.Request, error) {
+	language := p.language
+	if voice.Language != "" {
+		language = tts.BaseLanguage(voice.Language)
+	}
+	if p.apiMode == APIModeStandard {
+		params := url.Values{}
+		params.Set("text", sentence)
+		if voice.ID != "" {
+			params.Set("speaker_id", voice.ID)
+		}
+		if language != "" {
+			params.Set("language_id", language)
+		}
+
+		reqURL := p.serverURL + apiTTSEndpoint + "?" + params.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("coqui: create tts request: %w", err)
+		}
+		req.Header.Set("Accept", "audio/wav")
+		return req, nil
 	}
 
-	info, err := parseWAV(wav)
+	body := ttsRequest{
+		Text:       sentence,
+		SpeakerWav: voice.ID,
+		Language:   language,
+	}
+	data, err := json.Marshal(body)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("coqui: marshal tts request: %w", err)
 	}
 
-	pcm := wav[info.DataOffset:]
-	return pcm, nil
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.serverURL+ttsEndpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("coqui: create tts request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "audio/wav")
+	return req, nil
 }
 
 // ---- ListVoices ----
@@ -634,90 +941,31 @@ func (p *Provider) CloneVoice(ctx context.Context, samples [][]byte) (*tts.Voice
 
 // ---- helpers ----
 
-// findSentenceBoundary returns the index of the first sentence-ending character
-// ('.', '!', '?') that is either at the end of s or immediately followed by
-// whitespace. Returns -1 if no sentence boundary is found.
-//
-// This ensures that abbreviations like "Dr." or decimal numbers like "3.14" are
-// not incorrectly treated as sentence boundaries when followed by a non-space
-// character.
-func findSentenceBoundary(s string) int {
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if c == '.' || c == '!' || c == '?' {
-			// Boundary: end of string or followed by whitespace.
-			if i+1 >= len(s) || unicode.IsSpace(rune(s[i+1])) {
-				return i
-			}
-		}
+// pcmDuration returns the playback length of n bytes of PCM in the format of
+// info.
+func pcmDuration(n int, info audio.WAVInfo) time.Duration {
+	rate := info.SampleRate * info.Channels * info.BitsPerSample / 8
+	if rate <= 0 {
+		return 0
 	}
-	return -1
+	return time.Duration(n) * time.Second / time.Duration(rate)
 }
 
-// wavInfo holds the format metadata extracted from a RIFF/WAVE header.
-type wavInfo struct {
-	DataOffset int // byte offset of the first PCM sample
-	SampleRate int // samples per second (e.g., 22050, 44100, 48000)
-	Channels   int // 1 = mono, 2 = stereo
-}
-
-// parseWAV scans the RIFF/WAVE container in wav and returns the data offset
-// and audio format from the "fmt " sub-chunk. This is more robust than
-// hardcoding a fixed 44-byte offset because the fmt chunk size may vary.
-//
-// Returns an error if wav is not a valid RIFF/WAVE container or if the fmt
-// or data chunk cannot be located.
-func parseWAV(wav []byte) (wavInfo, error) {
-	if len(wav) < 12 {
-		return wavInfo{}, errors.New("coqui: WAV response too short to be a valid RIFF file")
-	}
-	if string(wav[0:4]) != "RIFF" {
-		return wavInfo{}, errors.New("coqui: WAV response missing RIFF header")
-	}
-	if string(wav[8:12]) != "WAVE" {
-		return wavInfo{}, errors.New("coqui: WAV response missing WAVE identifier")
-	}
-
-	var info wavInfo
-	foundFmt := false
-
-	// Walk RIFF chunks starting immediately after the 12-byte RIFF/WAVE header.
-	offset := 12
-	for offset+8 <= len(wav) {
-		chunkID := string(wav[offset : offset+4])
-		chunkSize := int(binary.LittleEndian.Uint32(wav[offset+4 : offset+8]))
-
-		switch chunkID {
-		case "fmt ":
-			if chunkSize >= 16 && offset+8+16 <= len(wav) {
-				fmtData := wav[offset+8:]
-				info.Channels = int(binary.LittleEndian.Uint16(fmtData[2:4]))
-				info.SampleRate = int(binary.LittleEndian.Uint32(fmtData[4:8]))
-				foundFmt = true
-			}
-		case "data":
-			info.DataOffset = offset + 8
-			if !foundFmt {
-				// fmt chunk should appear before data, but be defensive.
-				info.SampleRate = 22050
-				info.Channels = 1
-			}
-			return info, nil
-		}
-
-		// Advance past this chunk (chunks are word-aligned: pad by 1 if odd size).
-		offset += 8 + chunkSize
-		if chunkSize%2 != 0 {
-			offset++
-		}
+// pcmBytes returns the number of bytes of PCM in the format of info that play
+// for d, rounded to whole sample frames.
+func pcmBytes(d time.Duration, info audio.WAVInfo) int {
+	frame := info.Channels * info.BitsPerSample / 8
+	if d <= 0 || frame <= 0 {
+		return 0
 	}
-	return wavInfo{}, errors.New("coqui: WAV response missing data chunk")
+	frames := int((d*time.Duration(info.SampleRate) + time.Second/2) / time.Second)
+	return frames * frame
 }
 
-// findWAVDataOffset is a convenience wrapper around parseWAV that returns only
-// the data offset. Retained for backward compatibility with tests.
+// findWAVDataOffset is a convenience wrapper around [audio.ParseWAV] that
+// returns only the data offset. Retained for backward compatibility with tests.
 func findWAVDataOffset(wav []byte) (int, error) {
-	info, err := parseWAV(wav)
+	info, err := audio.ParseWAV(wav)
 	if err != nil {
 		return 0, err
 	}
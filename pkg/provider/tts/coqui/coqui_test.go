@@ -1,18 +1,23 @@
 package coqui
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"fmt"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/text/segment"
 )
 
 // ---- test helpers ----
@@ -66,11 +71,11 @@ func buildTestWAV(pcm []byte) []byte {
 	return buf
 }
 
-// drainAudio reads all []byte chunks from the audio channel until it is closed
-// and returns the concatenated PCM data.
-func drainAudio(ch <-chan []byte) []byte {
+// drainAudio reads all []byte chunks from the stream's audio channel until it
+// is closed and returns the concatenated PCM data.
+func drainAudio(stream *tts.Stream) []byte {
 	var out []byte
-	for chunk := range ch {
+	for chunk := range stream.Audio() {
 		out = append(out, chunk...)
 	}
 	return out
@@ -162,7 +167,7 @@ func TestSynthesizeStream_EmptyVoiceID_Standard(t *testing.T) {
 		t.Fatalf("standard mode should accept empty voice ID, got error: %v", err)
 	}
 	if ch == nil {
-		t.Fatal("expected non-nil channel")
+		t.Fatal("expected non-nil stream")
 	}
 }
 
@@ -208,12 +213,16 @@ func TestSynthesizeStream_MockServer(t *testing.T) {
 	// Send two complete sentences.
 	textCh := sendFragments([]string{"Hello world. ", "Goodbye now!"})
 
-	audioCh, err := p.SynthesizeStream(context.Background(), textCh, voice)
+	stream, err := p.SynthesizeStream(context.Background(), textCh, voice)
 	if err != nil {
 		t.Fatalf("SynthesizeStream: unexpected error: %v", err)
 	}
 
-	pcm := drainAudio(audioCh)
+	pcm := drainAudio(stream)
+
+	if err := stream.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
 
 	// Expect two sentences × 100 PCM bytes each = 200 bytes.
 	wantTotal := 2 * len(wantPCM)
@@ -265,14 +274,14 @@ func TestSynthesizeStream_ContextCancellation(t *testing.T) {
 
 	textCh := sendFragments([]string{"This sentence should not be synthesised."})
 
-	audioCh, err := p.SynthesizeStream(ctx, textCh, voice)
+	stream, err := p.SynthesizeStream(ctx, textCh, voice)
 	if err != nil {
 		t.Fatalf("SynthesizeStream: unexpected error: %v", err)
 	}
 
 	done := make(chan struct{})
 	go func() {
-		drainAudio(audioCh)
+		drainAudio(stream)
 		close(done)
 	}()
 
@@ -296,52 +305,23 @@ func TestSynthesizeStream_ServerError(t *testing.T) {
 
 	textCh := sendFragments([]string{"A sentence."})
 
-	audioCh, err := p.SynthesizeStream(context.Background(), textCh, voice)
+	stream, err := p.SynthesizeStream(context.Background(), textCh, voice)
 	if err != nil {
 		t.Fatalf("SynthesizeStream start unexpected error: %v", err)
 	}
 
-	pcm := drainAudio(audioCh)
+	pcm := drainAudio(stream)
 	if len(pcm) != 0 {
 		t.Errorf("expected empty audio on server error, got %d bytes", len(pcm))
 	}
+	var se *statusError
+	if err := stream.Err(); !errors.As(err, &se) || se.code != http.StatusInternalServerError {
+		t.Errorf("Err() = %v, want status %d", err, http.StatusInternalServerError)
+	}
 }
 
 // ---- Sentence accumulation ----
 
-func TestFindSentenceBoundary(t *testing.T) {
-	tests := []struct {
-		name  string
-		input string
-		want  int
-	}{
-		{"period at end", "Hello.", 5},
-		{"period space", "Hello. World", 5},
-		{"exclamation", "Hello!", 5},
-		{"question", "Hello?", 5},
-		{"no boundary", "Hello", -1},
-		// NOTE: "Dr." followed by a space IS treated as a sentence boundary by this
-		// simple algorithm (abbreviation-awareness is out of scope for this provider).
-		{"abbreviation mid", "Dr. Smith", 2},
-		// '.' in "3.14" is followed by '1', not whitespace — not a boundary.
-		{"decimal", "3.14 is pi", -1},
-		{"empty", "", -1},
-		{"multiple", "First. Second.", 5},
-		{"question mid", "How? Great!", 3},
-	}
-
-	// Note: "Dr. Smith" — the '.' IS followed by a space, so it IS a boundary.
-	// Adjust the test to reflect actual behaviour.
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := findSentenceBoundary(tt.input)
-			if got != tt.want {
-				t.Errorf("findSentenceBoundary(%q) = %d, want %d", tt.input, got, tt.want)
-			}
-		})
-	}
-}
-
 // TestSentenceAccumulation verifies that fragments are assembled into sentences
 // before dispatching HTTP requests, by checking what the mock server receives.
 func TestSentenceAccumulation(t *testing.T) {
@@ -373,11 +353,11 @@ func TestSentenceAccumulation(t *testing.T) {
 		"Hello ", "world. ", "Are ", "you ", "there?",
 	})
 
-	audioCh, err := p.SynthesizeStream(context.Background(), textCh, voice)
+	stream, err := p.SynthesizeStream(context.Background(), textCh, voice)
 	if err != nil {
 		t.Fatalf("SynthesizeStream: %v", err)
 	}
-	drainAudio(audioCh)
+	drainAudio(stream)
 
 	if len(receivedTexts) != 2 {
 		t.Fatalf("server received %d requests, want 2; got: %v", len(receivedTexts), receivedTexts)
@@ -396,6 +376,92 @@ func TestSentenceAccumulation(t *testing.T) {
 	}
 }
 
+func TestSynthesizeStream_Segmenter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{name: "default keeps titles", want: []string{"Ask Dr. Smith.", "Then go."}},
+		{name: "simple", opts: []Option{WithSegmenter(segment.Simple())}, want: []string{"Ask Dr.", "Smith.", "Then go."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				received []string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req ttsRequest
+				_ = json.NewDecoder(r.Body).Decode(&req)
+				mu.Lock()
+				received = append(received, req.Text)
+				mu.Unlock()
+				w.Header().Set("Content-Type", "audio/wav")
+				_, _ = w.Write(buildTestWAV([]byte{0x01, 0x02}))
+			}))
+			defer srv.Close()
+
+			p := mustNew(t, srv.URL, append(tt.opts, WithAPIMode(APIModeXTTS), WithConcurrency(1))...)
+			stream, err := p.SynthesizeStream(context.Background(), sendFragments([]string{"Ask Dr. ", "Smith. Then go."}), tts.VoiceProfile{ID: "spk"})
+			if err != nil {
+				t.Fatalf("SynthesizeStream: %v", err)
+			}
+			drainAudio(stream)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !slices.Equal(received, tt.want) {
+				t.Errorf("server received %q, want %q", received, tt.want)
+			}
+		})
+	}
+}
+
+func TestSynthesizeMarkupStream(t *testing.T) {
+	wavData := buildTestWAV([]byte{0x01, 0x02})
+
+	var (
+		mu            sync.Mutex
+		receivedTexts []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req ttsRequest
+		_ = json.Unmarshal(body, &req)
+		mu.Lock()
+		receivedTexts = append(receivedTexts, req.Text)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "audio/wav")
+		_, _ = w.Write(wavData)
+	}))
+	defer srv.Close()
+
+	p := mustNew(t, srv.URL, WithAPIMode(APIModeXTTS), WithConcurrency(1))
+	voice := tts.VoiceProfile{ID: "spk"}
+
+	// The '.' inside the break tag ends a fragment and must not split the
+	// sentence; the break on its own must not become a request.
+	textCh := sendFragments([]string{
+		`<emphasis level="strong">Halt!</emphasis> Who goes <break time="0.`,
+		`5s"/>there? `, `<break time="1s"/>`,
+	})
+
+	stream, err := p.SynthesizeMarkupStream(context.Background(), textCh, voice)
+	if err != nil {
+		t.Fatalf("SynthesizeMarkupStream: %v", err)
+	}
+	drainAudio(stream)
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"Halt!", "Who goes there?"}
+	if !slices.Equal(receivedTexts, want) {
+		t.Errorf("server received %q, want %q", receivedTexts, want)
+	}
+}
+
 // ---- ListVoices ----
 
 func TestListVoices(t *testing.T) {
@@ -637,12 +703,12 @@ func TestSynthesizeStream_StandardAPI(t *testing.T) {
 
 	textCh := sendFragments([]string{"Hello world."})
 
-	audioCh, err := p.SynthesizeStream(context.Background(), textCh, voice)
+	stream, err := p.SynthesizeStream(context.Background(), textCh, voice)
 	if err != nil {
 		t.Fatalf("SynthesizeStream: unexpected error: %v", err)
 	}
 
-	pcm := drainAudio(audioCh)
+	pcm := drainAudio(stream)
 
 	if len(pcm) != len(wantPCM) {
 		t.Errorf("total PCM bytes = %d, want %d", len(pcm), len(wantPCM))
@@ -813,3 +879,592 @@ func TestNew_WithAPIMode(t *testing.T) {
 		t.Errorf("apiMode = %q, want %q", p.apiMode, APIModeXTTS)
 	}
 }
+
+// TestSynthesizeStream_ConcurrencyOne verifies that WithConcurrency(1) issues
+// synthesis requests strictly one at a time while preserving sentence order.
+func TestSynthesizeStream_ConcurrencyOne(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxSeen  int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxSeen = max(maxSeen, inFlight)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "audio/wav")
+		_, _ = w.Write(buildTestWAV([]byte(r.URL.Query().Get("text")[:1])))
+	}))
+	defer srv.Close()
+
+	p := mustNew(t, srv.URL, WithConcurrency(1))
+	if p.concurrency != 1 {
+		t.Fatalf("concurrency = %d, want 1", p.concurrency)
+	}
+
+	stream, err := p.SynthesizeStream(context.Background(), sendFragments([]string{"A one. B two. C three."}), tts.VoiceProfile{})
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	if got := string(drainAudio(stream)); got != "ABC" {
+		t.Errorf("audio = %q, want %q", got, "ABC")
+	}
+	if maxSeen != 1 {
+		t.Errorf("max concurrent requests = %d, want 1", maxSeen)
+	}
+}
+
+// TestSynthesizeStream_FlushFirstSentence verifies that WithFlushFirstSentence
+// delivers the first sentence's audio before the server has finished sending
+// the WAV response.
+func TestSynthesizeStream_OutputFormat(t *testing.T) {
+	t.Parallel()
+
+	// 160 samples of 16 kHz mono, every one of value 1000.
+	pcm := make([]byte, 320)
+	for i := 0; i < len(pcm); i += 2 {
+		binary.LittleEndian.PutUint16(pcm[i:], 1000)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/wav")
+		_, _ = w.Write(buildTestWAV(pcm))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantBytes int
+	}{
+		{name: "server format", wantBytes: 320},
+		{name: "stereo", opts: []Option{WithOutputChannels(2)}, wantBytes: 640},
+		{name: "discord", opts: []Option{WithOutputSampleRate(48000), WithOutputChannels(2)}, wantBytes: 480 * 4},
+		{name: "downsample", opts: []Option{WithOutputSampleRate(8000)}, wantBytes: 160},
+		{name: "unsupported channels", opts: []Option{WithOutputChannels(6)}, wantBytes: 320},
+		{name: "discord streamed", opts: []Option{WithOutputSampleRate(48000), WithOutputChannels(2), WithFlushFirstSentence(true)}, wantBytes: 480 * 4},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := mustNew(t, srv.URL, tc.opts...)
+			stream, err := p.SynthesizeStream(context.Background(), sendFragments([]string{"Hello there."}), tts.VoiceProfile{ID: "v"})
+			if err != nil {
+				t.Fatalf("SynthesizeStream: %v", err)
+			}
+			got := drainAudio(stream)
+			if err := stream.Err(); err != nil {
+				t.Fatalf("stream error: %v", err)
+			}
+			if len(got) != tc.wantBytes {
+				t.Fatalf("audio = %d bytes, want %d", len(got), tc.wantBytes)
+			}
+			for i := 0; i < len(got); i += 2 {
+				if v := int16(binary.LittleEndian.Uint16(got[i:])); v != 1000 {
+					t.Fatalf("sample %d = %d, want 1000", i/2, v)
+				}
+			}
+		})
+	}
+}
+
+// TestSynthesizeStream_StreamedResamplingMatches verifies that audio converted
+// while it streams in equals audio converted after the whole response
+// arrived, so piece boundaries leave no gaps or clicks.
+func TestSynthesizeStream_StreamedResamplingMatches(t *testing.T) {
+	t.Parallel()
+
+	// 3000 samples of 16 kHz mono, a ramp with a wiggle.
+	pcm := make([]byte, 6000)
+	for i := 0; i < len(pcm); i += 2 {
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(int16(i*5-15000+(i%7)*200)))
+	}
+	wav := buildTestWAV(pcm)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/wav")
+		// Odd-sized writes, so pieces split sample frames.
+		for off := 0; off < len(wav); off += 777 {
+			_, _ = w.Write(wav[off:min(off+777, len(wav))])
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	synth := func(flush bool) []byte {
+		p := mustNew(t, srv.URL, WithOutputSampleRate(48000), WithOutputChannels(2), WithFlushFirstSentence(flush))
+		stream, err := p.SynthesizeStream(context.Background(), sendFragments([]string{"Hello there."}), tts.VoiceProfile{ID: "v"})
+		if err != nil {
+			t.Fatalf("SynthesizeStream: %v", err)
+		}
+		got := drainAudio(stream)
+		if err := stream.Err(); err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+		return got
+	}
+	whole, streamed := synth(false), synth(true)
+	if len(whole) != 9000*4 {
+		t.Fatalf("converted audio = %d bytes, want %d", len(whole), 9000*4)
+	}
+	if !bytes.Equal(streamed, whole) {
+		t.Errorf("streamed audio (%d bytes) differs from the audio converted at once (%d bytes)", len(streamed), len(whole))
+	}
+}
+
+func TestSynthesizeStream_FlushFirstSentence(t *testing.T) {
+	t.Parallel()
+
+	const delay = 300 * time.Millisecond
+	pcm := make([]byte, 1000)
+	for i := range pcm {
+		pcm[i] = byte(i)
+	}
+	wav := buildTestWAV(pcm)
+	split := len(wav) - len(pcm) + 201 // header plus an odd number of PCM bytes
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/wav")
+		_, _ = w.Write(wav[:split])
+		w.(http.Flusher).Flush()
+		time.Sleep(delay)
+		_, _ = w.Write(wav[split:])
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name      string
+		flush     bool
+		wantEarly bool
+	}{
+		{name: "enabled", flush: true, wantEarly: true},
+		{name: "disabled", flush: false, wantEarly: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := mustNew(t, srv.URL, WithFlushFirstSentence(tc.flush))
+			if p.flushFirst != tc.flush {
+				t.Fatalf("flushFirst = %v, want %v", p.flushFirst, tc.flush)
+			}
+
+			start := time.Now()
+			stream, err := p.SynthesizeStream(context.Background(), sendFragments([]string{"Hello there."}), tts.VoiceProfile{ID: "v"})
+			if err != nil {
+				t.Fatalf("SynthesizeStream: %v", err)
+			}
+			first, ok := <-stream.Audio()
+			if !ok {
+				t.Fatal("audio channel closed before the first chunk")
+			}
+			elapsed := time.Since(start)
+			got := append(first, drainAudio(stream)...)
+
+			if early := elapsed < delay; early != tc.wantEarly {
+				t.Errorf("first chunk after %v (response delay %v), want early = %v", elapsed, delay, tc.wantEarly)
+			}
+			if tc.wantEarly && len(first)%2 != 0 {
+				t.Errorf("first chunk length = %d, want whole 16-bit samples", len(first))
+			}
+			if string(got) != string(pcm) {
+				t.Errorf("audio = %d bytes, want the %d PCM bytes unchanged", len(got), len(pcm))
+			}
+		})
+	}
+}
+
+func TestNewRequest_VoiceLanguage(t *testing.T) {
+	t.Parallel()
+
+	voice := tts.VoiceProfile{ID: "p225", Language: "de-DE"}
+
+	std := mustNew(t, "http://localhost:5002")
+	req, err := std.newRequest(context.Background(), "Hallo.", voice)
+	if err != nil {
+		t.Fatalf("newRequest: %v", err)
+	}
+	if got := req.URL.Query().Get("language_id"); got != "de" {
+		t.Errorf("standard: language_id = %q, want de", got)
+	}
+
+	xtts := mustNew(t, "http://localhost:8020", WithAPIMode(APIModeXTTS))
+	req, err = xtts.newRequest(context.Background(), "Hallo.", voice)
+	if err != nil {
+		t.Fatalf("newRequest: %v", err)
+	}
+	var body ttsRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Language != "de" {
+		t.Errorf("xtts: language = %q, want de", body.Language)
+	}
+}
+
+func TestNew_AdaptiveConcurrency(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantErr  bool
+		wantInit int // expected initial limit
+		wantMax  int // expected limit once grown to the upper bound
+	}{
+		{name: "bounds", opts: []Option{WithAdaptiveConcurrency(2, 5, time.Second)}, wantInit: 2, wantMax: 5},
+		{name: "zero bounds use defaults", opts: []Option{WithConcurrency(4), WithAdaptiveConcurrency(0, 0, time.Second)}, wantInit: 1, wantMax: 4},
+		{name: "zero target", opts: []Option{WithAdaptiveConcurrency(1, 4, 0)}, wantErr: true},
+		{name: "max below min", opts: []Option{WithAdaptiveConcurrency(4, 2, time.Second)}, wantErr: true},
+		{name: "negative min", opts: []Option{WithAdaptiveConcurrency(-1, 2, time.Second)}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := New("http://localhost:5002", tc.opts...)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if got := p.adaptive.Limit(); got != tc.wantInit {
+				t.Errorf("initial limit = %d, want %d", got, tc.wantInit)
+			}
+			// Fast, saturated calls grow the limit until it reaches the upper bound.
+			for range 100 {
+				p.adaptive.Observe(time.Millisecond, p.adaptive.Limit())
+			}
+			if got := p.adaptive.Limit(); got != tc.wantMax {
+				t.Errorf("grown limit = %d, want %d", got, tc.wantMax)
+			}
+		})
+	}
+}
+
+// TestSynthesizeStream_AdaptiveConcurrency verifies that the number of requests
+// in flight follows the latency of a server that slows down with every request
+// it is working on.
+func TestSynthesizeStream_AdaptiveConcurrency(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxSeen  int
+		perReq   time.Duration
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxSeen = max(maxSeen, inFlight)
+		delay := time.Duration(inFlight) * perReq
+		mu.Unlock()
+		time.Sleep(delay)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "audio/wav")
+		_, _ = w.Write(buildTestWAV([]byte{1}))
+	}))
+	defer srv.Close()
+
+	setLoad := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		perReq = d
+		maxSeen = 0
+	}
+	peak := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return maxSeen
+	}
+
+	const maxConcurrency = 6
+	p := mustNew(t, srv.URL, WithAdaptiveConcurrency(1, maxConcurrency, 30*time.Millisecond))
+	synthesize := func(sentences int) {
+		t.Helper()
+		var text strings.Builder
+		for range sentences {
+			text.WriteString("Hello there. ")
+		}
+		stream, err := p.SynthesizeStream(context.Background(), sendFragments([]string{text.String()}), tts.VoiceProfile{})
+		if err != nil {
+			t.Fatalf("SynthesizeStream: %v", err)
+		}
+		if got := len(drainAudio(stream)); got != sentences {
+			t.Fatalf("got %d bytes of PCM, want %d", got, sentences)
+		}
+	}
+
+	// While the server answers quickly, the lookahead grows to the maximum.
+	// The measurements carry over between streams.
+	setLoad(time.Millisecond)
+	synthesize(20)
+	synthesize(20)
+	if got := p.adaptive.Limit(); got != maxConcurrency {
+		t.Errorf("limit with an idle server = %d, want %d", got, maxConcurrency)
+	}
+	if got := peak(); got < 3 || got > maxConcurrency {
+		t.Errorf("peak requests in flight with an idle server = %d, want 3..%d", got, maxConcurrency)
+	}
+
+	// Under load every extra request delays the others, so the lookahead
+	// shrinks until sentences are back within the target latency.
+	setLoad(15 * time.Millisecond)
+	synthesize(30)
+	if got := p.adaptive.Limit(); got > 2 {
+		t.Errorf("limit with a loaded server = %d, want <= 2", got)
+	}
+	if got := peak(); got > maxConcurrency {
+		t.Errorf("peak requests in flight under load = %d, want <= %d", got, maxConcurrency)
+	}
+}
+
+// TestSynthesizeStream_SeedPhrase verifies that a voice's seed phrase is sent
+// to the server in front of every sentence but trimmed from the output, using
+// the length of the phrase synthesised on its own.
+func TestSynthesizeStream_SeedPhrase(t *testing.T) {
+	t.Parallel()
+
+	// speak renders every non-space character as 100 samples of its code, so
+	// the audio of a text is the concatenation of the audio of its words.
+	speak := func(text string) []byte {
+		var pcm []byte
+		for _, r := range strings.ReplaceAll(text, " ", "") {
+			for range 100 {
+				pcm = binary.LittleEndian.AppendUint16(pcm, uint16(r))
+			}
+		}
+		return pcm
+	}
+
+	for _, flush := range []bool{false, true} {
+		t.Run(fmt.Sprintf("flush=%v", flush), func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu    sync.Mutex
+				texts []string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req ttsRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				mu.Lock()
+				texts = append(texts, req.Text)
+				mu.Unlock()
+				w.Header().Set("Content-Type", "audio/wav")
+				_, _ = w.Write(buildTestWAV(speak(req.Text)))
+			}))
+			t.Cleanup(srv.Close)
+
+			p := mustNew(t, srv.URL, WithAPIMode(APIModeXTTS), WithConcurrency(1), WithFlushFirstSentence(flush))
+			voice := tts.VoiceProfile{ID: "cloned", Metadata: map[string]string{tts.MetaSeedPhrase: " Hmm. "}}
+			stream, err := p.SynthesizeStream(context.Background(), sendFragments([]string{"Hello there. ", "Farewell."}), voice)
+			if err != nil {
+				t.Fatalf("SynthesizeStream: %v", err)
+			}
+			got := drainAudio(stream)
+
+			if want := string(speak("Hello there.Farewell.")); string(got) != want {
+				t.Errorf("audio = %d bytes, want %d bytes of the sentences without the seed phrase", len(got), len(want))
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			wantTexts := []string{"Hmm.", "Hmm. Hello there.", "Hmm. Farewell."}
+			if strings.Join(texts, "|") != strings.Join(wantTexts, "|") {
+				t.Errorf("server received %q, want %q", texts, wantTexts)
+			}
+		})
+	}
+}
+
+func TestSynthesizeStream_Retry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		opts       []Option
+		failures   int // leading failures for the sentence "One."
+		status     int
+		wantCalls  int // requests for "One."
+		wantAudio  string
+		wantFailed string // sentence reported to the error handler
+	}{
+		{
+			name:      "recovers",
+			opts:      []Option{WithMaxRetries(2)},
+			failures:  2,
+			status:    http.StatusBadGateway,
+			wantCalls: 3,
+			wantAudio: "One.Two.",
+		},
+		{
+			name:       "exhausted",
+			opts:       []Option{WithMaxRetries(2)},
+			failures:   5,
+			status:     http.StatusBadGateway,
+			wantCalls:  3,
+			wantFailed: "One.",
+		},
+		{
+			name:       "client error not retried",
+			opts:       []Option{WithMaxRetries(2)},
+			failures:   1,
+			status:     http.StatusBadRequest,
+			wantCalls:  1,
+			wantFailed: "One.",
+		},
+		{
+			name:       "no retries by default",
+			failures:   1,
+			status:     http.StatusServiceUnavailable,
+			wantCalls:  1,
+			wantFailed: "One.",
+		},
+		{
+			name:      "flushed first sentence",
+			opts:      []Option{WithMaxRetries(1), WithFlushFirstSentence(true)},
+			failures:  1,
+			status:    http.StatusTooManyRequests,
+			wantCalls: 2,
+			wantAudio: "One.Two.",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu    sync.Mutex
+				calls int
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				text := r.URL.Query().Get("text")
+				if text == "One." {
+					mu.Lock()
+					calls++
+					fail := calls <= tc.failures
+					mu.Unlock()
+					if fail {
+						http.Error(w, "upstream unavailable", tc.status)
+						return
+					}
+				}
+				_, _ = w.Write(buildTestWAV([]byte(text)))
+			}))
+			t.Cleanup(srv.Close)
+
+			var failed []string
+			opts := append([]Option{
+				WithRetryBackoff(time.Millisecond),
+				WithErrorHandler(func(sentence string, err error) {
+					failed = append(failed, sentence)
+					var se *statusError
+					if !errors.As(err, &se) || se.code != tc.status {
+						t.Errorf("reported error = %v, want status %d", err, tc.status)
+					}
+				}),
+			}, tc.opts...)
+			p := mustNew(t, srv.URL, opts...)
+
+			stream, err := p.SynthesizeStream(context.Background(), sendFragments([]string{"One. Two."}), tts.VoiceProfile{})
+			if err != nil {
+				t.Fatalf("SynthesizeStream: %v", err)
+			}
+			if got := string(drainAudio(stream)); got != tc.wantAudio {
+				t.Errorf("audio = %q, want %q", got, tc.wantAudio)
+			}
+			if failed := stream.Err() != nil; failed != (tc.wantFailed != "") {
+				t.Errorf("Err() = %v, want failure %v", stream.Err(), tc.wantFailed != "")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if calls != tc.wantCalls {
+				t.Errorf("requests for the failing sentence = %d, want %d", calls, tc.wantCalls)
+			}
+			if got := strings.Join(failed, "|"); got != tc.wantFailed {
+				t.Errorf("reported failures = %q, want %q", got, tc.wantFailed)
+			}
+		})
+	}
+}
+
+func TestSynthesizeStream_RetryCancelled(t *testing.T) {
+	t.Parallel()
+
+	requested := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	var reported bool
+	p := mustNew(t, srv.URL,
+		WithMaxRetries(3),
+		WithRetryBackoff(time.Hour),
+		WithErrorHandler(func(string, error) { reported = true }),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := p.SynthesizeStream(ctx, sendFragments([]string{"Hello."}), tts.VoiceProfile{})
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	<-requested
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		drainAudio(stream)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("audio channel did not close while waiting to retry")
+	}
+	if reported {
+		t.Error("error handler called for a cancelled stream")
+	}
+}
+
+func TestTransient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &statusError{code: http.StatusBadGateway}, want: true},
+		{err: &statusError{code: http.StatusTooManyRequests}, want: true},
+		{err: &statusError{code: http.StatusNotFound}, want: false},
+		{err: fmt.Errorf("coqui: read WAV response: %w", io.ErrUnexpectedEOF), want: true},
+		{err: fmt.Errorf("coqui: GET /api/tts: %w", context.Canceled), want: false},
+	}
+	for _, tc := range tests {
+		if got := transient(tc.err); got != tc.want {
+			t.Errorf("transient(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
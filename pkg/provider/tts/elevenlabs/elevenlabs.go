@@ -10,7 +10,11 @@ import (
 	"fmt"
 	"maps"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
 	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
 	"github.com/coder/websocket"
 )
@@ -20,6 +24,13 @@ const (
 	voicesEndpoint   = "https://api.elevenlabs.io/v1/voices"
 	defaultModel     = "eleven_flash_v2_5"
 	defaultOutputFmt = "pcm_16000"
+
+	// maxBreak is the longest pause an ElevenLabs break tag may request.
+	maxBreak = 3 * time.Second
+
+	// Default voice settings, matching the ElevenLabs defaults.
+	defaultStability       = 0.5
+	defaultSimilarityBoost = 0.75
 )
 
 // Option is a functional option for configuring the ElevenLabs Provider.
@@ -39,15 +50,41 @@ func WithOutputFormat(format string) Option {
 	}
 }
 
+// WithVoiceSettings sets the voice settings sent with every synthesis request.
+// stability (lower is more expressive), similarityBoost (adherence to the
+// original voice) and style (exaggeration of its speaking style) must be in
+// [0, 1]; New returns an error otherwise. useSpeakerBoost boosts the
+// similarity to the original speaker at a small latency cost.
+//
+// Individual settings can be overridden per voice through the
+// [tts.MetaStability], [tts.MetaSimilarityBoost], [tts.MetaStyle] and
+// [tts.MetaUseSpeakerBoost] keys of [tts.VoiceProfile.Metadata].
+//
+// Without this option, stability 0.5 and similarity boost 0.75 are sent and
+// ElevenLabs defaults apply to the rest.
+func WithVoiceSettings(stability, similarityBoost, style float64, useSpeakerBoost bool) Option {
+	return func(p *Provider) {
+		p.settings = voiceSettings{
+			Stability:       stability,
+			SimilarityBoost: similarityBoost,
+			Style:           style,
+			UseSpeakerBoost: &useSpeakerBoost,
+		}
+	}
+}
+
 // Provider implements tts.Provider backed by the ElevenLabs streaming API.
 type Provider struct {
 	apiKey       string
 	model        string
 	outputFormat string
+	settings     voiceSettings
 	httpClient   *http.Client
+	wsEndpoint   string // format string for the voice ID and model; see wsEndpointFmt
 }
 
-// New creates a new ElevenLabs Provider. apiKey must be non-empty.
+// New creates a new ElevenLabs Provider. apiKey must be non-empty, and voice
+// settings passed with [WithVoiceSettings] must be in range.
 func New(apiKey string, opts ...Option) (*Provider, error) {
 	if apiKey == "" {
 		return nil, errors.New("elevenlabs: apiKey must not be empty")
@@ -56,14 +93,34 @@ func New(apiKey string, opts ...Option) (*Provider, error) {
 		apiKey:       apiKey,
 		model:        defaultModel,
 		outputFormat: defaultOutputFmt,
-		httpClient:   &http.Client{},
+		settings:     voiceSettings{Stability: defaultStability, SimilarityBoost: defaultSimilarityBoost},
+		httpClient:   &http.Client{Transport: ident.Transport(nil)},
+		wsEndpoint:   wsEndpointFmt,
 	}
 	for _, o := range opts {
 		o(p)
 	}
+	if err := p.settings.validate(); err != nil {
+		return nil, fmt.Errorf("elevenlabs: %w", err)
+	}
 	return p, nil
 }
 
+// SampleRate returns the sample rate in Hz of the PCM produced by the
+// provider, taken from its "pcm_<rate>" output format. It returns 0 for other
+// output formats.
+func (p *Provider) SampleRate() int {
+	rate, ok := strings.CutPrefix(p.outputFormat, "pcm_")
+	if !ok {
+		return 0
+	}
+	hz, err := strconv.Atoi(rate)
+	if err != nil || hz < 1 {
+		return 0
+	}
+	return hz
+}
+
 // ---- WebSocket message types ----
 
 // textMessage is the JSON payload sent to ElevenLabs for each text fragment.
@@ -72,10 +129,62 @@ type textMessage struct {
 	VoiceSettings *voiceSettings `json:"voice_settings,omitempty"`
 }
 
-// voiceSettings mirrors the ElevenLabs voice_settings object.
+// voiceSettings mirrors the ElevenLabs voice_settings object. Style and
+// UseSpeakerBoost are omitted unless set, leaving the ElevenLabs defaults.
 type voiceSettings struct {
 	Stability       float64 `json:"stability"`
 	SimilarityBoost float64 `json:"similarity_boost"`
+	Style           float64 `json:"style,omitempty"`
+	UseSpeakerBoost *bool   `json:"use_speaker_boost,omitempty"`
+}
+
+// validate reports an error if a setting is outside [0, 1].
+func (vs voiceSettings) validate() error {
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{
+		{"stability", vs.Stability},
+		{"similarity_boost", vs.SimilarityBoost},
+		{"style", vs.Style},
+	} {
+		if f.value < 0 || f.value > 1 {
+			return fmt.Errorf("voice setting %s %.2f is out of range [0, 1]", f.name, f.value)
+		}
+	}
+	return nil
+}
+
+// settingsFor returns the provider's voice settings with the overrides from
+// voice.Metadata applied.
+func (p *Provider) settingsFor(voice tts.VoiceProfile) (voiceSettings, error) {
+	vs := p.settings
+	for _, f := range []struct {
+		key string
+		dst *float64
+	}{
+		{tts.MetaStability, &vs.Stability},
+		{tts.MetaSimilarityBoost, &vs.SimilarityBoost},
+		{tts.MetaStyle, &vs.Style},
+	} {
+		raw, ok := voice.Metadata[f.key]
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return voiceSettings{}, fmt.Errorf("voice setting %s: %w", f.key, err)
+		}
+		*f.dst = v
+	}
+	if raw, ok := voice.Metadata[tts.MetaUseSpeakerBoost]; ok {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return voiceSettings{}, fmt.Errorf("voice setting %s: %w", tts.MetaUseSpeakerBoost, err)
+		}
+		vs.UseSpeakerBoost = &b
+	}
+	return vs, vs.validate()
 }
 
 // audioResponse is the JSON message received from ElevenLabs over the WebSocket.
@@ -94,29 +203,56 @@ type boiMessage struct {
 }
 
 // SynthesizeStream opens a WebSocket to ElevenLabs, pipes text fragments from
-// the text channel, and returns a channel emitting raw PCM audio chunks.
+// the text channel, and returns a stream emitting raw PCM audio chunks.
 //
-// The returned audio channel is closed when synthesis is complete or ctx is cancelled.
-func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (<-chan []byte, error) {
+// The audio channel is closed when synthesis is complete, when the WebSocket
+// fails, or when ctx is cancelled. A failed send or a connection closed with
+// anything but a normal closure is reported by [tts.Stream.Err].
+func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	return p.synthesizeStream(ctx, text, voice, nil)
+}
+
+// SynthesizeMarkupStream implements [tts.MarkupSynthesizer]. Breaks are sent
+// as ElevenLabs break tags, capped at the supported three seconds. Emphasis and
+// prosody have no per-phrase equivalent in the streaming API, where the voice
+// settings apply to the whole stream, so those tags are dropped.
+func (p *Provider) SynthesizeMarkupStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	return p.synthesizeStream(ctx, text, voice, tts.NewMarkupFilter(breakTag))
+}
+
+// breakTag translates a markup tag into an ElevenLabs break tag, or drops it.
+func breakTag(t tts.Tag) string {
+	d, ok := tts.BreakDuration(t)
+	if !ok || d <= 0 {
+		return ""
+	}
+	secs := min(d, maxBreak).Seconds()
+	return `<break time="` + strconv.FormatFloat(secs, 'f', -1, 64) + `s" />`
+}
+
+// synthesizeStream implements SynthesizeStream and SynthesizeMarkupStream.
+// If filter is non-nil, every text fragment is passed through it.
+func (p *Provider) synthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile, filter *tts.MarkupFilter) (*tts.Stream, error) {
 	if voice.ID == "" {
 		return nil, errors.New("elevenlabs: voice.ID must not be empty")
 	}
+	settings, err := p.settingsFor(voice)
+	if err != nil {
+		return nil, fmt.Errorf("elevenlabs: %w", err)
+	}
 
-	wsURL := fmt.Sprintf(wsEndpointFmt, voice.ID, p.model)
-	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	wsURL := fmt.Sprintf(p.wsEndpoint, voice.ID, p.model)
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{HTTPHeader: ident.Header(ctx, nil)})
 	if err != nil {
 		return nil, fmt.Errorf("elevenlabs: dial: %w", err)
 	}
 
 	// Send the initial BOI message to authenticate and configure the stream.
 	boi := boiMessage{
-		Text: " ", // ElevenLabs requires a non-empty first text value
-		VoiceSettings: &voiceSettings{
-			Stability:       0.5,
-			SimilarityBoost: 0.75,
-		},
-		XiAPIKey:     p.apiKey,
-		OutputFormat: p.outputFormat,
+		Text:          " ", // ElevenLabs requires a non-empty first text value
+		VoiceSettings: &settings,
+		XiAPIKey:      p.apiKey,
+		OutputFormat:  p.outputFormat,
 	}
 	boiBytes, _ := json.Marshal(boi)
 	if err := conn.Write(ctx, websocket.MessageText, boiBytes); err != nil {
@@ -125,10 +261,17 @@ func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voi
 	}
 
 	audioCh := make(chan []byte, 256)
+	stream := tts.NewStream(audioCh)
 
 	go func() {
 		defer close(audioCh)
-		defer conn.Close(websocket.StatusNormalClosure, "done")
+
+		// fail records err unless the stream was cancelled.
+		fail := func(err error) {
+			if ctx.Err() == nil {
+				stream.SetErr(err)
+			}
+		}
 
 		// Start reader goroutine.
 		readDone := make(chan struct{})
@@ -137,6 +280,11 @@ func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voi
 			for {
 				_, msg, err := conn.Read(ctx)
 				if err != nil {
+					// ElevenLabs closes the socket normally after the final
+					// audio; anything else cut the stream short.
+					if websocket.CloseStatus(err) != websocket.StatusNormalClosure {
+						fail(fmt.Errorf("elevenlabs: read: %w", err))
+					}
 					return
 				}
 				var resp audioResponse
@@ -158,12 +306,26 @@ func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voi
 			}
 		}()
 
+		// Closing the socket stops the reader; wait for it so it never sends
+		// on the closed audio channel.
+		defer func() { <-readDone }()
+		defer conn.Close(websocket.StatusNormalClosure, "done")
+
 		// Write text fragments to ElevenLabs.
-		vs := &voiceSettings{Stability: 0.5, SimilarityBoost: 0.75}
+		vs := &settings
 		for {
 			select {
 			case sentence, ok := <-text:
 				if !ok {
+					if filter != nil {
+						if rest := filter.Flush(); rest != "" {
+							msgBytes, _ := json.Marshal(textMessage{Text: rest, VoiceSettings: vs})
+							if err := conn.Write(ctx, websocket.MessageText, msgBytes); err != nil {
+								fail(fmt.Errorf("elevenlabs: send text: %w", err))
+								return
+							}
+						}
+					}
 					// Text channel closed — send flush command.
 					flush := textMessage{Text: ""}
 					flushBytes, _ := json.Marshal(flush)
@@ -172,6 +334,9 @@ func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voi
 					<-readDone
 					return
 				}
+				if filter != nil {
+					sentence = filter.Write(sentence)
+				}
 				if sentence == "" {
 					continue
 				}
@@ -180,15 +345,21 @@ func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voi
 				vs = nil
 				msgBytes, _ := json.Marshal(payload)
 				if err := conn.Write(ctx, websocket.MessageText, msgBytes); err != nil {
+					fail(fmt.Errorf("elevenlabs: send text: %w", err))
 					return
 				}
+			case <-readDone:
+				// The reader records why the connection failed; a normal
+				// closure this early still leaves text unspoken.
+				fail(errors.New("elevenlabs: connection closed before all text was sent"))
+				return
 			case <-ctx.Done():
 				return
 			}
 		}
 	}()
 
-	return audioCh, nil
+	return stream, nil
 }
 
 // ---- ListVoices ----
@@ -1,9 +1,19 @@
 package elevenlabs
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
 )
 
 // ---- WebSocket message construction ----
@@ -211,3 +221,295 @@ func TestNew_WithOptions(t *testing.T) {
 		t.Errorf("expected outputFormat 'pcm_24000', got %q", p.outputFormat)
 	}
 }
+
+func TestNew_VoiceSettingsOutOfRange(t *testing.T) {
+	tests := []struct {
+		name                              string
+		stability, similarityBoost, style float64
+		wantErr                           string
+	}{
+		{name: "stability above 1", stability: 1.2, similarityBoost: 0.75, wantErr: "stability"},
+		{name: "negative similarity boost", stability: 0.5, similarityBoost: -0.1, wantErr: "similarity_boost"},
+		{name: "style above 1", stability: 0.5, similarityBoost: 0.75, style: 1.5, wantErr: "style"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New("key", WithVoiceSettings(tc.stability, tc.similarityBoost, tc.style, true))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("New: want error naming %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := New("key", WithVoiceSettings(0, 1, 1, false)); err != nil {
+		t.Errorf("New with boundary values: %v", err)
+	}
+}
+
+// ---- Synthesis requests ----
+
+// recordingServer is a fake ElevenLabs stream-input endpoint. It records every
+// message the client sends and answers the final flush with one audio chunk.
+type recordingServer struct {
+	mu       sync.Mutex
+	messages []map[string]json.RawMessage
+}
+
+func (s *recordingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	ctx := r.Context()
+
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		var msg map[string]json.RawMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.messages = append(s.messages, msg)
+		s.mu.Unlock()
+
+		if string(msg["text"]) == `""` {
+			reply, _ := json.Marshal(audioResponse{Audio: base64.StdEncoding.EncodeToString([]byte{1, 2}), IsFinal: true})
+			_ = conn.Write(ctx, websocket.MessageText, reply)
+			conn.Close(websocket.StatusNormalClosure, "")
+			return
+		}
+	}
+}
+
+// synthesize runs one synthesis of text against srv and returns the decoded
+// voice_settings of every message that carried them.
+func synthesize(t *testing.T, p *Provider, voice tts.VoiceProfile) []map[string]any {
+	t.Helper()
+
+	srv := &recordingServer{}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	p.wsEndpoint = "ws" + strings.TrimPrefix(ts.URL, "http") + "/v1/text-to-speech/%s/stream-input?model_id=%s"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	text := make(chan string, 1)
+	text <- "Well met, traveller."
+	close(text)
+	stream, err := p.SynthesizeStream(ctx, text, voice)
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	for range stream.Audio() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	var settings []map[string]any
+	for _, msg := range srv.messages {
+		raw, ok := msg["voice_settings"]
+		if !ok {
+			continue
+		}
+		var vs map[string]any
+		if err := json.Unmarshal(raw, &vs); err != nil {
+			t.Fatalf("decode voice_settings %s: %v", raw, err)
+		}
+		settings = append(settings, vs)
+	}
+	if len(settings) == 0 {
+		t.Fatalf("no message carried voice_settings: %v", srv.messages)
+	}
+	return settings
+}
+
+func TestSynthesizeStream_VoiceSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		metadata map[string]string
+		want     map[string]any
+	}{
+		{
+			name: "defaults",
+			want: map[string]any{"stability": 0.5, "similarity_boost": 0.75},
+		},
+		{
+			name: "provider settings",
+			opts: []Option{WithVoiceSettings(0.3, 0.9, 0.4, true)},
+			want: map[string]any{"stability": 0.3, "similarity_boost": 0.9, "style": 0.4, "use_speaker_boost": true},
+		},
+		{
+			name: "per-voice overrides",
+			opts: []Option{WithVoiceSettings(0.3, 0.9, 0.4, true)},
+			metadata: map[string]string{
+				tts.MetaStability:       "0.8",
+				tts.MetaStyle:           "0.1",
+				tts.MetaUseSpeakerBoost: "false",
+			},
+			want: map[string]any{"stability": 0.8, "similarity_boost": 0.9, "style": 0.1, "use_speaker_boost": false},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := New("key", tc.opts...)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			for i, got := range synthesize(t, p, tts.VoiceProfile{ID: "voice-1", Metadata: tc.metadata}) {
+				if len(got) != len(tc.want) {
+					t.Errorf("message %d voice_settings = %v, want %v", i, got, tc.want)
+					continue
+				}
+				for k, v := range tc.want {
+					if got[k] != v {
+						t.Errorf("message %d voice_settings[%q] = %v, want %v", i, k, got[k], v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSynthesizeStream_VoiceSettingsOverrideInvalid(t *testing.T) {
+	p, err := New("key")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, metadata := range []map[string]string{
+		{tts.MetaSimilarityBoost: "1.01"},
+		{tts.MetaStability: "calm"},
+		{tts.MetaUseSpeakerBoost: "maybe"},
+	} {
+		text := make(chan string)
+		close(text)
+		if _, err := p.SynthesizeStream(context.Background(), text, tts.VoiceProfile{ID: "voice-1", Metadata: metadata}); err == nil {
+			t.Errorf("SynthesizeStream with %v: expected an error", metadata)
+		}
+	}
+}
+
+func TestSynthesizeMarkupStream(t *testing.T) {
+	p, err := New("key")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv := &recordingServer{}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	p.wsEndpoint = "ws" + strings.TrimPrefix(ts.URL, "http") + "/v1/text-to-speech/%s/stream-input?model_id=%s"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fragments := []string{
+		`<emphasis level="strong">Halt!</emphasis> <break str`,
+		`ength="x-strong"/>Who goes there?<break time="5s"/>`,
+		`<prosody rate="slow">Speak.</prosody>`,
+	}
+	text := make(chan string, len(fragments))
+	for _, f := range fragments {
+		text <- f
+	}
+	close(text)
+	stream, err := p.SynthesizeMarkupStream(ctx, text, tts.VoiceProfile{ID: "voice-1"})
+	if err != nil {
+		t.Fatalf("SynthesizeMarkupStream: %v", err)
+	}
+	for range stream.Audio() {
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	var got strings.Builder
+	for _, msg := range srv.messages[1:] { // skip the BOI message
+		var s string
+		if err := json.Unmarshal(msg["text"], &s); err != nil {
+			t.Fatalf("decode text %s: %v", msg["text"], err)
+		}
+		got.WriteString(s)
+	}
+	want := `Halt! <break time="1s" />Who goes there?<break time="3s" />Speak.`
+	if got.String() != want {
+		t.Errorf("sent text = %q, want %q", got.String(), want)
+	}
+}
+
+func TestSynthesizeStream_Err(t *testing.T) {
+	p, err := New("key")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// The server sends one chunk and then drops the connection with an
+	// error status, as ElevenLabs does on quota or auth failures.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		if _, _, err := conn.Read(r.Context()); err != nil {
+			return
+		}
+		reply, _ := json.Marshal(audioResponse{Audio: base64.StdEncoding.EncodeToString([]byte{1, 2})})
+		_ = conn.Write(r.Context(), websocket.MessageText, reply)
+		conn.Close(websocket.StatusPolicyViolation, "quota exceeded")
+	}))
+	defer ts.Close()
+	p.wsEndpoint = "ws" + strings.TrimPrefix(ts.URL, "http") + "/v1/text-to-speech/%s/stream-input?model_id=%s"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	text := make(chan string)
+	defer close(text)
+	stream, err := p.SynthesizeStream(ctx, text, tts.VoiceProfile{ID: "voice-1"})
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	var got []byte
+	for chunk := range stream.Audio() {
+		got = append(got, chunk...)
+	}
+	if len(got) != 2 {
+		t.Errorf("audio = %v, want the chunk sent before the failure", got)
+	}
+	if err := stream.Err(); websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Errorf("Err() = %v, want close status %d", err, websocket.StatusPolicyViolation)
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format string
+		want   int
+	}{
+		{format: "", want: 16000}, // default output format
+		{format: "pcm_24000", want: 24000},
+		{format: "mp3_44100_128", want: 0},
+		{format: "pcm_x", want: 0},
+	}
+	for _, tc := range tests {
+		var opts []Option
+		if tc.format != "" {
+			opts = append(opts, WithOutputFormat(tc.format))
+		}
+		p, err := New("key", opts...)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		if got := p.SampleRate(); got != tc.want {
+			t.Errorf("SampleRate with %q = %d, want %d", tc.format, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package tts
+
+import "github.com/MrWong99/glyphoxa/pkg/audio"
+
+// FormatReporter is an optional capability of a [Provider] that knows the
+// sample rate of the PCM it synthesises, so consumers can tag and mix the
+// audio correctly instead of assuming a default.
+type FormatReporter interface {
+	// SampleRate returns the sample rate in Hz of the synthesised PCM, or 0
+	// if it is not known before synthesis.
+	SampleRate() int
+}
+
+// ChannelReporter is an optional capability of a [Provider] whose PCM may
+// have more than one channel.
+type ChannelReporter interface {
+	// Channels returns the channel count of the synthesised PCM, or 0 if it
+	// is not known before synthesis.
+	Channels() int
+}
+
+// OutputFormat returns the format of the PCM p synthesises, as reported
+// through [FormatReporter] and [ChannelReporter]. A zero SampleRate means p
+// does not report it. Providers not reporting a channel count synthesise
+// mono.
+func OutputFormat(p Provider) audio.Format {
+	f := audio.Format{Channels: 1}
+	if r, ok := p.(FormatReporter); ok {
+		f.SampleRate = max(r.SampleRate(), 0)
+	}
+	if r, ok := p.(ChannelReporter); ok && r.Channels() > 0 {
+		f.Channels = r.Channels()
+	}
+	return f
+}
@@ -0,0 +1,36 @@
+package tts_test
+
+import (
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+)
+
+// stereoProvider reports a sample rate and a channel count.
+type stereoProvider struct {
+	mock.Provider
+}
+
+func (*stereoProvider) SampleRate() int { return 48000 }
+func (*stereoProvider) Channels() int   { return 2 }
+
+func TestOutputFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		provider tts.Provider
+		want     audio.Format
+	}{
+		{name: "unreported", provider: &mock.Provider{}, want: audio.Format{Channels: 1}},
+		{name: "sample rate", provider: &mock.Provider{SampleRateResult: 16000}, want: audio.Format{SampleRate: 16000, Channels: 1}},
+		{name: "sample rate and channels", provider: &stereoProvider{}, want: audio.Format{SampleRate: 48000, Channels: 2}},
+	}
+	for _, tc := range tests {
+		if got := tts.OutputFormat(tc.provider); got != tc.want {
+			t.Errorf("%s: OutputFormat = %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
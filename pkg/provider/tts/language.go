@@ -0,0 +1,123 @@
+package tts
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// languageMetadataKeys are the [VoiceProfile.Metadata] keys under which
+// providers report a voice's language from ListVoices.
+var languageMetadataKeys = []string{"language", "locale", "language_code"}
+
+// LanguageReporter is an optional capability of a [Provider] that knows the
+// languages it can speak without listing its voices — for example a
+// multilingual model whose voices all speak every supported language.
+type LanguageReporter interface {
+	// SupportedLanguages returns the BCP-47 tags of the languages the
+	// provider can synthesise.
+	SupportedLanguages(ctx context.Context) ([]string, error)
+}
+
+// LanguageSet is a set of languages compared by their primary language
+// subtag, so "de" matches "de-DE" and "de-AT" and "en-US" matches "en-GB".
+// Regional voice variants are close enough to avoid garbled speech.
+//
+// The zero value is an empty set, which means the supported languages are
+// unknown: [LanguageSet.Supports] then reports true for every language.
+type LanguageSet struct {
+	bases map[string]struct{}
+}
+
+// NewLanguageSet returns a LanguageSet containing tags. Empty tags are ignored.
+func NewLanguageSet(tags ...string) LanguageSet {
+	var s LanguageSet
+	for _, tag := range tags {
+		base := BaseLanguage(tag)
+		if base == "" {
+			continue
+		}
+		if s.bases == nil {
+			s.bases = make(map[string]struct{})
+		}
+		s.bases[base] = struct{}{}
+	}
+	return s
+}
+
+// Known reports whether the set lists any language.
+func (s LanguageSet) Known() bool {
+	return len(s.bases) > 0
+}
+
+// Supports reports whether lang is in the set. It reports true when lang is
+// empty or the set is unknown.
+func (s LanguageSet) Supports(lang string) bool {
+	base := BaseLanguage(lang)
+	if base == "" || !s.Known() {
+		return true
+	}
+	_, ok := s.bases[base]
+	return ok
+}
+
+// Languages returns the primary subtags in the set in sorted order.
+func (s LanguageSet) Languages() []string {
+	out := make([]string, 0, len(s.bases))
+	for base := range s.bases {
+		out = append(out, base)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// BaseLanguage returns the lower-cased primary subtag of a BCP-47 tag
+// ("pt-BR" → "pt"). Underscores are accepted as separators ("en_US" → "en").
+func BaseLanguage(tag string) string {
+	base, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	base, _, _ = strings.Cut(base, "_")
+	return strings.ToLower(base)
+}
+
+// SupportedLanguages queries the languages p can speak. Providers implementing
+// [LanguageReporter] are asked directly; otherwise the languages are collected
+// from the voices returned by ListVoices, using [VoiceProfile.Language] or the
+// "language", "locale", or "language_code" metadata.
+//
+// An empty set without error means p does not report languages.
+func SupportedLanguages(ctx context.Context, p Provider) (LanguageSet, error) {
+	if r, ok := p.(LanguageReporter); ok {
+		tags, err := r.SupportedLanguages(ctx)
+		if err != nil {
+			return LanguageSet{}, err
+		}
+		return NewLanguageSet(tags...), nil
+	}
+
+	voices, err := p.ListVoices(ctx)
+	if err != nil {
+		return LanguageSet{}, err
+	}
+	var tags []string
+	for _, v := range voices {
+		tags = append(tags, voiceLanguageTags(v)...)
+	}
+	return NewLanguageSet(tags...), nil
+}
+
+// VoiceLanguages returns the languages voice reports to speak, from
+// [VoiceProfile.Language] or the "language", "locale", or "language_code"
+// metadata. The set is unknown if the voice reports none.
+func VoiceLanguages(voice VoiceProfile) LanguageSet {
+	return NewLanguageSet(voiceLanguageTags(voice)...)
+}
+
+// voiceLanguageTags returns the language tags voice reports, some of which
+// may be empty.
+func voiceLanguageTags(voice VoiceProfile) []string {
+	tags := []string{voice.Language}
+	for _, key := range languageMetadataKeys {
+		tags = append(tags, voice.Metadata[key])
+	}
+	return tags
+}
@@ -0,0 +1,93 @@
+package tts_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+)
+
+func TestLanguageSet_Supports(t *testing.T) {
+	t.Parallel()
+
+	set := tts.NewLanguageSet("en-US", "de_DE", "", "FR")
+
+	tests := []struct {
+		lang string
+		want bool
+	}{
+		{lang: "en", want: true},
+		{lang: "en-GB", want: true},
+		{lang: "de-AT", want: true},
+		{lang: "fr-CA", want: true},
+		{lang: "", want: true},
+		{lang: "ja", want: false},
+		{lang: "es-ES", want: false},
+	}
+	for _, tc := range tests {
+		if got := set.Supports(tc.lang); got != tc.want {
+			t.Errorf("Supports(%q) = %v, want %v", tc.lang, got, tc.want)
+		}
+	}
+	if got := set.Languages(); !slices.Equal(got, []string{"de", "en", "fr"}) {
+		t.Errorf("Languages = %q", got)
+	}
+
+	var unknown tts.LanguageSet
+	if unknown.Known() || !unknown.Supports("ja") {
+		t.Error("an empty set must report every language as supported")
+	}
+}
+
+// reporter is a provider that reports its languages directly.
+type reporter struct {
+	mock.Provider
+}
+
+func (*reporter) SupportedLanguages(context.Context) ([]string, error) {
+	return []string{"ja", "ko"}, nil
+}
+
+func TestSupportedLanguages(t *testing.T) {
+	t.Parallel()
+
+	t.Run("from voices", func(t *testing.T) {
+		t.Parallel()
+		p := &mock.Provider{ListVoicesResult: []tts.VoiceProfile{
+			{ID: "a", Metadata: map[string]string{"locale": "en-GB"}},
+			{ID: "b", Metadata: map[string]string{"language_code": "de-DE"}},
+			{ID: "c", Metadata: map[string]string{"language": "fr"}},
+			{ID: "d", Language: "it-IT"},
+			{ID: "e"},
+		}}
+		set, err := tts.SupportedLanguages(context.Background(), p)
+		if err != nil {
+			t.Fatalf("SupportedLanguages: %v", err)
+		}
+		if got := set.Languages(); !slices.Equal(got, []string{"de", "en", "fr", "it"}) {
+			t.Errorf("Languages = %q", got)
+		}
+	})
+
+	t.Run("from capability", func(t *testing.T) {
+		t.Parallel()
+		set, err := tts.SupportedLanguages(context.Background(), &reporter{})
+		if err != nil {
+			t.Fatalf("SupportedLanguages: %v", err)
+		}
+		if got := set.Languages(); !slices.Equal(got, []string{"ja", "ko"}) {
+			t.Errorf("Languages = %q", got)
+		}
+	})
+
+	t.Run("list error", func(t *testing.T) {
+		t.Parallel()
+		p := &mock.Provider{ListVoicesErr: errors.New("offline")}
+		if _, err := tts.SupportedLanguages(context.Background(), p); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,283 @@
+package tts
+
+import (
+	"cmp"
+	"context"
+	"strings"
+	"time"
+)
+
+// Markup tag names.
+const (
+	TagBreak    = "break"
+	TagEmphasis = "emphasis"
+	TagProsody  = "prosody"
+)
+
+// breakStrengths maps the SSML break strengths to pause durations.
+var breakStrengths = map[string]time.Duration{
+	"none":     0,
+	"x-weak":   100 * time.Millisecond,
+	"weak":     250 * time.Millisecond,
+	"medium":   500 * time.Millisecond,
+	"strong":   750 * time.Millisecond,
+	"x-strong": time.Second,
+}
+
+// MarkupSynthesizer is an optional capability of a [Provider] that
+// understands markup: a small SSML-like vocabulary that callers may embed in
+// the text they stream to hint at delivery:
+//
+//	<break time="500ms"/>       a pause; time accepts Go durations ("1.5s")
+//	<break strength="strong"/>  or a strength from "none" to "x-strong"
+//	<emphasis level="strong">…</emphasis>
+//	<prosody rate="slow" pitch="+2st" volume="loud">…</prosody>
+//
+// A tag starts with '<' followed by a letter or '/' and ends at the next '>'.
+// Any other '<' is plain text. Implementations translate the tags they can
+// express into their native format and drop the rest. Use [SynthesizeMarkup]
+// to synthesise markup with any provider; it strips the tags for providers
+// without this capability, so markup is never spoken literally.
+type MarkupSynthesizer interface {
+	// SynthesizeMarkupStream behaves like [Provider.SynthesizeStream], but
+	// the text fragments may contain markup. Tags the provider cannot
+	// express are dropped; their content is spoken normally.
+	SynthesizeMarkupStream(ctx context.Context, text <-chan string, voice VoiceProfile) (*Stream, error)
+}
+
+// SynthesizeMarkup synthesises text containing markup with p. Providers
+// implementing [MarkupSynthesizer] translate the markup themselves; for all
+// others the tags are stripped from the stream before it reaches the provider.
+func SynthesizeMarkup(ctx context.Context, p Provider, text <-chan string, voice VoiceProfile) (*Stream, error) {
+	if m, ok := p.(MarkupSynthesizer); ok {
+		return m.SynthesizeMarkupStream(ctx, text, voice)
+	}
+	stop := make(chan struct{})
+	stream, err := p.SynthesizeStream(ctx, filterMarkup(ctx, stop, text, nil), voice)
+	if err != nil {
+		// The provider never reads the filtered stream; release the filter.
+		close(stop)
+		return nil, err
+	}
+	return stream, nil
+}
+
+// Tag is a parsed markup tag.
+type Tag struct {
+	// Name is the lower-cased tag name, e.g. [TagBreak].
+	Name string
+
+	// Closing is true for end tags such as "</emphasis>".
+	Closing bool
+
+	// SelfClosing is true for empty-element tags such as "<break/>".
+	SelfClosing bool
+
+	// Attrs holds the attributes by lower-cased name.
+	Attrs map[string]string
+}
+
+// TagLength reports the length in bytes of the markup tag at the start of s:
+// 0 if s does not start with a tag, or -1 if it starts a tag whose closing '>'
+// is not in s yet.
+func TagLength(s string) int {
+	if len(s) < 2 || s[0] != '<' {
+		return 0
+	}
+	if c := s[1]; c != '/' && !isASCIILetter(c) {
+		return 0
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 0 {
+		return -1
+	}
+	return end + 1
+}
+
+// ParseTag parses a complete tag such as `<break time="1s"/>`. It returns
+// false if raw is not a tag.
+func ParseTag(raw string) (Tag, bool) {
+	if TagLength(raw) != len(raw) {
+		return Tag{}, false
+	}
+	body := raw[1 : len(raw)-1]
+	var t Tag
+	if rest, ok := strings.CutPrefix(body, "/"); ok {
+		t.Closing = true
+		body = rest
+	}
+	if rest, ok := strings.CutSuffix(body, "/"); ok {
+		t.SelfClosing = true
+		body = rest
+	}
+	body = strings.TrimSpace(body)
+	name, attrs := body, ""
+	if i := strings.IndexAny(body, " \t\n"); i >= 0 {
+		name, attrs = body[:i], body[i:]
+	}
+	t.Name = strings.ToLower(name)
+	if t.Name == "" {
+		return Tag{}, false
+	}
+	t.Attrs = parseAttrs(attrs)
+	return t, true
+}
+
+// parseAttrs parses name="value" pairs. Values may be single- or
+// double-quoted or bare; names without a value are ignored.
+func parseAttrs(s string) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		s = strings.TrimSpace(s)
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return attrs
+		}
+		name := strings.ToLower(strings.TrimSpace(s[:eq]))
+		if i := strings.LastIndexAny(name, " \t\n"); i >= 0 {
+			name = name[i+1:]
+		}
+		s = strings.TrimSpace(s[eq+1:])
+		var value string
+		if s != "" && (s[0] == '"' || s[0] == '\'') {
+			end := strings.IndexByte(s[1:], s[0])
+			if end < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(s, " \t\n")
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+		if name != "" {
+			attrs[name] = value
+		}
+	}
+}
+
+// BreakDuration returns the pause requested by a break tag. The time
+// attribute takes precedence over strength; a break without either is a
+// medium pause of 500ms. It returns false if t is not a valid break tag.
+func BreakDuration(t Tag) (time.Duration, bool) {
+	if t.Name != TagBreak || t.Closing {
+		return 0, false
+	}
+	if raw, ok := t.Attrs["time"]; ok {
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || d < 0 {
+			return 0, false
+		}
+		return d, true
+	}
+	d, ok := breakStrengths[strings.ToLower(cmp.Or(t.Attrs["strength"], "medium"))]
+	return d, ok
+}
+
+// MarkupFilter rewrites the markup tags in a stream of text fragments. Tags
+// split across fragments are held back until they are complete, so the
+// filter never emits part of a tag.
+type MarkupFilter struct {
+	rewrite func(Tag) string
+	pending string
+}
+
+// NewMarkupFilter returns a [MarkupFilter] that replaces every tag with
+// rewrite's result; an empty result drops the tag. A nil rewrite strips all
+// tags.
+func NewMarkupFilter(rewrite func(Tag) string) *MarkupFilter {
+	return &MarkupFilter{rewrite: rewrite}
+}
+
+// Write filters fragment and returns the text that is ready to be sent on.
+func (f *MarkupFilter) Write(fragment string) string {
+	s := f.pending + fragment
+	f.pending = ""
+	var out strings.Builder
+	for {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			out.WriteString(s)
+			return out.String()
+		}
+		out.WriteString(s[:i])
+		s = s[i:]
+		n := TagLength(s)
+		switch {
+		case n < 0 || (n == 0 && len(s) == 1):
+			// A tag may still be starting; wait for more text.
+			f.pending = s
+			return out.String()
+		case n == 0:
+			out.WriteByte('<')
+			s = s[1:]
+		default:
+			if t, ok := ParseTag(s[:n]); ok && f.rewrite != nil {
+				out.WriteString(f.rewrite(t))
+			}
+			s = s[n:]
+		}
+	}
+}
+
+// Flush returns any held-back text. An unterminated tag at the end of the
+// stream is not markup and is returned unchanged.
+func (f *MarkupFilter) Flush() string {
+	s := f.pending
+	f.pending = ""
+	return s
+}
+
+// StripMarkup removes all markup tags from s.
+func StripMarkup(s string) string {
+	f := NewMarkupFilter(nil)
+	return f.Write(s) + f.Flush()
+}
+
+// filterMarkup returns a channel carrying the fragments of text passed
+// through a [MarkupFilter] with rewrite. The returned channel is closed when
+// text is closed, ctx is cancelled, or stop is closed.
+func filterMarkup(ctx context.Context, stop <-chan struct{}, text <-chan string, rewrite func(Tag) string) <-chan string {
+	out := make(chan string, cap(text))
+	go func() {
+		defer close(out)
+		f := NewMarkupFilter(rewrite)
+		send := func(s string) bool {
+			if s == "" {
+				return true
+			}
+			select {
+			case out <- s:
+				return true
+			case <-ctx.Done():
+			case <-stop:
+			}
+			return false
+		}
+		for {
+			select {
+			case fragment, ok := <-text:
+				if !ok {
+					send(f.Flush())
+					return
+				}
+				if !send(f.Write(fragment)) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// isASCIILetter reports whether c is an ASCII letter.
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
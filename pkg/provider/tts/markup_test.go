@@ -0,0 +1,239 @@
+package tts_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/mock"
+)
+
+func TestTagLength(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{s: `<break time="1s"/> then`, want: 18},
+		{s: `</emphasis>`, want: 11},
+		{s: `<prosody rate="slow`, want: -1},
+		{s: `<`, want: 0},
+		{s: `<3 you`, want: 0},
+		{s: `< 5`, want: 0},
+		{s: `plain`, want: 0},
+	}
+	for _, tc := range tests {
+		if got := tts.TagLength(tc.s); got != tc.want {
+			t.Errorf("TagLength(%q) = %d, want %d", tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw  string
+		want tts.Tag
+		ok   bool
+	}{
+		{
+			raw:  `<break time="1.5s"/>`,
+			want: tts.Tag{Name: "break", SelfClosing: true, Attrs: map[string]string{"time": "1.5s"}},
+			ok:   true,
+		},
+		{
+			raw:  `<Prosody RATE='slow' pitch=+2st>`,
+			want: tts.Tag{Name: "prosody", Attrs: map[string]string{"rate": "slow", "pitch": "+2st"}},
+			ok:   true,
+		},
+		{
+			raw:  `</emphasis>`,
+			want: tts.Tag{Name: "emphasis", Closing: true, Attrs: map[string]string{}},
+			ok:   true,
+		},
+		{raw: `<break`},
+		{raw: `plain`},
+	}
+	for _, tc := range tests {
+		got, ok := tts.ParseTag(tc.raw)
+		if ok != tc.ok {
+			t.Errorf("ParseTag(%q) ok = %v, want %v", tc.raw, ok, tc.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if got.Name != tc.want.Name || got.Closing != tc.want.Closing || got.SelfClosing != tc.want.SelfClosing || len(got.Attrs) != len(tc.want.Attrs) {
+			t.Errorf("ParseTag(%q) = %+v, want %+v", tc.raw, got, tc.want)
+			continue
+		}
+		for k, v := range tc.want.Attrs {
+			if got.Attrs[k] != v {
+				t.Errorf("ParseTag(%q).Attrs[%q] = %q, want %q", tc.raw, k, got.Attrs[k], v)
+			}
+		}
+	}
+}
+
+func TestBreakDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw  string
+		want time.Duration
+		ok   bool
+	}{
+		{raw: `<break time="1.5s"/>`, want: 1500 * time.Millisecond, ok: true},
+		{raw: `<break time="250ms" strength="x-strong"/>`, want: 250 * time.Millisecond, ok: true},
+		{raw: `<break strength="weak"/>`, want: 250 * time.Millisecond, ok: true},
+		{raw: `<break/>`, want: 500 * time.Millisecond, ok: true},
+		{raw: `<break time="soon"/>`},
+		{raw: `<break strength="huge"/>`},
+		{raw: `<emphasis>`},
+	}
+	for _, tc := range tests {
+		tag, _ := tts.ParseTag(tc.raw)
+		got, ok := tts.BreakDuration(tag)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("BreakDuration(%s) = %v, %v, want %v, %v", tc.raw, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestMarkupFilter(t *testing.T) {
+	t.Parallel()
+
+	keepBreaks := func(tag tts.Tag) string {
+		if d, ok := tts.BreakDuration(tag); ok {
+			return "[" + d.String() + "]"
+		}
+		return ""
+	}
+	tests := []struct {
+		name      string
+		rewrite   func(tts.Tag) string
+		fragments []string
+		want      string
+	}{
+		{
+			name:      "strip",
+			fragments: []string{`Run! <emphasis level="strong">Now</emphasis>.`},
+			want:      "Run! Now.",
+		},
+		{
+			name:      "tag split across fragments",
+			fragments: []string{"Wait", `<break ti`, `me="1.5s"/>`, " here."},
+			want:      "Wait here.",
+		},
+		{
+			name:      "rewrite",
+			rewrite:   keepBreaks,
+			fragments: []string{"Wait.", "<", `break time="2s"/> <prosody rate="slow">Now.</prosody>`},
+			want:      "Wait.[2s] Now.",
+		},
+		{
+			name:      "less-than sign",
+			fragments: []string{"3 <", " 5 and I <", "3 you"},
+			want:      "3 < 5 and I <3 you",
+		},
+		{
+			name:      "unterminated tag",
+			fragments: []string{"a <b c"},
+			want:      "a <b c",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f := tts.NewMarkupFilter(tc.rewrite)
+			var got strings.Builder
+			for _, fragment := range tc.fragments {
+				got.WriteString(f.Write(fragment))
+			}
+			got.WriteString(f.Flush())
+			if got.String() != tc.want {
+				t.Errorf("filtered = %q, want %q", got.String(), tc.want)
+			}
+		})
+	}
+}
+
+// textRecorder is a provider without markup support that records the text
+// it is asked to synthesise.
+type textRecorder struct {
+	mock.Provider
+	text chan string
+}
+
+func (r *textRecorder) SynthesizeStream(_ context.Context, text <-chan string, _ tts.VoiceProfile) (*tts.Stream, error) {
+	go func() {
+		var b strings.Builder
+		for s := range text {
+			b.WriteString(s)
+		}
+		r.text <- b.String()
+	}()
+	audio := make(chan []byte)
+	close(audio)
+	return tts.NewStream(audio), nil
+}
+
+// markupProvider is a provider with markup support.
+type markupProvider struct {
+	mock.Provider
+	called bool
+}
+
+func (m *markupProvider) SynthesizeMarkupStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	m.called = true
+	return m.SynthesizeStream(ctx, text, voice)
+}
+
+func TestSynthesizeMarkup(t *testing.T) {
+	t.Parallel()
+
+	fragments := func() <-chan string {
+		ch := make(chan string, 3)
+		ch <- `Hold `
+		ch <- `<break time="1s"/><emphasis>still</emph`
+		ch <- `asis>.`
+		close(ch)
+		return ch
+	}
+
+	t.Run("stripped for plain providers", func(t *testing.T) {
+		t.Parallel()
+		p := &textRecorder{text: make(chan string, 1)}
+		if _, err := tts.SynthesizeMarkup(context.Background(), p, fragments(), tts.VoiceProfile{}); err != nil {
+			t.Fatalf("SynthesizeMarkup: %v", err)
+		}
+		if got := <-p.text; got != "Hold still." {
+			t.Errorf("provider received %q, want %q", got, "Hold still.")
+		}
+	})
+
+	t.Run("capability", func(t *testing.T) {
+		t.Parallel()
+		p := &markupProvider{}
+		if _, err := tts.SynthesizeMarkup(context.Background(), p, fragments(), tts.VoiceProfile{}); err != nil {
+			t.Fatalf("SynthesizeMarkup: %v", err)
+		}
+		if !p.called {
+			t.Error("SynthesizeMarkupStream was not used")
+		}
+	})
+
+	t.Run("start error", func(t *testing.T) {
+		t.Parallel()
+		errDown := errors.New("down")
+		p := &mock.Provider{SynthesizeErr: errDown}
+		if _, err := tts.SynthesizeMarkup(context.Background(), p, make(chan string), tts.VoiceProfile{}); !errors.Is(err, errDown) {
+			t.Errorf("error = %v, want %v", err, errDown)
+		}
+	})
+}
@@ -9,7 +9,7 @@
 //	    SynthesizeChunks: [][]byte{[]byte("audio1"), []byte("audio2")},
 //	    ListVoicesResult: []tts.VoiceProfile{{ID: "v1", Name: "Alice"}},
 //	}
-//	ch, _ := p.SynthesizeStream(ctx, textCh, voice)
+//	stream, _ := p.SynthesizeStream(ctx, textCh, voice)
 package mock
 
 import (
@@ -54,9 +54,13 @@ type Provider struct {
 	SynthesizeChunks [][]byte
 
 	// SynthesizeErr, if non-nil, is returned as the error from SynthesizeStream
-	// instead of starting a channel.
+	// instead of starting a stream.
 	SynthesizeErr error
 
+	// StreamErr, if non-nil, ends every stream as a mid-stream failure: it is
+	// reported by [tts.Stream.Err] after SynthesizeChunks have been emitted.
+	StreamErr error
+
 	// ListVoicesResult is returned by ListVoices.
 	ListVoicesResult []tts.VoiceProfile
 
@@ -69,6 +73,10 @@ type Provider struct {
 	// CloneVoiceErr, if non-nil, is returned as the error from CloneVoice.
 	CloneVoiceErr error
 
+	// SampleRateResult is returned by SampleRate. Zero, the default, reports
+	// an unknown sample rate.
+	SampleRateResult int
+
 	// --- Call records ---
 
 	// SynthesizeStreamCalls records every call to SynthesizeStream in order.
@@ -82,8 +90,8 @@ type Provider struct {
 }
 
 // SynthesizeStream records the call and, if SynthesizeErr is nil, returns a
-// channel that emits SynthesizeChunks then closes.
-func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (<-chan []byte, error) {
+// stream that emits SynthesizeChunks, records StreamErr, then closes.
+func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
 	p.mu.Lock()
 	if p.SynthesizeErr != nil {
 		err := p.SynthesizeErr
@@ -93,10 +101,12 @@ func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voi
 	}
 	chunks := make([][]byte, len(p.SynthesizeChunks))
 	copy(chunks, p.SynthesizeChunks)
+	streamErr := p.StreamErr
 	p.SynthesizeStreamCalls = append(p.SynthesizeStreamCalls, SynthesizeStreamCall{Ctx: ctx, Text: text, Voice: voice})
 	p.mu.Unlock()
 
 	ch := make(chan []byte, len(chunks))
+	stream := tts.NewStream(ch)
 	go func() {
 		defer close(ch)
 		// Drain the incoming text channel to simulate real behaviour and avoid
@@ -112,8 +122,9 @@ func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voi
 			case ch <- audio:
 			}
 		}
+		stream.SetErr(streamErr)
 	}()
-	return ch, nil
+	return stream, nil
 }
 
 // ListVoices records the call and returns ListVoicesResult, ListVoicesErr.
@@ -134,6 +145,13 @@ func (p *Provider) CloneVoice(ctx context.Context, samples [][]byte) (*tts.Voice
 	return p.CloneVoiceResult, p.CloneVoiceErr
 }
 
+// SampleRate implements [tts.FormatReporter] and returns SampleRateResult.
+func (p *Provider) SampleRate() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.SampleRateResult
+}
+
 // Reset clears all recorded calls. Thread-safe.
 func (p *Provider) Reset() {
 	p.mu.Lock()
@@ -143,5 +161,8 @@ func (p *Provider) Reset() {
 	p.CloneVoiceCalls = nil
 }
 
-// Ensure Provider implements tts.Provider at compile time.
-var _ tts.Provider = (*Provider)(nil)
+// Ensure Provider implements tts.Provider and tts.FormatReporter at compile time.
+var (
+	_ tts.Provider       = (*Provider)(nil)
+	_ tts.FormatReporter = (*Provider)(nil)
+)
@@ -0,0 +1,260 @@
+package onnx
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// ArchKokoro is the architecture of Kokoro models
+// (https://huggingface.co/hexgrad/Kokoro-82M), such as the ONNX exports at
+// https://huggingface.co/onnx-community/Kokoro-82M-v1.0-ONNX.
+//
+// The model speaks at 24 kHz with a style vector taken from a voice pack.
+// [LoadConfig.ConfigPath] is the model's config.json, whose "vocab" maps
+// phoneme symbols to token IDs; a tokenizer.json with the vocabulary under
+// "model" works too. It defaults to config.json next to the model.
+// [LoadConfig.VoicesPath] is a directory of voice packs named after their
+// voice, such as af_heart.bin, each holding little-endian float32 style
+// vectors of 256 values; it defaults to the voices directory next to the
+// model. The default voice is af_heart if present, otherwise the first in
+// alphabetical order.
+const ArchKokoro = "kokoro"
+
+const (
+	kokoroSampleRate = 24000
+
+	// kokoroStyleDim is the length of one style vector.
+	kokoroStyleDim = 256
+
+	// kokoroMaxTokens is the most tokens Kokoro synthesises at once. Longer
+	// input is synthesised in pieces.
+	kokoroMaxTokens = 510
+
+	kokoroDefaultVoice = "af_heart"
+)
+
+// kokoroLanguages maps the first letter of a Kokoro voice name to the
+// espeak-ng voice its language is phonemized with.
+var kokoroLanguages = map[byte]string{
+	'a': "en-us",
+	'b': "en-gb",
+	'e': "es",
+	'f': "fr-fr",
+	'h': "hi",
+	'i': "it",
+	'j': "ja",
+	'p': "pt-br",
+	'z': "cmn",
+}
+
+// kokoro is a [Model] of the [ArchKokoro] architecture.
+type kokoro struct {
+	vocab  map[rune]int64
+	voices map[string][]float32 // style vectors by voice, concatenated
+	order  []string             // voice IDs, default first
+
+	// infer runs the model on one padded token sequence.
+	infer func(tokens []int64, style []float32, speed float32) ([]float32, error)
+	close func() error
+}
+
+// loadKokoro implements [LoadFunc] for [ArchKokoro].
+func loadKokoro(modelPath string, cfg LoadConfig) (Model, error) {
+	dir := filepath.Dir(modelPath)
+	vocab, err := readKokoroVocab(cmp.Or(cfg.ConfigPath, filepath.Join(dir, "config.json")))
+	if err != nil {
+		return nil, err
+	}
+	voices, err := readKokoroVoices(cmp.Or(cfg.VoicesPath, filepath.Join(dir, "voices")))
+	if err != nil {
+		return nil, err
+	}
+
+	// Older exports name the token input "tokens".
+	names, err := inputNames(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	tokenInput := "input_ids"
+	if slices.Contains(names, "tokens") {
+		tokenInput = "tokens"
+	}
+	s, err := newSession(modelPath, []string{tokenInput, "style", "speed"}, []string{"waveform"})
+	if err != nil {
+		return nil, err
+	}
+
+	k := newKokoro(vocab, voices)
+	k.infer = func(tokens []int64, style []float32, speed float32) ([]float32, error) {
+		in, err := ort.NewTensor(ort.NewShape(1, int64(len(tokens))), tokens)
+		if err != nil {
+			return nil, fmt.Errorf("kokoro: create token tensor: %w", err)
+		}
+		st, err := ort.NewTensor(ort.NewShape(1, kokoroStyleDim), style)
+		if err != nil {
+			in.Destroy()
+			return nil, fmt.Errorf("kokoro: create style tensor: %w", err)
+		}
+		sp, err := ort.NewTensor(ort.NewShape(1), []float32{speed})
+		if err != nil {
+			in.Destroy()
+			st.Destroy()
+			return nil, fmt.Errorf("kokoro: create speed tensor: %w", err)
+		}
+		return runFloat32(s, in, st, sp)
+	}
+	k.close = s.Destroy
+	return k, nil
+}
+
+// newKokoro returns a kokoro model with the given vocabulary and voices,
+// without an inference function.
+func newKokoro(vocab map[rune]int64, voices map[string][]float32) *kokoro {
+	order := make([]string, 0, len(voices))
+	for id := range voices {
+		order = append(order, id)
+	}
+	slices.Sort(order)
+	if i := slices.Index(order, kokoroDefaultVoice); i > 0 {
+		order = slices.Insert(slices.Delete(order, i, i+1), 0, kokoroDefaultVoice)
+	}
+	return &kokoro{vocab: vocab, voices: voices, order: order}
+}
+
+// Synthesize implements [Model]. Phonemes missing from the vocabulary are
+// skipped. Input longer than the model's 510-token limit is synthesised in
+// pieces, which are concatenated.
+func (k *kokoro) Synthesize(ctx context.Context, phonemes string, params Params) ([]float32, error) {
+	voice := cmp.Or(params.Voice, k.order[0])
+	pack, ok := k.voices[voice]
+	if !ok {
+		return nil, fmt.Errorf("kokoro: unknown voice %q", voice)
+	}
+	speed := float32(params.Speed)
+	if speed == 0 {
+		speed = 1
+	}
+
+	var tokens []int64
+	for _, r := range phonemes {
+		if id, ok := k.vocab[r]; ok {
+			tokens = append(tokens, id)
+		}
+	}
+
+	var out []float32
+	for piece := range slices.Chunk(tokens, kokoroMaxTokens) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// The style vector depends on the length of the piece; the pad
+		// token 0 frames it.
+		padded := append(append([]int64{0}, piece...), 0)
+		samples, err := k.infer(padded, kokoroStyle(pack, len(piece)), speed)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, samples...)
+	}
+	return out, nil
+}
+
+// kokoroStyle returns the style vector of pack for n tokens, or its last one
+// if pack holds fewer than n+1 vectors.
+func kokoroStyle(pack []float32, n int) []float32 {
+	rows := len(pack) / kokoroStyleDim
+	row := min(n, rows-1)
+	return pack[row*kokoroStyleDim : (row+1)*kokoroStyleDim]
+}
+
+// SampleRate implements [Model].
+func (k *kokoro) SampleRate() int { return kokoroSampleRate }
+
+// Voices implements [Model]. Each voice's language follows from the first
+// letter of its name, as in "af_heart" for American English.
+func (k *kokoro) Voices() []Voice {
+	voices := make([]Voice, len(k.order))
+	for i, id := range k.order {
+		voices[i] = Voice{ID: id, Language: kokoroLanguages[id[0]]}
+	}
+	return voices
+}
+
+// Close implements [Model].
+func (k *kokoro) Close() error {
+	if k.close == nil {
+		return nil
+	}
+	return k.close()
+}
+
+// readKokoroVocab reads the phoneme vocabulary from the config.json or
+// tokenizer.json at path.
+func readKokoroVocab(path string) (map[rune]int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("kokoro: read config: %w", err)
+	}
+	var cfg struct {
+		Vocab map[string]int64 `json:"vocab"`
+		Model struct {
+			Vocab map[string]int64 `json:"vocab"`
+		} `json:"model"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("kokoro: decode config %s: %w", path, err)
+	}
+	symbols := cfg.Vocab
+	if len(symbols) == 0 {
+		symbols = cfg.Model.Vocab
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("kokoro: config %s has no vocab", path)
+	}
+	vocab := make(map[rune]int64, len(symbols))
+	for sym, id := range symbols {
+		r := []rune(sym)
+		if len(r) != 1 {
+			continue
+		}
+		vocab[r[0]] = id
+	}
+	return vocab, nil
+}
+
+// readKokoroVoices reads every voice pack (*.bin) in dir.
+func readKokoroVoices(dir string) (map[string][]float32, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.bin"))
+	if err != nil {
+		return nil, fmt.Errorf("kokoro: list voices: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("kokoro: no voice packs (*.bin) in %s", dir)
+	}
+	voices := make(map[string][]float32, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("kokoro: read voice: %w", err)
+		}
+		if len(data) == 0 || len(data)%(4*kokoroStyleDim) != 0 {
+			return nil, fmt.Errorf("kokoro: voice %s: size %d is not a multiple of %d float32 values", path, len(data), kokoroStyleDim)
+		}
+		pack := make([]float32, len(data)/4)
+		for i := range pack {
+			pack[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+		}
+		voices[strings.TrimSuffix(filepath.Base(path), ".bin")] = pack
+	}
+	return voices, nil
+}
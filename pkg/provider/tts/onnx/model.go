@@ -0,0 +1,185 @@
+package onnx
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// Model is a loaded neural TTS model that turns phonemes into speech. Models
+// of the built-in architectures are loaded by [New]; other models plug in
+// through [Register] or [NewWithModel]. Implementations must be safe for
+// concurrent use.
+type Model interface {
+	// Synthesize renders phonemes, an IPA string as produced by a
+	// [Phonemizer], as mono samples in [-1, 1] at [Model.SampleRate].
+	Synthesize(ctx context.Context, phonemes string, params Params) ([]float32, error)
+
+	// SampleRate returns the sample rate in Hz of the synthesised samples.
+	SampleRate() int
+
+	// Voices lists the voices the model can speak, default voice first.
+	Voices() []Voice
+
+	// Close releases the model.
+	Close() error
+}
+
+// Params selects how a [Model] speaks.
+type Params struct {
+	// Voice is the ID of one of the model's [Model.Voices]. Empty uses the
+	// model's default voice.
+	Voice string
+
+	// Speaker is the speaker ID used by multi-speaker models when Voice is
+	// empty. Models with a single speaker ignore it.
+	Speaker int
+
+	// Speed is the speaking rate; 1 is the model's natural rate. Zero means 1.
+	Speed float64
+}
+
+// Voice is a voice of a [Model].
+type Voice struct {
+	// ID selects the voice in [Params.Voice].
+	ID string
+
+	// Language is the espeak-ng voice the text is phonemized with, such as
+	// "en-us" or "fr-fr". Empty uses the provider's language (see
+	// [WithLanguage]).
+	Language string
+}
+
+// LoadConfig holds the files besides the ONNX model that a [LoadFunc] may
+// need. Each architecture documents its defaults for empty paths.
+type LoadConfig struct {
+	// ConfigPath is the model's JSON config, holding at least its phoneme
+	// vocabulary.
+	ConfigPath string
+
+	// VoicesPath holds the model's voice embeddings, for architectures that
+	// keep them outside the ONNX graph.
+	VoicesPath string
+}
+
+// LoadFunc loads the ONNX model at modelPath. ONNX Runtime is initialised
+// before it is called.
+type LoadFunc func(modelPath string, cfg LoadConfig) (Model, error)
+
+// architectures holds the registered [LoadFunc] of every architecture.
+var architectures = struct {
+	mu sync.RWMutex
+	m  map[string]LoadFunc
+}{m: make(map[string]LoadFunc)}
+
+// Register makes the architecture name available to [WithArchitecture], so
+// other ONNX TTS models can be used with the provider. It panics if load is
+// nil or name is already registered. "kokoro" and "vits" are built in.
+func Register(name string, load LoadFunc) {
+	architectures.mu.Lock()
+	defer architectures.mu.Unlock()
+	if load == nil {
+		panic("onnx: Register load func is nil")
+	}
+	if _, dup := architectures.m[name]; dup {
+		panic(fmt.Sprintf("onnx: Register called twice for architecture %q", name))
+	}
+	architectures.m[name] = load
+}
+
+// Architectures returns the names of the registered architectures in sorted
+// order.
+func Architectures() []string {
+	architectures.mu.RLock()
+	defer architectures.mu.RUnlock()
+	names := make([]string, 0, len(architectures.m))
+	for name := range architectures.m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// loadFunc returns the [LoadFunc] registered for name.
+func loadFunc(name string) (LoadFunc, bool) {
+	architectures.mu.RLock()
+	defer architectures.mu.RUnlock()
+	load, ok := architectures.m[name]
+	return load, ok
+}
+
+func init() {
+	Register(ArchKokoro, loadKokoro)
+	Register(ArchVITS, loadVITS)
+}
+
+// ── ONNX Runtime ─────────────────────────────────────────────────────────────
+
+// ortInit initialises the process-wide ONNX Runtime environment once.
+var ortInit struct {
+	once sync.Once
+	err  error
+}
+
+// initRuntime initialises ONNX Runtime with the shared library at libPath,
+// or the default library if it is empty. Only the first call has an effect;
+// a runtime already initialised elsewhere in the process is reused.
+func initRuntime(libPath string) error {
+	ortInit.once.Do(func() {
+		if ort.IsInitialized() {
+			return
+		}
+		if libPath != "" {
+			ort.SetSharedLibraryPath(libPath)
+		}
+		if err := ort.InitializeEnvironment(); err != nil {
+			ortInit.err = fmt.Errorf("onnx: initialise ONNX Runtime: %w", err)
+		}
+	})
+	return ortInit.err
+}
+
+// newSession opens the model at path with the given input and output names.
+func newSession(path string, inputs, outputs []string) (*ort.DynamicAdvancedSession, error) {
+	s, err := ort.NewDynamicAdvancedSession(path, inputs, outputs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("onnx: load model %q: %w", path, err)
+	}
+	return s, nil
+}
+
+// inputNames returns the names of the inputs of the model at path.
+func inputNames(path string) ([]string, error) {
+	inputs, _, err := ort.GetInputOutputInfo(path)
+	if err != nil {
+		return nil, fmt.Errorf("onnx: read model %q: %w", path, err)
+	}
+	names := make([]string, len(inputs))
+	for i, in := range inputs {
+		names[i] = in.Name
+	}
+	return names, nil
+}
+
+// runFloat32 runs s on inputs and returns a copy of its single float32
+// output. The inputs are destroyed.
+func runFloat32(s *ort.DynamicAdvancedSession, inputs ...ort.Value) ([]float32, error) {
+	defer func() {
+		for _, in := range inputs {
+			in.Destroy()
+		}
+	}()
+	outputs := []ort.Value{nil}
+	if err := s.Run(inputs, outputs); err != nil {
+		return nil, fmt.Errorf("onnx: run model: %w", err)
+	}
+	defer outputs[0].Destroy()
+	out, ok := outputs[0].(*ort.Tensor[float32])
+	if !ok {
+		return nil, fmt.Errorf("onnx: model output is %T, want a float32 tensor", outputs[0])
+	}
+	return slices.Clone(out.GetData()), nil
+}
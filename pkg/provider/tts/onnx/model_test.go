@@ -0,0 +1,289 @@
+package onnx
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ---- registry ----
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	if got := Architectures(); !slices.Contains(got, ArchKokoro) || !slices.Contains(got, ArchVITS) {
+		t.Errorf("Architectures: got %v, want kokoro and vits", got)
+	}
+	tests := []struct {
+		name string
+		arch string
+		load LoadFunc
+	}{
+		{name: "duplicate", arch: ArchKokoro, load: loadKokoro},
+		{name: "nil load", arch: "test-nil", load: nil},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register %s: expected panic", tt.name)
+				}
+			}()
+			Register(tt.arch, tt.load)
+		}()
+	}
+}
+
+// ---- kokoro ----
+
+// stubKokoro returns a kokoro model whose vocab maps a, b and c to 1, 2 and
+// 3, with the voices af_heart and bf_emma holding two style vectors, and whose
+// inference records its inputs in calls.
+func stubKokoro(calls *[]kokoroCall) *kokoro {
+	pack := func(base float32) []float32 {
+		p := make([]float32, 2*kokoroStyleDim)
+		for i := range p {
+			p[i] = base + float32(i/kokoroStyleDim)
+		}
+		return p
+	}
+	k := newKokoro(map[rune]int64{'a': 1, 'b': 2, 'c': 3}, map[string][]float32{
+		"bf_emma":  pack(10),
+		"af_heart": pack(20),
+	})
+	k.infer = func(tokens []int64, style []float32, speed float32) ([]float32, error) {
+		*calls = append(*calls, kokoroCall{tokens: tokens, style: style[0], speed: speed})
+		return []float32{float32(len(tokens))}, nil
+	}
+	return k
+}
+
+// kokoroCall records one inference: the tokens, the first value of the style
+// vector and the speed.
+type kokoroCall struct {
+	tokens []int64
+	style  float32
+	speed  float32
+}
+
+func TestKokoro_Synthesize(t *testing.T) {
+	t.Parallel()
+
+	var calls []kokoroCall
+	k := stubKokoro(&calls)
+	if got := k.Voices(); len(got) != 2 || got[0] != (Voice{ID: "af_heart", Language: "en-us"}) || got[1] != (Voice{ID: "bf_emma", Language: "en-gb"}) {
+		t.Errorf("Voices: got %+v", got)
+	}
+
+	out, err := k.Synthesize(context.Background(), "ab x c", Params{})
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("got %d calls, want 1", len(calls))
+	}
+	// Unknown symbols are dropped; the style row is clamped to the last one.
+	if want := []int64{0, 1, 2, 3, 0}; !slices.Equal(calls[0].tokens, want) {
+		t.Errorf("tokens: got %v, want %v", calls[0].tokens, want)
+	}
+	if calls[0].style != 21 || calls[0].speed != 1 {
+		t.Errorf("style %v speed %v, want 21 and 1", calls[0].style, calls[0].speed)
+	}
+	if !slices.Equal(out, []float32{5}) {
+		t.Errorf("samples: got %v", out)
+	}
+
+	calls = nil
+	if _, err := k.Synthesize(context.Background(), "a", Params{Voice: "bf_emma", Speed: 1.5}); err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if calls[0].style != 11 || calls[0].speed != 1.5 {
+		t.Errorf("style %v speed %v, want 11 and 1.5", calls[0].style, calls[0].speed)
+	}
+
+	if _, err := k.Synthesize(context.Background(), "a", Params{Voice: "zz_nobody"}); err == nil {
+		t.Error("unknown voice: expected error")
+	}
+}
+
+func TestKokoro_SynthesizeChunks(t *testing.T) {
+	t.Parallel()
+
+	var calls []kokoroCall
+	k := stubKokoro(&calls)
+	out, err := k.Synthesize(context.Background(), strings.Repeat("a", kokoroMaxTokens+3), Params{})
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if len(calls) != 2 || len(calls[0].tokens) != kokoroMaxTokens+2 || len(calls[1].tokens) != 5 {
+		t.Fatalf("got %d calls", len(calls))
+	}
+	if !slices.Equal(out, []float32{kokoroMaxTokens + 2, 5}) {
+		t.Errorf("samples: got %v", out)
+	}
+}
+
+func TestReadKokoroFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(cfg, []byte(`{"vocab": {"a": 43, "ə": 83, "ab": 1}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	vocab, err := readKokoroVocab(cfg)
+	if err != nil {
+		t.Fatalf("readKokoroVocab: %v", err)
+	}
+	if len(vocab) != 2 || vocab['a'] != 43 || vocab['ə'] != 83 {
+		t.Errorf("vocab: got %v", vocab)
+	}
+	tokenizer := filepath.Join(dir, "tokenizer.json")
+	if err := os.WriteFile(tokenizer, []byte(`{"model": {"vocab": {"a": 7}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if vocab, err := readKokoroVocab(tokenizer); err != nil || vocab['a'] != 7 {
+		t.Errorf("readKokoroVocab tokenizer: got %v, %v", vocab, err)
+	}
+	empty := filepath.Join(dir, "empty.json")
+	if err := os.WriteFile(empty, []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readKokoroVocab(empty); err == nil {
+		t.Error("readKokoroVocab without vocab: expected error")
+	}
+
+	voices := filepath.Join(dir, "voices")
+	if err := os.Mkdir(voices, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readKokoroVoices(voices); err == nil {
+		t.Error("readKokoroVoices without packs: expected error")
+	}
+	var data []byte
+	for i := range kokoroStyleDim {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(float32(i)/2))
+	}
+	if err := os.WriteFile(filepath.Join(voices, "af_heart.bin"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	packs, err := readKokoroVoices(voices)
+	if err != nil {
+		t.Fatalf("readKokoroVoices: %v", err)
+	}
+	if p := packs["af_heart"]; len(p) != kokoroStyleDim || p[3] != 1.5 {
+		t.Errorf("voice pack: got %d values", len(p))
+	}
+	if err := os.WriteFile(filepath.Join(voices, "bad.bin"), data[:10], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readKokoroVoices(voices); err == nil {
+		t.Error("truncated voice pack: expected error")
+	}
+}
+
+// ---- vits ----
+
+// writeVITSConfig writes a Piper voice config to a temporary file and
+// returns its path.
+func writeVITSConfig(t *testing.T, cfg string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "voice.onnx.json")
+	if err := os.WriteFile(path, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadVITSConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := readVITSConfig(writeVITSConfig(t, `{"espeak": {"voice": "de"}, "inference": {"noise_scale": 0.5}, "phoneme_id_map": {"a": [14]}}`))
+	if err != nil {
+		t.Fatalf("readVITSConfig: %v", err)
+	}
+	inf := cfg.Inference
+	if cfg.Audio.SampleRate != vitsSampleRate || inf.NoiseScale != 0.5 || inf.LengthScale != vitsLengthScale || inf.NoiseW != vitsNoiseW {
+		t.Errorf("defaults: got %+v", cfg)
+	}
+	if _, err := readVITSConfig(writeVITSConfig(t, `{"audio": {"sample_rate": 16000}}`)); err == nil {
+		t.Error("config without phoneme_id_map: expected error")
+	}
+}
+
+func TestVITS_Synthesize(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := readVITSConfig(writeVITSConfig(t, `{
+		"espeak": {"voice": "en-us"},
+		"phoneme_id_map": {"^": [1], "$": [2], "_": [0], "h": [20], "i": [21]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotIDs []int64
+	var gotScales [3]float32
+	var gotSpeaker int
+	v := &vits{name: "en_US-lessac-medium", cfg: cfg}
+	v.infer = func(ids []int64, scales [3]float32, speaker int) ([]float32, error) {
+		gotIDs, gotScales, gotSpeaker = ids, scales, speaker
+		return []float32{0}, nil
+	}
+
+	if _, err := v.Synthesize(context.Background(), "hi!", Params{Speed: 2}); err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if want := []int64{1, 0, 20, 0, 21, 0, 2}; !slices.Equal(gotIDs, want) {
+		t.Errorf("ids: got %v, want %v", gotIDs, want)
+	}
+	if want := [3]float32{vitsNoiseScale, 0.5, vitsNoiseW}; gotScales != want {
+		t.Errorf("scales: got %v, want %v", gotScales, want)
+	}
+	if gotSpeaker != -1 {
+		t.Errorf("speaker: got %d, want -1", gotSpeaker)
+	}
+	if got := v.Voices(); len(got) != 1 || got[0] != (Voice{ID: "en_US-lessac-medium", Language: "en-us"}) {
+		t.Errorf("Voices: got %+v", got)
+	}
+	if _, err := v.Synthesize(context.Background(), "hi", Params{Voice: "other"}); err == nil {
+		t.Error("foreign voice: expected error")
+	}
+}
+
+func TestVITS_Speaker(t *testing.T) {
+	t.Parallel()
+
+	v := &vits{cfg: vitsConfig{NumSpeakers: 3, SpeakerIDMap: map[string]int{"p225": 2, "p226": 0, "p227": 1}}}
+	tests := []struct {
+		params  Params
+		want    int
+		wantErr bool
+	}{
+		{params: Params{}, want: 0},
+		{params: Params{Speaker: 2}, want: 2},
+		{params: Params{Voice: "p227", Speaker: 2}, want: 1},
+		{params: Params{Voice: "2"}, want: 2},
+		{params: Params{Voice: "3"}, wantErr: true},
+		{params: Params{Speaker: -1}, wantErr: true},
+		{params: Params{Voice: "nobody"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := v.speaker(tt.params)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("speaker(%+v): got %d, %v; want %d, error %v", tt.params, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	var ids []string
+	for _, voice := range v.Voices() {
+		ids = append(ids, voice.ID)
+	}
+	if want := []string{"p226", "p227", "p225"}; !slices.Equal(ids, want) {
+		t.Errorf("Voices: got %v, want %v", ids, want)
+	}
+}
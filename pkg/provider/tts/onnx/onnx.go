@@ -0,0 +1,376 @@
+// Package onnx provides a TTS provider that runs neural text-to-speech models
+// such as Kokoro (https://huggingface.co/hexgrad/Kokoro-82M) in-process
+// through ONNX Runtime. It implements the tts.Provider interface.
+//
+// Synthesis runs in two steps. A [Phonemizer], by default [Espeak], turns each
+// sentence into IPA phonemes, and a [Model] renders the phonemes as speech.
+// Models are loaded by architecture: [ArchKokoro] and [ArchVITS] (Piper
+// voices) are built in, and [Register] adds others, so any ONNX TTS model
+// with a phoneme input can be plugged in. [NewWithModel] accepts a model
+// loaded by other means.
+//
+// Like the Silero VAD, the provider needs the ONNX Runtime shared library at
+// run time (looked up as onnxruntime.so, or onnxruntime.dll on Windows,
+// unless [WithLibraryPath] is given), and the espeak-ng executable for
+// phonemization.
+//
+// The models synthesise one utterance per call, so SynthesizeStream
+// accumulates incoming text fragments into complete sentences and dispatches
+// one synthesis call per sentence with a small lookahead window, emitting the
+// audio in the original sentence order (see package pipeline). The model's
+// output is converted to 16-bit mono PCM and resampled to the output rate set
+// with [WithOutputSampleRate].
+//
+// Typical usage:
+//
+//	p, err := onnx.New("/models/kokoro/model.onnx",
+//	    onnx.WithArchitecture(onnx.ArchKokoro),
+//	    onnx.WithVoice("bf_emma"),
+//	)
+//	defer p.Close()
+//	stream, err := p.SynthesizeStream(ctx, textCh, voiceProfile)
+package onnx
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/pipeline"
+)
+
+// Compile-time interface assertions.
+var (
+	_ tts.Provider       = (*Provider)(nil)
+	_ tts.FormatReporter = (*Provider)(nil)
+)
+
+// ---- constants ----
+
+const (
+	// DefaultOutputSampleRate is the PCM sample rate in Hz emitted when
+	// [WithOutputSampleRate] is not supplied.
+	DefaultOutputSampleRate = 16000
+
+	// DefaultLanguage is the espeak-ng voice text is phonemized with when
+	// neither the model's voice nor [WithLanguage] names one.
+	DefaultLanguage = "en-us"
+
+	// warmupText is synthesised by [Provider.Warmup].
+	warmupText = "Ready."
+)
+
+// ---- options ----
+
+// Option is a functional option for configuring an ONNX Provider.
+type Option func(*Provider)
+
+// WithArchitecture selects the architecture of the model passed to [New], as
+// registered with [Register]. Defaults to [ArchKokoro]. Ignored by
+// [NewWithModel].
+func WithArchitecture(name string) Option {
+	return func(p *Provider) {
+		p.arch = name
+	}
+}
+
+// WithConfig sets the path to the model's JSON config. The default depends
+// on the architecture (see [ArchKokoro] and [ArchVITS]). Ignored by
+// [NewWithModel].
+func WithConfig(path string) Option {
+	return func(p *Provider) {
+		p.load.ConfigPath = path
+	}
+}
+
+// WithVoices sets the path to the model's voice embeddings, for
+// architectures that keep them outside the model (see [ArchKokoro]). Ignored
+// by [NewWithModel].
+func WithVoices(path string) Option {
+	return func(p *Provider) {
+		p.load.VoicesPath = path
+	}
+}
+
+// WithVoice sets the voice synthesised for voice profiles without an ID.
+// Defaults to the model's default voice.
+func WithVoice(id string) Option {
+	return func(p *Provider) {
+		p.voice = id
+	}
+}
+
+// WithSpeaker sets the speaker ID of multi-speaker models used for voice
+// profiles without an ID when [WithVoice] is not set. Defaults to 0.
+func WithSpeaker(id int) Option {
+	return func(p *Provider) {
+		p.speaker = id
+	}
+}
+
+// WithLanguage sets the espeak-ng voice, such as "de" or "en-gb", text is
+// phonemized with when the model's voice does not name a language. Defaults
+// to [DefaultLanguage].
+func WithLanguage(lang string) Option {
+	return func(p *Provider) {
+		p.language = lang
+	}
+}
+
+// WithPhonemizer replaces the default [Espeak] phonemizer.
+func WithPhonemizer(ph Phonemizer) Option {
+	return func(p *Provider) {
+		p.phonemizer = ph
+	}
+}
+
+// WithLibraryPath sets the path of the ONNX Runtime shared library. When
+// empty the platform's default library name is looked up by the dynamic
+// loader. ONNX Runtime is initialised once per process, so only the first
+// path takes effect. Ignored by [NewWithModel].
+func WithLibraryPath(path string) Option {
+	return func(p *Provider) {
+		p.libraryPath = path
+	}
+}
+
+// WithOutputSampleRate sets the sample rate in Hz of the emitted PCM. Audio
+// is resampled from the model's native rate. Defaults to
+// [DefaultOutputSampleRate]. Values < 1 are ignored.
+func WithOutputSampleRate(hz int) Option {
+	return func(p *Provider) {
+		if hz > 0 {
+			p.outputRate = hz
+		}
+	}
+}
+
+// WithConcurrency sets how many sentence synthesis calls may be in flight at
+// the same time. Defaults to [pipeline.DefaultConcurrency]. Values < 1 are
+// ignored.
+func WithConcurrency(n int) Option {
+	return func(p *Provider) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// WithMaxTextLength caps the characters synthesised in one call. Longer
+// sentences are split at clause or word boundaries and synthesised as several
+// calls in order. Values < 1, the default, disable splitting.
+func WithMaxTextLength(n int) Option {
+	return func(p *Provider) {
+		p.maxTextLength = n
+	}
+}
+
+// ---- Provider ----
+
+// Provider implements tts.Provider with an in-process ONNX model. It is safe
+// for concurrent use; multiple SynthesizeStream calls may run in parallel.
+type Provider struct {
+	model         Model
+	phonemizer    Phonemizer
+	arch          string
+	load          LoadConfig
+	libraryPath   string
+	voice         string
+	speaker       int
+	language      string
+	outputRate    int
+	concurrency   int
+	maxTextLength int
+}
+
+// New loads the ONNX model at modelPath with the loader of its architecture
+// (see [WithArchitecture]) and returns a Provider speaking with it. It
+// initialises ONNX Runtime on first use. The caller must call Close when the
+// provider is no longer needed.
+func New(modelPath string, opts ...Option) (*Provider, error) {
+	if modelPath == "" {
+		return nil, errors.New("onnx: modelPath must not be empty")
+	}
+	p := newProvider(opts)
+	load, ok := loadFunc(p.arch)
+	if !ok {
+		return nil, fmt.Errorf("onnx: unknown architecture %q; registered: %s", p.arch, strings.Join(Architectures(), ", "))
+	}
+	if err := initRuntime(p.libraryPath); err != nil {
+		return nil, err
+	}
+	m, err := load(modelPath, p.load)
+	if err != nil {
+		return nil, err
+	}
+	p.model = m
+	if err := p.checkVoice(); err != nil {
+		_ = m.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewWithModel returns a Provider speaking with m, which it closes on Close.
+// Options that only affect loading are ignored.
+func NewWithModel(m Model, opts ...Option) (*Provider, error) {
+	if m == nil {
+		return nil, errors.New("onnx: model must not be nil")
+	}
+	p := newProvider(opts)
+	p.model = m
+	if err := p.checkVoice(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newProvider applies opts to the defaults.
+func newProvider(opts []Option) *Provider {
+	p := &Provider{
+		phonemizer:  Espeak{},
+		arch:        ArchKokoro,
+		language:    DefaultLanguage,
+		outputRate:  DefaultOutputSampleRate,
+		concurrency: pipeline.DefaultConcurrency,
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+// checkVoice returns an error if the model has no voices or does not know
+// the voice set with [WithVoice].
+func (p *Provider) checkVoice() error {
+	voices := p.model.Voices()
+	if len(voices) == 0 {
+		return errors.New("onnx: model has no voices")
+	}
+	if p.voice != "" && !slices.ContainsFunc(voices, func(v Voice) bool { return v.ID == p.voice }) {
+		return fmt.Errorf("onnx: model has no voice %q", p.voice)
+	}
+	return nil
+}
+
+// SampleRate returns the sample rate in Hz of the PCM produced by the provider.
+func (p *Provider) SampleRate() int { return p.outputRate }
+
+// Close releases the model.
+func (p *Provider) Close() error {
+	return p.model.Close()
+}
+
+// ---- SynthesizeStream ----
+
+// SynthesizeStream consumes text fragments from the text channel, accumulates
+// them into complete sentences (split on '.', '!', '?' followed by whitespace
+// or EOF), and synthesises each sentence with one model call. voice.ID selects
+// one of the model's voices; empty uses the configured voice or speaker. A
+// voice.SpeedFactor other than 1.0 changes the speaking rate. The PCM is
+// resampled to the output rate and emitted on the audio channel in the
+// original sentence order.
+//
+// Up to the configured concurrency (see [WithConcurrency]) calls may be in
+// flight at once. Sentence splitting and ordered dispatch are provided by
+// [pipeline.Pipeline].
+//
+// The audio channel is closed when all text has been synthesised, when a
+// sentence fails, or when ctx is cancelled; [tts.Stream.Err] reports the
+// failure. The caller must drain the channel to prevent goroutine leaks.
+func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	if voice.SpeedFactor < 0 {
+		return nil, fmt.Errorf("onnx: speed factor must not be negative, got %g", voice.SpeedFactor)
+	}
+	pl := pipeline.New(func(ctx context.Context, sentence string) ([]byte, error) {
+		return p.synthesize(ctx, sentence, voice)
+	}, pipeline.WithConcurrency(p.concurrency), pipeline.WithMaxSentenceLength(p.maxTextLength))
+	return pl.Run(ctx, text), nil
+}
+
+// Warmup phonemizes and synthesises a short sentence with the configured
+// voice and discards the audio. The first run of an ONNX session allocates its
+// buffers and the first espeak-ng call loads its data, which would otherwise
+// delay the first NPC line.
+func (p *Provider) Warmup(ctx context.Context) error {
+	_, err := p.synthesize(ctx, warmupText, tts.VoiceProfile{})
+	return err
+}
+
+// synthesize phonemizes sentence, renders it with the model and returns its
+// PCM at the output sample rate.
+func (p *Provider) synthesize(ctx context.Context, sentence string, voice tts.VoiceProfile) ([]byte, error) {
+	params := Params{Voice: cmp.Or(voice.ID, p.voice), Speaker: p.speaker, Speed: voice.SpeedFactor}
+	phonemes, err := p.phonemizer.Phonemize(ctx, sentence, p.voiceLanguage(params.Voice))
+	if err != nil {
+		return nil, err
+	}
+	samples, err := p.model.Synthesize(ctx, phonemes, params)
+	if err != nil {
+		return nil, fmt.Errorf("onnx: synthesize: %w", err)
+	}
+	return audio.ResampleMono16(float32ToPCM(samples), p.model.SampleRate(), p.outputRate), nil
+}
+
+// voiceLanguage returns the espeak-ng voice to phonemize text for the model
+// voice id with: the voice's own language, otherwise the configured one. An
+// empty id is the model's default voice.
+func (p *Provider) voiceLanguage(id string) string {
+	voices := p.model.Voices()
+	i := 0
+	if id != "" {
+		i = slices.IndexFunc(voices, func(v Voice) bool { return v.ID == id })
+	}
+	if i >= 0 && i < len(voices) && voices[i].Language != "" {
+		return voices[i].Language
+	}
+	return p.language
+}
+
+// ---- ListVoices ----
+
+// ListVoices returns one VoiceProfile per voice of the model, default voice
+// first.
+func (p *Provider) ListVoices(_ context.Context) ([]tts.VoiceProfile, error) {
+	voices := p.model.Voices()
+	profiles := make([]tts.VoiceProfile, len(voices))
+	for i, v := range voices {
+		profiles[i] = tts.VoiceProfile{
+			ID:       v.ID,
+			Name:     v.ID,
+			Provider: "onnx",
+			Language: cmp.Or(v.Language, p.language),
+			Metadata: map[string]string{
+				"type": "model",
+			},
+		}
+	}
+	return profiles, nil
+}
+
+// ---- CloneVoice ----
+
+// CloneVoice is not supported by ONNX models and always returns an error.
+func (p *Provider) CloneVoice(_ context.Context, _ [][]byte) (*tts.VoiceProfile, error) {
+	return nil, errors.New("onnx: voice cloning is not supported")
+}
+
+// ---- helpers ----
+
+// float32ToPCM converts samples in [-1, 1] to 16-bit little-endian PCM,
+// clipping values outside that range.
+func float32ToPCM(samples []float32) []byte {
+	out := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		v := int16(math.Round(float64(max(-1, min(1, s))) * math.MaxInt16))
+		out[i*2] = byte(v)
+		out[i*2+1] = byte(v >> 8)
+	}
+	return out
+}
@@ -0,0 +1,388 @@
+package onnx
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// ---- test helpers ----
+
+// stubModel is a [Model] that records its calls and returns samples, one
+// call's worth of audio, for every synthesis.
+type stubModel struct {
+	rate    int
+	voices  []Voice
+	samples []float32
+	err     error
+
+	mu     sync.Mutex
+	calls  []stubCall
+	closed bool
+}
+
+// stubCall records one Synthesize call.
+type stubCall struct {
+	phonemes string
+	params   Params
+}
+
+func (m *stubModel) Synthesize(_ context.Context, phonemes string, params Params) ([]float32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, stubCall{phonemes: phonemes, params: params})
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.samples, nil
+}
+
+func (m *stubModel) SampleRate() int { return m.rate }
+func (m *stubModel) Voices() []Voice { return m.voices }
+
+func (m *stubModel) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+// stubPhonemizer is a [Phonemizer] that returns the text upper-cased and
+// records the languages it was asked for.
+type stubPhonemizer struct {
+	mu        sync.Mutex
+	languages []string
+}
+
+func (p *stubPhonemizer) Phonemize(_ context.Context, text, language string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.languages = append(p.languages, language)
+	return strings.ToUpper(text), nil
+}
+
+// newStub returns a model with two voices at rate that renders every call as
+// the samples 0.5 and -1.5.
+func newStub(rate int) *stubModel {
+	return &stubModel{
+		rate:    rate,
+		voices:  []Voice{{ID: "af_heart", Language: "en-us"}, {ID: "custom"}},
+		samples: []float32{0.5, -1.5},
+	}
+}
+
+// sendFragments sends the given text fragments on a freshly-created channel,
+// then closes it.
+func sendFragments(fragments ...string) <-chan string {
+	ch := make(chan string, len(fragments))
+	for _, f := range fragments {
+		ch <- f
+	}
+	close(ch)
+	return ch
+}
+
+// drainAudio reads all chunks from stream until its audio channel is closed
+// and returns the concatenated PCM data.
+func drainAudio(stream *tts.Stream) []byte {
+	var out []byte
+	for chunk := range stream.Audio() {
+		out = append(out, chunk...)
+	}
+	return out
+}
+
+// ---- New ----
+
+func TestNew_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		modelPath string
+		opts      []Option
+		want      string
+	}{
+		{name: "empty path", want: "must not be empty"},
+		{name: "unknown architecture", modelPath: "model.onnx", opts: []Option{WithArchitecture("tacotron")}, want: "kokoro, vits"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := New(tt.modelPath, tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New: error %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWithModel(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewWithModel(nil); err == nil {
+		t.Error("nil model: expected error")
+	}
+	if _, err := NewWithModel(&stubModel{rate: 24000}); err == nil {
+		t.Error("model without voices: expected error")
+	}
+	if _, err := NewWithModel(newStub(24000), WithVoice("missing")); err == nil {
+		t.Error("unknown voice: expected error")
+	}
+
+	p, err := NewWithModel(newStub(24000))
+	if err != nil {
+		t.Fatalf("NewWithModel: %v", err)
+	}
+	if got := p.SampleRate(); got != DefaultOutputSampleRate {
+		t.Errorf("SampleRate: got %d, want %d", got, DefaultOutputSampleRate)
+	}
+	p, err = NewWithModel(newStub(24000), WithOutputSampleRate(48000), WithOutputSampleRate(0))
+	if err != nil {
+		t.Fatalf("NewWithModel: %v", err)
+	}
+	if got := p.SampleRate(); got != 48000 {
+		t.Errorf("SampleRate: got %d, want 48000", got)
+	}
+}
+
+// ---- SynthesizeStream ----
+
+func TestSynthesizeStream_PCM(t *testing.T) {
+	t.Parallel()
+
+	m := newStub(24000)
+	p, err := NewWithModel(m, WithPhonemizer(&stubPhonemizer{}), WithOutputSampleRate(24000), WithConcurrency(1))
+	if err != nil {
+		t.Fatalf("NewWithModel: %v", err)
+	}
+	stream, err := p.SynthesizeStream(context.Background(), sendFragments("Hello there. ", "General Kenobi!"), tts.VoiceProfile{})
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	pcm := drainAudio(stream)
+	if err := stream.Err(); err != nil {
+		t.Fatalf("stream error: %v", err)
+	}
+
+	// Two sentences, each 0.5 and -1.5 clipped to -1.
+	var want []byte
+	for range 2 {
+		for _, v := range []int16{16384, -32767} {
+			want = binary.LittleEndian.AppendUint16(want, uint16(v))
+		}
+	}
+	if string(pcm) != string(want) {
+		t.Errorf("PCM: got %v, want %v", pcm, want)
+	}
+	if len(m.calls) != 2 || m.calls[0].phonemes != "HELLO THERE." || m.calls[1].phonemes != "GENERAL KENOBI!" {
+		t.Errorf("calls: got %+v", m.calls)
+	}
+}
+
+func TestSynthesizeStream_Resample(t *testing.T) {
+	t.Parallel()
+
+	m := newStub(24000)
+	m.samples = make([]float32, 2400) // 100 ms
+	p, err := NewWithModel(m, WithPhonemizer(&stubPhonemizer{}))
+	if err != nil {
+		t.Fatalf("NewWithModel: %v", err)
+	}
+	stream, err := p.SynthesizeStream(context.Background(), sendFragments("Hi."), tts.VoiceProfile{})
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	// 100 ms at 16 kHz is 1600 samples of 2 bytes.
+	if got := len(drainAudio(stream)); got != 3200 {
+		t.Errorf("PCM length: got %d bytes, want 3200", got)
+	}
+}
+
+func TestSynthesizeStream_Params(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		voice    tts.VoiceProfile
+		want     Params
+		language string
+	}{
+		{
+			name:     "defaults",
+			want:     Params{},
+			language: "en-us",
+		},
+		{
+			name:     "profile voice and speed",
+			voice:    tts.VoiceProfile{ID: "custom", SpeedFactor: 1.25},
+			want:     Params{Voice: "custom", Speed: 1.25},
+			language: DefaultLanguage,
+		},
+		{
+			name:     "configured voice, speaker and language",
+			opts:     []Option{WithVoice("custom"), WithSpeaker(3), WithLanguage("de")},
+			want:     Params{Voice: "custom", Speaker: 3},
+			language: "de",
+		},
+		{
+			name:     "voice language wins",
+			opts:     []Option{WithLanguage("de")},
+			voice:    tts.VoiceProfile{ID: "af_heart"},
+			want:     Params{Voice: "af_heart"},
+			language: "en-us",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := newStub(16000)
+			ph := &stubPhonemizer{}
+			p, err := NewWithModel(m, append([]Option{WithPhonemizer(ph)}, tt.opts...)...)
+			if err != nil {
+				t.Fatalf("NewWithModel: %v", err)
+			}
+			stream, err := p.SynthesizeStream(context.Background(), sendFragments("Hello."), tt.voice)
+			if err != nil {
+				t.Fatalf("SynthesizeStream: %v", err)
+			}
+			drainAudio(stream)
+			if len(m.calls) != 1 || m.calls[0].params != tt.want {
+				t.Errorf("params: got %+v, want %+v", m.calls, tt.want)
+			}
+			if len(ph.languages) != 1 || ph.languages[0] != tt.language {
+				t.Errorf("language: got %v, want %q", ph.languages, tt.language)
+			}
+		})
+	}
+}
+
+func TestSynthesizeStream_Errors(t *testing.T) {
+	t.Parallel()
+
+	m := newStub(16000)
+	m.err = errors.New("boom")
+	p, err := NewWithModel(m, WithPhonemizer(&stubPhonemizer{}))
+	if err != nil {
+		t.Fatalf("NewWithModel: %v", err)
+	}
+	if _, err := p.SynthesizeStream(context.Background(), sendFragments("Hi."), tts.VoiceProfile{SpeedFactor: -1}); err == nil {
+		t.Error("negative speed: expected error")
+	}
+
+	stream, err := p.SynthesizeStream(context.Background(), sendFragments("Hi."), tts.VoiceProfile{})
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	drainAudio(stream)
+	if err := stream.Err(); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("stream error: got %v, want boom", err)
+	}
+}
+
+// ---- ListVoices / CloneVoice / Close ----
+
+func TestWarmup(t *testing.T) {
+	t.Parallel()
+
+	m := newStub(24000)
+	p, err := NewWithModel(m, WithPhonemizer(&stubPhonemizer{}), WithVoice("custom"))
+	if err != nil {
+		t.Fatalf("NewWithModel: %v", err)
+	}
+	if err := p.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	m.mu.Lock()
+	calls := m.calls
+	m.mu.Unlock()
+	if len(calls) != 1 || calls[0].params.Voice != "custom" {
+		t.Errorf("Synthesize calls = %+v, want one with the configured voice", calls)
+	}
+
+	m.err = errors.New("session failed")
+	if err := p.Warmup(context.Background()); err == nil {
+		t.Error("Warmup with a failing model: want error, got nil")
+	}
+}
+
+func TestListVoices(t *testing.T) {
+	t.Parallel()
+
+	m := newStub(24000)
+	p, err := NewWithModel(m, WithLanguage("de"))
+	if err != nil {
+		t.Fatalf("NewWithModel: %v", err)
+	}
+	voices, err := p.ListVoices(context.Background())
+	if err != nil {
+		t.Fatalf("ListVoices: %v", err)
+	}
+	if len(voices) != 2 {
+		t.Fatalf("ListVoices: got %d voices, want 2", len(voices))
+	}
+	for i, want := range []tts.VoiceProfile{
+		{ID: "af_heart", Name: "af_heart", Provider: "onnx", Language: "en-us"},
+		{ID: "custom", Name: "custom", Provider: "onnx", Language: "de"},
+	} {
+		got := voices[i]
+		if got.ID != want.ID || got.Name != want.Name || got.Provider != want.Provider || got.Language != want.Language {
+			t.Errorf("voice %d: got %+v, want %+v", i, got, want)
+		}
+	}
+
+	if _, err := p.CloneVoice(context.Background(), nil); err == nil {
+		t.Error("CloneVoice: expected error")
+	}
+	if err := p.Close(); err != nil || !m.closed {
+		t.Errorf("Close: err %v, closed %v", err, m.closed)
+	}
+}
+
+// ---- Espeak ----
+
+func TestEspeak_Phonemize(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake espeak-ng binary requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "espeak-ng")
+	log := filepath.Join(dir, "args.log")
+	script := "#!/bin/sh\n" +
+		"echo \"$@\" > " + log + "\n" +
+		"cat > /dev/null\n" +
+		"printf ' həlˈoʊ\\n  ðɛɹ\\n'\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Espeak{Binary: bin}.Phonemize(context.Background(), "Hello there? ", "en-gb")
+	if err != nil {
+		t.Fatalf("Phonemize: %v", err)
+	}
+	if want := "həlˈoʊ ðɛɹ?"; got != want {
+		t.Errorf("Phonemize: got %q, want %q", got, want)
+	}
+	args, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "-q --ipa -v en-gb --stdin"; strings.TrimSpace(string(args)) != want {
+		t.Errorf("args: got %q, want %q", args, want)
+	}
+
+	if _, err := (Espeak{Binary: filepath.Join(dir, "missing")}).Phonemize(context.Background(), "Hi", "en-us"); err == nil {
+		t.Error("missing binary: expected error")
+	}
+}
@@ -0,0 +1,63 @@
+package onnx
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultEspeakBinary is the espeak-ng executable [Espeak] runs when its
+// Binary is empty. It is looked up in PATH.
+const DefaultEspeakBinary = "espeak-ng"
+
+// Phonemizer converts text to the IPA phonemes a [Model] speaks.
+// Implementations must be safe for concurrent use.
+type Phonemizer interface {
+	// Phonemize returns the phonemes of text spoken in language, an
+	// espeak-ng voice name such as "en-us".
+	Phonemize(ctx context.Context, text, language string) (string, error)
+}
+
+// Espeak is the default [Phonemizer]. It runs the espeak-ng executable
+// (https://github.com/espeak-ng/espeak-ng), which both Kokoro and Piper
+// voices were trained on, once per sentence.
+type Espeak struct {
+	// Binary is the path to the espeak-ng executable. Empty uses
+	// [DefaultEspeakBinary].
+	Binary string
+}
+
+// Compile-time interface assertion.
+var _ Phonemizer = Espeak{}
+
+// Phonemize implements [Phonemizer]. espeak-ng drops punctuation, so the
+// final punctuation mark of text is appended to the phonemes, which lets the
+// model speak questions and exclamations with the right intonation.
+func (e Espeak) Phonemize(ctx context.Context, text, language string) (string, error) {
+	bin := cmp.Or(e.Binary, DefaultEspeakBinary)
+	cmd := exec.CommandContext(ctx, bin, "-q", "--ipa", "-v", language, "--stdin")
+	cmd.Stdin = strings.NewReader(text)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("onnx: run %s: %w", bin, ctx.Err())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("onnx: run %s: %w: %s", bin, err, msg)
+		}
+		return "", fmt.Errorf("onnx: run %s: %w", bin, err)
+	}
+
+	phonemes := strings.Join(strings.Fields(stdout.String()), " ")
+	text = strings.TrimSpace(text)
+	if r, _ := utf8.DecodeLastRuneInString(text); strings.ContainsRune(".!?", r) {
+		phonemes += string(r)
+	}
+	return phonemes, nil
+}
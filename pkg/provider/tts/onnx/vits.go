@@ -0,0 +1,232 @@
+package onnx
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// ArchVITS is the architecture of VITS models exported the way Piper
+// (https://github.com/rhasspy/piper) exports its voices, which covers the
+// Piper voice catalogue and other VITS models trained with Piper.
+//
+// [LoadConfig.ConfigPath] is the voice's JSON config, which holds its sample
+// rate, phoneme IDs, espeak-ng voice, inference scales and, for
+// multi-speaker voices, speaker names. It defaults to the model path with
+// ".json" appended. [LoadConfig.VoicesPath] is unused.
+const ArchVITS = "vits"
+
+const (
+	// vitsSampleRate is assumed for configs that do not state a rate.
+	vitsSampleRate = 22050
+
+	vitsNoiseScale  = 0.667
+	vitsLengthScale = 1
+	vitsNoiseW      = 0.8
+
+	// Special symbols of the phoneme ID map: beginning and end of the
+	// sentence, and the padding inserted after every phoneme.
+	vitsBOS = "^"
+	vitsEOS = "$"
+	vitsPad = "_"
+)
+
+// vitsConfig holds the fields of a Piper voice config the model uses.
+type vitsConfig struct {
+	Audio struct {
+		SampleRate int `json:"sample_rate"`
+	} `json:"audio"`
+	Espeak struct {
+		Voice string `json:"voice"`
+	} `json:"espeak"`
+	Inference struct {
+		NoiseScale  float32 `json:"noise_scale"`
+		LengthScale float32 `json:"length_scale"`
+		NoiseW      float32 `json:"noise_w"`
+	} `json:"inference"`
+	NumSpeakers  int                `json:"num_speakers"`
+	SpeakerIDMap map[string]int     `json:"speaker_id_map"`
+	PhonemeIDMap map[string][]int64 `json:"phoneme_id_map"`
+}
+
+// vits is a [Model] of the [ArchVITS] architecture.
+type vits struct {
+	name string // voice ID of single-speaker models
+	cfg  vitsConfig
+
+	// infer runs the model on one phoneme ID sequence. speaker is negative
+	// for single-speaker models.
+	infer func(ids []int64, scales [3]float32, speaker int) ([]float32, error)
+	close func() error
+}
+
+// loadVITS implements [LoadFunc] for [ArchVITS].
+func loadVITS(modelPath string, lc LoadConfig) (Model, error) {
+	cfg, err := readVITSConfig(cmp.Or(lc.ConfigPath, modelPath+".json"))
+	if err != nil {
+		return nil, err
+	}
+	inputs := []string{"input", "input_lengths", "scales"}
+	if cfg.NumSpeakers > 1 {
+		inputs = append(inputs, "sid")
+	}
+	s, err := newSession(modelPath, inputs, []string{"output"})
+	if err != nil {
+		return nil, err
+	}
+
+	v := &vits{name: strings.TrimSuffix(filepath.Base(modelPath), ".onnx"), cfg: cfg, close: s.Destroy}
+	v.infer = func(ids []int64, scales [3]float32, speaker int) ([]float32, error) {
+		var values []ort.Value
+		destroy := func() {
+			for _, val := range values {
+				val.Destroy()
+			}
+		}
+		in, err := ort.NewTensor(ort.NewShape(1, int64(len(ids))), ids)
+		if err != nil {
+			return nil, fmt.Errorf("vits: create input tensor: %w", err)
+		}
+		values = append(values, in)
+		lengths, err := ort.NewTensor(ort.NewShape(1), []int64{int64(len(ids))})
+		if err != nil {
+			destroy()
+			return nil, fmt.Errorf("vits: create length tensor: %w", err)
+		}
+		values = append(values, lengths)
+		sc, err := ort.NewTensor(ort.NewShape(3), scales[:])
+		if err != nil {
+			destroy()
+			return nil, fmt.Errorf("vits: create scales tensor: %w", err)
+		}
+		values = append(values, sc)
+		if speaker >= 0 {
+			sid, err := ort.NewTensor(ort.NewShape(1), []int64{int64(speaker)})
+			if err != nil {
+				destroy()
+				return nil, fmt.Errorf("vits: create speaker tensor: %w", err)
+			}
+			values = append(values, sid)
+		}
+		return runFloat32(s, values...)
+	}
+	return v, nil
+}
+
+// Synthesize implements [Model]. Phonemes missing from the phoneme ID map
+// are skipped. The speed divides the config's length scale.
+func (v *vits) Synthesize(_ context.Context, phonemes string, params Params) ([]float32, error) {
+	speaker, err := v.speaker(params)
+	if err != nil {
+		return nil, err
+	}
+	speed := float32(params.Speed)
+	if speed == 0 {
+		speed = 1
+	}
+	inf := v.cfg.Inference
+	scales := [3]float32{inf.NoiseScale, inf.LengthScale / speed, inf.NoiseW}
+	return v.infer(v.phonemeIDs(phonemes), scales, speaker)
+}
+
+// phonemeIDs converts phonemes to the model's input: the beginning symbol,
+// then every phoneme followed by padding, then the end symbol.
+func (v *vits) phonemeIDs(phonemes string) []int64 {
+	ids := slices.Clone(v.cfg.PhonemeIDMap[vitsBOS])
+	pad := v.cfg.PhonemeIDMap[vitsPad]
+	ids = append(ids, pad...)
+	for _, r := range phonemes {
+		if id, ok := v.cfg.PhonemeIDMap[string(r)]; ok {
+			ids = append(ids, id...)
+			ids = append(ids, pad...)
+		}
+	}
+	return append(ids, v.cfg.PhonemeIDMap[vitsEOS]...)
+}
+
+// speaker returns the speaker ID selected by params, or -1 for a
+// single-speaker model. A voice is a speaker name of the config or a
+// numeric speaker ID.
+func (v *vits) speaker(params Params) (int, error) {
+	if v.cfg.NumSpeakers <= 1 {
+		if params.Voice != "" && params.Voice != v.name {
+			return 0, fmt.Errorf("vits: unknown voice %q", params.Voice)
+		}
+		return -1, nil
+	}
+	id := params.Speaker
+	if params.Voice != "" {
+		var ok bool
+		if id, ok = v.cfg.SpeakerIDMap[params.Voice]; !ok {
+			n, err := strconv.Atoi(params.Voice)
+			if err != nil {
+				return 0, fmt.Errorf("vits: unknown voice %q", params.Voice)
+			}
+			id = n
+		}
+	}
+	if id < 0 || id >= v.cfg.NumSpeakers {
+		return 0, fmt.Errorf("vits: speaker %d out of range [0, %d)", id, v.cfg.NumSpeakers)
+	}
+	return id, nil
+}
+
+// SampleRate implements [Model].
+func (v *vits) SampleRate() int { return v.cfg.Audio.SampleRate }
+
+// Voices implements [Model]. A multi-speaker model has one voice per speaker
+// name, ordered by speaker ID; a single-speaker model has one voice named
+// after its file.
+func (v *vits) Voices() []Voice {
+	if v.cfg.NumSpeakers <= 1 || len(v.cfg.SpeakerIDMap) == 0 {
+		return []Voice{{ID: v.name, Language: v.cfg.Espeak.Voice}}
+	}
+	voices := make([]Voice, 0, len(v.cfg.SpeakerIDMap))
+	for name := range v.cfg.SpeakerIDMap {
+		voices = append(voices, Voice{ID: name, Language: v.cfg.Espeak.Voice})
+	}
+	slices.SortFunc(voices, func(a, b Voice) int {
+		return cmp.Compare(v.cfg.SpeakerIDMap[a.ID], v.cfg.SpeakerIDMap[b.ID])
+	})
+	return voices
+}
+
+// Close implements [Model].
+func (v *vits) Close() error {
+	if v.close == nil {
+		return nil
+	}
+	return v.close()
+}
+
+// readVITSConfig reads the Piper voice config at path and fills in the
+// defaults of missing settings.
+func readVITSConfig(path string) (vitsConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return vitsConfig{}, fmt.Errorf("vits: read config: %w", err)
+	}
+	var cfg vitsConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return vitsConfig{}, fmt.Errorf("vits: decode config %s: %w", path, err)
+	}
+	if len(cfg.PhonemeIDMap) == 0 {
+		return vitsConfig{}, fmt.Errorf("vits: config %s has no phoneme_id_map", path)
+	}
+	if cfg.Audio.SampleRate <= 0 {
+		cfg.Audio.SampleRate = vitsSampleRate
+	}
+	inf := &cfg.Inference
+	inf.NoiseScale = cmp.Or(inf.NoiseScale, vitsNoiseScale)
+	inf.LengthScale = cmp.Or(inf.LengthScale, vitsLengthScale)
+	inf.NoiseW = cmp.Or(inf.NoiseW, vitsNoiseW)
+	return cfg, nil
+}
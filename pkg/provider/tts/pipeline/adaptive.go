@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+)
+
+// latencySmoothing is the weight of a new sample in the moving average of
+// synthesis latency kept by [Adaptive].
+const latencySmoothing = 0.25
+
+// Adaptive is a concurrency controller that adjusts how many synthesis calls
+// may be in flight based on recently measured per-sentence latency.
+//
+// A batch TTS server that receives more requests than it can process in
+// parallel queues them, so every sentence takes longer and the lookahead
+// meant to lower latency raises it instead. Adaptive keeps a moving average
+// of the latency of completed calls and, once enough calls have completed at
+// the current limit:
+//
+//   - lowers the limit by one while the average exceeds the target latency,
+//     or halves it when the average exceeds twice the target;
+//   - raises the limit by one while the average stays below three quarters
+//     of the target and calls actually ran at the full limit, so unused
+//     capacity is only claimed when it can be measured.
+//
+// The limit always stays within the configured bounds. An Adaptive is safe for
+// concurrent use and is meant to be shared by all streams of one provider so
+// that what it learns carries over between replies. See
+// [WithAdaptiveConcurrency].
+type Adaptive struct {
+	min, max int
+	target   time.Duration
+
+	mu      sync.Mutex
+	limit   int
+	avg     time.Duration // moving average of latency; 0 until the first sample
+	samples int           // samples observed since the last adjustment
+}
+
+// NewAdaptive returns an [Adaptive] controller that keeps the concurrency
+// between lo and hi and aims for a per-sentence latency of at most target.
+// It starts at lo. lo is raised to 1 and hi to lo if they are lower.
+func NewAdaptive(lo, hi int, target time.Duration) *Adaptive {
+	lo = max(lo, 1)
+	hi = max(hi, lo)
+	return &Adaptive{min: lo, max: hi, target: target, limit: lo}
+}
+
+// Limit returns the number of synthesis calls currently allowed in flight.
+func (a *Adaptive) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limit
+}
+
+// Latency returns the moving average of observed synthesis latency, or 0
+// before the first observation.
+func (a *Adaptive) Latency() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.avg
+}
+
+// Observe records that a synthesis call took latency while concurrent calls,
+// including itself, were in flight when it started, and adjusts the limit.
+func (a *Adaptive) Observe(latency time.Duration, concurrent int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.avg == 0 {
+		a.avg = latency
+	} else {
+		a.avg += time.Duration(latencySmoothing * float64(latency-a.avg))
+	}
+	a.samples++
+	// Let calls started at the current limit complete before judging it.
+	if a.samples < a.limit {
+		return
+	}
+
+	switch {
+	case a.avg > 2*a.target && a.limit > a.min:
+		a.limit = max(a.limit/2, a.min)
+	case a.avg > a.target && a.limit > a.min:
+		a.limit--
+	case a.avg < a.target*3/4 && concurrent >= a.limit && a.limit < a.max:
+		a.limit++
+	default:
+		return
+	}
+	a.samples = 0
+}
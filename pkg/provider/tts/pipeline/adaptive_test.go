@@ -0,0 +1,146 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewAdaptive_Bounds(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		lo, hi           int
+		wantMin, wantMax int
+	}{
+		{name: "valid", lo: 2, hi: 6, wantMin: 2, wantMax: 6},
+		{name: "zero values", lo: 0, hi: 0, wantMin: 1, wantMax: 1},
+		{name: "max below min", lo: 3, hi: 2, wantMin: 3, wantMax: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := NewAdaptive(tc.lo, tc.hi, time.Second)
+			if a.min != tc.wantMin || a.max != tc.wantMax {
+				t.Errorf("bounds = %d..%d, want %d..%d", a.min, a.max, tc.wantMin, tc.wantMax)
+			}
+			if a.Limit() != tc.wantMin {
+				t.Errorf("Limit() = %d, want to start at %d", a.Limit(), tc.wantMin)
+			}
+		})
+	}
+}
+
+func TestAdaptive_Observe(t *testing.T) {
+	t.Parallel()
+
+	const target = 10 * time.Millisecond
+
+	tests := []struct {
+		name       string
+		min, max   int
+		limit      int
+		avg        time.Duration // moving average before the observations
+		latency    time.Duration
+		concurrent int
+		count      int
+		want       int
+	}{
+		{name: "grows when fast and saturated", min: 1, max: 8, limit: 2, latency: time.Millisecond, concurrent: 2, count: 2, want: 3},
+		{name: "holds when not saturated", min: 1, max: 8, limit: 2, latency: time.Millisecond, concurrent: 1, count: 4, want: 2},
+		{name: "capped at max", min: 1, max: 8, limit: 8, latency: time.Millisecond, concurrent: 8, count: 16, want: 8},
+		{name: "holds within target band", min: 1, max: 8, limit: 4, avg: 9 * time.Millisecond, latency: 9 * time.Millisecond, concurrent: 4, count: 8, want: 4},
+		{name: "waits for a full round", min: 1, max: 8, limit: 4, avg: 30 * time.Millisecond, latency: 30 * time.Millisecond, concurrent: 4, count: 3, want: 4},
+		{name: "steps down above target", min: 1, max: 8, limit: 4, avg: 12 * time.Millisecond, latency: 12 * time.Millisecond, concurrent: 4, count: 4, want: 3},
+		{name: "halves far above target", min: 1, max: 8, limit: 8, avg: 30 * time.Millisecond, latency: 30 * time.Millisecond, concurrent: 8, count: 8, want: 4},
+		{name: "halving stops at min", min: 3, max: 8, limit: 4, avg: 30 * time.Millisecond, latency: 30 * time.Millisecond, concurrent: 4, count: 4, want: 3},
+		{name: "never below min", min: 1, max: 8, limit: 1, avg: 30 * time.Millisecond, latency: 30 * time.Millisecond, concurrent: 1, count: 5, want: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := &Adaptive{min: tc.min, max: tc.max, target: target, limit: tc.limit, avg: tc.avg}
+			for range tc.count {
+				a.Observe(tc.latency, tc.concurrent)
+			}
+			if got := a.Limit(); got != tc.want {
+				t.Errorf("Limit() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRun_AdaptiveConcurrency(t *testing.T) {
+	t.Parallel()
+
+	const maxConcurrency = 6
+
+	// The fake server takes perCall for every request it is working on, like
+	// a server that processes requests one after another.
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+		perCall  time.Duration
+	)
+	synth := func(ctx context.Context, sentence string) ([]byte, error) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		delay := time.Duration(inFlight) * perCall
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+		time.Sleep(delay)
+		return []byte(sentence), nil
+	}
+	setLoad := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		perCall = d
+	}
+
+	a := NewAdaptive(1, maxConcurrency, 25*time.Millisecond)
+	run := func(n int) {
+		t.Helper()
+		var text string
+		for i := range n {
+			text += fmt.Sprintf("Sentence %d. ", i)
+		}
+		pl := New(synth, WithAdaptiveConcurrency(a))
+		if got := len(drain(pl.Run(context.Background(), sendFragments(text)).Audio())); got != n {
+			t.Fatalf("got %d chunks, want %d", got, n)
+		}
+	}
+
+	// A fast server lets the lookahead grow to the maximum.
+	setLoad(time.Millisecond)
+	run(40)
+	if got := a.Limit(); got != maxConcurrency {
+		t.Errorf("limit with a fast server = %d, want %d", got, maxConcurrency)
+	}
+
+	// Once every queued request slows the others down, the limit drops to
+	// what the server can handle within the target latency.
+	setLoad(10 * time.Millisecond)
+	run(30)
+	if got := a.Limit(); got > 3 {
+		t.Errorf("limit with a loaded server = %d, want <= 3", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if peak > maxConcurrency {
+		t.Errorf("peak concurrency = %d, want <= %d", peak, maxConcurrency)
+	}
+}
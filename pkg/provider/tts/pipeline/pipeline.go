@@ -0,0 +1,637 @@
+// Package pipeline provides ordered concurrent sentence synthesis for batch TTS
+// providers.
+//
+// Batch providers make one request per utterance instead of streaming over a
+// socket. To keep perceived latency low they should start synthesising the next
+// sentences while the current one is still being generated, but the resulting
+// audio must still be played back in the order the text arrived. A [Pipeline]
+// does exactly that: it accumulates incoming text fragments into complete
+// sentences, runs up to a configurable number of [SynthesizeFunc] calls
+// concurrently, and emits the audio strictly in sentence order regardless of
+// which request finishes first.
+//
+// Typical usage inside a provider:
+//
+//	func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+//	    pl := pipeline.New(func(ctx context.Context, sentence string) ([]byte, error) {
+//	        return p.synthesize(ctx, sentence, voice)
+//	    }, pipeline.WithConcurrency(p.concurrency))
+//	    return pl.Run(ctx, text), nil
+//	}
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/text/segment"
+)
+
+const (
+	// DefaultConcurrency is the default number of synthesis calls that may be
+	// in flight at the same time.
+	DefaultConcurrency = 4
+
+	// DefaultChunkSize is the default size in bytes of each audio chunk emitted
+	// by [Pipeline.Run].
+	DefaultChunkSize = 4096
+
+	// DefaultBufferSize is the default buffer depth of the audio channel
+	// returned by [Pipeline.Run].
+	DefaultBufferSize = 256
+)
+
+// SynthesizeFunc synthesises a single complete sentence and returns its raw
+// audio. It is called concurrently from multiple goroutines and must honour
+// ctx cancellation.
+type SynthesizeFunc func(ctx context.Context, sentence string) ([]byte, error)
+
+// StreamFunc synthesises a single complete sentence like [SynthesizeFunc] but
+// delivers its audio incrementally: it calls emit with each piece of audio as
+// soon as it is available. emit blocks until the piece has been handed to the
+// consumer and returns false once the stream is cancelled, after which the
+// function should return promptly.
+type StreamFunc func(ctx context.Context, sentence string, emit func([]byte) bool) error
+
+// ErrorHandler is called with the text of a sentence whose synthesis failed
+// and the error that stopped it.
+type ErrorHandler func(sentence string, err error)
+
+// Option is a functional option for configuring a [Pipeline].
+type Option func(*Pipeline)
+
+// WithConcurrency sets the maximum number of synthesis calls in flight at the
+// same time. A value of 1 synthesises sentences strictly one after another.
+// Values < 1 are ignored.
+func WithConcurrency(n int) Option {
+	return func(p *Pipeline) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// WithAdaptiveConcurrency makes a decide how many synthesis calls may be in
+// flight instead of the fixed [WithConcurrency] value, and reports the latency
+// of every successful call to it. Share one [Adaptive] across the pipelines of
+// a provider so its measurements carry over between streams. A nil a keeps
+// the fixed concurrency.
+func WithAdaptiveConcurrency(a *Adaptive) Option {
+	return func(p *Pipeline) {
+		p.adaptive = a
+	}
+}
+
+// WithChunkSize sets the maximum size in bytes of each audio chunk emitted on
+// the output channel. Values < 1 are ignored.
+func WithChunkSize(n int) Option {
+	return func(p *Pipeline) {
+		if n > 0 {
+			p.chunkSize = n
+		}
+	}
+}
+
+// WithBufferSize sets the buffer depth of the output audio channel. Negative
+// values are ignored.
+func WithBufferSize(n int) Option {
+	return func(p *Pipeline) {
+		if n >= 0 {
+			p.bufferSize = n
+		}
+	}
+}
+
+// WithFirstSentenceStream makes [Pipeline.Run] synthesise the first sentence
+// of every stream with fn and forward its audio as soon as each piece arrives,
+// instead of waiting for the complete sentence and splitting it into uniform
+// chunks. This lowers time-to-first-audio at the cost of irregular chunk sizes
+// for the first sentence; pieces are still capped at the chunk size. Later
+// sentences use the regular [SynthesizeFunc]. A nil fn disables streaming.
+func WithFirstSentenceStream(fn StreamFunc) Option {
+	return func(p *Pipeline) {
+		p.firstStream = fn
+	}
+}
+
+// WithMaxSentenceLength caps the length in characters (runes) of the text
+// passed to a single synthesis call. Sentences longer than n are split before
+// dispatch: preferably after a clause boundary (',', ';', ':' or a dash
+// followed by whitespace) in the second half of the limit, otherwise at the
+// last whitespace, and only within a word if a single word exceeds n. The
+// pieces are synthesised in order like separate sentences. Set it to the
+// provider's request limit. Values < 1 disable splitting, the default.
+func WithMaxSentenceLength(n int) Option {
+	return func(p *Pipeline) {
+		if n > 0 {
+			p.maxLength = n
+		}
+	}
+}
+
+// WithMarkup makes the sentence accumulator aware of the markup tags
+// described at [tts.MarkupSynthesizer]: punctuation and whitespace inside a
+// tag never end or split a sentence, and a tag that is still incomplete at the
+// end of the buffered text is kept buffered until it is closed. The tags are
+// passed to the [SynthesizeFunc] unchanged.
+func WithMarkup(enabled bool) Option {
+	return func(p *Pipeline) {
+		p.markup = enabled
+	}
+}
+
+// WithSegmenter sets how the sentence accumulator finds sentence ends. The
+// default, [segment.New], does not end a sentence after abbreviations such as
+// "Dr."; pass [segment.Simple] to end one at every '.', '!' or '?'. A nil seg
+// keeps the default.
+func WithSegmenter(seg *segment.Segmenter) Option {
+	return func(p *Pipeline) {
+		if seg != nil {
+			p.segmenter = seg
+		}
+	}
+}
+
+// WithErrorHandler makes [Pipeline.Run] call fn when a synthesis call fails
+// and stops the stream, so callers can tell which sentence failed. The error
+// is also reported by the stream's [tts.Stream.Err]. fn is not called for
+// calls that fail because ctx was cancelled.
+// It runs on the goroutine that emits the audio, before the output channel
+// is closed.
+func WithErrorHandler(fn ErrorHandler) Option {
+	return func(p *Pipeline) {
+		p.onError = fn
+	}
+}
+
+// Pipeline turns a stream of text fragments into an ordered stream of audio by
+// synthesising complete sentences concurrently.
+//
+// A Pipeline holds no per-stream state; a single instance may serve multiple
+// concurrent [Pipeline.Run] calls.
+type Pipeline struct {
+	synth       SynthesizeFunc
+	firstStream StreamFunc // may be nil
+	concurrency int
+	adaptive    *Adaptive // may be nil; overrides concurrency
+	chunkSize   int
+	bufferSize  int
+	maxLength   int // 0 means unlimited
+	markup      bool
+	segmenter   *segment.Segmenter
+	onError     ErrorHandler // may be nil
+}
+
+// New creates a [Pipeline] that synthesises each sentence with synth.
+func New(synth SynthesizeFunc, opts ...Option) *Pipeline {
+	p := &Pipeline{
+		synth:       synth,
+		concurrency: DefaultConcurrency,
+		chunkSize:   DefaultChunkSize,
+		bufferSize:  DefaultBufferSize,
+		segmenter:   segment.New(),
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+// Concurrency returns the maximum number of synthesis calls in flight. With
+// [WithAdaptiveConcurrency] this is the controller's current limit.
+func (p *Pipeline) Concurrency() int {
+	if p.adaptive != nil {
+		return p.adaptive.Limit()
+	}
+	return p.concurrency
+}
+
+// maxConcurrency returns the highest concurrency the pipeline may reach.
+func (p *Pipeline) maxConcurrency() int {
+	if p.adaptive != nil {
+		return p.adaptive.max
+	}
+	return p.concurrency
+}
+
+// future carries the audio of one sentence. sentence is the text being
+// synthesised. pieces is closed once the
+// sentence is complete; err is valid after that and reports the error that
+// stopped synthesis, if any.
+type future struct {
+	sentence string
+	pieces   chan []byte
+	err      error
+}
+
+// Run consumes text fragments from text, accumulates them into complete
+// sentences (split on '.', '!', '?' followed by whitespace or end of input),
+// and synthesises each sentence with the pipeline's [SynthesizeFunc]. Audio is
+// emitted on the returned channel in fixed-size chunks and in the original
+// sentence order, even though up to [Pipeline.Concurrency] sentences are
+// synthesised at once. With [WithMaxSentenceLength], over-long sentences are
+// split into several calls, and text without a sentence boundary is
+// dispatched as soon as it exceeds the limit.
+//
+// Any text left over when text is closed is synthesised as a final sentence.
+// The first synthesis error stops the stream; sentences after the failed one
+// are not emitted and their in-flight calls are cancelled. The error is
+// reported by [tts.Stream.Err] and to the [WithErrorHandler] callback, if
+// any.
+//
+// The audio channel is closed when all text has been synthesised, when a
+// synthesis call fails, or when ctx is cancelled. The caller must drain the
+// channel to prevent goroutine leaks.
+func (p *Pipeline) Run(ctx context.Context, text <-chan string) *tts.Stream {
+	audioCh := make(chan []byte, p.bufferSize)
+	stream := tts.NewStream(audioCh)
+
+	go func() {
+		defer close(audioCh)
+
+		// Cancelling on return stops the accumulator, the dispatcher, and any
+		// in-flight synthesis once the collector gives up.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sentences := make(chan string, p.maxConcurrency())
+		// queue carries one future per sentence in dispatch order so the
+		// collector can drain results in order.
+		queue := make(chan *future, p.maxConcurrency())
+
+		go accumulate(ctx, text, sentences, p.maxLength, p.markup, p.segmenter)
+		go p.dispatch(ctx, sentences, queue)
+
+		for {
+			select {
+			case f, ok := <-queue:
+				if !ok {
+					return
+				}
+				if !p.collect(ctx, stream, audioCh, f) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return stream
+}
+
+// collect forwards the audio of f to out until the sentence is complete. It
+// returns false if synthesis failed, recording the error on stream, or if ctx
+// was cancelled.
+func (p *Pipeline) collect(ctx context.Context, stream *tts.Stream, out chan<- []byte, f *future) bool {
+	for {
+		select {
+		case piece, ok := <-f.pieces:
+			if !ok {
+				// The caller can inspect ctx.Err() to distinguish
+				// cancellation from provider errors.
+				if f.err != nil && ctx.Err() == nil {
+					stream.SetErr(f.err)
+					if p.onError != nil {
+						p.onError(f.sentence, f.err)
+					}
+				}
+				return f.err == nil
+			}
+			if !p.emit(ctx, out, piece) {
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// dispatch launches one synthesis goroutine per sentence, never more than
+// [Pipeline.Concurrency] at once, and enqueues each sentence's future in
+// order. The first sentence is streamed if a [StreamFunc] is configured.
+func (p *Pipeline) dispatch(ctx context.Context, sentences <-chan string, queue chan<- *future) {
+	defer close(queue)
+
+	slots := &gate{limit: p.Concurrency, released: make(chan struct{}, 1)}
+	for first := true; ; first = false {
+		var sentence string
+		select {
+		case s, ok := <-sentences:
+			if !ok {
+				return
+			}
+			sentence = s
+		case <-ctx.Done():
+			return
+		}
+
+		concurrent, ok := slots.acquire(ctx)
+		if !ok {
+			return
+		}
+
+		f := &future{sentence: sentence, pieces: make(chan []byte, 1)}
+		select {
+		case queue <- f:
+		case <-ctx.Done():
+			slots.release()
+			return
+		}
+
+		stream := first && p.firstStream != nil
+		go func() {
+			defer slots.release()
+			defer close(f.pieces)
+			start := time.Now()
+			defer func() {
+				if p.adaptive != nil && f.err == nil && ctx.Err() == nil {
+					p.adaptive.Observe(time.Since(start), concurrent)
+				}
+			}()
+			if stream {
+				f.err = p.firstStream(ctx, sentence, func(piece []byte) bool {
+					select {
+					case f.pieces <- piece:
+						return true
+					case <-ctx.Done():
+						return false
+					}
+				})
+				return
+			}
+			audio, err := p.synth(ctx, sentence)
+			if err != nil {
+				f.err = err
+				return
+			}
+			f.pieces <- audio
+		}()
+	}
+}
+
+// gate bounds the number of synthesis calls in flight to a limit that may
+// change between calls.
+type gate struct {
+	limit    func() int
+	released chan struct{} // buffered; signalled whenever a call finishes
+
+	mu       sync.Mutex
+	inflight int
+}
+
+// acquire waits until fewer calls than the limit are in flight and claims a
+// slot. It returns the number of calls in flight including the new one, and
+// false if ctx was cancelled first.
+func (g *gate) acquire(ctx context.Context) (int, bool) {
+	for {
+		g.mu.Lock()
+		if g.inflight < g.limit() {
+			g.inflight++
+			n := g.inflight
+			g.mu.Unlock()
+			return n, true
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-g.released:
+		case <-ctx.Done():
+			return 0, false
+		}
+	}
+}
+
+// release frees a slot claimed by acquire.
+func (g *gate) release() {
+	g.mu.Lock()
+	g.inflight--
+	g.mu.Unlock()
+	select {
+	case g.released <- struct{}{}:
+	default:
+	}
+}
+
+// emit writes audio to out in chunks of at most p.chunkSize bytes. It returns
+// false if ctx was cancelled before all chunks were written.
+func (p *Pipeline) emit(ctx context.Context, out chan<- []byte, audio []byte) bool {
+	for len(audio) > 0 {
+		end := min(p.chunkSize, len(audio))
+		select {
+		case out <- audio[:end]:
+		case <-ctx.Done():
+			return false
+		}
+		audio = audio[end:]
+	}
+	return true
+}
+
+// accumulate reads text fragments, buffers them, and sends every complete
+// sentence on sentences. The remainder is flushed when text is closed. If
+// maxLength is positive, no text longer than maxLength runes is sent: long
+// sentences are split with [splitLong], and buffered text without a sentence
+// boundary is sent in pieces as soon as it exceeds the limit. If markup is
+// set, markup tags are treated as unbreakable. seg decides which candidate
+// boundaries end a sentence.
+func accumulate(ctx context.Context, text <-chan string, sentences chan<- string, maxLength int, markup bool, seg *segment.Segmenter) {
+	defer close(sentences)
+
+	// send sends sentence, split into pieces of at most maxLength runes. It
+	// returns false if ctx was cancelled.
+	send := func(sentence string) bool {
+		pieces := []string{sentence}
+		if maxLength > 0 {
+			var rest string
+			pieces, rest = splitLong(sentence, maxLength, markup)
+			if rest = strings.TrimSpace(rest); rest != "" {
+				pieces = append(pieces, rest)
+			}
+		}
+		for _, piece := range pieces {
+			select {
+			case sentences <- piece:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		return true
+	}
+
+	var buf strings.Builder
+	for {
+		select {
+		case fragment, ok := <-text:
+			if !ok {
+				if remaining := strings.TrimSpace(buf.String()); remaining != "" {
+					send(remaining)
+				}
+				return
+			}
+			buf.WriteString(fragment)
+			for {
+				s := buf.String()
+				idx := findSentenceBoundary(s, markup, seg)
+				if idx < 0 {
+					if maxLength > 0 && utf8.RuneCountInString(s) > maxLength {
+						// Dispatch what cannot grow any more; keep the rest
+						// buffered, it may still end in a better boundary.
+						pieces, rest := splitLong(strings.TrimLeftFunc(s, unicode.IsSpace), maxLength, markup)
+						buf.Reset()
+						buf.WriteString(rest)
+						for _, piece := range pieces {
+							if !send(piece) {
+								return
+							}
+						}
+					}
+					break
+				}
+				sentence := strings.TrimSpace(s[:idx+1])
+				buf.Reset()
+				buf.WriteString(s[idx+1:])
+				if sentence == "" {
+					continue
+				}
+				if !send(sentence) {
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// splitLong splits pieces of at most maxLength runes off the front of s until
+// the rest is no longer than maxLength. Every piece ends at the best split
+// point found by [splitIndex]; whitespace around the split is dropped. s must
+// not start with whitespace.
+func splitLong(s string, maxLength int, markup bool) (pieces []string, rest string) {
+	for utf8.RuneCountInString(s) > maxLength {
+		cut := splitIndex(s, maxLength, markup)
+		if piece := strings.TrimRightFunc(s[:cut], unicode.IsSpace); piece != "" {
+			pieces = append(pieces, piece)
+		}
+		s = strings.TrimLeftFunc(s[cut:], unicode.IsSpace)
+	}
+	return pieces, s
+}
+
+// splitIndex returns the byte index at which to split s so that s[:i] holds
+// at most maxLength runes: after the last clause boundary in the second half
+// of the limit, else before the last whitespace, else after maxLength runes.
+// s must be longer than maxLength runes.
+//
+// If markup is set, no split point lies inside a tag; a tag crossing the
+// limit is moved to the next piece unless it starts the text.
+func splitIndex(s string, maxLength int, markup bool) int {
+	clause, space, end := -1, -1, 0
+	n := 0
+	tagStart, tagEnd := -1, -1 // byte range of the tag containing i
+	for i, r := range s {
+		if markup && i >= tagEnd {
+			tagStart, tagEnd = -1, -1
+			if r == '<' {
+				switch l := tts.TagLength(s[i:]); {
+				case l > 0:
+					tagStart, tagEnd = i, i+l
+				case l < 0:
+					tagStart, tagEnd = i, len(s)
+				}
+			}
+		}
+		inTag := tagStart >= 0
+		if n == maxLength {
+			// The rune after the limit may still be whitespace to split at.
+			if unicode.IsSpace(r) && !inTag {
+				space = i
+			}
+			end = i
+			if inTag && tagStart > 0 {
+				end = tagStart
+			} else if inTag {
+				end = tagEnd
+			}
+			break
+		}
+		n++
+		if inTag {
+			continue
+		}
+		next, size := utf8.DecodeRuneInString(s[i+utf8.RuneLen(r):])
+		switch {
+		case unicode.IsSpace(r):
+			space = i
+		case isClausePunct(r) && size > 0 && unicode.IsSpace(next) && n > maxLength/2:
+			clause = i + utf8.RuneLen(r)
+		}
+	}
+	switch {
+	case clause > 0:
+		return clause
+	case space > 0:
+		return space
+	default:
+		return end
+	}
+}
+
+// isClausePunct reports whether r ends a clause within a sentence.
+func isClausePunct(r rune) bool {
+	switch r {
+	case ',', ';', ':', '\u2013', '\u2014': // en dash, em dash
+		return true
+	}
+	return false
+}
+
+// findSentenceBoundary returns the index of the first sentence-ending character
+// ('.', '!', '?') that is either at the end of s or immediately followed by
+// whitespace, and that seg accepts as the end of the sentence (see
+// [segment.Segmenter.EndsSentence]). Returns -1 if no sentence boundary is
+// found. The end of s counts because callers such as the cascade engine send
+// one complete sentence per fragment.
+//
+// This ensures that decimal numbers like "3.14" are not incorrectly treated as
+// sentence boundaries. If markup is set, characters inside markup tags are
+// skipped, and no boundary is reported past a tag that is not closed yet.
+// Tags directly after the sentence-ending character belong to the sentence;
+// the index of their last character is returned instead.
+func findSentenceBoundary(s string, markup bool, seg *segment.Segmenter) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if markup && c == '<' {
+			switch l := tts.TagLength(s[i:]); {
+			case l < 0:
+				return -1
+			case l > 0:
+				i += l - 1
+				continue
+			}
+		}
+		if c == '.' || c == '!' || c == '?' {
+			end := i
+			for markup && end+1 < len(s) {
+				l := tts.TagLength(s[end+1:])
+				if l < 0 {
+					return -1
+				}
+				if l == 0 {
+					break
+				}
+				end += l
+			}
+			if (end+1 >= len(s) || unicode.IsSpace(rune(s[end+1]))) && seg.EndsSentence(s[:i+1]) {
+				return end
+			}
+		}
+	}
+	return -1
+}
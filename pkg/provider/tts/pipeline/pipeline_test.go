@@ -0,0 +1,592 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MrWong99/glyphoxa/pkg/text/segment"
+)
+
+// sendFragments sends fragments on a buffered channel and closes it.
+func sendFragments(fragments ...string) <-chan string {
+	ch := make(chan string, len(fragments))
+	for _, f := range fragments {
+		ch <- f
+	}
+	close(ch)
+	return ch
+}
+
+// drain reads all chunks from ch until it is closed.
+func drain(ch <-chan []byte) [][]byte {
+	var out [][]byte
+	for chunk := range ch {
+		out = append(out, chunk)
+	}
+	return out
+}
+
+// tracker records synthesis calls and the peak number of concurrent calls.
+type tracker struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	calls    []string
+}
+
+func (tr *tracker) enter(sentence string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.inFlight++
+	tr.peak = max(tr.peak, tr.inFlight)
+	tr.calls = append(tr.calls, sentence)
+}
+
+func (tr *tracker) leave() {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.inFlight--
+}
+
+func TestRun_OrderedUnderConcurrency(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		concurrency int
+	}{
+		{name: "sequential", concurrency: 1},
+		{name: "two", concurrency: 2},
+		{name: "default", concurrency: DefaultConcurrency},
+		{name: "more workers than sentences", concurrency: 16},
+	}
+
+	sentences := []string{"One.", "Two.", "Three.", "Four.", "Five.", "Six."}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tr := &tracker{}
+			// Earlier sentences take longer, so without ordering the audio
+			// would come out reversed.
+			delays := map[string]time.Duration{}
+			for i, s := range sentences {
+				delays[s] = time.Duration(len(sentences)-i) * 5 * time.Millisecond
+			}
+			pl := New(func(ctx context.Context, sentence string) ([]byte, error) {
+				tr.enter(sentence)
+				defer tr.leave()
+				time.Sleep(delays[sentence])
+				return []byte(sentence), nil
+			}, WithConcurrency(tc.concurrency))
+
+			var got []string
+			for chunk := range pl.Run(context.Background(), sendFragments("One. Two. Three. ", "Four. Five. Six.")).Audio() {
+				got = append(got, string(chunk))
+			}
+
+			if !slices.Equal(got, sentences) {
+				t.Errorf("audio order = %v, want %v", got, sentences)
+			}
+			if tr.peak > tc.concurrency {
+				t.Errorf("peak concurrency = %d, want <= %d", tr.peak, tc.concurrency)
+			}
+			if tc.concurrency > 1 && tr.peak < 2 {
+				t.Errorf("peak concurrency = %d, want synthesis to overlap", tr.peak)
+			}
+		})
+	}
+}
+
+func TestRun_SentenceAccumulation(t *testing.T) {
+	t.Parallel()
+
+	tr := &tracker{}
+	pl := New(func(ctx context.Context, sentence string) ([]byte, error) {
+		tr.enter(sentence)
+		defer tr.leave()
+		return nil, nil
+	}, WithConcurrency(1))
+
+	// A trailing '.' at the end of the buffered text counts as a boundary
+	// even if the next fragment continues the number.
+	drain(pl.Run(context.Background(), sendFragments("Hello ", "world. ", "Pi is 3.", "14 exactly! ", "No trailing punctuation")).Audio())
+
+	want := []string{"Hello world.", "Pi is 3.", "14 exactly!", "No trailing punctuation"}
+	if !slices.Equal(tr.calls, want) {
+		t.Errorf("synthesised sentences = %q, want %q", tr.calls, want)
+	}
+}
+
+func TestRun_Markup(t *testing.T) {
+	t.Parallel()
+
+	tr := &tracker{}
+	pl := New(func(ctx context.Context, sentence string) ([]byte, error) {
+		tr.enter(sentence)
+		defer tr.leave()
+		return nil, nil
+	}, WithConcurrency(1), WithMarkup(true))
+
+	// The '.' inside the tag arrives at the end of a fragment, where it would
+	// otherwise count as a boundary.
+	// Tags right after the punctuation stay with their sentence.
+	drain(pl.Run(context.Background(), sendFragments("Halt! ", `<prosody rate="x-slow">Who goes <break time="0.`, `5s"/>there?</pro`, "sody> Speak.")).Audio())
+
+	want := []string{"Halt!", `<prosody rate="x-slow">Who goes <break time="0.5s"/>there?</prosody>`, "Speak."}
+	if !slices.Equal(tr.calls, want) {
+		t.Errorf("synthesised sentences = %q, want %q", tr.calls, want)
+	}
+}
+
+func TestRun_MaxSentenceLength(t *testing.T) {
+	t.Parallel()
+
+	// One monologue "sentence" of about 2000 characters with clauses, streamed
+	// word by word like LLM output, followed by a short sentence.
+	var long strings.Builder
+	for i := range 60 {
+		fmt.Fprintf(&long, "and the %d-th dragon of the northern wastes rose, ", i)
+	}
+	long.WriteString("until the sky itself grew dark.")
+	text := long.String() + " The end."
+	fragments := strings.SplitAfter(text, " ")
+
+	const limit = 200
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	pl := New(func(ctx context.Context, sentence string) ([]byte, error) {
+		mu.Lock()
+		calls = append(calls, sentence)
+		mu.Unlock()
+		// Vary the latency so that out-of-order completion would show.
+		time.Sleep(time.Duration(len(sentence)%7) * time.Millisecond)
+		return []byte(sentence + "|"), nil
+	}, WithMaxSentenceLength(limit), WithConcurrency(4))
+
+	var audio []byte
+	for _, chunk := range drain(pl.Run(context.Background(), sendFragments(fragments...)).Audio()) {
+		audio = append(audio, chunk...)
+	}
+
+	pieces := strings.Split(strings.TrimSuffix(string(audio), "|"), "|")
+	if len(pieces) < len(text)/limit+1 {
+		t.Fatalf("got %d requests, want the monologue split into at least %d", len(pieces), len(text)/limit+1)
+	}
+	for i, piece := range pieces {
+		if n := utf8.RuneCountInString(piece); n > limit {
+			t.Errorf("request %d has %d characters, want at most %d: %q", i, n, limit, piece)
+		}
+		if i < len(pieces)-2 && !strings.HasSuffix(piece, ",") {
+			t.Errorf("request %d = %q, want it split after a clause", i, piece)
+		}
+	}
+	if got := strings.Join(pieces, " "); got != text {
+		t.Errorf("audio order or content changed:\n got %q\nwant %q", got, text)
+	}
+	if pieces[len(pieces)-1] != "The end." {
+		t.Errorf("last request = %q, want the following sentence on its own", pieces[len(pieces)-1])
+	}
+	if len(calls) != len(pieces) {
+		t.Errorf("synthesis calls = %d, want one per piece (%d)", len(calls), len(pieces))
+	}
+}
+
+func TestSplitLong(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		input      string
+		max        int
+		markup     bool
+		wantPieces []string
+		wantRest   string
+	}{
+		{
+			name:     "short enough",
+			input:    "Hello there.",
+			max:      20,
+			wantRest: "Hello there.",
+		},
+		{
+			name:       "clause boundary preferred",
+			input:      "The gate was shut, so we waited outside",
+			max:        25,
+			wantPieces: []string{"The gate was shut,"},
+			wantRest:   "so we waited outside",
+		},
+		{
+			name:       "clause in first half ignored",
+			input:      "Aye, the gate was shut and barred",
+			max:        24,
+			wantPieces: []string{"Aye, the gate was shut"},
+			wantRest:   "and barred",
+		},
+		{
+			name:       "dash",
+			input:      "It was him \u2014 the one who burned the mill",
+			max:        14,
+			wantPieces: []string{"It was him \u2014", "the one who", "burned the"},
+			wantRest:   "mill",
+		},
+		{
+			name:       "word boundary",
+			input:      "one two three four five",
+			max:        9,
+			wantPieces: []string{"one two", "three"},
+			wantRest:   "four five",
+		},
+		{
+			name:       "space right after the limit",
+			input:      "abcde fgh",
+			max:        5,
+			wantPieces: []string{"abcde"},
+			wantRest:   "fgh",
+		},
+		{
+			name:       "overlong word cut",
+			input:      "Ärgerlichkeitsgrenzwert",
+			max:        10,
+			wantPieces: []string{"Ärgerlichk", "eitsgrenzw"},
+			wantRest:   "ert",
+		},
+		{
+			name:       "markup tag kept whole",
+			input:      `Stay <break time="1s"/> here`,
+			max:        12,
+			markup:     true,
+			wantPieces: []string{"Stay", `<break time="1s"/>`},
+			wantRest:   "here",
+		},
+		{
+			name:       "markup tag moved to the next piece",
+			input:      `Hold on, <emphasis level="strong">now</emphasis>`,
+			max:        20,
+			markup:     true,
+			wantPieces: []string{"Hold on,", `<emphasis level="strong">`},
+			wantRest:   "now</emphasis>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pieces, rest := splitLong(tt.input, tt.max, tt.markup)
+			if !slices.Equal(pieces, tt.wantPieces) || rest != tt.wantRest {
+				t.Errorf("splitLong(%q, %d) = %q, %q, want %q, %q", tt.input, tt.max, pieces, rest, tt.wantPieces, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestRun_Chunking(t *testing.T) {
+	t.Parallel()
+
+	audio := bytes.Repeat([]byte{0xAB}, 10)
+	pl := New(func(context.Context, string) ([]byte, error) {
+		return audio, nil
+	}, WithChunkSize(4))
+
+	chunks := drain(pl.Run(context.Background(), sendFragments("Hi.")).Audio())
+
+	sizes := make([]int, len(chunks))
+	for i, c := range chunks {
+		sizes[i] = len(c)
+	}
+	if !slices.Equal(sizes, []int{4, 4, 2}) {
+		t.Errorf("chunk sizes = %v, want [4 4 2]", sizes)
+	}
+}
+
+func TestRun_FirstSentenceStream(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	var streamed []string
+	pl := New(func(_ context.Context, sentence string) ([]byte, error) {
+		return []byte(sentence), nil
+	}, WithFirstSentenceStream(func(_ context.Context, sentence string, emit func([]byte) bool) error {
+		streamed = append(streamed, sentence)
+		if !emit([]byte("a")) {
+			return nil
+		}
+		<-release // the rest of the sentence is still being synthesised
+		emit([]byte("b"))
+		return nil
+	}))
+
+	out := pl.Run(context.Background(), sendFragments("One. Two.")).Audio()
+	select {
+	case chunk := <-out:
+		if string(chunk) != "a" {
+			t.Errorf("first chunk = %q, want %q", chunk, "a")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("first chunk not emitted before the first sentence finished")
+	}
+	close(release)
+
+	var got []string
+	for _, chunk := range drain(out) {
+		got = append(got, string(chunk))
+	}
+	if !slices.Equal(got, []string{"b", "Two."}) {
+		t.Errorf("remaining audio = %q, want [b Two.]", got)
+	}
+	if !slices.Equal(streamed, []string{"One."}) {
+		t.Errorf("streamed sentences = %q, want only the first", streamed)
+	}
+}
+
+func TestRun_ErrorStopsStream(t *testing.T) {
+	t.Parallel()
+
+	errExploded := errors.New("server exploded")
+	pl := New(func(ctx context.Context, sentence string) ([]byte, error) {
+		if sentence == "Two." {
+			return nil, errExploded
+		}
+		return []byte(sentence), nil
+	})
+
+	var got []string
+	stream := pl.Run(context.Background(), sendFragments("One. Two. Three."))
+	for chunk := range stream.Audio() {
+		got = append(got, string(chunk))
+	}
+	if !slices.Equal(got, []string{"One."}) {
+		t.Errorf("audio = %v, want only the sentences before the failure", got)
+	}
+	if !errors.Is(stream.Err(), errExploded) {
+		t.Errorf("Err() = %v, want %v", stream.Err(), errExploded)
+	}
+
+	stream = pl.Run(context.Background(), sendFragments("One. Three."))
+	drain(stream.Audio())
+	if err := stream.Err(); err != nil {
+		t.Errorf("Err() = %v after a complete stream, want nil", err)
+	}
+}
+
+func TestRun_Cancellation(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{}, DefaultConcurrency)
+	var (
+		mu        sync.Mutex
+		cancelled int
+	)
+	pl := New(func(ctx context.Context, sentence string) ([]byte, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		mu.Lock()
+		cancelled++
+		mu.Unlock()
+		return nil, ctx.Err()
+	}, WithConcurrency(2))
+
+	// The text channel is never closed: only cancellation can end the stream.
+	text := make(chan string, 4)
+	text <- "One. Two. Three. "
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := pl.Run(ctx, text)
+	audioCh := stream.Audio()
+
+	<-started
+	<-started
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		drain(audioCh)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("audio channel did not close after cancellation")
+	}
+	if err := stream.Err(); err != nil {
+		t.Errorf("Err() = %v after cancellation, want nil", err)
+	}
+
+	// Both in-flight calls observe the cancellation; the third sentence is
+	// never started because only two slots exist.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := cancelled
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cancelled calls = %d, want 2", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if len(started) != 0 {
+		t.Errorf("%d extra synthesis calls started after cancellation", len(started))
+	}
+}
+
+func TestNew_Options(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []Option
+		want Pipeline
+	}{
+		{
+			name: "defaults",
+			want: Pipeline{concurrency: DefaultConcurrency, chunkSize: DefaultChunkSize, bufferSize: DefaultBufferSize},
+		},
+		{
+			name: "custom",
+			opts: []Option{WithConcurrency(8), WithChunkSize(1024), WithBufferSize(0)},
+			want: Pipeline{concurrency: 8, chunkSize: 1024, bufferSize: 0},
+		},
+		{
+			name: "invalid values ignored",
+			opts: []Option{WithConcurrency(0), WithChunkSize(-1), WithBufferSize(-1)},
+			want: Pipeline{concurrency: DefaultConcurrency, chunkSize: DefaultChunkSize, bufferSize: DefaultBufferSize},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(nil, tc.opts...)
+			if p.concurrency != tc.want.concurrency || p.chunkSize != tc.want.chunkSize || p.bufferSize != tc.want.bufferSize {
+				t.Errorf("got concurrency=%d chunk=%d buffer=%d, want %d/%d/%d",
+					p.concurrency, p.chunkSize, p.bufferSize,
+					tc.want.concurrency, tc.want.chunkSize, tc.want.bufferSize)
+			}
+			if p.Concurrency() != p.concurrency {
+				t.Errorf("Concurrency() = %d, want %d", p.Concurrency(), p.concurrency)
+			}
+		})
+	}
+}
+
+func TestFindSentenceBoundary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{"period at end", "Hello.", 5},
+		{"period space", "Hello. World", 5},
+		{"exclamation", "Hello!", 5},
+		{"question", "Hello?", 5},
+		{"no boundary", "Hello", -1},
+		// The simple segmenter ends a sentence after "Dr." as well.
+		{"abbreviation mid", "Dr. Smith", 2},
+		// '.' in "3.14" is followed by '1', not whitespace — not a boundary.
+		{"decimal", "3.14 is pi", -1},
+		{"empty", "", -1},
+		{"multiple", "First. Second.", 5},
+		{"question mid", "How? Great!", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := findSentenceBoundary(tt.input, false, segment.Simple()); got != tt.want {
+				t.Errorf("findSentenceBoundary(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSentenceBoundary_Segmenter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		input  string
+		markup bool
+		want   int
+	}{
+		{"title", "Ask Dr. Smith. Now", false, 13},
+		{"title at end", "Ask Dr.", false, -1},
+		{"sentence at end", "Ask Dr. Smith.", false, 13},
+		{"title in markup", "<emphasis>Dr.</emphasis> Smith. Now", true, 30},
+	}
+
+	seg := segment.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := findSentenceBoundary(tt.input, tt.markup, seg); got != tt.want {
+				t.Errorf("findSentenceBoundary(%q, %v) = %d, want %d", tt.input, tt.markup, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSentenceBoundary_Markup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{"period in attribute", `Wait <break time="1. 5s"/> here.`, 31},
+		{"boundary before tag", `Wait. <break time="1.5s"/>`, 4},
+		{"unclosed tag", `Wait <break time="1. 5s`, -1},
+		{"less-than sign", "3 < 5. Yes", 5},
+		{"closing tag after punctuation", "<emphasis>Run!</emphasis> Now", 24},
+		{"closing tag not complete", "<emphasis>Run!</emph", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := findSentenceBoundary(tt.input, true, segment.Simple()); got != tt.want {
+				t.Errorf("findSentenceBoundary(%q, true) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_ErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	errExploded := errors.New("server exploded")
+	var (
+		sentence string
+		reported error
+	)
+	pl := New(func(ctx context.Context, s string) ([]byte, error) {
+		if s == "Two." {
+			return nil, errExploded
+		}
+		return []byte(s), nil
+	}, WithErrorHandler(func(s string, err error) {
+		sentence, reported = s, err
+	}))
+
+	drain(pl.Run(context.Background(), sendFragments("One. Two. Three.")).Audio())
+	if sentence != "Two." || !errors.Is(reported, errExploded) {
+		t.Errorf("handler got (%q, %v), want (%q, %v)", sentence, reported, "Two.", errExploded)
+	}
+}
@@ -0,0 +1,477 @@
+// Package piper provides a TTS provider backed by Piper, a fast local neural
+// text-to-speech engine (https://github.com/rhasspy/piper). It implements the
+// tts.Provider interface.
+//
+// Two modes are supported, chosen by the source passed to [New]:
+//
+//   - Server mode: source is the URL of a Piper HTTP server
+//     (python -m piper.http_server). Synthesis is performed via POST / with a
+//     JSON body; the server replies with a WAV file.
+//
+//   - Local mode: source is the path to a Piper ONNX voice model. The model's
+//     JSON config is expected next to it (model.onnx.json) unless
+//     [WithConfig] names another file. Every synthesis runs the piper
+//     executable, which writes raw PCM to stdout.
+//
+// Piper synthesises one utterance per call, so SynthesizeStream accumulates
+// incoming text fragments into complete sentences and dispatches one
+// synthesis call per sentence with a small lookahead window, emitting the
+// audio in the original sentence order (see package pipeline). Piper voices
+// produce 16-bit mono PCM at the model's sample rate, typically 22050 Hz; the
+// provider resamples it to the output rate set with [WithOutputSampleRate].
+//
+// Typical usage (server):
+//
+//	p, err := piper.New("http://localhost:5000",
+//	    piper.WithModel("en_US-lessac-medium"),
+//	)
+//	stream, err := p.SynthesizeStream(ctx, textCh, voiceProfile)
+//
+// Typical usage (local model):
+//
+//	p, err := piper.New("/models/en_US-lessac-medium.onnx",
+//	    piper.WithOutputSampleRate(48000),
+//	)
+//	stream, err := p.SynthesizeStream(ctx, textCh, voiceProfile)
+package piper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/pipeline"
+)
+
+// Compile-time interface assertions.
+var (
+	_ tts.Provider       = (*Provider)(nil)
+	_ tts.FormatReporter = (*Provider)(nil)
+)
+
+// ---- constants ----
+
+const (
+	// NativeSampleRate is the sample rate in Hz of Piper's medium and high
+	// quality voices. It is assumed for local models whose config does not
+	// state a rate.
+	NativeSampleRate = 22050
+
+	// DefaultOutputSampleRate is the PCM sample rate in Hz emitted when
+	// [WithOutputSampleRate] is not supplied.
+	DefaultOutputSampleRate = 16000
+
+	// DefaultBinary is the piper executable run in local mode when
+	// [WithBinary] is not supplied. It is looked up in PATH.
+	DefaultBinary = "piper"
+
+	defaultLanguage = "en"
+	defaultTimeout  = 30 * time.Second
+	ttsEndpoint     = "/"
+
+	// warmupText is synthesised by [Provider.Warmup].
+	warmupText = "Ready."
+)
+
+// ---- options ----
+
+// Option is a functional option for configuring a Piper Provider.
+type Option func(*Provider)
+
+// WithModel sets the voice model synthesised for voice profiles without an ID.
+// In server mode it is the name of a voice known to the server (e.g.,
+// "en_US-lessac-medium"); without it the server's default voice is used. In
+// local mode it is the path to an ONNX model and replaces the one passed to
+// [New].
+func WithModel(model string) Option {
+	return func(p *Provider) {
+		p.model = model
+	}
+}
+
+// WithConfig sets the path to the JSON config of the default local model.
+// Defaults to the model path with ".json" appended. Ignored in server mode.
+func WithConfig(path string) Option {
+	return func(p *Provider) {
+		p.configPath = path
+	}
+}
+
+// WithBinary sets the path to the piper executable used in local mode.
+// Defaults to [DefaultBinary]. Ignored in server mode.
+func WithBinary(path string) Option {
+	return func(p *Provider) {
+		p.binary = path
+	}
+}
+
+// WithLanguage sets the BCP-47 language code reported for the configured
+// model by ListVoices. Defaults to "en". Piper voices speak the language they
+// were trained on, so it does not affect synthesis.
+func WithLanguage(lang string) Option {
+	return func(p *Provider) {
+		p.language = lang
+	}
+}
+
+// WithOutputSampleRate sets the sample rate in Hz of the emitted PCM. Audio
+// is resampled from the voice's native rate. Defaults to
+// [DefaultOutputSampleRate]. Values < 1 are ignored.
+func WithOutputSampleRate(hz int) Option {
+	return func(p *Provider) {
+		if hz > 0 {
+			p.outputRate = hz
+		}
+	}
+}
+
+// WithTimeout sets the per-request timeout for synthesis, covering the HTTP
+// request in server mode and the piper process in local mode. Defaults to
+// 30 s if not set.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Provider) {
+		p.httpClient.Timeout = d
+	}
+}
+
+// WithConcurrency sets how many sentence synthesis calls may be in flight at
+// the same time. Defaults to [pipeline.DefaultConcurrency]. Values < 1 are
+// ignored.
+func WithConcurrency(n int) Option {
+	return func(p *Provider) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// WithMaxTextLength caps the characters sent in one synthesis call. Longer
+// sentences are split at clause or word boundaries and synthesised as several
+// calls in order. Values < 1, the default, disable splitting.
+func WithMaxTextLength(n int) Option {
+	return func(p *Provider) {
+		p.maxTextLength = n
+	}
+}
+
+// ---- Provider ----
+
+// Provider implements tts.Provider backed by Piper. It is safe for concurrent
+// use; multiple SynthesizeStream calls may run in parallel.
+type Provider struct {
+	serverURL     string // empty in local mode
+	model         string
+	configPath    string
+	binary        string
+	language      string
+	outputRate    int
+	concurrency   int
+	maxTextLength int
+	httpClient    *http.Client
+
+	rateMu sync.Mutex
+	rates  map[string]int // native sample rate of each local model
+}
+
+// New creates a Piper Provider. source is either the URL of a Piper HTTP
+// server (starting with "http://" or "https://") or the path to a local ONNX
+// voice model. In local mode New reads the model's config to learn its sample
+// rate and returns an error if the config cannot be read.
+func New(source string, opts ...Option) (*Provider, error) {
+	if source == "" {
+		return nil, errors.New("piper: source must not be empty")
+	}
+	p := &Provider{
+		binary:      DefaultBinary,
+		language:    defaultLanguage,
+		outputRate:  DefaultOutputSampleRate,
+		concurrency: pipeline.DefaultConcurrency,
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: ident.Transport(nil),
+		},
+		rates: make(map[string]int),
+	}
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		p.serverURL = strings.TrimRight(source, "/")
+	} else {
+		p.model = source
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	if p.serverURL == "" {
+		if p.configPath == "" {
+			p.configPath = p.model + ".json"
+		}
+		rate, err := readSampleRate(p.configPath)
+		if err != nil {
+			return nil, err
+		}
+		p.rates[p.model] = rate
+	}
+	return p, nil
+}
+
+// SampleRate returns the sample rate in Hz of the PCM produced by the provider.
+func (p *Provider) SampleRate() int { return p.outputRate }
+
+// ---- internal request/response types ----
+
+// ttsRequest is the JSON body sent to POST / in server mode.
+type ttsRequest struct {
+	Text        string  `json:"text"`
+	Voice       string  `json:"voice,omitempty"`
+	LengthScale float64 `json:"length_scale,omitempty"`
+}
+
+// modelConfig holds the fields of a Piper model config the provider uses.
+type modelConfig struct {
+	Audio struct {
+		SampleRate int `json:"sample_rate"`
+	} `json:"audio"`
+}
+
+// ---- SynthesizeStream ----
+
+// SynthesizeStream consumes text fragments from the text channel, accumulates
+// them into complete sentences (split on '.', '!', '?' followed by whitespace
+// or EOF), and synthesises each sentence with one Piper call. voice.ID selects
+// the model (a server voice name or a local model path); empty uses the
+// configured model. A voice.SpeedFactor other than 1.0 is passed to Piper as
+// its length scale. The PCM is resampled to the output rate and emitted on the
+// audio channel in the original sentence order.
+//
+// Up to the configured concurrency (see [WithConcurrency]) calls may be in
+// flight at once. Sentence splitting and ordered dispatch are provided by
+// [pipeline.Pipeline].
+//
+// The audio channel is closed when all text has been synthesised, when a
+// sentence fails, or when ctx is cancelled; [tts.Stream.Err] reports the
+// failure. The caller must drain the channel to prevent goroutine leaks.
+func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	if voice.SpeedFactor < 0 {
+		return nil, fmt.Errorf("piper: speed factor must not be negative, got %g", voice.SpeedFactor)
+	}
+	pl := pipeline.New(func(ctx context.Context, sentence string) ([]byte, error) {
+		return p.synthesize(ctx, sentence, voice)
+	}, pipeline.WithConcurrency(p.concurrency), pipeline.WithMaxSentenceLength(p.maxTextLength))
+	return pl.Run(ctx, text), nil
+}
+
+// Warmup synthesises a short sentence with the configured model and discards
+// the audio. In local mode this starts the piper executable once, so the model
+// is in the page cache before the first NPC line; in server mode it makes the
+// server load the voice.
+func (p *Provider) Warmup(ctx context.Context) error {
+	_, err := p.synthesize(ctx, warmupText, tts.VoiceProfile{})
+	return err
+}
+
+// synthesize performs a single synthesis call for sentence and returns its
+// PCM at the output sample rate.
+func (p *Provider) synthesize(ctx context.Context, sentence string, voice tts.VoiceProfile) ([]byte, error) {
+	model := voice.ID
+	if model == "" {
+		model = p.model
+	}
+	var (
+		pcm  []byte
+		rate int
+		err  error
+	)
+	if p.serverURL != "" {
+		pcm, rate, err = p.synthesizeServer(ctx, sentence, model, lengthScale(voice))
+	} else {
+		pcm, rate, err = p.synthesizeLocal(ctx, sentence, model, lengthScale(voice))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return audio.ResampleMono16(pcm, rate, p.outputRate), nil
+}
+
+// synthesizeServer sends sentence to the Piper HTTP server and returns the
+// PCM of the WAV response together with its sample rate.
+func (p *Provider) synthesizeServer(ctx context.Context, sentence, model string, scale float64) ([]byte, int, error) {
+	data, err := json.Marshal(ttsRequest{Text: sentence, Voice: model, LengthScale: scale})
+	if err != nil {
+		return nil, 0, fmt.Errorf("piper: marshal tts request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.serverURL+ttsEndpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("piper: create tts request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "audio/wav")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("piper: POST %s: %w", ttsEndpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("piper: POST %s returned status %d", ttsEndpoint, resp.StatusCode)
+	}
+
+	wav, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("piper: read WAV response: %w", err)
+	}
+	info, err := audio.ParseWAV(wav)
+	if err != nil {
+		return nil, 0, fmt.Errorf("piper: %w", err)
+	}
+	if info.Channels != 1 || info.BitsPerSample != 16 {
+		return nil, 0, fmt.Errorf("piper: expected 16-bit mono WAV, got %d-bit with %d channels", info.BitsPerSample, info.Channels)
+	}
+	return wav[info.DataOffset:], info.SampleRate, nil
+}
+
+// synthesizeLocal runs the piper executable for sentence and returns the raw
+// PCM it writes to stdout together with the model's sample rate.
+func (p *Provider) synthesizeLocal(ctx context.Context, sentence, model string, scale float64) ([]byte, int, error) {
+	rate, err := p.sampleRate(model)
+	if err != nil {
+		return nil, 0, err
+	}
+	if p.httpClient.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.httpClient.Timeout)
+		defer cancel()
+	}
+
+	args := []string{"--model", model, "--config", p.config(model), "--output-raw"}
+	if scale > 0 {
+		args = append(args, "--length_scale", strconv.FormatFloat(scale, 'f', -1, 64))
+	}
+	cmd := exec.CommandContext(ctx, p.binary, args...)
+	cmd.Stdin = strings.NewReader(sentence + "\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, fmt.Errorf("piper: run %s: %w", p.binary, ctx.Err())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, 0, fmt.Errorf("piper: run %s: %w: %s", p.binary, err, lastLine(msg))
+		}
+		return nil, 0, fmt.Errorf("piper: run %s: %w", p.binary, err)
+	}
+	return stdout.Bytes(), rate, nil
+}
+
+// config returns the path to the JSON config of a local model.
+func (p *Provider) config(model string) string {
+	if model == p.model {
+		return p.configPath
+	}
+	return model + ".json"
+}
+
+// sampleRate returns the native sample rate of a local model, reading its
+// config on first use.
+func (p *Provider) sampleRate(model string) (int, error) {
+	p.rateMu.Lock()
+	defer p.rateMu.Unlock()
+	if rate, ok := p.rates[model]; ok {
+		return rate, nil
+	}
+	rate, err := readSampleRate(p.config(model))
+	if err != nil {
+		return 0, err
+	}
+	p.rates[model] = rate
+	return rate, nil
+}
+
+// ---- ListVoices ----
+
+// ListVoices returns one VoiceProfile for the configured model. In server
+// mode without [WithModel] it describes the server's default voice with an
+// empty ID.
+func (p *Provider) ListVoices(_ context.Context) ([]tts.VoiceProfile, error) {
+	name := modelName(p.model)
+	if name == "" {
+		name = "default"
+	}
+	return []tts.VoiceProfile{
+		{
+			ID:       p.model,
+			Name:     name,
+			Provider: "piper",
+			Language: p.language,
+			Metadata: map[string]string{
+				"type": "model",
+			},
+		},
+	}, nil
+}
+
+// ---- CloneVoice ----
+
+// CloneVoice is not supported by Piper and always returns an error.
+func (p *Provider) CloneVoice(_ context.Context, _ [][]byte) (*tts.VoiceProfile, error) {
+	return nil, errors.New("piper: voice cloning is not supported")
+}
+
+// ---- helpers ----
+
+// readSampleRate reads the native sample rate from the Piper model config at
+// path, falling back to [NativeSampleRate] if the config does not state one.
+func readSampleRate(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("piper: read model config: %w", err)
+	}
+	var cfg modelConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return 0, fmt.Errorf("piper: decode model config %s: %w", path, err)
+	}
+	if cfg.Audio.SampleRate <= 0 {
+		return NativeSampleRate, nil
+	}
+	return cfg.Audio.SampleRate, nil
+}
+
+// lengthScale converts the speed factor of voice into Piper's length scale,
+// the inverse of the speaking rate. It returns 0, meaning the model's
+// default, for an unset or neutral speed factor.
+func lengthScale(voice tts.VoiceProfile) float64 {
+	if voice.SpeedFactor == 0 || voice.SpeedFactor == 1 {
+		return 0
+	}
+	return 1 / voice.SpeedFactor
+}
+
+// modelName returns the voice name of a model: the file name without the
+// ".onnx" extension for a local path, or the server voice name unchanged.
+func modelName(model string) string {
+	if model == "" {
+		return ""
+	}
+	return strings.TrimSuffix(filepath.Base(model), ".onnx")
+}
+
+// lastLine returns the last line of a multi-line process error output.
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
@@ -0,0 +1,362 @@
+package piper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// ---- test helpers ----
+
+// sendFragments sends the given text fragments on a freshly-created channel,
+// then closes it.
+func sendFragments(fragments ...string) <-chan string {
+	ch := make(chan string, len(fragments))
+	for _, f := range fragments {
+		ch <- f
+	}
+	close(ch)
+	return ch
+}
+
+// drainAudio reads all chunks from stream until its audio channel is closed
+// and returns the concatenated PCM data.
+func drainAudio(stream *tts.Stream) []byte {
+	var out []byte
+	for chunk := range stream.Audio() {
+		out = append(out, chunk...)
+	}
+	return out
+}
+
+// writeModel creates an empty model file with a config stating rate in dir
+// and returns the model path.
+func writeModel(t *testing.T, dir, name string, rate int) string {
+	t.Helper()
+	model := filepath.Join(dir, name+".onnx")
+	if err := os.WriteFile(model, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := `{"audio": {"sample_rate": ` + strconv.Itoa(rate) + `}}`
+	if err := os.WriteFile(model+".json", []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return model
+}
+
+// fakeBinary writes a shell script standing in for the piper executable. It
+// appends its arguments and stdin to a log file and writes samples bytes of
+// silence to stdout. It returns the script path and the log path.
+func fakeBinary(t *testing.T, samples int) (bin, log string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake piper binary requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	bin = filepath.Join(dir, "piper")
+	log = filepath.Join(dir, "calls.log")
+	script := "#!/bin/sh\n" +
+		"echo \"$@\" >> " + log + "\n" +
+		"cat >> " + log + "\n" +
+		"head -c " + strconv.Itoa(samples*2) + " /dev/zero\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return bin, log
+}
+
+// ---- New ----
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	model := writeModel(t, dir, "en_US-test-medium", 22050)
+
+	tests := []struct {
+		name    string
+		source  string
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "server", source: "http://localhost:5000/"},
+		{name: "local", source: model},
+		{name: "local with config", source: filepath.Join(dir, "other.onnx"), opts: []Option{WithConfig(model + ".json")}},
+		{name: "empty source", source: "", wantErr: true},
+		{name: "missing config", source: filepath.Join(dir, "missing.onnx"), wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := New(tc.source, tc.opts...)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("New(%q) error = %v, wantErr %v", tc.source, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// ---- SynthesizeStream (server mode) ----
+
+func TestSynthesizeStream_Server(t *testing.T) {
+	t.Parallel()
+
+	const nativeSamples = 2205 // 100 ms at 22050 Hz
+	var (
+		mu       sync.Mutex
+		requests []ttsRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		var req ttsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "audio/wav")
+		w.Write(audio.EncodeWAV(make([]byte, nativeSamples*2), NativeSampleRate, 1))
+	}))
+	defer srv.Close()
+
+	p, err := New(srv.URL, WithModel("en_US-lessac-medium"), WithOutputSampleRate(16000), WithConcurrency(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	stream, err := p.SynthesizeStream(context.Background(), sendFragments("Hello there. ", "Who ", "goes there?"), tts.VoiceProfile{SpeedFactor: 2})
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	pcm := drainAudio(stream)
+
+	// Each sentence is resampled from 22050 Hz to 16000 Hz.
+	if want := 2 * 1600 * 2; len(pcm) != want {
+		t.Errorf("PCM length = %d bytes, want %d", len(pcm), want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(requests))
+	}
+	for i, want := range []string{"Hello there.", "Who goes there?"} {
+		got := requests[i]
+		if strings.TrimSpace(got.Text) != want {
+			t.Errorf("request %d text = %q, want %q", i, got.Text, want)
+		}
+		if got.Voice != "en_US-lessac-medium" {
+			t.Errorf("request %d voice = %q, want the configured model", i, got.Voice)
+		}
+		if got.LengthScale != 0.5 {
+			t.Errorf("request %d length_scale = %g, want 0.5", i, got.LengthScale)
+		}
+	}
+}
+
+func TestSynthesizeStream_ServerError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "model not loaded", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	p, err := New(srv.URL)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	stream, err := p.SynthesizeStream(context.Background(), sendFragments("Hello."), tts.VoiceProfile{})
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	if pcm := drainAudio(stream); len(pcm) != 0 {
+		t.Errorf("got %d bytes of audio from a failing server, want none", len(pcm))
+	}
+	if err := stream.Err(); err == nil {
+		t.Error("Err() = nil, want the server error")
+	}
+}
+
+func TestSynthesizeStream_NegativeSpeed(t *testing.T) {
+	t.Parallel()
+
+	p, err := New("http://localhost:5000")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := p.SynthesizeStream(context.Background(), sendFragments("Hi."), tts.VoiceProfile{SpeedFactor: -1}); err == nil {
+		t.Error("SynthesizeStream with negative speed factor: want error, got nil")
+	}
+}
+
+// ---- SynthesizeStream (local mode) ----
+
+func TestSynthesizeStream_Local(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	model := writeModel(t, dir, "en_US-test-medium", 22050)
+	other := writeModel(t, dir, "de_DE-test-low", 16000)
+	bin, log := fakeBinary(t, 2205)
+
+	p, err := New(model, WithBinary(bin), WithOutputSampleRate(44100), WithConcurrency(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	stream, err := p.SynthesizeStream(context.Background(), sendFragments("Well met, traveller."), tts.VoiceProfile{})
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	// 100 ms at 22050 Hz becomes 100 ms at 44100 Hz.
+	if pcm, want := drainAudio(stream), 4410*2; len(pcm) != want {
+		t.Errorf("default model PCM length = %d bytes, want %d", len(pcm), want)
+	}
+
+	// voice.ID selects another model with its own sample rate.
+	stream, err = p.SynthesizeStream(context.Background(), sendFragments("Guten Tag."), tts.VoiceProfile{ID: other, SpeedFactor: 0.5})
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	// 2205 samples at 16000 Hz become 6077 samples at 44100 Hz.
+	if pcm, want := drainAudio(stream), 6077*2; len(pcm) != want {
+		t.Errorf("other model PCM length = %d bytes, want %d", len(pcm), want)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := string(data)
+	for _, want := range []string{
+		"--model " + model + " --config " + model + ".json --output-raw\nWell met, traveller.\n",
+		"--model " + other + " --config " + other + ".json --output-raw --length_scale 2\nGuten Tag.\n",
+	} {
+		if !strings.Contains(calls, want) {
+			t.Errorf("piper calls = %q, want them to contain %q", calls, want)
+		}
+	}
+}
+
+// ---- Warmup ----
+
+func TestWarmup(t *testing.T) {
+	t.Parallel()
+
+	t.Run("local", func(t *testing.T) {
+		t.Parallel()
+
+		model := writeModel(t, t.TempDir(), "en_US-test-medium", 22050)
+		bin, log := fakeBinary(t, 2205)
+		p, err := New(model, WithBinary(bin))
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		if err := p.Warmup(context.Background()); err != nil {
+			t.Fatalf("Warmup: %v", err)
+		}
+		data, err := os.ReadFile(log)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "--model " + model; !strings.Contains(string(data), want) {
+			t.Errorf("piper calls = %q, want a call with %q", data, want)
+		}
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "model not loaded", http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+
+		p, err := New(srv.URL)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		if err := p.Warmup(context.Background()); err == nil {
+			t.Error("Warmup against a failing server: want error, got nil")
+		}
+	})
+}
+
+// ---- ListVoices / CloneVoice ----
+
+func TestListVoices(t *testing.T) {
+	t.Parallel()
+
+	model := writeModel(t, t.TempDir(), "en_GB-alan-low", 16000)
+	tests := []struct {
+		name   string
+		source string
+		opts   []Option
+		want   tts.VoiceProfile
+	}{
+		{
+			name:   "local model",
+			source: model,
+			opts:   []Option{WithLanguage("en-GB")},
+			want:   tts.VoiceProfile{ID: model, Name: "en_GB-alan-low", Language: "en-GB"},
+		},
+		{
+			name:   "server model",
+			source: "http://localhost:5000",
+			opts:   []Option{WithModel("en_US-lessac-medium")},
+			want:   tts.VoiceProfile{ID: "en_US-lessac-medium", Name: "en_US-lessac-medium", Language: "en"},
+		},
+		{
+			name:   "server default",
+			source: "http://localhost:5000",
+			want:   tts.VoiceProfile{ID: "", Name: "default", Language: "en"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := New(tc.source, tc.opts...)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			voices, err := p.ListVoices(context.Background())
+			if err != nil {
+				t.Fatalf("ListVoices: %v", err)
+			}
+			if len(voices) != 1 {
+				t.Fatalf("ListVoices returned %d voices, want 1", len(voices))
+			}
+			got := voices[0]
+			if got.ID != tc.want.ID || got.Name != tc.want.Name || got.Language != tc.want.Language || got.Provider != "piper" {
+				t.Errorf("voice = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCloneVoice_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	p, err := New("http://localhost:5000")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := p.CloneVoice(context.Background(), [][]byte{{0}}); err == nil {
+		t.Error("CloneVoice: want error, got nil")
+	}
+}
@@ -0,0 +1,329 @@
+// Package polly provides an Amazon Polly-backed TTS provider. It implements
+// the tts.Provider interface using the AWS SDK for Go v2.
+//
+// Polly synthesises one request at a time, so SynthesizeStream accumulates
+// incoming text fragments into complete sentences and dispatches concurrent
+// SynthesizeSpeech calls with a small lookahead window, emitting the audio in
+// the original sentence order (see package pipeline). Audio is requested as
+// raw 16-bit little-endian mono PCM, which Polly offers at 8 kHz or 16 kHz.
+//
+// Credentials and, unless [WithRegion] is given, the region are resolved by
+// the default AWS credential chain: environment variables, the shared
+// config and credentials files, and instance or task roles.
+//
+// Typical usage:
+//
+//	p, err := polly.New(
+//	    polly.WithRegion("eu-central-1"),
+//	    polly.WithVoiceEngine("neural"),
+//	)
+//	stream, err := p.SynthesizeStream(ctx, textCh, tts.VoiceProfile{ID: "Vicki"})
+package polly
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/polly"
+	"github.com/aws/aws-sdk-go-v2/service/polly/types"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/ident"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts/pipeline"
+)
+
+// Compile-time interface assertions.
+var (
+	_ tts.Provider       = (*Provider)(nil)
+	_ tts.FormatReporter = (*Provider)(nil)
+)
+
+const (
+	// DefaultVoiceEngine is the Polly engine used when [WithVoiceEngine] is
+	// not supplied.
+	DefaultVoiceEngine = "neural"
+
+	// DefaultSampleRate is the PCM sample rate in Hz used when
+	// [WithSampleRate] is not supplied.
+	DefaultSampleRate = 16000
+
+	// DefaultMaxTextLength is the number of characters Polly accepts in one
+	// SynthesizeSpeech call, used when [WithMaxTextLength] is not supplied.
+	DefaultMaxTextLength = 3000
+)
+
+// Client is the subset of the Polly API used by [Provider]. It is satisfied
+// by *polly.Client and allows tests to substitute a fake.
+type Client interface {
+	SynthesizeSpeech(ctx context.Context, params *polly.SynthesizeSpeechInput, optFns ...func(*polly.Options)) (*polly.SynthesizeSpeechOutput, error)
+	DescribeVoices(ctx context.Context, params *polly.DescribeVoicesInput, optFns ...func(*polly.Options)) (*polly.DescribeVoicesOutput, error)
+}
+
+// Option is a functional option for configuring a Polly Provider.
+type Option func(*Provider)
+
+// WithRegion sets the AWS region (e.g., "us-east-1"). Defaults to the region
+// of the default AWS configuration.
+func WithRegion(region string) Option {
+	return func(p *Provider) {
+		p.region = region
+	}
+}
+
+// WithVoiceEngine selects the Polly engine: "standard", "neural",
+// "long-form", or "generative". Defaults to [DefaultVoiceEngine]. The
+// chosen voice must support the engine.
+func WithVoiceEngine(engine string) Option {
+	return func(p *Provider) {
+		p.engine = types.Engine(engine)
+	}
+}
+
+// WithSampleRate sets the PCM sample rate in Hz. Polly supports 8000 and
+// 16000 for PCM output. Defaults to [DefaultSampleRate].
+func WithSampleRate(hz int) Option {
+	return func(p *Provider) {
+		p.sampleRate = hz
+	}
+}
+
+// WithLanguageCode sets the language of bilingual voices (e.g., "en-IN" for
+// Aditi). Leave unset for all other voices.
+func WithLanguageCode(code string) Option {
+	return func(p *Provider) {
+		p.languageCode = types.LanguageCode(code)
+	}
+}
+
+// WithEndpoint overrides the Polly API endpoint, for example to target a
+// local AWS emulator.
+func WithEndpoint(url string) Option {
+	return func(p *Provider) {
+		p.endpoint = url
+	}
+}
+
+// WithConcurrency sets how many sentence synthesis requests may be in flight
+// at the same time. Defaults to [pipeline.DefaultConcurrency]. Values < 1 are
+// ignored.
+func WithConcurrency(n int) Option {
+	return func(p *Provider) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// WithMaxTextLength caps the characters sent in one SynthesizeSpeech call.
+// Longer sentences are split at clause or word boundaries and synthesised as
+// several requests in order. Defaults to [DefaultMaxTextLength], Polly's
+// limit; values < 1 disable splitting.
+func WithMaxTextLength(n int) Option {
+	return func(p *Provider) {
+		p.maxTextLength = n
+	}
+}
+
+// WithClient injects the Polly API client instead of building one from the
+// default AWS configuration. [WithRegion] and [WithEndpoint] have no effect
+// on an injected client.
+func WithClient(c Client) Option {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider implements tts.Provider backed by Amazon Polly.
+// It is safe for concurrent use; multiple SynthesizeStream calls may run in parallel.
+type Provider struct {
+	client        Client
+	region        string
+	endpoint      string
+	engine        types.Engine
+	sampleRate    int
+	languageCode  types.LanguageCode
+	concurrency   int
+	maxTextLength int
+}
+
+// New creates a Polly Provider. Unless a client is injected with
+// [WithClient], it loads the default AWS configuration, which fails if no
+// region can be determined.
+func New(opts ...Option) (*Provider, error) {
+	p := &Provider{
+		engine:        DefaultVoiceEngine,
+		sampleRate:    DefaultSampleRate,
+		concurrency:   pipeline.DefaultConcurrency,
+		maxTextLength: DefaultMaxTextLength,
+	}
+	for _, o := range opts {
+		o(p)
+	}
+
+	if p.sampleRate != 8000 && p.sampleRate != 16000 {
+		return nil, fmt.Errorf("polly: sample rate %d is not supported for PCM output (want 8000 or 16000)", p.sampleRate)
+	}
+	if p.client != nil {
+		return p, nil
+	}
+
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if p.region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(p.region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("polly: load AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("polly: no AWS region configured")
+	}
+	p.client = polly.NewFromConfig(cfg, func(o *polly.Options) {
+		o.HTTPClient = identClient{next: o.HTTPClient}
+		if p.endpoint != "" {
+			o.BaseEndpoint = aws.String(p.endpoint)
+		}
+	})
+	return p, nil
+}
+
+// identClient adds Glyphoxa's identification headers to the requests of the
+// AWS SDK. It runs after request signing; SigV4 does not sign the User-Agent
+// and tolerates the unsigned session header.
+type identClient struct {
+	next polly.HTTPClient
+}
+
+// Do implements [polly.HTTPClient].
+func (c identClient) Do(req *http.Request) (*http.Response, error) {
+	ident.Header(req.Context(), req.Header)
+	return c.next.Do(req)
+}
+
+// SampleRate returns the sample rate in Hz of the PCM produced by the provider.
+func (p *Provider) SampleRate() int { return p.sampleRate }
+
+// SynthesizeStream consumes text fragments from the text channel, accumulates
+// them into complete sentences, and synthesises each sentence with a Polly
+// SynthesizeSpeech call. The raw PCM is emitted on the audio channel in the
+// original sentence order.
+//
+// voice.ID must be a Polly voice ID (e.g., "Joanna"). A voice.SpeedFactor
+// other than 1 is applied with SSML prosody; PitchShift is ignored because
+// neural voices do not support it. A sentence that is itself an SSML
+// document ("<speak>…</speak>") is sent as SSML unchanged.
+//
+// The audio channel is closed when all text has been synthesised, when a
+// sentence fails, or when ctx is cancelled; [tts.Stream.Err] reports the
+// failure. The caller must drain the channel to prevent goroutine leaks.
+func (p *Provider) SynthesizeStream(ctx context.Context, text <-chan string, voice tts.VoiceProfile) (*tts.Stream, error) {
+	if voice.ID == "" {
+		return nil, errors.New("polly: voice.ID must not be empty")
+	}
+	pl := pipeline.New(func(ctx context.Context, sentence string) ([]byte, error) {
+		return p.synthesize(ctx, sentence, voice)
+	}, pipeline.WithConcurrency(p.concurrency), pipeline.WithMaxSentenceLength(p.maxTextLength))
+	return pl.Run(ctx, text), nil
+}
+
+// synthesize performs a single SynthesizeSpeech call and returns the PCM.
+func (p *Provider) synthesize(ctx context.Context, sentence string, voice tts.VoiceProfile) ([]byte, error) {
+	out, err := p.client.SynthesizeSpeech(ctx, p.speechInput(sentence, voice))
+	if err != nil {
+		return nil, fmt.Errorf("polly: synthesize speech: %w", err)
+	}
+	defer out.AudioStream.Close()
+
+	pcm, err := io.ReadAll(out.AudioStream)
+	if err != nil {
+		return nil, fmt.Errorf("polly: read audio stream: %w", err)
+	}
+	return pcm, nil
+}
+
+// speechInput builds the SynthesizeSpeech request for sentence.
+func (p *Provider) speechInput(sentence string, voice tts.VoiceProfile) *polly.SynthesizeSpeechInput {
+	in := &polly.SynthesizeSpeechInput{
+		Engine:       p.engine,
+		LanguageCode: p.languageCode,
+		OutputFormat: types.OutputFormatPcm,
+		SampleRate:   aws.String(strconv.Itoa(p.sampleRate)),
+		Text:         aws.String(sentence),
+		TextType:     types.TextTypeText,
+		VoiceId:      types.VoiceId(voice.ID),
+	}
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(sentence), "<speak>"):
+		in.TextType = types.TextTypeSsml
+	case voice.SpeedFactor > 0 && voice.SpeedFactor != 1:
+		in.TextType = types.TextTypeSsml
+		in.Text = aws.String(fmt.Sprintf(`<speak><prosody rate="%d%%">%s</prosody></speak>`,
+			int(voice.SpeedFactor*100+0.5), escapeSSML(sentence)))
+	}
+	return in
+}
+
+// ssmlEscaper escapes the characters that are special in SSML text.
+var ssmlEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+	"'", "&apos;",
+)
+
+// escapeSSML escapes s for use as SSML text content.
+func escapeSSML(s string) string { return ssmlEscaper.Replace(s) }
+
+// ListVoices returns the Polly voices that support the configured engine,
+// following DescribeVoices pagination. Metadata carries the voice's gender,
+// language_code, language_name, and comma-separated supported engines.
+func (p *Provider) ListVoices(ctx context.Context) ([]tts.VoiceProfile, error) {
+	var (
+		profiles []tts.VoiceProfile
+		token    *string
+	)
+	for {
+		out, err := p.client.DescribeVoices(ctx, &polly.DescribeVoicesInput{
+			Engine:    p.engine,
+			NextToken: token,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("polly: describe voices: %w", err)
+		}
+		for _, v := range out.Voices {
+			engines := make([]string, len(v.SupportedEngines))
+			for i, e := range v.SupportedEngines {
+				engines[i] = string(e)
+			}
+			profiles = append(profiles, tts.VoiceProfile{
+				ID:       string(v.Id),
+				Name:     cmp.Or(aws.ToString(v.Name), string(v.Id)),
+				Provider: "polly",
+				Metadata: map[string]string{
+					"gender":        string(v.Gender),
+					"language_code": string(v.LanguageCode),
+					"language_name": aws.ToString(v.LanguageName),
+					"engines":       strings.Join(engines, ","),
+				},
+			})
+		}
+		token = out.NextToken
+		if aws.ToString(token) == "" {
+			return profiles, nil
+		}
+	}
+}
+
+// CloneVoice is not supported by Amazon Polly and always returns an error.
+func (p *Provider) CloneVoice(_ context.Context, _ [][]byte) (*tts.VoiceProfile, error) {
+	return nil, errors.New("polly: voice cloning is not supported")
+}
@@ -0,0 +1,321 @@
+package polly
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/polly"
+	"github.com/aws/aws-sdk-go-v2/service/polly/types"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/tts"
+)
+
+// fakeClient is an in-memory [Client] that records every request. Each
+// synthesised sentence is answered with PCM derived from its text so the
+// output order can be checked.
+type fakeClient struct {
+	mu          sync.Mutex
+	speechCalls []*polly.SynthesizeSpeechInput
+	voiceCalls  []*polly.DescribeVoicesInput
+
+	synthErr error
+	pages    []*polly.DescribeVoicesOutput
+}
+
+func (f *fakeClient) SynthesizeSpeech(_ context.Context, in *polly.SynthesizeSpeechInput, _ ...func(*polly.Options)) (*polly.SynthesizeSpeechOutput, error) {
+	f.mu.Lock()
+	f.speechCalls = append(f.speechCalls, in)
+	f.mu.Unlock()
+	if f.synthErr != nil {
+		return nil, f.synthErr
+	}
+	return &polly.SynthesizeSpeechOutput{
+		AudioStream: io.NopCloser(bytes.NewReader(pcmFor(aws.ToString(in.Text)))),
+	}, nil
+}
+
+func (f *fakeClient) DescribeVoices(_ context.Context, in *polly.DescribeVoicesInput, _ ...func(*polly.Options)) (*polly.DescribeVoicesOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.voiceCalls = append(f.voiceCalls, in)
+	return f.pages[len(f.voiceCalls)-1], nil
+}
+
+func (f *fakeClient) calls() []*polly.SynthesizeSpeechInput {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.speechCalls)
+}
+
+// pcmFor returns two bytes of fake PCM per character of text.
+func pcmFor(text string) []byte {
+	return bytes.Repeat([]byte{byte(len(text)), 0}, len(text))
+}
+
+// synthesizeAll feeds fragments to p and returns the concatenated output.
+func synthesizeAll(t *testing.T, p *Provider, voice tts.VoiceProfile, fragments ...string) []byte {
+	t.Helper()
+	textCh := make(chan string, len(fragments))
+	for _, f := range fragments {
+		textCh <- f
+	}
+	close(textCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := p.SynthesizeStream(ctx, textCh, voice)
+	if err != nil {
+		t.Fatalf("SynthesizeStream: %v", err)
+	}
+	var out []byte
+	for chunk := range stream.Audio() {
+		out = append(out, chunk...)
+	}
+	return out
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(WithClient(&fakeClient{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if p.engine != types.EngineNeural {
+		t.Errorf("engine = %q, want %q", p.engine, types.EngineNeural)
+	}
+	if p.SampleRate() != DefaultSampleRate {
+		t.Errorf("SampleRate = %d, want %d", p.SampleRate(), DefaultSampleRate)
+	}
+
+	if _, err := New(WithClient(&fakeClient{}), WithSampleRate(22050)); err == nil {
+		t.Error("New with 22050 Hz: expected error, PCM supports only 8000 and 16000")
+	}
+}
+
+func TestSynthesizeStream_RequestShape(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{}
+	p, err := New(WithClient(client), WithVoiceEngine("standard"), WithSampleRate(8000), WithLanguageCode("en-GB"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	got := synthesizeAll(t, p, tts.VoiceProfile{ID: "Brian"}, "Welcome, ", "traveller. ", "Sit down!")
+
+	want := append(pcmFor("Welcome, traveller."), pcmFor("Sit down!")...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("PCM output = %v, want %v", got, want)
+	}
+
+	calls := client.calls()
+	if len(calls) != 2 {
+		t.Fatalf("SynthesizeSpeech calls = %d, want 2", len(calls))
+	}
+	var texts []string
+	for _, in := range calls {
+		texts = append(texts, aws.ToString(in.Text))
+		if in.OutputFormat != types.OutputFormatPcm {
+			t.Errorf("OutputFormat = %q, want pcm", in.OutputFormat)
+		}
+		if aws.ToString(in.SampleRate) != "8000" {
+			t.Errorf("SampleRate = %q, want 8000", aws.ToString(in.SampleRate))
+		}
+		if in.Engine != types.EngineStandard {
+			t.Errorf("Engine = %q, want standard", in.Engine)
+		}
+		if in.VoiceId != "Brian" {
+			t.Errorf("VoiceId = %q, want Brian", in.VoiceId)
+		}
+		if in.LanguageCode != "en-GB" {
+			t.Errorf("LanguageCode = %q, want en-GB", in.LanguageCode)
+		}
+		if in.TextType != types.TextTypeText {
+			t.Errorf("TextType = %q, want text", in.TextType)
+		}
+	}
+	slices.Sort(texts)
+	if !slices.Equal(texts, []string{"Sit down!", "Welcome, traveller."}) {
+		t.Errorf("sentences = %q", texts)
+	}
+}
+
+func TestSynthesizeStream_MaxTextLength(t *testing.T) {
+	t.Parallel()
+
+	// A monologue sentence far beyond Polly's limit.
+	long := strings.Repeat("the wind howled over the barrow hills, ", 200) + "and then it was quiet."
+
+	// Sequential requests, so the calls are recorded in sentence order.
+	client := &fakeClient{}
+	p, err := New(WithClient(client), WithConcurrency(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got := synthesizeAll(t, p, tts.VoiceProfile{ID: "Brian"}, long)
+
+	calls := client.calls()
+	if len(calls) < 3 {
+		t.Fatalf("SynthesizeSpeech calls = %d, want the %d characters split into at least 3", len(calls), len(long))
+	}
+	var (
+		pieces []string
+		want   []byte
+	)
+	for _, in := range calls {
+		text := aws.ToString(in.Text)
+		if len(text) > DefaultMaxTextLength {
+			t.Errorf("request of %d characters exceeds the limit of %d", len(text), DefaultMaxTextLength)
+		}
+		pieces = append(pieces, text)
+		want = append(want, pcmFor(text)...)
+	}
+	if joined := strings.Join(pieces, " "); joined != long {
+		t.Errorf("requests do not re-join to the original sentence:\n got %q\nwant %q", joined, long)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("PCM output is not the requests' audio in order")
+	}
+}
+
+func TestSpeechInput_SSML(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(WithClient(&fakeClient{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		sentence string
+		speed    float64
+		wantType types.TextType
+		wantText string
+	}{
+		{
+			name:     "default speed is plain text",
+			sentence: "Salt & pepper.",
+			speed:    1,
+			wantType: types.TextTypeText,
+			wantText: "Salt & pepper.",
+		},
+		{
+			name:     "speed uses escaped prosody",
+			sentence: "Salt & pepper.",
+			speed:    1.25,
+			wantType: types.TextTypeSsml,
+			wantText: `<speak><prosody rate="125%">Salt &amp; pepper.</prosody></speak>`,
+		},
+		{
+			name:     "ssml document passes through",
+			sentence: `<speak>Hush. <break time="1s"/> Listen.</speak>`,
+			speed:    1.5,
+			wantType: types.TextTypeSsml,
+			wantText: `<speak>Hush. <break time="1s"/> Listen.</speak>`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			in := p.speechInput(tc.sentence, tts.VoiceProfile{ID: "Joanna", SpeedFactor: tc.speed})
+			if in.TextType != tc.wantType {
+				t.Errorf("TextType = %q, want %q", in.TextType, tc.wantType)
+			}
+			if got := aws.ToString(in.Text); got != tc.wantText {
+				t.Errorf("Text = %q, want %q", got, tc.wantText)
+			}
+		})
+	}
+}
+
+func TestSynthesizeStream_Errors(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{synthErr: errors.New("throttled")}
+	p, err := New(WithClient(client))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if _, err := p.SynthesizeStream(context.Background(), make(chan string), tts.VoiceProfile{}); err == nil {
+		t.Error("SynthesizeStream without voice ID: expected error")
+	}
+
+	// A failing sentence closes the stream early instead of hanging.
+	if got := synthesizeAll(t, p, tts.VoiceProfile{ID: "Joanna"}, "Hello there."); len(got) != 0 {
+		t.Errorf("got %d bytes of audio after synthesis error, want 0", len(got))
+	}
+}
+
+func TestListVoices(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{pages: []*polly.DescribeVoicesOutput{
+		{
+			Voices: []types.Voice{{
+				Id:               types.VoiceIdJoanna,
+				Name:             aws.String("Joanna"),
+				Gender:           types.GenderFemale,
+				LanguageCode:     types.LanguageCodeEnUs,
+				LanguageName:     aws.String("US English"),
+				SupportedEngines: []types.Engine{types.EngineStandard, types.EngineNeural},
+			}},
+			NextToken: aws.String("page-2"),
+		},
+		{
+			Voices: []types.Voice{{Id: types.VoiceIdVicki, SupportedEngines: []types.Engine{types.EngineNeural}}},
+		},
+	}}
+	p, err := New(WithClient(client))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	voices, err := p.ListVoices(context.Background())
+	if err != nil {
+		t.Fatalf("ListVoices: %v", err)
+	}
+	if len(voices) != 2 {
+		t.Fatalf("got %d voices, want 2", len(voices))
+	}
+	if len(client.voiceCalls) != 2 || aws.ToString(client.voiceCalls[1].NextToken) != "page-2" {
+		t.Errorf("DescribeVoices did not follow pagination: %d calls", len(client.voiceCalls))
+	}
+	if client.voiceCalls[0].Engine != types.EngineNeural {
+		t.Errorf("DescribeVoices Engine = %q, want neural", client.voiceCalls[0].Engine)
+	}
+
+	j := voices[0]
+	if j.ID != "Joanna" || j.Name != "Joanna" || j.Provider != "polly" {
+		t.Errorf("voice = %+v", j)
+	}
+	if j.Metadata["gender"] != "Female" || j.Metadata["language_code"] != "en-US" || j.Metadata["engines"] != "standard,neural" {
+		t.Errorf("metadata = %v", j.Metadata)
+	}
+	if voices[1].Name != "Vicki" {
+		t.Errorf("unnamed voice Name = %q, want its ID", voices[1].Name)
+	}
+}
+
+func TestCloneVoice_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(WithClient(&fakeClient{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = p.CloneVoice(context.Background(), [][]byte{{1}})
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("CloneVoice error = %v, want not supported", err)
+	}
+}
@@ -3,7 +3,7 @@
 // A TTS provider wraps a speech synthesis service (e.g., ElevenLabs, Google
 // Cloud TTS, or a local Piper instance) and presents a uniform streaming interface.
 // The primary entry point is SynthesizeStream, which accepts a channel of text
-// fragments and returns a channel of raw PCM audio bytes as they become available —
+// fragments and returns a [Stream] of raw PCM audio bytes as they become available —
 // enabling low-latency pipelining between the LLM output and the audio mixer.
 //
 // Implementations must be safe for concurrent use.
@@ -19,24 +19,25 @@ import (
 // run in parallel (e.g., multiple NPC voices at once).
 type Provider interface {
 	// SynthesizeStream consumes text fragments from the text channel and returns a
-	// channel that emits raw PCM audio byte slices as they are synthesised. This
-	// design allows the caller to pipe LLM streaming output directly into synthesis
-	// without waiting for the full text to be available.
+	// [Stream] whose Audio channel emits raw PCM audio byte slices as they are
+	// synthesised. This design allows the caller to pipe LLM streaming output
+	// directly into synthesis without waiting for the full text to be available.
 	//
-	// The returned audio channel is closed by the implementation when all text has
-	// been synthesised or when ctx is cancelled. The caller must drain the audio
-	// channel to avoid blocking the provider's internal goroutines.
+	// The audio channel is closed by the implementation when all text has been
+	// synthesised, when synthesis fails, or when ctx is cancelled. The caller must
+	// drain the audio channel to avoid blocking the provider's internal goroutines.
 	//
 	// voice specifies the voice profile to use for synthesis. Providers should return
 	// an error if the requested voice is not available.
 	//
 	// Returns a non-nil error only if the stream cannot be started. Errors
-	// encountered during synthesis are signalled by closing the audio channel
-	// early. Callers that wrap the returned channel (e.g., engine implementations)
-	// should propagate mid-stream errors via the wrapping type's SetStreamErr
-	// method (see engine.Response or audio.AudioSegment) so that downstream
-	// consumers can distinguish a clean completion from a failure.
-	SynthesizeStream(ctx context.Context, text <-chan string, voice VoiceProfile) (<-chan []byte, error)
+	// encountered during synthesis end the stream early and are reported by
+	// [Stream.Err] once the audio channel is closed. Callers that wrap the audio
+	// channel (e.g., engine implementations) should propagate that error via the
+	// wrapping type's SetStreamErr method (see engine.Response or
+	// audio.AudioSegment) so that downstream consumers can distinguish a clean
+	// completion from a failure.
+	SynthesizeStream(ctx context.Context, text <-chan string, voice VoiceProfile) (*Stream, error)
 
 	// ListVoices returns all voice profiles available from this provider. The list
 	// reflects the provider's current catalogue and may change between calls if the
@@ -0,0 +1,46 @@
+package tts
+
+import "sync/atomic"
+
+// Stream is the audio produced by one [Provider.SynthesizeStream] call.
+//
+// Audio delivers the PCM chunks as they are synthesised. The provider closes
+// the channel when all text has been synthesised, when synthesis fails, or
+// when the stream's context is cancelled. Err tells these cases apart after
+// the channel is closed.
+type Stream struct {
+	audio <-chan []byte
+	err   atomic.Pointer[error]
+}
+
+// NewStream returns a Stream that delivers audio. Providers create it around
+// the channel they write to and call [Stream.SetErr] before closing that
+// channel when synthesis fails.
+func NewStream(audio <-chan []byte) *Stream {
+	return &Stream{audio: audio}
+}
+
+// Audio returns the channel of synthesised PCM chunks. The caller must drain
+// it to avoid blocking the provider's internal goroutines.
+func (s *Stream) Audio() <-chan []byte {
+	return s.audio
+}
+
+// Err returns the error that stopped synthesis before all text was
+// processed, or nil if the stream completed. Cancellation of the stream's
+// context is not reported; check the context instead. Call Err after the
+// Audio channel is closed.
+func (s *Stream) Err() error {
+	if p := s.err.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// SetErr records the error that stopped synthesis. Providers must call it
+// before closing the Audio channel. Only the first non-nil error is kept.
+func (s *Stream) SetErr(err error) {
+	if err != nil {
+		s.err.CompareAndSwap(nil, &err)
+	}
+}
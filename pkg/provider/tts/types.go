@@ -17,6 +17,38 @@ type VoiceProfile struct {
 	// SpeedFactor adjusts speaking rate (0.5–2.0, 1.0 = default).
 	SpeedFactor float64
 
+	// Language is the BCP-47 tag of the language to speak (e.g., "de-DE", "fr").
+	// Empty uses the provider's configured default language. Providers whose
+	// language is fixed by the voice ignore it.
+	Language string
+
 	// Metadata holds provider-specific voice attributes (gender, age, accent, etc.).
 	Metadata map[string]string
 }
+
+// [VoiceProfile.Metadata] keys for per-voice tuning. Providers that support
+// them (currently ElevenLabs) read these to override their configured voice
+// settings; others ignore them. Numeric values are decimal strings in [0, 1].
+const (
+	// MetaStability is how consistent the delivery is; lower is more
+	// expressive.
+	MetaStability = "stability"
+
+	// MetaSimilarityBoost is how closely the output adheres to the original
+	// voice.
+	MetaSimilarityBoost = "similarity_boost"
+
+	// MetaStyle is how strongly the speaking style of the original voice is
+	// exaggerated.
+	MetaStyle = "style"
+
+	// MetaUseSpeakerBoost is "true" or "false" and toggles boosting the
+	// similarity to the original speaker.
+	MetaUseSpeakerBoost = "use_speaker_boost"
+)
+
+// MetaSeedPhrase is a [VoiceProfile.Metadata] key holding a short reference
+// phrase that is synthesised in front of every sentence to keep a cloned
+// voice's timbre consistent. Its audio is trimmed from the output. Supported
+// by Coqui; other providers ignore it.
+const MetaSeedPhrase = "seed_phrase"
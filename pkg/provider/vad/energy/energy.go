@@ -0,0 +1,151 @@
+// Package energy provides a voice activity detection engine that needs no
+// model: it compares the short-term RMS energy of the audio with an adaptive
+// estimate of the background noise floor.
+//
+// It is less accurate than a neural VAD such as [silero] — loud non-speech
+// noise counts as speech — but it has no dependencies, costs next to nothing,
+// and is fully deterministic. Use it where shipping an ONNX model is not an
+// option.
+//
+// Each session cuts its audio into analysis frames of WithFrameMs
+// milliseconds. A frame's energy in dB above the noise floor is mapped to a
+// pseudo-probability in [0, 1], which reaches 0.5 at the margin set by
+// WithSensitivity. Speech starts after WithOnsetFrames consecutive frames at
+// or above the speech threshold and ends once frames have stayed below the
+// silence threshold for WithHangoverMs. The noise floor follows quieter audio
+// quickly and louder audio slowly, and almost not at all during speech.
+//
+// Usage:
+//
+//	eng, err := energy.New(energy.WithSensitivity(0.7), energy.WithHangoverMs(400))
+//	sess, err := eng.NewSession(vad.Config{SampleRate: 48000, FrameSizeMs: 20})
+//	ev, err := sess.ProcessFrame(pcm)
+//	if ev.Type == vad.VADSpeechStart { ... }
+//
+// [silero]: https://pkg.go.dev/github.com/MrWong99/glyphoxa/pkg/provider/vad/silero
+package energy
+
+import (
+	"fmt"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+)
+
+const (
+	defaultFrameMs     = 20
+	defaultHangoverMs  = 300
+	defaultSensitivity = 0.5
+	defaultOnsetFrames = 3
+	defaultSampleRate  = 16000
+
+	// defaultThreshold and silenceOffset derive the session thresholds when
+	// the session Config sets none, matching the other engines.
+	defaultThreshold = 0.5
+	silenceOffset    = 0.15
+)
+
+// Compile-time assertion that Engine implements vad.Engine.
+var _ vad.Engine = (*Engine)(nil)
+
+// Option is a functional option for configuring an Engine.
+type Option func(*Engine)
+
+// WithFrameMs sets the duration of the analysis frames the energy is measured
+// over, in milliseconds. It is independent of the size of the frames passed
+// to ProcessFrame. Defaults to 20.
+func WithFrameMs(ms int) Option {
+	return func(e *Engine) {
+		e.frameMs = ms
+	}
+}
+
+// WithHangoverMs sets how long the energy must stay below the silence
+// threshold before a speech segment ends, in milliseconds. It bridges the
+// short pauses between words. Defaults to 300.
+func WithHangoverMs(ms int) Option {
+	return func(e *Engine) {
+		e.hangoverMs = ms
+	}
+}
+
+// WithSensitivity sets how readily energy above the noise floor counts as
+// speech, in [0, 1]. At the default of 0.5 a frame 15 dB above the floor
+// scores 0.5; 1 lowers that margin to 3 dB and 0 raises it to 27 dB.
+func WithSensitivity(s float64) Option {
+	return func(e *Engine) {
+		e.sensitivity = s
+	}
+}
+
+// WithOnsetFrames sets the number of consecutive analysis frames at or above
+// the speech threshold needed to start a speech segment. Higher values ignore
+// clicks and knocks at the cost of a later start. Defaults to 3.
+func WithOnsetFrames(n int) Option {
+	return func(e *Engine) {
+		e.onsetFrames = n
+	}
+}
+
+// Engine implements [vad.Engine] with an energy detector. It holds only
+// settings, so it is safe for concurrent use and needs no closing.
+type Engine struct {
+	frameMs     int
+	hangoverMs  int
+	sensitivity float64
+	onsetFrames int
+}
+
+// New returns an Engine configured by opts.
+func New(opts ...Option) (*Engine, error) {
+	e := &Engine{
+		frameMs:     defaultFrameMs,
+		hangoverMs:  defaultHangoverMs,
+		sensitivity: defaultSensitivity,
+		onsetFrames: defaultOnsetFrames,
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	switch {
+	case e.frameMs <= 0:
+		return nil, fmt.Errorf("energy: frame duration must be positive, got %d ms", e.frameMs)
+	case e.hangoverMs < 0:
+		return nil, fmt.Errorf("energy: hangover must not be negative, got %d ms", e.hangoverMs)
+	case e.sensitivity < 0 || e.sensitivity > 1:
+		return nil, fmt.Errorf("energy: sensitivity must be in [0, 1], got %v", e.sensitivity)
+	case e.onsetFrames <= 0:
+		return nil, fmt.Errorf("energy: onset frames must be positive, got %d", e.onsetFrames)
+	}
+	return e, nil
+}
+
+// NewSession implements [vad.Engine]. Zero fields of cfg fall back to
+// defaults: SampleRate to 16000 Hz, SpeechThreshold to 0.5 and
+// SilenceThreshold to the speech threshold minus 0.15. A non-zero FrameSizeMs
+// makes ProcessFrame reject frames of any other duration.
+func (e *Engine) NewSession(cfg vad.Config) (vad.SessionHandle, error) {
+	if cfg.SampleRate == 0 {
+		cfg.SampleRate = defaultSampleRate
+	}
+	if cfg.SpeechThreshold == 0 {
+		cfg.SpeechThreshold = defaultThreshold
+	}
+	if cfg.SilenceThreshold == 0 {
+		cfg.SilenceThreshold = max(cfg.SpeechThreshold-silenceOffset, 0.01)
+	}
+	switch {
+	case cfg.SampleRate < 0:
+		return nil, fmt.Errorf("energy: sample rate must be positive, got %d", cfg.SampleRate)
+	case cfg.FrameSizeMs < 0:
+		return nil, fmt.Errorf("energy: frame size must not be negative, got %d ms", cfg.FrameSizeMs)
+	case cfg.SpeechThreshold < 0 || cfg.SpeechThreshold > 1:
+		return nil, fmt.Errorf("energy: speech threshold must be in [0, 1], got %v", cfg.SpeechThreshold)
+	case cfg.SilenceThreshold < 0 || cfg.SilenceThreshold > cfg.SpeechThreshold:
+		return nil, fmt.Errorf("energy: silence threshold must be in [0, %v], got %v", cfg.SpeechThreshold, cfg.SilenceThreshold)
+	}
+	window := cfg.SampleRate * e.frameMs / 1000
+	if window == 0 {
+		return nil, fmt.Errorf("energy: %d ms frames hold no samples at %d Hz", e.frameMs, cfg.SampleRate)
+	}
+	return newSession(e, cfg, window), nil
+}
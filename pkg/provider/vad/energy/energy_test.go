@@ -0,0 +1,345 @@
+package energy_test
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad/energy"
+)
+
+const rate = 16000
+
+// tone returns ms milliseconds of a 440 Hz sine with peak amplitude amp in
+// [0, 1] as 16-bit mono PCM at rate.
+func tone(ms int, amp float64) []byte {
+	n := rate * ms / 1000
+	pcm := make([]byte, 2*n)
+	for i := range n {
+		v := amp * math.Sin(2*math.Pi*440*float64(i)/rate)
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(int16(v*32767)))
+	}
+	return pcm
+}
+
+// silence returns ms milliseconds of digital silence at rate.
+func silence(ms int) []byte {
+	return make([]byte, 2*rate*ms/1000)
+}
+
+// newSession opens a session with 20 ms frames on an engine built from opts.
+func newSession(t *testing.T, opts ...energy.Option) vad.SessionHandle {
+	t.Helper()
+	eng, err := energy.New(opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	sess, err := eng.NewSession(vad.Config{SampleRate: rate, FrameSizeMs: 20})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	t.Cleanup(func() { _ = sess.Close() })
+	return sess
+}
+
+// feed passes pcm to sess in 20 ms frames and returns the event types.
+func feed(t *testing.T, sess vad.SessionHandle, pcm []byte) []vad.VADEventType {
+	t.Helper()
+	const frame = 2 * rate * 20 / 1000
+	var types []vad.VADEventType
+	for i := 0; i+frame <= len(pcm); i += frame {
+		ev, err := sess.ProcessFrame(pcm[i : i+frame])
+		if err != nil {
+			t.Fatalf("ProcessFrame: %v", err)
+		}
+		types = append(types, ev.Type)
+	}
+	return types
+}
+
+// indexOf returns the index of the first event of type want, or -1.
+func indexOf(types []vad.VADEventType, want vad.VADEventType) int {
+	for i, typ := range types {
+		if typ == want {
+			return i
+		}
+	}
+	return -1
+}
+
+// count returns the number of events of type want.
+func count(types []vad.VADEventType, want vad.VADEventType) int {
+	n := 0
+	for _, typ := range types {
+		if typ == want {
+			n++
+		}
+	}
+	return n
+}
+
+func TestNew_Options(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []energy.Option
+		wantErr string
+	}{
+		{name: "defaults"},
+		{name: "all options", opts: []energy.Option{
+			energy.WithFrameMs(10), energy.WithHangoverMs(0), energy.WithSensitivity(1), energy.WithOnsetFrames(1),
+		}},
+		{name: "zero frame", opts: []energy.Option{energy.WithFrameMs(0)}, wantErr: "frame duration must be positive"},
+		{name: "negative hangover", opts: []energy.Option{energy.WithHangoverMs(-1)}, wantErr: "hangover must not be negative"},
+		{name: "sensitivity above 1", opts: []energy.Option{energy.WithSensitivity(1.5)}, wantErr: "sensitivity must be in [0, 1]"},
+		{name: "zero onset", opts: []energy.Option{energy.WithOnsetFrames(0)}, wantErr: "onset frames must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			eng, err := energy.New(tt.opts...)
+			if tt.wantErr == "" {
+				if err != nil || eng == nil {
+					t.Fatalf("New = %v, %v; want an engine", eng, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("New error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEngine_NewSession(t *testing.T) {
+	t.Parallel()
+
+	eng, err := energy.New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	tests := []struct {
+		name    string
+		cfg     vad.Config
+		wantErr string
+	}{
+		{name: "defaults", cfg: vad.Config{}},
+		{name: "explicit", cfg: vad.Config{SampleRate: 48000, FrameSizeMs: 20, SpeechThreshold: 0.6, SilenceThreshold: 0.4}},
+		{name: "negative rate", cfg: vad.Config{SampleRate: -1}, wantErr: "sample rate must be positive"},
+		{name: "negative frame", cfg: vad.Config{FrameSizeMs: -20}, wantErr: "frame size must not be negative"},
+		{name: "threshold above 1", cfg: vad.Config{SpeechThreshold: 1.2}, wantErr: "speech threshold must be in [0, 1]"},
+		{name: "silence above speech", cfg: vad.Config{SpeechThreshold: 0.4, SilenceThreshold: 0.6}, wantErr: "silence threshold must be in"},
+		{name: "frame too short for rate", cfg: vad.Config{SampleRate: 10}, wantErr: "hold no samples"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			sess, err := eng.NewSession(tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("NewSession: %v", err)
+				}
+				_ = sess.Close()
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewSession error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSession_Silence(t *testing.T) {
+	t.Parallel()
+
+	sess := newSession(t)
+	types := feed(t, sess, silence(1000))
+	if n := count(types, vad.VADSilence); n != len(types) {
+		t.Errorf("%d of %d silent frames reported as silence: %v", n, len(types), types)
+	}
+}
+
+func TestSession_SpeechStartAndEnd(t *testing.T) {
+	t.Parallel()
+
+	sess := newSession(t, energy.WithOnsetFrames(3), energy.WithHangoverMs(200))
+	var pcm []byte
+	pcm = append(pcm, silence(200)...)   // frames 0-9
+	pcm = append(pcm, tone(500, 0.3)...) // frames 10-34
+	pcm = append(pcm, silence(600)...)   // frames 35-64
+	types := feed(t, sess, pcm)
+
+	// The third loud frame starts speech.
+	if got := indexOf(types, vad.VADSpeechStart); got != 12 {
+		t.Errorf("speech start at frame %d, want 12: %v", got, types)
+	}
+	// Ten quiet frames (200 ms) end it.
+	if got := indexOf(types, vad.VADSpeechEnd); got != 44 {
+		t.Errorf("speech end at frame %d, want 44: %v", got, types)
+	}
+	if n := count(types, vad.VADSpeechStart); n != 1 {
+		t.Errorf("speech starts = %d, want 1", n)
+	}
+	for i := 13; i < 44; i++ {
+		if types[i] != vad.VADSpeechContinue {
+			t.Fatalf("frame %d = %v, want continue: %v", i, types[i], types)
+		}
+	}
+}
+
+func TestSession_OnsetIgnoresClicks(t *testing.T) {
+	t.Parallel()
+
+	sess := newSession(t, energy.WithOnsetFrames(3))
+	var pcm []byte
+	for range 5 {
+		pcm = append(pcm, silence(200)...)
+		pcm = append(pcm, tone(40, 0.5)...) // two loud frames
+	}
+	if got := indexOf(feed(t, sess, pcm), vad.VADSpeechStart); got != -1 {
+		t.Errorf("clicks shorter than the onset started speech at frame %d", got)
+	}
+}
+
+func TestSession_HangoverBridgesPauses(t *testing.T) {
+	t.Parallel()
+
+	sess := newSession(t, energy.WithHangoverMs(300))
+	var pcm []byte
+	pcm = append(pcm, silence(100)...)
+	pcm = append(pcm, tone(300, 0.3)...)
+	pcm = append(pcm, silence(200)...) // shorter than the hangover
+	pcm = append(pcm, tone(300, 0.3)...)
+	types := feed(t, sess, pcm)
+
+	if n := count(types, vad.VADSpeechStart); n != 1 {
+		t.Errorf("speech starts = %d, want 1: %v", n, types)
+	}
+	if n := count(types, vad.VADSpeechEnd); n != 0 {
+		t.Errorf("speech ends = %d, want 0: %v", n, types)
+	}
+	if last := types[len(types)-1]; last != vad.VADSpeechContinue {
+		t.Errorf("last frame = %v, want continue", last)
+	}
+}
+
+func TestSession_Sensitivity(t *testing.T) {
+	t.Parallel()
+
+	// A hum sets the noise floor; a tone about 10 dB louder follows.
+	var pcm []byte
+	pcm = append(pcm, tone(500, 0.01)...)
+	pcm = append(pcm, tone(500, 0.03)...)
+
+	tests := []struct {
+		sensitivity float64
+		wantSpeech  bool
+	}{
+		{sensitivity: 0, wantSpeech: false},
+		{sensitivity: 0.5, wantSpeech: false},
+		{sensitivity: 1, wantSpeech: true},
+	}
+	for _, tt := range tests {
+		sess := newSession(t, energy.WithSensitivity(tt.sensitivity))
+		got := indexOf(feed(t, sess, pcm), vad.VADSpeechStart) >= 0
+		if got != tt.wantSpeech {
+			t.Errorf("sensitivity %v: speech = %v, want %v", tt.sensitivity, got, tt.wantSpeech)
+		}
+	}
+}
+
+func TestSession_AdaptsToSteadyNoise(t *testing.T) {
+	t.Parallel()
+
+	sess := newSession(t)
+	// A loud, steady hum from the start becomes the noise floor...
+	types := feed(t, sess, tone(1000, 0.05))
+	if got := indexOf(types, vad.VADSpeechStart); got != -1 {
+		t.Errorf("steady hum started speech at frame %d", got)
+	}
+	// ...while a much louder voice on top of it is still detected.
+	if got := indexOf(feed(t, sess, tone(300, 0.8)), vad.VADSpeechStart); got == -1 {
+		t.Error("speech above the hum was not detected")
+	}
+}
+
+func TestSession_ProcessAudioFrame(t *testing.T) {
+	t.Parallel()
+
+	eng, err := energy.New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	handle, err := eng.NewSession(vad.Config{SampleRate: rate})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	sess := handle.(*energy.Session)
+
+	// 48 kHz stereo, as delivered by Discord: duplicate each sample of a
+	// 48 kHz tone into both channels.
+	stereo := func(ms int, amp float64) audio.AudioFrame {
+		n := 48000 * ms / 1000
+		pcm := make([]byte, 4*n)
+		for i := range n {
+			v := uint16(int16(amp * 32767 * math.Sin(2*math.Pi*440*float64(i)/48000)))
+			binary.LittleEndian.PutUint16(pcm[4*i:], v)
+			binary.LittleEndian.PutUint16(pcm[4*i+2:], v)
+		}
+		return audio.AudioFrame{Data: pcm, SampleRate: 48000, Channels: 2}
+	}
+
+	ev, err := sess.ProcessAudioFrame(stereo(200, 0))
+	if err != nil || ev.Type != vad.VADSilence {
+		t.Fatalf("silence = %+v, %v; want silence", ev, err)
+	}
+	ev, err = sess.ProcessAudioFrame(stereo(200, 0.3))
+	if err != nil || ev.Type != vad.VADSpeechStart {
+		t.Fatalf("tone = %+v, %v; want speech start", ev, err)
+	}
+	if ev.Probability < 0.5 {
+		t.Errorf("probability = %v, want >= 0.5", ev.Probability)
+	}
+	if _, err := sess.ProcessAudioFrame(audio.AudioFrame{Data: make([]byte, 12), SampleRate: rate, Channels: 6}); err == nil {
+		t.Error("expected an error for 6 channels")
+	}
+}
+
+func TestSession_Errors(t *testing.T) {
+	t.Parallel()
+
+	sess := newSession(t)
+	if _, err := sess.ProcessFrame(silence(10)); err == nil || !strings.Contains(err.Error(), "want 640") {
+		t.Errorf("short frame error = %v", err)
+	}
+	if err := sess.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := sess.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if _, err := sess.ProcessFrame(silence(20)); err == nil {
+		t.Error("expected an error after Close")
+	}
+}
+
+func TestSession_Reset(t *testing.T) {
+	t.Parallel()
+
+	sess := newSession(t)
+	feed(t, sess, append(silence(100), tone(200, 0.3)...))
+	sess.Reset()
+
+	// After a reset the session is silent again and the first frame sets a
+	// fresh noise floor, so the ongoing tone is not speech.
+	types := feed(t, sess, tone(200, 0.3))
+	if n := count(types, vad.VADSilence); n != len(types) {
+		t.Errorf("after Reset: %v, want only silence", types)
+	}
+}
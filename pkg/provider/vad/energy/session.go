@@ -0,0 +1,202 @@
+package energy
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+)
+
+const (
+	// minFloorDB is the lowest noise floor, in dBFS. It keeps digital silence
+	// from making the detector react to the faintest hiss.
+	minFloorDB = -60
+
+	// The noise floor moves towards each frame's energy by these fractions of
+	// the difference: quickly down, slowly up, and barely during speech so
+	// that a long utterance is not absorbed into the floor.
+	floorFall       = 0.5
+	floorRise       = 0.05
+	floorRiseSpeech = 0.001
+
+	// minMarginDB and maxMarginDB are the margins above the noise floor that
+	// score 0.5 at sensitivity 1 and 0.
+	minMarginDB = 3
+	maxMarginDB = 27
+)
+
+// Compile-time assertion that Session implements vad.SessionHandle.
+var _ vad.SessionHandle = (*Session)(nil)
+
+// Session is an energy VAD session for one audio stream, returned by
+// [Engine.NewSession]. It implements [vad.SessionHandle]. A Session is not
+// safe for concurrent use.
+type Session struct {
+	rate       int // sample rate of the session's audio
+	frameBytes int // expected frame length; 0 accepts any length
+
+	speechThreshold  float64
+	silenceThreshold float64
+	marginDB         float64
+	onsetFrames      int
+	hangoverFrames   int
+
+	pending []int16 // samples of the next analysis frame
+	window  int     // samples per analysis frame
+
+	floor    float64 // noise floor in dBFS; NaN until the first frame
+	speaking bool
+	loud     int     // consecutive frames at or above the speech threshold
+	quiet    int     // consecutive frames below the silence threshold while speaking
+	prob     float64 // probability of the last analysis frame
+	closed   bool
+}
+
+// newSession creates a session for cfg with analysis frames of window
+// samples. cfg must already be validated and have its defaults applied.
+func newSession(e *Engine, cfg vad.Config, window int) *Session {
+	s := &Session{
+		rate:             cfg.SampleRate,
+		speechThreshold:  cfg.SpeechThreshold,
+		silenceThreshold: cfg.SilenceThreshold,
+		marginDB:         maxMarginDB - e.sensitivity*(maxMarginDB-minMarginDB),
+		onsetFrames:      e.onsetFrames,
+		hangoverFrames:   (e.hangoverMs + e.frameMs - 1) / e.frameMs,
+		window:           window,
+		pending:          make([]int16, 0, window),
+	}
+	if cfg.FrameSizeMs > 0 {
+		s.frameBytes = cfg.SampleRate * cfg.FrameSizeMs / 1000 * 2
+	}
+	s.Reset()
+	return s
+}
+
+// ProcessFrame implements [vad.SessionHandle]. frame must be 16-bit
+// little-endian mono PCM at the session's sample rate. The frame is added to
+// the pending audio and every complete analysis frame is scored. The result
+// reports the last speech start or end among those frames, or otherwise
+// whether speech is ongoing, together with the last frame's probability.
+func (s *Session) ProcessFrame(frame []byte) (vad.VADEvent, error) {
+	if s.frameBytes > 0 && len(frame) != s.frameBytes {
+		return vad.VADEvent{}, fmt.Errorf("energy: frame is %d bytes, want %d", len(frame), s.frameBytes)
+	}
+	return s.ProcessAudioFrame(audio.AudioFrame{Data: frame, SampleRate: s.rate, Channels: 1})
+}
+
+// ProcessAudioFrame is like ProcessFrame but takes the frame's format from
+// frame itself, so mono or stereo audio at any sample rate and of any
+// duration is accepted. Stereo is mixed down to mono and other sample rates
+// are resampled to the session's.
+func (s *Session) ProcessAudioFrame(frame audio.AudioFrame) (vad.VADEvent, error) {
+	if s.closed {
+		return vad.VADEvent{}, errors.New("energy: session is closed")
+	}
+	pcm := frame.Data
+	switch frame.Channels {
+	case 0, 1:
+	case 2:
+		pcm = audio.StereoToMono(pcm)
+	default:
+		return vad.VADEvent{}, fmt.Errorf("energy: unsupported channel count %d", frame.Channels)
+	}
+	if frame.SampleRate > 0 {
+		pcm = audio.ResampleMono16(pcm, frame.SampleRate, s.rate)
+	}
+
+	transition := vad.VADSilence
+	changed := false
+	for i := 0; i+1 < len(pcm); i += 2 {
+		s.pending = append(s.pending, int16(binary.LittleEndian.Uint16(pcm[i:])))
+		if len(s.pending) < s.window {
+			continue
+		}
+		if ev := s.score(); ev == vad.VADSpeechStart || ev == vad.VADSpeechEnd {
+			transition, changed = ev, true
+		}
+		s.pending = s.pending[:0]
+	}
+
+	switch {
+	case changed:
+		return vad.VADEvent{Type: transition, Probability: s.prob}, nil
+	case s.speaking:
+		return vad.VADEvent{Type: vad.VADSpeechContinue, Probability: s.prob}, nil
+	default:
+		return vad.VADEvent{Type: vad.VADSilence, Probability: s.prob}, nil
+	}
+}
+
+// score measures the pending analysis frame, advances the speech state and
+// adapts the noise floor.
+func (s *Session) score() vad.VADEventType {
+	db := levelDB(s.pending)
+	if math.IsNaN(s.floor) {
+		s.floor = max(db, minFloorDB)
+	}
+	s.prob = min(max((db-s.floor)/(2*s.marginDB), 0), 1)
+
+	ev := vad.VADSilence
+	switch {
+	case !s.speaking && s.prob >= s.speechThreshold:
+		s.loud++
+		if s.loud >= s.onsetFrames {
+			s.speaking, s.loud, s.quiet = true, 0, 0
+			ev = vad.VADSpeechStart
+		}
+	case !s.speaking:
+		s.loud = 0
+	case s.prob < s.silenceThreshold:
+		s.quiet++
+		ev = vad.VADSpeechContinue
+		if s.quiet >= s.hangoverFrames {
+			s.speaking, s.quiet = false, 0
+			ev = vad.VADSpeechEnd
+		}
+	default:
+		s.quiet = 0
+		ev = vad.VADSpeechContinue
+	}
+
+	switch {
+	case db < s.floor:
+		s.floor += (db - s.floor) * floorFall
+	case s.speaking:
+		s.floor += (db - s.floor) * floorRiseSpeech
+	default:
+		s.floor += (db - s.floor) * floorRise
+	}
+	s.floor = max(s.floor, minFloorDB)
+	return ev
+}
+
+// levelDB returns the RMS level of samples in dBFS. Digital silence yields
+// a level far below minFloorDB rather than -Inf.
+func levelDB(samples []int16) float64 {
+	var sum float64
+	for _, v := range samples {
+		f := float64(v) / 32768
+		sum += f * f
+	}
+	rms := math.Sqrt(sum / float64(len(samples)))
+	return 20 * math.Log10(rms+1e-9)
+}
+
+// Reset implements [vad.SessionHandle]. It also forgets the noise floor.
+func (s *Session) Reset() {
+	s.pending = s.pending[:0]
+	s.floor = math.NaN()
+	s.speaking = false
+	s.loud = 0
+	s.quiet = 0
+	s.prob = 0
+}
+
+// Close implements [vad.SessionHandle].
+func (s *Session) Close() error {
+	s.closed = true
+	return nil
+}
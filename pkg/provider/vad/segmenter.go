@@ -0,0 +1,201 @@
+package vad
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	// DefaultSpeechThreshold is the speech probability threshold used when
+	// none is configured.
+	DefaultSpeechThreshold = 0.5
+
+	// DefaultSilenceThreshold is the silence probability threshold used when
+	// none is configured.
+	DefaultSilenceThreshold = 0.35
+)
+
+// Thresholds overrides the speech and silence thresholds of a [Config] for a
+// single speaker. A zero field keeps the value of the base Config.
+type Thresholds struct {
+	// Speech overrides [Config.SpeechThreshold]. Lower values make the
+	// detector more sensitive, which suits soft-spoken players.
+	Speech float64
+
+	// Silence overrides [Config.SilenceThreshold].
+	Silence float64
+}
+
+// Apply returns base with the non-zero thresholds of t applied.
+func (t Thresholds) Apply(base Config) Config {
+	if t.Speech > 0 {
+		base.SpeechThreshold = t.Speech
+	}
+	if t.Silence > 0 {
+		base.SilenceThreshold = t.Silence
+	}
+	return base
+}
+
+// SegmenterOption is a functional option for configuring a [Segmenter].
+type SegmenterOption func(*Segmenter)
+
+// WithSpeakerThresholds overrides the thresholds used for speakerID's frames.
+func WithSpeakerThresholds(speakerID string, t Thresholds) SegmenterOption {
+	return func(s *Segmenter) {
+		s.overrides[speakerID] = t
+	}
+}
+
+// WithSpeakerResolver sets resolve to map the speaker IDs passed to
+// [Segmenter.ProcessFrame], such as the stream IDs of an audio platform, to
+// the IDs the threshold overrides are keyed by, such as user IDs. An override
+// for the resolved ID takes precedence over one for the speaker ID itself.
+// resolve returns "" for speakers it does not know.
+func WithSpeakerResolver(resolve func(speakerID string) string) SegmenterOption {
+	return func(s *Segmenter) {
+		s.resolve = resolve
+	}
+}
+
+// Segmenter runs voice activity detection for every speaker of a voice
+// channel. It lazily opens one [SessionHandle] per speaker, configured with the
+// base [Config] plus that speaker's [Thresholds] override, if any.
+//
+// Segmenter is safe for concurrent use. Frames of a single speaker must still
+// be submitted from one goroutine at a time.
+type Segmenter struct {
+	engine  Engine
+	base    Config
+	resolve func(speakerID string) string
+
+	mu        sync.Mutex
+	overrides map[string]Thresholds
+	sessions  map[string]SessionHandle
+	closed    bool
+}
+
+// NewSegmenter creates a [Segmenter] that opens sessions on engine using base
+// as the configuration for speakers without an override.
+func NewSegmenter(engine Engine, base Config, opts ...SegmenterOption) *Segmenter {
+	s := &Segmenter{
+		engine:    engine,
+		base:      base,
+		overrides: make(map[string]Thresholds),
+		sessions:  make(map[string]SessionHandle),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// ConfigFor returns the effective session configuration for speakerID.
+func (s *Segmenter) ConfigFor(speakerID string) Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.configFor(speakerID)
+}
+
+// configFor is ConfigFor without locking. The caller must hold s.mu.
+func (s *Segmenter) configFor(speakerID string) Config {
+	if id := s.resolveID(speakerID); id != "" {
+		if t, ok := s.overrides[id]; ok {
+			return t.Apply(s.base)
+		}
+	}
+	t, ok := s.overrides[speakerID]
+	if !ok {
+		return s.base
+	}
+	return t.Apply(s.base)
+}
+
+// resolveID returns the ID speakerID resolves to, or "" without a resolver.
+func (s *Segmenter) resolveID(speakerID string) string {
+	if s.resolve == nil {
+		return ""
+	}
+	return s.resolve(speakerID)
+}
+
+// SetThresholds replaces speakerID's threshold override. The open sessions of
+// the speaker, including those of speaker IDs that resolve to speakerID, are
+// closed so the next frame is analysed with the new thresholds.
+func (s *Segmenter) SetThresholds(speakerID string, t Thresholds) error {
+	s.mu.Lock()
+	s.overrides[speakerID] = t
+	stale := make(map[string]SessionHandle)
+	for id, sess := range s.sessions {
+		if id == speakerID || s.resolveID(id) == speakerID {
+			stale[id] = sess
+			delete(s.sessions, id)
+		}
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for id, sess := range stale {
+		if err := sess.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("vad: close session of speaker %q: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ProcessFrame analyses one frame of speakerID's audio, opening the speaker's
+// session on first use.
+func (s *Segmenter) ProcessFrame(speakerID string, frame []byte) (VADEvent, error) {
+	sess, err := s.session(speakerID)
+	if err != nil {
+		return VADEvent{}, err
+	}
+	return sess.ProcessFrame(frame)
+}
+
+// session returns speakerID's session, creating it if necessary.
+func (s *Segmenter) session(speakerID string) (SessionHandle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errors.New("vad: segmenter is closed")
+	}
+	if sess, ok := s.sessions[speakerID]; ok {
+		return sess, nil
+	}
+	sess, err := s.engine.NewSession(s.configFor(speakerID))
+	if err != nil {
+		return nil, fmt.Errorf("vad: new session for speaker %q: %w", speakerID, err)
+	}
+	s.sessions[speakerID] = sess
+	return sess, nil
+}
+
+// Reset clears the detection state of speakerID's session, if any.
+func (s *Segmenter) Reset(speakerID string) {
+	s.mu.Lock()
+	sess := s.sessions[speakerID]
+	s.mu.Unlock()
+	if sess != nil {
+		sess.Reset()
+	}
+}
+
+// Close closes all open sessions. Subsequent calls to ProcessFrame return an
+// error; calling Close more than once is safe.
+func (s *Segmenter) Close() error {
+	s.mu.Lock()
+	sessions := s.sessions
+	s.sessions = make(map[string]SessionHandle)
+	s.closed = true
+	s.mu.Unlock()
+
+	var errs []error
+	for id, sess := range sessions {
+		if err := sess.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("vad: close session of speaker %q: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,178 @@
+package vad_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad/mock"
+)
+
+var baseConfig = vad.Config{
+	SampleRate:       16000,
+	FrameSizeMs:      20,
+	SpeechThreshold:  vad.DefaultSpeechThreshold,
+	SilenceThreshold: vad.DefaultSilenceThreshold,
+}
+
+func TestSegmenter_ConfigFor(t *testing.T) {
+	t.Parallel()
+
+	seg := vad.NewSegmenter(&mock.Engine{}, baseConfig,
+		vad.WithSpeakerThresholds("soft", vad.Thresholds{Speech: 0.3, Silence: 0.2}),
+		vad.WithSpeakerThresholds("loud", vad.Thresholds{Speech: 0.8}),
+	)
+
+	tests := []struct {
+		speaker     string
+		wantSpeech  float64
+		wantSilence float64
+	}{
+		{speaker: "soft", wantSpeech: 0.3, wantSilence: 0.2},
+		{speaker: "loud", wantSpeech: 0.8, wantSilence: vad.DefaultSilenceThreshold},
+		{speaker: "other", wantSpeech: vad.DefaultSpeechThreshold, wantSilence: vad.DefaultSilenceThreshold},
+	}
+	for _, tc := range tests {
+		t.Run(tc.speaker, func(t *testing.T) {
+			t.Parallel()
+			cfg := seg.ConfigFor(tc.speaker)
+			if cfg.SpeechThreshold != tc.wantSpeech || cfg.SilenceThreshold != tc.wantSilence {
+				t.Errorf("ConfigFor(%q) thresholds = (%v, %v), want (%v, %v)",
+					tc.speaker, cfg.SpeechThreshold, cfg.SilenceThreshold, tc.wantSpeech, tc.wantSilence)
+			}
+			if cfg.SampleRate != baseConfig.SampleRate || cfg.FrameSizeMs != baseConfig.FrameSizeMs {
+				t.Errorf("ConfigFor(%q) changed the frame format: %+v", tc.speaker, cfg)
+			}
+		})
+	}
+}
+
+func TestSegmenter_ProcessFrameUsesSpeakerThresholds(t *testing.T) {
+	t.Parallel()
+
+	eng := &mock.Engine{}
+	seg := vad.NewSegmenter(eng, baseConfig,
+		vad.WithSpeakerThresholds("soft", vad.Thresholds{Speech: 0.3}),
+	)
+
+	frame := make([]byte, 640)
+	for _, speaker := range []string{"soft", "other", "soft", "other"} {
+		if _, err := seg.ProcessFrame(speaker, frame); err != nil {
+			t.Fatalf("ProcessFrame(%q) error: %v", speaker, err)
+		}
+	}
+
+	// One session per speaker, opened on the first frame.
+	if got := len(eng.NewSessionCalls); got != 2 {
+		t.Fatalf("NewSession calls = %d, want 2", got)
+	}
+	if got := eng.NewSessionCalls[0].Cfg.SpeechThreshold; got != 0.3 {
+		t.Errorf("soft speaker threshold = %v, want 0.3", got)
+	}
+	if got := eng.NewSessionCalls[1].Cfg.SpeechThreshold; got != vad.DefaultSpeechThreshold {
+		t.Errorf("other speaker threshold = %v, want %v", got, vad.DefaultSpeechThreshold)
+	}
+}
+
+func TestSegmenter_SetThresholds(t *testing.T) {
+	t.Parallel()
+
+	sess := &mock.Session{}
+	eng := &mock.Engine{Session: sess}
+	seg := vad.NewSegmenter(eng, baseConfig)
+
+	frame := make([]byte, 640)
+	if _, err := seg.ProcessFrame("player-1", frame); err != nil {
+		t.Fatalf("ProcessFrame error: %v", err)
+	}
+	if err := seg.SetThresholds("player-1", vad.Thresholds{Speech: 0.7}); err != nil {
+		t.Fatalf("SetThresholds error: %v", err)
+	}
+	if sess.CloseCallCount != 1 {
+		t.Errorf("Close calls after SetThresholds = %d, want 1", sess.CloseCallCount)
+	}
+	if _, err := seg.ProcessFrame("player-1", frame); err != nil {
+		t.Fatalf("ProcessFrame error: %v", err)
+	}
+
+	if got := len(eng.NewSessionCalls); got != 2 {
+		t.Fatalf("NewSession calls = %d, want 2", got)
+	}
+	if got := eng.NewSessionCalls[1].Cfg.SpeechThreshold; got != 0.7 {
+		t.Errorf("threshold after SetThresholds = %v, want 0.7", got)
+	}
+}
+
+func TestSegmenter_SpeakerResolver(t *testing.T) {
+	t.Parallel()
+
+	// Streams are keyed by SSRC; overrides by user ID.
+	users := map[string]string{"1111": "user-soft", "2222": "user-other"}
+	sess := &mock.Session{}
+	eng := &mock.Engine{Session: sess}
+	seg := vad.NewSegmenter(eng, baseConfig,
+		vad.WithSpeakerResolver(func(id string) string { return users[id] }),
+		vad.WithSpeakerThresholds("user-soft", vad.Thresholds{Speech: 0.3}),
+		vad.WithSpeakerThresholds("3333", vad.Thresholds{Speech: 0.6}),
+	)
+
+	tests := []struct {
+		stream     string
+		wantSpeech float64
+	}{
+		{stream: "1111", wantSpeech: 0.3},
+		{stream: "2222", wantSpeech: vad.DefaultSpeechThreshold},
+		{stream: "3333", wantSpeech: 0.6}, // unresolved, keyed directly
+	}
+	for _, tc := range tests {
+		if got := seg.ConfigFor(tc.stream).SpeechThreshold; got != tc.wantSpeech {
+			t.Errorf("ConfigFor(%q) speech threshold = %v, want %v", tc.stream, got, tc.wantSpeech)
+		}
+	}
+
+	// Changing a user's thresholds reopens the session of their stream.
+	frame := make([]byte, 640)
+	if _, err := seg.ProcessFrame("2222", frame); err != nil {
+		t.Fatalf("ProcessFrame error: %v", err)
+	}
+	if err := seg.SetThresholds("user-other", vad.Thresholds{Speech: 0.7}); err != nil {
+		t.Fatalf("SetThresholds error: %v", err)
+	}
+	if sess.CloseCallCount != 1 {
+		t.Errorf("Close calls after SetThresholds = %d, want 1", sess.CloseCallCount)
+	}
+	if _, err := seg.ProcessFrame("2222", frame); err != nil {
+		t.Fatalf("ProcessFrame error: %v", err)
+	}
+	if got := eng.NewSessionCalls[len(eng.NewSessionCalls)-1].Cfg.SpeechThreshold; got != 0.7 {
+		t.Errorf("threshold after SetThresholds = %v, want 0.7", got)
+	}
+}
+
+func TestSegmenter_Errors(t *testing.T) {
+	t.Parallel()
+
+	engErr := errors.New("model not loaded")
+	seg := vad.NewSegmenter(&mock.Engine{NewSessionErr: engErr}, baseConfig)
+	if _, err := seg.ProcessFrame("player-1", nil); !errors.Is(err, engErr) {
+		t.Errorf("ProcessFrame error = %v, want %v", err, engErr)
+	}
+
+	sess := &mock.Session{}
+	seg = vad.NewSegmenter(&mock.Engine{Session: sess}, baseConfig)
+	if _, err := seg.ProcessFrame("player-1", nil); err != nil {
+		t.Fatalf("ProcessFrame error: %v", err)
+	}
+	if err := seg.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if sess.CloseCallCount != 1 {
+		t.Errorf("session Close calls = %d, want 1", sess.CloseCallCount)
+	}
+	if _, err := seg.ProcessFrame("player-1", nil); err == nil {
+		t.Error("ProcessFrame after Close should return an error")
+	}
+	if err := seg.Close(); err != nil {
+		t.Errorf("second Close error: %v", err)
+	}
+}
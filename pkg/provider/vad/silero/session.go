@@ -0,0 +1,185 @@
+package silero
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+)
+
+// windowSizes maps the supported model sample rates to the number of new
+// samples the model scores per inference.
+var windowSizes = map[int]int{8000: 256, 16000: 512}
+
+// contextSizes maps the supported model sample rates to the number of
+// samples of the previous window that are prepended to each window.
+var contextSizes = map[int]int{8000: 32, 16000: 64}
+
+// model computes the speech probability of one window of audio. input holds
+// the context samples followed by the window, normalised to [-1, 1]. state
+// is the stream's recurrent state of stateSize values; predict replaces it
+// with the state after input.
+type model interface {
+	predict(input, state []float32) (float32, error)
+}
+
+// Compile-time assertion that Session implements vad.SessionHandle.
+var _ vad.SessionHandle = (*Session)(nil)
+
+// Session is a Silero VAD session for one audio stream, returned by
+// [Engine.NewSession]. It implements [vad.SessionHandle]. A Session is not
+// safe for concurrent use.
+type Session struct {
+	model      model
+	inputRate  int // sample rate of frames passed to ProcessFrame
+	modelRate  int // sample rate the model runs at
+	frameBytes int // expected frame length; 0 accepts any length
+
+	speechThreshold  float32
+	silenceThreshold float32
+	minSilence       int // samples at modelRate
+
+	input []float32 // context followed by the samples of the next window
+	ctx   int       // number of context samples
+	win   int       // number of samples per window
+	state []float32
+
+	speaking bool
+	silence  int     // samples since the probability fell below silenceThreshold; -1 if it has not
+	prob     float32 // probability of the last scored window
+	closed   bool
+}
+
+// newSession creates a session scoring frames of cfg with m at modelRate.
+// cfg must already be validated and have its defaults applied.
+func newSession(m model, cfg vad.Config, modelRate int, minSilence time.Duration) *Session {
+	s := &Session{
+		model:            m,
+		inputRate:        cfg.SampleRate,
+		modelRate:        modelRate,
+		speechThreshold:  float32(cfg.SpeechThreshold),
+		silenceThreshold: float32(cfg.SilenceThreshold),
+		minSilence:       int(minSilence * time.Duration(modelRate) / time.Second),
+		ctx:              contextSizes[modelRate],
+		win:              windowSizes[modelRate],
+		state:            make([]float32, stateSize),
+	}
+	if cfg.FrameSizeMs > 0 {
+		s.frameBytes = cfg.SampleRate * cfg.FrameSizeMs / 1000 * 2
+	}
+	s.Reset()
+	return s
+}
+
+// ProcessFrame implements [vad.SessionHandle]. frame must be 16-bit
+// little-endian mono PCM at the session's sample rate. The frame is added to
+// the pending audio and every complete window is scored. The result reports
+// the last speech start or end among those windows, or otherwise whether
+// speech is ongoing, together with the last window's probability.
+func (s *Session) ProcessFrame(frame []byte) (vad.VADEvent, error) {
+	if s.frameBytes > 0 && len(frame) != s.frameBytes {
+		return vad.VADEvent{}, fmt.Errorf("silero: frame is %d bytes, want %d", len(frame), s.frameBytes)
+	}
+	return s.ProcessAudioFrame(audio.AudioFrame{Data: frame, SampleRate: s.inputRate, Channels: 1})
+}
+
+// ProcessAudioFrame is like ProcessFrame but takes the frame's format from
+// frame itself, so mono or stereo audio at any sample rate and of any
+// duration is accepted. Stereo is mixed down to mono.
+func (s *Session) ProcessAudioFrame(frame audio.AudioFrame) (vad.VADEvent, error) {
+	if s.closed {
+		return vad.VADEvent{}, errors.New("silero: session is closed")
+	}
+	pcm := frame.Data
+	switch frame.Channels {
+	case 0, 1:
+	case 2:
+		pcm = audio.StereoToMono(pcm)
+	default:
+		return vad.VADEvent{}, fmt.Errorf("silero: unsupported channel count %d", frame.Channels)
+	}
+	pcm = audio.ResampleMono16(pcm, frame.SampleRate, s.modelRate)
+
+	transition := vad.VADSilence
+	changed := false
+	for i := 0; i+1 < len(pcm); i += 2 {
+		sample := int16(binary.LittleEndian.Uint16(pcm[i:]))
+		s.input = append(s.input, float32(sample)/32768)
+		if len(s.input) < s.ctx+s.win {
+			continue
+		}
+		ev, err := s.score()
+		if err != nil {
+			return vad.VADEvent{}, err
+		}
+		if ev != vad.VADSpeechContinue && ev != vad.VADSilence {
+			transition, changed = ev, true
+		}
+	}
+
+	switch {
+	case changed:
+		return vad.VADEvent{Type: transition, Probability: float64(s.prob)}, nil
+	case s.speaking:
+		return vad.VADEvent{Type: vad.VADSpeechContinue, Probability: float64(s.prob)}, nil
+	default:
+		return vad.VADEvent{Type: vad.VADSilence, Probability: float64(s.prob)}, nil
+	}
+}
+
+// score runs the model on the pending window, keeps its last samples as the
+// context of the next one, and advances the speech state.
+//
+// Speech starts when the probability reaches the speech threshold. It ends
+// once the probability has stayed below the silence threshold for the
+// minimum silence duration; reaching the speech threshold again in between
+// cancels the countdown.
+func (s *Session) score() (vad.VADEventType, error) {
+	prob, err := s.model.predict(s.input, s.state)
+	if err != nil {
+		return 0, err
+	}
+	s.prob = prob
+	s.input = append(s.input[:0], s.input[len(s.input)-s.ctx:]...)
+
+	switch {
+	case prob >= s.speechThreshold:
+		s.silence = -1
+		if !s.speaking {
+			s.speaking = true
+			return vad.VADSpeechStart, nil
+		}
+	case s.speaking && prob < s.silenceThreshold && s.silence < 0:
+		s.silence = s.win
+	case s.speaking && s.silence >= 0:
+		s.silence += s.win
+	}
+	if s.speaking && s.silence >= s.minSilence {
+		s.speaking = false
+		s.silence = -1
+		return vad.VADSpeechEnd, nil
+	}
+	if s.speaking {
+		return vad.VADSpeechContinue, nil
+	}
+	return vad.VADSilence, nil
+}
+
+// Reset implements [vad.SessionHandle].
+func (s *Session) Reset() {
+	s.input = make([]float32, s.ctx, s.ctx+s.win)
+	clear(s.state)
+	s.speaking = false
+	s.silence = -1
+	s.prob = 0
+}
+
+// Close implements [vad.SessionHandle]. The shared model stays loaded until
+// the engine is closed.
+func (s *Session) Close() error {
+	s.closed = true
+	return nil
+}
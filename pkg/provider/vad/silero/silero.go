@@ -0,0 +1,291 @@
+// Package silero provides a local voice activity detection engine backed by
+// the Silero VAD v5 ONNX model.
+//
+// The model runs in-process through ONNX Runtime, so no audio leaves the
+// machine. It needs the ONNX Runtime shared library at run time (looked up as
+// onnxruntime.so, or onnxruntime.dll on Windows, unless [WithLibraryPath] is
+// given) and the model file, silero_vad.onnx, from
+// https://github.com/snakers4/silero-vad.
+//
+// Silero scores windows of 512 samples at 16 kHz (256 at 8 kHz). Sessions
+// buffer the frames passed to ProcessFrame into such windows, so frames of
+// any size can be used. Frames at other sample rates are resampled to the
+// model's rate.
+//
+// Usage:
+//
+//	eng, err := silero.New("models/silero_vad.onnx",
+//	    silero.WithThreshold(0.5),
+//	    silero.WithMinSilence(300*time.Millisecond),
+//	)
+//	defer eng.Close()
+//	sess, err := eng.NewSession(vad.Config{SampleRate: 16000, FrameSizeMs: 20})
+//	ev, err := sess.ProcessFrame(pcm)
+//	if ev.Type == vad.VADSpeechStart { ... }
+package silero
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	ort "github.com/yalue/onnxruntime_go"
+
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+)
+
+const (
+	// defaultThreshold is the speech probability threshold used when neither
+	// WithThreshold nor the session Config sets one.
+	defaultThreshold = 0.5
+
+	// silenceOffset is subtracted from the speech threshold to derive the
+	// silence threshold when the session Config does not set one, as in the
+	// reference implementation.
+	silenceOffset = 0.15
+
+	defaultMinSilence = 100 * time.Millisecond
+	defaultSampleRate = 16000
+)
+
+// Compile-time assertion that Engine implements vad.Engine.
+var _ vad.Engine = (*Engine)(nil)
+
+// Option is a functional option for configuring an Engine.
+type Option func(*Engine)
+
+// WithThreshold sets the speech probability threshold, in [0, 1], used by
+// sessions whose [vad.Config] has no SpeechThreshold. Defaults to 0.5.
+func WithThreshold(threshold float32) Option {
+	return func(e *Engine) {
+		e.threshold = threshold
+	}
+}
+
+// WithMinSilence sets how long the speech probability must stay below the
+// silence threshold before a speech segment ends. Shorter values end turns
+// sooner but may split utterances at pauses. Defaults to 100 ms.
+func WithMinSilence(d time.Duration) Option {
+	return func(e *Engine) {
+		e.minSilence = d
+	}
+}
+
+// WithSampleRate sets the sample rate the model runs at: 8000 or 16000 Hz.
+// Frames at a different rate are resampled to it. Defaults to 16000.
+func WithSampleRate(rate int) Option {
+	return func(e *Engine) {
+		e.sampleRate = rate
+	}
+}
+
+// WithLibraryPath sets the path of the ONNX Runtime shared library. When
+// empty the platform's default library name is looked up by the dynamic
+// loader. ONNX Runtime is initialised once per process, so only the path
+// given to the first successful [New] takes effect.
+func WithLibraryPath(path string) Option {
+	return func(e *Engine) {
+		e.libraryPath = path
+	}
+}
+
+// Engine implements [vad.Engine] with the Silero VAD model. The model is
+// loaded once and shared by all sessions; each session keeps its own
+// recurrent state. Engine is safe for concurrent use.
+type Engine struct {
+	threshold   float32
+	minSilence  time.Duration
+	sampleRate  int
+	libraryPath string
+
+	model     model
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// New loads the Silero VAD ONNX model from modelPath and returns an Engine.
+// It initialises ONNX Runtime on first use. The caller must call Close when
+// the engine is no longer needed.
+func New(modelPath string, opts ...Option) (*Engine, error) {
+	if modelPath == "" {
+		return nil, errors.New("silero: modelPath must not be empty")
+	}
+	e, err := newEngine(opts...)
+	if err != nil {
+		return nil, err
+	}
+	m, err := loadONNXModel(modelPath, e.libraryPath, e.sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	e.model = m
+	return e, nil
+}
+
+// newEngine applies opts to the defaults and validates the result.
+func newEngine(opts ...Option) (*Engine, error) {
+	e := &Engine{
+		threshold:  defaultThreshold,
+		minSilence: defaultMinSilence,
+		sampleRate: defaultSampleRate,
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	if e.threshold <= 0 || e.threshold > 1 {
+		return nil, fmt.Errorf("silero: threshold must be in (0, 1], got %v", e.threshold)
+	}
+	if e.minSilence < 0 {
+		return nil, fmt.Errorf("silero: min silence must not be negative, got %v", e.minSilence)
+	}
+	if _, ok := windowSizes[e.sampleRate]; !ok {
+		return nil, fmt.Errorf("silero: sample rate must be 8000 or 16000 Hz, got %d", e.sampleRate)
+	}
+	return e, nil
+}
+
+// NewSession implements [vad.Engine]. Zero fields of cfg fall back to the
+// engine's settings: SampleRate to the model's rate, SpeechThreshold to the
+// WithThreshold value, and SilenceThreshold to the speech threshold minus
+// 0.15. A non-zero FrameSizeMs makes ProcessFrame reject frames of any other
+// duration.
+func (e *Engine) NewSession(cfg vad.Config) (vad.SessionHandle, error) {
+	if cfg.SampleRate == 0 {
+		cfg.SampleRate = e.sampleRate
+	}
+	if cfg.SpeechThreshold == 0 {
+		cfg.SpeechThreshold = float64(e.threshold)
+	}
+	if cfg.SilenceThreshold == 0 {
+		cfg.SilenceThreshold = max(cfg.SpeechThreshold-silenceOffset, 0.01)
+	}
+	switch {
+	case cfg.SampleRate < 0:
+		return nil, fmt.Errorf("silero: sample rate must be positive, got %d", cfg.SampleRate)
+	case cfg.FrameSizeMs < 0:
+		return nil, fmt.Errorf("silero: frame size must not be negative, got %d ms", cfg.FrameSizeMs)
+	case cfg.SpeechThreshold < 0 || cfg.SpeechThreshold > 1:
+		return nil, fmt.Errorf("silero: speech threshold must be in [0, 1], got %v", cfg.SpeechThreshold)
+	case cfg.SilenceThreshold < 0 || cfg.SilenceThreshold > cfg.SpeechThreshold:
+		return nil, fmt.Errorf("silero: silence threshold must be in [0, %v], got %v", cfg.SpeechThreshold, cfg.SilenceThreshold)
+	}
+	return newSession(e.model, cfg, e.sampleRate, e.minSilence), nil
+}
+
+// Close releases the model. Sessions must not be used afterwards. Calling
+// Close more than once is safe.
+func (e *Engine) Close() error {
+	e.closeOnce.Do(func() {
+		if c, ok := e.model.(interface{ close() error }); ok {
+			e.closeErr = c.close()
+		}
+	})
+	return e.closeErr
+}
+
+// ── ONNX model ───────────────────────────────────────────────────────────────
+
+// ortInit initialises the process-wide ONNX Runtime environment once.
+var ortInit struct {
+	once sync.Once
+	err  error
+}
+
+// initRuntime initialises ONNX Runtime with the shared library at libPath,
+// or the default library if it is empty. Only the first call has an effect.
+func initRuntime(libPath string) error {
+	ortInit.once.Do(func() {
+		if ort.IsInitialized() {
+			return
+		}
+		if libPath != "" {
+			ort.SetSharedLibraryPath(libPath)
+		}
+		if err := ort.InitializeEnvironment(); err != nil {
+			ortInit.err = fmt.Errorf("silero: initialise ONNX Runtime: %w", err)
+		}
+	})
+	return ortInit.err
+}
+
+// stateSize is the number of values in the model's recurrent state tensor
+// of shape [2, 1, 128].
+const stateSize = 2 * 1 * 128
+
+// onnxModel runs the Silero VAD v5 model through ONNX Runtime. Its inputs
+// are "input" [1, n], "state" [2, 1, 128], and the scalar "sr"; its outputs
+// are the speech probability "output" [1, 1] and the next state "stateN".
+type onnxModel struct {
+	session    *ort.DynamicAdvancedSession
+	sampleRate int64
+}
+
+// loadONNXModel loads the model at path for audio at sampleRate.
+func loadONNXModel(path, libPath string, sampleRate int) (*onnxModel, error) {
+	if err := initRuntime(libPath); err != nil {
+		return nil, err
+	}
+	opts, err := ort.NewSessionOptions()
+	if err != nil {
+		return nil, fmt.Errorf("silero: create session options: %w", err)
+	}
+	defer opts.Destroy()
+	// The model is tiny; one thread per inference avoids contention between
+	// concurrent sessions.
+	if err := opts.SetIntraOpNumThreads(1); err != nil {
+		return nil, fmt.Errorf("silero: set intra-op threads: %w", err)
+	}
+	if err := opts.SetInterOpNumThreads(1); err != nil {
+		return nil, fmt.Errorf("silero: set inter-op threads: %w", err)
+	}
+	s, err := ort.NewDynamicAdvancedSession(path,
+		[]string{"input", "state", "sr"},
+		[]string{"output", "stateN"},
+		opts,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("silero: load model %q: %w", path, err)
+	}
+	return &onnxModel{session: s, sampleRate: int64(sampleRate)}, nil
+}
+
+// predict implements model.
+func (m *onnxModel) predict(input, state []float32) (float32, error) {
+	in, err := ort.NewTensor(ort.NewShape(1, int64(len(input))), input)
+	if err != nil {
+		return 0, fmt.Errorf("silero: create input tensor: %w", err)
+	}
+	defer in.Destroy()
+	st, err := ort.NewTensor(ort.NewShape(2, 1, 128), state)
+	if err != nil {
+		return 0, fmt.Errorf("silero: create state tensor: %w", err)
+	}
+	defer st.Destroy()
+	sr, err := ort.NewScalar(m.sampleRate)
+	if err != nil {
+		return 0, fmt.Errorf("silero: create sample rate tensor: %w", err)
+	}
+	defer sr.Destroy()
+	out, err := ort.NewEmptyTensor[float32](ort.NewShape(1, 1))
+	if err != nil {
+		return 0, fmt.Errorf("silero: create output tensor: %w", err)
+	}
+	defer out.Destroy()
+	next, err := ort.NewEmptyTensor[float32](ort.NewShape(2, 1, 128))
+	if err != nil {
+		return 0, fmt.Errorf("silero: create state output tensor: %w", err)
+	}
+	defer next.Destroy()
+
+	if err := m.session.Run([]ort.Value{in, st, sr}, []ort.Value{out, next}); err != nil {
+		return 0, fmt.Errorf("silero: run model: %w", err)
+	}
+	copy(state, next.GetData())
+	return out.GetData()[0], nil
+}
+
+// close releases the ONNX Runtime session.
+func (m *onnxModel) close() error {
+	return m.session.Destroy()
+}
@@ -0,0 +1,337 @@
+package silero
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrWong99/glyphoxa/pkg/audio"
+	"github.com/MrWong99/glyphoxa/pkg/provider/vad"
+)
+
+// fakeModel returns scripted probabilities, repeating the last one, and
+// records the inputs it was given.
+type fakeModel struct {
+	probs  []float32
+	err    error
+	inputs [][]float32
+	states [][]float32
+}
+
+func (m *fakeModel) predict(input, state []float32) (float32, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	m.inputs = append(m.inputs, slices.Clone(input))
+	m.states = append(m.states, slices.Clone(state))
+	state[0]++
+	return m.probs[min(len(m.inputs), len(m.probs))-1], nil
+}
+
+// newTestEngine returns an engine backed by m.
+func newTestEngine(t *testing.T, m model, opts ...Option) *Engine {
+	t.Helper()
+	e, err := newEngine(opts...)
+	if err != nil {
+		t.Fatalf("newEngine: %v", err)
+	}
+	e.model = m
+	return e
+}
+
+// newTestSession opens a session on an engine backed by m.
+func newTestSession(t *testing.T, m model, cfg vad.Config, opts ...Option) *Session {
+	t.Helper()
+	sess, err := newTestEngine(t, m, opts...).NewSession(cfg)
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	return sess.(*Session)
+}
+
+// pcm returns n samples of 16-bit mono PCM whose i-th sample is f(i).
+func pcm(n int, f func(i int) int16) []byte {
+	b := make([]byte, 2*n)
+	for i := range n {
+		binary.LittleEndian.PutUint16(b[2*i:], uint16(f(i)))
+	}
+	return b
+}
+
+func TestNew_EmptyModelPath(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(""); err == nil {
+		t.Error("New(\"\") returned nil error")
+	}
+}
+
+func TestNewEngine_Options(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{name: "defaults"},
+		{name: "valid", opts: []Option{WithThreshold(0.7), WithMinSilence(time.Second), WithSampleRate(8000), WithLibraryPath("/opt/onnxruntime.so")}},
+		{name: "zero threshold", opts: []Option{WithThreshold(0)}, wantErr: "threshold"},
+		{name: "threshold above one", opts: []Option{WithThreshold(1.5)}, wantErr: "threshold"},
+		{name: "negative min silence", opts: []Option{WithMinSilence(-time.Millisecond)}, wantErr: "min silence"},
+		{name: "unsupported sample rate", opts: []Option{WithSampleRate(48000)}, wantErr: "sample rate"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newEngine(tc.opts...)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("newEngine() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("newEngine() error = %v, want it to mention %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestEngine_NewSession(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		cfg         vad.Config
+		wantErr     bool
+		wantSpeech  float32
+		wantSilence float32
+		wantRate    int
+	}{
+		{name: "defaults", wantSpeech: 0.5, wantSilence: 0.35, wantRate: 16000},
+		{name: "engine threshold", opts: []Option{WithThreshold(0.8)}, wantSpeech: 0.8, wantSilence: 0.65, wantRate: 16000},
+		{name: "low threshold keeps silence positive", opts: []Option{WithThreshold(0.1)}, wantSpeech: 0.1, wantSilence: 0.01, wantRate: 16000},
+		{
+			name:       "config overrides engine",
+			opts:       []Option{WithThreshold(0.8)},
+			cfg:        vad.Config{SampleRate: 48000, SpeechThreshold: 0.6, SilenceThreshold: 0.2},
+			wantSpeech: 0.6, wantSilence: 0.2, wantRate: 48000,
+		},
+		{name: "silence above speech", cfg: vad.Config{SpeechThreshold: 0.4, SilenceThreshold: 0.5}, wantErr: true},
+		{name: "speech above one", cfg: vad.Config{SpeechThreshold: 1.2}, wantErr: true},
+		{name: "negative sample rate", cfg: vad.Config{SampleRate: -1}, wantErr: true},
+		{name: "negative frame size", cfg: vad.Config{FrameSizeMs: -20}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			sess, err := newTestEngine(t, &fakeModel{}, tc.opts...).NewSession(tc.cfg)
+			if tc.wantErr {
+				if err == nil {
+					t.Error("NewSession() returned nil error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewSession() error = %v", err)
+			}
+			s := sess.(*Session)
+			near := func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-6 }
+			if !near(s.speechThreshold, tc.wantSpeech) || !near(s.silenceThreshold, tc.wantSilence) || s.inputRate != tc.wantRate {
+				t.Errorf("session thresholds = %v/%v at %d Hz, want %v/%v at %d Hz",
+					s.speechThreshold, s.silenceThreshold, s.inputRate, tc.wantSpeech, tc.wantSilence, tc.wantRate)
+			}
+		})
+	}
+}
+
+func TestSession_Events(t *testing.T) {
+	t.Parallel()
+
+	const (
+		S = vad.VADSpeechStart
+		C = vad.VADSpeechContinue
+		E = vad.VADSpeechEnd
+		Q = vad.VADSilence
+	)
+	// Each frame is one 512-sample window; 96 ms of silence are 3 windows.
+	tests := []struct {
+		name  string
+		probs []float32
+		want  []vad.VADEventType
+	}{
+		{
+			name:  "speech then silence",
+			probs: []float32{0.1, 0.9, 0.8, 0.2, 0.4, 0.1, 0.1},
+			want:  []vad.VADEventType{Q, S, C, C, C, E, Q},
+		},
+		{
+			name:  "speech resumes before min silence",
+			probs: []float32{0.9, 0.1, 0.1, 0.6, 0.1, 0.1, 0.1},
+			want:  []vad.VADEventType{S, C, C, C, C, C, E},
+		},
+		{
+			name:  "probability between thresholds does not start the countdown",
+			probs: []float32{0.9, 0.4, 0.4, 0.4, 0.4},
+			want:  []vad.VADEventType{S, C, C, C, C},
+		},
+		{
+			name:  "no speech",
+			probs: []float32{0.2, 0.45, 0.3},
+			want:  []vad.VADEventType{Q, Q, Q},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := &fakeModel{probs: tc.probs}
+			s := newTestSession(t, m, vad.Config{SampleRate: 16000}, WithMinSilence(96*time.Millisecond))
+			frame := pcm(512, func(int) int16 { return 0 })
+			for i, want := range tc.want {
+				ev, err := s.ProcessFrame(frame)
+				if err != nil {
+					t.Fatalf("frame %d: ProcessFrame() error = %v", i, err)
+				}
+				if ev.Type != want || ev.Probability != float64(tc.probs[i]) {
+					t.Errorf("frame %d: event = %v (p=%v), want %v (p=%v)", i, ev.Type, ev.Probability, want, tc.probs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSession_Windows(t *testing.T) {
+	t.Parallel()
+
+	m := &fakeModel{probs: []float32{0.9}}
+	s := newTestSession(t, m, vad.Config{SampleRate: 16000, FrameSizeMs: 20})
+
+	// 20 ms frames hold 320 samples: the first window is complete after the
+	// second frame, the second after the fourth.
+	var next int16
+	ramp := func(int) int16 { next++; return next }
+	wantTypes := []vad.VADEventType{vad.VADSilence, vad.VADSpeechStart, vad.VADSpeechContinue, vad.VADSpeechContinue}
+	for i, want := range wantTypes {
+		ev, err := s.ProcessFrame(pcm(320, ramp))
+		if err != nil {
+			t.Fatalf("frame %d: ProcessFrame() error = %v", i, err)
+		}
+		if ev.Type != want {
+			t.Errorf("frame %d: event = %v, want %v", i, ev.Type, want)
+		}
+	}
+
+	if len(m.inputs) != 2 {
+		t.Fatalf("model called %d times, want 2", len(m.inputs))
+	}
+	first, second := m.inputs[0], m.inputs[1]
+	if len(first) != 64+512 || len(second) != 64+512 {
+		t.Fatalf("input lengths = %d, %d, want %d", len(first), len(second), 64+512)
+	}
+	if slices.ContainsFunc(first[:64], func(v float32) bool { return v != 0 }) {
+		t.Error("first window's context is not silence")
+	}
+	if first[64] != 1.0/32768 {
+		t.Errorf("first sample = %v, want %v", first[64], 1.0/32768)
+	}
+	if !slices.Equal(second[:64], first[len(first)-64:]) {
+		t.Error("second window's context is not the end of the first window")
+	}
+	if m.states[1][0] != 1 {
+		t.Errorf("state passed to second window = %v, want the state after the first", m.states[1][0])
+	}
+}
+
+func TestSession_ProcessAudioFrame(t *testing.T) {
+	t.Parallel()
+
+	m := &fakeModel{probs: []float32{0.9}}
+	s := newTestSession(t, m, vad.Config{SampleRate: 16000, FrameSizeMs: 20})
+
+	// 32 ms of 48 kHz stereo are one 512-sample window at 16 kHz.
+	stereo := pcm(2*1536, func(int) int16 { return 1000 })
+	ev, err := s.ProcessAudioFrame(audio.AudioFrame{Data: stereo, SampleRate: 48000, Channels: 2})
+	if err != nil {
+		t.Fatalf("ProcessAudioFrame() error = %v", err)
+	}
+	if ev.Type != vad.VADSpeechStart {
+		t.Errorf("event = %v, want %v", ev.Type, vad.VADSpeechStart)
+	}
+	if len(m.inputs) != 1 {
+		t.Errorf("model called %d times, want 1", len(m.inputs))
+	}
+
+	if _, err := s.ProcessAudioFrame(audio.AudioFrame{Data: stereo, SampleRate: 48000, Channels: 6}); err == nil {
+		t.Error("ProcessAudioFrame() with 6 channels returned nil error")
+	}
+}
+
+func TestSession_Errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("wrong frame size", func(t *testing.T) {
+		t.Parallel()
+		s := newTestSession(t, &fakeModel{probs: []float32{0}}, vad.Config{SampleRate: 16000, FrameSizeMs: 20})
+		if _, err := s.ProcessFrame(make([]byte, 100)); err == nil {
+			t.Error("ProcessFrame() with short frame returned nil error")
+		}
+	})
+
+	t.Run("model error", func(t *testing.T) {
+		t.Parallel()
+		errModel := errors.New("boom")
+		s := newTestSession(t, &fakeModel{err: errModel}, vad.Config{SampleRate: 16000})
+		if _, err := s.ProcessFrame(make([]byte, 1024)); !errors.Is(err, errModel) {
+			t.Errorf("ProcessFrame() error = %v, want %v", err, errModel)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		t.Parallel()
+		s := newTestSession(t, &fakeModel{probs: []float32{0}}, vad.Config{SampleRate: 16000})
+		if err := s.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if err := s.Close(); err != nil {
+			t.Errorf("second Close() error = %v", err)
+		}
+		if _, err := s.ProcessFrame(make([]byte, 1024)); err == nil {
+			t.Error("ProcessFrame() after Close returned nil error")
+		}
+	})
+}
+
+func TestSession_Reset(t *testing.T) {
+	t.Parallel()
+
+	m := &fakeModel{probs: []float32{0.9}}
+	s := newTestSession(t, m, vad.Config{SampleRate: 16000})
+	frame := pcm(512, func(int) int16 { return 100 })
+
+	if ev, _ := s.ProcessFrame(frame); ev.Type != vad.VADSpeechStart {
+		t.Fatalf("event = %v, want %v", ev.Type, vad.VADSpeechStart)
+	}
+	s.Reset()
+	ev, err := s.ProcessFrame(frame)
+	if err != nil {
+		t.Fatalf("ProcessFrame() error = %v", err)
+	}
+	if ev.Type != vad.VADSpeechStart {
+		t.Errorf("event after Reset = %v, want %v", ev.Type, vad.VADSpeechStart)
+	}
+	if m.states[1][0] != 0 {
+		t.Error("Reset did not clear the model state")
+	}
+	if slices.ContainsFunc(m.inputs[1][:64], func(v float32) bool { return v != 0 }) {
+		t.Error("Reset did not clear the context")
+	}
+}
@@ -0,0 +1,146 @@
+// Package segment splits streamed text into sentences for speech synthesis.
+//
+// Voice pipelines hand each sentence of an LLM reply to TTS as soon as it is
+// complete, so a wrong sentence end cuts the speech in the middle of a phrase:
+// "Dr. Smith" would be spoken as "Dr." and, after a pause, "Smith". A
+// [Segmenter] only ends a sentence at '.', '!' or '?' followed by whitespace,
+// and not after a known abbreviation or before the sentence reaches a minimum
+// length:
+//
+//	seg := segment.New(segment.WithMinChars(8))
+//	i := seg.Boundary("Ask Dr. Smith. He knows.") // 13, after "Smith."
+//
+// [Simple] returns the naive segmenter that ends a sentence at every such
+// punctuation mark, for callers that depend on the historical behaviour.
+package segment
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultAbbreviations are the abbreviations [New] recognises by default,
+// lower-case and without their final period. Abbreviations that commonly end
+// a sentence, such as "etc.", are deliberately missing: holding back a real
+// sentence end costs latency, while a missed abbreviation only costs a pause.
+var defaultAbbreviations = []string{
+	"mr", "mrs", "ms", "mx", "dr", "prof", "sr", "jr", "st", "mt", "ft",
+	"capt", "col", "gen", "lt", "sgt", "cpl", "cmdr", "adm", "gov", "rev",
+	"fr", "hon", "pres", "vs", "e.g", "i.e", "cf", "approx", "ca", "fig",
+}
+
+// DefaultAbbreviations returns a copy of the abbreviations [New] recognises
+// by default, lower-case and without their final period. Extend it to add
+// campaign-specific abbreviations:
+//
+//	segment.New(segment.WithAbbreviations(append(segment.DefaultAbbreviations(), "lord")))
+func DefaultAbbreviations() []string {
+	return append([]string(nil), defaultAbbreviations...)
+}
+
+// Option is a functional option for [New].
+type Option func(*Segmenter)
+
+// WithAbbreviations replaces the abbreviations after which a period does not
+// end a sentence. Matching is case-insensitive and ignores a final period, so
+// "Dr." and "dr" are the same entry. Multi-part abbreviations such as "e.g."
+// match as a whole. An empty list disables abbreviation detection.
+func WithAbbreviations(abbrevs []string) Option {
+	return func(s *Segmenter) {
+		s.abbrevs = make(map[string]bool, len(abbrevs))
+		for _, a := range abbrevs {
+			a = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(a), "."))
+			if a != "" {
+				s.abbrevs[a] = true
+			}
+		}
+	}
+}
+
+// WithMinChars sets the number of characters (runes, ignoring surrounding
+// whitespace) a sentence needs before it may end. Shorter sentences are joined
+// with the next one, so an interjection like "Ah." is not synthesised on its
+// own. Values < 1 disable the guard, the default.
+func WithMinChars(n int) Option {
+	return func(s *Segmenter) {
+		s.minChars = max(n, 0)
+	}
+}
+
+// Segmenter finds sentence ends in text. Create one with [New] or [Simple].
+//
+// A Segmenter is immutable after construction and safe for concurrent use.
+type Segmenter struct {
+	abbrevs  map[string]bool // lower-case, without the final period
+	minChars int             // 0 disables the guard
+}
+
+// New creates a [Segmenter] that recognises [DefaultAbbreviations] and has
+// no minimum sentence length unless configured otherwise with opts.
+func New(opts ...Option) *Segmenter {
+	s := &Segmenter{}
+	WithAbbreviations(defaultAbbreviations)(s)
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Simple returns a [Segmenter] without abbreviations or a minimum sentence
+// length: every '.', '!' or '?' followed by whitespace ends a sentence.
+func Simple() *Segmenter {
+	return &Segmenter{}
+}
+
+// Boundary returns the index of the '.', '!' or '?' that ends the first
+// sentence of text, or -1 if text holds no complete sentence yet. The
+// punctuation mark must be followed by whitespace; one at the very end of
+// text is never a boundary, as streamed text may continue it ("3." + "14").
+// Consecutive marks such as "?!" end the sentence at the last one.
+func (s *Segmenter) Boundary(text string) int {
+	for i := 0; i < len(text)-1; i++ {
+		if !isTerminal(text[i]) {
+			continue
+		}
+		r, _ := utf8.DecodeRuneInString(text[i+1:])
+		if unicode.IsSpace(r) && s.EndsSentence(text[:i+1]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// EndsSentence reports whether sentence, which ends with '.', '!' or '?' and
+// starts where the previous sentence ended, may end there: it is long enough
+// and, for a period, the word before it is not an abbreviation. Callers that
+// scan text themselves, for example to skip markup, use it to check their
+// candidate boundaries; [Segmenter.Boundary] is built on it.
+func (s *Segmenter) EndsSentence(sentence string) bool {
+	if s.minChars > 0 && utf8.RuneCountInString(strings.TrimSpace(sentence)) < s.minChars {
+		return false
+	}
+	if len(s.abbrevs) == 0 || !strings.HasSuffix(sentence, ".") {
+		return true
+	}
+	return !s.abbrevs[strings.ToLower(lastWord(sentence[:len(sentence)-1]))]
+}
+
+// lastWord returns the trailing run of letters and inner periods of text, the
+// word an abbreviation's final period follows.
+func lastWord(text string) string {
+	start := len(text)
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:start])
+		if !unicode.IsLetter(r) && (r != '.' || start == len(text)) {
+			break
+		}
+		start -= size
+	}
+	return strings.TrimLeft(text[start:], ".")
+}
+
+// isTerminal reports whether c is a sentence-ending punctuation mark.
+func isTerminal(c byte) bool {
+	return c == '.' || c == '!' || c == '?'
+}
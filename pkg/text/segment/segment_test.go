@@ -0,0 +1,89 @@
+package segment_test
+
+import (
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/text/segment"
+)
+
+func TestBoundary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		seg  *segment.Segmenter
+		in   string
+		want int
+	}{
+		{"sentence", segment.New(), "Hello there. More", 11},
+		{"last of consecutive marks", segment.New(), "Really?! Yes", 7},
+		{"newline", segment.New(), "Wait!\nNo", 4},
+		{"end of text", segment.New(), "No boundary yet.", -1},
+		{"no whitespace", segment.New(), "Dr.Smith is here", -1},
+		{"empty", segment.New(), "", -1},
+		{"title", segment.New(), "Ask Dr. Smith. He knows.", 13},
+		{"title case-insensitive", segment.New(), "ask DR. smith. he knows.", 13},
+		{"multi-part abbreviation", segment.New(), "Bring tools, e.g. a rope. Go.", 24},
+		{"decimal and unit", segment.New(), "It is 3.14 km. Next", 13},
+		{"decimal streamed", segment.New(), "It is 3.", -1},
+		{"abbreviation only applies to periods", segment.New(), "Dr! Help me", 2},
+		{"custom abbreviations", segment.New(segment.WithAbbreviations([]string{"Lord."})), "Ask Lord. Dr. Smith. Go", 12},
+		{"no abbreviations", segment.New(segment.WithAbbreviations(nil)), "Ask Dr. Smith. Go", 6},
+		{"min chars joins short sentences", segment.New(segment.WithMinChars(8)), "Ah. Well, yes. Go", 13},
+		{"min chars ignores whitespace", segment.New(segment.WithMinChars(4)), "  Ah. Yes. Go", 9},
+		{"simple ends after titles", segment.Simple(), "Ask Dr. Smith. Go", 6},
+		{"simple keeps decimals", segment.Simple(), "3.14 is pi. Yes", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.seg.Boundary(tt.in); got != tt.want {
+				t.Errorf("Boundary(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndsSentence(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"Hello.", true},
+		{"Ask Mr.", false},
+		{"(see fig.", false},
+		{"I met the mister.", true},
+		{"Really?", true},
+		{"i.e.", false},
+		{"Sure.", true},
+	}
+	seg := segment.New()
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			if got := seg.EndsSentence(tt.in); got != tt.want {
+				t.Errorf("EndsSentence(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	if !segment.Simple().EndsSentence("Ask Mr.") {
+		t.Error("Simple should end a sentence after every period")
+	}
+}
+
+func TestDefaultAbbreviations(t *testing.T) {
+	t.Parallel()
+
+	abbrevs := segment.DefaultAbbreviations()
+	abbrevs[0] = "changed"
+	if segment.DefaultAbbreviations()[0] == "changed" {
+		t.Error("DefaultAbbreviations must return a copy")
+	}
+	seg := segment.New(segment.WithAbbreviations(append(segment.DefaultAbbreviations(), "lord")))
+	if seg.EndsSentence("Ask Lord.") || seg.EndsSentence("Ask Dr.") {
+		t.Error("extended list should keep the defaults and add the new entry")
+	}
+}
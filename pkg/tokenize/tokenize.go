@@ -0,0 +1,166 @@
+// Package tokenize counts LLM tokens for context-budget estimation.
+//
+// A [Counter] reports how many tokens a model's tokenizer produces for a piece
+// of text. [Tiktoken] is an exact, tiktoken-compatible counter for OpenAI model
+// families; its byte-pair-encoding tables are embedded in the binary, so no
+// network access is needed at runtime. [Approximate] is a character-based
+// heuristic for model families whose tokenizer is not available locally.
+//
+// Use [ForModel] to pick the appropriate counter for a configured model name:
+//
+//	counter := tokenize.ForModel("gpt-4o-mini") // exact o200k_base counts
+//	n := counter.Count("Welcome to the Rusty Tankard!")
+package tokenize
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// Tiktoken encoding names.
+const (
+	// EncodingO200K is used by GPT-4o, GPT-4.1, GPT-5, and the o-series
+	// reasoning models.
+	EncodingO200K = "o200k_base"
+
+	// EncodingCL100K is used by GPT-4, GPT-3.5 Turbo, and the text-embedding-3
+	// and ada-002 embedding models.
+	EncodingCL100K = "cl100k_base"
+)
+
+// Counter counts the tokens a tokenizer produces for a piece of text.
+//
+// Implementations must be safe for concurrent use.
+type Counter interface {
+	// Count returns the number of tokens in text. Count never fails; counters
+	// that cannot tokenise text exactly return a conservative estimate.
+	Count(text string) int
+}
+
+// Compile-time interface assertions.
+var (
+	_ Counter = Approximate{}
+	_ Counter = (*Tiktoken)(nil)
+)
+
+// charsPerToken is the heuristic ratio used by [Approximate]. English text
+// averages roughly 4 characters per token across common LLM tokenizers.
+const charsPerToken = 4
+
+// Approximate is a [Counter] that estimates one token per 4 bytes of text,
+// rounded up. It is a reasonable fallback for model families without a
+// locally available tokenizer but may undercount non-English text.
+//
+// The zero value is ready to use.
+type Approximate struct{}
+
+// Count implements [Counter].
+func (Approximate) Count(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// Tiktoken is an exact [Counter] compatible with OpenAI's tiktoken library.
+// Create one with [NewTiktoken]. It is safe for concurrent use.
+type Tiktoken struct {
+	encoding string
+	enc      *tiktoken.Tiktoken
+}
+
+var (
+	loaderOnce sync.Once
+
+	encMu     sync.Mutex
+	encodings = map[string]*Tiktoken{}
+)
+
+// NewTiktoken returns a counter for the named tiktoken encoding (e.g.,
+// [EncodingO200K]). Building an encoding takes a noticeable fraction of a
+// second, so counters are cached and shared per encoding name.
+func NewTiktoken(encoding string) (*Tiktoken, error) {
+	loaderOnce.Do(func() {
+		// Read the BPE tables embedded in the binary instead of downloading
+		// them from OpenAI on first use.
+		tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+	})
+
+	encMu.Lock()
+	defer encMu.Unlock()
+	if t, ok := encodings[encoding]; ok {
+		return t, nil
+	}
+	enc, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("tokenize: load encoding %q: %w", encoding, err)
+	}
+	t := &Tiktoken{encoding: encoding, enc: enc}
+	encodings[encoding] = t
+	return t, nil
+}
+
+// Encoding returns the tiktoken encoding name of the counter.
+func (t *Tiktoken) Encoding() string { return t.encoding }
+
+// Count implements [Counter]. Special tokens such as "<|endoftext|>" are
+// counted as ordinary text.
+func (t *Tiktoken) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	return len(t.enc.EncodeOrdinary(text))
+}
+
+// modelEncodings maps model name prefixes to tiktoken encodings. More
+// specific prefixes must come before shorter ones they start with.
+var modelEncodings = []struct {
+	prefix   string
+	encoding string
+}{
+	{"gpt-4o", EncodingO200K},
+	{"chatgpt-4o", EncodingO200K},
+	{"gpt-4.1", EncodingO200K},
+	{"gpt-4.5", EncodingO200K},
+	{"gpt-5", EncodingO200K},
+	{"o1", EncodingO200K},
+	{"o3", EncodingO200K},
+	{"o4", EncodingO200K},
+	{"gpt-4", EncodingCL100K},
+	{"gpt-3.5", EncodingCL100K},
+	{"text-embedding-3", EncodingCL100K},
+	{"text-embedding-ada-002", EncodingCL100K},
+}
+
+// EncodingForModel returns the tiktoken encoding used by model and true, or
+// "" and false if model is not a known OpenAI model. A leading "openai/"
+// provider prefix is ignored.
+func EncodingForModel(model string) (string, bool) {
+	model = strings.ToLower(strings.TrimPrefix(model, "openai/"))
+	for _, m := range modelEncodings {
+		rest, ok := strings.CutPrefix(model, m.prefix)
+		// The prefix must end at a name boundary so that, for example,
+		// "o1" does not claim an unrelated model called "o1x".
+		if ok && (rest == "" || rest[0] == '-' || rest[0] == '.') {
+			return m.encoding, true
+		}
+	}
+	return "", false
+}
+
+// ForModel returns the most accurate [Counter] available for model: an exact
+// [Tiktoken] counter for OpenAI models and [Approximate] for everything else.
+func ForModel(model string) Counter {
+	encoding, ok := EncodingForModel(model)
+	if !ok {
+		return Approximate{}
+	}
+	t, err := NewTiktoken(encoding)
+	if err != nil {
+		slog.Warn("tokenize: falling back to approximate token counts", "model", model, "err", err)
+		return Approximate{}
+	}
+	return t
+}
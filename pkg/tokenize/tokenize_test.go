@@ -0,0 +1,164 @@
+package tokenize_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MrWong99/glyphoxa/pkg/tokenize"
+)
+
+// Expected counts were produced with OpenAI's Python tiktoken library, e.g.
+// len(tiktoken.get_encoding("cl100k_base").encode("hello world")).
+var tiktokenCases = []struct {
+	text   string
+	cl100k int
+	o200k  int
+}{
+	{text: "", cl100k: 0, o200k: 0},
+	{text: "hello world", cl100k: 2, o200k: 2},
+	{text: "tiktoken is great!", cl100k: 6, o200k: 6},
+	{text: "antidisestablishmentarianism", cl100k: 6, o200k: 6},
+	{text: "2 + 2 = 4", cl100k: 7, o200k: 7},
+	{text: "お誕生日おめでとう", cl100k: 9, o200k: 8},
+	{text: `Grimjaw slams his tankard on the bar. "No credit, adventurer!"`, cl100k: 18, o200k: 19},
+}
+
+func TestTiktoken_KnownCounts(t *testing.T) {
+	t.Parallel()
+
+	cl100k, err := tokenize.NewTiktoken(tokenize.EncodingCL100K)
+	if err != nil {
+		t.Fatalf("NewTiktoken(cl100k): %v", err)
+	}
+	o200k, err := tokenize.NewTiktoken(tokenize.EncodingO200K)
+	if err != nil {
+		t.Fatalf("NewTiktoken(o200k): %v", err)
+	}
+
+	for _, tc := range tiktokenCases {
+		if got := cl100k.Count(tc.text); got != tc.cl100k {
+			t.Errorf("cl100k_base Count(%q) = %d, want %d", tc.text, got, tc.cl100k)
+		}
+		if got := o200k.Count(tc.text); got != tc.o200k {
+			t.Errorf("o200k_base Count(%q) = %d, want %d", tc.text, got, tc.o200k)
+		}
+	}
+}
+
+func TestTiktoken_SpecialTokensCountedAsText(t *testing.T) {
+	t.Parallel()
+
+	tk, err := tokenize.NewTiktoken(tokenize.EncodingCL100K)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// As a special token "<|endoftext|>" would be a single token; as player
+	// text it must not panic and is split into ordinary tokens.
+	if got := tk.Count("<|endoftext|>"); got <= 1 {
+		t.Errorf("Count(<|endoftext|>) = %d, want ordinary-text tokenisation", got)
+	}
+}
+
+func TestNewTiktoken(t *testing.T) {
+	t.Parallel()
+
+	a, err := tokenize.NewTiktoken(tokenize.EncodingO200K)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := tokenize.NewTiktoken(tokenize.EncodingO200K)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Error("NewTiktoken should return the cached counter for the same encoding")
+	}
+	if a.Encoding() != tokenize.EncodingO200K {
+		t.Errorf("Encoding() = %q, want %q", a.Encoding(), tokenize.EncodingO200K)
+	}
+
+	if _, err := tokenize.NewTiktoken("klingon_base"); err == nil {
+		t.Error("expected error for unknown encoding")
+	}
+}
+
+func TestTiktoken_ConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	tk, err := tokenize.NewTiktoken(tokenize.EncodingCL100K)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for _, tc := range tiktokenCases {
+				if got := tk.Count(tc.text); got != tc.cl100k {
+					t.Errorf("Count(%q) = %d, want %d", tc.text, got, tc.cl100k)
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestApproximate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "Hi", want: 1},
+		{text: "four", want: 1},
+		{text: "fives", want: 2},
+		{text: strings.Repeat("a", 400), want: 100},
+	}
+	for _, tc := range tests {
+		if got := (tokenize.Approximate{}).Count(tc.text); got != tc.want {
+			t.Errorf("Approximate.Count(%q) = %d, want %d", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{model: "gpt-4o", want: tokenize.EncodingO200K},
+		{model: "gpt-4o-mini", want: tokenize.EncodingO200K},
+		{model: "openai/gpt-4.1-nano", want: tokenize.EncodingO200K},
+		{model: "gpt-5", want: tokenize.EncodingO200K},
+		{model: "o3-mini", want: tokenize.EncodingO200K},
+		{model: "o1", want: tokenize.EncodingO200K},
+		{model: "GPT-4-Turbo", want: tokenize.EncodingCL100K},
+		{model: "gpt-3.5-turbo", want: tokenize.EncodingCL100K},
+		{model: "text-embedding-3-small", want: tokenize.EncodingCL100K},
+		{model: "claude-sonnet-4", want: ""},
+		{model: "llama3.2", want: ""},
+		{model: "o1x", want: ""},
+		{model: "", want: ""},
+	}
+	for _, tc := range tests {
+		got, ok := tokenize.EncodingForModel(tc.model)
+		if got != tc.want || ok != (tc.want != "") {
+			t.Errorf("EncodingForModel(%q) = %q, %v; want %q", tc.model, got, ok, tc.want)
+		}
+	}
+}
+
+func TestForModel(t *testing.T) {
+	t.Parallel()
+
+	if tk, ok := tokenize.ForModel("gpt-4o-mini").(*tokenize.Tiktoken); !ok || tk.Encoding() != tokenize.EncodingO200K {
+		t.Errorf("ForModel(gpt-4o-mini) = %T, want o200k_base Tiktoken", tokenize.ForModel("gpt-4o-mini"))
+	}
+	if _, ok := tokenize.ForModel("gemini-2.0-flash").(tokenize.Approximate); !ok {
+		t.Errorf("ForModel(gemini-2.0-flash) = %T, want Approximate", tokenize.ForModel("gemini-2.0-flash"))
+	}
+}